@@ -0,0 +1,181 @@
+// Package artifactcache keeps the extracted artifacts of deployments, so that their failed foundations can be
+// retried without fetching the artifact again.
+package artifactcache
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// DirectoryName is the name of the directory of the work directory the artifacts are cached in, when the cache is
+// not configured with one. It does not have the prefix of temporary directories, so it is not swept at startup.
+const DirectoryName = "artifact-cache"
+
+// NewCache returns a Cache configured by the descriptor, whose restored artifacts are copied to the work directory,
+// or to the temporary directory of the operating system when it is empty.
+func NewCache(fileSystem *afero.Afero, workDir string, descriptor S.ArtifactCacheDescriptor) *Cache {
+	dir := descriptor.Directory
+	if dir == "" {
+		base := workDir
+		if base == "" {
+			base = os.TempDir()
+		}
+		dir = filepath.Join(base, DirectoryName)
+	}
+
+	return &Cache{
+		FileSystem: fileSystem,
+		Dir:        dir,
+		WorkDir:    workDir,
+		MaxAge:     time.Duration(descriptor.MaxAgeMinutes) * time.Minute,
+		Now:        time.Now,
+	}
+}
+
+// Cache keeps a copy of the extracted artifact of every deployment in a directory of the Dir named after the
+// deployment, for MaxAge. Expired artifacts are removed whenever an artifact is stored.
+type Cache struct {
+	FileSystem *afero.Afero
+	Dir        string
+	WorkDir    string
+	MaxAge     time.Duration
+	Now        func() time.Time
+}
+
+// Store copies the extracted artifact of the deployment into the cache.
+func (c *Cache) Store(uuid, appPath string) error {
+	c.prune()
+
+	path, ok := c.path(uuid)
+	if !ok {
+		return InvalidUUIDError{UUID: uuid}
+	}
+
+	err := c.FileSystem.RemoveAll(path)
+	if err != nil {
+		return StoreError{UUID: uuid, Err: err}
+	}
+
+	err = c.copyDir(appPath, path)
+	if err != nil {
+		c.FileSystem.RemoveAll(path)
+		return StoreError{UUID: uuid, Err: err}
+	}
+
+	now := c.Now()
+	err = c.FileSystem.Chtimes(path, now, now)
+	if err != nil {
+		return StoreError{UUID: uuid, Err: err}
+	}
+
+	return nil
+}
+
+// Restore copies the cached artifact of the deployment to a new temporary directory, which the caller removes.
+// It returns an ArtifactNotCachedError when the artifact of the deployment is not cached or has expired.
+func (c *Cache) Restore(uuid string) (string, error) {
+	path, ok := c.path(uuid)
+	if !ok {
+		return "", InvalidUUIDError{UUID: uuid}
+	}
+
+	info, err := c.FileSystem.Stat(path)
+	if err != nil || !info.IsDir() || c.expired(info) {
+		return "", ArtifactNotCachedError{UUID: uuid}
+	}
+
+	appPath, err := c.FileSystem.TempDir(c.WorkDir, "deployadactyl-unzipped-")
+	if err != nil {
+		return "", RestoreError{UUID: uuid, Err: err}
+	}
+
+	err = c.copyDir(path, appPath)
+	if err != nil {
+		c.FileSystem.RemoveAll(appPath)
+		return "", RestoreError{UUID: uuid, Err: err}
+	}
+
+	return appPath, nil
+}
+
+// Remove removes the cached artifact of the deployment, if there is one.
+func (c *Cache) Remove(uuid string) error {
+	path, ok := c.path(uuid)
+	if !ok {
+		return InvalidUUIDError{UUID: uuid}
+	}
+
+	return c.FileSystem.RemoveAll(path)
+}
+
+// path returns the directory of the artifact of the deployment, unless its uuid is not a valid directory name.
+func (c *Cache) path(uuid string) (string, bool) {
+	if uuid == "" || uuid == "." || uuid == ".." || filepath.Base(uuid) != uuid {
+		return "", false
+	}
+	return filepath.Join(c.Dir, uuid), true
+}
+
+func (c *Cache) expired(info os.FileInfo) bool {
+	return info.ModTime().Before(c.Now().Add(-c.MaxAge))
+}
+
+// prune removes the expired artifacts.
+func (c *Cache) prune() {
+	infos, err := c.FileSystem.ReadDir(c.Dir)
+	if err != nil {
+		return
+	}
+
+	for _, info := range infos {
+		if c.expired(info) {
+			c.FileSystem.RemoveAll(filepath.Join(c.Dir, info.Name()))
+		}
+	}
+}
+
+// copyDir copies the files of the src directory to the dst directory.
+func (c *Cache) copyDir(src, dst string) error {
+	return c.FileSystem.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return c.FileSystem.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+
+		return c.copyFile(path, target, info.Mode().Perm())
+	})
+}
+
+func (c *Cache) copyFile(src, dst string, perm os.FileMode) error {
+	in, err := c.FileSystem.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := c.FileSystem.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,89 @@
+package artifactcache_test
+
+import (
+	"time"
+
+	. "github.com/compozed/deployadactyl/artifactcache"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		fileSystem *afero.Afero
+		cache      *Cache
+		now        time.Time
+	)
+
+	BeforeEach(func() {
+		fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+		now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+		cache = NewCache(fileSystem, "/work", S.ArtifactCacheDescriptor{MaxAgeMinutes: 60})
+		cache.Now = func() time.Time { return now }
+
+		Expect(fileSystem.MkdirAll("/work/deployadactyl-unzipped-1/lib", 0755)).To(Succeed())
+		Expect(fileSystem.WriteFile("/work/deployadactyl-unzipped-1/manifest.yml", []byte("---"), 0644)).To(Succeed())
+		Expect(fileSystem.WriteFile("/work/deployadactyl-unzipped-1/lib/app.jar", []byte("jar"), 0644)).To(Succeed())
+	})
+
+	It("caches the artifacts in the work directory", func() {
+		Expect(cache.Dir).To(Equal("/work/artifact-cache"))
+		Expect(NewCache(fileSystem, "", S.ArtifactCacheDescriptor{Directory: "/var/cache"}).Dir).To(Equal("/var/cache"))
+	})
+
+	It("restores a copy of the stored artifact", func() {
+		Expect(cache.Store("abc", "/work/deployadactyl-unzipped-1")).To(Succeed())
+		Expect(fileSystem.RemoveAll("/work/deployadactyl-unzipped-1")).To(Succeed())
+
+		appPath, err := cache.Restore("abc")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(appPath).To(HavePrefix("/work/deployadactyl-unzipped-"))
+		Expect(fileSystem.ReadFile(appPath + "/manifest.yml")).To(Equal([]byte("---")))
+		Expect(fileSystem.ReadFile(appPath + "/lib/app.jar")).To(Equal([]byte("jar")))
+		Expect(fileSystem.Exists("/work/artifact-cache/abc/lib/app.jar")).To(BeTrue())
+	})
+
+	It("returns an ArtifactNotCachedError for a deployment whose artifact is not cached", func() {
+		_, err := cache.Restore("abc")
+
+		Expect(err).To(MatchError(ArtifactNotCachedError{UUID: "abc"}))
+	})
+
+	It("does not restore an artifact that expired", func() {
+		Expect(cache.Store("abc", "/work/deployadactyl-unzipped-1")).To(Succeed())
+		now = now.Add(61 * time.Minute)
+
+		_, err := cache.Restore("abc")
+
+		Expect(err).To(MatchError(ArtifactNotCachedError{UUID: "abc"}))
+	})
+
+	It("removes the expired artifacts when an artifact is stored", func() {
+		Expect(cache.Store("abc", "/work/deployadactyl-unzipped-1")).To(Succeed())
+		now = now.Add(61 * time.Minute)
+
+		Expect(cache.Store("def", "/work/deployadactyl-unzipped-1")).To(Succeed())
+
+		Expect(fileSystem.Exists("/work/artifact-cache/abc")).To(BeFalse())
+		Expect(fileSystem.Exists("/work/artifact-cache/def")).To(BeTrue())
+	})
+
+	It("removes the artifact of a deployment", func() {
+		Expect(cache.Store("abc", "/work/deployadactyl-unzipped-1")).To(Succeed())
+
+		Expect(cache.Remove("abc")).To(Succeed())
+
+		Expect(fileSystem.Exists("/work/artifact-cache/abc")).To(BeFalse())
+	})
+
+	It("refuses a uuid that is not a directory name", func() {
+		Expect(cache.Store("../abc", "/work/deployadactyl-unzipped-1")).To(MatchError(InvalidUUIDError{UUID: "../abc"}))
+
+		_, err := cache.Restore("..")
+		Expect(err).To(MatchError(InvalidUUIDError{UUID: ".."}))
+	})
+})
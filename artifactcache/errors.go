@@ -0,0 +1,37 @@
+package artifactcache
+
+import "fmt"
+
+type InvalidUUIDError struct {
+	UUID string
+}
+
+func (e InvalidUUIDError) Error() string {
+	return fmt.Sprintf("the artifact cache has no place for deployment %q: it is not a valid directory name", e.UUID)
+}
+
+type ArtifactNotCachedError struct {
+	UUID string
+}
+
+func (e ArtifactNotCachedError) Error() string {
+	return fmt.Sprintf("the artifact of deployment %s is not cached", e.UUID)
+}
+
+type StoreError struct {
+	UUID string
+	Err  error
+}
+
+func (e StoreError) Error() string {
+	return fmt.Sprintf("cannot cache the artifact of deployment %s: %s", e.UUID, e.Err)
+}
+
+type RestoreError struct {
+	UUID string
+	Err  error
+}
+
+func (e RestoreError) Error() string {
+	return fmt.Sprintf("cannot restore the cached artifact of deployment %s: %s", e.UUID, e.Err)
+}
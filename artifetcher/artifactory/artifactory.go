@@ -0,0 +1,157 @@
+// Package artifactory resolves the artifacts of a JFrog Artifactory server with its REST API, so deployments can
+// fetch the latest file of a folder and have the download checked against the SHA-256 Artifactory computed.
+package artifactory
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// APIKeyHeader is the header Artifactory reads the API key of a request from.
+const APIKeyHeader = "X-JFrog-Art-Api"
+
+// Latest is the last segment of the artifact URL of a folder whose most recently modified file is fetched.
+const Latest = "latest"
+
+// storageAPI is the path of the API describing the files and folders of the repositories.
+const storageAPI = "/api/storage/"
+
+// maxResponseSize is the most that is read of a response of the API.
+const maxResponseSize = 1 << 20
+
+// maxRedirects is the most redirects a request follows, like the clients of net/http.
+const maxRedirects = 10
+
+// Artifact is a file of an Artifactory repository.
+type Artifact struct {
+	DownloadURL string
+	SHA256      string
+}
+
+// Validate returns an error when the URL of the descriptor is not an http or https URL.
+func Validate(descriptor S.ArtifactoryDescriptor) error {
+	if descriptor.URL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(descriptor.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return InvalidURLError{descriptor.URL}
+	}
+	return nil
+}
+
+// Serves reports whether the URL is that of a file of the Artifactory server of the descriptor.
+func Serves(descriptor S.ArtifactoryDescriptor, rawURL string) bool {
+	return descriptor.URL != "" && strings.HasPrefix(rawURL, base(descriptor)+"/")
+}
+
+// Authorize adds the API key of the descriptor to the request when it is sent to its Artifactory server.
+func Authorize(request *http.Request, descriptor S.ArtifactoryDescriptor) {
+	if descriptor.APIKey != "" && Serves(descriptor, request.URL.String()) {
+		request.Header.Set(APIKeyHeader, descriptor.APIKey)
+	}
+}
+
+// CheckRedirect follows redirects like the clients of net/http, but drops the API key of the descriptor from the
+// redirected request once it leaves the Artifactory server, such as for the storage a download is served from.
+func CheckRedirect(descriptor S.ArtifactoryDescriptor) func(request *http.Request, via []*http.Request) error {
+	return func(request *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return TooManyRedirectsError{maxRedirects}
+		}
+		if !Serves(descriptor, request.URL.String()) {
+			request.Header.Del(APIKeyHeader)
+		}
+		return nil
+	}
+}
+
+// Resolve returns the file of the artifact URL with its SHA-256, finding the most recently modified file of the
+// folder when the URL ends in /latest.
+func Resolve(ctx context.Context, client *http.Client, descriptor S.ArtifactoryDescriptor, rawURL string) (Artifact, error) {
+	path := strings.TrimPrefix(rawURL, base(descriptor)+"/")
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+
+	if strings.HasSuffix(path, "/"+Latest) {
+		folder := strings.TrimSuffix(path, "/"+Latest)
+
+		var item struct {
+			URI string `json:"uri"`
+		}
+		err := get(ctx, client, descriptor, base(descriptor)+storageAPI+folder+"?lastModified", &item)
+		if err != nil {
+			return Artifact{}, err
+		}
+
+		i := strings.Index(item.URI, storageAPI)
+		if i < 0 {
+			return Artifact{}, NoLatestFileError{folder}
+		}
+		path = item.URI[i+len(storageAPI):]
+	}
+
+	var info struct {
+		Repo      string        `json:"repo"`
+		Path      string        `json:"path"`
+		Children  []interface{} `json:"children"`
+		Checksums struct {
+			SHA256 string `json:"sha256"`
+		} `json:"checksums"`
+	}
+	err := get(ctx, client, descriptor, base(descriptor)+storageAPI+path, &info)
+	if err != nil {
+		return Artifact{}, err
+	}
+	if info.Children != nil || info.Repo == "" {
+		return Artifact{}, NotAFileError{path}
+	}
+	if info.Checksums.SHA256 == "" {
+		return Artifact{}, MissingChecksumError{path}
+	}
+
+	// The file is downloaded from the configured server rather than the downloadUri of the response, which holds
+	// the base URL Artifactory is configured with, so the API key is never sent anywhere else.
+	return Artifact{
+		DownloadURL: base(descriptor) + (&url.URL{Path: "/" + info.Repo + "/" + strings.TrimPrefix(info.Path, "/")}).EscapedPath(),
+		SHA256:      strings.ToLower(info.Checksums.SHA256),
+	}, nil
+}
+
+// get decodes the JSON response of the API at the URL into v.
+func get(ctx context.Context, client *http.Client, descriptor S.ArtifactoryDescriptor, rawURL string, v interface{}) error {
+	request, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return APIError{URL: rawURL, Err: err}
+	}
+	request = request.WithContext(ctx)
+	Authorize(request, descriptor)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return APIError{URL: rawURL, Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return APIError{URL: rawURL, Err: StatusError{response.Status}}
+	}
+
+	err = json.NewDecoder(io.LimitReader(response.Body, maxResponseSize)).Decode(v)
+	if err != nil {
+		return APIError{URL: rawURL, Err: err}
+	}
+	return nil
+}
+
+func base(descriptor S.ArtifactoryDescriptor) string {
+	return strings.TrimSuffix(descriptor.URL, "/")
+}
@@ -0,0 +1,13 @@
+package artifactory_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestArtifactory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Artifactory Suite")
+}
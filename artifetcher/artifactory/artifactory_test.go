@@ -0,0 +1,195 @@
+package artifactory_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/compozed/deployadactyl/artifetcher/artifactory"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const sha256Sum = "2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824"
+
+var _ = Describe("Artifactory", func() {
+	var (
+		server     *httptest.Server
+		descriptor S.ArtifactoryDescriptor
+		responses  map[string]interface{}
+		apiKeys    []string
+	)
+
+	BeforeEach(func() {
+		responses = map[string]interface{}{
+			"/artifactory/api/storage/libs-release/com/example/app?lastModified": map[string]string{
+				"uri":          "https://artifactory.internal/artifactory/api/storage/libs-release/com/example/app/2.0/app-2.0.jar",
+				"lastModified": "2026-10-01T12:00:00.000Z",
+			},
+			"/artifactory/api/storage/libs-release/com/example/app/2.0/app-2.0.jar": map[string]interface{}{
+				"repo":        "libs-release",
+				"path":        "/com/example/app/2.0/app-2.0.jar",
+				"downloadUri": "https://artifactory.internal/artifactory/libs-release/com/example/app/2.0/app-2.0.jar",
+				"checksums":   map[string]string{"sha1": "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", "sha256": sha256Sum},
+			},
+			"/artifactory/api/storage/libs-release/com/example/app/2.0": map[string]interface{}{
+				"repo":     "libs-release",
+				"path":     "/com/example/app/2.0",
+				"children": []map[string]interface{}{{"uri": "/app-2.0.jar", "folder": false}},
+			},
+			"/artifactory/api/storage/libs-release/com/example/app/1.0/app-1.0.jar": map[string]interface{}{
+				"repo":      "libs-release",
+				"path":      "/com/example/app/1.0/app-1.0.jar",
+				"checksums": map[string]string{"sha1": "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+			},
+		}
+		apiKeys = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKeys = append(apiKeys, r.Header.Get(APIKeyHeader))
+
+			response, ok := responses[r.URL.RequestURI()]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(response)
+		}))
+		descriptor = S.ArtifactoryDescriptor{URL: server.URL + "/artifactory/", APIKey: "the-api-key"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("resolving an artifact", func() {
+		It("returns the file with the sha256 artifactory reports", func() {
+			artifact, err := Resolve(context.Background(), http.DefaultClient, descriptor, server.URL+"/artifactory/libs-release/com/example/app/2.0/app-2.0.jar")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(artifact).To(Equal(Artifact{
+				DownloadURL: server.URL + "/artifactory/libs-release/com/example/app/2.0/app-2.0.jar",
+				SHA256:      strings.ToLower(sha256Sum),
+			}))
+			Expect(apiKeys).To(Equal([]string{"the-api-key"}))
+		})
+
+		It("returns the most recently modified file of the folder of a latest url", func() {
+			artifact, err := Resolve(context.Background(), http.DefaultClient, descriptor, server.URL+"/artifactory/libs-release/com/example/app/latest")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(artifact.DownloadURL).To(Equal(server.URL + "/artifactory/libs-release/com/example/app/2.0/app-2.0.jar"))
+			Expect(artifact.SHA256).To(Equal(strings.ToLower(sha256Sum)))
+			Expect(apiKeys).To(Equal([]string{"the-api-key", "the-api-key"}))
+		})
+
+		It("returns an error when the artifact is a folder", func() {
+			_, err := Resolve(context.Background(), http.DefaultClient, descriptor, server.URL+"/artifactory/libs-release/com/example/app/2.0")
+
+			Expect(err).To(MatchError(NotAFileError{"libs-release/com/example/app/2.0"}))
+		})
+
+		It("returns an error when artifactory reports no sha256", func() {
+			_, err := Resolve(context.Background(), http.DefaultClient, descriptor, server.URL+"/artifactory/libs-release/com/example/app/1.0/app-1.0.jar")
+
+			Expect(err).To(MatchError(MissingChecksumError{"libs-release/com/example/app/1.0/app-1.0.jar"}))
+		})
+
+		It("returns an error when artifactory does not have the artifact", func() {
+			_, err := Resolve(context.Background(), http.DefaultClient, descriptor, server.URL+"/artifactory/libs-release/com/example/app/3.0/app-3.0.jar")
+
+			Expect(err).To(MatchError(APIError{
+				URL: server.URL + "/artifactory/api/storage/libs-release/com/example/app/3.0/app-3.0.jar",
+				Err: StatusError{"404 Not Found"},
+			}))
+		})
+	})
+
+	Describe("authorizing a request", func() {
+		It("adds the api key to the requests of the server", func() {
+			request, _ := http.NewRequest("GET", server.URL+"/artifactory/libs-release/app.jar", nil)
+
+			Authorize(request, descriptor)
+
+			Expect(request.Header.Get(APIKeyHeader)).To(Equal("the-api-key"))
+		})
+
+		It("does not add the api key to the requests of other servers", func() {
+			request, _ := http.NewRequest("GET", "https://example.com/artifactory/libs-release/app.jar", nil)
+
+			Authorize(request, descriptor)
+
+			Expect(request.Header.Get(APIKeyHeader)).To(BeEmpty())
+		})
+	})
+
+	Describe("following redirects", func() {
+		var (
+			other  *httptest.Server
+			client *http.Client
+		)
+
+		BeforeEach(func() {
+			other = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				apiKeys = append(apiKeys, r.Header.Get(APIKeyHeader))
+			}))
+			responses = nil
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				apiKeys = append(apiKeys, r.Header.Get(APIKeyHeader))
+				switch r.URL.Path {
+				case "/artifactory/libs-release/moved.jar":
+					http.Redirect(w, r, server.URL+"/artifactory/libs-release/app.jar", http.StatusFound)
+				case "/artifactory/libs-release/app.jar":
+					http.Redirect(w, r, other.URL+"/storage/app.jar", http.StatusFound)
+				case "/loop":
+					http.Redirect(w, r, "/loop", http.StatusFound)
+				}
+			})
+			client = &http.Client{CheckRedirect: CheckRedirect(descriptor)}
+		})
+
+		AfterEach(func() {
+			other.Close()
+		})
+
+		get := func(rawURL string) error {
+			request, _ := http.NewRequest("GET", rawURL, nil)
+			Authorize(request, descriptor)
+			response, err := client.Do(request)
+			if err == nil {
+				response.Body.Close()
+			}
+			return err
+		}
+
+		It("keeps the api key on the server and drops it once redirected to another server", func() {
+			Expect(get(server.URL + "/artifactory/libs-release/moved.jar")).To(Succeed())
+
+			Expect(apiKeys).To(Equal([]string{"the-api-key", "the-api-key", ""}))
+		})
+
+		It("stops after 10 redirects", func() {
+			descriptor.URL = server.URL
+			client = &http.Client{CheckRedirect: CheckRedirect(descriptor)}
+
+			err := get(server.URL + "/loop")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(TooManyRedirectsError{10}.Error()))
+			Expect(apiKeys).To(HaveLen(10))
+		})
+	})
+
+	Describe("validating the descriptor", func() {
+		It("accepts an https url", func() {
+			Expect(Validate(S.ArtifactoryDescriptor{URL: "https://artifactory.example.com/artifactory"})).To(Succeed())
+		})
+
+		It("rejects a url that is not http or https", func() {
+			Expect(Validate(S.ArtifactoryDescriptor{URL: "artifactory.example.com"})).To(MatchError(InvalidURLError{"artifactory.example.com"}))
+		})
+	})
+})
@@ -0,0 +1,60 @@
+package artifactory
+
+import "fmt"
+
+type InvalidURLError struct {
+	URL string
+}
+
+func (e InvalidURLError) Error() string {
+	return fmt.Sprintf("the url of artifactory must be an http or https url: %s", e.URL)
+}
+
+type APIError struct {
+	URL string
+	Err error
+}
+
+func (e APIError) Error() string {
+	return fmt.Sprintf("cannot get %s from artifactory: %s", e.URL, e.Err)
+}
+
+type StatusError struct {
+	Status string
+}
+
+func (e StatusError) Error() string {
+	return e.Status
+}
+
+type NoLatestFileError struct {
+	Folder string
+}
+
+func (e NoLatestFileError) Error() string {
+	return fmt.Sprintf("artifactory has no latest file in %s", e.Folder)
+}
+
+type NotAFileError struct {
+	Path string
+}
+
+func (e NotAFileError) Error() string {
+	return fmt.Sprintf("%s is not a file of artifactory", e.Path)
+}
+
+type MissingChecksumError struct {
+	Path string
+}
+
+func (e MissingChecksumError) Error() string {
+	return fmt.Sprintf("artifactory reports no sha256 for %s", e.Path)
+}
+
+type TooManyRedirectsError struct {
+	Redirects int
+}
+
+func (e TooManyRedirectsError) Error() string {
+	return fmt.Sprintf("stopped after %d redirects", e.Redirects)
+}
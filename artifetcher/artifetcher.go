@@ -2,64 +2,138 @@
 package artifetcher
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/compozed/deployadactyl/artifetcher/artifactory"
+	"github.com/compozed/deployadactyl/artifetcher/bucket"
+	"github.com/compozed/deployadactyl/artifetcher/maven"
+	"github.com/compozed/deployadactyl/cabundle"
 	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
 	"github.com/spf13/afero"
 )
 
-type ArtifetcherConstructor func(fs *afero.Afero, ex I.Extractor, log I.DeploymentLogger) I.Fetcher
+// SpaceFactor is how many times the size of an artifact has to be free in the work directory before it is fetched.
+// It leaves room for the artifact itself and its extracted contents, which are usually larger than the artifact.
+const SpaceFactor = 3
 
-func NewArtifetcher(fs *afero.Afero, ex I.Extractor, log I.DeploymentLogger) I.Fetcher {
+type ArtifetcherConstructor func(fs *afero.Afero, ex I.Extractor, log I.DeploymentLogger, verifier I.ArtifactVerifier, caBundle string, buckets S.BucketsDescriptor, artifactory S.ArtifactoryDescriptor, maven S.MavenDescriptor, workDir string) I.Fetcher
+
+func NewArtifetcher(fs *afero.Afero, ex I.Extractor, log I.DeploymentLogger, verifier I.ArtifactVerifier, caBundle string, buckets S.BucketsDescriptor, artifactory S.ArtifactoryDescriptor, maven S.MavenDescriptor, workDir string) I.Fetcher {
 	return &Artifetcher{
-		FileSystem: fs,
-		Extractor:  ex,
-		Log:        log,
+		FileSystem:  fs,
+		Extractor:   ex,
+		Log:         log,
+		Progress:    LogProgress(log),
+		Verifier:    verifier,
+		CABundle:    caBundle,
+		Buckets:     buckets,
+		Artifactory: artifactory,
+		Maven:       maven,
+		WorkDir:     workDir,
+		FreeSpace:   freeSpace,
 	}
 }
 
 // Artifetcher fetches artifacts within a file system with an Extractor.
+// Artifacts are streamed to a temporary file, so their size does not affect memory usage.
+// If there is a Verifier, the artifact is verified before it is extracted.
+// If there is a CABundle, artifacts are only downloaded from servers it trusts.
+// Artifacts of s3:// and gs:// URLs are downloaded from their buckets with the credentials of the Buckets.
+// Artifacts of the Artifactory server are resolved with its API, and rejected when their SHA-256 is not the one it reports.
+// The artifact URLs of Maven coordinates are resolved against the repositories of Maven.
+// Artifacts are downloaded and extracted in the WorkDir, or the temporary directory of the operating system.
+// If there is a FreeSpace function, an artifact is only fetched when the WorkDir has room for it.
 type Artifetcher struct {
-	FileSystem *afero.Afero
-	Extractor  I.Extractor
-	Log        I.DeploymentLogger
+	FileSystem  *afero.Afero
+	Extractor   I.Extractor
+	Log         I.DeploymentLogger
+	Progress    ProgressFunc
+	Verifier    I.ArtifactVerifier
+	CABundle    string
+	Buckets     S.BucketsDescriptor
+	Artifactory S.ArtifactoryDescriptor
+	Maven       S.MavenDescriptor
+	WorkDir     string
+	FreeSpace   func(dir string) (uint64, error)
 }
 
 // Fetch downloads an artifact located at URL.
 // It then passes it to the extractor with the manifest for unzipping.
 //
+// The download is aborted when ctx is done.
+//
 // Returns a string to the unzipped artifacts path and an error.
-func (a *Artifetcher) Fetch(url, manifest string) (string, error) {
+func (a *Artifetcher) Fetch(ctx context.Context, url, manifest string) (string, error) {
 	a.Log.Info("fetching artifact")
 	a.Log.Debugf("artifact URL: %s", url)
 
-	artifactFile, err := a.FileSystem.TempFile("", "deployadactyl-zip-")
+	artifactFile, err := a.FileSystem.TempFile(a.WorkDir, "deployadactyl-zip-")
 	if err != nil {
 		return "", CreateTempFileError{err}
 	}
 	defer artifactFile.Close()
 	defer a.FileSystem.Remove(artifactFile.Name())
 
+	tlsConfig, err := cabundle.TLSConfig(a.FileSystem, a.CABundle)
+	if err != nil {
+		return "", err
+	}
+
+	transport := &http.Transport{
+		Dial: (&net.Dialer{
+			Timeout:   60 * time.Second,
+			KeepAlive: 60 * time.Second,
+		}).Dial,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   15 * time.Second,
+		ResponseHeaderTimeout: 15 * time.Second,
+		ExpectContinueTimeout: 2 * time.Second,
+	}
+	err = bucket.Register(transport, a.FileSystem, a.Buckets)
+	if err != nil {
+		return "", err
+	}
+
 	var client = &http.Client{
-		Timeout: 15 * time.Minute,
-		Transport: &http.Transport{
-			Dial: (&net.Dialer{
-				Timeout:   60 * time.Second,
-				KeepAlive: 60 * time.Second,
-			}).Dial,
-			TLSHandshakeTimeout:   15 * time.Second,
-			ResponseHeaderTimeout: 15 * time.Second,
-			ExpectContinueTimeout: 2 * time.Second,
-		},
+		Timeout:       15 * time.Minute,
+		Transport:     transport,
+		CheckRedirect: artifactory.CheckRedirect(a.Artifactory),
+	}
+
+	if coordinates, ok := S.ParseMavenArtifactURL(url); ok {
+		url, err = maven.Resolve(ctx, client, a.Maven, coordinates)
+		if err != nil {
+			return "", err
+		}
+		a.Log.Infof("resolved %s to %s", coordinates.ArtifactURL(), url)
+	}
+
+	var checksum string
+	if artifactory.Serves(a.Artifactory, url) {
+		artifact, err := artifactory.Resolve(ctx, client, a.Artifactory, url)
+		if err != nil {
+			return "", err
+		}
+		a.Log.Debugf("artifactory artifact: %s", artifact.DownloadURL)
+		url, checksum = artifact.DownloadURL, artifact.SHA256
 	}
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return "", FetcherRequestError{err}
 	}
+	req = req.WithContext(ctx)
+	artifactory.Authorize(req, a.Artifactory)
+	maven.Authorize(req, a.Maven)
 
 	response, err := client.Do(req)
 	if err != nil {
@@ -71,12 +145,38 @@ func (a *Artifetcher) Fetch(url, manifest string) (string, error) {
 		return "", GetStatusError{url, response.Status}
 	}
 
-	_, err = io.Copy(artifactFile, response.Body)
+	if response.ContentLength > 0 {
+		err = a.checkFreeSpace(uint64(response.ContentLength) * SpaceFactor)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var body io.Reader = response.Body
+	hash := sha256.New()
+	if checksum != "" {
+		body = io.TeeReader(response.Body, hash)
+	}
+
+	written, err := a.copyToFile(artifactFile, body, response.ContentLength)
 	if err != nil {
 		return "", WriteResponseError{err}
 	}
+	a.Log.Debugf("fetched %d bytes to %s", written, artifactFile.Name())
 
-	unzippedPath, err := a.FileSystem.TempDir("", "deployadactyl-unzipped-")
+	if checksum != "" {
+		actual := hex.EncodeToString(hash.Sum(nil))
+		if actual != checksum {
+			return "", ChecksumError{URL: url, Expected: checksum, Actual: actual}
+		}
+	}
+
+	err = a.verify(ctx, artifactFile.Name())
+	if err != nil {
+		return "", err
+	}
+
+	unzippedPath, err := a.FileSystem.TempDir(a.WorkDir, "deployadactyl-unzipped-")
 	if err != nil {
 		return "", CreateTempDirectoryError{err}
 	}
@@ -97,7 +197,7 @@ func (a *Artifetcher) Fetch(url, manifest string) (string, error) {
 // Returns a string to the unzipped application path and an error.
 func (a *Artifetcher) FetchZipFromRequest(body io.Reader) (string, string, error) {
 
-	zipFile, err := a.FileSystem.TempFile("", "deployadactyl-")
+	zipFile, err := a.FileSystem.TempFile(a.WorkDir, "deployadactyl-")
 	if err != nil {
 		return "", "", CreateTempFileError{err}
 	}
@@ -106,12 +206,23 @@ func (a *Artifetcher) FetchZipFromRequest(body io.Reader) (string, string, error
 
 	a.Log.Infof("fetching zip file %s", zipFile.Name())
 
-	_, err = io.Copy(zipFile, body)
+	written, err := a.copyToFile(zipFile, body, -1)
 	if err != nil {
 		return "", "", WriteResponseError{err}
 	}
 
-	unzippedPath, err := a.FileSystem.TempDir("", "deployadactyl-")
+	// The size of the request body is only known once it is written, so only room for its extraction is checked.
+	err = a.checkFreeSpace(uint64(written) * (SpaceFactor - 1))
+	if err != nil {
+		return "", "", err
+	}
+
+	err = a.verify(context.Background(), zipFile.Name())
+	if err != nil {
+		return "", "", err
+	}
+
+	unzippedPath, err := a.FileSystem.TempDir(a.WorkDir, "deployadactyl-")
 	if err != nil {
 		return "", "", CreateTempDirectoryError{err}
 	}
@@ -122,11 +233,46 @@ func (a *Artifetcher) FetchZipFromRequest(body io.Reader) (string, string, error
 		return "", "", UnzipError{err}
 	}
 
-	manifest, err := a.FileSystem.ReadFile(unzippedPath + "/manifest.yml")
-	if err != nil {
+	manifest, err := a.FileSystem.ReadFile(filepath.Join(unzippedPath, "manifest.yml"))
+	if err != nil && !os.IsNotExist(err) {
 		return "", "", err
 	}
 
 	a.Log.Debugf("fetched and unzipped to tempdir %s", unzippedPath)
 	return unzippedPath, string(manifest), nil
 }
+
+// checkFreeSpace returns a DiskSpaceError when the work directory has less than required bytes available.
+// The check is skipped when the free space cannot be determined.
+func (a *Artifetcher) checkFreeSpace(required uint64) error {
+	if a.FreeSpace == nil {
+		return nil
+	}
+
+	dir := a.WorkDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	available, err := a.FreeSpace(dir)
+	if err != nil {
+		a.Log.Debugf("cannot determine the free space of %s: %s", dir, err)
+		return nil
+	}
+	if available < required {
+		return DiskSpaceError{Dir: dir, Required: required, Available: available}
+	}
+	return nil
+}
+
+func (a *Artifetcher) verify(ctx context.Context, artifactPath string) error {
+	if a.Verifier == nil {
+		return nil
+	}
+
+	err := a.Verifier.Verify(ctx, artifactPath)
+	if err != nil {
+		return VerifyError{err}
+	}
+	return nil
+}
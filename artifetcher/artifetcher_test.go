@@ -1,10 +1,17 @@
 package artifetcher_test
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -13,9 +20,11 @@ import (
 	"github.com/op/go-logging"
 
 	. "github.com/compozed/deployadactyl/artifetcher"
+	"github.com/compozed/deployadactyl/conformance"
 	"github.com/compozed/deployadactyl/mocks"
 	"github.com/compozed/deployadactyl/randomizer"
 	"github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
 	E "github.com/compozed/deployadactyl/artifetcher/extractor"
 )
 
@@ -33,7 +42,7 @@ var _ = Describe("Artifetcher", func() {
 		log = interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(GinkgoWriter, logging.DEBUG, "artifetcher_test")}
 		af = &afero.Afero{Fs: afero.NewMemMapFs()}
 		extractor = &mocks.Extractor{}
-		artifetcher = &Artifetcher{af, extractor, log}
+		artifetcher = &Artifetcher{FileSystem: af, Extractor: extractor, Log: log}
 		manifest = "manifest-" + randomizer.StringRunes(10)
 
 		testserver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -49,7 +58,7 @@ var _ = Describe("Artifetcher", func() {
 		It("can fetch a jar file", func() {
 			extractor.UnzipCall.Returns.Error = nil
 
-			unzippedPath, err := artifetcher.Fetch(testserver.URL, "")
+			unzippedPath, err := artifetcher.Fetch(context.Background(), testserver.URL, "")
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(af.IsDir(unzippedPath)).To(BeTrue())
@@ -60,7 +69,7 @@ var _ = Describe("Artifetcher", func() {
 		})
 
 		It("returns an error when an invalid url is given", func() {
-			_, err := artifetcher.Fetch("example://example.example", manifest)
+			_, err := artifetcher.Fetch(context.Background(), "example://example.example", manifest)
 			Expect(err).To(HaveOccurred())
 		})
 
@@ -69,24 +78,255 @@ var _ = Describe("Artifetcher", func() {
 				http.Error(w, "not found", 404)
 			}))
 
-			_, err := artifetcher.Fetch(testserver.URL, manifest)
+			_, err := artifetcher.Fetch(context.Background(), testserver.URL, manifest)
 			Expect(err).To(HaveOccurred())
 		})
 
+		It("returns an error when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := artifetcher.Fetch(ctx, testserver.URL, manifest)
+			Expect(err).To(BeAssignableToTypeOf(GetUrlError{}))
+		})
+
+		It("reports the progress of the download", func() {
+			fixture, err := os.Stat("./fixtures/deployadactyl-fixture.jar")
+			Expect(err).ToNot(HaveOccurred())
+
+			var written, total int64
+			artifetcher.Progress = func(w, t int64) {
+				written, total = w, t
+			}
+
+			_, err = artifetcher.Fetch(context.Background(), testserver.URL, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(written).To(Equal(fixture.Size()))
+			Expect(total).To(Equal(fixture.Size()))
+		})
+
 		Context("when extractor fails", func() {
 			It("returns an error", func() {
 				extractor.UnzipCall.Returns.Error = errors.New("unzip call failed")
 
-				_, err := artifetcher.Fetch(testserver.URL, "")
+				_, err := artifetcher.Fetch(context.Background(), testserver.URL, "")
 
 				Expect(err).To(MatchError(UnzipError{errors.New("unzip call failed")}))
 			})
 		})
+
+		Context("when a CA bundle is configured", func() {
+			var tlsserver *httptest.Server
+
+			BeforeEach(func() {
+				tlsserver = httptest.NewTLSServer(testserver.Config.Handler)
+				artifetcher.CABundle = "/etc/ssl/internal-ca.pem"
+			})
+
+			AfterEach(func() {
+				tlsserver.Close()
+			})
+
+			It("fetches from a server signed by the bundle", func() {
+				bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsserver.TLS.Certificates[0].Certificate[0]})
+				Expect(af.WriteFile("/etc/ssl/internal-ca.pem", bundle, 0644)).To(Succeed())
+
+				_, err := artifetcher.Fetch(context.Background(), tlsserver.URL, "")
+
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("returns an error when the bundle can not be read", func() {
+				_, err := artifetcher.Fetch(context.Background(), tlsserver.URL, "")
+
+				Expect(err).To(HaveOccurred())
+				Expect(extractor.UnzipCall.Received.Source).To(BeEmpty())
+			})
+		})
+
+		Context("when the artifact is in a bucket", func() {
+			It("fetches it from the endpoint of the bucket", func() {
+				var path string
+				bucketserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					path = r.URL.Path
+					testserver.Config.Handler.ServeHTTP(w, r)
+				}))
+				defer bucketserver.Close()
+				artifetcher.Buckets = S.BucketsDescriptor{S3: S.S3Descriptor{Endpoint: bucketserver.URL}}
+
+				_, err := artifetcher.Fetch(context.Background(), "s3://artifacts/releases/app.jar", "")
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(path).To(Equal("/artifacts/releases/app.jar"))
+				Expect(extractor.UnzipCall.Received.Source).ToNot(BeEmpty())
+			})
+		})
+
+		Context("when the artifact is in artifactory", func() {
+			var (
+				artifactoryServer *httptest.Server
+				storageServer     *httptest.Server
+				checksum          string
+				apiKey            string
+				storageAPIKey     string
+			)
+
+			BeforeEach(func() {
+				jar, err := ioutil.ReadFile("./fixtures/deployadactyl-fixture.jar")
+				Expect(err).ToNot(HaveOccurred())
+				sum := sha256.Sum256(jar)
+				checksum = hex.EncodeToString(sum[:])
+
+				storageServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					storageAPIKey = r.Header.Get("X-JFrog-Art-Api")
+					testserver.Config.Handler.ServeHTTP(w, r)
+				}))
+				artifactoryServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.URL.RequestURI() {
+					case "/artifactory/api/storage/libs-release/app?lastModified":
+						fmt.Fprintf(w, `{"uri": "%s/artifactory/api/storage/libs-release/app/app-1.0.jar"}`, artifactoryServer.URL)
+					case "/artifactory/api/storage/libs-release/app/app-1.0.jar":
+						fmt.Fprintf(w, `{"repo": "libs-release", "path": "/app/app-1.0.jar", "checksums": {"sha256": "%s"}}`, checksum)
+					case "/artifactory/libs-release/app/app-1.0.jar":
+						apiKey = r.Header.Get("X-JFrog-Art-Api")
+						testserver.Config.Handler.ServeHTTP(w, r)
+					case "/artifactory/api/storage/libs-release/app/app-2.0.jar":
+						fmt.Fprintf(w, `{"repo": "libs-release", "path": "/app/app-2.0.jar", "checksums": {"sha256": "%s"}}`, checksum)
+					case "/artifactory/libs-release/app/app-2.0.jar":
+						http.Redirect(w, r, storageServer.URL+"/app-2.0.jar", http.StatusFound)
+					default:
+						http.NotFound(w, r)
+					}
+				}))
+				artifetcher.Artifactory = S.ArtifactoryDescriptor{URL: artifactoryServer.URL + "/artifactory", APIKey: "the-api-key"}
+			})
+
+			AfterEach(func() {
+				artifactoryServer.Close()
+				storageServer.Close()
+			})
+
+			It("fetches the latest artifact of a folder with the api key", func() {
+				_, err := artifetcher.Fetch(context.Background(), artifactoryServer.URL+"/artifactory/libs-release/app/latest", "")
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(apiKey).To(Equal("the-api-key"))
+				Expect(extractor.UnzipCall.Received.Source).ToNot(BeEmpty())
+			})
+
+			It("does not send the api key to the server a download is redirected to", func() {
+				_, err := artifetcher.Fetch(context.Background(), artifactoryServer.URL+"/artifactory/libs-release/app/app-2.0.jar", "")
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(storageAPIKey).To(BeEmpty())
+				Expect(extractor.UnzipCall.Received.Source).ToNot(BeEmpty())
+			})
+
+			It("does not extract an artifact whose sha256 is not the one artifactory reports", func() {
+				actual := checksum
+				checksum = strings.Repeat("0", 64)
+
+				_, err := artifetcher.Fetch(context.Background(), artifactoryServer.URL+"/artifactory/libs-release/app/app-1.0.jar", "")
+
+				Expect(err).To(MatchError(ChecksumError{
+					URL:      artifactoryServer.URL + "/artifactory/libs-release/app/app-1.0.jar",
+					Expected: checksum,
+					Actual:   actual,
+				}))
+				Expect(extractor.UnzipCall.Received.Source).To(BeEmpty())
+			})
+		})
+
+		Context("when the artifact has maven coordinates", func() {
+			It("fetches the artifact the coordinates resolve to", func() {
+				var path string
+				repository := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					path = r.URL.Path
+					testserver.Config.Handler.ServeHTTP(w, r)
+				}))
+				defer repository.Close()
+				artifetcher.Maven = S.MavenDescriptor{Repositories: []string{repository.URL + "/maven2"}}
+
+				_, err := artifetcher.Fetch(context.Background(), "maven:com.example:app:1.2.0:war", "")
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(path).To(Equal("/maven2/com/example/app/1.2.0/app-1.2.0.war"))
+				Expect(extractor.UnzipCall.Received.Source).ToNot(BeEmpty())
+			})
+		})
+
+		Context("when a verifier is configured", func() {
+			var verifier *mocks.ArtifactVerifier
+
+			BeforeEach(func() {
+				verifier = &mocks.ArtifactVerifier{}
+				artifetcher.Verifier = verifier
+			})
+
+			It("verifies the downloaded artifact before extracting it", func() {
+				_, err := artifetcher.Fetch(context.Background(), testserver.URL, "")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(verifier.VerifyCall.Received.ArtifactPath).To(Equal(extractor.UnzipCall.Received.Source))
+			})
+
+			It("does not extract an artifact that fails verification", func() {
+				verifier.VerifyCall.Returns.Error = errors.New("invalid signature")
+
+				_, err := artifetcher.Fetch(context.Background(), testserver.URL, "")
+
+				Expect(err).To(MatchError(VerifyError{errors.New("invalid signature")}))
+				Expect(extractor.UnzipCall.Received.Source).To(BeEmpty())
+			})
+		})
+
+		Context("when a work directory is configured", func() {
+			BeforeEach(func() {
+				artifetcher.WorkDir = "/var/deployadactyl"
+				Expect(af.MkdirAll("/var/deployadactyl", 0755)).To(Succeed())
+			})
+
+			It("downloads and extracts the artifact in the work directory", func() {
+				unzippedPath, err := artifetcher.Fetch(context.Background(), testserver.URL, "")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(unzippedPath).To(HavePrefix("/var/deployadactyl/deployadactyl-unzipped-"))
+				Expect(extractor.UnzipCall.Received.Source).To(HavePrefix("/var/deployadactyl/deployadactyl-zip-"))
+			})
+
+			It("returns an error before downloading an artifact that does not fit", func() {
+				fixture, err := os.Stat("./fixtures/deployadactyl-fixture.jar")
+				Expect(err).ToNot(HaveOccurred())
+
+				var checkedDir string
+				artifetcher.FreeSpace = func(dir string) (uint64, error) {
+					checkedDir = dir
+					return uint64(fixture.Size()), nil
+				}
+
+				_, err = artifetcher.Fetch(context.Background(), testserver.URL, "")
+
+				Expect(err).To(MatchError(DiskSpaceError{Dir: "/var/deployadactyl", Required: uint64(fixture.Size()) * SpaceFactor, Available: uint64(fixture.Size())}))
+				Expect(checkedDir).To(Equal("/var/deployadactyl"))
+				Expect(extractor.UnzipCall.Received.Source).To(BeEmpty())
+			})
+
+			It("fetches the artifact when the free space cannot be determined", func() {
+				artifetcher.FreeSpace = func(dir string) (uint64, error) {
+					return 0, errors.New("not supported")
+				}
+
+				_, err := artifetcher.Fetch(context.Background(), testserver.URL, "")
+
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
 	})
 
 	Describe("fetching a zip file from a request", func() {
 		It("returns the path to the unzipped directory and manifest", func() {
-			artifetcher = &Artifetcher{af, E.NewExtractor(log, af), log}
+			artifetcher = &Artifetcher{FileSystem: af, Extractor: E.NewExtractor(log, af), Log: log}
 
 			expectManifest := `---
 applications:
@@ -117,5 +357,45 @@ applications:
 				Expect(path).To(BeEmpty())
 			})
 		})
+
+		It("returns an empty manifest when the zip file has none", func() {
+			body, err := os.Open("./fixtures/artifact-with-manifest.jar")
+			Expect(err).ToNot(HaveOccurred())
+
+			path, manifest, err := artifetcher.FetchZipFromRequest(body)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).ToNot(BeEmpty())
+			Expect(manifest).To(BeEmpty())
+		})
+
+		It("returns an error before extracting a zip file that does not fit", func() {
+			artifetcher.FreeSpace = func(dir string) (uint64, error) {
+				return 1024, nil
+			}
+
+			body, err := os.Open("./fixtures/artifact-with-manifest.jar")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = artifetcher.FetchZipFromRequest(body)
+
+			Expect(err).To(BeAssignableToTypeOf(DiskSpaceError{}))
+			Expect(extractor.UnzipCall.Received.Source).To(BeEmpty())
+		})
+	})
+
+	It("satisfies the Fetcher contract", func() {
+		files := map[string]string{"index.html": "hello", "lib/app.jar": "app"}
+		server, err := conformance.ServeArtifact(files)
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		conformance.Fetcher(GinkgoT(), conformance.FetcherSubject{
+			Fetcher:     NewArtifetcher(af, E.NewExtractor(log, af), log, nil, "", S.BucketsDescriptor{}, S.ArtifactoryDescriptor{}, S.MavenDescriptor{}, ""),
+			FileSystem:  af,
+			ArtifactURL: server.URL + "/artifact.zip",
+			Files:       files,
+			MissingURL:  server.URL + "/missing.zip",
+		})
 	})
 })
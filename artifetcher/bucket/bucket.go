@@ -0,0 +1,174 @@
+// Package bucket fetches the artifacts of s3:// and gs:// URLs from Amazon S3 and Google Cloud Storage with the
+// credentials of the environment, so they do not need to be exposed over plain HTTP. It speaks just enough of their
+// REST APIs to download an object.
+package bucket
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// The schemes of the artifact URLs of buckets.
+const (
+	SchemeS3  = "s3"
+	SchemeGCS = "gs"
+)
+
+// Supported reports whether the URL is that of an object in a bucket.
+func Supported(rawURL string) bool {
+	return strings.HasPrefix(rawURL, SchemeS3+"://") || strings.HasPrefix(rawURL, SchemeGCS+"://")
+}
+
+// Validate returns an error when an endpoint is not an http or https URL, an encryption key is not a base64 AES-256
+// key, S3 has an access key without a secret, or the credentials file of GCS cannot be read.
+func Validate(descriptor S.BucketsDescriptor) error {
+	for _, endpoint := range []string{descriptor.S3.Endpoint, descriptor.GCS.Endpoint} {
+		if endpoint == "" {
+			continue
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return InvalidEndpointError{endpoint}
+		}
+	}
+
+	for _, key := range []string{descriptor.S3.SSECustomerKey, descriptor.GCS.EncryptionKey} {
+		if key == "" {
+			continue
+		}
+		_, err := encryptionKey(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	if (descriptor.S3.AccessKeyID == "") != (descriptor.S3.SecretAccessKey == "") {
+		return IncompleteCredentialsError{}
+	}
+
+	if descriptor.GCS.CredentialsFile != "" {
+		_, err := readServiceAccount(&afero.Afero{Fs: afero.NewOsFs()}, descriptor.GCS.CredentialsFile)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Register lets the transport fetch the s3:// and gs:// URLs with the credentials of the descriptor.
+func Register(transport *http.Transport, fs *afero.Afero, descriptor S.BucketsDescriptor) error {
+	t, err := NewTransport(fs, descriptor, transport)
+	if err != nil {
+		return err
+	}
+
+	transport.RegisterProtocol(SchemeS3, t)
+	transport.RegisterProtocol(SchemeGCS, t)
+	return nil
+}
+
+// NewTransport returns a Transport sending the requests of the buckets of the descriptor with the base transport.
+func NewTransport(fs *afero.Afero, descriptor S.BucketsDescriptor, base http.RoundTripper) (*Transport, error) {
+	t := &Transport{Buckets: descriptor, Base: base, Now: time.Now}
+
+	if descriptor.GCS.CredentialsFile != "" {
+		account, err := readServiceAccount(fs, descriptor.GCS.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		t.account = account
+	}
+	return t, nil
+}
+
+// Transport turns the requests of s3:// and gs:// URLs into authorized requests of the APIs of their buckets.
+// It keeps the access token of the service account of GCS until it expires.
+type Transport struct {
+	Buckets S.BucketsDescriptor
+	Base    http.RoundTripper
+	Now     func() time.Time
+
+	account *serviceAccount
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// RoundTrip sends the request to the API of the bucket of its URL.
+func (t *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.URL.Host == "" || strings.Trim(request.URL.Path, "/") == "" {
+		closeBody(request)
+		return nil, InvalidObjectURLError{request.URL.String()}
+	}
+
+	var (
+		outgoing *http.Request
+		err      error
+	)
+	switch request.URL.Scheme {
+	case SchemeS3:
+		outgoing, err = t.s3Request(request)
+	case SchemeGCS:
+		outgoing, err = t.gcsRequest(request)
+	default:
+		err = InvalidObjectURLError{request.URL.String()}
+	}
+	if err != nil {
+		closeBody(request)
+		return nil, err
+	}
+
+	return t.Base.RoundTrip(outgoing)
+}
+
+// redirect returns a copy of the request to the URL, with a copy of its headers.
+func redirect(request *http.Request, u *url.URL) *http.Request {
+	outgoing := request.WithContext(request.Context())
+	outgoing.URL = u
+	outgoing.Host = u.Host
+	outgoing.Header = make(http.Header, len(request.Header))
+	for name, values := range request.Header {
+		outgoing.Header[name] = append([]string(nil), values...)
+	}
+	return outgoing
+}
+
+// escapePath returns the object key with every byte but the unreserved characters and slashes percent encoded, as
+// both APIs expect them.
+func escapePath(key string) string {
+	const hex = "0123456789ABCDEF"
+
+	escaped := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			escaped = append(escaped, c)
+			continue
+		}
+		escaped = append(escaped, '%', hex[c>>4], hex[c&0xf])
+	}
+	return string(escaped)
+}
+
+// encryptionKey returns the AES-256 key of the base64 encoded key.
+func encryptionKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, InvalidEncryptionKeyError{}
+	}
+	return key, nil
+}
+
+func closeBody(request *http.Request) {
+	if request.Body != nil {
+		request.Body.Close()
+	}
+}
@@ -0,0 +1,79 @@
+package bucket
+
+import "fmt"
+
+type InvalidEndpointError struct {
+	Endpoint string
+}
+
+func (e InvalidEndpointError) Error() string {
+	return fmt.Sprintf("the endpoint of the buckets must be an http or https url: %s", e.Endpoint)
+}
+
+type InvalidEncryptionKeyError struct{}
+
+func (e InvalidEncryptionKeyError) Error() string {
+	return "the encryption key of the buckets must be a base64 encoded 256 bit key"
+}
+
+type IncompleteCredentialsError struct{}
+
+func (e IncompleteCredentialsError) Error() string {
+	return "s3 needs both an access_key_id and a secret_access_key"
+}
+
+type CredentialsFileError struct {
+	Path string
+	Err  error
+}
+
+func (e CredentialsFileError) Error() string {
+	return fmt.Sprintf("cannot read the gcs credentials file %s: %s", e.Path, e.Err)
+}
+
+type MissingClientEmailError struct{}
+
+func (e MissingClientEmailError) Error() string {
+	return "the key has no client_email"
+}
+
+type InvalidPrivateKeyError struct{}
+
+func (e InvalidPrivateKeyError) Error() string {
+	return "the private_key of the key is not a PEM encoded RSA key"
+}
+
+type InvalidObjectURLError struct {
+	URL string
+}
+
+func (e InvalidObjectURLError) Error() string {
+	return fmt.Sprintf("invalid object url %s: it must be s3://bucket/key or gs://bucket/object", e.URL)
+}
+
+type TokenError struct {
+	TokenURI string
+	Err      error
+}
+
+func (e TokenError) Error() string {
+	return fmt.Sprintf("cannot get a gcs access token from %s: %s", e.TokenURI, e.Err)
+}
+
+type StatusError struct {
+	Status string
+	Body   string
+}
+
+func (e StatusError) Error() string {
+	if e.Body == "" {
+		return e.Status
+	}
+	return fmt.Sprintf("%s: %s", e.Status, e.Body)
+}
+
+type MissingAccessTokenError struct{}
+
+func (e MissingAccessTokenError) Error() string {
+	return "the response has no access_token"
+}
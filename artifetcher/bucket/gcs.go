@@ -0,0 +1,201 @@
+package bucket
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// DefaultGCSEndpoint is the XML API of Cloud Storage objects are downloaded from.
+const DefaultGCSEndpoint = "https://storage.googleapis.com"
+
+// DefaultTokenURI is where the access tokens of a service account are requested when its key does not say.
+const DefaultTokenURI = "https://oauth2.googleapis.com/token"
+
+// ReadOnlyScope is the scope of the access tokens, which may only read objects.
+const ReadOnlyScope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+// tokenLifetime is how long the assertions of the service account are valid, the longest Google accepts.
+const tokenLifetime = time.Hour
+
+// expiryMargin is how long before it expires an access token is requested again.
+const expiryMargin = time.Minute
+
+// serviceAccount is the JSON key of a service account.
+type serviceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+
+	key *rsa.PrivateKey
+}
+
+// readServiceAccount reads the JSON key of a service account from the file.
+func readServiceAccount(fs *afero.Afero, path string) (*serviceAccount, error) {
+	b, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, CredentialsFileError{path, err}
+	}
+
+	account := &serviceAccount{}
+	err = json.Unmarshal(b, account)
+	if err != nil {
+		return nil, CredentialsFileError{path, err}
+	}
+	if account.ClientEmail == "" {
+		return nil, CredentialsFileError{path, MissingClientEmailError{}}
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = DefaultTokenURI
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, CredentialsFileError{path, InvalidPrivateKeyError{}}
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if err != nil || !ok {
+		return nil, CredentialsFileError{path, InvalidPrivateKeyError{}}
+	}
+	account.key = key
+	return account, nil
+}
+
+// gcsRequest returns the request of the XML API of Cloud Storage getting the object of the gs:// URL of the request.
+func (t *Transport) gcsRequest(request *http.Request) (*http.Request, error) {
+	descriptor := t.Buckets.GCS
+	endpoint := descriptor.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultGCSEndpoint
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, InvalidEndpointError{endpoint}
+	}
+
+	u := &url.URL{
+		Scheme:   base.Scheme,
+		Host:     base.Host,
+		Path:     strings.TrimSuffix(base.Path, "/") + "/" + request.URL.Host + request.URL.Path,
+		RawQuery: request.URL.RawQuery,
+	}
+	u.RawPath = escapePath(u.Path)
+
+	outgoing := redirect(request, u)
+
+	if descriptor.EncryptionKey != "" {
+		key, err := encryptionKey(descriptor.EncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(key)
+		outgoing.Header.Set("X-Goog-Encryption-Algorithm", "AES256")
+		outgoing.Header.Set("X-Goog-Encryption-Key", descriptor.EncryptionKey)
+		outgoing.Header.Set("X-Goog-Encryption-Key-Sha256", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	if t.account != nil {
+		token, err := t.accessToken(request)
+		if err != nil {
+			return nil, err
+		}
+		outgoing.Header.Set("Authorization", "Bearer "+token)
+	}
+	return outgoing, nil
+}
+
+// accessToken returns the access token of the service account, requesting a new one when it is about to expire.
+func (t *Transport) accessToken(request *http.Request) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.Now()
+	if t.token != "" && now.Add(expiryMargin).Before(t.expiry) {
+		return t.token, nil
+	}
+
+	assertion, err := t.account.assertion(now)
+	if err != nil {
+		return "", TokenError{t.account.TokenURI, err}
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	tokenRequest, err := http.NewRequest("POST", t.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", TokenError{t.account.TokenURI, err}
+	}
+	tokenRequest = tokenRequest.WithContext(request.Context())
+	tokenRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := t.Base.RoundTrip(tokenRequest)
+	if err != nil {
+		return "", TokenError{t.account.TokenURI, err}
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", TokenError{t.account.TokenURI, err}
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", TokenError{t.account.TokenURI, StatusError{response.Status, strings.TrimSpace(string(body))}}
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	err = json.Unmarshal(body, &token)
+	if err != nil || token.AccessToken == "" {
+		return "", TokenError{t.account.TokenURI, MissingAccessTokenError{}}
+	}
+
+	t.token = token.AccessToken
+	t.expiry = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return t.token, nil
+}
+
+// assertion returns the JWT the service account requests an access token with.
+func (a *serviceAccount) assertion(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   a.ClientEmail,
+		"scope": ReadOnlyScope,
+		"aud":   a.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(tokenLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hashed := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,176 @@
+package bucket
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultRegion is the region of the S3 buckets when the environment does not set one.
+const DefaultRegion = "us-east-1"
+
+// emptyPayloadHash is the SHA-256 of the empty body of a GET.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Credentials are the AWS credentials requests are signed with. The SessionToken is only set for temporary
+// credentials.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// s3Request returns the request of the S3 API getting the object of the s3:// URL of the request.
+func (t *Transport) s3Request(request *http.Request) (*http.Request, error) {
+	descriptor := t.Buckets.S3
+	region := descriptor.Region
+	if region == "" {
+		region = DefaultRegion
+	}
+
+	bucket := request.URL.Host
+	key := strings.TrimPrefix(request.URL.Path, "/")
+
+	u := &url.URL{Scheme: "https", RawQuery: request.URL.RawQuery}
+	switch {
+	case descriptor.Endpoint != "":
+		endpoint, err := url.Parse(descriptor.Endpoint)
+		if err != nil {
+			return nil, InvalidEndpointError{descriptor.Endpoint}
+		}
+		u.Scheme = endpoint.Scheme
+		u.Host = endpoint.Host
+		u.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" + bucket + "/" + key
+	case descriptor.PathStyle || strings.Contains(bucket, "."):
+		// The wildcard certificate of the virtual hosts does not match the names of buckets with dots.
+		u.Host = "s3." + region + ".amazonaws.com"
+		u.Path = "/" + bucket + "/" + key
+	default:
+		u.Host = bucket + ".s3." + region + ".amazonaws.com"
+		u.Path = "/" + key
+	}
+	u.RawPath = escapePath(u.Path)
+
+	outgoing := redirect(request, u)
+
+	if descriptor.SSECustomerKey != "" {
+		key, err := encryptionKey(descriptor.SSECustomerKey)
+		if err != nil {
+			return nil, err
+		}
+		sum := md5.Sum(key)
+		outgoing.Header.Set("X-Amz-Server-Side-Encryption-Customer-Algorithm", "AES256")
+		outgoing.Header.Set("X-Amz-Server-Side-Encryption-Customer-Key", descriptor.SSECustomerKey)
+		outgoing.Header.Set("X-Amz-Server-Side-Encryption-Customer-Key-Md5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	if descriptor.AccessKeyID != "" {
+		SignV4(outgoing, Credentials{descriptor.AccessKeyID, descriptor.SecretAccessKey, descriptor.SessionToken}, region, "s3", t.Now())
+	}
+	return outgoing, nil
+}
+
+// SignV4 signs the request with the credentials for the service in the region, with AWS Signature Version 4. Every
+// header of the request is signed, with its host. The request must not have a body.
+func SignV4(request *http.Request, credentials Credentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	if request.Header.Get("X-Amz-Content-Sha256") == "" {
+		request.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	}
+	if credentials.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	host := request.Host
+	if host == "" {
+		host = request.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range request.Header {
+		var trimmed []string
+		for _, value := range values {
+			trimmed = append(trimmed, strings.Join(strings.Fields(value), " "))
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders []string
+	for _, name := range names {
+		canonicalHeaders = append(canonicalHeaders, name+":"+headers[name]+"\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		canonicalQuery(request.URL.Query()),
+		strings.Join(canonicalHeaders, ""),
+		signedHeaders,
+		request.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(hashed[:])}, "\n")
+
+	key := []byte("AWS4" + credentials.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery returns the query sorted by name and value, with everything but the unreserved characters percent
+// encoded.
+func canonicalQuery(query url.Values) string {
+	escaped := map[string][]string{}
+	var names []string
+	for name, values := range query {
+		name = escapeQuery(name)
+		names = append(names, name)
+		for _, value := range values {
+			escaped[name] = append(escaped[name], escapeQuery(value))
+		}
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		values := escaped[name]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, name+"="+value)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func escapeQuery(s string) string {
+	return strings.Replace(escapePath(s), "/", "%2F", -1)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
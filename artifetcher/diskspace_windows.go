@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package artifetcher
+
+import "errors"
+
+// freeSpace is not supported on Windows, so the free space of the work directory is not checked.
+func freeSpace(dir string) (uint64, error) {
+	return 0, errors.New("checking free space is not supported on windows")
+}
@@ -59,3 +59,31 @@ type UnzipError struct {
 func (e UnzipError) Error() string {
 	return fmt.Sprintf("cannot unzip artifact: %s", e.Err)
 }
+
+type VerifyError struct {
+	Err error
+}
+
+func (e VerifyError) Error() string {
+	return fmt.Sprintf("cannot verify artifact: %s", e.Err)
+}
+
+type DiskSpaceError struct {
+	Dir       string
+	Required  uint64
+	Available uint64
+}
+
+func (e DiskSpaceError) Error() string {
+	return fmt.Sprintf("not enough disk space in %s: %d bytes are required but only %d bytes are available", e.Dir, e.Required, e.Available)
+}
+
+type ChecksumError struct {
+	URL      string
+	Expected string
+	Actual   string
+}
+
+func (e ChecksumError) Error() string {
+	return fmt.Sprintf("the sha256 of %s is %s but artifactory reports %s", e.URL, e.Actual, e.Expected)
+}
@@ -74,3 +74,37 @@ type WriteFileError struct {
 func (e WriteFileError) Error() string {
 	return fmt.Sprintf("cannot write to file: %s: %s", e.SavedLocation, e.Err)
 }
+
+type ZipSlipError struct {
+	FileName string
+}
+
+func (e ZipSlipError) Error() string {
+	return fmt.Sprintf("archive entry would be extracted outside of the destination: %s", e.FileName)
+}
+
+type SymlinkEscapeError struct {
+	FileName string
+	Target   string
+}
+
+func (e SymlinkEscapeError) Error() string {
+	return fmt.Sprintf("archive symlink points outside of the destination: %s -> %s", e.FileName, e.Target)
+}
+
+type TooManyEntriesError struct {
+	Entries int
+	Max     int
+}
+
+func (e TooManyEntriesError) Error() string {
+	return fmt.Sprintf("archive contains %d entries: the maximum is %d", e.Entries, e.Max)
+}
+
+type UncompressedSizeError struct {
+	Max int64
+}
+
+func (e UncompressedSizeError) Error() string {
+	return fmt.Sprintf("archive is larger than %d bytes when uncompressed", e.Max)
+}
@@ -5,26 +5,47 @@ import (
 	"archive/zip"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/spf13/afero"
 )
 
+const (
+	// DefaultMaxUncompressedSize is the largest total size an archive may extract to.
+	DefaultMaxUncompressedSize int64 = 8 * 1024 * 1024 * 1024
+
+	// DefaultMaxEntries is the largest number of entries an archive may contain.
+	DefaultMaxEntries = 100000
+)
+
 type ExtractorConstructor func(log I.DeploymentLogger, fs *afero.Afero) I.Extractor
 
 func NewExtractor(log I.DeploymentLogger, fs *afero.Afero) I.Extractor {
 	return &Extractor{
-		Log: log,
+		Log:        log,
 		FileSystem: fs,
 	}
 }
 
 // Extractor has a file system from which files are extracted from.
+//
+// Entries that would be written outside of the destination, symlinks that point outside of it
+// and archives that exceed MaxEntries or MaxUncompressedSize are rejected. Entries are extracted
+// concurrently by Workers goroutines. Zero values use the defaults.
 type Extractor struct {
-	Log        I.DeploymentLogger
-	FileSystem *afero.Afero
+	Log                 I.DeploymentLogger
+	FileSystem          *afero.Afero
+	MaxUncompressedSize int64
+	MaxEntries          int
+	Workers             int
 }
 
 // Unzip unzips from source into destination.
@@ -51,20 +72,36 @@ func (e *Extractor) Unzip(source, destination, manifest string) error {
 		return err
 	}
 
-	reader, err := zip.NewReader(file, fileStat.Size())
+	reader, err := zip.NewReader(&syncReaderAt{reader: file}, fileStat.Size())
 	if err != nil {
 		return OpenZipError{source, err}
 	}
 
-	for _, file := range reader.File {
-		err := e.unzipFile(destination, file)
+	files, links, err := e.plan(destination, reader.File)
+	if err != nil {
+		return err
+	}
+
+	err = e.extractAll(destination, files)
+	if err != nil {
+		return err
+	}
+
+	linkLocations, err := locations(destination, links)
+	if err != nil {
+		return err
+	}
+
+	// Symlinks are created after every file is written so no entry can be written through one.
+	for _, link := range links {
+		err := e.unzipSymlink(destination, link, linkLocations)
 		if err != nil {
-			return ExtractFileError{file.Name, err}
+			return ExtractFileError{link.Name, err}
 		}
 	}
 
 	if manifest != "" {
-		manifestFile, err := e.FileSystem.OpenFile(path.Join(destination, "manifest.yml"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		manifestFile, err := e.FileSystem.OpenFile(filepath.Join(destination, "manifest.yml"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 		if err != nil {
 			return OpenManifestError{err}
 		}
@@ -80,22 +117,107 @@ func (e *Extractor) Unzip(source, destination, manifest string) error {
 	return nil
 }
 
-func (e *Extractor) unzipFile(destination string, file *zip.File) error {
+// plan validates every entry of the archive before anything is written and creates the directories.
+//
+// Returns the regular files and the symlinks to extract.
+func (e *Extractor) plan(destination string, entries []*zip.File) (files, links []*zip.File, err error) {
+	maxEntries := e.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	if len(entries) > maxEntries {
+		return nil, nil, TooManyEntriesError{Entries: len(entries), Max: maxEntries}
+	}
+
+	var declaredSize uint64
+	for _, file := range entries {
+		savedLocation, err := safeJoin(destination, file.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		declaredSize += file.UncompressedSize64
+		if declaredSize > uint64(e.maxUncompressedSize()) {
+			return nil, nil, UncompressedSizeError{Max: e.maxUncompressedSize()}
+		}
+
+		directory := filepath.Dir(savedLocation)
+		if file.FileInfo().IsDir() {
+			directory = savedLocation
+		}
+		err = e.FileSystem.MkdirAll(directory, 0755)
+		if err != nil {
+			return nil, nil, MakeDirectoryError{directory, err}
+		}
+
+		switch {
+		case file.FileInfo().IsDir():
+		case file.Mode()&os.ModeSymlink != 0:
+			links = append(links, file)
+		default:
+			files = append(files, file)
+		}
+	}
+
+	return files, links, nil
+}
+
+// extractAll writes the files with a bounded pool of workers and stops at the first error.
+func (e *Extractor) extractAll(destination string, files []*zip.File) error {
+	workers := e.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var (
+		written  int64
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		jobs     = make(chan *zip.File)
+		done     = make(chan struct{})
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				err := e.unzipFile(destination, file, &written)
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						close(done)
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, file := range files {
+		select {
+		case jobs <- file:
+		case <-done:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return firstErr
+}
+
+func (e *Extractor) unzipFile(destination string, file *zip.File, written *int64) error {
 	contents, err := file.Open()
 	if err != nil {
 		return ExtractFileError{file.Name, err}
 	}
 	defer contents.Close()
 
-	if file.FileInfo().IsDir() {
-		return nil
-	}
-
-	savedLocation := path.Join(destination, file.Name)
-	directory := path.Dir(savedLocation)
-	err = e.FileSystem.MkdirAll(directory, 0755)
+	savedLocation, err := safeJoin(destination, file.Name)
 	if err != nil {
-		return MakeDirectoryError{directory, err}
+		return err
 	}
 
 	mode := file.Mode()
@@ -105,10 +227,144 @@ func (e *Extractor) unzipFile(destination string, file *zip.File) error {
 	}
 	defer newFile.Close()
 
-	_, err = io.Copy(newFile, contents)
+	// The sizes in the zip headers can not be trusted, so the bytes actually written are counted as well.
+	_, err = io.Copy(newFile, &limitedReader{reader: contents, written: written, max: e.maxUncompressedSize()})
 	if err != nil {
+		if _, ok := err.(UncompressedSizeError); ok {
+			return err
+		}
 		return WriteFileError{savedLocation, err}
 	}
 
 	return nil
 }
+
+// unzipSymlink creates the symlink of the entry. Its target must stay inside the destination without going
+// through any of the links of the archive, whose own targets could lead it elsewhere.
+func (e *Extractor) unzipSymlink(destination string, file *zip.File, links map[string]bool) error {
+	contents, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer contents.Close()
+
+	target, err := readLinkTarget(contents)
+	if err != nil {
+		return err
+	}
+
+	savedLocation, err := safeJoin(destination, file.Name)
+	if err != nil {
+		return err
+	}
+
+	slashTarget := strings.Replace(target, "\\", "/", -1)
+	if path.IsAbs(slashTarget) || filepath.VolumeName(target) != "" || !resolvesWithin(destination, savedLocation, slashTarget, links) {
+		return SymlinkEscapeError{FileName: file.Name, Target: target}
+	}
+
+	if _, ok := e.FileSystem.Fs.(*afero.OsFs); ok {
+		return os.Symlink(target, savedLocation)
+	}
+
+	// File systems without symlinks get a regular file containing the target, as the zip stores it.
+	return e.FileSystem.WriteFile(savedLocation, []byte(target), file.Mode().Perm())
+}
+
+// resolvesWithin reports whether every step of the target of the link at location stays inside the destination.
+// Only the last step may be another link, since its target is checked on its own.
+func resolvesWithin(destination, location, target string, links map[string]bool) bool {
+	var steps []string
+	for _, step := range strings.Split(target, "/") {
+		if step != "" && step != "." {
+			steps = append(steps, step)
+		}
+	}
+
+	current := filepath.Dir(location)
+	for i, step := range steps {
+		if step == ".." {
+			current = filepath.Dir(current)
+		} else {
+			current = filepath.Join(current, step)
+		}
+		if !within(destination, current) || (i < len(steps)-1 && links[current]) {
+			return false
+		}
+	}
+	return true
+}
+
+// locations returns where the links of the archive are written.
+func locations(destination string, links []*zip.File) (map[string]bool, error) {
+	linkLocations := make(map[string]bool)
+	for _, link := range links {
+		savedLocation, err := safeJoin(destination, link.Name)
+		if err != nil {
+			return nil, err
+		}
+		linkLocations[savedLocation] = true
+	}
+	return linkLocations, nil
+}
+
+func (e *Extractor) maxUncompressedSize() int64 {
+	if e.MaxUncompressedSize <= 0 {
+		return DefaultMaxUncompressedSize
+	}
+	return e.MaxUncompressedSize
+}
+
+func readLinkTarget(contents io.Reader) (string, error) {
+	target, err := ioutil.ReadAll(io.LimitReader(contents, 4096))
+	return string(target), err
+}
+
+// safeJoin joins an entry name to the destination and rejects names that leave the destination.
+func safeJoin(destination, name string) (string, error) {
+	name = strings.Replace(name, "\\", "/", -1)
+	if path.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", ZipSlipError{FileName: name}
+	}
+
+	savedLocation := filepath.Join(destination, filepath.FromSlash(name))
+	if !within(destination, savedLocation) {
+		return "", ZipSlipError{FileName: name}
+	}
+
+	return savedLocation, nil
+}
+
+func within(destination, location string) bool {
+	destination = filepath.Clean(destination)
+	location = filepath.Clean(location)
+	return location == destination || strings.HasPrefix(location, destination+string(filepath.Separator))
+}
+
+// limitedReader fails once the bytes read by every reader sharing written exceed max.
+type limitedReader struct {
+	reader  io.Reader
+	written *int64
+	max     int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if atomic.AddInt64(r.written, int64(n)) > r.max {
+		return n, UncompressedSizeError{Max: r.max}
+	}
+	return n, err
+}
+
+// syncReaderAt serializes reads from the archive, since not every afero file supports concurrent ReadAt.
+// Decompressing and writing the entries still happens concurrently.
+type syncReaderAt struct {
+	mu     sync.Mutex
+	reader io.ReaderAt
+}
+
+func (r *syncReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reader.ReadAt(p, off)
+}
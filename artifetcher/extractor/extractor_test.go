@@ -1,8 +1,13 @@
 package extractor_test
 
 import (
+	"archive/zip"
+	"bytes"
+	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -34,7 +39,7 @@ var _ = Describe("Extracting", func() {
 		file = "/artifact.jar"
 		destination = "../fixtures/deployadactyl-fixture"
 		af = &afero.Afero{Fs: afero.NewMemMapFs()}
-		extractor = Extractor{interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(GinkgoWriter, logging.DEBUG, "extractor_test")}, af}
+		extractor = Extractor{Log: interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(GinkgoWriter, logging.DEBUG, "extractor_test")}, FileSystem: af}
 
 		fileBytes, err := ioutil.ReadFile("../fixtures/deployadactyl-fixture.jar")
 		Expect(err).ToNot(HaveOccurred())
@@ -83,8 +88,142 @@ var _ = Describe("Extracting", func() {
 		destination = "../fixtures/bad-deployadactyl-fixture"
 		af = &afero.Afero{Fs: afero.NewMemMapFs()}
 
-		extractor := Extractor{interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(GinkgoWriter, logging.DEBUG, "extractor_test")}, af}
+		extractor := Extractor{Log: interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(GinkgoWriter, logging.DEBUG, "extractor_test")}, FileSystem: af}
 
 		Expect(extractor.Unzip(file, destination, "")).ToNot(Succeed())
 	})
+
+	Context("when the archive is malicious", func() {
+		type entry struct {
+			name     string
+			contents string
+			mode     os.FileMode
+		}
+
+		writeArchive := func(entries ...entry) {
+			buffer := &bytes.Buffer{}
+			writer := zip.NewWriter(buffer)
+			for _, e := range entries {
+				header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+				if e.mode != 0 {
+					header.SetMode(e.mode)
+				}
+				w, err := writer.CreateHeader(header)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = w.Write([]byte(e.contents))
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(writer.Close()).To(Succeed())
+
+			Expect(af.WriteFile(file, buffer.Bytes(), 0644)).To(Succeed())
+		}
+
+		It("rejects entries that would be written outside of the destination", func() {
+			writeArchive(entry{name: "index.html"}, entry{name: "../../evil.sh", contents: "evil"})
+
+			Expect(extractor.Unzip(file, destination, "")).To(MatchError(ZipSlipError{FileName: "../../evil.sh"}))
+
+			exists, err := af.Exists(path.Join(destination, "../../evil.sh"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("rejects entries with absolute paths", func() {
+			writeArchive(entry{name: "/etc/cron.d/evil"})
+
+			Expect(extractor.Unzip(file, destination, "")).To(MatchError(ZipSlipError{FileName: "/etc/cron.d/evil"}))
+		})
+
+		It("rejects symlinks that point outside of the destination", func() {
+			writeArchive(entry{name: "link", contents: "../../../etc/passwd", mode: os.ModeSymlink | 0777})
+
+			err := extractor.Unzip(file, destination, "")
+
+			Expect(err).To(MatchError(ExtractFileError{"link", SymlinkEscapeError{FileName: "link", Target: "../../../etc/passwd"}}))
+		})
+
+		It("allows symlinks that stay inside the destination", func() {
+			writeArchive(entry{name: "lib/app.jar", contents: "jar"}, entry{name: "app.jar", contents: "lib/app.jar", mode: os.ModeSymlink | 0777})
+
+			Expect(extractor.Unzip(file, destination, "")).To(Succeed())
+		})
+
+		It("rejects symlinks that leave the destination through another symlink", func() {
+			writeArchive(
+				entry{name: "q", contents: ".", mode: os.ModeSymlink | 0777},
+				entry{name: "m", contents: "q/..", mode: os.ModeSymlink | 0777},
+			)
+
+			err := extractor.Unzip(file, destination, "")
+
+			Expect(err).To(MatchError(ExtractFileError{"m", SymlinkEscapeError{FileName: "m", Target: "q/.."}}))
+		})
+
+		It("rejects a chain of symlinks written to the disk", func() {
+			directory, err := ioutil.TempDir("", "extractor-test-")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(directory)
+
+			writeArchive(
+				entry{name: "q", contents: ".", mode: os.ModeSymlink | 0777},
+				entry{name: "m", contents: "q/..", mode: os.ModeSymlink | 0777},
+			)
+			archive, err := af.ReadFile(file)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(directory, "artifact.jar"), archive, 0644)).To(Succeed())
+
+			extractor.FileSystem = &afero.Afero{Fs: afero.NewOsFs()}
+			err = extractor.Unzip(filepath.Join(directory, "artifact.jar"), filepath.Join(directory, "app"), "")
+
+			Expect(err).To(MatchError(ExtractFileError{"m", SymlinkEscapeError{FileName: "m", Target: "q/.."}}))
+			_, err = os.Lstat(filepath.Join(directory, "app", "m"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("allows symlinks to other symlinks inside the destination", func() {
+			writeArchive(
+				entry{name: "lib/app-1.0.jar", contents: "jar"},
+				entry{name: "lib/app.jar", contents: "app-1.0.jar", mode: os.ModeSymlink | 0777},
+				entry{name: "app.jar", contents: "lib/app.jar", mode: os.ModeSymlink | 0777},
+			)
+
+			Expect(extractor.Unzip(file, destination, "")).To(Succeed())
+		})
+
+		It("rejects archives with too many entries", func() {
+			extractor.MaxEntries = 2
+			writeArchive(entry{name: "a"}, entry{name: "b"}, entry{name: "c"})
+
+			Expect(extractor.Unzip(file, destination, "")).To(MatchError(TooManyEntriesError{Entries: 3, Max: 2}))
+		})
+
+		It("rejects archives that are too large when uncompressed", func() {
+			extractor.MaxUncompressedSize = 1024
+			writeArchive(entry{name: "bomb", contents: string(bytes.Repeat([]byte("0"), 4096))})
+
+			Expect(extractor.Unzip(file, destination, "")).To(MatchError(UncompressedSizeError{Max: 1024}))
+		})
+	})
+
+	It("extracts many entries concurrently", func() {
+		extractor.Workers = 4
+
+		buffer := &bytes.Buffer{}
+		writer := zip.NewWriter(buffer)
+		for i := 0; i < 50; i++ {
+			w, err := writer.Create(fmt.Sprintf("dir-%d/file-%d.txt", i%5, i))
+			Expect(err).ToNot(HaveOccurred())
+			fmt.Fprintf(w, "contents-%d", i)
+		}
+		Expect(writer.Close()).To(Succeed())
+		Expect(af.WriteFile(file, buffer.Bytes(), 0644)).To(Succeed())
+
+		Expect(extractor.Unzip(file, destination, "")).To(Succeed())
+
+		for i := 0; i < 50; i++ {
+			contents, err := af.ReadFile(path.Join(destination, fmt.Sprintf("dir-%d/file-%d.txt", i%5, i)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal(fmt.Sprintf("contents-%d", i)))
+		}
+	})
 })
@@ -0,0 +1,139 @@
+package git
+
+import (
+	"fmt"
+	"time"
+)
+
+type InvalidBuildTimeoutError struct {
+	Seconds int
+}
+
+func (e InvalidBuildTimeoutError) Error() string {
+	return fmt.Sprintf("the timeout of the git build command must not be negative: %d", e.Seconds)
+}
+
+type InvalidPathError struct {
+	Path string
+}
+
+func (e InvalidPathError) Error() string {
+	return fmt.Sprintf("the git path must be a relative path within the checkout: %s", e.Path)
+}
+
+type CredentialsWithoutRepositoriesError struct{}
+
+func (e CredentialsWithoutRepositoriesError) Error() string {
+	return "the git username and password are only sent to the git repositories, and none are configured"
+}
+
+type InvalidRepositoryError struct {
+	Repository string
+}
+
+func (e InvalidRepositoryError) Error() string {
+	return fmt.Sprintf("the git repositories must be https URLs without credentials: %s", e.Repository)
+}
+
+type DisabledError struct{}
+
+func (e DisabledError) Error() string {
+	return "the environment does not deploy from git repositories"
+}
+
+type InvalidURLError struct {
+	URL string
+}
+
+func (e InvalidURLError) Error() string {
+	return fmt.Sprintf("invalid git repository url: %s", e.URL)
+}
+
+type InvalidRefError struct {
+	Ref string
+}
+
+func (e InvalidRefError) Error() string {
+	return fmt.Sprintf("invalid git ref: %s", e.Ref)
+}
+
+type ProtocolError struct {
+	URL      string
+	Protocol string
+}
+
+func (e ProtocolError) Error() string {
+	return fmt.Sprintf("cannot clone %s: git repositories are not cloned over %s", e.URL, e.Protocol)
+}
+
+type CreateTempDirectoryError struct {
+	Err error
+}
+
+func (e CreateTempDirectoryError) Error() string {
+	return fmt.Sprintf("cannot create temp directory: %s", e.Err)
+}
+
+type CommandError struct {
+	Command string
+	Err     error
+	Output  string
+}
+
+func (e CommandError) Error() string {
+	if e.Output == "" {
+		return fmt.Sprintf("%s failed: %s", e.Command, e.Err)
+	}
+	return fmt.Sprintf("%s failed: %s: %s", e.Command, e.Err, e.Output)
+}
+
+type RefNotFoundError struct {
+	URL string
+	Ref string
+}
+
+func (e RefNotFoundError) Error() string {
+	return fmt.Sprintf("cannot find %s in %s", e.Ref, e.URL)
+}
+
+type BuildTimeoutError struct {
+	Command string
+	Timeout time.Duration
+}
+
+func (e BuildTimeoutError) Error() string {
+	return fmt.Sprintf("git build command %s did not finish within %s", e.Command, e.Timeout)
+}
+
+type PathNotFoundError struct {
+	Path string
+}
+
+func (e PathNotFoundError) Error() string {
+	return fmt.Sprintf("the checkout has nothing at %s", e.Path)
+}
+
+type PathEscapeError struct {
+	Path string
+}
+
+func (e PathEscapeError) Error() string {
+	return fmt.Sprintf("the git path resolves outside of the checkout: %s", e.Path)
+}
+
+type SymlinkEscapeError struct {
+	Path string
+}
+
+func (e SymlinkEscapeError) Error() string {
+	return fmt.Sprintf("the checkout has a symlink pointing outside of it: %s", e.Path)
+}
+
+type UnzipError struct {
+	Path string
+	Err  error
+}
+
+func (e UnzipError) Error() string {
+	return fmt.Sprintf("cannot unzip %s: %s", e.Path, e.Err)
+}
@@ -0,0 +1,411 @@
+// Package git checks out the application of a deployment from a git repository, builds it with the build command of
+// the environment, and hands the directory to the Pusher, for teams without an artifact repository.
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// DefaultBuildTimeout is how long the build command may run when the environment does not set a timeout.
+const DefaultBuildTimeout = 10 * time.Minute
+
+// VariablePrefix prefixes the names of the variables describing the checkout to the build command.
+const VariablePrefix = "DEPLOYADACTYL_GIT_"
+
+// maxOutput is how much of the end of the output of a failed command is kept in its error.
+const maxOutput = 4096
+
+// DefaultProtocols are the protocols repositories may be cloned over. Local and file repositories are refused, so a
+// request cannot deploy the files of the server.
+var DefaultProtocols = []string{"https", "ssh"}
+
+// Validate returns an error when the build timeout is negative or the path is not within the checkout.
+func Validate(descriptor S.GitDescriptor) error {
+	if descriptor.BuildTimeoutSeconds < 0 {
+		return InvalidBuildTimeoutError{descriptor.BuildTimeoutSeconds}
+	}
+	if descriptor.Path != "" && !within(descriptor.Path) {
+		return InvalidPathError{descriptor.Path}
+	}
+	if (descriptor.Username != "" || descriptor.Password != "") && len(descriptor.Repositories) == 0 {
+		return CredentialsWithoutRepositoriesError{}
+	}
+	for _, repository := range descriptor.Repositories {
+		u, err := neturl.Parse(repository)
+		if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+			return InvalidRepositoryError{repository}
+		}
+	}
+	return nil
+}
+
+// Serves returns the repository of the descriptor the URL is under, and false when it is not under any of them, in
+// which case the username and password of the descriptor are not sent with its requests.
+func Serves(descriptor S.GitDescriptor, url string) (string, bool) {
+	for _, repository := range descriptor.Repositories {
+		repository = strings.TrimSuffix(repository, "/")
+		if url == repository || strings.HasPrefix(url, repository+"/") {
+			return repository, true
+		}
+	}
+	return "", false
+}
+
+func NewFetcher(fetcher I.Fetcher, fs *afero.Afero, ex I.Extractor, log I.DeploymentLogger, descriptor S.GitDescriptor, workDir string) I.Fetcher {
+	return &Fetcher{
+		Fetcher:    fetcher,
+		FileSystem: fs,
+		Extractor:  ex,
+		Log:        log,
+		Git:        descriptor,
+		WorkDir:    workDir,
+		Protocols:  DefaultProtocols,
+		Environ:    os.Environ,
+	}
+}
+
+// Fetcher checks out the artifact URLs of git sources, and passes any other artifact URL and the zips of the
+// requests to the Fetcher it wraps. The checkouts are made in the WorkDir, or the temporary directory of the
+// operating system, with the git command of the server, over the Protocols only.
+type Fetcher struct {
+	Fetcher    I.Fetcher
+	FileSystem *afero.Afero
+	Extractor  I.Extractor
+	Log        I.DeploymentLogger
+	Git        S.GitDescriptor
+	WorkDir    string
+	Protocols  []string
+	Environ    func() []string
+}
+
+// Fetch checks out the ref of the repository of a git artifact URL, runs the build command in the checkout, and
+// returns the directory of the path of the environment with the manifest of the deployment, if it has one.
+//
+// The checkout and the build are aborted when ctx is done.
+func (f *Fetcher) Fetch(ctx context.Context, url, manifest string) (string, error) {
+	source, ok := S.ParseGitArtifactURL(url)
+	if !ok {
+		return f.Fetcher.Fetch(ctx, url, manifest)
+	}
+
+	if !f.Git.Enabled {
+		return "", DisabledError{}
+	}
+	err := f.validate(source)
+	if err != nil {
+		return "", err
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	f.Log.Infof("checking out %s of %s", source.Ref, source.URL)
+
+	checkout, err := f.FileSystem.TempDir(f.WorkDir, "deployadactyl-git-")
+	if err != nil {
+		return "", CreateTempDirectoryError{err}
+	}
+
+	appPath, err := f.build(ctx, source, checkout, manifest)
+	if err != nil || appPath != checkout {
+		f.FileSystem.RemoveAll(checkout)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	f.Log.Debugf("checked out and built to tempdir: %s", appPath)
+	return appPath, nil
+}
+
+// FetchZipFromRequest passes the zip of the request to the Fetcher it wraps.
+func (f *Fetcher) FetchZipFromRequest(body io.Reader) (string, string, error) {
+	return f.Fetcher.FetchZipFromRequest(body)
+}
+
+// build checks out the source in the checkout directory and builds it, and returns the directory of the application.
+func (f *Fetcher) build(ctx context.Context, source S.GitSource, checkout, manifest string) (string, error) {
+	commit, err := f.checkout(ctx, source, checkout)
+	if err != nil {
+		return "", err
+	}
+	f.Log.Infof("checked out commit %s", commit)
+
+	if len(f.Git.BuildCommand) > 0 {
+		err = f.runBuild(ctx, source, commit, checkout)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = f.FileSystem.RemoveAll(filepath.Join(checkout, ".git"))
+	if err != nil {
+		return "", err
+	}
+
+	if f.Git.Path != "" {
+		return f.extract(checkout, manifest)
+	}
+
+	err = checkLinks(checkout)
+	if err != nil {
+		return "", err
+	}
+
+	if manifest != "" {
+		err = f.FileSystem.WriteFile(filepath.Join(checkout, "manifest.yml"), []byte(manifest), 0644)
+		if err != nil {
+			return "", err
+		}
+	}
+	return checkout, nil
+}
+
+// checkout fetches the ref of the repository to the directory and returns the commit it checked out. The ref is
+// fetched on its own first; abbreviated SHAs and servers refusing to send a commit by its SHA fall back to fetching
+// every branch and tag.
+func (f *Fetcher) checkout(ctx context.Context, source S.GitSource, dir string) (string, error) {
+	_, err := f.git(ctx, dir, source.URL, "init", "--quiet")
+	if err != nil {
+		return "", err
+	}
+
+	ref := "FETCH_HEAD"
+	_, err = f.git(ctx, dir, source.URL, "fetch", "--quiet", "--depth", "1", "--", source.URL, source.Ref)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
+		f.Log.Debugf("fetching every branch and tag of %s: %s", source.URL, err)
+
+		_, err = f.git(ctx, dir, source.URL, "fetch", "--quiet", "--", source.URL, "+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*")
+		if err != nil {
+			return "", err
+		}
+		ref = source.Ref
+		if _, err := f.git(ctx, dir, source.URL, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+ref+"^{commit}"); err == nil {
+			ref = "refs/remotes/origin/" + ref
+		}
+	}
+
+	_, err = f.git(ctx, dir, source.URL, "checkout", "--quiet", "--detach", ref+"^{commit}", "--")
+	if err != nil {
+		return "", RefNotFoundError{source.URL, source.Ref}
+	}
+
+	commit, err := f.git(ctx, dir, source.URL, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(commit), nil
+}
+
+// git runs git in the directory and returns its output for the repository at the URL. It never prompts for
+// credentials, and only sends the username and password of the environment to the repository when the environment
+// serves it, scoped to the repository so that git does not send them on redirects to other servers either.
+func (f *Fetcher) git(ctx context.Context, dir, url string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(f.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ALLOW_PROTOCOL="+strings.Join(f.Protocols, ":"))
+	repository, ok := Serves(f.Git, url)
+	if ok && (f.Git.Username != "" || f.Git.Password != "") {
+		credentials := base64.StdEncoding.EncodeToString([]byte(f.Git.Username + ":" + f.Git.Password))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http."+repository+"/.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials,
+		)
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", CommandError{"git " + args[0], err, tail(stderr.Bytes())}
+	}
+	return stdout.String(), nil
+}
+
+// runBuild runs the build command in the checkout with the environment of the server and the variables describing
+// the checkout.
+func (f *Fetcher) runBuild(ctx context.Context, source S.GitSource, commit, dir string) error {
+	timeout := DefaultBuildTimeout
+	if f.Git.BuildTimeoutSeconds > 0 {
+		timeout = time.Duration(f.Git.BuildTimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	command := strings.Join(f.Git.BuildCommand, " ")
+	f.Log.Infof("building with %s", command)
+
+	cmd := exec.CommandContext(ctx, f.Git.BuildCommand[0], f.Git.BuildCommand[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(f.Environ(),
+		VariablePrefix+"URL="+source.URL,
+		VariablePrefix+"REF="+source.Ref,
+		VariablePrefix+"COMMIT="+commit,
+	)
+
+	out, err := cmd.CombinedOutput()
+	f.Log.Debugf("output of %s:\n%s", command, out)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return BuildTimeoutError{command, timeout}
+		}
+		return CommandError{command, err, tail(out)}
+	}
+	return nil
+}
+
+// extract returns the directory at the path of the checkout, or the directory a zip, jar or war at the path is
+// extracted to with the manifest.
+func (f *Fetcher) extract(checkout, manifest string) (string, error) {
+	// The path is resolved on the disk git checked out to, since the repository can make it a symlink.
+	path, err := filepath.EvalSymlinks(filepath.Join(checkout, filepath.FromSlash(f.Git.Path)))
+	if err != nil {
+		return "", PathNotFoundError{f.Git.Path}
+	}
+	if !inside(checkout, path) {
+		return "", PathEscapeError{f.Git.Path}
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", PathNotFoundError{f.Git.Path}
+	}
+
+	err = checkLinks(checkout)
+	if err != nil {
+		return "", err
+	}
+
+	appPath, err := f.FileSystem.TempDir(f.WorkDir, "deployadactyl-unzipped-")
+	if err != nil {
+		return "", CreateTempDirectoryError{err}
+	}
+
+	if info.IsDir() {
+		// The directory replaces the temporary one, so removing the application removes all of it.
+		err = f.FileSystem.Remove(appPath)
+		if err == nil {
+			err = f.FileSystem.Rename(path, appPath)
+		}
+		if err != nil {
+			f.FileSystem.RemoveAll(appPath)
+			return "", err
+		}
+		if manifest != "" {
+			err = f.FileSystem.WriteFile(filepath.Join(appPath, "manifest.yml"), []byte(manifest), 0644)
+			if err != nil {
+				f.FileSystem.RemoveAll(appPath)
+				return "", err
+			}
+		}
+		return appPath, nil
+	}
+
+	err = f.Extractor.Unzip(path, appPath, manifest)
+	if err != nil {
+		f.FileSystem.RemoveAll(appPath)
+		return "", UnzipError{f.Git.Path, err}
+	}
+	return appPath, nil
+}
+
+// validate returns an error when the repository is not cloned over one of the protocols, or the URL or ref could be
+// mistaken for an option.
+func (f *Fetcher) validate(source S.GitSource) error {
+	if source.URL == "" || strings.HasPrefix(source.URL, "-") {
+		return InvalidURLError{source.URL}
+	}
+	if source.Ref == "" || strings.HasPrefix(source.Ref, "-") {
+		return InvalidRefError{source.Ref}
+	}
+
+	protocol := Protocol(source.URL)
+	for _, allowed := range f.Protocols {
+		if protocol == allowed {
+			return nil
+		}
+	}
+	return ProtocolError{source.URL, protocol}
+}
+
+// Protocol returns the protocol git clones the repository of the URL over: the scheme of a URL, ssh for the
+// user@host:path syntax, and file for a local path.
+func Protocol(url string) string {
+	if i := strings.Index(url, "://"); i > 0 {
+		return strings.ToLower(url[:i])
+	}
+	if i := strings.Index(url, "::"); i > 0 {
+		// A remote helper, such as ext::command.
+		return strings.ToLower(url[:i])
+	}
+
+	colon := strings.Index(url, ":")
+	slash := strings.Index(url, "/")
+	if colon > 0 && (slash < 0 || colon < slash) {
+		return "ssh"
+	}
+	return "file"
+}
+
+// checkLinks refuses a checkout with a symlink that resolves outside of it, so the files of the server it points
+// to are not pushed.
+func checkLinks(checkout string) error {
+	return filepath.Walk(checkout, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil || !inside(checkout, target) {
+			name, _ := filepath.Rel(checkout, path)
+			return SymlinkEscapeError{filepath.ToSlash(name)}
+		}
+		return nil
+	})
+}
+
+// inside reports whether the resolved path is the checkout or within it.
+func inside(checkout, path string) bool {
+	root, err := filepath.EvalSymlinks(checkout)
+	if err != nil {
+		return false
+	}
+	name, err := filepath.Rel(root, path)
+	return err == nil && within(name)
+}
+
+// within reports whether the relative path stays within the directory it is relative to.
+func within(path string) bool {
+	clean := filepath.Clean(filepath.FromSlash(path))
+	return !filepath.IsAbs(clean) && clean != ".." && !strings.HasPrefix(clean, ".."+string(filepath.Separator))
+}
+
+func tail(output []byte) string {
+	output = bytes.TrimSpace(output)
+	if len(output) > maxOutput {
+		output = output[len(output)-maxOutput:]
+	}
+	return string(output)
+}
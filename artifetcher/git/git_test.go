@@ -0,0 +1,451 @@
+package git_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/op/go-logging"
+	"github.com/spf13/afero"
+
+	"github.com/compozed/deployadactyl/artifetcher"
+	E "github.com/compozed/deployadactyl/artifetcher/extractor"
+	. "github.com/compozed/deployadactyl/artifetcher/git"
+	"github.com/compozed/deployadactyl/conformance"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+var _ = Describe("Git", func() {
+	var (
+		fetcher  *Fetcher
+		wrapped  *mocks.Fetcher
+		af       *afero.Afero
+		log      I.DeploymentLogger
+		repo     string
+		workDir  string
+		firstSHA string
+	)
+
+	run := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		Expect(err).ToNot(HaveOccurred(), string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	commit := func(files map[string]string, message string) string {
+		for name, content := range files {
+			path := filepath.Join(repo, filepath.FromSlash(name))
+			Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		}
+		run(repo, "add", "--all")
+		run(repo, "commit", "--quiet", "--message", message)
+		return run(repo, "rev-parse", "HEAD")
+	}
+
+	source := func(ref string) string {
+		return S.GitSource{URL: "file://" + filepath.ToSlash(repo), Ref: ref}.ArtifactURL()
+	}
+
+	fetch := func(ref string) (string, error) {
+		return fetcher.Fetch(context.Background(), source(ref), "")
+	}
+
+	read := func(dir, name string) string {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		Expect(err).ToNot(HaveOccurred())
+		return string(b)
+	}
+
+	BeforeEach(func() {
+		var err error
+		repo, err = ioutil.TempDir("", "git-test-repo-")
+		Expect(err).ToNot(HaveOccurred())
+		workDir, err = ioutil.TempDir("", "git-test-work-")
+		Expect(err).ToNot(HaveOccurred())
+
+		run(repo, "init", "--quiet")
+		run(repo, "checkout", "--quiet", "-b", "main")
+		firstSHA = commit(map[string]string{"index.html": "first"}, "first")
+		run(repo, "tag", "v1")
+		commit(map[string]string{"index.html": "second", "public/index.html": "public"}, "second")
+
+		log = I.DeploymentLogger{Log: I.DefaultLogger(GinkgoWriter, logging.DEBUG, "git_test")}
+		af = &afero.Afero{Fs: afero.NewOsFs()}
+		wrapped = &mocks.Fetcher{}
+		fetcher = NewFetcher(wrapped, af, E.NewExtractor(log, af), log, S.GitDescriptor{Enabled: true}, workDir).(*Fetcher)
+		fetcher.Protocols = []string{"file"}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(repo)
+		os.RemoveAll(workDir)
+	})
+
+	It("passes other artifact URLs to the fetcher it wraps", func() {
+		wrapped.FetchCall.Returns.AppPath = "app-path"
+
+		appPath, err := fetcher.Fetch(context.Background(), "https://example.com/app.zip", "manifest")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(appPath).To(Equal("app-path"))
+		Expect(wrapped.FetchCall.Received.ArtifactURL).To(Equal("https://example.com/app.zip"))
+		Expect(wrapped.FetchCall.Received.Manifest).To(Equal("manifest"))
+	})
+
+	It("passes the zips of the requests to the fetcher it wraps", func() {
+		wrapped.FetchFromZipCall.Returns.AppPath = "app-path"
+		wrapped.FetchFromZipCall.Returns.Manifest = "manifest"
+
+		appPath, manifest, err := fetcher.FetchZipFromRequest(strings.NewReader("zip"))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(appPath).To(Equal("app-path"))
+		Expect(manifest).To(Equal("manifest"))
+	})
+
+	It("checks out a tag", func() {
+		appPath, err := fetch("v1")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read(appPath, "index.html")).To(Equal("first"))
+	})
+
+	It("checks out a branch", func() {
+		appPath, err := fetch("main")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read(appPath, "index.html")).To(Equal("second"))
+	})
+
+	It("checks out a SHA", func() {
+		appPath, err := fetch(firstSHA)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read(appPath, "index.html")).To(Equal("first"))
+	})
+
+	It("checks out an abbreviated SHA", func() {
+		appPath, err := fetch(firstSHA[:10])
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read(appPath, "index.html")).To(Equal("first"))
+	})
+
+	It("does not push the git directory", func() {
+		appPath, err := fetch("main")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filepath.Join(appPath, ".git")).ToNot(BeADirectory())
+	})
+
+	It("writes the manifest of the deployment", func() {
+		appPath, err := fetcher.Fetch(context.Background(), source("main"), "manifest")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read(appPath, "manifest.yml")).To(Equal("manifest"))
+	})
+
+	It("returns an error for a ref the repository does not have", func() {
+		_, err := fetch("v9")
+
+		Expect(err).To(MatchError(RefNotFoundError{"file://" + filepath.ToSlash(repo), "v9"}))
+	})
+
+	It("removes the checkout when it fails", func() {
+		_, err := fetch("v9")
+		Expect(err).To(HaveOccurred())
+
+		Expect(ioutil.ReadDir(workDir)).To(BeEmpty())
+	})
+
+	It("refuses to check out when the environment does not deploy from git", func() {
+		fetcher.Git.Enabled = false
+
+		_, err := fetch("main")
+
+		Expect(err).To(MatchError(DisabledError{}))
+	})
+
+	It("refuses repositories cloned over other protocols", func() {
+		fetcher.Protocols = DefaultProtocols
+
+		_, err := fetch("main")
+
+		Expect(err).To(MatchError(ProtocolError{"file://" + filepath.ToSlash(repo), "file"}))
+	})
+
+	Context("when the environment has git credentials", func() {
+		var (
+			server, other *httptest.Server
+			authorization map[string]string
+			mutex         sync.Mutex
+		)
+
+		record := func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			authorization[r.Host+r.URL.Path] = r.Header.Get("Authorization")
+			mutex.Unlock()
+			http.NotFound(w, r)
+		}
+
+		received := func(s *httptest.Server, path string) (string, bool) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			header, ok := authorization[strings.TrimPrefix(s.URL, "https://")+path]
+			return header, ok
+		}
+
+		BeforeEach(func() {
+			authorization = map[string]string{}
+			other = httptest.NewTLSServer(http.HandlerFunc(record))
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.URL.Path, "/moved/") {
+					http.Redirect(w, r, other.URL+strings.TrimPrefix(r.URL.RequestURI(), "/moved"), http.StatusFound)
+					return
+				}
+				record(w, r)
+			}))
+
+			fetcher.Protocols = DefaultProtocols
+			fetcher.Environ = func() []string { return append(os.Environ(), "GIT_SSL_NO_VERIFY=true") }
+			fetcher.Git.Username = "deployer"
+			fetcher.Git.Password = "secret"
+			fetcher.Git.Repositories = []string{server.URL + "/org/"}
+		})
+
+		AfterEach(func() {
+			server.Close()
+			other.Close()
+		})
+
+		fetchFrom := func(url string) {
+			_, err := fetcher.Fetch(context.Background(), S.GitSource{URL: url, Ref: "main"}.ArtifactURL(), "")
+			Expect(err).To(HaveOccurred())
+		}
+
+		It("sends them to the repositories of the environment", func() {
+			fetchFrom(server.URL + "/org/app.git")
+
+			header, ok := received(server, "/org/app.git/info/refs")
+			Expect(ok).To(BeTrue())
+			Expect(header).To(Equal("Basic ZGVwbG95ZXI6c2VjcmV0"))
+		})
+
+		It("does not send them to other repositories", func() {
+			fetchFrom(server.URL + "/organisation/app.git")
+
+			header, ok := received(server, "/organisation/app.git/info/refs")
+			Expect(ok).To(BeTrue())
+			Expect(header).To(BeEmpty())
+		})
+
+		It("does not send them to the servers the repositories redirect to", func() {
+			fetcher.Git.Repositories = []string{server.URL + "/moved/"}
+
+			fetchFrom(server.URL + "/moved/org/app.git")
+
+			header, ok := received(other, "/org/app.git/info/refs")
+			Expect(ok).To(BeTrue())
+			Expect(header).To(BeEmpty())
+		})
+	})
+
+	It("refuses refs that could be mistaken for options", func() {
+		_, err := fetch("--upload-pack=touch")
+
+		Expect(err).To(MatchError(InvalidRefError{"--upload-pack=touch"}))
+	})
+
+	It("does not check out once the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := fetcher.Fetch(ctx, source("main"), "")
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("when there is a build command", func() {
+		It("runs it in the checkout with the variables of the checkout", func() {
+			fetcher.Git.BuildCommand = []string{"sh", "-c", "echo $DEPLOYADACTYL_GIT_REF $DEPLOYADACTYL_GIT_COMMIT > built"}
+
+			appPath, err := fetch(firstSHA)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(read(appPath, "built")).To(Equal(firstSHA + " " + firstSHA + "\n"))
+		})
+
+		It("returns the output of a failed build", func() {
+			fetcher.Git.BuildCommand = []string{"sh", "-c", "echo compilation failed; exit 1"}
+
+			_, err := fetch("main")
+
+			Expect(err).To(BeAssignableToTypeOf(CommandError{}))
+			Expect(err.Error()).To(ContainSubstring("compilation failed"))
+		})
+
+		It("stops a build that does not finish within its timeout", func() {
+			fetcher.Git.BuildCommand = []string{"sleep", "5"}
+			fetcher.Git.BuildTimeoutSeconds = 1
+
+			_, err := fetch("main")
+
+			Expect(err).To(BeAssignableToTypeOf(BuildTimeoutError{}))
+		})
+	})
+
+	It("refuses a checkout with a symlink pointing outside of it", func() {
+		Expect(os.MkdirAll(filepath.Join(repo, "config"), 0755)).To(Succeed())
+		Expect(os.Symlink("/etc/passwd", filepath.Join(repo, "config", "passwd"))).To(Succeed())
+		commit(nil, "link")
+
+		_, err := fetch("main")
+
+		Expect(err).To(MatchError(SymlinkEscapeError{"config/passwd"}))
+	})
+
+	Context("when there is a path", func() {
+		It("pushes the directory at the path", func() {
+			fetcher.Git.Path = "public"
+
+			appPath, err := fetcher.Fetch(context.Background(), source("main"), "manifest")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(read(appPath, "index.html")).To(Equal("public"))
+			Expect(read(appPath, "manifest.yml")).To(Equal("manifest"))
+			Expect(ioutil.ReadDir(workDir)).To(HaveLen(1))
+		})
+
+		It("extracts the archive the build makes at the path", func() {
+			b, err := conformance.Zip(map[string]string{"app.class": "built"})
+			Expect(err).ToNot(HaveOccurred())
+			commit(map[string]string{"dist/app.jar": string(b)}, "jar")
+
+			fetcher.Git.BuildCommand = []string{"sh", "-c", "mkdir target && cp dist/app.jar target/app.jar"}
+			fetcher.Git.Path = "target/app.jar"
+
+			appPath, err := fetch("main")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(read(appPath, "app.class")).To(Equal("built"))
+			Expect(ioutil.ReadDir(workDir)).To(HaveLen(1))
+		})
+
+		It("pushes the directory a symlink at the path points to within the checkout", func() {
+			Expect(os.Symlink("public", filepath.Join(repo, "current"))).To(Succeed())
+			commit(nil, "link")
+			fetcher.Git.Path = "current"
+
+			appPath, err := fetch("main")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(read(appPath, "index.html")).To(Equal("public"))
+		})
+
+		It("refuses a path that is a symlink to a directory outside of the checkout", func() {
+			outside, err := ioutil.TempDir("", "git-test-outside-")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(outside)
+			Expect(ioutil.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644)).To(Succeed())
+
+			Expect(os.Symlink(outside, filepath.Join(repo, "app"))).To(Succeed())
+			commit(nil, "link")
+			fetcher.Git.Path = "app"
+
+			_, err = fetch("main")
+
+			Expect(err).To(MatchError(PathEscapeError{"app"}))
+			Expect(read(outside, "secret")).To(Equal("secret"))
+		})
+
+		It("returns an error when the checkout has nothing at the path", func() {
+			fetcher.Git.Path = "target/app.jar"
+
+			_, err := fetch("main")
+
+			Expect(err).To(MatchError(PathNotFoundError{"target/app.jar"}))
+		})
+	})
+
+	Describe("Protocol", func() {
+		It("returns the protocol git clones a repository over", func() {
+			Expect(Protocol("https://github.com/example/app.git")).To(Equal("https"))
+			Expect(Protocol("ssh://git@github.com/example/app.git")).To(Equal("ssh"))
+			Expect(Protocol("git@github.com:example/app.git")).To(Equal("ssh"))
+			Expect(Protocol("ext::sh -c touch% /tmp/pwned")).To(Equal("ext"))
+			Expect(Protocol("/var/repos/app.git")).To(Equal("file"))
+			Expect(Protocol("./app")).To(Equal("file"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts a path within the checkout", func() {
+			Expect(Validate(S.GitDescriptor{Path: "target/app.jar"})).To(Succeed())
+		})
+
+		It("refuses a path outside of the checkout", func() {
+			Expect(Validate(S.GitDescriptor{Path: "../app.jar"})).To(MatchError(InvalidPathError{"../app.jar"}))
+			Expect(Validate(S.GitDescriptor{Path: "/etc"})).To(MatchError(InvalidPathError{"/etc"}))
+		})
+
+		It("refuses a negative build timeout", func() {
+			Expect(Validate(S.GitDescriptor{BuildTimeoutSeconds: -1})).To(MatchError(InvalidBuildTimeoutError{-1}))
+		})
+
+		It("refuses credentials without repositories to send them to", func() {
+			Expect(Validate(S.GitDescriptor{Username: "deployer", Password: "secret"})).To(MatchError(CredentialsWithoutRepositoriesError{}))
+			Expect(Validate(S.GitDescriptor{Username: "deployer", Repositories: []string{"https://git.example.com/org"}})).To(Succeed())
+		})
+
+		It("refuses repositories that are not https URLs", func() {
+			for _, repository := range []string{"http://git.example.com/org", "git.example.com/org", "https://", "https://user@git.example.com/org"} {
+				Expect(Validate(S.GitDescriptor{Repositories: []string{repository}})).To(MatchError(InvalidRepositoryError{repository}))
+			}
+		})
+	})
+
+	Describe("Serves", func() {
+		It("serves the URLs under the repositories", func() {
+			descriptor := S.GitDescriptor{Repositories: []string{"https://git.example.com/org/"}}
+
+			repository, ok := Serves(descriptor, "https://git.example.com/org/app.git")
+			Expect(ok).To(BeTrue())
+			Expect(repository).To(Equal("https://git.example.com/org"))
+
+			for _, url := range []string{"https://git.example.com/organisation/app.git", "https://git.example.com.attacker.com/org/app.git", "https://attacker.com/https://git.example.com/org/app.git"} {
+				_, ok := Serves(descriptor, url)
+				Expect(ok).To(BeFalse(), url)
+			}
+		})
+	})
+
+	It("satisfies the Fetcher contract", func() {
+		files := map[string]string{"index.html": "hello", "lib/app.jar": "app"}
+		commit(files, "contract")
+		run(repo, "tag", "contract")
+
+		fetcher.Fetcher = artifetcher.NewArtifetcher(af, E.NewExtractor(log, af), log, nil, "", S.BucketsDescriptor{}, S.ArtifactoryDescriptor{}, S.MavenDescriptor{}, "")
+
+		conformance.Fetcher(GinkgoT(), conformance.FetcherSubject{
+			Fetcher:     fetcher,
+			FileSystem:  af,
+			ArtifactURL: source("contract"),
+			Files:       files,
+			MissingURL:  source("missing"),
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package maven
+
+import "fmt"
+
+type InvalidRepositoryError struct {
+	URL string
+}
+
+func (e InvalidRepositoryError) Error() string {
+	return fmt.Sprintf("a maven repository must be an http, https, s3 or gs url: %s", e.URL)
+}
+
+type NoRepositoriesError struct{}
+
+func (e NoRepositoriesError) Error() string {
+	return "the environment has no maven repositories"
+}
+
+type InvalidCoordinatesError struct {
+	Coordinates string
+}
+
+func (e InvalidCoordinatesError) Error() string {
+	return fmt.Sprintf("invalid maven coordinates %s: they need a group_id, an artifact_id and a version", e.Coordinates)
+}
+
+type NotFoundError struct {
+	Coordinates string
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("%s is not in any maven repository of the environment", e.Coordinates)
+}
+
+type MetadataError struct {
+	URL string
+	Err error
+}
+
+func (e MetadataError) Error() string {
+	return fmt.Sprintf("cannot read the maven metadata %s: %s", e.URL, e.Err)
+}
+
+type RequestError struct {
+	URL string
+	Err error
+}
+
+func (e RequestError) Error() string {
+	return fmt.Sprintf("cannot get %s: %s", e.URL, e.Err)
+}
+
+type StatusError struct {
+	Status string
+}
+
+func (e StatusError) Error() string {
+	return e.Status
+}
@@ -0,0 +1,231 @@
+// Package maven resolves the Maven coordinates of deployments to the URL of their jar or war in the Maven
+// repositories of the environment, reading the maven-metadata.xml of the repositories for RELEASE, LATEST and
+// SNAPSHOT versions.
+package maven
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// The versions resolved to the newest release, and to the newest version including snapshots.
+const (
+	Release = "RELEASE"
+	Latest  = "LATEST"
+)
+
+// DefaultPackaging is the extension of the artifacts whose coordinates have no packaging.
+const DefaultPackaging = "jar"
+
+// snapshotSuffix ends the versions of snapshots, which are resolved to the file of their newest build.
+const snapshotSuffix = "-SNAPSHOT"
+
+// metadataFile is the file describing the versions of an artifact, or the builds of a snapshot.
+const metadataFile = "maven-metadata.xml"
+
+// maxMetadataSize is the most that is read of a metadata file.
+const maxMetadataSize = 1 << 20
+
+// metadata is the part of a maven-metadata.xml that is needed to resolve a version.
+type metadata struct {
+	Versioning struct {
+		Latest   string   `xml:"latest"`
+		Release  string   `xml:"release"`
+		Versions []string `xml:"versions>version"`
+		Snapshot struct {
+			Timestamp   string `xml:"timestamp"`
+			BuildNumber string `xml:"buildNumber"`
+		} `xml:"snapshot"`
+		SnapshotVersions []struct {
+			Classifier string `xml:"classifier"`
+			Extension  string `xml:"extension"`
+			Value      string `xml:"value"`
+		} `xml:"snapshotVersions>snapshotVersion"`
+	} `xml:"versioning"`
+}
+
+// Validate returns an error when a repository is not an http, https, s3 or gs URL.
+func Validate(descriptor S.MavenDescriptor) error {
+	for _, repository := range descriptor.Repositories {
+		u, err := url.Parse(repository)
+		if err != nil || u.Host == "" {
+			return InvalidRepositoryError{repository}
+		}
+		switch u.Scheme {
+		case "http", "https", "s3", "gs":
+		default:
+			return InvalidRepositoryError{repository}
+		}
+	}
+	return nil
+}
+
+// Authorize adds the basic auth of the descriptor to the request when it is sent to one of its repositories.
+func Authorize(request *http.Request, descriptor S.MavenDescriptor) {
+	if descriptor.Username == "" {
+		return
+	}
+
+	for _, repository := range descriptor.Repositories {
+		if strings.HasPrefix(request.URL.String(), strings.TrimSuffix(repository, "/")+"/") {
+			request.SetBasicAuth(descriptor.Username, descriptor.Password)
+			return
+		}
+	}
+}
+
+// Resolve returns the URL of the file of the coordinates in the first repository of the descriptor that has it.
+func Resolve(ctx context.Context, client *http.Client, descriptor S.MavenDescriptor, coordinates S.MavenCoordinates) (string, error) {
+	if len(descriptor.Repositories) == 0 {
+		return "", NoRepositoriesError{}
+	}
+	if coordinates.GroupID == "" || coordinates.ArtifactID == "" || coordinates.Version == "" {
+		return "", InvalidCoordinatesError{coordinates.ArtifactURL()}
+	}
+
+	r := resolver{ctx: ctx, client: client, descriptor: descriptor, coordinates: coordinates}
+	for _, repository := range descriptor.Repositories {
+		fileURL, found, err := r.resolve(strings.TrimSuffix(repository, "/"))
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return fileURL, nil
+		}
+	}
+	return "", NotFoundError{coordinates.ArtifactURL()}
+}
+
+// resolver finds the file of the coordinates in a repository.
+type resolver struct {
+	ctx         context.Context
+	client      *http.Client
+	descriptor  S.MavenDescriptor
+	coordinates S.MavenCoordinates
+}
+
+// resolve returns the URL of the file in the repository, and false when the repository does not have it.
+func (r resolver) resolve(repository string) (string, bool, error) {
+	artifactURL := repository + "/" + strings.Replace(r.coordinates.GroupID, ".", "/", -1) + "/" + r.coordinates.ArtifactID
+
+	version := r.coordinates.Version
+	if version == Release || version == Latest {
+		var m metadata
+		found, err := r.get(artifactURL+"/"+metadataFile, &m)
+		if err != nil || !found {
+			return "", found, err
+		}
+
+		version = m.Versioning.Release
+		if r.coordinates.Version == Latest {
+			version = m.Versioning.Latest
+			if version == "" && len(m.Versioning.Versions) > 0 {
+				version = m.Versioning.Versions[len(m.Versioning.Versions)-1]
+			}
+		}
+		if version == "" {
+			return "", false, nil
+		}
+	}
+
+	fileVersion := version
+	if strings.HasSuffix(version, snapshotSuffix) {
+		var m metadata
+		found, err := r.get(artifactURL+"/"+version+"/"+metadataFile, &m)
+		if err != nil {
+			return "", false, err
+		}
+		if found {
+			fileVersion = r.snapshotVersion(version, m)
+		}
+	}
+
+	name := r.coordinates.ArtifactID + "-" + fileVersion
+	if r.coordinates.Classifier != "" {
+		name += "-" + r.coordinates.Classifier
+	}
+	fileURL := artifactURL + "/" + version + "/" + name + "." + r.packaging()
+
+	found, err := r.exists(fileURL)
+	return fileURL, found, err
+}
+
+// snapshotVersion returns the version of the file of the newest build of the snapshot, such as
+// 1.2.0-20261001.120000-3, or the snapshot version when the metadata does not describe a build.
+func (r resolver) snapshotVersion(version string, m metadata) string {
+	for _, snapshot := range m.Versioning.SnapshotVersions {
+		if snapshot.Extension == r.packaging() && snapshot.Classifier == r.coordinates.Classifier && snapshot.Value != "" {
+			return snapshot.Value
+		}
+	}
+
+	build := m.Versioning.Snapshot
+	if build.Timestamp != "" && build.BuildNumber != "" {
+		return strings.TrimSuffix(version, snapshotSuffix) + "-" + build.Timestamp + "-" + build.BuildNumber
+	}
+	return version
+}
+
+func (r resolver) packaging() string {
+	if r.coordinates.Packaging == "" {
+		return DefaultPackaging
+	}
+	return r.coordinates.Packaging
+}
+
+// get decodes the metadata file at the URL into m, and returns false when it does not exist.
+func (r resolver) get(fileURL string, m *metadata) (bool, error) {
+	response, found, err := r.do("GET", fileURL)
+	if err != nil || !found {
+		return found, err
+	}
+	defer response.Body.Close()
+
+	err = xml.NewDecoder(io.LimitReader(response.Body, maxMetadataSize)).Decode(m)
+	if err != nil {
+		return false, MetadataError{URL: fileURL, Err: err}
+	}
+	return true, nil
+}
+
+// exists returns whether the repository has the file at the URL.
+func (r resolver) exists(fileURL string) (bool, error) {
+	response, found, err := r.do("HEAD", fileURL)
+	if err != nil || !found {
+		return found, err
+	}
+	response.Body.Close()
+	return true, nil
+}
+
+// do sends the request and returns its response, or false when the repository does not have the file.
+func (r resolver) do(method, fileURL string) (*http.Response, bool, error) {
+	request, err := http.NewRequest(method, fileURL, nil)
+	if err != nil {
+		return nil, false, RequestError{URL: fileURL, Err: err}
+	}
+	request = request.WithContext(r.ctx)
+	Authorize(request, r.descriptor)
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, false, RequestError{URL: fileURL, Err: err}
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return response, true, nil
+	case http.StatusNotFound:
+		response.Body.Close()
+		return nil, false, nil
+	default:
+		response.Body.Close()
+		return nil, false, RequestError{URL: fileURL, Err: StatusError{response.Status}}
+	}
+}
@@ -0,0 +1,13 @@
+package maven_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestMaven(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Maven Suite")
+}
@@ -0,0 +1,208 @@
+package maven_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/compozed/deployadactyl/artifetcher/maven"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const artifactMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <versioning>
+    <latest>1.3.0-SNAPSHOT</latest>
+    <release>1.2.0</release>
+    <versions>
+      <version>1.1.0</version>
+      <version>1.2.0</version>
+      <version>1.3.0-SNAPSHOT</version>
+    </versions>
+  </versioning>
+</metadata>`
+
+const snapshotMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata modelVersion="1.1.0">
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.3.0-SNAPSHOT</version>
+  <versioning>
+    <snapshot>
+      <timestamp>20261001.120000</timestamp>
+      <buildNumber>3</buildNumber>
+    </snapshot>
+    <snapshotVersions>
+      <snapshotVersion>
+        <extension>jar</extension>
+        <value>1.3.0-20261001.120000-3</value>
+      </snapshotVersion>
+      <snapshotVersion>
+        <extension>war</extension>
+        <value>1.3.0-20261001.115500-2</value>
+      </snapshotVersion>
+    </snapshotVersions>
+  </versioning>
+</metadata>`
+
+// repository serves its files, and keeps the requests it gets.
+type repository struct {
+	*httptest.Server
+	files    map[string]string
+	requests []*http.Request
+}
+
+func newRepository(files map[string]string) *repository {
+	r := &repository{files: files}
+	r.Server = httptest.NewServer(r)
+	return r
+}
+
+func (r *repository) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	r.requests = append(r.requests, request)
+
+	file, ok := r.files[request.URL.Path]
+	if !ok {
+		http.NotFound(w, request)
+		return
+	}
+	w.Write([]byte(file))
+}
+
+var _ = Describe("Maven", func() {
+	var (
+		central    *repository
+		internal   *repository
+		descriptor S.MavenDescriptor
+	)
+
+	BeforeEach(func() {
+		central = newRepository(map[string]string{
+			"/maven2/com/example/app/maven-metadata.xml":                             artifactMetadata,
+			"/maven2/com/example/app/1.2.0/app-1.2.0.jar":                            "jar",
+			"/maven2/com/example/app/1.2.0/app-1.2.0-exec.jar":                       "exec jar",
+			"/maven2/com/example/app/1.3.0-SNAPSHOT/maven-metadata.xml":              snapshotMetadata,
+			"/maven2/com/example/app/1.3.0-SNAPSHOT/app-1.3.0-20261001.120000-3.jar": "snapshot jar",
+		})
+		internal = newRepository(map[string]string{
+			"/releases/com/example/app/1.1.0/app-1.1.0.war": "war",
+		})
+		descriptor = S.MavenDescriptor{Repositories: []string{internal.URL + "/releases/", central.URL + "/maven2"}}
+	})
+
+	AfterEach(func() {
+		central.Close()
+		internal.Close()
+	})
+
+	resolve := func(coordinates S.MavenCoordinates) (string, error) {
+		return Resolve(context.Background(), http.DefaultClient, descriptor, coordinates)
+	}
+
+	It("resolves a version to its jar", func() {
+		url, err := resolve(S.MavenCoordinates{GroupID: "com.example", ArtifactID: "app", Version: "1.2.0"})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal(central.URL + "/maven2/com/example/app/1.2.0/app-1.2.0.jar"))
+	})
+
+	It("resolves the packaging and classifier", func() {
+		url, err := resolve(S.MavenCoordinates{GroupID: "com.example", ArtifactID: "app", Version: "1.2.0", Classifier: "exec"})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal(central.URL + "/maven2/com/example/app/1.2.0/app-1.2.0-exec.jar"))
+	})
+
+	It("uses the first repository that has the artifact", func() {
+		url, err := resolve(S.MavenCoordinates{GroupID: "com.example", ArtifactID: "app", Version: "1.1.0", Packaging: "war"})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal(internal.URL + "/releases/com/example/app/1.1.0/app-1.1.0.war"))
+	})
+
+	It("resolves RELEASE to the newest release of the metadata", func() {
+		url, err := resolve(S.MavenCoordinates{GroupID: "com.example", ArtifactID: "app", Version: Release})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal(central.URL + "/maven2/com/example/app/1.2.0/app-1.2.0.jar"))
+	})
+
+	It("resolves LATEST to the newest build of the newest snapshot", func() {
+		url, err := resolve(S.MavenCoordinates{GroupID: "com.example", ArtifactID: "app", Version: Latest})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal(central.URL + "/maven2/com/example/app/1.3.0-SNAPSHOT/app-1.3.0-20261001.120000-3.jar"))
+	})
+
+	It("returns an error when no repository has the artifact", func() {
+		_, err := resolve(S.MavenCoordinates{GroupID: "com.example", ArtifactID: "app", Version: "9.9.9"})
+
+		Expect(err).To(MatchError(NotFoundError{"maven:com.example:app:9.9.9"}))
+	})
+
+	It("returns an error when the environment has no repositories", func() {
+		descriptor.Repositories = nil
+
+		_, err := resolve(S.MavenCoordinates{GroupID: "com.example", ArtifactID: "app", Version: "1.2.0"})
+
+		Expect(err).To(MatchError(NoRepositoriesError{}))
+	})
+
+	It("returns an error when a repository fails", func() {
+		central.files["/maven2/com/example/app/maven-metadata.xml"] = "<metadata><versioning>"
+
+		_, err := resolve(S.MavenCoordinates{GroupID: "com.example", ArtifactID: "app", Version: Release})
+
+		Expect(err).To(BeAssignableToTypeOf(MetadataError{}))
+	})
+
+	It("sends the basic auth of the repositories", func() {
+		descriptor.Username = "deployer"
+		descriptor.Password = "secret"
+
+		_, err := resolve(S.MavenCoordinates{GroupID: "com.example", ArtifactID: "app", Version: Release})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(central.requests).ToNot(BeEmpty())
+		for _, request := range append(internal.requests, central.requests...) {
+			username, password, ok := request.BasicAuth()
+			Expect(ok).To(BeTrue())
+			Expect(username).To(Equal("deployer"))
+			Expect(password).To(Equal("secret"))
+		}
+	})
+
+	It("does not send the basic auth to other servers", func() {
+		descriptor.Username = "deployer"
+		request, _ := http.NewRequest("GET", "https://example.com/maven2/app.jar", nil)
+
+		Authorize(request, descriptor)
+
+		Expect(request.Header.Get("Authorization")).To(BeEmpty())
+	})
+
+	It("validates the repositories", func() {
+		Expect(Validate(descriptor)).To(Succeed())
+		Expect(Validate(S.MavenDescriptor{Repositories: []string{"s3://maven-releases/releases"}})).To(Succeed())
+		Expect(Validate(S.MavenDescriptor{Repositories: []string{"file:///var/maven"}})).To(MatchError(InvalidRepositoryError{"file:///var/maven"}))
+	})
+
+	It("round trips the coordinates through their artifact url", func() {
+		for _, coordinates := range []S.MavenCoordinates{
+			{GroupID: "com.example", ArtifactID: "app", Version: "1.2.0"},
+			{GroupID: "com.example", ArtifactID: "app", Version: "1.2.0", Packaging: "war"},
+			{GroupID: "com.example", ArtifactID: "app", Version: "1.2.0", Classifier: "exec"},
+		} {
+			parsed, ok := S.ParseMavenArtifactURL(coordinates.ArtifactURL())
+
+			Expect(ok).To(BeTrue())
+			Expect(parsed).To(Equal(coordinates))
+			Expect(strings.HasPrefix(coordinates.ArtifactURL(), S.MavenScheme)).To(BeTrue())
+		}
+	})
+})
@@ -0,0 +1,56 @@
+package artifetcher
+
+import (
+	"io"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// ProgressFunc is called while an artifact is written to disk with the number of bytes written so far
+// and the total size of the artifact, which is -1 when the size is not known.
+type ProgressFunc func(written, total int64)
+
+const progressLogInterval = 100 * 1024 * 1024
+
+// LogProgress returns a ProgressFunc that logs every 100MB written.
+func LogProgress(log I.DeploymentLogger) ProgressFunc {
+	var next int64 = progressLogInterval
+
+	return func(written, total int64) {
+		if written < next {
+			return
+		}
+		next = written - written%progressLogInterval + progressLogInterval
+
+		if total > 0 {
+			log.Infof("fetched %d of %d MB", written/(1024*1024), total/(1024*1024))
+		} else {
+			log.Infof("fetched %d MB", written/(1024*1024))
+		}
+	}
+}
+
+// progressReader reports the number of bytes read through it to a ProgressFunc.
+type progressReader struct {
+	reader   io.Reader
+	total    int64
+	written  int64
+	progress ProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.written += int64(n)
+		r.progress(r.written, r.total)
+	}
+	return n, err
+}
+
+// copyToFile streams body into file without holding the artifact in memory.
+func (a *Artifetcher) copyToFile(file io.Writer, body io.Reader, total int64) (int64, error) {
+	if a.Progress != nil {
+		body = &progressReader{reader: body, total: total, progress: a.Progress}
+	}
+	return io.Copy(file, body)
+}
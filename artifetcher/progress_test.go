@@ -0,0 +1,46 @@
+package artifetcher_test
+
+import (
+	. "github.com/compozed/deployadactyl/artifetcher"
+	"github.com/compozed/deployadactyl/interfaces"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("LogProgress", func() {
+	var (
+		logBuffer *Buffer
+		progress  ProgressFunc
+	)
+
+	const mb = 1024 * 1024
+
+	BeforeEach(func() {
+		logBuffer = NewBuffer()
+		log := interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(logBuffer, logging.DEBUG, "progress_test")}
+		progress = LogProgress(log)
+	})
+
+	It("logs every 100MB written", func() {
+		progress(50*mb, 250*mb)
+		Expect(logBuffer.Contents()).To(BeEmpty())
+
+		progress(120*mb, 250*mb)
+		Expect(logBuffer).To(Say("fetched 120 of 250 MB"))
+
+		progress(150*mb, 250*mb)
+		Expect(logBuffer.Contents()).ToNot(ContainSubstring("fetched 150"))
+
+		progress(210*mb, 250*mb)
+		Expect(logBuffer).To(Say("fetched 210 of 250 MB"))
+	})
+
+	It("logs without a total when the size is unknown", func() {
+		progress(100*mb, -1)
+
+		Expect(logBuffer).To(Say("fetched 100 MB"))
+	})
+})
@@ -0,0 +1,19 @@
+package sbom
+
+import "fmt"
+
+type DigestError struct {
+	Err error
+}
+
+func (e DigestError) Error() string {
+	return fmt.Sprintf("cannot compute the artifact digest: %s", e.Err)
+}
+
+type GenerateError struct {
+	Err error
+}
+
+func (e GenerateError) Error() string {
+	return fmt.Sprintf("cannot find the components of the artifact: %s", e.Err)
+}
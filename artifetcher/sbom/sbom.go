@@ -0,0 +1,190 @@
+// Package sbom finds the components and computes the digest of extracted artifacts.
+package sbom
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// archiveName matches library archives named like log4j-core-2.14.1.jar.
+var archiveName = regexp.MustCompile(`^(.+?)-(\d[\w.\-]*)\.(jar|war)$`)
+
+type GeneratorConstructor func(log I.DeploymentLogger, fs *afero.Afero) I.SBOMGenerator
+
+func NewGenerator(log I.DeploymentLogger, fs *afero.Afero) I.SBOMGenerator {
+	return &Generator{
+		Log:        log,
+		FileSystem: fs,
+	}
+}
+
+// Generator walks an extracted artifact to find the libraries it contains.
+//
+// Java libraries are found from jar and war file names and from the pom.properties files Maven
+// adds to META-INF. Node packages are found from the package.json files in node_modules.
+type Generator struct {
+	Log        I.DeploymentLogger
+	FileSystem *afero.Afero
+}
+
+// Digest returns the sha256 digest of the extracted artifact.
+//
+// The digest covers the path and contents of every file in path order, so the same artifact
+// always has the same digest no matter the order the files were extracted in.
+func (g *Generator) Digest(appPath string) (string, error) {
+	files := []string{}
+	err := g.FileSystem.Walk(appPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files = append(files, filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", DigestError{Err: err}
+	}
+	sort.Strings(files)
+
+	digest := sha256.New()
+	for _, filePath := range files {
+		fileDigest, err := g.fileDigest(filePath)
+		if err != nil {
+			return "", DigestError{Err: err}
+		}
+		fmt.Fprintf(digest, "%s\x00%s\n", relative(appPath, filePath), fileDigest)
+	}
+
+	return "sha256:" + hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// Generate returns the components found in the extracted artifact.
+func (g *Generator) Generate(appPath string) (S.SBOM, error) {
+	g.Log.Debug("finding the components of the artifact")
+
+	components := []S.Component{}
+	err := g.FileSystem.Walk(appPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		var component *S.Component
+		switch name := info.Name(); {
+		case archiveName.MatchString(name):
+			match := archiveName.FindStringSubmatch(name)
+			component = &S.Component{Type: "maven", Name: match[1], Version: match[2]}
+		case name == "pom.properties" && strings.Contains(filepath.ToSlash(filePath), "META-INF/maven/"):
+			component, err = g.pomProperties(filePath)
+		case name == "package.json" && strings.Contains(filepath.ToSlash(filePath), "node_modules/"):
+			component, err = g.packageJSON(filePath)
+		}
+		if err != nil {
+			g.Log.Debugf("skipping %s: %s", filePath, err)
+			return nil
+		}
+
+		if component != nil {
+			component.Path = relative(appPath, filePath)
+			components = append(components, *component)
+		}
+		return nil
+	})
+	if err != nil {
+		return S.SBOM{}, GenerateError{Err: err}
+	}
+
+	g.Log.Infof("found %d components in the artifact", len(components))
+	return S.SBOM{Generated: true, Components: components}, nil
+}
+
+func (g *Generator) fileDigest(filePath string) (string, error) {
+	file, err := g.FileSystem.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	digest := sha256.New()
+	_, err = io.Copy(digest, file)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+func (g *Generator) pomProperties(filePath string) (*S.Component, error) {
+	file, err := g.FileSystem.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	properties := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pair := strings.SplitN(line, "=", 2)
+		if len(pair) == 2 {
+			properties[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+		}
+	}
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
+	}
+
+	if properties["artifactId"] == "" || properties["version"] == "" {
+		return nil, nil
+	}
+	return &S.Component{Type: "maven", Group: properties["groupId"], Name: properties["artifactId"], Version: properties["version"]}, nil
+}
+
+func (g *Generator) packageJSON(filePath string) (*S.Component, error) {
+	contents, err := g.FileSystem.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	err = json.Unmarshal(contents, &pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	if pkg.Name == "" || pkg.Version == "" {
+		return nil, nil
+	}
+	return &S.Component{Type: "npm", Name: pkg.Name, Version: pkg.Version}, nil
+}
+
+func relative(appPath, filePath string) string {
+	rel, err := filepath.Rel(appPath, filePath)
+	if err != nil {
+		return filePath
+	}
+	return path.Clean(filepath.ToSlash(rel))
+}
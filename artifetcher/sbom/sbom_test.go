@@ -0,0 +1,112 @@
+package sbom_test
+
+import (
+	"path"
+
+	. "github.com/compozed/deployadactyl/artifetcher/sbom"
+	"github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/op/go-logging"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Generator", func() {
+	var (
+		af        *afero.Afero
+		appPath   string
+		generator *Generator
+	)
+
+	write := func(name, contents string) {
+		Expect(af.MkdirAll(path.Dir(path.Join(appPath, name)), 0755)).To(Succeed())
+		Expect(af.WriteFile(path.Join(appPath, name), []byte(contents), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		af = &afero.Afero{Fs: afero.NewMemMapFs()}
+		appPath = "/tmp/app"
+		generator = &Generator{
+			Log:        interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(GinkgoWriter, logging.DEBUG, "sbom_test")},
+			FileSystem: af,
+		}
+
+		write("index.html", "<html></html>")
+	})
+
+	Describe("Generate", func() {
+		It("finds jars by name", func() {
+			write("BOOT-INF/lib/log4j-core-2.14.1.jar", "jar")
+			write("BOOT-INF/lib/spring-boot-2.5.0-RELEASE.jar", "jar")
+
+			sbom, err := generator.Generate(appPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(sbom.Generated).To(BeTrue())
+			Expect(sbom.Components).To(ConsistOf(
+				S.Component{Type: "maven", Name: "log4j-core", Version: "2.14.1", Path: "BOOT-INF/lib/log4j-core-2.14.1.jar"},
+				S.Component{Type: "maven", Name: "spring-boot", Version: "2.5.0-RELEASE", Path: "BOOT-INF/lib/spring-boot-2.5.0-RELEASE.jar"},
+			))
+		})
+
+		It("finds maven pom.properties", func() {
+			write("META-INF/maven/org.apache.logging.log4j/log4j-api/pom.properties", "#Created by Maven\ngroupId=org.apache.logging.log4j\nartifactId=log4j-api\nversion=2.14.1\n")
+
+			sbom, err := generator.Generate(appPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(sbom.Components).To(ConsistOf(S.Component{
+				Type:    "maven",
+				Group:   "org.apache.logging.log4j",
+				Name:    "log4j-api",
+				Version: "2.14.1",
+				Path:    "META-INF/maven/org.apache.logging.log4j/log4j-api/pom.properties",
+			}))
+		})
+
+		It("finds node modules", func() {
+			write("node_modules/lodash/package.json", `{"name": "lodash", "version": "4.17.20"}`)
+			write("package.json", `{"name": "my-app", "version": "1.0.0"}`)
+
+			sbom, err := generator.Generate(appPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(sbom.Components).To(ConsistOf(S.Component{Type: "npm", Name: "lodash", Version: "4.17.20", Path: "node_modules/lodash/package.json"}))
+		})
+
+		It("skips files it can not parse", func() {
+			write("node_modules/broken/package.json", `{`)
+
+			sbom, err := generator.Generate(appPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(sbom.Components).To(BeEmpty())
+		})
+	})
+
+	Describe("Digest", func() {
+		It("returns the same digest for the same files", func() {
+			write("lib/a.txt", "a")
+
+			first, err := generator.Digest(appPath)
+			Expect(err).ToNot(HaveOccurred())
+			second, err := generator.Digest(appPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(first).To(HavePrefix("sha256:"))
+			Expect(first).To(HaveLen(len("sha256:") + 64))
+			Expect(first).To(Equal(second))
+		})
+
+		It("changes when a file changes", func() {
+			write("lib/a.txt", "a")
+			before, _ := generator.Digest(appPath)
+
+			write("lib/a.txt", "b")
+			after, _ := generator.Digest(appPath)
+
+			Expect(after).ToNot(Equal(before))
+		})
+	})
+})
@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+)
+
+// clamdChunkSize is the size of the chunks streamed to clamd, well below its default StreamMaxLength.
+const clamdChunkSize = 32 * 1024
+
+// clamdScanner returns a fileScanner that streams files to a clamd daemon with the INSTREAM command.
+// Addresses starting with a slash are unix sockets, anything else is a TCP host:port.
+func clamdScanner(address string) fileScanner {
+	network := "tcp"
+	if strings.HasPrefix(address, "/") {
+		network = "unix"
+	}
+
+	return func(ctx context.Context, contents io.Reader) (string, error) {
+		dialer := &net.Dialer{}
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+
+		_, err = conn.Write([]byte("zINSTREAM\x00"))
+		if err != nil {
+			return "", err
+		}
+
+		chunk := make([]byte, clamdChunkSize)
+		size := make([]byte, 4)
+		for {
+			n, err := contents.Read(chunk)
+			if n > 0 {
+				binary.BigEndian.PutUint32(size, uint32(n))
+				if _, err := conn.Write(size); err != nil {
+					return "", err
+				}
+				if _, err := conn.Write(chunk[:n]); err != nil {
+					return "", err
+				}
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+
+		binary.BigEndian.PutUint32(size, 0)
+		_, err = conn.Write(size)
+		if err != nil {
+			return "", err
+		}
+
+		reply, err := bufio.NewReader(conn).ReadString(0)
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+
+		return parseClamdReply(reply)
+	}
+}
+
+// parseClamdReply parses replies such as "stream: OK" and "stream: Eicar-Signature FOUND".
+func parseClamdReply(reply string) (string, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case reply == "OK":
+		return "", nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return strings.TrimSuffix(reply, " FOUND"), nil
+	}
+
+	return "", ClamdError{Reply: reply}
+}
@@ -0,0 +1,39 @@
+package scanner
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// findingsExitCode is the exit code scanners like clamscan use to report findings.
+// Any other non-zero exit code means the scan itself failed.
+const findingsExitCode = 1
+
+func (s *Scanner) scanWithCommand(ctx context.Context, command []string, appPath string) ([]S.ScanFinding, error) {
+	if len(command) == 0 {
+		return nil, MissingCommandError{}
+	}
+
+	args := append(append([]string{}, command[1:]...), appPath)
+	out, err := exec.CommandContext(ctx, command[0], args...).CombinedOutput()
+	if err == nil {
+		return nil, nil
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok && ctx.Err() == nil && exitCode(exitErr) == findingsExitCode {
+		return []S.ScanFinding{{Path: ".", Description: strings.TrimSpace(string(out))}}, nil
+	}
+
+	return nil, CommandError{Command: command[0], Err: err, Out: out}
+}
+
+func exitCode(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok {
+		return status.ExitStatus()
+	}
+	return -1
+}
@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"fmt"
+	"time"
+)
+
+type UnknownScannerError struct {
+	Type string
+}
+
+func (e UnknownScannerError) Error() string {
+	return fmt.Sprintf("unknown scanner type: %s: must be command, clamav or icap", e.Type)
+}
+
+type MissingCommandError struct{}
+
+func (e MissingCommandError) Error() string {
+	return "command scanner has no command"
+}
+
+type MissingAddressError struct {
+	Type string
+}
+
+func (e MissingAddressError) Error() string {
+	return fmt.Sprintf("%s scanner has no address", e.Type)
+}
+
+type InvalidAddressError struct {
+	Type    string
+	Address string
+}
+
+func (e InvalidAddressError) Error() string {
+	return fmt.Sprintf("invalid %s scanner address: %s", e.Type, e.Address)
+}
+
+type CommandError struct {
+	Command string
+	Err     error
+	Out     []byte
+}
+
+func (e CommandError) Error() string {
+	return fmt.Sprintf("scanner command %s failed: %s: %s", e.Command, e.Err, string(e.Out))
+}
+
+type ScanFileError struct {
+	Path string
+	Err  error
+}
+
+func (e ScanFileError) Error() string {
+	return fmt.Sprintf("cannot scan %s: %s", e.Path, e.Err)
+}
+
+type ClamdError struct {
+	Reply string
+}
+
+func (e ClamdError) Error() string {
+	return fmt.Sprintf("unexpected reply from clamd: %s", e.Reply)
+}
+
+type ICAPError struct {
+	Status string
+}
+
+func (e ICAPError) Error() string {
+	return fmt.Sprintf("unexpected response from ICAP service: %s", e.Status)
+}
+
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("scan did not finish within %s", e.Timeout)
+}
@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httputil"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultICAPPort    = "1344"
+	icapResponseHeader = "HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\n\r\n"
+)
+
+// icapScanner returns a fileScanner that sends files to an ICAP service as RESPMOD requests.
+// The service answers 204 for clean files and 200, usually with an X-Infection-Found or
+// X-Virus-ID header, for files it would block.
+func icapScanner(address string) (fileScanner, error) {
+	service, err := url.Parse(address)
+	if err != nil || service.Scheme != "icap" || service.Host == "" {
+		return nil, InvalidAddressError{Type: "icap", Address: address}
+	}
+
+	host := service.Host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, defaultICAPPort)
+	}
+
+	return func(ctx context.Context, contents io.Reader) (string, error) {
+		dialer := &net.Dialer{}
+		conn, err := dialer.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+
+		writer := bufio.NewWriter(conn)
+		fmt.Fprintf(writer, "RESPMOD %s ICAP/1.0\r\n", service.String())
+		fmt.Fprintf(writer, "Host: %s\r\n", service.Host)
+		fmt.Fprintf(writer, "Allow: 204\r\n")
+		fmt.Fprintf(writer, "Encapsulated: res-hdr=0, res-body=%d\r\n\r\n", len(icapResponseHeader))
+		fmt.Fprint(writer, icapResponseHeader)
+
+		body := httputil.NewChunkedWriter(writer)
+		_, err = io.Copy(body, contents)
+		if err != nil {
+			return "", err
+		}
+		body.Close()
+		fmt.Fprint(writer, "\r\n")
+
+		err = writer.Flush()
+		if err != nil {
+			return "", err
+		}
+
+		reader := textproto.NewReader(bufio.NewReader(conn))
+		statusLine, err := reader.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		header, err := reader.ReadMIMEHeader()
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+
+		return parseICAPResponse(statusLine, header)
+	}, nil
+}
+
+func parseICAPResponse(statusLine string, header textproto.MIMEHeader) (string, error) {
+	fields := strings.SplitN(statusLine, " ", 3)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "ICAP/") {
+		return "", ICAPError{Status: statusLine}
+	}
+
+	switch fields[1] {
+	case "204":
+		return "", nil
+	case "200":
+		if infection := header.Get("X-Infection-Found"); infection != "" {
+			return infection, nil
+		}
+		if virus := header.Get("X-Virus-ID"); virus != "" {
+			return virus, nil
+		}
+		return "blocked by ICAP service", nil
+	}
+
+	return "", ICAPError{Status: statusLine}
+}
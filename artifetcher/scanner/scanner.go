@@ -0,0 +1,132 @@
+// Package scanner submits fetched artifacts to a virus or malware scanner.
+package scanner
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// DefaultTimeout is how long a scan may take when the descriptor does not set a timeout.
+const DefaultTimeout = 5 * time.Minute
+
+type ScannerConstructor func(log I.DeploymentLogger, fs *afero.Afero) I.Scanner
+
+func NewScanner(log I.DeploymentLogger, fs *afero.Afero) I.Scanner {
+	return &Scanner{
+		Log:        log,
+		FileSystem: fs,
+	}
+}
+
+// Scanner scans an extracted artifact with the scanner described by the environment.
+//
+// A "command" scanner is run once against the artifact directory. The "clamav" and "icap"
+// scanners are sent every file of the artifact, one connection per file.
+type Scanner struct {
+	Log        I.DeploymentLogger
+	FileSystem *afero.Afero
+}
+
+// fileScanner scans the contents of a single file and returns a description of what it found,
+// or an empty string if the file is clean.
+type fileScanner func(ctx context.Context, contents io.Reader) (string, error)
+
+// Scan scans the artifact at appPath. Findings are returned in the result, not as an error.
+func (s *Scanner) Scan(ctx context.Context, descriptor S.ScannerDescriptor, appPath string) (S.ScanResult, error) {
+	timeout := DefaultTimeout
+	if descriptor.TimeoutSeconds > 0 {
+		timeout = time.Duration(descriptor.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := S.ScanResult{
+		Scanner:   descriptor.Type,
+		Findings:  []S.ScanFinding{},
+		ScannedAt: time.Now().UTC(),
+	}
+
+	s.Log.Infof("scanning artifact with %s scanner", descriptor.Type)
+
+	var (
+		findings []S.ScanFinding
+		err      error
+	)
+
+	switch strings.ToLower(descriptor.Type) {
+	case "command":
+		findings, err = s.scanWithCommand(ctx, descriptor.Command, appPath)
+	case "clamav":
+		if descriptor.Address == "" {
+			return result, MissingAddressError{Type: descriptor.Type}
+		}
+		findings, err = s.scanFiles(ctx, appPath, clamdScanner(descriptor.Address))
+	case "icap":
+		var scan fileScanner
+		scan, err = icapScanner(descriptor.Address)
+		if err != nil {
+			return result, err
+		}
+		findings, err = s.scanFiles(ctx, appPath, scan)
+	default:
+		return result, UnknownScannerError{Type: descriptor.Type}
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return result, TimeoutError{Timeout: timeout}
+		}
+		return result, err
+	}
+
+	result.Findings = append(result.Findings, findings...)
+	s.Log.Infof("scan finished with %d findings", len(result.Findings))
+
+	return result, nil
+}
+
+func (s *Scanner) scanFiles(ctx context.Context, appPath string, scan fileScanner) ([]S.ScanFinding, error) {
+	var findings []S.ScanFinding
+
+	err := s.FileSystem.Walk(appPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		file, err := s.FileSystem.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		relativePath, err := filepath.Rel(appPath, path)
+		if err != nil {
+			relativePath = path
+		}
+
+		s.Log.Debugf("scanning %s", relativePath)
+		description, err := scan(ctx, file)
+		if err != nil {
+			return ScanFileError{Path: relativePath, Err: err}
+		}
+		if description != "" {
+			findings = append(findings, S.ScanFinding{Path: relativePath, Description: description})
+		}
+		return nil
+	})
+
+	return findings, err
+}
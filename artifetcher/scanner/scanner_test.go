@@ -0,0 +1,268 @@
+package scanner_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	. "github.com/compozed/deployadactyl/artifetcher/scanner"
+	"github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/op/go-logging"
+	"github.com/spf13/afero"
+)
+
+const eicar = "EICAR-STANDARD-ANTIVIRUS-TEST-FILE"
+
+// serve accepts connections until the listener is closed and answers each with handle.
+func serve(listener net.Listener, handle func(conn net.Conn)) {
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+}
+
+// fakeClamd answers INSTREAM commands, reporting streams that contain the EICAR marker.
+func fakeClamd(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	command, err := reader.ReadString(0)
+	if err != nil || command != "zINSTREAM\x00" {
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+		return
+	}
+
+	stream := &bytes.Buffer{}
+	size := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(reader, size); err != nil {
+			return
+		}
+		n := binary.BigEndian.Uint32(size)
+		if n == 0 {
+			break
+		}
+		if _, err := io.CopyN(stream, reader, int64(n)); err != nil {
+			return
+		}
+	}
+
+	if strings.Contains(stream.String(), eicar) {
+		conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+		return
+	}
+	conn.Write([]byte("stream: OK\x00"))
+}
+
+// fakeICAP answers RESPMOD requests, blocking bodies that contain the EICAR marker.
+func fakeICAP(conn net.Conn) {
+	reader := textproto.NewReader(bufio.NewReader(conn))
+	requestLine, err := reader.ReadLine()
+	if err != nil || !strings.HasPrefix(requestLine, "RESPMOD icap://") {
+		fmt.Fprint(conn, "ICAP/1.0 400 Bad Request\r\n\r\n")
+		return
+	}
+	if _, err := reader.ReadMIMEHeader(); err != nil {
+		return
+	}
+	if _, err := reader.ReadLine(); err != nil {
+		return
+	}
+	if _, err := reader.ReadMIMEHeader(); err != nil {
+		return
+	}
+
+	body := &bytes.Buffer{}
+	for {
+		line, err := reader.ReadLine()
+		if err != nil {
+			return
+		}
+		var n int
+		fmt.Sscanf(line, "%x", &n)
+		if n == 0 {
+			reader.ReadLine()
+			break
+		}
+		chunk := make([]byte, n+2)
+		if _, err := io.ReadFull(reader.R, chunk); err != nil {
+			return
+		}
+		body.Write(chunk[:n])
+	}
+
+	if strings.Contains(body.String(), eicar) {
+		fmt.Fprint(conn, "ICAP/1.0 200 OK\r\nX-Infection-Found: Type=0; Resolution=2; Threat=EICAR-Test-File;\r\nEncapsulated: null-body=0\r\n\r\n")
+		return
+	}
+	fmt.Fprint(conn, "ICAP/1.0 204 No Content\r\nEncapsulated: null-body=0\r\n\r\n")
+}
+
+var _ = Describe("Scanner", func() {
+	var (
+		af       *afero.Afero
+		appPath  string
+		scanner  *Scanner
+		listener net.Listener
+	)
+
+	BeforeEach(func() {
+		af = &afero.Afero{Fs: afero.NewMemMapFs()}
+		appPath = "/tmp/app"
+		scanner = &Scanner{
+			Log:        interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(GinkgoWriter, logging.DEBUG, "scanner_test")},
+			FileSystem: af,
+		}
+
+		Expect(af.MkdirAll(appPath, 0755)).To(Succeed())
+		Expect(af.MkdirAll(path.Join(appPath, "lib"), 0755)).To(Succeed())
+		Expect(af.WriteFile(path.Join(appPath, "index.html"), []byte("<html></html>"), 0644)).To(Succeed())
+		Expect(af.WriteFile(path.Join(appPath, "lib", "app.jar"), bytes.Repeat([]byte("jar"), 50000), 0644)).To(Succeed())
+
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	Describe("clamav", func() {
+		BeforeEach(func() {
+			serve(listener, fakeClamd)
+		})
+
+		It("returns a clean result when clamd finds nothing", func() {
+			result, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "clamav", Address: listener.Addr().String()}, appPath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Clean()).To(BeTrue())
+			Expect(result.Scanner).To(Equal("clamav"))
+			Expect(result.ScannedAt.IsZero()).To(BeFalse())
+		})
+
+		It("returns the infected files", func() {
+			Expect(af.WriteFile(path.Join(appPath, "lib", "infected.txt"), []byte(eicar), 0644)).To(Succeed())
+
+			result, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "clamav", Address: listener.Addr().String()}, appPath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Findings).To(Equal([]S.ScanFinding{{Path: "lib/infected.txt", Description: "Eicar-Signature"}}))
+		})
+
+		It("returns an error when clamd can not be reached", func() {
+			address := listener.Addr().String()
+			listener.Close()
+
+			_, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "clamav", Address: address}, appPath)
+
+			Expect(err).To(BeAssignableToTypeOf(ScanFileError{}))
+		})
+
+		It("returns an error when there is no address", func() {
+			_, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "clamav"}, appPath)
+
+			Expect(err).To(MatchError(MissingAddressError{Type: "clamav"}))
+		})
+	})
+
+	Describe("icap", func() {
+		BeforeEach(func() {
+			serve(listener, fakeICAP)
+		})
+
+		It("returns a clean result when the service allows every file", func() {
+			result, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "icap", Address: "icap://" + listener.Addr().String() + "/avscan"}, appPath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Clean()).To(BeTrue())
+		})
+
+		It("returns the files the service blocks", func() {
+			Expect(af.WriteFile(path.Join(appPath, "infected.txt"), []byte(eicar), 0644)).To(Succeed())
+
+			result, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "icap", Address: "icap://" + listener.Addr().String() + "/avscan"}, appPath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Findings).To(HaveLen(1))
+			Expect(result.Findings[0].Path).To(Equal("infected.txt"))
+			Expect(result.Findings[0].Description).To(ContainSubstring("Threat=EICAR-Test-File"))
+		})
+
+		It("returns an error for an address that is not an icap url", func() {
+			_, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "icap", Address: listener.Addr().String()}, appPath)
+
+			Expect(err).To(BeAssignableToTypeOf(InvalidAddressError{}))
+		})
+	})
+
+	Describe("command", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "scanner")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("passes the artifact directory to the command", func() {
+			output := path.Join(dir, "scanned")
+
+			result, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "command", Command: []string{"sh", "-c", `echo "$1" > ` + output, "sh"}}, dir)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Clean()).To(BeTrue())
+			Expect(ioutil.ReadFile(output)).To(ContainSubstring(dir))
+		})
+
+		It("returns a finding when the command exits with 1", func() {
+			result, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "command", Command: []string{"sh", "-c", "echo Eicar-Signature FOUND; exit 1", "sh"}}, dir)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Findings).To(Equal([]S.ScanFinding{{Path: ".", Description: "Eicar-Signature FOUND"}}))
+		})
+
+		It("returns an error when the command exits with another code", func() {
+			_, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "command", Command: []string{"sh", "-c", "echo database missing; exit 2", "sh"}}, dir)
+
+			Expect(err).To(BeAssignableToTypeOf(CommandError{}))
+			Expect(err.Error()).To(ContainSubstring("database missing"))
+		})
+
+		It("returns an error when the command does not finish in time", func() {
+			_, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "command", Command: []string{"sh", "-c", "exec sleep 5", "sh"}, TimeoutSeconds: 1}, dir)
+
+			Expect(err).To(MatchError(TimeoutError{Timeout: time.Second}))
+		})
+	})
+
+	It("returns an error for an unknown scanner type", func() {
+		_, err := scanner.Scan(context.Background(), S.ScannerDescriptor{Type: "magic"}, appPath)
+
+		Expect(err).To(MatchError(UnknownScannerError{Type: "magic"}))
+	})
+})
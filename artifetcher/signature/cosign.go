@@ -0,0 +1,87 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// verifyCosign checks a signature made by cosign sign-blob, which is the base64 encoded
+// ECDSA or RSA PKCS #1 v1.5 signature of the sha256 digest of the artifact.
+func (v *Verifier) verifyCosign(artifactPath string, signature []byte, keys [][]byte) error {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return InvalidSignatureError{Err: err}
+	}
+
+	digest, err := v.digest(artifactPath)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		publicKey, err := parsePublicKey(key)
+		if err != nil {
+			return err
+		}
+
+		if verifyDigest(publicKey, digest, decoded) {
+			return nil
+		}
+	}
+
+	return InvalidSignatureError{}
+}
+
+func (v *Verifier) digest(artifactPath string) ([]byte, error) {
+	artifact, err := v.FileSystem.Open(artifactPath)
+	if err != nil {
+		return nil, err
+	}
+	defer artifact.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, artifact)
+	if err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+func parsePublicKey(key []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, ParsePublicKeyError{Err: NotPEMError{}}
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, ParsePublicKeyError{Err: err}
+	}
+	return publicKey, nil
+}
+
+func verifyDigest(publicKey crypto.PublicKey, digest, signature []byte) bool {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		var sig struct {
+			R, S *big.Int
+		}
+		rest, err := asn1.Unmarshal(signature, &sig)
+		if err != nil || len(rest) > 0 {
+			return false
+		}
+		return ecdsa.Verify(key, digest, sig.R, sig.S)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature) == nil
+	}
+	return false
+}
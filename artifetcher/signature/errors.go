@@ -0,0 +1,91 @@
+package signature
+
+import "fmt"
+
+type UnsignedArtifactError struct{}
+
+func (e UnsignedArtifactError) Error() string {
+	return "the artifact is not signed and signatures are required"
+}
+
+type InvalidSignatureError struct {
+	Err error
+}
+
+func (e InvalidSignatureError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("the artifact signature is not valid: %s", e.Err)
+	}
+	return "the artifact signature is not valid for any of the public keys"
+}
+
+type UnknownSignatureTypeError struct {
+	Type string
+}
+
+func (e UnknownSignatureTypeError) Error() string {
+	return fmt.Sprintf("unknown signature type: %s: must be cosign or gpg", e.Type)
+}
+
+type NoPublicKeysError struct{}
+
+func (e NoPublicKeysError) Error() string {
+	return "signature verification has no public keys"
+}
+
+type ReadPublicKeyError struct {
+	Key string
+	Err error
+}
+
+func (e ReadPublicKeyError) Error() string {
+	return fmt.Sprintf("cannot read public key %s: %s", e.Key, e.Err)
+}
+
+type ParsePublicKeyError struct {
+	Err error
+}
+
+func (e ParsePublicKeyError) Error() string {
+	return fmt.Sprintf("cannot parse public key: %s", e.Err)
+}
+
+type NotPEMError struct{}
+
+func (e NotPEMError) Error() string {
+	return "not a PEM encoded key"
+}
+
+type FetchSignatureError struct {
+	URL string
+	Err error
+}
+
+func (e FetchSignatureError) Error() string {
+	return fmt.Sprintf("cannot fetch signature from %s: %s", e.URL, e.Err)
+}
+
+type StatusError struct {
+	Status string
+}
+
+func (e StatusError) Error() string {
+	return e.Status
+}
+
+type SignatureTooLargeError struct {
+	Max int64
+}
+
+func (e SignatureTooLargeError) Error() string {
+	return fmt.Sprintf("signature is larger than %d bytes", e.Max)
+}
+
+type GPGError struct {
+	Err error
+	Out []byte
+}
+
+func (e GPGError) Error() string {
+	return fmt.Sprintf("gpg failed: %s: %s", e.Err, string(e.Out))
+}
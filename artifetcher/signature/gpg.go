@@ -0,0 +1,59 @@
+package signature
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// verifyGPG checks a detached GPG signature with a keyring that only holds the configured keys.
+// The gpg command reads the artifact from disk, so the file system must be the OS file system.
+func (v *Verifier) verifyGPG(ctx context.Context, artifactPath string, signature []byte, keys [][]byte) error {
+	home, err := ioutil.TempDir("", "deployadactyl-gpg-")
+	if err != nil {
+		return GPGError{Err: err}
+	}
+	defer os.RemoveAll(home)
+
+	for i, key := range keys {
+		keyFile := filepath.Join(home, fmt.Sprintf("key-%d", i))
+		err = ioutil.WriteFile(keyFile, key, 0600)
+		if err != nil {
+			return GPGError{Err: err}
+		}
+
+		out, err := v.gpg(ctx, home, "--import", keyFile)
+		if err != nil {
+			return GPGError{Err: err, Out: out}
+		}
+	}
+
+	signatureFile := filepath.Join(home, "artifact.sig")
+	err = ioutil.WriteFile(signatureFile, signature, 0600)
+	if err != nil {
+		return GPGError{Err: err}
+	}
+
+	out, err := v.gpg(ctx, home, "--verify", signatureFile, artifactPath)
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return InvalidSignatureError{Err: GPGError{Err: err, Out: out}}
+		}
+		return GPGError{Err: err, Out: out}
+	}
+
+	return nil
+}
+
+func (v *Verifier) gpg(ctx context.Context, home string, args ...string) ([]byte, error) {
+	command := v.GPGCommand
+	if command == "" {
+		command = "gpg"
+	}
+
+	args = append([]string{"--batch", "--no-tty", "--homedir", home}, args...)
+	return exec.CommandContext(ctx, command, args...).CombinedOutput()
+}
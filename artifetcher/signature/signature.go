@@ -0,0 +1,215 @@
+// Package signature verifies the signatures of artifacts before they are extracted.
+package signature
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/compozed/deployadactyl/artifetcher/artifactory"
+	"github.com/compozed/deployadactyl/artifetcher/bucket"
+	"github.com/compozed/deployadactyl/cabundle"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// maxSignatureSize is the largest signature that is downloaded.
+const maxSignatureSize = 64 * 1024
+
+type VerifierConstructor func(log I.DeploymentLogger, fs *afero.Afero, environment S.Environment, deploymentInfo *S.DeploymentInfo) I.ArtifactVerifier
+
+// NewVerifier returns a Verifier for the signature of the deployment, or nil if the environment
+// does not verify signatures.
+func NewVerifier(log I.DeploymentLogger, fs *afero.Afero, environment S.Environment, deploymentInfo *S.DeploymentInfo) I.ArtifactVerifier {
+	descriptor := environment.Signature
+	if !descriptor.Enabled() {
+		return nil
+	}
+
+	// Maven coordinates are not a URL the signature can be next to, so their signature has to be given.
+	signatureURL := deploymentInfo.SignatureURL
+	_, coordinates := S.ParseMavenArtifactURL(deploymentInfo.ArtifactURL)
+	if signatureURL == "" && deploymentInfo.ArtifactURL != "" && !coordinates {
+		signatureURL = deploymentInfo.ArtifactURL + suffix(descriptor.Type)
+	}
+
+	return &Verifier{
+		Log:          log,
+		FileSystem:   fs,
+		Client:       &http.Client{Timeout: 30 * time.Second},
+		Descriptor:   descriptor,
+		Signature:    deploymentInfo.Signature,
+		SignatureURL: signatureURL,
+		GPGCommand:   "gpg",
+		CABundle:     environment.CABundle,
+		Buckets:      environment.Buckets,
+		Artifactory:  environment.Artifactory,
+	}
+}
+
+// Verifier checks an artifact against a signature made by one of the public keys of the environment.
+//
+// The Signature is used when it is given, otherwise it is downloaded from SignatureURL.
+// Cosign signatures are checked in process, GPG signatures are checked with GPGCommand.
+// If there is a CABundle, signatures are only downloaded from servers it trusts.
+// Signatures of s3:// and gs:// URLs are downloaded from their buckets with the credentials of the Buckets.
+// Signatures of the Artifactory server are downloaded with its API key.
+type Verifier struct {
+	Log          I.DeploymentLogger
+	FileSystem   *afero.Afero
+	Client       *http.Client
+	Descriptor   S.SignatureDescriptor
+	Signature    string
+	SignatureURL string
+	GPGCommand   string
+	CABundle     string
+	Buckets      S.BucketsDescriptor
+	Artifactory  S.ArtifactoryDescriptor
+}
+
+// Verify returns an error if the artifact is not signed by one of the public keys.
+// Unsigned artifacts are only rejected when signatures are required.
+func (v *Verifier) Verify(ctx context.Context, artifactPath string) error {
+	v.Log.Infof("verifying the %s signature of the artifact", v.Descriptor.Type)
+
+	signature, err := v.signature(ctx)
+	if err != nil {
+		return err
+	}
+	if signature == nil {
+		if v.Descriptor.Required {
+			return UnsignedArtifactError{}
+		}
+		v.Log.Info("the artifact is not signed and signatures are not required")
+		return nil
+	}
+
+	keys, err := v.publicKeys()
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(v.Descriptor.Type) {
+	case "cosign":
+		err = v.verifyCosign(artifactPath, signature, keys)
+	case "gpg":
+		err = v.verifyGPG(ctx, artifactPath, signature, keys)
+	default:
+		return UnknownSignatureTypeError{Type: v.Descriptor.Type}
+	}
+	if err != nil {
+		return err
+	}
+
+	v.Log.Info("the artifact signature is valid")
+	return nil
+}
+
+// signature returns the given signature or downloads it. A missing signature returns nil.
+func (v *Verifier) signature(ctx context.Context) ([]byte, error) {
+	if v.Signature != "" {
+		return []byte(v.Signature), nil
+	}
+	if v.SignatureURL == "" {
+		return nil, nil
+	}
+
+	request, err := http.NewRequest("GET", v.SignatureURL, nil)
+	if err != nil {
+		return nil, FetchSignatureError{URL: v.SignatureURL, Err: err}
+	}
+	request = request.WithContext(ctx)
+	artifactory.Authorize(request, v.Artifactory)
+
+	client, err := v.client()
+	if err != nil {
+		return nil, FetchSignatureError{URL: v.SignatureURL, Err: err}
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, FetchSignatureError{URL: v.SignatureURL, Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, FetchSignatureError{URL: v.SignatureURL, Err: StatusError{Status: response.Status}}
+	}
+
+	signature, err := ioutil.ReadAll(&limitedReader{reader: response.Body, remaining: maxSignatureSize})
+	if err != nil {
+		return nil, FetchSignatureError{URL: v.SignatureURL, Err: err}
+	}
+	return signature, nil
+}
+
+// client returns the Client, trusting the CABundle when there is one, and fetching from the Buckets when the
+// signature is in one.
+func (v *Verifier) client() (*http.Client, error) {
+	tlsConfig, err := cabundle.TLSConfig(v.FileSystem, v.CABundle)
+	if err != nil || tlsConfig == nil && !bucket.Supported(v.SignatureURL) {
+		return v.Client, err
+	}
+
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
+	err = bucket.Register(transport, v.FileSystem, v.Buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Timeout:   v.Client.Timeout,
+		Transport: transport,
+	}, nil
+}
+
+// publicKeys returns the contents of the configured keys, reading the ones that are paths.
+func (v *Verifier) publicKeys() ([][]byte, error) {
+	if len(v.Descriptor.PublicKeys) == 0 {
+		return nil, NoPublicKeysError{}
+	}
+
+	keys := [][]byte{}
+	for _, key := range v.Descriptor.PublicKeys {
+		if strings.HasPrefix(strings.TrimSpace(key), "-----BEGIN") {
+			keys = append(keys, []byte(key))
+			continue
+		}
+
+		contents, err := v.FileSystem.ReadFile(key)
+		if err != nil {
+			return nil, ReadPublicKeyError{Key: key, Err: err}
+		}
+		keys = append(keys, contents)
+	}
+	return keys, nil
+}
+
+func suffix(signatureType string) string {
+	if strings.ToLower(signatureType) == "gpg" {
+		return ".asc"
+	}
+	return ".sig"
+}
+
+// limitedReader fails instead of truncating when there is more to read than remaining.
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n, SignatureTooLargeError{Max: maxSignatureSize}
+	}
+	return n, err
+}
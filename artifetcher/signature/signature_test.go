@@ -0,0 +1,259 @@
+package signature_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+
+	. "github.com/compozed/deployadactyl/artifetcher/signature"
+	"github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/op/go-logging"
+	"github.com/spf13/afero"
+)
+
+func publicKeyPEM(key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	Expect(err).ToNot(HaveOccurred())
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+var _ = Describe("Verifier", func() {
+	var (
+		af           *afero.Afero
+		log          interfaces.DeploymentLogger
+		artifactPath string
+		artifact     []byte
+		digest       [32]byte
+		ecdsaKey     *ecdsa.PrivateKey
+		descriptor   S.SignatureDescriptor
+		info         *S.DeploymentInfo
+	)
+
+	cosignSignature := func(data []byte) string {
+		hash := sha256.Sum256(data)
+		der, err := ecdsaKey.Sign(rand.Reader, hash[:], crypto.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		return base64.StdEncoding.EncodeToString(der)
+	}
+
+	verify := func() error {
+		verifier := NewVerifier(log, af, S.Environment{Signature: descriptor}, info)
+		Expect(verifier).ToNot(BeNil())
+		return verifier.Verify(context.Background(), artifactPath)
+	}
+
+	BeforeEach(func() {
+		af = &afero.Afero{Fs: afero.NewMemMapFs()}
+		log = interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(GinkgoWriter, logging.DEBUG, "signature_test")}
+
+		artifactPath = "/tmp/artifact.zip"
+		artifact = []byte("the artifact")
+		digest = sha256.Sum256(artifact)
+		Expect(af.WriteFile(artifactPath, artifact, 0644)).To(Succeed())
+
+		var err error
+		ecdsaKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		descriptor = S.SignatureDescriptor{Type: "cosign", PublicKeys: []string{publicKeyPEM(ecdsaKey.Public())}, Required: true}
+		info = &S.DeploymentInfo{}
+	})
+
+	It("is not created when the environment does not verify signatures", func() {
+		Expect(NewVerifier(log, af, S.Environment{}, info)).To(BeNil())
+	})
+
+	Describe("cosign", func() {
+		It("accepts an artifact signed with an ECDSA key", func() {
+			info.Signature = cosignSignature(artifact)
+
+			Expect(verify()).To(Succeed())
+		})
+
+		It("accepts an artifact signed with an RSA key read from a file", func() {
+			rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).ToNot(HaveOccurred())
+			sig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(af.WriteFile("/keys/release.pub", []byte(publicKeyPEM(rsaKey.Public())), 0644)).To(Succeed())
+			descriptor.PublicKeys = append(descriptor.PublicKeys, "/keys/release.pub")
+			info.Signature = base64.StdEncoding.EncodeToString(sig)
+
+			Expect(verify()).To(Succeed())
+		})
+
+		It("rejects a tampered artifact", func() {
+			info.Signature = cosignSignature([]byte("another artifact"))
+
+			Expect(verify()).To(MatchError(InvalidSignatureError{}))
+		})
+
+		It("rejects an artifact signed by another key", func() {
+			info.Signature = cosignSignature(artifact)
+			otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			descriptor.PublicKeys = []string{publicKeyPEM(otherKey.Public())}
+
+			Expect(verify()).To(MatchError(InvalidSignatureError{}))
+		})
+
+		It("returns an error when there are no public keys", func() {
+			info.Signature = cosignSignature(artifact)
+			descriptor.PublicKeys = nil
+
+			Expect(verify()).To(MatchError(NoPublicKeysError{}))
+		})
+	})
+
+	Describe("fetching the signature", func() {
+		var (
+			server     *httptest.Server
+			signatures map[string]string
+		)
+
+		BeforeEach(func() {
+			signatures = map[string]string{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				signature, ok := signatures[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(signature))
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("fetches the signature next to the artifact", func() {
+			signatures["/release/app.zip.sig"] = cosignSignature(artifact)
+			info.ArtifactURL = server.URL + "/release/app.zip"
+
+			Expect(verify()).To(Succeed())
+		})
+
+		It("fetches the signature from the signature url", func() {
+			signatures["/signatures/app"] = cosignSignature(artifact)
+			info.ArtifactURL = server.URL + "/release/app.zip"
+			info.SignatureURL = server.URL + "/signatures/app"
+
+			Expect(verify()).To(Succeed())
+		})
+
+		It("fetches the signature next to an artifact in a bucket", func() {
+			signatures["/artifacts/release/app.zip.sig"] = cosignSignature(artifact)
+			info.ArtifactURL = "s3://artifacts/release/app.zip"
+
+			verifier := NewVerifier(log, af, S.Environment{Signature: descriptor, Buckets: S.BucketsDescriptor{S3: S.S3Descriptor{Endpoint: server.URL}}}, info)
+
+			Expect(verifier.Verify(context.Background(), artifactPath)).To(Succeed())
+		})
+
+		It("fetches the signature from artifactory with its api key", func() {
+			var apiKey string
+			artifactoryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				apiKey = r.Header.Get("X-JFrog-Art-Api")
+				server.Config.Handler.ServeHTTP(w, r)
+			}))
+			defer artifactoryServer.Close()
+			signatures["/artifactory/libs-release/app.zip.sig"] = cosignSignature(artifact)
+			info.ArtifactURL = artifactoryServer.URL + "/artifactory/libs-release/app.zip"
+
+			verifier := NewVerifier(log, af, S.Environment{Signature: descriptor, Artifactory: S.ArtifactoryDescriptor{URL: artifactoryServer.URL + "/artifactory", APIKey: "the-api-key"}}, info)
+
+			Expect(verifier.Verify(context.Background(), artifactPath)).To(Succeed())
+			Expect(apiKey).To(Equal("the-api-key"))
+		})
+
+		It("rejects an unsigned artifact when signatures are required", func() {
+			info.ArtifactURL = server.URL + "/release/app.zip"
+
+			Expect(verify()).To(MatchError(UnsignedArtifactError{}))
+		})
+
+		It("does not look for the signature of maven coordinates", func() {
+			info.ArtifactURL = "maven:com.example:app:RELEASE"
+
+			Expect(verify()).To(MatchError(UnsignedArtifactError{}))
+		})
+
+		It("accepts an unsigned artifact when signatures are not required", func() {
+			info.ArtifactURL = server.URL + "/release/app.zip"
+			descriptor.Required = false
+
+			Expect(verify()).To(Succeed())
+		})
+	})
+
+	Describe("gpg", func() {
+		var (
+			dir      string
+			verifier *Verifier
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "signature-test")
+			Expect(err).ToNot(HaveOccurred())
+
+			af = &afero.Afero{Fs: afero.NewOsFs()}
+			artifactPath = path.Join(dir, "artifact.zip")
+			Expect(ioutil.WriteFile(artifactPath, artifact, 0644)).To(Succeed())
+
+			descriptor = S.SignatureDescriptor{Type: "gpg", PublicKeys: []string{"-----BEGIN PGP PUBLIC KEY BLOCK-----\nkey\n-----END PGP PUBLIC KEY BLOCK-----"}, Required: true}
+			info.Signature = "-----BEGIN PGP SIGNATURE-----\nsignature\n-----END PGP SIGNATURE-----"
+
+			verifier = NewVerifier(log, af, S.Environment{Signature: descriptor}, info).(*Verifier)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		// fakeGPG writes a gpg replacement that records its arguments and fails --verify with exit code.
+		fakeGPG := func(verifyExitCode string) string {
+			script := path.Join(dir, "gpg")
+			Expect(ioutil.WriteFile(script, []byte(`#!/bin/sh
+echo "$@" >> `+path.Join(dir, "calls")+`
+case "$*" in *--verify*) exit `+verifyExitCode+`;; esac
+`), 0755)).To(Succeed())
+			return script
+		}
+
+		It("verifies the signature with a keyring holding the public keys", func() {
+			verifier.GPGCommand = fakeGPG("0")
+
+			Expect(verifier.Verify(context.Background(), artifactPath)).To(Succeed())
+
+			calls, err := ioutil.ReadFile(path.Join(dir, "calls"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(calls)).To(MatchRegexp(`--batch --no-tty --homedir \S+ --import \S+key-0`))
+			Expect(string(calls)).To(MatchRegexp(`--verify \S+artifact.sig ` + artifactPath))
+		})
+
+		It("rejects a bad signature", func() {
+			verifier.GPGCommand = fakeGPG("1")
+
+			err := verifier.Verify(context.Background(), artifactPath)
+
+			Expect(err).To(BeAssignableToTypeOf(InvalidSignatureError{}))
+		})
+	})
+})
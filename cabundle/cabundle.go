@@ -0,0 +1,45 @@
+// Package cabundle loads the certificate authorities an environment trusts instead of the system roots.
+package cabundle
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/spf13/afero"
+)
+
+// Load reads a PEM encoded CA bundle from a file.
+func Load(fs *afero.Afero, path string) (*x509.CertPool, error) {
+	bundle, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, ReadBundleError{path, err}
+	}
+
+	pool, err := Parse(bundle)
+	if err != nil {
+		return nil, ParseBundleError{path}
+	}
+	return pool, nil
+}
+
+// TLSConfig returns a tls.Config trusting the CA bundle at path, or nil when path is empty.
+func TLSConfig(fs *afero.Afero, path string) (*tls.Config, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	roots, err := Load(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{RootCAs: roots}, nil
+}
+
+// Parse returns a pool of the certificates in a PEM encoded CA bundle.
+func Parse(bundle []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, NoCertificatesError{}
+	}
+	return pool, nil
+}
@@ -0,0 +1,13 @@
+package cabundle_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCabundle(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cabundle Suite")
+}
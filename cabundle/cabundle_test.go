@@ -0,0 +1,66 @@
+package cabundle_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/compozed/deployadactyl/cabundle"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("CA bundles", func() {
+	var (
+		af     *afero.Afero
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		af = &afero.Afero{Fs: afero.NewMemMapFs()}
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.TLS.Certificates[0].Certificate[0]})
+		Expect(af.WriteFile("/etc/ssl/internal-ca.pem", bundle, 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("TLSConfig", func() {
+		It("trusts the certificates in the bundle", func() {
+			tlsConfig, err := TLSConfig(af, "/etc/ssl/internal-ca.pem")
+			Expect(err).ToNot(HaveOccurred())
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+			resp, err := client.Get(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+		})
+
+		It("returns nil without a bundle", func() {
+			tlsConfig, err := TLSConfig(af, "")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tlsConfig).To(BeNil())
+		})
+	})
+
+	Describe("Load", func() {
+		It("returns an error when the bundle can not be read", func() {
+			_, err := Load(af, "/etc/ssl/missing.pem")
+
+			Expect(err).To(BeAssignableToTypeOf(ReadBundleError{}))
+		})
+
+		It("returns an error when the bundle does not contain certificates", func() {
+			Expect(af.WriteFile("/etc/ssl/empty.pem", []byte("not a certificate"), 0644)).To(Succeed())
+
+			_, err := Load(af, "/etc/ssl/empty.pem")
+
+			Expect(err).To(MatchError(ParseBundleError{Path: "/etc/ssl/empty.pem"}))
+		})
+	})
+})
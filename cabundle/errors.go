@@ -0,0 +1,26 @@
+package cabundle
+
+import "fmt"
+
+type ReadBundleError struct {
+	Path string
+	Err  error
+}
+
+func (e ReadBundleError) Error() string {
+	return fmt.Sprintf("cannot read CA bundle %s: %s", e.Path, e.Err)
+}
+
+type ParseBundleError struct {
+	Path string
+}
+
+func (e ParseBundleError) Error() string {
+	return fmt.Sprintf("CA bundle %s does not contain any PEM encoded certificates", e.Path)
+}
+
+type NoCertificatesError struct{}
+
+func (e NoCertificatesError) Error() string {
+	return "CA bundle does not contain any PEM encoded certificates"
+}
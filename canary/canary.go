@@ -0,0 +1,96 @@
+// Package canary promotes the canaries of the running deployments, whichever instance of the server they run on
+// when the instances share their state.
+package canary
+
+import (
+	"sync"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// Channel is the channel of the shared state the uuids of the deployments to promote are published on.
+const Channel = "canary-promote"
+
+// NewRegistry returns a Registry without waiting canaries.
+func NewRegistry() *Registry {
+	return &Registry{waiting: map[string]*canary{}}
+}
+
+// Registry keeps the canaries waiting to be promoted on this instance. Once it is shared, a promotion is published
+// to every instance and promotes the canary wherever it waits.
+type Registry struct {
+	mu      sync.Mutex
+	waiting map[string]*canary
+	shared  I.SharedState
+}
+
+type canary struct {
+	promoted chan struct{}
+	once     sync.Once
+}
+
+func (c *canary) promote() {
+	c.once.Do(func() { close(c.promoted) })
+}
+
+// Wait returns a channel that is closed when the canary of the deployment with the uuid is promoted. done must be
+// called once the canary no longer waits.
+func (r *Registry) Wait(uuid string) (<-chan struct{}, func()) {
+	c := &canary{promoted: make(chan struct{})}
+
+	r.mu.Lock()
+	r.waiting[uuid] = c
+	r.mu.Unlock()
+
+	return c.promoted, func() {
+		r.mu.Lock()
+		if r.waiting[uuid] == c {
+			delete(r.waiting, uuid)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Promote promotes the canary of the deployment with the uuid. Once the Registry is shared, the promotion is
+// published to every instance. Otherwise it returns a NotWaitingError when the canary does not wait on this instance.
+func (r *Registry) Promote(uuid string) error {
+	r.mu.Lock()
+	shared := r.shared
+	r.mu.Unlock()
+
+	if shared != nil {
+		return shared.Publish(Channel, []byte(uuid))
+	}
+	if !r.promote(uuid) {
+		return NotWaitingError{uuid}
+	}
+	return nil
+}
+
+// Share publishes the promotions from now on to the shared state, and promotes the canaries of this instance that
+// any instance publishes.
+func (r *Registry) Share(state I.SharedState) {
+	messages, _ := state.Subscribe(Channel)
+
+	r.mu.Lock()
+	r.shared = state
+	r.mu.Unlock()
+
+	go func() {
+		for uuid := range messages {
+			r.promote(string(uuid))
+		}
+	}()
+}
+
+// promote promotes the canary of the deployment with the uuid, and reports whether it waits on this instance.
+func (r *Registry) promote(uuid string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.waiting[uuid]
+	if ok {
+		c.promote()
+	}
+	return ok
+}
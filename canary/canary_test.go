@@ -0,0 +1,71 @@
+package canary_test
+
+import (
+	. "github.com/compozed/deployadactyl/canary"
+	"github.com/compozed/deployadactyl/sharedstate"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	var registry *Registry
+
+	BeforeEach(func() {
+		registry = NewRegistry()
+	})
+
+	It("promotes a waiting canary", func() {
+		promoted, done := registry.Wait("1234")
+		defer done()
+
+		Expect(registry.Promote("1234")).To(Succeed())
+
+		Expect(promoted).To(BeClosed())
+	})
+
+	It("promotes a canary once", func() {
+		promoted, done := registry.Wait("1234")
+		defer done()
+
+		Expect(registry.Promote("1234")).To(Succeed())
+		Expect(registry.Promote("1234")).To(Succeed())
+
+		Expect(promoted).To(BeClosed())
+	})
+
+	It("returns an error for a canary that is not waiting", func() {
+		_, done := registry.Wait("1234")
+		done()
+
+		Expect(registry.Promote("1234")).To(MatchError(NotWaitingError{"1234"}))
+	})
+
+	Context("when it is shared", func() {
+		var other *Registry
+
+		BeforeEach(func() {
+			state := sharedstate.NewMemory()
+			registry.Share(state)
+			other = NewRegistry()
+			other.Share(state)
+		})
+
+		It("promotes a canary waiting on another instance", func() {
+			promoted, done := other.Wait("1234")
+			defer done()
+
+			Expect(registry.Promote("1234")).To(Succeed())
+
+			Eventually(promoted).Should(BeClosed())
+		})
+
+		It("leaves the other canaries waiting", func() {
+			promoted, done := other.Wait("5678")
+			defer done()
+
+			Expect(registry.Promote("1234")).To(Succeed())
+
+			Consistently(promoted).ShouldNot(BeClosed())
+		})
+	})
+})
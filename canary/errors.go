@@ -0,0 +1,11 @@
+package canary
+
+import "fmt"
+
+type NotWaitingError struct {
+	UUID string
+}
+
+func (e NotWaitingError) Error() string {
+	return fmt.Sprintf("the canary of deployment %s is not waiting to be promoted", e.UUID)
+}
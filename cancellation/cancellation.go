@@ -0,0 +1,94 @@
+// Package cancellation cancels the running deployments, whichever instance of the server they run on when the
+// instances share their state.
+package cancellation
+
+import (
+	"context"
+	"sync"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// Channel is the channel of the shared state the uuids of the deployments to cancel are published on.
+const Channel = "cancel"
+
+// NewRegistry returns a Registry without running deployments.
+func NewRegistry() *Registry {
+	return &Registry{running: map[string]*deployment{}}
+}
+
+// Registry keeps the cancel functions of the deployments running on this instance. Once it is shared, a
+// cancellation is published to every instance and cancels the deployment wherever it runs.
+type Registry struct {
+	mu      sync.Mutex
+	running map[string]*deployment
+	shared  I.SharedState
+}
+
+type deployment struct {
+	cancel context.CancelFunc
+}
+
+// Context returns a context of the deployment with the uuid, which is cancelled when the deployment is cancelled.
+// done must be called once the deployment is over.
+func (r *Registry) Context(parent context.Context, uuid string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	d := &deployment{cancel}
+
+	r.mu.Lock()
+	r.running[uuid] = d
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		if r.running[uuid] == d {
+			delete(r.running, uuid)
+		}
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// Cancel cancels the deployment with the uuid. Once the Registry is shared, the cancellation is published to every
+// instance. Otherwise it returns a NotRunningError when the deployment does not run on this instance.
+func (r *Registry) Cancel(uuid string) error {
+	r.mu.Lock()
+	shared := r.shared
+	r.mu.Unlock()
+
+	if shared != nil {
+		return shared.Publish(Channel, []byte(uuid))
+	}
+	if !r.cancel(uuid) {
+		return NotRunningError{uuid}
+	}
+	return nil
+}
+
+// Share publishes the cancellations from now on to the shared state, and cancels the deployments of this instance
+// that any instance publishes.
+func (r *Registry) Share(state I.SharedState) {
+	messages, _ := state.Subscribe(Channel)
+
+	r.mu.Lock()
+	r.shared = state
+	r.mu.Unlock()
+
+	go func() {
+		for uuid := range messages {
+			r.cancel(string(uuid))
+		}
+	}()
+}
+
+// cancel cancels the deployment with the uuid, and reports whether it runs on this instance.
+func (r *Registry) cancel(uuid string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d, ok := r.running[uuid]
+	if ok {
+		d.cancel()
+	}
+	return ok
+}
@@ -0,0 +1,13 @@
+package cancellation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCancellation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cancellation Suite")
+}
@@ -0,0 +1,73 @@
+package cancellation_test
+
+import (
+	"context"
+
+	. "github.com/compozed/deployadactyl/cancellation"
+	"github.com/compozed/deployadactyl/sharedstate"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	var registry *Registry
+
+	BeforeEach(func() {
+		registry = NewRegistry()
+	})
+
+	It("cancels the context of a running deployment", func() {
+		ctx, done := registry.Context(context.Background(), "1234")
+		defer done()
+
+		Expect(registry.Cancel("1234")).To(Succeed())
+
+		Expect(ctx.Done()).To(BeClosed())
+	})
+
+	It("returns an error for a deployment that is not running", func() {
+		_, done := registry.Context(context.Background(), "1234")
+		done()
+
+		Expect(registry.Cancel("1234")).To(MatchError(NotRunningError{"1234"}))
+	})
+
+	It("keeps the parent of the context", func() {
+		parent, cancel := context.WithCancel(context.Background())
+		ctx, done := registry.Context(parent, "1234")
+		defer done()
+
+		cancel()
+
+		Expect(ctx.Done()).To(BeClosed())
+	})
+
+	Context("when it is shared", func() {
+		var other *Registry
+
+		BeforeEach(func() {
+			state := sharedstate.NewMemory()
+			registry.Share(state)
+			other = NewRegistry()
+			other.Share(state)
+		})
+
+		It("cancels a deployment running on another instance", func() {
+			ctx, done := other.Context(context.Background(), "1234")
+			defer done()
+
+			Expect(registry.Cancel("1234")).To(Succeed())
+
+			Eventually(ctx.Done()).Should(BeClosed())
+		})
+
+		It("leaves the other deployments running", func() {
+			ctx, done := other.Context(context.Background(), "5678")
+			defer done()
+
+			Expect(registry.Cancel("1234")).To(Succeed())
+
+			Consistently(ctx.Done()).ShouldNot(BeClosed())
+		})
+	})
+})
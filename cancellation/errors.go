@@ -0,0 +1,11 @@
+package cancellation
+
+import "fmt"
+
+type NotRunningError struct {
+	UUID string
+}
+
+func (e NotRunningError) Error() string {
+	return fmt.Sprintf("deployment %s is not running", e.UUID)
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// MaintenanceRequest puts a foundation in maintenance, or takes it out of maintenance.
+type MaintenanceRequest struct {
+	FoundationURL string `json:"foundation_url"`
+	Maintenance   bool   `json:"maintenance"`
+}
+
+type maintenanceResponse struct {
+	Foundations []string `json:"foundations"`
+}
+
+// ActiveDeployments returns the running and queued deployments, longest running first. It requires the
+// credentials of the server.
+func (c *Client) ActiveDeployments(ctx context.Context) ([]S.ActiveDeployment, error) {
+	active := []S.ActiveDeployment{}
+	err := c.json(ctx, request{method: "GET", path: "/v3/admin/deployments/active"}, &active)
+	return active, err
+}
+
+// RuntimeStats returns the runtime statistics of the server. It requires the credentials of the server.
+func (c *Client) RuntimeStats(ctx context.Context) (S.RuntimeStats, error) {
+	stats := S.RuntimeStats{}
+	err := c.json(ctx, request{method: "GET", path: "/v3/admin/debug/runtime"}, &stats)
+	return stats, err
+}
+
+// TempDirectories returns the temporary directories of the running deployments and the leaked ones.
+func (c *Client) TempDirectories(ctx context.Context) (S.TempDirectoryReport, error) {
+	report := S.TempDirectoryReport{}
+	err := c.json(ctx, request{method: "GET", path: "/v3/temp-directories"}, &report)
+	return report, err
+}
+
+// StaleApps returns the report of the last sweep for stale applications.
+func (c *Client) StaleApps(ctx context.Context) (S.StaleAppsReport, error) {
+	report := S.StaleAppsReport{}
+	err := c.json(ctx, request{method: "GET", path: "/v3/stale-apps"}, &report)
+	return report, err
+}
+
+// SweepStaleApps sweeps the foundations for stale applications right away, and deletes them when remove is not
+// nil and true, or when it is nil and the server is configured to. It requires the credentials of the server.
+func (c *Client) SweepStaleApps(ctx context.Context, remove *bool) (S.StaleAppsReport, error) {
+	r := request{method: "POST", path: "/v3/stale-apps"}
+	if remove != nil {
+		r.query = url.Values{"delete": {strconv.FormatBool(*remove)}}
+	}
+
+	report := S.StaleAppsReport{}
+	err := c.json(ctx, r, &report)
+	return report, err
+}
+
+// Maintenance returns the foundations in maintenance.
+func (c *Client) Maintenance(ctx context.Context) ([]string, error) {
+	response := maintenanceResponse{}
+	err := c.json(ctx, request{method: "GET", path: "/v3/maintenance"}, &response)
+	return response.Foundations, err
+}
+
+// SetMaintenance puts a foundation in maintenance or takes it out of it, and returns the foundations in
+// maintenance. It requires the credentials of the server.
+func (c *Client) SetMaintenance(ctx context.Context, maintenance MaintenanceRequest) ([]string, error) {
+	r, err := jsonRequest("PUT", "/v3/maintenance", maintenance)
+	if err != nil {
+		return nil, err
+	}
+
+	response := maintenanceResponse{}
+	err = c.json(ctx, r, &response)
+	return response.Foundations, err
+}
@@ -0,0 +1,107 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+
+	. "github.com/compozed/deployadactyl/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Admin", func() {
+	var (
+		fake   *server
+		client *Client
+		ctx    context.Context
+	)
+
+	BeforeEach(func() {
+		fake = newServer()
+		client = NewClient(fake.URL, "server-username", "server-password")
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("returns the active deployments", func() {
+		fake.respondJSON(http.StatusOK, `[{"uuid": "uuid-1", "phase": "queued"}]`)
+
+		active, err := client.ActiveDeployments(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(active[0].Phase).To(Equal("queued"))
+		Expect(fake.request.URL.Path).To(Equal("/v3/admin/deployments/active"))
+	})
+
+	It("returns the runtime statistics", func() {
+		fake.respondJSON(http.StatusOK, `{"goroutines": 12}`)
+
+		stats, err := client.RuntimeStats(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stats.Goroutines).To(Equal(12))
+		Expect(fake.request.URL.Path).To(Equal("/v3/admin/debug/runtime"))
+	})
+
+	It("returns the temporary directories", func() {
+		fake.respondJSON(http.StatusOK, `{"active": [{"path": "/tmp/deployadactyl-1"}], "leaked": []}`)
+
+		report, err := client.TempDirectories(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Active[0].Path).To(Equal("/tmp/deployadactyl-1"))
+	})
+
+	It("returns the last report of the stale applications", func() {
+		fake.respondJSON(http.StatusOK, `{"apps": [{"app_name": "indexer-old"}]}`)
+
+		report, err := client.StaleApps(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Apps[0].AppName).To(Equal("indexer-old"))
+		Expect(fake.request.Method).To(Equal("GET"))
+	})
+
+	It("sweeps the stale applications without deleting them", func() {
+		fake.respondJSON(http.StatusOK, `{"apps": []}`)
+		remove := false
+
+		_, err := client.SweepStaleApps(ctx, &remove)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fake.request.Method).To(Equal("POST"))
+		Expect(fake.request.URL.RawQuery).To(Equal("delete=false"))
+	})
+
+	It("sweeps the stale applications as configured", func() {
+		fake.respondJSON(http.StatusOK, `{"apps": []}`)
+
+		_, err := client.SweepStaleApps(ctx, nil)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fake.request.URL.RawQuery).To(BeEmpty())
+	})
+
+	It("returns the foundations in maintenance", func() {
+		fake.respondJSON(http.StatusOK, `{"foundations": ["https://api.west.example.com"]}`)
+
+		foundations, err := client.Maintenance(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(foundations).To(ConsistOf("https://api.west.example.com"))
+	})
+
+	It("puts a foundation in maintenance", func() {
+		fake.respondJSON(http.StatusOK, `{"foundations": ["https://api.west.example.com"]}`)
+
+		foundations, err := client.SetMaintenance(ctx, MaintenanceRequest{FoundationURL: "https://api.west.example.com", Maintenance: true})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(foundations).To(ConsistOf("https://api.west.example.com"))
+		Expect(fake.request.Method).To(Equal("PUT"))
+		Expect(fake.body).To(MatchJSON(`{"foundation_url": "https://api.west.example.com", "maintenance": true}`))
+	})
+})
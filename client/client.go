@@ -0,0 +1,174 @@
+// Package client is a typed client of the API of the server, described by the document of the openapi package.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The headers of the requests.
+const (
+	TenantTokenHeader    = "X-Deployadactyl-Token"
+	IdempotencyKeyHeader = "Idempotency-Key"
+	SignatureHeader      = "X-Deployadactyl-Signature"
+	ManifestNameHeader   = "X-Deployadactyl-Manifest-Name"
+	DeploymentIDHeader   = "X-Deployadactyl-Deployment-Id"
+)
+
+// NewClient returns a Client of the server at the URL, which authenticates with the username and password.
+func NewClient(serverURL, username, password string) *Client {
+	return &Client{
+		URL:        strings.TrimSuffix(serverURL, "/"),
+		Username:   username,
+		Password:   password,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Client sends the requests of the API to the server at the URL. The Username and Password are sent with basic
+// auth, and the Token of a tenant, when there is one, in the X-Deployadactyl-Token header.
+type Client struct {
+	URL        string
+	Username   string
+	Password   string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// App is an application of an environment.
+type App struct {
+	Environment string
+	Org         string
+	Space       string
+	Name        string
+}
+
+// request is a request of the API.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        io.Reader
+	contentType string
+}
+
+// jsonRequest returns a request with the JSON of the value as its body.
+func jsonRequest(method, path string, value interface{}) (request, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return request{}, err
+	}
+	return request{method: method, path: path, body: bytes.NewReader(b), contentType: "application/json"}, nil
+}
+
+// send sends the request and returns the response, which must be closed, whatever its status.
+func (c *Client) send(ctx context.Context, r request) (*http.Response, error) {
+	u := c.URL + r.path
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+
+	req, err := http.NewRequest(r.method, u, r.body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	for key, values := range r.header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	if c.Token != "" {
+		req.Header.Set(TenantTokenHeader, c.Token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return httpClient.Do(req)
+}
+
+// Output is the output of a deployment, or of a change of the state of an application.
+type Output struct {
+	// UUID is the uuid of the deployment, unless it was refused before it started.
+	UUID       string
+	StatusCode int
+	Text       string
+}
+
+// output sends the request and returns the output of the deployment. The output is also returned when the status is
+// not 2xx, with a StatusError, as it is the output of a deployment that failed.
+func (c *Client) output(ctx context.Context, r request) (Output, error) {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return Output{}, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Output{}, err
+	}
+
+	output := Output{UUID: resp.Header.Get(DeploymentIDHeader), StatusCode: resp.StatusCode, Text: string(b)}
+	if resp.StatusCode/100 != 2 {
+		return output, StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(output.Text)}
+	}
+	return output, nil
+}
+
+// json sends the request and reads the JSON of the response into out.
+func (c *Client) json(ctx context.Context, r request, out interface{}) error {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return statusError(resp)
+	}
+	return decode(resp, out)
+}
+
+// statusError returns the StatusError of the response, with the beginning of its body.
+func statusError(resp *http.Response) error {
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+}
+
+// decode reads the JSON of the response into out.
+func decode(resp *http.Response, out interface{}) error {
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return InvalidResponseError{StatusCode: resp.StatusCode, Body: string(b)}
+	}
+	return nil
+}
+
+// escape returns the path of the segments, each of them escaped.
+func escape(segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+	return "/" + strings.Join(escaped, "/")
+}
@@ -0,0 +1,52 @@
+package client_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Client Suite")
+}
+
+// server is a fake server recording the last request, and responding with the status, content type and body.
+type server struct {
+	*httptest.Server
+
+	request     *http.Request
+	body        string
+	header      http.Header
+	status      int
+	contentType string
+	response    string
+}
+
+func newServer() *server {
+	s := &server{status: http.StatusOK, contentType: "text/plain"}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		s.request = r
+		s.body = string(b)
+
+		for key, values := range s.header {
+			w.Header()[key] = values
+		}
+		w.Header().Set("Content-Type", s.contentType)
+		w.WriteHeader(s.status)
+		w.Write([]byte(s.response))
+	}))
+	return s
+}
+
+func (s *server) respondJSON(status int, body string) {
+	s.status = status
+	s.contentType = "application/json; charset=utf-8"
+	s.response = body
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DeployRequest is the JSON request of a deployment, with one of an ArtifactURL, a Git repository or Maven
+// coordinates. The manifests are base64 encoded or inline YAML, and replace the manifest of the artifact unless
+// MergeManifest is set.
+type DeployRequest struct {
+	ArtifactURL          string              `json:"artifact_url,omitempty"`
+	Git                  *S.GitSource        `json:"git,omitempty"`
+	Maven                *S.MavenCoordinates `json:"maven,omitempty"`
+	Manifest             string              `json:"manifest,omitempty"`
+	Manifests            map[string]string   `json:"manifests,omitempty"`
+	ManifestName         string              `json:"manifest_name,omitempty"`
+	MergeManifest        bool                `json:"merge_manifest,omitempty"`
+	EnvironmentVariables map[string]string   `json:"environment_variables,omitempty"`
+	HealthCheckEndpoint  string              `json:"health_check_endpoint,omitempty"`
+	Routes               []S.Route           `json:"routes,omitempty"`
+	Data                 S.Params            `json:"data,omitempty"`
+	Metadata             map[string]string   `json:"metadata,omitempty"`
+	Signature            string              `json:"signature,omitempty"`
+	SignatureURL         string              `json:"signature_url,omitempty"`
+	SBOM                 *S.SBOM             `json:"sbom,omitempty"`
+	Provenance           *S.Provenance       `json:"provenance,omitempty"`
+}
+
+// StateRequest starts, stops, restarts or restages an application.
+type StateRequest struct {
+	State     string            `json:"state"`
+	Mode      S.StopMode        `json:"mode,omitempty"`
+	BatchSize int               `json:"batch_size,omitempty"`
+	Data      S.Params          `json:"data,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// DeleteRequest deletes an application. DeleteRoutes also deletes the routes no other application of the space is
+// mapped to.
+type DeleteRequest struct {
+	DeleteRoutes bool              `json:"-"`
+	Data         S.Params          `json:"data,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// BatchRequest deploys an application to the environments, one after the other.
+type BatchRequest struct {
+	Environments []string      `json:"environments"`
+	Deployment   DeployRequest `json:"deployment"`
+}
+
+// PromotionRequest deploys the artifact running in the Source environment to the Target environment.
+type PromotionRequest struct {
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Org     string `json:"org"`
+	Space   string `json:"space"`
+	AppName string `json:"app_name"`
+}
+
+// Options are the options of a request that deploys. A request with the IdempotencyKey of a previous request
+// returns the deployment of the previous request instead of deploying again.
+type Options struct {
+	IdempotencyKey string
+}
+
+// ZipOptions are the options of the deployment of a zip.
+type ZipOptions struct {
+	IdempotencyKey string
+	Signature      string
+	ManifestName   string
+}
+
+func (o Options) header() http.Header {
+	header := http.Header{}
+	if o.IdempotencyKey != "" {
+		header.Set(IdempotencyKeyHeader, o.IdempotencyKey)
+	}
+	return header
+}
+
+func (a App) path() string {
+	return "/v3/apps" + escape(a.Environment, a.Org, a.Space, a.Name)
+}
+
+// Deploy deploys the artifact of the request to the environment of the app, and returns the output of the deployment.
+// The output is also returned when the deployment fails, with a StatusError.
+func (c *Client) Deploy(ctx context.Context, app App, deployment DeployRequest, opts Options) (Output, error) {
+	r, err := jsonRequest("POST", app.path(), deployment)
+	if err != nil {
+		return Output{}, err
+	}
+	r.header = opts.header()
+	return c.output(ctx, r)
+}
+
+// DeployZip deploys the zip to the environment of the app, and returns the output of the deployment.
+func (c *Client) DeployZip(ctx context.Context, app App, zip io.Reader, opts ZipOptions) (Output, error) {
+	header := Options{IdempotencyKey: opts.IdempotencyKey}.header()
+	if opts.Signature != "" {
+		header.Set(SignatureHeader, opts.Signature)
+	}
+	if opts.ManifestName != "" {
+		header.Set(ManifestNameHeader, opts.ManifestName)
+	}
+	return c.output(ctx, request{method: "POST", path: app.path(), header: header, body: zip, contentType: "application/zip"})
+}
+
+// SetState starts, stops, restarts or restages the app, and returns the output of the change.
+func (c *Client) SetState(ctx context.Context, app App, state StateRequest, opts Options) (Output, error) {
+	r, err := jsonRequest("PUT", app.path(), state)
+	if err != nil {
+		return Output{}, err
+	}
+	r.header = opts.header()
+	return c.output(ctx, r)
+}
+
+// Delete deletes the app from every foundation of its environment, and returns the output of the delete.
+func (c *Client) Delete(ctx context.Context, app App, deletion DeleteRequest, opts Options) (Output, error) {
+	r, err := jsonRequest("DELETE", app.path(), deletion)
+	if err != nil {
+		return Output{}, err
+	}
+	if deletion.DeleteRoutes {
+		r.query = url.Values{"delete_routes": {"true"}}
+	}
+	r.header = opts.header()
+	return c.output(ctx, r)
+}
+
+// Batch deploys the application to the environments of the batch in order, and returns the report of the batch. The
+// report is also returned when a deployment of the batch does not succeed, with a StatusError.
+func (c *Client) Batch(ctx context.Context, org, space, appName string, batch BatchRequest, opts Options) (S.BatchReport, error) {
+	r, err := jsonRequest("POST", "/v3/batches"+escape(org, space, appName), batch)
+	if err != nil {
+		return S.BatchReport{}, err
+	}
+	r.header = opts.header()
+
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return S.BatchReport{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 && !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return S.BatchReport{}, statusError(resp)
+	}
+
+	report := S.BatchReport{}
+	if err := decode(resp, &report); err != nil {
+		return S.BatchReport{}, err
+	}
+	if resp.StatusCode/100 != 2 {
+		failure := StatusError{StatusCode: resp.StatusCode}
+		for _, deployment := range report.Deployments {
+			if deployment.Error != "" {
+				failure.Body = deployment.Environment + ": " + deployment.Error
+				break
+			}
+		}
+		return report, failure
+	}
+	return report, nil
+}
+
+// Promote deploys the artifact running in the source environment to the target environment, and returns the
+// output of the deployment.
+func (c *Client) Promote(ctx context.Context, promotion PromotionRequest, opts Options) (Output, error) {
+	r, err := jsonRequest("POST", "/v3/promotions", promotion)
+	if err != nil {
+		return Output{}, err
+	}
+	r.header = opts.header()
+	return c.output(ctx, r)
+}
+
+// Retry deploys a failed deployment again with its request, only to the foundations it failed against when
+// failedFoundations is true, and returns the output of the new deployment.
+func (c *Client) Retry(ctx context.Context, uuid string, failedFoundations bool, opts Options) (Output, error) {
+	r := request{method: "POST", path: "/v3/deployments" + escape(uuid, "retry"), header: opts.header()}
+	if failedFoundations {
+		r.query = url.Values{"failed_foundations": {"true"}}
+	}
+	return c.output(ctx, r)
+}
+
+// RetryFoundations deploys a deployment again to the foundations it did not succeed on, and returns the output of
+// the new deployment.
+func (c *Client) RetryFoundations(ctx context.Context, uuid string, opts Options) (Output, error) {
+	return c.output(ctx, request{method: "POST", path: "/v3/deployments" + escape(uuid, "foundations", "retry"), header: opts.header()})
+}
+
+// Cancel cancels the running deployment, which then rolls back.
+func (c *Client) Cancel(ctx context.Context, uuid string) error {
+	_, err := c.output(ctx, request{method: "POST", path: "/v3/deployments" + escape(uuid, "cancel")})
+	return err
+}
+
+// PromoteCanary promotes the canary of the running deployment to every instance.
+func (c *Client) PromoteCanary(ctx context.Context, uuid string) error {
+	_, err := c.output(ctx, request{method: "POST", path: "/v3/deployments" + escape(uuid, "canary", "promote")})
+	return err
+}
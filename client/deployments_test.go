@@ -0,0 +1,210 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	. "github.com/compozed/deployadactyl/client"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deployments", func() {
+	var (
+		fake   *server
+		client *Client
+		ctx    context.Context
+		app    App
+	)
+
+	BeforeEach(func() {
+		fake = newServer()
+		client = NewClient(fake.URL+"/", "cf-username", "cf-password")
+		ctx = context.Background()
+		app = App{Environment: "prod", Org: "search", Space: "production", Name: "indexer"}
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	Describe("Deploy", func() {
+		It("posts the JSON of the request with the credentials and returns the output", func() {
+			fake.response = "deployment succeeded\n"
+			fake.header = http.Header{"X-Deployadactyl-Deployment-Id": {"uuid-1"}}
+
+			output, err := client.Deploy(ctx, app, DeployRequest{ArtifactURL: "https://example.com/indexer.jar", Metadata: map[string]string{"pipeline_id": "1234"}}, Options{IdempotencyKey: "build-42"})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(Equal(Output{UUID: "uuid-1", StatusCode: http.StatusOK, Text: "deployment succeeded\n"}))
+			Expect(fake.request.Method).To(Equal("POST"))
+			Expect(fake.request.URL.Path).To(Equal("/v3/apps/prod/search/production/indexer"))
+			Expect(fake.request.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(fake.request.Header.Get("Idempotency-Key")).To(Equal("build-42"))
+			Expect(fake.body).To(MatchJSON(`{"artifact_url": "https://example.com/indexer.jar", "metadata": {"pipeline_id": "1234"}}`))
+
+			username, password, ok := fake.request.BasicAuth()
+			Expect(ok).To(BeTrue())
+			Expect(username).To(Equal("cf-username"))
+			Expect(password).To(Equal("cf-password"))
+		})
+
+		It("returns the output of a failed deployment with a StatusError", func() {
+			fake.status = http.StatusInternalServerError
+			fake.response = "push failed\n"
+
+			output, err := client.Deploy(ctx, app, DeployRequest{ArtifactURL: "https://example.com/indexer.jar"}, Options{})
+
+			Expect(output.Text).To(Equal("push failed\n"))
+			Expect(err).To(MatchError(StatusError{StatusCode: http.StatusInternalServerError, Body: "push failed"}))
+		})
+
+		It("sends the token of the tenant", func() {
+			client.Token = "search-token"
+
+			_, err := client.Deploy(ctx, app, DeployRequest{}, Options{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fake.request.Header.Get("X-Deployadactyl-Token")).To(Equal("search-token"))
+		})
+
+		It("escapes the segments of the path", func() {
+			app.Space = "team space"
+
+			_, err := client.Deploy(ctx, app, DeployRequest{}, Options{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fake.request.URL.EscapedPath()).To(Equal("/v3/apps/prod/search/team%20space/indexer"))
+		})
+	})
+
+	Describe("DeployZip", func() {
+		It("posts the zip with its signature and manifest name", func() {
+			_, err := client.DeployZip(ctx, app, strings.NewReader("zip"), ZipOptions{Signature: "signature", ManifestName: "blue"})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fake.body).To(Equal("zip"))
+			Expect(fake.request.Header.Get("Content-Type")).To(Equal("application/zip"))
+			Expect(fake.request.Header.Get("X-Deployadactyl-Signature")).To(Equal("signature"))
+			Expect(fake.request.Header.Get("X-Deployadactyl-Manifest-Name")).To(Equal("blue"))
+		})
+	})
+
+	Describe("SetState", func() {
+		It("puts the state of the app", func() {
+			_, err := client.SetState(ctx, app, StateRequest{State: "stopped", Mode: S.StopModeScaleToZero}, Options{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fake.request.Method).To(Equal("PUT"))
+			Expect(fake.request.URL.Path).To(Equal("/v3/apps/prod/search/production/indexer"))
+			Expect(fake.body).To(MatchJSON(`{"state": "stopped", "mode": "scale_to_zero"}`))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("deletes the app", func() {
+			_, err := client.Delete(ctx, app, DeleteRequest{Metadata: map[string]string{"pipeline_id": "1234"}}, Options{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fake.request.Method).To(Equal("DELETE"))
+			Expect(fake.request.URL.Path).To(Equal("/v3/apps/prod/search/production/indexer"))
+			Expect(fake.request.URL.RawQuery).To(BeEmpty())
+			Expect(fake.body).To(MatchJSON(`{"metadata": {"pipeline_id": "1234"}}`))
+		})
+
+		It("deletes the routes of the app", func() {
+			_, err := client.Delete(ctx, app, DeleteRequest{DeleteRoutes: true}, Options{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fake.request.URL.Query().Get("delete_routes")).To(Equal("true"))
+		})
+	})
+
+	Describe("Batch", func() {
+		It("returns the report of the batch", func() {
+			fake.respondJSON(http.StatusOK, `{"batch_id": "batch-1", "status": "succeeded", "deployments": [{"environment": "qa", "uuid": "uuid-1", "status": "succeeded"}]}`)
+
+			report, err := client.Batch(ctx, "search", "production", "indexer", BatchRequest{Environments: []string{"qa"}, Deployment: DeployRequest{ArtifactURL: "https://example.com/indexer.jar"}}, Options{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.BatchID).To(Equal("batch-1"))
+			Expect(report.Deployments).To(Equal([]S.BatchDeployment{{Environment: "qa", UUID: "uuid-1", Status: "succeeded"}}))
+			Expect(fake.request.URL.Path).To(Equal("/v3/batches/search/production/indexer"))
+			Expect(fake.body).To(MatchJSON(`{"environments": ["qa"], "deployment": {"artifact_url": "https://example.com/indexer.jar"}}`))
+		})
+
+		It("returns the report of a failed batch with a StatusError", func() {
+			fake.respondJSON(http.StatusInternalServerError, `{"batch_id": "batch-1", "status": "failed", "deployments": [{"environment": "qa", "status": "failed", "error": "push failed"}, {"environment": "prod", "status": "skipped"}]}`)
+
+			report, err := client.Batch(ctx, "search", "production", "indexer", BatchRequest{}, Options{})
+
+			Expect(err).To(MatchError(StatusError{StatusCode: http.StatusInternalServerError, Body: "qa: push failed"}))
+			Expect(report.Deployments).To(HaveLen(2))
+		})
+
+		It("returns a StatusError when the batch is refused", func() {
+			fake.status = http.StatusBadRequest
+			fake.response = "no environments"
+
+			_, err := client.Batch(ctx, "search", "production", "indexer", BatchRequest{}, Options{})
+
+			Expect(err).To(MatchError(StatusError{StatusCode: http.StatusBadRequest, Body: "no environments"}))
+		})
+	})
+
+	Describe("Promote", func() {
+		It("posts the promotion", func() {
+			_, err := client.Promote(ctx, PromotionRequest{Source: "qa", Target: "prod", Org: "search", Space: "production", AppName: "indexer"}, Options{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fake.request.URL.Path).To(Equal("/v3/promotions"))
+			Expect(fake.body).To(MatchJSON(`{"source": "qa", "target": "prod", "org": "search", "space": "production", "app_name": "indexer"}`))
+		})
+	})
+
+	Describe("Retry", func() {
+		It("retries the deployment on its failed foundations", func() {
+			_, err := client.Retry(ctx, "uuid-1", true, Options{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fake.request.URL.Path).To(Equal("/v3/deployments/uuid-1/retry"))
+			Expect(fake.request.URL.Query().Get("failed_foundations")).To(Equal("true"))
+		})
+
+		It("retries the foundations of the deployment", func() {
+			_, err := client.RetryFoundations(ctx, "uuid-1", Options{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fake.request.URL.Path).To(Equal("/v3/deployments/uuid-1/foundations/retry"))
+		})
+	})
+
+	Describe("Cancel", func() {
+		It("cancels the deployment", func() {
+			fake.status = http.StatusAccepted
+
+			Expect(client.Cancel(ctx, "uuid-1")).To(Succeed())
+			Expect(fake.request.Method).To(Equal("POST"))
+			Expect(fake.request.URL.Path).To(Equal("/v3/deployments/uuid-1/cancel"))
+		})
+
+		It("returns a StatusError when the deployment is not running", func() {
+			fake.status = http.StatusConflict
+			fake.response = "deployment uuid-1 is not running\n"
+
+			Expect(client.Cancel(ctx, "uuid-1")).To(MatchError(StatusError{StatusCode: http.StatusConflict, Body: "deployment uuid-1 is not running"}))
+		})
+	})
+
+	Describe("PromoteCanary", func() {
+		It("promotes the canary of the deployment", func() {
+			fake.status = http.StatusAccepted
+
+			Expect(client.PromoteCanary(ctx, "uuid-1")).To(Succeed())
+			Expect(fake.request.Method).To(Equal("POST"))
+			Expect(fake.request.URL.Path).To(Equal("/v3/deployments/uuid-1/canary/promote"))
+		})
+	})
+})
@@ -0,0 +1,26 @@
+package client
+
+import "fmt"
+
+// StatusError is returned when the server responds with a status that is not 2xx.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("the server responded with %d", e.StatusCode)
+	}
+	return fmt.Sprintf("the server responded with %d: %s", e.StatusCode, e.Body)
+}
+
+// InvalidResponseError is returned when the response of the server is not the JSON it should be.
+type InvalidResponseError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e InvalidResponseError) Error() string {
+	return fmt.Sprintf("the response of the server with %d is not valid JSON: %s", e.StatusCode, e.Body)
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Query selects the deployments of the history. Empty fields select every deployment.
+type Query struct {
+	Environment string
+	Org         string
+	Space       string
+	AppName     string
+	Status      string
+
+	// Component and Version select the deployments whose SBOM has the component, with a version starting with Version.
+	Component string
+	Version   string
+
+	// Current selects the deployments still running on a foundation.
+	Current bool
+	Limit   int
+}
+
+func (q Query) values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	set("environment", q.Environment)
+	set("org", q.Org)
+	set("space", q.Space)
+	set("app", q.AppName)
+	set("status", q.Status)
+	set("component", q.Component)
+	set("version", q.Version)
+	if q.Current {
+		values.Set("current", "true")
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return values
+}
+
+// Deployments returns the deployments of the history selected by the query, newest first.
+func (c *Client) Deployments(ctx context.Context, query Query) ([]S.DeploymentRecord, error) {
+	records := []S.DeploymentRecord{}
+	err := c.json(ctx, request{method: "GET", path: "/v3/deployments", query: query.values()}, &records)
+	return records, err
+}
+
+// Deployment returns the deployment, with its progress while it runs.
+func (c *Client) Deployment(ctx context.Context, uuid string) (S.DeploymentRecord, error) {
+	record := S.DeploymentRecord{}
+	err := c.json(ctx, request{method: "GET", path: "/v3/deployments" + escape(uuid)}, &record)
+	return record, err
+}
+
+// DeploymentLog returns the output of the deployment.
+func (c *Client) DeploymentLog(ctx context.Context, uuid string) (string, error) {
+	output, err := c.output(ctx, request{method: "GET", path: "/v3/deployments" + escape(uuid, "logs")})
+	if err != nil {
+		return "", err
+	}
+	return output.Text, nil
+}
+
+// Versions returns the artifact running on every foundation the application was deployed to, only in the
+// environment when it is not empty.
+func (c *Client) Versions(ctx context.Context, org, space, appName, environment string) ([]S.DeployedVersion, error) {
+	r := request{method: "GET", path: "/v3/versions" + escape(org, space, appName)}
+	if environment != "" {
+		r.query = url.Values{"environment": {environment}}
+	}
+
+	versions := []S.DeployedVersion{}
+	err := c.json(ctx, r, &versions)
+	return versions, err
+}
+
+// Drift compares the application on every foundation of one or two environments.
+func (c *Client) Drift(ctx context.Context, org, space, appName string, environments ...string) (S.DriftReport, error) {
+	r := request{
+		method: "GET",
+		path:   "/v3/drift" + escape(org, space, appName),
+		query:  url.Values{"environments": {strings.Join(environments, ",")}},
+	}
+
+	report := S.DriftReport{}
+	err := c.json(ctx, r, &report)
+	return report, err
+}
@@ -0,0 +1,99 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+
+	. "github.com/compozed/deployadactyl/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+	var (
+		fake   *server
+		client *Client
+		ctx    context.Context
+	)
+
+	BeforeEach(func() {
+		fake = newServer()
+		client = NewClient(fake.URL, "cf-username", "cf-password")
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("queries the deployments", func() {
+		fake.respondJSON(http.StatusOK, `[{"uuid": "uuid-1", "app_name": "indexer", "status": "succeeded"}]`)
+
+		records, err := client.Deployments(ctx, Query{Environment: "prod", AppName: "indexer", Current: true, Limit: 5})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].UUID).To(Equal("uuid-1"))
+		Expect(fake.request.URL.Path).To(Equal("/v3/deployments"))
+		Expect(fake.request.URL.RawQuery).To(Equal("app=indexer&current=true&environment=prod&limit=5"))
+	})
+
+	It("returns a deployment", func() {
+		fake.respondJSON(http.StatusOK, `{"uuid": "uuid-1", "progress": {"uuid": "uuid-1", "phase": "executing", "percent": 50}}`)
+
+		record, err := client.Deployment(ctx, "uuid-1")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record.Progress.Percent).To(Equal(50))
+		Expect(fake.request.URL.Path).To(Equal("/v3/deployments/uuid-1"))
+	})
+
+	It("returns a StatusError when the deployment is not found", func() {
+		fake.status = http.StatusNotFound
+		fake.response = "deployment uuid-1 not found\n"
+
+		_, err := client.Deployment(ctx, "uuid-1")
+
+		Expect(err).To(MatchError(StatusError{StatusCode: http.StatusNotFound, Body: "deployment uuid-1 not found"}))
+	})
+
+	It("returns an InvalidResponseError when the response is not JSON", func() {
+		fake.response = "<html>"
+
+		_, err := client.Deployment(ctx, "uuid-1")
+
+		Expect(err).To(MatchError(InvalidResponseError{StatusCode: http.StatusOK, Body: "<html>"}))
+	})
+
+	It("returns the log of a deployment", func() {
+		fake.response = "deployment succeeded\n"
+
+		output, err := client.DeploymentLog(ctx, "uuid-1")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal("deployment succeeded\n"))
+		Expect(fake.request.URL.Path).To(Equal("/v3/deployments/uuid-1/logs"))
+	})
+
+	It("returns the deployed versions of an environment", func() {
+		fake.respondJSON(http.StatusOK, `[{"environment": "prod", "foundation_url": "https://api.east.example.com", "version": "1.2.3"}]`)
+
+		versions, err := client.Versions(ctx, "search", "production", "indexer", "prod")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(versions[0].Version).To(Equal("1.2.3"))
+		Expect(fake.request.URL.Path).To(Equal("/v3/versions/search/production/indexer"))
+		Expect(fake.request.URL.Query().Get("environment")).To(Equal("prod"))
+	})
+
+	It("compares the environments", func() {
+		fake.respondJSON(http.StatusOK, `{"drifted": true, "differences": [{"field": "instances", "values": []}]}`)
+
+		report, err := client.Drift(ctx, "search", "production", "indexer", "qa", "prod")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Drifted).To(BeTrue())
+		Expect(fake.request.URL.Path).To(Equal("/v3/drift/search/production/indexer"))
+		Expect(fake.request.URL.Query().Get("environments")).To(Equal("qa,prod"))
+	})
+})
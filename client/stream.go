@@ -0,0 +1,113 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// ProgressEvent is the name of the server-sent events carrying the progress of a deployment.
+const ProgressEvent = "progress"
+
+// EventFilter selects the streamed events. Empty fields select every event.
+type EventFilter struct {
+	Environments []string
+	AppNames     []string
+	Types        []string
+}
+
+// StreamProgress calls handle with the progress of the deployment every time it changes, until the deployment is
+// finished or ctx is done.
+func (c *Client) StreamProgress(ctx context.Context, uuid string, handle func(S.DeploymentProgress)) error {
+	return c.stream(ctx, request{method: "GET", path: "/v3/deployments" + escape(uuid, "progress")}, func(event, data string) error {
+		if event != ProgressEvent {
+			return nil
+		}
+		progress := S.DeploymentProgress{}
+		if err := json.Unmarshal([]byte(data), &progress); err != nil {
+			return InvalidResponseError{StatusCode: 200, Body: data}
+		}
+		handle(progress)
+		return nil
+	})
+}
+
+// StreamEvents calls handle with every event selected by the filter, until ctx is done or the server closes the
+// stream.
+func (c *Client) StreamEvents(ctx context.Context, filter EventFilter, handle func(S.StreamedEvent)) error {
+	query := url.Values{"environment": filter.Environments, "app": filter.AppNames, "type": filter.Types}
+	for key, values := range query {
+		if len(values) == 0 {
+			delete(query, key)
+		}
+	}
+
+	return c.stream(ctx, request{method: "GET", path: "/v3/events/stream", query: query}, func(event, data string) error {
+		streamed := S.StreamedEvent{}
+		if err := json.Unmarshal([]byte(data), &streamed); err != nil {
+			return InvalidResponseError{StatusCode: 200, Body: data}
+		}
+		handle(streamed)
+		return nil
+	})
+}
+
+// stream sends the request and calls handle with the name and data of every server-sent event of the response.
+// It returns the error of ctx once ctx is done.
+func (c *Client) stream(ctx context.Context, r request, handle func(event, data string) error) error {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return statusError(resp)
+	}
+
+	err = readEvents(resp.Body, handle)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// readEvents reads the server-sent events until the end of the reader. Comments, such as keep-alives, and events
+// without data are skipped.
+func readEvents(reader io.Reader, handle func(event, data string) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if err := handle(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+		default:
+			field, value := line, ""
+			if i := strings.Index(line, ":"); i >= 0 {
+				field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+			}
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+		}
+	}
+	return scanner.Err()
+}
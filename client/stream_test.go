@@ -0,0 +1,93 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/compozed/deployadactyl/client"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Streams", func() {
+	var (
+		fake   *server
+		client *Client
+		ctx    context.Context
+	)
+
+	BeforeEach(func() {
+		fake = newServer()
+		fake.contentType = "text/event-stream"
+		client = NewClient(fake.URL, "cf-username", "cf-password")
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("streams the progress of a deployment until it is finished", func() {
+		fake.response = ": keep-alive\n\n" +
+			"event: progress\ndata: {\"uuid\": \"uuid-1\", \"percent\": 50}\n\n" +
+			"event: progress\ndata: {\"uuid\": \"uuid-1\", \"percent\": 100, \"finished\": true}\n\n"
+
+		var updates []S.DeploymentProgress
+		err := client.StreamProgress(ctx, "uuid-1", func(progress S.DeploymentProgress) {
+			updates = append(updates, progress)
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updates).To(HaveLen(2))
+		Expect(updates[0].Percent).To(Equal(50))
+		Expect(updates[1].Finished).To(BeTrue())
+		Expect(fake.request.URL.Path).To(Equal("/v3/deployments/uuid-1/progress"))
+	})
+
+	It("streams the events selected by the filter", func() {
+		fake.response = "data: {\"type\": \"deploy.success\", \"app_name\": \"indexer\"}\n\n"
+
+		var events []S.StreamedEvent
+		err := client.StreamEvents(ctx, EventFilter{Environments: []string{"prod"}, Types: []string{"deploy.success", "deploy.failure"}}, func(event S.StreamedEvent) {
+			events = append(events, event)
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(Equal([]S.StreamedEvent{{Type: "deploy.success", AppName: "indexer"}}))
+		Expect(fake.request.URL.Path).To(Equal("/v3/events/stream"))
+		Expect(fake.request.URL.Query()["type"]).To(Equal([]string{"deploy.success", "deploy.failure"}))
+		Expect(fake.request.URL.Query()).ToNot(HaveKey("app"))
+	})
+
+	It("returns the error of the context once it is done", func() {
+		sent := make(chan struct{})
+		stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "data: {\"type\": \"deploy.start\"}\n\n")
+			w.(http.Flusher).Flush()
+			close(sent)
+			<-r.Context().Done()
+		}))
+		defer stream.Close()
+		client.URL = stream.URL
+
+		ctx, cancel := context.WithCancel(ctx)
+		err := client.StreamEvents(ctx, EventFilter{}, func(S.StreamedEvent) {
+			<-sent
+			cancel()
+		})
+
+		Expect(err).To(Equal(context.Canceled))
+	})
+
+	It("returns a StatusError when the stream is refused", func() {
+		fake.status = http.StatusNotFound
+		fake.response = "deployment progress is not enabled"
+
+		err := client.StreamProgress(ctx, "uuid-1", func(S.DeploymentProgress) {})
+
+		Expect(err).To(MatchError(StatusError{StatusCode: http.StatusNotFound, Body: "deployment progress is not enabled"}))
+	})
+})
@@ -2,16 +2,37 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/cloudfoundry-incubator/candiedyaml"
+	"github.com/compozed/deployadactyl/artifetcher/artifactory"
+	"github.com/compozed/deployadactyl/artifetcher/bucket"
+	"github.com/compozed/deployadactyl/artifetcher/git"
+	"github.com/compozed/deployadactyl/artifetcher/maven"
+	"github.com/compozed/deployadactyl/cabundle"
 	"github.com/compozed/deployadactyl/controller/deployer/error_finder"
+	"github.com/compozed/deployadactyl/deploymentid"
 	"github.com/compozed/deployadactyl/geterrors"
+	"github.com/compozed/deployadactyl/hooks"
 	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/ldap"
+	"github.com/compozed/deployadactyl/leader"
+	"github.com/compozed/deployadactyl/logsink"
+	"github.com/compozed/deployadactyl/oidc"
+	"github.com/compozed/deployadactyl/publisher"
+	"github.com/compozed/deployadactyl/rbac"
 	s "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tlspin"
+	"github.com/compozed/deployadactyl/tracing"
+	"github.com/compozed/deployadactyl/vault"
+	"github.com/compozed/deployadactyl/webhook"
 )
 
 const defaultConfigPath = "./config.yml"
@@ -23,11 +44,120 @@ type Config struct {
 	Environments  map[string]s.Environment
 	Port          int
 	ErrorMatchers []interfaces.ErrorMatcher
+
+	// DeploymentHistory configures where the record of every deployment is kept.
+	DeploymentHistory s.HistoryDescriptor
+
+	// DeploymentLogs configures where the output of every deployment is kept.
+	DeploymentLogs s.DeploymentLogsDescriptor
+
+	// TLSPins are the certificate pins of foundation and application hosts.
+	TLSPins tlspin.Pins
+
+	// CommandTimeouts are the seconds a cf command may run before it is killed, keyed by the command.
+	// The "default" timeout is used by commands without one.
+	CommandTimeouts map[string]int
+
+	// CFCLI is the path of the Cloud Foundry CLI. The cf in the PATH is used when it is empty.
+	CFCLI string
+
+	// Courier is how Cloud Foundry commands are run on the foundations of environments that do not set a courier,
+	// and by the background jobs: cli, the default, or api.
+	Courier string
+
+	// WorkDirectory is where artifacts are downloaded and extracted.
+	// The temporary directory of the operating system is used when it is empty.
+	WorkDirectory string
+
+	// DeploymentID configures how the identifiers of deployments are generated.
+	DeploymentID deploymentid.Format
+
+	// LogSinks are where the logs of the server are written. They are written to stdout when there are none.
+	LogSinks []s.LogSinkDescriptor
+
+	// LifecycleHooks are the local commands run at the phases of every push.
+	LifecycleHooks []s.LifecycleHook
+
+	// StaleApps configures the periodic sweep for temporary applications left behind by deployments.
+	StaleApps s.StaleAppsDescriptor
+
+	// MaintenanceFoundations are the foundations that are in maintenance when the server starts.
+	// Deployments skip the foundations that are in maintenance.
+	MaintenanceFoundations []string
+
+	// ArtifactCache configures the cache of the artifacts of failed deployments.
+	ArtifactCache s.ArtifactCacheDescriptor
+
+	// Tenants are the teams sharing the server, keyed by name. Their environments are also in the Environments.
+	Tenants map[string]s.Tenant
+
+	// DebugEndpoints exposes the pprof profiles, goroutine dumps and runtime stats of the server to requests with
+	// its credentials.
+	DebugEndpoints bool
+
+	// Limits configures how large requests and deployment outputs may grow.
+	Limits s.LimitsDescriptor
+
+	// EventPublishers are the message brokers every deployment event is published to.
+	EventPublishers []s.EventPublisherDescriptor
+
+	// DeploymentLocks configures where the locks of the applications being deployed are held.
+	DeploymentLocks s.DeploymentLocksDescriptor
+
+	// LeaderElection configures how the instance running the background jobs is elected.
+	LeaderElection s.LeaderElectionDescriptor
+
+	// SharedState configures the state the instances of the server share.
+	SharedState s.SharedStateDescriptor
+
+	// Tracing configures where the spans of every deployment are exported.
+	Tracing s.TracingDescriptor
+
+	// Slack configures the messages posted to Slack when a deployment starts, succeeds or fails.
+	Slack s.SlackDescriptor
+
+	// Vault configures the HashiCorp Vault the credentials of the environments with a vault_path are read from.
+	Vault s.VaultDescriptor
+
+	// CredentialStore reads the credentials of the environments with a vault_path. It is set by the creator, as it
+	// talks to Vault, and is nil without Vault.
+	CredentialStore interfaces.CredentialStore
+
+	// LDAP configures the server the basic auth of the requests to the environments with ldap is checked against.
+	LDAP s.LDAPDescriptor
+
+	// OIDC configures the issuer of the bearer tokens accepted instead of basic auth.
+	OIDC s.OIDCDescriptor
 }
 
 type configYaml struct {
-	Environments       []s.Environment            `yaml:",flow"`
-	MatcherDescriptors []s.ErrorMatcherDescriptor `yaml:"error_matchers,flow"`
+	Environments           []s.Environment              `yaml:",flow"`
+	MatcherDescriptors     []s.ErrorMatcherDescriptor   `yaml:"error_matchers,flow"`
+	DeploymentHistory      s.HistoryDescriptor          `yaml:"deployment_history"`
+	DeploymentLogs         s.DeploymentLogsDescriptor   `yaml:"deployment_logs"`
+	TLSPins                tlspin.Pins                  `yaml:"tls_pins"`
+	CommandTimeouts        map[string]int               `yaml:"command_timeouts"`
+	WorkDirectory          string                       `yaml:"work_directory"`
+	CFCLI                  string                       `yaml:"cf_cli"`
+	Courier                string                       `yaml:"courier"`
+	DeploymentID           deploymentid.Format          `yaml:"deployment_id"`
+	LogSinks               []s.LogSinkDescriptor        `yaml:"log_sinks,flow"`
+	LifecycleHooks         []s.LifecycleHook            `yaml:"lifecycle_hooks,flow"`
+	StaleApps              s.StaleAppsDescriptor        `yaml:"stale_apps"`
+	MaintenanceFoundations []string                     `yaml:"maintenance_foundations,flow"`
+	ArtifactCache          s.ArtifactCacheDescriptor    `yaml:"artifact_cache"`
+	Tenants                []s.Tenant                   `yaml:"tenants,flow"`
+	DebugEndpoints         bool                         `yaml:"debug_endpoints"`
+	Limits                 s.LimitsDescriptor           `yaml:"limits"`
+	EventPublishers        []s.EventPublisherDescriptor `yaml:"event_publishers,flow"`
+	DeploymentLocks        s.DeploymentLocksDescriptor  `yaml:"deployment_locks"`
+	LeaderElection         s.LeaderElectionDescriptor   `yaml:"leader_election"`
+	SharedState            s.SharedStateDescriptor      `yaml:"shared_state"`
+	Tracing                s.TracingDescriptor          `yaml:"tracing"`
+	Slack                  s.SlackDescriptor            `yaml:"slack"`
+	Vault                  s.VaultDescriptor            `yaml:"vault"`
+	LDAP                   s.LDAPDescriptor             `yaml:"ldap"`
+	OIDC                   s.OIDCDescriptor             `yaml:"oidc"`
 }
 
 type foundationYaml struct {
@@ -46,7 +176,12 @@ func Custom(getenv func(string) string, configPath string) (Config, error) {
 		return Config{}, err
 	}
 
-	environments, err := getEnvironmentsFromConfig(foundationConfig)
+	environments, err := getEnvironmentsFromConfig(getenv, foundationConfig)
+	if err != nil {
+		return Config{}, err
+	}
+
+	tenants, err := getTenantsFromConfig(getenv, foundationConfig, environments)
 	if err != nil {
 		return Config{}, err
 	}
@@ -56,7 +191,255 @@ func Custom(getenv func(string) string, configPath string) (Config, error) {
 		return Config{}, err
 	}
 
-	return createConfig(getenv, environments, errormatchers)
+	config, err := createConfig(getenv, environments, errormatchers)
+	if err != nil {
+		return Config{}, err
+	}
+	config.Tenants = tenants
+
+	deploymentHistory := foundationConfig.DeploymentHistory
+	if deploymentHistory.File != "" && deploymentHistory.Postgres != "" {
+		return Config{}, ConflictingStorageError{"deployment_history", "file"}
+	}
+	deploymentHistory.Postgres = os.Expand(deploymentHistory.Postgres, getenv)
+	config.DeploymentHistory = deploymentHistory
+
+	logs := foundationConfig.DeploymentLogs
+	if logs.MaxLogs < 0 || logs.MaxAgeDays < 0 {
+		return Config{}, InvalidDeploymentLogsError{logs.MaxLogs, logs.MaxAgeDays}
+	}
+	if logs.Directory != "" && logs.Postgres != "" {
+		return Config{}, ConflictingStorageError{"deployment_logs", "directory"}
+	}
+	logs.Postgres = os.Expand(logs.Postgres, getenv)
+	config.DeploymentLogs = logs
+
+	err = foundationConfig.TLSPins.Validate()
+	if err != nil {
+		return Config{}, err
+	}
+	config.TLSPins = foundationConfig.TLSPins
+
+	for command, seconds := range foundationConfig.CommandTimeouts {
+		if seconds <= 0 {
+			return Config{}, InvalidCommandTimeoutError{command, seconds}
+		}
+	}
+	config.CommandTimeouts = foundationConfig.CommandTimeouts
+
+	if foundationConfig.WorkDirectory != "" {
+		info, err := os.Stat(foundationConfig.WorkDirectory)
+		if err != nil {
+			return Config{}, InvalidWorkDirectoryError{foundationConfig.WorkDirectory, err}
+		}
+		if !info.IsDir() {
+			return Config{}, InvalidWorkDirectoryError{foundationConfig.WorkDirectory, errors.New("not a directory")}
+		}
+	}
+	config.WorkDirectory = foundationConfig.WorkDirectory
+	config.CFCLI = foundationConfig.CFCLI
+
+	config.Courier = foundationConfig.Courier
+	if config.Courier == "" {
+		config.Courier = s.CourierCLI
+	}
+	if !contains(s.Couriers, config.Courier) {
+		return Config{}, UnknownCourierError{Courier: config.Courier}
+	}
+
+	err = foundationConfig.DeploymentID.Validate()
+	if err != nil {
+		return Config{}, err
+	}
+	config.DeploymentID = foundationConfig.DeploymentID
+
+	err = logsink.Validate(foundationConfig.LogSinks)
+	if err != nil {
+		return Config{}, err
+	}
+	config.LogSinks = foundationConfig.LogSinks
+
+	err = hooks.Validate(foundationConfig.LifecycleHooks)
+	if err != nil {
+		return Config{}, err
+	}
+	config.LifecycleHooks = foundationConfig.LifecycleHooks
+
+	staleApps := foundationConfig.StaleApps
+	if staleApps.IntervalMinutes < 0 || staleApps.MaxAgeMinutes < 0 {
+		return Config{}, InvalidStaleAppsError{staleApps.IntervalMinutes, staleApps.MaxAgeMinutes}
+	}
+	config.StaleApps = staleApps
+
+	for _, foundationURL := range foundationConfig.MaintenanceFoundations {
+		if !config.HasFoundation(foundationURL) {
+			return Config{}, UnknownMaintenanceFoundationError{foundationURL}
+		}
+	}
+	config.MaintenanceFoundations = foundationConfig.MaintenanceFoundations
+
+	if foundationConfig.ArtifactCache.MaxAgeMinutes < 0 {
+		return Config{}, InvalidArtifactCacheError{foundationConfig.ArtifactCache.MaxAgeMinutes}
+	}
+	config.ArtifactCache = foundationConfig.ArtifactCache
+	config.DebugEndpoints = foundationConfig.DebugEndpoints
+
+	limits := foundationConfig.Limits
+	if limits.MaxRequestMB < 0 || limits.MaxUploadMB < 0 || limits.MaxResponseBufferMB < 0 {
+		return Config{}, InvalidLimitsError{limits}
+	}
+	config.Limits = limits
+
+	err = publisher.Validate(foundationConfig.EventPublishers)
+	if err != nil {
+		return Config{}, err
+	}
+	for _, eventPublisher := range foundationConfig.EventPublishers {
+		eventPublisher.SASL.Username = os.Expand(eventPublisher.SASL.Username, getenv)
+		eventPublisher.SASL.Password = os.Expand(eventPublisher.SASL.Password, getenv)
+		eventPublisher.Username = os.Expand(eventPublisher.Username, getenv)
+		eventPublisher.Password = os.Expand(eventPublisher.Password, getenv)
+		eventPublisher.Token = os.Expand(eventPublisher.Token, getenv)
+		config.EventPublishers = append(config.EventPublishers, eventPublisher)
+	}
+
+	locks := foundationConfig.DeploymentLocks
+	if locks.TTLSeconds < 0 {
+		return Config{}, InvalidDeploymentLocksError{locks.TTLSeconds}
+	}
+	locks.Redis = os.Expand(locks.Redis, getenv)
+	config.DeploymentLocks = locks
+
+	election := foundationConfig.LeaderElection
+	err = leader.Validate(election)
+	if err != nil {
+		return Config{}, err
+	}
+	election.URL = os.Expand(election.URL, getenv)
+	election.Token = os.Expand(election.Token, getenv)
+	config.LeaderElection = election
+
+	sharedState := foundationConfig.SharedState
+	sharedState.Redis = os.Expand(sharedState.Redis, getenv)
+	config.SharedState = sharedState
+
+	tracingDescriptor := foundationConfig.Tracing
+	err = tracing.Validate(tracingDescriptor)
+	if err != nil {
+		return Config{}, err
+	}
+	if len(tracingDescriptor.Headers) > 0 {
+		headers := map[string]string{}
+		for name, value := range tracingDescriptor.Headers {
+			headers[name] = os.Expand(value, getenv)
+		}
+		tracingDescriptor.Headers = headers
+	}
+	config.Tracing = tracingDescriptor
+
+	slack := foundationConfig.Slack
+	slack.WebhookURL = os.Expand(slack.WebhookURL, getenv)
+	if slack.WebhookURL != "" {
+		webhookURL, err := url.Parse(slack.WebhookURL)
+		if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+			return Config{}, InvalidSlackWebhookURLError{}
+		}
+	}
+	if slack.TimeoutSeconds < 0 {
+		return Config{}, InvalidSlackTimeoutError{slack.TimeoutSeconds}
+	}
+	config.Slack = slack
+
+	vaultDescriptor := foundationConfig.Vault
+	vaultDescriptor.Token = os.Expand(vaultDescriptor.Token, getenv)
+	vaultDescriptor.RoleID = os.Expand(vaultDescriptor.RoleID, getenv)
+	vaultDescriptor.SecretID = os.Expand(vaultDescriptor.SecretID, getenv)
+	err = vault.Validate(vaultDescriptor)
+	if err != nil {
+		return Config{}, err
+	}
+	for _, environment := range config.Environments {
+		if environment.VaultPath != "" && vaultDescriptor.Address == "" {
+			return Config{}, VaultPathWithoutVaultError{environment.Name}
+		}
+	}
+	config.Vault = vaultDescriptor
+
+	ldapDescriptor := foundationConfig.LDAP
+	ldapDescriptor.BindPassword = os.Expand(ldapDescriptor.BindPassword, getenv)
+	err = ldap.Validate(ldapDescriptor)
+	if err != nil {
+		return Config{}, err
+	}
+	for _, environment := range config.Environments {
+		if environment.LDAP && ldapDescriptor.URL == "" {
+			return Config{}, LDAPWithoutServerError{environment.Name}
+		}
+	}
+	config.LDAP = ldapDescriptor
+
+	err = oidc.Validate(foundationConfig.OIDC)
+	if err != nil {
+		return Config{}, err
+	}
+	config.OIDC = foundationConfig.OIDC
+
+	return config, nil
+}
+
+// Credentials returns the Cloud Foundry credentials of the deployments to the environment whose request has none:
+// those of its Vault secret when it has a vault_path, or else those of its tenant, or else those of the server.
+func (c Config) Credentials(environment s.Environment) (string, string, error) {
+	if environment.VaultPath == "" {
+		username, password := environment.Credentials(c.Username, c.Password)
+		return username, password, nil
+	}
+	if c.CredentialStore == nil {
+		return "", "", VaultPathWithoutVaultError{environment.Name}
+	}
+
+	username, password, err := c.CredentialStore.Credentials(environment.VaultPath)
+	if err != nil {
+		return "", "", CredentialsError{environment.Name, err}
+	}
+	return username, password, nil
+}
+
+// EnvironmentCourier returns the courier of the environment, or the courier of the server when the environment does
+// not set one.
+func (c Config) EnvironmentCourier(environment string) string {
+	if courier := c.Environments[strings.ToLower(environment)].Courier; courier != "" {
+		return courier
+	}
+	if c.Courier == "" {
+		return s.CourierCLI
+	}
+	return c.Courier
+}
+
+// UsesCLI reports whether the Cloud Foundry CLI runs the commands of the server or of any environment.
+func (c Config) UsesCLI() bool {
+	if c.EnvironmentCourier("") == s.CourierCLI {
+		return true
+	}
+	for name := range c.Environments {
+		if c.EnvironmentCourier(name) == s.CourierCLI {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFoundation reports whether the foundation is a foundation of any environment.
+func (c Config) HasFoundation(foundationURL string) bool {
+	for _, environment := range c.Environments {
+		for _, url := range environment.Foundations {
+			if url == foundationURL {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func createConfig(getenv func(string) string, environments map[string]s.Environment, errormatchers []interfaces.ErrorMatcher) (Config, error) {
@@ -114,20 +497,16 @@ func getErrorMatchersFromConfig(foundationConfig configYaml) []interfaces.ErrorM
 	return matchers
 }
 
-func getEnvironmentsFromConfig(foundationConfig configYaml) (map[string]s.Environment, error) {
-
-	if foundationConfig.Environments == nil || len(foundationConfig.Environments) == 0 {
+func getEnvironmentsFromConfig(getenv func(string) string, foundationConfig configYaml) (map[string]s.Environment, error) {
+	if len(foundationConfig.Environments) == 0 && len(foundationConfig.Tenants) == 0 {
 		return nil, EnvironmentsNotSpecifiedError{}
 	}
 
 	environments := map[string]s.Environment{}
 	for _, environment := range foundationConfig.Environments {
-		if environment.Name == "" || environment.Foundations == nil || len(environment.Foundations) == 0 {
-			return nil, MissingParameterError{}
-		}
-
-		if environment.Instances < 1 {
-			environment.Instances = 1
+		environment, err := validateEnvironment(getenv, environment)
+		if err != nil {
+			return nil, err
 		}
 
 		environments[strings.ToLower(environment.Name)] = environment
@@ -136,6 +515,247 @@ func getEnvironmentsFromConfig(foundationConfig configYaml) (map[string]s.Enviro
 	return environments, nil
 }
 
+// getTenantsFromConfig adds the environments of every tenant to the environments, with the name and credentials
+// of their tenant. The environments of a tenant cannot have the name of any other environment, and tenants cannot
+// share an API token.
+func getTenantsFromConfig(getenv func(string) string, foundationConfig configYaml, environments map[string]s.Environment) (map[string]s.Tenant, error) {
+	tenants := map[string]s.Tenant{}
+	tokens := map[string]string{}
+
+	for _, tenant := range foundationConfig.Tenants {
+		if tenant.Name == "" || len(tenant.Environments) == 0 {
+			return nil, MissingTenantParameterError{}
+		}
+		if _, ok := tenants[tenant.Name]; ok {
+			return nil, DuplicateTenantError{tenant.Name}
+		}
+
+		tenant.Username = os.Expand(tenant.Username, getenv)
+		tenant.Password = os.Expand(tenant.Password, getenv)
+		if (tenant.Username == "") != (tenant.Password == "") {
+			return nil, IncompleteTenantCredentialsError{tenant.Name}
+		}
+
+		if len(tenant.APITokens) == 0 {
+			return nil, MissingTenantTokenError{tenant.Name}
+		}
+		apiTokens := make([]string, 0, len(tenant.APITokens))
+		for _, token := range tenant.APITokens {
+			token = os.Expand(token, getenv)
+			if token == "" {
+				return nil, MissingTenantTokenError{tenant.Name}
+			}
+			if other, ok := tokens[token]; ok {
+				return nil, DuplicateTenantTokenError{other, tenant.Name}
+			}
+			tokens[token] = tenant.Name
+			apiTokens = append(apiTokens, token)
+		}
+		tenant.APITokens = apiTokens
+
+		tenantEnvironments := make([]s.Environment, 0, len(tenant.Environments))
+		for _, environment := range tenant.Environments {
+			environment, err := validateEnvironment(getenv, environment)
+			if err != nil {
+				return nil, err
+			}
+
+			name := strings.ToLower(environment.Name)
+			if _, ok := environments[name]; ok {
+				return nil, DuplicateEnvironmentError{environment.Name}
+			}
+
+			environment.Tenant = tenant.Name
+			environment.Username = tenant.Username
+			environment.Password = tenant.Password
+			environments[name] = environment
+			tenantEnvironments = append(tenantEnvironments, environment)
+		}
+		tenant.Environments = tenantEnvironments
+
+		tenants[tenant.Name] = tenant
+	}
+
+	return tenants, nil
+}
+
+func validateEnvironment(getenv func(string) string, environment s.Environment) (s.Environment, error) {
+	if environment.Name == "" || environment.Foundations == nil || len(environment.Foundations) == 0 {
+		return environment, MissingParameterError{}
+	}
+
+	if environment.Instances < 1 {
+		environment.Instances = 1
+	}
+
+	if environment.CABundle != "" {
+		bundle, err := ioutil.ReadFile(environment.CABundle)
+		if err != nil {
+			return environment, cabundle.ReadBundleError{Path: environment.CABundle, Err: err}
+		}
+		_, err = cabundle.Parse(bundle)
+		if err != nil {
+			return environment, InvalidCABundleError{environment.Name, environment.CABundle}
+		}
+	}
+
+	if environment.MinSuccessfulFoundations < 0 || environment.MinSuccessfulFoundations > len(environment.Foundations) {
+		return environment, InvalidMinSuccessfulFoundationsError{environment.Name, environment.MinSuccessfulFoundations, len(environment.Foundations)}
+	}
+
+	if environment.MaxConcurrentFoundations < 0 {
+		return environment, InvalidMaxConcurrentFoundationsError{environment.Name, environment.MaxConcurrentFoundations}
+	}
+
+	if environment.MinSuccessfulFoundations > 0 && !environment.EnableRollback {
+		return environment, MinSuccessfulFoundationsWithoutRollbackError{environment.Name}
+	}
+
+	if environment.AutoRollback && environment.CrashWatchSeconds <= 0 {
+		return environment, AutoRollbackWithoutCrashWatchError{environment.Name}
+	}
+
+	if environment.Strategy != "" && !contains(s.Strategies, environment.Strategy) {
+		return environment, UnknownStrategyError{environment.Name, environment.Strategy}
+	}
+
+	if environment.Strategy == s.StrategyCanary && !environment.EnableRollback {
+		return environment, CanaryWithoutRollbackError{environment.Name}
+	}
+
+	if environment.Strategy == s.StrategyCanary {
+		canary := environment.Canary
+		if canary.Percent == 0 {
+			canary.Percent = s.DefaultCanaryPercent
+		}
+		if canary.ConfirmTimeoutSeconds == 0 {
+			canary.ConfirmTimeoutSeconds = s.DefaultCanaryConfirmTimeoutSeconds
+		}
+		if canary.Percent < 0 || canary.Percent >= 100 || canary.BakeSeconds < 0 || canary.ConfirmTimeoutSeconds < 0 || canary.BakeSeconds == 0 && !canary.Confirm {
+			return environment, InvalidCanaryError{environment.Name, canary}
+		}
+		environment.Canary = canary
+	}
+
+	if environment.Courier != "" && !contains(s.Couriers, environment.Courier) {
+		return environment, UnknownCourierError{environment.Name, environment.Courier}
+	}
+
+	if environment.Rolling.Enabled {
+		if environment.Rolling.BatchSize < 0 {
+			return environment, InvalidRollingBatchSizeError{environment.Name, environment.Rolling.BatchSize}
+		}
+		if environment.MinSuccessfulFoundations > 0 {
+			return environment, RollingWithMinSuccessfulFoundationsError{environment.Name}
+		}
+		if environment.Rolling.BatchSize == 0 {
+			environment.Rolling.BatchSize = 1
+		}
+	}
+
+	if environment.ManifestTemplate != "" {
+		_, err := template.New("manifest").Parse(environment.ManifestTemplate)
+		if err != nil {
+			return environment, InvalidManifestTemplateError{environment.Name, err}
+		}
+	}
+
+	for _, foundation := range environment.PrePromotionTask.Foundations {
+		if !contains(environment.Foundations, foundation) {
+			return environment, UnknownTaskFoundationError{environment.Name, foundation}
+		}
+	}
+
+	for _, name := range environment.StampEnvVars {
+		if !stampEnvVar(name) {
+			return environment, UnknownStampEnvVarError{environment.Name, name}
+		}
+	}
+
+	for _, route := range environment.Routes {
+		err := route.Validate()
+		if err != nil {
+			return environment, InvalidRouteError{environment.Name, err}
+		}
+	}
+
+	if len(environment.LDAPGroups) > 0 {
+		environment.LDAP = true
+	}
+
+	if len(environment.Webhooks) > 0 {
+		webhooks := make([]s.WebhookDescriptor, 0, len(environment.Webhooks))
+		for _, descriptor := range environment.Webhooks {
+			descriptor.Secret = os.Expand(descriptor.Secret, getenv)
+			if len(descriptor.Headers) > 0 {
+				headers := make(map[string]string, len(descriptor.Headers))
+				for name, value := range descriptor.Headers {
+					headers[name] = os.Expand(value, getenv)
+				}
+				descriptor.Headers = headers
+			}
+			webhooks = append(webhooks, descriptor)
+		}
+		err := webhook.Validate(environment.Name, webhooks)
+		if err != nil {
+			return environment, err
+		}
+		environment.Webhooks = webhooks
+	}
+
+	if environment.Git.Enabled && environment.Signature.Required {
+		return environment, GitWithRequiredSignatureError{environment.Name}
+	}
+	err := git.Validate(environment.Git)
+	if err != nil {
+		return environment, err
+	}
+	environment.Git.Password = os.Expand(environment.Git.Password, getenv)
+
+	buckets := &environment.Buckets
+	buckets.S3.AccessKeyID = os.Expand(buckets.S3.AccessKeyID, getenv)
+	buckets.S3.SecretAccessKey = os.Expand(buckets.S3.SecretAccessKey, getenv)
+	buckets.S3.SessionToken = os.Expand(buckets.S3.SessionToken, getenv)
+	buckets.S3.SSECustomerKey = os.Expand(buckets.S3.SSECustomerKey, getenv)
+	buckets.GCS.EncryptionKey = os.Expand(buckets.GCS.EncryptionKey, getenv)
+	err = bucket.Validate(environment.Buckets)
+	if err != nil {
+		return environment, err
+	}
+
+	environment.Artifactory.APIKey = os.Expand(environment.Artifactory.APIKey, getenv)
+	err = artifactory.Validate(environment.Artifactory)
+	if err != nil {
+		return environment, err
+	}
+
+	environment.Maven.Password = os.Expand(environment.Maven.Password, getenv)
+	err = maven.Validate(environment.Maven)
+	if err != nil {
+		return environment, err
+	}
+
+	err = rbac.Validate(environment)
+	if err != nil {
+		return environment, err
+	}
+
+	return environment, nil
+}
+
+func stampEnvVar(name string) bool {
+	return contains(s.StampEnvVarNames, name)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func parseConfig(configPath string) (configYaml, error) {
 	file, err := ioutil.ReadFile(configPath)
 	if err != nil {
@@ -1,6 +1,11 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	s "github.com/compozed/deployadactyl/structs"
+)
 
 type EnvironmentsNotSpecifiedError struct{}
 
@@ -21,3 +26,309 @@ type ParseYamlError struct {
 func (e ParseYamlError) Error() string {
 	return fmt.Sprintf("cannot parse yaml file: %s", e.Err)
 }
+
+type InvalidCABundleError struct {
+	Environment string
+	Path        string
+}
+
+func (e InvalidCABundleError) Error() string {
+	return fmt.Sprintf("the CA bundle %s of environment %s does not contain any PEM encoded certificates", e.Path, e.Environment)
+}
+
+type AutoRollbackWithoutCrashWatchError struct {
+	Environment string
+}
+
+func (e AutoRollbackWithoutCrashWatchError) Error() string {
+	return fmt.Sprintf("environment %s enables auto_rollback without a crash_watch_seconds", e.Environment)
+}
+
+type UnknownStrategyError struct {
+	Environment string
+	Strategy    string
+}
+
+func (e UnknownStrategyError) Error() string {
+	return fmt.Sprintf("environment %s has an unknown strategy %s: expected %s", e.Environment, e.Strategy, strings.Join(s.Strategies, " or "))
+}
+
+type UnknownCourierError struct {
+	Environment string
+	Courier     string
+}
+
+func (e UnknownCourierError) Error() string {
+	if e.Environment == "" {
+		return fmt.Sprintf("unknown courier %s: expected %s", e.Courier, strings.Join(s.Couriers, " or "))
+	}
+	return fmt.Sprintf("environment %s has an unknown courier %s: expected %s", e.Environment, e.Courier, strings.Join(s.Couriers, " or "))
+}
+
+type InvalidCanaryError struct {
+	Environment string
+	Canary      s.CanaryDescriptor
+}
+
+func (e InvalidCanaryError) Error() string {
+	return fmt.Sprintf("environment %s has an invalid canary: the percent must be between 1 and 99, and it must bake for a positive number of bake_seconds or be confirmed within a positive confirm_timeout_seconds", e.Environment)
+}
+
+type InvalidMinSuccessfulFoundationsError struct {
+	Environment              string
+	MinSuccessfulFoundations int
+	Foundations              int
+}
+
+func (e InvalidMinSuccessfulFoundationsError) Error() string {
+	return fmt.Sprintf("environment %s requires %d successful foundations but has %d foundations", e.Environment, e.MinSuccessfulFoundations, e.Foundations)
+}
+
+type InvalidMaxConcurrentFoundationsError struct {
+	Environment              string
+	MaxConcurrentFoundations int
+}
+
+func (e InvalidMaxConcurrentFoundationsError) Error() string {
+	return fmt.Sprintf("environment %s has a negative max_concurrent_foundations %d", e.Environment, e.MaxConcurrentFoundations)
+}
+
+type MinSuccessfulFoundationsWithoutRollbackError struct {
+	Environment string
+}
+
+func (e MinSuccessfulFoundationsWithoutRollbackError) Error() string {
+	return fmt.Sprintf("environment %s sets min_successful_foundations without rollback_enabled", e.Environment)
+}
+
+type CanaryWithoutRollbackError struct {
+	Environment string
+}
+
+func (e CanaryWithoutRollbackError) Error() string {
+	return fmt.Sprintf("environment %s deploys with the canary strategy without rollback_enabled", e.Environment)
+}
+
+type InvalidRollingBatchSizeError struct {
+	Environment string
+	BatchSize   int
+}
+
+func (e InvalidRollingBatchSizeError) Error() string {
+	return fmt.Sprintf("environment %s has an invalid rolling batch_size %d: expected a positive number", e.Environment, e.BatchSize)
+}
+
+type RollingWithMinSuccessfulFoundationsError struct {
+	Environment string
+}
+
+func (e RollingWithMinSuccessfulFoundationsError) Error() string {
+	return fmt.Sprintf("environment %s sets min_successful_foundations with a rolling rollout, which halts on the first failed batch", e.Environment)
+}
+
+type UnknownStampEnvVarError struct {
+	Environment string
+	Name        string
+}
+
+func (e UnknownStampEnvVarError) Error() string {
+	return fmt.Sprintf("environment %s stamps an unknown deployment environment variable %s, expected one of %s", e.Environment, e.Name, strings.Join(s.StampEnvVarNames, ", "))
+}
+
+type InvalidRouteError struct {
+	Environment string
+	Err         error
+}
+
+func (e InvalidRouteError) Error() string {
+	return fmt.Sprintf("environment %s has an %s", e.Environment, e.Err)
+}
+
+type InvalidManifestTemplateError struct {
+	Environment string
+	Err         error
+}
+
+func (e InvalidManifestTemplateError) Error() string {
+	return fmt.Sprintf("the manifest template of environment %s cannot be parsed: %s", e.Environment, e.Err)
+}
+
+type UnknownTaskFoundationError struct {
+	Environment string
+	Foundation  string
+}
+
+func (e UnknownTaskFoundationError) Error() string {
+	return fmt.Sprintf("the pre-promotion task of environment %s runs on %s, which is not one of its foundations", e.Environment, e.Foundation)
+}
+
+type InvalidCommandTimeoutError struct {
+	Command string
+	Seconds int
+}
+
+func (e InvalidCommandTimeoutError) Error() string {
+	return fmt.Sprintf("the command timeout of %s must be a positive number of seconds: %d", e.Command, e.Seconds)
+}
+
+type InvalidWorkDirectoryError struct {
+	Path string
+	Err  error
+}
+
+func (e InvalidWorkDirectoryError) Error() string {
+	return fmt.Sprintf("cannot use %s as the work directory: %s", e.Path, e.Err)
+}
+
+type InvalidStaleAppsError struct {
+	IntervalMinutes int
+	MaxAgeMinutes   int
+}
+
+func (e InvalidStaleAppsError) Error() string {
+	return fmt.Sprintf("the stale apps sweep must not be negative: interval_minutes %d, max_age_minutes %d", e.IntervalMinutes, e.MaxAgeMinutes)
+}
+
+type UnknownMaintenanceFoundationError struct {
+	FoundationURL string
+}
+
+func (e UnknownMaintenanceFoundationError) Error() string {
+	return fmt.Sprintf("the maintenance foundation %s is not a foundation of any environment", e.FoundationURL)
+}
+
+type InvalidDeploymentLogsError struct {
+	MaxLogs    int
+	MaxAgeDays int
+}
+
+func (e InvalidDeploymentLogsError) Error() string {
+	return fmt.Sprintf("the retention of deployment logs must not be negative: max_logs %d, max_age_days %d", e.MaxLogs, e.MaxAgeDays)
+}
+
+type ConflictingStorageError struct {
+	Setting string
+	Field   string
+}
+
+func (e ConflictingStorageError) Error() string {
+	return fmt.Sprintf("%s cannot have both a %s and a postgres database", e.Setting, e.Field)
+}
+
+type InvalidDeploymentLocksError struct {
+	TTLSeconds int
+}
+
+func (e InvalidDeploymentLocksError) Error() string {
+	return fmt.Sprintf("the ttl of deployment locks must not be negative: ttl_seconds %d", e.TTLSeconds)
+}
+
+type InvalidArtifactCacheError struct {
+	MaxAgeMinutes int
+}
+
+func (e InvalidArtifactCacheError) Error() string {
+	return fmt.Sprintf("the artifact cache must not keep artifacts for a negative time: max_age_minutes %d", e.MaxAgeMinutes)
+}
+
+type MissingTenantParameterError struct{}
+
+func (e MissingTenantParameterError) Error() string {
+	return "missing required parameter in the tenants key: every tenant needs a name and environments"
+}
+
+type DuplicateTenantError struct {
+	Tenant string
+}
+
+func (e DuplicateTenantError) Error() string {
+	return fmt.Sprintf("tenant %s is configured more than once", e.Tenant)
+}
+
+type IncompleteTenantCredentialsError struct {
+	Tenant string
+}
+
+func (e IncompleteTenantCredentialsError) Error() string {
+	return fmt.Sprintf("tenant %s needs both a username and a password, or neither", e.Tenant)
+}
+
+type MissingTenantTokenError struct {
+	Tenant string
+}
+
+func (e MissingTenantTokenError) Error() string {
+	return fmt.Sprintf("tenant %s has a missing or empty api token", e.Tenant)
+}
+
+type DuplicateTenantTokenError struct {
+	Tenant      string
+	OtherTenant string
+}
+
+func (e DuplicateTenantTokenError) Error() string {
+	return fmt.Sprintf("tenants %s and %s share an api token", e.Tenant, e.OtherTenant)
+}
+
+type DuplicateEnvironmentError struct {
+	Environment string
+}
+
+func (e DuplicateEnvironmentError) Error() string {
+	return fmt.Sprintf("environment %s is configured more than once", e.Environment)
+}
+
+type InvalidLimitsError struct {
+	Limits s.LimitsDescriptor
+}
+
+func (e InvalidLimitsError) Error() string {
+	return fmt.Sprintf("the limits must not be negative: max_request_mb %d, max_upload_mb %d, max_response_buffer_mb %d", e.Limits.MaxRequestMB, e.Limits.MaxUploadMB, e.Limits.MaxResponseBufferMB)
+}
+
+type InvalidSlackWebhookURLError struct{}
+
+func (e InvalidSlackWebhookURLError) Error() string {
+	return "the slack webhook_url must be an http or https url"
+}
+
+type InvalidSlackTimeoutError struct {
+	Seconds int
+}
+
+func (e InvalidSlackTimeoutError) Error() string {
+	return fmt.Sprintf("the timeout of the slack notifications must not be negative: timeout_seconds %d", e.Seconds)
+}
+
+type VaultPathWithoutVaultError struct {
+	Environment string
+}
+
+func (e VaultPathWithoutVaultError) Error() string {
+	return fmt.Sprintf("environment %s has a vault_path but vault has no address", e.Environment)
+}
+
+type CredentialsError struct {
+	Environment string
+	Err         error
+}
+
+func (e CredentialsError) Error() string {
+	return fmt.Sprintf("cannot read the credentials of environment %s: %s", e.Environment, e.Err)
+}
+
+type LDAPWithoutServerError struct {
+	Environment string
+}
+
+func (e LDAPWithoutServerError) Error() string {
+	return fmt.Sprintf("environment %s requires ldap but ldap has no url", e.Environment)
+}
+
+type GitWithRequiredSignatureError struct {
+	Environment string
+}
+
+func (e GitWithRequiredSignatureError) Error() string {
+	return fmt.Sprintf("environment %s requires signed artifacts, which a git checkout is not", e.Environment)
+}
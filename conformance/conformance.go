@@ -0,0 +1,38 @@
+// Package conformance checks that implementations of the interfaces of deployadactyl behave the way it relies on,
+// so couriers, fetchers, event handlers and storage backends written outside of it can be verified by their own
+// tests:
+//
+//	func TestS3Fetcher(t *testing.T) {
+//		conformance.Fetcher(t, conformance.FetcherSubject{Fetcher: s3.NewFetcher(bucket), ArtifactURL: url, Files: files})
+//	}
+//
+// Every check of a contract that fails is reported to the T with the reason it failed, and the other checks still
+// run. The T may be a *testing.T or GinkgoT().
+package conformance
+
+import "fmt"
+
+// T receives the failures of the checks of a contract. It is implemented by *testing.T and GinkgoT().
+type T interface {
+	Errorf(format string, args ...interface{})
+}
+
+// check is a part of a contract, which returns the reason the implementation does not satisfy it.
+type check struct {
+	name string
+	run  func() error
+}
+
+// verify runs every check of the contract and reports the failures to t.
+func verify(t T, contract string, checks []check) {
+	for _, c := range checks {
+		if err := c.run(); err != nil {
+			t.Errorf("%s %s: %s", contract, c.name, err)
+		}
+	}
+}
+
+// failed returns the reason of a failed check.
+func failed(format string, args ...interface{}) error {
+	return fmt.Errorf(format, args...)
+}
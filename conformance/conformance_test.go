@@ -0,0 +1,116 @@
+package conformance_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	. "github.com/compozed/deployadactyl/conformance"
+	"github.com/compozed/deployadactyl/deploymentlog"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/lock"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/testharness"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// recorder records the failures reported to it.
+type recorder struct {
+	failures []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+// openLocks locks every key for every owner.
+type openLocks struct{}
+
+func (openLocks) Lock(key, owner string) error   { return nil }
+func (openLocks) Unlock(key, owner string) error { return nil }
+
+// forgetfulStore saves nothing.
+type forgetfulStore struct{}
+
+func (forgetfulStore) Save(uuid string, output []byte) error { return nil }
+func (forgetfulStore) Get(uuid string) ([]byte, error)       { return nil, errors.New("not found") }
+
+var _ = Describe("Conformance", func() {
+	var t *recorder
+
+	BeforeEach(func() {
+		t = &recorder{}
+	})
+
+	It("reports nothing for an implementation that satisfies the contract", func() {
+		LockManager(t, func() I.LockManager { return lock.NewMemoryLocks() })
+		DeploymentLogStore(t, func() I.DeploymentLogStore { return deploymentlog.NewMemoryStore(S.DeploymentLogsDescriptor{}) })
+
+		Expect(t.failures).To(BeEmpty())
+	})
+
+	It("reports every check an implementation fails", func() {
+		LockManager(t, func() I.LockManager { return openLocks{} })
+
+		Expect(t.failures).To(HaveLen(2))
+		Expect(t.failures[0]).To(HavePrefix("LockManager does not lock a key held by another owner: "))
+		Expect(t.failures[1]).To(HavePrefix("LockManager does not release the lock of another owner: "))
+	})
+
+	It("reports the failures of a storage backend", func() {
+		DeploymentLogStore(t, func() I.DeploymentLogStore { return forgetfulStore{} })
+
+		Expect(t.failures).To(ConsistOf(
+			`DeploymentLogStore returns a saved output: got "" and error not found`,
+			`DeploymentLogStore replaces the output of the same uuid: got "" and error not found`,
+		))
+	})
+
+	Describe("Courier", func() {
+		var (
+			cloud   *testharness.Cloud
+			subject CourierSubject
+		)
+
+		BeforeEach(func() {
+			cloud = testharness.NewCloud()
+			cloud.AddFoundation("api1.example.com", "apps.example.com")
+
+			appPath, err := ioutil.TempDir("", "conformance-")
+			Expect(err).ToNot(HaveOccurred())
+
+			subject = CourierSubject{
+				NewCourier:    func() I.Courier { return cloud.NewCourier(nil) },
+				FoundationURL: "api1.example.com",
+				Username:      "username",
+				Password:      "password",
+				Org:           "org",
+				Space:         "space",
+				Domain:        "apps.example.com",
+				AppPath:       appPath,
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(subject.AppPath)
+		})
+
+		It("deletes the apps it pushed", func() {
+			Courier(t, subject)
+
+			Expect(t.failures).To(BeEmpty())
+			Expect(cloud.Foundation("api1.example.com").Apps("org", "space")).To(BeEmpty())
+		})
+
+		It("reports the checks that fail when the Courier cannot log in", func() {
+			cloud.Foundation("api1.example.com").SetCredentials("username", "secret")
+
+			Courier(t, subject)
+
+			Expect(t.failures).To(HaveLen(9))
+			Expect(t.failures[0]).To(HavePrefix("Courier does not find an app that does not exist: cannot log in: "))
+		})
+	})
+})
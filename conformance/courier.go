@@ -0,0 +1,222 @@
+package conformance
+
+import (
+	"context"
+	"strings"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// CourierSubject is a Courier checked against the Courier contract, and the foundation and space it deploys to.
+// The checks push apps whose names start with conformance- to the space, and delete them again.
+type CourierSubject struct {
+	// NewCourier returns a Courier that is not logged in.
+	NewCourier func() I.Courier
+
+	FoundationURL string
+	Username      string
+	Password      string
+	Org           string
+	Space         string
+	SkipSSL       bool
+
+	// Domain is a domain of the foundation routes can be mapped on.
+	Domain string
+
+	// AppPath is a directory holding an app that can be pushed and started.
+	AppPath string
+}
+
+// Courier checks the Courier contract the blue green deployments rely on: apps are pushed, found, renamed,
+// routed, stopped, started, scaled and deleted, a login is shared by the copies of a Courier, and commands fail once
+// the context of the Courier is done.
+func Courier(t T, subject CourierSubject) {
+	login := func() (I.Courier, error) {
+		courier := subject.NewCourier()
+		if out, err := courier.Login(subject.FoundationURL, subject.Username, subject.Password, subject.Org, subject.Space, subject.SkipSSL); err != nil {
+			return nil, failed("cannot log in: %s: %s", err, out)
+		}
+		return courier, nil
+	}
+	// pushed logs in and pushes the app, which is deleted by the returned function.
+	pushed := func(appName string) (I.Courier, func(), error) {
+		courier, err := login()
+		if err != nil {
+			return nil, nil, err
+		}
+		if out, err := courier.Push(appName, subject.AppPath, appName, 1); err != nil {
+			courier.Delete(appName)
+			return nil, nil, failed("cannot push %s: %s: %s", appName, err, out)
+		}
+		return courier, func() { courier.Delete(appName); courier.CleanUp() }, nil
+	}
+	state := func(courier I.Courier, appName, expected string) error {
+		s, err := courier.AppState(appName)
+		if err != nil {
+			return failed("cannot read the state of %s: %s", appName, err)
+		}
+		if s.State != expected {
+			return failed("%s is %s instead of %s", appName, s.State, expected)
+		}
+		return nil
+	}
+
+	verify(t, "Courier", []check{
+		{"does not find an app that does not exist", func() error {
+			courier, err := login()
+			if err != nil {
+				return err
+			}
+			defer courier.CleanUp()
+
+			if courier.Exists("conformance-missing") {
+				return failed("found conformance-missing")
+			}
+			if _, err := courier.AppState("conformance-missing"); err == nil {
+				return failed("read the state of conformance-missing")
+			}
+			if _, err := courier.Rename("conformance-missing", "conformance-renamed"); err == nil {
+				return failed("renamed conformance-missing")
+			}
+			return nil
+		}},
+		{"pushes a started app", func() error {
+			courier, deleteApp, err := pushed("conformance-push")
+			if err != nil {
+				return err
+			}
+			defer deleteApp()
+
+			if !courier.Exists("conformance-push") {
+				return failed("did not find the pushed app")
+			}
+			return state(courier, "conformance-push", "STARTED")
+		}},
+		{"deletes an app", func() error {
+			courier, deleteApp, err := pushed("conformance-delete")
+			if err != nil {
+				return err
+			}
+			defer deleteApp()
+
+			if out, err := courier.Delete("conformance-delete"); err != nil {
+				return failed("cannot delete: %s: %s", err, out)
+			}
+			if courier.Exists("conformance-delete") {
+				return failed("found the deleted app")
+			}
+			return nil
+		}},
+		{"renames an app", func() error {
+			courier, deleteApp, err := pushed("conformance-rename")
+			if err != nil {
+				return err
+			}
+			defer deleteApp()
+			defer courier.Delete("conformance-renamed")
+
+			if out, err := courier.Rename("conformance-rename", "conformance-renamed"); err != nil {
+				return failed("cannot rename: %s: %s", err, out)
+			}
+			if courier.Exists("conformance-rename") || !courier.Exists("conformance-renamed") {
+				return failed("did not find the app by its new name only")
+			}
+			return nil
+		}},
+		{"maps and unmaps routes", func() error {
+			courier, deleteApp, err := pushed("conformance-route")
+			if err != nil {
+				return err
+			}
+			defer deleteApp()
+			defer courier.DeleteRoute(subject.Domain, "conformance-route-mapped")
+
+			route := "conformance-route-mapped." + subject.Domain
+			if out, err := courier.MapRoute("conformance-route", subject.Domain, "conformance-route-mapped"); err != nil {
+				return failed("cannot map the route: %s: %s", err, out)
+			}
+			routes, err := courier.AppRoutes("conformance-route")
+			if err != nil || !contains(routes, route) {
+				return failed("the routes are %v and the error %v after mapping %s", routes, err, route)
+			}
+			if out, err := courier.UnmapRoute("conformance-route", subject.Domain, "conformance-route-mapped"); err != nil {
+				return failed("cannot unmap the route: %s: %s", err, out)
+			}
+			routes, err = courier.AppRoutes("conformance-route")
+			if err != nil || contains(routes, route) {
+				return failed("the routes are %v and the error %v after unmapping %s", routes, err, route)
+			}
+			return nil
+		}},
+		{"stops and starts an app", func() error {
+			courier, deleteApp, err := pushed("conformance-stop")
+			if err != nil {
+				return err
+			}
+			defer deleteApp()
+
+			if out, err := courier.Stop("conformance-stop"); err != nil {
+				return failed("cannot stop: %s: %s", err, out)
+			}
+			if err := state(courier, "conformance-stop", "STOPPED"); err != nil {
+				return err
+			}
+			if out, err := courier.Start("conformance-stop"); err != nil {
+				return failed("cannot start: %s: %s", err, out)
+			}
+			return state(courier, "conformance-stop", "STARTED")
+		}},
+		{"scales an app", func() error {
+			courier, deleteApp, err := pushed("conformance-scale")
+			if err != nil {
+				return err
+			}
+			defer deleteApp()
+
+			if out, err := courier.Scale("conformance-scale", 2); err != nil {
+				return failed("cannot scale: %s: %s", err, out)
+			}
+			instances, err := courier.Instances("conformance-scale")
+			if err != nil || len(instances) != 2 {
+				return failed("the instances are %+v and the error %v", instances, err)
+			}
+			return nil
+		}},
+		{"shares the login with its copies", func() error {
+			courier, deleteApp, err := pushed("conformance-copy")
+			if err != nil {
+				return err
+			}
+			defer deleteApp()
+
+			if !courier.WithContext(context.Background()).Exists("conformance-copy") {
+				return failed("the copy did not find the pushed app")
+			}
+			return nil
+		}},
+		{"does not push once the context is done", func() error {
+			courier, err := login()
+			if err != nil {
+				return err
+			}
+			defer courier.CleanUp()
+			defer courier.Delete("conformance-cancel")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if _, err := courier.WithContext(ctx).Push("conformance-cancel", subject.AppPath, "conformance-cancel", 1); err == nil {
+				return failed("pushed the app")
+			}
+			return nil
+		}},
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
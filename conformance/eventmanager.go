@@ -0,0 +1,128 @@
+package conformance
+
+import (
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// EventManager checks the EventManager contract against the EventManagers returned by newManager, which have no
+// handlers or bindings.
+func EventManager(t T, newManager func() I.EventManager) {
+	verify(t, "EventManager", []check{
+		{"emits an event to the bindings that accept it in the order they were added", func() error {
+			manager := newManager()
+			emitted := []string{}
+			manager.AddBinding(binding{"first", "deploy", &emitted, nil})
+			manager.AddBinding(binding{"other", "stop", &emitted, nil})
+			manager.AddBinding(binding{"second", "deploy", &emitted, nil})
+
+			if err := manager.EmitEvent(event{"deploy"}); err != nil {
+				return failed("cannot emit: %s", err)
+			}
+			if !equal(emitted, []string{"first", "second"}) {
+				return failed("emitted to %v", emitted)
+			}
+			return nil
+		}},
+		{"returns the error of the first binding that fails", func() error {
+			manager := newManager()
+			emitted := []string{}
+			manager.AddBinding(binding{"first", "deploy", &emitted, failed("the handler failed")})
+			manager.AddBinding(binding{"second", "deploy", &emitted, nil})
+
+			err := manager.EmitEvent(event{"deploy"})
+			if err == nil || err.Error() != "the handler failed" {
+				return failed("returned %v", err)
+			}
+			if !equal(emitted, []string{"first"}) {
+				return failed("emitted to %v after the failure", emitted)
+			}
+			return nil
+		}},
+		{"emits an event to the handlers of its type", func() error {
+			manager := newManager()
+			h := &handler{}
+			if err := manager.AddHandler(h, "deploy.start"); err != nil {
+				return failed("cannot add the handler: %s", err)
+			}
+			if err := manager.Emit(I.Event{Type: "deploy.finish"}); err != nil {
+				return failed("cannot emit: %s", err)
+			}
+			if err := manager.Emit(I.Event{Type: "deploy.start", Data: "data"}); err != nil {
+				return failed("cannot emit: %s", err)
+			}
+			if len(h.events) != 1 || h.events[0].Type != "deploy.start" || h.events[0].Data != "data" {
+				return failed("the handler received %+v", h.events)
+			}
+			return nil
+		}},
+		{"does not add a nil handler", func() error {
+			if err := newManager().AddHandler(nil, "deploy.start"); err == nil {
+				return failed("got no error")
+			}
+			return nil
+		}},
+	})
+}
+
+// Binding checks the contract of a Binding of an event handler, such as the one returned by
+// push.NewPushFinishedEventBinding: it accepts the event and emits it to the handler, and rejects the other event.
+// The handler is expected to succeed for the event.
+func Binding(t T, b I.Binding, accepted I.IEvent, other I.IEvent) {
+	verify(t, "Binding", []check{
+		{"accepts its event", func() error {
+			if !b.Accepts(accepted) {
+				return failed("did not accept %s", accepted.Name())
+			}
+			if err := b.Emit(accepted); err != nil {
+				return failed("cannot emit %s: %s", accepted.Name(), err)
+			}
+			return nil
+		}},
+		{"rejects other events", func() error {
+			if b.Accepts(other) {
+				return failed("accepted %s", other.Name())
+			}
+			if err := b.Emit(other); err == nil {
+				return failed("emitted %s", other.Name())
+			}
+			return nil
+		}},
+	})
+}
+
+// event is an event of the EventManager contract.
+type event struct {
+	name string
+}
+
+func (e event) Name() string {
+	return e.name
+}
+
+// binding records the events it is emitted, and fails with err.
+type binding struct {
+	name    string
+	accepts string
+	emitted *[]string
+	err     error
+}
+
+func (b binding) Accepts(e interface{}) bool {
+	named, ok := e.(event)
+	return ok && named.name == b.accepts
+}
+
+func (b binding) Emit(e interface{}) error {
+	*b.emitted = append(*b.emitted, b.name)
+	return b.err
+}
+
+// handler records the events it handles.
+type handler struct {
+	events []I.Event
+}
+
+func (h *handler) OnEvent(e I.Event) error {
+	h.events = append(h.events, e)
+	return nil
+}
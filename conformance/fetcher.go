@@ -0,0 +1,142 @@
+package conformance
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/spf13/afero"
+)
+
+// FetcherSubject is a Fetcher checked against the Fetcher contract, and the artifact it fetches.
+type FetcherSubject struct {
+	Fetcher I.Fetcher
+
+	// FileSystem holds the directories the Fetcher fetches to. It is the file system of the operating system when
+	// it is nil.
+	FileSystem *afero.Afero
+
+	// ArtifactURL is the location of an artifact holding the Files, such as a zip served by ServeArtifact.
+	ArtifactURL string
+	Files       map[string]string
+
+	// MissingURL is the location of an artifact that does not exist. It is not checked when it is empty.
+	MissingURL string
+}
+
+// Fetcher checks the Fetcher contract: an artifact is fetched to a directory holding its files, with the manifest
+// of the deployment when there is one, and a zip of the request is fetched with the manifest it holds.
+func Fetcher(t T, subject FetcherSubject) {
+	fs := subject.FileSystem
+	if fs == nil {
+		fs = &afero.Afero{Fs: afero.NewOsFs()}
+	}
+	holds := func(dir string, files map[string]string) error {
+		for name, content := range files {
+			b, err := fs.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				return failed("cannot read %s: %s", name, err)
+			}
+			if string(b) != content {
+				return failed("%s holds %q", name, b)
+			}
+		}
+		return nil
+	}
+
+	verify(t, "Fetcher", []check{
+		{"fetches the files of the artifact", func() error {
+			dir, err := subject.Fetcher.Fetch(context.Background(), subject.ArtifactURL, "")
+			if err != nil {
+				return failed("cannot fetch: %s", err)
+			}
+			defer fs.RemoveAll(dir)
+			return holds(dir, subject.Files)
+		}},
+		{"writes the manifest of the deployment", func() error {
+			manifest := "---\napplications:\n- name: conformance\n"
+			dir, err := subject.Fetcher.Fetch(context.Background(), subject.ArtifactURL, manifest)
+			if err != nil {
+				return failed("cannot fetch: %s", err)
+			}
+			defer fs.RemoveAll(dir)
+			return holds(dir, map[string]string{"manifest.yml": manifest})
+		}},
+		{"does not fetch once the context is done", func() error {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			dir, err := subject.Fetcher.Fetch(ctx, subject.ArtifactURL, "")
+			if err == nil {
+				fs.RemoveAll(dir)
+				return failed("fetched to %s", dir)
+			}
+			return nil
+		}},
+		{"does not fetch an artifact that does not exist", func() error {
+			if subject.MissingURL == "" {
+				return nil
+			}
+			dir, err := subject.Fetcher.Fetch(context.Background(), subject.MissingURL, "")
+			if err == nil {
+				fs.RemoveAll(dir)
+				return failed("fetched to %s", dir)
+			}
+			return nil
+		}},
+		{"fetches the zip of a request with its manifest", func() error {
+			files := map[string]string{"manifest.yml": "---\napplications:\n- name: conformance\n", "index.html": "hello"}
+			b, err := Zip(files)
+			if err != nil {
+				return err
+			}
+			dir, manifest, err := subject.Fetcher.FetchZipFromRequest(bytes.NewReader(b))
+			if err != nil {
+				return failed("cannot fetch: %s", err)
+			}
+			defer fs.RemoveAll(dir)
+			if manifest != files["manifest.yml"] {
+				return failed("returned the manifest %q", manifest)
+			}
+			return holds(dir, files)
+		}},
+	})
+}
+
+// Zip returns a zip of the files, keyed by their names.
+func Zip(files map[string]string) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	w := zip.NewWriter(buffer)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// ServeArtifact serves a zip of the files at /artifact.zip, and nothing else. The server is closed by the caller.
+func ServeArtifact(files map[string]string) (*httptest.Server, error) {
+	b, err := Zip(files)
+	if err != nil {
+		return nil, err
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/artifact.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write(b)
+	})), nil
+}
@@ -0,0 +1,314 @@
+package conformance
+
+import (
+	"bytes"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DeliveryTimeout is how long a message published on shared state may take to reach its subscribers.
+const DeliveryTimeout = 5 * time.Second
+
+// DeploymentHistory checks the DeploymentHistory contract against the histories returned by newHistory, which are
+// empty and keep at least ten records.
+func DeploymentHistory(t T, newHistory func() I.DeploymentHistory) {
+	started := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	record := func(uuid, appName, status string) S.DeploymentRecord {
+		return S.DeploymentRecord{
+			UUID:        uuid,
+			Environment: "prod",
+			Org:         "org",
+			Space:       "space",
+			AppName:     appName,
+			ArtifactURL: "https://example.com/" + appName + ".jar",
+			Status:      status,
+			StartedAt:   started,
+			Metadata:    map[string]string{"pipeline": "42"},
+		}
+	}
+	uuids := func(records []S.DeploymentRecord) []string {
+		found := []string{}
+		for _, r := range records {
+			found = append(found, r.UUID)
+		}
+		return found
+	}
+	recordAll := func(history I.DeploymentHistory, records ...S.DeploymentRecord) error {
+		for _, r := range records {
+			if err := history.Record(r); err != nil {
+				return failed("cannot record %s: %s", r.UUID, err)
+			}
+		}
+		return nil
+	}
+
+	verify(t, "DeploymentHistory", []check{
+		{"returns a recorded record", func() error {
+			history := newHistory()
+			if err := recordAll(history, record("1", "app", S.DeploymentRunning)); err != nil {
+				return err
+			}
+			got, err := history.Get("1")
+			if err != nil {
+				return failed("cannot get the record: %s", err)
+			}
+			if got.UUID != "1" || got.AppName != "app" || got.Status != S.DeploymentRunning || got.ArtifactURL != "https://example.com/app.jar" ||
+				!got.StartedAt.Equal(started) || got.Metadata["pipeline"] != "42" {
+				return failed("got %+v", got)
+			}
+			return nil
+		}},
+		{"replaces the record of the same uuid", func() error {
+			history := newHistory()
+			if err := recordAll(history, record("1", "app", S.DeploymentRunning), record("1", "app", S.DeploymentSucceeded)); err != nil {
+				return err
+			}
+			got, err := history.Get("1")
+			if err != nil || got.Status != S.DeploymentSucceeded {
+				return failed("got %+v and error %v", got, err)
+			}
+			found, err := history.Find(S.DeploymentQuery{})
+			if err != nil || len(found) != 1 {
+				return failed("found %v and error %v", uuids(found), err)
+			}
+			return nil
+		}},
+		{"returns an error for an unknown uuid", func() error {
+			if _, err := newHistory().Get("unknown"); err == nil {
+				return failed("got no error")
+			}
+			return nil
+		}},
+		{"finds the records newest first", func() error {
+			history := newHistory()
+			if err := recordAll(history, record("1", "app", S.DeploymentSucceeded), record("2", "app", S.DeploymentFailed), record("3", "other", S.DeploymentSucceeded)); err != nil {
+				return err
+			}
+			found, err := history.Find(S.DeploymentQuery{})
+			if err != nil || !equal(uuids(found), []string{"3", "2", "1"}) {
+				return failed("found %v and error %v", uuids(found), err)
+			}
+			return nil
+		}},
+		{"filters the records regardless of case and limits them", func() error {
+			history := newHistory()
+			if err := recordAll(history, record("1", "app", S.DeploymentSucceeded), record("2", "app", S.DeploymentFailed), record("3", "other", S.DeploymentSucceeded), record("4", "app", S.DeploymentSucceeded)); err != nil {
+				return err
+			}
+			found, err := history.Find(S.DeploymentQuery{AppName: "APP", Status: S.DeploymentSucceeded})
+			if err != nil || !equal(uuids(found), []string{"4", "1"}) {
+				return failed("found %v and error %v", uuids(found), err)
+			}
+			found, err = history.Find(S.DeploymentQuery{AppName: "app", Limit: 2})
+			if err != nil || !equal(uuids(found), []string{"4", "2"}) {
+				return failed("found %v with a limit of 2 and error %v", uuids(found), err)
+			}
+			return nil
+		}},
+		{"finds the current deployment of every app", func() error {
+			history := newHistory()
+			if err := recordAll(history, record("1", "app", S.DeploymentSucceeded), record("2", "other", S.DeploymentSucceeded), record("3", "app", S.DeploymentFailed)); err != nil {
+				return err
+			}
+			found, err := history.Find(S.DeploymentQuery{Current: true})
+			if err != nil || !equal(uuids(found), []string{"2", "1"}) {
+				return failed("found %v and error %v", uuids(found), err)
+			}
+			return nil
+		}},
+	})
+}
+
+// DeploymentLogStore checks the DeploymentLogStore contract against the empty stores returned by newStore.
+func DeploymentLogStore(t T, newStore func() I.DeploymentLogStore) {
+	verify(t, "DeploymentLogStore", []check{
+		{"returns a saved output", func() error {
+			store := newStore()
+			output := []byte("deployment succeeded\n")
+			if err := store.Save("1", output); err != nil {
+				return failed("cannot save: %s", err)
+			}
+			output[0] = 'D'
+			got, err := store.Get("1")
+			if err != nil || string(got) != "deployment succeeded\n" {
+				return failed("got %q and error %v", got, err)
+			}
+			return nil
+		}},
+		{"replaces the output of the same uuid", func() error {
+			store := newStore()
+			if err := store.Save("1", []byte("first")); err != nil {
+				return failed("cannot save: %s", err)
+			}
+			if err := store.Save("1", []byte("second")); err != nil {
+				return failed("cannot save again: %s", err)
+			}
+			got, err := store.Get("1")
+			if err != nil || string(got) != "second" {
+				return failed("got %q and error %v", got, err)
+			}
+			return nil
+		}},
+		{"returns an error for an unknown uuid", func() error {
+			if _, err := newStore().Get("unknown"); err == nil {
+				return failed("got no error")
+			}
+			return nil
+		}},
+	})
+}
+
+// LockManager checks the LockManager contract against the LockManagers returned by newLocks, which hold no lock.
+func LockManager(t T, newLocks func() I.LockManager) {
+	verify(t, "LockManager", []check{
+		{"does not lock a key held by another owner", func() error {
+			locks := newLocks()
+			if err := locks.Lock("prod/org/space/app", "1"); err != nil {
+				return failed("cannot lock: %s", err)
+			}
+			if err := locks.Lock("prod/org/space/app", "2"); err == nil {
+				return failed("another owner locked the key")
+			}
+			if err := locks.Lock("prod/org/space/other", "2"); err != nil {
+				return failed("cannot lock another key: %s", err)
+			}
+			return nil
+		}},
+		{"locks a key again for the owner holding it", func() error {
+			locks := newLocks()
+			if err := locks.Lock("prod/org/space/app", "1"); err != nil {
+				return failed("cannot lock: %s", err)
+			}
+			if err := locks.Lock("prod/org/space/app", "1"); err != nil {
+				return failed("cannot lock again: %s", err)
+			}
+			return nil
+		}},
+		{"locks a key again once it is released", func() error {
+			locks := newLocks()
+			if err := locks.Lock("prod/org/space/app", "1"); err != nil {
+				return failed("cannot lock: %s", err)
+			}
+			if err := locks.Unlock("prod/org/space/app", "1"); err != nil {
+				return failed("cannot unlock: %s", err)
+			}
+			if err := locks.Lock("prod/org/space/app", "2"); err != nil {
+				return failed("cannot lock the released key: %s", err)
+			}
+			return nil
+		}},
+		{"does not release the lock of another owner", func() error {
+			locks := newLocks()
+			if err := locks.Lock("prod/org/space/app", "1"); err != nil {
+				return failed("cannot lock: %s", err)
+			}
+			if err := locks.Unlock("prod/org/space/app", "2"); err == nil {
+				return failed("another owner released the lock")
+			}
+			if err := locks.Lock("prod/org/space/app", "2"); err == nil {
+				return failed("another owner locked the key")
+			}
+			return nil
+		}},
+	})
+}
+
+// SharedState checks the SharedState contract against the SharedStates returned by newState, which hold no value.
+// Values are checked to expire with a TTL of a tenth of a second.
+func SharedState(t T, newState func() I.SharedState) {
+	verify(t, "SharedState", []check{
+		{"passes the messages of a channel to its subscribers", func() error {
+			state := newState()
+			first, unsubscribeFirst := state.Subscribe("conformance-events")
+			defer unsubscribeFirst()
+			second, unsubscribeSecond := state.Subscribe("conformance-events")
+			defer unsubscribeSecond()
+			other, unsubscribeOther := state.Subscribe("conformance-other")
+			defer unsubscribeOther()
+
+			if err := state.Publish("conformance-events", []byte("an event")); err != nil {
+				return failed("cannot publish: %s", err)
+			}
+			for _, messages := range []<-chan []byte{first, second} {
+				select {
+				case message := <-messages:
+					if !bytes.Equal(message, []byte("an event")) {
+						return failed("received %q", message)
+					}
+				case <-time.After(DeliveryTimeout):
+					return failed("a subscriber received no message")
+				}
+			}
+			select {
+			case message := <-other:
+				return failed("the subscriber of another channel received %q", message)
+			default:
+			}
+			return nil
+		}},
+		{"closes the messages once the subscriber unsubscribes", func() error {
+			messages, unsubscribe := newState().Subscribe("conformance-events")
+			unsubscribe()
+			unsubscribe()
+
+			deadline := time.After(DeliveryTimeout)
+			for {
+				select {
+				case _, open := <-messages:
+					if !open {
+						return nil
+					}
+				case <-deadline:
+					return failed("the messages were not closed")
+				}
+			}
+		}},
+		{"returns the value of a key", func() error {
+			state := newState()
+			if err := state.Put("conformance-key", []byte("first"), time.Minute); err != nil {
+				return failed("cannot put: %s", err)
+			}
+			if err := state.Put("conformance-key", []byte("second"), time.Minute); err != nil {
+				return failed("cannot put again: %s", err)
+			}
+			value, found, err := state.Get("conformance-key")
+			if err != nil || !found || string(value) != "second" {
+				return failed("got %q, found %t and error %v", value, found, err)
+			}
+			return nil
+		}},
+		{"does not find an unknown key", func() error {
+			value, found, err := newState().Get("conformance-unknown")
+			if err != nil || found {
+				return failed("got %q, found %t and error %v", value, found, err)
+			}
+			return nil
+		}},
+		{"does not find a value once its ttl ran out", func() error {
+			state := newState()
+			if err := state.Put("conformance-expiring", []byte("value"), 100*time.Millisecond); err != nil {
+				return failed("cannot put: %s", err)
+			}
+			time.Sleep(200 * time.Millisecond)
+			value, found, err := state.Get("conformance-expiring")
+			if err != nil || found {
+				return failed("got %q, found %t and error %v", value, found, err)
+			}
+			return nil
+		}},
+	})
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
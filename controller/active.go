@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// ActiveDeploymentsHandler returns every deployment that is running or queued as JSON, longest running first, so
+// operators can see what is in progress before restarting the server. A deployment is queued until it starts its
+// first phase. The deployments of every tenant are listed, so the request must have the credentials of the server.
+func (c *Controller) ActiveDeploymentsHandler(g *gin.Context) {
+	if c.History == nil {
+		g.String(http.StatusNotFound, "deployment history is not enabled")
+		return
+	}
+
+	user, pwd, _ := g.Request.BasicAuth()
+	if !c.serverCredentials(user, pwd) {
+		g.String(http.StatusUnauthorized, "the credentials of the server are required to list the active deployments")
+		return
+	}
+
+	records, err := c.History.Find(S.DeploymentQuery{Status: S.DeploymentRunning})
+	if err != nil {
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot query deployment history: %s", err)
+		return
+	}
+
+	now := time.Now()
+	active := []S.ActiveDeployment{}
+	for _, record := range records {
+		deployment := S.ActiveDeployment{
+			UUID:           record.UUID,
+			Environment:    record.Environment,
+			Org:            record.Org,
+			Space:          record.Space,
+			AppName:        record.AppName,
+			Phase:          S.PhaseQueued,
+			StartedAt:      record.StartedAt,
+			ElapsedSeconds: int(now.Sub(record.StartedAt).Seconds()),
+			Username:       record.Username,
+		}
+
+		if c.Progress != nil {
+			if progress, ok := c.Progress.Get(record.UUID); ok {
+				if progress.Finished {
+					continue
+				}
+				if progress.Phase != "" {
+					deployment.Phase = progress.Phase
+				}
+				deployment.Percent = progress.Percent
+			}
+		}
+
+		active = append(active, deployment)
+	}
+
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].StartedAt.Before(active[j].StartedAt)
+	})
+
+	g.JSON(http.StatusOK, active)
+}
@@ -0,0 +1,122 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/progress"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("ActiveDeploymentsHandler", func() {
+	var (
+		deploymentHistory *history.MemoryHistory
+		tracker           *progress.Tracker
+		controller        *Controller
+		router            *gin.Engine
+		resp              *httptest.ResponseRecorder
+	)
+
+	request := func(username, password string) []S.ActiveDeployment {
+		req, err := http.NewRequest("GET", "/v3/admin/deployments/active", nil)
+		Expect(err).ToNot(HaveOccurred())
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		router.ServeHTTP(resp, req)
+
+		var active []S.ActiveDeployment
+		if resp.Code == http.StatusOK {
+			Expect(json.Unmarshal(resp.Body.Bytes(), &active)).To(Succeed())
+		}
+		return active
+	}
+
+	BeforeEach(func() {
+		deploymentHistory = history.NewMemoryHistory(0)
+		tracker = progress.NewTracker()
+
+		controller = &Controller{
+			Log:      I.DefaultLogger(NewBuffer(), logging.DEBUG, "active_test"),
+			Config:   config.Config{Username: "username", Password: "password"},
+			History:  deploymentHistory,
+			Progress: tracker,
+		}
+
+		now := time.Now().UTC()
+		for _, record := range []S.DeploymentRecord{
+			{UUID: "running", Environment: "prod", Org: "org", Space: "space", AppName: "search", Username: "alice", Status: S.DeploymentRunning, StartedAt: now.Add(-time.Minute)},
+			{UUID: "queued", Environment: "stage", Org: "org", Space: "space", AppName: "checkout", Username: "bob", Status: S.DeploymentRunning, StartedAt: now.Add(-time.Second)},
+			{UUID: "finished", Environment: "prod", Org: "org", Space: "space", AppName: "search", Status: S.DeploymentSucceeded, StartedAt: now.Add(-time.Hour)},
+		} {
+			Expect(deploymentHistory.Record(record)).To(Succeed())
+		}
+		tracker.StartPhase("running", S.PhaseExecuting, 2)
+
+		router = gin.New()
+		router.GET("/v3/admin/deployments/active", controller.ActiveDeploymentsHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	It("returns the running and queued deployments, longest running first", func() {
+		active := request("username", "password")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(active).To(HaveLen(2))
+
+		Expect(active[0].UUID).To(Equal("running"))
+		Expect(active[0].Environment).To(Equal("prod"))
+		Expect(active[0].AppName).To(Equal("search"))
+		Expect(active[0].Username).To(Equal("alice"))
+		Expect(active[0].Phase).To(Equal(S.PhaseExecuting))
+		Expect(active[0].Percent).To(Equal(40))
+		Expect(active[0].ElapsedSeconds).To(BeNumerically(">=", 60))
+
+		Expect(active[1].UUID).To(Equal("queued"))
+		Expect(active[1].Phase).To(Equal(S.PhaseQueued))
+		Expect(active[1].Username).To(Equal("bob"))
+	})
+
+	It("does not return deployments whose progress is finished", func() {
+		tracker.Finish("running", errors.New("push failed"))
+
+		active := request("username", "password")
+
+		Expect(active).To(HaveLen(1))
+		Expect(active[0].UUID).To(Equal("queued"))
+	})
+
+	It("returns an empty list when no deployment is active", func() {
+		deploymentHistory = history.NewMemoryHistory(0)
+		controller.History = deploymentHistory
+
+		Expect(request("username", "password")).To(BeEmpty())
+		Expect(resp.Body.String()).To(Equal("[]"))
+	})
+
+	It("requires the credentials of the server", func() {
+		request("username", "wrong")
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("returns a 404 when the deployment history is not enabled", func() {
+		controller.History = nil
+
+		request("username", "password")
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/compozed/deployadactyl/deploymentid"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// BatchIDMetadataKey is the metadata key holding the id of the batch a deployment is part of.
+const BatchIDMetadataKey = "batch_id"
+
+// BatchRequest deploys the same push request to each of the environments, in order.
+type BatchRequest struct {
+	Environments []string        `json:"environments"`
+	Deployment   json.RawMessage `json:"deployment"`
+}
+
+// BatchDeploymentHandler deploys the application to every environment of the batch in order, and stops at the
+// first deployment that does not succeed. The environments after it are skipped.
+//
+// The response is the combined BatchReport. The output of every deployment is kept in the deployment logs.
+func (c *Controller) BatchDeploymentHandler(g *gin.Context) {
+	bodyBuffer, ok := c.readBody(g)
+	if !ok {
+		return
+	}
+
+	batchRequest := &BatchRequest{}
+	err := json.Unmarshal(bodyBuffer, batchRequest)
+	if err != nil {
+		g.String(http.StatusBadRequest, "invalid request body: %s", err)
+		return
+	}
+	for i, environment := range batchRequest.Environments {
+		batchRequest.Environments[i] = strings.ToLower(environment)
+	}
+	if len(batchRequest.Environments) == 0 {
+		g.String(http.StatusBadRequest, "invalid request body: no environments")
+		return
+	}
+	if len(batchRequest.Deployment) == 0 {
+		g.String(http.StatusBadRequest, "invalid request body: no deployment")
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, environment := range batchRequest.Environments {
+		if _, ok := c.Config.Environments[environment]; !ok {
+			g.String(http.StatusBadRequest, "environment not found: %s", environment)
+			return
+		}
+		if seen[environment] {
+			g.String(http.StatusBadRequest, "environment is in the batch more than once: %s", environment)
+			return
+		}
+		seen[environment] = true
+	}
+	for _, environment := range batchRequest.Environments {
+		if !c.authorizeEnvironment(g, environment) || !c.authenticateUser(g, environment) {
+			return
+		}
+	}
+
+	idempotencyKey := g.Request.Header.Get(deploymentid.IdempotencyKeyHeader)
+	report := S.BatchReport{
+		BatchID: c.Config.DeploymentID.Generate("", idempotencyKey),
+		Status:  S.DeploymentSucceeded,
+	}
+	c.Log.Debugf("batch %s of %s originated from: %+v", report.BatchID, batchRequest.Environments, g.Request.RemoteAddr)
+
+	authorization := requestAuthorization(g)
+	metadata := S.MergeMetadata(metadataFromHeaders(g.Request.Header), map[string]string{BatchIDMetadataKey: report.BatchID})
+	statusCode := http.StatusOK
+
+	for _, environment := range batchRequest.Environments {
+		if report.Status != S.DeploymentSucceeded {
+			report.Deployments = append(report.Deployments, S.BatchDeployment{Environment: environment, Status: S.DeploymentSkipped})
+			continue
+		}
+
+		log := I.DeploymentLogger{Log: c.Log, UUID: c.Config.DeploymentID.Generate(environment, idempotencyKey)}
+		body := []byte(batchRequest.Deployment)
+		deployment := I.Deployment{
+			Authorization: authorization,
+			CFContext: I.CFContext{
+				Environment:  environment,
+				Organization: g.Param("org"),
+				Space:        g.Param("space"),
+				Application:  g.Param("appName"),
+			},
+			Type:     I.DeploymentType{JSON: true},
+			Body:     &body,
+			Metadata: S.MergeMetadata(metadata),
+		}
+
+		unlock, err := c.lockApp(log, deployment.CFContext)
+		if err != nil {
+			statusCode = lockErrorStatus(err)
+			report.Status = S.DeploymentFailed
+			report.Deployments = append(report.Deployments, S.BatchDeployment{
+				Environment: environment,
+				UUID:        log.UUID,
+				Status:      S.DeploymentFailed,
+				StatusCode:  statusCode,
+				Error:       err.Error(),
+			})
+			continue
+		}
+
+		response := c.newResponse()
+		stopStreaming := c.streamLogs(log, response)
+		ctx, done := c.deploymentContext(g, environment, log)
+		deployResponse := c.PushControllerFactory(log).RunDeployment(ctx, &deployment, response)
+		done()
+		c.recordFailedFoundations(log)
+		unlock()
+
+		result := S.BatchDeployment{
+			Environment: environment,
+			UUID:        log.UUID,
+			Status:      S.DeploymentSucceeded,
+			StatusCode:  deployResponse.StatusCode,
+		}
+		if deployResponse.Error != nil {
+			result.Status = S.DeploymentFailed
+			result.Error = deployResponse.Error.Error()
+			statusCode = deployResponse.StatusCode
+			fmt.Fprintf(response, "%s: %s\n", deployErrorMessage(deployResponse), deployResponse.Error)
+		} else if deployResponse.Degraded {
+			result.Status = S.DeploymentDegraded
+		}
+		if deployResponse.PartialSuccess {
+			result.Status = S.DeploymentPartial
+			statusCode = deployResponse.StatusCode
+		}
+		c.saveDeploymentLog(log, response)
+		stopStreaming()
+		response.Close()
+
+		report.Status = result.Status
+		report.Deployments = append(report.Deployments, result)
+	}
+
+	g.JSON(statusCode, report)
+}
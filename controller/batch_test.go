@@ -0,0 +1,187 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/deploymentlog"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("BatchDeploymentHandler", func() {
+	var (
+		pushControllers []*mocks.PushController
+		responses       []I.DeployResponse
+		logStore        *deploymentlog.MemoryStore
+		controller      *Controller
+		router          *gin.Engine
+		resp            *httptest.ResponseRecorder
+	)
+
+	deploy := func(body string) S.BatchReport {
+		req, err := http.NewRequest("POST", "/v3/batches/org/space/search", bytes.NewBufferString(body))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(MetadataHeaderPrefix+"Pipeline-Id", "42")
+		req.SetBasicAuth("username", "password")
+		router.ServeHTTP(resp, req)
+
+		report := S.BatchReport{}
+		if resp.Header().Get("Content-Type") == "application/json; charset=utf-8" {
+			Expect(json.Unmarshal(resp.Body.Bytes(), &report)).To(Succeed())
+		}
+		return report
+	}
+
+	BeforeEach(func() {
+		pushControllers = nil
+		responses = []I.DeployResponse{{StatusCode: http.StatusOK}, {StatusCode: http.StatusOK}, {StatusCode: http.StatusOK}}
+		logStore = deploymentlog.NewMemoryStore(S.DeploymentLogsDescriptor{})
+
+		controller = &Controller{
+			Log: I.DefaultLogger(NewBuffer(), logging.DEBUG, "batch_test"),
+			Config: config.Config{
+				Environments: map[string]S.Environment{
+					"sandbox": {Name: "sandbox"},
+					"stage":   {Name: "stage"},
+					"prod":    {Name: "prod"},
+				},
+			},
+			DeploymentLogs: logStore,
+			PushControllerFactory: func(log I.DeploymentLogger) I.PushController {
+				pushController := &mocks.PushController{}
+				pushController.RunDeploymentCall.Returns.DeployResponse = responses[len(pushControllers)]
+				pushController.RunDeploymentCall.Writes = "deploy output"
+				pushControllers = append(pushControllers, pushController)
+				return pushController
+			},
+		}
+
+		router = gin.New()
+		router.POST("/v3/batches/:org/:space/:appName", controller.BatchDeploymentHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	It("deploys to every environment in order", func() {
+		report := deploy(`{"environments": ["sandbox", "stage", "prod"], "deployment": {"artifact_url": "https://example.com/artifact.zip"}}`)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(report.Status).To(Equal(S.DeploymentSucceeded))
+		Expect(report.BatchID).ToNot(BeEmpty())
+		Expect(report.Deployments).To(HaveLen(3))
+
+		Expect(pushControllers).To(HaveLen(3))
+		for i, environment := range []string{"sandbox", "stage", "prod"} {
+			deployment := pushControllers[i].RunDeploymentCall.Received.Deployment
+			Expect(deployment.CFContext).To(Equal(I.CFContext{Environment: environment, Organization: "org", Space: "space", Application: "search"}))
+			Expect(deployment.Authorization).To(Equal(I.Authorization{Username: "username", Password: "password"}))
+			Expect(deployment.Type).To(Equal(I.DeploymentType{JSON: true}))
+			Expect(string(*deployment.Body)).To(Equal(`{"artifact_url": "https://example.com/artifact.zip"}`))
+			Expect(deployment.Metadata).To(Equal(map[string]string{"pipeline_id": "42", BatchIDMetadataKey: report.BatchID}))
+
+			Expect(report.Deployments[i].Environment).To(Equal(environment))
+			Expect(report.Deployments[i].Status).To(Equal(S.DeploymentSucceeded))
+			Expect(report.Deployments[i].StatusCode).To(Equal(http.StatusOK))
+		}
+	})
+
+	It("keeps the output of every deployment in the deployment logs", func() {
+		report := deploy(`{"environments": ["sandbox", "stage"], "deployment": {}}`)
+
+		for _, deployment := range report.Deployments {
+			output, err := logStore.Get(deployment.UUID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(output)).To(Equal("deploy output"))
+		}
+	})
+
+	It("stops at the first deployment that fails and skips the rest", func() {
+		responses[1] = I.DeployResponse{StatusCode: http.StatusInternalServerError, Error: errors.New("push failed")}
+
+		report := deploy(`{"environments": ["sandbox", "stage", "prod"], "deployment": {}}`)
+
+		Expect(resp.Code).To(Equal(http.StatusInternalServerError))
+		Expect(pushControllers).To(HaveLen(2))
+		Expect(report.Status).To(Equal(S.DeploymentFailed))
+		Expect(report.Deployments[0].Status).To(Equal(S.DeploymentSucceeded))
+		Expect(report.Deployments[1].Status).To(Equal(S.DeploymentFailed))
+		Expect(report.Deployments[1].Error).To(Equal("push failed"))
+		Expect(report.Deployments[2]).To(Equal(S.BatchDeployment{Environment: "prod", Status: S.DeploymentSkipped}))
+
+		output, err := logStore.Get(report.Deployments[1].UUID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(output)).To(ContainSubstring("cannot deploy application: push failed"))
+	})
+
+	It("stops at a degraded deployment", func() {
+		responses[0] = I.DeployResponse{StatusCode: http.StatusOK, Degraded: true}
+
+		report := deploy(`{"environments": ["sandbox", "stage"], "deployment": {}}`)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(pushControllers).To(HaveLen(1))
+		Expect(report.Status).To(Equal(S.DeploymentDegraded))
+		Expect(report.Deployments[1].Status).To(Equal(S.DeploymentSkipped))
+	})
+
+	It("stops at a partially successful deployment", func() {
+		responses[0] = I.DeployResponse{StatusCode: http.StatusMultiStatus, Error: errors.New("push failed"), PartialSuccess: true}
+
+		report := deploy(`{"environments": ["sandbox", "stage"], "deployment": {}}`)
+
+		Expect(resp.Code).To(Equal(http.StatusMultiStatus))
+		Expect(pushControllers).To(HaveLen(1))
+		Expect(report.Status).To(Equal(S.DeploymentPartial))
+		Expect(report.Deployments[0].Error).To(Equal("push failed"))
+		Expect(report.Deployments[1].Status).To(Equal(S.DeploymentSkipped))
+
+		output, err := logStore.Get(report.Deployments[0].UUID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(output)).To(ContainSubstring("deployment partially succeeded: push failed"))
+	})
+
+	It("returns http.StatusBadRequest for an unknown environment", func() {
+		deploy(`{"environments": ["sandbox", "qa"], "deployment": {}}`)
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		Expect(resp.Body.String()).To(ContainSubstring("environment not found: qa"))
+		Expect(pushControllers).To(BeEmpty())
+	})
+
+	It("returns http.StatusBadRequest for an environment that is in the batch twice", func() {
+		deploy(`{"environments": ["sandbox", "sandbox"], "deployment": {}}`)
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		Expect(pushControllers).To(BeEmpty())
+	})
+
+	It("returns http.StatusBadRequest without environments", func() {
+		deploy(`{"environments": [], "deployment": {}}`)
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("returns http.StatusBadRequest without a deployment", func() {
+		deploy(`{"environments": ["sandbox"]}`)
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("returns http.StatusBadRequest for an invalid body", func() {
+		deploy(`not json`)
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+})
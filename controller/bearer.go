@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/oidc"
+	"github.com/gin-gonic/gin"
+)
+
+// Where the user of the bearer token of a request is kept by AuthenticateBearer, and the groups of the user of a
+// request by AuthenticateBearer or LDAP.
+const (
+	bearerUserKey = "deployadactyl.bearerUser"
+	groupsKey     = "deployadactyl.groups"
+)
+
+// AuthenticateBearer checks the bearer token of the requests that have one, and refuses them with 401 when it is not
+// valid. The user of a valid token is recorded as the user of the deployments of the request, which are made with
+// the Cloud Foundry credentials of the environment. Requests without a bearer token are left to basic auth.
+func AuthenticateBearer(verifier I.TokenVerifier, log I.Logger) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		header := g.Request.Header.Get("Authorization")
+		if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+			return
+		}
+
+		username, groups, err := verifier.Verify(strings.TrimSpace(header[7:]))
+		if err != nil {
+			if _, ok := err.(oidc.InvalidTokenError); ok {
+				g.Header("WWW-Authenticate", `Bearer realm="deployadactyl", error="invalid_token"`)
+				g.String(http.StatusUnauthorized, "%s", err)
+			} else {
+				log.Errorf("cannot verify bearer token: %s", err)
+				g.String(http.StatusServiceUnavailable, "cannot verify bearer token: %s", err)
+			}
+			g.Abort()
+			return
+		}
+
+		g.Set(bearerUserKey, username)
+		g.Set(groupsKey, groups)
+	}
+}
+
+// environmentParam returns the environment of the path of the request. Environments are configured in lowercase,
+// so the name is lowercased once here rather than at every lookup.
+func environmentParam(g *gin.Context) string {
+	return strings.ToLower(g.Param("environment"))
+}
+
+// requestAuthorization returns the basic auth of the request, the user of its bearer token and the groups of its
+// user.
+func requestAuthorization(g *gin.Context) I.Authorization {
+	user, pwd, _ := g.Request.BasicAuth()
+	subject, _ := g.Get(bearerUserKey)
+	username, _ := subject.(string)
+	memberOf, _ := g.Get(groupsKey)
+	groups, _ := memberOf.([]string)
+
+	return I.Authorization{
+		Username: user,
+		Password: pwd,
+		Subject:  username,
+		Groups:   groups,
+	}
+}
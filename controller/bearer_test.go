@@ -0,0 +1,116 @@
+package controller_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/oidc"
+	"github.com/compozed/deployadactyl/rbac"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Bearer tokens", func() {
+	var (
+		verifier       *mocks.TokenVerifier
+		pushController *mocks.PushController
+		router         *gin.Engine
+		req            *http.Request
+		resp           *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		verifier = &mocks.TokenVerifier{}
+		verifier.VerifyCall.Returns.Username = "jane@example.com"
+		verifier.VerifyCall.Returns.Groups = []string{"deployers"}
+		pushController = &mocks.PushController{}
+		pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+
+		log := I.DefaultLogger(NewBuffer(), logging.DEBUG, "bearer_test")
+		controller := &Controller{
+			Log: log,
+			Config: config.Config{
+				Environments: map[string]S.Environment{
+					"prod": {Name: "prod", Foundations: []string{"https://api1.example.com"}},
+				},
+			},
+			PushControllerFactory: func(log I.DeploymentLogger) I.PushController {
+				return pushController
+			},
+		}
+
+		router = gin.New()
+		router.Use(AuthenticateBearer(verifier, log))
+		router.POST("/v3/apps/:environment/:org/:space/:appName", controller.RunDeploymentViaHttp)
+		resp = httptest.NewRecorder()
+
+		var err error
+		req, err = http.NewRequest("POST", "/v3/apps/prod/org/space/app", strings.NewReader(`{"artifact_url": "https://example.com/artifact.zip"}`))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+	})
+
+	It("deploys as the user of a valid token", func() {
+		req.Header.Set("Authorization", "Bearer the-token")
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(verifier.VerifyCall.Received.Token).To(Equal("the-token"))
+		Expect(pushController.RunDeploymentCall.Received.Deployment.Authorization).To(Equal(I.Authorization{Subject: "jane@example.com", Groups: []string{"deployers"}}))
+	})
+
+	It("leaves the requests without a bearer token to basic auth", func() {
+		req.SetBasicAuth("cf-user", "cf-password")
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(verifier.VerifyCall.Called).To(BeZero())
+		Expect(pushController.RunDeploymentCall.Received.Deployment.Authorization).To(Equal(I.Authorization{Username: "cf-user", Password: "cf-password"}))
+	})
+
+	It("refuses an invalid token", func() {
+		verifier.VerifyCall.Returns.Error = oidc.InvalidTokenError{Reason: "expired"}
+		req.Header.Set("Authorization", "Bearer the-token")
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Header().Get("WWW-Authenticate")).To(ContainSubstring(`error="invalid_token"`))
+		Expect(resp.Body.String()).To(Equal("invalid bearer token: expired"))
+		Expect(pushController.RunDeploymentCall.Received.Deployment).To(BeNil())
+	})
+
+	It("writes the operations the access rules do not allow as json only", func() {
+		forbidden := rbac.ForbiddenError{User: "jane@example.com", Operation: rbac.Deploy, Environment: "prod"}
+		pushController.RunDeploymentCall.Writes = `{"error": "jane@example.com may not deploy on environment prod"}`
+		pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusForbidden, Error: forbidden}
+		req.Header.Set("Authorization", "Bearer the-token")
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusForbidden))
+		Expect(resp.Body.String()).To(Equal(`{"error": "jane@example.com may not deploy on environment prod"}`))
+	})
+
+	It("does not deploy when the keys of the issuer cannot be read", func() {
+		verifier.VerifyCall.Returns.Error = oidc.DiscoveryError{URL: "https://login.example.com/keys", Err: errors.New("connection refused")}
+		req.Header.Set("Authorization", "Bearer the-token")
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(pushController.RunDeploymentCall.Received.Deployment).To(BeNil())
+	})
+})
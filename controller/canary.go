@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/compozed/deployadactyl/canary"
+	"github.com/gin-gonic/gin"
+)
+
+// PromoteCanaryHandler promotes the canary of the running deployment with the uuid in the path before its bake
+// period is over, or once it has to be confirmed. The promotion reaches the deployment on any instance of the server
+// sharing its state, and the response does not wait for the canary to be scaled. A canary is aborted by cancelling
+// its deployment.
+//
+// The request must have the credentials of the server, or an API token of the tenant of the environment of the
+// deployment.
+func (c *Controller) PromoteCanaryHandler(g *gin.Context) {
+	if c.CanaryPromotions == nil {
+		g.String(http.StatusNotFound, "canary promotion is not enabled")
+		return
+	}
+
+	uuid := g.Param("uuid")
+	environment, ok := c.runningEnvironment(g, uuid)
+	if !ok || !c.authorizeRunningDeployment(g, environment, "promote a canary") {
+		return
+	}
+
+	err := c.CanaryPromotions.Promote(uuid)
+	if err != nil {
+		if _, ok := err.(canary.NotWaitingError); ok {
+			g.String(http.StatusNotFound, err.Error())
+			return
+		}
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot promote the canary of deployment %s: %s", uuid, err)
+		return
+	}
+
+	c.Log.Infof("promoting the canary of deployment %s", uuid)
+	g.String(http.StatusAccepted, "promoting the canary of deployment %s\n", uuid)
+}
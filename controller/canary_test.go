@@ -0,0 +1,131 @@
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/compozed/deployadactyl/canary"
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("PromoteCanaryHandler", func() {
+	var (
+		deploymentHistory *history.MemoryHistory
+		registry          *canary.Registry
+		controller        *Controller
+		router            *gin.Engine
+		resp              *httptest.ResponseRecorder
+		promoted          <-chan struct{}
+		done              func()
+	)
+
+	promote := func(uuid string, auth func(*http.Request)) {
+		req, err := http.NewRequest("POST", "/v3/deployments/"+uuid+"/canary/promote", nil)
+		Expect(err).ToNot(HaveOccurred())
+		if auth != nil {
+			auth(req)
+		}
+		router.ServeHTTP(resp, req)
+	}
+
+	serverCredentials := func(req *http.Request) {
+		req.SetBasicAuth("username", "password")
+	}
+
+	BeforeEach(func() {
+		deploymentHistory = history.NewMemoryHistory(0)
+		registry = canary.NewRegistry()
+
+		searchProd := S.Environment{Name: "search-prod", Tenant: "search"}
+		controller = &Controller{
+			Log:              I.DefaultLogger(NewBuffer(), logging.DEBUG, "canary_test"),
+			History:          deploymentHistory,
+			CanaryPromotions: registry,
+			Config: config.Config{
+				Username: "username",
+				Password: "password",
+				Environments: map[string]S.Environment{
+					"prod":        {Name: "prod"},
+					"search-prod": searchProd,
+				},
+				Tenants: map[string]S.Tenant{
+					"search": {Name: "search", APITokens: []string{"search-token"}, Environments: []S.Environment{searchProd}},
+				},
+			},
+		}
+
+		for _, record := range []S.DeploymentRecord{
+			{UUID: "baking", Environment: "prod", Status: S.DeploymentRunning},
+			{UUID: "search", Environment: "search-prod", Status: S.DeploymentRunning},
+			{UUID: "pushing", Environment: "prod", Status: S.DeploymentRunning},
+			{UUID: "finished", Environment: "prod", Status: S.DeploymentSucceeded},
+		} {
+			Expect(deploymentHistory.Record(record)).To(Succeed())
+		}
+		promoted, done = registry.Wait("baking")
+
+		router = gin.New()
+		router.POST("/v3/deployments/:uuid/canary/promote", controller.PromoteCanaryHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		done()
+	})
+
+	It("promotes the canary of the running deployment", func() {
+		promote("baking", serverCredentials)
+
+		Expect(resp.Code).To(Equal(http.StatusAccepted))
+		Expect(resp.Body.String()).To(ContainSubstring("promoting the canary of deployment baking"))
+		Expect(promoted).To(BeClosed())
+	})
+
+	It("requires the credentials of the server", func() {
+		promote("baking", func(req *http.Request) { req.SetBasicAuth("username", "wrong") })
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Body.String()).To(ContainSubstring("to promote a canary"))
+		Expect(promoted).ToNot(BeClosed())
+	})
+
+	It("accepts an api token of the tenant of the environment", func() {
+		searchPromoted, searchDone := registry.Wait("search")
+		defer searchDone()
+
+		promote("search", func(req *http.Request) { req.Header.Set(TenantTokenHeader, "search-token") })
+
+		Expect(resp.Code).To(Equal(http.StatusAccepted))
+		Expect(searchPromoted).To(BeClosed())
+	})
+
+	It("returns a conflict for a deployment that is not running", func() {
+		promote("finished", serverCredentials)
+
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+	})
+
+	It("returns not found for a deployment whose canary is not waiting to be promoted", func() {
+		promote("pushing", serverCredentials)
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+		Expect(resp.Body.String()).To(Equal("the canary of deployment pushing is not waiting to be promoted"))
+	})
+
+	It("returns not found when canary promotion is not enabled", func() {
+		controller.CanaryPromotions = nil
+
+		promote("baking", serverCredentials)
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+	})
+})
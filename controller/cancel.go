@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/compozed/deployadactyl/cancellation"
+	"github.com/compozed/deployadactyl/history"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// CancelDeploymentHandler cancels the running deployment with the uuid in the path, which then rolls back like a
+// deployment that failed. The cancellation reaches the deployment on any instance of the server sharing its state,
+// and the response does not wait for the deployment to stop.
+//
+// The request must have the credentials of the server, or an API token of the tenant of the environment of the
+// deployment, which is only known from the deployment history.
+func (c *Controller) CancelDeploymentHandler(g *gin.Context) {
+	if c.Cancellations == nil {
+		g.String(http.StatusNotFound, "deployment cancellation is not enabled")
+		return
+	}
+
+	uuid := g.Param("uuid")
+	environment, ok := c.runningEnvironment(g, uuid)
+	if !ok || !c.authorizeRunningDeployment(g, environment, "cancel a deployment") {
+		return
+	}
+
+	err := c.Cancellations.Cancel(uuid)
+	if err != nil {
+		if _, ok := err.(cancellation.NotRunningError); ok {
+			g.String(http.StatusNotFound, err.Error())
+			return
+		}
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot cancel deployment %s: %s", uuid, err)
+		return
+	}
+
+	c.Log.Infof("cancelling deployment %s", uuid)
+	g.String(http.StatusAccepted, "cancelling deployment %s\n", uuid)
+}
+
+// runningEnvironment returns the environment of the running deployment with the uuid, which is only known from the
+// deployment history, or writes why the deployment is not running.
+func (c *Controller) runningEnvironment(g *gin.Context, uuid string) (string, bool) {
+	if c.History == nil {
+		return "", true
+	}
+
+	record, err := c.History.Get(uuid)
+	if err != nil {
+		if _, ok := err.(history.RecordNotFoundError); ok {
+			g.String(http.StatusNotFound, err.Error())
+			return "", false
+		}
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot query deployment history: %s", err)
+		return "", false
+	}
+	if record.Status != S.DeploymentRunning {
+		g.String(http.StatusConflict, "deployment %s is not running", uuid)
+		return "", false
+	}
+	return record.Environment, true
+}
+
+// authorizeRunningDeployment reports whether the request may take the action on a running deployment to the
+// environment, or writes why it may not.
+func (c *Controller) authorizeRunningDeployment(g *gin.Context, environment, action string) bool {
+	tenant, ok := c.requestTenant(g)
+	if !ok {
+		return false
+	}
+	if tenant != "" && environment != "" && c.environmentTenant(environment) == tenant {
+		return true
+	}
+
+	user, pwd, _ := g.Request.BasicAuth()
+	if c.serverCredentials(user, pwd) {
+		return true
+	}
+
+	g.String(http.StatusUnauthorized, "the credentials of the server or an api token of the tenant of the environment are required to %s", action)
+	return false
+}
@@ -0,0 +1,144 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/compozed/deployadactyl/cancellation"
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("CancelDeploymentHandler", func() {
+	var (
+		deploymentHistory *history.MemoryHistory
+		registry          *cancellation.Registry
+		controller        *Controller
+		router            *gin.Engine
+		resp              *httptest.ResponseRecorder
+		ctx               context.Context
+		done              func()
+	)
+
+	cancel := func(uuid string, auth func(*http.Request)) {
+		req, err := http.NewRequest("POST", "/v3/deployments/"+uuid+"/cancel", nil)
+		Expect(err).ToNot(HaveOccurred())
+		if auth != nil {
+			auth(req)
+		}
+		router.ServeHTTP(resp, req)
+	}
+
+	serverCredentials := func(req *http.Request) {
+		req.SetBasicAuth("username", "password")
+	}
+
+	BeforeEach(func() {
+		deploymentHistory = history.NewMemoryHistory(0)
+		registry = cancellation.NewRegistry()
+
+		searchProd := S.Environment{Name: "search-prod", Tenant: "search"}
+		controller = &Controller{
+			Log:           I.DefaultLogger(NewBuffer(), logging.DEBUG, "cancel_test"),
+			History:       deploymentHistory,
+			Cancellations: registry,
+			Config: config.Config{
+				Username: "username",
+				Password: "password",
+				Environments: map[string]S.Environment{
+					"prod":        {Name: "prod"},
+					"search-prod": searchProd,
+				},
+				Tenants: map[string]S.Tenant{
+					"search": {Name: "search", APITokens: []string{"search-token"}, Environments: []S.Environment{searchProd}},
+				},
+			},
+		}
+
+		for _, record := range []S.DeploymentRecord{
+			{UUID: "running", Environment: "prod", Status: S.DeploymentRunning},
+			{UUID: "search", Environment: "search-prod", Status: S.DeploymentRunning},
+			{UUID: "finished", Environment: "prod", Status: S.DeploymentSucceeded},
+		} {
+			Expect(deploymentHistory.Record(record)).To(Succeed())
+		}
+		ctx, done = registry.Context(context.Background(), "running")
+
+		router = gin.New()
+		router.POST("/v3/deployments/:uuid/cancel", controller.CancelDeploymentHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		done()
+	})
+
+	It("cancels the running deployment", func() {
+		cancel("running", serverCredentials)
+
+		Expect(resp.Code).To(Equal(http.StatusAccepted))
+		Expect(resp.Body.String()).To(ContainSubstring("cancelling deployment running"))
+		Expect(ctx.Done()).To(BeClosed())
+	})
+
+	It("requires the credentials of the server", func() {
+		cancel("running", func(req *http.Request) { req.SetBasicAuth("username", "wrong") })
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(ctx.Done()).ToNot(BeClosed())
+	})
+
+	It("accepts an api token of the tenant of the environment", func() {
+		searchCtx, searchDone := registry.Context(context.Background(), "search")
+		defer searchDone()
+
+		cancel("search", func(req *http.Request) { req.Header.Set(TenantTokenHeader, "search-token") })
+
+		Expect(resp.Code).To(Equal(http.StatusAccepted))
+		Expect(searchCtx.Done()).To(BeClosed())
+	})
+
+	It("does not accept an api token of a tenant for a shared environment", func() {
+		cancel("running", func(req *http.Request) { req.Header.Set(TenantTokenHeader, "search-token") })
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("returns a conflict for a deployment that is not running", func() {
+		cancel("finished", serverCredentials)
+
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+	})
+
+	It("returns not found for an unknown deployment", func() {
+		cancel("unknown", serverCredentials)
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("returns not found for a deployment that runs on another instance without shared state", func() {
+		deploymentHistory.Record(S.DeploymentRecord{UUID: "elsewhere", Environment: "prod", Status: S.DeploymentRunning})
+
+		cancel("elsewhere", serverCredentials)
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+		Expect(resp.Body.String()).To(Equal("deployment elsewhere is not running"))
+	})
+
+	It("returns not found when cancellation is not enabled", func() {
+		controller.Cancellations = nil
+
+		cancel("running", serverCredentials)
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -3,121 +3,238 @@ package controller
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"io/ioutil"
 
 	"encoding/json"
 	I "github.com/compozed/deployadactyl/interfaces"
 
 	"github.com/compozed/deployadactyl/config"
-	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/deploymentid"
+	"github.com/compozed/deployadactyl/rbac"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tracing"
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/afero"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type PushControllerFactory func(log I.DeploymentLogger) I.PushController
 type StartControllerFactory func(log I.DeploymentLogger) I.StartController
+type RestartControllerFactory func(log I.DeploymentLogger) I.RestartController
+type RestageControllerFactory func(log I.DeploymentLogger) I.RestageController
+type DeleteControllerFactory func(log I.DeploymentLogger) I.DeleteController
 type StopControllerFactory func(log I.DeploymentLogger) I.StopController
 
 // Controller is used to determine the type of request and process it accordingly.
 type Controller struct {
-	Log                    I.Logger
-	PushControllerFactory  PushControllerFactory
-	StartControllerFactory StartControllerFactory
-	StopControllerFactory  StopControllerFactory
-	Config                 config.Config
-	EventManager           I.EventManager
-	ErrorFinder            I.ErrorFinder
+	Log                      I.Logger
+	PushControllerFactory    PushControllerFactory
+	StartControllerFactory   StartControllerFactory
+	StopControllerFactory    StopControllerFactory
+	RestartControllerFactory RestartControllerFactory
+	RestageControllerFactory RestageControllerFactory
+	DeleteControllerFactory  DeleteControllerFactory
+	Config                   config.Config
+	EventManager             I.EventManager
+	ErrorFinder              I.ErrorFinder
+	History                  I.DeploymentHistory
+	TempDirectories          I.TempDirectoryTracker
+	DeploymentLogs           I.DeploymentLogStore
+	LogStreams               I.LogStreamer
+	Progress                 I.ProgressTracker
+	AppInspector             I.AppInspector
+	StaleApps                I.StaleAppSweeper
+	Maintenance              I.FoundationMaintenance
+	FileSystem               *afero.Afero
+	Events                   I.EventStream
+	Locks                    I.LockManager
+	Cancellations            I.DeploymentCanceller
+	CanaryPromotions         I.CanaryPromoter
+	Tracer                   *tracing.Tracer
+	Authenticator            I.Authenticator
 }
 
+// MetadataHeaderPrefix is the prefix of request headers that are added to the deployment metadata.
+// X-Deployadactyl-Metadata-Pipeline-Id: 1234 becomes the metadata key pipeline_id.
+const MetadataHeaderPrefix = "X-Deployadactyl-Metadata-"
+
+// SignatureHeader carries the signature of an artifact that is uploaded as a zip.
+const SignatureHeader = "X-Deployadactyl-Signature"
+
+// ManifestNameHeader selects the manifest of an artifact that is uploaded as a zip.
+const ManifestNameHeader = "X-Deployadactyl-Manifest-Name"
+
+// DeploymentIDHeader carries the uuid of the deployment in the response of a request that deploys or changes the
+// state of an application.
+const DeploymentIDHeader = "X-Deployadactyl-Deployment-Id"
+
+// TraceparentHeader carries the W3C trace context of the caller, whose trace the spans of the deployment join.
+const TraceparentHeader = "Traceparent"
+
 type PutRequest struct {
-	State string                 `json:"state"`
-	Data  map[string]interface{} `json:"data"`
+	State     string            `json:"state"`
+	Mode      S.StopMode        `json:"mode"`
+	BatchSize int               `json:"batch_size"`
+	Data      S.Params          `json:"data"`
+	Metadata  map[string]string `json:"metadata"`
+}
+
+// DeleteRequest is the optional body of a request deleting an application.
+type DeleteRequest struct {
+	Data     S.Params          `json:"data"`
+	Metadata map[string]string `json:"metadata"`
 }
 
+// DeleteRoutesParameter is the query parameter of a delete request that also deletes the routes the application
+// leaves without an application.
+const DeleteRoutesParameter = "delete_routes"
+
 // Deprecated - wrapper for PushController.RunDeployment
-func (c *Controller) RunDeployment(deployment *I.Deployment, response *bytes.Buffer) I.DeployResponse {
-	uuid := randomizer.StringRunes(10)
+func (c *Controller) RunDeployment(deployment *I.Deployment, response io.ReadWriter) I.DeployResponse {
+	uuid := c.Config.DeploymentID.Generate(deployment.CFContext.Environment, "")
 	log := I.DeploymentLogger{Log: c.Log, UUID: uuid}
-	return c.PushControllerFactory(log).RunDeployment(deployment, response)
+
+	unlock, err := c.lockApp(log, deployment.CFContext)
+	if err != nil {
+		return I.DeployResponse{StatusCode: lockErrorStatus(err), Error: err}
+	}
+	defer unlock()
+
+	return c.PushControllerFactory(log).RunDeployment(context.Background(), deployment, response)
 }
 
 // RunDeploymentViaHttp checks the request content type and passes it to the Deployer.
 func (c *Controller) RunDeploymentViaHttp(g *gin.Context) {
-	uuid := randomizer.StringRunes(10)
+	environment := environmentParam(g)
+	if !c.authorizeEnvironment(g, environment) || !c.authenticateUser(g, environment) {
+		return
+	}
+
+	uuid := c.Config.DeploymentID.Generate(environment, g.Request.Header.Get(deploymentid.IdempotencyKeyHeader))
 	log := I.DeploymentLogger{Log: c.Log, UUID: uuid}
 	log.Debugf("Request originated from: %+v", g.Request.RemoteAddr)
 
 	cfContext := I.CFContext{
-		Environment:  g.Param("environment"),
+		Environment:  environment,
 		Organization: g.Param("org"),
 		Space:        g.Param("space"),
 		Application:  g.Param("appName"),
 	}
 
-	user, pwd, _ := g.Request.BasicAuth()
-	authorization := I.Authorization{
-		Username: user,
-		Password: pwd,
-	}
+	authorization := requestAuthorization(g)
 
 	deploymentType := I.DeploymentType{
 		JSON: g.Request.Header.Get("Content-Type") == "application/json",
 		ZIP:  g.Request.Header.Get("Content-Type") == "application/zip",
 	}
-	response := &bytes.Buffer{}
 
 	deployment := I.Deployment{
 		Authorization: authorization,
 		CFContext:     cfContext,
 		Type:          deploymentType,
+		Metadata:      metadataFromHeaders(g.Request.Header),
+		Signature:     g.Request.Header.Get(SignatureHeader),
+		ManifestName:  g.Request.Header.Get(ManifestNameHeader),
+	}
+	if deploymentType.ZIP {
+		upload, ok := c.spoolUpload(g)
+		if !ok {
+			return
+		}
+		defer c.removeUpload(upload)
+		deployment.Upload = upload
+	} else {
+		bodyBuffer, ok := c.readBody(g)
+		if !ok {
+			return
+		}
+		deployment.Body = &bodyBuffer
 	}
-	bodyBuffer, _ := ioutil.ReadAll(g.Request.Body)
-	g.Request.Body.Close()
-	deployment.Body = &bodyBuffer
 
-	deployResponse := c.PushControllerFactory(log).RunDeployment(&deployment, response)
+	c.pushDeployment(g, log, &deployment)
+}
+
+// pushDeployment runs the push deployment and writes its output as the response.
+func (c *Controller) pushDeployment(g *gin.Context, log I.DeploymentLogger, deployment *I.Deployment) {
+	unlock, err := c.lockApp(log, deployment.CFContext)
+	if err != nil {
+		g.String(lockErrorStatus(err), "cannot deploy application: %s", err)
+		return
+	}
+	defer unlock()
+	g.Header(DeploymentIDHeader, log.UUID)
+
+	response := c.newResponse()
+	defer response.Close()
+	defer c.streamLogs(log, response)()
+
+	ctx, done := c.deploymentContext(g, deployment.CFContext.Environment, log)
+	defer done()
+
+	deployResponse := c.PushControllerFactory(log).RunDeployment(ctx, deployment, response)
+	c.recordFailedFoundations(log)
 
 	defer io.Copy(g.Writer, response)
+	defer c.saveDeploymentLog(log, response)
 
 	if deployResponse.Error != nil {
 		g.Writer.WriteHeader(deployResponse.StatusCode)
-		fmt.Fprintf(response, "cannot deploy application: %s\n", deployResponse.Error)
+		// An operation the access rules do not allow is already written as JSON.
+		if _, ok := deployResponse.Error.(rbac.ForbiddenError); !ok {
+			fmt.Fprintf(response, "%s: %s\n", deployErrorMessage(deployResponse), deployResponse.Error)
+		}
 		return
 	}
 
 	g.Writer.WriteHeader(deployResponse.StatusCode)
 }
 
+// deployErrorMessage prefixes the error of a deployment, which only failed on some foundations when it partially
+// succeeded.
+func deployErrorMessage(deployResponse I.DeployResponse) string {
+	if deployResponse.PartialSuccess {
+		return "deployment partially succeeded"
+	}
+	return "cannot deploy application"
+}
+
 func (c *Controller) PutRequestHandler(g *gin.Context) {
-	uuid := randomizer.StringRunes(10)
+	environment := environmentParam(g)
+	if !c.authorizeEnvironment(g, environment) || !c.authenticateUser(g, environment) {
+		return
+	}
+
+	uuid := c.Config.DeploymentID.Generate(environment, g.Request.Header.Get(deploymentid.IdempotencyKeyHeader))
 	log := I.DeploymentLogger{Log: c.Log, UUID: uuid}
 	log.Debugf("PUT Request originated from: %+v", g.Request.RemoteAddr)
 
 	cfContext := I.CFContext{
-		Environment:  g.Param("environment"),
+		Environment:  environment,
 		Organization: g.Param("org"),
 		Space:        g.Param("space"),
 		Application:  g.Param("appName"),
 	}
 
-	response := &bytes.Buffer{}
+	response := c.newResponse()
+	defer response.Close()
+	defer c.streamLogs(log, response)()
 	defer io.Copy(g.Writer, response)
 
-	user, pwd, _ := g.Request.BasicAuth()
-	authorization := I.Authorization{
-		Username: user,
-		Password: pwd,
-	}
+	authorization := requestAuthorization(g)
 
 	deployment := I.Deployment{
 		Authorization: authorization,
 		CFContext:     cfContext,
 	}
 
-	bodyBuffer, _ := ioutil.ReadAll(g.Request.Body)
-	g.Request.Body.Close()
+	bodyBuffer, ok := c.readBody(g)
+	if !ok {
+		return
+	}
 
 	putRequest := &PutRequest{}
 	err := json.Unmarshal(bodyBuffer, putRequest)
@@ -127,12 +244,45 @@ func (c *Controller) PutRequestHandler(g *gin.Context) {
 		return
 	}
 
+	deployment.Metadata = S.MergeMetadata(metadataFromHeaders(g.Request.Header), putRequest.Metadata)
+
+	unlock, err := c.lockApp(log, cfContext)
+	if err != nil {
+		response.Write([]byte("cannot change the state of the application: " + err.Error()))
+		g.Writer.WriteHeader(lockErrorStatus(err))
+		return
+	}
+	defer unlock()
+	defer c.saveDeploymentLog(log, response)
+	g.Header(DeploymentIDHeader, log.UUID)
+
+	ctx, done := c.deploymentContext(g, cfContext.Environment, log)
+	defer done()
+
 	var deployResponse I.DeployResponse
 
 	if putRequest.State == "stopped" {
-		deployResponse = c.StopControllerFactory(log).StopDeployment(&deployment, putRequest.Data, response)
+		if !putRequest.Mode.Valid() {
+			response.Write([]byte("Unknown requested stop mode: " + string(putRequest.Mode)))
+			g.Writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		deployment.StopMode = putRequest.Mode
+
+		deployResponse = c.StopControllerFactory(log).StopDeployment(ctx, &deployment, putRequest.Data, response)
 	} else if putRequest.State == "started" {
-		deployResponse = c.StartControllerFactory(log).StartDeployment(&deployment, putRequest.Data, response)
+		deployResponse = c.StartControllerFactory(log).StartDeployment(ctx, &deployment, putRequest.Data, response)
+	} else if putRequest.State == "restarted" {
+		if putRequest.BatchSize < 0 {
+			response.Write([]byte("Invalid batch size: " + strconv.Itoa(putRequest.BatchSize)))
+			g.Writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		deployment.BatchSize = putRequest.BatchSize
+
+		deployResponse = c.RestartControllerFactory(log).RestartDeployment(ctx, &deployment, putRequest.Data, response)
+	} else if putRequest.State == "restaged" {
+		deployResponse = c.RestageControllerFactory(log).RestageDeployment(ctx, &deployment, putRequest.Data, response)
 	} else {
 		response.Write([]byte("Unknown requested state: " + putRequest.State))
 		deployResponse = I.DeployResponse{
@@ -142,3 +292,124 @@ func (c *Controller) PutRequestHandler(g *gin.Context) {
 
 	g.Writer.WriteHeader(deployResponse.StatusCode)
 }
+
+// DeleteRequestHandler deletes the application from every foundation of the environment. The routes it leaves
+// without an application are deleted too when the delete_routes query parameter is true.
+func (c *Controller) DeleteRequestHandler(g *gin.Context) {
+	environment := environmentParam(g)
+	if !c.authorizeEnvironment(g, environment) || !c.authenticateUser(g, environment) {
+		return
+	}
+
+	uuid := c.Config.DeploymentID.Generate(environment, g.Request.Header.Get(deploymentid.IdempotencyKeyHeader))
+	log := I.DeploymentLogger{Log: c.Log, UUID: uuid}
+	log.Debugf("DELETE Request originated from: %+v", g.Request.RemoteAddr)
+
+	cfContext := I.CFContext{
+		Environment:  environment,
+		Organization: g.Param("org"),
+		Space:        g.Param("space"),
+		Application:  g.Param("appName"),
+	}
+
+	response := c.newResponse()
+	defer response.Close()
+	defer c.streamLogs(log, response)()
+	defer io.Copy(g.Writer, response)
+
+	deployment := I.Deployment{
+		Authorization: requestAuthorization(g),
+		CFContext:     cfContext,
+	}
+
+	if value := g.Query(DeleteRoutesParameter); value != "" {
+		deleteRoutes, err := strconv.ParseBool(value)
+		if err != nil {
+			response.Write([]byte("Invalid " + DeleteRoutesParameter + ": " + value))
+			g.Writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		deployment.DeleteRoutes = deleteRoutes
+	}
+
+	bodyBuffer, ok := c.readBody(g)
+	if !ok {
+		return
+	}
+
+	deleteRequest := &DeleteRequest{}
+	if len(bytes.TrimSpace(bodyBuffer)) > 0 {
+		err := json.Unmarshal(bodyBuffer, deleteRequest)
+		if err != nil {
+			response.Write([]byte("Invalid request body."))
+			g.Writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	deployment.Metadata = S.MergeMetadata(metadataFromHeaders(g.Request.Header), deleteRequest.Metadata)
+
+	unlock, err := c.lockApp(log, cfContext)
+	if err != nil {
+		response.Write([]byte("cannot delete the application: " + err.Error()))
+		g.Writer.WriteHeader(lockErrorStatus(err))
+		return
+	}
+	defer unlock()
+	defer c.saveDeploymentLog(log, response)
+	g.Header(DeploymentIDHeader, log.UUID)
+
+	ctx, done := c.deploymentContext(g, cfContext.Environment, log)
+	defer done()
+
+	deployResponse := c.DeleteControllerFactory(log).DeleteDeployment(ctx, &deployment, deleteRequest.Data, response)
+
+	g.Writer.WriteHeader(deployResponse.StatusCode)
+}
+
+// deploymentContext returns the context of the deployment, which is cancelled when the deployment is cancelled.
+// It is the context of the request when the environment aborts deployments on client disconnect. Otherwise the
+// deployment keeps running after the client goes away. done must be called once the deployment is over.
+//
+// The deployment is traced with the Tracer, in the trace of the Traceparent header of the request when it has one.
+func (c *Controller) deploymentContext(g *gin.Context, environmentName string, log I.DeploymentLogger) (context.Context, func()) {
+	ctx := context.Background()
+	if environment, ok := c.Config.Environments[environmentName]; ok && environment.AbortOnDisconnect {
+		log.Debug("deployment will be cancelled if the client disconnects")
+		ctx = g.Request.Context()
+	}
+
+	ctx = tracing.WithTracer(ctx, c.Tracer, tracing.String("deployment.uuid", log.UUID), tracing.String("deployment.environment", environmentName))
+	ctx = tracing.WithRemoteParent(ctx, g.Request.Header.Get(TraceparentHeader))
+	ctx, span := tracing.Start(ctx, g.Request.Method+" "+g.Request.URL.Path, tracing.String("http.method", g.Request.Method))
+	finish := func() {
+		status := g.Writer.Status()
+		span.SetAttributes(tracing.Int("http.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetError(fmt.Errorf("%d %s", status, http.StatusText(status)))
+		}
+		span.Finish()
+	}
+
+	if c.Cancellations == nil {
+		return ctx, finish
+	}
+	ctx, done := c.Cancellations.Context(ctx, log.UUID)
+	return ctx, func() {
+		done()
+		finish()
+	}
+}
+
+func metadataFromHeaders(header http.Header) map[string]string {
+	metadata := map[string]string{}
+	for key, values := range header {
+		if len(values) == 0 || !strings.HasPrefix(key, MetadataHeaderPrefix) {
+			continue
+		}
+
+		name := strings.ToLower(strings.TrimPrefix(key, MetadataHeaderPrefix))
+		metadata[strings.Replace(name, "-", "_", -1)] = values[0]
+	}
+	return metadata
+}
@@ -2,10 +2,13 @@ package controller_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"time"
 
 	"io/ioutil"
 
@@ -13,9 +16,12 @@ import (
 
 	"github.com/compozed/deployadactyl/config"
 	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/deploymentid"
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/mocks"
 	"github.com/compozed/deployadactyl/randomizer"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tracing"
 	"github.com/gin-gonic/gin"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -32,6 +38,9 @@ var _ = Describe("Controller", func() {
 		errorFinder     *mocks.ErrorFinder
 		stopController  *mocks.StopController
 		startController *mocks.StartController
+		restartController *mocks.RestartController
+		restageController *mocks.RestageController
+		deleteController  *mocks.DeleteController
 		pushController  *mocks.PushController
 
 		controller      *Controller
@@ -60,6 +69,9 @@ var _ = Describe("Controller", func() {
 		pushController = &mocks.PushController{}
 		stopController = &mocks.StopController{}
 		startController = &mocks.StartController{}
+		restartController = &mocks.RestartController{}
+		restageController = &mocks.RestageController{}
+		deleteController = &mocks.DeleteController{}
 
 		errorFinder = &mocks.ErrorFinder{}
 		controller = &Controller{
@@ -70,6 +82,15 @@ var _ = Describe("Controller", func() {
 			StartControllerFactory: func(log I.DeploymentLogger) I.StartController {
 				return startController
 			},
+			RestartControllerFactory: func(log I.DeploymentLogger) I.RestartController {
+				return restartController
+			},
+			RestageControllerFactory: func(log I.DeploymentLogger) I.RestageController {
+				return restageController
+			},
+			DeleteControllerFactory: func(log I.DeploymentLogger) I.DeleteController {
+				return deleteController
+			},
 			PushControllerFactory: func(log I.DeploymentLogger) I.PushController {
 				return pushController
 			},
@@ -124,7 +145,7 @@ var _ = Describe("Controller", func() {
 				Eventually(resp.Code).Should(Equal(http.StatusOK))
 				Eventually(resp.Body).Should(ContainSubstring("deploy success"))
 
-				Eventually(pushController.RunDeploymentCall.Received.Deployment.CFContext.Environment).Should(Equal(environment))
+				Eventually(pushController.RunDeploymentCall.Received.Deployment.CFContext.Environment).Should(Equal(strings.ToLower(environment)))
 				Eventually(pushController.RunDeploymentCall.Received.Deployment.CFContext.Organization).Should(Equal(org))
 				Eventually(pushController.RunDeploymentCall.Received.Deployment.CFContext.Space).Should(Equal(space))
 				Eventually(pushController.RunDeploymentCall.Received.Deployment.CFContext.Application).Should(Equal(appName))
@@ -173,6 +194,197 @@ var _ = Describe("Controller", func() {
 			})
 		})
 
+		Context("when metadata headers are provided", func() {
+			It("adds them to the deployment metadata", func() {
+				foundationURL = fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				req.Header.Set("Content-Type", "application/zip")
+				req.Header.Set("X-Deployadactyl-Metadata-Pipeline-Id", "pipeline-1234")
+				req.Header.Set("X-Deployadactyl-Metadata-Commit", "abc123")
+				req.Header.Set("X-Other-Header", "ignored")
+
+				Expect(err).ToNot(HaveOccurred())
+
+				pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{
+					StatusCode: http.StatusOK,
+				}
+
+				router.ServeHTTP(resp, req)
+
+				Expect(pushController.RunDeploymentCall.Received.Deployment.Metadata).To(Equal(map[string]string{
+					"pipeline_id": "pipeline-1234",
+					"commit":      "abc123",
+				}))
+			})
+		})
+
+		Context("when a manifest name header is provided", func() {
+			It("selects the manifest of the deployment", func() {
+				foundationURL = fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Content-Type", "application/zip")
+				req.Header.Set(ManifestNameHeader, "canary")
+
+				pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{
+					StatusCode: http.StatusOK,
+				}
+
+				router.ServeHTTP(resp, req)
+
+				Expect(pushController.RunDeploymentCall.Received.Deployment.ManifestName).To(Equal("canary"))
+			})
+		})
+
+		Context("when an idempotency key is provided", func() {
+			var uuids []string
+
+			BeforeEach(func() {
+				uuids = nil
+				controller.PushControllerFactory = func(log I.DeploymentLogger) I.PushController {
+					uuids = append(uuids, log.UUID)
+					return pushController
+				}
+				pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{
+					StatusCode: http.StatusOK,
+				}
+			})
+
+			deploy := func() {
+				foundationURL = fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Content-Type", "application/zip")
+				req.Header.Set(deploymentid.IdempotencyKeyHeader, "build-42")
+
+				router.ServeHTTP(httptest.NewRecorder(), req)
+			}
+
+			It("derives the deployment id from the key when it is enabled", func() {
+				controller.Config.DeploymentID = deploymentid.Format{IdempotencyKey: true}
+
+				deploy()
+				deploy()
+
+				Expect(uuids).To(HaveLen(2))
+				Expect(uuids[0]).To(Equal(controller.Config.DeploymentID.Generate(strings.ToLower(environment), "build-42")))
+				Expect(uuids[1]).To(Equal(uuids[0]))
+			})
+
+			It("generates a random deployment id by default", func() {
+				deploy()
+				deploy()
+
+				Expect(uuids).To(HaveLen(2))
+				Expect(uuids[1]).ToNot(Equal(uuids[0]))
+			})
+
+			It("returns the deployment id in a header", func() {
+				req, err := http.NewRequest("POST", fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName), jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Content-Type", "application/zip")
+
+				router.ServeHTTP(resp, req)
+
+				Expect(uuids).To(HaveLen(1))
+				Expect(resp.Header().Get(DeploymentIDHeader)).To(Equal(uuids[0]))
+			})
+		})
+
+		Context("when the client disconnects", func() {
+			var ctx context.Context
+
+			BeforeEach(func() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(context.Background())
+				cancel()
+
+				pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{
+					StatusCode: http.StatusOK,
+				}
+			})
+
+			It("cancels the deployment when the environment aborts on disconnect", func() {
+				controller.Config.Environments = map[string]S.Environment{strings.ToLower(environment): {Name: environment, AbortOnDisconnect: true}}
+				foundationURL = fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Content-Type", "application/zip")
+
+				router.ServeHTTP(resp, req.WithContext(ctx))
+
+				Expect(pushController.RunDeploymentCall.Received.Context.Err()).To(Equal(context.Canceled))
+			})
+
+			It("cancels the deployment when the environment of the request is not lowercase", func() {
+				controller.Config.Environments = map[string]S.Environment{strings.ToLower(environment): {Name: environment, AbortOnDisconnect: true}}
+				foundationURL = fmt.Sprintf("/v3/apps/%s/%s/%s/%s", strings.ToUpper(environment), org, space, appName)
+
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Content-Type", "application/zip")
+
+				router.ServeHTTP(resp, req.WithContext(ctx))
+
+				Expect(pushController.RunDeploymentCall.Received.Context.Err()).To(Equal(context.Canceled))
+			})
+
+			It("keeps the deployment running by default", func() {
+				foundationURL = fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Content-Type", "application/zip")
+
+				router.ServeHTTP(resp, req.WithContext(ctx))
+
+				Expect(pushController.RunDeploymentCall.Received.Context.Err()).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when the deployment is traced", func() {
+			var spans chan []*tracing.Span
+
+			BeforeEach(func() {
+				spans = make(chan []*tracing.Span, 1)
+				controller.Tracer = tracing.NewTracer(spanExporter(spans), I.DefaultLogger(NewBuffer(), logging.DEBUG, "controller_test"))
+				controller.Tracer.Interval = 10 * time.Millisecond
+				go controller.Tracer.Run()
+
+				pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{
+					StatusCode: http.StatusOK,
+				}
+			})
+
+			It("traces the request in the trace of its traceparent", func() {
+				foundationURL = fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Content-Type", "application/zip")
+				req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+				router.ServeHTTP(resp, req)
+
+				var exported []*tracing.Span
+				Eventually(spans).Should(Receive(&exported))
+				Expect(exported).To(HaveLen(1))
+				Expect(exported[0].Name).To(Equal("POST " + foundationURL))
+				Expect(exported[0].TraceID).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+				Expect(exported[0].ParentSpanID).To(Equal("00f067aa0ba902b7"))
+				Expect(exported[0].Attributes).To(ContainElement(tracing.String("deployment.uuid", resp.Header().Get(DeploymentIDHeader))))
+				Expect(exported[0].Attributes).To(ContainElement(tracing.String("deployment.environment", strings.ToLower(environment))))
+				Expect(exported[0].Attributes).To(ContainElement(tracing.Int("http.status_code", http.StatusOK)))
+
+				_, child := tracing.Start(pushController.RunDeploymentCall.Received.Context, "deployer")
+				Expect(child.ParentSpanID).To(Equal(exported[0].SpanID))
+			})
+		})
+
 		Context("when parameters are added to the url", func() {
 			It("does not return an error", func() {
 				foundationURL = fmt.Sprintf("/v3/apps/%s/%s/%s/%s?broken=false", environment, org, space, appName)
@@ -231,9 +443,30 @@ var _ = Describe("Controller", func() {
 					router.ServeHTTP(resp, req)
 
 					Eventually(resp.Code).Should(Equal(http.StatusOK))
+					Expect(resp.Header().Get(DeploymentIDHeader)).ToNot(BeEmpty())
 				})
 			})
 
+			It("merges metadata from the body over metadata headers", func() {
+				foundationURL := fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+				jsonBuffer = bytes.NewBufferString(`{"state": "stopped", "metadata": {"ticket": "CHG-1", "commit": "def456"}}`)
+
+				req, err := http.NewRequest("PUT", foundationURL, jsonBuffer)
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-Deployadactyl-Metadata-Commit", "abc123")
+				req.Header.Set("X-Deployadactyl-Metadata-Pipeline-Id", "pipeline-1234")
+
+				Expect(err).ToNot(HaveOccurred())
+
+				router.ServeHTTP(resp, req)
+
+				Expect(stopController.StopDeploymentCall.Received.Deployment.Metadata).To(Equal(map[string]string{
+					"pipeline_id": "pipeline-1234",
+					"commit":      "def456",
+					"ticket":      "CHG-1",
+				}))
+			})
+
 			It("logs request origination address", func() {
 				foundationURL := fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
 				jsonBuffer = bytes.NewBufferString(`{"state": "stopped"}`)
@@ -272,7 +505,7 @@ var _ = Describe("Controller", func() {
 				router.ServeHTTP(resp, req)
 
 				cfContext := stopController.StopDeploymentCall.Received.Deployment.CFContext
-				Expect(cfContext.Environment).To(Equal(environment))
+				Expect(cfContext.Environment).To(Equal(strings.ToLower(environment)))
 				Expect(cfContext.Space).To(Equal(space))
 				Expect(cfContext.Organization).To(Equal(org))
 				Expect(cfContext.Application).To(Equal(appName))
@@ -326,6 +559,36 @@ var _ = Describe("Controller", func() {
 				Expect(stopController.StopDeploymentCall.Received.Data["group"]).To(Equal("XP_IS_CHG"))
 			})
 
+			It("passes the requested stop mode", func() {
+				foundationURL := fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+				jsonBuffer = bytes.NewBufferString(`{"state": "stopped", "mode": "scale_to_zero"}`)
+
+				req, err := http.NewRequest("PUT", foundationURL, jsonBuffer)
+				req.Header.Set("Content-Type", "application/json")
+
+				Expect(err).ToNot(HaveOccurred())
+
+				router.ServeHTTP(resp, req)
+
+				Expect(stopController.StopDeploymentCall.Received.Deployment.StopMode).To(Equal(S.StopModeScaleToZero))
+			})
+
+			It("returns http status.BadRequest for an unknown stop mode", func() {
+				foundationURL := fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+				jsonBuffer = bytes.NewBufferString(`{"state": "stopped", "mode": "hibernate"}`)
+
+				req, err := http.NewRequest("PUT", foundationURL, jsonBuffer)
+				req.Header.Set("Content-Type", "application/json")
+
+				Expect(err).ToNot(HaveOccurred())
+
+				router.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+				Expect(stopController.StopDeploymentCall.Received.Deployment).To(BeNil())
+				Expect(resp.Body.String()).To(ContainSubstring("Unknown requested stop mode: hibernate"))
+			})
+
 			Context("if requested state is not 'stop'", func() {
 				It("does not call StopDeployment", func() {
 					foundationURL := fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
@@ -370,7 +633,7 @@ var _ = Describe("Controller", func() {
 				router.ServeHTTP(resp, req)
 
 				cfContext := startController.StartDeploymentCall.Received.Deployment.CFContext
-				Expect(cfContext.Environment).To(Equal(environment))
+				Expect(cfContext.Environment).To(Equal(strings.ToLower(environment)))
 				Expect(cfContext.Space).To(Equal(space))
 				Expect(cfContext.Organization).To(Equal(org))
 				Expect(cfContext.Application).To(Equal(appName))
@@ -410,6 +673,63 @@ var _ = Describe("Controller", func() {
 			})
 		})
 
+		Context("when state is set to restarted", func() {
+			It("calls RestartDeployment with the batch size", func() {
+				foundationURL := fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+				jsonBuffer = bytes.NewBufferString(`{"state": "restarted", "batch_size": 2}`)
+
+				req, err := http.NewRequest("PUT", foundationURL, jsonBuffer)
+				req.Header.Set("Content-Type", "application/json")
+
+				Expect(err).ToNot(HaveOccurred())
+
+				router.ServeHTTP(resp, req)
+
+				Expect(restartController.RestartDeploymentCall.Called).To(Equal(true))
+				Expect(restartController.RestartDeploymentCall.Received.Deployment.BatchSize).To(Equal(2))
+				Expect(restartController.RestartDeploymentCall.Received.Deployment.CFContext.Application).To(Equal(appName))
+				Expect(startController.StartDeploymentCall.Called).To(Equal(false))
+			})
+
+			It("returns a Bad Request error for a negative batch size", func() {
+				foundationURL := fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+				jsonBuffer = bytes.NewBufferString(`{"state": "restarted", "batch_size": -1}`)
+
+				req, err := http.NewRequest("PUT", foundationURL, jsonBuffer)
+				req.Header.Set("Content-Type", "application/json")
+
+				Expect(err).ToNot(HaveOccurred())
+
+				router.ServeHTTP(resp, req)
+
+				Expect(restartController.RestartDeploymentCall.Called).To(Equal(false))
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("when state is set to restaged", func() {
+			It("calls RestageDeployment", func() {
+				foundationURL := fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+				jsonBuffer = bytes.NewBufferString(`{"state": "restaged"}`)
+
+				req, err := http.NewRequest("PUT", foundationURL, jsonBuffer)
+				req.Header.Set("Content-Type", "application/json")
+
+				Expect(err).ToNot(HaveOccurred())
+
+				restageController.RestageDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+				restageController.RestageDeploymentCall.Writes = "restaged app"
+
+				router.ServeHTTP(resp, req)
+
+				Expect(restageController.RestageDeploymentCall.Called).To(Equal(true))
+				Expect(restageController.RestageDeploymentCall.Received.Deployment.CFContext.Application).To(Equal(appName))
+				Expect(restartController.RestartDeploymentCall.Called).To(Equal(false))
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(ContainSubstring("restaged app"))
+			})
+		})
+
 		Context("when requested state is unknown", func() {
 			It("returns a Bad Request error", func() {
 				foundationURL := fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
@@ -451,4 +771,86 @@ var _ = Describe("Controller", func() {
 		})
 	})
 
+	Describe("DeleteRequestHandler", func() {
+		var (
+			router        *gin.Engine
+			resp          *httptest.ResponseRecorder
+			foundationURL string
+		)
+
+		BeforeEach(func() {
+			router = gin.New()
+			resp = httptest.NewRecorder()
+			foundationURL = fmt.Sprintf("/v3/apps/%s/%s/%s/%s", environment, org, space, appName)
+
+			router.DELETE("/v3/apps/:environment/:org/:space/:appName", controller.DeleteRequestHandler)
+		})
+
+		It("calls DeleteDeployment with the CFContext and authorization", func() {
+			req, err := http.NewRequest("DELETE", foundationURL, &bytes.Buffer{})
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth("myUser", "myPassword")
+
+			deleteController.DeleteDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+			deleteController.DeleteDeploymentCall.Writes = "deleted app"
+
+			router.ServeHTTP(resp, req)
+
+			deployment := deleteController.DeleteDeploymentCall.Received.Deployment
+			Expect(deployment.CFContext).To(Equal(I.CFContext{Environment: strings.ToLower(environment), Organization: org, Space: space, Application: appName}))
+			Expect(deployment.Authorization).To(Equal(I.Authorization{Username: "myUser", Password: "myPassword"}))
+			Expect(deployment.DeleteRoutes).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Body.String()).To(ContainSubstring("deleted app"))
+		})
+
+		It("deletes the routes when delete_routes is true", func() {
+			req, err := http.NewRequest("DELETE", foundationURL+"?delete_routes=true", &bytes.Buffer{})
+			Expect(err).ToNot(HaveOccurred())
+
+			router.ServeHTTP(resp, req)
+
+			Expect(deleteController.DeleteDeploymentCall.Received.Deployment.DeleteRoutes).To(BeTrue())
+		})
+
+		It("passes the data and metadata of the body", func() {
+			req, err := http.NewRequest("DELETE", foundationURL, bytes.NewBufferString(`{"data": {"ticket": "CHG-1"}, "metadata": {"pipeline_id": "1234"}}`))
+			Expect(err).ToNot(HaveOccurred())
+
+			router.ServeHTTP(resp, req)
+
+			Expect(deleteController.DeleteDeploymentCall.Received.Data).To(Equal(S.Params{"ticket": "CHG-1"}))
+			Expect(deleteController.DeleteDeploymentCall.Received.Deployment.Metadata).To(Equal(map[string]string{"pipeline_id": "1234"}))
+		})
+
+		It("returns a Bad Request error for an invalid delete_routes", func() {
+			req, err := http.NewRequest("DELETE", foundationURL+"?delete_routes=maybe", &bytes.Buffer{})
+			Expect(err).ToNot(HaveOccurred())
+
+			router.ServeHTTP(resp, req)
+
+			Expect(deleteController.DeleteDeploymentCall.Called).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(resp.Body.String()).To(Equal("Invalid delete_routes: maybe"))
+		})
+
+		It("returns a Bad Request error for a bad request body", func() {
+			req, err := http.NewRequest("DELETE", foundationURL, bytes.NewBufferString(`{`))
+			Expect(err).ToNot(HaveOccurred())
+
+			router.ServeHTTP(resp, req)
+
+			Expect(deleteController.DeleteDeploymentCall.Called).To(BeFalse())
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
 })
+
+// spanExporter sends the spans it exports to its channel.
+type spanExporter chan []*tracing.Span
+
+func (e spanExporter) Export(spans []*tracing.Span) error {
+	e <- spans
+	return nil
+}
@@ -0,0 +1,102 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Debug endpoints", func() {
+	var (
+		controller *Controller
+		router     *gin.Engine
+		resp       *httptest.ResponseRecorder
+	)
+
+	request := func(path, username, password string) {
+		req, err := http.NewRequest("GET", path, nil)
+		Expect(err).ToNot(HaveOccurred())
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		router.ServeHTTP(resp, req)
+	}
+
+	BeforeEach(func() {
+		controller = &Controller{
+			Log:    I.DefaultLogger(NewBuffer(), logging.DEBUG, "debug_test"),
+			Config: config.Config{Username: "username", Password: "password", DebugEndpoints: true},
+		}
+
+		router = gin.New()
+		router.GET("/v3/admin/debug/pprof/*profile", controller.ProfileHandler)
+		router.GET("/v3/admin/debug/goroutines", controller.GoroutinesHandler)
+		router.GET("/v3/admin/debug/runtime", controller.RuntimeStatsHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	It("returns the index of the profiles", func() {
+		request("/v3/admin/debug/pprof/", "username", "password")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(ContainSubstring("heap"))
+	})
+
+	It("returns a named profile", func() {
+		request("/v3/admin/debug/pprof/goroutine?debug=1", "username", "password")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(ContainSubstring("goroutine profile:"))
+	})
+
+	It("returns the stack of every goroutine", func() {
+		request("/v3/admin/debug/goroutines", "username", "password")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("text/plain; charset=utf-8"))
+		Expect(resp.Body.String()).To(ContainSubstring("goroutine "))
+		Expect(resp.Body.String()).To(ContainSubstring("GoroutinesHandler"))
+	})
+
+	It("returns the runtime stats", func() {
+		request("/v3/admin/debug/runtime", "username", "password")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var stats S.RuntimeStats
+		Expect(json.Unmarshal(resp.Body.Bytes(), &stats)).To(Succeed())
+		Expect(stats.GoVersion).To(Equal(runtime.Version()))
+		Expect(stats.Goroutines).To(BeNumerically(">", 0))
+		Expect(stats.HeapAllocBytes).To(BeNumerically(">", 0))
+		Expect(stats.SysBytes).To(BeNumerically(">", 0))
+	})
+
+	It("requires the credentials of the server", func() {
+		for _, path := range []string{"/v3/admin/debug/pprof/heap", "/v3/admin/debug/goroutines", "/v3/admin/debug/runtime"} {
+			resp = httptest.NewRecorder()
+			request(path, "username", "wrong")
+
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized), path)
+		}
+	})
+
+	It("returns a 404 when the debug endpoints are not enabled", func() {
+		controller.Config.DebugEndpoints = false
+
+		request("/v3/admin/debug/runtime", "username", "password")
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+		Expect(resp.Body.String()).To(Equal("debug endpoints are not enabled"))
+	})
+})
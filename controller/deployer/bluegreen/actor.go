@@ -1,6 +1,10 @@
 package bluegreen
 
-import I "github.com/compozed/deployadactyl/interfaces"
+import (
+	"runtime/debug"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
 
 func NewActor(action I.Action) actor {
 	commands := make(chan ActorCommand)
@@ -8,7 +12,7 @@ func NewActor(action I.Action) actor {
 
 	go func() {
 		for command := range commands {
-			errs <- command(action)
+			errs <- run(command, action)
 		}
 		close(errs)
 	}()
@@ -22,6 +26,21 @@ func NewActor(action I.Action) actor {
 type actor struct {
 	Commands chan<- ActorCommand
 	Errs     <-chan error
+
+	// FoundationURL is the foundation the action is run against.
+	FoundationURL string
 }
 
 type ActorCommand func(action I.Action) error
+
+// run returns a panic of the command as an error, so the deployment is still rolled back and
+// cleaned up instead of the panic taking down the server.
+func run(command ActorCommand, action I.Action) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = ActionPanicError{Value: r, Stack: string(debug.Stack())}
+		}
+	}()
+
+	return command(action)
+}
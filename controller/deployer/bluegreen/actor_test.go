@@ -1,6 +1,7 @@
 package bluegreen_test
 
 import (
+	"context"
 	"errors"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
 	"github.com/compozed/deployadactyl/interfaces"
@@ -17,7 +18,7 @@ var _ = Describe("Actor", func() {
 			action.ExecuteCall.Returns.Error = errors.New("error")
 			a := bluegreen.NewActor(action)
 			a.Commands <- func(action interfaces.Action) error {
-				return action.Execute()
+				return action.Execute(context.Background())
 			}
 			Expect((<-a.Errs).Error()).To(Equal("error"))
 		})
@@ -25,7 +26,23 @@ var _ = Describe("Actor", func() {
 			action := &mocks.Action{}
 			a := bluegreen.NewActor(action)
 			a.Commands <- func(action interfaces.Action) error {
-				return action.Execute()
+				return action.Execute(context.Background())
+			}
+			Expect(<-a.Errs).ToNot(HaveOccurred())
+		})
+		It("returns an error when the command panics", func() {
+			action := &mocks.Action{}
+			a := bluegreen.NewActor(action)
+			a.Commands <- func(action interfaces.Action) error {
+				panic("nil map")
+			}
+
+			err := <-a.Errs
+			Expect(err).To(BeAssignableToTypeOf(bluegreen.ActionPanicError{}))
+			Expect(err.Error()).To(Equal("action panicked: nil map"))
+
+			a.Commands <- func(action interfaces.Action) error {
+				return action.Execute(context.Background())
 			}
 			Expect(<-a.Errs).ToNot(HaveOccurred())
 		})
@@ -3,36 +3,95 @@ package bluegreen
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	I "github.com/compozed/deployadactyl/interfaces"
 	S "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tracing"
 )
 
 // BlueGreen has a PushManager to creater pushers for blue green deployments.
 type BlueGreen struct {
 	Log I.DeploymentLogger
+
+	// Progress receives the phase every foundation is in.
+	Progress I.ProgressReporter
+
+	// maxConcurrentFoundations is the MaxConcurrentFoundations of the environment of the deployment.
+	maxConcurrentFoundations int
+}
+
+// progressPhases are the phases of the progress of a deployment, keyed by the phase of its actions.
+var progressPhases = map[string]string{
+	"initially": S.PhaseLoggingIn,
+	"execute":   S.PhaseExecuting,
+	"verify":    S.PhaseVerifying,
+	"promote":   S.PhasePromoting,
+	"undo":      S.PhaseRollingBack,
+	"success":   S.PhaseSucceeding,
+}
+
+// undoFinisher is implemented by action creators whose actions finish instead of rolling back when the
+// environment does not roll back, so that the foundations they were undone on succeeded.
+type undoFinisher interface {
+	UndoFinishes(environment S.Environment) bool
+}
+
+// promoter is implemented by the actions of a canary, which are promoted to every instance once the canary baked.
+type promoter interface {
+	Promote(ctx context.Context) error
 }
 
 // Push will login to all the Cloud Foundry instances provided in the Config and then push the application to all the instances concurrently.
 // If the application fails to start in any of the instances it handles rolling back the application in every instance, unless it is the first deploy.
-func (bg BlueGreen) Execute(actionCreator I.ActionCreator, environment S.Environment, response io.ReadWriter) error {
+//
+// Every phase is finished on all the instances before the next one starts. The actions are verified once they are
+// executed everywhere, and only succeed once they are verified everywhere. A failed verification is rolled back
+// like a failed execution.
+//
+// Cancelling ctx stops the running actions. Undo and Finally are still run, with a context that is never cancelled.
+// Success is run with that context too: once the actions start to succeed the original applications are being
+// replaced, and stopping half way would leave them unmapped or deleted without the new builds taking their place.
+//
+// When the environment has MinSuccessfulFoundations and at least that many foundations were executed and verified,
+// the actions only fail on the other foundations: they are undone there and succeed everywhere else.
+//
+// The outcome on every foundation is returned with the error, in the order of the foundations of the environment,
+// so a deployment that succeeded on some foundations only can report which.
+func (bg BlueGreen) Execute(ctx context.Context, actionCreator I.ActionCreator, environment S.Environment, response io.ReadWriter) ([]S.FoundationResult, error) {
+	return bg.execute(ctx, actionCreator, environment, response, nil)
+}
+
+// execute runs the actions like Execute. When there is a bake function, it is run once the actions are verified,
+// and the actions are promoted once it returns. An error of bake fails every verified action.
+func (bg BlueGreen) execute(ctx context.Context, actionCreator I.ActionCreator, environment S.Environment, response io.ReadWriter, bake func(ctx context.Context) error) ([]S.FoundationResult, error) {
+	cleanUpCtx := detach(ctx)
+	bg.maxConcurrentFoundations = environment.MaxConcurrentFoundations
+
+	if limit := environment.MaxConcurrentFoundations; limit > 0 && limit < len(environment.Foundations) {
+		fmt.Fprintf(response, "deploying to at most %d of %d foundations at once\n", limit, len(environment.Foundations))
+	}
 
 	actors := make([]actor, len(environment.Foundations))
 	buffers := make([]*bytes.Buffer, len(environment.Foundations))
+	results := make([]S.FoundationResult, len(environment.Foundations))
 
 	for i, foundationURL := range environment.Foundations {
 		buffers[i] = &bytes.Buffer{}
+		results[i] = S.FoundationResult{FoundationURL: foundationURL, Status: S.FoundationAborted}
 
 		action, err := actionCreator.Create(environment, buffers[i], foundationURL)
 		if err != nil {
-			return InitializationError{err}
+			return results, InitializationError{err}
 		}
-		defer action.Finally()
+		defer action.Finally(cleanUpCtx)
 
 		actors[i] = NewActor(action)
+		actors[i].FoundationURL = foundationURL
 		defer close(actors[i].Commands)
 	}
 
@@ -45,49 +104,259 @@ func (bg BlueGreen) Execute(actionCreator I.ActionCreator, environment S.Environ
 		fmt.Fprintf(response, "\n%s End Cloud Foundry Output %s\n", strings.Repeat("-", 17), strings.Repeat("-", 17))
 	}()
 
-	loginErrors := bg.commands(actors, func(action I.Action) error {
-		return action.Initially()
+	loginErrors := bg.commands(ctx, actors, "initially", func(ctx context.Context, action I.Action) error {
+		return action.Initially(ctx)
 	})
 
-	if len(loginErrors) != 0 {
-		return actionCreator.InitiallyError(loginErrors)
+	if failed(loginErrors) {
+		fail(results, loginErrors)
+		return results, actionCreator.InitiallyError(compact(loginErrors))
 	}
 
-	actionErrors := bg.commands(actors, func(action I.Action) error {
-		return action.Execute()
+	actionErrors := bg.commands(ctx, actors, "execute", func(ctx context.Context, action I.Action) error {
+		return action.Execute(ctx)
 	})
 
-	if len(actionErrors) != 0 {
-		bg.Log.Errorf("failed to execute action against all foundations - rolling back action")
-		rollbackErrors := bg.commands(actors, func(action I.Action) error {
-			return action.Undo()
+	if ctx.Err() == nil && (!failed(actionErrors) || meetsThreshold(environment, actionErrors)) {
+		verifyErrors := bg.commandsOn(ctx, actors, succeededActors(actionErrors), "verify", func(ctx context.Context, action I.Action) error {
+			return action.Verify(ctx)
+		})
+		for i, err := range verifyErrors {
+			if err != nil {
+				actionErrors[i] = err
+			}
+		}
+	}
+
+	if bake != nil && ctx.Err() == nil && (!failed(actionErrors) || meetsThreshold(environment, actionErrors)) {
+		bg.promote(ctx, actors, actionErrors, bake)
+	}
+
+	manyErrors := compact(actionErrors)
+	if len(manyErrors) == 0 && ctx.Err() != nil {
+		manyErrors = []error{CancelledError{ctx.Err()}}
+	}
+
+	if len(manyErrors) != 0 && ctx.Err() == nil && meetsThreshold(environment, actionErrors) {
+		return bg.acceptThreshold(cleanUpCtx, actors, actionCreator, environment, actionErrors, results, response)
+	}
+
+	if len(manyErrors) != 0 {
+		log := bg.Log.WithFields(I.LogFields{I.PhaseLogField: "execute"})
+		if ctx.Err() != nil {
+			log.Errorf("deployment cancelled: %s", ctx.Err())
+		}
+		log.Errorf("failed to execute action against all foundations - rolling back action")
+		rollbackErrors := bg.commands(cleanUpCtx, actors, "undo", func(ctx context.Context, action I.Action) error {
+			return action.Undo(ctx)
 		})
 
-		if len(rollbackErrors) != 0 {
-			return actionCreator.UndoError(actionErrors, rollbackErrors)
+		undone := S.FoundationRolledBack
+		if finisher, ok := actionCreator.(undoFinisher); ok && finisher.UndoFinishes(environment) {
+			undone = S.FoundationSucceeded
+		}
+		for i := range results {
+			results[i].Status = undone
+		}
+		fail(results, rollbackErrors)
+		fail(results, actionErrors)
+
+		if failed(rollbackErrors) {
+			return results, actionCreator.UndoError(manyErrors, compact(rollbackErrors))
 		}
 
-		return actionCreator.ExecuteError(actionErrors)
+		return results, actionCreator.ExecuteError(manyErrors)
 	}
 
-	finishActionErrors := bg.commands(actors, func(action I.Action) error {
-		return action.Success()
+	finishActionErrors := bg.commands(cleanUpCtx, actors, "success", func(ctx context.Context, action I.Action) error {
+		return action.Success(ctx)
 	})
-	if len(finishActionErrors) != 0 {
-		return actionCreator.SuccessError(finishActionErrors)
+
+	for i := range results {
+		results[i].Status = S.FoundationSucceeded
 	}
+	fail(results, finishActionErrors)
 
-	return nil
+	if failed(finishActionErrors) {
+		return results, actionCreator.SuccessError(compact(finishActionErrors))
+	}
+
+	return results, nil
+}
+
+// promote runs bake, then promotes the actions that did not fail. The errors of the actions that fail are set in
+// actionErrors.
+func (bg BlueGreen) promote(ctx context.Context, actors []actor, actionErrors []error, bake func(ctx context.Context) error) {
+	succeeded := succeededActors(actionErrors)
+
+	err := bake(ctx)
+	if err != nil {
+		for _, i := range succeeded {
+			actionErrors[i] = err
+		}
+		return
+	}
+
+	promoteErrors := bg.commandsOn(ctx, actors, succeeded, "promote", func(ctx context.Context, action I.Action) error {
+		if p, ok := action.(promoter); ok {
+			return p.Promote(ctx)
+		}
+		return nil
+	})
+	for i, err := range promoteErrors {
+		if err != nil {
+			actionErrors[i] = err
+		}
+	}
 }
 
-func (bg BlueGreen) commands(actors []actor, doFunc ActorCommand) (manyErrors []error) {
-	for _, a := range actors {
-		a.Commands <- doFunc
+// acceptThreshold undoes the actions on the foundations they failed on, and has them succeed on every other
+// foundation, because enough foundations succeeded to meet the MinSuccessfulFoundations of the environment.
+func (bg BlueGreen) acceptThreshold(cleanUpCtx context.Context, actors []actor, actionCreator I.ActionCreator, environment S.Environment, actionErrors []error, results []S.FoundationResult, response io.ReadWriter) ([]S.FoundationResult, error) {
+	succeeded := succeededActors(actionErrors)
+	bg.Log.WithFields(I.LogFields{I.PhaseLogField: "execute"}).Errorf("failed to execute action against %d of %d foundations - rolling back the failed foundations only", len(actors)-len(succeeded), len(actors))
+	fmt.Fprintf(response, "%d of %d foundations succeeded, which meets the minimum of %d: only the failed foundations are rolled back\n", len(succeeded), len(actors), environment.MinSuccessfulFoundations)
+
+	rollbackErrors := bg.commandsOn(cleanUpCtx, actors, failedActors(actionErrors), "undo", func(ctx context.Context, action I.Action) error {
+		return action.Undo(ctx)
+	})
+
+	finishActionErrors := bg.commandsOn(cleanUpCtx, actors, succeeded, "success", func(ctx context.Context, action I.Action) error {
+		return action.Success(ctx)
+	})
+
+	for i := range results {
+		results[i].Status = S.FoundationSucceeded
 	}
-	for _, a := range actors {
-		if err := <-a.Errs; err != nil {
+	fail(results, finishActionErrors)
+	fail(results, rollbackErrors)
+	fail(results, actionErrors)
+
+	if failed(rollbackErrors) {
+		return results, actionCreator.UndoError(compact(actionErrors), compact(rollbackErrors))
+	}
+
+	return results, actionCreator.ExecuteError(append(compact(actionErrors), compact(finishActionErrors)...))
+}
+
+// meetsThreshold reports whether enough actors succeeded to meet the MinSuccessfulFoundations of the environment.
+func meetsThreshold(environment S.Environment, errs []error) bool {
+	return environment.MinSuccessfulFoundations > 0 && len(succeededActors(errs)) >= environment.MinSuccessfulFoundations
+}
+
+// phaseCommand is run on the action of an actor with the context of its phase.
+type phaseCommand func(ctx context.Context, action I.Action) error
+
+// commandsOn runs the command on the actors at the indexes, and returns the error of every actor in the order
+// of the actors. The errors of the other actors are nil.
+func (bg BlueGreen) commandsOn(ctx context.Context, actors []actor, indexes []int, phase string, doFunc phaseCommand) []error {
+	selected := make([]actor, len(indexes))
+	for i, index := range indexes {
+		selected[i] = actors[index]
+	}
+
+	errs := make([]error, len(actors))
+	for i, err := range bg.commands(ctx, selected, phase, doFunc) {
+		errs[indexes[i]] = err
+	}
+	return errs
+}
+
+// commands runs the command on every actor, at most maxConcurrentFoundations at once, and returns the error of every
+// actor in the order of the actors. The phase is traced as a span of ctx.
+func (bg BlueGreen) commands(ctx context.Context, actors []actor, phase string, doFunc phaseCommand) []error {
+	if bg.Progress != nil {
+		bg.Progress.StartPhase(bg.Log.UUID, progressPhases[phase], len(actors))
+	}
+
+	ctx, span := tracing.Start(ctx, "bluegreen "+phase, tracing.Int("foundations", len(actors)))
+	defer span.Finish()
+
+	command := func(action I.Action) error {
+		return doFunc(ctx, action)
+	}
+	return runPool(actors, bg.maxConcurrentFoundations, command, func(i int, err error) {
+		if bg.Progress != nil {
+			bg.Progress.FinishFoundation(bg.Log.UUID)
+		}
+		if err != nil {
+			if bg.Progress != nil && phase != "undo" {
+				bg.Progress.FailFoundation(bg.Log.UUID, actors[i].FoundationURL)
+			}
+			span.SetError(err)
+			if panicErr, ok := err.(ActionPanicError); ok {
+				bg.Log.WithFields(I.LogFields{I.PhaseLogField: phase}).Errorf("%s\n%s", panicErr, panicErr.Stack)
+			}
+		}
+	})
+}
+
+// failed reports whether any of the actors failed.
+func failed(errs []error) bool {
+	return len(compact(errs)) != 0
+}
+
+// compact returns the errors of the actors that failed.
+func compact(errs []error) (manyErrors []error) {
+	for _, err := range errs {
+		if err != nil {
 			manyErrors = append(manyErrors, err)
 		}
 	}
 	return
 }
+
+// succeededActors returns the indexes of the actors that did not fail.
+func succeededActors(errs []error) (indexes []int) {
+	for i, err := range errs {
+		if err == nil {
+			indexes = append(indexes, i)
+		}
+	}
+	return
+}
+
+// failedActors returns the indexes of the actors that failed.
+func failedActors(errs []error) (indexes []int) {
+	for i, err := range errs {
+		if err != nil {
+			indexes = append(indexes, i)
+		}
+	}
+	return
+}
+
+// fail marks the foundations whose actor failed as failed with its error.
+func fail(results []S.FoundationResult, errs []error) {
+	for i, err := range errs {
+		if err != nil {
+			results[i].Status = S.FoundationFailed
+			results[i].Error = err.Error()
+		}
+	}
+}
+
+// detachedContext keeps the values of its parent but is never cancelled and has no deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+func detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
@@ -1,6 +1,7 @@
 package bluegreen_test
 
 import (
+	"context"
 	"errors"
 
 	. "github.com/compozed/deployadactyl/controller/deployer/bluegreen"
@@ -77,12 +78,66 @@ var _ = Describe("Bluegreen", func() {
 				}
 			}
 
-			err := blueGreen.Execute(pusherCreator, environment, response)
+			_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
 
 			Expect(err).To(MatchError("push creator failed"))
 		})
 	})
 
+	Context("when the context is cancelled", func() {
+		It("passes the context to the actions and a context that is not cancelled to Undo and Finally", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			for _, pusher := range pushers {
+				pusher.ExecuteCall.Returns.Error = ctx.Err()
+			}
+
+			_, err := blueGreen.Execute(ctx, pusherCreator, environment, response)
+			Expect(err).To(HaveOccurred())
+
+			for _, pusher := range pushers {
+				Expect(pusher.InitiallyCall.Received.Context.Err()).To(Equal(context.Canceled))
+				Expect(pusher.ExecuteCall.Received.Context.Err()).To(Equal(context.Canceled))
+				Expect(pusher.UndoCall.Received.Context.Err()).ToNot(HaveOccurred())
+				Expect(pusher.FinallyCall.Received.Context.Err()).ToNot(HaveOccurred())
+			}
+		})
+	})
+
+	Context("when the context is cancelled after the actions are executed", func() {
+		It("rolls back the actions", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			pusherCreator.CreatePusherCall.Returns.Pushers[0] = &cancellingPusher{Pusher: pushers[0], cancel: cancel}
+
+			_, err := blueGreen.Execute(ctx, pusherCreator, environment, response)
+
+			Expect(err).To(MatchError(PushError{[]error{CancelledError{context.Canceled}}}))
+			for _, pusher := range pushers {
+				Expect(pusher.UndoCall.Received.Context).ToNot(BeNil())
+			}
+			Eventually(logBuffer).Should(Say("deployment cancelled: context canceled"))
+		})
+	})
+
+	Context("when the context is cancelled once the actions are verified", func() {
+		It("finishes promoting the actions", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			promoting := &promotingPusher{Pusher: pushers[0], cancel: cancel}
+			pusherCreator.CreatePusherCall.Returns.Pushers[0] = promoting
+
+			_, err := blueGreen.Execute(ctx, pusherCreator, environment, response)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ctx.Err()).To(Equal(context.Canceled))
+			Expect(promoting.renamed).To(BeTrue())
+			for _, pusher := range pushers {
+				Expect(pusher.SuccessCall.Called).To(BeTrue())
+				Expect(pusher.UndoCall.Received.Context).To(BeNil())
+			}
+		})
+	})
+
 	Context("when a login command is called", func() {
 		It("starts a deployment when successful", func() {
 			for i, pusher := range pushers {
@@ -93,7 +148,7 @@ var _ = Describe("Bluegreen", func() {
 				}
 			}
 
-			err := blueGreen.Execute(pusherCreator, environment, response)
+			_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
 			Expect(err).ToNot(HaveOccurred())
 
 			for range environment.Foundations {
@@ -110,7 +165,7 @@ var _ = Describe("Bluegreen", func() {
 				}
 			}
 
-			err := blueGreen.Execute(pusherCreator, environment, response)
+			_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
 			Expect(err).To(MatchError(LoginError{[]error{errors.New(loginOutput)}}))
 
 			for range environment.Foundations {
@@ -141,7 +196,8 @@ var _ = Describe("Bluegreen", func() {
 
 			blueGreen = BlueGreen{Log: log}
 
-			Expect(blueGreen.Execute(pusherCreator, environment, response)).To(Succeed())
+			_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+			Expect(err).ToNot(HaveOccurred())
 
 			Eventually(response).Should(Say(loginOutput))
 			Eventually(response).Should(Say(pushOutput))
@@ -156,7 +212,8 @@ var _ = Describe("Bluegreen", func() {
 				pusher.ExecuteCall.Write.Output = pushOutput
 			}
 
-			Expect(blueGreen.Execute(pusherCreator, environment, response)).To(Succeed())
+			_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+			Expect(err).ToNot(HaveOccurred())
 
 			Eventually(response).Should(Say(loginOutput))
 			Eventually(response).Should(Say(loginOutput))
@@ -164,6 +221,55 @@ var _ = Describe("Bluegreen", func() {
 			Eventually(response).Should(Say(pushOutput))
 		})
 
+		It("reports the progress of every phase", func() {
+			progress := &mocks.ProgressReporter{}
+			blueGreen.Progress = progress
+
+			_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(progress.Calls).To(Equal([]string{
+				"StartPhase " + log.UUID + " logging_in 2",
+				"FinishFoundation " + log.UUID,
+				"FinishFoundation " + log.UUID,
+				"StartPhase " + log.UUID + " executing 2",
+				"FinishFoundation " + log.UUID,
+				"FinishFoundation " + log.UUID,
+				"StartPhase " + log.UUID + " verifying 2",
+				"FinishFoundation " + log.UUID,
+				"FinishFoundation " + log.UUID,
+				"StartPhase " + log.UUID + " succeeding 2",
+				"FinishFoundation " + log.UUID,
+				"FinishFoundation " + log.UUID,
+			}))
+		})
+
+		It("verifies the actions on every foundation before any of them succeeds", func() {
+			pushers[0].VerifyCall.Returns.Error = errors.New("route is not mapped")
+
+			_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+			Expect(err).To(MatchError(ContainSubstring("route is not mapped")))
+			Expect(pushers[0].SuccessCall.Called).To(BeFalse())
+			Expect(pushers[1].SuccessCall.Called).To(BeFalse())
+			Expect(pushers[0].UndoCall.Received.Context).ToNot(BeNil())
+			Expect(pushers[1].UndoCall.Received.Context).ToNot(BeNil())
+		})
+
+		It("reports rolling back when an action fails", func() {
+			progress := &mocks.ProgressReporter{}
+			blueGreen.Progress = progress
+			pushers[0].ExecuteCall.Returns.Error = pushError
+
+			_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+			Expect(err).To(HaveOccurred())
+
+			Expect(progress.Calls).To(ContainElement("StartPhase " + log.UUID + " rolling_back 2"))
+			Expect(progress.Calls).ToNot(ContainElement("StartPhase " + log.UUID + " succeeding 2"))
+			Expect(progress.Calls).To(ContainElement("FailFoundation " + log.UUID + " " + environment.Foundations[0]))
+			Expect(progress.Calls).ToNot(ContainElement("FailFoundation " + log.UUID + " " + environment.Foundations[1]))
+		})
+
 		Context("when enable_rollback is false", func() {
 			It("can push an app that does not rollback on fail", func() {
 				By("setting a single foundation")
@@ -186,7 +292,8 @@ var _ = Describe("Bluegreen", func() {
 
 				blueGreen = BlueGreen{Log: log}
 
-				Expect(blueGreen.Execute(pusherCreator, environment, response)).To(Succeed())
+				_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+			Expect(err).ToNot(HaveOccurred())
 
 				Eventually(response).Should(Say(loginOutput))
 				Eventually(response).Should(Say(pushOutput))
@@ -213,7 +320,7 @@ var _ = Describe("Bluegreen", func() {
 
 				blueGreen = BlueGreen{Log: log}
 
-				err := blueGreen.Execute(pusherCreator, environment, response)
+				_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
 
 				Expect(err).To(MatchError(FinishPushError{[]error{errors.New("finish push error")}}))
 			})
@@ -234,7 +341,7 @@ var _ = Describe("Bluegreen", func() {
 					}
 				}
 
-				err := blueGreen.Execute(pusherCreator, environment, response)
+				_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
 				Expect(err).To(MatchError(PushError{[]error{pushError}}))
 
 				Eventually(response).Should(Say(loginOutput))
@@ -248,7 +355,7 @@ var _ = Describe("Bluegreen", func() {
 					pushers[0].ExecuteCall.Returns.Error = pushError
 					pushers[0].UndoCall.Returns.Error = rollbackError
 
-					err := blueGreen.Execute(pusherCreator, environment, response)
+					_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
 
 					Expect(err).To(MatchError(RollbackError{[]error{pushError}, []error{rollbackError}}))
 				})
@@ -261,7 +368,7 @@ var _ = Describe("Bluegreen", func() {
 					pusher.ExecuteCall.Returns.Error = pushError
 				}
 
-				err := blueGreen.Execute(pusherCreator, environment, response)
+				_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
 				Expect(err).To(MatchError(PushError{[]error{pushError, pushError}}))
 
 				Eventually(response).Should(Say(loginOutput))
@@ -279,7 +386,7 @@ var _ = Describe("Bluegreen", func() {
 					pusher.ExecuteCall.Returns.Error = pushError
 				}
 
-				err := blueGreen.Execute(pusherCreator, environment, response)
+				_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
 
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(Equal("push failed: push error: push error"))
@@ -292,13 +399,157 @@ var _ = Describe("Bluegreen", func() {
 					pusher.ExecuteCall.Returns.Error = errors.New("a push execute error")
 				}
 				pushers[0].UndoCall.Returns.Error = errors.New("a push success error")
-				err := blueGreen.Execute(pusherCreator, environment, response)
+				_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
 
 				Expect(err.Error()).To(Equal("push failed: a push execute error: a push execute error: rollback failed: a push success error"))
 			})
 		})
 	})
 
+	Describe("a minimum of successful foundations", func() {
+		BeforeEach(func() {
+			environment.Foundations = append(environment.Foundations, randomizer.StringRunes(10))
+			pusher := &mocks.Pusher{Response: response}
+			pushers = append(pushers, pusher)
+			pusherCreator.CreatePusherCall.Returns.Pushers = append(pusherCreator.CreatePusherCall.Returns.Pushers, pusher)
+			pusherCreator.CreatePusherCall.Returns.Error = append(pusherCreator.CreatePusherCall.Returns.Error, nil)
+
+			environment.MinSuccessfulFoundations = 2
+		})
+
+		It("only rolls back the failed foundations when the minimum is met", func() {
+			pushers[1].ExecuteCall.Returns.Error = pushError
+
+			results, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+			Expect(err).To(MatchError(PushError{[]error{pushError}}))
+			Expect(pushers[1].UndoCall.Received.Context).ToNot(BeNil())
+			Expect(pushers[1].VerifyCall.Called).To(BeFalse())
+			Expect(pushers[1].SuccessCall.Called).To(BeFalse())
+			for _, pusher := range []*mocks.Pusher{pushers[0], pushers[2]} {
+				Expect(pusher.VerifyCall.Called).To(BeTrue())
+				Expect(pusher.SuccessCall.Called).To(BeTrue())
+				Expect(pusher.UndoCall.Received.Context).To(BeNil())
+			}
+
+			Expect(results).To(Equal([]S.FoundationResult{
+				{FoundationURL: environment.Foundations[0], Status: S.FoundationSucceeded},
+				{FoundationURL: environment.Foundations[1], Status: S.FoundationFailed, Error: "push error"},
+				{FoundationURL: environment.Foundations[2], Status: S.FoundationSucceeded},
+			}))
+			Eventually(response).Should(Say("2 of 3 foundations succeeded, which meets the minimum of 2: only the failed foundations are rolled back"))
+		})
+
+		It("counts the foundations that fail to verify as failed", func() {
+			pushers[1].ExecuteCall.Returns.Error = pushError
+			pushers[2].VerifyCall.Returns.Error = errors.New("route is not mapped")
+
+			results, _ := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+			Expect(pushers[0].SuccessCall.Called).To(BeFalse())
+			for _, pusher := range pushers {
+				Expect(pusher.UndoCall.Received.Context).ToNot(BeNil())
+			}
+			Expect(results[0].Status).To(Equal(S.FoundationRolledBack))
+			Expect(results[2].Status).To(Equal(S.FoundationFailed))
+		})
+
+		It("rolls back every foundation when the minimum is not met", func() {
+			pushers[0].ExecuteCall.Returns.Error = pushError
+			pushers[1].ExecuteCall.Returns.Error = pushError
+
+			results, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+			Expect(err).To(MatchError(PushError{[]error{pushError, pushError}}))
+			Expect(pushers[2].VerifyCall.Called).To(BeFalse())
+			Expect(pushers[2].SuccessCall.Called).To(BeFalse())
+			Expect(pushers[2].UndoCall.Received.Context).ToNot(BeNil())
+			Expect(results[2].Status).To(Equal(S.FoundationRolledBack))
+		})
+
+		It("returns a RollbackError when a failed foundation cannot be rolled back", func() {
+			pushers[1].ExecuteCall.Returns.Error = pushError
+			pushers[1].UndoCall.Returns.Error = rollbackError
+
+			_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+			Expect(err).To(MatchError(RollbackError{[]error{pushError}, []error{rollbackError}}))
+			Expect(pushers[0].SuccessCall.Called).To(BeTrue())
+		})
+	})
+
+	Describe("foundation results", func() {
+		It("returns a succeeded result for every foundation", func() {
+			results, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(Equal([]S.FoundationResult{
+				{FoundationURL: environment.Foundations[0], Status: S.FoundationSucceeded},
+				{FoundationURL: environment.Foundations[1], Status: S.FoundationSucceeded},
+			}))
+		})
+
+		It("returns the foundations that could not be logged into as failed and the others as aborted", func() {
+			pushers[1].InitiallyCall.Returns.Error = errors.New("login failed")
+
+			results, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+			Expect(err).To(HaveOccurred())
+			Expect(results).To(Equal([]S.FoundationResult{
+				{FoundationURL: environment.Foundations[0], Status: S.FoundationAborted},
+				{FoundationURL: environment.Foundations[1], Status: S.FoundationFailed, Error: "login failed"},
+			}))
+		})
+
+		It("returns the foundations that were rolled back", func() {
+			pushers[0].ExecuteCall.Returns.Error = pushError
+
+			results, _ := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+			Expect(results).To(Equal([]S.FoundationResult{
+				{FoundationURL: environment.Foundations[0], Status: S.FoundationFailed, Error: "push error"},
+				{FoundationURL: environment.Foundations[1], Status: S.FoundationRolledBack},
+			}))
+		})
+
+		It("returns the foundations that could not be rolled back as failed", func() {
+			pushers[0].ExecuteCall.Returns.Error = pushError
+			pushers[1].UndoCall.Returns.Error = rollbackError
+
+			results, _ := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+			Expect(results[0].Status).To(Equal(S.FoundationFailed))
+			Expect(results[1]).To(Equal(S.FoundationResult{FoundationURL: environment.Foundations[1], Status: S.FoundationFailed, Error: "rollback error"}))
+		})
+
+		It("returns the foundations that were finished instead of rolled back as succeeded", func() {
+			environment.EnableRollback = false
+			pusherCreator.UndoFinishesCall.Returns.Finishes = true
+			pushers[0].ExecuteCall.Returns.Error = pushError
+
+			results, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+			Expect(err).To(MatchError(PushError{[]error{pushError}}))
+			Expect(pusherCreator.UndoFinishesCall.Received.Environment).To(Equal(environment))
+			Expect(results).To(Equal([]S.FoundationResult{
+				{FoundationURL: environment.Foundations[0], Status: S.FoundationFailed, Error: "push error"},
+				{FoundationURL: environment.Foundations[1], Status: S.FoundationSucceeded},
+			}))
+			Expect(S.PartialSuccess(results)).To(BeTrue())
+		})
+
+		It("returns the foundations whose success failed as failed", func() {
+			pushers[1].SuccessCall.Returns.Error = errors.New("finish push error")
+
+			results, _ := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+			Expect(results).To(Equal([]S.FoundationResult{
+				{FoundationURL: environment.Foundations[0], Status: S.FoundationSucceeded},
+				{FoundationURL: environment.Foundations[1], Status: S.FoundationFailed, Error: "finish push error"},
+			}))
+		})
+	})
+
 	Describe("Stop", func() {
 		Context("when called", func() {
 			It("creates a stopper for each foundation", func() {
@@ -311,7 +562,7 @@ var _ = Describe("Bluegreen", func() {
 
 				blueGreen = BlueGreen{}
 
-				err := blueGreen.Execute(stopperFactory, environment, NewBuffer())
+				_, err := blueGreen.Execute(context.Background(), stopperFactory, environment, NewBuffer())
 				Expect(err).ToNot(HaveOccurred())
 
 				for i, foundation := range environment.Foundations {
@@ -325,7 +576,7 @@ var _ = Describe("Bluegreen", func() {
 				stopperFactory.CreateStopperCall.Returns.Error = append(stopperFactory.CreateStopperCall.Returns.Error, errors.New("stop creator failed"))
 
 				blueGreen = BlueGreen{Log: log}
-				err := blueGreen.Execute(stopperFactory, environment, NewBuffer())
+				_, err := blueGreen.Execute(context.Background(), stopperFactory, environment, NewBuffer())
 
 				Expect(err).To(MatchError("stop creator failed"))
 			})
@@ -343,7 +594,7 @@ var _ = Describe("Bluegreen", func() {
 
 				blueGreen = BlueGreen{}
 
-				err := blueGreen.Execute(stopperFactory, environment, NewBuffer())
+				_, err := blueGreen.Execute(context.Background(), stopperFactory, environment, NewBuffer())
 				Expect(err).ToNot(HaveOccurred())
 
 			})
@@ -360,7 +611,7 @@ var _ = Describe("Bluegreen", func() {
 				}
 				stoppers[0].InitiallyCall.Returns.Error = errors.New("login to stop failed")
 				blueGreen = BlueGreen{}
-				err := blueGreen.Execute(stopperFactory, environment, NewBuffer())
+				_, err := blueGreen.Execute(context.Background(), stopperFactory, environment, NewBuffer())
 
 				Expect(err.Error()).To(Equal("login failed: login to stop failed"))
 			})
@@ -378,7 +629,7 @@ var _ = Describe("Bluegreen", func() {
 				}
 
 				blueGreen = BlueGreen{}
-				err := blueGreen.Execute(stopperFactory, environment, NewBuffer())
+				_, err := blueGreen.Execute(context.Background(), stopperFactory, environment, NewBuffer())
 
 				Expect(err.Error()).To(Equal("login failed: login 0 to stop failed: login 1 to stop failed"))
 			})
@@ -396,7 +647,7 @@ var _ = Describe("Bluegreen", func() {
 
 				blueGreen = BlueGreen{}
 
-				err := blueGreen.Execute(stopperFactory, environment, NewBuffer())
+				_, err := blueGreen.Execute(context.Background(), stopperFactory, environment, NewBuffer())
 				Expect(err).ToNot(HaveOccurred())
 
 			})
@@ -415,7 +666,7 @@ var _ = Describe("Bluegreen", func() {
 
 				blueGreen = BlueGreen{Log: log}
 
-				err := blueGreen.Execute(stopperFactory, environment, NewBuffer())
+				_, err := blueGreen.Execute(context.Background(), stopperFactory, environment, NewBuffer())
 				Expect(err).To(MatchError(StopError{[]error{errors.New("stop failed")}}))
 			})
 
@@ -433,7 +684,7 @@ var _ = Describe("Bluegreen", func() {
 
 				blueGreen = BlueGreen{Log: log}
 
-				err := blueGreen.Execute(stopperFactory, environment, NewBuffer())
+				_, err := blueGreen.Execute(context.Background(), stopperFactory, environment, NewBuffer())
 				Expect(err.Error()).To(Equal("stop failed: stop failed: stop failed"))
 			})
 
@@ -451,7 +702,7 @@ var _ = Describe("Bluegreen", func() {
 
 				blueGreen = BlueGreen{Log: log}
 
-				err := blueGreen.Execute(stopperFactory, environment, NewBuffer())
+				_, err := blueGreen.Execute(context.Background(), stopperFactory, environment, NewBuffer())
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(Equal("stop failed: an error occurred"))
 			})
@@ -472,7 +723,7 @@ var _ = Describe("Bluegreen", func() {
 					Log: log,
 				}
 
-				err := blueGreen.Execute(stopperFactory, environment, NewBuffer())
+				_, err := blueGreen.Execute(context.Background(), stopperFactory, environment, NewBuffer())
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(Equal("stop failed: an error occurred: rollback failed: an error occurred while attempting undo"))
 			})
@@ -492,7 +743,7 @@ var _ = Describe("Bluegreen", func() {
 
 				blueGreen = BlueGreen{}
 
-				err := blueGreen.Execute(stopperFactory, environment, out)
+				_, err := blueGreen.Execute(context.Background(), stopperFactory, environment, out)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(out).Should(Say("- Cloud Foundry Output -"))
@@ -501,3 +752,29 @@ var _ = Describe("Bluegreen", func() {
 		})
 	})
 })
+
+type cancellingPusher struct {
+	*mocks.Pusher
+	cancel context.CancelFunc
+}
+
+func (p *cancellingPusher) Execute(ctx context.Context) error {
+	defer p.cancel()
+	return p.Pusher.Execute(ctx)
+}
+
+// promotingPusher cancels the deployment while it unmaps the original application, and only renames the new build
+// while its context is not done, like a courier bound to it.
+type promotingPusher struct {
+	*mocks.Pusher
+	cancel  context.CancelFunc
+	renamed bool
+}
+
+func (p *promotingPusher) Success(ctx context.Context) error {
+	p.cancel()
+	if ctx.Err() == nil {
+		p.renamed = true
+	}
+	return p.Pusher.Success(ctx)
+}
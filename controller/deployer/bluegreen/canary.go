@@ -0,0 +1,85 @@
+package bluegreen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// canaryCreator is implemented by action creators whose actions deploy a canary to the environment.
+type canaryCreator interface {
+	Canary(environment S.Environment) bool
+}
+
+// Canary deploys like BlueGreen, except that the canaries deployed by the actions bake once they are verified on
+// every foundation, and are promoted to every instance before they succeed. A canary that is cancelled while it
+// bakes, or that is not promoted in time when it has to be confirmed, is rolled back. The actions of other action
+// creators, such as the stops and starts of the environment, are run like BlueGreen runs them.
+type Canary struct {
+	BlueGreen
+
+	// Promotions promotes the canaries before their bake period is over. It may be nil.
+	Promotions I.CanaryPromoter
+}
+
+// Execute runs the actions of the action creator against every foundation of the environment.
+func (c Canary) Execute(ctx context.Context, actionCreator I.ActionCreator, environment S.Environment, response io.ReadWriter) ([]S.FoundationResult, error) {
+	creator, ok := actionCreator.(canaryCreator)
+	if !ok || !creator.Canary(environment) {
+		return c.BlueGreen.Execute(ctx, actionCreator, environment, response)
+	}
+
+	return c.BlueGreen.execute(ctx, actionCreator, environment, response, func(ctx context.Context) error {
+		return c.bake(ctx, environment.Canary, response)
+	})
+}
+
+// bake waits for the bake period of the canary or for its promotion, or only for its promotion when it has to be
+// confirmed.
+func (c Canary) bake(ctx context.Context, canary S.CanaryDescriptor, response io.Writer) error {
+	var promoted <-chan struct{}
+	if c.Promotions != nil {
+		var done func()
+		promoted, done = c.Promotions.Wait(c.Log.UUID)
+		defer done()
+	}
+
+	if c.Progress != nil {
+		c.Progress.StartPhase(c.Log.UUID, S.PhaseBaking, 0)
+	}
+
+	log := c.Log.WithFields(I.LogFields{I.PhaseLogField: "bake"})
+	wait := time.Duration(canary.BakeSeconds) * time.Second
+	if canary.Confirm {
+		wait = time.Duration(canary.ConfirmTimeoutSeconds) * time.Second
+		log.Infof("waiting %s for the canary to be promoted", wait)
+		fmt.Fprintf(response, "\nthe canary runs on every foundation and waits %s to be promoted\n", wait)
+	} else {
+		log.Infof("baking the canary for %s", wait)
+		fmt.Fprintf(response, "\nthe canary runs on every foundation and bakes for %s\n", wait)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return CancelledError{ctx.Err()}
+	case <-promoted:
+		log.Info("the canary was promoted")
+		fmt.Fprintf(response, "the canary was promoted\n")
+		return nil
+	case <-timer.C:
+		if canary.Confirm {
+			log.Errorf("the canary was not promoted within %s", wait)
+			return CanaryNotPromotedError{wait}
+		}
+		log.Info("the canary baked")
+		fmt.Fprintf(response, "the canary baked\n")
+		return nil
+	}
+}
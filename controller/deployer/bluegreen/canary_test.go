@@ -0,0 +1,154 @@
+package bluegreen_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/compozed/deployadactyl/canary"
+	. "github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Canary", func() {
+	var (
+		pusherCreator *mocks.PushManager
+		pushers       []*mocks.Pusher
+		promotions    *canary.Registry
+		progress      *mocks.ProgressReporter
+		strategy      Canary
+		environment   S.Environment
+		response      *Buffer
+	)
+
+	BeforeEach(func() {
+		response = NewBuffer()
+		log := interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(NewBuffer(), logging.DEBUG, "canary_test"), UUID: "uuid-1"}
+
+		environment = S.Environment{
+			Name:           "production",
+			Foundations:    []string{"https://api1.example.com", "https://api2.example.com"},
+			EnableRollback: true,
+			Strategy:       S.StrategyCanary,
+			Canary:         S.CanaryDescriptor{Percent: 10, Confirm: true, ConfirmTimeoutSeconds: 60},
+		}
+
+		pusherCreator = &mocks.PushManager{}
+		pusherCreator.CanaryCall.Returns.Canary = true
+		pushers = nil
+		for range environment.Foundations {
+			pusher := &mocks.Pusher{Response: response}
+			pushers = append(pushers, pusher)
+			pusherCreator.CreatePusherCall.Returns.Pushers = append(pusherCreator.CreatePusherCall.Returns.Pushers, pusher)
+			pusherCreator.CreatePusherCall.Returns.Error = append(pusherCreator.CreatePusherCall.Returns.Error, nil)
+		}
+
+		promotions = canary.NewRegistry()
+		progress = &mocks.ProgressReporter{}
+		strategy = Canary{BlueGreen: BlueGreen{Log: log, Progress: progress}, Promotions: promotions}
+	})
+
+	promoteWhenWaiting := func() {
+		go func() {
+			defer GinkgoRecover()
+			Eventually(func() error { return promotions.Promote("uuid-1") }).Should(Succeed())
+		}()
+	}
+
+	It("promotes the canaries on every foundation once the canary is promoted", func() {
+		promoteWhenWaiting()
+
+		_, err := strategy.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pusherCreator.CanaryCall.Received.Environment.Name).To(Equal("production"))
+		for _, pusher := range pushers {
+			Expect(pusher.PromoteCall.Called).To(BeTrue())
+			Expect(pusher.SuccessCall.Called).To(BeTrue())
+		}
+		Expect(progress.Calls).To(ContainElement("StartPhase uuid-1 baking 0"))
+		Expect(progress.Calls).To(ContainElement("StartPhase uuid-1 promoting 2"))
+		Eventually(response).Should(Say("waits 1m0s to be promoted"))
+		Eventually(response).Should(Say("the canary was promoted"))
+	})
+
+	It("promotes the canaries once they baked", func() {
+		environment.Canary = S.CanaryDescriptor{Percent: 10}
+
+		_, err := strategy.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pushers[0].PromoteCall.Called).To(BeTrue())
+		Eventually(response).Should(Say("the canary baked"))
+	})
+
+	It("rolls back the canaries when they are not promoted in time", func() {
+		environment.Canary.ConfirmTimeoutSeconds = 0
+
+		_, err := strategy.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).To(MatchError(PushError{[]error{CanaryNotPromotedError{0}, CanaryNotPromotedError{0}}}))
+		for _, pusher := range pushers {
+			Expect(pusher.PromoteCall.Called).To(BeFalse())
+			Expect(pusher.SuccessCall.Called).To(BeFalse())
+			Expect(pusher.UndoCall.Received.Context).ToNot(BeNil())
+		}
+	})
+
+	It("rolls back the canaries when the deployment is cancelled while they bake", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+
+		_, err := strategy.Execute(ctx, pusherCreator, environment, response)
+
+		Expect(err).To(MatchError(PushError{[]error{CancelledError{context.Canceled}, CancelledError{context.Canceled}}}))
+		for _, pusher := range pushers {
+			Expect(pusher.PromoteCall.Called).To(BeFalse())
+			Expect(pusher.UndoCall.Received.Context).ToNot(BeNil())
+		}
+	})
+
+	It("rolls back every foundation when a canary cannot be promoted", func() {
+		pushers[1].PromoteCall.Returns.Error = errors.New("scale failed")
+		promoteWhenWaiting()
+
+		_, err := strategy.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).To(MatchError(PushError{[]error{errors.New("scale failed")}}))
+		for _, pusher := range pushers {
+			Expect(pusher.SuccessCall.Called).To(BeFalse())
+			Expect(pusher.UndoCall.Received.Context).ToNot(BeNil())
+		}
+	})
+
+	It("does not bake the canaries when an action fails", func() {
+		pushers[0].ExecuteCall.Returns.Error = errors.New("push failed")
+
+		_, err := strategy.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).To(MatchError(PushError{[]error{errors.New("push failed")}}))
+		Expect(progress.Calls).ToNot(ContainElement("StartPhase uuid-1 baking 0"))
+	})
+
+	It("deploys like BlueGreen when the action creator does not deploy a canary", func() {
+		pusherCreator.CanaryCall.Returns.Canary = false
+
+		_, err := strategy.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).ToNot(HaveOccurred())
+		for _, pusher := range pushers {
+			Expect(pusher.PromoteCall.Called).To(BeFalse())
+			Expect(pusher.SuccessCall.Called).To(BeTrue())
+		}
+		Expect(progress.Calls).ToNot(ContainElement("StartPhase uuid-1 baking 0"))
+	})
+})
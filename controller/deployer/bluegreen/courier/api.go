@@ -0,0 +1,1192 @@
+package courier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/compozed/deployadactyl/cabundle"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tracing"
+	"github.com/spf13/afero"
+)
+
+// DefaultAPIPollInterval is how often the APICourier checks on packages, builds, jobs and instances.
+const DefaultAPIPollInterval = 2 * time.Second
+
+// DefaultAPIStagingTimeout is how long the APICourier waits for an application to be staged.
+const DefaultAPIStagingTimeout = 15 * time.Minute
+
+// DefaultAPIStartTimeout is how long the APICourier waits for an instance of an application to be running.
+const DefaultAPIStartTimeout = 5 * time.Minute
+
+// apiTimeout is how long a request to the Cloud Controller or UAA may take, except for the upload of the bits of an
+// application.
+const apiTimeout = 2 * time.Minute
+
+// uploadTimeout is how long the upload of the bits of an application may take.
+const uploadTimeout = 30 * time.Minute
+
+// NewAPICourier returns an APICourier that is not logged in.
+func NewAPICourier() APICourier {
+	return APICourier{session: &session{}}
+}
+
+// APICourier runs the commands of a Courier against the v3 API of the Cloud Controller instead of running the
+// Cloud Foundry CLI, so the CLI does not have to be installed on the server.
+//
+// The copies of an APICourier share its login.
+type APICourier struct {
+	// PollInterval is how often packages, builds, jobs and instances are checked. DefaultAPIPollInterval is used
+	// when it is zero.
+	PollInterval time.Duration
+
+	session  *session
+	logCache S.LogCacheDescriptor
+	caBundle string
+	ctx      context.Context
+}
+
+// session is the login of an APICourier: the foundation, its tokens and the targeted org and space.
+type session struct {
+	sync.Mutex
+
+	apiURL       string
+	tokenURL     string
+	skipSSL      bool
+	accessToken  string
+	refreshToken string
+	orgGUID      string
+	spaceGUID    string
+}
+
+// Login authenticates with the foundation and targets the org and space.
+//
+// Returns a summary of the login, or the error.
+func (c APICourier) Login(foundationURL, username, password, org, space string, skipSSL bool) ([]byte, error) {
+	output, err := c.Authenticate(foundationURL, username, password, skipSSL)
+	if err != nil {
+		return output, err
+	}
+
+	orgGUID, err := c.guidByName("/v3/organizations?names="+url.QueryEscape(org), "organization", org)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	spaceGUID, err := c.guidByName("/v3/spaces?names="+url.QueryEscape(space)+"&organization_guids="+orgGUID, "space", space)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	c.session.Lock()
+	c.session.orgGUID = orgGUID
+	c.session.spaceGUID = spaceGUID
+	c.session.Unlock()
+
+	return append(output, fmt.Sprintf("targeted org %s and space %s\n", org, space)...), nil
+}
+
+// Authenticate authenticates with the UAA of the foundation without targeting an org and space,
+// so every org and space the user can see can be read.
+//
+// Returns a summary of the login, or the error.
+func (c APICourier) Authenticate(foundationURL, username, password string, skipSSL bool) ([]byte, error) {
+	apiURL := strings.TrimSuffix(foundationURL, "/")
+	if !strings.Contains(apiURL, "://") {
+		apiURL = "https://" + apiURL
+	}
+
+	c.session.Lock()
+	c.session.apiURL, c.session.skipSSL = apiURL, skipSSL
+	c.session.tokenURL, c.session.accessToken, c.session.refreshToken = "", "", ""
+	c.session.orgGUID, c.session.spaceGUID = "", ""
+	c.session.Unlock()
+
+	var root struct {
+		Links struct {
+			Login struct {
+				Href string `json:"href"`
+			} `json:"login"`
+			UAA *struct {
+				Href string `json:"href"`
+			} `json:"uaa"`
+		} `json:"links"`
+	}
+	err := c.do("GET", "/", nil, &root)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	tokenURL := root.Links.Login.Href
+	if root.Links.UAA != nil && root.Links.UAA.Href != "" {
+		tokenURL = root.Links.UAA.Href
+	}
+	if tokenURL == "" {
+		err = APIError{Method: "GET", Path: "/", Detail: "the foundation does not link to a UAA"}
+		return []byte(err.Error()), err
+	}
+
+	c.session.Lock()
+	c.session.tokenURL = strings.TrimSuffix(tokenURL, "/") + "/oauth/token"
+	c.session.Unlock()
+
+	err = c.token(url.Values{"grant_type": {"password"}, "username": {username}, "password": {password}})
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("authenticated with %s as %s\n", apiURL, username)), nil
+}
+
+// token requests an access token from UAA with the grant, as the cf client of the Cloud Foundry CLI.
+func (c APICourier) token(grant url.Values) error {
+	c.session.Lock()
+	tokenURL := c.session.tokenURL
+	c.session.Unlock()
+
+	request, err := http.NewRequest("POST", tokenURL, strings.NewReader(grant.Encode()))
+	if err != nil {
+		return err
+	}
+	request.SetBasicAuth("cf", "")
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	var tokens struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+	}
+	err = c.roundTrip(request, apiTimeout, &tokens, nil)
+	if err != nil {
+		return AuthenticationError{Err: err}
+	}
+
+	tokenType := tokens.TokenType
+	if tokenType == "" {
+		tokenType = "bearer"
+	}
+
+	c.session.Lock()
+	c.session.accessToken = tokenType + " " + tokens.AccessToken
+	if tokens.RefreshToken != "" {
+		c.session.refreshToken = tokens.RefreshToken
+	}
+	c.session.Unlock()
+
+	return nil
+}
+
+// refresh requests a new access token with the refresh token of the session.
+func (c APICourier) refresh() error {
+	c.session.Lock()
+	refreshToken := c.session.refreshToken
+	c.session.Unlock()
+
+	if refreshToken == "" {
+		return AuthenticationError{Err: fmt.Errorf("the access token expired and cannot be refreshed")}
+	}
+	return c.token(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+}
+
+// CreateService creates an instance of the plan of a managed service and waits until it is created.
+func (c APICourier) CreateService(service, plan, name string) ([]byte, error) {
+	spaceGUID, err := c.space()
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	planGUID, err := c.guidByName("/v3/service_plans?names="+url.QueryEscape(plan)+"&service_offering_names="+url.QueryEscape(service), "service plan", service+" "+plan)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.doJob("POST", "/v3/service_instances", map[string]interface{}{
+		"type": "managed",
+		"name": name,
+		"relationships": map[string]interface{}{
+			"space":        relationship(spaceGUID),
+			"service_plan": relationship(planGUID),
+		},
+	}, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("created service %s of %s %s\n", name, service, plan)), nil
+}
+
+// BindService binds the service instance to the application.
+func (c APICourier) BindService(appName, serviceName string) ([]byte, error) {
+	appGUID, err := c.appGUID(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+	serviceGUID, err := c.serviceGUID(serviceName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.doJob("POST", "/v3/service_credential_bindings", map[string]interface{}{
+		"type": "app",
+		"relationships": map[string]interface{}{
+			"app":              relationship(appGUID),
+			"service_instance": relationship(serviceGUID),
+		},
+	}, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("bound service %s to %s\n", serviceName, appName)), nil
+}
+
+// UnbindService unbinds the service instance from the application. Nothing is done when it is not bound.
+func (c APICourier) UnbindService(appName, serviceName string) ([]byte, error) {
+	appGUID, err := c.appGUID(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+	serviceGUID, err := c.serviceGUID(serviceName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	var bindings resources
+	err = c.do("GET", "/v3/service_credential_bindings?app_guids="+appGUID+"&service_instance_guids="+serviceGUID, nil, &bindings)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+	for _, binding := range bindings.Resources {
+		err = c.doJob("DELETE", "/v3/service_credential_bindings/"+binding.GUID, nil, nil)
+		if err != nil {
+			return []byte(err.Error()), err
+		}
+	}
+
+	return []byte(fmt.Sprintf("unbound service %s from %s\n", serviceName, appName)), nil
+}
+
+// DeleteService deletes the service instance and waits until it is deleted. Nothing is done when it does not exist.
+func (c APICourier) DeleteService(serviceName string) ([]byte, error) {
+	guid, err := c.serviceGUID(serviceName)
+	if _, ok := err.(NotFoundError); ok {
+		return []byte(fmt.Sprintf("service %s does not exist\n", serviceName)), nil
+	}
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.doJob("DELETE", "/v3/service_instances/"+guid, nil, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("deleted service %s\n", serviceName)), nil
+}
+
+// Restage stages the latest package of the application again and restarts it with the new droplet.
+func (c APICourier) Restage(appName string) ([]byte, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	var packages resources
+	err = c.do("GET", "/v3/apps/"+guid+"/packages?states=READY&order_by=-created_at&per_page=1", nil, &packages)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+	if len(packages.Resources) == 0 {
+		err = NotFoundError{"package of application", appName}
+		return []byte(err.Error()), err
+	}
+
+	return c.stageAndStart(appName, guid, packages.Resources[0].GUID, []byte(fmt.Sprintf("restaging %s\n", appName)))
+}
+
+// Start starts the application and waits until an instance of it is running.
+func (c APICourier) Start(appName string) ([]byte, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.do("POST", "/v3/apps/"+guid+"/actions/start", nil, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.waitForInstances(appName, guid)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("started %s\n", appName)), nil
+}
+
+// Stop stops the application.
+func (c APICourier) Stop(appName string) ([]byte, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.do("POST", "/v3/apps/"+guid+"/actions/stop", nil, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("stopped %s\n", appName)), nil
+}
+
+// Delete deletes the application and waits until it is deleted. Nothing is done when it does not exist.
+func (c APICourier) Delete(appName string) ([]byte, error) {
+	guid, err := c.appGUID(appName)
+	if _, ok := err.(NotFoundError); ok {
+		return []byte(fmt.Sprintf("application %s does not exist\n", appName)), nil
+	}
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return c.DeleteByGUID(guid)
+}
+
+// DeleteByGUID deletes an application by its guid, so it does not have to be in the targeted space, and waits until
+// it is deleted.
+func (c APICourier) DeleteByGUID(guid string) ([]byte, error) {
+	err := c.doJob("DELETE", "/v3/apps/"+guid, nil, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("deleted application %s\n", guid)), nil
+}
+
+// Rename renames the application.
+func (c APICourier) Rename(appName, newAppName string) ([]byte, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.do("PATCH", "/v3/apps/"+guid, map[string]string{"name": newAppName}, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("renamed %s to %s\n", appName, newAppName)), nil
+}
+
+// MapRoute maps the route of the hostname on the domain to the application, and creates the route when it does not
+// exist.
+func (c APICourier) MapRoute(appName, domain, hostname string) ([]byte, error) {
+	return c.MapRouteWithPath(appName, domain, hostname, "")
+}
+
+// MapRouteWithPath maps the route of the hostname and path on the domain to the application, and creates the route
+// when it does not exist.
+func (c APICourier) MapRouteWithPath(appName, domain, hostname, path string) ([]byte, error) {
+	appGUID, err := c.appGUID(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	routeGUID, err := c.routeGUID(domain, hostname, path)
+	if _, ok := err.(NotFoundError); ok {
+		routeGUID, err = c.createRoute(domain, hostname, path)
+	}
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.do("POST", "/v3/routes/"+routeGUID+"/destinations", map[string]interface{}{
+		"destinations": []interface{}{map[string]interface{}{"app": map[string]string{"guid": appGUID}}},
+	}, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("mapped %s to %s\n", routeURL(domain, hostname, path), appName)), nil
+}
+
+// UnmapRoute unmaps the route of the hostname on the domain from the application. Nothing is done when it is not
+// mapped.
+func (c APICourier) UnmapRoute(appName, domain, hostname string) ([]byte, error) {
+	return c.UnmapRouteWithPath(appName, domain, hostname, "")
+}
+
+// UnmapRouteWithPath unmaps the route of the hostname and path on the domain from the application. Nothing is done
+// when it is not mapped.
+func (c APICourier) UnmapRouteWithPath(appName, domain, hostname, path string) ([]byte, error) {
+	appGUID, err := c.appGUID(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	routeGUID, err := c.routeGUID(domain, hostname, path)
+	if _, ok := err.(NotFoundError); ok {
+		return []byte(fmt.Sprintf("route %s does not exist\n", routeURL(domain, hostname, path))), nil
+	}
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	var destinations struct {
+		Destinations []struct {
+			GUID string `json:"guid"`
+			App  struct {
+				GUID string `json:"guid"`
+			} `json:"app"`
+		} `json:"destinations"`
+	}
+	err = c.do("GET", "/v3/routes/"+routeGUID+"/destinations", nil, &destinations)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+	for _, destination := range destinations.Destinations {
+		if destination.App.GUID != appGUID {
+			continue
+		}
+		err = c.do("DELETE", "/v3/routes/"+routeGUID+"/destinations/"+destination.GUID, nil, nil)
+		if err != nil {
+			return []byte(err.Error()), err
+		}
+	}
+
+	return []byte(fmt.Sprintf("unmapped %s from %s\n", routeURL(domain, hostname, path), appName)), nil
+}
+
+// DeleteRoute deletes the route of the hostname on the domain. Nothing is done when it does not exist.
+func (c APICourier) DeleteRoute(domain, hostname string) ([]byte, error) {
+	guid, err := c.routeGUID(domain, hostname, "")
+	if _, ok := err.(NotFoundError); ok {
+		return []byte(fmt.Sprintf("route %s does not exist\n", routeURL(domain, hostname, ""))), nil
+	}
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.doJob("DELETE", "/v3/routes/"+guid, nil, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("deleted route %s\n", routeURL(domain, hostname, ""))), nil
+}
+
+// Logs reads the recent logs of the application from Log Cache, formatted like the recent logs of the Cloud Foundry
+// CLI. Log Cache is read even when it is not enabled, as there is no CLI to read the logs with.
+func (c APICourier) Logs(appName string) ([]byte, error) {
+	envelopes, err := c.LogEnvelopes(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return c.logCacheReader().format(envelopes), nil
+}
+
+// LogEnvelopes reads the log envelopes of every source of the application from Log Cache, oldest first.
+// The Log Cache API is found from the Cloud Controller when a descriptor has not been given.
+func (c APICourier) LogEnvelopes(appName string) ([]S.LogEnvelope, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return nil, LogCacheError{appName, err}
+	}
+
+	c.session.Lock()
+	token := c.session.accessToken
+	c.session.Unlock()
+
+	envelopes, err := c.logCacheReader().envelopes(c.get, guid, token)
+	if err != nil {
+		return nil, LogCacheError{appName, err}
+	}
+
+	return envelopes, nil
+}
+
+func (c APICourier) logCacheReader() logCacheReader {
+	return logCacheReader{descriptor: c.logCache, caBundle: c.caBundle, ctx: c.ctx}
+}
+
+// Cups creates a user provided service with the credentials in the JSON body.
+func (c APICourier) Cups(serviceName string, body string) ([]byte, error) {
+	spaceGUID, err := c.space()
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+	credentials, err := credentials(body)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.do("POST", "/v3/service_instances", map[string]interface{}{
+		"type":          "user-provided",
+		"name":          serviceName,
+		"credentials":   credentials,
+		"relationships": map[string]interface{}{"space": relationship(spaceGUID)},
+	}, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("created user provided service %s\n", serviceName)), nil
+}
+
+// Uups updates the credentials of a user provided service with the JSON body.
+func (c APICourier) Uups(serviceName string, body string) ([]byte, error) {
+	guid, err := c.serviceGUID(serviceName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+	credentials, err := credentials(body)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.do("PATCH", "/v3/service_instances/"+guid, map[string]interface{}{"credentials": credentials}, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("updated user provided service %s\n", serviceName)), nil
+}
+
+// Exists checks to see whether the application name exists already.
+//
+// Returns true if the application exists.
+func (c APICourier) Exists(appName string) bool {
+	_, err := c.appGUID(appName)
+	return err == nil
+}
+
+// ServiceExists checks to see whether the service instance exists in the space.
+//
+// Returns true if the service exists.
+func (c APICourier) ServiceExists(serviceName string) bool {
+	_, err := c.serviceGUID(serviceName)
+	return err == nil
+}
+
+// Domains returns the names of the domains the user can see on the foundation.
+func (c APICourier) Domains() ([]string, error) {
+	domains := []string{}
+	path := "/v3/domains?per_page=5000"
+	for path != "" {
+		var page struct {
+			Pagination pagination `json:"pagination"`
+			Resources  []struct {
+				Name string `json:"name"`
+			} `json:"resources"`
+		}
+		err := c.get(path, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, domain := range page.Resources {
+			domains = append(domains, domain.Name)
+		}
+
+		path, err = page.Pagination.next()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return domains, nil
+}
+
+// SetLabel sets the labels of the application.
+func (c APICourier) SetLabel(appName string, labels map[string]string) ([]byte, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.do("PATCH", "/v3/apps/"+guid, map[string]interface{}{"metadata": map[string]interface{}{"labels": labels}}, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	keys := []string{}
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return []byte(fmt.Sprintf("set the labels %s of %s\n", strings.Join(keys, ", "), appName)), nil
+}
+
+// Scale changes the number of instances of the web process of the application.
+func (c APICourier) Scale(appName string, instances uint16) ([]byte, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.do("POST", "/v3/apps/"+guid+"/processes/web/actions/scale", map[string]uint16{"instances": instances}, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("scaled %s to %d instances\n", appName, instances)), nil
+}
+
+// RestartInstance restarts a single instance of the web process of the application.
+func (c APICourier) RestartInstance(appName string, index int) ([]byte, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	err = c.do("DELETE", fmt.Sprintf("/v3/apps/%s/processes/web/instances/%d", guid, index), nil, nil)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return []byte(fmt.Sprintf("restarted instance %d of %s\n", index, appName)), nil
+}
+
+// AppState returns the state, instance count and labels of an application.
+func (c APICourier) AppState(appName string) (S.AppState, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return S.AppState{}, AppStateError{appName, []byte(err.Error())}
+	}
+
+	state, err := readAppState(c.get, guid)
+	if err != nil {
+		return S.AppState{}, AppStateError{appName, []byte(err.Error())}
+	}
+
+	return state, nil
+}
+
+// Instances returns every instance of the web process of an application, ordered by index.
+func (c APICourier) Instances(appName string) ([]S.Instance, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	instances, err := readInstances(c.get, guid)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	return instances, nil
+}
+
+// AppEnvironment returns the environment variables set on an application by the user.
+func (c APICourier) AppEnvironment(appName string) (map[string]string, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	environment, err := readAppEnvironment(c.get, guid)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	return environment, nil
+}
+
+// AppRoutes returns the URLs of the routes mapped to an application, such as "app.example.com/path", sorted.
+func (c APICourier) AppRoutes(appName string) ([]string, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	routes, err := readAppRoutes(c.get, guid)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	return routes, nil
+}
+
+// Apps returns every application the user can see on the foundation, with the org and space it is in.
+func (c APICourier) Apps() ([]S.AppSummary, error) {
+	apps, err := readApps(c.get)
+	if err != nil {
+		return nil, AppsError{[]byte(err.Error())}
+	}
+
+	return apps, nil
+}
+
+// RunTask starts a task with the droplet of an application. The task runs in the background.
+func (c APICourier) RunTask(appName, taskName, command string) (S.Task, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return S.Task{}, TaskError{taskName, []byte(err.Error())}
+	}
+
+	var t task
+	err = c.do("POST", "/v3/apps/"+guid+"/tasks", map[string]string{"name": taskName, "command": command}, &t)
+	if err != nil {
+		return S.Task{}, TaskError{taskName, []byte(err.Error())}
+	}
+
+	return t.task(), nil
+}
+
+// Task returns the state of a task.
+func (c APICourier) Task(guid string) (S.Task, error) {
+	var t task
+	err := c.get("/v3/tasks/"+guid, &t)
+	if err != nil {
+		return S.Task{}, TaskError{guid, []byte(err.Error())}
+	}
+
+	return t.task(), nil
+}
+
+// QuotaUsage returns the memory and instances used by the org and the space, and the limits of their quotas.
+func (c APICourier) QuotaUsage(org, space string) (S.OrgSpaceQuotaUsage, error) {
+	var usage S.OrgSpaceQuotaUsage
+
+	orgGUID, err := c.guidByName("/v3/organizations?names="+url.QueryEscape(org), "organization", org)
+	if err != nil {
+		return usage, QuotaUsageError{org, []byte(err.Error())}
+	}
+
+	spaceGUID, err := c.guidByName("/v3/spaces?names="+url.QueryEscape(space)+"&organization_guids="+orgGUID, "space", space)
+	if err != nil {
+		return usage, QuotaUsageError{space, []byte(err.Error())}
+	}
+
+	usage.Org, err = readQuotaUsage(c.get, "/v3/organizations/"+orgGUID, "/v3/organization_quotas/")
+	if err != nil {
+		return usage, QuotaUsageError{org, []byte(err.Error())}
+	}
+
+	usage.Space, err = readQuotaUsage(c.get, "/v3/spaces/"+spaceGUID, "/v3/space_quotas/")
+	if err != nil {
+		return usage, QuotaUsageError{space, []byte(err.Error())}
+	}
+
+	return usage, nil
+}
+
+// CrashCount returns the number of times the instances of an application crashed since the given time,
+// from the crash audit events.
+func (c APICourier) CrashCount(appName string, since time.Time) (int, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return 0, AppStateError{appName, []byte(err.Error())}
+	}
+
+	crashes, err := readCrashCount(c.get, guid, since)
+	if err != nil {
+		return 0, AppStateError{appName, []byte(err.Error())}
+	}
+
+	return crashes, nil
+}
+
+// CleanUp does nothing: the APICourier keeps no files.
+func (c APICourier) CleanUp() error {
+	return nil
+}
+
+// WithContext returns an APICourier whose requests are cancelled once ctx is done.
+func (c APICourier) WithContext(ctx context.Context) I.Courier {
+	c.ctx = ctx
+	return c
+}
+
+// WithCABundle returns an APICourier that trusts the certificate authorities in the file at path.
+func (c APICourier) WithCABundle(path string) I.Courier {
+	c.caBundle = path
+	return c
+}
+
+// WithLogCache returns an APICourier that reads the logs of applications from the Log Cache API as the descriptor
+// says.
+func (c APICourier) WithLogCache(descriptor S.LogCacheDescriptor) I.Courier {
+	c.logCache = descriptor
+	return c
+}
+
+type resources struct {
+	Resources []struct {
+		GUID string `json:"guid"`
+	} `json:"resources"`
+}
+
+// guidByName returns the guid of the first resource the path lists, or a NotFoundError naming the kind and name of
+// the resource.
+func (c APICourier) guidByName(path, kind, name string) (string, error) {
+	var list resources
+	err := c.get(path, &list)
+	if err != nil {
+		return "", err
+	}
+	if len(list.Resources) == 0 {
+		return "", NotFoundError{kind, name}
+	}
+
+	return list.Resources[0].GUID, nil
+}
+
+// space returns the guid of the targeted space.
+func (c APICourier) space() (string, error) {
+	c.session.Lock()
+	defer c.session.Unlock()
+
+	if c.session.spaceGUID == "" {
+		return "", NoSpaceTargetedError{}
+	}
+	return c.session.spaceGUID, nil
+}
+
+// appGUID returns the guid of the application in the targeted space.
+func (c APICourier) appGUID(appName string) (string, error) {
+	spaceGUID, err := c.space()
+	if err != nil {
+		return "", err
+	}
+
+	return c.guidByName("/v3/apps?names="+url.QueryEscape(appName)+"&space_guids="+spaceGUID, "application", appName)
+}
+
+// serviceGUID returns the guid of the service instance in the targeted space.
+func (c APICourier) serviceGUID(serviceName string) (string, error) {
+	spaceGUID, err := c.space()
+	if err != nil {
+		return "", err
+	}
+
+	return c.guidByName("/v3/service_instances?names="+url.QueryEscape(serviceName)+"&space_guids="+spaceGUID, "service", serviceName)
+}
+
+// routeGUID returns the guid of the route of the hostname and path on the domain in the targeted space.
+func (c APICourier) routeGUID(domain, hostname, path string) (string, error) {
+	spaceGUID, err := c.space()
+	if err != nil {
+		return "", err
+	}
+	domainGUID, err := c.guidByName("/v3/domains?names="+url.QueryEscape(domain), "domain", domain)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("domain_guids", domainGUID)
+	query.Set("space_guids", spaceGUID)
+	query.Set("hosts", hostname)
+	query.Set("paths", path)
+
+	return c.guidByName("/v3/routes?"+query.Encode(), "route", routeURL(domain, hostname, path))
+}
+
+// createRoute creates the route of the hostname and path on the domain in the targeted space.
+func (c APICourier) createRoute(domain, hostname, path string) (string, error) {
+	spaceGUID, err := c.space()
+	if err != nil {
+		return "", err
+	}
+	domainGUID, err := c.guidByName("/v3/domains?names="+url.QueryEscape(domain), "domain", domain)
+	if err != nil {
+		return "", err
+	}
+
+	var route struct {
+		GUID string `json:"guid"`
+	}
+	err = c.do("POST", "/v3/routes", map[string]interface{}{
+		"host": hostname,
+		"path": path,
+		"relationships": map[string]interface{}{
+			"space":  relationship(spaceGUID),
+			"domain": relationship(domainGUID),
+		},
+	}, &route)
+	return route.GUID, err
+}
+
+// get reads a path of the v3 API and decodes the JSON response into v.
+func (c APICourier) get(path string, v interface{}) error {
+	return c.do("GET", path, nil, v)
+}
+
+// do sends a request with the body encoded as JSON to the Cloud Controller and decodes the JSON response into v.
+func (c APICourier) do(method, path string, body, v interface{}) error {
+	_, err := c.send(method, path, jsonBody(body), v)
+	return err
+}
+
+// doJob sends a request like do, and waits for the job of the request to be complete when it is asynchronous.
+func (c APICourier) doJob(method, path string, body, v interface{}) error {
+	job, err := c.send(method, path, jsonBody(body), v)
+	if err != nil || job == "" {
+		return err
+	}
+
+	return c.waitForJob(job)
+}
+
+// requestBody opens the body of a request, and returns its content type. It is opened again when the request is
+// sent again.
+type requestBody func() (io.ReadCloser, string, error)
+
+func jsonBody(body interface{}) requestBody {
+	if body == nil {
+		return nil
+	}
+
+	return func() (io.ReadCloser, string, error) {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, "", err
+		}
+		return ioutil.NopCloser(bytes.NewReader(b)), "application/json", nil
+	}
+}
+
+// send sends a request to the Cloud Controller with the access token of the session. The request is sent again
+// with a refreshed token once when the token expired. It returns the path of the job of an asynchronous request.
+func (c APICourier) send(method, path string, body requestBody, v interface{}) (job string, err error) {
+	_, span := tracing.Start(c.ctx, "cf api "+method, tracing.String("http.method", method), tracing.String("http.path", strings.SplitN(path, "?", 2)[0]))
+	defer func() {
+		span.SetError(err)
+		span.Finish()
+	}()
+
+	job, err = c.sendOnce(method, path, body, v)
+	if apiErr, ok := err.(APIError); ok && apiErr.StatusCode == http.StatusUnauthorized {
+		err = c.refresh()
+		if err != nil {
+			return "", err
+		}
+		job, err = c.sendOnce(method, path, body, v)
+	}
+
+	return job, err
+}
+
+func (c APICourier) sendOnce(method, path string, body requestBody, v interface{}) (string, error) {
+	c.session.Lock()
+	apiURL, token := c.session.apiURL, c.session.accessToken
+	c.session.Unlock()
+
+	if apiURL == "" {
+		return "", NotLoggedInError{}
+	}
+
+	var reader io.Reader
+	contentType := ""
+	timeout := apiTimeout
+	if body != nil {
+		readCloser, t, err := body()
+		if err != nil {
+			return "", err
+		}
+		defer readCloser.Close()
+		reader, contentType = readCloser, t
+		if strings.HasPrefix(contentType, "multipart/") {
+			timeout = uploadTimeout
+		}
+	}
+
+	request, err := http.NewRequest(method, apiURL+path, reader)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		request.Header.Set("Authorization", token)
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	request.Header.Set("Accept", "application/json")
+
+	var location string
+	err = c.roundTrip(request, timeout, v, &location)
+	if err != nil {
+		if apiErr, ok := err.(APIError); ok {
+			apiErr.Method, apiErr.Path = method, path
+			return "", apiErr
+		}
+		return "", err
+	}
+
+	if location == "" {
+		return "", nil
+	}
+	job, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return job.RequestURI(), nil
+}
+
+// roundTrip sends the request with the context, certificate authorities and certificate verification of the
+// APICourier, and decodes the JSON response into v. The location of an accepted request is kept in location.
+// The errors reported by the API are returned as an APIError.
+func (c APICourier) roundTrip(request *http.Request, timeout time.Duration, v interface{}, location *string) error {
+	if c.ctx != nil {
+		request = request.WithContext(c.ctx)
+	}
+
+	tlsConfig, err := cabundle.TLSConfig(&afero.Afero{Fs: afero.NewOsFs()}, c.caBundle)
+	if err != nil {
+		return err
+	}
+	c.session.Lock()
+	skipSSL := c.session.skipSSL
+	c.session.Unlock()
+
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig}
+	if skipSSL {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+	client := &http.Client{Timeout: timeout, Transport: transport}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	b, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return newAPIError(request.Method, request.URL.Path, response.StatusCode, b)
+	}
+
+	if location != nil && response.StatusCode == http.StatusAccepted {
+		*location = response.Header.Get("Location")
+	}
+	if v == nil || len(bytes.TrimSpace(b)) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// waitForJob polls the job until it is complete, and returns the errors of a failed job.
+func (c APICourier) waitForJob(job string) error {
+	for {
+		var status struct {
+			State  string     `json:"state"`
+			Errors []apiError `json:"errors"`
+		}
+		err := c.get(job, &status)
+		if err != nil {
+			return err
+		}
+
+		switch status.State {
+		case "COMPLETE":
+			return nil
+		case "FAILED":
+			return JobError{job, apiErrorDetail(status.Errors)}
+		}
+
+		err = c.sleep()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sleep waits for the poll interval, or returns the error of the context once it is done.
+func (c APICourier) sleep() error {
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = DefaultAPIPollInterval
+	}
+
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(interval):
+		return nil
+	}
+}
+
+type apiError struct {
+	Code   int    `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+func newAPIError(method, path string, statusCode int, body []byte) APIError {
+	var response struct {
+		Errors []apiError `json:"errors"`
+		// UAA reports a single error.
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	detail := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &response) == nil {
+		if len(response.Errors) > 0 {
+			detail = apiErrorDetail(response.Errors)
+		} else if response.ErrorDescription != "" {
+			detail = response.ErrorDescription
+		} else if response.Error != "" {
+			detail = response.Error
+		}
+	}
+
+	return APIError{Method: method, Path: path, StatusCode: statusCode, Detail: detail}
+}
+
+func apiErrorDetail(errors []apiError) string {
+	details := []string{}
+	for _, e := range errors {
+		details = append(details, e.Detail)
+	}
+	return strings.Join(details, "; ")
+}
+
+func relationship(guid string) map[string]interface{} {
+	return map[string]interface{}{"data": map[string]string{"guid": guid}}
+}
+
+// credentials parses the credentials of a user provided service, which must be a JSON object.
+func credentials(body string) (map[string]interface{}, error) {
+	credentials := map[string]interface{}{}
+	err := json.Unmarshal([]byte(body), &credentials)
+	if err != nil {
+		return nil, InvalidCredentialsError{err}
+	}
+	return credentials, nil
+}
+
+func routeURL(domain, hostname, path string) string {
+	host := domain
+	if hostname != "" {
+		host = hostname + "." + domain
+	}
+	return host + path
+}
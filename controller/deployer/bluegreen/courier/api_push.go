@@ -0,0 +1,416 @@
+package courier
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+)
+
+// ignoredFiles are left out of the bits of an application, like the Cloud Foundry CLI leaves them out.
+var ignoredFiles = map[string]bool{
+	".cfignore":  true,
+	".gitignore": true,
+	".git":       true,
+	".hg":        true,
+	".svn":       true,
+	"_darcs":     true,
+	".DS_Store":  true,
+}
+
+// Push applies the manifest in the directory of the application, with the name, instances and route of the push,
+// uploads the directory, stages it and starts the application with the new droplet. It waits until an instance of
+// the application is running, like the push command of the Cloud Foundry CLI.
+//
+// The route of the push is the hostname on the default domain of the org, unless the manifest says no-route.
+func (c APICourier) Push(appName, appLocation, hostname string, instances uint16) ([]byte, error) {
+	output := []byte(fmt.Sprintf("pushing %s with %d instances\n", appName, instances))
+
+	spaceGUID, err := c.space()
+	if err != nil {
+		return append(output, err.Error()...), err
+	}
+
+	manifest, bitsPath, dockerImage, err := c.pushManifest(appName, appLocation, hostname, instances)
+	if err != nil {
+		return append(output, err.Error()...), err
+	}
+
+	job, err := c.send("POST", "/v3/spaces/"+spaceGUID+"/actions/apply_manifest", yamlBody(manifest), nil)
+	if err == nil && job != "" {
+		err = c.waitForJob(job)
+	}
+	if err != nil {
+		return append(output, err.Error()...), err
+	}
+
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return append(output, err.Error()...), err
+	}
+
+	packageGUID, err := c.createPackage(guid, bitsPath, dockerImage)
+	if err != nil {
+		return append(output, err.Error()...), err
+	}
+	output = append(output, "uploaded the application\n"...)
+
+	return c.stageAndStart(appName, guid, packageGUID, output)
+}
+
+// pushManifest returns the manifest in the directory of the application with the name, instances and route of the
+// push, the directory whose bits are uploaded, and the docker image of a docker application.
+func (c APICourier) pushManifest(appName, appLocation, hostname string, instances uint16) ([]byte, string, string, error) {
+	m := map[interface{}]interface{}{}
+	content, err := ioutil.ReadFile(filepath.Join(appLocation, "manifest.yml"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, "", "", err
+	}
+	if err == nil {
+		err = candiedyaml.Unmarshal(content, &m)
+		if err != nil {
+			return nil, "", "", ManifestError{err}
+		}
+	}
+
+	application := map[interface{}]interface{}{}
+	if applications, _ := m["applications"].([]interface{}); len(applications) > 0 {
+		a, ok := applications[0].(map[interface{}]interface{})
+		if !ok {
+			return nil, "", "", ManifestError{fmt.Errorf("the application of the manifest is not a map")}
+		}
+		application = a
+	}
+
+	bitsPath := appLocation
+	if path, ok := application["path"].(string); ok && path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(appLocation, path)
+		}
+		bitsPath = path
+	}
+
+	dockerImage := ""
+	if docker, ok := application["docker"].(map[interface{}]interface{}); ok {
+		dockerImage, _ = docker["image"].(string)
+	}
+
+	for _, key := range []string{"path", "host", "hosts", "domain", "domains", "no-hostname", "random-route", "routes"} {
+		delete(application, key)
+	}
+	application["name"] = appName
+	application["instances"] = instances
+
+	if noRoute, _ := application["no-route"].(bool); !noRoute {
+		domain, err := c.defaultDomain()
+		if err != nil {
+			return nil, "", "", err
+		}
+		application["routes"] = []interface{}{map[interface{}]interface{}{"route": routeURL(domain, hostname, "")}}
+	}
+
+	manifest, err := candiedyaml.Marshal(map[interface{}]interface{}{"applications": []interface{}{application}})
+	if err != nil {
+		return nil, "", "", ManifestError{err}
+	}
+
+	return manifest, bitsPath, dockerImage, nil
+}
+
+// defaultDomain returns the name of the default domain of the targeted org.
+func (c APICourier) defaultDomain() (string, error) {
+	c.session.Lock()
+	orgGUID := c.session.orgGUID
+	c.session.Unlock()
+
+	var domain struct {
+		Name string `json:"name"`
+	}
+	err := c.get("/v3/organizations/"+orgGUID+"/domains/default", &domain)
+	return domain.Name, err
+}
+
+// createPackage creates a package of the application with the docker image, or with the bits of the directory, and
+// waits until it is ready.
+func (c APICourier) createPackage(appGUID, bitsPath, dockerImage string) (string, error) {
+	body := map[string]interface{}{
+		"type":          "bits",
+		"relationships": map[string]interface{}{"app": relationship(appGUID)},
+	}
+	if dockerImage != "" {
+		body["type"] = "docker"
+		data := map[string]string{"image": dockerImage}
+		if password := os.Getenv("CF_DOCKER_PASSWORD"); password != "" {
+			data["password"] = password
+		}
+		body["data"] = data
+	}
+
+	var p struct {
+		GUID string `json:"guid"`
+	}
+	err := c.do("POST", "/v3/packages", body, &p)
+	if err != nil {
+		return "", err
+	}
+
+	if dockerImage == "" {
+		bits, err := zipDirectory(bitsPath)
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(bits)
+
+		_, err = c.send("POST", "/v3/packages/"+p.GUID+"/upload", multipartBody(bits), nil)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for {
+		var status struct {
+			State string `json:"state"`
+		}
+		err = c.get("/v3/packages/"+p.GUID, &status)
+		if err != nil {
+			return "", err
+		}
+
+		switch status.State {
+		case "READY":
+			return p.GUID, nil
+		case "FAILED", "EXPIRED":
+			return "", PackageError{p.GUID, status.State}
+		}
+
+		err = c.sleep()
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// stageAndStart stages the package, starts the application with the new droplet, or restarts it when it is
+// started, and waits until an instance of it is running.
+func (c APICourier) stageAndStart(appName, guid, packageGUID string, output []byte) ([]byte, error) {
+	var build struct {
+		GUID string `json:"guid"`
+	}
+	err := c.do("POST", "/v3/builds", map[string]interface{}{"package": map[string]string{"guid": packageGUID}}, &build)
+	if err != nil {
+		return append(output, err.Error()...), err
+	}
+
+	deadline := time.Now().Add(DefaultAPIStagingTimeout)
+	var staged struct {
+		State   string `json:"state"`
+		Error   string `json:"error"`
+		Droplet *struct {
+			GUID string `json:"guid"`
+		} `json:"droplet"`
+	}
+	for {
+		err = c.get("/v3/builds/"+build.GUID, &staged)
+		if err != nil {
+			return append(output, err.Error()...), err
+		}
+		if staged.State == "STAGED" && staged.Droplet != nil {
+			break
+		}
+		if staged.State == "FAILED" {
+			err = StagingError{appName, staged.Error}
+			return append(output, err.Error()...), err
+		}
+		if time.Now().After(deadline) {
+			err = StagingError{appName, fmt.Sprintf("not staged after %s", DefaultAPIStagingTimeout)}
+			return append(output, err.Error()...), err
+		}
+
+		err = c.sleep()
+		if err != nil {
+			return append(output, err.Error()...), err
+		}
+	}
+	output = append(output, "staged the application\n"...)
+
+	err = c.do("PATCH", "/v3/apps/"+guid+"/relationships/current_droplet", relationship(staged.Droplet.GUID), nil)
+	if err != nil {
+		return append(output, err.Error()...), err
+	}
+
+	var app struct {
+		State string `json:"state"`
+	}
+	err = c.get("/v3/apps/"+guid, &app)
+	if err != nil {
+		return append(output, err.Error()...), err
+	}
+	action := "start"
+	if app.State == "STARTED" {
+		action = "restart"
+	}
+	err = c.do("POST", "/v3/apps/"+guid+"/actions/"+action, nil, nil)
+	if err != nil {
+		return append(output, err.Error()...), err
+	}
+
+	err = c.waitForInstances(appName, guid)
+	if err != nil {
+		return append(output, err.Error()...), err
+	}
+
+	return append(output, fmt.Sprintf("started %s\n", appName)...), nil
+}
+
+// waitForInstances waits until an instance of the web process of the application is running. It fails once every
+// instance crashed, or when none is running in time.
+func (c APICourier) waitForInstances(appName, guid string) error {
+	deadline := time.Now().Add(DefaultAPIStartTimeout)
+	for {
+		instances, err := readInstances(c.get, guid)
+		if err != nil {
+			return err
+		}
+		if len(instances) == 0 {
+			return nil
+		}
+
+		crashed := 0
+		for _, instance := range instances {
+			switch instance.State {
+			case "RUNNING":
+				return nil
+			case "CRASHED":
+				crashed++
+			}
+		}
+		if crashed == len(instances) {
+			return StartError{appName, "every instance crashed"}
+		}
+		if time.Now().After(deadline) {
+			return StartError{appName, fmt.Sprintf("no instance running after %s", DefaultAPIStartTimeout)}
+		}
+
+		err = c.sleep()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func yamlBody(manifest []byte) requestBody {
+	return func() (io.ReadCloser, string, error) {
+		return ioutil.NopCloser(strings.NewReader(string(manifest))), "application/x-yaml", nil
+	}
+}
+
+// multipartBody streams the zip file as the bits of a package.
+func multipartBody(path string) requestBody {
+	return func() (io.ReadCloser, string, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, "", err
+		}
+
+		reader, writer := io.Pipe()
+		form := multipart.NewWriter(writer)
+		go func() {
+			defer file.Close()
+
+			part, err := form.CreateFormFile("bits", "application.zip")
+			if err == nil {
+				_, err = io.Copy(part, file)
+			}
+			if err == nil {
+				err = form.Close()
+			}
+			writer.CloseWithError(err)
+		}()
+
+		return reader, form.FormDataContentType(), nil
+	}
+}
+
+// zipDirectory zips the files of the directory into a temporary file, without the files the Cloud Foundry CLI
+// leaves out. Symbolic links are kept as links.
+//
+// Returns the path of the zip file, which is removed by the caller.
+func zipDirectory(directory string) (string, error) {
+	file, err := ioutil.TempFile("", "deployadactyl-bits-")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	err = filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == directory {
+			return nil
+		}
+
+		relative, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		if ignoredFiles[info.Name()] || relative == "manifest.yml" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relative)
+		if info.IsDir() {
+			header.Name += "/"
+			_, err = archive.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			_, err = writer.Write([]byte(filepath.ToSlash(target)))
+			return err
+		}
+
+		content, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+
+		_, err = io.Copy(writer, content)
+		return err
+	})
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
@@ -0,0 +1,174 @@
+package courier_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/compozed/deployadactyl/conformance"
+	. "github.com/compozed/deployadactyl/controller/deployer/bluegreen/courier"
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("APICourier", func() {
+	var (
+		cc      *fakeCloudController
+		appPath string
+		courier APICourier
+	)
+
+	BeforeEach(func() {
+		cc = newFakeCloudController()
+
+		var err error
+		appPath, err = ioutil.TempDir("", "api-courier-test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(appPath, "index.html"), []byte("hello"), 0644)).To(Succeed())
+		Expect(os.Mkdir(filepath.Join(appPath, ".git"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(appPath, ".git", "HEAD"), []byte("ref"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(appPath, "manifest.yml"), []byte(`---
+applications:
+- name: from-the-manifest
+  memory: 64M
+  host: from-the-manifest
+  instances: 3
+`), 0644)).To(Succeed())
+
+		courier = NewAPICourier()
+		courier.PollInterval = time.Millisecond
+	})
+
+	AfterEach(func() {
+		cc.Close()
+		os.RemoveAll(appPath)
+	})
+
+	login := func() {
+		output, err := courier.Login(cc.URL, "username", "password", "org", "space", false)
+		Expect(err).ToNot(HaveOccurred(), string(output))
+	}
+
+	It("satisfies the Courier contract", func() {
+		conformance.Courier(GinkgoT(), conformance.CourierSubject{
+			NewCourier: func() I.Courier {
+				courier := NewAPICourier()
+				courier.PollInterval = time.Millisecond
+				return courier
+			},
+			FoundationURL: cc.URL,
+			Username:      "username",
+			Password:      "password",
+			Org:           "org",
+			Space:         "space",
+			Domain:        "apps.example.com",
+			AppPath:       appPath,
+		})
+	})
+
+	Describe("pushing an application", func() {
+		BeforeEach(login)
+
+		It("applies the manifest with the name, instances and route of the push", func() {
+			output, err := courier.Push("app-new-build", appPath, "app-new-build", 2)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring("started app-new-build"))
+
+			app, found := cc.App("app-new-build")
+			Expect(found).To(BeTrue())
+			Expect(app.State).To(Equal("STARTED"))
+			Expect(app.Instances).To(Equal(2))
+			Expect(app.Droplet).ToNot(BeEmpty())
+			Expect(app.Manifest["memory"]).To(Equal("64M"))
+			Expect(app.Manifest).ToNot(HaveKey("host"))
+			Expect(courier.AppRoutes("app-new-build")).To(Equal([]string{"app-new-build.apps.example.com"}))
+		})
+
+		It("uploads the directory of the application without the files the CLI leaves out", func() {
+			_, err := courier.Push("app", appPath, "app", 1)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cc.Bits("app")).To(Equal([]string{"index.html"}))
+		})
+
+		It("returns an error when the application cannot be staged", func() {
+			cc.FailStaging = "NoAppDetectedError"
+
+			output, err := courier.Push("app", appPath, "app", 1)
+
+			Expect(err).To(MatchError(StagingError{"app", "NoAppDetectedError"}))
+			Expect(string(output)).To(ContainSubstring("NoAppDetectedError"))
+		})
+	})
+
+	It("refreshes an expired access token and sends the request again", func() {
+		login()
+		cc.ExpireToken = true
+
+		Expect(courier.Domains()).To(Equal([]string{"apps.example.com"}))
+
+		tokens := 0
+		for _, request := range cc.Requests() {
+			if request == "POST /oauth/token" {
+				tokens++
+			}
+		}
+		Expect(tokens).To(Equal(2))
+	})
+
+	It("returns the errors of the Cloud Controller", func() {
+		login()
+		_, err := courier.Push("app", appPath, "app", 1)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = courier.Push("other-app", appPath, "other-app", 1)
+		Expect(err).ToNot(HaveOccurred())
+
+		output, err := courier.Rename("app", "other-app")
+
+		Expect(err).To(MatchError(ContainSubstring("App with the name 'other-app' already exists.")))
+		Expect(err.(APIError).StatusCode).To(Equal(422))
+		Expect(string(output)).To(Equal(err.Error()))
+	})
+
+	It("does not log in with the wrong password", func() {
+		_, err := courier.Login(cc.URL, "username", "wrong", "org", "space", false)
+
+		Expect(err).To(MatchError(ContainSubstring("Bad credentials")))
+		Expect(err).To(BeAssignableToTypeOf(AuthenticationError{}))
+	})
+
+	It("does not log in to a space that does not exist", func() {
+		_, err := courier.Login(cc.URL, "username", "password", "org", "missing", false)
+
+		Expect(err).To(MatchError(NotFoundError{"space", "missing"}))
+	})
+
+	It("reads the applications of every space after authenticating", func() {
+		_, err := courier.Authenticate(cc.URL, "username", "password", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(courier.Apps()).To(BeEmpty())
+		Expect(courier.Exists("app")).To(BeFalse())
+		_, err = courier.Push("app", appPath, "app", 1)
+		Expect(err).To(MatchError(NoSpaceTargetedError{}))
+	})
+
+	It("does not create a user provided service with credentials that are not JSON", func() {
+		login()
+
+		_, err := courier.Cups("credentials", "password")
+
+		Expect(err).To(BeAssignableToTypeOf(InvalidCredentialsError{}))
+	})
+
+	It("does not send requests before logging in", func() {
+		Expect(courier.Exists("app")).To(BeFalse())
+		_, err := courier.Apps()
+
+		Expect(err).To(MatchError(AppsError{[]byte(NotLoggedInError{}.Error())}))
+		Expect(cc.Requests()).To(BeEmpty())
+	})
+})
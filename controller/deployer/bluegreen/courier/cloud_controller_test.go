@@ -0,0 +1,463 @@
+package courier_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+)
+
+type fakeApp struct {
+	GUID      string
+	Name      string
+	State     string
+	Instances int
+	Labels    map[string]string
+	Manifest  map[interface{}]interface{}
+	Droplet   string
+}
+
+type fakeRoute struct {
+	GUID   string
+	Host   string
+	Path   string
+	Domain string
+	Apps   []string
+}
+
+// fakeCloudController is a Cloud Controller and UAA with a single org, space and domain, keeping its apps and routes
+// in memory. Packages are staged as soon as they are uploaded, and jobs are complete as soon as they are created.
+type fakeCloudController struct {
+	sync.Mutex
+	*httptest.Server
+
+	apps     map[string]*fakeApp
+	routes   map[string]*fakeRoute
+	packages map[string]string
+	bits     map[string][]string
+	builds   map[string]string
+	requests []string
+	tokens   int
+	guids    int
+
+	// FailStaging fails the builds with the error.
+	FailStaging string
+	// ExpireToken rejects the access token of the next request to the Cloud Controller once.
+	ExpireToken bool
+}
+
+func newFakeCloudController() *fakeCloudController {
+	cc := &fakeCloudController{
+		apps:     map[string]*fakeApp{},
+		routes:   map[string]*fakeRoute{},
+		packages: map[string]string{},
+		bits:     map[string][]string{},
+		builds:   map[string]string{},
+	}
+	cc.Server = httptest.NewServer(http.HandlerFunc(cc.serve))
+	return cc
+}
+
+func (cc *fakeCloudController) App(name string) (fakeApp, bool) {
+	cc.Lock()
+	defer cc.Unlock()
+
+	for _, app := range cc.apps {
+		if app.Name == name {
+			return *app, true
+		}
+	}
+	return fakeApp{}, false
+}
+
+func (cc *fakeCloudController) Bits(name string) []string {
+	app, _ := cc.App(name)
+
+	cc.Lock()
+	defer cc.Unlock()
+	return cc.bits[app.GUID]
+}
+
+func (cc *fakeCloudController) Requests() []string {
+	cc.Lock()
+	defer cc.Unlock()
+	return append([]string{}, cc.requests...)
+}
+
+func (cc *fakeCloudController) guid(kind string) string {
+	cc.guids++
+	return fmt.Sprintf("%s-%d", kind, cc.guids)
+}
+
+func (cc *fakeCloudController) serve(w http.ResponseWriter, r *http.Request) {
+	cc.Lock()
+	defer cc.Unlock()
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+	body, _ := ioutil.ReadAll(r.Body)
+	cc.requests = append(cc.requests, r.Method+" "+r.URL.Path)
+
+	write := func(status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	fail := func(status int, detail string) {
+		write(status, map[string]interface{}{"errors": []interface{}{map[string]interface{}{"detail": detail}}})
+	}
+	job := func() {
+		w.Header().Set("Location", cc.URL+"/v3/jobs/"+cc.guid("job"))
+		w.WriteHeader(http.StatusAccepted)
+	}
+	list := func(resources []interface{}) {
+		write(http.StatusOK, map[string]interface{}{"pagination": map[string]interface{}{"total_results": len(resources)}, "resources": resources})
+	}
+
+	if r.URL.Path == "/" {
+		write(http.StatusOK, map[string]interface{}{"links": map[string]interface{}{"uaa": map[string]string{"href": cc.URL}}})
+		return
+	}
+	if r.URL.Path == "/oauth/token" {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		r.ParseForm()
+		if username, _, _ := r.BasicAuth(); username != "cf" {
+			write(http.StatusUnauthorized, map[string]string{"error": "unauthorized", "error_description": "bad client"})
+			return
+		}
+		if r.Form.Get("grant_type") == "password" && r.Form.Get("password") != "password" {
+			write(http.StatusUnauthorized, map[string]string{"error": "unauthorized", "error_description": "Bad credentials"})
+			return
+		}
+		cc.tokens++
+		write(http.StatusOK, map[string]string{"access_token": fmt.Sprintf("token-%d", cc.tokens), "refresh_token": "refresh", "token_type": "bearer"})
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "bearer token-") || cc.ExpireToken {
+		cc.ExpireToken = false
+		fail(http.StatusUnauthorized, "Invalid Auth Token")
+		return
+	}
+
+	switch {
+	case match(path, "v3", "organizations") && r.Method == "GET":
+		if query.Get("names") == "org" {
+			list([]interface{}{map[string]string{"guid": "org-guid", "name": "org"}})
+			return
+		}
+		list(nil)
+	case match(path, "v3", "organizations", "org-guid", "domains", "default"):
+		write(http.StatusOK, map[string]string{"guid": "domain-guid", "name": "apps.example.com"})
+	case match(path, "v3", "spaces") && r.Method == "GET":
+		if query.Get("names") == "space" && query.Get("organization_guids") == "org-guid" {
+			list([]interface{}{map[string]string{"guid": "space-guid", "name": "space"}})
+			return
+		}
+		list(nil)
+	case match(path, "v3", "domains") && r.Method == "GET":
+		if query.Get("names") == "" || query.Get("names") == "apps.example.com" {
+			list([]interface{}{map[string]string{"guid": "domain-guid", "name": "apps.example.com"}})
+			return
+		}
+		list(nil)
+	case match(path, "v3", "jobs", "*"):
+		write(http.StatusOK, map[string]string{"state": "COMPLETE"})
+
+	case match(path, "v3", "spaces", "space-guid", "actions", "apply_manifest"):
+		m := map[interface{}]interface{}{}
+		if err := candiedyaml.Unmarshal(body, &m); err != nil || r.Header.Get("Content-Type") != "application/x-yaml" {
+			fail(http.StatusBadRequest, "invalid manifest")
+			return
+		}
+		application := m["applications"].([]interface{})[0].(map[interface{}]interface{})
+		app := cc.appByName(application["name"].(string))
+		if app == nil {
+			app = &fakeApp{GUID: cc.guid("app"), Name: application["name"].(string), State: "STOPPED"}
+			cc.apps[app.GUID] = app
+		}
+		app.Manifest = application
+		switch instances := application["instances"].(type) {
+		case int64:
+			app.Instances = int(instances)
+		case int:
+			app.Instances = instances
+		}
+		routes, _ := application["routes"].([]interface{})
+		for _, route := range routes {
+			url := route.(map[interface{}]interface{})["route"].(string)
+			host := strings.TrimSuffix(url, ".apps.example.com")
+			cc.mapRoute(cc.findOrCreateRoute(host, ""), app.GUID)
+		}
+		job()
+
+	case match(path, "v3", "apps") && r.Method == "GET":
+		resources := []interface{}{}
+		for _, app := range cc.sortedApps() {
+			if query.Get("names") == "" || query.Get("names") == app.Name {
+				resources = append(resources, cc.appResource(app))
+			}
+		}
+		list(resources)
+	case match(path, "v3", "apps", "*"):
+		app, ok := cc.apps[path[2]]
+		if !ok {
+			fail(http.StatusNotFound, "App not found")
+			return
+		}
+		switch r.Method {
+		case "GET":
+			write(http.StatusOK, cc.appResource(app))
+		case "PATCH":
+			var update struct {
+				Name     string `json:"name"`
+				Metadata struct {
+					Labels map[string]string `json:"labels"`
+				} `json:"metadata"`
+			}
+			json.Unmarshal(body, &update)
+			if update.Name != "" {
+				if cc.appByName(update.Name) != nil {
+					fail(http.StatusUnprocessableEntity, "App with the name '"+update.Name+"' already exists.")
+					return
+				}
+				app.Name = update.Name
+			}
+			if update.Metadata.Labels != nil {
+				app.Labels = update.Metadata.Labels
+			}
+			write(http.StatusOK, cc.appResource(app))
+		case "DELETE":
+			delete(cc.apps, app.GUID)
+			for _, route := range cc.routes {
+				route.Apps = without(route.Apps, app.GUID)
+			}
+			job()
+		}
+	case match(path, "v3", "apps", "*", "actions", "*"):
+		app := cc.apps[path[2]]
+		if path[4] == "stop" {
+			app.State = "STOPPED"
+		} else {
+			if app.Droplet == "" {
+				fail(http.StatusUnprocessableEntity, "Assign a droplet before starting this app.")
+				return
+			}
+			app.State = "STARTED"
+		}
+		write(http.StatusOK, cc.appResource(app))
+	case match(path, "v3", "apps", "*", "processes", "web"):
+		write(http.StatusOK, map[string]interface{}{"instances": cc.apps[path[2]].Instances})
+	case match(path, "v3", "apps", "*", "processes", "web", "actions", "scale"):
+		var scale struct {
+			Instances int `json:"instances"`
+		}
+		json.Unmarshal(body, &scale)
+		cc.apps[path[2]].Instances = scale.Instances
+		write(http.StatusAccepted, map[string]interface{}{"instances": scale.Instances})
+	case match(path, "v3", "apps", "*", "processes", "web", "stats"):
+		app := cc.apps[path[2]]
+		stats := []interface{}{}
+		for i := 0; i < app.Instances; i++ {
+			state := "DOWN"
+			if app.State == "STARTED" {
+				state = "RUNNING"
+			}
+			stats = append(stats, map[string]interface{}{"index": i, "state": state, "uptime": 10})
+		}
+		write(http.StatusOK, map[string]interface{}{"resources": stats})
+	case match(path, "v3", "apps", "*", "relationships", "current_droplet"):
+		var droplet struct {
+			Data struct {
+				GUID string `json:"guid"`
+			} `json:"data"`
+		}
+		json.Unmarshal(body, &droplet)
+		cc.apps[path[2]].Droplet = droplet.Data.GUID
+		write(http.StatusOK, droplet)
+	case match(path, "v3", "apps", "*", "routes"):
+		resources := []interface{}{}
+		for _, route := range cc.routes {
+			if contains(route.Apps, path[2]) {
+				resources = append(resources, map[string]string{"guid": route.GUID, "url": route.Host + "." + route.Domain + route.Path})
+			}
+		}
+		list(resources)
+
+	case match(path, "v3", "packages") && r.Method == "POST":
+		var p struct {
+			Relationships struct {
+				App struct {
+					Data struct {
+						GUID string `json:"guid"`
+					} `json:"data"`
+				} `json:"app"`
+			} `json:"relationships"`
+		}
+		json.Unmarshal(body, &p)
+		guid := cc.guid("package")
+		cc.packages[guid] = p.Relationships.App.Data.GUID
+		write(http.StatusCreated, map[string]string{"guid": guid, "state": "AWAITING_UPLOAD"})
+	case match(path, "v3", "packages", "*", "upload"):
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		file, _, err := r.FormFile("bits")
+		if err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		bits, _ := ioutil.ReadAll(file)
+		archive, err := zip.NewReader(bytes.NewReader(bits), int64(len(bits)))
+		if err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		names := []string{}
+		for _, f := range archive.File {
+			names = append(names, f.Name)
+		}
+		cc.bits[cc.packages[path[2]]] = names
+		write(http.StatusOK, map[string]string{"guid": path[2], "state": "PROCESSING_UPLOAD"})
+	case match(path, "v3", "packages", "*"):
+		write(http.StatusOK, map[string]string{"guid": path[2], "state": "READY"})
+	case match(path, "v3", "builds") && r.Method == "POST":
+		guid := cc.guid("build")
+		cc.builds[guid] = cc.guid("droplet")
+		write(http.StatusCreated, map[string]string{"guid": guid, "state": "STAGING"})
+	case match(path, "v3", "builds", "*"):
+		if cc.FailStaging != "" {
+			write(http.StatusOK, map[string]interface{}{"guid": path[2], "state": "FAILED", "error": cc.FailStaging})
+			return
+		}
+		write(http.StatusOK, map[string]interface{}{"guid": path[2], "state": "STAGED", "droplet": map[string]string{"guid": cc.builds[path[2]]}})
+
+	case match(path, "v3", "routes") && r.Method == "GET":
+		resources := []interface{}{}
+		for _, route := range cc.routes {
+			if route.Host == query.Get("hosts") && route.Path == query.Get("paths") && query.Get("domain_guids") == "domain-guid" {
+				resources = append(resources, map[string]string{"guid": route.GUID})
+			}
+		}
+		list(resources)
+	case match(path, "v3", "routes") && r.Method == "POST":
+		var route struct {
+			Host string `json:"host"`
+			Path string `json:"path"`
+		}
+		json.Unmarshal(body, &route)
+		write(http.StatusCreated, map[string]string{"guid": cc.findOrCreateRoute(route.Host, route.Path).GUID})
+	case match(path, "v3", "routes", "*") && r.Method == "DELETE":
+		delete(cc.routes, path[2])
+		job()
+	case match(path, "v3", "routes", "*", "destinations"):
+		route := cc.routes[path[2]]
+		if r.Method == "POST" {
+			var destinations struct {
+				Destinations []struct {
+					App struct {
+						GUID string `json:"guid"`
+					} `json:"app"`
+				} `json:"destinations"`
+			}
+			json.Unmarshal(body, &destinations)
+			for _, destination := range destinations.Destinations {
+				cc.mapRoute(route, destination.App.GUID)
+			}
+		}
+		destinations := []interface{}{}
+		for _, app := range route.Apps {
+			destinations = append(destinations, map[string]interface{}{"guid": "destination-" + app, "app": map[string]string{"guid": app}})
+		}
+		write(http.StatusOK, map[string]interface{}{"destinations": destinations})
+	case match(path, "v3", "routes", "*", "destinations", "*") && r.Method == "DELETE":
+		route := cc.routes[path[2]]
+		route.Apps = without(route.Apps, strings.TrimPrefix(path[4], "destination-"))
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		fail(http.StatusNotFound, "Unknown request "+r.Method+" "+r.URL.Path)
+	}
+}
+
+func (cc *fakeCloudController) appByName(name string) *fakeApp {
+	for _, app := range cc.apps {
+		if app.Name == name {
+			return app
+		}
+	}
+	return nil
+}
+
+func (cc *fakeCloudController) sortedApps() []*fakeApp {
+	apps := []*fakeApp{}
+	for _, app := range cc.apps {
+		apps = append(apps, app)
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].GUID < apps[j].GUID })
+	return apps
+}
+
+func (cc *fakeCloudController) appResource(app *fakeApp) map[string]interface{} {
+	return map[string]interface{}{
+		"guid":     app.GUID,
+		"name":     app.Name,
+		"state":    app.State,
+		"metadata": map[string]interface{}{"labels": app.Labels},
+	}
+}
+
+func (cc *fakeCloudController) findOrCreateRoute(host, path string) *fakeRoute {
+	for _, route := range cc.routes {
+		if route.Host == host && route.Path == path {
+			return route
+		}
+	}
+	route := &fakeRoute{GUID: cc.guid("route"), Host: host, Path: path, Domain: "apps.example.com"}
+	cc.routes[route.GUID] = route
+	return route
+}
+
+func (cc *fakeCloudController) mapRoute(route *fakeRoute, appGUID string) {
+	if !contains(route.Apps, appGUID) {
+		route.Apps = append(route.Apps, appGUID)
+	}
+}
+
+// match reports whether the segments of a path match the pattern, where * matches any segment.
+func match(path []string, pattern ...string) bool {
+	if len(path) != len(pattern) {
+		return false
+	}
+	for i := range path {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func without(values []string, value string) []string {
+	kept := []string{}
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -1,11 +1,19 @@
-// Package courier interfaces with the Executor to run specific Cloud Foundry CLI commands.
+// Package courier runs Cloud Foundry commands, with the Executor running the Cloud Foundry CLI or against the v3 API
+// of the Cloud Controller.
 package courier
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/courier/executor"
 	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
 )
 
 type CourierConstructor func(executor I.Executor) I.Courier
@@ -19,6 +27,10 @@ func NewCourier(executor I.Executor) I.Courier {
 // Courier has an Executor to execute Cloud Foundry commands.
 type Courier struct {
 	Executor I.Executor
+
+	logCache S.LogCacheDescriptor
+	caBundle string
+	ctx      context.Context
 }
 
 // Login runs the Cloud Foundry login command.
@@ -33,6 +45,25 @@ func (c Courier) Login(foundationURL, username, password, org, space string, ski
 	return c.Executor.Execute("login", "-a", foundationURL, "-u", username, "-p", password, "-o", org, "-s", space, s)
 }
 
+// Authenticate targets the foundation and authenticates with it without targeting an org and space,
+// so every org and space the user can see can be read.
+//
+// Returns the combined standard output and standard error.
+func (c Courier) Authenticate(foundationURL, username, password string, skipSSL bool) ([]byte, error) {
+	args := []string{"api", foundationURL}
+	if skipSSL {
+		args = append(args, "--skip-ssl-validation")
+	}
+
+	output, err := c.Executor.Execute(args...)
+	if err != nil {
+		return output, err
+	}
+
+	authOutput, err := c.Executor.Execute("auth", username, password)
+	return append(output, authOutput...), err
+}
+
 func (c Courier) CreateService(service, plan, name string) ([]byte, error) {
 	return c.Executor.Execute("create-service", service, plan, name)
 }
@@ -67,6 +98,13 @@ func (c Courier) Delete(appName string) ([]byte, error) {
 	return c.Executor.Execute("delete", appName, "-f")
 }
 
+// DeleteByGUID deletes an application through the v3 API, so it does not have to be in the targeted space.
+//
+// Returns the combined standard output and standard error.
+func (c Courier) DeleteByGUID(guid string) ([]byte, error) {
+	return c.Executor.Execute("curl", "-X", "DELETE", "/v3/apps/"+guid)
+}
+
 // Push runs the Cloud Foundry push command.
 //
 // Returns the combined standard output and standard error.
@@ -113,10 +151,18 @@ func (c Courier) DeleteRoute(domain, hostname string) ([]byte, error) {
 	return c.Executor.Execute("delete-route", domain, "-n", hostname, "-f")
 }
 
-// Logs runs the Cloud Foundry logs command.
+// Logs reads the logs of the application from the Log Cache API when it is enabled. It runs the
+// Cloud Foundry logs command when Log Cache is not enabled, can not be read or has no logs.
 //
 // Returns the combined standard output and standard error.
 func (c Courier) Logs(appName string) ([]byte, error) {
+	if c.logCache.Enabled {
+		logs, err := c.logCacheLogs(appName)
+		if err == nil && len(logs) > 0 {
+			return logs, nil
+		}
+	}
+
 	logs, err := c.Executor.Execute("logs", appName, "--recent")
 	return logs, err
 }
@@ -142,13 +188,21 @@ func (c Courier) Exists(appName string) bool {
 	return err == nil
 }
 
+// ServiceExists checks to see whether the service instance exists in the space.
+//
+// Returns true if the service exists.
+func (c Courier) ServiceExists(serviceName string) bool {
+	_, err := c.Executor.Execute("service", serviceName)
+	return err == nil
+}
+
 // Domains returns a list of domain in a foundation.
 //
 // Returns the combined standard output and standard error.
 func (c Courier) Domains() ([]string, error) {
 	output, err := c.Executor.Execute("domains")
 
-	domains := strings.Split(string(output), "\n")[2:]
+	domains := strings.Split(string(stdout(output)), "\n")[2:]
 	for i, domain := range domains {
 		domains[i] = strings.Split(domain, " ")[0]
 	}
@@ -156,7 +210,254 @@ func (c Courier) Domains() ([]string, error) {
 	return domains, err
 }
 
+// SetLabel runs the Cloud Foundry set-label command for an application.
+// Labels are passed in key order so the command is the same for the same labels.
+//
+// Returns the combined standard output and standard error.
+func (c Courier) SetLabel(appName string, labels map[string]string) ([]byte, error) {
+	keys := []string{}
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := []string{"set-label", "app", appName}
+	for _, key := range keys {
+		args = append(args, fmt.Sprintf("%s=%s", key, labels[key]))
+	}
+
+	return c.Executor.Execute(args...)
+}
+
+// Scale runs the Cloud Foundry scale command to change the number of instances of an application.
+//
+// Returns the combined standard output and standard error.
+func (c Courier) Scale(appName string, instances uint16) ([]byte, error) {
+	return c.Executor.Execute("scale", appName, "-i", fmt.Sprint(instances))
+}
+
+// RestartInstance runs the Cloud Foundry restart-app-instance command to restart a single instance.
+func (c Courier) RestartInstance(appName string, index int) ([]byte, error) {
+	return c.Executor.Execute("restart-app-instance", appName, strconv.Itoa(index))
+}
+
+// AppState returns the state, instance count and labels of an application from the v3 API.
+func (c Courier) AppState(appName string) (S.AppState, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return S.AppState{}, AppStateError{appName, []byte(err.Error())}
+	}
+
+	state, err := readAppState(c.get, guid)
+	if err != nil {
+		return S.AppState{}, AppStateError{appName, []byte(err.Error())}
+	}
+
+	return state, nil
+}
+
+// Instances returns every instance of the web process of an application, ordered by index.
+func (c Courier) Instances(appName string) ([]S.Instance, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	instances, err := readInstances(c.get, guid)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	return instances, nil
+}
+
+// AppEnvironment returns the environment variables set on an application by the user, from the v3 API.
+func (c Courier) AppEnvironment(appName string) (map[string]string, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	environment, err := readAppEnvironment(c.get, guid)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	return environment, nil
+}
+
+// AppRoutes returns the URLs of the routes mapped to an application, such as "app.example.com/path", sorted.
+func (c Courier) AppRoutes(appName string) ([]string, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	routes, err := readAppRoutes(c.get, guid)
+	if err != nil {
+		return nil, AppStateError{appName, []byte(err.Error())}
+	}
+
+	return routes, nil
+}
+
+// Apps returns every application the user can see on the foundation, with the org and space it is in,
+// from the v3 API.
+func (c Courier) Apps() ([]S.AppSummary, error) {
+	apps, err := readApps(c.get)
+	if err != nil {
+		return nil, AppsError{[]byte(err.Error())}
+	}
+
+	return apps, nil
+}
+
+// RunTask starts a task with the droplet of an application through the v3 API. The task runs in the background.
+func (c Courier) RunTask(appName, taskName, command string) (S.Task, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return S.Task{}, TaskError{taskName, []byte(err.Error())}
+	}
+
+	body, err := json.Marshal(map[string]string{"name": taskName, "command": command})
+	if err != nil {
+		return S.Task{}, TaskError{taskName, []byte(err.Error())}
+	}
+
+	var t task
+	err = c.curl("/v3/apps/"+guid+"/tasks", &t, "-X", "POST", "-d", string(body))
+	if err != nil {
+		return S.Task{}, TaskError{taskName, []byte(err.Error())}
+	}
+
+	return t.task(), nil
+}
+
+// Task returns the state of a task from the v3 API.
+func (c Courier) Task(guid string) (S.Task, error) {
+	var t task
+	err := c.curl("/v3/tasks/"+guid, &t)
+	if err != nil {
+		return S.Task{}, TaskError{guid, []byte(err.Error())}
+	}
+
+	return t.task(), nil
+}
+
+// QuotaUsage returns the memory and instances used by the org and the space, and the limits of their quotas, from
+// the v3 API.
+func (c Courier) QuotaUsage(org, space string) (S.OrgSpaceQuotaUsage, error) {
+	var usage S.OrgSpaceQuotaUsage
+
+	output, err := c.Executor.Execute("org", org, "--guid")
+	if err != nil {
+		return usage, QuotaUsageError{org, output}
+	}
+	orgGUID := strings.TrimSpace(string(stdout(output)))
+
+	output, err = c.Executor.Execute("space", space, "--guid")
+	if err != nil {
+		return usage, QuotaUsageError{space, output}
+	}
+	spaceGUID := strings.TrimSpace(string(stdout(output)))
+
+	usage.Org, err = readQuotaUsage(c.get, "/v3/organizations/"+orgGUID, "/v3/organization_quotas/")
+	if err != nil {
+		return usage, QuotaUsageError{org, []byte(err.Error())}
+	}
+
+	usage.Space, err = readQuotaUsage(c.get, "/v3/spaces/"+spaceGUID, "/v3/space_quotas/")
+	if err != nil {
+		return usage, QuotaUsageError{space, []byte(err.Error())}
+	}
+
+	return usage, nil
+}
+
+// CrashCount returns the number of times the instances of an application crashed since the given time,
+// from the crash audit events of the v3 API.
+func (c Courier) CrashCount(appName string, since time.Time) (int, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return 0, AppStateError{appName, []byte(err.Error())}
+	}
+
+	crashes, err := readCrashCount(c.get, guid, since)
+	if err != nil {
+		return 0, AppStateError{appName, []byte(err.Error())}
+	}
+
+	return crashes, nil
+}
+
+// appGUID returns the guid of the application in the targeted space.
+func (c Courier) appGUID(appName string) (string, error) {
+	output, err := c.Executor.Execute("app", appName, "--guid")
+	if err != nil {
+		return "", fmt.Errorf("%s", output)
+	}
+	return strings.TrimSpace(string(stdout(output))), nil
+}
+
+// get reads a path of the v3 API with the Cloud Foundry curl command.
+func (c Courier) get(path string, v interface{}) error {
+	return c.curl(path, v)
+}
+
+// curl runs the Cloud Foundry curl command and decodes the JSON response into v.
+// The API reports failures in an errors list, which is returned as an error.
+func (c Courier) curl(path string, v interface{}, flags ...string) error {
+	output, err := c.Executor.Execute(append([]string{"curl", path}, flags...)...)
+	if err != nil {
+		return fmt.Errorf("%s", output)
+	}
+	output = stdout(output)
+
+	var apiErrors struct {
+		Errors []struct {
+			Detail string `json:"detail"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(output, &apiErrors) == nil && len(apiErrors.Errors) > 0 {
+		return fmt.Errorf("%s", apiErrors.Errors[0].Detail)
+	}
+
+	return json.Unmarshal(output, v)
+}
+
+// stdout returns the output without the lines the CLI wrote to standard error, such as warnings,
+// so it can be parsed.
+func stdout(output []byte) []byte {
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if !strings.HasPrefix(line, executor.StderrLabel) {
+			lines = append(lines, line)
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
 // CleanUp removes the temporary directory created by the Executor.
 func (c Courier) CleanUp() error {
 	return c.Executor.CleanUp()
 }
+
+// WithContext returns a Courier whose commands are killed once ctx is done.
+func (c Courier) WithContext(ctx context.Context) I.Courier {
+	c.Executor = c.Executor.WithContext(ctx)
+	c.ctx = ctx
+	return c
+}
+
+// WithCABundle returns a Courier whose commands trust the certificate authorities in the file at path.
+func (c Courier) WithCABundle(path string) I.Courier {
+	c.Executor = c.Executor.WithCABundle(path)
+	c.caBundle = path
+	return c
+}
+
+// WithLogCache returns a Courier that reads the logs of applications from the Log Cache API.
+func (c Courier) WithLogCache(descriptor S.LogCacheDescriptor) I.Courier {
+	c.logCache = descriptor
+	return c
+}
@@ -1,13 +1,20 @@
 package courier_test
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	. "github.com/compozed/deployadactyl/controller/deployer/bluegreen/courier"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"time"
 
 	"github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/mocks"
 	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/structs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -31,6 +38,27 @@ var _ = Describe("Courier", func() {
 		}
 	})
 
+	Describe("WithContext", func() {
+		It("binds the executor to the context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			courier.WithContext(ctx).Start(appName)
+
+			Expect(executor.WithContextCall.Received.Context).To(Equal(ctx))
+			Expect(executor.ExecuteCall.Received.Args).To(Equal([]string{"start", appName}))
+		})
+	})
+
+	Describe("WithCABundle", func() {
+		It("binds the executor to the CA bundle", func() {
+			courier.WithCABundle("/etc/ssl/internal-ca.pem").Start(appName)
+
+			Expect(executor.WithCABundleCall.Received.Path).To(Equal("/etc/ssl/internal-ca.pem"))
+			Expect(executor.ExecuteCall.Received.Args).To(Equal([]string{"start", appName}))
+		})
+	})
+
 	Describe("logging in", func() {
 		It("should get a valid Cloud Foundry login command", func() {
 			var (
@@ -105,6 +133,21 @@ var _ = Describe("Courier", func() {
 		})
 	})
 
+	Describe("labelling an app", func() {
+		It("should send a valid Cloud Foundry set-label command", func() {
+			expectedArgs := []string{"set-label", "app", appName, "deployadactyl.io/artifact-digest=sha256-abc", "deployadactyl.io/uuid=123"}
+
+			executor.ExecuteCall.Returns.Output = []byte(output)
+			executor.ExecuteCall.Returns.Error = nil
+
+			out, err := courier.SetLabel(appName, map[string]string{"deployadactyl.io/uuid": "123", "deployadactyl.io/artifact-digest": "sha256-abc"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal(expectedArgs))
+			Expect(string(out)).To(Equal(output))
+		})
+	})
+
 	Describe("deleting an app", func() {
 		It("should get a valid Cloud Foundry delete command", func() {
 			expectedArgs := []string{"delete", appName, "-f"}
@@ -120,6 +163,41 @@ var _ = Describe("Courier", func() {
 		})
 	})
 
+	Describe("deleting an app by its guid", func() {
+		It("deletes the app through the v3 api", func() {
+			_, err := courier.DeleteByGUID("app-guid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal([]string{"curl", "-X", "DELETE", "/v3/apps/app-guid"}))
+		})
+	})
+
+	Describe("authenticating without a target", func() {
+		It("sets the api and authenticates", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{[]byte("api set\n"), []byte("authenticated\n")}
+
+			out, err := courier.Authenticate("https://api.example.com", "username", "password", true)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(out)).To(Equal("api set\nauthenticated\n"))
+			Expect(executor.ExecuteCall.Received.AllArgs).To(Equal([][]string{
+				{"api", "https://api.example.com", "--skip-ssl-validation"},
+				{"auth", "username", "password"},
+			}))
+		})
+
+		It("does not authenticate when the api cannot be set", func() {
+			executor.ExecuteCall.Returns.Output = []byte("api unreachable")
+			executor.ExecuteCall.Returns.Error = errors.New("api failed")
+
+			out, err := courier.Authenticate("https://api.example.com", "username", "password", false)
+
+			Expect(err).To(HaveOccurred())
+			Expect(string(out)).To(Equal("api unreachable"))
+			Expect(executor.ExecuteCall.TimesCalled).To(Equal(1))
+		})
+	})
+
 	Describe("pushing an application", func() {
 		It("should get a valid Cloud Foundry push command", func() {
 			var (
@@ -258,6 +336,69 @@ var _ = Describe("Courier", func() {
 		})
 	})
 
+	Describe("getting the logs for an application from Log Cache", func() {
+		var (
+			server  *httptest.Server
+			request *http.Request
+			body    string
+		)
+
+		BeforeEach(func() {
+			body = `{"envelopes":{"batch":[
+				{"timestamp":"1500000002000000000","instance_id":"0","tags":{"source_type":"RTR"},"log":{"payload":"` + base64.StdEncoding.EncodeToString([]byte("GET /health")) + `","type":"OUT"}},
+				{"timestamp":"1500000001000000000","instance_id":"1","tags":{"source_type":"APP/PROC/WEB"},"log":{"payload":"` + base64.StdEncoding.EncodeToString([]byte("panic: boom\n")) + `","type":"ERR"}},
+				{"timestamp":"1500000000000000000","instance_id":"0","tags":{"source_type":"APP/PROC/WEB"},"log":{"payload":"` + base64.StdEncoding.EncodeToString([]byte("starting")) + `","type":"OUT"}}]}}`
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request = r
+				fmt.Fprint(w, body)
+			}))
+			executor.ExecuteCall.Returns.Outputs = [][]byte{[]byte("app-guid\n"), []byte("bearer token\n")}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("reads the logs of the source types of the descriptor, oldest first", func() {
+			courier = courier.WithLogCache(structs.LogCacheDescriptor{Enabled: true, URL: server.URL, Limit: 50, SourceTypes: []string{"app"}})
+
+			out, err := courier.Logs(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.AllArgs).To(Equal([][]string{{"app", appName, "--guid"}, {"oauth-token"}}))
+			Expect(request.URL.Path).To(Equal("/api/v1/read/app-guid"))
+			Expect(request.URL.Query().Get("limit")).To(Equal("50"))
+			Expect(request.URL.Query().Get("envelope_types")).To(Equal("LOG"))
+			Expect(request.Header.Get("Authorization")).To(Equal("bearer token"))
+			Expect(string(out)).To(Equal(
+				"   2017-07-14T02:40:00.00+0000 [APP/PROC/WEB/0] OUT starting\n" +
+					"   2017-07-14T02:40:01.00+0000 [APP/PROC/WEB/1] ERR panic: boom\n"))
+		})
+
+		It("finds the Log Cache API from the Cloud Controller", func() {
+			executor.ExecuteCall.Returns.Outputs = append(executor.ExecuteCall.Returns.Outputs, []byte(`{"links":{"log_cache":{"href":"`+server.URL+`"}}}`))
+			courier = courier.WithLogCache(structs.LogCacheDescriptor{Enabled: true})
+
+			out, err := courier.Logs(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.AllArgs[2]).To(Equal([]string{"curl", "/"}))
+			Expect(string(out)).To(ContainSubstring("[RTR/0] OUT GET /health"))
+		})
+
+		It("falls back to the recent logs when Log Cache has no logs", func() {
+			body = `{"envelopes":{"batch":[]}}`
+			executor.ExecuteCall.Returns.Output = []byte(output)
+			courier = courier.WithLogCache(structs.LogCacheDescriptor{Enabled: true, URL: server.URL})
+
+			out, err := courier.Logs(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal([]string{"logs", appName, "--recent"}))
+			Expect(string(out)).To(Equal(output))
+		})
+	})
+
 	Describe("checking for an existing app", func() {
 		It("should get a valid cloud foundry exists command", func() {
 			expectedArgs := []string{"app", appName}
@@ -271,6 +412,22 @@ var _ = Describe("Courier", func() {
 		})
 	})
 
+	Describe("checking for an existing service", func() {
+		It("should get a valid cloud foundry service command", func() {
+			executor.ExecuteCall.Returns.Output = []byte(output)
+
+			Expect(courier.ServiceExists("my-service")).To(BeTrue())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal([]string{"service", "my-service"}))
+		})
+
+		It("returns false when the service does not exist", func() {
+			executor.ExecuteCall.Returns.Error = fmt.Errorf("Service instance my-service not found")
+
+			Expect(courier.ServiceExists("my-service")).To(BeFalse())
+		})
+	})
+
 	Describe("creating user provided services", func() {
 		It("should get a valid Cloud Foundry Cups command", func() {
 			var (
@@ -420,6 +577,296 @@ var _ = Describe("Courier", func() {
 		})
 	})
 
+	Describe("scaling an app", func() {
+		It("should get a valid Cloud Foundry scale command", func() {
+			executor.ExecuteCall.Returns.Output = []byte(output)
+
+			out, err := courier.Scale(appName, 3)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal([]string{"scale", appName, "-i", "3"}))
+			Expect(string(out)).To(Equal(output))
+		})
+	})
+
+	Describe("running a task", func() {
+		It("starts the task through the v3 api", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("app-guid\n"),
+				[]byte(`{"guid": "task-guid", "name": "migrate", "state": "RUNNING", "result": {"failure_reason": null}}`),
+			}
+
+			task, err := courier.RunTask(appName, "migrate", "bin/migrate")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(task).To(Equal(structs.Task{GUID: "task-guid", Name: "migrate", State: "RUNNING"}))
+			Expect(executor.ExecuteCall.Received.AllArgs).To(Equal([][]string{
+				{"app", appName, "--guid"},
+				{"curl", "/v3/apps/app-guid/tasks", "-X", "POST", "-d", `{"command":"bin/migrate","name":"migrate"}`},
+			}))
+		})
+
+		It("returns an error when the api reports one", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("app-guid"),
+				[]byte(`{"errors": [{"detail": "Task must have a droplet"}]}`),
+			}
+
+			_, err := courier.RunTask(appName, "migrate", "bin/migrate")
+
+			Expect(err).To(MatchError(TaskError{Task: "migrate", Out: []byte("Task must have a droplet")}))
+		})
+
+		It("reads the state of the task", func() {
+			executor.ExecuteCall.Returns.Output = []byte(`{"guid": "task-guid", "name": "migrate", "state": "FAILED", "result": {"failure_reason": "Exited with status 1"}}`)
+
+			task, err := courier.Task("task-guid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(task).To(Equal(structs.Task{GUID: "task-guid", Name: "migrate", State: structs.TaskFailed, FailureReason: "Exited with status 1"}))
+			Expect(executor.ExecuteCall.Received.Args).To(Equal([]string{"curl", "/v3/tasks/task-guid"}))
+		})
+	})
+
+	Describe("getting the quota usage", func() {
+		It("reads the usage of the org and space and the limits of their quotas from the v3 api", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("org-guid\n"),
+				[]byte("space-guid\n"),
+				[]byte(`{"usage_summary": {"started_instances": 6, "memory_in_mb": 3072}}`),
+				[]byte(`{"guid": "org-guid", "relationships": {"quota": {"data": {"guid": "org-quota-guid"}}}}`),
+				[]byte(`{"apps": {"total_memory_in_mb": 10240, "total_instances": null}}`),
+				[]byte(`{"usage_summary": {"started_instances": 2, "memory_in_mb": 1024}}`),
+				[]byte(`{"guid": "space-guid", "relationships": {"quota": {"data": null}}}`),
+			}
+
+			usage, err := courier.QuotaUsage("org", "space")
+			Expect(err).ToNot(HaveOccurred())
+
+			memoryLimit := 10240
+			Expect(usage).To(Equal(structs.OrgSpaceQuotaUsage{
+				Org:   structs.QuotaUsage{MemoryMB: 3072, MemoryLimitMB: &memoryLimit, Instances: 6},
+				Space: structs.QuotaUsage{MemoryMB: 1024, Instances: 2},
+			}))
+			Expect(executor.ExecuteCall.Received.AllArgs).To(Equal([][]string{
+				{"org", "org", "--guid"},
+				{"space", "space", "--guid"},
+				{"curl", "/v3/organizations/org-guid/usage_summary"},
+				{"curl", "/v3/organizations/org-guid"},
+				{"curl", "/v3/organization_quotas/org-quota-guid"},
+				{"curl", "/v3/spaces/space-guid/usage_summary"},
+				{"curl", "/v3/spaces/space-guid"},
+			}))
+		})
+
+		It("returns an error when the api reports one", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("org-guid"),
+				[]byte("space-guid"),
+				[]byte(`{"errors": [{"detail": "You are not authorized to perform the requested action"}]}`),
+			}
+
+			_, err := courier.QuotaUsage("org", "space")
+
+			Expect(err).To(MatchError(QuotaUsageError{Name: "org", Out: []byte("You are not authorized to perform the requested action")}))
+		})
+	})
+
+	Describe("getting the state of an app", func() {
+		It("reads the state, labels and instances from the v3 api", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("app-guid\n"),
+				[]byte(`{"guid": "app-guid", "state": "STARTED", "metadata": {"labels": {"deployadactyl.io/instances": "4"}}}`),
+				[]byte(`{"type": "web", "instances": 2}`),
+			}
+
+			appState, err := courier.AppState(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(appState).To(Equal(structs.AppState{
+				GUID:      "app-guid",
+				State:     "STARTED",
+				Instances: 2,
+				Labels:    map[string]string{"deployadactyl.io/instances": "4"},
+			}))
+			Expect(executor.ExecuteCall.Received.AllArgs).To(Equal([][]string{
+				{"app", appName, "--guid"},
+				{"curl", "/v3/apps/app-guid"},
+				{"curl", "/v3/apps/app-guid/processes/web"},
+			}))
+		})
+
+		It("returns an error when the api reports one", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("app-guid"),
+				[]byte(`{"errors": [{"detail": "App not found", "title": "CF-ResourceNotFound"}]}`),
+			}
+
+			_, err := courier.AppState(appName)
+
+			Expect(err).To(MatchError(AppStateError{AppName: appName, Out: []byte("App not found")}))
+		})
+
+		It("ignores the warnings the CLI writes to standard error", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("[stderr] Warning: the CLI is outdated\napp-guid\n"),
+				[]byte("[stderr] Warning: the CLI is outdated\n" + `{"guid": "app-guid", "state": "STOPPED"}`),
+				[]byte(`{"type": "web", "instances": 1}`),
+			}
+
+			appState, err := courier.AppState(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(appState.GUID).To(Equal("app-guid"))
+			Expect(appState.State).To(Equal("STOPPED"))
+			Expect(executor.ExecuteCall.Received.AllArgs[1]).To(Equal([]string{"curl", "/v3/apps/app-guid"}))
+		})
+	})
+
+	Describe("listing the apps", func() {
+		It("returns every app with its org and space, following the pages of the v3 api", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte(`{
+					"pagination": {"next": {"href": "https://api.example.com/v3/apps?include=space.organization&page=2&per_page=5000"}},
+					"resources": [{"guid": "app-1", "name": "search", "state": "STARTED", "created_at": "2026-10-16T10:00:00Z", "relationships": {"space": {"data": {"guid": "space-guid"}}}}],
+					"included": {
+						"spaces": [{"guid": "space-guid", "name": "space", "relationships": {"organization": {"data": {"guid": "org-guid"}}}}],
+						"organizations": [{"guid": "org-guid", "name": "org"}]
+					}
+				}`),
+				[]byte(`{
+					"pagination": {"next": null},
+					"resources": [{"guid": "app-2", "name": "search-new-build-abc", "state": "STOPPED", "created_at": "2026-10-16T11:00:00Z", "relationships": {"space": {"data": {"guid": "space-guid"}}}}],
+					"included": {
+						"spaces": [{"guid": "space-guid", "name": "space", "relationships": {"organization": {"data": {"guid": "org-guid"}}}}],
+						"organizations": [{"guid": "org-guid", "name": "org"}]
+					}
+				}`),
+			}
+
+			apps, err := courier.Apps()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(apps).To(Equal([]structs.AppSummary{
+				{GUID: "app-1", Name: "search", Org: "org", Space: "space", State: "STARTED", CreatedAt: time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)},
+				{GUID: "app-2", Name: "search-new-build-abc", Org: "org", Space: "space", State: "STOPPED", CreatedAt: time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC)},
+			}))
+			Expect(executor.ExecuteCall.Received.AllArgs).To(Equal([][]string{
+				{"curl", "/v3/apps?per_page=5000&include=space.organization"},
+				{"curl", "/v3/apps?include=space.organization&page=2&per_page=5000"},
+			}))
+		})
+
+		It("returns an error when the api reports one", func() {
+			executor.ExecuteCall.Returns.Output = []byte(`{"errors": [{"detail": "Invalid Auth Token"}]}`)
+
+			_, err := courier.Apps()
+
+			Expect(err).To(MatchError(AppsError{Out: []byte("Invalid Auth Token")}))
+		})
+	})
+
+	Describe("getting the environment variables of an app", func() {
+		It("returns the variables set by the user", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("app-guid"),
+				[]byte(`{"var": {"LOG_LEVEL": "debug"}, "links": {}}`),
+			}
+
+			environment, err := courier.AppEnvironment(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(environment).To(Equal(map[string]string{"LOG_LEVEL": "debug"}))
+			Expect(executor.ExecuteCall.Received.AllArgs[1]).To(Equal([]string{"curl", "/v3/apps/app-guid/environment_variables"}))
+		})
+
+		It("returns an empty map when no variable is set", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("app-guid"),
+				[]byte(`{"var": {}}`),
+			}
+
+			environment, err := courier.AppEnvironment(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(environment).To(BeEmpty())
+		})
+
+		It("returns an error when the api reports one", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("app-guid"),
+				[]byte(`{"errors": [{"detail": "App not found"}]}`),
+			}
+
+			_, err := courier.AppEnvironment(appName)
+
+			Expect(err).To(MatchError(AppStateError{AppName: appName, Out: []byte("App not found")}))
+		})
+	})
+
+	Describe("getting the routes of an app", func() {
+		It("returns the sorted urls of the routes", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("app-guid"),
+				[]byte(`{"resources": [{"url": "search.example.com/v2"}, {"url": "search.example.com"}]}`),
+			}
+
+			routes, err := courier.AppRoutes(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(routes).To(Equal([]string{"search.example.com", "search.example.com/v2"}))
+			Expect(executor.ExecuteCall.Received.AllArgs[1]).To(Equal([]string{"curl", "/v3/apps/app-guid/routes"}))
+		})
+	})
+
+	Describe("restarting an app instance", func() {
+		It("should get a valid Cloud Foundry restart-app-instance command", func() {
+			executor.ExecuteCall.Returns.Output = []byte(output)
+
+			out, err := courier.RestartInstance(appName, 2)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal([]string{"restart-app-instance", appName, "2"}))
+			Expect(string(out)).To(Equal(output))
+		})
+	})
+
+	Describe("counting the crashes of an app", func() {
+		It("counts the crash audit events since the given time", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("app-guid"),
+				[]byte(`{"pagination": {"total_results": 2}, "resources": []}`),
+			}
+			since := time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+
+			crashes, err := courier.CrashCount(appName, since)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(crashes).To(Equal(2))
+			Expect(executor.ExecuteCall.Received.Args).To(Equal([]string{
+				"curl", "/v3/audit_events?created_ats%5Bgt%5D=2018-03-04T05%3A06%3A07Z&target_guids=app-guid&types=audit.app.process.crash",
+			}))
+		})
+	})
+
+	Describe("getting the instances of an app", func() {
+		It("reads every instance of the web process in order of index", func() {
+			executor.ExecuteCall.Returns.Outputs = [][]byte{
+				[]byte("app-guid"),
+				[]byte(`{"resources": [{"type": "web", "index": 1, "state": "STARTING", "uptime": 0}, {"type": "web", "index": 0, "state": "RUNNING", "uptime": 42}]}`),
+			}
+
+			instances, err := courier.Instances(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(instances).To(Equal([]structs.Instance{
+				{Index: 0, State: "RUNNING", Uptime: 42 * time.Second},
+				{Index: 1, State: "STARTING"},
+			}))
+			Expect(executor.ExecuteCall.Received.Args).To(Equal([]string{"curl", "/v3/apps/app-guid/processes/web/stats"}))
+		})
+	})
+
 	Describe("cleaning up executor directories", func() {
 		It("should be successful", func() {
 			executor.CleanUpCall.Returns.Error = nil
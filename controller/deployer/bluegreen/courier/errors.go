@@ -0,0 +1,142 @@
+package courier
+
+import "fmt"
+
+type AppStateError struct {
+	AppName string
+	Out     []byte
+}
+
+func (e AppStateError) Error() string {
+	return fmt.Sprintf("cannot get the state of application %s: %s", e.AppName, e.Out)
+}
+
+type LogCacheError struct {
+	AppName string
+	Err     error
+}
+
+func (e LogCacheError) Error() string {
+	return fmt.Sprintf("cannot read the logs of application %s from Log Cache: %s", e.AppName, e.Err)
+}
+
+type TaskError struct {
+	Task string
+	Out  []byte
+}
+
+func (e TaskError) Error() string {
+	return fmt.Sprintf("cannot run task %s: %s", e.Task, e.Out)
+}
+
+type QuotaUsageError struct {
+	Name string
+	Out  []byte
+}
+
+func (e QuotaUsageError) Error() string {
+	return fmt.Sprintf("cannot read the quota usage of %s: %s", e.Name, e.Out)
+}
+
+type AppsError struct {
+	Out []byte
+}
+
+func (e AppsError) Error() string {
+	return fmt.Sprintf("cannot list the applications: %s", e.Out)
+}
+
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Detail     string
+}
+
+func (e APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("%s %s failed: %s", e.Method, e.Path, e.Detail)
+	}
+	return fmt.Sprintf("%s %s returned %d: %s", e.Method, e.Path, e.StatusCode, e.Detail)
+}
+
+type AuthenticationError struct {
+	Err error
+}
+
+func (e AuthenticationError) Error() string {
+	return fmt.Sprintf("cannot authenticate: %s", e.Err)
+}
+
+type NotLoggedInError struct{}
+
+func (e NotLoggedInError) Error() string {
+	return "not logged into a foundation"
+}
+
+type NoSpaceTargetedError struct{}
+
+func (e NoSpaceTargetedError) Error() string {
+	return "no org and space targeted"
+}
+
+type NotFoundError struct {
+	Kind string
+	Name string
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Kind, e.Name)
+}
+
+type JobError struct {
+	Job    string
+	Detail string
+}
+
+func (e JobError) Error() string {
+	return fmt.Sprintf("job %s failed: %s", e.Job, e.Detail)
+}
+
+type InvalidCredentialsError struct {
+	Err error
+}
+
+func (e InvalidCredentialsError) Error() string {
+	return fmt.Sprintf("the credentials of a user provided service must be a JSON object: %s", e.Err)
+}
+
+type ManifestError struct {
+	Err error
+}
+
+func (e ManifestError) Error() string {
+	return fmt.Sprintf("cannot read the manifest: %s", e.Err)
+}
+
+type PackageError struct {
+	Package string
+	State   string
+}
+
+func (e PackageError) Error() string {
+	return fmt.Sprintf("package %s is %s", e.Package, e.State)
+}
+
+type StagingError struct {
+	AppName string
+	Reason  string
+}
+
+func (e StagingError) Error() string {
+	return fmt.Sprintf("cannot stage application %s: %s", e.AppName, e.Reason)
+}
+
+type StartError struct {
+	AppName string
+	Reason  string
+}
+
+func (e StartError) Error() string {
+	return fmt.Sprintf("cannot start application %s: %s", e.AppName, e.Reason)
+}
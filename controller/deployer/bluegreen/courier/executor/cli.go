@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DefaultCLI is the Cloud Foundry CLI run by an Executor that is not given another one.
+const DefaultCLI = "cf"
+
+// resolveCLI returns the path of the CLI on Windows, where the extension of the file found in the PATH
+// decides how it has to be run. Elsewhere the CLI is returned as is.
+func resolveCLI(cli string) string {
+	if runtime.GOOS != "windows" {
+		return cli
+	}
+
+	resolved, err := exec.LookPath(cli)
+	if err != nil {
+		return cli
+	}
+	return resolved
+}
+
+// commandLine returns the program and arguments that run the CLI with args on goos.
+//
+// On Windows, batch files are run through cmd and PowerShell scripts through powershell,
+// since neither can be started directly.
+func commandLine(goos, cli string, args []string) (string, []string) {
+	if goos != "windows" {
+		return cli, args
+	}
+
+	switch strings.ToLower(filepath.Ext(cli)) {
+	case ".bat", ".cmd":
+		return "cmd", append([]string{"/C", cli}, args...)
+	case ".ps1":
+		return "powershell", append([]string{"-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", cli}, args...)
+	}
+	return cli, args
+}
+
+// sameEnvKey reports whether the environment variable keys are the same on goos.
+// Windows environment variable keys are case insensitive.
+func sameEnvKey(goos, a, b string) bool {
+	if goos == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
@@ -0,0 +1,48 @@
+package executor
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CLI", func() {
+	Describe("commandLine", func() {
+		args := []string{"push", "my-app"}
+
+		It("runs the CLI directly outside of Windows", func() {
+			name, cliArgs := commandLine("linux", "/usr/local/bin/cf.cmd", args)
+
+			Expect(name).To(Equal("/usr/local/bin/cf.cmd"))
+			Expect(cliArgs).To(Equal(args))
+		})
+
+		It("runs an executable directly on Windows", func() {
+			name, cliArgs := commandLine("windows", `C:\Program Files\CloudFoundry\cf.exe`, args)
+
+			Expect(name).To(Equal(`C:\Program Files\CloudFoundry\cf.exe`))
+			Expect(cliArgs).To(Equal(args))
+		})
+
+		It("runs a batch file through cmd on Windows", func() {
+			name, cliArgs := commandLine("windows", `C:\tools\cf.CMD`, args)
+
+			Expect(name).To(Equal("cmd"))
+			Expect(cliArgs).To(Equal([]string{"/C", `C:\tools\cf.CMD`, "push", "my-app"}))
+		})
+
+		It("runs a PowerShell script through powershell on Windows", func() {
+			name, cliArgs := commandLine("windows", `C:\tools\cf.ps1`, args)
+
+			Expect(name).To(Equal("powershell"))
+			Expect(cliArgs).To(Equal([]string{"-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", `C:\tools\cf.ps1`, "push", "my-app"}))
+		})
+	})
+
+	Describe("sameEnvKey", func() {
+		It("ignores the case of keys on Windows", func() {
+			Expect(sameEnvKey("windows", "Cf_Home", "CF_HOME")).To(BeTrue())
+			Expect(sameEnvKey("linux", "Cf_Home", "CF_HOME")).To(BeFalse())
+			Expect(sameEnvKey("linux", "CF_HOME", "CF_HOME")).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,15 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+)
+
+type CommandTimeoutError struct {
+	Command string
+	Timeout time.Duration
+}
+
+func (e CommandTimeoutError) Error() string {
+	return fmt.Sprintf("cf %s did not finish within %s and was killed", e.Command, e.Timeout)
+}
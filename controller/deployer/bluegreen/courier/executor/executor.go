@@ -2,13 +2,34 @@
 package executor
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"os/exec"
+	"runtime"
 	"strings"
+	"time"
 
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/tracing"
 	"github.com/spf13/afero"
 )
 
+// DefaultTimeout is how long a command may run before it is killed, unless it has a timeout of its own.
+const DefaultTimeout = 10 * time.Minute
+
+// DefaultKey is the key of the timeout used by commands without a timeout of their own.
+const DefaultKey = "default"
+
+// defaultTimeouts give the commands that stage applications longer than the others.
+var defaultTimeouts = map[string]time.Duration{
+	DefaultKey: DefaultTimeout,
+	"push":     30 * time.Minute,
+	"restage":  30 * time.Minute,
+	"start":    30 * time.Minute,
+	"restart":  30 * time.Minute,
+}
+
 // New returns a new Executor struct.
 func New(fileSystem *afero.Afero) (Executor, error) {
 	tempDir, err := fileSystem.TempDir("", "deployadactyl-executor-")
@@ -19,6 +40,8 @@ func New(fileSystem *afero.Afero) (Executor, error) {
 	return Executor{
 		fileSystem: fileSystem,
 		tempDir:    tempDir,
+		timeouts:   defaultTimeouts,
+		cli:        DefaultCLI,
 	}, nil
 }
 
@@ -26,25 +49,69 @@ func New(fileSystem *afero.Afero) (Executor, error) {
 type Executor struct {
 	tempDir    string
 	fileSystem *afero.Afero
+	ctx        context.Context
+	caBundle   string
+	timeouts   map[string]time.Duration
+	cli        string
 }
 
 // Execute takes a slice of string args and runs them together against the cf command on the Cloud Foundry binary.
 //
 // Returns the combined standard output and standard error.
 func (e Executor) Execute(args ...string) ([]byte, error) {
-	command := exec.Command("cf", args...)
-	command.Env = setEnv(os.Environ(), "CF_HOME", e.tempDir)
-	return command.CombinedOutput()
+	command := e.command(args...)
+	return e.run(command, args)
 }
 
 // ExecuteInDirectory does the same thing as Execute does, but does it in a specific directory.
 //
 // Returns the combined standard output and standard error.
 func (e Executor) ExecuteInDirectory(directory string, args ...string) ([]byte, error) {
-	command := exec.Command("cf", args...)
-	command.Env = setEnv(os.Environ(), "CF_HOME", e.tempDir)
+	command := e.command(args...)
 	command.Dir = directory
-	return command.CombinedOutput()
+	return e.run(command, args)
+}
+
+// WithContext returns a copy of the Executor whose commands are killed once ctx is done.
+// The copy shares the temporary directory of the original.
+func (e Executor) WithContext(ctx context.Context) I.Executor {
+	e.ctx = ctx
+	return e
+}
+
+// WithCABundle returns a copy of the Executor whose commands trust the certificate authorities
+// in the file at path instead of the system roots.
+func (e Executor) WithCABundle(path string) I.Executor {
+	e.caBundle = path
+	return e
+}
+
+// WithTimeouts returns a copy of the Executor whose commands are killed, with every process they
+// started, once they run longer than their timeout. Timeouts are keyed by the cf command, and the
+// DefaultKey timeout is used by the commands without one. They replace the default timeouts.
+func (e Executor) WithTimeouts(timeouts map[string]time.Duration) Executor {
+	merged := map[string]time.Duration{}
+	for command, timeout := range defaultTimeouts {
+		merged[command] = timeout
+	}
+	for command, timeout := range timeouts {
+		merged[command] = timeout
+	}
+
+	e.timeouts = merged
+	return e
+}
+
+// WithCLI returns a copy of the Executor that runs the Cloud Foundry CLI at path instead of the cf in the PATH.
+// On Windows, the CLI may be a batch file or a PowerShell script.
+func (e Executor) WithCLI(path string) Executor {
+	e.cli = path
+	return e
+}
+
+// TempDir returns the temporary directory of the Executor.
+func (e Executor) TempDir() string {
+	return e.tempDir
 }
 
 // CleanUp removes the temporary directory of the Executor.
@@ -52,11 +119,92 @@ func (e Executor) CleanUp() error {
 	return e.fileSystem.RemoveAll(e.tempDir)
 }
 
+func (e Executor) command(args ...string) *exec.Cmd {
+	cli := e.cli
+	if cli == "" {
+		cli = DefaultCLI
+	}
+
+	name, cliArgs := commandLine(runtime.GOOS, resolveCLI(cli), args)
+	command := exec.Command(name, cliArgs...)
+	command.Env = setEnv(os.Environ(), "CF_HOME", e.tempDir)
+	if e.caBundle != "" {
+		command.Env = setEnv(command.Env, "SSL_CERT_FILE", e.caBundle)
+	}
+	return command
+}
+
+// run runs the command and returns its combined output, in which every line of standard error starts
+// with the StderrLabel. The command and every process it started are killed when its timeout passes or
+// the context of the Executor is done.
+func (e Executor) run(command *exec.Cmd, args []string) (out []byte, err error) {
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	// Only the name of the command is traced, since its arguments can be credentials.
+	_, span := tracing.Start(e.ctx, "cf "+name, tracing.String("cf.command", name))
+	defer func() {
+		span.SetError(err)
+		span.Finish()
+	}()
+
+	output := &output{}
+	command.Stdout = output.Stdout()
+	command.Stderr = output.Stderr()
+	setProcessGroup(command)
+
+	err = command.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- command.Wait() }()
+
+	var ctxDone <-chan struct{}
+	if e.ctx != nil {
+		ctxDone = e.ctx.Done()
+	}
+
+	timeout := e.timeout(name)
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case err = <-done:
+		return output.Bytes(), err
+	case <-ctxDone:
+		killProcessGroup(command)
+		err = <-done
+		return output.Bytes(), err
+	case <-expired:
+		killProcessGroup(command)
+		<-done
+		timeoutErr := CommandTimeoutError{Command: name, Timeout: timeout}
+		fmt.Fprintf(output.Stdout(), "\n%s\n", timeoutErr)
+		return output.Bytes(), timeoutErr
+	}
+}
+
+// timeout returns the timeout of a cf command, or zero if it never times out.
+func (e Executor) timeout(name string) time.Duration {
+	if timeout, ok := e.timeouts[name]; ok {
+		return timeout
+	}
+	return e.timeouts[DefaultKey]
+}
+
 func setEnv(env []string, key, value string) []string {
 	keyValuePair := key + "=" + value
 
 	for i, envVar := range env {
-		if strings.HasPrefix(envVar, key+"=") {
+		if equals := strings.Index(envVar, "="); equals > 0 && sameEnvKey(runtime.GOOS, envVar[:equals], key) {
 			env[i] = keyValuePair
 			return env
 		}
@@ -0,0 +1,138 @@
+//go:build !windows
+// +build !windows
+
+package executor_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/bluegreen/courier/executor"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeCF echoes its arguments, warns on standard error when it is asked to, or starts a child that
+// outlives it and hangs when it is asked to.
+const fakeCF = `#!/bin/sh
+if [ "$1" = "warn" ]; then
+  echo "Pushing app"
+  echo "Warning: the manifest is deprecated" >&2
+  printf "multi\nline" >&2
+  echo "done"
+  exit 0
+fi
+if [ "$1" = "hang" ]; then
+  echo "hanging"
+  sleep 60 &
+  echo $! > "$CHILD_PID_FILE"
+  wait
+fi
+echo "ran $@"
+`
+
+var _ = Describe("Executor", func() {
+	var (
+		binDir       string
+		path         string
+		childPIDFile string
+		executor     Executor
+	)
+
+	BeforeEach(func() {
+		var err error
+		binDir, err = ioutil.TempDir("", "executor-test-")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(binDir, "cf"), []byte(fakeCF), 0755)).To(Succeed())
+
+		childPIDFile = filepath.Join(binDir, "child.pid")
+		path = os.Getenv("PATH")
+		os.Setenv("PATH", binDir+string(os.PathListSeparator)+path)
+		os.Setenv("CHILD_PID_FILE", childPIDFile)
+
+		executor, err = New(&afero.Afero{Fs: afero.NewOsFs()})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", path)
+		os.Unsetenv("CHILD_PID_FILE")
+		executor.CleanUp()
+		os.RemoveAll(binDir)
+	})
+
+	childIsRunning := func() bool {
+		contents, err := ioutil.ReadFile(childPIDFile)
+		Expect(err).ToNot(HaveOccurred())
+		pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+		Expect(err).ToNot(HaveOccurred())
+
+		if syscall.Kill(pid, 0) != nil {
+			return false
+		}
+
+		// A killed child whose parent has exited stays a zombie until it is reaped.
+		stat, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+		return err != nil || !strings.Contains(string(stat), ") Z ")
+	}
+
+	It("returns the output of the command", func() {
+		output, err := executor.Execute("apps")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(output)).To(Equal("ran apps\n"))
+	})
+
+	It("labels every line of standard error", func() {
+		output, err := executor.Execute("warn")
+
+		Expect(err).ToNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		Expect(lines).To(ConsistOf(
+			"Pushing app",
+			"[stderr] Warning: the manifest is deprecated",
+			"[stderr] multi",
+			"[stderr] line",
+			"done",
+		))
+	})
+
+	It("kills a command and its children when it runs longer than its timeout", func() {
+		executor = executor.WithTimeouts(map[string]time.Duration{"hang": 200 * time.Millisecond})
+
+		started := time.Now()
+		output, err := executor.Execute("hang")
+
+		Expect(err).To(MatchError(CommandTimeoutError{Command: "hang", Timeout: 200 * time.Millisecond}))
+		Expect(time.Since(started)).To(BeNumerically("<", 10*time.Second))
+		Expect(string(output)).To(ContainSubstring("hanging"))
+		Expect(string(output)).To(ContainSubstring("cf hang did not finish within 200ms and was killed"))
+		Eventually(childIsRunning).Should(BeFalse())
+	})
+
+	It("uses the default timeout for commands without one", func() {
+		executor = executor.WithTimeouts(map[string]time.Duration{DefaultKey: 200 * time.Millisecond})
+
+		_, err := executor.Execute("hang")
+
+		Expect(err).To(MatchError(CommandTimeoutError{Command: "hang", Timeout: 200 * time.Millisecond}))
+	})
+
+	It("kills a command and its children when the context is done", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		_, err := executor.WithContext(ctx).Execute("hang")
+
+		Expect(err).To(HaveOccurred())
+		Eventually(childIsRunning).Should(BeFalse())
+	})
+})
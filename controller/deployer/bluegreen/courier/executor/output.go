@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// StderrLabel starts every line a command writes to standard error, so the output shows which lines are
+// warnings and errors of the CLI and error matchers can target them.
+const StderrLabel = "[stderr] "
+
+type stream int
+
+const (
+	noStream stream = iota
+	stdoutStream
+	stderrStream
+)
+
+// output captures the standard output and standard error of a command through separate writers and
+// interleaves them as they are read, labelling every line of standard error.
+type output struct {
+	mu       sync.Mutex
+	combined bytes.Buffer
+	last     stream
+}
+
+func (o *output) Stdout() io.Writer {
+	return streamWriter{output: o, stream: stdoutStream}
+}
+
+func (o *output) Stderr() io.Writer {
+	return streamWriter{output: o, stream: stderrStream}
+}
+
+// Bytes returns the combined output.
+func (o *output) Bytes() []byte {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.combined.Bytes()
+}
+
+func (o *output) write(s stream, p []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// A line that one stream left unfinished is ended before the other stream writes.
+	if o.last != noStream && o.last != s && !o.atLineStart() {
+		o.combined.WriteByte('\n')
+	}
+	o.last = s
+
+	if s == stdoutStream {
+		o.combined.Write(p)
+		return
+	}
+
+	for len(p) > 0 {
+		if o.atLineStart() {
+			o.combined.WriteString(StderrLabel)
+		}
+
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			o.combined.Write(p)
+			return
+		}
+		o.combined.Write(p[:i+1])
+		p = p[i+1:]
+	}
+}
+
+func (o *output) atLineStart() bool {
+	contents := o.combined.Bytes()
+	return len(contents) == 0 || contents[len(contents)-1] == '\n'
+}
+
+type streamWriter struct {
+	output *output
+	stream stream
+}
+
+func (w streamWriter) Write(p []byte) (int, error) {
+	w.output.write(w.stream, p)
+	return len(p), nil
+}
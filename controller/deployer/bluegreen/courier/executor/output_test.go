@@ -0,0 +1,31 @@
+package executor
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("output", func() {
+	It("labels every line of standard error", func() {
+		o := &output{}
+
+		fmt.Fprint(o.Stdout(), "Pushing app\n")
+		fmt.Fprint(o.Stderr(), "Warning: deprecated\nsecond ")
+		fmt.Fprint(o.Stderr(), "half\n")
+		fmt.Fprint(o.Stdout(), "done\n")
+
+		Expect(string(o.Bytes())).To(Equal("Pushing app\n[stderr] Warning: deprecated\n[stderr] second half\ndone\n"))
+	})
+
+	It("ends a line one stream left unfinished before the other stream writes", func() {
+		o := &output{}
+
+		fmt.Fprint(o.Stdout(), "Uploading")
+		fmt.Fprint(o.Stderr(), "Warning: slow\n")
+		fmt.Fprint(o.Stdout(), "done\n")
+
+		Expect(string(o.Bytes())).To(Equal("Uploading\n[stderr] Warning: slow\ndone\n"))
+	})
+})
@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package executor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in a process group of its own, so the processes it starts can be killed with it.
+func setProcessGroup(command *exec.Cmd) {
+	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the command and every process in its process group.
+func killProcessGroup(command *exec.Cmd) {
+	if command.Process == nil {
+		return
+	}
+
+	err := syscall.Kill(-command.Process.Pid, syscall.SIGKILL)
+	if err != nil {
+		command.Process.Kill()
+	}
+}
@@ -0,0 +1,24 @@
+//go:build windows
+// +build windows
+
+package executor
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// setProcessGroup does nothing on Windows, where the processes started by the command are found by taskkill.
+func setProcessGroup(command *exec.Cmd) {}
+
+// killProcessGroup kills the command and every process it started.
+func killProcessGroup(command *exec.Cmd) {
+	if command.Process == nil {
+		return
+	}
+
+	err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(command.Process.Pid)).Run()
+	if err != nil {
+		command.Process.Kill()
+	}
+}
@@ -0,0 +1,238 @@
+package courier
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compozed/deployadactyl/cabundle"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// DefaultLogCacheLimit is how many envelopes are read from Log Cache when the descriptor does not set a limit.
+// It is also the most Log Cache returns for a single read.
+const DefaultLogCacheLimit = 1000
+
+// DefaultLogCacheLookback is how far back logs are read from Log Cache when the descriptor does not set it.
+const DefaultLogCacheLookback = time.Hour
+
+// logCacheTimeout is how long a read from Log Cache may take.
+const logCacheTimeout = 30 * time.Second
+
+type logCacheEnvelope struct {
+	Timestamp  string            `json:"timestamp"`
+	InstanceID string            `json:"instance_id"`
+	Tags       map[string]string `json:"tags"`
+	Log        *struct {
+		Payload string `json:"payload"`
+		Type    string `json:"type"`
+	} `json:"log"`
+}
+
+// logCacheReader reads the logs of applications from Log Cache, as the descriptor of a courier says.
+type logCacheReader struct {
+	descriptor S.LogCacheDescriptor
+	caBundle   string
+	ctx        context.Context
+}
+
+// logCacheLogs reads the logs of the application from Log Cache and formats them like the recent
+// logs of the Cloud Foundry CLI, oldest first.
+func (c Courier) logCacheLogs(appName string) ([]byte, error) {
+	envelopes, err := c.LogEnvelopes(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.logCacheReader().format(envelopes), nil
+}
+
+// LogEnvelopes reads the log envelopes of every source of the application from Log Cache, oldest first.
+// The Log Cache API is found from the Cloud Controller when a descriptor has not been given.
+func (c Courier) LogEnvelopes(appName string) ([]S.LogEnvelope, error) {
+	guid, err := c.appGUID(appName)
+	if err != nil {
+		return nil, LogCacheError{appName, err}
+	}
+
+	token, err := c.Executor.Execute("oauth-token")
+	if err != nil {
+		return nil, LogCacheError{appName, fmt.Errorf("%s", token)}
+	}
+
+	envelopes, err := c.logCacheReader().envelopes(c.get, guid, strings.TrimSpace(string(stdout(token))))
+	if err != nil {
+		return nil, LogCacheError{appName, err}
+	}
+
+	return envelopes, nil
+}
+
+func (c Courier) logCacheReader() logCacheReader {
+	return logCacheReader{descriptor: c.logCache, caBundle: c.caBundle, ctx: c.ctx}
+}
+
+// envelopes reads the log envelopes of the application with the guid, oldest first, with the token.
+func (r logCacheReader) envelopes(get getter, guid, token string) ([]S.LogEnvelope, error) {
+	logCacheURL, err := r.url(get)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := r.descriptor.Limit
+	if limit <= 0 || limit > DefaultLogCacheLimit {
+		limit = DefaultLogCacheLimit
+	}
+	lookback := DefaultLogCacheLookback
+	if r.descriptor.LookbackSeconds > 0 {
+		lookback = time.Duration(r.descriptor.LookbackSeconds) * time.Second
+	}
+
+	query := url.Values{}
+	query.Set("envelope_types", "LOG")
+	query.Set("descending", "true")
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("start_time", strconv.FormatInt(time.Now().Add(-lookback).UnixNano(), 10))
+
+	var response struct {
+		Envelopes struct {
+			Batch []logCacheEnvelope `json:"batch"`
+		} `json:"envelopes"`
+	}
+	err = r.get(fmt.Sprintf("%s/api/v1/read/%s?%s", strings.TrimSuffix(logCacheURL, "/"), guid, query.Encode()), token, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	envelopes := []S.LogEnvelope{}
+	batch := response.Envelopes.Batch
+	for i := len(batch) - 1; i >= 0; i-- {
+		if batch[i].Log != nil {
+			envelopes = append(envelopes, batch[i].envelope())
+		}
+	}
+
+	return envelopes, nil
+}
+
+// format formats the envelopes of the source types the descriptor keeps like the recent logs of the Cloud Foundry
+// CLI. It returns nothing when no envelope is kept.
+func (r logCacheReader) format(envelopes []S.LogEnvelope) []byte {
+	lines := []string{}
+	for _, envelope := range envelopes {
+		if r.keepSourceType(envelope.SourceType) {
+			lines = append(lines, envelope.String())
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// url returns the Log Cache API of the descriptor, or the one the Cloud Controller links to.
+func (r logCacheReader) url(get getter) (string, error) {
+	if r.descriptor.URL != "" {
+		return r.descriptor.URL, nil
+	}
+
+	var root struct {
+		Links struct {
+			LogCache struct {
+				Href string `json:"href"`
+			} `json:"log_cache"`
+		} `json:"links"`
+	}
+	err := get("/", &root)
+	if err != nil {
+		return "", err
+	}
+	if root.Links.LogCache.Href == "" {
+		return "", fmt.Errorf("the foundation does not link to a Log Cache API")
+	}
+
+	return root.Links.LogCache.Href, nil
+}
+
+// get sends an authorized GET request to Log Cache and decodes the JSON response into v.
+func (r logCacheReader) get(logCacheURL, token string, v interface{}) error {
+	request, err := http.NewRequest("GET", logCacheURL, nil)
+	if err != nil {
+		return err
+	}
+	if r.ctx != nil {
+		request = request.WithContext(r.ctx)
+	}
+	request.Header.Set("Authorization", token)
+
+	client := &http.Client{Timeout: logCacheTimeout}
+	tlsConfig, err := cabundle.TLSConfig(&afero.Afero{Fs: afero.NewOsFs()}, r.caBundle)
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response %s: %s", response.Status, body)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// keepSourceType returns true if the logs of the source type are kept by the descriptor.
+func (r logCacheReader) keepSourceType(sourceType string) bool {
+	if len(r.descriptor.SourceTypes) == 0 {
+		return true
+	}
+
+	for _, prefix := range r.descriptor.SourceTypes {
+		if strings.HasPrefix(strings.ToUpper(sourceType), strings.ToUpper(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// envelope converts a log envelope of Log Cache, whose payload is base64 encoded.
+func (e logCacheEnvelope) envelope() S.LogEnvelope {
+	payload, err := base64.StdEncoding.DecodeString(e.Log.Payload)
+	if err != nil {
+		payload = []byte(e.Log.Payload)
+	}
+
+	nanoseconds, _ := strconv.ParseInt(e.Timestamp, 10, 64)
+
+	logType := "OUT"
+	if e.Log.Type == "ERR" {
+		logType = "ERR"
+	}
+
+	return S.LogEnvelope{
+		Timestamp:  time.Unix(0, nanoseconds).UTC(),
+		SourceType: e.Tags["source_type"],
+		InstanceID: e.InstanceID,
+		Type:       logType,
+		Message:    strings.TrimRight(string(payload), "\n"),
+	}
+}
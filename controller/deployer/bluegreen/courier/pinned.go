@@ -0,0 +1,77 @@
+package courier
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/compozed/deployadactyl/cabundle"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tlspin"
+	"github.com/spf13/afero"
+)
+
+// PinnedCourier checks the certificate pins of a foundation before logging into it.
+// The Cloud Foundry CLI can only verify certificates against the system roots or skip verification,
+// so the foundation is contacted once before the CLI is.
+type PinnedCourier struct {
+	I.Courier
+	Pins     tlspin.Pins
+	CABundle string
+}
+
+// Login verifies the pins of the foundation and runs the Cloud Foundry login command.
+//
+// Returns the combined standard output and standard error.
+func (c PinnedCourier) Login(foundationURL, username, password, org, space string, skipSSL bool) ([]byte, error) {
+	err := c.checkPins(foundationURL, skipSSL)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return c.Courier.Login(foundationURL, username, password, org, space, skipSSL)
+}
+
+// Authenticate verifies the pins of the foundation and authenticates with it without targeting an org and space.
+//
+// Returns the combined standard output and standard error.
+func (c PinnedCourier) Authenticate(foundationURL, username, password string, skipSSL bool) ([]byte, error) {
+	err := c.checkPins(foundationURL, skipSSL)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return c.Courier.Authenticate(foundationURL, username, password, skipSSL)
+}
+
+func (c PinnedCourier) checkPins(foundationURL string, skipSSL bool) error {
+	config := &tls.Config{InsecureSkipVerify: skipSSL}
+	if c.CABundle != "" {
+		roots, err := cabundle.Load(&afero.Afero{Fs: afero.NewOsFs()}, c.CABundle)
+		if err != nil {
+			return err
+		}
+		config.RootCAs = roots
+	}
+
+	return c.Pins.Check(foundationURL, config)
+}
+
+// WithContext returns a PinnedCourier whose commands are killed once ctx is done.
+func (c PinnedCourier) WithContext(ctx context.Context) I.Courier {
+	c.Courier = c.Courier.WithContext(ctx)
+	return c
+}
+
+// WithCABundle returns a PinnedCourier that trusts the certificate authorities in the file at path.
+func (c PinnedCourier) WithCABundle(path string) I.Courier {
+	c.Courier = c.Courier.WithCABundle(path)
+	c.CABundle = path
+	return c
+}
+
+// WithLogCache returns a PinnedCourier that reads the logs of applications from the Log Cache API.
+func (c PinnedCourier) WithLogCache(descriptor S.LogCacheDescriptor) I.Courier {
+	c.Courier = c.Courier.WithLogCache(descriptor)
+	return c
+}
@@ -0,0 +1,76 @@
+package courier_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/bluegreen/courier"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/tlspin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PinnedCourier", func() {
+	var (
+		server  *httptest.Server
+		courier *mocks.Courier
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		courier = &mocks.Courier{}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	pin := func(spki []byte) string {
+		sum := sha256.Sum256(spki)
+		return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	It("logs in when the certificate of the foundation matches a pin", func() {
+		certificate, err := x509.ParseCertificate(server.TLS.Certificates[0].Certificate[0])
+		Expect(err).ToNot(HaveOccurred())
+
+		pinned := PinnedCourier{Courier: courier, Pins: tlspin.Pins{server.URL: {pin(certificate.RawSubjectPublicKeyInfo)}}}
+
+		_, err = pinned.Login(server.URL, "username", "password", "org", "space", true)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(courier.LoginCall.Received.FoundationURL).To(Equal(server.URL))
+	})
+
+	It("does not log in when the certificate of the foundation does not match", func() {
+		pinned := PinnedCourier{Courier: courier, Pins: tlspin.Pins{server.URL: {pin([]byte("another key"))}}}
+
+		output, err := pinned.Login(server.URL, "username", "password", "org", "space", true)
+
+		Expect(err).To(MatchError(tlspin.PinMismatchError{Host: "127.0.0.1"}))
+		Expect(string(output)).To(Equal(err.Error()))
+		Expect(courier.LoginCall.Received.FoundationURL).To(BeEmpty())
+	})
+
+	It("does not authenticate when the certificate of the foundation does not match", func() {
+		pinned := PinnedCourier{Courier: courier, Pins: tlspin.Pins{server.URL: {pin([]byte("another key"))}}}
+
+		_, err := pinned.Authenticate(server.URL, "username", "password", true)
+
+		Expect(err).To(MatchError(tlspin.PinMismatchError{Host: "127.0.0.1"}))
+		Expect(courier.AuthenticateCall.Received.FoundationURL).To(BeEmpty())
+	})
+
+	It("keeps checking pins when bound to a context", func() {
+		pinned := PinnedCourier{Courier: courier, Pins: tlspin.Pins{server.URL: {pin([]byte("another key"))}}}
+
+		_, err := pinned.WithContext(context.Background()).Login(server.URL, "username", "password", "org", "space", true)
+
+		Expect(err).To(MatchError(tlspin.PinMismatchError{Host: "127.0.0.1"}))
+	})
+})
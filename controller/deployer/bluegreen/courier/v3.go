@@ -0,0 +1,277 @@
+package courier
+
+import (
+	"net/url"
+	"sort"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// getter reads a path of the v3 API of the Cloud Controller and decodes the JSON response into v.
+// The Courier reads it with the Cloud Foundry curl command, the APICourier with its own client.
+type getter func(path string, v interface{}) error
+
+// readAppState reads the state, instance count and labels of the application with the guid.
+func readAppState(get getter, guid string) (S.AppState, error) {
+	var app struct {
+		State    string `json:"state"`
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	err := get("/v3/apps/"+guid, &app)
+	if err != nil {
+		return S.AppState{}, err
+	}
+
+	var process struct {
+		Instances uint16 `json:"instances"`
+	}
+	err = get("/v3/apps/"+guid+"/processes/web", &process)
+	if err != nil {
+		return S.AppState{}, err
+	}
+
+	return S.AppState{
+		GUID:      guid,
+		State:     app.State,
+		Instances: process.Instances,
+		Labels:    app.Metadata.Labels,
+	}, nil
+}
+
+// readInstances reads every instance of the web process of the application with the guid, ordered by index.
+func readInstances(get getter, guid string) ([]S.Instance, error) {
+	var stats struct {
+		Resources []struct {
+			Index  int    `json:"index"`
+			State  string `json:"state"`
+			Uptime int64  `json:"uptime"`
+		} `json:"resources"`
+	}
+	err := get("/v3/apps/"+guid+"/processes/web/stats", &stats)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []S.Instance{}
+	for _, instance := range stats.Resources {
+		instances = append(instances, S.Instance{
+			Index:  instance.Index,
+			State:  instance.State,
+			Uptime: time.Duration(instance.Uptime) * time.Second,
+		})
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].Index < instances[j].Index })
+
+	return instances, nil
+}
+
+// readAppEnvironment reads the environment variables set by the user on the application with the guid.
+func readAppEnvironment(get getter, guid string) (map[string]string, error) {
+	var environment struct {
+		Var map[string]string `json:"var"`
+	}
+	err := get("/v3/apps/"+guid+"/environment_variables", &environment)
+	if err != nil {
+		return nil, err
+	}
+	if environment.Var == nil {
+		return map[string]string{}, nil
+	}
+
+	return environment.Var, nil
+}
+
+// readAppRoutes reads the URLs of the routes mapped to the application with the guid, sorted.
+func readAppRoutes(get getter, guid string) ([]string, error) {
+	var routes struct {
+		Resources []struct {
+			URL string `json:"url"`
+		} `json:"resources"`
+	}
+	err := get("/v3/apps/"+guid+"/routes", &routes)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []string{}
+	for _, route := range routes.Resources {
+		urls = append(urls, route.URL)
+	}
+	sort.Strings(urls)
+
+	return urls, nil
+}
+
+// readApps reads every application the user can see, with the org and space it is in, page by page.
+func readApps(get getter) ([]S.AppSummary, error) {
+	type resource struct {
+		GUID          string    `json:"guid"`
+		Name          string    `json:"name"`
+		State         string    `json:"state"`
+		CreatedAt     time.Time `json:"created_at"`
+		Relationships struct {
+			Space struct {
+				Data struct {
+					GUID string `json:"guid"`
+				} `json:"data"`
+			} `json:"space"`
+			Organization struct {
+				Data struct {
+					GUID string `json:"guid"`
+				} `json:"data"`
+			} `json:"organization"`
+		} `json:"relationships"`
+	}
+
+	apps := []S.AppSummary{}
+	path := "/v3/apps?per_page=5000&include=space.organization"
+	for path != "" {
+		var page struct {
+			Pagination pagination `json:"pagination"`
+			Resources  []resource `json:"resources"`
+			Included   struct {
+				Spaces        []resource `json:"spaces"`
+				Organizations []resource `json:"organizations"`
+			} `json:"included"`
+		}
+		err := get(path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		spaces := map[string]resource{}
+		for _, space := range page.Included.Spaces {
+			spaces[space.GUID] = space
+		}
+		orgs := map[string]string{}
+		for _, org := range page.Included.Organizations {
+			orgs[org.GUID] = org.Name
+		}
+
+		for _, app := range page.Resources {
+			space := spaces[app.Relationships.Space.Data.GUID]
+			apps = append(apps, S.AppSummary{
+				GUID:      app.GUID,
+				Name:      app.Name,
+				State:     app.State,
+				Org:       orgs[space.Relationships.Organization.Data.GUID],
+				Space:     space.Name,
+				CreatedAt: app.CreatedAt,
+			})
+		}
+
+		path, err = page.Pagination.next()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return apps, nil
+}
+
+// readQuotaUsage reads the usage summary of an org or a space and the limits of its quota, if it has one.
+func readQuotaUsage(get getter, path, quotasPath string) (S.QuotaUsage, error) {
+	var summary struct {
+		UsageSummary struct {
+			StartedInstances int `json:"started_instances"`
+			MemoryInMB       int `json:"memory_in_mb"`
+		} `json:"usage_summary"`
+	}
+	err := get(path+"/usage_summary", &summary)
+	if err != nil {
+		return S.QuotaUsage{}, err
+	}
+
+	usage := S.QuotaUsage{
+		MemoryMB:  summary.UsageSummary.MemoryInMB,
+		Instances: summary.UsageSummary.StartedInstances,
+	}
+
+	var resource struct {
+		Relationships struct {
+			Quota struct {
+				Data *struct {
+					GUID string `json:"guid"`
+				} `json:"data"`
+			} `json:"quota"`
+		} `json:"relationships"`
+	}
+	err = get(path, &resource)
+	if err != nil {
+		return S.QuotaUsage{}, err
+	}
+	if resource.Relationships.Quota.Data == nil {
+		return usage, nil
+	}
+
+	var quota struct {
+		Apps struct {
+			TotalMemoryInMB *int `json:"total_memory_in_mb"`
+			TotalInstances  *int `json:"total_instances"`
+		} `json:"apps"`
+	}
+	err = get(quotasPath+resource.Relationships.Quota.Data.GUID, &quota)
+	if err != nil {
+		return S.QuotaUsage{}, err
+	}
+	usage.MemoryLimitMB = quota.Apps.TotalMemoryInMB
+	usage.InstanceLimit = quota.Apps.TotalInstances
+
+	return usage, nil
+}
+
+// readCrashCount reads the number of times the instances of the application with the guid crashed since the given
+// time, from the crash audit events.
+func readCrashCount(get getter, guid string, since time.Time) (int, error) {
+	query := url.Values{}
+	query.Set("types", "audit.app.process.crash")
+	query.Set("target_guids", guid)
+	query.Set("created_ats[gt]", since.UTC().Format(time.RFC3339))
+
+	var events struct {
+		Pagination struct {
+			TotalResults int `json:"total_results"`
+		} `json:"pagination"`
+	}
+	err := get("/v3/audit_events?"+query.Encode(), &events)
+	if err != nil {
+		return 0, err
+	}
+
+	return events.Pagination.TotalResults, nil
+}
+
+type pagination struct {
+	Next *struct {
+		Href string `json:"href"`
+	} `json:"next"`
+}
+
+// next returns the path of the next page, or an empty path on the last page.
+func (p pagination) next() (string, error) {
+	if p.Next == nil {
+		return "", nil
+	}
+
+	next, err := url.Parse(p.Next.Href)
+	if err != nil {
+		return "", err
+	}
+	return next.RequestURI(), nil
+}
+
+type task struct {
+	GUID   string `json:"guid"`
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Result struct {
+		FailureReason string `json:"failure_reason"`
+	} `json:"result"`
+}
+
+func (t task) task() S.Task {
+	return S.Task{GUID: t.GUID, Name: t.Name, State: t.State, FailureReason: t.Result.FailureReason}
+}
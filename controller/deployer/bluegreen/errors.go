@@ -3,6 +3,7 @@ package bluegreen
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 type LoginError struct {
@@ -185,3 +186,179 @@ func (e RollbackStartError) Error() string {
 
 	return fmt.Sprintf("start failed: %s: rollback failed: %s", startErrs, rollbackStartErrors)
 }
+
+type RestartError struct {
+	Errors []error
+}
+
+func (e RestartError) Error() string {
+	errs := makeErrorString(e.Errors)
+	return fmt.Sprintf("restart failed: %s", errs)
+}
+
+func (e RestartError) Code() string {
+	return "RestartError"
+}
+
+type FinishRestartError struct {
+	FinishRestartErrors []error
+}
+
+func (e FinishRestartError) Error() string {
+	finishRestartErrors := makeErrorString(e.FinishRestartErrors)
+
+	return fmt.Sprintf("finish restart failed: %s", finishRestartErrors)
+}
+
+type RestageError struct {
+	Errors []error
+}
+
+func (e RestageError) Error() string {
+	errs := makeErrorString(e.Errors)
+	return fmt.Sprintf("restage failed: %s", errs)
+}
+
+func (e RestageError) Code() string {
+	return "RestageError"
+}
+
+type FinishRestageError struct {
+	FinishRestageErrors []error
+}
+
+func (e FinishRestageError) Error() string {
+	finishRestageErrors := makeErrorString(e.FinishRestageErrors)
+
+	return fmt.Sprintf("finish restage failed: %s", finishRestageErrors)
+}
+
+type DeleteError struct {
+	Errors []error
+}
+
+func (e DeleteError) Error() string {
+	errs := makeErrorString(e.Errors)
+	return fmt.Sprintf("delete failed: %s", errs)
+}
+
+func (e DeleteError) Code() string {
+	return "DeleteError"
+}
+
+type FinishDeleteError struct {
+	FinishDeleteErrors []error
+}
+
+func (e FinishDeleteError) Error() string {
+	finishDeleteErrors := makeErrorString(e.FinishDeleteErrors)
+
+	return fmt.Sprintf("finish delete failed: %s", finishDeleteErrors)
+}
+
+type CancelledError struct {
+	Err error
+}
+
+func (e CancelledError) Error() string {
+	return fmt.Sprintf("deployment cancelled: %s", e.Err)
+}
+
+func (e CancelledError) Code() string {
+	return "CancelledError"
+}
+
+type CanaryNotPromotedError struct {
+	Timeout time.Duration
+}
+
+func (e CanaryNotPromotedError) Error() string {
+	return fmt.Sprintf("the canary was not promoted within %s", e.Timeout)
+}
+
+func (e CanaryNotPromotedError) Code() string {
+	return "CanaryNotPromotedError"
+}
+
+type RolloutHaltedError struct {
+	Err         error
+	Batch       int
+	Batches     int
+	Foundations []string
+	Aborted     []string
+}
+
+func (e RolloutHaltedError) Error() string {
+	return fmt.Sprintf("the rollout halted at batch %d of %d, %d foundations were not deployed: %s", e.Batch, e.Batches, len(e.Aborted), e.Err)
+}
+
+func (e RolloutHaltedError) Code() string {
+	return "RolloutHaltedError"
+}
+
+type ActionPanicError struct {
+	Value interface{}
+	Stack string
+}
+
+func (e ActionPanicError) Error() string {
+	return fmt.Sprintf("action panicked: %v", e.Value)
+}
+
+// FailedFoundations returns on how many of the foundations an error returned by Execute failed. Errors that are
+// not reported per foundation, such as a cancelled deployment, failed on all of them.
+func FailedFoundations(err error, foundations int) int {
+	var errs []error
+
+	switch e := err.(type) {
+	case nil:
+		return 0
+	case RolloutHaltedError:
+		return FailedFoundations(e.Err, len(e.Foundations)) + len(e.Aborted)
+	case LoginError:
+		errs = e.LoginErrors
+	case PushError:
+		errs = e.PushErrors
+	case RollbackError:
+		errs = e.PushErrors
+	case FinishPushError:
+		errs = e.FinishPushError
+	case StopError:
+		errs = e.Errors
+	case RollbackStopError:
+		errs = e.StopErrors
+	case FinishStopError:
+		errs = e.FinishStopErrors
+	case StartError:
+		errs = e.Errors
+	case RollbackStartError:
+		errs = e.StartErrors
+	case FinishStartError:
+		errs = e.FinishStartErrors
+	case RestartError:
+		errs = e.Errors
+	case FinishRestartError:
+		errs = e.FinishRestartErrors
+	case RestageError:
+		errs = e.Errors
+	case FinishRestageError:
+		errs = e.FinishRestageErrors
+	case DeleteError:
+		errs = e.Errors
+	case FinishDeleteError:
+		errs = e.FinishDeleteErrors
+	default:
+		return foundations
+	}
+
+	for _, err := range errs {
+		if _, ok := err.(CancelledError); ok {
+			return foundations
+		}
+	}
+	if len(errs) == 0 || len(errs) > foundations {
+		return foundations
+	}
+
+	return len(errs)
+}
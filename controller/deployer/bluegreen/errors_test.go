@@ -0,0 +1,36 @@
+package bluegreen_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FailedFoundations", func() {
+	It("returns 0 without an error", func() {
+		Expect(bluegreen.FailedFoundations(nil, 3)).To(Equal(0))
+	})
+
+	It("returns the number of foundations the action failed on", func() {
+		err := bluegreen.RollbackError{
+			PushErrors:     []error{errors.New("push failed"), errors.New("push failed")},
+			RollbackErrors: []error{errors.New("rollback failed")},
+		}
+
+		Expect(bluegreen.FailedFoundations(err, 3)).To(Equal(2))
+		Expect(bluegreen.FailedFoundations(bluegreen.StopError{Errors: []error{errors.New("stop failed")}}, 3)).To(Equal(1))
+	})
+
+	It("fails every foundation when the deployment was cancelled", func() {
+		err := bluegreen.PushError{PushErrors: []error{bluegreen.CancelledError{Err: context.Canceled}}}
+
+		Expect(bluegreen.FailedFoundations(err, 3)).To(Equal(3))
+	})
+
+	It("fails every foundation for errors that are not reported per foundation", func() {
+		Expect(bluegreen.FailedFoundations(bluegreen.InitializationError{Err: errors.New("init failed")}, 3)).To(Equal(3))
+	})
+})
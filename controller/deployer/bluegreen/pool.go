@@ -0,0 +1,44 @@
+package bluegreen
+
+// result is the error an actor of a pool finished its command with.
+type result struct {
+	index int
+	err   error
+}
+
+// runPool runs the command on every actor with a pool of at most size workers, so at most size actors run it at
+// once. Every size below one, or above the number of actors, runs the command on every actor at once.
+//
+// done is called with the index of every actor and its error in the order the actors finish. The errors are
+// returned in the order of the actors.
+func runPool(actors []actor, size int, command ActorCommand, done func(index int, err error)) []error {
+	if size < 1 || size > len(actors) {
+		size = len(actors)
+	}
+
+	indexes := make(chan int)
+	results := make(chan result)
+	for w := 0; w < size; w++ {
+		go func() {
+			for i := range indexes {
+				actors[i].Commands <- command
+				results <- result{index: i, err: <-actors[i].Errs}
+			}
+		}()
+	}
+
+	go func() {
+		for i := range actors {
+			indexes <- i
+		}
+		close(indexes)
+	}()
+
+	errs := make([]error, len(actors))
+	for range actors {
+		r := <-results
+		done(r.index, r.err)
+		errs[r.index] = r.err
+	}
+	return errs
+}
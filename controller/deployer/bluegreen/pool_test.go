@@ -0,0 +1,113 @@
+package bluegreen_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+// slowPusher executes for a while, and counts how many pushers are executing at once.
+type slowPusher struct {
+	*mocks.Pusher
+	executing *int32
+	most      *int32
+}
+
+func (p slowPusher) Execute(ctx context.Context) error {
+	executing := atomic.AddInt32(p.executing, 1)
+	defer atomic.AddInt32(p.executing, -1)
+
+	for {
+		most := atomic.LoadInt32(p.most)
+		if executing <= most || atomic.CompareAndSwapInt32(p.most, most, executing) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	return p.Pusher.Execute(ctx)
+}
+
+var _ = Describe("A limit of concurrent foundations", func() {
+	var (
+		pusherCreator *mocks.PushManager
+		pushers       []*mocks.Pusher
+		progress      *mocks.ProgressReporter
+		blueGreen     BlueGreen
+		environment   S.Environment
+		response      *Buffer
+		executing     int32
+		most          int32
+	)
+
+	BeforeEach(func() {
+		response = NewBuffer()
+		log := interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(NewBuffer(), logging.DEBUG, "pool_test"), UUID: "uuid-1"}
+		executing, most = 0, 0
+
+		environment = S.Environment{
+			Name:                     "production",
+			EnableRollback:           true,
+			MaxConcurrentFoundations: 2,
+		}
+		pusherCreator = &mocks.PushManager{}
+		pushers = nil
+		for i := 0; i < 5; i++ {
+			environment.Foundations = append(environment.Foundations, fmt.Sprintf("https://api%d.example.com", i+1))
+			pusher := &mocks.Pusher{Response: response}
+			pushers = append(pushers, pusher)
+			pusherCreator.CreatePusherCall.Returns.Pushers = append(pusherCreator.CreatePusherCall.Returns.Pushers, slowPusher{pusher, &executing, &most})
+			pusherCreator.CreatePusherCall.Returns.Error = append(pusherCreator.CreatePusherCall.Returns.Error, nil)
+		}
+
+		progress = &mocks.ProgressReporter{}
+		blueGreen = BlueGreen{Log: log, Progress: progress}
+	})
+
+	It("deploys to at most the limit of foundations at once", func() {
+		results, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(most).To(Equal(int32(2)))
+		for i, result := range results {
+			Expect(result).To(Equal(S.FoundationResult{FoundationURL: environment.Foundations[i], Status: S.FoundationSucceeded}))
+		}
+		Eventually(response).Should(Say("deploying to at most 2 of 5 foundations at once"))
+	})
+
+	It("deploys to every foundation at once without a limit", func() {
+		environment.MaxConcurrentFoundations = 0
+
+		_, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(most).To(Equal(int32(5)))
+		Expect(response).ToNot(Say("at most"))
+	})
+
+	It("returns the errors of the foundations in the order of the foundations", func() {
+		pushers[1].ExecuteCall.Returns.Error = errors.New("push failed on 2")
+		pushers[4].ExecuteCall.Returns.Error = errors.New("push failed on 5")
+
+		results, err := blueGreen.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).To(MatchError(PushError{[]error{errors.New("push failed on 2"), errors.New("push failed on 5")}}))
+		Expect(results[1].Error).To(Equal("push failed on 2"))
+		Expect(results[4].Error).To(Equal("push failed on 5"))
+		for _, pusher := range pushers {
+			Expect(pusher.UndoCall.Received.Context).ToNot(BeNil())
+		}
+		Expect(progress.Calls).To(ContainElement("FailFoundation uuid-1 https://api5.example.com"))
+	})
+})
@@ -0,0 +1,65 @@
+package bluegreen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Rolling deploys the foundations of an environment with a rolling rollout in batches, each of them with the
+// BlueGreener, instead of all at once. The rollout halts on the first batch that fails, so a bad build never reaches
+// the foundations after it, which are aborted. The batches that already succeeded keep the new build.
+type Rolling struct {
+	BlueGreener I.BlueGreener
+	Log         I.DeploymentLogger
+
+	// Progress receives the aborted foundations as failed ones, so they are retried with the failed foundations.
+	Progress I.ProgressReporter
+}
+
+// Execute runs the actions of the action creator against every foundation of the environment, batch by batch.
+func (r Rolling) Execute(ctx context.Context, actionCreator I.ActionCreator, environment S.Environment, response io.ReadWriter) ([]S.FoundationResult, error) {
+	batches := environment.Rolling.Batches(environment.Foundations)
+	if len(batches) < 2 {
+		return r.BlueGreener.Execute(ctx, actionCreator, environment, response)
+	}
+
+	log := r.Log.WithFields(I.LogFields{I.PhaseLogField: "rollout"})
+
+	var results []S.FoundationResult
+	for i, batch := range batches {
+		log.Infof("rolling out batch %d of %d to %s", i+1, len(batches), strings.Join(batch, ", "))
+		fmt.Fprintf(response, "\nrolling out batch %d of %d: %s\n", i+1, len(batches), strings.Join(batch, ", "))
+
+		batchEnvironment := environment
+		batchEnvironment.Foundations = batch
+
+		batchResults, err := r.BlueGreener.Execute(ctx, actionCreator, batchEnvironment, response)
+		results = append(results, batchResults...)
+		if err == nil {
+			continue
+		}
+
+		var aborted []string
+		for _, remaining := range batches[i+1:] {
+			for _, foundationURL := range remaining {
+				aborted = append(aborted, foundationURL)
+				results = append(results, S.FoundationResult{FoundationURL: foundationURL, Status: S.FoundationAborted})
+				if r.Progress != nil {
+					r.Progress.FailFoundation(r.Log.UUID, foundationURL)
+				}
+			}
+		}
+
+		log.Errorf("halting the rollout at batch %d of %d: %s", i+1, len(batches), err)
+		fmt.Fprintf(response, "\nhalting the rollout at batch %d of %d: %d foundations were not deployed\n", i+1, len(batches), len(aborted))
+
+		return results, RolloutHaltedError{Err: err, Batch: i + 1, Batches: len(batches), Foundations: batch, Aborted: aborted}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,106 @@
+package bluegreen_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Rolling", func() {
+	var (
+		pusherCreator *mocks.PushManager
+		pushers       []*mocks.Pusher
+		rolling       Rolling
+		environment   S.Environment
+		response      *Buffer
+	)
+
+	BeforeEach(func() {
+		response = NewBuffer()
+		log := interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(NewBuffer(), logging.DEBUG, "rolling_test"), UUID: "uuid-1"}
+
+		environment = S.Environment{
+			Name:           "production",
+			Foundations:    []string{"https://api1.example.com", "https://api2.example.com", "https://api3.example.com"},
+			EnableRollback: true,
+			Rolling:        S.RollingDescriptor{Enabled: true, BatchSize: 1},
+		}
+
+		pusherCreator = &mocks.PushManager{}
+		pushers = nil
+		for range environment.Foundations {
+			pusher := &mocks.Pusher{Response: response}
+			pushers = append(pushers, pusher)
+			pusherCreator.CreatePusherCall.Returns.Pushers = append(pusherCreator.CreatePusherCall.Returns.Pushers, pusher)
+			pusherCreator.CreatePusherCall.Returns.Error = append(pusherCreator.CreatePusherCall.Returns.Error, nil)
+		}
+
+		rolling = Rolling{BlueGreener: BlueGreen{Log: log}, Log: log}
+	})
+
+	It("deploys every foundation one batch after the other", func() {
+		results, err := rolling.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(Equal([]S.FoundationResult{
+			{FoundationURL: "https://api1.example.com", Status: S.FoundationSucceeded},
+			{FoundationURL: "https://api2.example.com", Status: S.FoundationSucceeded},
+			{FoundationURL: "https://api3.example.com", Status: S.FoundationSucceeded},
+		}))
+		Eventually(response).Should(Say("rolling out batch 1 of 3: https://api1.example.com"))
+		Eventually(response).Should(Say("rolling out batch 2 of 3: https://api2.example.com"))
+		Eventually(response).Should(Say("rolling out batch 3 of 3: https://api3.example.com"))
+	})
+
+	It("halts the rollout at the first batch that fails", func() {
+		progress := &mocks.ProgressReporter{}
+		rolling.Progress = progress
+		pushers[1].ExecuteCall.Returns.Error = errors.New("push failed")
+
+		results, err := rolling.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).To(MatchError(RolloutHaltedError{
+			Err:         PushError{[]error{errors.New("push failed")}},
+			Batch:       2,
+			Batches:     3,
+			Foundations: []string{"https://api2.example.com"},
+			Aborted:     []string{"https://api3.example.com"},
+		}))
+		Expect(results[0].Status).To(Equal(S.FoundationSucceeded))
+		Expect(results[1].Status).To(Equal(S.FoundationFailed))
+		Expect(results[2]).To(Equal(S.FoundationResult{FoundationURL: "https://api3.example.com", Status: S.FoundationAborted}))
+		Expect(pushers[0].SuccessCall.Called).To(BeTrue())
+		Expect(pushers[2].InitiallyCall.Received.Context).To(BeNil())
+		Expect(FailedFoundations(err, 3)).To(Equal(2))
+		Expect(progress.Calls).To(ContainElement("FailFoundation uuid-1 https://api3.example.com"))
+		Eventually(response).Should(Say("halting the rollout at batch 2 of 3: 1 foundations were not deployed"))
+	})
+
+	It("deploys the foundations in batches of the batch size", func() {
+		environment.Rolling.BatchSize = 2
+
+		_, err := rolling.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(response).Should(Say("rolling out batch 1 of 2: https://api1.example.com, https://api2.example.com"))
+		Eventually(response).Should(Say("rolling out batch 2 of 2: https://api3.example.com"))
+	})
+
+	It("deploys every foundation at once without a rolling rollout", func() {
+		environment.Rolling = S.RollingDescriptor{}
+
+		results, err := rolling.Execute(context.Background(), pusherCreator, environment, response)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(3))
+		Expect(response).ToNot(Say("rolling out"))
+	})
+})
@@ -2,9 +2,11 @@
 package deployer
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"crypto/tls"
 	"log"
@@ -12,8 +14,10 @@ import (
 
 	"encoding/base64"
 	"github.com/compozed/deployadactyl/config"
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
 	I "github.com/compozed/deployadactyl/interfaces"
 	S "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tracing"
 )
 
 const (
@@ -36,7 +40,7 @@ AppName:      %s`
 type SilentDeployer struct {
 }
 
-func (d SilentDeployer) Deploy(deploymentInfo *S.DeploymentInfo, env S.Environment, actionCreator I.ActionCreator, response io.ReadWriter) *I.DeployResponse {
+func (d SilentDeployer) Deploy(ctx context.Context, deploymentInfo *S.DeploymentInfo, env S.Environment, actionCreator I.ActionCreator, response io.ReadWriter) *I.DeployResponse {
 	url := os.Getenv("SILENT_DEPLOY_URL")
 	deployResponse := &I.DeployResponse{}
 
@@ -45,6 +49,7 @@ func (d SilentDeployer) Deploy(deploymentInfo *S.DeploymentInfo, env S.Environme
 		log.Println(fmt.Sprintf("Silent deployer request err: %s", err))
 		deployResponse.Error = err
 	}
+	request = request.WithContext(ctx)
 	usernamePassword := base64.StdEncoding.EncodeToString([]byte(deploymentInfo.Username + ":" + deploymentInfo.Password))
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Accept", "application/json")
@@ -76,16 +81,57 @@ type Deployer struct {
 	Randomizer   I.Randomizer
 	ErrorFinder  I.ErrorFinder
 	Log          I.DeploymentLogger
+
+	// TempDirectories removes the temporary directories of the deployment once it is finished,
+	// including the ones whose action never got to clean up after itself.
+	TempDirectories I.TempDirectoryTracker
+
+	// Progress receives the phase the deployment is in.
+	Progress I.ProgressReporter
+
+	// Maintenance has the foundations that are in maintenance, which are skipped.
+	Maintenance I.FoundationMaintenance
 }
 
-func (d Deployer) Deploy(deploymentInfo *S.DeploymentInfo, env S.Environment, actionCreator I.ActionCreator, response io.ReadWriter) *I.DeployResponse {
+func (d Deployer) Deploy(ctx context.Context, deploymentInfo *S.DeploymentInfo, env S.Environment, actionCreator I.ActionCreator, response io.ReadWriter) (result *I.DeployResponse) {
+	defer func() { d.finishProgress(deploymentInfo.UUID, result) }()
+
+	ctx, span := tracing.Start(ctx, "deployer", tracing.String("app", deploymentInfo.AppName), tracing.String("org", deploymentInfo.Org), tracing.String("space", deploymentInfo.Space))
+	defer func() {
+		span.SetError(result.Error)
+		span.Finish()
+	}()
+
+	started := time.Now()
+	env = d.skipMaintenance(deploymentInfo, env, response)
+	summary := S.DeploymentSummary{Foundations: len(env.Foundations), FoundationsSkipped: len(deploymentInfo.SkippedFoundations)}
+	defer func() {
+		summary.Duration = time.Since(started)
+		summary.ArtifactSizeBytes = deploymentInfo.ArtifactSize
+		summary.Instances = deploymentInfo.Instances
+		result.Summary = summary
+		summary.Write(response)
+	}()
 
 	deployResponse := &I.DeployResponse{
 		DeploymentInfo: deploymentInfo,
 	}
 
+	if len(env.Foundations) == 0 && len(deploymentInfo.SkippedFoundations) > 0 {
+		err := FoundationsInMaintenanceError{Environment: env.Name}
+		d.Log.Error(err)
+		fmt.Fprintln(response, err.Error())
+		deployResponse.StatusCode = http.StatusServiceUnavailable
+		deployResponse.Error = err
+		return deployResponse
+	}
+
+	d.startPhase(deploymentInfo.UUID, S.PhasePrechecking, len(env.Foundations))
 	d.Log.Debug("prechecking the foundations")
+	_, precheckSpan := tracing.Start(ctx, "deployer.precheck", tracing.Int("foundations", len(env.Foundations)))
 	err := d.Prechecker.AssertAllFoundationsUp(env)
+	precheckSpan.SetError(err)
+	precheckSpan.Finish()
 	if err != nil {
 		d.Log.Error(err)
 		deployResponse.StatusCode = http.StatusInternalServerError
@@ -93,14 +139,37 @@ func (d Deployer) Deploy(deploymentInfo *S.DeploymentInfo, env S.Environment, ac
 		return deployResponse
 	}
 
+	defer d.removeTempDirectories(deploymentInfo.UUID)
 	defer func() { actionCreator.CleanUp() }()
-	err = actionCreator.SetUp()
+	d.startPhase(deploymentInfo.UUID, S.PhasePreparing, 0)
+	setUpCtx, setUpSpan := tracing.Start(ctx, "deployer.setup")
+	err = actionCreator.SetUp(setUpCtx)
+	setUpSpan.SetError(err)
+	setUpSpan.Finish()
 	if err != nil {
 		deployResponse.StatusCode = http.StatusInternalServerError
 		deployResponse.Error = err
 		return deployResponse
 	}
 
+	d.Log.Debug("checking the buildpacks of the manifest")
+	err = d.Prechecker.AssertBuildpacksAvailable(env, *deploymentInfo)
+	if err != nil {
+		d.Log.Error(err)
+		deployResponse.StatusCode = http.StatusInternalServerError
+		deployResponse.Error = err
+		return deployResponse
+	}
+
+	d.Log.Debug("checking the domains of the environment and the manifest")
+	err = d.Prechecker.AssertDomainsAvailable(env, *deploymentInfo)
+	if err != nil {
+		d.Log.Error(err)
+		deployResponse.StatusCode = http.StatusInternalServerError
+		deployResponse.Error = err
+		return deployResponse
+	}
+
 	err = actionCreator.OnStart()
 	if err != nil {
 		deployResponse.StatusCode = http.StatusInternalServerError
@@ -108,9 +177,69 @@ func (d Deployer) Deploy(deploymentInfo *S.DeploymentInfo, env S.Environment, ac
 		return deployResponse
 	}
 
-	err = d.BlueGreener.Execute(actionCreator, env, response)
+	results, err := d.BlueGreener.Execute(ctx, actionCreator, env, response)
+	summary.FoundationsFailed = bluegreen.FailedFoundations(err, len(env.Foundations))
+	summary.FoundationsSucceeded = summary.Foundations - summary.FoundationsFailed
 
 	resp := actionCreator.OnFinish(env, response, err)
 	resp.DeploymentInfo = deploymentInfo
+	resp.Foundations = results
+	for _, foundationURL := range deploymentInfo.SkippedFoundations {
+		resp.Foundations = append(resp.Foundations, S.FoundationResult{FoundationURL: foundationURL, Status: S.FoundationSkipped})
+	}
+
+	if S.PartialSuccess(resp.Foundations) {
+		d.Log.Infof("the deployment only succeeded on %d of %d foundations", len(S.FoundationsWithStatus(resp.Foundations, S.FoundationSucceeded)), len(resp.Foundations))
+		resp.PartialSuccess = true
+		resp.StatusCode = http.StatusMultiStatus
+		summary.PartialResults = resp.Foundations
+	}
+
 	return &resp
 }
+
+// skipMaintenance returns the environment without the foundations that are in maintenance, which are added to the
+// SkippedFoundations of the deployment with a warning.
+func (d Deployer) skipMaintenance(deploymentInfo *S.DeploymentInfo, env S.Environment, response io.Writer) S.Environment {
+	if d.Maintenance == nil {
+		return env
+	}
+
+	var foundations []string
+	for _, foundationURL := range env.Foundations {
+		if !d.Maintenance.InMaintenance(foundationURL) {
+			foundations = append(foundations, foundationURL)
+			continue
+		}
+
+		d.Log.Infof("skipping %s because it is in maintenance", foundationURL)
+		fmt.Fprintf(response, "WARNING: skipping %s because it is in maintenance\n", foundationURL)
+		deploymentInfo.SkippedFoundations = append(deploymentInfo.SkippedFoundations, foundationURL)
+	}
+	env.Foundations = foundations
+
+	return env
+}
+
+func (d Deployer) startPhase(uuid, phase string, foundations int) {
+	if d.Progress != nil {
+		d.Progress.StartPhase(uuid, phase, foundations)
+	}
+}
+
+func (d Deployer) finishProgress(uuid string, deployResponse *I.DeployResponse) {
+	if d.Progress != nil {
+		d.Progress.Finish(uuid, deployResponse.Error)
+	}
+}
+
+func (d Deployer) removeTempDirectories(deploymentID string) {
+	if d.TempDirectories == nil {
+		return
+	}
+
+	err := d.TempDirectories.RemoveDeployment(deploymentID)
+	if err != nil {
+		d.Log.Error(err)
+	}
+}
@@ -2,6 +2,7 @@ package deployer_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/compozed/deployadactyl/config"
 	. "github.com/compozed/deployadactyl/controller/deployer"
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
 	"github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/mocks"
 	"github.com/compozed/deployadactyl/randomizer"
@@ -111,7 +113,7 @@ var _ = Describe("Deployer", func() {
 			base64Manifest,
 		))
 
-		customParams := make(map[string]interface{})
+		customParams := make(S.Params)
 		customParams["service_now_column_name"] = "u_change"
 		customParams["service_now_table_name"] = "u_table"
 
@@ -184,6 +186,9 @@ var _ = Describe("Deployer", func() {
 			randomizerMock,
 			nil,
 			log,
+			nil,
+			nil,
+			nil,
 		}
 	})
 
@@ -192,7 +197,7 @@ var _ = Describe("Deployer", func() {
 			It("rejects the request with a http.StatusInternalServerError", func() {
 				prechecker.AssertAllFoundationsUpCall.Returns.Error = errors.New("prechecker failed")
 
-				deployResponse := deployer.Deploy(&deploymentInfo, deployer.Config.Environments[environment], pusherCreator, response)
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, deployer.Config.Environments[environment], pusherCreator, response)
 				Expect(deployResponse.Error).To(MatchError("prechecker failed"))
 
 				Expect(deployResponse.StatusCode).To(Equal(http.StatusInternalServerError))
@@ -214,7 +219,7 @@ var _ = Describe("Deployer", func() {
 
 					By("not setting basic auth")
 
-					deployResponse := deployer.Deploy(&deploymentInfo, env, pusherCreator, response)
+					deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreator, response)
 
 					Expect(deployResponse.Error).ToNot(HaveOccurred())
 					Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
@@ -243,7 +248,7 @@ var _ = Describe("Deployer", func() {
 						StatusCode: http.StatusOK,
 					}
 
-					deployResponse := deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreator, response)
+					deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreator, response)
 
 					Expect(deployResponse.Error).ToNot(HaveOccurred())
 
@@ -267,7 +272,7 @@ var _ = Describe("Deployer", func() {
 						StatusCode: http.StatusOK,
 					}
 
-					deployResponse := deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreator, response)
+					deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreator, response)
 
 					Expect(deployResponse.Error).ToNot(HaveOccurred())
 
@@ -287,7 +292,7 @@ var _ = Describe("Deployer", func() {
 						base64Manifest,
 					))
 
-					deployResponse := deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreator, response)
+					deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreator, response)
 
 					Expect(deployResponse.StatusCode).To(Equal(http.StatusInternalServerError))
 
@@ -310,7 +315,7 @@ var _ = Describe("Deployer", func() {
 					base64Manifest,
 				))
 
-				deployResponse := deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreator, response)
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreator, response)
 
 				Expect(deployResponse.Error).ToNot(HaveOccurred())
 
@@ -336,7 +341,7 @@ var _ = Describe("Deployer", func() {
 				))
 
 				uuid = ""
-				deployResponse := deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreator, response)
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreator, response)
 
 				Expect(deployResponse.Error).ToNot(HaveOccurred())
 
@@ -353,7 +358,7 @@ var _ = Describe("Deployer", func() {
 				It("returns an error and http.StatusInternalServerError", func() {
 					pusherCreator.SetUpCall.Returns.Err = errors.New("a test error")
 
-					deployResponse := deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreator, response)
+					deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreator, response)
 
 					Expect(deployResponse.Error.Error()).To(ContainSubstring("a test error"))
 
@@ -375,7 +380,7 @@ var _ = Describe("Deployer", func() {
 				eventManager.EmitCall.Returns.Error = append(eventManager.EmitCall.Returns.Error, nil)
 				eventManager.EmitCall.Returns.Error = append(eventManager.EmitCall.Returns.Error, nil)
 
-				deployResponse := deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreator, response)
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreator, response)
 
 				Expect(deployResponse.DeploymentInfo.UUID).ToNot(Equal(""))
 				manifest := deployResponse.DeploymentInfo.Manifest
@@ -392,7 +397,7 @@ var _ = Describe("Deployer", func() {
 					StatusCode: http.StatusOK,
 				}
 
-				deployResponse := deployer.Deploy(&deploymentInfo, environments[environment], pusherCreator, response)
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, environments[environment], pusherCreator, response)
 
 				Expect(deployResponse.Error).To(BeNil())
 
@@ -405,6 +410,20 @@ var _ = Describe("Deployer", func() {
 		})
 	})
 
+	Describe("passing the context", func() {
+		It("passes the context to the blue greener", func() {
+			type key string
+			ctx := context.WithValue(context.Background(), key("deployment"), "value")
+			pusherCreator.OnFinishCall.Returns.DeployResponse = interfaces.DeployResponse{
+				StatusCode: http.StatusOK,
+			}
+
+			deployer.Deploy(ctx, &deploymentInfo, environments[environment], pusherCreator, response)
+
+			Expect(blueGreener.ExecuteCall.Received.Context).To(Equal(ctx))
+		})
+	})
+
 	Describe("happy path deploying with a zip file in the request body", func() {
 		Context("when no errors occur", func() {
 			It("accepts the request and returns http.StatusOK", func() {
@@ -414,7 +433,7 @@ var _ = Describe("Deployer", func() {
 					StatusCode: http.StatusOK,
 				}
 
-				deployResponse := deployer.Deploy(&deploymentInfo, environments[environment], pusherCreator, response)
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, environments[environment], pusherCreator, response)
 				Expect(deployResponse.Error).To(BeNil())
 
 				Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
@@ -452,12 +471,15 @@ var _ = Describe("Deployer", func() {
 					randomizerMock,
 					nil,
 					log,
+					nil,
+					nil,
+					nil,
 				}
 			})
 
 			It("doesn't return an error", func() {
 
-				deployResponse := deployer.Deploy(&deploymentInfoNoCustomParams, environmentsNoCustomParams[environment], pusherCreator, response)
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfoNoCustomParams, environmentsNoCustomParams[environment], pusherCreator, response)
 
 				Expect(deployResponse.Error).ToNot(HaveOccurred())
 				Expect(blueGreener.ExecuteCall.Received.Environment).To(Equal(environmentsNoCustomParams[environment]))
@@ -468,9 +490,15 @@ var _ = Describe("Deployer", func() {
 		var (
 			deployer          interfaces.Deployer
 			pusherCreatorMock *mocks.PushManager
+			tempDirectories   *mocks.TempDirectoryTracker
+			progress          *mocks.ProgressReporter
+			maintenance       *mocks.FoundationMaintenance
 		)
 		BeforeEach(func() {
 			pusherCreatorMock = &mocks.PushManager{}
+			tempDirectories = &mocks.TempDirectoryTracker{}
+			progress = &mocks.ProgressReporter{}
+			maintenance = &mocks.FoundationMaintenance{}
 			deployer = Deployer{
 				c,
 				blueGreener,
@@ -479,20 +507,65 @@ var _ = Describe("Deployer", func() {
 				randomizerMock,
 				nil,
 				log,
+				tempDirectories,
+				progress,
+				maintenance,
 			}
 		})
 		Context("when no initialization errors occur", func() {
 			It("it calls setup on the provided action creator", func() {
 
-				deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreatorMock, response)
+				deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreatorMock, response)
 
 				Expect(pusherCreatorMock.SetUpCall.Called).To(Equal(true))
 			})
 		})
 
+		It("checks the buildpacks of the deployment after it is set up", func() {
+			env := S.Environment{Foundations: []string{"https://api1.example.com"}}
+
+			deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+			Expect(prechecker.AssertBuildpacksAvailableCall.Received.Environment).To(Equal(env))
+			Expect(prechecker.AssertBuildpacksAvailableCall.Received.DeploymentInfo).To(Equal(deploymentInfo))
+		})
+
+		Context("when buildpacks are missing", func() {
+			It("does not push and returns http.StatusInternalServerError", func() {
+				prechecker.AssertBuildpacksAvailableCall.Returns.Error = errors.New("buildpacks are missing")
+
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreatorMock, response)
+
+				Expect(deployResponse.Error).To(MatchError("buildpacks are missing"))
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusInternalServerError))
+				Expect(pusherCreatorMock.OnStartCall.Called).To(BeFalse())
+			})
+		})
+
+		It("checks the domains of the deployment after it is set up", func() {
+			env := S.Environment{Foundations: []string{"https://api1.example.com"}, Domain: "example.com"}
+
+			deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+			Expect(prechecker.AssertDomainsAvailableCall.Received.Environment).To(Equal(env))
+			Expect(prechecker.AssertDomainsAvailableCall.Received.DeploymentInfo).To(Equal(deploymentInfo))
+		})
+
+		Context("when domains are not available", func() {
+			It("does not push and returns http.StatusInternalServerError", func() {
+				prechecker.AssertDomainsAvailableCall.Returns.Error = errors.New("domains are not available")
+
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreatorMock, response)
+
+				Expect(deployResponse.Error).To(MatchError("domains are not available"))
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusInternalServerError))
+				Expect(pusherCreatorMock.OnStartCall.Called).To(BeFalse())
+			})
+		})
+
 		It("calls Start on the provided action creator", func() {
 
-			deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreatorMock, response)
+			deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreatorMock, response)
 
 			Expect(pusherCreatorMock.OnStartCall.Called).To(Equal(true))
 		})
@@ -501,22 +574,207 @@ var _ = Describe("Deployer", func() {
 			It("returns an error", func() {
 				pusherCreatorMock.OnStartCall.Returns.Err = errors.New("a test error")
 
-				deployResponse := deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreatorMock, response)
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreatorMock, response)
 
 				Expect(deployResponse.Error).To(Equal(pusherCreatorMock.OnStartCall.Returns.Err))
 			})
 		})
 
 		It("calls CleanUp on the provided action creator", func() {
-			deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreatorMock, response)
+			deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreatorMock, response)
 
 			Expect(pusherCreatorMock.CleanUpCall.Called).To(Equal(true))
 		})
 
 		It("calls OnFinish on the provided action creator", func() {
-			deployer.Deploy(&deploymentInfo, S.Environment{}, pusherCreatorMock, response)
+			deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreatorMock, response)
 
 			Expect(pusherCreatorMock.OnFinishCall.Called).To(Equal(true))
 		})
+
+		It("removes the temporary directories of the deployment", func() {
+			deploymentInfo.UUID = "uuid-1"
+
+			deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreatorMock, response)
+
+			Expect(tempDirectories.RemoveDeploymentCall.Received.DeploymentID).To(Equal("uuid-1"))
+		})
+
+		Context("when action creator SetUp fails", func() {
+			It("removes the temporary directories of the deployment", func() {
+				deploymentInfo.UUID = "uuid-1"
+				pusherCreatorMock.SetUpCall.Returns.Err = errors.New("a test error")
+
+				deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreatorMock, response)
+
+				Expect(tempDirectories.RemoveDeploymentCall.Received.DeploymentID).To(Equal("uuid-1"))
+			})
+		})
+
+		It("reports the phases of the deployment", func() {
+			deploymentInfo.UUID = "uuid-1"
+			pusherCreatorMock.OnFinishCall.Returns.DeployResponse = interfaces.DeployResponse{Error: errors.New("push failed")}
+			env := S.Environment{Foundations: []string{"https://api1.example.com", "https://api2.example.com"}}
+
+			deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+			Expect(progress.Calls).To(Equal([]string{
+				"StartPhase uuid-1 prechecking 2",
+				"StartPhase uuid-1 preparing 0",
+				"Finish uuid-1 push failed",
+			}))
+		})
+
+		Describe("the summary of the deployment", func() {
+			var env S.Environment
+
+			BeforeEach(func() {
+				env = S.Environment{Foundations: []string{"https://api1.example.com", "https://api2.example.com"}}
+				deploymentInfo.Instances = 4
+				deploymentInfo.ArtifactSize = 3 * 1024 * 1024
+			})
+
+			It("returns the summary and writes it at the end of the response", func() {
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+				Expect(deployResponse.Summary.Duration).To(BeNumerically(">", 0))
+				Expect(deployResponse.Summary.ArtifactSizeBytes).To(Equal(int64(3 * 1024 * 1024)))
+				Expect(deployResponse.Summary.Foundations).To(Equal(2))
+				Expect(deployResponse.Summary.FoundationsSucceeded).To(Equal(2))
+				Expect(deployResponse.Summary.FoundationsFailed).To(Equal(0))
+				Expect(deployResponse.Summary.Instances).To(Equal(uint16(4)))
+
+				Expect(response.String()).To(HaveSuffix("  foundations: 2 succeeded, 0 failed of 2\n  new instances: 4\n"))
+				Expect(response.String()).To(ContainSubstring("Deployment Summary:\n  duration: "))
+				Expect(response.String()).To(ContainSubstring("  artifact size: 3.0 MB\n"))
+			})
+
+			It("counts the foundations the action failed on", func() {
+				blueGreener.ExecuteCall.Returns.Error = bluegreen.PushError{PushErrors: []error{errors.New("push failed")}}
+
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+				Expect(deployResponse.Summary.FoundationsSucceeded).To(Equal(1))
+				Expect(deployResponse.Summary.FoundationsFailed).To(Equal(1))
+				Expect(response.String()).To(ContainSubstring("  foundations: 1 succeeded, 1 failed of 2\n"))
+			})
+
+			It("writes the summary when the prechecks fail", func() {
+				prechecker.AssertAllFoundationsUpCall.Returns.Error = errors.New("prechecker failed")
+
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+				Expect(deployResponse.Summary.Foundations).To(Equal(2))
+				Expect(deployResponse.Summary.FoundationsSucceeded).To(Equal(0))
+				Expect(deployResponse.Summary.FoundationsFailed).To(Equal(0))
+				Expect(response.String()).To(ContainSubstring("  foundations: 0 succeeded, 0 failed of 2\n"))
+			})
+		})
+
+		Describe("foundations in maintenance", func() {
+			var env S.Environment
+
+			BeforeEach(func() {
+				env = S.Environment{Name: "prod", Foundations: []string{"https://api1.example.com", "https://api2.example.com"}}
+				maintenance.InMaintenanceCall.Returns.Foundations = map[string]bool{"https://api2.example.com": true}
+			})
+
+			It("skips the foundations in maintenance with a warning", func() {
+				deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+				Expect(blueGreener.ExecuteCall.Received.Environment.Foundations).To(Equal([]string{"https://api1.example.com"}))
+				Expect(prechecker.AssertAllFoundationsUpCall.Received.Environment.Foundations).To(Equal([]string{"https://api1.example.com"}))
+				Expect(deploymentInfo.SkippedFoundations).To(Equal([]string{"https://api2.example.com"}))
+				Expect(response.String()).To(ContainSubstring("WARNING: skipping https://api2.example.com because it is in maintenance"))
+			})
+
+			It("counts the skipped foundations in the summary", func() {
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+				Expect(deployResponse.Summary.Foundations).To(Equal(1))
+				Expect(deployResponse.Summary.FoundationsSucceeded).To(Equal(1))
+				Expect(deployResponse.Summary.FoundationsSkipped).To(Equal(1))
+				Expect(response.String()).To(ContainSubstring("  skipped foundations in maintenance: 1\n"))
+			})
+
+			It("returns http.StatusServiceUnavailable when every foundation is in maintenance", func() {
+				maintenance.InMaintenanceCall.Returns.Foundations["https://api1.example.com"] = true
+
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusServiceUnavailable))
+				Expect(deployResponse.Error).To(MatchError(FoundationsInMaintenanceError{Environment: "prod"}))
+				Expect(pusherCreatorMock.SetUpCall.Called).To(BeFalse())
+				Expect(prechecker.AssertAllFoundationsUpCall.Received.Environment.Foundations).To(BeEmpty())
+			})
+		})
+
+		Describe("partial success", func() {
+			var env S.Environment
+
+			BeforeEach(func() {
+				env = S.Environment{Name: "prod", Foundations: []string{"https://api1.example.com", "https://api2.example.com"}}
+				blueGreener.ExecuteCall.Returns.Results = []S.FoundationResult{
+					{FoundationURL: "https://api1.example.com", Status: S.FoundationSucceeded},
+					{FoundationURL: "https://api2.example.com", Status: S.FoundationFailed, Error: "push failed"},
+				}
+				blueGreener.ExecuteCall.Returns.Error = bluegreen.PushError{PushErrors: []error{errors.New("push failed")}}
+				pusherCreatorMock.OnFinishCall.Returns.DeployResponse = interfaces.DeployResponse{
+					StatusCode: http.StatusInternalServerError,
+					Error:      blueGreener.ExecuteCall.Returns.Error,
+				}
+			})
+
+			It("returns http.StatusMultiStatus with the result on every foundation", func() {
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusMultiStatus))
+				Expect(deployResponse.PartialSuccess).To(BeTrue())
+				Expect(deployResponse.Error).To(HaveOccurred())
+				Expect(deployResponse.Foundations).To(Equal(blueGreener.ExecuteCall.Returns.Results))
+			})
+
+			It("writes the result on every foundation in the summary", func() {
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+				Expect(deployResponse.Summary.PartialResults).To(HaveLen(2))
+				Expect(response.String()).To(ContainSubstring("  partial success:\n    https://api1.example.com: succeeded\n    https://api2.example.com: failed: push failed\n"))
+			})
+
+			It("is a partial success when foundations in maintenance were skipped", func() {
+				maintenance.InMaintenanceCall.Returns.Foundations = map[string]bool{"https://api2.example.com": true}
+				blueGreener.ExecuteCall.Returns.Results = blueGreener.ExecuteCall.Returns.Results[:1]
+				blueGreener.ExecuteCall.Returns.Error = nil
+				pusherCreatorMock.OnFinishCall.Returns.DeployResponse = interfaces.DeployResponse{StatusCode: http.StatusOK}
+
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusMultiStatus))
+				Expect(deployResponse.PartialSuccess).To(BeTrue())
+				Expect(deployResponse.Foundations).To(ContainElement(S.FoundationResult{FoundationURL: "https://api2.example.com", Status: S.FoundationSkipped}))
+			})
+
+			It("is not a partial success when every foundation failed", func() {
+				blueGreener.ExecuteCall.Returns.Results[0].Status = S.FoundationRolledBack
+
+				deployResponse := deployer.Deploy(context.Background(), &deploymentInfo, env, pusherCreatorMock, response)
+
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusInternalServerError))
+				Expect(deployResponse.PartialSuccess).To(BeFalse())
+				Expect(response.String()).ToNot(ContainSubstring("partial success"))
+			})
+		})
+
+		It("finishes the progress when the prechecks fail", func() {
+			deploymentInfo.UUID = "uuid-1"
+			prechecker.AssertAllFoundationsUpCall.Returns.Error = errors.New("prechecker failed")
+
+			deployer.Deploy(context.Background(), &deploymentInfo, S.Environment{}, pusherCreatorMock, response)
+
+			Expect(progress.Calls).To(Equal([]string{
+				"StartPhase uuid-1 prechecking 0",
+				"Finish uuid-1 prechecker failed",
+			}))
+		})
 	})
 })
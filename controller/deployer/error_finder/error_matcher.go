@@ -2,8 +2,11 @@ package error_finder
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
+	"strings"
 
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/courier/executor"
 	"github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/structs"
 )
@@ -21,6 +24,12 @@ func (f *ErrorMatcherFactory) CreateErrorMatcher(descriptor structs.ErrorMatcher
 		return &RegExErrorMatcher{}, err
 	}
 
+	switch descriptor.Stream {
+	case "", structs.StdoutStream, structs.StderrStream:
+	default:
+		return &RegExErrorMatcher{}, fmt.Errorf("error matcher stream must be %s or %s: %s", structs.StdoutStream, structs.StderrStream, descriptor.Stream)
+	}
+
 	description := descriptor.Description
 	if description == "" {
 		description = "This error does not have a description."
@@ -36,7 +45,8 @@ func (f *ErrorMatcherFactory) CreateErrorMatcher(descriptor structs.ErrorMatcher
 		regex:       regex,
 		pattern:     descriptor.Pattern,
 		solution:    solution,
-		code:        descriptor.Code}, nil
+		code:        descriptor.Code,
+		stream:      descriptor.Stream}, nil
 }
 
 type RegExErrorMatcher struct {
@@ -45,6 +55,7 @@ type RegExErrorMatcher struct {
 	solution    string
 	regex       *regexp.Regexp
 	code        string
+	stream      string
 }
 
 func (m *RegExErrorMatcher) Descriptor() string {
@@ -52,9 +63,29 @@ func (m *RegExErrorMatcher) Descriptor() string {
 }
 
 func (m *RegExErrorMatcher) Match(matchTo []byte) interfaces.LogMatchedError {
-	matches := m.regex.FindAllString(string(matchTo), -1)
+	matches := m.regex.FindAllString(m.filter(string(matchTo)), -1)
 	if len(matches) > 0 {
 		return CreateLogMatchedError(m.description, matches, m.solution, m.code)
 	}
 	return nil
 }
+
+// filter returns the lines of the stream of the matcher. Lines of standard error start with the
+// StderrLabel of the executor, which is removed.
+func (m *RegExErrorMatcher) filter(text string) string {
+	if m.stream == "" {
+		return text
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		isStderr := strings.HasPrefix(line, executor.StderrLabel)
+		switch {
+		case m.stream == structs.StderrStream && isStderr:
+			lines = append(lines, strings.TrimPrefix(line, executor.StderrLabel))
+		case m.stream == structs.StdoutStream && !isStderr:
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
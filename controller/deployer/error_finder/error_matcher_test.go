@@ -80,4 +80,44 @@ var _ = Describe("RegExErrorMatcher", func() {
 		})
 		Expect(errorMatcher.Descriptor()).To(Equal("a description: a regex pattern: No recommended solution available.: "))
 	})
+
+	Context("when the matcher has a stream", func() {
+		output := []byte("Pushing app\n[stderr] FAILED: insufficient resources\nFAILED to stage\n")
+
+		It("only matches lines of standard error", func() {
+			factory := ErrorMatcherFactory{}
+			errorMatcher, err := factory.CreateErrorMatcher(structs.ErrorMatcherDescriptor{
+				Description: "stderr failure",
+				Pattern:     "^FAILED.*",
+				Stream:      structs.StderrStream,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			matched := errorMatcher.Match(output)
+			Expect(matched.Details()).To(Equal([]string{"FAILED: insufficient resources"}))
+		})
+
+		It("only matches lines of standard output", func() {
+			factory := ErrorMatcherFactory{}
+			errorMatcher, err := factory.CreateErrorMatcher(structs.ErrorMatcherDescriptor{
+				Description: "stdout failure",
+				Pattern:     "FAILED.*",
+				Stream:      structs.StdoutStream,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			matched := errorMatcher.Match(output)
+			Expect(matched.Details()).To(Equal([]string{"FAILED to stage"}))
+		})
+
+		It("returns an error for an unknown stream", func() {
+			factory := ErrorMatcherFactory{}
+			_, err := factory.CreateErrorMatcher(structs.ErrorMatcherDescriptor{
+				Pattern: "FAILED",
+				Stream:  "stdin",
+			})
+
+			Expect(err).To(MatchError("error matcher stream must be stdout or stderr: stdin"))
+		})
+	})
 })
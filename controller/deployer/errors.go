@@ -38,3 +38,20 @@ type EnvironmentNotFoundError struct {
 func (e EnvironmentNotFoundError) Error() string {
 	return fmt.Sprintf("environment not found: %s", e.Environment)
 }
+
+type FoundationNotFoundError struct {
+	Foundation  string
+	Environment string
+}
+
+func (e FoundationNotFoundError) Error() string {
+	return fmt.Sprintf("foundation %s not found in environment %s", e.Foundation, e.Environment)
+}
+
+type FoundationsInMaintenanceError struct {
+	Environment string
+}
+
+func (e FoundationsInMaintenanceError) Error() string {
+	return fmt.Sprintf("every foundation of environment %s is in maintenance", e.Environment)
+}
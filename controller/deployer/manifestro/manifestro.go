@@ -1,7 +1,13 @@
 package manifestro
 
 import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
 	"github.com/cloudfoundry-incubator/candiedyaml"
+	S "github.com/compozed/deployadactyl/structs"
 )
 
 type manifestYaml struct {
@@ -24,3 +30,256 @@ func GetInstances(manifest string) *uint16 {
 
 	return m.Applications[0].Instances
 }
+
+// GetBuildpacks reads a Cloud Foundry manifest as a string and returns the names of the buildpacks its applications
+// request, in the order they are requested.
+//
+// Buildpacks given by URL are left out, since they are not installed on the foundation.
+func GetBuildpacks(manifest string) []string {
+	var m struct {
+		Applications []struct {
+			Buildpack  string
+			Buildpacks []string
+		}
+	}
+
+	err := candiedyaml.Unmarshal([]byte(manifest), &m)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	for _, application := range m.Applications {
+		requested := application.Buildpacks
+		if application.Buildpack != "" {
+			requested = append([]string{application.Buildpack}, requested...)
+		}
+
+		for _, name := range requested {
+			if name == "" || name == "default" || name == "null" || strings.Contains(name, "://") || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// GetCustomRoutes reads a Cloud Foundry manifest as a string and returns the custom-routes of its applications,
+// which are mapped to the application once it is pushed.
+func GetCustomRoutes(manifest string) []string {
+	var m struct {
+		Applications []struct {
+			CustomRoutes []struct {
+				Route string
+			} `yaml:"custom-routes"`
+		}
+	}
+
+	err := candiedyaml.Unmarshal([]byte(manifest), &m)
+	if err != nil {
+		return nil
+	}
+
+	var routes []string
+	for _, application := range m.Applications {
+		for _, route := range application.CustomRoutes {
+			if route.Route != "" {
+				routes = append(routes, route.Route)
+			}
+		}
+	}
+
+	return routes
+}
+
+// Generate executes the manifest template of an environment with the DeploymentInfo of a deployment.
+//
+// Returns the manifest, or an error if the template cannot be parsed or executed or does not produce YAML.
+func Generate(manifestTemplate string, info S.DeploymentInfo) (string, error) {
+	t, err := template.New("manifest").Parse(manifestTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	manifest := &bytes.Buffer{}
+	err = t.Execute(manifest, info)
+	if err != nil {
+		return "", err
+	}
+
+	var m manifestYaml
+	err = candiedyaml.Unmarshal(manifest.Bytes(), &m)
+	if err != nil {
+		return "", err
+	}
+
+	return manifest.String(), nil
+}
+
+// Overlay merges the overlay of an environment onto every application of a manifest. Without a manifest, the
+// overlay is applied to a manifest of the application alone.
+//
+// Returns the merged manifest. Attributes of the manifest the overlay does not know about are kept.
+func Overlay(manifest, appName string, overlay S.ManifestOverlay) (string, error) {
+	m := map[interface{}]interface{}{}
+	if strings.TrimSpace(manifest) != "" {
+		err := candiedyaml.Unmarshal([]byte(manifest), &m)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	applications, _ := m["applications"].([]interface{})
+	if len(applications) == 0 {
+		applications = []interface{}{map[interface{}]interface{}{"name": appName}}
+	}
+
+	for i, a := range applications {
+		application, ok := a.(map[interface{}]interface{})
+		if !ok {
+			return "", fmt.Errorf("application %d of the manifest is not a map", i)
+		}
+
+		if overlay.Instances > 0 {
+			application["instances"] = overlay.Instances
+		}
+		if overlay.Memory != "" {
+			application["memory"] = overlay.Memory
+		}
+		if overlay.DiskQuota != "" {
+			application["disk_quota"] = overlay.DiskQuota
+		}
+
+		if len(overlay.Env) > 0 {
+			env, _ := application["env"].(map[interface{}]interface{})
+			if env == nil {
+				env = map[interface{}]interface{}{}
+			}
+			for name, value := range overlay.Env {
+				env[name] = value
+			}
+			application["env"] = env
+		}
+
+		if len(overlay.Services) > 0 {
+			services, _ := application["services"].([]interface{})
+			for _, service := range overlay.Services {
+				if !hasService(services, service) {
+					services = append(services, service)
+				}
+			}
+			application["services"] = services
+		}
+
+		applications[i] = application
+	}
+	m["applications"] = applications
+
+	merged, err := candiedyaml.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(merged), nil
+}
+
+// Merge merges an override onto a manifest, as when a deployment request tunes the manifest of its artifact.
+// Applications of the override are merged onto the application of the manifest with the same name, or onto its only
+// application when the override has a single application without a name, and are added otherwise. Maps are merged
+// key by key, and any other value of the override replaces the one of the manifest.
+//
+// Returns the merged manifest, or an error if either manifest is not YAML.
+func Merge(manifest, override string) (string, error) {
+	m := map[interface{}]interface{}{}
+	if strings.TrimSpace(manifest) != "" {
+		err := candiedyaml.Unmarshal([]byte(manifest), &m)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	o := map[interface{}]interface{}{}
+	err := candiedyaml.Unmarshal([]byte(override), &o)
+	if err != nil {
+		return "", err
+	}
+
+	applications, _ := m["applications"].([]interface{})
+	overrides, _ := o["applications"].([]interface{})
+	delete(o, "applications")
+	mergeMaps(m, o)
+
+	for i, a := range overrides {
+		application, ok := a.(map[interface{}]interface{})
+		if !ok {
+			return "", fmt.Errorf("application %d of the override is not a map", i)
+		}
+
+		j := -1
+		for k, existing := range applications {
+			if e, ok := existing.(map[interface{}]interface{}); ok && application["name"] != nil && e["name"] == application["name"] {
+				j = k
+				break
+			}
+		}
+		if application["name"] == nil && len(overrides) == 1 && len(applications) == 1 {
+			j = 0
+		}
+
+		if j < 0 {
+			applications = append(applications, application)
+			continue
+		}
+
+		existing, ok := applications[j].(map[interface{}]interface{})
+		if !ok {
+			return "", fmt.Errorf("application %d of the manifest is not a map", j)
+		}
+		applications[j] = mergeMaps(existing, application)
+	}
+	if len(applications) > 0 {
+		m["applications"] = applications
+	}
+
+	merged, err := candiedyaml.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(merged), nil
+}
+
+// mergeMaps merges the override onto the map, key by key, and returns the map.
+func mergeMaps(m, override map[interface{}]interface{}) map[interface{}]interface{} {
+	for key, value := range override {
+		if o, ok := value.(map[interface{}]interface{}); ok {
+			if existing, ok := m[key].(map[interface{}]interface{}); ok {
+				m[key] = mergeMaps(existing, o)
+				continue
+			}
+		}
+		m[key] = value
+	}
+	return m
+}
+
+// hasService reports whether the services of a manifest, given by name or as a map with a name, include a service.
+func hasService(services []interface{}, name string) bool {
+	for _, service := range services {
+		switch s := service.(type) {
+		case string:
+			if s == name {
+				return true
+			}
+		case map[interface{}]interface{}:
+			if s["name"] == name {
+				return true
+			}
+		}
+	}
+	return false
+}
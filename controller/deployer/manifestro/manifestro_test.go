@@ -1,7 +1,9 @@
 package manifestro_test
 
 import (
+	"github.com/cloudfoundry-incubator/candiedyaml"
 	. "github.com/compozed/deployadactyl/controller/deployer/manifestro"
+	S "github.com/compozed/deployadactyl/structs"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -110,4 +112,248 @@ applications:
 			})
 		})
 	})
+
+	Describe("GetBuildpacks", func() {
+		It("returns the buildpacks requested by every application once", func() {
+			manifest := `---
+applications:
+- name: example
+  buildpack: java_buildpack
+- name: example2
+  buildpacks:
+  - nodejs_buildpack
+  - java_buildpack
+  - https://github.com/cloudfoundry/go-buildpack.git
+- name: example3
+  buildpack: default`
+
+			Expect(GetBuildpacks(manifest)).To(Equal([]string{"java_buildpack", "nodejs_buildpack"}))
+		})
+
+		It("returns nothing for a manifest without buildpacks", func() {
+			Expect(GetBuildpacks("applications:\n- name: example")).To(BeEmpty())
+			Expect(GetBuildpacks("")).To(BeEmpty())
+		})
+	})
+
+	Describe("GetCustomRoutes", func() {
+		It("returns the custom routes of every application", func() {
+			manifest := `---
+applications:
+- name: example
+  custom-routes:
+  - route: search.example.com
+  - route: example.com/search
+- name: example2
+  custom-routes:
+  - route: search.internal.example.com`
+
+			Expect(GetCustomRoutes(manifest)).To(Equal([]string{"search.example.com", "example.com/search", "search.internal.example.com"}))
+		})
+
+		It("returns nothing for a manifest without custom routes", func() {
+			Expect(GetCustomRoutes("applications:\n- name: example")).To(BeEmpty())
+		})
+	})
+
+	Describe("Generate", func() {
+		manifestTemplate := `---
+applications:
+- name: {{.AppName}}
+  memory: {{or .Data.memory "1G"}}
+  instances: {{.Instances}}
+  routes:
+  - route: {{.AppName}}.{{.Domain}}
+  env:{{range $name, $value := .EnvironmentVariables}}
+    {{$name}}: {{$value}}{{end}}
+`
+
+		It("executes the template with the deployment info", func() {
+			info := S.DeploymentInfo{
+				AppName:              "search",
+				Domain:               "example.com",
+				Instances:            3,
+				EnvironmentVariables: map[string]string{"FEATURE": "on"},
+				Data:                 S.Params{"memory": "2G"},
+			}
+
+			manifest, err := Generate(manifestTemplate, info)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest).To(ContainSubstring("- name: search\n  memory: 2G\n  instances: 3"))
+			Expect(manifest).To(ContainSubstring("- route: search.example.com"))
+			Expect(manifest).To(ContainSubstring("    FEATURE: on"))
+			Expect(*GetInstances(manifest)).To(Equal(uint16(3)))
+		})
+
+		It("returns an error when the template cannot be executed", func() {
+			_, err := Generate("{{.Unknown}}", S.DeploymentInfo{})
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error when the template does not produce YAML", func() {
+			_, err := Generate("{{.AppName}}: [", S.DeploymentInfo{AppName: "search"})
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Overlay", func() {
+		var overlay S.ManifestOverlay
+
+		BeforeEach(func() {
+			overlay = S.ManifestOverlay{
+				Instances: 4,
+				Memory:    "2G",
+				Env:       map[string]string{"REGION": "east"},
+				Services:  []string{"logging", "database"},
+			}
+		})
+
+		It("merges the overlay onto every application", func() {
+			manifest := `---
+applications:
+- name: search
+  memory: 1G
+  instances: 1
+  buildpack: java_buildpack
+  env:
+    FEATURE: "on"
+    REGION: west
+  services:
+  - database
+- name: search-worker
+`
+
+			merged, err := Overlay(manifest, "search", overlay)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(*GetInstances(merged)).To(Equal(uint16(4)))
+
+			m := struct {
+				Applications []struct {
+					Name      string
+					Memory    string
+					Buildpack string
+					Env       map[string]string
+					Services  []string
+				}
+			}{}
+			Expect(candiedyaml.Unmarshal([]byte(merged), &m)).To(Succeed())
+			Expect(m.Applications).To(HaveLen(2))
+			Expect(m.Applications[0].Name).To(Equal("search"))
+			Expect(m.Applications[0].Memory).To(Equal("2G"))
+			Expect(m.Applications[0].Buildpack).To(Equal("java_buildpack"))
+			Expect(m.Applications[0].Env).To(Equal(map[string]string{"FEATURE": "on", "REGION": "east"}))
+			Expect(m.Applications[0].Services).To(Equal([]string{"database", "logging"}))
+			Expect(m.Applications[1].Memory).To(Equal("2G"))
+			Expect(m.Applications[1].Services).To(Equal([]string{"logging", "database"}))
+		})
+
+		It("applies the overlay to the application without a manifest", func() {
+			merged, err := Overlay("", "search", S.ManifestOverlay{Instances: 2})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(merged).To(ContainSubstring("name: search"))
+			Expect(*GetInstances(merged)).To(Equal(uint16(2)))
+		})
+
+		It("returns an error for a manifest that is not YAML", func() {
+			_, err := Overlay("applications: [", "search", overlay)
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Merge", func() {
+		manifest := `---
+applications:
+- name: search
+  memory: 1G
+  instances: 1
+  buildpack: java_buildpack
+  env:
+    FEATURE: "on"
+    REGION: west
+  routes:
+  - route: search.example.com
+- name: search-worker
+  memory: 512M
+`
+
+		type merged struct {
+			Applications []struct {
+				Name      string
+				Memory    string
+				Buildpack string
+				Env       map[string]string
+				Routes    []struct {
+					Route string
+				}
+			}
+		}
+
+		It("merges the applications of the override onto those with the same name", func() {
+			override := `---
+applications:
+- name: search-worker
+  memory: 2G
+- name: search
+  env:
+    REGION: east
+  routes:
+  - route: search.internal.example.com
+`
+
+			result, err := Merge(manifest, override)
+			Expect(err).ToNot(HaveOccurred())
+
+			var m merged
+			Expect(candiedyaml.Unmarshal([]byte(result), &m)).To(Succeed())
+			Expect(m.Applications).To(HaveLen(2))
+			Expect(m.Applications[0].Name).To(Equal("search"))
+			Expect(m.Applications[0].Memory).To(Equal("1G"))
+			Expect(m.Applications[0].Buildpack).To(Equal("java_buildpack"))
+			Expect(m.Applications[0].Env).To(Equal(map[string]string{"FEATURE": "on", "REGION": "east"}))
+			Expect(m.Applications[0].Routes).To(HaveLen(1))
+			Expect(m.Applications[0].Routes[0].Route).To(Equal("search.internal.example.com"))
+			Expect(m.Applications[1].Memory).To(Equal("2G"))
+			Expect(*GetInstances(result)).To(Equal(uint16(1)))
+		})
+
+		It("merges a single application without a name onto the only application of the manifest", func() {
+			result, err := Merge("---\napplications:\n- name: search\n  memory: 1G\n", "applications:\n- instances: 3\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			var m merged
+			Expect(candiedyaml.Unmarshal([]byte(result), &m)).To(Succeed())
+			Expect(m.Applications).To(HaveLen(1))
+			Expect(m.Applications[0].Memory).To(Equal("1G"))
+			Expect(*GetInstances(result)).To(Equal(uint16(3)))
+		})
+
+		It("adds the applications the manifest does not have", func() {
+			result, err := Merge(manifest, "applications:\n- name: search-admin\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			var m merged
+			Expect(candiedyaml.Unmarshal([]byte(result), &m)).To(Succeed())
+			Expect(m.Applications).To(HaveLen(3))
+			Expect(m.Applications[2].Name).To(Equal("search-admin"))
+		})
+
+		It("uses the override without a manifest", func() {
+			result, err := Merge("", "applications:\n- name: search\n  instances: 2\n")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*GetInstances(result)).To(Equal(uint16(2)))
+		})
+
+		It("returns an error for an override that is not YAML", func() {
+			_, err := Merge(manifest, "applications: [")
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,90 @@
+package prechecker
+
+import (
+	"net/http"
+
+	"github.com/compozed/deployadactyl/controller/deployer/manifestro"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// MissingBuildpacks are the buildpacks of a manifest a foundation does not have, or has disabled.
+type MissingBuildpacks struct {
+	FoundationURL string
+	Buildpacks    []string
+}
+
+// AssertBuildpacksAvailable checks that every foundation has the buildpacks the manifest of the deployment
+// requests by name, and has them enabled. The manifest of the artifact is checked when the deployment has none.
+//
+// Returns a MissingBuildpacksError with the missing buildpacks of every foundation.
+func (p Prechecker) AssertBuildpacksAvailable(environment S.Environment, deploymentInfo S.DeploymentInfo) error {
+	manifest, err := deploymentManifest(deploymentInfo)
+	if err != nil {
+		return BuildpackListError{Err: err}
+	}
+
+	requested := manifestro.GetBuildpacks(manifest)
+	if len(requested) == 0 {
+		return nil
+	}
+
+	client := newInsecureClient()
+
+	var missing []MissingBuildpacks
+	for _, foundationURL := range environment.Foundations {
+		available, err := listBuildpacks(client, foundationURL, deploymentInfo.Username, deploymentInfo.Password)
+		if err != nil {
+			return BuildpackListError{FoundationURL: foundationURL, Err: err}
+		}
+
+		foundation := MissingBuildpacks{FoundationURL: foundationURL}
+		for _, name := range requested {
+			if !available[name] {
+				foundation.Buildpacks = append(foundation.Buildpacks, name)
+			}
+		}
+		if len(foundation.Buildpacks) > 0 {
+			missing = append(missing, foundation)
+		}
+	}
+
+	if len(missing) > 0 {
+		return MissingBuildpacksError{Missing: missing}
+	}
+	return nil
+}
+
+type buildpacks struct {
+	NextURL   string `json:"next_url"`
+	Resources []struct {
+		Entity struct {
+			Name    string `json:"name"`
+			Enabled bool   `json:"enabled"`
+		} `json:"entity"`
+	} `json:"resources"`
+}
+
+// listBuildpacks logs in to the foundation and returns whether each of its buildpacks is enabled.
+func listBuildpacks(client *http.Client, foundationURL, username, password string) (map[string]bool, error) {
+	accessToken, err := login(client, foundationURL, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	available := map[string]bool{}
+	next := "/v2/buildpacks?results-per-page=100"
+	for next != "" {
+		var page buildpacks
+		err = getJSON(client, foundationURL+next, accessToken, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range page.Resources {
+			available[resource.Entity.Name] = available[resource.Entity.Name] || resource.Entity.Enabled
+		}
+		next = page.NextURL
+	}
+
+	return available, nil
+}
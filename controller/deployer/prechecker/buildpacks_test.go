@@ -0,0 +1,139 @@
+package prechecker_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/prechecker"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AssertBuildpacksAvailable", func() {
+	var (
+		prechecker     Prechecker
+		foundation     *httptest.Server
+		other          *httptest.Server
+		environment    S.Environment
+		deploymentInfo S.DeploymentInfo
+		requests       []string
+	)
+
+	newFoundation := func(pages ...string) *httptest.Server {
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+
+			switch r.URL.Path {
+			case "/v2/info":
+				fmt.Fprintf(w, `{"token_endpoint": "%s/uaa"}`, server.URL)
+			case "/uaa/oauth/token":
+				r.ParseForm()
+				if r.Form.Get("username") != "username" || r.Form.Get("password") != "password" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				fmt.Fprint(w, `{"access_token": "token"}`)
+			case "/v2/buildpacks":
+				if r.Header.Get("Authorization") != "bearer token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				page := 0
+				if r.URL.Query().Get("page") == "2" {
+					page = 1
+				}
+				fmt.Fprint(w, pages[page])
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		return server
+	}
+
+	BeforeEach(func() {
+		requests = nil
+		prechecker = Prechecker{EventManager: &mocks.EventManager{}}
+
+		foundation = newFoundation(
+			`{"next_url": "/v2/buildpacks?page=2", "resources": [{"entity": {"name": "java_buildpack", "enabled": true}}]}`,
+			`{"next_url": null, "resources": [{"entity": {"name": "nodejs_buildpack", "enabled": true}}]}`,
+		)
+		other = newFoundation(
+			`{"resources": [{"entity": {"name": "java_buildpack", "enabled": false}}]}`,
+		)
+
+		environment = S.Environment{Foundations: []string{foundation.URL, other.URL}}
+		deploymentInfo = S.DeploymentInfo{
+			Username: "username",
+			Password: "password",
+			Manifest: `---
+applications:
+- name: example
+  buildpacks:
+  - java_buildpack
+  - nodejs_buildpack`,
+		}
+	})
+
+	AfterEach(func() {
+		foundation.Close()
+		other.Close()
+	})
+
+	It("returns the missing or disabled buildpacks of every foundation", func() {
+		err := prechecker.AssertBuildpacksAvailable(environment, deploymentInfo)
+
+		Expect(err).To(MatchError(MissingBuildpacksError{Missing: []MissingBuildpacks{
+			{FoundationURL: other.URL, Buildpacks: []string{"java_buildpack", "nodejs_buildpack"}},
+		}}))
+		Expect(err.Error()).To(ContainSubstring(other.URL + ": java_buildpack, nodejs_buildpack"))
+	})
+
+	It("succeeds when every foundation has the buildpacks", func() {
+		environment.Foundations = []string{foundation.URL}
+
+		Expect(prechecker.AssertBuildpacksAvailable(environment, deploymentInfo)).To(Succeed())
+		Expect(requests).To(Equal([]string{"GET /v2/info", "POST /uaa/oauth/token", "GET /v2/buildpacks", "GET /v2/buildpacks"}))
+	})
+
+	It("checks the manifest of the artifact when the deployment has none", func() {
+		appPath, err := ioutil.TempDir("", "prechecker")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(appPath)
+		Expect(ioutil.WriteFile(filepath.Join(appPath, "manifest.yml"), []byte("applications:\n- name: example\n  buildpack: go_buildpack\n"), 0644)).To(Succeed())
+
+		deploymentInfo.Manifest = ""
+		deploymentInfo.AppPath = appPath
+		environment.Foundations = []string{foundation.URL}
+
+		err = prechecker.AssertBuildpacksAvailable(environment, deploymentInfo)
+
+		Expect(err).To(MatchError(MissingBuildpacksError{Missing: []MissingBuildpacks{
+			{FoundationURL: foundation.URL, Buildpacks: []string{"go_buildpack"}},
+		}}))
+	})
+
+	It("does not contact the foundations when the manifest names no buildpacks", func() {
+		deploymentInfo.Manifest = "applications:\n- name: example\n  buildpack: https://github.com/cloudfoundry/go-buildpack.git\n"
+
+		Expect(prechecker.AssertBuildpacksAvailable(environment, deploymentInfo)).To(Succeed())
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("returns an error when the buildpacks cannot be listed", func() {
+		deploymentInfo.Password = "wrong"
+
+		err := prechecker.AssertBuildpacksAvailable(environment, deploymentInfo)
+
+		Expect(err).To(BeAssignableToTypeOf(BuildpackListError{}))
+		Expect(err.Error()).To(ContainSubstring("cannot list the buildpacks of " + foundation.URL))
+	})
+})
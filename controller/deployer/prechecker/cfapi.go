@@ -0,0 +1,70 @@
+package prechecker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type info struct {
+	TokenEndpoint string `json:"token_endpoint"`
+}
+
+type token struct {
+	AccessToken string `json:"access_token"`
+}
+
+// login gets an access token for the Cloud Foundry API of the foundation from its UAA.
+func login(client *http.Client, foundationURL, username, password string) (string, error) {
+	var i info
+	err := getJSON(client, foundationURL+"/v2/info", "", &i)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{"grant_type": {"password"}, "username": {username}, "password": {password}}
+	request, err := http.NewRequest("POST", strings.TrimSuffix(i.TokenEndpoint, "/")+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth("cf", "")
+
+	var t token
+	err = doJSON(client, request, &t)
+	if err != nil {
+		return "", err
+	}
+
+	return t.AccessToken, nil
+}
+
+func getJSON(client *http.Client, url, accessToken string, v interface{}) error {
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		request.Header.Set("Authorization", "bearer "+accessToken)
+	}
+
+	return doJSON(client, request, v)
+}
+
+func doJSON(client *http.Client, request *http.Request, v interface{}) error {
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", request.Method, request.URL.Path, response.Status)
+	}
+
+	return json.NewDecoder(response.Body).Decode(v)
+}
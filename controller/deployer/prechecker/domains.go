@@ -0,0 +1,155 @@
+package prechecker
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/compozed/deployadactyl/controller/deployer/manifestro"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// UnavailableDomains are the domains, or the custom routes without a domain, a foundation does not make available
+// to the org of a deployment.
+type UnavailableDomains struct {
+	FoundationURL string
+	Domains       []string
+}
+
+// AssertDomainsAvailable checks that the load balanced domain of the environment, the domains of the routes of the
+// environment and of the deployment, and a domain of every custom route in the manifest of the deployment, are
+// available to the org of the deployment on every foundation. The manifest of the artifact is checked when the
+// deployment has none.
+//
+// Returns an UnavailableDomainsError with the unavailable domains of every foundation.
+func (p Prechecker) AssertDomainsAvailable(environment S.Environment, deploymentInfo S.DeploymentInfo) error {
+	manifest, err := deploymentManifest(deploymentInfo)
+	if err != nil {
+		return DomainListError{Err: err}
+	}
+
+	domains := routeDomains(environment, deploymentInfo)
+	routes := manifestro.GetCustomRoutes(manifest)
+	if len(domains) == 0 && len(routes) == 0 {
+		return nil
+	}
+
+	client := newInsecureClient()
+
+	var unavailable []UnavailableDomains
+	for _, foundationURL := range environment.Foundations {
+		available, err := listDomains(client, foundationURL, deploymentInfo.Org, deploymentInfo.Username, deploymentInfo.Password)
+		if err != nil {
+			return DomainListError{FoundationURL: foundationURL, Err: err}
+		}
+
+		foundation := UnavailableDomains{FoundationURL: foundationURL}
+		for _, domain := range domains {
+			if !available[domain] {
+				foundation.Domains = append(foundation.Domains, domain)
+			}
+		}
+		for _, route := range routes {
+			if !routeHasDomain(route, available) {
+				foundation.Domains = append(foundation.Domains, route)
+			}
+		}
+		if len(foundation.Domains) > 0 {
+			unavailable = append(unavailable, foundation)
+		}
+	}
+
+	if len(unavailable) > 0 {
+		return UnavailableDomainsError{Org: deploymentInfo.Org, Unavailable: unavailable}
+	}
+	return nil
+}
+
+// routeDomains returns the load balanced domain of the environment and the domains of the routes of the environment
+// and of the deployment, each once.
+func routeDomains(environment S.Environment, deploymentInfo S.DeploymentInfo) []string {
+	var domains []string
+	seen := map[string]bool{}
+	add := func(domain string) {
+		if domain != "" && !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+
+	add(environment.Domain)
+	for _, route := range environment.Routes {
+		add(route.Domain)
+	}
+	for _, route := range deploymentInfo.Routes {
+		add(route.Domain)
+	}
+	return domains
+}
+
+// routeHasDomain reports whether the route is a domain, or a host of a domain, like the route mapper maps it.
+func routeHasDomain(route string, domains map[string]bool) bool {
+	host := strings.SplitN(route, "/", 2)[0]
+	if domains[host] {
+		return true
+	}
+
+	hostAndDomain := strings.SplitN(host, ".", 2)
+	return len(hostAndDomain) == 2 && domains[hostAndDomain[1]]
+}
+
+type organizations struct {
+	Resources []struct {
+		GUID string `json:"guid"`
+	} `json:"resources"`
+}
+
+type domains struct {
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+	Resources []struct {
+		Name string `json:"name"`
+	} `json:"resources"`
+}
+
+// listDomains logs in to the foundation and returns the domains available to the org.
+func listDomains(client *http.Client, foundationURL, org, username, password string) (map[string]bool, error) {
+	accessToken, err := login(client, foundationURL, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var orgs organizations
+	err = getJSON(client, foundationURL+"/v3/organizations?names="+url.QueryEscape(org), accessToken, &orgs)
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs.Resources) == 0 {
+		return nil, fmt.Errorf("org %s not found", org)
+	}
+
+	available := map[string]bool{}
+	next := foundationURL + "/v3/organizations/" + orgs.Resources[0].GUID + "/domains?per_page=5000"
+	for next != "" {
+		var page domains
+		err = getJSON(client, next, accessToken, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range page.Resources {
+			available[resource.Name] = true
+		}
+
+		next = ""
+		if page.Pagination.Next != nil {
+			next = page.Pagination.Next.Href
+		}
+	}
+
+	return available, nil
+}
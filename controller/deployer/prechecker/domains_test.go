@@ -0,0 +1,115 @@
+package prechecker_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/prechecker"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AssertDomainsAvailable", func() {
+	var (
+		prechecker     Prechecker
+		foundation     *httptest.Server
+		environment    S.Environment
+		deploymentInfo S.DeploymentInfo
+		requests       []string
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		prechecker = Prechecker{EventManager: &mocks.EventManager{}}
+
+		foundation = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+
+			switch r.URL.Path {
+			case "/v2/info":
+				fmt.Fprintf(w, `{"token_endpoint": "%s"}`, foundation.URL)
+			case "/oauth/token":
+				fmt.Fprint(w, `{"access_token": "token"}`)
+			case "/v3/organizations":
+				if r.URL.Query().Get("names") != "org" {
+					fmt.Fprint(w, `{"resources": []}`)
+					return
+				}
+				fmt.Fprint(w, `{"resources": [{"guid": "org-guid"}]}`)
+			case "/v3/organizations/org-guid/domains":
+				if r.URL.Query().Get("page") == "2" {
+					fmt.Fprint(w, `{"pagination": {"next": null}, "resources": [{"name": "internal.example.com"}]}`)
+					return
+				}
+				fmt.Fprintf(w, `{"pagination": {"next": {"href": "%s/v3/organizations/org-guid/domains?page=2"}}, "resources": [{"name": "apps.example.com"}]}`, foundation.URL)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		environment = S.Environment{Foundations: []string{foundation.URL}, Domain: "apps.example.com"}
+		deploymentInfo = S.DeploymentInfo{
+			Org:      "org",
+			Username: "username",
+			Password: "password",
+			Manifest: `---
+applications:
+- name: example
+  custom-routes:
+  - route: search.internal.example.com
+  - route: internal.example.com/search`,
+		}
+	})
+
+	AfterEach(func() {
+		foundation.Close()
+	})
+
+	It("succeeds when the domains are available to the org", func() {
+		Expect(prechecker.AssertDomainsAvailable(environment, deploymentInfo)).To(Succeed())
+		Expect(requests).To(ContainElement("GET /v3/organizations/org-guid/domains"))
+	})
+
+	It("returns the domain of the environment and the routes without an available domain", func() {
+		environment.Domain = "example.com"
+		deploymentInfo.Manifest = "applications:\n- name: example\n  custom-routes:\n  - route: search.other.example.com\n"
+
+		err := prechecker.AssertDomainsAvailable(environment, deploymentInfo)
+
+		Expect(err).To(MatchError(UnavailableDomainsError{Org: "org", Unavailable: []UnavailableDomains{
+			{FoundationURL: foundation.URL, Domains: []string{"example.com", "search.other.example.com"}},
+		}}))
+		Expect(err.Error()).To(ContainSubstring("domains are not available to org org: " + foundation.URL + ": example.com, search.other.example.com"))
+	})
+
+	It("returns the domains of the routes of the environment and of the deployment that are not available", func() {
+		environment.Routes = []S.Route{{Hostname: "www", Domain: "example.com"}, {Domain: "apps.example.com", Path: "/search"}}
+		deploymentInfo.Routes = []S.Route{{Domain: "other.example.com"}, {Hostname: "www", Domain: "example.com"}}
+
+		err := prechecker.AssertDomainsAvailable(environment, deploymentInfo)
+
+		Expect(err).To(MatchError(UnavailableDomainsError{Org: "org", Unavailable: []UnavailableDomains{
+			{FoundationURL: foundation.URL, Domains: []string{"example.com", "other.example.com"}},
+		}}))
+	})
+
+	It("does not contact the foundations without a domain or custom routes", func() {
+		environment.Domain = ""
+		deploymentInfo.Manifest = ""
+
+		Expect(prechecker.AssertDomainsAvailable(environment, deploymentInfo)).To(Succeed())
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("returns an error when the org does not exist", func() {
+		deploymentInfo.Org = "other"
+
+		err := prechecker.AssertDomainsAvailable(environment, deploymentInfo)
+
+		Expect(err).To(MatchError(DomainListError{FoundationURL: foundation.URL, Err: fmt.Errorf("org other not found")}))
+	})
+})
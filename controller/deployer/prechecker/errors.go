@@ -1,6 +1,9 @@
 package prechecker
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type NoFoundationsConfiguredError struct{}
 
@@ -25,3 +28,52 @@ type FoundationUnavailableError struct {
 func (e FoundationUnavailableError) Error() string {
 	return fmt.Sprintf("deploy aborted: one or more CF foundations unavailable: %s: %s", e.FoundationURL, e.Status)
 }
+
+type BuildpackListError struct {
+	FoundationURL string
+	Err           error
+}
+
+func (e BuildpackListError) Error() string {
+	if e.FoundationURL == "" {
+		return fmt.Sprintf("cannot read the buildpacks of the manifest: %s", e.Err)
+	}
+	return fmt.Sprintf("cannot list the buildpacks of %s: %s", e.FoundationURL, e.Err)
+}
+
+type MissingBuildpacksError struct {
+	Missing []MissingBuildpacks
+}
+
+func (e MissingBuildpacksError) Error() string {
+	var foundations []string
+	for _, missing := range e.Missing {
+		foundations = append(foundations, fmt.Sprintf("%s: %s", missing.FoundationURL, strings.Join(missing.Buildpacks, ", ")))
+	}
+	return fmt.Sprintf("deploy aborted: buildpacks are missing or disabled: %s", strings.Join(foundations, "; "))
+}
+
+type DomainListError struct {
+	FoundationURL string
+	Err           error
+}
+
+func (e DomainListError) Error() string {
+	if e.FoundationURL == "" {
+		return fmt.Sprintf("cannot read the routes of the manifest: %s", e.Err)
+	}
+	return fmt.Sprintf("cannot list the domains of %s: %s", e.FoundationURL, e.Err)
+}
+
+type UnavailableDomainsError struct {
+	Org         string
+	Unavailable []UnavailableDomains
+}
+
+func (e UnavailableDomainsError) Error() string {
+	var foundations []string
+	for _, unavailable := range e.Unavailable {
+		foundations = append(foundations, fmt.Sprintf("%s: %s", unavailable.FoundationURL, strings.Join(unavailable.Domains, ", ")))
+	}
+	return fmt.Sprintf("deploy aborted: domains are not available to org %s: %s", e.Org, strings.Join(foundations, "; "))
+}
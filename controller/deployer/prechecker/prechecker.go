@@ -1,10 +1,14 @@
-// Package prechecker checks that all the Cloud Foundry instances are running before a deploy.
+// Package prechecker checks that all the Cloud Foundry instances are running, and have the buildpacks of the
+// application, before a deploy.
 package prechecker
 
 import (
 	"crypto/tls"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/compozed/deployadactyl/eventmanager"
@@ -81,12 +85,7 @@ func (p Prechecker) AssertAllFoundationsUp(environment S.Environment) error {
 		return NoFoundationsConfiguredError{}
 	}
 
-	insecureClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
-			ResponseHeaderTimeout: 15 * time.Second,
-		},
-	}
+	insecureClient := newInsecureClient()
 
 	for _, foundationURL := range environment.Foundations {
 		resp, err := insecureClient.Get(fmt.Sprintf("%s/v2/info", foundationURL))
@@ -110,3 +109,25 @@ func (p Prechecker) AssertAllFoundationsUp(environment S.Environment) error {
 
 	return nil
 }
+
+func newInsecureClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
+			ResponseHeaderTimeout: 15 * time.Second,
+		},
+	}
+}
+
+// deploymentManifest returns the manifest of the deployment, or the manifest of its artifact when it has none.
+func deploymentManifest(deploymentInfo S.DeploymentInfo) (string, error) {
+	if deploymentInfo.Manifest != "" || deploymentInfo.AppPath == "" {
+		return deploymentInfo.Manifest, nil
+	}
+
+	manifest, err := ioutil.ReadFile(filepath.Join(deploymentInfo.AppPath, "manifest.yml"))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	return string(manifest), nil
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/compozed/deployadactyl/deploymentlog"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/responsebuffer"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// LogStreamKeepAlive is how often a ping is sent over an idle log stream, so proxies do not close it.
+const LogStreamKeepAlive = 30 * time.Second
+
+// DeploymentLogHandler returns the output of the deployment with the uuid in the path as plain text.
+func (c *Controller) DeploymentLogHandler(g *gin.Context) {
+	if c.DeploymentLogs == nil {
+		g.String(http.StatusNotFound, "deployment logs are not enabled")
+		return
+	}
+
+	if !c.authorizeDeployment(g, g.Param("uuid")) {
+		return
+	}
+
+	output, err := c.DeploymentLogs.Get(g.Param("uuid"))
+	if err != nil {
+		if _, ok := err.(deploymentlog.LogNotFoundError); ok {
+			g.String(http.StatusNotFound, err.Error())
+			return
+		}
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot read deployment logs: %s", err)
+		return
+	}
+
+	g.Data(http.StatusOK, "text/plain; charset=utf-8", output)
+}
+
+// DeploymentLogStreamHandler streams the output of the deployment with the uuid in the path over a WebSocket while
+// it runs, one text message per write. The end of the output written before the connection is sent first, and the
+// connection is closed once the deployment is finished. A deployment that has not started yet is waited for, unless
+// tenants are configured: the tenant of a deployment is only known once it started.
+func (c *Controller) DeploymentLogStreamHandler(g *gin.Context) {
+	if c.LogStreams == nil {
+		g.String(http.StatusNotFound, "log streaming is not enabled")
+		return
+	}
+
+	uuid := g.Param("uuid")
+	if !c.authorizeDeployment(g, uuid) {
+		return
+	}
+
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		output, unsubscribe := c.LogStreams.Subscribe(uuid)
+		defer unsubscribe()
+
+		// The client sends nothing but control frames, which are answered while reading. Reading fails once the
+		// client is gone.
+		gone := make(chan struct{})
+		go func() {
+			ioutil.ReadAll(ws)
+			close(gone)
+		}()
+
+		keepAlive := time.NewTicker(LogStreamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case p, ok := <-output:
+				if !ok {
+					return
+				}
+				err := websocket.Message.Send(ws, validUTF8(p))
+				if err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				ws.PayloadType = websocket.PingFrame
+				_, err := ws.Write(nil)
+				ws.PayloadType = websocket.TextFrame
+				if err != nil {
+					return
+				}
+			case <-gone:
+				return
+			}
+		}
+	}}.ServeHTTP(g.Writer, g.Request)
+}
+
+// validUTF8 returns the output as a string, replacing the bytes that are not UTF-8, which text messages must be.
+func validUTF8(p []byte) string {
+	if utf8.Valid(p) {
+		return string(p)
+	}
+	return strings.Map(func(r rune) rune { return r }, string(p))
+}
+
+// streamLogs streams everything written to the response as the output of the deployment. The returned function ends
+// the stream once the response is complete.
+func (c *Controller) streamLogs(log I.DeploymentLogger, response *responsebuffer.Buffer) func() {
+	if c.LogStreams == nil {
+		return func() {}
+	}
+
+	stream := c.LogStreams.Open(log.UUID)
+	response.Stream = stream
+	return func() { stream.Close() }
+}
+
+// saveDeploymentLog keeps the response of the deployment. It must run before the response is copied
+// to the client, since copying drains it. Only the end of a response larger than the response buffer is kept.
+func (c *Controller) saveDeploymentLog(log I.DeploymentLogger, response *responsebuffer.Buffer) {
+	if c.DeploymentLogs == nil {
+		return
+	}
+
+	maxBytes := c.Config.Limits.MaxResponseBufferBytes()
+	output, err := response.Tail(maxBytes)
+	if err != nil {
+		log.Errorf("cannot read the output of the deployment: %s", err)
+		return
+	}
+	if dropped := response.Len() - maxBytes; dropped > 0 {
+		output = append([]byte(fmt.Sprintf("[the first %d bytes of the output were not kept]\n", dropped)), output...)
+	}
+
+	err = c.DeploymentLogs.Save(log.UUID, output)
+	if err != nil {
+		log.Errorf("cannot save the output of the deployment: %s", err)
+	}
+}
@@ -0,0 +1,247 @@
+package controller_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/deploymentlog"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logstream"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+	"golang.org/x/net/websocket"
+)
+
+var _ = Describe("Deployment logs", func() {
+	var (
+		deploymentLogs *mocks.DeploymentLogStore
+		pushController *mocks.PushController
+		controller     *Controller
+		router         *gin.Engine
+		resp           *httptest.ResponseRecorder
+		uuid           string
+	)
+
+	BeforeEach(func() {
+		deploymentLogs = &mocks.DeploymentLogStore{}
+		pushController = &mocks.PushController{}
+		controller = &Controller{
+			Log: I.DefaultLogger(NewBuffer(), logging.DEBUG, "deploymentlogs_test"),
+			PushControllerFactory: func(log I.DeploymentLogger) I.PushController {
+				uuid = log.UUID
+				return pushController
+			},
+			DeploymentLogs: deploymentLogs,
+		}
+
+		router = gin.New()
+		router.POST("/v3/apps/:environment/:org/:space/:appName", controller.RunDeploymentViaHttp)
+		router.GET("/v3/deployments/:uuid/logs", controller.DeploymentLogHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	Describe("RunDeploymentViaHttp", func() {
+		deploy := func() {
+			req, err := http.NewRequest("POST", "/v3/apps/prod/org/space/app", &bytes.Buffer{})
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set("Content-Type", "application/zip")
+			router.ServeHTTP(resp, req)
+		}
+
+		It("saves the response of the deployment", func() {
+			pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+			pushController.RunDeploymentCall.Writes = "deploy success"
+
+			deploy()
+
+			Expect(resp.Body.String()).To(ContainSubstring("deploy success"))
+			Expect(deploymentLogs.SaveCall.Received.UUID).To(Equal(uuid))
+			Expect(string(deploymentLogs.SaveCall.Received.Output)).To(Equal(resp.Body.String()))
+		})
+
+		It("saves the error of a failed deployment", func() {
+			pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{
+				StatusCode: http.StatusInternalServerError,
+				Error:      errors.New("push failed"),
+			}
+
+			deploy()
+
+			Expect(string(deploymentLogs.SaveCall.Received.Output)).To(ContainSubstring("cannot deploy application: push failed"))
+		})
+
+		It("streams the response of the deployment", func() {
+			hub := logstream.NewHub()
+			controller.LogStreams = hub
+			pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{
+				StatusCode: http.StatusInternalServerError,
+				Error:      errors.New("push failed"),
+			}
+			pushController.RunDeploymentCall.Writes = "pushing app\n"
+
+			deploy()
+
+			output, unsubscribe := hub.Subscribe(uuid)
+			defer unsubscribe()
+
+			streamed := ""
+			for p := range output {
+				streamed += string(p)
+			}
+			Expect(streamed).To(Equal("pushing app\ncannot deploy application: push failed\n"))
+		})
+
+		It("still responds when the response cannot be saved", func() {
+			pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+			pushController.RunDeploymentCall.Writes = "deploy success"
+			deploymentLogs.SaveCall.Returns.Error = errors.New("disk full")
+
+			deploy()
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Body.String()).To(ContainSubstring("deploy success"))
+		})
+	})
+
+	Describe("DeploymentLogHandler", func() {
+		get := func(uuid string) {
+			req, err := http.NewRequest("GET", "/v3/deployments/"+uuid+"/logs", nil)
+			Expect(err).ToNot(HaveOccurred())
+			router.ServeHTTP(resp, req)
+		}
+
+		It("returns the output of the deployment", func() {
+			deploymentLogs.GetCall.Returns.Output = []byte("deploy success")
+
+			get("abc")
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Header().Get("Content-Type")).To(HavePrefix("text/plain"))
+			Expect(resp.Body.String()).To(Equal("deploy success"))
+			Expect(deploymentLogs.GetCall.Received.UUID).To(Equal("abc"))
+		})
+
+		It("returns http.StatusNotFound for an unknown deployment", func() {
+			deploymentLogs.GetCall.Returns.Error = deploymentlog.LogNotFoundError{UUID: "abc"}
+
+			get("abc")
+
+			Expect(resp.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("returns http.StatusInternalServerError when the output cannot be read", func() {
+			deploymentLogs.GetCall.Returns.Error = errors.New("permission denied")
+
+			get("abc")
+
+			Expect(resp.Code).To(Equal(http.StatusInternalServerError))
+			Expect(resp.Body.String()).To(ContainSubstring("permission denied"))
+		})
+
+		It("returns http.StatusNotFound when deployment logs are not enabled", func() {
+			controller.DeploymentLogs = nil
+
+			get("abc")
+
+			Expect(resp.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("DeploymentLogStreamHandler", func() {
+		var (
+			hub    *logstream.Hub
+			server *httptest.Server
+		)
+
+		BeforeEach(func() {
+			hub = logstream.NewHub()
+			controller.LogStreams = hub
+			router.GET("/v3/deployments/:uuid/logs/stream", controller.DeploymentLogStreamHandler)
+			server = httptest.NewServer(router)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		dial := func(uuid string) *websocket.Conn {
+			url := "ws" + strings.TrimPrefix(server.URL, "http") + "/v3/deployments/" + uuid + "/logs/stream"
+			ws, err := websocket.Dial(url, "", server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			return ws
+		}
+
+		receive := func(ws *websocket.Conn) []string {
+			messages := []string{}
+			for {
+				var message string
+				if websocket.Message.Receive(ws, &message) != nil {
+					return messages
+				}
+				messages = append(messages, message)
+			}
+		}
+
+		It("streams the output of the deployment until it is finished", func() {
+			stream := hub.Open("abc")
+			fmt.Fprint(stream, "pushing app\n")
+
+			ws := dial("abc")
+			defer ws.Close()
+
+			fmt.Fprint(stream, "started app\n")
+			stream.Close()
+
+			Expect(receive(ws)).To(Equal([]string{"pushing app\n", "started app\n"}))
+		})
+
+		It("waits for a deployment that has not started yet", func() {
+			ws := dial("abc")
+			defer ws.Close()
+
+			stream := hub.Open("abc")
+			fmt.Fprint(stream, "pushing app\n")
+			stream.Close()
+
+			Expect(receive(ws)).To(Equal([]string{"pushing app\n"}))
+		})
+
+		It("replaces the output that is not UTF-8", func() {
+			stream := hub.Open("abc")
+			fmt.Fprint(stream, "caf\xe9\n")
+			stream.Close()
+
+			ws := dial("abc")
+			defer ws.Close()
+
+			Expect(receive(ws)).To(Equal([]string{"caf\uFFFD\n"}))
+		})
+
+		It("returns http.StatusBadRequest to a request that is not a WebSocket handshake", func() {
+			resp, err := http.Get(server.URL + "/v3/deployments/abc/logs/stream")
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns http.StatusNotFound when log streaming is not enabled", func() {
+			controller.LogStreams = nil
+
+			resp, err := http.Get(server.URL + "/v3/deployments/abc/logs/stream")
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+})
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/compozed/deployadactyl/drift"
+	"github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// DriftHandler compares the application in the path on every foundation of one or two environments, given as
+// a comma separated environments query parameter, and returns the DriftReport as JSON.
+//
+// The foundations are inspected with the credentials of the request, or when none of the environments requires
+// authentication, with those of their tenant or of the server.
+func (c *Controller) DriftHandler(g *gin.Context) {
+	if c.AppInspector == nil {
+		g.String(http.StatusNotFound, "drift detection is not enabled")
+		return
+	}
+
+	var environments []S.Environment
+	for _, name := range strings.Split(g.Query("environments"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		environment, ok := c.Config.Environments[name]
+		if !ok {
+			g.String(http.StatusBadRequest, "environment not found: %s", name)
+			return
+		}
+		environment.Name = name
+		environments = append(environments, environment)
+	}
+	if len(environments) == 0 || len(environments) > 2 {
+		g.String(http.StatusBadRequest, "environments must name one or two environments")
+		return
+	}
+	for _, environment := range environments {
+		if !c.authorizeEnvironment(g, environment.Name) {
+			return
+		}
+	}
+
+	user, pwd, _ := g.Request.BasicAuth()
+	auth := I.Authorization{Username: user, Password: pwd}
+	serverAuth := auth.Username == "" && auth.Password == ""
+	if serverAuth {
+		for _, environment := range environments {
+			if environment.Authenticate {
+				g.String(http.StatusUnauthorized, "basic auth is required for environment %s", environment.Name)
+				return
+			}
+		}
+	}
+
+	org, space, appName := g.Param("org"), g.Param("space"), g.Param("appName")
+
+	type target struct {
+		environment   S.Environment
+		foundationURL string
+	}
+	var targets []target
+	for _, environment := range environments {
+		for _, foundationURL := range environment.Foundations {
+			targets = append(targets, target{environment, foundationURL})
+		}
+	}
+
+	snapshots := make([]S.AppSnapshot, len(targets))
+	wg := sync.WaitGroup{}
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, environment S.Environment, foundationURL string) {
+			defer wg.Done()
+
+			auth := auth
+			var err error
+			if serverAuth {
+				auth.Username, auth.Password, err = c.Config.Credentials(environment)
+			}
+
+			var snapshot S.AppSnapshot
+			if err == nil {
+				snapshot, err = c.AppInspector.Inspect(g.Request.Context(), environment, foundationURL, auth, org, space, appName)
+			}
+			if err != nil {
+				c.Log.Errorf("cannot inspect %s on %s: %s", appName, foundationURL, err)
+				snapshot.Error = err.Error()
+			}
+			snapshot.Environment = environment.Name
+			snapshot.FoundationURL = foundationURL
+			snapshots[i] = snapshot
+		}(i, t.environment, t.foundationURL)
+	}
+	wg.Wait()
+
+	c.addDeployedVersions(snapshots, org, space, appName)
+
+	g.JSON(http.StatusOK, drift.Compare(snapshots, c.Config.Environments))
+}
+
+// addDeployedVersions sets the artifact the deployment history says is deployed on the foundation of every snapshot.
+func (c *Controller) addDeployedVersions(snapshots []S.AppSnapshot, org, space, appName string) {
+	if c.History == nil {
+		return
+	}
+
+	records, err := c.History.Find(S.DeploymentQuery{Org: org, Space: space, AppName: appName})
+	if err != nil {
+		c.Log.Errorf("cannot query deployment history: %s", err)
+		return
+	}
+
+	versions := map[string]S.DeployedVersion{}
+	for _, version := range history.DeployedVersions(records, c.Config.Environments) {
+		versions[version.Environment+"\x00"+version.FoundationURL] = version
+	}
+
+	for i := range snapshots {
+		version, ok := versions[snapshots[i].Environment+"\x00"+snapshots[i].FoundationURL]
+		if !ok {
+			continue
+		}
+		snapshots[i].ArtifactURL = version.ArtifactURL
+		snapshots[i].ArtifactDigest = version.ArtifactDigest
+		snapshots[i].Version = version.Version
+	}
+}
@@ -0,0 +1,153 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("DriftHandler", func() {
+	var (
+		inspector  *mocks.AppInspector
+		controller *Controller
+		router     *gin.Engine
+		resp       *httptest.ResponseRecorder
+	)
+
+	detect := func(environments string) S.DriftReport {
+		req, err := http.NewRequest("GET", "/v3/drift/org/space/search?environments="+environments, nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.SetBasicAuth("username", "password")
+		router.ServeHTTP(resp, req)
+
+		report := S.DriftReport{}
+		if resp.Code == http.StatusOK {
+			Expect(json.Unmarshal(resp.Body.Bytes(), &report)).To(Succeed())
+		}
+		return report
+	}
+
+	BeforeEach(func() {
+		inspector = &mocks.AppInspector{}
+		inspector.InspectCall.Returns.Snapshots = map[string]S.AppSnapshot{
+			"https://api.stage.example.com": {Instances: 2, Routes: []string{"search.stage.example.com"}},
+			"https://api.prod.example.com":  {Instances: 2, Routes: []string{"search.prod.example.com"}},
+		}
+
+		controller = &Controller{
+			Log:          I.DefaultLogger(NewBuffer(), logging.DEBUG, "drift_test"),
+			AppInspector: inspector,
+			Config: config.Config{
+				Environments: map[string]S.Environment{
+					"stage": {Name: "stage", Domain: "stage.example.com", Foundations: []string{"https://api.stage.example.com"}},
+					"prod":  {Name: "prod", Domain: "prod.example.com", Foundations: []string{"https://api.prod.example.com"}},
+				},
+			},
+		}
+
+		router = gin.New()
+		router.GET("/v3/drift/:org/:space/:appName", controller.DriftHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	It("inspects every foundation of the environments", func() {
+		report := detect("stage,prod")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(report.Drifted).To(BeFalse())
+		Expect(report.Snapshots).To(HaveLen(2))
+		Expect(report.Snapshots[0].Environment).To(Equal("stage"))
+		Expect(report.Snapshots[0].FoundationURL).To(Equal("https://api.stage.example.com"))
+		Expect(report.Snapshots[1].Environment).To(Equal("prod"))
+
+		Expect(inspector.InspectCall.Received.Authorization).To(Equal(I.Authorization{Username: "username", Password: "password"}))
+		Expect(inspector.InspectCall.Received.Org).To(Equal("org"))
+		Expect(inspector.InspectCall.Received.Space).To(Equal("space"))
+		Expect(inspector.InspectCall.Received.AppName).To(Equal("search"))
+	})
+
+	It("reports the differences between the foundations", func() {
+		inspector.InspectCall.Returns.Snapshots["https://api.prod.example.com"] = S.AppSnapshot{Instances: 4, Routes: []string{"search.prod.example.com"}}
+
+		report := detect("stage,prod")
+
+		Expect(report.Drifted).To(BeTrue())
+		Expect(report.Differences).To(HaveLen(1))
+		Expect(report.Differences[0].Field).To(Equal(S.DriftInstances))
+	})
+
+	It("reports the foundations that cannot be inspected without comparing them", func() {
+		inspector.InspectCall.Returns.Errors = map[string]error{"https://api.prod.example.com": errors.New("login failed")}
+
+		report := detect("stage,prod")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(report.Drifted).To(BeFalse())
+		Expect(report.Snapshots[1].Error).To(Equal("login failed"))
+		Expect(report.Snapshots[1].FoundationURL).To(Equal("https://api.prod.example.com"))
+	})
+
+	It("compares the artifacts recorded in the deployment history", func() {
+		deploymentHistory := history.NewMemoryHistory(0)
+		for _, environment := range []string{"stage", "prod"} {
+			Expect(deploymentHistory.Record(S.DeploymentRecord{
+				UUID: environment, Environment: environment, Org: "org", Space: "space", AppName: "search",
+				Status: S.DeploymentSucceeded, ArtifactURL: "https://example.com/" + environment + ".zip",
+			})).To(Succeed())
+		}
+		controller.History = deploymentHistory
+
+		report := detect("stage,prod")
+
+		Expect(report.Snapshots[0].ArtifactURL).To(Equal("https://example.com/stage.zip"))
+		Expect(report.Snapshots[1].ArtifactURL).To(Equal("https://example.com/prod.zip"))
+		Expect(report.Drifted).To(BeTrue())
+		Expect(report.Differences[0].Field).To(Equal(S.DriftArtifactURL))
+	})
+
+	It("returns http.StatusBadRequest for an unknown environment", func() {
+		detect("stage,qa")
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		Expect(resp.Body.String()).To(ContainSubstring("environment not found: qa"))
+	})
+
+	It("returns http.StatusBadRequest without environments", func() {
+		detect("")
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("returns http.StatusUnauthorized without credentials for an environment that requires them", func() {
+		environment := controller.Config.Environments["prod"]
+		environment.Authenticate = true
+		controller.Config.Environments["prod"] = environment
+
+		req, err := http.NewRequest("GET", "/v3/drift/org/space/search?environments=stage,prod", nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("returns http.StatusNotFound when drift detection is not enabled", func() {
+		controller.AppInspector = nil
+
+		detect("stage,prod")
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+	})
+})
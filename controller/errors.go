@@ -0,0 +1,11 @@
+package controller
+
+import "fmt"
+
+type RequestTooLargeError struct {
+	MaxBytes int64
+}
+
+func (e RequestTooLargeError) Error() string {
+	return fmt.Sprintf("the request body is larger than the limit of %d bytes", e.MaxBytes)
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// EventStreamKeepAlive is how often a comment is written to an idle event stream, so proxies do not close it.
+const EventStreamKeepAlive = 30 * time.Second
+
+// EventStreamHandler streams a summary of every event emitted from now on as server-sent events, each holding a
+// StreamedEvent as JSON. The environment, app and type query params, which can be repeated, only stream the events
+// of those environments, applications and types.
+//
+// The events of the environments of a tenant are only streamed to requests with one of its API tokens.
+func (c *Controller) EventStreamHandler(g *gin.Context) {
+	if c.Events == nil {
+		g.String(http.StatusNotFound, "the event stream is not enabled")
+		return
+	}
+
+	tenant, ok := c.requestTenant(g)
+	if !ok {
+		return
+	}
+
+	environments := queryValues(g, "environment", true)
+	apps := queryValues(g, "app", false)
+	types := queryValues(g, "type", false)
+
+	events, unsubscribe := c.Events.Subscribe(func(event S.StreamedEvent) bool {
+		if owner := c.environmentTenant(event.Environment); owner != "" && owner != tenant {
+			return false
+		}
+		return matches(environments, strings.ToLower(event.Environment)) && matches(apps, event.AppName) && matches(types, event.Type)
+	})
+	defer unsubscribe()
+
+	g.Header("Content-Type", "text/event-stream")
+	g.Header("Cache-Control", "no-cache")
+	g.Header("Connection", "keep-alive")
+	g.Writer.WriteHeader(http.StatusOK)
+	g.Writer.Flush()
+
+	keepAlive := time.NewTicker(EventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				c.Log.Error(err)
+				return
+			}
+			fmt.Fprintf(g.Writer, "data: %s\n\n", data)
+			g.Writer.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(g.Writer, ": keep-alive\n\n")
+			g.Writer.Flush()
+		case <-g.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// queryValues returns the values of the repeated query param as a set, lowercased when the param is not case
+// sensitive.
+func queryValues(g *gin.Context, key string, lower bool) map[string]bool {
+	values := map[string]bool{}
+	for _, value := range g.QueryArray(key) {
+		if lower {
+			value = strings.ToLower(value)
+		}
+		values[value] = true
+	}
+	return values
+}
+
+// matches reports whether the value is one of the values, or whether there are no values to match.
+func matches(values map[string]bool, value string) bool {
+	return len(values) == 0 || values[value]
+}
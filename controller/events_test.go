@@ -0,0 +1,159 @@
+package controller_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/eventmanager"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("EventStreamHandler", func() {
+	var (
+		stream     *eventmanager.Stream
+		controller *Controller
+		server     *httptest.Server
+		bodies     []io.Closer
+	)
+
+	BeforeEach(func() {
+		stream = eventmanager.NewStream()
+		controller = &Controller{
+			Log:    I.DefaultLogger(NewBuffer(), logging.DEBUG, "events_test"),
+			Events: stream,
+			Config: config.Config{
+				Environments: map[string]S.Environment{
+					"prod":        {Name: "prod"},
+					"stage":       {Name: "stage"},
+					"search-prod": {Name: "search-prod", Tenant: "search"},
+				},
+				Tenants: map[string]S.Tenant{
+					"search": {Name: "search", APITokens: []string{"search-token"}},
+				},
+			},
+		}
+
+		router := gin.New()
+		router.GET("/v3/events/stream", controller.EventStreamHandler)
+		server = httptest.NewServer(router)
+	})
+
+	AfterEach(func() {
+		for _, body := range bodies {
+			body.Close()
+		}
+		bodies = nil
+		server.Close()
+	})
+
+	subscribe := func(query, token string) *bufio.Scanner {
+		req, err := http.NewRequest("GET", server.URL+"/v3/events/stream"+query, nil)
+		Expect(err).ToNot(HaveOccurred())
+		if token != "" {
+			req.Header.Set(TenantTokenHeader, token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+		bodies = append(bodies, resp.Body)
+
+		return bufio.NewScanner(resp.Body)
+	}
+
+	next := func(scanner *bufio.Scanner) S.StreamedEvent {
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "data: ") {
+				event := S.StreamedEvent{}
+				Expect(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event)).To(Succeed())
+				return event
+			}
+		}
+		Fail("the stream ended")
+		return S.StreamedEvent{}
+	}
+
+	emit := func(environment, app string) {
+		stream.Emit(push.DeployStartedEvent{
+			CFContext: I.CFContext{Environment: environment, Organization: "org", Space: "space", Application: app},
+			Log:       I.DeploymentLogger{UUID: environment + "-" + app},
+		})
+	}
+
+	It("streams every event", func() {
+		scanner := subscribe("", "")
+
+		emit("prod", "search")
+
+		event := next(scanner)
+		Expect(event.Type).To(Equal("DeployStartedEvent"))
+		Expect(event.UUID).To(Equal("prod-search"))
+		Expect(event.Environment).To(Equal("prod"))
+		Expect(event.AppName).To(Equal("search"))
+	})
+
+	It("only streams the events of the environments, apps and types in the query", func() {
+		scanner := subscribe("?environment=PROD&environment=stage&app=search&type=DeployStartedEvent", "")
+
+		emit("prod", "payments")
+		stream.Emit(push.DeployFinishedEvent{CFContext: I.CFContext{Environment: "prod", Application: "search"}})
+		emit("qa", "search")
+		emit("stage", "search")
+
+		Expect(next(scanner).UUID).To(Equal("stage-search"))
+	})
+
+	It("does not stream the events of a tenant without one of its api tokens", func() {
+		scanner := subscribe("", "")
+
+		emit("search-prod", "search")
+		emit("prod", "search")
+
+		Expect(next(scanner).UUID).To(Equal("prod-search"))
+	})
+
+	It("streams the events of a tenant with one of its api tokens", func() {
+		scanner := subscribe("", "search-token")
+
+		emit("search-prod", "search")
+
+		Expect(next(scanner).UUID).To(Equal("search-prod-search"))
+	})
+
+	It("returns http.StatusUnauthorized for an unknown api token", func() {
+		req, err := http.NewRequest("GET", server.URL+"/v3/events/stream", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(TenantTokenHeader, "unknown")
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("returns http.StatusNotFound when the event stream is not enabled", func() {
+		controller.Events = nil
+
+		resp, err := http.Get(server.URL + "/v3/events/stream")
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gzip decompresses request bodies sent with Content-Encoding gzip, and compresses the responses of clients that
+// accept gzip. Deployment outputs are mostly text, so they shrink a lot.
+//
+// Request bodies are decompressed before they are limited, so the limits apply to the decompressed size. Server-sent
+// events and responses that already have a Content-Encoding are not compressed.
+func Gzip() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		if strings.EqualFold(g.Request.Header.Get("Content-Encoding"), "gzip") {
+			reader, err := gzip.NewReader(g.Request.Body)
+			if err != nil {
+				g.String(http.StatusBadRequest, "the request body is not valid gzip: %s", err)
+				g.Abort()
+				return
+			}
+
+			g.Request.Body = gzipBody{Reader: reader, body: g.Request.Body}
+			g.Request.Header.Del("Content-Encoding")
+			g.Request.ContentLength = -1
+		}
+
+		if !acceptsGzip(g.Request.Header.Get("Accept-Encoding")) {
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: g.Writer}
+		g.Writer = writer
+		defer writer.close()
+
+		g.Next()
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header of a request accepts gzip.
+func acceptsGzip(acceptEncoding string) bool {
+	for _, encoding := range strings.Split(acceptEncoding, ",") {
+		parts := strings.Split(encoding, ";")
+		if !strings.EqualFold(strings.TrimSpace(parts[0]), "gzip") {
+			continue
+		}
+
+		for _, parameter := range parts[1:] {
+			parameter = strings.Replace(parameter, " ", "", -1)
+			if parameter == "q=0" || strings.HasPrefix(parameter, "q=0.") && strings.Trim(parameter[4:], "0") == "" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipBody is a decompressed request body. Closing it closes the original body.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// gzipWriter compresses the response once its body is written. Responses without a body are not compressed.
+type gzipWriter struct {
+	gin.ResponseWriter
+	gz      *gzip.Writer
+	decided bool
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	w.decide()
+	if w.gz == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.gz.Write(p)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipWriter) Flush() {
+	w.decide()
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide compresses the response, unless it is a stream of server-sent events or is already encoded. It must be
+// called before the headers are written.
+func (w *gzipWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") {
+		return
+	}
+
+	header.Set("Content-Encoding", "gzip")
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+}
+
+func (w *gzipWriter) close() {
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
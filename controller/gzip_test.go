@@ -0,0 +1,158 @@
+package controller_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Gzip", func() {
+	var (
+		pushController *mocks.PushController
+		router         *gin.Engine
+		resp           *httptest.ResponseRecorder
+	)
+
+	compress := func(data []byte) []byte {
+		compressed := &bytes.Buffer{}
+		writer := gzip.NewWriter(compressed)
+		_, err := writer.Write(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(writer.Close()).To(Succeed())
+		return compressed.Bytes()
+	}
+
+	decompress := func(data []byte) string {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		Expect(err).ToNot(HaveOccurred())
+		decompressed, err := ioutil.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		return string(decompressed)
+	}
+
+	BeforeEach(func() {
+		pushController = &mocks.PushController{}
+		pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+		pushController.RunDeploymentCall.Writes = "deploy success"
+
+		controller := &Controller{
+			Log: I.DefaultLogger(NewBuffer(), logging.DEBUG, "gzip_test"),
+			PushControllerFactory: func(log I.DeploymentLogger) I.PushController {
+				return pushController
+			},
+		}
+
+		router = gin.New()
+		router.Use(Gzip(), LimitRequestSize(S.LimitsDescriptor{MaxRequestMB: 1}))
+		router.POST("/v3/apps/:environment/:org/:space/:appName", controller.RunDeploymentViaHttp)
+		router.GET("/empty", func(g *gin.Context) {
+			g.Status(http.StatusNoContent)
+		})
+		router.GET("/events", func(g *gin.Context) {
+			g.Header("Content-Type", "text/event-stream")
+			g.Writer.WriteHeader(http.StatusOK)
+			g.Writer.Flush()
+			g.Writer.WriteString("event: progress\n\n")
+		})
+		resp = httptest.NewRecorder()
+	})
+
+	deploy := func(body []byte, header http.Header) {
+		req, err := http.NewRequest("POST", "/v3/apps/prod/org/space/app", bytes.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header = header
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(resp, req)
+	}
+
+	Describe("requests", func() {
+		It("decompresses gzip request bodies", func() {
+			deploy(compress([]byte(`{"artifact_url": "https://example.com/app.zip"}`)), http.Header{"Content-Encoding": {"gzip"}})
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(string(*pushController.RunDeploymentCall.Received.Deployment.Body)).To(Equal(`{"artifact_url": "https://example.com/app.zip"}`))
+		})
+
+		It("leaves request bodies that are not compressed alone", func() {
+			deploy([]byte(`{}`), http.Header{})
+
+			Expect(string(*pushController.RunDeploymentCall.Received.Deployment.Body)).To(Equal(`{}`))
+		})
+
+		It("returns http.StatusBadRequest when the body is not gzip", func() {
+			deploy([]byte(`{}`), http.Header{"Content-Encoding": {"gzip"}})
+
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(resp.Body.String()).To(ContainSubstring("the request body is not valid gzip"))
+			Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+		})
+
+		It("returns http.StatusBadRequest when the compressed body is corrupt", func() {
+			body := compress([]byte(`{"artifact_url": "https://example.com/app.zip"}`))
+			body[len(body)-5]++
+
+			deploy(body, http.Header{"Content-Encoding": {"gzip"}})
+
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(resp.Body.String()).To(ContainSubstring("cannot read the request body"))
+		})
+
+		It("limits the decompressed size of the body", func() {
+			deploy(compress(make([]byte, 2<<20)), http.Header{"Content-Encoding": {"gzip"}})
+
+			Expect(resp.Code).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+		})
+	})
+
+	Describe("responses", func() {
+		It("compresses the response when the client accepts gzip", func() {
+			deploy([]byte(`{}`), http.Header{"Accept-Encoding": {"deflate, gzip;q=0.8"}})
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Header().Get("Content-Encoding")).To(Equal("gzip"))
+			Expect(resp.Header().Get("Vary")).To(Equal("Accept-Encoding"))
+			Expect(decompress(resp.Body.Bytes())).To(Equal("deploy success"))
+		})
+
+		It("does not compress the response when the client does not accept gzip", func() {
+			deploy([]byte(`{}`), http.Header{"Accept-Encoding": {"gzip;q=0"}})
+
+			Expect(resp.Header().Get("Content-Encoding")).To(BeEmpty())
+			Expect(resp.Body.String()).To(Equal("deploy success"))
+		})
+
+		It("does not compress responses without a body", func() {
+			req, err := http.NewRequest("GET", "/empty", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set("Accept-Encoding", "gzip")
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusNoContent))
+			Expect(resp.Header().Get("Content-Encoding")).To(BeEmpty())
+			Expect(resp.Body.Len()).To(BeZero())
+		})
+
+		It("does not compress server-sent events", func() {
+			req, err := http.NewRequest("GET", "/events", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set("Accept-Encoding", "gzip")
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Header().Get("Content-Encoding")).To(BeEmpty())
+			Expect(resp.Body.String()).To(Equal("event: progress\n\n"))
+		})
+	})
+})
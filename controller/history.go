@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/compozed/deployadactyl/history"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// DeploymentHistoryHandler returns the deployments matching the query parameters as JSON, newest first.
+//
+// GET /v3/deployments?component=log4j-core&version=2.14&current=true returns the applications whose
+// running version contains log4j-core 2.14.x.
+func (c *Controller) DeploymentHistoryHandler(g *gin.Context) {
+	if c.History == nil {
+		g.String(http.StatusNotFound, "deployment history is not enabled")
+		return
+	}
+
+	tenant, ok := c.requestTenant(g)
+	if !ok {
+		return
+	}
+
+	query := S.DeploymentQuery{
+		Environment:      strings.ToLower(g.Query("environment")),
+		Org:              g.Query("org"),
+		Space:            g.Query("space"),
+		AppName:          g.Query("app"),
+		Status:           g.Query("status"),
+		Component:        g.Query("component"),
+		ComponentVersion: g.Query("version"),
+	}
+
+	var err error
+	if current := g.Query("current"); current != "" {
+		query.Current, err = strconv.ParseBool(current)
+		if err != nil {
+			g.String(http.StatusBadRequest, "invalid current parameter: %s", current)
+			return
+		}
+	}
+	if limit := g.Query("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			g.String(http.StatusBadRequest, "invalid limit parameter: %s", limit)
+			return
+		}
+	}
+
+	// The limit applies to the deployments the tenant may see, so the query cannot be limited.
+	limit := query.Limit
+	if len(c.Config.Tenants) != 0 {
+		query.Limit = 0
+	}
+
+	records, err := c.History.Find(query)
+	if err != nil {
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot query deployment history: %s", err)
+		return
+	}
+
+	records = c.visibleRecords(tenant, records)
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+
+	g.JSON(http.StatusOK, records)
+}
+
+// DeploymentRecordHandler returns the deployment with the uuid in the path as JSON.
+func (c *Controller) DeploymentRecordHandler(g *gin.Context) {
+	if c.History == nil {
+		g.String(http.StatusNotFound, "deployment history is not enabled")
+		return
+	}
+
+	record, err := c.History.Get(g.Param("uuid"))
+	if err != nil {
+		if _, ok := err.(history.RecordNotFoundError); ok {
+			g.String(http.StatusNotFound, err.Error())
+			return
+		}
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot query deployment history: %s", err)
+		return
+	}
+
+	if !c.authorizeEnvironment(g, record.Environment) {
+		return
+	}
+
+	if c.Progress != nil {
+		if progress, ok := c.Progress.Get(record.UUID); ok {
+			record.Progress = &progress
+		}
+	}
+
+	g.JSON(http.StatusOK, record)
+}
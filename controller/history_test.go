@@ -0,0 +1,153 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/progress"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Deployment history handlers", func() {
+	var (
+		deploymentHistory *history.MemoryHistory
+		controller        *Controller
+		router            *gin.Engine
+		resp              *httptest.ResponseRecorder
+	)
+
+	get := func(url string) []S.DeploymentRecord {
+		req, err := http.NewRequest("GET", url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(resp, req)
+
+		records := []S.DeploymentRecord{}
+		if resp.Code == http.StatusOK {
+			Expect(json.Unmarshal(resp.Body.Bytes(), &records)).To(Succeed())
+		}
+		return records
+	}
+
+	BeforeEach(func() {
+		deploymentHistory = history.NewMemoryHistory(0)
+		controller = &Controller{
+			Log:     I.DefaultLogger(NewBuffer(), logging.DEBUG, "history_test"),
+			History: deploymentHistory,
+		}
+
+		router = gin.New()
+		router.GET("/v3/deployments", controller.DeploymentHistoryHandler)
+		router.GET("/v3/deployments/:uuid", controller.DeploymentRecordHandler)
+		router.GET("/v3/versions/:org/:space/:appName", controller.DeployedVersionsHandler)
+		resp = httptest.NewRecorder()
+
+		log4j := S.Component{Name: "log4j-core", Version: "2.14.1"}
+		Expect(deploymentHistory.Record(S.DeploymentRecord{UUID: "1", AppName: "billing", Status: S.DeploymentSucceeded, SBOM: &S.SBOM{Components: []S.Component{log4j}}})).To(Succeed())
+		Expect(deploymentHistory.Record(S.DeploymentRecord{UUID: "2", AppName: "search", Status: S.DeploymentSucceeded, SBOM: &S.SBOM{}})).To(Succeed())
+	})
+
+	Describe("DeploymentHistoryHandler", func() {
+		It("returns the deployments matching the query", func() {
+			records := get("/v3/deployments?component=log4j-core&version=2.14&current=true")
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].AppName).To(Equal("billing"))
+		})
+
+		It("returns every deployment without a query", func() {
+			Expect(get("/v3/deployments")).To(HaveLen(2))
+		})
+
+		It("returns bad request for an invalid limit", func() {
+			get("/v3/deployments?limit=many")
+
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("DeploymentRecordHandler", func() {
+		It("returns the deployment", func() {
+			req, _ := http.NewRequest("GET", "/v3/deployments/2", nil)
+			router.ServeHTTP(resp, req)
+
+			record := S.DeploymentRecord{}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &record)).To(Succeed())
+			Expect(record.AppName).To(Equal("search"))
+			Expect(record.Progress).To(BeNil())
+		})
+
+		It("returns the progress of the deployment", func() {
+			tracker := progress.NewTracker()
+			tracker.StartPhase("2", S.PhaseExecuting, 2)
+			tracker.FinishFoundation("2")
+			controller.Progress = tracker
+
+			req, _ := http.NewRequest("GET", "/v3/deployments/2", nil)
+			router.ServeHTTP(resp, req)
+
+			record := S.DeploymentRecord{}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &record)).To(Succeed())
+			Expect(record.Progress.Phase).To(Equal(S.PhaseExecuting))
+			Expect(record.Progress.Percent).To(Equal(60))
+		})
+
+		It("returns not found for an unknown deployment", func() {
+			req, _ := http.NewRequest("GET", "/v3/deployments/unknown", nil)
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("DeployedVersionsHandler", func() {
+		BeforeEach(func() {
+			Expect(deploymentHistory.Record(S.DeploymentRecord{UUID: "3", Environment: "stage", Org: "org", Space: "space", AppName: "search", Status: S.DeploymentSucceeded, ArtifactURL: "https://example.com/2.zip", Foundations: []string{"https://api.stage.example.com"}})).To(Succeed())
+			Expect(deploymentHistory.Record(S.DeploymentRecord{UUID: "4", Environment: "prod", Org: "org", Space: "space", AppName: "search", Status: S.DeploymentSucceeded, ArtifactURL: "https://example.com/1.zip", Foundations: []string{"https://api.prod.example.com"}})).To(Succeed())
+		})
+
+		versions := func(url string) []S.DeployedVersion {
+			req, _ := http.NewRequest("GET", url, nil)
+			router.ServeHTTP(resp, req)
+
+			versions := []S.DeployedVersion{}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &versions)).To(Succeed())
+			return versions
+		}
+
+		It("returns what is deployed to every foundation", func() {
+			deployed := versions("/v3/versions/org/space/search")
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(deployed).To(HaveLen(2))
+			Expect(deployed[0].Environment).To(Equal("prod"))
+			Expect(deployed[0].ArtifactURL).To(Equal("https://example.com/1.zip"))
+			Expect(deployed[1].Environment).To(Equal("stage"))
+			Expect(deployed[1].ArtifactURL).To(Equal("https://example.com/2.zip"))
+		})
+
+		It("returns what is deployed to one environment", func() {
+			deployed := versions("/v3/versions/org/space/search?environment=stage")
+
+			Expect(deployed).To(HaveLen(1))
+			Expect(deployed[0].FoundationURL).To(Equal("https://api.stage.example.com"))
+		})
+
+		It("returns http.StatusNotFound when there is no deployment history", func() {
+			controller.History = nil
+			req, _ := http.NewRequest("GET", "/v3/versions/org/space/search", nil)
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
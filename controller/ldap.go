@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/compozed/deployadactyl/ldap"
+	"github.com/gin-gonic/gin"
+)
+
+// authenticateUser reports whether the basic auth of the request is that of an LDAP user allowed to deploy to the
+// environment, or writes why it is not. The requests to environments without ldap are not checked, and their basic
+// auth is only checked by Cloud Foundry.
+func (c *Controller) authenticateUser(g *gin.Context, environment string) bool {
+	env := c.Config.Environments[environment]
+	if !env.LDAP {
+		return true
+	}
+	if c.Authenticator == nil {
+		g.String(http.StatusInternalServerError, "environment %s requires ldap but ldap is not configured", environment)
+		return false
+	}
+
+	user, pwd, _ := g.Request.BasicAuth()
+	memberOf, err := c.Authenticator.Authenticate(user, pwd, env.LDAPGroups)
+	switch err.(type) {
+	case nil:
+		g.Set(groupsKey, memberOf)
+		return true
+	case ldap.InvalidCredentialsError:
+		g.Header("WWW-Authenticate", `Basic realm="deployadactyl"`)
+		g.String(http.StatusUnauthorized, "%s", err)
+	case ldap.ForbiddenError:
+		g.String(http.StatusForbidden, "cannot use environment %s: %s", environment, err)
+	default:
+		c.Log.Errorf("cannot authenticate %s: %s", user, err)
+		g.String(http.StatusServiceUnavailable, "cannot authenticate with ldap: %s", err)
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package controller_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/ldap"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("LDAP", func() {
+	var (
+		authenticator  *mocks.Authenticator
+		pushController *mocks.PushController
+		router         *gin.Engine
+		resp           *httptest.ResponseRecorder
+	)
+
+	deploy := func(environment string) {
+		req, err := http.NewRequest("POST", "/v3/apps/"+environment+"/org/space/app", strings.NewReader(`{"artifact_url": "https://example.com/artifact.zip"}`))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		req.SetBasicAuth("jane", "the-password")
+		router.ServeHTTP(resp, req)
+	}
+
+	BeforeEach(func() {
+		authenticator = &mocks.Authenticator{}
+		pushController = &mocks.PushController{}
+		pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+
+		controller := &Controller{
+			Log: I.DefaultLogger(NewBuffer(), logging.DEBUG, "ldap_test"),
+			Config: config.Config{
+				Environments: map[string]S.Environment{
+					"dev":  {Name: "dev", Foundations: []string{"https://api1.example.com"}},
+					"prod": {Name: "prod", Foundations: []string{"https://api1.example.com"}, LDAP: true, LDAPGroups: []string{"deployers"}},
+				},
+			},
+			PushControllerFactory: func(log I.DeploymentLogger) I.PushController {
+				return pushController
+			},
+			Authenticator: authenticator,
+		}
+
+		router = gin.New()
+		router.POST("/v3/apps/:environment/:org/:space/:appName", controller.RunDeploymentViaHttp)
+		resp = httptest.NewRecorder()
+	})
+
+	It("deploys with the basic auth of a user of the groups of the environment", func() {
+		authenticator.AuthenticateCall.Returns.MemberOf = []string{"cn=deployers,ou=groups,dc=example,dc=com", "deployers"}
+
+		deploy("prod")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(authenticator.AuthenticateCall.Received.Username).To(Equal("jane"))
+		Expect(authenticator.AuthenticateCall.Received.Password).To(Equal("the-password"))
+		Expect(authenticator.AuthenticateCall.Received.Groups).To(Equal([]string{"deployers"}))
+		Expect(pushController.RunDeploymentCall.Received.Deployment.Authorization).To(Equal(I.Authorization{
+			Username: "jane",
+			Password: "the-password",
+			Groups:   []string{"cn=deployers,ou=groups,dc=example,dc=com", "deployers"},
+		}))
+	})
+
+	It("does not check the requests to environments without ldap", func() {
+		deploy("dev")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(authenticator.AuthenticateCall.Called).To(BeZero())
+	})
+
+	It("refuses invalid credentials", func() {
+		authenticator.AuthenticateCall.Returns.Error = ldap.InvalidCredentialsError{Username: "jane"}
+
+		deploy("prod")
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Body.String()).To(Equal("invalid ldap credentials for user jane"))
+		Expect(pushController.RunDeploymentCall.Received.Deployment).To(BeNil())
+	})
+
+	It("forbids users of other groups", func() {
+		authenticator.AuthenticateCall.Returns.Error = ldap.ForbiddenError{Username: "jane", Groups: []string{"deployers"}}
+
+		deploy("prod")
+
+		Expect(resp.Code).To(Equal(http.StatusForbidden))
+		Expect(resp.Body.String()).To(Equal("cannot use environment prod: user jane is not a member of any of the ldap groups deployers"))
+		Expect(pushController.RunDeploymentCall.Received.Deployment).To(BeNil())
+	})
+
+	It("does not deploy when ldap cannot be reached", func() {
+		authenticator.AuthenticateCall.Returns.Error = errors.New("connection refused")
+
+		deploy("prod")
+
+		Expect(resp.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(pushController.RunDeploymentCall.Received.Deployment).To(BeNil())
+	})
+})
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/compozed/deployadactyl/responsebuffer"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/afero"
+)
+
+// LimitRequestSize refuses requests whose body is larger than the limits with 413. Artifacts uploaded as a zip are
+// limited by MaxUploadBytes and every other body by MaxRequestBytes.
+//
+// Requests announcing a larger Content-Length are refused before their body is read. Otherwise the body fails with
+// a RequestTooLargeError once it is read past the limit.
+func LimitRequestSize(limits S.LimitsDescriptor) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		maxBytes := limits.MaxRequestBytes()
+		if g.Request.Header.Get("Content-Type") == "application/zip" {
+			maxBytes = limits.MaxUploadBytes()
+		}
+
+		if g.Request.ContentLength > maxBytes {
+			g.String(http.StatusRequestEntityTooLarge, RequestTooLargeError{maxBytes}.Error())
+			g.Abort()
+			return
+		}
+
+		g.Request.Body = &limitedBody{ReadCloser: g.Request.Body, maxBytes: maxBytes, remaining: maxBytes}
+	}
+}
+
+// limitedBody is a request body that fails with a RequestTooLargeError once more than maxBytes are read from it.
+type limitedBody struct {
+	io.ReadCloser
+	maxBytes  int64
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return n, err
+	}
+
+	n = int(b.remaining)
+	b.remaining = 0
+	return n, RequestTooLargeError{b.maxBytes}
+}
+
+// readBody returns the body of the request, or writes why it cannot be read, such as being larger than the limits
+// or not being valid gzip.
+func (c *Controller) readBody(g *gin.Context) ([]byte, bool) {
+	body, err := ioutil.ReadAll(g.Request.Body)
+	g.Request.Body.Close()
+
+	if err != nil {
+		writeBodyError(g, err)
+		return nil, false
+	}
+	return body, true
+}
+
+// spoolUpload writes the zip uploaded with the request to a temporary file of the work directory, so that the
+// artifact is not held in memory, and returns the file to read it from. It writes why the upload cannot be stored
+// like readBody. The file is removed with removeUpload.
+func (c *Controller) spoolUpload(g *gin.Context) (afero.File, bool) {
+	upload, err := c.fileSystem().TempFile(c.Config.WorkDirectory, "deployadactyl-upload-")
+	if err != nil {
+		g.String(http.StatusInternalServerError, "cannot store the uploaded artifact: %s", err)
+		return nil, false
+	}
+
+	_, err = io.Copy(upload, g.Request.Body)
+	g.Request.Body.Close()
+	if err != nil {
+		c.removeUpload(upload)
+		writeBodyError(g, err)
+		return nil, false
+	}
+
+	_, err = upload.Seek(0, io.SeekStart)
+	if err != nil {
+		c.removeUpload(upload)
+		g.String(http.StatusInternalServerError, "cannot store the uploaded artifact: %s", err)
+		return nil, false
+	}
+	return upload, true
+}
+
+// removeUpload closes and removes the file an upload was stored in by spoolUpload.
+func (c *Controller) removeUpload(upload afero.File) {
+	upload.Close()
+	c.fileSystem().Remove(upload.Name())
+}
+
+// writeBodyError responds with why the body of the request could not be read.
+func writeBodyError(g *gin.Context, err error) {
+	if tooLarge, ok := err.(RequestTooLargeError); ok {
+		g.String(http.StatusRequestEntityTooLarge, tooLarge.Error())
+		return
+	}
+	g.String(http.StatusBadRequest, "cannot read the request body: %s", err)
+}
+
+// newResponse returns the buffer the output of a deployment is written to. Past the configured limit the output
+// is moved to a temporary file of the work directory, which is removed when the buffer is closed.
+func (c *Controller) newResponse() *responsebuffer.Buffer {
+	return responsebuffer.New(c.fileSystem(), c.Config.WorkDirectory, c.Config.Limits.MaxResponseBufferBytes())
+}
+
+// fileSystem returns the file system of the temporary files of the controller, which is the one of the OS unless
+// another one is set.
+func (c *Controller) fileSystem() *afero.Afero {
+	if c.FileSystem == nil {
+		return &afero.Afero{Fs: afero.NewOsFs()}
+	}
+	return c.FileSystem
+}
@@ -0,0 +1,149 @@
+package controller_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Limits", func() {
+	var (
+		deploymentLogs *mocks.DeploymentLogStore
+		pushController *mocks.PushController
+		fileSystem     *afero.Afero
+		router         *gin.Engine
+		resp           *httptest.ResponseRecorder
+	)
+
+	const megabyte = 1 << 20
+
+	BeforeEach(func() {
+		deploymentLogs = &mocks.DeploymentLogStore{}
+		pushController = &mocks.PushController{}
+		pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+
+		fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+		Expect(fileSystem.MkdirAll("/work", 0755)).To(Succeed())
+
+		limits := S.LimitsDescriptor{MaxRequestMB: 1, MaxUploadMB: 2, MaxResponseBufferMB: 1}
+		controller := &Controller{
+			Log: I.DefaultLogger(NewBuffer(), logging.DEBUG, "limits_test"),
+			PushControllerFactory: func(log I.DeploymentLogger) I.PushController {
+				return pushController
+			},
+			Config:         config.Config{WorkDirectory: "/work", Limits: limits},
+			DeploymentLogs: deploymentLogs,
+			FileSystem:     fileSystem,
+		}
+
+		router = gin.New()
+		router.Use(LimitRequestSize(limits))
+		router.POST("/v3/apps/:environment/:org/:space/:appName", controller.RunDeploymentViaHttp)
+		resp = httptest.NewRecorder()
+	})
+
+	deploy := func(contentType string, size int, chunked bool) {
+		req, err := http.NewRequest("POST", "/v3/apps/prod/org/space/app", bytes.NewReader(make([]byte, size)))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Content-Type", contentType)
+		if chunked {
+			req.ContentLength = -1
+		}
+		router.ServeHTTP(resp, req)
+	}
+
+	It("deploys requests within the limit", func() {
+		deploy("application/json", megabyte, false)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(*pushController.RunDeploymentCall.Received.Deployment.Body).To(HaveLen(megabyte))
+	})
+
+	It("refuses a request whose content length is larger than the limit", func() {
+		deploy("application/json", megabyte+1, false)
+
+		Expect(resp.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(resp.Body.String()).To(Equal(RequestTooLargeError{megabyte}.Error()))
+		Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+	})
+
+	It("refuses a request without a content length once its body is read past the limit", func() {
+		deploy("application/json", megabyte+1, true)
+
+		Expect(resp.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+	})
+
+	It("limits uploaded artifacts with the upload limit", func() {
+		deploy("application/zip", 2*megabyte, true)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		resp = httptest.NewRecorder()
+		deploy("application/zip", 2*megabyte+1, false)
+
+		Expect(resp.Code).To(Equal(http.StatusRequestEntityTooLarge))
+	})
+
+	It("streams uploaded artifacts to a file of the work directory and removes it", func() {
+		deploy("application/zip", 2*megabyte, false)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(pushController.RunDeploymentCall.Received.Deployment.Body).To(BeNil())
+		Expect(pushController.RunDeploymentCall.Received.Upload).To(Equal(make([]byte, 2*megabyte)))
+
+		infos, err := fileSystem.ReadDir("/work")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(infos).To(BeEmpty())
+	})
+
+	It("removes the file of an upload larger than the limit", func() {
+		deploy("application/zip", 2*megabyte+1, true)
+
+		Expect(resp.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+
+		infos, err := fileSystem.ReadDir("/work")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(infos).To(BeEmpty())
+	})
+
+	Context("when the output of the deployment is larger than the response buffer", func() {
+		BeforeEach(func() {
+			pushController.RunDeploymentCall.Writes = strings.Repeat("a", megabyte) + strings.Repeat("b", 10)
+		})
+
+		It("responds with the whole output and removes its temporary file", func() {
+			deploy("application/json", 0, false)
+
+			Expect(resp.Body.Len()).To(Equal(megabyte + 10))
+			Expect(strings.HasSuffix(resp.Body.String(), "abbbbbbbbbb")).To(BeTrue())
+
+			infos, err := fileSystem.ReadDir("/work")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(infos).To(BeEmpty())
+		})
+
+		It("only saves the end of the output", func() {
+			deploy("application/json", 0, false)
+
+			output := string(deploymentLogs.SaveCall.Received.Output)
+			Expect(output).To(HavePrefix("[the first 10 bytes of the output were not kept]\n"))
+			Expect(output).To(HaveLen(megabyte + len("[the first 10 bytes of the output were not kept]\n")))
+			Expect(strings.HasSuffix(output, "bbbbbbbbbb")).To(BeTrue())
+		})
+	})
+})
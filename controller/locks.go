@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"net/http"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/lock"
+)
+
+// lockApp takes the lock of the application of the deployment, so no other deployment changes it until the returned
+// function releases it. Without a LockManager applications are not locked.
+func (c *Controller) lockApp(log I.DeploymentLogger, cfContext I.CFContext) (func(), error) {
+	if c.Locks == nil {
+		return func() {}, nil
+	}
+
+	key := lock.AppKey(cfContext.Environment, cfContext.Organization, cfContext.Space, cfContext.Application)
+	err := c.Locks.Lock(key, log.UUID)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("locked %s", key)
+
+	return func() {
+		err := c.Locks.Unlock(key, log.UUID)
+		if err != nil {
+			log.Errorf("cannot unlock %s: %s", key, err)
+		}
+	}, nil
+}
+
+// lockErrorStatus returns the status of a request whose application could not be locked: 409 when another
+// deployment holds the lock.
+func lockErrorStatus(err error) int {
+	if _, ok := err.(lock.HeldError); ok {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,124 @@
+package controller_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/lock"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Application locks", func() {
+	var (
+		locks          *lock.MemoryLocks
+		pushController *mocks.PushController
+		stopController *mocks.StopController
+		controller     *Controller
+		router         *gin.Engine
+		resp           *httptest.ResponseRecorder
+	)
+
+	request := func(method, url, body string) {
+		req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(resp, req)
+	}
+
+	BeforeEach(func() {
+		locks = lock.NewMemoryLocks()
+		pushController = &mocks.PushController{}
+		pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+		stopController = &mocks.StopController{}
+		stopController.StopDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+
+		controller = &Controller{
+			Log:    I.DefaultLogger(NewBuffer(), logging.DEBUG, "locks_test"),
+			Locks:  locks,
+			Config: config.Config{Environments: map[string]S.Environment{"stage": {Name: "stage"}, "prod": {Name: "prod"}}},
+			PushControllerFactory: func(log I.DeploymentLogger) I.PushController {
+				return pushController
+			},
+			StopControllerFactory: func(log I.DeploymentLogger) I.StopController {
+				return stopController
+			},
+		}
+
+		router = gin.New()
+		router.POST("/v3/apps/:environment/:org/:space/:appName", controller.RunDeploymentViaHttp)
+		router.PUT("/v3/apps/:environment/:org/:space/:appName", controller.PutRequestHandler)
+		router.POST("/v3/batches/:org/:space/:appName", controller.BatchDeploymentHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	It("locks the application while it is deployed", func() {
+		mockLocks := &mocks.LockManager{}
+		controller.Locks = mockLocks
+
+		request("POST", "/v3/apps/Prod/org/space/Search", "{}")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(mockLocks.LockCall.Received.Key).To(Equal("prod/org/space/search"))
+		Expect(mockLocks.LockCall.Received.Owner).ToNot(BeEmpty())
+		Expect(mockLocks.UnlockCall.Received.Key).To(Equal("prod/org/space/search"))
+		Expect(mockLocks.UnlockCall.Received.Owner).To(Equal(mockLocks.LockCall.Received.Owner))
+	})
+
+	It("releases the lock once the deployment is over", func() {
+		request("POST", "/v3/apps/prod/org/space/search", "{}")
+
+		Expect(locks.Lock("prod/org/space/search", "5678")).To(Succeed())
+	})
+
+	It("returns 409 without deploying when another deployment holds the lock", func() {
+		Expect(locks.Lock("prod/org/space/search", "5678")).To(Succeed())
+
+		request("POST", "/v3/apps/prod/org/space/search", "{}")
+
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+		Expect(resp.Body.String()).To(ContainSubstring("prod/org/space/search is locked by deployment 5678"))
+		Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+	})
+
+	It("returns 500 when the lock cannot be taken", func() {
+		mockLocks := &mocks.LockManager{}
+		mockLocks.LockCall.Returns.Error = errors.New("connection refused")
+		controller.Locks = mockLocks
+
+		request("POST", "/v3/apps/prod/org/space/search", "{}")
+
+		Expect(resp.Code).To(Equal(http.StatusInternalServerError))
+		Expect(mockLocks.UnlockCall.Called).To(BeFalse())
+	})
+
+	It("does not change the state of an application another deployment holds the lock of", func() {
+		Expect(locks.Lock("prod/org/space/search", "5678")).To(Succeed())
+
+		request("PUT", "/v3/apps/prod/org/space/search", `{"state": "stopped"}`)
+
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+		Expect(stopController.StopDeploymentCall.Called).To(BeFalse())
+	})
+
+	It("fails the deployments of a batch whose application is locked", func() {
+		Expect(locks.Lock("stage/org/space/search", "5678")).To(Succeed())
+
+		request("POST", "/v3/batches/org/space/search", `{"environments": ["stage", "prod"], "deployment": {}}`)
+
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+		Expect(resp.Body.String()).To(ContainSubstring(string(S.DeploymentFailed)))
+		Expect(resp.Body.String()).To(ContainSubstring("stage/org/space/search is locked by deployment 5678"))
+		Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+	})
+})
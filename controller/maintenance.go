@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceRequest puts a foundation in maintenance, or takes it out of maintenance.
+type MaintenanceRequest struct {
+	FoundationURL string `json:"foundation_url"`
+	Maintenance   bool   `json:"maintenance"`
+}
+
+// MaintenanceResponse has the foundations in maintenance.
+type MaintenanceResponse struct {
+	Foundations []string `json:"foundations"`
+}
+
+// MaintenanceHandler returns the foundations in maintenance as JSON. The foundations can belong to the
+// environments of every tenant, so the request must have the credentials of the server.
+func (c *Controller) MaintenanceHandler(g *gin.Context) {
+	if c.Maintenance == nil {
+		g.String(http.StatusNotFound, "foundation maintenance is not enabled")
+		return
+	}
+
+	user, pwd, _ := g.Request.BasicAuth()
+	if !c.serverCredentials(user, pwd) {
+		g.String(http.StatusUnauthorized, "the credentials of the server are required to see the foundations in maintenance")
+		return
+	}
+
+	g.JSON(http.StatusOK, MaintenanceResponse{Foundations: c.Maintenance.Foundations()})
+}
+
+// SetMaintenanceHandler puts a foundation in maintenance, so that deployments skip it, or takes it out of
+// maintenance. Every deployment is affected, so the request must have the credentials of the server.
+func (c *Controller) SetMaintenanceHandler(g *gin.Context) {
+	if c.Maintenance == nil {
+		g.String(http.StatusNotFound, "foundation maintenance is not enabled")
+		return
+	}
+
+	user, pwd, _ := g.Request.BasicAuth()
+	if !c.serverCredentials(user, pwd) {
+		g.String(http.StatusUnauthorized, "the credentials of the server are required to change the foundations in maintenance")
+		return
+	}
+
+	bodyBuffer, ok := c.readBody(g)
+	if !ok {
+		return
+	}
+
+	request := &MaintenanceRequest{}
+	err := json.Unmarshal(bodyBuffer, request)
+	if err != nil {
+		g.String(http.StatusBadRequest, "invalid request body: %s", err)
+		return
+	}
+	if request.FoundationURL == "" {
+		g.String(http.StatusBadRequest, "invalid request body: foundation_url is required")
+		return
+	}
+	if !c.Config.HasFoundation(request.FoundationURL) {
+		g.String(http.StatusBadRequest, "%s is not a foundation of any environment", request.FoundationURL)
+		return
+	}
+
+	c.Maintenance.SetMaintenance(request.FoundationURL, request.Maintenance)
+	if request.Maintenance {
+		c.Log.Infof("%s was put in maintenance, deployments will skip it", request.FoundationURL)
+	} else {
+		c.Log.Infof("%s was taken out of maintenance", request.FoundationURL)
+	}
+
+	g.JSON(http.StatusOK, MaintenanceResponse{Foundations: c.Maintenance.Foundations()})
+}
@@ -0,0 +1,139 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("MaintenanceHandler", func() {
+	var (
+		maintenance *mocks.FoundationMaintenance
+		controller  *Controller
+		router      *gin.Engine
+		resp        *httptest.ResponseRecorder
+		logBuffer   *Buffer
+		token       string
+	)
+
+	request := func(method, body, username, password string) {
+		req, err := http.NewRequest(method, "/v3/maintenance", bytes.NewBufferString(body))
+		Expect(err).ToNot(HaveOccurred())
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		if token != "" {
+			req.Header.Set(TenantTokenHeader, token)
+		}
+		router.ServeHTTP(resp, req)
+	}
+
+	BeforeEach(func() {
+		maintenance = &mocks.FoundationMaintenance{}
+		maintenance.FoundationsCall.Returns.Foundations = []string{"https://api.west.example.com"}
+		logBuffer = NewBuffer()
+
+		controller = &Controller{
+			Log:         I.DefaultLogger(logBuffer, logging.DEBUG, "maintenance_test"),
+			Maintenance: maintenance,
+			Config: config.Config{
+				Username: "cf-username",
+				Password: "cf-password",
+				Environments: map[string]S.Environment{
+					"prod": {Name: "prod", Foundations: []string{"https://api.east.example.com", "https://api.west.example.com"}},
+				},
+				Tenants: map[string]S.Tenant{
+					"search": {Name: "search", APITokens: []string{"search-token"}},
+				},
+			},
+		}
+		token = ""
+
+		router = gin.New()
+		router.GET("/v3/maintenance", controller.MaintenanceHandler)
+		router.PUT("/v3/maintenance", controller.SetMaintenanceHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	It("returns the foundations in maintenance", func() {
+		request("GET", "", "cf-username", "cf-password")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		returned := MaintenanceResponse{}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &returned)).To(Succeed())
+		Expect(returned.Foundations).To(Equal([]string{"https://api.west.example.com"}))
+	})
+
+	It("returns http.StatusUnauthorized for the foundations in maintenance without the credentials of the server", func() {
+		request("GET", "", "", "")
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Body.String()).ToNot(ContainSubstring("api.west.example.com"))
+	})
+
+	It("returns http.StatusUnauthorized for the foundations in maintenance with the api token of a tenant", func() {
+		token = "search-token"
+
+		request("GET", "", "", "")
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Body.String()).ToNot(ContainSubstring("api.west.example.com"))
+	})
+
+	It("puts a foundation in maintenance", func() {
+		request("PUT", `{"foundation_url": "https://api.east.example.com", "maintenance": true}`, "cf-username", "cf-password")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(maintenance.SetMaintenanceCall.Received.FoundationURL).To(Equal("https://api.east.example.com"))
+		Expect(maintenance.SetMaintenanceCall.Received.Maintenance).To(BeTrue())
+		Eventually(logBuffer).Should(Say("https://api.east.example.com was put in maintenance"))
+	})
+
+	It("takes a foundation out of maintenance", func() {
+		request("PUT", `{"foundation_url": "https://api.west.example.com", "maintenance": false}`, "cf-username", "cf-password")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(maintenance.SetMaintenanceCall.Received.FoundationURL).To(Equal("https://api.west.example.com"))
+		Expect(maintenance.SetMaintenanceCall.Received.Maintenance).To(BeFalse())
+	})
+
+	It("returns http.StatusUnauthorized without the credentials of the server", func() {
+		request("PUT", `{"foundation_url": "https://api.east.example.com", "maintenance": true}`, "cf-username", "wrong")
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(maintenance.SetMaintenanceCall.Called).To(BeFalse())
+	})
+
+	It("returns http.StatusBadRequest when the foundation is not a foundation of any environment", func() {
+		request("PUT", `{"foundation_url": "https://api.north.example.com", "maintenance": true}`, "cf-username", "cf-password")
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		Expect(maintenance.SetMaintenanceCall.Called).To(BeFalse())
+	})
+
+	It("returns http.StatusBadRequest without a foundation", func() {
+		request("PUT", `{"maintenance": true}`, "cf-username", "cf-password")
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("returns http.StatusNotFound when maintenance is not enabled", func() {
+		controller.Maintenance = nil
+
+		request("GET", "", "", "")
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,13 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/compozed/deployadactyl/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPIHandler returns the OpenAPI document of the API.
+func (c *Controller) OpenAPIHandler(g *gin.Context) {
+	g.Data(http.StatusOK, "application/json", []byte(openapi.Document))
+}
@@ -0,0 +1,28 @@
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/openapi"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OpenAPIHandler", func() {
+	It("returns the OpenAPI document", func() {
+		router := gin.New()
+		router.GET("/v3/openapi.json", (&Controller{}).OpenAPIHandler)
+		resp := httptest.NewRecorder()
+
+		req, err := http.NewRequest("GET", "/v3/openapi.json", nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(resp.Body.String()).To(Equal(openapi.Document))
+	})
+})
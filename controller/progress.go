@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProgressEvent is the name of the server-sent events carrying the progress of a deployment.
+const ProgressEvent = "progress"
+
+// DeploymentProgressHandler streams the progress of the deployment with the uuid in the path as
+// server-sent events, each holding the whole progress as JSON. The stream ends once the deployment
+// is finished. A deployment that has not started yet is waited for, unless tenants are configured: the tenant of
+// a deployment is only known once it started.
+func (c *Controller) DeploymentProgressHandler(g *gin.Context) {
+	if c.Progress == nil {
+		g.String(http.StatusNotFound, "deployment progress is not enabled")
+		return
+	}
+
+	if !c.authorizeDeployment(g, g.Param("uuid")) {
+		return
+	}
+
+	updates, unsubscribe := c.Progress.Subscribe(g.Param("uuid"))
+	defer unsubscribe()
+
+	g.Header("Content-Type", "text/event-stream")
+	g.Header("Cache-Control", "no-cache")
+	g.Header("Connection", "keep-alive")
+	g.Writer.WriteHeader(http.StatusOK)
+	g.Writer.Flush()
+
+	for {
+		select {
+		case progress, ok := <-updates:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(progress)
+			if err != nil {
+				c.Log.Error(err)
+				return
+			}
+			fmt.Fprintf(g.Writer, "event: %s\ndata: %s\n\n", ProgressEvent, data)
+			g.Writer.Flush()
+		case <-g.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package controller_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/progress"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("DeploymentProgressHandler", func() {
+	var (
+		tracker    *progress.Tracker
+		controller *Controller
+		server     *httptest.Server
+	)
+
+	BeforeEach(func() {
+		tracker = progress.NewTracker()
+		controller = &Controller{
+			Log:      I.DefaultLogger(NewBuffer(), logging.DEBUG, "progress_test"),
+			Progress: tracker,
+		}
+
+		router := gin.New()
+		router.GET("/v3/deployments/:uuid/progress", controller.DeploymentProgressHandler)
+		server = httptest.NewServer(router)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("streams the progress until the deployment is finished", func() {
+		tracker.StartPhase("abc", S.PhaseExecuting, 2)
+
+		resp, err := http.Get(server.URL + "/v3/deployments/abc/progress")
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+		tracker.FinishFoundation("abc")
+		tracker.Finish("abc", nil)
+
+		var events []string
+		var updates []S.DeploymentProgress
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "event: ") {
+				events = append(events, strings.TrimPrefix(line, "event: "))
+			}
+			if strings.HasPrefix(line, "data: ") {
+				update := S.DeploymentProgress{}
+				Expect(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &update)).To(Succeed())
+				updates = append(updates, update)
+			}
+		}
+
+		Expect(events).To(ConsistOf(ProgressEvent, ProgressEvent, ProgressEvent))
+		Expect(updates).To(HaveLen(3))
+		Expect(updates[0].Percent).To(Equal(40))
+		Expect(updates[1].Percent).To(Equal(60))
+		Expect(updates[2].Finished).To(BeTrue())
+	})
+
+	It("returns http.StatusNotFound when progress is not enabled", func() {
+		controller.Progress = nil
+
+		resp, err := http.Get(server.URL + "/v3/deployments/abc/progress")
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/compozed/deployadactyl/deploymentid"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// PromotedFromMetadataKey is the metadata key holding the uuid of the deployment a promotion deploys again.
+const PromotedFromMetadataKey = "promoted_from"
+
+// PromotionRequest promotes the application running in the source environment to the target environment.
+type PromotionRequest struct {
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Org     string `json:"org"`
+	Space   string `json:"space"`
+	AppName string `json:"app_name"`
+}
+
+// PromotionHandler deploys the application to the target environment with the request of its current deployment
+// in the source environment. The artifact must have the digest recorded in the source, so what was tested in the
+// source is exactly what is deployed to the target.
+func (c *Controller) PromotionHandler(g *gin.Context) {
+	if c.History == nil {
+		g.String(http.StatusNotFound, "deployment history is not enabled")
+		return
+	}
+
+	bodyBuffer, ok := c.readBody(g)
+	if !ok {
+		return
+	}
+
+	promotion := &PromotionRequest{}
+	err := json.Unmarshal(bodyBuffer, promotion)
+	if err != nil {
+		g.String(http.StatusBadRequest, "invalid request body: %s", err)
+		return
+	}
+	promotion.Source, promotion.Target = strings.ToLower(promotion.Source), strings.ToLower(promotion.Target)
+	if promotion.Source == "" || promotion.Target == "" || promotion.Org == "" || promotion.Space == "" || promotion.AppName == "" {
+		g.String(http.StatusBadRequest, "invalid request body: source, target, org, space and app_name are required")
+		return
+	}
+	if promotion.Source == promotion.Target {
+		g.String(http.StatusBadRequest, "cannot promote from %s to itself", promotion.Source)
+		return
+	}
+	if _, ok := c.Config.Environments[promotion.Target]; !ok {
+		g.String(http.StatusBadRequest, "environment not found: %s", promotion.Target)
+		return
+	}
+	if !c.authorizeEnvironment(g, promotion.Source) || !c.authorizeEnvironment(g, promotion.Target) || !c.authenticateUser(g, promotion.Target) {
+		return
+	}
+
+	records, err := c.History.Find(S.DeploymentQuery{
+		Environment: promotion.Source,
+		Org:         promotion.Org,
+		Space:       promotion.Space,
+		AppName:     promotion.AppName,
+		Current:     true,
+		Limit:       1,
+	})
+	if err != nil {
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot query deployment history: %s", err)
+		return
+	}
+	if len(records) == 0 {
+		g.String(http.StatusNotFound, "%s is not deployed to %s/%s in %s", promotion.AppName, promotion.Org, promotion.Space, promotion.Source)
+		return
+	}
+
+	record := records[0]
+	if len(record.Request) == 0 {
+		g.String(http.StatusConflict, "deployment %s cannot be promoted: it was not requested with JSON", record.UUID)
+		return
+	}
+	if record.ArtifactDigest == "" {
+		g.String(http.StatusConflict, "deployment %s cannot be promoted: the digest of its artifact is not known", record.UUID)
+		return
+	}
+
+	uuid := c.Config.DeploymentID.Generate(promotion.Target, g.Request.Header.Get(deploymentid.IdempotencyKeyHeader))
+	log := I.DeploymentLogger{Log: c.Log, UUID: uuid}
+	log.Debugf("promoting deployment %s from %s to %s, request originated from: %+v", record.UUID, promotion.Source, promotion.Target, g.Request.RemoteAddr)
+
+	body := []byte(record.Request)
+
+	deployment := I.Deployment{
+		Authorization: requestAuthorization(g),
+		CFContext: I.CFContext{
+			Environment:  promotion.Target,
+			Organization: promotion.Org,
+			Space:        promotion.Space,
+			Application:  promotion.AppName,
+		},
+		Type:           I.DeploymentType{JSON: true},
+		Body:           &body,
+		Metadata:       S.MergeMetadata(record.Metadata, metadataFromHeaders(g.Request.Header), map[string]string{PromotedFromMetadataKey: record.UUID}),
+		ArtifactDigest: record.ArtifactDigest,
+	}
+
+	c.pushDeployment(g, log, &deployment)
+}
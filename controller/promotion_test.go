@@ -0,0 +1,155 @@
+package controller_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("PromotionHandler", func() {
+	var (
+		deploymentHistory *history.MemoryHistory
+		pushController    *mocks.PushController
+		controller        *Controller
+		router            *gin.Engine
+		resp              *httptest.ResponseRecorder
+		tested            S.DeploymentRecord
+	)
+
+	promote := func(body string) {
+		req, err := http.NewRequest("POST", "/v3/promotions", bytes.NewBufferString(body))
+		Expect(err).ToNot(HaveOccurred())
+		req.SetBasicAuth("username", "password")
+		router.ServeHTTP(resp, req)
+	}
+
+	BeforeEach(func() {
+		deploymentHistory = history.NewMemoryHistory(0)
+		pushController = &mocks.PushController{}
+		pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+		pushController.RunDeploymentCall.Writes = "deploy success"
+
+		controller = &Controller{
+			Log:     I.DefaultLogger(NewBuffer(), logging.DEBUG, "promotion_test"),
+			History: deploymentHistory,
+			Config: config.Config{
+				Environments: map[string]S.Environment{
+					"stage": {Name: "stage"},
+					"prod":  {Name: "prod"},
+				},
+			},
+			PushControllerFactory: func(log I.DeploymentLogger) I.PushController {
+				return pushController
+			},
+		}
+
+		tested = S.DeploymentRecord{
+			UUID:           "tested",
+			Environment:    "stage",
+			Org:            "org",
+			Space:          "space",
+			AppName:        "search",
+			Status:         S.DeploymentSucceeded,
+			ArtifactURL:    "https://example.com/artifact-2.zip",
+			ArtifactDigest: "sha256:abc",
+			Metadata:       map[string]string{"revision": "2"},
+			Request:        []byte(`{"artifact_url": "https://example.com/artifact-2.zip"}`),
+			StartedAt:      time.Now(),
+		}
+		Expect(deploymentHistory.Record(S.DeploymentRecord{
+			UUID: "older", Environment: "stage", Org: "org", Space: "space", AppName: "search",
+			Status: S.DeploymentSucceeded, ArtifactDigest: "sha256:old", Request: []byte(`{}`),
+		})).To(Succeed())
+		Expect(deploymentHistory.Record(tested)).To(Succeed())
+		Expect(deploymentHistory.Record(S.DeploymentRecord{
+			UUID: "broken", Environment: "stage", Org: "org", Space: "space", AppName: "search",
+			Status: S.DeploymentFailed, ArtifactDigest: "sha256:broken", Request: []byte(`{}`),
+		})).To(Succeed())
+
+		router = gin.New()
+		router.POST("/v3/promotions", controller.PromotionHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	It("deploys the artifact running in the source environment to the target environment", func() {
+		promote(`{"source": "stage", "target": "prod", "org": "org", "space": "space", "app_name": "search"}`)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(ContainSubstring("deploy success"))
+
+		deployment := pushController.RunDeploymentCall.Received.Deployment
+		Expect(deployment.CFContext).To(Equal(I.CFContext{Environment: "prod", Organization: "org", Space: "space", Application: "search"}))
+		Expect(deployment.Authorization).To(Equal(I.Authorization{Username: "username", Password: "password"}))
+		Expect(deployment.Type).To(Equal(I.DeploymentType{JSON: true}))
+		Expect(string(*deployment.Body)).To(Equal(string(tested.Request)))
+		Expect(deployment.ArtifactDigest).To(Equal("sha256:abc"))
+		Expect(deployment.Metadata).To(Equal(map[string]string{"revision": "2", PromotedFromMetadataKey: "tested"}))
+	})
+
+	It("returns http.StatusNotFound when the application is not deployed to the source environment", func() {
+		promote(`{"source": "stage", "target": "prod", "org": "org", "space": "space", "app_name": "other"}`)
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+		Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+	})
+
+	It("returns http.StatusConflict when the digest of the artifact is not known", func() {
+		tested.ArtifactDigest = ""
+		Expect(deploymentHistory.Record(tested)).To(Succeed())
+
+		promote(`{"source": "stage", "target": "prod", "org": "org", "space": "space", "app_name": "search"}`)
+
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+		Expect(resp.Body.String()).To(ContainSubstring("the digest of its artifact is not known"))
+		Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+	})
+
+	It("returns http.StatusConflict when the deployment was not requested with JSON", func() {
+		tested.Request = nil
+		Expect(deploymentHistory.Record(tested)).To(Succeed())
+
+		promote(`{"source": "stage", "target": "prod", "org": "org", "space": "space", "app_name": "search"}`)
+
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+	})
+
+	It("returns http.StatusBadRequest for an unknown target environment", func() {
+		promote(`{"source": "stage", "target": "qa", "org": "org", "space": "space", "app_name": "search"}`)
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		Expect(resp.Body.String()).To(ContainSubstring("environment not found: qa"))
+	})
+
+	It("returns http.StatusBadRequest when the source is the target", func() {
+		promote(`{"source": "stage", "target": "stage", "org": "org", "space": "space", "app_name": "search"}`)
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("returns http.StatusBadRequest when a field is missing", func() {
+		promote(`{"source": "stage", "target": "prod", "org": "org", "space": "space"}`)
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("returns http.StatusNotFound when there is no deployment history", func() {
+		controller.History = nil
+
+		promote(`{"source": "stage", "target": "prod", "org": "org", "space": "space", "app_name": "search"}`)
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+	})
+})
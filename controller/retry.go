@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/compozed/deployadactyl/deploymentid"
+	"github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// RetryOfMetadataKey is the metadata key holding the uuid of the deployment a retry retries.
+const RetryOfMetadataKey = "retry_of"
+
+// RetryDeploymentHandler deploys a failed or partially succeeded deployment again with the request it was made with.
+//
+// With failed_foundations=true it only deploys to the foundations the deployment failed against, which is
+// meant for environments that do not roll back, where the other foundations already run the new version.
+// The credentials are not recorded, so the retry is authorized like any other deployment.
+func (c *Controller) RetryDeploymentHandler(g *gin.Context) {
+	record, ok := c.retriedDeployment(g)
+	if !ok {
+		return
+	}
+
+	var foundations []string
+	if failedFoundations := g.Query("failed_foundations"); failedFoundations != "" {
+		onlyFailed, err := strconv.ParseBool(failedFoundations)
+		if err != nil {
+			g.String(http.StatusBadRequest, "invalid failed_foundations parameter: %s", failedFoundations)
+			return
+		}
+		if onlyFailed {
+			if len(record.FailedFoundations) == 0 {
+				g.String(http.StatusConflict, "deployment %s cannot be retried on its failed foundations: they are not known", record.UUID)
+				return
+			}
+			foundations = record.FailedFoundations
+		}
+	}
+
+	c.retry(g, record, foundations, "")
+}
+
+// RetryFoundationsHandler deploys a failed or partially succeeded deployment again, only to the foundations it did
+// not succeed on: the ones it failed on, rolled back, aborted or skipped because they were in maintenance.
+//
+// The cached artifact of the deployment is deployed when the artifact cache still has it, so the foundations get
+// the artifact the others run. Otherwise it is fetched again.
+func (c *Controller) RetryFoundationsHandler(g *gin.Context) {
+	record, ok := c.retriedDeployment(g)
+	if !ok {
+		return
+	}
+
+	foundations := retriedFoundations(record)
+	if len(foundations) == 0 {
+		g.String(http.StatusConflict, "deployment %s cannot be retried on its failed foundations: they are not known", record.UUID)
+		return
+	}
+
+	c.retry(g, record, foundations, record.UUID)
+}
+
+// retriedDeployment returns the record of the deployment to retry, or writes why it cannot be retried.
+func (c *Controller) retriedDeployment(g *gin.Context) (S.DeploymentRecord, bool) {
+	if c.History == nil {
+		g.String(http.StatusNotFound, "deployment history is not enabled")
+		return S.DeploymentRecord{}, false
+	}
+
+	record, err := c.History.Get(g.Param("uuid"))
+	if err != nil {
+		if _, ok := err.(history.RecordNotFoundError); ok {
+			g.String(http.StatusNotFound, err.Error())
+			return record, false
+		}
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot query deployment history: %s", err)
+		return record, false
+	}
+
+	if !c.authorizeEnvironment(g, record.Environment) || !c.authenticateUser(g, record.Environment) {
+		return record, false
+	}
+
+	if record.Status != S.DeploymentFailed && record.Status != S.DeploymentPartial {
+		g.String(http.StatusConflict, "deployment %s cannot be retried: it is %s", record.UUID, record.Status)
+		return record, false
+	}
+	if len(record.Request) == 0 {
+		g.String(http.StatusConflict, "deployment %s cannot be retried: it was not requested with JSON", record.UUID)
+		return record, false
+	}
+
+	return record, true
+}
+
+// retry deploys the request of the deployment again to the foundations, or to every foundation of its environment
+// when there are none, with the cached artifact of the cachedArtifact deployment if it is set.
+// The credentials are not recorded, so the retry is authorized like any other deployment.
+func (c *Controller) retry(g *gin.Context, record S.DeploymentRecord, foundations []string, cachedArtifact string) {
+	uuid := c.Config.DeploymentID.Generate(record.Environment, g.Request.Header.Get(deploymentid.IdempotencyKeyHeader))
+	log := I.DeploymentLogger{Log: c.Log, UUID: uuid}
+	log.Debugf("retrying deployment %s, request originated from: %+v", record.UUID, g.Request.RemoteAddr)
+
+	body := []byte(record.Request)
+
+	deployment := I.Deployment{
+		Authorization: requestAuthorization(g),
+		CFContext: I.CFContext{
+			Environment:  record.Environment,
+			Organization: record.Org,
+			Space:        record.Space,
+			Application:  record.AppName,
+		},
+		Type:           I.DeploymentType{JSON: true},
+		Body:           &body,
+		Metadata:       S.MergeMetadata(record.Metadata, metadataFromHeaders(g.Request.Header), map[string]string{RetryOfMetadataKey: record.UUID}),
+		Foundations:    foundations,
+		CachedArtifact: cachedArtifact,
+	}
+
+	c.pushDeployment(g, log, &deployment)
+}
+
+// retriedFoundations returns the foundations the deployment did not succeed on. Deployments recorded without the
+// result on every foundation did not succeed on their failed and skipped foundations.
+func retriedFoundations(record S.DeploymentRecord) []string {
+	var foundations []string
+	if len(record.FoundationResults) > 0 {
+		for _, result := range record.FoundationResults {
+			if result.Status != S.FoundationSucceeded {
+				foundations = append(foundations, result.FoundationURL)
+			}
+		}
+		return foundations
+	}
+
+	seen := map[string]bool{}
+	for _, foundationURLs := range [][]string{record.FailedFoundations, record.SkippedFoundations} {
+		for _, foundationURL := range foundationURLs {
+			if !seen[foundationURL] {
+				seen[foundationURL] = true
+				foundations = append(foundations, foundationURL)
+			}
+		}
+	}
+	return foundations
+}
+
+// recordFailedFoundations adds the foundations the deployment failed against to its record, so it can be
+// retried on them alone.
+func (c *Controller) recordFailedFoundations(log I.DeploymentLogger) {
+	if c.History == nil || c.Progress == nil {
+		return
+	}
+
+	progress, ok := c.Progress.Get(log.UUID)
+	if !ok || len(progress.FailedFoundations) == 0 {
+		return
+	}
+
+	record, err := c.History.Get(log.UUID)
+	if err != nil {
+		return
+	}
+
+	record.FailedFoundations = progress.FailedFoundations
+	err = c.History.Record(record)
+	if err != nil {
+		log.Errorf("cannot record the failed foundations of the deployment: %s", err)
+	}
+}
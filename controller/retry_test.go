@@ -0,0 +1,238 @@
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/progress"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("RetryDeploymentHandler", func() {
+	var (
+		deploymentHistory *history.MemoryHistory
+		tracker           *progress.Tracker
+		pushController    *mocks.PushController
+		controller        *Controller
+		router            *gin.Engine
+		resp              *httptest.ResponseRecorder
+		record            S.DeploymentRecord
+	)
+
+	retry := func(url string) {
+		req, err := http.NewRequest("POST", url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.SetBasicAuth("username", "password")
+		req.Header.Set(MetadataHeaderPrefix+"Pipeline-Id", "42")
+		router.ServeHTTP(resp, req)
+	}
+
+	BeforeEach(func() {
+		deploymentHistory = history.NewMemoryHistory(0)
+		tracker = progress.NewTracker()
+		pushController = &mocks.PushController{}
+		pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+		pushController.RunDeploymentCall.Writes = "deploy success"
+
+		controller = &Controller{
+			Log:      I.DefaultLogger(NewBuffer(), logging.DEBUG, "retry_test"),
+			History:  deploymentHistory,
+			Progress: tracker,
+			PushControllerFactory: func(log I.DeploymentLogger) I.PushController {
+				return pushController
+			},
+		}
+
+		record = S.DeploymentRecord{
+			UUID:              "abc",
+			Environment:       "prod",
+			Org:               "org",
+			Space:             "space",
+			AppName:           "search",
+			Status:            S.DeploymentFailed,
+			Metadata:          map[string]string{"revision": "1234"},
+			Request:           []byte(`{"artifact_url": "https://example.com/artifact.zip"}`),
+			FailedFoundations: []string{"https://api2.example.com"},
+		}
+		Expect(deploymentHistory.Record(record)).To(Succeed())
+
+		router = gin.New()
+		router.POST("/v3/deployments/:uuid/retry", controller.RetryDeploymentHandler)
+		router.POST("/v3/deployments/:uuid/foundations/retry", controller.RetryFoundationsHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	It("deploys the application again with the recorded request", func() {
+		retry("/v3/deployments/abc/retry")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(ContainSubstring("deploy success"))
+
+		deployment := pushController.RunDeploymentCall.Received.Deployment
+		Expect(deployment.CFContext).To(Equal(I.CFContext{Environment: "prod", Organization: "org", Space: "space", Application: "search"}))
+		Expect(deployment.Authorization).To(Equal(I.Authorization{Username: "username", Password: "password"}))
+		Expect(deployment.Type).To(Equal(I.DeploymentType{JSON: true}))
+		Expect(string(*deployment.Body)).To(Equal(string(record.Request)))
+		Expect(deployment.Foundations).To(BeEmpty())
+		Expect(deployment.Metadata).To(Equal(map[string]string{
+			"revision":         "1234",
+			"pipeline_id":      "42",
+			RetryOfMetadataKey: "abc",
+		}))
+	})
+
+	It("deploys to the failed foundations only when asked to", func() {
+		retry("/v3/deployments/abc/retry?failed_foundations=true")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(pushController.RunDeploymentCall.Received.Deployment.Foundations).To(Equal([]string{"https://api2.example.com"}))
+	})
+
+	It("deploys a partially successful deployment to its failed foundations", func() {
+		record.Status = S.DeploymentPartial
+		Expect(deploymentHistory.Record(record)).To(Succeed())
+
+		retry("/v3/deployments/abc/retry?failed_foundations=true")
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(pushController.RunDeploymentCall.Received.Deployment.Foundations).To(Equal([]string{"https://api2.example.com"}))
+	})
+
+	It("records the foundations the retry failed against", func() {
+		controller.PushControllerFactory = func(log I.DeploymentLogger) I.PushController {
+			deploymentHistory.Record(S.DeploymentRecord{UUID: log.UUID, Status: S.DeploymentFailed})
+			tracker.FailFoundation(log.UUID, "https://api2.example.com")
+			return pushController
+		}
+		pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusInternalServerError}
+
+		retry("/v3/deployments/abc/retry")
+
+		records, err := deploymentHistory.Find(S.DeploymentQuery{Limit: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records[0].UUID).ToNot(Equal("abc"))
+		Expect(records[0].FailedFoundations).To(Equal([]string{"https://api2.example.com"}))
+	})
+
+	It("returns http.StatusConflict when the deployment did not fail", func() {
+		record.Status = S.DeploymentSucceeded
+		Expect(deploymentHistory.Record(record)).To(Succeed())
+
+		retry("/v3/deployments/abc/retry")
+
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+		Expect(resp.Body.String()).To(ContainSubstring("deployment abc cannot be retried: it is succeeded"))
+		Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+	})
+
+	It("returns http.StatusConflict when the deployment was not requested with JSON", func() {
+		record.Request = nil
+		Expect(deploymentHistory.Record(record)).To(Succeed())
+
+		retry("/v3/deployments/abc/retry")
+
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+		Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+	})
+
+	It("returns http.StatusConflict when the failed foundations are not known", func() {
+		record.FailedFoundations = nil
+		Expect(deploymentHistory.Record(record)).To(Succeed())
+
+		retry("/v3/deployments/abc/retry?failed_foundations=true")
+
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+		Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+	})
+
+	It("returns http.StatusBadRequest for an invalid failed_foundations parameter", func() {
+		retry("/v3/deployments/abc/retry?failed_foundations=maybe")
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("returns http.StatusNotFound for an unknown deployment", func() {
+		retry("/v3/deployments/unknown/retry")
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("returns http.StatusNotFound when there is no deployment history", func() {
+		controller.History = nil
+
+		retry("/v3/deployments/abc/retry")
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("does not deploy the cached artifact", func() {
+		retry("/v3/deployments/abc/retry")
+
+		Expect(pushController.RunDeploymentCall.Received.Deployment.CachedArtifact).To(BeEmpty())
+	})
+
+	Describe("retrying the foundations a deployment did not succeed on", func() {
+		It("deploys the cached artifact to the failed and skipped foundations", func() {
+			record.SkippedFoundations = []string{"https://api3.example.com"}
+			Expect(deploymentHistory.Record(record)).To(Succeed())
+
+			retry("/v3/deployments/abc/foundations/retry")
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			deployment := pushController.RunDeploymentCall.Received.Deployment
+			Expect(deployment.Foundations).To(Equal([]string{"https://api2.example.com", "https://api3.example.com"}))
+			Expect(deployment.CachedArtifact).To(Equal("abc"))
+			Expect(deployment.Metadata).To(HaveKeyWithValue(RetryOfMetadataKey, "abc"))
+			Expect(string(*deployment.Body)).To(Equal(string(record.Request)))
+		})
+
+		It("deploys to every foundation whose result is not succeeded", func() {
+			record.Status = S.DeploymentPartial
+			record.FoundationResults = []S.FoundationResult{
+				{FoundationURL: "https://api1.example.com", Status: S.FoundationSucceeded},
+				{FoundationURL: "https://api2.example.com", Status: S.FoundationFailed},
+				{FoundationURL: "https://api3.example.com", Status: S.FoundationRolledBack},
+				{FoundationURL: "https://api4.example.com", Status: S.FoundationSkipped},
+			}
+			Expect(deploymentHistory.Record(record)).To(Succeed())
+
+			retry("/v3/deployments/abc/foundations/retry")
+
+			Expect(pushController.RunDeploymentCall.Received.Deployment.Foundations).To(Equal([]string{
+				"https://api2.example.com",
+				"https://api3.example.com",
+				"https://api4.example.com",
+			}))
+		})
+
+		It("returns http.StatusConflict when the failed foundations are not known", func() {
+			record.FailedFoundations = nil
+			Expect(deploymentHistory.Record(record)).To(Succeed())
+
+			retry("/v3/deployments/abc/foundations/retry")
+
+			Expect(resp.Code).To(Equal(http.StatusConflict))
+			Expect(resp.Body.String()).To(ContainSubstring("deployment abc cannot be retried on its failed foundations: they are not known"))
+			Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+		})
+
+		It("returns http.StatusConflict when the deployment did not fail", func() {
+			record.Status = S.DeploymentSucceeded
+			Expect(deploymentHistory.Record(record)).To(Succeed())
+
+			retry("/v3/deployments/abc/foundations/retry")
+
+			Expect(resp.Code).To(Equal(http.StatusConflict))
+			Expect(pushController.RunDeploymentCall.Called).To(BeFalse())
+		})
+	})
+})
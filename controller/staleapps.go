@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StaleAppsHandler returns the report of the last sweep for stale applications as JSON.
+// The report has the applications of every tenant, so the request must have the credentials of the server.
+func (c *Controller) StaleAppsHandler(g *gin.Context) {
+	if c.StaleApps == nil {
+		g.String(http.StatusNotFound, "stale app sweeping is not enabled")
+		return
+	}
+
+	user, pwd, _ := g.Request.BasicAuth()
+	if !c.serverCredentials(user, pwd) {
+		g.String(http.StatusUnauthorized, "the credentials of the server are required to see the stale apps")
+		return
+	}
+
+	report, found := c.StaleApps.LastReport()
+	if !found {
+		g.String(http.StatusNotFound, "no sweep for stale apps has run yet")
+		return
+	}
+
+	g.JSON(http.StatusOK, report)
+}
+
+// SweepStaleAppsHandler sweeps every foundation for stale applications and returns the report as JSON.
+// The foundations are swept with the credentials of the server, so the request must have them.
+// Stale applications are deleted as configured unless the delete query parameter says otherwise.
+func (c *Controller) SweepStaleAppsHandler(g *gin.Context) {
+	if c.StaleApps == nil {
+		g.String(http.StatusNotFound, "stale app sweeping is not enabled")
+		return
+	}
+
+	user, pwd, _ := g.Request.BasicAuth()
+	if !c.serverCredentials(user, pwd) {
+		g.String(http.StatusUnauthorized, "the credentials of the server are required to sweep for stale apps")
+		return
+	}
+
+	del := c.Config.StaleApps.Delete
+	if value := g.Query("delete"); value != "" {
+		var err error
+		del, err = strconv.ParseBool(value)
+		if err != nil {
+			g.String(http.StatusBadRequest, "invalid delete: %s", value)
+			return
+		}
+	}
+
+	g.JSON(http.StatusOK, c.StaleApps.Sweep(g.Request.Context(), del))
+}
+
+// serverCredentials reports whether the username and password are the Cloud Foundry credentials of the server.
+func (c *Controller) serverCredentials(username, password string) bool {
+	if c.Config.Username == "" || c.Config.Password == "" {
+		return false
+	}
+
+	usernameMatches := subtle.ConstantTimeCompare([]byte(username), []byte(c.Config.Username)) == 1
+	passwordMatches := subtle.ConstantTimeCompare([]byte(password), []byte(c.Config.Password)) == 1
+	return usernameMatches && passwordMatches
+}
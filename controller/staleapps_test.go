@@ -0,0 +1,163 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("StaleAppsHandler", func() {
+	var (
+		staleApps  *mocks.StaleAppSweeper
+		controller *Controller
+		router     *gin.Engine
+		resp       *httptest.ResponseRecorder
+		report     S.StaleAppsReport
+		token      string
+	)
+
+	request := func(method, path, username, password string) {
+		req, err := http.NewRequest(method, path, nil)
+		Expect(err).ToNot(HaveOccurred())
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		if token != "" {
+			req.Header.Set(TenantTokenHeader, token)
+		}
+		router.ServeHTTP(resp, req)
+	}
+
+	BeforeEach(func() {
+		staleApps = &mocks.StaleAppSweeper{}
+		controller = &Controller{
+			Log:       I.DefaultLogger(NewBuffer(), logging.DEBUG, "staleapps_test"),
+			StaleApps: staleApps,
+			Config: config.Config{
+				Username:  "cf-username",
+				Password:  "cf-password",
+				StaleApps: S.StaleAppsDescriptor{Delete: true},
+				Tenants: map[string]S.Tenant{
+					"search": {Name: "search", APITokens: []string{"search-token"}},
+				},
+			},
+		}
+		token = ""
+
+		report = S.StaleAppsReport{
+			SweptAt: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+			Apps:    []S.StaleApp{{Environment: "prod", FoundationURL: "https://api.example.com", Org: "org", Space: "space", AppName: "search-new-build-abc", Deleted: true}},
+			Errors:  []S.StaleAppsFoundationError{},
+		}
+
+		router = gin.New()
+		router.GET("/v3/stale-apps", controller.StaleAppsHandler)
+		router.POST("/v3/stale-apps", controller.SweepStaleAppsHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	Describe("getting the last sweep", func() {
+		It("returns the report of the last sweep", func() {
+			staleApps.LastReportCall.Returns.Report = report
+			staleApps.LastReportCall.Returns.Found = true
+
+			request("GET", "/v3/stale-apps", "cf-username", "cf-password")
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			returned := S.StaleAppsReport{}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &returned)).To(Succeed())
+			Expect(returned).To(Equal(report))
+		})
+
+		It("returns http.StatusNotFound when no sweep has run", func() {
+			request("GET", "/v3/stale-apps", "cf-username", "cf-password")
+
+			Expect(resp.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("returns http.StatusUnauthorized without the credentials of the server", func() {
+			staleApps.LastReportCall.Returns.Report = report
+			staleApps.LastReportCall.Returns.Found = true
+
+			request("GET", "/v3/stale-apps", "", "")
+
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			Expect(staleApps.LastReportCall.Called).To(BeFalse())
+		})
+
+		It("returns http.StatusUnauthorized with the api token of a tenant", func() {
+			staleApps.LastReportCall.Returns.Report = report
+			staleApps.LastReportCall.Returns.Found = true
+			token = "search-token"
+
+			request("GET", "/v3/stale-apps", "", "")
+
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			Expect(staleApps.LastReportCall.Called).To(BeFalse())
+		})
+	})
+
+	Describe("sweeping", func() {
+		It("sweeps with the configured deletion and returns the report", func() {
+			staleApps.SweepCall.Returns.Report = report
+
+			request("POST", "/v3/stale-apps", "cf-username", "cf-password")
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(staleApps.SweepCall.Received.Delete).To(BeTrue())
+			returned := S.StaleAppsReport{}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &returned)).To(Succeed())
+			Expect(returned).To(Equal(report))
+		})
+
+		It("only reports the stale apps when delete is false", func() {
+			request("POST", "/v3/stale-apps?delete=false", "cf-username", "cf-password")
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(staleApps.SweepCall.Received.Delete).To(BeFalse())
+		})
+
+		It("returns http.StatusBadRequest for an invalid delete", func() {
+			request("POST", "/v3/stale-apps?delete=maybe", "cf-username", "cf-password")
+
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(staleApps.SweepCall.Called).To(BeFalse())
+		})
+
+		It("returns http.StatusUnauthorized without the credentials of the server", func() {
+			request("POST", "/v3/stale-apps", "cf-username", "wrong")
+
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			Expect(staleApps.SweepCall.Called).To(BeFalse())
+		})
+
+		It("returns http.StatusUnauthorized when the server has no credentials", func() {
+			controller.Config.Username = ""
+			controller.Config.Password = ""
+
+			request("POST", "/v3/stale-apps", "", "")
+
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	It("returns http.StatusNotFound when sweeping is not enabled", func() {
+		controller.StaleApps = nil
+
+		request("POST", "/v3/stale-apps", "cf-username", "cf-password")
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+	})
+})
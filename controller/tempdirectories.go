@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TempDirectoriesHandler returns the temporary directories of the running deployments and the ones
+// that were left behind as JSON. They belong to the deployments of every tenant, so the request must have
+// the credentials of the server.
+func (c *Controller) TempDirectoriesHandler(g *gin.Context) {
+	if c.TempDirectories == nil {
+		g.String(http.StatusNotFound, "temporary directory tracking is not enabled")
+		return
+	}
+
+	user, pwd, _ := g.Request.BasicAuth()
+	if !c.serverCredentials(user, pwd) {
+		g.String(http.StatusUnauthorized, "the credentials of the server are required to list the temporary directories")
+		return
+	}
+
+	report, err := c.TempDirectories.Report()
+	if err != nil {
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot list temporary directories: %s", err)
+		return
+	}
+
+	g.JSON(http.StatusOK, report)
+}
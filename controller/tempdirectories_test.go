@@ -0,0 +1,107 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("TempDirectoriesHandler", func() {
+	var (
+		tempDirectories *mocks.TempDirectoryTracker
+		controller      *Controller
+		router          *gin.Engine
+		resp            *httptest.ResponseRecorder
+	)
+
+	request := func(username, password, token string) {
+		req, err := http.NewRequest("GET", "/v3/temp-directories", nil)
+		Expect(err).ToNot(HaveOccurred())
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		if token != "" {
+			req.Header.Set(TenantTokenHeader, token)
+		}
+		router.ServeHTTP(resp, req)
+	}
+	get := func() { request("cf-username", "cf-password", "") }
+
+	BeforeEach(func() {
+		tempDirectories = &mocks.TempDirectoryTracker{}
+		controller = &Controller{
+			Log:             I.DefaultLogger(NewBuffer(), logging.DEBUG, "tempdirectories_test"),
+			TempDirectories: tempDirectories,
+			Config: config.Config{
+				Username: "cf-username",
+				Password: "cf-password",
+				Tenants: map[string]S.Tenant{
+					"search": {Name: "search", APITokens: []string{"search-token"}},
+				},
+			},
+		}
+
+		router = gin.New()
+		router.GET("/v3/temp-directories", controller.TempDirectoriesHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	It("returns the active and leaked temporary directories", func() {
+		createdAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+		tempDirectories.ReportCall.Returns.Report = S.TempDirectoryReport{
+			Active: []S.TempDirectory{{Path: "/tmp/deployadactyl-executor-1", DeploymentID: "uuid-1", CreatedAt: createdAt}},
+			Leaked: []S.TempDirectory{{Path: "/tmp/deployadactyl-unzipped-2", CreatedAt: createdAt}},
+		}
+
+		get()
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		report := S.TempDirectoryReport{}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &report)).To(Succeed())
+		Expect(report).To(Equal(tempDirectories.ReportCall.Returns.Report))
+	})
+
+	It("returns http.StatusUnauthorized without the credentials of the server", func() {
+		request("", "", "")
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(tempDirectories.ReportCall.Called).To(BeFalse())
+	})
+
+	It("returns http.StatusUnauthorized with the api token of a tenant", func() {
+		request("", "", "search-token")
+
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(tempDirectories.ReportCall.Called).To(BeFalse())
+	})
+
+	It("returns an internal server error when the directories cannot be listed", func() {
+		tempDirectories.ReportCall.Returns.Error = errors.New("permission denied")
+
+		get()
+
+		Expect(resp.Code).To(Equal(http.StatusInternalServerError))
+		Expect(resp.Body.String()).To(ContainSubstring("permission denied"))
+	})
+
+	It("returns not found when temporary directories are not tracked", func() {
+		controller.TempDirectories = nil
+
+		get()
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+	})
+})
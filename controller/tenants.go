@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/compozed/deployadactyl/history"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantTokenHeader carries the API token of the tenant a request is made for.
+const TenantTokenHeader = "X-Deployadactyl-Token"
+
+// requestTenant returns the tenant whose API token the request has, or writes that the token is unknown.
+// Requests without a token have no tenant.
+func (c *Controller) requestTenant(g *gin.Context) (string, bool) {
+	token := g.Request.Header.Get(TenantTokenHeader)
+	if token == "" || len(c.Config.Tenants) == 0 {
+		return "", true
+	}
+
+	for name, tenant := range c.Config.Tenants {
+		for _, apiToken := range tenant.APITokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(apiToken)) == 1 {
+				return name, true
+			}
+		}
+	}
+
+	g.String(http.StatusUnauthorized, "unknown api token")
+	return "", false
+}
+
+// authorizeEnvironment reports whether the request may use the environment, or writes why it may not.
+// Shared environments can be used by every request, and the environments of a tenant only with one of its API tokens.
+func (c *Controller) authorizeEnvironment(g *gin.Context, environment string) bool {
+	tenant, ok := c.requestTenant(g)
+	if !ok {
+		return false
+	}
+
+	owner := c.environmentTenant(environment)
+	if owner == "" || owner == tenant {
+		return true
+	}
+
+	if tenant == "" {
+		g.String(http.StatusUnauthorized, "an api token of tenant %s is required for environment %s", owner, environment)
+		return false
+	}
+	g.String(http.StatusForbidden, "environment %s does not belong to tenant %s", environment, tenant)
+	return false
+}
+
+// authorizeDeployment reports whether the request may see the deployment with the uuid, or writes why it may not.
+// When tenants are configured, the tenant of a deployment is only known once the history has its record.
+func (c *Controller) authorizeDeployment(g *gin.Context, uuid string) bool {
+	if len(c.Config.Tenants) == 0 {
+		return true
+	}
+
+	if c.History == nil {
+		g.String(http.StatusNotFound, "deployment history is not enabled, so the tenant of deployment %s is not known", uuid)
+		return false
+	}
+
+	record, err := c.History.Get(uuid)
+	if err != nil {
+		if _, ok := err.(history.RecordNotFoundError); ok {
+			g.String(http.StatusNotFound, err.Error())
+			return false
+		}
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot query deployment history: %s", err)
+		return false
+	}
+
+	return c.authorizeEnvironment(g, record.Environment)
+}
+
+// visibleRecords returns the deployments of the records the tenant may see: those of shared environments and of
+// its own.
+func (c *Controller) visibleRecords(tenant string, records []S.DeploymentRecord) []S.DeploymentRecord {
+	if len(c.Config.Tenants) == 0 {
+		return records
+	}
+
+	visible := []S.DeploymentRecord{}
+	for _, record := range records {
+		owner := c.environmentTenant(record.Environment)
+		if owner == "" || owner == tenant {
+			visible = append(visible, record)
+		}
+	}
+	return visible
+}
+
+// environmentTenant returns the tenant of the environment, which is empty for shared environments.
+func (c *Controller) environmentTenant(environment string) string {
+	return c.Config.Environments[environment].Tenant
+}
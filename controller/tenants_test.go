@@ -0,0 +1,158 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Tenants", func() {
+	var (
+		deploymentHistory *history.MemoryHistory
+		pushController    *mocks.PushController
+		controller        *Controller
+		router            *gin.Engine
+		resp              *httptest.ResponseRecorder
+	)
+
+	request := func(method, url, token, body string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set(TenantTokenHeader, token)
+		}
+		router.ServeHTTP(resp, req)
+	}
+
+	BeforeEach(func() {
+		deploymentHistory = history.NewMemoryHistory(0)
+		pushController = &mocks.PushController{}
+		pushController.RunDeploymentCall.Returns.DeployResponse = I.DeployResponse{StatusCode: http.StatusOK}
+
+		searchProd := S.Environment{Name: "search-prod", Foundations: []string{"https://api1.example.com"}, Tenant: "search"}
+		paymentsProd := S.Environment{Name: "payments-prod", Foundations: []string{"https://api1.example.com"}, Tenant: "payments"}
+
+		controller = &Controller{
+			Log:     I.DefaultLogger(NewBuffer(), logging.DEBUG, "tenants_test"),
+			History: deploymentHistory,
+			Config: config.Config{
+				Environments: map[string]S.Environment{
+					"shared":        {Name: "shared", Foundations: []string{"https://api1.example.com"}},
+					"search-prod":   searchProd,
+					"payments-prod": paymentsProd,
+				},
+				Tenants: map[string]S.Tenant{
+					"search":   {Name: "search", APITokens: []string{"search-token"}, Environments: []S.Environment{searchProd}},
+					"payments": {Name: "payments", APITokens: []string{"payments-token"}, Environments: []S.Environment{paymentsProd}},
+				},
+			},
+			PushControllerFactory: func(log I.DeploymentLogger) I.PushController {
+				return pushController
+			},
+		}
+
+		for _, record := range []S.DeploymentRecord{
+			{UUID: "1", Environment: "shared", Status: S.DeploymentSucceeded},
+			{UUID: "2", Environment: "search-prod", Status: S.DeploymentSucceeded},
+			{UUID: "3", Environment: "payments-prod", Status: S.DeploymentSucceeded},
+		} {
+			Expect(deploymentHistory.Record(record)).To(Succeed())
+		}
+
+		router = gin.New()
+		router.POST("/v3/apps/:environment/:org/:space/:appName", controller.RunDeploymentViaHttp)
+		router.GET("/v3/deployments", controller.DeploymentHistoryHandler)
+		router.GET("/v3/deployments/:uuid", controller.DeploymentRecordHandler)
+		resp = httptest.NewRecorder()
+	})
+
+	Context("when deploying", func() {
+		It("deploys to an environment of the tenant of the api token", func() {
+			request("POST", "/v3/apps/search-prod/org/space/app", "search-token", `{"artifact_url": "https://example.com/artifact.zip"}`)
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(pushController.RunDeploymentCall.Received.Deployment.CFContext.Environment).To(Equal("search-prod"))
+		})
+
+		It("deploys to a shared environment without an api token", func() {
+			request("POST", "/v3/apps/shared/org/space/app", "", `{"artifact_url": "https://example.com/artifact.zip"}`)
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+		})
+
+		It("requires an api token for an environment of a tenant", func() {
+			request("POST", "/v3/apps/search-prod/org/space/app", "", `{"artifact_url": "https://example.com/artifact.zip"}`)
+
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			Expect(resp.Body.String()).To(Equal("an api token of tenant search is required for environment search-prod"))
+			Expect(pushController.RunDeploymentCall.Received.Deployment).To(BeNil())
+		})
+
+		It("does not deploy to the environment of another tenant", func() {
+			request("POST", "/v3/apps/search-prod/org/space/app", "payments-token", `{"artifact_url": "https://example.com/artifact.zip"}`)
+
+			Expect(resp.Code).To(Equal(http.StatusForbidden))
+			Expect(resp.Body.String()).To(Equal("environment search-prod does not belong to tenant payments"))
+			Expect(pushController.RunDeploymentCall.Received.Deployment).To(BeNil())
+		})
+
+		It("rejects an unknown api token", func() {
+			request("POST", "/v3/apps/shared/org/space/app", "unknown-token", `{"artifact_url": "https://example.com/artifact.zip"}`)
+
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			Expect(resp.Body.String()).To(Equal("unknown api token"))
+		})
+	})
+
+	Context("when querying the deployment history", func() {
+		uuids := func() []string {
+			var records []S.DeploymentRecord
+			Expect(json.Unmarshal(resp.Body.Bytes(), &records)).To(Succeed())
+
+			var uuids []string
+			for _, record := range records {
+				uuids = append(uuids, record.UUID)
+			}
+			return uuids
+		}
+
+		It("returns the deployments of shared environments and of the environments of the tenant", func() {
+			request("GET", "/v3/deployments", "search-token", "")
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(uuids()).To(ConsistOf("1", "2"))
+		})
+
+		It("returns the deployments of shared environments without an api token", func() {
+			request("GET", "/v3/deployments", "", "")
+
+			Expect(uuids()).To(ConsistOf("1"))
+		})
+
+		It("limits the deployments the tenant may see", func() {
+			request("GET", "/v3/deployments?limit=2", "search-token", "")
+
+			Expect(uuids()).To(ConsistOf("1", "2"))
+		})
+
+		It("does not return a deployment of another tenant", func() {
+			request("GET", "/v3/deployments/3", "search-token", "")
+
+			Expect(resp.Code).To(Equal(http.StatusForbidden))
+		})
+	})
+})
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/compozed/deployadactyl/history"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// DeployedVersionsHandler returns the artifact running on every foundation the application in the path was
+// deployed to, as JSON. The environment query parameter restricts it to one environment.
+func (c *Controller) DeployedVersionsHandler(g *gin.Context) {
+	if c.History == nil {
+		g.String(http.StatusNotFound, "deployment history is not enabled")
+		return
+	}
+
+	tenant, ok := c.requestTenant(g)
+	if !ok {
+		return
+	}
+
+	records, err := c.History.Find(S.DeploymentQuery{
+		Environment: strings.ToLower(g.Query("environment")),
+		Org:         g.Param("org"),
+		Space:       g.Param("space"),
+		AppName:     g.Param("appName"),
+	})
+	if err != nil {
+		c.Log.Error(err)
+		g.String(http.StatusInternalServerError, "cannot query deployment history: %s", err)
+		return
+	}
+
+	g.JSON(http.StatusOK, history.DeployedVersions(c.visibleRecords(tenant, records), c.Config.Environments))
+}
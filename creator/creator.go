@@ -2,10 +2,18 @@
 package creator
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"github.com/compozed/deployadactyl/artifactcache"
 	"github.com/compozed/deployadactyl/artifetcher"
 	"github.com/compozed/deployadactyl/artifetcher/extractor"
+	"github.com/compozed/deployadactyl/artifetcher/git"
+	"github.com/compozed/deployadactyl/artifetcher/sbom"
+	"github.com/compozed/deployadactyl/artifetcher/scanner"
+	"github.com/compozed/deployadactyl/artifetcher/signature"
+	"github.com/compozed/deployadactyl/canary"
+	"github.com/compozed/deployadactyl/cancellation"
 	"github.com/compozed/deployadactyl/config"
 	"github.com/compozed/deployadactyl/controller"
 	"github.com/compozed/deployadactyl/controller/deployer"
@@ -16,15 +24,40 @@ import (
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/courier/executor"
 	"github.com/compozed/deployadactyl/controller/deployer/error_finder"
 	"github.com/compozed/deployadactyl/controller/deployer/prechecker"
+	"github.com/compozed/deployadactyl/deploymentlog"
+	"github.com/compozed/deployadactyl/drift"
 	"github.com/compozed/deployadactyl/eventmanager"
 	"github.com/compozed/deployadactyl/eventmanager/handlers/envvar"
 	"github.com/compozed/deployadactyl/eventmanager/handlers/healthchecker"
+	"github.com/compozed/deployadactyl/eventmanager/handlers/slack"
 	"github.com/compozed/deployadactyl/eventmanager/handlers/routemapper"
+	"github.com/compozed/deployadactyl/history"
+	"github.com/compozed/deployadactyl/hooks"
+	"github.com/compozed/deployadactyl/janitor"
+	"github.com/compozed/deployadactyl/progress"
 	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/ldap"
+	"github.com/compozed/deployadactyl/oidc"
+	"github.com/compozed/deployadactyl/leader"
+	"github.com/compozed/deployadactyl/lock"
+	"github.com/compozed/deployadactyl/logsink"
+	"github.com/compozed/deployadactyl/logstream"
+	"github.com/compozed/deployadactyl/maintenance"
+	"github.com/compozed/deployadactyl/promotiongate"
+	"github.com/compozed/deployadactyl/publisher"
 	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/reconcile"
+	"github.com/compozed/deployadactyl/sharedstate"
+	"github.com/compozed/deployadactyl/state/deletion"
+	"github.com/compozed/deployadactyl/state/restage"
+	"github.com/compozed/deployadactyl/state/restart"
 	"github.com/compozed/deployadactyl/state/start"
 	"github.com/compozed/deployadactyl/state/stop"
 	"github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tempdir"
+	"github.com/compozed/deployadactyl/tracing"
+	"github.com/compozed/deployadactyl/vault"
+	"github.com/compozed/deployadactyl/webhook"
 	"github.com/gin-gonic/gin"
 	"github.com/op/go-logging"
 	"github.com/spf13/afero"
@@ -34,12 +67,70 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"time"
 )
 
 // ENDPOINT is used by the handler to define the deployment endpoint.
 const v2ENDPOINT = "/v2/deploy/:environment/:org/:space/:appName"
 const ENDPOINT = "/v3/apps/:environment/:org/:space/:appName"
 
+// BATCH_ENDPOINT is used by the handler to deploy an application to several environments, one after the other.
+const BATCH_ENDPOINT = "/v3/batches/:org/:space/:appName"
+
+// PROMOTIONS_ENDPOINT is used by the handler to deploy the artifact running in one environment to another.
+const PROMOTIONS_ENDPOINT = "/v3/promotions"
+
+// VERSIONS_ENDPOINT is used by the handler to return what is deployed to every foundation.
+const VERSIONS_ENDPOINT = "/v3/versions/:org/:space/:appName"
+
+// DRIFT_ENDPOINT is used by the handler to compare an application across environments and foundations.
+const DRIFT_ENDPOINT = "/v3/drift/:org/:space/:appName"
+
+// DEPLOYMENTS_ENDPOINT is used by the handler to query the deployment history.
+const DEPLOYMENTS_ENDPOINT = "/v3/deployments"
+
+// ACTIVE_DEPLOYMENTS_ENDPOINT is used by the handler to list the deployments that are running or queued.
+const ACTIVE_DEPLOYMENTS_ENDPOINT = "/v3/admin/deployments/active"
+
+// DEBUG_ENDPOINT is used by the handler to return the profiles, goroutine dump and runtime stats of the server.
+const DEBUG_ENDPOINT = "/v3/admin/debug"
+
+// EVENTS_STREAM_ENDPOINT is used by the handler to stream every deployment event as server-sent events.
+const EVENTS_STREAM_ENDPOINT = "/v3/events/stream"
+
+// TEMP_DIRECTORIES_ENDPOINT is used by the handler to list the temporary directories of running deployments and leaked ones.
+const TEMP_DIRECTORIES_ENDPOINT = "/v3/temp-directories"
+
+// STALE_APPS_ENDPOINT is used by the handler to report and sweep the temporary applications left behind by deployments.
+const STALE_APPS_ENDPOINT = "/v3/stale-apps"
+
+// MAINTENANCE_ENDPOINT is used by the handler to list the foundations in maintenance and to put them in or out of it.
+const MAINTENANCE_ENDPOINT = "/v3/maintenance"
+
+// DEPLOYMENT_LOG_ENDPOINT is used by the handler to return the output of a deployment after its response is gone.
+const DEPLOYMENT_LOG_ENDPOINT = DEPLOYMENTS_ENDPOINT + "/:uuid/logs"
+
+// DEPLOYMENT_LOG_STREAM_ENDPOINT is used by the handler to stream the output of a running deployment over a WebSocket.
+const DEPLOYMENT_LOG_STREAM_ENDPOINT = DEPLOYMENT_LOG_ENDPOINT + "/stream"
+
+// DEPLOYMENT_PROGRESS_ENDPOINT is used by the handler to stream the progress of a deployment as server-sent events.
+const DEPLOYMENT_PROGRESS_ENDPOINT = DEPLOYMENTS_ENDPOINT + "/:uuid/progress"
+
+// DEPLOYMENT_RETRY_ENDPOINT is used by the handler to deploy a failed deployment again with its original request.
+const DEPLOYMENT_RETRY_ENDPOINT = DEPLOYMENTS_ENDPOINT + "/:uuid/retry"
+
+// FOUNDATIONS_RETRY_ENDPOINT is used by the handler to deploy a deployment again to the foundations it did not succeed on.
+const FOUNDATIONS_RETRY_ENDPOINT = DEPLOYMENTS_ENDPOINT + "/:uuid/foundations/retry"
+
+// DEPLOYMENT_CANCEL_ENDPOINT is used by the handler to cancel a running deployment.
+const DEPLOYMENT_CANCEL_ENDPOINT = DEPLOYMENTS_ENDPOINT + "/:uuid/cancel"
+
+// DEPLOYMENT_PROMOTE_CANARY_ENDPOINT is used by the handler to promote the canary of a running deployment.
+const DEPLOYMENT_PROMOTE_CANARY_ENDPOINT = DEPLOYMENTS_ENDPOINT + "/:uuid/canary/promote"
+
+// OPENAPI_ENDPOINT is used by the handler to return the OpenAPI document of the API.
+const OPENAPI_ENDPOINT = "/v3/openapi.json"
+
 type CreatorModuleProvider struct {
 	NewCourier         courier.CourierConstructor
 	NewPrechecker      prechecker.PrecheckerConstructor
@@ -48,7 +139,18 @@ type CreatorModuleProvider struct {
 	NewEventManager    eventmanager.EventManagerConstructor
 	NewPushController  push.PushControllerConstructor
 	NewStartController start.StartControllerConstructor
+	NewRestartController restart.RestartControllerConstructor
+	NewRestageController restage.RestageControllerConstructor
+	NewDeleteController  deletion.DeleteControllerConstructor
 	NewStopController  stop.StopControllerConstructor
+	NewPushPipeline    push.PipelineConstructor
+	NewScanner         scanner.ScannerConstructor
+	NewSBOMGenerator   sbom.GeneratorConstructor
+	NewHistory         history.HistoryConstructor
+	NewDeploymentLogs  deploymentlog.StoreConstructor
+	NewVerifier        signature.VerifierConstructor
+	NewPromotionGate   promotiongate.GateConstructor
+	NewHookRunner      hooks.RunnerConstructor
 }
 
 // Creator has a config, eventManager, logger and writer for creating dependencies.
@@ -59,6 +161,27 @@ type Creator struct {
 	writer       io.Writer
 	fileSystem   *afero.Afero
 	provider     CreatorModuleProvider
+	history      I.DeploymentHistory
+	tempDirs     *tempdir.Tracker
+	logs         I.DeploymentLogStore
+	progress     I.ProgressTracker
+	logStreams   *logstream.Hub
+	janitor      *janitor.Janitor
+	staleApps    *janitor.StaleAppSweeper
+	maintenance  *maintenance.Registry
+	artifacts    I.ArtifactCache
+	events       *eventmanager.Stream
+	publishers   []*publisher.Publisher
+	locks        I.LockManager
+	elector      *leader.Elector
+	cancels      *cancellation.Registry
+	canaries     *canary.Registry
+	tracer       *tracing.Tracer
+	slack        *slack.Notifier
+	webhooks     *webhook.Notifier
+	vault        *vault.Client
+	authenticator I.Authenticator
+	verifier      I.TokenVerifier
 }
 
 // Default returns a default Creator and an Error.
@@ -92,10 +215,39 @@ func (c Creator) CreateControllerHandler(controller I.Controller) *gin.Engine {
 	r.Use(gin.Recovery())
 	r.Use(gin.LoggerWithWriter(c.createWriter()))
 	r.Use(gin.ErrorLogger())
+	r.Use(c.createRequestMiddleware()...)
 
 	r.POST(v2ENDPOINT, controller.RunDeploymentViaHttp)
 	r.POST(ENDPOINT, controller.RunDeploymentViaHttp)
 	r.PUT(ENDPOINT, controller.PutRequestHandler)
+	r.DELETE(ENDPOINT, controller.DeleteRequestHandler)
+	r.POST(BATCH_ENDPOINT, controller.BatchDeploymentHandler)
+	r.POST(PROMOTIONS_ENDPOINT, controller.PromotionHandler)
+
+	r.GET(DEPLOYMENTS_ENDPOINT, controller.DeploymentHistoryHandler)
+	r.GET(DEPLOYMENTS_ENDPOINT+"/:uuid", controller.DeploymentRecordHandler)
+	r.GET(VERSIONS_ENDPOINT, controller.DeployedVersionsHandler)
+	r.GET(DRIFT_ENDPOINT, controller.DriftHandler)
+	r.GET(DEPLOYMENT_LOG_ENDPOINT, controller.DeploymentLogHandler)
+	r.GET(DEPLOYMENT_LOG_STREAM_ENDPOINT, controller.DeploymentLogStreamHandler)
+	r.GET(DEPLOYMENT_PROGRESS_ENDPOINT, controller.DeploymentProgressHandler)
+	r.POST(DEPLOYMENT_RETRY_ENDPOINT, controller.RetryDeploymentHandler)
+	r.POST(FOUNDATIONS_RETRY_ENDPOINT, controller.RetryFoundationsHandler)
+	r.POST(DEPLOYMENT_CANCEL_ENDPOINT, controller.CancelDeploymentHandler)
+	r.POST(DEPLOYMENT_PROMOTE_CANARY_ENDPOINT, controller.PromoteCanaryHandler)
+
+	r.GET(ACTIVE_DEPLOYMENTS_ENDPOINT, controller.ActiveDeploymentsHandler)
+	r.GET(DEBUG_ENDPOINT+"/pprof/*profile", controller.ProfileHandler)
+	r.POST(DEBUG_ENDPOINT+"/pprof/*profile", controller.ProfileHandler)
+	r.GET(DEBUG_ENDPOINT+"/goroutines", controller.GoroutinesHandler)
+	r.GET(DEBUG_ENDPOINT+"/runtime", controller.RuntimeStatsHandler)
+	r.GET(EVENTS_STREAM_ENDPOINT, controller.EventStreamHandler)
+	r.GET(TEMP_DIRECTORIES_ENDPOINT, controller.TempDirectoriesHandler)
+	r.GET(STALE_APPS_ENDPOINT, controller.StaleAppsHandler)
+	r.POST(STALE_APPS_ENDPOINT, controller.SweepStaleAppsHandler)
+	r.GET(MAINTENANCE_ENDPOINT, controller.MaintenanceHandler)
+	r.PUT(MAINTENANCE_ENDPOINT, controller.SetMaintenanceHandler)
+	r.GET(OPENAPI_ENDPOINT, controller.OpenAPIHandler)
 
 	return r
 }
@@ -113,18 +265,52 @@ func (c Creator) CreateListener() net.Listener {
 	return ls
 }
 
-// CreateCourier returns a courier with an executor.
+// CreateCourier returns the courier of the server.
 func (c Creator) CreateCourier() (I.Courier, error) {
+	return c.createCourier("", "")
+}
+
+// createCourier returns the courier of the environment. The temporary directory of a courier with an executor is
+// removed with the other temporary directories of the deployment once it is finished.
+func (c Creator) createCourier(deploymentID, environment string) (I.Courier, error) {
+	if c.provider.NewCourier == nil && c.config.EnvironmentCourier(environment) == structs.CourierAPI {
+		var cr I.Courier = courier.NewAPICourier()
+		if len(c.config.TLSPins) > 0 {
+			cr = courier.PinnedCourier{Courier: cr, Pins: c.config.TLSPins}
+		}
+		return cr, nil
+	}
+
 	ex, err := executor.New(c.CreateFileSystem())
 	if err != nil {
 		return nil, err
 	}
+	if deploymentID != "" {
+		c.tempDirs.Track(deploymentID, ex.TempDir())
+	}
+	if c.config.CFCLI != "" {
+		ex = ex.WithCLI(c.config.CFCLI)
+	}
+	if len(c.config.CommandTimeouts) > 0 {
+		timeouts := map[string]time.Duration{}
+		for command, seconds := range c.config.CommandTimeouts {
+			timeouts[command] = time.Duration(seconds) * time.Second
+		}
+		ex = ex.WithTimeouts(timeouts)
+	}
 
+	var cr I.Courier
 	if c.provider.NewCourier != nil {
-		return c.provider.NewCourier(ex), nil
+		cr = c.provider.NewCourier(ex)
+	} else {
+		cr = courier.NewCourier(ex)
+	}
+
+	if len(c.config.TLSPins) > 0 {
+		cr = courier.PinnedCourier{Courier: cr, Pins: c.config.TLSPins}
 	}
 
-	return courier.NewCourier(ex), nil
+	return cr, nil
 }
 
 func (c Creator) GetLogger() I.Logger {
@@ -141,20 +327,169 @@ func (c Creator) CreateEventManager() I.EventManager {
 	return c.eventManager
 }
 
+// CreateDeploymentHistory returns the DeploymentHistory shared by every deployment.
+func (c Creator) CreateDeploymentHistory() I.DeploymentHistory {
+	return c.history
+}
+
+// CreateDeploymentLogStore returns the DeploymentLogStore shared by every deployment.
+func (c Creator) CreateDeploymentLogStore() I.DeploymentLogStore {
+	return c.logs
+}
+
+// CreateLockManager returns the LockManager holding the locks of the applications being deployed.
+func (c Creator) CreateLockManager() I.LockManager {
+	return c.locks
+}
+
+// CreateEventStream returns the EventStream of every event emitted by the event manager.
+func (c Creator) CreateEventStream() I.EventStream {
+	return c.events
+}
+
+// CreateProgressTracker returns the ProgressTracker shared by every deployment.
+func (c Creator) CreateProgressTracker() I.ProgressTracker {
+	return c.progress
+}
+
+// CreateLogStreamer returns the LogStreamer of the output of every deployment.
+func (c Creator) CreateLogStreamer() I.LogStreamer {
+	return c.logStreams
+}
+
+// CreateDeploymentCanceller returns the DeploymentCanceller of the running deployments.
+func (c Creator) CreateDeploymentCanceller() I.DeploymentCanceller {
+	return c.cancels
+}
+
+// CreateCanaryPromoter returns the CanaryPromoter of the canaries of the running deployments.
+func (c Creator) CreateCanaryPromoter() I.CanaryPromoter {
+	return c.canaries
+}
+
+// CreateAppInspector returns the AppInspector used to detect drift between foundations.
+func (c Creator) CreateAppInspector() I.AppInspector {
+	return drift.NewInspector(c)
+}
+
+// CreateTempDirectoryTracker returns the TempDirectoryTracker shared by every deployment.
+func (c Creator) CreateTempDirectoryTracker() I.TempDirectoryTracker {
+	return c.tempDirs
+}
+
+// CreateJanitor returns the janitor that deletes replaced applications once their retention period is over.
+func (c Creator) CreateJanitor() I.Janitor {
+	return c.janitor
+}
+
+// CreateStaleAppSweeper returns the sweeper of the temporary applications left behind by deployments.
+func (c Creator) CreateStaleAppSweeper() I.StaleAppSweeper {
+	return c.staleApps
+}
+
+// CreateFoundationMaintenance returns the foundations in maintenance, which deployments skip.
+func (c Creator) CreateFoundationMaintenance() I.FoundationMaintenance {
+	return c.maintenance
+}
+
+// CreateArtifactCache returns the cache of the artifacts of failed deployments, or nil when it is not configured.
+func (c Creator) CreateArtifactCache() I.ArtifactCache {
+	return c.artifacts
+}
+
+// AddTenantBinding adds the binding of a handler of the tenant to the event manager. It is only given the events
+// of the environments of the tenant.
+func (c Creator) AddTenantBinding(tenant string, binding I.Binding) error {
+	t, ok := c.config.Tenants[tenant]
+	if !ok {
+		return UnknownTenantError{tenant}
+	}
+
+	c.eventManager.AddBinding(eventmanager.NewTenantBinding(t, binding))
+	return nil
+}
+
+// StartLeaderElection takes part in the election of the leader in the background. While the server is the leader,
+// it sweeps the foundations for stale applications at the configured interval and reconciles the interrupted
+// deployments, so that they run once across all the instances sharing the history. Without a configured election
+// the server is always the leader.
+func (c Creator) StartLeaderElection() {
+	go c.elector.Run(context.Background())
+}
+
+// StartTracing exports the spans of the deployments to the configured collector in the background.
+func (c Creator) StartTracing() {
+	if c.tracer != nil {
+		go c.tracer.Run()
+	}
+}
+
+// StartCredentialRenewal renews the Vault token in the background, so it does not expire between deployments.
+func (c Creator) StartCredentialRenewal() {
+	if c.vault != nil {
+		go c.vault.Run()
+	}
+}
+
+// CreateSlackNotifier returns the handler of the events posting the deployments to Slack, or nil when Slack is not
+// configured.
+func (c Creator) CreateSlackNotifier() *slack.Notifier {
+	return c.slack
+}
+
+// StartNotifications posts the queued notifications of the deployments in the background.
+func (c Creator) StartNotifications() {
+	if c.slack != nil {
+		go c.slack.Run()
+	}
+	if c.webhooks != nil {
+		c.webhooks.Start()
+	}
+}
+
+// StartEventPublishers publishes the events of every deployment to the configured message brokers in the background.
+func (c Creator) StartEventPublishers() {
+	for _, p := range c.publishers {
+		go p.Run()
+	}
+}
+
+// ReconcileDeployments completes or rolls back the deployments that were interrupted by a restart of the server,
+// so that no environment is left with a half finished blue green deployment. It runs whenever the server becomes
+// the leader, and skips the deployments whose application is locked by a deployment still running elsewhere.
+func (c Creator) ReconcileDeployments() error {
+	auth := I.Authorization{Username: c.config.Username, Password: c.config.Password}
+	reconciler := reconcile.NewReconciler(c, c.history, c.eventManager, c.logger, c.config.Environments, auth)
+	reconciler.Locks = c.locks
+	reconciler.Credentials = c.config.CredentialStore
+
+	return reconciler.Reconcile()
+}
+
+// SweepTempDirectories removes the temporary directories left behind before the server started.
+//
+// Returns the paths that were removed.
+func (c Creator) SweepTempDirectories() ([]string, error) {
+	return c.tempDirs.Sweep()
+}
+
 // CreateFileSystem returns a file system.
 func (c Creator) CreateFileSystem() *afero.Afero {
 	return c.fileSystem
 }
 
 // CreateHTTPClient return an http client.
+// Connections to hosts with TLS pins are rejected unless the certificate matches a pin.
 func (c Creator) CreateHTTPClient() *http.Client {
-	insecureClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+	}
+	if len(c.config.TLSPins) > 0 {
+		transport.DialTLS = c.config.TLSPins.DialTLS(tlsConfig)
 	}
 
-	return insecureClient
+	return &http.Client{Transport: transport}
 }
 
 func (c Creator) CreateController() I.Controller {
@@ -163,17 +498,46 @@ func (c Creator) CreateController() I.Controller {
 		PushControllerFactory:  c.CreatePushController,
 		StopControllerFactory:  c.CreateStopController,
 		StartControllerFactory: c.CreateStartController,
+		RestartControllerFactory: c.CreateRestartController,
+		RestageControllerFactory: c.CreateRestageController,
+		DeleteControllerFactory:  c.CreateDeleteController,
 		Config:                 c.CreateConfig(),
 		EventManager:           c.CreateEventManager(),
 		ErrorFinder:            c.createErrorFinder(),
+		History:                c.CreateDeploymentHistory(),
+		TempDirectories:        c.CreateTempDirectoryTracker(),
+		DeploymentLogs:         c.CreateDeploymentLogStore(),
+		LogStreams:             c.CreateLogStreamer(),
+		Progress:               c.CreateProgressTracker(),
+		AppInspector:           c.CreateAppInspector(),
+		StaleApps:              c.CreateStaleAppSweeper(),
+		Maintenance:            c.CreateFoundationMaintenance(),
+		FileSystem:             c.CreateFileSystem(),
+		Events:                 c.CreateEventStream(),
+		Locks:                  c.CreateLockManager(),
+		Cancellations:          c.CreateDeploymentCanceller(),
+		CanaryPromotions:       c.CreateCanaryPromoter(),
+		Tracer:                 c.tracer,
+		Authenticator:          c.authenticator,
+	}
+}
+
+// createRequestMiddleware returns the middleware decompressing request bodies and compressing responses, and
+// refusing requests larger than the configured limits. Bodies are decompressed first, so the limits apply to their
+// decompressed size.
+func (c Creator) createRequestMiddleware() []gin.HandlerFunc {
+	middleware := []gin.HandlerFunc{controller.Gzip(), controller.LimitRequestSize(c.config.Limits)}
+	if c.verifier != nil {
+		middleware = append(middleware, controller.AuthenticateBearer(c.verifier, c.logger))
 	}
+	return middleware
 }
 
 func (c Creator) CreatePushController(log I.DeploymentLogger) I.PushController {
 	if c.provider.NewPushController != nil {
-		return c.provider.NewPushController(log, c.createDeployer(log), c.createSilentDeployer(), c.CreateConfig(), c.CreateEventManager(), c.createErrorFinder(), c)
+		return c.provider.NewPushController(log, c.createDeployer(log), c.createSilentDeployer(), c.CreateConfig(), c.CreateEventManager(), c.createErrorFinder(), c, c.CreateDeploymentHistory())
 	}
-	return push.NewPushController(log, c.createDeployer(log), c.createSilentDeployer(), c.CreateConfig(), c.CreateEventManager(), c.createErrorFinder(), c)
+	return push.NewPushController(log, c.createDeployer(log), c.createSilentDeployer(), c.CreateConfig(), c.CreateEventManager(), c.createErrorFinder(), c, c.CreateDeploymentHistory())
 }
 
 func (c Creator) CreateStopController(log I.DeploymentLogger) I.StopController {
@@ -190,6 +554,27 @@ func (c Creator) CreateStartController(log I.DeploymentLogger) I.StartController
 	return start.NewStartController(log, c.createDeployer(log), c.CreateConfig(), c.CreateEventManager(), c.createErrorFinder(), c)
 }
 
+func (c Creator) CreateRestartController(log I.DeploymentLogger) I.RestartController {
+	if c.provider.NewRestartController != nil {
+		return c.provider.NewRestartController(log, c.createDeployer(log), c.CreateConfig(), c.CreateEventManager(), c.createErrorFinder(), c)
+	}
+	return restart.NewRestartController(log, c.createDeployer(log), c.CreateConfig(), c.CreateEventManager(), c.createErrorFinder(), c)
+}
+
+func (c Creator) CreateRestageController(log I.DeploymentLogger) I.RestageController {
+	if c.provider.NewRestageController != nil {
+		return c.provider.NewRestageController(log, c.createDeployer(log), c.CreateConfig(), c.CreateEventManager(), c.createErrorFinder(), c)
+	}
+	return restage.NewRestageController(log, c.createDeployer(log), c.CreateConfig(), c.CreateEventManager(), c.createErrorFinder(), c)
+}
+
+func (c Creator) CreateDeleteController(log I.DeploymentLogger) I.DeleteController {
+	if c.provider.NewDeleteController != nil {
+		return c.provider.NewDeleteController(log, c.createDeployer(log), c.CreateConfig(), c.CreateEventManager(), c.createErrorFinder(), c)
+	}
+	return deletion.NewDeleteController(log, c.createDeployer(log), c.CreateConfig(), c.CreateEventManager(), c.createErrorFinder(), c)
+}
+
 func (c Creator) createDeployer(log I.DeploymentLogger) I.Deployer {
 	return deployer.Deployer{
 		Config:       c.CreateConfig(),
@@ -199,27 +584,46 @@ func (c Creator) createDeployer(log I.DeploymentLogger) I.Deployer {
 		Randomizer:   c.createRandomizer(),
 		ErrorFinder:  c.createErrorFinder(),
 		Log:          log,
+
+		TempDirectories: c.CreateTempDirectoryTracker(),
+		Progress:        c.CreateProgressTracker(),
+		Maintenance:     c.CreateFoundationMaintenance(),
 	}
 }
 
 func (c Creator) PushManager(log I.DeploymentLogger, deployEventData structs.DeployEventData, cf I.CFContext, auth I.Authorization, env structs.Environment, envVars map[string]string) I.ActionCreator {
 	return &push.PushManager{
-		CourierCreator:       c,
+		CourierCreator:       c.deploymentCourierCreator(log.UUID, env.Name),
 		EventManager:         c.CreateEventManager(),
 		Logger:               log,
-		Fetcher:              c.createFetcher(log),
+		Fetcher:              c.createFetcher(log, env, c.createVerifier(log, env, deployEventData.DeploymentInfo)),
 		DeployEventData:      deployEventData,
 		FileSystemCleaner:    c.CreateFileSystem(),
 		CFContext:            cf,
 		Auth:                 auth,
 		Environment:          env,
 		EnvironmentVariables: envVars,
+		Pipeline:             c.createPushPipeline(),
+		Scanner:              c.createScanner(log),
+		SBOMGenerator:        c.createSBOMGenerator(log),
+		HealthChecks:         &structs.HealthCheckReport{},
+		CrashWatch:           &structs.CrashWatchReport{},
+		HealthChecker:        c.CreateHealthChecker(),
+		PromotionGate:        c.createPromotionGate(log),
+		Evidence:             &structs.DeploymentEvidenceReport{},
+		TempDirectories:      c.CreateTempDirectoryTracker(),
+		Progress:             c.CreateProgressTracker(),
+		FileSystem:           c.CreateFileSystem(),
+		Hooks:                c.createHookRunner(log),
+		QuotaUsage:           &structs.QuotaUsageReport{},
+		Janitor:              c.CreateJanitor(),
+		ArtifactCache:        c.CreateArtifactCache(),
 	}
 }
 
 func (c Creator) StopManager(log I.DeploymentLogger, deployEventData structs.DeployEventData) I.ActionCreator {
 	return stop.StopManager{
-		CourierCreator:  c,
+		CourierCreator:  c.deploymentCourierCreator(log.UUID, deployEventData.DeploymentInfo.Environment),
 		EventManager:    c.CreateEventManager(),
 		Log:             log,
 		DeployEventData: deployEventData,
@@ -229,13 +633,55 @@ func (c Creator) StopManager(log I.DeploymentLogger, deployEventData structs.Dep
 func (c Creator) StartManager(log I.DeploymentLogger, deployEventData structs.DeployEventData) I.ActionCreator {
 	//deploymentLogger := I.DeploymentLogger{c.CreateLogger(), deployEventData.DeploymentInfo.UUID}
 	return start.StartManager{
-		CourierCreator:  c,
+		CourierCreator:  c.deploymentCourierCreator(log.UUID, deployEventData.DeploymentInfo.Environment),
 		EventManager:    c.CreateEventManager(),
 		Logger:          log,
 		DeployEventData: deployEventData,
 	}
 }
 
+func (c Creator) RestartManager(log I.DeploymentLogger, deployEventData structs.DeployEventData) I.ActionCreator {
+	return restart.RestartManager{
+		CourierCreator:  c.deploymentCourierCreator(log.UUID, deployEventData.DeploymentInfo.Environment),
+		EventManager:    c.CreateEventManager(),
+		Logger:          log,
+		DeployEventData: deployEventData,
+	}
+}
+
+func (c Creator) RestageManager(log I.DeploymentLogger, deployEventData structs.DeployEventData) I.ActionCreator {
+	return restage.RestageManager{
+		CourierCreator:  c.deploymentCourierCreator(log.UUID, deployEventData.DeploymentInfo.Environment),
+		EventManager:    c.CreateEventManager(),
+		Logger:          log,
+		DeployEventData: deployEventData,
+	}
+}
+
+func (c Creator) DeleteManager(log I.DeploymentLogger, deployEventData structs.DeployEventData) I.ActionCreator {
+	return deletion.DeleteManager{
+		CourierCreator:  c.deploymentCourierCreator(log.UUID, deployEventData.DeploymentInfo.Environment),
+		EventManager:    c.CreateEventManager(),
+		Logger:          log,
+		DeployEventData: deployEventData,
+	}
+}
+
+// deploymentCourierCreator returns a courier creator whose couriers belong to the deployment to the environment.
+func (c Creator) deploymentCourierCreator(deploymentID, environment string) deploymentCourierCreator {
+	return deploymentCourierCreator{creator: c, deploymentID: deploymentID, environment: environment}
+}
+
+type deploymentCourierCreator struct {
+	creator      Creator
+	deploymentID string
+	environment  string
+}
+
+func (d deploymentCourierCreator) CreateCourier() (I.Courier, error) {
+	return d.creator.createCourier(d.deploymentID, d.environment)
+}
+
 func (c Creator) CreateEnvVarHandler() envvar.Envvarhandler {
 	return envvar.Envvarhandler{FileSystem: c.CreateFileSystem()}
 }
@@ -244,10 +690,28 @@ func (c Creator) CreateHealthChecker() healthchecker.HealthChecker {
 	return healthchecker.HealthChecker{
 		OldURL: "api.cf",
 		NewURL: "apps",
-		Client: c.CreateHTTPClient(),
+		Client:  c.CreateHTTPClient(),
+		Clients: c.createHealthCheckClients(),
 	}
 }
 
+// createHealthCheckClients returns the clients of the environments with a CA bundle or health check settings.
+func (c Creator) createHealthCheckClients() map[string]I.Client {
+	clients := map[string]I.Client{}
+	for _, environment := range c.config.Environments {
+		if environment.CABundle == "" && !environment.HealthCheck.Configured() {
+			continue
+		}
+
+		client, err := healthchecker.NewClient(c.CreateFileSystem(), environment, c.config.TLSPins)
+		if err != nil {
+			c.logger.Fatal(err)
+		}
+		clients[environment.Name] = client
+	}
+	return clients
+}
+
 func (c Creator) CreateRouteMapper() routemapper.RouteMapper {
 	return routemapper.RouteMapper{
 		FileSystem: c.CreateFileSystem(),
@@ -265,11 +729,56 @@ func (c Creator) createExtractor(log I.DeploymentLogger) I.Extractor {
 	return extractor.NewExtractor(log, c.CreateFileSystem())
 }
 
-func (c Creator) createFetcher(log I.DeploymentLogger) I.Fetcher {
+func (c Creator) createFetcher(log I.DeploymentLogger, env structs.Environment, verifier I.ArtifactVerifier) I.Fetcher {
+	var fetcher I.Fetcher
 	if c.provider.NewFetcher != nil {
-		return c.provider.NewFetcher(c.CreateFileSystem(), c.createExtractor(log), log)
+		fetcher = c.provider.NewFetcher(c.CreateFileSystem(), c.createExtractor(log), log, verifier, env.CABundle, env.Buckets, env.Artifactory, env.Maven, c.config.WorkDirectory)
+	} else {
+		fetcher = artifetcher.NewArtifetcher(c.CreateFileSystem(), c.createExtractor(log), log, verifier, env.CABundle, env.Buckets, env.Artifactory, env.Maven, c.config.WorkDirectory)
+	}
+	return git.NewFetcher(fetcher, c.CreateFileSystem(), c.createExtractor(log), log, env.Git, c.config.WorkDirectory)
+}
+
+func (c Creator) createVerifier(log I.DeploymentLogger, env structs.Environment, deploymentInfo *structs.DeploymentInfo) I.ArtifactVerifier {
+	if c.provider.NewVerifier != nil {
+		return c.provider.NewVerifier(log, c.CreateFileSystem(), env, deploymentInfo)
+	}
+	return signature.NewVerifier(log, c.CreateFileSystem(), env, deploymentInfo)
+}
+
+func (c Creator) createScanner(log I.DeploymentLogger) I.Scanner {
+	if c.provider.NewScanner != nil {
+		return c.provider.NewScanner(log, c.CreateFileSystem())
+	}
+	return scanner.NewScanner(log, c.CreateFileSystem())
+}
+
+func (c Creator) createPromotionGate(log I.DeploymentLogger) I.PromotionGate {
+	if c.provider.NewPromotionGate != nil {
+		return c.provider.NewPromotionGate(log)
+	}
+	return promotiongate.NewGate(log)
+}
+
+func (c Creator) createHookRunner(log I.DeploymentLogger) I.HookRunner {
+	if c.provider.NewHookRunner != nil {
+		return c.provider.NewHookRunner(log, c.config.LifecycleHooks)
+	}
+	return hooks.NewRunner(log, c.config.LifecycleHooks)
+}
+
+func (c Creator) createSBOMGenerator(log I.DeploymentLogger) I.SBOMGenerator {
+	if c.provider.NewSBOMGenerator != nil {
+		return c.provider.NewSBOMGenerator(log, c.CreateFileSystem())
+	}
+	return sbom.NewGenerator(log, c.CreateFileSystem())
+}
+
+func (c Creator) createPushPipeline() *push.Pipeline {
+	if c.provider.NewPushPipeline != nil {
+		return c.provider.NewPushPipeline()
 	}
-	return artifetcher.NewArtifetcher(c.CreateFileSystem(), c.createExtractor(log), log)
+	return push.NewPipeline()
 }
 
 func (c Creator) createRandomizer() I.Randomizer {
@@ -288,8 +797,16 @@ func (c Creator) createWriter() io.Writer {
 }
 
 func (c Creator) createBlueGreener(log I.DeploymentLogger) I.BlueGreener {
-	return bluegreen.BlueGreen{
-		Log: log,
+	return bluegreen.Rolling{
+		BlueGreener: bluegreen.Canary{
+			BlueGreen: bluegreen.BlueGreen{
+				Log:      log,
+				Progress: c.CreateProgressTracker(),
+			},
+			Promotions: c.CreateCanaryPromoter(),
+		},
+		Log:      log,
+		Progress: c.CreateProgressTracker(),
 	}
 }
 
@@ -300,12 +817,19 @@ func (c Creator) createErrorFinder() I.ErrorFinder {
 }
 
 func createCreator(l logging.Level, cfg config.Config, provider CreatorModuleProvider) (Creator, error) {
-	err := ensureCLI()
+	// A custom courier, such as the one of the testharness, or the api courier do not run the Cloud Foundry CLI.
+	if provider.NewCourier == nil && cfg.UsesCLI() {
+		err := ensureCLI(cfg.CFCLI)
+		if err != nil {
+			return Creator{}, err
+		}
+	}
+
+	logger, err := logsink.NewLogger(cfg.LogSinks, l, "controller", os.Stdout)
 	if err != nil {
 		return Creator{}, err
 	}
 
-	logger := I.DefaultLogger(os.Stdout, l, "controller")
 	var eventManager I.EventManager
 	if provider.NewEventManager != nil {
 		eventManager = provider.NewEventManager(logger)
@@ -313,19 +837,159 @@ func createCreator(l logging.Level, cfg config.Config, provider CreatorModulePro
 		eventManager = eventmanager.NewEventManager(logger)
 	}
 
-	return Creator{
+	events := eventmanager.NewStream()
+	eventManager.AddBinding(events)
+
+	fileSystem := &afero.Afero{Fs: afero.NewOsFs()}
+
+	publishers := []*publisher.Publisher{}
+	for _, descriptor := range cfg.EventPublishers {
+		p, err := publisher.New(descriptor, fileSystem, logger)
+		if err != nil {
+			return Creator{}, err
+		}
+		eventManager.AddBinding(p)
+		publishers = append(publishers, p)
+	}
+
+	webhooks := webhook.NewNotifier(cfg.Environments, logger)
+	if !webhooks.Empty() {
+		eventManager.AddBinding(webhooks)
+	}
+
+	var deploymentHistory I.DeploymentHistory
+	if provider.NewHistory != nil {
+		deploymentHistory, err = provider.NewHistory(cfg.DeploymentHistory, fileSystem)
+	} else {
+		deploymentHistory, err = history.NewHistory(cfg.DeploymentHistory, fileSystem)
+	}
+	if err != nil {
+		return Creator{}, err
+	}
+
+	var deploymentLogs I.DeploymentLogStore
+	if provider.NewDeploymentLogs != nil {
+		deploymentLogs, err = provider.NewDeploymentLogs(cfg.DeploymentLogs, fileSystem)
+	} else {
+		deploymentLogs, err = deploymentlog.NewStore(cfg.DeploymentLogs, fileSystem)
+	}
+	if err != nil {
+		return Creator{}, err
+	}
+
+	locks, err := lock.New(cfg.DeploymentLocks, fileSystem, logger)
+	if err != nil {
+		return Creator{}, err
+	}
+
+	elector, err := leader.New(cfg.LeaderElection, fileSystem, logger)
+	if err != nil {
+		return Creator{}, err
+	}
+
+	sharedState, err := sharedstate.New(cfg.SharedState, fileSystem, logger)
+	if err != nil {
+		return Creator{}, err
+	}
+
+	var progressTracker I.ProgressTracker = progress.NewTracker()
+	logStreams := logstream.NewHub()
+	cancels := cancellation.NewRegistry()
+	canaries := canary.NewRegistry()
+	if sharedState != nil {
+		progressTracker = progress.NewShared(progress.NewTracker(), sharedState, logger)
+		events.Share(sharedState, logger)
+		logStreams.Share(sharedState, logger)
+		cancels.Share(sharedState)
+		canaries.Share(sharedState)
+	}
+
+	tracer, err := tracing.New(cfg.Tracing, fileSystem, logger)
+	if err != nil {
+		return Creator{}, err
+	}
+
+	credentials, err := vault.New(cfg.Vault, fileSystem, logger)
+	if err != nil {
+		return Creator{}, err
+	}
+	if credentials != nil {
+		cfg.CredentialStore = credentials
+	}
+
+	var authenticator I.Authenticator
+	ldapAuthenticator, err := ldap.New(cfg.LDAP, fileSystem)
+	if err != nil {
+		return Creator{}, err
+	}
+	if ldapAuthenticator != nil {
+		authenticator = ldapAuthenticator
+	}
+
+	var verifier I.TokenVerifier
+	oidcVerifier, err := oidc.New(cfg.OIDC, fileSystem)
+	if err != nil {
+		return Creator{}, err
+	}
+	if oidcVerifier != nil {
+		verifier = oidcVerifier
+	}
+
+	var artifacts I.ArtifactCache
+	if cfg.ArtifactCache.MaxAgeMinutes > 0 {
+		artifacts = artifactcache.NewCache(fileSystem, cfg.WorkDirectory, cfg.ArtifactCache)
+	}
+
+	creator := Creator{
 		cfg,
 		eventManager,
 		logger,
 		os.Stdout,
-		&afero.Afero{Fs: afero.NewOsFs()},
+		fileSystem,
 		provider,
-	}, nil
+		deploymentHistory,
+		tempdir.NewTracker(fileSystem, cfg.WorkDirectory),
+		deploymentLogs,
+		progressTracker,
+		logStreams,
+		janitor.NewJanitor(nil, logger),
+		nil,
+		maintenance.NewRegistry(cfg.MaintenanceFoundations),
+		artifacts,
+		events,
+		publishers,
+		locks,
+		elector,
+		cancels,
+		canaries,
+		tracer,
+		slack.NewNotifier(cfg.Slack, &error_finder.ErrorFinder{Matchers: cfg.ErrorMatchers}, logger),
+		webhooks,
+		credentials,
+		authenticator,
+		verifier,
+	}
+	creator.janitor.CourierCreator = creator
+	creator.staleApps = janitor.NewStaleAppSweeper(creator, creator.progress, logger, cfg.Environments, I.Authorization{Username: cfg.Username, Password: cfg.Password}, cfg.StaleApps)
+	creator.staleApps.Credentials = cfg.CredentialStore
+
+	creator.elector.Add("the sweep for stale apps", creator.staleApps.Run)
+	creator.elector.Add("the reconciliation of interrupted deployments", func(ctx context.Context) {
+		err := creator.ReconcileDeployments()
+		if err != nil {
+			logger.Error(err)
+		}
+	})
+
+	return creator, nil
 
 }
 
-func ensureCLI() error {
-	_, err := exec.LookPath("cf")
+func ensureCLI(cli string) error {
+	if cli == "" {
+		cli = executor.DefaultCLI
+	}
+	_, err := exec.LookPath(cli)
 	return err
 }
 
@@ -1,8 +1,16 @@
 package creator
 
 import (
+	"encoding/json"
 	"os"
+	"regexp"
+	"strings"
 
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/courier"
+	"github.com/compozed/deployadactyl/eventmanager"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/openapi"
+	"github.com/compozed/deployadactyl/structs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"runtime"
@@ -48,6 +56,59 @@ var _ = Describe("Custom creator", func() {
 		Expect(creator.writer).ToNot(BeNil())
 	})
 
+	It("adds the bindings of a tenant to the event manager", func() {
+		os.Setenv("CF_USERNAME", "test user")
+		os.Setenv("CF_PASSWORD", "test pwd")
+
+		creator, err := Custom("DEBUG", "./testconfig.yml", CreatorModuleProvider{})
+		Expect(err).ToNot(HaveOccurred())
+		creator.config.Tenants = map[string]structs.Tenant{"search": {Name: "search"}}
+
+		bindings := len(creator.eventManager.(*eventmanager.EventManager).Bindings)
+		Expect(creator.AddTenantBinding("search", &mocks.EventBinding{})).To(Succeed())
+		Expect(creator.eventManager.(*eventmanager.EventManager).Bindings).To(HaveLen(bindings + 1))
+
+		Expect(creator.AddTenantBinding("payments", &mocks.EventBinding{})).To(MatchError(UnknownTenantError{"payments"}))
+	})
+
+	It("documents every route in the OpenAPI document", func() {
+		os.Setenv("CF_USERNAME", "test user")
+		os.Setenv("CF_PASSWORD", "test pwd")
+
+		creator, err := Custom("DEBUG", "./testconfig.yml", CreatorModuleProvider{})
+		Expect(err).ToNot(HaveOccurred())
+
+		document := struct {
+			Paths map[string]map[string]interface{} `json:"paths"`
+		}{}
+		Expect(json.Unmarshal([]byte(openapi.Document), &document)).To(Succeed())
+
+		param := regexp.MustCompile(`[:*]([A-Za-z]+)`)
+		for _, route := range creator.CreateControllerHandler(&mocks.Controller{}).Routes() {
+			path := param.ReplaceAllString(route.Path, "{$1}")
+			Expect(document.Paths).To(HaveKey(path))
+			Expect(document.Paths[path]).To(HaveKey(strings.ToLower(route.Method)), route.Method+" "+path)
+		}
+	})
+
+	It("creates the courier of the environment", func() {
+		os.Setenv("CF_USERNAME", "test user")
+		os.Setenv("CF_PASSWORD", "test pwd")
+
+		creator, err := Custom("DEBUG", "./testconfig.yml", CreatorModuleProvider{})
+		Expect(err).ToNot(HaveOccurred())
+		creator.config.Environments["sandbox"] = structs.Environment{Name: "sandbox", Courier: structs.CourierAPI}
+
+		apiCourier, err := creator.deploymentCourierCreator("uuid-1", "sandbox").CreateCourier()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(apiCourier).To(BeAssignableToTypeOf(courier.APICourier{}))
+
+		cliCourier, err := creator.CreateCourier()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cliCourier).To(BeAssignableToTypeOf(courier.Courier{}))
+		Expect(cliCourier.CleanUp()).To(Succeed())
+	})
+
 	It("fails due to lack of required env variables", func() {
 		level := "DEBUG"
 		configPath := "./testconfig.yml"
@@ -0,0 +1,11 @@
+package creator
+
+import "fmt"
+
+type UnknownTenantError struct {
+	Tenant string
+}
+
+func (e UnknownTenantError) Error() string {
+	return fmt.Sprintf("tenant %s is not configured", e.Tenant)
+}
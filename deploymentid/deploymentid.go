@@ -0,0 +1,111 @@
+// Package deploymentid generates the identifiers of deployments.
+package deploymentid
+
+import (
+	"crypto/sha256"
+	"strings"
+
+	"github.com/compozed/deployadactyl/randomizer"
+)
+
+// IdempotencyKeyHeader carries a key from which the identifier of a deployment is derived.
+// Requests with the same key in the same environment get the same identifier.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// DefaultLength is the length of an identifier, without its prefix, when no length is configured.
+const DefaultLength = 10
+
+// MinLength and MaxLength bound the configurable length of an identifier.
+const (
+	MinLength = 4
+	MaxLength = 32
+)
+
+// The characters an identifier is made of.
+const (
+	Letters   = "letters"
+	Lowercase = "lowercase"
+	Hex       = "hex"
+)
+
+var alphabets = map[string]string{
+	Letters:   "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	Lowercase: "abcdefghijklmnopqrstuvwxyz0123456789",
+	Hex:       "0123456789abcdef",
+}
+
+// Format configures how the identifiers of deployments are generated.
+// The zero value generates 10 random letters.
+type Format struct {
+	Length            int    `yaml:"length"`
+	Charset           string `yaml:"charset"`
+	PrefixEnvironment bool   `yaml:"prefix_environment"`
+	IdempotencyKey    bool   `yaml:"idempotency_key"`
+}
+
+// Validate returns an error when the length or the charset is not supported.
+func (f Format) Validate() error {
+	if f.Length != 0 && (f.Length < MinLength || f.Length > MaxLength) {
+		return InvalidLengthError{f.Length}
+	}
+	if f.Charset != "" {
+		if _, ok := alphabets[f.Charset]; !ok {
+			return UnknownCharsetError{f.Charset}
+		}
+	}
+	return nil
+}
+
+// Generate returns the identifier of a deployment to the environment.
+//
+// When IdempotencyKey is enabled and a key is given, the identifier is derived from the environment and the key,
+// otherwise it is random. When PrefixEnvironment is enabled, the identifier starts with the environment.
+func (f Format) Generate(environment, idempotencyKey string) string {
+	length := f.Length
+	if length == 0 {
+		length = DefaultLength
+	}
+
+	alphabet, ok := alphabets[f.Charset]
+	if !ok {
+		alphabet = alphabets[Letters]
+	}
+
+	var id string
+	if f.IdempotencyKey && idempotencyKey != "" {
+		id = derive(alphabet, length, environment, idempotencyKey)
+	} else {
+		id = randomizer.FromAlphabet(alphabet, length)
+	}
+
+	if f.PrefixEnvironment && environment != "" {
+		id = prefix(environment, f.Charset == Letters || f.Charset == "") + "-" + id
+	}
+
+	return id
+}
+
+func derive(alphabet string, length int, environment, idempotencyKey string) string {
+	sum := sha256.Sum256([]byte(environment + "\x00" + idempotencyKey))
+
+	id := make([]byte, length)
+	for i := range id {
+		id[i] = alphabet[int(sum[i])%len(alphabet)]
+	}
+	return string(id)
+}
+
+// prefix keeps the letters, digits and dashes of the environment so that the identifier can be used in app names.
+func prefix(environment string, keepCase bool) string {
+	if !keepCase {
+		environment = strings.ToLower(environment)
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		}
+		return '-'
+	}, environment)
+}
@@ -0,0 +1,13 @@
+package deploymentid_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDeploymentid(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Deploymentid Suite")
+}
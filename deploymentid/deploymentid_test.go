@@ -0,0 +1,73 @@
+package deploymentid_test
+
+import (
+	. "github.com/compozed/deployadactyl/deploymentid"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deployment IDs", func() {
+	Describe("Validate", func() {
+		It("accepts the zero value", func() {
+			Expect(Format{}.Validate()).To(Succeed())
+		})
+
+		It("rejects a length that is too short or too long", func() {
+			Expect(Format{Length: 3}.Validate()).To(MatchError(InvalidLengthError{3}))
+			Expect(Format{Length: 33}.Validate()).To(MatchError(InvalidLengthError{33}))
+		})
+
+		It("rejects an unknown charset", func() {
+			Expect(Format{Charset: "emoji"}.Validate()).To(MatchError(UnknownCharsetError{"emoji"}))
+		})
+	})
+
+	Describe("Generate", func() {
+		It("generates 10 random letters by default", func() {
+			id := Format{}.Generate("prod", "")
+
+			Expect(id).To(MatchRegexp(`^[a-zA-Z]{10}$`))
+			Expect(Format{}.Generate("prod", "")).ToNot(Equal(id))
+		})
+
+		It("generates ids of the configured length and charset", func() {
+			Expect(Format{Length: 6, Charset: Lowercase}.Generate("prod", "")).To(MatchRegexp(`^[a-z0-9]{6}$`))
+			Expect(Format{Length: 8, Charset: Hex}.Generate("prod", "")).To(MatchRegexp(`^[0-9a-f]{8}$`))
+		})
+
+		It("prefixes the id with the environment", func() {
+			format := Format{Length: 6, Charset: Lowercase, PrefixEnvironment: true}
+
+			Expect(format.Generate("Prod_East", "")).To(MatchRegexp(`^prod-east-[a-z0-9]{6}$`))
+		})
+
+		It("ignores the idempotency key unless it is enabled", func() {
+			format := Format{}
+
+			Expect(format.Generate("prod", "key")).ToNot(Equal(format.Generate("prod", "key")))
+		})
+
+		Context("when idempotency keys are enabled", func() {
+			format := Format{Charset: Hex, IdempotencyKey: true}
+
+			It("derives the same id from the same key and environment", func() {
+				id := format.Generate("prod", "build-42")
+
+				Expect(id).To(MatchRegexp(`^[0-9a-f]{10}$`))
+				Expect(format.Generate("prod", "build-42")).To(Equal(id))
+			})
+
+			It("derives different ids from different keys or environments", func() {
+				id := format.Generate("prod", "build-42")
+
+				Expect(format.Generate("prod", "build-43")).ToNot(Equal(id))
+				Expect(format.Generate("test", "build-42")).ToNot(Equal(id))
+			})
+
+			It("generates a random id without a key", func() {
+				Expect(format.Generate("prod", "")).ToNot(Equal(format.Generate("prod", "")))
+			})
+		})
+	})
+})
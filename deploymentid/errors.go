@@ -0,0 +1,19 @@
+package deploymentid
+
+import "fmt"
+
+type InvalidLengthError struct {
+	Length int
+}
+
+func (e InvalidLengthError) Error() string {
+	return fmt.Sprintf("the deployment id length must be between %d and %d: %d", MinLength, MaxLength, e.Length)
+}
+
+type UnknownCharsetError struct {
+	Charset string
+}
+
+func (e UnknownCharsetError) Error() string {
+	return fmt.Sprintf("unknown deployment id charset %s: expected %s, %s or %s", e.Charset, Letters, Lowercase, Hex)
+}
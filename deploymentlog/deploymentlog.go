@@ -0,0 +1,41 @@
+// Package deploymentlog keeps the output of every deployment so it can be retrieved after the fact.
+package deploymentlog
+
+import (
+	"regexp"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/postgres"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// DefaultMaxLogs is the number of deployments whose output is kept when the descriptor does not set a maximum.
+const DefaultMaxLogs = 1000
+
+type StoreConstructor func(descriptor S.DeploymentLogsDescriptor, fs *afero.Afero) (I.DeploymentLogStore, error)
+
+// NewStore returns a PostgresStore if the descriptor has a database, a FileStore if it has a directory and a
+// MemoryStore otherwise.
+func NewStore(descriptor S.DeploymentLogsDescriptor, fs *afero.Afero) (I.DeploymentLogStore, error) {
+	if descriptor.Postgres != "" {
+		db, err := postgres.Open(descriptor.Postgres, fs)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresStore(db, descriptor)
+	}
+	if descriptor.Directory != "" {
+		return NewFileStore(fs, descriptor)
+	}
+	return NewMemoryStore(descriptor), nil
+}
+
+var validUUID = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+func maxLogs(descriptor S.DeploymentLogsDescriptor) int {
+	if descriptor.MaxLogs <= 0 {
+		return DefaultMaxLogs
+	}
+	return descriptor.MaxLogs
+}
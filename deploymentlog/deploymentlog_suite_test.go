@@ -0,0 +1,13 @@
+package deploymentlog_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDeploymentlog(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Deploymentlog Suite")
+}
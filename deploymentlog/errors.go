@@ -0,0 +1,46 @@
+package deploymentlog
+
+import "fmt"
+
+type LogNotFoundError struct {
+	UUID string
+}
+
+func (e LogNotFoundError) Error() string {
+	return fmt.Sprintf("the output of deployment %s was not found", e.UUID)
+}
+
+type InvalidUUIDError struct {
+	UUID string
+}
+
+func (e InvalidUUIDError) Error() string {
+	return fmt.Sprintf("cannot keep the output of deployment %s: the uuid may only contain letters, digits and dashes", e.UUID)
+}
+
+type ReadLogError struct {
+	Path string
+	Err  error
+}
+
+func (e ReadLogError) Error() string {
+	return fmt.Sprintf("cannot read deployment output from %s: %s", e.Path, e.Err)
+}
+
+type WriteLogError struct {
+	Path string
+	Err  error
+}
+
+func (e WriteLogError) Error() string {
+	return fmt.Sprintf("cannot write deployment output to %s: %s", e.Path, e.Err)
+}
+
+type DatabaseError struct {
+	Operation string
+	Err       error
+}
+
+func (e DatabaseError) Error() string {
+	return fmt.Sprintf("cannot %s deployment output in the database: %s", e.Operation, e.Err)
+}
@@ -0,0 +1,114 @@
+package deploymentlog
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// Extension is the extension of the file holding the output of a deployment.
+const Extension = ".log"
+
+// NewFileStore returns a FileStore that keeps the output of deployments in the directory of the
+// descriptor, creating it if needed.
+func NewFileStore(fs *afero.Afero, descriptor S.DeploymentLogsDescriptor) (*FileStore, error) {
+	err := fs.MkdirAll(descriptor.Directory, 0755)
+	if err != nil {
+		return nil, WriteLogError{descriptor.Directory, err}
+	}
+
+	return &FileStore{
+		FileSystem: fs,
+		Directory:  descriptor.Directory,
+		MaxLogs:    maxLogs(descriptor),
+		MaxAgeDays: descriptor.MaxAgeDays,
+		Now:        time.Now,
+	}, nil
+}
+
+// FileStore keeps the output of every deployment in a file named after its UUID. Once it holds more
+// than MaxLogs files, or files older than MaxAgeDays, they are removed oldest first.
+type FileStore struct {
+	FileSystem *afero.Afero
+	Directory  string
+	MaxLogs    int
+	MaxAgeDays int
+	Now        func() time.Time
+
+	mu sync.Mutex
+}
+
+// Save writes the output of the deployment to its file and removes the files past the retention.
+func (s *FileStore) Save(uuid string, output []byte) error {
+	if !validUUID.MatchString(uuid) {
+		return InvalidUUIDError{uuid}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.path(uuid)
+	err := s.FileSystem.WriteFile(path, output, 0644)
+	if err != nil {
+		return WriteLogError{path, err}
+	}
+
+	return s.prune()
+}
+
+// Get returns the output of the deployment.
+func (s *FileStore) Get(uuid string) ([]byte, error) {
+	if !validUUID.MatchString(uuid) {
+		return nil, LogNotFoundError{UUID: uuid}
+	}
+
+	path := s.path(uuid)
+	info, err := s.FileSystem.Stat(path)
+	if os.IsNotExist(err) || (err == nil && expired(info.ModTime(), s.MaxAgeDays, s.Now())) {
+		return nil, LogNotFoundError{UUID: uuid}
+	}
+	if err != nil {
+		return nil, ReadLogError{path, err}
+	}
+
+	output, err := s.FileSystem.ReadFile(path)
+	if err != nil {
+		return nil, ReadLogError{path, err}
+	}
+	return output, nil
+}
+
+func (s *FileStore) prune() error {
+	infos, err := s.FileSystem.ReadDir(s.Directory)
+	if err != nil {
+		return ReadLogError{s.Directory, err}
+	}
+
+	var logs []os.FileInfo
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasSuffix(info.Name(), Extension) {
+			logs = append(logs, info)
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].ModTime().Before(logs[j].ModTime())
+	})
+
+	now := s.Now()
+	for i, info := range logs {
+		if len(logs)-i > s.MaxLogs || expired(info.ModTime(), s.MaxAgeDays, now) {
+			s.FileSystem.Remove(filepath.Join(s.Directory, info.Name()))
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) path(uuid string) string {
+	return filepath.Join(s.Directory, uuid+Extension)
+}
@@ -0,0 +1,101 @@
+package deploymentlog_test
+
+import (
+	"github.com/compozed/deployadactyl/conformance"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"time"
+
+	. "github.com/compozed/deployadactyl/deploymentlog"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("FileStore", func() {
+	var (
+		af         *afero.Afero
+		descriptor S.DeploymentLogsDescriptor
+		now        time.Time
+	)
+
+	BeforeEach(func() {
+		af = &afero.Afero{Fs: afero.NewMemMapFs()}
+		descriptor = S.DeploymentLogsDescriptor{Directory: "/var/deployadactyl/logs"}
+		now = time.Now()
+	})
+
+	newStore := func() *FileStore {
+		store, err := NewFileStore(af, descriptor)
+		Expect(err).ToNot(HaveOccurred())
+		store.Now = func() time.Time { return now }
+		return store
+	}
+
+	It("keeps the output after a restart", func() {
+		Expect(newStore().Save("abc", []byte("push succeeded"))).To(Succeed())
+
+		output, err := newStore().Get("abc")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(output)).To(Equal("push succeeded"))
+
+		Expect(af.Exists("/var/deployadactyl/logs/abc.log")).To(BeTrue())
+	})
+
+	It("returns a LogNotFoundError for an unknown deployment", func() {
+		_, err := newStore().Get("abc")
+
+		Expect(err).To(MatchError(LogNotFoundError{UUID: "abc"}))
+	})
+
+	It("does not read or write outside of its directory", func() {
+		Expect(af.WriteFile("/var/deployadactyl/secret.log", []byte("secret"), 0644)).To(Succeed())
+		store := newStore()
+
+		_, err := store.Get("../secret")
+		Expect(err).To(MatchError(LogNotFoundError{UUID: "../secret"}))
+
+		Expect(store.Save("../secret", []byte("overwritten"))).To(MatchError(InvalidUUIDError{"../secret"}))
+	})
+
+	It("removes the oldest output once it holds more than the maximum", func() {
+		descriptor.MaxLogs = 2
+		store := newStore()
+
+		for i, uuid := range []string{"a", "b", "c"} {
+			Expect(store.Save(uuid, []byte(uuid))).To(Succeed())
+			modTime := now.Add(time.Duration(i-3) * time.Minute)
+			Expect(af.Chtimes("/var/deployadactyl/logs/"+uuid+".log", modTime, modTime)).To(Succeed())
+		}
+		Expect(store.Save("d", []byte("d"))).To(Succeed())
+
+		_, err := store.Get("a")
+		Expect(err).To(HaveOccurred())
+		_, err = store.Get("b")
+		Expect(err).To(HaveOccurred())
+		Expect(store.Get("c")).To(Equal([]byte("c")))
+		Expect(store.Get("d")).To(Equal([]byte("d")))
+	})
+
+	It("removes the output older than the maximum age", func() {
+		descriptor.MaxAgeDays = 7
+		store := newStore()
+
+		Expect(store.Save("old", []byte("old"))).To(Succeed())
+		modTime := now.AddDate(0, 0, -8)
+		Expect(af.Chtimes("/var/deployadactyl/logs/old.log", modTime, modTime)).To(Succeed())
+
+		_, err := store.Get("old")
+		Expect(err).To(MatchError(LogNotFoundError{UUID: "old"}))
+
+		Expect(store.Save("new", []byte("new"))).To(Succeed())
+		Expect(af.Exists("/var/deployadactyl/logs/old.log")).To(BeFalse())
+	})
+
+	It("satisfies the DeploymentLogStore contract", func() {
+		conformance.DeploymentLogStore(GinkgoT(), func() I.DeploymentLogStore {
+			af = &afero.Afero{Fs: afero.NewMemMapFs()}
+			return newStore()
+		})
+	})
+})
@@ -0,0 +1,70 @@
+package deploymentlog
+
+import (
+	"sync"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// NewMemoryStore returns a MemoryStore with the retention of the descriptor.
+func NewMemoryStore(descriptor S.DeploymentLogsDescriptor) *MemoryStore {
+	return &MemoryStore{
+		MaxLogs:    maxLogs(descriptor),
+		MaxAgeDays: descriptor.MaxAgeDays,
+		Now:        time.Now,
+	}
+}
+
+// MemoryStore keeps the output of deployments in memory. Once it holds MaxLogs outputs the oldest
+// ones are dropped.
+type MemoryStore struct {
+	MaxLogs    int
+	MaxAgeDays int
+	Now        func() time.Time
+
+	mu   sync.RWMutex
+	logs []memoryLog
+}
+
+type memoryLog struct {
+	uuid    string
+	output  []byte
+	savedAt time.Time
+}
+
+// Save keeps the output of the deployment, replacing any output it already had.
+func (s *MemoryStore) Save(uuid string, output []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, log := range s.logs {
+		if log.uuid == uuid {
+			s.logs = append(s.logs[:i:i], s.logs[i+1:]...)
+			break
+		}
+	}
+	s.logs = append(s.logs, memoryLog{uuid, append([]byte{}, output...), s.Now()})
+
+	if len(s.logs) > s.MaxLogs {
+		s.logs = append([]memoryLog{}, s.logs[len(s.logs)-s.MaxLogs:]...)
+	}
+	return nil
+}
+
+// Get returns the output of the deployment.
+func (s *MemoryStore) Get(uuid string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, log := range s.logs {
+		if log.uuid == uuid && !expired(log.savedAt, s.MaxAgeDays, s.Now()) {
+			return log.output, nil
+		}
+	}
+	return nil, LogNotFoundError{UUID: uuid}
+}
+
+func expired(savedAt time.Time, maxAgeDays int, now time.Time) bool {
+	return maxAgeDays > 0 && savedAt.Before(now.AddDate(0, 0, -maxAgeDays))
+}
@@ -0,0 +1,61 @@
+package deploymentlog_test
+
+import (
+	"github.com/compozed/deployadactyl/conformance"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"time"
+
+	. "github.com/compozed/deployadactyl/deploymentlog"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MemoryStore", func() {
+	It("returns the output of a deployment", func() {
+		store := NewMemoryStore(S.DeploymentLogsDescriptor{})
+
+		Expect(store.Save("abc", []byte("push succeeded"))).To(Succeed())
+
+		Expect(store.Get("abc")).To(Equal([]byte("push succeeded")))
+	})
+
+	It("replaces the output of a deployment that is saved again", func() {
+		store := NewMemoryStore(S.DeploymentLogsDescriptor{MaxLogs: 2})
+
+		Expect(store.Save("a", []byte("first"))).To(Succeed())
+		Expect(store.Save("b", []byte("b"))).To(Succeed())
+		Expect(store.Save("a", []byte("second"))).To(Succeed())
+
+		Expect(store.Get("a")).To(Equal([]byte("second")))
+		Expect(store.Get("b")).To(Equal([]byte("b")))
+	})
+
+	It("drops the oldest output once it holds the maximum", func() {
+		store := NewMemoryStore(S.DeploymentLogsDescriptor{MaxLogs: 2})
+
+		Expect(store.Save("a", []byte("a"))).To(Succeed())
+		Expect(store.Save("b", []byte("b"))).To(Succeed())
+		Expect(store.Save("c", []byte("c"))).To(Succeed())
+
+		_, err := store.Get("a")
+		Expect(err).To(MatchError(LogNotFoundError{UUID: "a"}))
+		Expect(store.Get("c")).To(Equal([]byte("c")))
+	})
+
+	It("does not return output older than the maximum age", func() {
+		store := NewMemoryStore(S.DeploymentLogsDescriptor{MaxAgeDays: 1})
+		now := time.Now()
+		store.Now = func() time.Time { return now }
+
+		Expect(store.Save("a", []byte("a"))).To(Succeed())
+		now = now.Add(25 * time.Hour)
+
+		_, err := store.Get("a")
+		Expect(err).To(MatchError(LogNotFoundError{UUID: "a"}))
+	})
+
+	It("satisfies the DeploymentLogStore contract", func() {
+		conformance.DeploymentLogStore(GinkgoT(), func() I.DeploymentLogStore { return NewMemoryStore(S.DeploymentLogsDescriptor{}) })
+	})
+})
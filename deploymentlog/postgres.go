@@ -0,0 +1,101 @@
+package deploymentlog
+
+import (
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/postgres"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// PostgresTable is the table of the outputs of deployments. It is created when it does not exist.
+const PostgresTable = "deployadactyl_deployment_logs"
+
+// NewPostgresStore returns a PostgresStore with the retention of the descriptor, creating its table if needed.
+func NewPostgresStore(db I.Database, descriptor S.DeploymentLogsDescriptor) (*PostgresStore, error) {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + PostgresTable + ` (
+		uuid text PRIMARY KEY,
+		output bytea NOT NULL,
+		saved_at timestamptz NOT NULL
+	)`)
+	if err != nil {
+		return nil, DatabaseError{"create", err}
+	}
+
+	err = db.Exec(`CREATE INDEX IF NOT EXISTS ` + PostgresTable + `_saved_at ON ` + PostgresTable + ` (saved_at)`)
+	if err != nil {
+		return nil, DatabaseError{"create", err}
+	}
+
+	return &PostgresStore{
+		DB:         db,
+		MaxLogs:    maxLogs(descriptor),
+		MaxAgeDays: descriptor.MaxAgeDays,
+		Now:        time.Now,
+	}, nil
+}
+
+// PostgresStore keeps the output of every deployment in a PostgreSQL database, so it can be read from every instance
+// of the server sharing the database. Once it holds more than MaxLogs outputs, or outputs older than MaxAgeDays,
+// they are deleted oldest first.
+type PostgresStore struct {
+	DB         I.Database
+	MaxLogs    int
+	MaxAgeDays int
+	Now        func() time.Time
+}
+
+// Save keeps the output of the deployment, replacing any output it already had, and deletes the outputs past the
+// retention.
+func (s *PostgresStore) Save(uuid string, output []byte) error {
+	if !validUUID.MatchString(uuid) {
+		return InvalidUUIDError{uuid}
+	}
+
+	now := s.Now()
+	err := s.DB.Exec(`INSERT INTO `+PostgresTable+` (uuid, output, saved_at) VALUES ($1, $2, $3)
+		ON CONFLICT (uuid) DO UPDATE SET output = EXCLUDED.output, saved_at = EXCLUDED.saved_at`,
+		uuid, output, now)
+	if err != nil {
+		return DatabaseError{"write", err}
+	}
+
+	err = s.DB.Exec(`DELETE FROM `+PostgresTable+`
+		WHERE saved_at <= (SELECT saved_at FROM `+PostgresTable+` ORDER BY saved_at DESC OFFSET $1 LIMIT 1)`, s.MaxLogs)
+	if err != nil {
+		return DatabaseError{"write", err}
+	}
+
+	if s.MaxAgeDays > 0 {
+		err = s.DB.Exec(`DELETE FROM `+PostgresTable+` WHERE saved_at < $1`, now.AddDate(0, 0, -s.MaxAgeDays))
+		if err != nil {
+			return DatabaseError{"write", err}
+		}
+	}
+	return nil
+}
+
+// Get returns the output of the deployment.
+func (s *PostgresStore) Get(uuid string) ([]byte, error) {
+	rows, err := s.DB.Query(`SELECT output, saved_at FROM `+PostgresTable+` WHERE uuid = $1`, uuid)
+	if err != nil {
+		return nil, DatabaseError{"read", err}
+	}
+	if len(rows) == 0 || len(rows[0]) != 2 {
+		return nil, LogNotFoundError{UUID: uuid}
+	}
+
+	savedAt, err := postgres.ParseTime(rows[0][1])
+	if err != nil {
+		return nil, DatabaseError{"read", err}
+	}
+	if expired(savedAt, s.MaxAgeDays, s.Now()) {
+		return nil, LogNotFoundError{UUID: uuid}
+	}
+
+	output, err := postgres.ParseBytea(rows[0][0])
+	if err != nil {
+		return nil, DatabaseError{"read", err}
+	}
+	return output, nil
+}
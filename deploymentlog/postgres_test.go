@@ -0,0 +1,76 @@
+package deploymentlog_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/compozed/deployadactyl/deploymentlog"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PostgresStore", func() {
+	var (
+		db    *mocks.Database
+		store *PostgresStore
+		now   time.Time
+	)
+
+	BeforeEach(func() {
+		db = &mocks.Database{}
+		now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+		var err error
+		store, err = NewPostgresStore(db, S.DeploymentLogsDescriptor{MaxLogs: 2, MaxAgeDays: 7})
+		Expect(err).ToNot(HaveOccurred())
+		store.Now = func() time.Time { return now }
+	})
+
+	It("creates its table", func() {
+		Expect(db.ExecCall.Received.Queries[0]).To(ContainSubstring("CREATE TABLE IF NOT EXISTS " + PostgresTable))
+	})
+
+	It("upserts the output of the deployment and deletes the outputs past the retention", func() {
+		Expect(store.Save("abc", []byte("push succeeded"))).To(Succeed())
+
+		Expect(db.ExecCall.Received.Queries[2]).To(ContainSubstring("ON CONFLICT (uuid) DO UPDATE"))
+		Expect(db.ExecCall.Received.Args[2]).To(Equal([]interface{}{"abc", []byte("push succeeded"), now}))
+		Expect(db.ExecCall.Received.Args[3]).To(Equal([]interface{}{2}))
+		Expect(db.ExecCall.Received.Args[4]).To(Equal([]interface{}{now.AddDate(0, 0, -7)}))
+	})
+
+	It("refuses a uuid that is not safe", func() {
+		Expect(store.Save("../etc", []byte("output"))).To(MatchError(InvalidUUIDError{"../etc"}))
+	})
+
+	It("returns the output of a deployment", func() {
+		db.QueryCall.Returns.Rows = [][]string{{`\x70757368`, "2026-10-16 11:00:00+00"}}
+
+		Expect(store.Get("abc")).To(Equal([]byte("push")))
+		Expect(db.QueryCall.Received.Args[0]).To(Equal([]interface{}{"abc"}))
+	})
+
+	It("does not return an expired output", func() {
+		db.QueryCall.Returns.Rows = [][]string{{`\x70757368`, "2026-10-01 11:00:00+00"}}
+
+		_, err := store.Get("abc")
+
+		Expect(err).To(MatchError(LogNotFoundError{UUID: "abc"}))
+	})
+
+	It("returns an error for an unknown deployment", func() {
+		_, err := store.Get("abc")
+
+		Expect(err).To(MatchError(LogNotFoundError{UUID: "abc"}))
+	})
+
+	It("returns an error when the database fails", func() {
+		db.ExecCall.Returns.Error = errors.New("connection refused")
+
+		err := store.Save("abc", []byte("output"))
+
+		Expect(err).To(MatchError(DatabaseError{"write", errors.New("connection refused")}))
+	})
+})
@@ -0,0 +1,107 @@
+package drift
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DomainPlaceholder replaces the domain of its environment in routes, so that routes of different environments
+// can be compared.
+const DomainPlaceholder = "<domain>"
+
+// Compare reports the fields whose value is not the same on every snapshot. Snapshots with an error are
+// returned in the report but not compared.
+func Compare(snapshots []S.AppSnapshot, environments map[string]S.Environment) S.DriftReport {
+	report := S.DriftReport{Differences: []S.DriftDifference{}, Snapshots: snapshots}
+
+	var compared []S.AppSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.Error == "" {
+			compared = append(compared, snapshot)
+		}
+	}
+
+	fields := []struct {
+		name  string
+		value func(snapshot S.AppSnapshot) string
+	}{
+		{S.DriftArtifactURL, func(s S.AppSnapshot) string { return s.ArtifactURL }},
+		{S.DriftArtifactDigest, func(s S.AppSnapshot) string { return s.ArtifactDigest }},
+		{S.DriftVersion, func(s S.AppSnapshot) string { return s.Version }},
+		{S.DriftInstances, func(s S.AppSnapshot) string { return fmt.Sprint(s.Instances) }},
+		{S.DriftRoutes, func(s S.AppSnapshot) string {
+			return strings.Join(routes(s.Routes, environments[s.Environment].Domain), ", ")
+		}},
+	}
+	for _, field := range fields {
+		if difference, ok := compare(compared, field.name, "", field.value); ok {
+			report.Differences = append(report.Differences, difference)
+		}
+	}
+
+	for _, name := range variableNames(compared) {
+		value := func(s S.AppSnapshot) string { return s.EnvironmentVariables[name] }
+		if difference, ok := compare(compared, S.DriftEnvironmentVariable, name, value); ok {
+			report.Differences = append(report.Differences, difference)
+		}
+	}
+
+	report.Drifted = len(report.Differences) > 0
+	return report
+}
+
+func compare(snapshots []S.AppSnapshot, field, name string, value func(snapshot S.AppSnapshot) string) (S.DriftDifference, bool) {
+	difference := S.DriftDifference{Field: field, Name: name}
+	drifted := false
+
+	for _, snapshot := range snapshots {
+		v := value(snapshot)
+		if len(difference.Values) > 0 && v != difference.Values[0].Value {
+			drifted = true
+		}
+		difference.Values = append(difference.Values, S.DriftValue{
+			Environment:   snapshot.Environment,
+			FoundationURL: snapshot.FoundationURL,
+			Value:         v,
+		})
+	}
+
+	return difference, drifted
+}
+
+// routes replaces the domain of the environment in the routes, and sorts them.
+func routes(urls []string, domain string) []string {
+	normalized := []string{}
+	for _, url := range urls {
+		host, path := url, ""
+		if i := strings.Index(url, "/"); i >= 0 {
+			host, path = url[:i], url[i:]
+		}
+		if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			host = strings.TrimSuffix(host, domain) + DomainPlaceholder
+		}
+		normalized = append(normalized, host+path)
+	}
+	sort.Strings(normalized)
+
+	return normalized
+}
+
+func variableNames(snapshots []S.AppSnapshot) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, snapshot := range snapshots {
+		for name := range snapshot.EnvironmentVariables {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,107 @@
+package drift_test
+
+import (
+	. "github.com/compozed/deployadactyl/drift"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compare", func() {
+	var (
+		environments map[string]S.Environment
+		east, west   S.AppSnapshot
+	)
+
+	BeforeEach(func() {
+		environments = map[string]S.Environment{
+			"prod":  {Name: "prod", Domain: "apps.prod.example.com"},
+			"stage": {Name: "stage", Domain: "apps.stage.example.com"},
+		}
+
+		east = S.AppSnapshot{
+			Environment:          "prod",
+			FoundationURL:        "https://api.east.example.com",
+			Instances:            4,
+			Routes:               []string{"search.apps.prod.example.com"},
+			EnvironmentVariables: map[string]string{"LOG_LEVEL": "sha256:1"},
+			ArtifactURL:          "https://example.com/2.zip",
+			Version:              "2.0.0",
+		}
+		west = east
+		west.FoundationURL = "https://api.west.example.com"
+	})
+
+	It("reports no drift when every snapshot is the same", func() {
+		report := Compare([]S.AppSnapshot{east, west}, environments)
+
+		Expect(report.Drifted).To(BeFalse())
+		Expect(report.Differences).To(BeEmpty())
+		Expect(report.Snapshots).To(HaveLen(2))
+	})
+
+	It("reports a foundation that never got the latest release", func() {
+		west.ArtifactURL = "https://example.com/1.zip"
+		west.Version = "1.0.0"
+
+		report := Compare([]S.AppSnapshot{east, west}, environments)
+
+		Expect(report.Drifted).To(BeTrue())
+		Expect(report.Differences).To(HaveLen(2))
+		Expect(report.Differences[0]).To(Equal(S.DriftDifference{
+			Field: S.DriftArtifactURL,
+			Values: []S.DriftValue{
+				{Environment: "prod", FoundationURL: "https://api.east.example.com", Value: "https://example.com/2.zip"},
+				{Environment: "prod", FoundationURL: "https://api.west.example.com", Value: "https://example.com/1.zip"},
+			},
+		}))
+		Expect(report.Differences[1].Field).To(Equal(S.DriftVersion))
+	})
+
+	It("reports different instance counts", func() {
+		west.Instances = 2
+
+		report := Compare([]S.AppSnapshot{east, west}, environments)
+
+		Expect(report.Differences).To(HaveLen(1))
+		Expect(report.Differences[0].Field).To(Equal(S.DriftInstances))
+		Expect(report.Differences[0].Values[1].Value).To(Equal("2"))
+	})
+
+	It("reports environment variables that are missing or have a different value", func() {
+		west.EnvironmentVariables = map[string]string{"LOG_LEVEL": "sha256:2", "FEATURE_X": "sha256:3"}
+
+		report := Compare([]S.AppSnapshot{east, west}, environments)
+
+		Expect(report.Differences).To(HaveLen(2))
+		Expect(report.Differences[0].Field).To(Equal(S.DriftEnvironmentVariable))
+		Expect(report.Differences[0].Name).To(Equal("FEATURE_X"))
+		Expect(report.Differences[0].Values[0].Value).To(BeEmpty())
+		Expect(report.Differences[1].Name).To(Equal("LOG_LEVEL"))
+	})
+
+	It("compares the routes of different environments without their domain", func() {
+		stage := east
+		stage.Environment = "stage"
+		stage.FoundationURL = "https://api.stage.example.com"
+		stage.Routes = []string{"search.apps.stage.example.com"}
+
+		Expect(Compare([]S.AppSnapshot{east, stage}, environments).Drifted).To(BeFalse())
+
+		stage.Routes = []string{"search.apps.stage.example.com", "search.apps.stage.example.com/v2"}
+		report := Compare([]S.AppSnapshot{east, stage}, environments)
+
+		Expect(report.Differences).To(HaveLen(1))
+		Expect(report.Differences[0].Field).To(Equal(S.DriftRoutes))
+		Expect(report.Differences[0].Values[1].Value).To(Equal("search." + DomainPlaceholder + ", search." + DomainPlaceholder + "/v2"))
+	})
+
+	It("does not compare snapshots that have an error", func() {
+		west = S.AppSnapshot{Environment: "prod", FoundationURL: "https://api.west.example.com", Error: "cannot login"}
+
+		report := Compare([]S.AppSnapshot{east, west}, environments)
+
+		Expect(report.Drifted).To(BeFalse())
+		Expect(report.Snapshots).To(HaveLen(2))
+	})
+})
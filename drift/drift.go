@@ -0,0 +1,76 @@
+// Package drift inspects an application on several foundations and reports how they differ.
+package drift
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+type courierCreator interface {
+	CreateCourier() (I.Courier, error)
+}
+
+// NewInspector returns an Inspector whose couriers are created by courierCreator.
+func NewInspector(courierCreator courierCreator) *Inspector {
+	return &Inspector{CourierCreator: courierCreator}
+}
+
+// Inspector logs into a foundation to take a snapshot of an application.
+type Inspector struct {
+	CourierCreator courierCreator
+}
+
+// Inspect returns the state, instances, routes and environment variables of the application on the foundation.
+// The values of the environment variables are replaced by their digest.
+func (i *Inspector) Inspect(ctx context.Context, environment S.Environment, foundationURL string, auth I.Authorization, org, space, appName string) (S.AppSnapshot, error) {
+	snapshot := S.AppSnapshot{Environment: environment.Name, FoundationURL: foundationURL}
+
+	courier, err := i.CourierCreator.CreateCourier()
+	if err != nil {
+		return snapshot, CourierCreationError{Err: err}
+	}
+	defer courier.CleanUp()
+
+	courier = courier.WithContext(ctx)
+	if environment.CABundle != "" {
+		courier = courier.WithCABundle(environment.CABundle)
+	}
+
+	output, err := courier.Login(foundationURL, auth.Username, auth.Password, org, space, environment.SkipSSL)
+	if err != nil {
+		return snapshot, LoginError{FoundationURL: foundationURL, Out: output}
+	}
+
+	appState, err := courier.AppState(appName)
+	if err != nil {
+		return snapshot, InspectError{FoundationURL: foundationURL, Err: err}
+	}
+	snapshot.State = appState.State
+	snapshot.Instances = appState.Instances
+
+	snapshot.Routes, err = courier.AppRoutes(appName)
+	if err != nil {
+		return snapshot, InspectError{FoundationURL: foundationURL, Err: err}
+	}
+
+	variables, err := courier.AppEnvironment(appName)
+	if err != nil {
+		return snapshot, InspectError{FoundationURL: foundationURL, Err: err}
+	}
+	snapshot.EnvironmentVariables = map[string]string{}
+	for name, value := range variables {
+		snapshot.EnvironmentVariables[name] = digest(value)
+	}
+
+	return snapshot, nil
+}
+
+// digest identifies a value without revealing it.
+func digest(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])[:16]
+}
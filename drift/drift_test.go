@@ -0,0 +1,97 @@
+package drift_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/compozed/deployadactyl/drift"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type courierCreator struct {
+	courier *mocks.Courier
+	err     error
+}
+
+func (c courierCreator) CreateCourier() (I.Courier, error) {
+	return c.courier, c.err
+}
+
+var _ = Describe("Inspector", func() {
+	var (
+		courier     *mocks.Courier
+		inspector   *Inspector
+		environment S.Environment
+		auth        I.Authorization
+	)
+
+	BeforeEach(func() {
+		courier = &mocks.Courier{}
+		courier.AppStateCall.Returns.AppState = S.AppState{State: "STARTED", Instances: 3}
+		courier.AppRoutesCall.Returns.Routes = []string{"search.apps.example.com"}
+		courier.AppEnvironmentCall.Returns.Environment = map[string]string{"DATABASE_PASSWORD": "secret"}
+
+		inspector = NewInspector(courierCreator{courier: courier})
+		environment = S.Environment{Name: "prod", SkipSSL: true, CABundle: "/etc/ssl/ca.pem"}
+		auth = I.Authorization{Username: "username", Password: "password"}
+	})
+
+	It("takes a snapshot of the application on the foundation", func() {
+		snapshot, err := inspector.Inspect(context.Background(), environment, "https://api.example.com", auth, "org", "space", "search")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(snapshot.Environment).To(Equal("prod"))
+		Expect(snapshot.FoundationURL).To(Equal("https://api.example.com"))
+		Expect(snapshot.State).To(Equal("STARTED"))
+		Expect(snapshot.Instances).To(Equal(uint16(3)))
+		Expect(snapshot.Routes).To(Equal([]string{"search.apps.example.com"}))
+
+		Expect(courier.LoginCall.Received.FoundationURL).To(Equal("https://api.example.com"))
+		Expect(courier.LoginCall.Received.Username).To(Equal("username"))
+		Expect(courier.LoginCall.Received.Org).To(Equal("org"))
+		Expect(courier.LoginCall.Received.Space).To(Equal("space"))
+		Expect(courier.LoginCall.Received.SkipSSL).To(BeTrue())
+		Expect(courier.WithCABundleCall.Received.Path).To(Equal("/etc/ssl/ca.pem"))
+		Expect(courier.AppStateCall.Received.AppName).To(Equal("search"))
+		Expect(courier.CleanUpCall.Called).To(BeTrue())
+	})
+
+	It("does not reveal the values of the environment variables", func() {
+		snapshot, err := inspector.Inspect(context.Background(), environment, "https://api.example.com", auth, "org", "space", "search")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(snapshot.EnvironmentVariables).To(HaveKey("DATABASE_PASSWORD"))
+		Expect(snapshot.EnvironmentVariables["DATABASE_PASSWORD"]).To(HavePrefix("sha256:"))
+		Expect(snapshot.EnvironmentVariables["DATABASE_PASSWORD"]).ToNot(ContainSubstring("secret"))
+	})
+
+	It("returns an error when it cannot login", func() {
+		courier.LoginCall.Returns.Output = []byte("bad credentials")
+		courier.LoginCall.Returns.Error = errors.New("exit status 1")
+
+		_, err := inspector.Inspect(context.Background(), environment, "https://api.example.com", auth, "org", "space", "search")
+
+		Expect(err).To(MatchError(LoginError{FoundationURL: "https://api.example.com", Out: []byte("bad credentials")}))
+		Expect(courier.CleanUpCall.Called).To(BeTrue())
+	})
+
+	It("returns an error when the application cannot be inspected", func() {
+		courier.AppRoutesCall.Returns.Error = errors.New("app not found")
+
+		_, err := inspector.Inspect(context.Background(), environment, "https://api.example.com", auth, "org", "space", "search")
+
+		Expect(err).To(MatchError(InspectError{FoundationURL: "https://api.example.com", Err: errors.New("app not found")}))
+	})
+
+	It("returns an error when it cannot create a courier", func() {
+		inspector = NewInspector(courierCreator{err: errors.New("no cf cli")})
+
+		_, err := inspector.Inspect(context.Background(), environment, "https://api.example.com", auth, "org", "space", "search")
+
+		Expect(err).To(MatchError(CourierCreationError{Err: errors.New("no cf cli")}))
+	})
+})
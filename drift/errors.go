@@ -0,0 +1,29 @@
+package drift
+
+import "fmt"
+
+type CourierCreationError struct {
+	Err error
+}
+
+func (e CourierCreationError) Error() string {
+	return fmt.Sprintf("cannot create courier: %s", e.Err)
+}
+
+type LoginError struct {
+	FoundationURL string
+	Out           []byte
+}
+
+func (e LoginError) Error() string {
+	return fmt.Sprintf("cannot login to %s: %s", e.FoundationURL, string(e.Out))
+}
+
+type InspectError struct {
+	FoundationURL string
+	Err           error
+}
+
+func (e InspectError) Error() string {
+	return fmt.Sprintf("cannot inspect the application on %s: %s", e.FoundationURL, e.Err)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/onsi/gomega/gbytes"
 	"github.com/op/go-logging"
 
+	"github.com/compozed/deployadactyl/conformance"
 	. "github.com/compozed/deployadactyl/eventmanager"
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/mocks"
@@ -162,4 +163,8 @@ var _ = Describe("Events", func() {
 		})
 
 	})
+
+	It("satisfies the EventManager contract", func() {
+		conformance.EventManager(GinkgoT(), func() I.EventManager { return NewEventManager(log) })
+	})
 })
@@ -3,7 +3,7 @@ package envvar
 import (
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/cloudfoundry-incubator/candiedyaml"
@@ -216,7 +216,7 @@ func (m *Manifest) WriteManifest(destination string, includePrefix bool) error {
 			manifest = fmt.Sprintf("---\n%s", manifest)
 		}
 
-		manifestFile, err := m.FileSystem.OpenFile(path.Join(destination, "manifest.yml"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		manifestFile, err := m.FileSystem.OpenFile(filepath.Join(destination, "manifest.yml"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 
 		if err != nil {
 			return ManifestError{err}
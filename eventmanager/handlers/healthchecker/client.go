@@ -0,0 +1,109 @@
+package healthchecker
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/compozed/deployadactyl/cabundle"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tlspin"
+	"github.com/spf13/afero"
+)
+
+// DefaultTimeout is how long a health check may take when the environment does not configure a timeout.
+const DefaultTimeout = 30 * time.Second
+
+// Client sends health checks with the headers of an environment.
+type Client struct {
+	HTTPClient *http.Client
+	Headers    map[string]string
+}
+
+// Get sends a GET request with the Headers.
+func (c Client) Get(url string) (*http.Response, error) {
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range c.Headers {
+		request.Header.Set(key, value)
+	}
+
+	return c.HTTPClient.Do(request)
+}
+
+// NewClient returns a Client for the health check settings and CA bundle of an environment.
+// Connections to hosts with pins are rejected unless the certificate matches one of them. Requests
+// sent through a proxy are tunnelled by the proxy, so pins are not checked for them.
+func NewClient(fs *afero.Afero, environment S.Environment, pins tlspin.Pins) (I.Client, error) {
+	descriptor := environment.HealthCheck
+
+	tlsConfig, err := cabundle.TLSConfig(fs, environment.CABundle)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{InsecureSkipVerify: !descriptor.VerifySSL}
+	}
+
+	if descriptor.ClientCertificate != "" {
+		certificate, err := loadKeyPair(fs, descriptor.ClientCertificate, descriptor.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
+	if descriptor.Proxy != "" {
+		proxyURL, err := url.Parse(descriptor.Proxy)
+		if err != nil {
+			return nil, ProxyURLError{descriptor.Proxy, err}
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if len(pins) > 0 {
+		transport.DialTLS = pins.DialTLS(tlsConfig)
+	}
+
+	timeout := DefaultTimeout
+	if descriptor.TimeoutSeconds > 0 {
+		timeout = time.Duration(descriptor.TimeoutSeconds) * time.Second
+	}
+
+	httpClient := &http.Client{Transport: transport, Timeout: timeout}
+	if descriptor.DisableRedirects {
+		httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	headers := map[string]string{}
+	for key, value := range descriptor.Headers {
+		headers[key] = os.ExpandEnv(value)
+	}
+
+	return Client{HTTPClient: httpClient, Headers: headers}, nil
+}
+
+func loadKeyPair(fs *afero.Afero, certificatePath, keyPath string) (tls.Certificate, error) {
+	certificate, err := fs.ReadFile(certificatePath)
+	if err != nil {
+		return tls.Certificate{}, ClientCertificateError{certificatePath, err}
+	}
+
+	key, err := fs.ReadFile(keyPath)
+	if err != nil {
+		return tls.Certificate{}, ClientCertificateError{keyPath, err}
+	}
+
+	keyPair, err := tls.X509KeyPair(certificate, key)
+	if err != nil {
+		return tls.Certificate{}, ClientCertificateError{certificatePath, err}
+	}
+	return keyPair, nil
+}
@@ -0,0 +1,108 @@
+package healthchecker_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/compozed/deployadactyl/eventmanager/handlers/healthchecker"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		af       *afero.Afero
+		server   *httptest.Server
+		requests []*http.Request
+	)
+
+	BeforeEach(func() {
+		af = &afero.Afero{Fs: afero.NewMemMapFs()}
+		requests = nil
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			if r.URL.Path == "/redirect" {
+				http.Redirect(w, r, "/login", http.StatusFound)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	get := func(descriptor S.HealthCheckDescriptor, path string) (*http.Response, error) {
+		client, err := NewClient(af, S.Environment{HealthCheck: descriptor}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		return client.Get(server.URL + path)
+	}
+
+	It("sends the headers of the environment, expanding environment variables", func() {
+		os.Setenv("HEALTH_CHECK_TEST_TOKEN", "secret")
+		defer os.Unsetenv("HEALTH_CHECK_TEST_TOKEN")
+
+		resp, err := get(S.HealthCheckDescriptor{Headers: map[string]string{"Authorization": "Bearer ${HEALTH_CHECK_TEST_TOKEN}"}}, "/health")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(requests[0].Header.Get("Authorization")).To(Equal("Bearer secret"))
+	})
+
+	It("follows redirects by default", func() {
+		resp, err := get(S.HealthCheckDescriptor{}, "/redirect")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(requests).To(HaveLen(2))
+	})
+
+	It("returns the redirect when redirects are disabled", func() {
+		resp, err := get(S.HealthCheckDescriptor{DisableRedirects: true}, "/redirect")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(resp.StatusCode).To(Equal(http.StatusFound))
+		Expect(requests).To(HaveLen(1))
+	})
+
+	It("verifies certificates when verify_ssl is set", func() {
+		_, err := get(S.HealthCheckDescriptor{VerifySSL: true}, "/health")
+
+		Expect(err).To(HaveOccurred())
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("sends requests through the proxy", func() {
+		var proxied *http.Request
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = r
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer proxy.Close()
+
+		client, err := NewClient(af, S.Environment{HealthCheck: S.HealthCheckDescriptor{Proxy: proxy.URL}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.Get("http://my-app.apps.example.com/health")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(proxied.Host).To(Equal("my-app.apps.example.com"))
+	})
+
+	It("returns an error when the client certificate can not be read", func() {
+		_, err := NewClient(af, S.Environment{HealthCheck: S.HealthCheckDescriptor{ClientCertificate: "/missing.pem", ClientKey: "/missing.key"}}, nil)
+
+		Expect(err).To(BeAssignableToTypeOf(ClientCertificateError{}))
+	})
+
+	It("returns an error when the CA bundle can not be read", func() {
+		_, err := NewClient(af, S.Environment{CABundle: "/missing.pem"}, nil)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
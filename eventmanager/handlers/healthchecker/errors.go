@@ -64,3 +64,21 @@ type WrongEventTypeError struct {
 func (e WrongEventTypeError) Error() string {
 	return fmt.Sprintf("wrong event type for healthchecker: %s", e.Type)
 }
+
+type ProxyURLError struct {
+	URL string
+	Err error
+}
+
+func (e ProxyURLError) Error() string {
+	return fmt.Sprintf("invalid health check proxy %s: %s", e.URL, e.Err)
+}
+
+type ClientCertificateError struct {
+	Path string
+	Err  error
+}
+
+func (e ClientCertificateError) Error() string {
+	return fmt.Sprintf("cannot load health check client certificate %s: %s", e.Path, e.Err)
+}
@@ -6,9 +6,11 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
 )
 
 // HealthChecker will check an endpoint for a http.StatusOK
@@ -28,6 +30,9 @@ type HealthChecker struct {
 
 	Client  I.Client
 	Courier I.Courier
+
+	// Clients are used instead of Client for the environments they are keyed by.
+	Clients map[string]I.Client
 }
 
 func (h HealthChecker) PushFinishedEventHandler(event push.PushFinishedEvent) error {
@@ -42,16 +47,13 @@ func (h HealthChecker) PushFinishedEventHandler(event push.PushFinishedEvent) er
 	}
 
 	h.Courier = event.Courier
+	if client, ok := h.Clients[event.CFContext.Environment]; ok {
+		h.Client = client
+	}
 
 	event.Log.Debugf("starting health check")
 
-	if event.CFContext.Environment != h.SilentDeployEnvironment {
-		newFoundationURL = strings.Replace(event.FoundationURL, h.OldURL, h.NewURL, 1)
-		domain = regexp.MustCompile(fmt.Sprintf("%s.*", h.NewURL)).FindString(newFoundationURL)
-	} else {
-		newFoundationURL = strings.Replace(event.FoundationURL, h.OldURL, h.SilentDeployURL, 1)
-		domain = regexp.MustCompile(fmt.Sprintf("%s.*", h.SilentDeployURL)).FindString(newFoundationURL)
-	}
+	newFoundationURL, domain = h.appsURL(event.CFContext.Environment, event.FoundationURL)
 
 	err := h.mapTemporaryRoute(event.TempAppWithUUID, domain, event.Log)
 	if err != nil {
@@ -64,12 +66,65 @@ func (h HealthChecker) PushFinishedEventHandler(event push.PushFinishedEvent) er
 
 	newFoundationURL = strings.Replace(newFoundationURL, h.NewURL, fmt.Sprintf("%s.%s", event.TempAppWithUUID, h.NewURL), 1)
 
-	return h.Check(newFoundationURL, event.HealthCheckEndpoint, event.Log)
+	started := time.Now()
+	statusCode, err := h.check(newFoundationURL, event.HealthCheckEndpoint, event.Log)
+
+	if event.HealthChecks != nil {
+		result := S.HealthCheckResult{
+			FoundationURL: event.FoundationURL,
+			URL:           fmt.Sprintf("%s/%s", newFoundationURL, strings.TrimPrefix(event.HealthCheckEndpoint, "/")),
+			StatusCode:    statusCode,
+			Duration:      time.Since(started),
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		event.HealthChecks.Add(result)
+	}
+
+	return err
+}
+
+// CheckApplication checks the health endpoint of an application on the default route of a foundation.
+func (h HealthChecker) CheckApplication(environment, foundationURL, appName, endpoint string, log I.DeploymentLogger) error {
+	if client, ok := h.Clients[environment]; ok {
+		h.Client = client
+	}
+
+	appsDomain := h.appsDomain(environment)
+	appsURL, _ := h.appsURL(environment, foundationURL)
+	appURL := strings.Replace(appsURL, appsDomain, fmt.Sprintf("%s.%s", appName, appsDomain), 1)
+
+	return h.Check(appURL, endpoint, log)
+}
+
+// appsDomain returns the prefix of the apps domain that replaces OldURL for an environment.
+func (h HealthChecker) appsDomain(environment string) string {
+	if environment == h.SilentDeployEnvironment {
+		return h.SilentDeployURL
+	}
+	return h.NewURL
+}
+
+// appsURL returns the url of the apps domain of a foundation, and the apps domain itself.
+func (h HealthChecker) appsURL(environment, foundationURL string) (string, string) {
+	appsDomain := h.appsDomain(environment)
+
+	appsURL := strings.Replace(foundationURL, h.OldURL, appsDomain, 1)
+	domain := regexp.MustCompile(fmt.Sprintf("%s.*", appsDomain)).FindString(appsURL)
+
+	return appsURL, domain
 }
 
 // Check takes a url and endpoint. It does an http.Get to get the response
 // status and returns an error if it is not http.StatusOK.
 func (h HealthChecker) Check(url, endpoint string, log I.DeploymentLogger) error {
+	_, err := h.check(url, endpoint, log)
+	return err
+}
+
+// check returns the status code of the response, or 0 if there was none.
+func (h HealthChecker) check(url, endpoint string, log I.DeploymentLogger) (int, error) {
 	trimmedEndpoint := strings.TrimPrefix(endpoint, "/")
 
 	log.Debugf("checking route %s%s", url, endpoint)
@@ -77,17 +132,20 @@ func (h HealthChecker) Check(url, endpoint string, log I.DeploymentLogger) error
 	resp, err := h.Client.Get(fmt.Sprintf("%s/%s", url, trimmedEndpoint))
 	if err != nil {
 		log.Error(ClientError{err})
-		return ClientError{err}
+		return 0, ClientError{err}
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := ioutil.ReadAll(resp.Body)
 		log.Errorf("health check failed for %s/%s", url, trimmedEndpoint)
-		return HealthCheckError{resp.StatusCode, endpoint, body}
+		return resp.StatusCode, HealthCheckError{resp.StatusCode, endpoint, body}
 	}
 
 	log.Infof("health check successful for %s%s", url, endpoint)
-	return nil
+	return resp.StatusCode, nil
 }
 
 func (h HealthChecker) mapTemporaryRoute(tempAppWithUUID, domain string, log I.DeploymentLogger) error {
@@ -15,6 +15,7 @@ import (
 
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
 	"github.com/op/go-logging"
 )
 
@@ -102,6 +103,30 @@ var _ = Describe("Healthchecker", func() {
 					Expect(err).ToNot(HaveOccurred())
 				})
 
+				It("uses the client of the environment when there is one", func() {
+					environmentClient := &mocks.Client{}
+					environmentClient.GetCall.Returns.Response = http.Response{StatusCode: http.StatusOK}
+					healthchecker.Clients = map[string]I.Client{randomEnvironment: environmentClient}
+
+					Expect(healthchecker.PushFinishedEventHandler(ievent)).To(Succeed())
+
+					Expect(environmentClient.GetCall.Received.URL).To(ContainSubstring(randomEndpoint))
+					Expect(client.GetCall.Received.URL).To(BeEmpty())
+				})
+
+				It("records the result in the health check report", func() {
+					ievent.HealthChecks = &S.HealthCheckReport{}
+
+					Expect(healthchecker.PushFinishedEventHandler(ievent)).To(Succeed())
+
+					results := ievent.HealthChecks.Results()
+					Expect(results).To(HaveLen(1))
+					Expect(results[0].FoundationURL).To(Equal(randomFoundationURL))
+					Expect(results[0].StatusCode).To(Equal(http.StatusOK))
+					Expect(results[0].URL).To(HaveSuffix(randomEndpoint))
+					Expect(results[0].Passed()).To(BeTrue())
+				})
+
 				It("maps a new temporary route", func() {
 					healthchecker.PushFinishedEventHandler(ievent)
 
@@ -192,6 +217,18 @@ var _ = Describe("Healthchecker", func() {
 					Expect(err).To(MatchError(HealthCheckError{http.StatusNotFound, randomEndpoint, body}))
 				})
 
+				It("records the failure in the health check report", func() {
+					ievent.HealthChecks = &S.HealthCheckReport{}
+
+					err := healthchecker.PushFinishedEventHandler(ievent)
+
+					results := ievent.HealthChecks.Results()
+					Expect(results).To(HaveLen(1))
+					Expect(results[0].StatusCode).To(Equal(http.StatusNotFound))
+					Expect(results[0].Error).To(Equal(err.Error()))
+					Expect(results[0].Passed()).To(BeFalse())
+				})
+
 				It("prints the endpoint error to the console", func() {
 					healthchecker.PushFinishedEventHandler(ievent)
 
@@ -300,4 +337,23 @@ var _ = Describe("Healthchecker", func() {
 			})
 		})
 	})
+
+	Describe("CheckApplication", func() {
+		It("checks the endpoint on the default route of the application", func() {
+			log := I.DeploymentLogger{Log: I.DefaultLogger(logBuffer, logging.DEBUG, "healthchecker_test")}
+
+			Expect(healthchecker.CheckApplication(randomEnvironment, randomFoundationURL, randomAppName, randomEndpoint, log)).To(Succeed())
+
+			Expect(client.GetCall.Received.URL).To(Equal(fmt.Sprintf("https://%s.%s%s", randomAppName, randomDomain, randomEndpoint)))
+		})
+
+		It("returns an error when the application is not healthy", func() {
+			client.GetCall.Returns.Response = http.Response{StatusCode: http.StatusServiceUnavailable, Body: NewBuffer()}
+			log := I.DeploymentLogger{Log: I.DefaultLogger(logBuffer, logging.DEBUG, "healthchecker_test")}
+
+			err := healthchecker.CheckApplication(randomEnvironment, randomFoundationURL, randomAppName, randomEndpoint, log)
+
+			Expect(err).To(MatchError(HealthCheckError{http.StatusServiceUnavailable, randomEndpoint, []byte{}}))
+		})
+	})
 })
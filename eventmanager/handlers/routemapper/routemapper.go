@@ -1,6 +1,7 @@
 package routemapper
 
 import (
+	"path/filepath"
 	"strings"
 
 	"github.com/cloudfoundry-incubator/candiedyaml"
@@ -78,7 +79,7 @@ func (r RouteMapper) readManifest(manifest, appPath string, log I.DeploymentLogg
 		manifestBytes = []byte(manifest)
 		return manifestBytes, nil
 	} else if appPath != "" {
-		manifestBytes, err = r.FileSystem.ReadFile(appPath + "/manifest.yml")
+		manifestBytes, err = r.FileSystem.ReadFile(filepath.Join(appPath, "manifest.yml"))
 		if err != nil {
 			log.Errorf("failed to read manifest file: %s", err.Error())
 			return nil, ReadFileError{err}
@@ -0,0 +1,16 @@
+package slack
+
+import "fmt"
+
+type PostError struct {
+	StatusCode int
+	Reply      string
+	Err        error
+}
+
+func (e PostError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("cannot post the message: %s", e.Err)
+	}
+	return fmt.Sprintf("the message was rejected with status %d: %s", e.StatusCode, e.Reply)
+}
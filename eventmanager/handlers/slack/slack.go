@@ -0,0 +1,225 @@
+// Package slack posts a message to a Slack incoming webhook when a deployment starts, succeeds or fails, so teams do
+// not have to write an event handler of their own to follow their deployments.
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DefaultTimeout is how long a post may take when the configuration has no timeout.
+const DefaultTimeout = 10 * time.Second
+
+// QueueSize is how many messages can wait to be posted. Further messages are dropped while the queue is full, so a
+// slow or unreachable Slack never holds up a deployment.
+const QueueSize = 100
+
+// The colors of the messages.
+const (
+	startedColor   = "#439FE0"
+	succeededColor = "good"
+	failedColor    = "danger"
+)
+
+// NewNotifier returns a Notifier posting to the webhook of the descriptor, with the summary of the errors the
+// error finder finds in the errors of the failed deployments. It is nil without a webhook URL.
+func NewNotifier(descriptor S.SlackDescriptor, errorFinder I.ErrorFinder, log I.Logger) *Notifier {
+	if descriptor.WebhookURL == "" {
+		return nil
+	}
+
+	timeout := DefaultTimeout
+	if descriptor.TimeoutSeconds > 0 {
+		timeout = time.Duration(descriptor.TimeoutSeconds) * time.Second
+	}
+
+	return &Notifier{
+		Descriptor:  descriptor,
+		ErrorFinder: errorFinder,
+		Log:         log,
+		Client:      &http.Client{Timeout: timeout},
+		queue:       make(chan Message, QueueSize),
+	}
+}
+
+// Notifier queues a message when a deployment starts, succeeds or fails, and posts them to Slack one after the
+// other, so the messages of a deployment stay in order.
+type Notifier struct {
+	Descriptor  S.SlackDescriptor
+	ErrorFinder I.ErrorFinder
+	Log         I.Logger
+	Client      *http.Client
+
+	queue chan Message
+}
+
+// Message is the payload of a Slack incoming webhook.
+type Message struct {
+	Channel     string       `json:"channel,omitempty"`
+	Username    string       `json:"username,omitempty"`
+	IconEmoji   string       `json:"icon_emoji,omitempty"`
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is the colored part of a Message with the fields of the deployment.
+type Attachment struct {
+	Color    string  `json:"color"`
+	Fallback string  `json:"fallback"`
+	Fields   []Field `json:"fields"`
+}
+
+// Field is a title and value of an Attachment.
+type Field struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// DeployStartedEventHandler posts that the deployment started.
+func (n *Notifier) DeployStartedEventHandler(event push.DeployStartedEvent) error {
+	n.notify(event.CFContext, event.Log.UUID, event.Auth.User(), startedColor, "started", nil)
+	return nil
+}
+
+// DeploySuccessEventHandler posts that the deployment succeeded.
+func (n *Notifier) DeploySuccessEventHandler(event push.DeploySuccessEvent) error {
+	n.notify(event.CFContext, event.Log.UUID, event.Auth.User(), succeededColor, "succeeded", nil)
+	return nil
+}
+
+// DeployFailureEventHandler posts that the deployment failed, with the summary of its error.
+func (n *Notifier) DeployFailureEventHandler(event push.DeployFailureEvent) error {
+	n.notify(event.CFContext, event.Log.UUID, event.Auth.User(), failedColor, "failed", event.Error)
+	return nil
+}
+
+// Run posts the queued messages, one after the other, forever. Messages that cannot be posted are logged and
+// dropped.
+func (n *Notifier) Run() {
+	for message := range n.queue {
+		err := n.Post(message)
+		if err != nil {
+			n.Log.Errorf("cannot post to slack: %s", err)
+		}
+	}
+}
+
+// Post posts the message to the webhook.
+func (n *Notifier) Post(message Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	response, err := n.Client.Post(n.Descriptor.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The webhook URL is a credential, so it is left out of the error.
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return PostError{Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		reply, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		return PostError{StatusCode: response.StatusCode, Reply: string(bytes.TrimSpace(reply))}
+	}
+	io.Copy(ioutil.Discard, response.Body)
+	return nil
+}
+
+func (n *Notifier) notify(cf I.CFContext, uuid, user, color, outcome string, deployErr error) {
+	if !n.notifies(cf.Environment) {
+		return
+	}
+
+	summary := fmt.Sprintf("Deployment of %s to %s %s", cf.Application, cf.Environment, outcome)
+	fields := []Field{
+		{Title: "Application", Value: cf.Application, Short: true},
+		{Title: "Environment", Value: cf.Environment, Short: true},
+		{Title: "Org / Space", Value: cf.Organization + " / " + cf.Space, Short: true},
+		{Title: "User", Value: user, Short: true},
+		{Title: "Deployment", Value: uuid},
+	}
+	if deployErr != nil {
+		fields = append(fields, n.errorFields(deployErr)...)
+	}
+
+	message := Message{
+		Channel:     n.Descriptor.Channel,
+		Username:    n.Descriptor.Username,
+		IconEmoji:   n.Descriptor.IconEmoji,
+		Text:        fmt.Sprintf("Deployment of *%s* to *%s* %s", escape(cf.Application), escape(cf.Environment), outcome),
+		Attachments: []Attachment{{Color: color, Fallback: summary, Fields: escapeFields(fields)}},
+	}
+
+	select {
+	case n.queue <- message:
+	default:
+		n.Log.Errorf("slack is %d messages behind: dropping the message of deployment %s", QueueSize, uuid)
+	}
+}
+
+// errorFields returns the error of a failed deployment, with the details and solution of the error the error finder
+// matched in its output, or in the error itself.
+func (n *Notifier) errorFields(deployErr error) []Field {
+	matched, ok := deployErr.(I.LogMatchedError)
+	if !ok && n.ErrorFinder != nil {
+		if found := n.ErrorFinder.FindErrors(deployErr.Error()); len(found) > 0 {
+			matched, ok = found[0], true
+		}
+	}
+
+	fields := []Field{{Title: "Error", Value: deployErr.Error()}}
+	if !ok {
+		return fields
+	}
+
+	if details := matched.Details(); len(details) > 0 && details[0] != "" {
+		fields = append(fields, Field{Title: "Details", Value: details[0]})
+	}
+	if solution := matched.Solution(); solution != "" {
+		fields = append(fields, Field{Title: "Potential Solution", Value: solution})
+	}
+	return fields
+}
+
+// notifies reports whether the deployments to the environment are posted.
+func (n *Notifier) notifies(environment string) bool {
+	if len(n.Descriptor.Environments) == 0 {
+		return true
+	}
+	for _, e := range n.Descriptor.Environments {
+		if strings.EqualFold(e, environment) {
+			return true
+		}
+	}
+	return false
+}
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// escape escapes the control characters of Slack messages.
+func escape(text string) string {
+	return escaper.Replace(text)
+}
+
+func escapeFields(fields []Field) []Field {
+	for i := range fields {
+		fields[i].Value = escape(fields[i].Value)
+	}
+	return fields
+}
@@ -0,0 +1,156 @@
+package slack_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/compozed/deployadactyl/controller/deployer/error_finder"
+	. "github.com/compozed/deployadactyl/eventmanager/handlers/slack"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Notifier", func() {
+	var (
+		server      *httptest.Server
+		messages    chan Message
+		status      int
+		errorFinder *mocks.ErrorFinder
+		logBuffer   *Buffer
+		notifier    *Notifier
+		cf          I.CFContext
+		log         I.DeploymentLogger
+	)
+
+	BeforeEach(func() {
+		messages = make(chan Message, 10)
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var message Message
+			body, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(body, &message)
+			messages <- message
+			w.WriteHeader(status)
+			w.Write([]byte("invalid_payload"))
+		}))
+
+		errorFinder = &mocks.ErrorFinder{}
+		logBuffer = NewBuffer()
+		logger := I.DefaultLogger(logBuffer, logging.DEBUG, "slack_test")
+		notifier = NewNotifier(S.SlackDescriptor{WebhookURL: server.URL + "/services/T000/B000/XXXX", Channel: "#deployments"}, errorFinder, logger)
+		go notifier.Run()
+
+		cf = I.CFContext{Environment: "production", Organization: "my-org", Space: "my-space", Application: "my-app"}
+		log = I.DeploymentLogger{Log: logger, UUID: "the-uuid"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("is nil without a webhook url", func() {
+		Expect(NewNotifier(S.SlackDescriptor{}, errorFinder, I.DefaultLogger(logBuffer, logging.DEBUG, "slack_test"))).To(BeNil())
+	})
+
+	It("posts that a deployment started", func() {
+		Expect(notifier.DeployStartedEventHandler(push.DeployStartedEvent{CFContext: cf, Auth: I.Authorization{Username: "jane"}, Log: log})).To(Succeed())
+
+		var message Message
+		Eventually(messages).Should(Receive(&message))
+		Expect(message.Channel).To(Equal("#deployments"))
+		Expect(message.Text).To(Equal("Deployment of *my-app* to *production* started"))
+		Expect(message.Attachments).To(HaveLen(1))
+		Expect(message.Attachments[0].Color).To(Equal("#439FE0"))
+		Expect(message.Attachments[0].Fields).To(Equal([]Field{
+			{Title: "Application", Value: "my-app", Short: true},
+			{Title: "Environment", Value: "production", Short: true},
+			{Title: "Org / Space", Value: "my-org / my-space", Short: true},
+			{Title: "User", Value: "jane", Short: true},
+			{Title: "Deployment", Value: "the-uuid"},
+		}))
+	})
+
+	It("posts that a deployment succeeded", func() {
+		Expect(notifier.DeploySuccessEventHandler(push.DeploySuccessEvent{CFContext: cf, Log: log})).To(Succeed())
+
+		var message Message
+		Eventually(messages).Should(Receive(&message))
+		Expect(message.Text).To(Equal("Deployment of *my-app* to *production* succeeded"))
+		Expect(message.Attachments[0].Color).To(Equal("good"))
+	})
+
+	It("posts the error of a failed deployment with the summary of the error finder", func() {
+		matched := error_finder.CreateLogMatchedError("insufficient resources", []string{"memory quota exceeded"}, "request more memory from your admin", "insufficient-resources")
+
+		Expect(notifier.DeployFailureEventHandler(push.DeployFailureEvent{CFContext: cf, Log: log, Error: matched})).To(Succeed())
+
+		var message Message
+		Eventually(messages).Should(Receive(&message))
+		Expect(message.Text).To(Equal("Deployment of *my-app* to *production* failed"))
+		Expect(message.Attachments[0].Color).To(Equal("danger"))
+		Expect(message.Attachments[0].Fields).To(ContainElement(Field{Title: "Error", Value: "insufficient resources"}))
+		Expect(message.Attachments[0].Fields).To(ContainElement(Field{Title: "Details", Value: "memory quota exceeded"}))
+		Expect(message.Attachments[0].Fields).To(ContainElement(Field{Title: "Potential Solution", Value: "request more memory from your admin"}))
+	})
+
+	It("finds the errors of an error that was not matched", func() {
+		errorFinder.FindErrorsCall.Returns.Errors = []I.LogMatchedError{
+			error_finder.CreateLogMatchedError("no route", []string{"the route is taken"}, "use another hostname", "route"),
+		}
+
+		Expect(notifier.DeployFailureEventHandler(push.DeployFailureEvent{CFContext: cf, Log: log, Error: errors.New("push failed: route taken")})).To(Succeed())
+
+		var message Message
+		Eventually(messages).Should(Receive(&message))
+		Expect(errorFinder.FindErrorsCall.Received.Response).To(Equal("push failed: route taken"))
+		Expect(message.Attachments[0].Fields).To(ContainElement(Field{Title: "Error", Value: "push failed: route taken"}))
+		Expect(message.Attachments[0].Fields).To(ContainElement(Field{Title: "Potential Solution", Value: "use another hostname"}))
+	})
+
+	It("escapes the control characters of slack", func() {
+		Expect(notifier.DeployFailureEventHandler(push.DeployFailureEvent{CFContext: cf, Log: log, Error: errors.New("<html> & friends")})).To(Succeed())
+
+		var message Message
+		Eventually(messages).Should(Receive(&message))
+		Expect(message.Attachments[0].Fields).To(ContainElement(Field{Title: "Error", Value: "&lt;html&gt; &amp; friends"}))
+	})
+
+	It("only posts the deployments to the environments", func() {
+		notifier.Descriptor.Environments = []string{"Staging"}
+
+		notifier.DeployStartedEventHandler(push.DeployStartedEvent{CFContext: cf, Log: log})
+		cf.Environment = "staging"
+		notifier.DeployStartedEventHandler(push.DeployStartedEvent{CFContext: cf, Log: log})
+
+		var message Message
+		Eventually(messages).Should(Receive(&message))
+		Expect(message.Text).To(ContainSubstring("to *staging*"))
+		Consistently(messages).ShouldNot(Receive())
+	})
+
+	It("logs the messages slack rejects", func() {
+		status = http.StatusBadRequest
+
+		Expect(notifier.DeploySuccessEventHandler(push.DeploySuccessEvent{CFContext: cf, Log: log})).To(Succeed())
+
+		Eventually(logBuffer).Should(Say("cannot post to slack: the message was rejected with status 400: invalid_payload"))
+	})
+
+	It("does not put the webhook url in the errors", func() {
+		server.Close()
+
+		err := notifier.Post(Message{Text: "hello"})
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).ToNot(ContainSubstring("XXXX"))
+	})
+})
@@ -0,0 +1,205 @@
+package eventmanager
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// StreamSubscriberBuffer is how many events a subscriber can fall behind. Further events are dropped for it until
+// it catches up, so a slow subscriber never holds up a deployment.
+const StreamSubscriberBuffer = 64
+
+// EventsChannel is the channel of the shared state the streamed events are published on.
+const EventsChannel = "events"
+
+// NewStream returns a Stream without subscribers.
+func NewStream() *Stream {
+	return &Stream{
+		Now:         time.Now,
+		subscribers: map[chan S.StreamedEvent]func(S.StreamedEvent) bool{},
+	}
+}
+
+// Stream is a binding that accepts every event and passes a StreamedEvent summarizing it to the subscribers that
+// accept it. Once it is shared, the subscribers receive the events of every instance of the server.
+type Stream struct {
+	Now func() time.Time
+
+	mu          sync.Mutex
+	subscribers map[chan S.StreamedEvent]func(S.StreamedEvent) bool
+	shared      I.SharedState
+	log         I.Logger
+}
+
+// Share publishes the events emitted from now on to the shared state instead of passing them to the subscribers,
+// and passes the events published by every instance to the subscribers.
+func (s *Stream) Share(state I.SharedState, log I.Logger) {
+	messages, _ := state.Subscribe(EventsChannel)
+
+	s.mu.Lock()
+	s.shared = state
+	s.log = log
+	s.mu.Unlock()
+
+	go func() {
+		for message := range messages {
+			streamed := S.StreamedEvent{}
+			if err := json.Unmarshal(message, &streamed); err != nil {
+				log.Errorf("cannot read a shared event: %s", err)
+				continue
+			}
+			s.deliver(streamed)
+		}
+	}()
+}
+
+// Subscribe returns the events accepted by accept, from now on, until unsubscribe is called.
+func (s *Stream) Subscribe(accept func(S.StreamedEvent) bool) (<-chan S.StreamedEvent, func()) {
+	events := make(chan S.StreamedEvent, StreamSubscriberBuffer)
+
+	s.mu.Lock()
+	s.subscribers[events] = accept
+	s.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subscribers, events)
+			s.mu.Unlock()
+			close(events)
+		})
+	}
+	return events, unsubscribe
+}
+
+func (s *Stream) Accepts(event interface{}) bool {
+	return true
+}
+
+// Emit passes the summary of the event to the subscribers, or publishes it to the shared state. It never fails, so
+// the stream cannot fail a deployment.
+func (s *Stream) Emit(event interface{}) error {
+	s.mu.Lock()
+	shared, log, idle := s.shared, s.log, len(s.subscribers) == 0
+	s.mu.Unlock()
+
+	if shared == nil && idle {
+		return nil
+	}
+
+	streamed := Summarize(event)
+	streamed.Time = s.Now()
+
+	if shared == nil {
+		s.deliver(streamed)
+		return nil
+	}
+
+	data, err := json.Marshal(streamed)
+	if err == nil {
+		err = shared.Publish(EventsChannel, data)
+	}
+	if err != nil {
+		log.Errorf("cannot share a %s: %s", streamed.Type, err)
+	}
+	return nil
+}
+
+// deliver passes the event to the subscribers that accept it and have not fallen behind.
+func (s *Stream) deliver(streamed S.StreamedEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for subscriber, accept := range s.subscribers {
+		if !accept(streamed) {
+			continue
+		}
+
+		select {
+		case subscriber <- streamed:
+		default:
+		}
+	}
+}
+
+// Summarize returns the type of the event and what it tells about its deployment, read from its fields or from
+// those of the Data of a legacy Event. The time is left to the caller.
+func Summarize(event interface{}) S.StreamedEvent {
+	streamed := S.StreamedEvent{}
+	if named, ok := event.(I.IEvent); ok {
+		streamed.Type = named.Name()
+	}
+
+	if legacy, ok := event.(I.Event); ok {
+		if legacy.Error != nil {
+			streamed.Error = legacy.Error.Error()
+		}
+		event = legacy.Data
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(event))
+	if !value.IsValid() || value.Kind() != reflect.Struct {
+		return streamed
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if !field.CanInterface() {
+			continue
+		}
+
+		switch f := field.Interface().(type) {
+		case I.CFContext:
+			fill(&streamed.Environment, f.Environment)
+			fill(&streamed.Org, f.Organization)
+			fill(&streamed.Space, f.Space)
+			fill(&streamed.AppName, f.Application)
+		case *S.DeploymentInfo:
+			if f != nil {
+				fill(&streamed.UUID, f.UUID)
+				fill(&streamed.Environment, f.Environment)
+				fill(&streamed.Org, f.Org)
+				fill(&streamed.Space, f.Space)
+				fill(&streamed.AppName, f.AppName)
+				fill(&streamed.ArtifactURL, f.ArtifactURL)
+			}
+		case S.DeploymentRecord:
+			fill(&streamed.UUID, f.UUID)
+			fill(&streamed.Environment, f.Environment)
+			fill(&streamed.Org, f.Org)
+			fill(&streamed.Space, f.Space)
+			fill(&streamed.AppName, f.AppName)
+		case I.DeploymentLogger:
+			fill(&streamed.UUID, f.UUID)
+		case S.Environment:
+			fill(&streamed.Environment, f.Name)
+		case error:
+			if f != nil {
+				fill(&streamed.Error, f.Error())
+			}
+		case string:
+			switch value.Type().Field(i).Name {
+			case "FoundationURL":
+				fill(&streamed.FoundationURL, f)
+			case "ArtifactURL":
+				fill(&streamed.ArtifactURL, f)
+			case "AppName":
+				fill(&streamed.AppName, f)
+			}
+		}
+	}
+	return streamed
+}
+
+// fill sets the field to the value unless it is already set.
+func fill(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
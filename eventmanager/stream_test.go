@@ -0,0 +1,165 @@
+package eventmanager_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/compozed/deployadactyl/eventmanager"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/sharedstate"
+	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Stream", func() {
+	var (
+		stream *Stream
+		now    time.Time
+	)
+
+	all := func(S.StreamedEvent) bool { return true }
+
+	BeforeEach(func() {
+		now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+		stream = NewStream()
+		stream.Now = func() time.Time { return now }
+	})
+
+	It("accepts every event", func() {
+		Expect(stream.Accepts(push.DeployStartedEvent{})).To(BeTrue())
+		Expect(stream.Accepts(nil)).To(BeTrue())
+	})
+
+	It("streams a summary of the events without their credentials", func() {
+		events, unsubscribe := stream.Subscribe(all)
+		defer unsubscribe()
+
+		Expect(stream.Emit(push.DeployFailureEvent{
+			CFContext:   I.CFContext{Environment: "prod", Organization: "org", Space: "space", Application: "search"},
+			Auth:        I.Authorization{Username: "username", Password: "password"},
+			Environment: S.Environment{Name: "prod", Password: "tenant-password"},
+			Error:       errors.New("push failed"),
+			Log:         I.DeploymentLogger{UUID: "1234"},
+		})).To(Succeed())
+
+		Expect(<-events).To(Equal(S.StreamedEvent{
+			Type:        "DeployFailureEvent",
+			Time:        now,
+			UUID:        "1234",
+			Environment: "prod",
+			Org:         "org",
+			Space:       "space",
+			AppName:     "search",
+			Error:       "push failed",
+		}))
+	})
+
+	It("reads the foundation of events about one foundation", func() {
+		events, unsubscribe := stream.Subscribe(all)
+		defer unsubscribe()
+
+		stream.Emit(push.DeploymentReconciledEvent{
+			Record:        S.DeploymentRecord{UUID: "1234", Environment: "prod", AppName: "search"},
+			FoundationURL: "https://api.east.example.com",
+		})
+
+		event := <-events
+		Expect(event.UUID).To(Equal("1234"))
+		Expect(event.AppName).To(Equal("search"))
+		Expect(event.FoundationURL).To(Equal("https://api.east.example.com"))
+	})
+
+	It("reads the deployment of the data of legacy events", func() {
+		events, unsubscribe := stream.Subscribe(all)
+		defer unsubscribe()
+
+		stream.Emit(I.Event{
+			Type:  "deploy.error",
+			Data:  S.DeployEventData{DeploymentInfo: &S.DeploymentInfo{UUID: "1234", Environment: "prod", ArtifactURL: "https://example.com/search.zip"}},
+			Error: errors.New("push failed"),
+		})
+
+		event := <-events
+		Expect(event.Type).To(Equal("deploy.error"))
+		Expect(event.UUID).To(Equal("1234"))
+		Expect(event.ArtifactURL).To(Equal("https://example.com/search.zip"))
+		Expect(event.Error).To(Equal("push failed"))
+	})
+
+	It("only streams the events a subscriber accepts", func() {
+		events, unsubscribe := stream.Subscribe(func(event S.StreamedEvent) bool {
+			return event.Environment == "prod"
+		})
+		defer unsubscribe()
+
+		stream.Emit(push.DeployStartedEvent{CFContext: I.CFContext{Environment: "stage"}})
+		stream.Emit(push.DeployStartedEvent{CFContext: I.CFContext{Environment: "prod"}})
+
+		Expect((<-events).Environment).To(Equal("prod"))
+		Consistently(events).ShouldNot(Receive())
+	})
+
+	It("drops the events of a subscriber that fell behind instead of blocking", func() {
+		events, unsubscribe := stream.Subscribe(all)
+		defer unsubscribe()
+
+		for i := 0; i < StreamSubscriberBuffer+10; i++ {
+			Expect(stream.Emit(push.DeployStartedEvent{})).To(Succeed())
+		}
+
+		Expect(events).To(HaveLen(StreamSubscriberBuffer))
+	})
+
+	It("ends the subscription when it is unsubscribed", func() {
+		events, unsubscribe := stream.Subscribe(all)
+		unsubscribe()
+		unsubscribe()
+
+		Expect(stream.Emit(push.DeployStartedEvent{})).To(Succeed())
+		Eventually(events).Should(BeClosed())
+	})
+})
+
+var _ = Describe("Shared Stream", func() {
+	var (
+		state     *sharedstate.Memory
+		emitting  *Stream
+		streaming *Stream
+	)
+
+	all := func(S.StreamedEvent) bool { return true }
+
+	BeforeEach(func() {
+		state = sharedstate.NewMemory()
+		log := I.DefaultLogger(NewBuffer(), logging.DEBUG, "stream_test")
+
+		emitting = NewStream()
+		emitting.Share(state, log)
+		streaming = NewStream()
+		streaming.Share(state, log)
+	})
+
+	It("streams the events emitted by every instance", func() {
+		events, unsubscribe := streaming.Subscribe(all)
+		defer unsubscribe()
+
+		Expect(emitting.Emit(push.DeployStartedEvent{Log: I.DeploymentLogger{UUID: "1234"}})).To(Succeed())
+
+		Eventually(events).Should(Receive(WithTransform(func(e S.StreamedEvent) string { return e.UUID }, Equal("1234"))))
+	})
+
+	It("streams the events emitted by its own instance once", func() {
+		events, unsubscribe := emitting.Subscribe(all)
+		defer unsubscribe()
+
+		Expect(emitting.Emit(push.DeployStartedEvent{})).To(Succeed())
+
+		Eventually(events).Should(Receive())
+		Consistently(events).ShouldNot(Receive())
+	})
+})
@@ -0,0 +1,74 @@
+package eventmanager
+
+import (
+	"reflect"
+	"strings"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// NewTenantBinding returns a binding that only emits the events accepted by the binding that are about one of the
+// environments of the tenant, so the event handlers of a tenant never see the deployments of the others.
+//
+// The environment of an event is read from its CFContext, DeploymentInfo or Environment, or from those of the Data
+// of a legacy Event. Events without an environment are not emitted.
+func NewTenantBinding(tenant S.Tenant, binding I.Binding) I.Binding {
+	environments := map[string]bool{}
+	for _, environment := range tenant.Environments {
+		environments[strings.ToLower(environment.Name)] = true
+	}
+
+	return tenantBinding{environments: environments, binding: binding}
+}
+
+type tenantBinding struct {
+	environments map[string]bool
+	binding      I.Binding
+}
+
+func (b tenantBinding) Accepts(event interface{}) bool {
+	return b.environments[strings.ToLower(eventEnvironment(event))] && b.binding.Accepts(event)
+}
+
+func (b tenantBinding) Emit(event interface{}) error {
+	return b.binding.Emit(event)
+}
+
+// eventEnvironment returns the name of the environment the event is about, or an empty string when it has none.
+func eventEnvironment(event interface{}) string {
+	if legacy, ok := event.(I.Event); ok {
+		event = legacy.Data
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(event))
+	if !value.IsValid() || value.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for _, name := range []string{"CFContext", "Context", "DeploymentInfo", "Environment"} {
+		field := value.FieldByName(name)
+		if !field.IsValid() || !field.CanInterface() {
+			continue
+		}
+
+		var environment string
+		switch f := field.Interface().(type) {
+		case I.CFContext:
+			environment = f.Environment
+		case *S.DeploymentInfo:
+			if f != nil {
+				environment = f.Environment
+			}
+		case S.Environment:
+			environment = f.Name
+		case string:
+			environment = f
+		}
+		if environment != "" {
+			return environment
+		}
+	}
+
+	return ""
+}
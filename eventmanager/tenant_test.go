@@ -0,0 +1,67 @@
+package eventmanager_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/compozed/deployadactyl/eventmanager"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/state/push"
+	"github.com/compozed/deployadactyl/state/stop"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+var _ = Describe("TenantBinding", func() {
+	var (
+		binding       *mocks.EventBinding
+		tenantBinding I.Binding
+	)
+
+	BeforeEach(func() {
+		binding = &mocks.EventBinding{}
+		binding.AcceptsCall.Returns.Bool = true
+
+		tenantBinding = NewTenantBinding(S.Tenant{
+			Name:         "search",
+			Environments: []S.Environment{{Name: "Search-Prod"}},
+		}, binding)
+	})
+
+	It("accepts the events of the environments of the tenant", func() {
+		event := push.DeployStartedEvent{CFContext: I.CFContext{Environment: "search-prod"}}
+
+		Expect(tenantBinding.Accepts(event)).To(BeTrue())
+		Expect(binding.AcceptsCall.Received.Event).To(Equal(event))
+
+		Expect(tenantBinding.Emit(event)).To(Succeed())
+		Expect(binding.EmitCall.Received.Event).To(Equal(event))
+	})
+
+	It("does not accept the events of other environments", func() {
+		Expect(tenantBinding.Accepts(push.DeployStartedEvent{CFContext: I.CFContext{Environment: "payments-prod"}})).To(BeFalse())
+		Expect(tenantBinding.Accepts(push.DeploymentReconciledEvent{Environment: S.Environment{Name: "payments-prod"}})).To(BeFalse())
+	})
+
+	It("reads the environment of events without a CFContext", func() {
+		Expect(tenantBinding.Accepts(push.DeploymentReconciledEvent{Environment: S.Environment{Name: "Search-Prod"}})).To(BeTrue())
+	})
+
+	It("reads the environment of the data of legacy events", func() {
+		event := I.Event{Type: "deploy.start", Data: S.DeployEventData{DeploymentInfo: &S.DeploymentInfo{Environment: "search-prod"}}}
+
+		Expect(tenantBinding.Accepts(event)).To(BeTrue())
+		Expect(tenantBinding.Accepts(I.Event{Type: "deploy.start", Data: S.DeployEventData{}})).To(BeFalse())
+	})
+
+	It("does not accept events without an environment", func() {
+		Expect(tenantBinding.Accepts(stop.StopStartedEvent{})).To(BeFalse())
+		Expect(tenantBinding.Accepts(nil)).To(BeFalse())
+	})
+
+	It("does not accept the events the binding does not accept", func() {
+		binding.AcceptsCall.Returns.Bool = false
+
+		Expect(tenantBinding.Accepts(push.DeployStartedEvent{CFContext: I.CFContext{Environment: "search-prod"}})).To(BeFalse())
+	})
+})
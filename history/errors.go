@@ -0,0 +1,46 @@
+package history
+
+import "fmt"
+
+type RecordNotFoundError struct {
+	UUID string
+}
+
+func (e RecordNotFoundError) Error() string {
+	return fmt.Sprintf("deployment %s not found", e.UUID)
+}
+
+type ReadHistoryError struct {
+	File string
+	Err  error
+}
+
+func (e ReadHistoryError) Error() string {
+	return fmt.Sprintf("cannot read deployment history from %s: %s", e.File, e.Err)
+}
+
+type WriteHistoryError struct {
+	File string
+	Err  error
+}
+
+func (e WriteHistoryError) Error() string {
+	return fmt.Sprintf("cannot write deployment history to %s: %s", e.File, e.Err)
+}
+
+type DatabaseError struct {
+	Operation string
+	Err       error
+}
+
+func (e DatabaseError) Error() string {
+	return fmt.Sprintf("cannot %s the deployment history in the database: %s", e.Operation, e.Err)
+}
+
+type InvalidRowError struct {
+	Columns int
+}
+
+func (e InvalidRowError) Error() string {
+	return fmt.Sprintf("expected a row with a single column, got %d columns", e.Columns)
+}
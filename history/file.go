@@ -0,0 +1,76 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// NewFileHistory returns a FileHistory that loads the records already in the file.
+func NewFileHistory(fs *afero.Afero, file string, maxRecords int) (*FileHistory, error) {
+	h := &FileHistory{
+		MemoryHistory: NewMemoryHistory(maxRecords),
+		FileSystem:    fs,
+		File:          file,
+	}
+
+	contents, err := fs.ReadFile(file)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, ReadHistoryError{File: file, Err: err}
+	}
+
+	var records []S.DeploymentRecord
+	err = json.Unmarshal(contents, &records)
+	if err != nil {
+		return nil, ReadHistoryError{File: file, Err: err}
+	}
+	for _, record := range records {
+		h.MemoryHistory.record(record)
+	}
+
+	return h, nil
+}
+
+// FileHistory keeps the deployment history in memory and writes it to a JSON file every time
+// a record is added or changed, so it survives restarts.
+type FileHistory struct {
+	*MemoryHistory
+	FileSystem *afero.Afero
+	File       string
+}
+
+// Record adds or replaces the record and writes the history to the file.
+func (h *FileHistory) Record(record S.DeploymentRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.record(record)
+
+	contents, err := json.Marshal(h.records)
+	if err != nil {
+		return WriteHistoryError{File: h.File, Err: err}
+	}
+
+	// The history is written next to the file and renamed over it, so it is never left half written.
+	err = h.FileSystem.MkdirAll(filepath.Dir(h.File), 0755)
+	if err != nil {
+		return WriteHistoryError{File: h.File, Err: err}
+	}
+	temp := h.File + ".tmp"
+	err = h.FileSystem.WriteFile(temp, contents, 0600)
+	if err != nil {
+		return WriteHistoryError{File: h.File, Err: err}
+	}
+	err = h.FileSystem.Rename(temp, h.File)
+	if err != nil {
+		return WriteHistoryError{File: h.File, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package history_test
+
+import (
+	"github.com/compozed/deployadactyl/conformance"
+	. "github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("FileHistory", func() {
+	var (
+		af   *afero.Afero
+		file string
+	)
+
+	BeforeEach(func() {
+		af = &afero.Afero{Fs: afero.NewMemMapFs()}
+		file = "/var/deployadactyl/history.json"
+	})
+
+	It("keeps the records after a restart", func() {
+		history, err := NewFileHistory(af, file, 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		record := deployment("1", "app", S.DeploymentSucceeded, S.Component{Name: "log4j-core", Version: "2.14.1"})
+		Expect(history.Record(record)).To(Succeed())
+
+		reloaded, err := NewFileHistory(af, file, 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		found, err := reloaded.Get("1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found.SBOM).To(Equal(record.SBOM))
+		Expect(found.StartedAt.Equal(record.StartedAt)).To(BeTrue())
+	})
+
+	It("starts empty when the file does not exist", func() {
+		history, err := NewFileHistory(af, file, 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(history.Find(S.DeploymentQuery{})).To(BeEmpty())
+	})
+
+	It("returns an error when the file can not be parsed", func() {
+		Expect(af.WriteFile(file, []byte("not json"), 0600)).To(Succeed())
+
+		_, err := NewFileHistory(af, file, 0)
+
+		Expect(err).To(BeAssignableToTypeOf(ReadHistoryError{}))
+	})
+
+	It("is returned by NewHistory when a file is configured", func() {
+		history, err := NewHistory(S.HistoryDescriptor{File: file}, af)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(history).To(BeAssignableToTypeOf(&FileHistory{}))
+	})
+
+	It("satisfies the DeploymentHistory contract", func() {
+		conformance.DeploymentHistory(GinkgoT(), func() I.DeploymentHistory {
+			history, err := NewFileHistory(&afero.Afero{Fs: afero.NewMemMapFs()}, file, 0)
+			Expect(err).ToNot(HaveOccurred())
+			return history
+		})
+	})
+})
@@ -0,0 +1,91 @@
+// Package history keeps a record of every deployment.
+package history
+
+import (
+	"strings"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/postgres"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// DefaultMaxRecords is the number of records kept when the descriptor does not set a maximum.
+const DefaultMaxRecords = 10000
+
+type HistoryConstructor func(descriptor S.HistoryDescriptor, fs *afero.Afero) (I.DeploymentHistory, error)
+
+// NewHistory returns a PostgresHistory if the descriptor has a database, a FileHistory if it has a file and a
+// MemoryHistory otherwise.
+func NewHistory(descriptor S.HistoryDescriptor, fs *afero.Afero) (I.DeploymentHistory, error) {
+	if descriptor.Postgres != "" {
+		db, err := postgres.Open(descriptor.Postgres, fs)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresHistory(db, descriptor.MaxRecords)
+	}
+	if descriptor.File != "" {
+		return NewFileHistory(fs, descriptor.File, descriptor.MaxRecords)
+	}
+	return NewMemoryHistory(descriptor.MaxRecords), nil
+}
+
+// find returns the records matching the query, newest first. records are ordered oldest first.
+func find(records []S.DeploymentRecord, query S.DeploymentQuery) []S.DeploymentRecord {
+	found := []S.DeploymentRecord{}
+	seen := map[string]bool{}
+
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+
+		if query.Current {
+			if record.Status != S.DeploymentSucceeded && record.Status != S.DeploymentDegraded {
+				continue
+			}
+			app := strings.Join([]string{record.Environment, record.Org, record.Space, record.AppName}, "/")
+			if seen[app] {
+				continue
+			}
+			seen[app] = true
+		}
+
+		if !matches(record, query) {
+			continue
+		}
+
+		found = append(found, record)
+		if query.Limit > 0 && len(found) == query.Limit {
+			break
+		}
+	}
+
+	return found
+}
+
+func matches(record S.DeploymentRecord, query S.DeploymentQuery) bool {
+	if !matchesField(record.Environment, query.Environment) ||
+		!matchesField(record.Org, query.Org) ||
+		!matchesField(record.Space, query.Space) ||
+		!matchesField(record.AppName, query.AppName) ||
+		!matchesField(record.Status, query.Status) {
+		return false
+	}
+
+	if query.Component == "" {
+		return true
+	}
+	if record.SBOM == nil {
+		return false
+	}
+	for _, component := range record.SBOM.Components {
+		if strings.EqualFold(component.Name, query.Component) && strings.HasPrefix(component.Version, query.ComponentVersion) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesField(value, filter string) bool {
+	return filter == "" || strings.EqualFold(value, filter)
+}
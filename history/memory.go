@@ -0,0 +1,76 @@
+package history
+
+import (
+	"sync"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// NewMemoryHistory returns a MemoryHistory that keeps at most maxRecords records.
+func NewMemoryHistory(maxRecords int) *MemoryHistory {
+	if maxRecords <= 0 {
+		maxRecords = DefaultMaxRecords
+	}
+	return &MemoryHistory{MaxRecords: maxRecords}
+}
+
+// MemoryHistory keeps the deployment history in memory. Once it holds MaxRecords records
+// the oldest ones are dropped.
+type MemoryHistory struct {
+	MaxRecords int
+
+	mu      sync.RWMutex
+	records []S.DeploymentRecord
+}
+
+// Record adds the record, or replaces the record with the same UUID.
+func (h *MemoryHistory) Record(record S.DeploymentRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.record(record)
+	return nil
+}
+
+// Get returns the record with the given UUID.
+func (h *MemoryHistory) Get(uuid string) (S.DeploymentRecord, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	i := h.index(uuid)
+	if i < 0 {
+		return S.DeploymentRecord{}, RecordNotFoundError{UUID: uuid}
+	}
+	return h.records[i], nil
+}
+
+// Find returns the records matching the query, newest first.
+func (h *MemoryHistory) Find(query S.DeploymentQuery) ([]S.DeploymentRecord, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return find(h.records, query), nil
+}
+
+func (h *MemoryHistory) record(record S.DeploymentRecord) {
+	i := h.index(record.UUID)
+	if i >= 0 {
+		h.records[i] = record
+		return
+	}
+
+	h.records = append(h.records, record)
+	if h.MaxRecords > 0 && len(h.records) > h.MaxRecords {
+		h.records = append([]S.DeploymentRecord{}, h.records[len(h.records)-h.MaxRecords:]...)
+	}
+}
+
+// index searches from the newest record, since records are usually updated shortly after they are added.
+func (h *MemoryHistory) index(uuid string) int {
+	for i := len(h.records) - 1; i >= 0; i-- {
+		if h.records[i].UUID == uuid {
+			return i
+		}
+	}
+	return -1
+}
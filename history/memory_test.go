@@ -0,0 +1,132 @@
+package history_test
+
+import (
+	"github.com/compozed/deployadactyl/conformance"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"time"
+
+	. "github.com/compozed/deployadactyl/history"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func deployment(uuid, appName, status string, components ...S.Component) S.DeploymentRecord {
+	return S.DeploymentRecord{
+		UUID:        uuid,
+		Environment: "prod",
+		Org:         "org",
+		Space:       "space",
+		AppName:     appName,
+		Status:      status,
+		StartedAt:   time.Now().UTC(),
+		SBOM:        &S.SBOM{Components: components},
+	}
+}
+
+func uuids(records []S.DeploymentRecord) []string {
+	ids := []string{}
+	for _, record := range records {
+		ids = append(ids, record.UUID)
+	}
+	return ids
+}
+
+var _ = Describe("MemoryHistory", func() {
+	var (
+		history  *MemoryHistory
+		log4j214 = S.Component{Type: "maven", Name: "log4j-core", Version: "2.14.1"}
+		log4j217 = S.Component{Type: "maven", Name: "log4j-core", Version: "2.17.0"}
+	)
+
+	BeforeEach(func() {
+		history = NewMemoryHistory(0)
+	})
+
+	It("returns a recorded deployment", func() {
+		record := deployment("1", "app", S.DeploymentRunning)
+		Expect(history.Record(record)).To(Succeed())
+
+		Expect(history.Get("1")).To(Equal(record))
+	})
+
+	It("replaces the record of the same deployment", func() {
+		Expect(history.Record(deployment("1", "app", S.DeploymentRunning))).To(Succeed())
+		Expect(history.Record(deployment("1", "app", S.DeploymentSucceeded))).To(Succeed())
+
+		record, err := history.Get("1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record.Status).To(Equal(S.DeploymentSucceeded))
+
+		Expect(history.Find(S.DeploymentQuery{})).To(HaveLen(1))
+	})
+
+	It("returns an error for an unknown deployment", func() {
+		_, err := history.Get("unknown")
+
+		Expect(err).To(MatchError(RecordNotFoundError{UUID: "unknown"}))
+	})
+
+	It("drops the oldest records", func() {
+		history = NewMemoryHistory(2)
+		for _, uuid := range []string{"1", "2", "3"} {
+			Expect(history.Record(deployment(uuid, "app", S.DeploymentSucceeded))).To(Succeed())
+		}
+
+		records, err := history.Find(S.DeploymentQuery{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(uuids(records)).To(Equal([]string{"3", "2"}))
+	})
+
+	Describe("Find", func() {
+		BeforeEach(func() {
+			Expect(history.Record(deployment("1", "billing", S.DeploymentSucceeded, log4j214))).To(Succeed())
+			Expect(history.Record(deployment("2", "search", S.DeploymentSucceeded, log4j214))).To(Succeed())
+			Expect(history.Record(deployment("3", "search", S.DeploymentSucceeded, log4j217))).To(Succeed())
+			Expect(history.Record(deployment("4", "billing", S.DeploymentFailed, log4j217))).To(Succeed())
+		})
+
+		It("returns every record newest first", func() {
+			Expect(history.Find(S.DeploymentQuery{})).To(HaveLen(4))
+
+			records, _ := history.Find(S.DeploymentQuery{})
+			Expect(uuids(records)).To(Equal([]string{"4", "3", "2", "1"}))
+		})
+
+		It("filters by application and status", func() {
+			records, _ := history.Find(S.DeploymentQuery{AppName: "billing", Status: S.DeploymentSucceeded})
+
+			Expect(uuids(records)).To(Equal([]string{"1"}))
+		})
+
+		It("filters by component version prefix", func() {
+			records, _ := history.Find(S.DeploymentQuery{Component: "log4j-core", ComponentVersion: "2.14"})
+
+			Expect(uuids(records)).To(Equal([]string{"2", "1"}))
+		})
+
+		It("only returns the running version of each application when current", func() {
+			records, _ := history.Find(S.DeploymentQuery{Component: "log4j-core", ComponentVersion: "2.14", Current: true})
+
+			Expect(uuids(records)).To(Equal([]string{"1"}))
+		})
+
+		It("treats a degraded deployment as the running version", func() {
+			Expect(history.Record(deployment("5", "search", S.DeploymentDegraded, log4j217))).To(Succeed())
+
+			records, _ := history.Find(S.DeploymentQuery{AppName: "search", Current: true})
+
+			Expect(uuids(records)).To(Equal([]string{"5"}))
+		})
+
+		It("limits the number of records", func() {
+			records, _ := history.Find(S.DeploymentQuery{Limit: 2})
+
+			Expect(uuids(records)).To(Equal([]string{"4", "3"}))
+		})
+	})
+
+	It("satisfies the DeploymentHistory contract", func() {
+		conformance.DeploymentHistory(GinkgoT(), func() I.DeploymentHistory { return NewMemoryHistory(0) })
+	})
+})
@@ -0,0 +1,139 @@
+package history
+
+import (
+	"encoding/json"
+	"strconv"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// PostgresTable is the table of the deployment records. It is created when it does not exist.
+const PostgresTable = "deployadactyl_deployments"
+
+// NewPostgresHistory returns a PostgresHistory that keeps at most maxRecords records in the database, creating its
+// table if needed.
+func NewPostgresHistory(db I.Database, maxRecords int) (*PostgresHistory, error) {
+	if maxRecords <= 0 {
+		maxRecords = DefaultMaxRecords
+	}
+
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + PostgresTable + ` (
+		seq bigserial NOT NULL,
+		uuid text PRIMARY KEY,
+		environment text NOT NULL,
+		org text NOT NULL,
+		space text NOT NULL,
+		app_name text NOT NULL,
+		status text NOT NULL,
+		record json NOT NULL
+	)`)
+	if err != nil {
+		return nil, DatabaseError{"create", err}
+	}
+
+	err = db.Exec(`CREATE INDEX IF NOT EXISTS ` + PostgresTable + `_seq ON ` + PostgresTable + ` (seq)`)
+	if err != nil {
+		return nil, DatabaseError{"create", err}
+	}
+
+	return &PostgresHistory{DB: db, MaxRecords: maxRecords}, nil
+}
+
+// PostgresHistory keeps the deployment history in a PostgreSQL database, so every instance of the server sharing the
+// database sees the same deployments and they survive the loss of the disk of an instance. Records are kept as JSON,
+// next to the columns they are found by. Once it holds MaxRecords records the oldest ones are deleted.
+type PostgresHistory struct {
+	DB         I.Database
+	MaxRecords int
+}
+
+// Record adds the record, or replaces the record with the same UUID.
+func (h *PostgresHistory) Record(record S.DeploymentRecord) error {
+	contents, err := json.Marshal(record)
+	if err != nil {
+		return DatabaseError{"write", err}
+	}
+
+	err = h.DB.Exec(`INSERT INTO `+PostgresTable+` (uuid, environment, org, space, app_name, status, record)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (uuid) DO UPDATE SET
+			environment = EXCLUDED.environment,
+			org = EXCLUDED.org,
+			space = EXCLUDED.space,
+			app_name = EXCLUDED.app_name,
+			status = EXCLUDED.status,
+			record = EXCLUDED.record`,
+		record.UUID, record.Environment, record.Org, record.Space, record.AppName, record.Status, string(contents))
+	if err != nil {
+		return DatabaseError{"write", err}
+	}
+
+	err = h.DB.Exec(`DELETE FROM `+PostgresTable+`
+		WHERE seq <= (SELECT seq FROM `+PostgresTable+` ORDER BY seq DESC OFFSET $1 LIMIT 1)`, h.MaxRecords)
+	if err != nil {
+		return DatabaseError{"write", err}
+	}
+	return nil
+}
+
+// Get returns the record with the given UUID.
+func (h *PostgresHistory) Get(uuid string) (S.DeploymentRecord, error) {
+	rows, err := h.DB.Query(`SELECT record FROM `+PostgresTable+` WHERE uuid = $1`, uuid)
+	if err != nil {
+		return S.DeploymentRecord{}, DatabaseError{"read", err}
+	}
+	if len(rows) == 0 {
+		return S.DeploymentRecord{}, RecordNotFoundError{UUID: uuid}
+	}
+
+	records, err := parseRecords(rows)
+	if err != nil {
+		return S.DeploymentRecord{}, err
+	}
+	return records[0], nil
+}
+
+// Find returns the records matching the query, newest first. The database only filters by the columns, and the
+// current deployments and components are found like in the other histories.
+func (h *PostgresHistory) Find(query S.DeploymentQuery) ([]S.DeploymentRecord, error) {
+	statement := `SELECT record FROM ` + PostgresTable + `
+		WHERE ($1::text = '' OR lower(environment) = lower($1::text))
+		AND ($2::text = '' OR lower(org) = lower($2::text))
+		AND ($3::text = '' OR lower(space) = lower($3::text))
+		AND ($4::text = '' OR lower(app_name) = lower($4::text))
+		AND ($5::text = '' OR lower(status) = lower($5::text))
+		ORDER BY seq DESC`
+	if query.Limit > 0 && !query.Current && query.Component == "" {
+		statement += ` LIMIT ` + strconv.Itoa(query.Limit)
+	}
+
+	rows, err := h.DB.Query(statement, query.Environment, query.Org, query.Space, query.AppName, query.Status)
+	if err != nil {
+		return nil, DatabaseError{"read", err}
+	}
+
+	records, err := parseRecords(rows)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return find(records, query), nil
+}
+
+func parseRecords(rows [][]string) ([]S.DeploymentRecord, error) {
+	records := make([]S.DeploymentRecord, len(rows))
+	for i, row := range rows {
+		if len(row) != 1 {
+			return nil, DatabaseError{"read", InvalidRowError{len(row)}}
+		}
+
+		err := json.Unmarshal([]byte(row[0]), &records[i])
+		if err != nil {
+			return nil, DatabaseError{"read", err}
+		}
+	}
+	return records, nil
+}
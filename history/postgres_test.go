@@ -0,0 +1,134 @@
+package history_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/compozed/deployadactyl/history"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/postgres"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("PostgresHistory", func() {
+	var (
+		db      *mocks.Database
+		history *PostgresHistory
+	)
+
+	rowsOf := func(records ...S.DeploymentRecord) [][]string {
+		rows := [][]string{}
+		for _, record := range records {
+			contents, err := json.Marshal(record)
+			Expect(err).ToNot(HaveOccurred())
+			rows = append(rows, []string{string(contents)})
+		}
+		return rows
+	}
+
+	BeforeEach(func() {
+		db = &mocks.Database{}
+
+		var err error
+		history, err = NewPostgresHistory(db, 0)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("creates its table", func() {
+		Expect(db.ExecCall.Received.Queries[0]).To(ContainSubstring("CREATE TABLE IF NOT EXISTS " + PostgresTable))
+		Expect(history.MaxRecords).To(Equal(DefaultMaxRecords))
+	})
+
+	It("returns an error when its table cannot be created", func() {
+		db.ExecCall.Returns.Error = errors.New("permission denied")
+
+		_, err := NewPostgresHistory(db, 0)
+
+		Expect(err).To(MatchError(DatabaseError{"create", errors.New("permission denied")}))
+	})
+
+	It("upserts the record with its columns and JSON", func() {
+		record := deployment("1", "app", S.DeploymentRunning)
+
+		Expect(history.Record(record)).To(Succeed())
+
+		insert := db.ExecCall.Received.Args[2]
+		Expect(db.ExecCall.Received.Queries[2]).To(ContainSubstring("ON CONFLICT (uuid) DO UPDATE"))
+		Expect(insert[:6]).To(Equal([]interface{}{"1", "prod", "org", "space", "app", S.DeploymentRunning}))
+
+		recorded := S.DeploymentRecord{}
+		Expect(json.Unmarshal([]byte(insert[6].(string)), &recorded)).To(Succeed())
+		Expect(recorded.UUID).To(Equal("1"))
+	})
+
+	It("deletes the records beyond the maximum", func() {
+		history.MaxRecords = 2
+
+		Expect(history.Record(deployment("1", "app", S.DeploymentRunning))).To(Succeed())
+
+		Expect(db.ExecCall.Received.Queries[3]).To(ContainSubstring("DELETE FROM " + PostgresTable))
+		Expect(db.ExecCall.Received.Args[3]).To(Equal([]interface{}{2}))
+	})
+
+	It("returns a recorded deployment", func() {
+		record := deployment("1", "app", S.DeploymentSucceeded)
+		db.QueryCall.Returns.Rows = rowsOf(record)
+
+		found, err := history.Get("1")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found.UUID).To(Equal("1"))
+		Expect(found.StartedAt.Equal(record.StartedAt)).To(BeTrue())
+		Expect(db.QueryCall.Received.Args[0]).To(Equal([]interface{}{"1"}))
+	})
+
+	It("returns an error for an unknown deployment", func() {
+		_, err := history.Get("unknown")
+
+		Expect(err).To(MatchError(RecordNotFoundError{UUID: "unknown"}))
+	})
+
+	It("returns an error when the database fails", func() {
+		db.QueryCall.Returns.Error = errors.New("connection refused")
+
+		_, err := history.Get("1")
+
+		Expect(err).To(MatchError(DatabaseError{"read", errors.New("connection refused")}))
+	})
+
+	It("filters by the columns in the database and limits the rows", func() {
+		db.QueryCall.Returns.Rows = rowsOf(deployment("2", "app", S.DeploymentSucceeded), deployment("1", "app", S.DeploymentSucceeded))
+
+		records, err := history.Find(S.DeploymentQuery{Environment: "prod", AppName: "app", Limit: 2})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(uuids(records)).To(Equal([]string{"2", "1"}))
+		Expect(db.QueryCall.Received.Queries[0]).To(HaveSuffix("LIMIT 2"))
+		Expect(db.QueryCall.Received.Args[0]).To(Equal([]interface{}{"prod", "", "", "app", ""}))
+	})
+
+	It("finds the current deployments and components like the other histories", func() {
+		log4j := S.Component{Name: "log4j-core", Version: "2.14.1"}
+		db.QueryCall.Returns.Rows = rowsOf(
+			deployment("3", "app", S.DeploymentFailed, log4j),
+			deployment("2", "app", S.DeploymentSucceeded),
+			deployment("1", "app", S.DeploymentSucceeded, log4j),
+		)
+
+		Expect(history.Find(S.DeploymentQuery{Component: "log4j-core", Limit: 1})).To(HaveLen(1))
+		Expect(db.QueryCall.Received.Queries[0]).ToNot(ContainSubstring("LIMIT"))
+
+		records, err := history.Find(S.DeploymentQuery{Component: "log4j-core", Current: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(BeEmpty())
+	})
+
+	It("is not returned by NewHistory when the database URL is invalid", func() {
+		_, err := NewHistory(S.HistoryDescriptor{Postgres: "mysql://db.example.com"}, &afero.Afero{Fs: afero.NewMemMapFs()})
+
+		Expect(err).To(BeAssignableToTypeOf(postgres.InvalidURLError{}))
+	})
+})
@@ -0,0 +1,83 @@
+package history
+
+import (
+	"sort"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DeployedVersions returns what is running on every foundation the records deployed to, given the records of a
+// single application newest first, sorted by environment and foundation.
+//
+// A foundation runs the artifact of the latest deployment to it that succeeded, including the deployments that
+// only partially succeeded. Records that do not name their foundations deployed to every foundation of their
+// environment.
+func DeployedVersions(records []S.DeploymentRecord, environments map[string]S.Environment) []S.DeployedVersion {
+	versions := []S.DeployedVersion{}
+	seen := map[string]bool{}
+
+	for _, record := range records {
+		if record.Status != S.DeploymentSucceeded && record.Status != S.DeploymentDegraded && record.Status != S.DeploymentPartial {
+			continue
+		}
+
+		for _, foundationURL := range deployedFoundations(record, environments) {
+			key := record.Environment + "\x00" + foundationURL
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			version := S.DeployedVersion{
+				Environment:    record.Environment,
+				FoundationURL:  foundationURL,
+				UUID:           record.UUID,
+				Status:         record.Status,
+				ArtifactURL:    record.ArtifactURL,
+				ArtifactDigest: record.ArtifactDigest,
+				Version:        record.Metadata[S.VersionMetadataKey],
+				DeployedBy:     record.Username,
+				DeployedAt:     record.FinishedAt,
+			}
+			if record.Provenance != nil {
+				version.Revision = record.Provenance.Revision
+			}
+			versions = append(versions, version)
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].Environment == versions[j].Environment {
+			return versions[i].FoundationURL < versions[j].FoundationURL
+		}
+		return versions[i].Environment < versions[j].Environment
+	})
+
+	return versions
+}
+
+// deployedFoundations returns the foundations the deployment succeeded on.
+func deployedFoundations(record S.DeploymentRecord, environments map[string]S.Environment) []string {
+	if len(record.FoundationResults) > 0 {
+		return S.FoundationsWithStatus(record.FoundationResults, S.FoundationSucceeded)
+	}
+
+	foundations := record.Foundations
+	if len(foundations) == 0 {
+		foundations = environments[record.Environment].Foundations
+	}
+
+	skipped := map[string]bool{}
+	for _, foundationURL := range record.SkippedFoundations {
+		skipped[foundationURL] = true
+	}
+
+	var deployed []string
+	for _, foundationURL := range foundations {
+		if !skipped[foundationURL] {
+			deployed = append(deployed, foundationURL)
+		}
+	}
+
+	return deployed
+}
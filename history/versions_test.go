@@ -0,0 +1,106 @@
+package history_test
+
+import (
+	. "github.com/compozed/deployadactyl/history"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeployedVersions", func() {
+	var environments map[string]S.Environment
+
+	BeforeEach(func() {
+		environments = map[string]S.Environment{
+			"prod":  {Name: "prod", Foundations: []string{"https://api.east.example.com", "https://api.west.example.com"}},
+			"stage": {Name: "stage", Foundations: []string{"https://api.stage.example.com"}},
+		}
+	})
+
+	version := func(uuid, artifactURL string, foundations ...string) S.DeploymentRecord {
+		record := deployment(uuid, "search", S.DeploymentSucceeded)
+		record.ArtifactURL = artifactURL
+		record.Foundations = foundations
+		return record
+	}
+
+	It("returns the latest successful deployment on every foundation", func() {
+		records := []S.DeploymentRecord{
+			deployment("failed", "search", S.DeploymentFailed),
+			version("3", "https://example.com/3.zip", "https://api.west.example.com"),
+			version("2", "https://example.com/2.zip"),
+			version("1", "https://example.com/1.zip"),
+		}
+		records[1].Metadata = map[string]string{S.VersionMetadataKey: "3.0.0"}
+		records[1].Provenance = &S.Provenance{Revision: "abc123"}
+		records[1].Username = "ci"
+
+		versions := DeployedVersions(records, environments)
+
+		Expect(versions).To(HaveLen(2))
+		Expect(versions[0].FoundationURL).To(Equal("https://api.east.example.com"))
+		Expect(versions[0].UUID).To(Equal("2"))
+		Expect(versions[0].ArtifactURL).To(Equal("https://example.com/2.zip"))
+		Expect(versions[1].FoundationURL).To(Equal("https://api.west.example.com"))
+		Expect(versions[1].UUID).To(Equal("3"))
+		Expect(versions[1].Version).To(Equal("3.0.0"))
+		Expect(versions[1].Revision).To(Equal("abc123"))
+		Expect(versions[1].DeployedBy).To(Equal("ci"))
+	})
+
+	It("sorts the versions by environment and foundation", func() {
+		stage := version("stage", "https://example.com/2.zip")
+		stage.Environment = "stage"
+		records := []S.DeploymentRecord{version("prod", "https://example.com/1.zip"), stage}
+
+		versions := DeployedVersions(records, environments)
+
+		Expect(versions).To(HaveLen(3))
+		Expect(versions[0].Environment).To(Equal("prod"))
+		Expect(versions[1].Environment).To(Equal("prod"))
+		Expect(versions[2].Environment).To(Equal("stage"))
+	})
+
+	It("includes degraded deployments, which are still running", func() {
+		degraded := version("degraded", "https://example.com/2.zip", "https://api.east.example.com")
+		degraded.Status = S.DeploymentDegraded
+
+		versions := DeployedVersions([]S.DeploymentRecord{degraded}, environments)
+
+		Expect(versions).To(HaveLen(1))
+		Expect(versions[0].Status).To(Equal(S.DeploymentDegraded))
+	})
+
+	It("includes the foundations a partially successful deployment succeeded on", func() {
+		partial := version("partial", "https://example.com/2.zip")
+		partial.Status = S.DeploymentPartial
+		partial.FoundationResults = []S.FoundationResult{
+			{FoundationURL: "https://api.east.example.com", Status: S.FoundationSucceeded},
+			{FoundationURL: "https://api.west.example.com", Status: S.FoundationFailed},
+		}
+		records := []S.DeploymentRecord{partial, version("1", "https://example.com/1.zip")}
+
+		versions := DeployedVersions(records, environments)
+
+		Expect(versions).To(HaveLen(2))
+		Expect(versions[0].UUID).To(Equal("partial"))
+		Expect(versions[1].UUID).To(Equal("1"))
+	})
+
+	It("does not include the foundations a deployment skipped", func() {
+		skipping := version("2", "https://example.com/2.zip")
+		skipping.SkippedFoundations = []string{"https://api.west.example.com"}
+		records := []S.DeploymentRecord{skipping, version("1", "https://example.com/1.zip")}
+
+		versions := DeployedVersions(records, environments)
+
+		Expect(versions[0].UUID).To(Equal("2"))
+		Expect(versions[1].UUID).To(Equal("1"))
+	})
+
+	It("returns nothing without a successful deployment", func() {
+		versions := DeployedVersions([]S.DeploymentRecord{deployment("failed", "search", S.DeploymentFailed)}, environments)
+
+		Expect(versions).To(BeEmpty())
+	})
+})
@@ -0,0 +1,54 @@
+package hooks
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+type UnknownPhaseError struct {
+	Phase string
+}
+
+func (e UnknownPhaseError) Error() string {
+	return fmt.Sprintf("unknown lifecycle hook phase %s: expected %s", e.Phase, strings.Join(S.LifecycleHookPhases, ", "))
+}
+
+type MissingCommandError struct {
+	Phase string
+}
+
+func (e MissingCommandError) Error() string {
+	return fmt.Sprintf("a %s lifecycle hook has no command", e.Phase)
+}
+
+type InvalidTimeoutError struct {
+	Phase          string
+	TimeoutSeconds int
+}
+
+func (e InvalidTimeoutError) Error() string {
+	return fmt.Sprintf("invalid timeout of a %s lifecycle hook: %d seconds", e.Phase, e.TimeoutSeconds)
+}
+
+type CommandError struct {
+	Phase   string
+	Command string
+	Err     error
+}
+
+func (e CommandError) Error() string {
+	return fmt.Sprintf("%s hook %s failed: %s", e.Phase, e.Command, e.Err)
+}
+
+type TimeoutError struct {
+	Phase   string
+	Command string
+	Timeout time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("%s hook %s did not finish within %s", e.Phase, e.Command, e.Timeout)
+}
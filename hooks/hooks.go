@@ -0,0 +1,123 @@
+// Package hooks runs the local commands configured to run at the phases of a push.
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DefaultTimeout is how long a hook may run when it does not set a timeout.
+const DefaultTimeout = time.Minute
+
+// VariablePrefix prefixes the names of the variables passed to a hook.
+const VariablePrefix = "DEPLOYADACTYL_"
+
+// PhaseVariable is the variable holding the phase a hook is run at.
+const PhaseVariable = VariablePrefix + "PHASE"
+
+type RunnerConstructor func(log I.DeploymentLogger, hooks []S.LifecycleHook) I.HookRunner
+
+func NewRunner(log I.DeploymentLogger, hooks []S.LifecycleHook) I.HookRunner {
+	return &Runner{
+		Log:     log,
+		Hooks:   hooks,
+		Environ: os.Environ,
+	}
+}
+
+// Runner runs the hooks of a phase one after another, in the order they are configured.
+type Runner struct {
+	Log     I.DeploymentLogger
+	Hooks   []S.LifecycleHook
+	Environ func() []string
+}
+
+// Run runs the hooks of the phase with the environment of the server and the variables, and writes their output.
+// It stops at the first hook that fails.
+func (r *Runner) Run(ctx context.Context, phase string, variables map[string]string, output io.Writer) error {
+	environment := append(r.Environ(), PhaseVariable+"="+phase)
+
+	var names []string
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		environment = append(environment, VariablePrefix+name+"="+variables[name])
+	}
+
+	for _, hook := range r.Hooks {
+		if hook.Phase != phase {
+			continue
+		}
+
+		err := r.run(ctx, hook, environment, output)
+		if err != nil {
+			r.Log.Errorf("%s hook failed: %s", phase, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Runner) run(ctx context.Context, hook S.LifecycleHook, environment []string, output io.Writer) error {
+	timeout := DefaultTimeout
+	if hook.TimeoutSeconds > 0 {
+		timeout = time.Duration(hook.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	command := strings.Join(hook.Command, " ")
+	r.Log.Infof("running %s hook %s", hook.Phase, command)
+	fmt.Fprintf(output, "\nrunning %s hook: %s\n", hook.Phase, command)
+
+	cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+	cmd.Env = environment
+
+	out, err := cmd.CombinedOutput()
+	output.Write(out)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return TimeoutError{Phase: hook.Phase, Command: command, Timeout: timeout}
+		}
+		return CommandError{Phase: hook.Phase, Command: command, Err: err}
+	}
+
+	return nil
+}
+
+// Validate returns an error for the first hook with an unknown phase, no command or a negative timeout.
+func Validate(hooks []S.LifecycleHook) error {
+	for _, hook := range hooks {
+		known := false
+		for _, phase := range S.LifecycleHookPhases {
+			if hook.Phase == phase {
+				known = true
+			}
+		}
+		if !known {
+			return UnknownPhaseError{hook.Phase}
+		}
+
+		if len(hook.Command) == 0 || hook.Command[0] == "" {
+			return MissingCommandError{hook.Phase}
+		}
+
+		if hook.TimeoutSeconds < 0 {
+			return InvalidTimeoutError{hook.Phase, hook.TimeoutSeconds}
+		}
+	}
+
+	return nil
+}
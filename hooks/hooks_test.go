@@ -0,0 +1,103 @@
+package hooks_test
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/compozed/deployadactyl/hooks"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/op/go-logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Runner", func() {
+	var (
+		runner *Runner
+		output *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		output = &bytes.Buffer{}
+		runner = &Runner{
+			Log:     I.DeploymentLogger{Log: I.DefaultLogger(NewBuffer(), logging.DEBUG, "hooks_test")},
+			Environ: func() []string { return []string{"PATH=/usr/bin:/bin", "SERVER=deployadactyl"} },
+		}
+	})
+
+	It("runs the hooks of the phase in order with the variables of the deployment", func() {
+		runner.Hooks = []S.LifecycleHook{
+			{Phase: S.HookPreFetch, Command: []string{"sh", "-c", `echo "first $DEPLOYADACTYL_PHASE $DEPLOYADACTYL_APP_NAME $SERVER"`}},
+			{Phase: S.HookPostPromotion, Command: []string{"sh", "-c", "echo other phase"}},
+			{Phase: S.HookPreFetch, Command: []string{"sh", "-c", `echo "second $DEPLOYADACTYL_UUID"`}},
+		}
+
+		err := runner.Run(context.Background(), S.HookPreFetch, map[string]string{"APP_NAME": "search", "UUID": "uuid"}, output)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(ContainSubstring("running pre-fetch hook: sh -c"))
+		Expect(output.String()).To(ContainSubstring("first pre-fetch search deployadactyl\n"))
+		Expect(output.String()).To(ContainSubstring("second uuid\n"))
+		Expect(output.String()).ToNot(ContainSubstring("other phase"))
+		Expect(output.String()).To(MatchRegexp("(?s)first.*second"))
+	})
+
+	It("does nothing without hooks for the phase", func() {
+		Expect(runner.Run(context.Background(), S.HookPrePush, nil, output)).To(Succeed())
+		Expect(output.String()).To(BeEmpty())
+	})
+
+	It("stops at the first hook that fails", func() {
+		runner.Hooks = []S.LifecycleHook{
+			{Phase: S.HookPrePush, Command: []string{"sh", "-c", "echo change freeze; exit 3"}},
+			{Phase: S.HookPrePush, Command: []string{"sh", "-c", "echo not run"}},
+		}
+
+		err := runner.Run(context.Background(), S.HookPrePush, nil, output)
+
+		Expect(err).To(BeAssignableToTypeOf(CommandError{}))
+		Expect(err.Error()).To(ContainSubstring("pre-push hook sh -c echo change freeze; exit 3 failed"))
+		Expect(output.String()).To(ContainSubstring("change freeze"))
+		Expect(output.String()).ToNot(ContainSubstring("not run"))
+	})
+
+	It("returns an error when a hook does not finish in time", func() {
+		runner.Hooks = []S.LifecycleHook{{Phase: S.HookPostFetch, Command: []string{"sh", "-c", "exec sleep 5"}, TimeoutSeconds: 1}}
+
+		err := runner.Run(context.Background(), S.HookPostFetch, nil, output)
+
+		Expect(err).To(BeAssignableToTypeOf(TimeoutError{}))
+	})
+})
+
+var _ = Describe("Validate", func() {
+	It("accepts hooks of every phase", func() {
+		var hooks []S.LifecycleHook
+		for _, phase := range S.LifecycleHookPhases {
+			hooks = append(hooks, S.LifecycleHook{Phase: phase, Command: []string{"notify"}})
+		}
+
+		Expect(Validate(hooks)).To(Succeed())
+	})
+
+	It("returns an error for an unknown phase", func() {
+		err := Validate([]S.LifecycleHook{{Phase: "pre-deploy", Command: []string{"notify"}}})
+
+		Expect(err).To(Equal(UnknownPhaseError{"pre-deploy"}))
+	})
+
+	It("returns an error for a hook without a command", func() {
+		err := Validate([]S.LifecycleHook{{Phase: S.HookPostRollback}})
+
+		Expect(err).To(Equal(MissingCommandError{S.HookPostRollback}))
+	})
+
+	It("returns an error for a negative timeout", func() {
+		err := Validate([]S.LifecycleHook{{Phase: S.HookPostRollback, Command: []string{"notify"}, TimeoutSeconds: -1}})
+
+		Expect(err).To(Equal(InvalidTimeoutError{S.HookPostRollback, -1}))
+	})
+})
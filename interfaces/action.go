@@ -1,21 +1,25 @@
 package interfaces
 
 import (
+	"context"
+
 	S "github.com/compozed/deployadactyl/structs"
 	"io"
 )
 
+// Action is run against a single foundation. The context passed to Undo and Finally
+// is never cancelled, so an action can always clean up after itself.
 type Action interface {
-	Initially() error
-	Execute() error
-	Verify() error
-	Success() error
-	Undo() error
-	Finally() error
+	Initially(ctx context.Context) error
+	Execute(ctx context.Context) error
+	Verify(ctx context.Context) error
+	Success(ctx context.Context) error
+	Undo(ctx context.Context) error
+	Finally(ctx context.Context) error
 }
 
 type ActionCreator interface {
-	SetUp() error
+	SetUp(ctx context.Context) error
 	CleanUp()
 	OnStart() error
 	OnFinish(environment S.Environment, response io.ReadWriter, err error) DeployResponse
@@ -0,0 +1,8 @@
+package interfaces
+
+// ArtifactCache interface.
+type ArtifactCache interface {
+	Store(uuid, appPath string) error
+	Restore(uuid string) (string, error)
+	Remove(uuid string) error
+}
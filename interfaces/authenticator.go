@@ -0,0 +1,14 @@
+package interfaces
+
+// Authenticator checks the basic auth of the requests to the environments that require it.
+type Authenticator interface {
+	// Authenticate returns the groups of the user. It returns an error unless the password is that of the user and,
+	// when there are groups, the user is a member of one of them.
+	Authenticate(username, password string, groups []string) (memberOf []string, err error)
+}
+
+// TokenVerifier checks the bearer tokens of the requests.
+type TokenVerifier interface {
+	// Verify returns the user of the token and its groups, or an error when it is not a valid token.
+	Verify(token string) (username string, groups []string, err error)
+}
@@ -1,6 +1,7 @@
 package interfaces
 
 import (
+	"context"
 	"io"
 
 	S "github.com/compozed/deployadactyl/structs"
@@ -8,8 +9,9 @@ import (
 
 type BlueGreener interface {
 	Execute(
+		ctx context.Context,
 		actionCreator ActionCreator,
 		environment S.Environment,
 		response io.ReadWriter,
-	) error
+	) ([]S.FoundationResult, error)
 }
@@ -0,0 +1,9 @@
+package interfaces
+
+// CanaryPromoter promotes the canaries of the running deployments before their bake period is over. The channel
+// returned by Wait is closed when Promote is called with the uuid of the deployment, and done must be called once
+// the canary no longer waits.
+type CanaryPromoter interface {
+	Wait(uuid string) (promoted <-chan struct{}, done func())
+	Promote(uuid string) error
+}
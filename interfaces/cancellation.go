@@ -0,0 +1,10 @@
+package interfaces
+
+import "context"
+
+// DeploymentCanceller cancels the running deployments. The context of a deployment is cancelled when Cancel is
+// called with its uuid, and done must be called once the deployment is over.
+type DeploymentCanceller interface {
+	Context(parent context.Context, uuid string) (ctx context.Context, done func())
+	Cancel(uuid string) error
+}
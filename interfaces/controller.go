@@ -1,7 +1,9 @@
 package interfaces
 
 import (
-	"bytes"
+	"io"
+
+	"github.com/compozed/deployadactyl/structs"
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,11 +17,47 @@ type Deployment struct {
 	Type          DeploymentType
 	Authorization Authorization
 	CFContext     CFContext
+	Metadata      map[string]string
+	Signature     string
+	ManifestName  string
+	StopMode      structs.StopMode
+	BatchSize     int
+
+	// DeleteRoutes deletes the routes the deleted application leaves without an application.
+	DeleteRoutes bool
+
+	// ArtifactDigest is the digest the fetched artifact must have. Empty accepts any artifact.
+	ArtifactDigest string
+
+	// Foundations restricts the deployment to these foundations of the environment. Empty deploys to all of them.
+	Foundations []string
+
+	// CachedArtifact is the uuid of the deployment whose cached artifact is deployed, instead of fetching the artifact
+	// again. The artifact is fetched when it is not cached.
+	CachedArtifact string
+
+	// Upload is the zip uploaded with the request, which is read from a file instead of being held in Body.
+	Upload io.Reader
 }
 
 type Authorization struct {
 	Username string
 	Password string
+
+	// Subject is the user of the bearer token the request was authenticated with, whose deployments are made with
+	// the Cloud Foundry credentials of the environment.
+	Subject string
+
+	// Groups are the groups of the user, from its bearer token or from LDAP.
+	Groups []string
+}
+
+// User returns who made the request: the user of its bearer token, or else its Cloud Foundry user.
+func (a Authorization) User() string {
+	if a.Subject != "" {
+		return a.Subject
+	}
+	return a.Username
 }
 
 type CFContext struct {
@@ -31,9 +69,59 @@ type CFContext struct {
 }
 
 type Controller interface {
-	RunDeployment(deployment *Deployment, response *bytes.Buffer) DeployResponse
+	RunDeployment(deployment *Deployment, response io.ReadWriter) DeployResponse
 
 	RunDeploymentViaHttp(g *gin.Context)
 
 	PutRequestHandler(g *gin.Context)
+
+	DeleteRequestHandler(g *gin.Context)
+
+	DeploymentHistoryHandler(g *gin.Context)
+
+	DeploymentRecordHandler(g *gin.Context)
+
+	ActiveDeploymentsHandler(g *gin.Context)
+
+	ProfileHandler(g *gin.Context)
+
+	GoroutinesHandler(g *gin.Context)
+
+	RuntimeStatsHandler(g *gin.Context)
+
+	EventStreamHandler(g *gin.Context)
+
+	TempDirectoriesHandler(g *gin.Context)
+
+	StaleAppsHandler(g *gin.Context)
+
+	SweepStaleAppsHandler(g *gin.Context)
+
+	MaintenanceHandler(g *gin.Context)
+
+	SetMaintenanceHandler(g *gin.Context)
+
+	DeploymentLogHandler(g *gin.Context)
+
+	DeploymentLogStreamHandler(g *gin.Context)
+
+	DeploymentProgressHandler(g *gin.Context)
+
+	RetryDeploymentHandler(g *gin.Context)
+
+	RetryFoundationsHandler(g *gin.Context)
+
+	CancelDeploymentHandler(g *gin.Context)
+
+	PromoteCanaryHandler(g *gin.Context)
+
+	BatchDeploymentHandler(g *gin.Context)
+
+	PromotionHandler(g *gin.Context)
+
+	DeployedVersionsHandler(g *gin.Context)
+
+	DriftHandler(g *gin.Context)
+
+	OpenAPIHandler(g *gin.Context)
 }
@@ -1,9 +1,18 @@
 package interfaces
 
+import (
+	"context"
+	"time"
+
+	"github.com/compozed/deployadactyl/structs"
+)
+
 // Courier interface.
 type Courier interface {
 	Login(foundationURL, username, password, org, space string, skipSSL bool) ([]byte, error)
+	Authenticate(foundationURL, username, password string, skipSSL bool) ([]byte, error)
 	Delete(appName string) ([]byte, error)
+	DeleteByGUID(guid string) ([]byte, error)
 	Push(appName, appLocation, hostname string, instances uint16) ([]byte, error)
 	Rename(oldName, newName string) ([]byte, error)
 	MapRoute(appName, domain, hostname string) ([]byte, error)
@@ -20,8 +29,31 @@ type Courier interface {
 	Restage(appName string) ([]byte, error)
 	Logs(appName string) ([]byte, error)
 	Exists(appName string) bool
+	ServiceExists(serviceName string) bool
 	Cups(appName string, body string) ([]byte, error)
 	Uups(appName string, body string) ([]byte, error)
 	Domains() ([]string, error)
+	SetLabel(appName string, labels map[string]string) ([]byte, error)
+	Scale(appName string, instances uint16) ([]byte, error)
+	RestartInstance(appName string, index int) ([]byte, error)
+	AppState(appName string) (structs.AppState, error)
+	AppEnvironment(appName string) (map[string]string, error)
+	AppRoutes(appName string) ([]string, error)
+	Apps() ([]structs.AppSummary, error)
+	Instances(appName string) ([]structs.Instance, error)
+	CrashCount(appName string, since time.Time) (int, error)
+	RunTask(appName, taskName, command string) (structs.Task, error)
+	Task(guid string) (structs.Task, error)
+	QuotaUsage(org, space string) (structs.OrgSpaceQuotaUsage, error)
+	LogEnvelopes(appName string) ([]structs.LogEnvelope, error)
 	CleanUp() error
+
+	// WithContext returns a Courier that stops running commands once ctx is done.
+	WithContext(ctx context.Context) Courier
+
+	// WithCABundle returns a Courier that trusts the certificate authorities in the file at path.
+	WithCABundle(path string) Courier
+
+	// WithLogCache returns a Courier that reads the logs of applications from the Log Cache API.
+	WithLogCache(descriptor structs.LogCacheDescriptor) Courier
 }
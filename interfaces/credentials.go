@@ -0,0 +1,7 @@
+package interfaces
+
+// CredentialStore reads the Cloud Foundry credentials of the environments that keep them in a secret store.
+type CredentialStore interface {
+	// Credentials returns the username and password of the secret at the path.
+	Credentials(path string) (username, password string, err error)
+}
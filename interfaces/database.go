@@ -0,0 +1,8 @@
+package interfaces
+
+// Database runs the statements of the stores kept in a database shared by every instance of the server.
+// Query returns the rows of the result as text.
+type Database interface {
+	Exec(query string, args ...interface{}) error
+	Query(query string, args ...interface{}) ([][]string, error)
+}
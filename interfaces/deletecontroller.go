@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"github.com/compozed/deployadactyl/structs"
+)
+
+type DeleteManagerFactory interface {
+	DeleteManager(log DeploymentLogger, deployEventData structs.DeployEventData) ActionCreator
+}
+
+type DeleteController interface {
+	DeleteDeployment(ctx context.Context, deployment *Deployment, data structs.Params, response io.ReadWriter) (deployResponse DeployResponse)
+}
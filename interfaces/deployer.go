@@ -1,6 +1,7 @@
 package interfaces
 
 import (
+	"context"
 	"io"
 
 	"github.com/compozed/deployadactyl/structs"
@@ -10,11 +11,25 @@ type DeployResponse struct {
 	StatusCode     int
 	DeploymentInfo *structs.DeploymentInfo
 	Error          error
+
+	// Degraded is true when the deployment succeeded but the application started crashing afterwards.
+	Degraded bool
+
+	// Summary is what the deployment took and what it left behind, as written in the footer of the response.
+	Summary structs.DeploymentSummary
+
+	// Foundations are the outcomes of the deployment on every foundation of the environment.
+	Foundations []structs.FoundationResult
+
+	// PartialSuccess is true when the deployment succeeded on some foundations but failed on or skipped others.
+	// The StatusCode of a partial success is http.StatusMultiStatus.
+	PartialSuccess bool
 }
 
 // Deployer interface.
 type Deployer interface {
 	Deploy(
+		ctx context.Context,
 		deploymentInfo *structs.DeploymentInfo,
 		environment structs.Environment,
 		actionCreator ActionCreator,
@@ -0,0 +1,7 @@
+package interfaces
+
+// DeploymentLogStore keeps the output of every deployment after its response is gone.
+type DeploymentLogStore interface {
+	Save(uuid string, output []byte) error
+	Get(uuid string) ([]byte, error)
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/compozed/deployadactyl/structs"
+)
+
+// AppInspector interface.
+type AppInspector interface {
+	Inspect(ctx context.Context, environment structs.Environment, foundationURL string, auth Authorization, org, space, appName string) (structs.AppSnapshot, error)
+}
@@ -0,0 +1,8 @@
+package interfaces
+
+import "github.com/compozed/deployadactyl/structs"
+
+// EventStream streams a summary of every emitted event to its subscribers.
+type EventStream interface {
+	Subscribe(accept func(structs.StreamedEvent) bool) (events <-chan structs.StreamedEvent, unsubscribe func())
+}
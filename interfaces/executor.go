@@ -1,8 +1,16 @@
 package interfaces
 
+import "context"
+
 // Executor interface.
 type Executor interface {
 	Execute(args ...string) ([]byte, error)
 	ExecuteInDirectory(directory string, args ...string) ([]byte, error)
 	CleanUp() error
+
+	// WithContext returns an Executor that kills running commands once ctx is done.
+	WithContext(ctx context.Context) Executor
+
+	// WithCABundle returns an Executor whose commands trust the certificate authorities in the file at path.
+	WithCABundle(path string) Executor
 }
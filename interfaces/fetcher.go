@@ -1,11 +1,12 @@
 package interfaces
 
 import (
+	"context"
 	"io"
 )
 
 // Fetcher interface.
 type Fetcher interface {
-	Fetch(url, manifest string) (string, error)
+	Fetch(ctx context.Context, url, manifest string) (string, error)
 	FetchZipFromRequest(body io.Reader) (string, string, error)
 }
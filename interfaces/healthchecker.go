@@ -4,3 +4,8 @@ package interfaces
 type HealthChecker interface {
 	Check(endpoint, serverURL string) error
 }
+
+// ApplicationHealthChecker checks the health endpoint of an application running on a foundation.
+type ApplicationHealthChecker interface {
+	CheckApplication(environment, foundationURL, appName, endpoint string, log DeploymentLogger) error
+}
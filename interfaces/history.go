@@ -0,0 +1,10 @@
+package interfaces
+
+import "github.com/compozed/deployadactyl/structs"
+
+// DeploymentHistory interface.
+type DeploymentHistory interface {
+	Record(record structs.DeploymentRecord) error
+	Get(uuid string) (structs.DeploymentRecord, error)
+	Find(query structs.DeploymentQuery) ([]structs.DeploymentRecord, error)
+}
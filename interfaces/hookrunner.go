@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+)
+
+// HookRunner interface.
+type HookRunner interface {
+	Run(ctx context.Context, phase string, variables map[string]string, output io.Writer) error
+}
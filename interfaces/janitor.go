@@ -0,0 +1,8 @@
+package interfaces
+
+import "github.com/compozed/deployadactyl/structs"
+
+// Janitor interface.
+type Janitor interface {
+	Schedule(deletion structs.ScheduledDeletion)
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import "time"
+
+// LeaderElection elects the instance of the server that runs the background jobs which must only run once across
+// every instance. Acquire takes or renews the leadership for the identity for the ttl, and reports whether the
+// identity is the leader.
+type LeaderElection interface {
+	Acquire(identity string, ttl time.Duration) (bool, error)
+	Release(identity string) error
+}
@@ -0,0 +1,8 @@
+package interfaces
+
+// LockManager holds the locks of the applications being deployed, so an application is only deployed by one
+// deployment at a time. The owner of a lock is the uuid of the deployment holding it.
+type LockManager interface {
+	Lock(key, owner string) error
+	Unlock(key, owner string) error
+}
@@ -3,6 +3,8 @@ package interfaces
 
 import (
 	"io"
+	"sort"
+	"strings"
 
 	"github.com/op/go-logging"
 )
@@ -17,13 +19,14 @@ type Logger interface {
 	Fatal(...interface{})
 }
 
+// LogFormat is the format of every log line of the server.
+const LogFormat = `%{time:2006/01/02 15:04:05} %{level:.4s} ▶ %{message}`
+
 // DefaultLogger returns a logging.Logger with a specific logging format.
 func DefaultLogger(out io.Writer, level logging.Level, module string) Logger {
 	var log = logging.MustGetLogger(module)
 
-	var format = logging.MustStringFormatter(
-		`%{time:2006/01/02 15:04:05} %{level:.4s} ▶ %{message}`,
-	)
+	var format = logging.MustStringFormatter(LogFormat)
 
 	backend := logging.NewLogBackend(out, "", 0)
 	backendFormatter := logging.NewBackendFormatter(backend, format)
@@ -34,39 +37,83 @@ func DefaultLogger(out io.Writer, level logging.Level, module string) Logger {
 	return log
 }
 
+// The keys of the fields logged by the actions of a deployment.
+const (
+	FoundationLogField = "foundation"
+	AppLogField        = "app"
+	PhaseLogField      = "phase"
+)
+
+// LogFields are keys and values that are included in every line logged by a DeploymentLogger.
+type LogFields map[string]string
+
+// DeploymentLogger prefixes every line it logs with the UUID of the deployment and its fields.
 type DeploymentLogger struct {
-	Log Logger
-	UUID string
+	Log    Logger
+	UUID   string
+	Fields LogFields
+}
+
+// WithFields returns a copy of the logger that also logs the fields, replacing the ones with the same key.
+func (l DeploymentLogger) WithFields(fields LogFields) DeploymentLogger {
+	merged := LogFields{}
+	for key, value := range l.Fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	l.Fields = merged
+	return l
+}
+
+// prefix returns the UUID followed by the fields sorted by key, such as "abc app=foo phase=execute".
+func (l DeploymentLogger) prefix() string {
+	if len(l.Fields) == 0 {
+		return l.UUID
+	}
+
+	keys := make([]string, 0, len(l.Fields))
+	for key := range l.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{l.UUID}
+	for _, key := range keys {
+		parts = append(parts, key+"="+l.Fields[key])
+	}
+	return strings.Join(parts, " ")
 }
 
 func (l DeploymentLogger) Error(args ...interface{}) {
-	args = append([]interface{}{l.UUID}, args...)
+	args = append([]interface{}{l.prefix()}, args...)
 	l.Log.Error(args...)
 }
 
 func (l DeploymentLogger) Errorf(str string, args ...interface{}) {
-	l.Log.Errorf(l.UUID+" "+str, args...)
+	l.Log.Errorf(l.prefix()+" "+str, args...)
 }
 
 func (l DeploymentLogger) Debug(args ...interface{}) {
-	args = append([]interface{}{l.UUID}, args...)
+	args = append([]interface{}{l.prefix()}, args...)
 	l.Log.Debug(args...)
 }
 
 func (l DeploymentLogger) Debugf(str string, args ...interface{}) {
-	l.Log.Debugf(l.UUID+" "+str, args...)
+	l.Log.Debugf(l.prefix()+" "+str, args...)
 }
 
 func (l DeploymentLogger) Info(args ...interface{}) {
-	args = append([]interface{}{l.UUID}, args...)
+	args = append([]interface{}{l.prefix()}, args...)
 	l.Log.Info(args...)
 }
 
 func (l DeploymentLogger) Infof(str string, args ...interface{}) {
-	l.Log.Infof(l.UUID+" "+str, args...)
+	l.Log.Infof(l.prefix()+" "+str, args...)
 }
 
 func (l DeploymentLogger) Fatal(args ...interface{}) {
-	args = append([]interface{}{l.UUID}, args...)
+	args = append([]interface{}{l.prefix()}, args...)
 	l.Log.Fatal(args...)
 }
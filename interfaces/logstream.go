@@ -0,0 +1,9 @@
+package interfaces
+
+import "io"
+
+// LogStreamer streams the output of deployments while they run.
+type LogStreamer interface {
+	Open(uuid string) io.WriteCloser
+	Subscribe(uuid string) (output <-chan []byte, unsubscribe func())
+}
@@ -0,0 +1,8 @@
+package interfaces
+
+// FoundationMaintenance interface.
+type FoundationMaintenance interface {
+	InMaintenance(foundationURL string) bool
+	SetMaintenance(foundationURL string, maintenance bool)
+	Foundations() []string
+}
@@ -5,4 +5,6 @@ import S "github.com/compozed/deployadactyl/structs"
 // Prechecker interface.
 type Prechecker interface {
 	AssertAllFoundationsUp(environment S.Environment) error
+	AssertBuildpacksAvailable(environment S.Environment, deploymentInfo S.DeploymentInfo) error
+	AssertDomainsAvailable(environment S.Environment, deploymentInfo S.DeploymentInfo) error
 }
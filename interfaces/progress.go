@@ -0,0 +1,19 @@
+package interfaces
+
+import "github.com/compozed/deployadactyl/structs"
+
+// ProgressReporter receives the progress of deployments.
+type ProgressReporter interface {
+	StartPhase(uuid, phase string, foundations int)
+	FinishFoundation(uuid string)
+	FailFoundation(uuid, foundationURL string)
+	Step(uuid, foundationURL, step string)
+	Finish(uuid string, err error)
+}
+
+// ProgressTracker keeps the progress of deployments so it can be read and streamed.
+type ProgressTracker interface {
+	ProgressReporter
+	Get(uuid string) (structs.DeploymentProgress, bool)
+	Subscribe(uuid string) (updates <-chan structs.DeploymentProgress, unsubscribe func())
+}
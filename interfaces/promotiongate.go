@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/compozed/deployadactyl/structs"
+)
+
+// PromotionGate interface.
+type PromotionGate interface {
+	Evaluate(ctx context.Context, descriptor structs.PromotionGateDescriptor, appName string) (structs.PromotionGateResult, error)
+}
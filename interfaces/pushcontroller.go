@@ -1,7 +1,9 @@
 package interfaces
 
 import (
-	"bytes"
+	"context"
+	"io"
+
 	"github.com/compozed/deployadactyl/structs"
 )
 
@@ -10,5 +12,5 @@ type PushManagerFactory interface {
 }
 
 type PushController interface {
-	RunDeployment(deployment *Deployment, response *bytes.Buffer) (deployResponse DeployResponse)
+	RunDeployment(ctx context.Context, deployment *Deployment, response io.ReadWriter) (deployResponse DeployResponse)
 }
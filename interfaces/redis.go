@@ -0,0 +1,7 @@
+package interfaces
+
+// Redis runs the commands of the stores kept in a Redis shared by every instance of the server.
+// Do returns a string, an int64, nil or a []interface{} of those.
+type Redis interface {
+	Do(args ...string) (interface{}, error)
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"github.com/compozed/deployadactyl/structs"
+)
+
+type RestageManagerFactory interface {
+	RestageManager(log DeploymentLogger, deployEventData structs.DeployEventData) ActionCreator
+}
+
+type RestageController interface {
+	RestageDeployment(ctx context.Context, deployment *Deployment, data structs.Params, response io.ReadWriter) (deployResponse DeployResponse)
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"github.com/compozed/deployadactyl/structs"
+)
+
+type RestartManagerFactory interface {
+	RestartManager(log DeploymentLogger, deployEventData structs.DeployEventData) ActionCreator
+}
+
+type RestartController interface {
+	RestartDeployment(ctx context.Context, deployment *Deployment, data structs.Params, response io.ReadWriter) (deployResponse DeployResponse)
+}
@@ -0,0 +1,9 @@
+package interfaces
+
+import "github.com/compozed/deployadactyl/structs"
+
+// SBOMGenerator interface.
+type SBOMGenerator interface {
+	Digest(appPath string) (string, error)
+	Generate(appPath string) (structs.SBOM, error)
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/compozed/deployadactyl/structs"
+)
+
+// Scanner interface.
+type Scanner interface {
+	Scan(ctx context.Context, descriptor structs.ScannerDescriptor, appPath string) (structs.ScanResult, error)
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import "time"
+
+// SharedState is the state shared by every instance of the server, so a request can be served by another instance
+// than the one running its deployment. A message published on a channel reaches the subscribers of every instance,
+// and a value put by one instance is read by all of them until its TTL runs out.
+type SharedState interface {
+	Publish(channel string, message []byte) error
+	Subscribe(channel string) (messages <-chan []byte, unsubscribe func())
+	Put(key string, value []byte, ttl time.Duration) error
+	Get(key string) (value []byte, found bool, err error)
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/compozed/deployadactyl/structs"
+)
+
+// StaleAppSweeper interface.
+type StaleAppSweeper interface {
+	Sweep(ctx context.Context, delete bool) structs.StaleAppsReport
+	LastReport() (structs.StaleAppsReport, bool)
+}
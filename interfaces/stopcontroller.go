@@ -1,7 +1,9 @@
 package interfaces
 
 import (
-	"bytes"
+	"context"
+	"io"
+
 	"github.com/compozed/deployadactyl/structs"
 )
 
@@ -10,5 +12,5 @@ type StopManagerFactory interface {
 }
 
 type StopController interface {
-	StopDeployment(deployment *Deployment, data map[string]interface{}, response *bytes.Buffer) (deployResponse DeployResponse)
+	StopDeployment(ctx context.Context, deployment *Deployment, data structs.Params, response io.ReadWriter) (deployResponse DeployResponse)
 }
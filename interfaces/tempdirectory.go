@@ -0,0 +1,10 @@
+package interfaces
+
+import "github.com/compozed/deployadactyl/structs"
+
+// TempDirectoryTracker interface.
+type TempDirectoryTracker interface {
+	Track(deploymentID, path string)
+	RemoveDeployment(deploymentID string) error
+	Report() (structs.TempDirectoryReport, error)
+}
@@ -0,0 +1,8 @@
+package interfaces
+
+import "context"
+
+// ArtifactVerifier interface.
+type ArtifactVerifier interface {
+	Verify(ctx context.Context, artifactPath string) error
+}
@@ -0,0 +1,53 @@
+package janitor
+
+import "fmt"
+
+type CourierCreationError struct {
+	Err error
+}
+
+func (e CourierCreationError) Error() string {
+	return fmt.Sprintf("cannot create courier: %s", e.Err)
+}
+
+type LoginError struct {
+	FoundationURL string
+	Out           []byte
+}
+
+func (e LoginError) Error() string {
+	return fmt.Sprintf("cannot login to %s: %s", e.FoundationURL, string(e.Out))
+}
+
+type AppStateError struct {
+	AppName       string
+	FoundationURL string
+	Err           error
+}
+
+func (e AppStateError) Error() string {
+	return fmt.Sprintf("cannot read the state of %s on %s: %s", e.AppName, e.FoundationURL, e.Err)
+}
+
+type DeleteApplicationError struct {
+	AppName       string
+	FoundationURL string
+	Out           []byte
+}
+
+func (e DeleteApplicationError) Error() string {
+	return fmt.Sprintf("cannot delete %s on %s: %s", e.AppName, e.FoundationURL, string(e.Out))
+}
+
+type DeleteReplacedApplicationsError struct {
+	Deletions []string
+	Errs      []error
+}
+
+func (e DeleteReplacedApplicationsError) Error() string {
+	message := "cannot delete replaced applications:"
+	for i, deletion := range e.Deletions {
+		message += fmt.Sprintf("\n%s: %s", deletion, e.Errs[i])
+	}
+	return message
+}
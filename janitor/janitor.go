@@ -0,0 +1,159 @@
+// Package janitor deletes the applications replaced by a deployment once their retention period is over.
+package janitor
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DefaultRetryInterval is how long a replaced application that could not be deleted waits before it is tried again.
+const DefaultRetryInterval = 5 * time.Minute
+
+// stoppedState is the state of an application that is not running.
+const stoppedState = "STOPPED"
+
+type courierCreator interface {
+	CreateCourier() (I.Courier, error)
+}
+
+// NewJanitor returns a Janitor whose couriers are created by courierCreator.
+func NewJanitor(courierCreator courierCreator, log I.Logger) *Janitor {
+	return &Janitor{
+		CourierCreator: courierCreator,
+		Log:            log,
+		RetryInterval:  DefaultRetryInterval,
+		Now:            time.Now,
+	}
+}
+
+// Janitor keeps the replaced applications that are waiting to be deleted, and deletes each of them once
+// it is due. Pending deletions are only kept in memory, so they are lost when the server restarts.
+type Janitor struct {
+	CourierCreator courierCreator
+	Log            I.Logger
+	RetryInterval  time.Duration
+	Now            func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]S.ScheduledDeletion
+}
+
+// Schedule deletes the application once its deletion is due.
+// Scheduling an application that is already pending replaces its deletion.
+func (j *Janitor) Schedule(deletion S.ScheduledDeletion) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.pending == nil {
+		j.pending = map[string]S.ScheduledDeletion{}
+	}
+	j.pending[key(deletion)] = deletion
+
+	time.AfterFunc(deletion.DeleteAt.Sub(j.Now()), func() { j.Sweep() })
+	j.Log.Infof("scheduled the deletion of %s on %s at %s", deletion.AppName, deletion.FoundationURL, deletion.DeleteAt)
+}
+
+// Pending returns the deletions that are not due yet, soonest first.
+func (j *Janitor) Pending() []S.ScheduledDeletion {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	pending := []S.ScheduledDeletion{}
+	for _, deletion := range j.pending {
+		pending = append(pending, deletion)
+	}
+	sortDeletions(pending)
+
+	return pending
+}
+
+// Sweep deletes every application whose deletion is due. An application that was started again since it was
+// replaced has been recovered, and is not deleted. Deletions that fail are tried again after the RetryInterval.
+func (j *Janitor) Sweep() error {
+	now := j.Now()
+
+	j.mu.Lock()
+	var due []S.ScheduledDeletion
+	for k, deletion := range j.pending {
+		if !deletion.DeleteAt.After(now) {
+			due = append(due, deletion)
+			delete(j.pending, k)
+		}
+	}
+	j.mu.Unlock()
+	sortDeletions(due)
+
+	deleteErr := DeleteReplacedApplicationsError{}
+	for _, deletion := range due {
+		err := j.delete(deletion)
+		if err != nil {
+			j.Log.Errorf("could not delete %s on %s: %s", deletion.AppName, deletion.FoundationURL, err)
+			deleteErr.Deletions = append(deleteErr.Deletions, deletion.AppName+" on "+deletion.FoundationURL)
+			deleteErr.Errs = append(deleteErr.Errs, err)
+
+			deletion.DeleteAt = now.Add(j.RetryInterval)
+			j.Schedule(deletion)
+		}
+	}
+
+	if len(deleteErr.Deletions) != 0 {
+		return deleteErr
+	}
+	return nil
+}
+
+func (j *Janitor) delete(deletion S.ScheduledDeletion) error {
+	courier, err := j.CourierCreator.CreateCourier()
+	if err != nil {
+		return CourierCreationError{Err: err}
+	}
+	defer courier.CleanUp()
+
+	if deletion.CABundle != "" {
+		courier = courier.WithCABundle(deletion.CABundle)
+	}
+
+	out, err := courier.Login(deletion.FoundationURL, deletion.Username, deletion.Password, deletion.Org, deletion.Space, deletion.SkipSSL)
+	if err != nil {
+		return LoginError{FoundationURL: deletion.FoundationURL, Out: out}
+	}
+
+	if !courier.Exists(deletion.AppName) {
+		j.Log.Infof("%s on %s no longer exists", deletion.AppName, deletion.FoundationURL)
+		return nil
+	}
+
+	state, err := courier.AppState(deletion.AppName)
+	if err != nil {
+		return AppStateError{AppName: deletion.AppName, FoundationURL: deletion.FoundationURL, Err: err}
+	}
+	if state.State != stoppedState {
+		j.Log.Infof("not deleting %s on %s because it was started again", deletion.AppName, deletion.FoundationURL)
+		return nil
+	}
+
+	out, err = courier.Delete(deletion.AppName)
+	if err != nil {
+		return DeleteApplicationError{AppName: deletion.AppName, FoundationURL: deletion.FoundationURL, Out: out}
+	}
+
+	j.Log.Infof("deleted the replaced application %s on %s", deletion.AppName, deletion.FoundationURL)
+	return nil
+}
+
+func key(deletion S.ScheduledDeletion) string {
+	return deletion.FoundationURL + "\x00" + deletion.Org + "\x00" + deletion.Space + "\x00" + deletion.AppName
+}
+
+func sortDeletions(deletions []S.ScheduledDeletion) {
+	sort.Slice(deletions, func(i, j int) bool {
+		if deletions[i].DeleteAt.Equal(deletions[j].DeleteAt) {
+			return key(deletions[i]) < key(deletions[j])
+		}
+		return deletions[i].DeleteAt.Before(deletions[j].DeleteAt)
+	})
+}
@@ -0,0 +1,156 @@
+package janitor_test
+
+import (
+	"errors"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/janitor"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+type courierCreator struct {
+	courier *mocks.Courier
+	err     error
+}
+
+func (c courierCreator) CreateCourier() (I.Courier, error) {
+	return c.courier, c.err
+}
+
+var _ = Describe("Janitor", func() {
+	var (
+		courier   *mocks.Courier
+		janitor   *Janitor
+		logBuffer *Buffer
+		now       time.Time
+		deletion  S.ScheduledDeletion
+	)
+
+	BeforeEach(func() {
+		courier = &mocks.Courier{}
+		courier.ExistsCall.Returns.Bool = true
+		courier.AppStateCall.Returns.AppState = S.AppState{State: "STOPPED"}
+
+		logBuffer = NewBuffer()
+		now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+		janitor = NewJanitor(courierCreator{courier: courier}, I.DefaultLogger(logBuffer, logging.DEBUG, "janitor_test"))
+		janitor.Now = func() time.Time { return now }
+
+		deletion = S.ScheduledDeletion{
+			FoundationURL: "https://api.example.com",
+			Org:           "org",
+			Space:         "space",
+			AppName:       "search-replaced-1234",
+			Username:      "username",
+			Password:      "password",
+			SkipSSL:       true,
+			DeleteAt:      now.Add(time.Hour),
+		}
+	})
+
+	It("does not delete an application before its deletion is due", func() {
+		janitor.Schedule(deletion)
+
+		Expect(janitor.Sweep()).To(Succeed())
+
+		Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+		Expect(janitor.Pending()).To(Equal([]S.ScheduledDeletion{deletion}))
+	})
+
+	It("deletes the application once its deletion is due", func() {
+		janitor.Schedule(deletion)
+		now = now.Add(time.Hour)
+
+		Expect(janitor.Sweep()).To(Succeed())
+
+		Expect(courier.LoginCall.Received.FoundationURL).To(Equal("https://api.example.com"))
+		Expect(courier.LoginCall.Received.Username).To(Equal("username"))
+		Expect(courier.LoginCall.Received.Password).To(Equal("password"))
+		Expect(courier.LoginCall.Received.Org).To(Equal("org"))
+		Expect(courier.LoginCall.Received.Space).To(Equal("space"))
+		Expect(courier.LoginCall.Received.SkipSSL).To(BeTrue())
+		Expect(courier.DeleteCall.Received.AppName).To(Equal("search-replaced-1234"))
+		Expect(courier.CleanUpCall.Called).To(BeTrue())
+		Expect(janitor.Pending()).To(BeEmpty())
+		Expect(logBuffer).To(Say("deleted the replaced application search-replaced-1234 on https://api.example.com"))
+	})
+
+	It("replaces the deletion of an application that is scheduled again", func() {
+		janitor.Schedule(deletion)
+		deletion.DeleteAt = now.Add(2 * time.Hour)
+		janitor.Schedule(deletion)
+		now = now.Add(time.Hour)
+
+		Expect(janitor.Sweep()).To(Succeed())
+
+		Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+		Expect(janitor.Pending()).To(HaveLen(1))
+	})
+
+	It("does not delete an application that was started again", func() {
+		courier.AppStateCall.Returns.AppState = S.AppState{State: "STARTED"}
+		janitor.Schedule(deletion)
+		now = now.Add(time.Hour)
+
+		Expect(janitor.Sweep()).To(Succeed())
+
+		Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+		Expect(janitor.Pending()).To(BeEmpty())
+		Expect(logBuffer).To(Say("not deleting search-replaced-1234 on https://api.example.com because it was started again"))
+	})
+
+	It("does not delete an application that no longer exists", func() {
+		courier.ExistsCall.Returns.Bool = false
+		janitor.Schedule(deletion)
+		now = now.Add(time.Hour)
+
+		Expect(janitor.Sweep()).To(Succeed())
+
+		Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+		Expect(janitor.Pending()).To(BeEmpty())
+	})
+
+	It("tries a deletion that failed again after the retry interval", func() {
+		courier.DeleteCall.Returns.Error = errors.New("delete failed")
+		courier.DeleteCall.Returns.Output = []byte("app is busy")
+		janitor.Schedule(deletion)
+		now = now.Add(time.Hour)
+
+		err := janitor.Sweep()
+
+		Expect(err).To(MatchError(DeleteReplacedApplicationsError{
+			Deletions: []string{"search-replaced-1234 on https://api.example.com"},
+			Errs:      []error{DeleteApplicationError{AppName: "search-replaced-1234", FoundationURL: "https://api.example.com", Out: []byte("app is busy")}},
+		}))
+		Expect(janitor.Pending()).To(HaveLen(1))
+		Expect(janitor.Pending()[0].DeleteAt).To(Equal(now.Add(DefaultRetryInterval)))
+	})
+
+	It("returns an error when it cannot login", func() {
+		courier.LoginCall.Returns.Error = errors.New("login failed")
+		courier.LoginCall.Returns.Output = []byte("bad credentials")
+		janitor.Schedule(deletion)
+		now = now.Add(time.Hour)
+
+		err := janitor.Sweep()
+
+		Expect(err).To(MatchError(ContainSubstring("cannot login to https://api.example.com: bad credentials")))
+		Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+	})
+
+	It("returns an error when it cannot create a courier", func() {
+		janitor.CourierCreator = courierCreator{err: errors.New("no cf cli")}
+		janitor.Schedule(deletion)
+		now = now.Add(time.Hour)
+
+		err := janitor.Sweep()
+
+		Expect(err).To(MatchError(ContainSubstring("cannot create courier: no cf cli")))
+	})
+})
@@ -0,0 +1,232 @@
+package janitor
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DefaultStaleAppMaxAge is how old a temporary application has to be before it is stale, when the sweep
+// is not configured with an age.
+const DefaultStaleAppMaxAge = 2 * time.Hour
+
+// NewStaleAppSweeper returns a StaleAppSweeper of the foundations of every environment, which logs into them
+// with the credentials of the server, or those of the tenant of the environment.
+func NewStaleAppSweeper(courierCreator courierCreator, progress I.ProgressTracker, log I.Logger, environments map[string]S.Environment, auth I.Authorization, descriptor S.StaleAppsDescriptor) *StaleAppSweeper {
+	maxAge := time.Duration(descriptor.MaxAgeMinutes) * time.Minute
+	if maxAge <= 0 {
+		maxAge = DefaultStaleAppMaxAge
+	}
+
+	return &StaleAppSweeper{
+		CourierCreator: courierCreator,
+		Progress:       progress,
+		Log:            log,
+		Environments:   environments,
+		Auth:           auth,
+		MaxAge:         maxAge,
+		Interval:       time.Duration(descriptor.IntervalMinutes) * time.Minute,
+		Delete:         descriptor.Delete,
+		Now:            time.Now,
+	}
+}
+
+// StaleAppSweeper finds the temporary applications left behind by deployments that crashed or were cancelled,
+// which are named after the TemporaryNameSuffix and are older than the MaxAge. The temporary applications of
+// deployments that are still running are never stale.
+type StaleAppSweeper struct {
+	CourierCreator courierCreator
+	Progress       I.ProgressTracker
+	Log            I.Logger
+	Environments   map[string]S.Environment
+	Auth           I.Authorization
+	Credentials    I.CredentialStore
+	MaxAge         time.Duration
+	Interval       time.Duration
+	Delete         bool
+	Now            func() time.Time
+
+	sweeping sync.Mutex
+	mu       sync.Mutex
+	last     *S.StaleAppsReport
+}
+
+// Run sweeps every Interval until ctx is done, deleting the stale applications if Delete is set.
+// It does nothing when there is no Interval.
+func (s *StaleAppSweeper) Run(ctx context.Context) {
+	if s.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report := s.Sweep(ctx, s.Delete)
+		s.Log.Infof("found %d stale apps and could not sweep %d foundations", len(report.Apps), len(report.Errors))
+	}
+}
+
+// Sweep finds the stale applications on every foundation, and deletes them if delete is set.
+// Only one sweep runs at a time.
+func (s *StaleAppSweeper) Sweep(ctx context.Context, delete bool) S.StaleAppsReport {
+	s.sweeping.Lock()
+	defer s.sweeping.Unlock()
+
+	type target struct {
+		environment   S.Environment
+		foundationURL string
+	}
+	var targets []target
+	seen := map[string]bool{}
+	for _, name := range environmentNames(s.Environments) {
+		environment := s.Environments[name]
+		environment.Name = name
+		for _, foundationURL := range environment.Foundations {
+			key := foundationURL + "\x00" + environment.Tenant
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			targets = append(targets, target{environment, foundationURL})
+		}
+	}
+
+	report := S.StaleAppsReport{SweptAt: s.Now(), Apps: []S.StaleApp{}, Errors: []S.StaleAppsFoundationError{}}
+	apps := make([][]S.StaleApp, len(targets))
+	errs := make([]error, len(targets))
+
+	wg := sync.WaitGroup{}
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, environment S.Environment, foundationURL string) {
+			defer wg.Done()
+			apps[i], errs[i] = s.sweepFoundation(ctx, environment, foundationURL, report.SweptAt, delete)
+		}(i, t.environment, t.foundationURL)
+	}
+	wg.Wait()
+
+	for i, t := range targets {
+		report.Apps = append(report.Apps, apps[i]...)
+		if errs[i] != nil {
+			s.Log.Errorf("cannot sweep %s for stale apps: %s", t.foundationURL, errs[i])
+			report.Errors = append(report.Errors, S.StaleAppsFoundationError{
+				Environment:   t.environment.Name,
+				FoundationURL: t.foundationURL,
+				Error:         errs[i].Error(),
+			})
+		}
+	}
+
+	s.mu.Lock()
+	s.last = &report
+	s.mu.Unlock()
+
+	return report
+}
+
+// LastReport returns the report of the last sweep.
+func (s *StaleAppSweeper) LastReport() (S.StaleAppsReport, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.last == nil {
+		return S.StaleAppsReport{}, false
+	}
+	return *s.last, true
+}
+
+func (s *StaleAppSweeper) sweepFoundation(ctx context.Context, environment S.Environment, foundationURL string, now time.Time, delete bool) ([]S.StaleApp, error) {
+	courier, err := s.CourierCreator.CreateCourier()
+	if err != nil {
+		return nil, CourierCreationError{Err: err}
+	}
+	defer courier.CleanUp()
+
+	courier = courier.WithContext(ctx)
+	if environment.CABundle != "" {
+		courier = courier.WithCABundle(environment.CABundle)
+	}
+
+	username, password := environment.Credentials(s.Auth.Username, s.Auth.Password)
+	if environment.VaultPath != "" && s.Credentials != nil {
+		username, password, err = s.Credentials.Credentials(environment.VaultPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	out, err := courier.Authenticate(foundationURL, username, password, environment.SkipSSL)
+	if err != nil {
+		return nil, LoginError{FoundationURL: foundationURL, Out: out}
+	}
+
+	apps, err := courier.Apps()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := now.Add(-s.MaxAge)
+	var stale []S.StaleApp
+	for _, app := range apps {
+		i := strings.Index(app.Name, push.TemporaryNameSuffix)
+		if i <= 0 || app.CreatedAt.After(cutoff) || s.running(app.Name[i+len(push.TemporaryNameSuffix):]) {
+			continue
+		}
+
+		staleApp := S.StaleApp{
+			Environment:   environment.Name,
+			FoundationURL: foundationURL,
+			Org:           app.Org,
+			Space:         app.Space,
+			AppName:       app.Name,
+			CreatedAt:     app.CreatedAt,
+		}
+
+		if delete {
+			out, err := courier.DeleteByGUID(app.GUID)
+			if err != nil {
+				s.Log.Errorf("could not delete the stale app %s in %s/%s on %s: %s", app.Name, app.Org, app.Space, foundationURL, string(out))
+				staleApp.Error = DeleteApplicationError{AppName: app.Name, FoundationURL: foundationURL, Out: out}.Error()
+			} else {
+				s.Log.Infof("deleted the stale app %s in %s/%s on %s", app.Name, app.Org, app.Space, foundationURL)
+				staleApp.Deleted = true
+			}
+		}
+
+		stale = append(stale, staleApp)
+	}
+
+	return stale, nil
+}
+
+// running reports whether the deployment is still running.
+func (s *StaleAppSweeper) running(uuid string) bool {
+	if s.Progress == nil {
+		return false
+	}
+
+	progress, ok := s.Progress.Get(uuid)
+	return ok && !progress.Finished
+}
+
+func environmentNames(environments map[string]S.Environment) []string {
+	var names []string
+	for name := range environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,155 @@
+package janitor_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/janitor"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/progress"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("StaleAppSweeper", func() {
+	var (
+		courier   *mocks.Courier
+		tracker   *progress.Tracker
+		sweeper   *StaleAppSweeper
+		logBuffer *Buffer
+		now       time.Time
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+		courier = &mocks.Courier{}
+		courier.AppsCall.Returns.Apps = []S.AppSummary{
+			{GUID: "stale-guid", Name: "search-new-build-abc", Org: "org", Space: "space", CreatedAt: now.Add(-3 * time.Hour)},
+			{GUID: "young-guid", Name: "search-new-build-def", Org: "org", Space: "space", CreatedAt: now.Add(-time.Hour)},
+			{GUID: "app-guid", Name: "search", Org: "org", Space: "space", CreatedAt: now.Add(-30 * 24 * time.Hour)},
+		}
+
+		tracker = progress.NewTracker()
+		logBuffer = NewBuffer()
+
+		sweeper = NewStaleAppSweeper(
+			courierCreator{courier: courier},
+			tracker,
+			I.DefaultLogger(logBuffer, logging.DEBUG, "stale_test"),
+			map[string]S.Environment{"prod": {Foundations: []string{"https://api.example.com"}, SkipSSL: true, CABundle: "/etc/ssl/ca.pem"}},
+			I.Authorization{Username: "username", Password: "password"},
+			S.StaleAppsDescriptor{},
+		)
+		sweeper.Now = func() time.Time { return now }
+	})
+
+	It("reports the temporary apps older than the max age", func() {
+		report := sweeper.Sweep(context.Background(), false)
+
+		Expect(report.SweptAt).To(Equal(now))
+		Expect(report.Apps).To(Equal([]S.StaleApp{{
+			Environment:   "prod",
+			FoundationURL: "https://api.example.com",
+			Org:           "org",
+			Space:         "space",
+			AppName:       "search-new-build-abc",
+			CreatedAt:     now.Add(-3 * time.Hour),
+		}}))
+		Expect(report.Errors).To(BeEmpty())
+		Expect(courier.DeleteByGUIDCall.Received.GUIDs).To(BeEmpty())
+
+		Expect(courier.AuthenticateCall.Received.FoundationURL).To(Equal("https://api.example.com"))
+		Expect(courier.AuthenticateCall.Received.Username).To(Equal("username"))
+		Expect(courier.AuthenticateCall.Received.Password).To(Equal("password"))
+		Expect(courier.AuthenticateCall.Received.SkipSSL).To(BeTrue())
+		Expect(courier.WithCABundleCall.Received.Path).To(Equal("/etc/ssl/ca.pem"))
+		Expect(courier.CleanUpCall.Called).To(BeTrue())
+	})
+
+	It("deletes the stale apps", func() {
+		report := sweeper.Sweep(context.Background(), true)
+
+		Expect(courier.DeleteByGUIDCall.Received.GUIDs).To(Equal([]string{"stale-guid"}))
+		Expect(report.Apps).To(HaveLen(1))
+		Expect(report.Apps[0].Deleted).To(BeTrue())
+		Expect(logBuffer).To(Say("deleted the stale app search-new-build-abc in org/space on https://api.example.com"))
+	})
+
+	It("reports a stale app that cannot be deleted", func() {
+		courier.DeleteByGUIDCall.Returns.Error = errors.New("delete failed")
+		courier.DeleteByGUIDCall.Returns.Output = []byte("not authorized")
+
+		report := sweeper.Sweep(context.Background(), true)
+
+		Expect(report.Apps).To(HaveLen(1))
+		Expect(report.Apps[0].Deleted).To(BeFalse())
+		Expect(report.Apps[0].Error).To(ContainSubstring("not authorized"))
+	})
+
+	It("does not report the temporary app of a deployment that is still running", func() {
+		tracker.StartPhase("abc", S.PhaseExecuting, 1)
+
+		report := sweeper.Sweep(context.Background(), true)
+
+		Expect(report.Apps).To(BeEmpty())
+		Expect(courier.DeleteByGUIDCall.Received.GUIDs).To(BeEmpty())
+	})
+
+	It("reports the temporary app of a deployment that finished", func() {
+		tracker.StartPhase("abc", S.PhaseExecuting, 1)
+		tracker.Finish("abc", errors.New("cancelled"))
+
+		report := sweeper.Sweep(context.Background(), false)
+
+		Expect(report.Apps).To(HaveLen(1))
+	})
+
+	It("uses the max age of the descriptor", func() {
+		sweeper = NewStaleAppSweeper(courierCreator{courier: courier}, tracker, I.DefaultLogger(logBuffer, logging.DEBUG, "stale_test"),
+			sweeper.Environments, sweeper.Auth, S.StaleAppsDescriptor{MaxAgeMinutes: 30})
+		sweeper.Now = func() time.Time { return now }
+
+		report := sweeper.Sweep(context.Background(), false)
+
+		Expect(report.Apps).To(HaveLen(2))
+	})
+
+	It("sweeps a foundation shared by several environments once", func() {
+		sweeper.Environments["stage"] = S.Environment{Foundations: []string{"https://api.example.com"}}
+
+		report := sweeper.Sweep(context.Background(), false)
+
+		Expect(report.Apps).To(HaveLen(1))
+	})
+
+	It("reports a foundation it cannot log into", func() {
+		courier.AuthenticateCall.Returns.Error = errors.New("login failed")
+		courier.AuthenticateCall.Returns.Output = []byte("bad credentials")
+
+		report := sweeper.Sweep(context.Background(), false)
+
+		Expect(report.Apps).To(BeEmpty())
+		Expect(report.Errors).To(Equal([]S.StaleAppsFoundationError{{
+			Environment:   "prod",
+			FoundationURL: "https://api.example.com",
+			Error:         "cannot login to https://api.example.com: bad credentials",
+		}}))
+	})
+
+	It("keeps the report of the last sweep", func() {
+		_, found := sweeper.LastReport()
+		Expect(found).To(BeFalse())
+
+		report := sweeper.Sweep(context.Background(), false)
+
+		last, found := sweeper.LastReport()
+		Expect(found).To(BeTrue())
+		Expect(last).To(Equal(report))
+	})
+})
@@ -0,0 +1,168 @@
+package ldap
+
+import (
+	"bufio"
+	"io"
+)
+
+// The identifiers of the BER elements of the protocol.
+const (
+	berBoolean     = 0x01
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berEnumerated  = 0x0a
+	berSequence    = 0x30
+	berSet         = 0x31
+
+	// constructed marks the elements holding other elements.
+	constructed = 0x20
+)
+
+// maxPacket is the largest message read from the server.
+const maxPacket = 16 << 20
+
+// packet is a BER element, with its elements when it is constructed.
+type packet struct {
+	identifier byte
+	value      []byte
+	children   []*packet
+}
+
+// element returns the encoding of an element with the identifier and content.
+func element(identifier byte, content []byte) []byte {
+	encoded := append([]byte{identifier}, length(len(content))...)
+	return append(encoded, content...)
+}
+
+func length(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+func sequence(identifier byte, elements ...[]byte) []byte {
+	var content []byte
+	for _, e := range elements {
+		content = append(content, e...)
+	}
+	return element(identifier, content)
+}
+
+func octetString(identifier byte, s string) []byte {
+	return element(identifier, []byte(s))
+}
+
+// integer returns the encoding of a non negative integer, such as a message ID or a size limit.
+func integer(identifier byte, n int) []byte {
+	b := []byte{byte(n)}
+	for n >>= 8; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	if b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return element(identifier, b)
+}
+
+func boolean(b bool) []byte {
+	if b {
+		return element(berBoolean, []byte{0xff})
+	}
+	return element(berBoolean, []byte{0})
+}
+
+// readPacket reads the next element from the reader.
+func readPacket(reader *bufio.Reader) (*packet, error) {
+	identifier, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	size := int(first)
+	if first&0x80 != 0 {
+		n := int(first & 0x7f)
+		if n == 0 || n > 4 {
+			return nil, MalformedPacketError{"unsupported length"}
+		}
+		size = 0
+		for i := 0; i < n; i++ {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			size = size<<8 | int(b)
+		}
+	}
+	if size > maxPacket {
+		return nil, MalformedPacketError{"message too large"}
+	}
+
+	value := make([]byte, size)
+	_, err = io.ReadFull(reader, value)
+	if err != nil {
+		return nil, err
+	}
+	return parse(identifier, value)
+}
+
+// parse returns the element with the identifier and value, and its elements when it is constructed.
+func parse(identifier byte, value []byte) (*packet, error) {
+	p := &packet{identifier: identifier, value: value}
+	if identifier&constructed == 0 {
+		return p, nil
+	}
+
+	for len(value) > 0 {
+		if len(value) < 2 {
+			return nil, MalformedPacketError{"truncated element"}
+		}
+		childIdentifier := value[0]
+		size := int(value[1])
+		header := 2
+		if value[1]&0x80 != 0 {
+			n := int(value[1] & 0x7f)
+			if n == 0 || n > 4 || len(value) < 2+n {
+				return nil, MalformedPacketError{"unsupported length"}
+			}
+			size = 0
+			for _, b := range value[2 : 2+n] {
+				size = size<<8 | int(b)
+			}
+			header += n
+		}
+		if size < 0 || len(value) < header+size {
+			return nil, MalformedPacketError{"truncated element"}
+		}
+
+		child, err := parse(childIdentifier, value[header:header+size])
+		if err != nil {
+			return nil, err
+		}
+		p.children = append(p.children, child)
+		value = value[header+size:]
+	}
+	return p, nil
+}
+
+// int returns the value of an integer or enumerated element.
+func (p *packet) int() int {
+	n := 0
+	for _, b := range p.value {
+		n = n<<8 | int(b)
+	}
+	return n
+}
+
+func (p *packet) string() string {
+	return string(p.value)
+}
@@ -0,0 +1,240 @@
+package ldap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+)
+
+// The identifiers of the operations of the protocol.
+const (
+	bindRequest           = 0x60
+	bindResponse          = 0x61
+	unbindRequest         = 0x42
+	searchRequest         = 0x63
+	searchResultEntry     = 0x64
+	searchResultDone      = 0x65
+	searchResultReference = 0x73
+	extendedRequest       = 0x77
+	extendedResponse      = 0x78
+
+	// simpleAuthentication is the password of a simple bind.
+	simpleAuthentication = 0x80
+	// requestName is the OID of an extended request.
+	requestName = 0x80
+)
+
+// The result codes the client tells apart.
+const (
+	resultSuccess            = 0
+	resultInvalidCredentials = 49
+)
+
+// startTLSOID is the name of the extended request starting TLS.
+const startTLSOID = "1.3.6.1.4.1.1466.20037"
+
+const scopeSubtree = 2
+
+// entry is an entry found by a search, with its attributes keyed by their lower case name.
+type entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// conn is a connection to the LDAP server, which runs one operation at a time.
+type conn struct {
+	net.Conn
+	reader    *bufio.Reader
+	timeout   time.Duration
+	messageID int
+}
+
+// dial opens a connection to the address, with TLS from the start for ldaps or after a StartTLS request when
+// startTLS is set.
+func dial(address string, secure, startTLS bool, tlsConfig *tls.Config, timeout time.Duration) (*conn, error) {
+	raw, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, ConnectError{address, err}
+	}
+
+	if secure {
+		raw, err = handshake(raw, address, tlsConfig, timeout)
+		if err != nil {
+			return nil, ConnectError{address, err}
+		}
+	}
+
+	c := &conn{Conn: raw, reader: bufio.NewReader(raw), timeout: timeout}
+	if startTLS {
+		_, err = c.do(sequence(extendedRequest, octetString(requestName, startTLSOID)), extendedResponse)
+		if err != nil {
+			c.Conn.Close()
+			return nil, ConnectError{address, err}
+		}
+
+		secured, err := handshake(raw, address, tlsConfig, timeout)
+		if err != nil {
+			return nil, ConnectError{address, err}
+		}
+		c.Conn = secured
+		c.reader = bufio.NewReader(secured)
+	}
+	return c, nil
+}
+
+func handshake(raw net.Conn, address string, tlsConfig *tls.Config, timeout time.Duration) (net.Conn, error) {
+	config := tlsConfig.Clone()
+	if config.ServerName == "" {
+		host, _, _ := net.SplitHostPort(address)
+		config.ServerName = host
+	}
+
+	secure := tls.Client(raw, config)
+	secure.SetDeadline(time.Now().Add(timeout))
+	err := secure.Handshake()
+	if err != nil {
+		raw.Close()
+		return nil, err
+	}
+	return secure, nil
+}
+
+// bind authenticates the connection as the DN with the password.
+func (c *conn) bind(dn, password string) error {
+	_, err := c.do(sequence(bindRequest, integer(berInteger, 3), octetString(berOctetString, dn), octetString(simpleAuthentication, password)), bindResponse)
+	return err
+}
+
+// search returns the entries under the base matching the filter, with the attributes.
+func (c *conn) search(base, filter string, attributes []string) ([]entry, error) {
+	compiled, err := compileFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var requested [][]byte
+	for _, attribute := range attributes {
+		requested = append(requested, octetString(berOctetString, attribute))
+	}
+
+	request := sequence(searchRequest,
+		octetString(berOctetString, base),
+		integer(berEnumerated, scopeSubtree),
+		integer(berEnumerated, 0),
+		integer(berInteger, 0),
+		integer(berInteger, int(c.timeout/time.Second)),
+		boolean(false),
+		compiled,
+		sequence(berSequence, requested...),
+	)
+	err = c.send(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for {
+		op, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.identifier {
+		case searchResultEntry:
+			entries = append(entries, readEntry(op))
+		case searchResultReference:
+		case searchResultDone:
+			return entries, result(op)
+		default:
+			return nil, MalformedPacketError{"unexpected operation in search results"}
+		}
+	}
+}
+
+// Close tells the server the connection is done with and closes it.
+func (c *conn) Close() error {
+	c.send(element(unbindRequest, nil))
+	return c.Conn.Close()
+}
+
+// do sends the request and returns its response, which must be the expected operation and succeed.
+func (c *conn) do(request []byte, expected byte) (*packet, error) {
+	err := c.send(request)
+	if err != nil {
+		return nil, err
+	}
+
+	op, err := c.receive()
+	if err != nil {
+		return nil, err
+	}
+	if op.identifier != expected {
+		return nil, MalformedPacketError{"unexpected operation in response"}
+	}
+	return op, result(op)
+}
+
+func (c *conn) send(op []byte) error {
+	c.messageID++
+	c.SetDeadline(time.Now().Add(c.timeout))
+	_, err := c.Write(sequence(berSequence, integer(berInteger, c.messageID), op))
+	return err
+}
+
+// receive returns the operation of the next response to the last request.
+func (c *conn) receive() (*packet, error) {
+	for {
+		message, err := readPacket(c.reader)
+		if err != nil {
+			return nil, err
+		}
+		if message.identifier != berSequence || len(message.children) < 2 {
+			return nil, MalformedPacketError{"message is not a sequence"}
+		}
+		// Unsolicited notifications, such as a notice of disconnection, have the message ID 0.
+		if message.children[0].int() == c.messageID {
+			return message.children[1], nil
+		}
+		if message.children[0].int() == 0 {
+			err = result(message.children[1])
+			if err == nil {
+				err = MalformedPacketError{"unsolicited notification"}
+			}
+			return nil, err
+		}
+	}
+}
+
+// result returns the error of a response, or nil when it succeeded.
+func result(op *packet) error {
+	if len(op.children) < 3 {
+		return MalformedPacketError{"response has no result"}
+	}
+
+	code := op.children[0].int()
+	if code == resultSuccess {
+		return nil
+	}
+	return ResultError{code, op.children[2].string()}
+}
+
+func readEntry(op *packet) entry {
+	e := entry{Attributes: map[string][]string{}}
+	if len(op.children) < 2 {
+		return e
+	}
+
+	e.DN = op.children[0].string()
+	for _, attribute := range op.children[1].children {
+		if len(attribute.children) < 2 {
+			continue
+		}
+		name := strings.ToLower(attribute.children[0].string())
+		for _, value := range attribute.children[1].children {
+			e.Attributes[name] = append(e.Attributes[name], value.string())
+		}
+	}
+	return e
+}
@@ -0,0 +1,98 @@
+package ldap
+
+import (
+	"fmt"
+	"strings"
+)
+
+type InvalidURLError struct {
+	URL string
+}
+
+func (e InvalidURLError) Error() string {
+	return fmt.Sprintf("the ldap url must be an ldap url, or an ldaps url without start_tls: %s", e.URL)
+}
+
+type MissingUserBaseDNError struct{}
+
+func (e MissingUserBaseDNError) Error() string {
+	return "ldap needs the user_base_dn the users are searched under"
+}
+
+type InvalidFilterError struct {
+	Filter string
+}
+
+func (e InvalidFilterError) Error() string {
+	return fmt.Sprintf("invalid ldap filter %s", e.Filter)
+}
+
+type InvalidTimeoutError struct {
+	Seconds int
+}
+
+func (e InvalidTimeoutError) Error() string {
+	return fmt.Sprintf("the timeout of the ldap operations must not be negative: %d", e.Seconds)
+}
+
+type ConnectError struct {
+	Address string
+	Err     error
+}
+
+func (e ConnectError) Error() string {
+	return fmt.Sprintf("cannot connect to ldap at %s: %s", e.Address, e.Err)
+}
+
+type MalformedPacketError struct {
+	Reason string
+}
+
+func (e MalformedPacketError) Error() string {
+	return fmt.Sprintf("malformed ldap message: %s", e.Reason)
+}
+
+// ResultError is a response of the server that is not a success, such as invalid credentials.
+type ResultError struct {
+	Code    int
+	Message string
+}
+
+func (e ResultError) Error() string {
+	return fmt.Sprintf("ldap result code %d: %s", e.Code, e.Message)
+}
+
+type BindError struct {
+	DN  string
+	Err error
+}
+
+func (e BindError) Error() string {
+	return fmt.Sprintf("cannot bind to ldap as %s: %s", e.DN, e.Err)
+}
+
+type SearchError struct {
+	BaseDN string
+	Err    error
+}
+
+func (e SearchError) Error() string {
+	return fmt.Sprintf("cannot search ldap under %s: %s", e.BaseDN, e.Err)
+}
+
+type InvalidCredentialsError struct {
+	Username string
+}
+
+func (e InvalidCredentialsError) Error() string {
+	return fmt.Sprintf("invalid ldap credentials for user %s", e.Username)
+}
+
+type ForbiddenError struct {
+	Username string
+	Groups   []string
+}
+
+func (e ForbiddenError) Error() string {
+	return fmt.Sprintf("user %s is not a member of any of the ldap groups %s", e.Username, strings.Join(e.Groups, ", "))
+}
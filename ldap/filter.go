@@ -0,0 +1,147 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// The identifiers of the filters of a search.
+const (
+	filterAnd        = 0xa0
+	filterOr         = 0xa1
+	filterNot        = 0xa2
+	filterEquality   = 0xa3
+	filterSubstrings = 0xa4
+	filterPresent    = 0x87
+
+	substringInitial = 0x80
+	substringAny     = 0x81
+	substringFinal   = 0x82
+)
+
+var filterEscaper = strings.NewReplacer(`\`, `\5c`, `*`, `\2a`, `(`, `\28`, `)`, `\29`, "\x00", `\00`)
+
+// EscapeFilter escapes the characters of the value that have a meaning in a filter, so a username cannot change the
+// filter it is put in.
+func EscapeFilter(value string) string {
+	return filterEscaper.Replace(value)
+}
+
+// compileFilter returns the encoding of a filter of RFC 4515, such as (&(objectClass=person)(uid=jane)). It supports
+// the and, or and not filters, and the equality, presence and substrings matches.
+func compileFilter(filter string) ([]byte, error) {
+	encoded, rest, err := compile(filter)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, InvalidFilterError{filter}
+	}
+	return encoded, nil
+}
+
+func compile(filter string) ([]byte, string, error) {
+	if len(filter) < 2 || filter[0] != '(' {
+		return nil, "", InvalidFilterError{filter}
+	}
+
+	switch filter[1] {
+	case '&', '|':
+		identifier := byte(filterAnd)
+		if filter[1] == '|' {
+			identifier = filterOr
+		}
+		var filters [][]byte
+		rest := filter[2:]
+		for strings.HasPrefix(rest, "(") {
+			encoded, remaining, err := compile(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			filters = append(filters, encoded)
+			rest = remaining
+		}
+		if len(filters) == 0 || !strings.HasPrefix(rest, ")") {
+			return nil, "", InvalidFilterError{filter}
+		}
+		return sequence(identifier, filters...), rest[1:], nil
+
+	case '!':
+		encoded, rest, err := compile(filter[2:])
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.HasPrefix(rest, ")") {
+			return nil, "", InvalidFilterError{filter}
+		}
+		return sequence(filterNot, encoded), rest[1:], nil
+	}
+
+	end := strings.Index(filter, ")")
+	if end < 0 {
+		return nil, "", InvalidFilterError{filter}
+	}
+	item := filter[1:end]
+	equals := strings.Index(item, "=")
+	if equals < 1 || strings.ContainsAny(item[equals-1:equals], "<>~:") {
+		return nil, "", InvalidFilterError{filter}
+	}
+	attribute, value := item[:equals], item[equals+1:]
+
+	if value == "*" {
+		return octetString(filterPresent, attribute), filter[end+1:], nil
+	}
+
+	if !strings.Contains(value, "*") {
+		unescaped, err := unescapeFilter(value)
+		if err != nil {
+			return nil, "", InvalidFilterError{filter}
+		}
+		return sequence(filterEquality, octetString(berOctetString, attribute), octetString(berOctetString, unescaped)), filter[end+1:], nil
+	}
+
+	parts := strings.Split(value, "*")
+	var substrings [][]byte
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		unescaped, err := unescapeFilter(part)
+		if err != nil {
+			return nil, "", InvalidFilterError{filter}
+		}
+		identifier := byte(substringAny)
+		if i == 0 {
+			identifier = substringInitial
+		} else if i == len(parts)-1 {
+			identifier = substringFinal
+		}
+		substrings = append(substrings, octetString(identifier, unescaped))
+	}
+	return sequence(filterSubstrings, octetString(berOctetString, attribute), sequence(berSequence, substrings...)), filter[end+1:], nil
+}
+
+// unescapeFilter returns the value with its \XX escapes replaced by the bytes they stand for.
+func unescapeFilter(value string) (string, error) {
+	if !strings.Contains(value, `\`) {
+		return value, nil
+	}
+
+	var unescaped []byte
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			unescaped = append(unescaped, value[i])
+			continue
+		}
+		if i+3 > len(value) {
+			return "", InvalidFilterError{value}
+		}
+		b, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return "", InvalidFilterError{value}
+		}
+		unescaped = append(unescaped, b...)
+		i += 2
+	}
+	return string(unescaped), nil
+}
@@ -0,0 +1,245 @@
+// Package ldap checks the basic auth of the requests against an LDAP or Active Directory server, and the groups of
+// their user against the groups allowed to deploy to an environment. It speaks just enough of LDAPv3 over TCP,
+// optionally with TLS, to bind and search.
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/compozed/deployadactyl/cabundle"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// DefaultTimeout is how long connecting to the server and every operation may take when the configuration has no
+// timeout.
+const DefaultTimeout = 10 * time.Second
+
+// The defaults of the searches of the users and their groups.
+const (
+	DefaultUserFilter     = "(uid=%s)"
+	DefaultGroupAttribute = "memberOf"
+	DefaultGroupFilter    = "(member=%s)"
+)
+
+// Validate returns an error when the URL is not an ldap or ldaps URL, the filters are not valid, there is no base DN
+// for the users, or the timeout is negative.
+func Validate(descriptor S.LDAPDescriptor) error {
+	if descriptor.URL == "" {
+		return nil
+	}
+
+	server, err := url.Parse(descriptor.URL)
+	if err != nil || (server.Scheme != "ldap" && server.Scheme != "ldaps") || server.Host == "" {
+		return InvalidURLError{descriptor.URL}
+	}
+	if server.Scheme == "ldaps" && descriptor.StartTLS {
+		return InvalidURLError{descriptor.URL}
+	}
+	if descriptor.UserBaseDN == "" {
+		return MissingUserBaseDNError{}
+	}
+
+	for _, filter := range []string{descriptor.UserFilter, descriptor.GroupFilter} {
+		if filter == "" {
+			continue
+		}
+		if strings.Count(filter, "%s") != 1 {
+			return InvalidFilterError{filter}
+		}
+		_, err := compileFilter(fmt.Sprintf(filter, "user"))
+		if err != nil {
+			return err
+		}
+	}
+
+	if descriptor.TimeoutSeconds < 0 {
+		return InvalidTimeoutError{descriptor.TimeoutSeconds}
+	}
+	return nil
+}
+
+// New returns the Authenticator of the descriptor. It is nil without a URL.
+func New(descriptor S.LDAPDescriptor, fileSystem *afero.Afero) (*Authenticator, error) {
+	if descriptor.URL == "" {
+		return nil, nil
+	}
+
+	tlsConfig, err := cabundle.TLSConfig(fileSystem, descriptor.CABundle)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.InsecureSkipVerify = descriptor.SkipSSL
+
+	authenticator := NewAuthenticator(descriptor)
+	authenticator.TLSConfig = tlsConfig
+	return authenticator, nil
+}
+
+// NewAuthenticator returns an Authenticator of the server of the descriptor, with its defaults.
+func NewAuthenticator(descriptor S.LDAPDescriptor) *Authenticator {
+	if descriptor.UserFilter == "" {
+		descriptor.UserFilter = DefaultUserFilter
+	}
+	if descriptor.GroupAttribute == "" {
+		descriptor.GroupAttribute = DefaultGroupAttribute
+	}
+	if descriptor.GroupFilter == "" {
+		descriptor.GroupFilter = DefaultGroupFilter
+	}
+
+	timeout := DefaultTimeout
+	if descriptor.TimeoutSeconds > 0 {
+		timeout = time.Duration(descriptor.TimeoutSeconds) * time.Second
+	}
+
+	return &Authenticator{Descriptor: descriptor, TLSConfig: &tls.Config{}, Timeout: timeout}
+}
+
+// Authenticator checks the credentials of users against the LDAP server. Every check opens its own connection, so
+// a password changed or a user removed from a group on the server is taken into account by the next request.
+type Authenticator struct {
+	Descriptor S.LDAPDescriptor
+	TLSConfig  *tls.Config
+	Timeout    time.Duration
+}
+
+// Authenticate returns the groups of the user, by DN and by common name. It returns an InvalidCredentialsError unless
+// the password is that of the user, and a ForbiddenError when there are groups and the user is a member of none of
+// them. A group is given by its DN or by its common name.
+func (a *Authenticator) Authenticate(username, password string, groups []string) ([]string, error) {
+	// An empty password would be an unauthenticated bind, which servers accept for any DN.
+	if username == "" || password == "" {
+		return nil, InvalidCredentialsError{username}
+	}
+
+	c, err := a.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	err = a.bindService(c)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := []string{a.Descriptor.GroupAttribute}
+	if a.Descriptor.GroupBaseDN != "" {
+		attributes = []string{"1.1"}
+	}
+	users, err := c.search(a.Descriptor.UserBaseDN, fmt.Sprintf(a.Descriptor.UserFilter, EscapeFilter(username)), attributes)
+	if err != nil {
+		return nil, SearchError{a.Descriptor.UserBaseDN, err}
+	}
+	if len(users) != 1 {
+		return nil, InvalidCredentialsError{username}
+	}
+	user := users[0]
+
+	err = c.bind(user.DN, password)
+	if result, ok := err.(ResultError); ok && result.Code == resultInvalidCredentials {
+		return nil, InvalidCredentialsError{username}
+	}
+	if err != nil {
+		return nil, BindError{user.DN, err}
+	}
+
+	memberOf := user.Attributes[strings.ToLower(a.Descriptor.GroupAttribute)]
+	if a.Descriptor.GroupBaseDN != "" {
+		memberOf, err = a.searchGroups(c, user.DN)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var names []string
+	for _, group := range memberOf {
+		names = append(names, group)
+		if cn := commonName(group); cn != "" {
+			names = append(names, cn)
+		}
+	}
+
+	if len(groups) == 0 {
+		return names, nil
+	}
+	for _, name := range names {
+		for _, allowed := range groups {
+			if strings.EqualFold(name, allowed) {
+				return names, nil
+			}
+		}
+	}
+	return nil, ForbiddenError{username, groups}
+}
+
+// searchGroups returns the DNs of the groups under the group base DN the user is a member of, searched as the
+// service account, since users may not be allowed to read groups.
+func (a *Authenticator) searchGroups(c *conn, userDN string) ([]string, error) {
+	err := a.bindService(c)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := c.search(a.Descriptor.GroupBaseDN, fmt.Sprintf(a.Descriptor.GroupFilter, EscapeFilter(userDN)), []string{"1.1"})
+	if err != nil {
+		return nil, SearchError{a.Descriptor.GroupBaseDN, err}
+	}
+
+	groups := make([]string, 0, len(entries))
+	for _, e := range entries {
+		groups = append(groups, e.DN)
+	}
+	return groups, nil
+}
+
+// bindService binds as the service account, or anonymously without one.
+func (a *Authenticator) bindService(c *conn) error {
+	if a.Descriptor.BindDN == "" {
+		return nil
+	}
+
+	err := c.bind(a.Descriptor.BindDN, a.Descriptor.BindPassword)
+	if err != nil {
+		return BindError{a.Descriptor.BindDN, err}
+	}
+	return nil
+}
+
+func (a *Authenticator) connect() (*conn, error) {
+	server, err := url.Parse(a.Descriptor.URL)
+	if err != nil {
+		return nil, InvalidURLError{a.Descriptor.URL}
+	}
+
+	address := server.Host
+	if server.Port() == "" {
+		port := "389"
+		if server.Scheme == "ldaps" {
+			port = "636"
+		}
+		address = net.JoinHostPort(server.Hostname(), port)
+	}
+
+	return dial(address, server.Scheme == "ldaps", a.Descriptor.StartTLS, a.TLSConfig, a.Timeout)
+}
+
+// commonName returns the value of the first RDN of the DN when it is a cn, such as deployers for
+// cn=deployers,ou=groups,dc=example,dc=com.
+func commonName(dn string) string {
+	rdn := strings.SplitN(dn, ",", 2)[0]
+	parts := strings.SplitN(rdn, "=", 2)
+	if len(parts) != 2 || !strings.EqualFold(strings.TrimSpace(parts[0]), "cn") {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
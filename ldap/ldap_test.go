@@ -0,0 +1,396 @@
+package ldap_test
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	. "github.com/compozed/deployadactyl/ldap"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// ber is a BER element of a message of the fake server.
+type ber struct {
+	identifier byte
+	value      []byte
+	children   []ber
+}
+
+func readBER(reader *bufio.Reader) (ber, error) {
+	identifier, err := reader.ReadByte()
+	if err != nil {
+		return ber{}, err
+	}
+	size, err := reader.ReadByte()
+	if err != nil {
+		return ber{}, err
+	}
+	n := int(size)
+	if size&0x80 != 0 {
+		n = 0
+		for i := 0; i < int(size&0x7f); i++ {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return ber{}, err
+			}
+			n = n<<8 | int(b)
+		}
+	}
+	value := make([]byte, n)
+	_, err = io.ReadFull(reader, value)
+	if err != nil {
+		return ber{}, err
+	}
+
+	e := ber{identifier: identifier, value: value}
+	if identifier&0x20 != 0 {
+		children := bufio.NewReader(strings.NewReader(string(value)))
+		for {
+			child, err := readBER(children)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return ber{}, err
+			}
+			e.children = append(e.children, child)
+		}
+	}
+	return e, nil
+}
+
+func encode(identifier byte, content []byte) []byte {
+	header := []byte{identifier}
+	if len(content) < 0x80 {
+		header = append(header, byte(len(content)))
+	} else {
+		header = append(header, 0x82, byte(len(content)>>8), byte(len(content)))
+	}
+	return append(header, content...)
+}
+
+func constructed(identifier byte, elements ...[]byte) []byte {
+	var content []byte
+	for _, e := range elements {
+		content = append(content, e...)
+	}
+	return encode(identifier, content)
+}
+
+func str(s string) []byte {
+	return encode(0x04, []byte(s))
+}
+
+func result(identifier byte, code byte, message string) []byte {
+	return constructed(identifier, encode(0x0a, []byte{code}), str(""), str(message))
+}
+
+type fakeEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+type search struct {
+	Base      string
+	Attribute string
+	Value     string
+}
+
+// fakeLDAP is an LDAP server with the entries, which records the binds and searches it is sent.
+type fakeLDAP struct {
+	listener net.Listener
+	tls      *tls.Config
+	entries  []fakeEntry
+
+	mu          sync.Mutex
+	binds       []string
+	searches    []search
+	connections int
+}
+
+func (l *fakeLDAP) serve() {
+	for {
+		c, err := l.listener.Accept()
+		if err != nil {
+			return
+		}
+		l.mu.Lock()
+		l.connections++
+		l.mu.Unlock()
+		go l.handle(c)
+	}
+}
+
+func (l *fakeLDAP) handle(c net.Conn) {
+	defer c.Close()
+	reader := bufio.NewReader(c)
+
+	for {
+		message, err := readBER(reader)
+		if err != nil {
+			return
+		}
+		id, op := message.children[0].value, message.children[1]
+		respond := func(op []byte) {
+			c.Write(constructed(0x30, encode(0x02, id), op))
+		}
+
+		switch op.identifier {
+		case 0x60:
+			dn, password := string(op.children[1].value), string(op.children[2].value)
+			l.mu.Lock()
+			l.binds = append(l.binds, dn)
+			l.mu.Unlock()
+			code := byte(49)
+			for _, e := range l.entries {
+				if e.dn == dn && e.password == password {
+					code = 0
+				}
+			}
+			respond(result(0x61, code, ""))
+
+		case 0x63:
+			base, filter := string(op.children[0].value), op.children[6]
+			s := search{Base: base, Attribute: string(filter.children[0].value), Value: string(filter.children[1].value)}
+			l.mu.Lock()
+			l.searches = append(l.searches, s)
+			l.mu.Unlock()
+			for _, e := range l.entries {
+				if !strings.HasSuffix(e.dn, base) || !contains(e.attributes[s.Attribute], s.Value) {
+					continue
+				}
+				var attributes [][]byte
+				for name, values := range e.attributes {
+					var encoded [][]byte
+					for _, v := range values {
+						encoded = append(encoded, str(v))
+					}
+					attributes = append(attributes, constructed(0x30, str(name), constructed(0x31, encoded...)))
+				}
+				respond(constructed(0x64, str(e.dn), constructed(0x30, attributes...)))
+			}
+			respond(result(0x65, 0, ""))
+
+		case 0x77:
+			respond(result(0x78, 0, ""))
+			secure := tls.Server(c, l.tls)
+			c, reader = secure, bufio.NewReader(secure)
+
+		case 0x42:
+			return
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var _ = Describe("Authenticator", func() {
+	var (
+		server        *fakeLDAP
+		descriptor    S.LDAPDescriptor
+		groups        []string
+		authenticator *Authenticator
+	)
+
+	BeforeEach(func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		server = &fakeLDAP{
+			listener: listener,
+			entries: []fakeEntry{
+				{dn: "cn=deployadactyl,ou=services,dc=example,dc=com", password: "the-service-password"},
+				{
+					dn:       "uid=jane,ou=people,dc=example,dc=com",
+					password: "the-password",
+					attributes: map[string][]string{
+						"uid":      {"jane"},
+						"memberOf": {"cn=deployers,ou=groups,dc=example,dc=com"},
+					},
+				},
+				{
+					dn:         "cn=deployers,ou=groups,dc=example,dc=com",
+					attributes: map[string][]string{"member": {"uid=jane,ou=people,dc=example,dc=com"}},
+				},
+			},
+		}
+		go server.serve()
+
+		descriptor = S.LDAPDescriptor{
+			URL:          "ldap://" + listener.Addr().String(),
+			BindDN:       "cn=deployadactyl,ou=services,dc=example,dc=com",
+			BindPassword: "the-service-password",
+			UserBaseDN:   "ou=people,dc=example,dc=com",
+		}
+		groups = nil
+	})
+
+	JustBeforeEach(func() {
+		authenticator = NewAuthenticator(descriptor)
+	})
+
+	authenticate := func(username, password string, groups []string) error {
+		_, err := authenticator.Authenticate(username, password, groups)
+		return err
+	}
+
+	AfterEach(func() {
+		server.listener.Close()
+	})
+
+	It("binds as the user found by the service account", func() {
+		Expect(authenticate("jane", "the-password", groups)).To(Succeed())
+
+		Expect(server.binds).To(Equal([]string{"cn=deployadactyl,ou=services,dc=example,dc=com", "uid=jane,ou=people,dc=example,dc=com"}))
+		Expect(server.searches).To(Equal([]search{{Base: "ou=people,dc=example,dc=com", Attribute: "uid", Value: "jane"}}))
+	})
+
+	It("returns the groups of the user by DN and by common name", func() {
+		memberOf, err := authenticator.Authenticate("jane", "the-password", groups)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(memberOf).To(Equal([]string{"cn=deployers,ou=groups,dc=example,dc=com", "deployers"}))
+	})
+
+	It("returns an InvalidCredentialsError when the password is wrong", func() {
+		Expect(authenticate("jane", "not-the-password", groups)).To(MatchError(InvalidCredentialsError{"jane"}))
+	})
+
+	It("returns an InvalidCredentialsError when there is no such user", func() {
+		Expect(authenticate("john", "the-password", groups)).To(MatchError(InvalidCredentialsError{"john"}))
+		Expect(server.binds).To(HaveLen(1))
+	})
+
+	It("does not connect without a password", func() {
+		Expect(authenticate("jane", "", groups)).To(MatchError(InvalidCredentialsError{"jane"}))
+		Expect(server.connections).To(BeZero())
+	})
+
+	It("escapes the username in the filter", func() {
+		Expect(authenticate("jane)(uid=*", "the-password", groups)).To(MatchError(InvalidCredentialsError{"jane)(uid=*"}))
+		Expect(server.searches).To(Equal([]search{{Base: "ou=people,dc=example,dc=com", Attribute: "uid", Value: "jane)(uid=*"}}))
+	})
+
+	It("returns a BindError when the service account cannot bind", func() {
+		descriptor.BindPassword = "not-the-service-password"
+		authenticator = NewAuthenticator(descriptor)
+
+		err := authenticate("jane", "the-password", groups)
+
+		Expect(err).To(BeAssignableToTypeOf(BindError{}))
+		Expect(server.searches).To(BeEmpty())
+	})
+
+	It("returns a ConnectError when the server cannot be reached", func() {
+		server.listener.Close()
+
+		Expect(authenticate("jane", "the-password", groups)).To(BeAssignableToTypeOf(ConnectError{}))
+	})
+
+	Context("when the environment has groups", func() {
+		It("accepts the members of a group given by its common name", func() {
+			Expect(authenticate("jane", "the-password", []string{"admins", "Deployers"})).To(Succeed())
+		})
+
+		It("accepts the members of a group given by its DN", func() {
+			Expect(authenticate("jane", "the-password", []string{"cn=deployers,ou=groups,dc=example,dc=com"})).To(Succeed())
+		})
+
+		It("returns a ForbiddenError when the user is a member of none of them", func() {
+			err := authenticate("jane", "the-password", []string{"admins"})
+
+			Expect(err).To(MatchError(ForbiddenError{"jane", []string{"admins"}}))
+		})
+
+		Context("when the groups are searched", func() {
+			BeforeEach(func() {
+				descriptor.GroupBaseDN = "ou=groups,dc=example,dc=com"
+			})
+
+			It("searches the groups with the DN of the user", func() {
+				Expect(authenticate("jane", "the-password", []string{"deployers"})).To(Succeed())
+
+				Expect(server.searches[1]).To(Equal(search{Base: "ou=groups,dc=example,dc=com", Attribute: "member", Value: "uid=jane,ou=people,dc=example,dc=com"}))
+			})
+
+			It("returns a ForbiddenError when no group has the user", func() {
+				Expect(authenticate("jane", "the-password", []string{"admins"})).To(MatchError(ForbiddenError{"jane", []string{"admins"}}))
+			})
+		})
+	})
+
+	Context("when the connection starts TLS", func() {
+		BeforeEach(func() {
+			https := httptest.NewTLSServer(http.NotFoundHandler())
+			server.tls = &tls.Config{Certificates: https.TLS.Certificates}
+			https.Close()
+
+			descriptor.StartTLS = true
+			descriptor.SkipSSL = true
+		})
+
+		It("binds over TLS", func() {
+			var err error
+			authenticator, err = New(descriptor, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(authenticate("jane", "the-password", groups)).To(Succeed())
+			Expect(server.binds).To(HaveLen(2))
+		})
+
+		It("refuses a certificate it cannot verify", func() {
+			Expect(authenticate("jane", "the-password", groups)).To(BeAssignableToTypeOf(ConnectError{}))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts an empty or valid configuration", func() {
+			Expect(Validate(S.LDAPDescriptor{})).To(Succeed())
+			Expect(Validate(descriptor)).To(Succeed())
+			Expect(Validate(S.LDAPDescriptor{URL: "ldaps://ldap.example.com", UserBaseDN: "dc=example,dc=com", UserFilter: "(&(objectClass=person)(sAMAccountName=%s))"})).To(Succeed())
+		})
+
+		It("refuses URLs that are not ldap URLs", func() {
+			Expect(Validate(S.LDAPDescriptor{URL: "https://ldap.example.com", UserBaseDN: "dc=example,dc=com"})).To(MatchError(InvalidURLError{"https://ldap.example.com"}))
+			Expect(Validate(S.LDAPDescriptor{URL: "ldaps://ldap.example.com", StartTLS: true, UserBaseDN: "dc=example,dc=com"})).To(MatchError(InvalidURLError{"ldaps://ldap.example.com"}))
+		})
+
+		It("needs the base DN of the users", func() {
+			Expect(Validate(S.LDAPDescriptor{URL: "ldap://ldap.example.com"})).To(MatchError(MissingUserBaseDNError{}))
+		})
+
+		It("refuses invalid filters", func() {
+			descriptor.UserFilter = "(uid=jane)"
+			Expect(Validate(descriptor)).To(MatchError(InvalidFilterError{"(uid=jane)"}))
+
+			descriptor.UserFilter = "(&(uid=%s)"
+			Expect(Validate(descriptor)).To(HaveOccurred())
+		})
+
+		It("refuses a negative timeout", func() {
+			descriptor.TimeoutSeconds = -1
+			Expect(Validate(descriptor)).To(MatchError(InvalidTimeoutError{-1}))
+		})
+	})
+
+	Describe("EscapeFilter", func() {
+		It("escapes the characters of filters", func() {
+			Expect(EscapeFilter(`a*(b)\c`)).To(Equal(`a\2a\28b\29\5cc`))
+		})
+	})
+})
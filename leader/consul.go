@@ -0,0 +1,133 @@
+package leader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultConsulURL is the address of the local Consul agent.
+const DefaultConsulURL = "http://127.0.0.1:8500"
+
+// NewConsulElection returns a ConsulElection of the key in the key value store of the Consul agent at the URL.
+func NewConsulElection(consulURL, token, key string, client *http.Client) *ConsulElection {
+	if consulURL == "" {
+		consulURL = DefaultConsulURL
+	}
+	return &ConsulElection{URL: strings.TrimSuffix(consulURL, "/"), Token: token, Key: key, Client: client}
+}
+
+// ConsulElection elects the identity whose session acquired the key in Consul. The session expires after the TTL
+// and then releases the key.
+type ConsulElection struct {
+	URL    string
+	Token  string
+	Key    string
+	Client *http.Client
+
+	session string
+}
+
+// Acquire renews the session of the identity, or creates one, and acquires the key with it.
+func (c *ConsulElection) Acquire(identity string, ttl time.Duration) (bool, error) {
+	if c.session != "" {
+		status, _, err := c.do("PUT", "/v1/session/renew/"+c.session, nil)
+		if err != nil {
+			return false, err
+		}
+		if status == http.StatusNotFound {
+			c.session = ""
+		} else if status != http.StatusOK {
+			return false, UnexpectedResponseError{"PUT", "/v1/session/renew", status}
+		}
+	}
+
+	if c.session == "" {
+		session := map[string]string{
+			"Name":      c.Key,
+			"TTL":       fmt.Sprintf("%ds", int(ttl/time.Second)),
+			"Behavior":  "delete",
+			"LockDelay": "0s",
+		}
+		b, err := json.Marshal(session)
+		if err != nil {
+			return false, err
+		}
+		status, body, err := c.do("PUT", "/v1/session/create", b)
+		if err != nil {
+			return false, err
+		}
+		if status != http.StatusOK {
+			return false, UnexpectedResponseError{"PUT", "/v1/session/create", status}
+		}
+		created := struct{ ID string }{}
+		if err := json.Unmarshal(body, &created); err != nil || created.ID == "" {
+			return false, InvalidResponseError{"/v1/session/create", string(body)}
+		}
+		c.session = created.ID
+	}
+
+	status, body, err := c.do("PUT", "/v1/kv/"+c.Key+"?acquire="+url.QueryEscape(c.session), []byte(identity))
+	if err != nil {
+		return false, err
+	}
+	if status != http.StatusOK {
+		return false, UnexpectedResponseError{"PUT", "/v1/kv/" + c.Key, status}
+	}
+	return strings.TrimSpace(string(body)) == "true", nil
+}
+
+// Release releases the key and destroys the session.
+func (c *ConsulElection) Release(identity string) error {
+	if c.session == "" {
+		return nil
+	}
+	session := c.session
+	c.session = ""
+
+	status, _, err := c.do("PUT", "/v1/kv/"+c.Key+"?release="+url.QueryEscape(session), []byte(identity))
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return UnexpectedResponseError{"PUT", "/v1/kv/" + c.Key, status}
+	}
+
+	status, _, err = c.do("PUT", "/v1/session/destroy/"+session, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return UnexpectedResponseError{"PUT", "/v1/session/destroy", status}
+	}
+	return nil
+}
+
+// do sends the request, and returns the status and body of the response.
+func (c *ConsulElection) do(method, path string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequest(method, c.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("X-Consul-Token", c.Token)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, b, nil
+}
@@ -0,0 +1,137 @@
+package leader_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/compozed/deployadactyl/leader"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// consulAgent is a fake Consul agent whose key is held by the session that acquired it.
+type consulAgent struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]string
+	holder   string
+	value    string
+	tokens   []string
+	created  int
+}
+
+func (a *consulAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.tokens = append(a.tokens, r.Header.Get("X-Consul-Token"))
+	body, _ := ioutil.ReadAll(r.Body)
+
+	switch {
+	case r.URL.Path == "/v1/session/create":
+		a.created++
+		id := "session-" + strconv.Itoa(a.created)
+		session := map[string]string{}
+		json.Unmarshal(body, &session)
+		a.sessions[id] = session
+		w.Write([]byte(`{"ID": "` + id + `"}`))
+	case strings.HasPrefix(r.URL.Path, "/v1/session/renew/"):
+		if _, ok := a.sessions[strings.TrimPrefix(r.URL.Path, "/v1/session/renew/")]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case strings.HasPrefix(r.URL.Path, "/v1/session/destroy/"):
+		delete(a.sessions, strings.TrimPrefix(r.URL.Path, "/v1/session/destroy/"))
+	case r.URL.Path == "/v1/kv/deployadactyl-leader":
+		if session := r.URL.Query().Get("acquire"); session != "" {
+			if a.holder != "" && a.holder != session {
+				w.Write([]byte("false"))
+				return
+			}
+			a.holder, a.value = session, string(body)
+			w.Write([]byte("true"))
+			return
+		}
+		if a.holder == r.URL.Query().Get("release") {
+			a.holder = ""
+		}
+		w.Write([]byte("true"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+var _ = Describe("ConsulElection", func() {
+	var (
+		agent    *consulAgent
+		server   *httptest.Server
+		election *ConsulElection
+	)
+
+	BeforeEach(func() {
+		agent = &consulAgent{sessions: map[string]map[string]string{}}
+		server = httptest.NewServer(agent)
+		election = NewConsulElection(server.URL, "acl-token", "deployadactyl-leader", http.DefaultClient)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("acquires the key with a session that deletes it once it expires", func() {
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+
+		Expect(agent.holder).To(Equal("session-1"))
+		Expect(agent.value).To(Equal("host-1"))
+		Expect(agent.sessions["session-1"]).To(Equal(map[string]string{"Name": "deployadactyl-leader", "TTL": "15s", "Behavior": "delete", "LockDelay": "0s"}))
+		Expect(agent.tokens).To(ConsistOf("acl-token", "acl-token"))
+	})
+
+	It("renews the session of the leader", func() {
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+
+		Expect(agent.created).To(Equal(1))
+	})
+
+	It("creates a new session when its session expired", func() {
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+		delete(agent.sessions, "session-1")
+		agent.holder = ""
+
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+
+		Expect(agent.holder).To(Equal("session-2"))
+	})
+
+	It("does not elect the identity when another session holds the key", func() {
+		agent.holder = "another-session"
+
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeFalse())
+	})
+
+	It("releases the key and destroys the session", func() {
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+
+		Expect(election.Release("host-1")).To(Succeed())
+
+		Expect(agent.holder).To(BeEmpty())
+		Expect(agent.sessions).To(BeEmpty())
+	})
+
+	It("returns an error when the agent fails", func() {
+		server.Close()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		election.URL = server.URL
+
+		_, err := election.Acquire("host-1", 15*time.Second)
+
+		Expect(err).To(MatchError(UnexpectedResponseError{"PUT", "/v1/session/create", http.StatusForbidden}))
+	})
+})
@@ -0,0 +1,122 @@
+package leader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// Job is a background job that only runs on the leader, until ctx is done.
+type Job func(ctx context.Context)
+
+type job struct {
+	name string
+	run  Job
+}
+
+// NewElector returns an Elector of the identity in the election, holding the leadership for the TTL.
+func NewElector(election I.LeaderElection, identity string, ttl time.Duration, log I.Logger) *Elector {
+	return &Elector{
+		Election: election,
+		Identity: identity,
+		TTL:      ttl,
+		Log:      log,
+	}
+}
+
+// Elector takes part in the election of the leader, and runs the jobs while it is the leader. The jobs are started
+// every time the Elector becomes the leader, and their context is done as soon as it may no longer be the leader,
+// which is before the leadership it held expires.
+type Elector struct {
+	Election I.LeaderElection
+	Identity string
+	TTL      time.Duration
+	Log      I.Logger
+
+	mu     sync.Mutex
+	jobs   []job
+	cancel context.CancelFunc
+}
+
+// Add runs the job whenever the Elector becomes the leader.
+func (e *Elector) Add(name string, run Job) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.jobs = append(e.jobs, job{name, run})
+}
+
+// IsLeader reports whether the Elector is the leader.
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.cancel != nil
+}
+
+// Run takes part in the election every third of the TTL until ctx is done, and then releases the leadership.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		e.Elect(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Elect takes or renews the leadership, starting the jobs when the Elector becomes the leader and stopping them
+// when it is not the leader anymore. It reports whether the Elector is the leader.
+func (e *Elector) Elect(ctx context.Context) bool {
+	leader, err := e.Election.Acquire(e.Identity, e.TTL)
+	if err != nil {
+		e.Log.Errorf("cannot take part in the leader election: %s", err)
+		leader = false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if leader && e.cancel == nil {
+		var jobCtx context.Context
+		jobCtx, e.cancel = context.WithCancel(ctx)
+		e.Log.Infof("%s is the leader", e.Identity)
+
+		for _, j := range e.jobs {
+			e.Log.Debugf("running %s", j.name)
+			go j.run(jobCtx)
+		}
+	}
+	if !leader && e.cancel != nil {
+		e.cancel()
+		e.cancel = nil
+		e.Log.Infof("%s is no longer the leader", e.Identity)
+	}
+
+	return leader
+}
+
+// resign stops the jobs and releases the leadership, so another instance does not wait for it to expire.
+func (e *Elector) resign() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.cancel == nil {
+		return
+	}
+	e.cancel()
+	e.cancel = nil
+
+	err := e.Election.Release(e.Identity)
+	if err != nil {
+		e.Log.Errorf("cannot release the leadership: %s", err)
+	}
+}
@@ -0,0 +1,126 @@
+package leader_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/leader"
+	"github.com/compozed/deployadactyl/mocks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Elector", func() {
+	var (
+		election  *mocks.LeaderElection
+		logBuffer *Buffer
+		elector   *Elector
+		started   chan string
+		stopped   chan string
+		ctx       context.Context
+		cancel    context.CancelFunc
+	)
+
+	job := func(name string, started, stopped chan string) Job {
+		return func(ctx context.Context) {
+			started <- name
+			<-ctx.Done()
+			stopped <- name
+		}
+	}
+
+	BeforeEach(func() {
+		election = &mocks.LeaderElection{}
+		logBuffer = NewBuffer()
+		elector = NewElector(election, "host-1", 15*time.Second, I.DefaultLogger(logBuffer, logging.DEBUG, "elector_test"))
+		started = make(chan string, 10)
+		stopped = make(chan string, 10)
+		ctx, cancel = context.WithCancel(context.Background())
+
+		elector.Add("sweep", job("sweep", started, stopped))
+		elector.Add("reconcile", job("reconcile", started, stopped))
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("takes part in the election with its identity and TTL", func() {
+		elector.Elect(ctx)
+
+		Expect(election.AcquireCall.Received.Identity).To(Equal("host-1"))
+		Expect(election.AcquireCall.Received.TTL).To(Equal(15 * time.Second))
+	})
+
+	It("runs the jobs once it is the leader", func() {
+		election.AcquireCall.Returns.Leader = true
+
+		Expect(elector.Elect(ctx)).To(BeTrue())
+
+		Expect(elector.IsLeader()).To(BeTrue())
+		Eventually(started).Should(Receive())
+		Eventually(started).Should(Receive())
+		Eventually(logBuffer).Should(Say("host-1 is the leader"))
+	})
+
+	It("does not run the jobs again while it stays the leader", func() {
+		election.AcquireCall.Returns.Leader = true
+
+		elector.Elect(ctx)
+		elector.Elect(ctx)
+
+		Eventually(started).Should(HaveLen(2))
+		Consistently(started).Should(HaveLen(2))
+	})
+
+	It("does not run the jobs when another instance is the leader", func() {
+		Expect(elector.Elect(ctx)).To(BeFalse())
+
+		Expect(elector.IsLeader()).To(BeFalse())
+		Consistently(started).ShouldNot(Receive())
+	})
+
+	It("stops the jobs when it is not the leader anymore", func() {
+		election.AcquireCall.Returns.Leader = true
+		elector.Elect(ctx)
+
+		election.AcquireCall.Returns.Leader = false
+		elector.Elect(ctx)
+
+		Expect(elector.IsLeader()).To(BeFalse())
+		Eventually(stopped).Should(HaveLen(2))
+		Eventually(logBuffer).Should(Say("host-1 is no longer the leader"))
+	})
+
+	It("stops the jobs when the election cannot be taken part in", func() {
+		election.AcquireCall.Returns.Leader = true
+		elector.Elect(ctx)
+
+		election.AcquireCall.Returns.Error = errors.New("connection refused")
+		Expect(elector.Elect(ctx)).To(BeFalse())
+
+		Eventually(stopped).Should(HaveLen(2))
+		Eventually(logBuffer).Should(Say("cannot take part in the leader election: connection refused"))
+	})
+
+	It("releases the leadership once it is done", func() {
+		election.AcquireCall.Returns.Leader = true
+		done := make(chan struct{})
+		go func() {
+			elector.Run(ctx)
+			close(done)
+		}()
+		Eventually(started).Should(HaveLen(2))
+
+		cancel()
+
+		Eventually(done).Should(BeClosed())
+		Expect(election.ReleaseCall.Called).To(BeTrue())
+		Expect(election.ReleaseCall.Received.Identity).To(Equal("host-1"))
+		Eventually(stopped).Should(HaveLen(2))
+	})
+})
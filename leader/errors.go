@@ -0,0 +1,66 @@
+package leader
+
+import "fmt"
+
+type UnknownTypeError struct {
+	Type string
+}
+
+func (e UnknownTypeError) Error() string {
+	return fmt.Sprintf("unknown leader election type %s: expected %s, %s or %s", e.Type, Redis, Consul, Kubernetes)
+}
+
+type MissingURLError struct {
+	Type string
+}
+
+func (e MissingURLError) Error() string {
+	return fmt.Sprintf("a %s leader election requires a url", e.Type)
+}
+
+type InvalidTTLError struct {
+	Type       string
+	TTLSeconds int
+}
+
+func (e InvalidTTLError) Error() string {
+	if e.Type == Consul {
+		return fmt.Sprintf("the ttl of a consul leader election must be at least %d seconds: ttl_seconds %d", consulMinTTL, e.TTLSeconds)
+	}
+	return fmt.Sprintf("the ttl of a leader election must not be negative: ttl_seconds %d", e.TTLSeconds)
+}
+
+type UnexpectedResponseError struct {
+	Method     string
+	Path       string
+	StatusCode int
+}
+
+func (e UnexpectedResponseError) Error() string {
+	return fmt.Sprintf("%s %s returned %d", e.Method, e.Path, e.StatusCode)
+}
+
+type InvalidResponseError struct {
+	Path string
+	Body string
+}
+
+func (e InvalidResponseError) Error() string {
+	return fmt.Sprintf("invalid response from %s: %q", e.Path, e.Body)
+}
+
+type NamespaceError struct {
+	Err error
+}
+
+func (e NamespaceError) Error() string {
+	return fmt.Sprintf("cannot read the namespace of the pod, set the namespace of the leader election: %s", e.Err)
+}
+
+type TokenError struct {
+	Err error
+}
+
+func (e TokenError) Error() string {
+	return fmt.Sprintf("cannot read the service account token of the pod, set the token of the leader election: %s", e.Err)
+}
@@ -0,0 +1,230 @@
+package leader
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// The files of the service account of a pod.
+const (
+	ServiceAccountToken     = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	ServiceAccountCA        = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	ServiceAccountNamespace = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// microTime is the format of the times of a Lease.
+const microTime = "2006-01-02T15:04:05.000000Z07:00"
+
+// lease is a coordination.k8s.io/v1 Lease.
+type lease struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name            string `json:"name"`
+		Namespace       string `json:"namespace"`
+		ResourceVersion string `json:"resourceVersion,omitempty"`
+	} `json:"metadata"`
+	Spec struct {
+		HolderIdentity       string `json:"holderIdentity,omitempty"`
+		LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+		AcquireTime          string `json:"acquireTime,omitempty"`
+		RenewTime            string `json:"renewTime,omitempty"`
+		LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+	} `json:"spec"`
+}
+
+// NewKubernetesElection returns a KubernetesElection of the Lease named key. The API server, namespace, token and
+// CA bundle default to those of the pod the server runs in.
+func NewKubernetesElection(descriptor S.LeaderElectionDescriptor, key string, fs *afero.Afero) (*KubernetesElection, error) {
+	apiURL := descriptor.URL
+	caBundle := descriptor.CABundle
+	if apiURL == "" {
+		apiURL = "https://" + net.JoinHostPort(os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"))
+		if caBundle == "" {
+			caBundle = ServiceAccountCA
+		}
+	}
+
+	namespace := descriptor.Namespace
+	if namespace == "" {
+		b, err := fs.ReadFile(ServiceAccountNamespace)
+		if err != nil {
+			return nil, NamespaceError{err}
+		}
+		namespace = strings.TrimSpace(string(b))
+	}
+
+	client, err := httpClient(fs, caBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KubernetesElection{
+		URL:        strings.TrimSuffix(apiURL, "/"),
+		Namespace:  namespace,
+		Name:       key,
+		Token:      descriptor.Token,
+		Client:     client,
+		FileSystem: fs,
+		Now:        time.Now,
+	}, nil
+}
+
+// KubernetesElection elects the identity holding a Lease, which expires once it was not renewed for its duration.
+// Without a Token, the token of the service account of the pod is read before every request, as it is rotated.
+type KubernetesElection struct {
+	URL        string
+	Namespace  string
+	Name       string
+	Token      string
+	Client     *http.Client
+	FileSystem *afero.Afero
+	Now        func() time.Time
+}
+
+// Acquire creates the Lease for the identity, or renews it when the identity holds it or it expired.
+func (k *KubernetesElection) Acquire(identity string, ttl time.Duration) (bool, error) {
+	now := k.Now().UTC().Format(microTime)
+
+	current, found, err := k.get()
+	if err != nil {
+		return false, err
+	}
+
+	if !found {
+		l := lease{APIVersion: "coordination.k8s.io/v1", Kind: "Lease"}
+		l.Metadata.Name = k.Name
+		l.Metadata.Namespace = k.Namespace
+		l.Spec.HolderIdentity = identity
+		l.Spec.LeaseDurationSeconds = int(ttl / time.Second)
+		l.Spec.AcquireTime = now
+		l.Spec.RenewTime = now
+
+		status, err := k.send("POST", k.leasesPath(), l, nil)
+		if err != nil {
+			return false, err
+		}
+		return status == http.StatusCreated, nil
+	}
+
+	if current.Spec.HolderIdentity != identity {
+		if current.Spec.HolderIdentity != "" && !k.expired(current) {
+			return false, nil
+		}
+		current.Spec.AcquireTime = now
+		current.Spec.LeaseTransitions++
+	}
+	current.Spec.HolderIdentity = identity
+	current.Spec.LeaseDurationSeconds = int(ttl / time.Second)
+	current.Spec.RenewTime = now
+
+	status, err := k.send("PUT", k.leasePath(), current, nil)
+	if err != nil {
+		return false, err
+	}
+	return status == http.StatusOK, nil
+}
+
+// Release clears the holder of the Lease when the identity holds it.
+func (k *KubernetesElection) Release(identity string) error {
+	current, found, err := k.get()
+	if err != nil || !found || current.Spec.HolderIdentity != identity {
+		return err
+	}
+
+	current.Spec.HolderIdentity = ""
+	current.Spec.LeaseDurationSeconds = 1
+	_, err = k.send("PUT", k.leasePath(), current, nil)
+	return err
+}
+
+// expired reports whether the Lease was not renewed for its duration. A Lease that cannot be parsed has expired.
+func (k *KubernetesElection) expired(l lease) bool {
+	renewed, err := time.Parse(microTime, l.Spec.RenewTime)
+	if err != nil {
+		return true
+	}
+	return k.Now().After(renewed.Add(time.Duration(l.Spec.LeaseDurationSeconds) * time.Second))
+}
+
+// get returns the Lease, and reports whether it exists.
+func (k *KubernetesElection) get() (lease, bool, error) {
+	current := lease{}
+	status, err := k.send("GET", k.leasePath(), nil, &current)
+	if err != nil {
+		return lease{}, false, err
+	}
+	return current, status != http.StatusNotFound, nil
+}
+
+// send sends the request with the Lease as its body, and reads the Lease of the response into out. A conflict
+// with another update or creation of the Lease is not an error.
+func (k *KubernetesElection) send(method, path string, in interface{}, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, k.URL+path, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	token := k.Token
+	if token == "" {
+		b, err := k.FileSystem.ReadFile(ServiceAccountToken)
+		if err != nil {
+			return 0, TokenError{err}
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := k.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusConflict, resp.StatusCode == http.StatusNotFound && method == "GET":
+		return resp.StatusCode, nil
+	case resp.StatusCode/100 != 2:
+		return resp.StatusCode, UnexpectedResponseError{method, path, resp.StatusCode}
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, err
+	}
+	if out != nil {
+		if err := json.Unmarshal(b, out); err != nil {
+			return 0, InvalidResponseError{path, string(b)}
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func (k *KubernetesElection) leasesPath() string {
+	return "/apis/coordination.k8s.io/v1/namespaces/" + k.Namespace + "/leases"
+}
+
+func (k *KubernetesElection) leasePath() string {
+	return k.leasesPath() + "/" + k.Name
+}
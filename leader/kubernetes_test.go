@@ -0,0 +1,178 @@
+package leader_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	. "github.com/compozed/deployadactyl/leader"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+const leasesPath = "/apis/coordination.k8s.io/v1/namespaces/deployadactyl/leases"
+
+// apiServer is a fake Kubernetes API server holding a single Lease, which refuses the updates of an outdated
+// resourceVersion.
+type apiServer struct {
+	lease   map[string]interface{}
+	version int
+	tokens  []string
+}
+
+func (a *apiServer) spec() map[string]interface{} {
+	return a.lease["spec"].(map[string]interface{})
+}
+
+func (a *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.tokens = append(a.tokens, r.Header.Get("Authorization"))
+
+	if r.Method == "GET" && r.URL.Path == leasesPath+"/deployadactyl-leader" {
+		if a.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(a.lease)
+		return
+	}
+
+	lease := map[string]interface{}{}
+	json.NewDecoder(r.Body).Decode(&lease)
+	metadata := lease["metadata"].(map[string]interface{})
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == leasesPath:
+		if a.lease != nil {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "PUT" && r.URL.Path == leasesPath+"/deployadactyl-leader":
+		if metadata["resourceVersion"] != strconv.Itoa(a.version) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	a.version++
+	metadata["resourceVersion"] = strconv.Itoa(a.version)
+	a.lease = lease
+	json.NewEncoder(w).Encode(a.lease)
+}
+
+var _ = Describe("KubernetesElection", func() {
+	var (
+		api      *apiServer
+		server   *httptest.Server
+		fs       *afero.Afero
+		now      time.Time
+		election *KubernetesElection
+	)
+
+	BeforeEach(func() {
+		api = &apiServer{}
+		server = httptest.NewServer(api)
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		Expect(fs.WriteFile(ServiceAccountToken, []byte("service-account-token\n"), 0600)).To(Succeed())
+		now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+		var err error
+		election, err = NewKubernetesElection(S.LeaderElectionDescriptor{URL: server.URL, Namespace: "deployadactyl"}, "deployadactyl-leader", fs)
+		Expect(err).ToNot(HaveOccurred())
+		election.Now = func() time.Time { return now }
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("creates the Lease for the identity", func() {
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+
+		Expect(api.lease["kind"]).To(Equal("Lease"))
+		Expect(api.spec()["holderIdentity"]).To(Equal("host-1"))
+		Expect(api.spec()["leaseDurationSeconds"]).To(BeEquivalentTo(15))
+		Expect(api.spec()["renewTime"]).To(Equal("2026-10-16T12:00:00.000000Z"))
+		Expect(api.tokens).To(ConsistOf("Bearer service-account-token", "Bearer service-account-token"))
+	})
+
+	It("renews the Lease of the leader", func() {
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+		now = now.Add(5 * time.Second)
+
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+
+		Expect(api.spec()["renewTime"]).To(Equal("2026-10-16T12:00:05.000000Z"))
+		Expect(api.spec()["acquireTime"]).To(Equal("2026-10-16T12:00:00.000000Z"))
+	})
+
+	It("does not elect the identity while another identity holds the Lease", func() {
+		Expect(election.Acquire("host-2", 15*time.Second)).To(BeTrue())
+		now = now.Add(10 * time.Second)
+
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeFalse())
+		Expect(api.spec()["holderIdentity"]).To(Equal("host-2"))
+	})
+
+	It("takes over a Lease that expired", func() {
+		Expect(election.Acquire("host-2", 15*time.Second)).To(BeTrue())
+		now = now.Add(20 * time.Second)
+
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+
+		Expect(api.spec()["holderIdentity"]).To(Equal("host-1"))
+		Expect(api.spec()["leaseTransitions"]).To(BeEquivalentTo(1))
+	})
+
+	It("clears the holder of the Lease when it is released", func() {
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+
+		Expect(election.Release("host-1")).To(Succeed())
+
+		Expect(api.spec()).ToNot(HaveKey("holderIdentity"))
+		Expect(election.Acquire("host-2", 15*time.Second)).To(BeTrue())
+	})
+
+	It("does not elect the identity when the Lease was updated by another instance meanwhile", func() {
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+		api.version++
+
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeFalse())
+	})
+
+	It("uses the token of the descriptor", func() {
+		election.Token = "token"
+
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+
+		Expect(api.tokens).To(ConsistOf("Bearer token", "Bearer token"))
+	})
+
+	It("reads the namespace of the pod", func() {
+		Expect(fs.WriteFile(ServiceAccountNamespace, []byte("team-namespace\n"), 0600)).To(Succeed())
+
+		election, err := NewKubernetesElection(S.LeaderElectionDescriptor{URL: server.URL}, "deployadactyl-leader", fs)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(election.Namespace).To(Equal("team-namespace"))
+	})
+
+	It("returns an error when the API server refuses the request", func() {
+		server.Close()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		election.URL = server.URL
+
+		_, err := election.Acquire("host-1", 15*time.Second)
+
+		Expect(err).To(MatchError(UnexpectedResponseError{"GET", leasesPath + "/deployadactyl-leader", http.StatusForbidden}))
+	})
+})
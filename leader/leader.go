@@ -0,0 +1,131 @@
+// Package leader elects the instance of the server that runs the background jobs which must only run once across
+// every instance, such as the sweep for stale applications and the reconciliation of interrupted deployments.
+// The leadership is held in Redis, Consul or a Kubernetes Lease.
+package leader
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/compozed/deployadactyl/cabundle"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/redis"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// The types of leader election.
+const (
+	Redis      = "redis"
+	Consul     = "consul"
+	Kubernetes = "kubernetes"
+)
+
+// The defaults of an election.
+const (
+	DefaultTTL     = 15 * time.Second
+	DefaultKey     = "deployadactyl-leader"
+	DefaultTimeout = 10 * time.Second
+)
+
+// consulMinTTL is the shortest TTL of a Consul session.
+const consulMinTTL = 10
+
+// Validate returns an error when the descriptor is not a valid leader election.
+func Validate(descriptor S.LeaderElectionDescriptor) error {
+	switch descriptor.Type {
+	case "", Consul, Kubernetes:
+	case Redis:
+		if descriptor.URL == "" {
+			return MissingURLError{descriptor.Type}
+		}
+	default:
+		return UnknownTypeError{descriptor.Type}
+	}
+
+	if descriptor.TTLSeconds < 0 || (descriptor.Type == Consul && descriptor.TTLSeconds != 0 && descriptor.TTLSeconds < consulMinTTL) {
+		return InvalidTTLError{descriptor.Type, descriptor.TTLSeconds}
+	}
+	return nil
+}
+
+// New returns the Elector of the server configured by the descriptor. Without a type the server is always the
+// leader.
+func New(descriptor S.LeaderElectionDescriptor, fs *afero.Afero, log I.Logger) (*Elector, error) {
+	ttl := time.Duration(descriptor.TTLSeconds) * time.Second
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	key := descriptor.Key
+	if key == "" {
+		key = DefaultKey
+	}
+
+	var election I.LeaderElection
+	switch descriptor.Type {
+	case "":
+		election = Local{}
+	case Redis:
+		client, err := redis.Open(descriptor.URL, fs)
+		if err != nil {
+			return nil, err
+		}
+		election = NewRedisElection(client, key)
+	case Consul:
+		client, err := httpClient(fs, descriptor.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		election = NewConsulElection(descriptor.URL, descriptor.Token, key, client)
+	case Kubernetes:
+		kubernetes, err := NewKubernetesElection(descriptor, key, fs)
+		if err != nil {
+			return nil, err
+		}
+		election = kubernetes
+	default:
+		return nil, UnknownTypeError{descriptor.Type}
+	}
+
+	return NewElector(election, Identity(), ttl, log), nil
+}
+
+// Identity returns the identity of the server in the elections: its hostname, which is the name of its pod on
+// Kubernetes, and its process id.
+func Identity() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// Local is the election of a server that runs alone, which is always the leader.
+type Local struct{}
+
+// Acquire always elects the identity.
+func (Local) Acquire(identity string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+// Release does nothing.
+func (Local) Release(identity string) error {
+	return nil
+}
+
+// httpClient returns a client of the HTTP APIs of the elections, trusting the CA bundle instead of the system roots
+// when it is set.
+func httpClient(fs *afero.Afero, caBundle string) (*http.Client, error) {
+	tlsConfig, err := cabundle.TLSConfig(fs, caBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: DefaultTimeout}
+	if tlsConfig != nil {
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment}
+	}
+	return client, nil
+}
@@ -0,0 +1,70 @@
+package leader_test
+
+import (
+	"time"
+
+	. "github.com/compozed/deployadactyl/leader"
+	"github.com/compozed/deployadactyl/redis"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Validate", func() {
+	It("accepts every type of election", func() {
+		for _, descriptor := range []S.LeaderElectionDescriptor{
+			{},
+			{Type: Redis, URL: "redis://redis.example.com"},
+			{Type: Consul, TTLSeconds: 10},
+			{Type: Kubernetes, TTLSeconds: 5},
+		} {
+			Expect(Validate(descriptor)).To(Succeed(), descriptor.Type)
+		}
+	})
+
+	It("returns an error for an unknown type", func() {
+		Expect(Validate(S.LeaderElectionDescriptor{Type: "zookeeper"})).To(MatchError(UnknownTypeError{"zookeeper"}))
+	})
+
+	It("returns an error for a redis election without a url", func() {
+		Expect(Validate(S.LeaderElectionDescriptor{Type: Redis})).To(MatchError(MissingURLError{Redis}))
+	})
+
+	It("returns an error for an invalid ttl", func() {
+		Expect(Validate(S.LeaderElectionDescriptor{Type: Kubernetes, TTLSeconds: -1})).To(MatchError(InvalidTTLError{Kubernetes, -1}))
+		Expect(Validate(S.LeaderElectionDescriptor{Type: Consul, TTLSeconds: 5})).To(MatchError(InvalidTTLError{Consul, 5}))
+	})
+})
+
+var _ = Describe("New", func() {
+	fs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	It("is always the leader without a type", func() {
+		elector, err := New(S.LeaderElectionDescriptor{}, fs, nil)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(elector.Election).To(Equal(Local{}))
+		Expect(elector.TTL).To(Equal(DefaultTTL))
+		Expect(elector.Identity).To(Equal(Identity()))
+	})
+
+	It("holds the key in redis for the TTL", func() {
+		elector, err := New(S.LeaderElectionDescriptor{Type: Redis, URL: "redis://redis.example.com", Key: "leader", TTLSeconds: 30}, fs, nil)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(elector.TTL).To(Equal(30 * time.Second))
+		election := elector.Election.(*RedisElection)
+		Expect(election.Key).To(Equal("leader"))
+		Expect(election.Redis.(*redis.Client).Config.Address).To(Equal("redis.example.com:6379"))
+	})
+
+	It("acquires the default key of the local consul agent", func() {
+		elector, err := New(S.LeaderElectionDescriptor{Type: Consul}, fs, nil)
+
+		Expect(err).ToNot(HaveOccurred())
+		election := elector.Election.(*ConsulElection)
+		Expect(election.URL).To(Equal(DefaultConsulURL))
+		Expect(election.Key).To(Equal(DefaultKey))
+	})
+})
@@ -0,0 +1,44 @@
+package leader
+
+import (
+	"strconv"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// acquireScript renews the key when the identity holds it, and sets it when nobody does.
+const acquireScript = `local holder = redis.call("get", KEYS[1])
+if holder == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) end
+if holder then return 0 end
+redis.call("set", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1`
+
+// releaseScript deletes the key only when the identity holds it.
+const releaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
+// NewRedisElection returns a RedisElection holding the key.
+func NewRedisElection(redis I.Redis, key string) *RedisElection {
+	return &RedisElection{Redis: redis, Key: key}
+}
+
+// RedisElection elects the identity that holds the key in Redis, which expires after the TTL.
+type RedisElection struct {
+	Redis I.Redis
+	Key   string
+}
+
+// Acquire sets or renews the key for the identity, unless another identity holds it.
+func (r *RedisElection) Acquire(identity string, ttl time.Duration) (bool, error) {
+	reply, err := r.Redis.Do("EVAL", acquireScript, "1", r.Key, identity, strconv.FormatInt(int64(ttl/time.Millisecond), 10))
+	if err != nil {
+		return false, err
+	}
+	return reply == int64(1), nil
+}
+
+// Release deletes the key when the identity holds it.
+func (r *RedisElection) Release(identity string) error {
+	_, err := r.Redis.Do("EVAL", releaseScript, "1", r.Key, identity)
+	return err
+}
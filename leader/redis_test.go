@@ -0,0 +1,86 @@
+package leader_test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/compozed/deployadactyl/leader"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeRedis runs the scripts of the election on a key kept in memory, which never expires.
+type fakeRedis struct {
+	holder string
+	ttl    string
+	err    error
+}
+
+func (r *fakeRedis) Do(args ...string) (interface{}, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	script, identity := args[1], args[4]
+	if strings.Contains(script, "pexpire") {
+		if r.holder != "" && r.holder != identity {
+			return int64(0), nil
+		}
+		r.holder, r.ttl = identity, args[5]
+		return int64(1), nil
+	}
+	if r.holder != identity {
+		return int64(0), nil
+	}
+	r.holder = ""
+	return int64(1), nil
+}
+
+var _ = Describe("RedisElection", func() {
+	var (
+		fake     *fakeRedis
+		election *RedisElection
+	)
+
+	BeforeEach(func() {
+		fake = &fakeRedis{}
+		election = NewRedisElection(fake, "deployadactyl-leader")
+	})
+
+	It("elects the identity when nobody holds the key", func() {
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+
+		Expect(fake.holder).To(Equal("host-1"))
+		Expect(fake.ttl).To(Equal("15000"))
+	})
+
+	It("renews the key of the leader", func() {
+		fake.holder = "host-1"
+
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeTrue())
+	})
+
+	It("does not elect the identity when another identity holds the key", func() {
+		fake.holder = "host-2"
+
+		Expect(election.Acquire("host-1", 15*time.Second)).To(BeFalse())
+		Expect(fake.holder).To(Equal("host-2"))
+	})
+
+	It("releases the key it holds", func() {
+		fake.holder = "host-1"
+
+		Expect(election.Release("host-1")).To(Succeed())
+
+		Expect(fake.holder).To(BeEmpty())
+	})
+
+	It("returns the errors of redis", func() {
+		fake.err = errors.New("connection refused")
+
+		_, err := election.Acquire("host-1", 15*time.Second)
+
+		Expect(err).To(MatchError("connection refused"))
+	})
+})
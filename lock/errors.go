@@ -0,0 +1,31 @@
+package lock
+
+import "fmt"
+
+type HeldError struct {
+	Key   string
+	Owner string
+}
+
+func (e HeldError) Error() string {
+	return fmt.Sprintf("%s is locked by deployment %s", e.Key, e.Owner)
+}
+
+type NotHeldError struct {
+	Key   string
+	Owner string
+}
+
+func (e NotHeldError) Error() string {
+	return fmt.Sprintf("%s is not locked by deployment %s", e.Key, e.Owner)
+}
+
+type RedisError struct {
+	Operation string
+	Key       string
+	Err       error
+}
+
+func (e RedisError) Error() string {
+	return fmt.Sprintf("cannot %s %s: %s", e.Operation, e.Key, e.Err)
+}
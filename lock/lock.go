@@ -0,0 +1,36 @@
+// Package lock holds the locks of the applications being deployed, so two deployments never change an application
+// at the same time. The locks are held in memory, or in Redis to hold across every instance of the server.
+package lock
+
+import (
+	"strings"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/redis"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// New returns the LockManager configured by the descriptor: in Redis when it has a URL, otherwise in memory.
+func New(descriptor S.DeploymentLocksDescriptor, fs *afero.Afero, log I.Logger) (I.LockManager, error) {
+	if descriptor.Redis == "" {
+		return NewMemoryLocks(), nil
+	}
+
+	client, err := redis.Open(descriptor.Redis, fs)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := descriptor.TTLSeconds
+	if ttl == 0 {
+		ttl = S.DefaultLockTTLSeconds
+	}
+	return NewRedisLocks(client, time.Duration(ttl)*time.Second, log), nil
+}
+
+// AppKey returns the key of the lock of an application, which is the same whatever the case of its names.
+func AppKey(environment, org, space, app string) string {
+	return strings.ToLower(strings.Join([]string{environment, org, space, app}, "/"))
+}
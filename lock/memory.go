@@ -0,0 +1,38 @@
+package lock
+
+import "sync"
+
+// MemoryLocks holds the locks in memory, so they only hold within one instance of the server.
+type MemoryLocks struct {
+	mu     sync.Mutex
+	owners map[string]string
+}
+
+// NewMemoryLocks returns MemoryLocks holding no lock.
+func NewMemoryLocks() *MemoryLocks {
+	return &MemoryLocks{owners: map[string]string{}}
+}
+
+// Lock takes the lock of the key for the owner, or returns a HeldError when another owner holds it.
+func (l *MemoryLocks) Lock(key, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if holder, ok := l.owners[key]; ok && holder != owner {
+		return HeldError{key, holder}
+	}
+	l.owners[key] = owner
+	return nil
+}
+
+// Unlock releases the lock of the key, or returns a NotHeldError when the owner does not hold it.
+func (l *MemoryLocks) Unlock(key, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.owners[key] != owner {
+		return NotHeldError{key, owner}
+	}
+	delete(l.owners, key)
+	return nil
+}
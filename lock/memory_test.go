@@ -0,0 +1,42 @@
+package lock_test
+
+import (
+	"github.com/compozed/deployadactyl/conformance"
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/lock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MemoryLocks", func() {
+	var locks *MemoryLocks
+
+	BeforeEach(func() {
+		locks = NewMemoryLocks()
+	})
+
+	It("does not lock a key held by another owner", func() {
+		Expect(locks.Lock("prod/org/space/search", "1234")).To(Succeed())
+
+		Expect(locks.Lock("prod/org/space/search", "5678")).To(MatchError(HeldError{"prod/org/space/search", "1234"}))
+		Expect(locks.Lock("prod/org/space/other", "5678")).To(Succeed())
+	})
+
+	It("locks the key again once it is released", func() {
+		Expect(locks.Lock("prod/org/space/search", "1234")).To(Succeed())
+		Expect(locks.Unlock("prod/org/space/search", "1234")).To(Succeed())
+
+		Expect(locks.Lock("prod/org/space/search", "5678")).To(Succeed())
+	})
+
+	It("does not release the lock of another owner", func() {
+		Expect(locks.Lock("prod/org/space/search", "1234")).To(Succeed())
+
+		Expect(locks.Unlock("prod/org/space/search", "5678")).To(MatchError(NotHeldError{"prod/org/space/search", "5678"}))
+		Expect(locks.Lock("prod/org/space/search", "5678")).To(HaveOccurred())
+	})
+
+	It("satisfies the LockManager contract", func() {
+		conformance.LockManager(GinkgoT(), func() I.LockManager { return NewMemoryLocks() })
+	})
+})
@@ -0,0 +1,135 @@
+package lock
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// DefaultPrefix is prepended to the keys of the locks in Redis.
+const DefaultPrefix = "deployadactyl:lock:"
+
+// releaseScript deletes the lock only when the owner still holds it, so a lock that expired and was taken by another
+// deployment is not released.
+const releaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
+// refreshScript extends the lock only when the owner still holds it.
+const refreshScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`
+
+// RedisLocks holds the locks in Redis, so they hold across every instance of the server sharing it.
+//
+// A lock expires after the TTL, so the locks of an instance that stopped are released. The locks taken by an
+// instance are refreshed every third of the TTL until they are released.
+type RedisLocks struct {
+	Redis  I.Redis
+	TTL    time.Duration
+	Prefix string
+	Log    I.Logger
+
+	mu   sync.Mutex
+	held map[string]*refresher
+}
+
+// refresher refreshes a lock until it is stopped, and is done once it stopped refreshing it.
+type refresher struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRedisLocks returns RedisLocks holding the locks in the Redis for the TTL.
+func NewRedisLocks(redis I.Redis, ttl time.Duration, log I.Logger) *RedisLocks {
+	return &RedisLocks{
+		Redis:  redis,
+		TTL:    ttl,
+		Prefix: DefaultPrefix,
+		Log:    log,
+		held:   map[string]*refresher{},
+	}
+}
+
+// Lock takes the lock of the key for the owner, or returns a HeldError when another owner holds it.
+func (l *RedisLocks) Lock(key, owner string) error {
+	reply, err := l.Redis.Do("SET", l.Prefix+key, owner, "NX", "PX", l.milliseconds())
+	if err != nil {
+		return RedisError{"lock", key, err}
+	}
+
+	if reply == nil {
+		// The owner may already hold it when the command was run again on a new connection.
+		reply, err = l.Redis.Do("GET", l.Prefix+key)
+		if err != nil {
+			return RedisError{"lock", key, err}
+		}
+		if holder, _ := reply.(string); holder != owner {
+			return HeldError{key, holder}
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.held[heldKey(key, owner)]; !ok {
+		r := &refresher{stop: make(chan struct{}), done: make(chan struct{})}
+		l.held[heldKey(key, owner)] = r
+		go l.refresh(key, owner, r)
+	}
+	return nil
+}
+
+// Unlock releases the lock of the key, or returns a NotHeldError when the owner does not hold it anymore.
+func (l *RedisLocks) Unlock(key, owner string) error {
+	l.mu.Lock()
+	r, ok := l.held[heldKey(key, owner)]
+	delete(l.held, heldKey(key, owner))
+	l.mu.Unlock()
+
+	if ok {
+		close(r.stop)
+		<-r.done
+	}
+
+	reply, err := l.Redis.Do("EVAL", releaseScript, "1", l.Prefix+key, owner)
+	if err != nil {
+		return RedisError{"unlock", key, err}
+	}
+	if reply == int64(0) {
+		return NotHeldError{key, owner}
+	}
+	return nil
+}
+
+// refresh extends the lock until it is stopped or lost.
+func (l *RedisLocks) refresh(key, owner string, r *refresher) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(l.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+
+		reply, err := l.Redis.Do("EVAL", refreshScript, "1", l.Prefix+key, owner, l.milliseconds())
+		if err != nil {
+			l.Log.Errorf("cannot refresh the lock of %s: %s", key, err)
+			continue
+		}
+		if reply == int64(0) {
+			l.Log.Errorf("the lock of %s held by deployment %s expired before it was released", key, owner)
+			return
+		}
+	}
+}
+
+func (l *RedisLocks) milliseconds() string {
+	return strconv.FormatInt(int64(l.TTL/time.Millisecond), 10)
+}
+
+func heldKey(key, owner string) string {
+	return key + "\x00" + owner
+}
@@ -0,0 +1,185 @@
+package lock_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/lock"
+	"github.com/compozed/deployadactyl/redis"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+	"github.com/spf13/afero"
+)
+
+// fakeRedis runs the commands of the locks on values kept in memory, which never expire.
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	ttls     map[string]string
+	commands []string
+	err      error
+}
+
+func (r *fakeRedis) Do(args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.commands = append(r.commands, args[0])
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	switch args[0] {
+	case "SET":
+		if _, ok := r.values[args[1]]; ok {
+			return nil, nil
+		}
+		r.values[args[1]] = args[2]
+		r.ttls[args[1]] = args[5]
+		return "OK", nil
+	case "GET":
+		value, ok := r.values[args[1]]
+		if !ok {
+			return nil, nil
+		}
+		return value, nil
+	case "EVAL":
+		if r.values[args[3]] != args[4] {
+			return int64(0), nil
+		}
+		if strings.Contains(args[1], "pexpire") {
+			r.ttls[args[3]] = args[5]
+		} else {
+			delete(r.values, args[3])
+		}
+		return int64(1), nil
+	}
+	return nil, redis.Error{"ERR unknown command"}
+}
+
+func (r *fakeRedis) count(command string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, c := range r.commands {
+		if c == command {
+			n++
+		}
+	}
+	return n
+}
+
+var _ = Describe("RedisLocks", func() {
+	var (
+		fake      *fakeRedis
+		logBuffer *Buffer
+		locks     *RedisLocks
+	)
+
+	BeforeEach(func() {
+		fake = &fakeRedis{values: map[string]string{}, ttls: map[string]string{}}
+		logBuffer = NewBuffer()
+		locks = NewRedisLocks(fake, 30*time.Second, I.DefaultLogger(logBuffer, logging.DEBUG, "lock_test"))
+	})
+
+	It("sets the key of the lock to the owner for the TTL", func() {
+		Expect(locks.Lock("prod/org/space/search", "1234")).To(Succeed())
+
+		Expect(fake.values).To(HaveKeyWithValue("deployadactyl:lock:prod/org/space/search", "1234"))
+		Expect(fake.ttls).To(HaveKeyWithValue("deployadactyl:lock:prod/org/space/search", "30000"))
+	})
+
+	It("does not lock a key held by another owner", func() {
+		fake.values["deployadactyl:lock:prod/org/space/search"] = "1234"
+
+		Expect(locks.Lock("prod/org/space/search", "5678")).To(MatchError(HeldError{"prod/org/space/search", "1234"}))
+	})
+
+	It("locks a key it already holds", func() {
+		fake.values["deployadactyl:lock:prod/org/space/search"] = "1234"
+
+		Expect(locks.Lock("prod/org/space/search", "1234")).To(Succeed())
+	})
+
+	It("deletes the key when the lock is released", func() {
+		Expect(locks.Lock("prod/org/space/search", "1234")).To(Succeed())
+		Expect(locks.Unlock("prod/org/space/search", "1234")).To(Succeed())
+
+		Expect(fake.values).To(BeEmpty())
+	})
+
+	It("does not release the lock of another owner", func() {
+		fake.values["deployadactyl:lock:prod/org/space/search"] = "1234"
+
+		Expect(locks.Unlock("prod/org/space/search", "5678")).To(MatchError(NotHeldError{"prod/org/space/search", "5678"}))
+		Expect(fake.values).To(HaveLen(1))
+	})
+
+	It("refreshes the lock until it is released", func() {
+		locks.TTL = 30 * time.Millisecond
+
+		Expect(locks.Lock("prod/org/space/search", "1234")).To(Succeed())
+		Eventually(func() int { return fake.count("EVAL") }).Should(BeNumerically(">=", 2))
+
+		Expect(locks.Unlock("prod/org/space/search", "1234")).To(Succeed())
+		evals := fake.count("EVAL")
+		Consistently(func() int { return fake.count("EVAL") }, 50*time.Millisecond).Should(Equal(evals))
+	})
+
+	It("stops refreshing a lock that was lost", func() {
+		locks.TTL = 30 * time.Millisecond
+
+		Expect(locks.Lock("prod/org/space/search", "1234")).To(Succeed())
+		fake.mu.Lock()
+		fake.values["deployadactyl:lock:prod/org/space/search"] = "5678"
+		fake.mu.Unlock()
+
+		Eventually(logBuffer).Should(Say("the lock of prod/org/space/search held by deployment 1234 expired before it was released"))
+	})
+
+	It("returns the errors of redis", func() {
+		fake.err = errors.New("connection refused")
+
+		Expect(locks.Lock("prod/org/space/search", "1234")).To(MatchError(RedisError{"lock", "prod/org/space/search", fake.err}))
+		Expect(locks.Unlock("prod/org/space/search", "1234")).To(MatchError(RedisError{"unlock", "prod/org/space/search", fake.err}))
+	})
+})
+
+var _ = Describe("New", func() {
+	fs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	It("holds the locks in memory without a redis", func() {
+		locks, err := New(S.DeploymentLocksDescriptor{}, fs, nil)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(locks).To(BeAssignableToTypeOf(&MemoryLocks{}))
+	})
+
+	It("holds the locks in redis for the TTL", func() {
+		locks, err := New(S.DeploymentLocksDescriptor{Redis: "redis://redis.example.com", TTLSeconds: 10}, fs, nil)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(locks.(*RedisLocks).TTL).To(Equal(10 * time.Second))
+		Expect(locks.(*RedisLocks).Redis.(*redis.Client).Config.Address).To(Equal("redis.example.com:6379"))
+	})
+
+	It("defaults the TTL", func() {
+		locks, err := New(S.DeploymentLocksDescriptor{Redis: "redis://redis.example.com"}, fs, nil)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(locks.(*RedisLocks).TTL).To(Equal(S.DefaultLockTTLSeconds * time.Second))
+	})
+
+	It("returns an error for an invalid URL", func() {
+		_, err := New(S.DeploymentLocksDescriptor{Redis: "http://redis.example.com"}, fs, nil)
+
+		Expect(err).To(BeAssignableToTypeOf(redis.InvalidURLError{}))
+	})
+})
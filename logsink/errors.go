@@ -0,0 +1,75 @@
+package logsink
+
+import "fmt"
+
+type UnknownTypeError struct {
+	Type string
+}
+
+func (e UnknownTypeError) Error() string {
+	return fmt.Sprintf("unknown log sink type %s: expected %s, %s, %s or %s", e.Type, Stdout, File, Syslog, HTTP)
+}
+
+type InvalidLevelError struct {
+	Type  string
+	Level string
+}
+
+func (e InvalidLevelError) Error() string {
+	return fmt.Sprintf("invalid level %s of the %s log sink", e.Level, e.Type)
+}
+
+type MissingDestinationError struct {
+	Type  string
+	Field string
+}
+
+func (e MissingDestinationError) Error() string {
+	return fmt.Sprintf("the %s log sink requires a %s", e.Type, e.Field)
+}
+
+type InvalidURLError struct {
+	URL string
+}
+
+func (e InvalidURLError) Error() string {
+	return fmt.Sprintf("the url of an http log sink must be an http or https url: %s", e.URL)
+}
+
+type OpenFileError struct {
+	Path string
+	Err  error
+}
+
+func (e OpenFileError) Error() string {
+	return fmt.Sprintf("cannot open log file %s: %s", e.Path, e.Err)
+}
+
+type RotateFileError struct {
+	Path string
+	Err  error
+}
+
+func (e RotateFileError) Error() string {
+	return fmt.Sprintf("cannot rotate log file %s: %s", e.Path, e.Err)
+}
+
+type InvalidRotationError struct {
+	Path string
+}
+
+func (e InvalidRotationError) Error() string {
+	return fmt.Sprintf("the rotation of log file %s must not have negative limits", e.Path)
+}
+
+type SyslogError struct {
+	Address string
+	Err     error
+}
+
+func (e SyslogError) Error() string {
+	if e.Address == "" {
+		return fmt.Sprintf("cannot connect to the local syslog: %s", e.Err)
+	}
+	return fmt.Sprintf("cannot connect to syslog at %s: %s", e.Address, e.Err)
+}
@@ -0,0 +1,67 @@
+package logsink
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/op/go-logging"
+)
+
+// HTTPBufferSize is how many lines an http sink holds while they are being posted.
+// Lines are dropped while the buffer is full so that a slow forwarder never holds up a deployment.
+const HTTPBufferSize = 1000
+
+// HTTPTimeout is how long an http sink waits for a line to be accepted.
+const HTTPTimeout = 5 * time.Second
+
+// HTTPLine is the JSON posted by an http sink for every log line.
+type HTTPLine struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Module  string    `json:"module"`
+	Message string    `json:"message"`
+}
+
+type httpBackend struct {
+	lines chan HTTPLine
+}
+
+func newHTTPBackend(url string) logging.Backend {
+	b := httpBackend{lines: make(chan HTTPLine, HTTPBufferSize)}
+	go b.forward(url, &http.Client{Timeout: HTTPTimeout})
+	return b
+}
+
+func (b httpBackend) Log(level logging.Level, calldepth int, rec *logging.Record) error {
+	line := HTTPLine{
+		Time:    rec.Time,
+		Level:   level.String(),
+		Module:  rec.Module,
+		Message: rec.Message(),
+	}
+
+	select {
+	case b.lines <- line:
+	default:
+	}
+	return nil
+}
+
+// forward posts the lines one at a time. A line that cannot be posted is dropped, since logging
+// the failure would only add another line to forward.
+func (b httpBackend) forward(url string, client *http.Client) {
+	for line := range b.lines {
+		body, err := json.Marshal(line)
+		if err != nil {
+			continue
+		}
+
+		response, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			continue
+		}
+		response.Body.Close()
+	}
+}
@@ -0,0 +1,114 @@
+// Package logsink writes the logs of the server to the sinks configured in the config.yml.
+package logsink
+
+import (
+	"io"
+	"net/url"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/op/go-logging"
+)
+
+// The types of sinks.
+const (
+	Stdout = "stdout"
+	File   = "file"
+	Syslog = "syslog"
+	HTTP   = "http"
+)
+
+// DefaultTag is the syslog tag of a syslog sink without one.
+const DefaultTag = "deployadactyl"
+
+// Validate returns an error when a sink has an unknown type or level, or is missing its destination.
+func Validate(sinks []S.LogSinkDescriptor) error {
+	for _, sink := range sinks {
+		if sink.Level != "" {
+			_, err := logging.LogLevel(sink.Level)
+			if err != nil {
+				return InvalidLevelError{sink.Type, sink.Level}
+			}
+		}
+
+		switch sink.Type {
+		case Stdout, Syslog:
+		case File:
+			if sink.Path == "" {
+				return MissingDestinationError{sink.Type, "path"}
+			}
+			rotation := sink.Rotation
+			if rotation.MaxSizeMB < 0 || rotation.IntervalHours < 0 || rotation.MaxBackups < 0 || rotation.MaxAgeDays < 0 {
+				return InvalidRotationError{sink.Path}
+			}
+		case HTTP:
+			if sink.URL == "" {
+				return MissingDestinationError{sink.Type, "url"}
+			}
+			u, err := url.Parse(sink.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return InvalidURLError{sink.URL}
+			}
+		default:
+			return UnknownTypeError{sink.Type}
+		}
+	}
+	return nil
+}
+
+// NewLogger sends every log line of the server to the sinks and returns the logger of the module.
+// The logs are written to stdout when there are no sinks.
+func NewLogger(sinks []S.LogSinkDescriptor, level logging.Level, module string, stdout io.Writer) (I.Logger, error) {
+	if len(sinks) == 0 {
+		return I.DefaultLogger(stdout, level, module), nil
+	}
+
+	backends := make([]logging.Backend, 0, len(sinks))
+	for _, sink := range sinks {
+		backend, err := newBackend(sink, stdout)
+		if err != nil {
+			return nil, err
+		}
+
+		sinkLevel := level
+		if sink.Level != "" {
+			sinkLevel, err = logging.LogLevel(sink.Level)
+			if err != nil {
+				return nil, InvalidLevelError{sink.Type, sink.Level}
+			}
+		}
+
+		leveled := logging.AddModuleLevel(backend)
+		leveled.SetLevel(sinkLevel, "")
+		backends = append(backends, leveled)
+	}
+	logging.SetBackend(backends...)
+
+	return logging.MustGetLogger(module), nil
+}
+
+func newBackend(sink S.LogSinkDescriptor, stdout io.Writer) (logging.Backend, error) {
+	switch sink.Type {
+	case Stdout:
+		return writerBackend(stdout), nil
+	case File:
+		file, err := OpenRotatingFile(sink.Path, sink.Rotation)
+		if err != nil {
+			return nil, err
+		}
+		return writerBackend(file), nil
+	case Syslog:
+		tag := sink.Tag
+		if tag == "" {
+			tag = DefaultTag
+		}
+		return newSyslogBackend(sink.Network, sink.Address, tag)
+	case HTTP:
+		return newHTTPBackend(sink.URL), nil
+	}
+	return nil, UnknownTypeError{sink.Type}
+}
+
+func writerBackend(out io.Writer) logging.Backend {
+	return logging.NewBackendFormatter(logging.NewLogBackend(out, "", 0), logging.MustStringFormatter(I.LogFormat))
+}
@@ -0,0 +1,160 @@
+package logsink_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/compozed/deployadactyl/logsink"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Log sinks", func() {
+	Describe("Validate", func() {
+		It("accepts every type of sink", func() {
+			Expect(Validate([]S.LogSinkDescriptor{
+				{Type: Stdout},
+				{Type: File, Path: "/var/log/deployadactyl.log", Level: "error"},
+				{Type: Syslog, Network: "udp", Address: "syslog.example.com:514"},
+				{Type: HTTP, URL: "https://fluentd.example.com/deployadactyl"},
+			})).To(Succeed())
+		})
+
+		It("rejects an unknown type", func() {
+			Expect(Validate([]S.LogSinkDescriptor{{Type: "kafka"}})).To(MatchError(UnknownTypeError{"kafka"}))
+		})
+
+		It("rejects an unknown level", func() {
+			Expect(Validate([]S.LogSinkDescriptor{{Type: Stdout, Level: "loud"}})).To(MatchError(InvalidLevelError{Stdout, "loud"}))
+		})
+
+		It("rejects a sink without its destination", func() {
+			Expect(Validate([]S.LogSinkDescriptor{{Type: File}})).To(MatchError(MissingDestinationError{File, "path"}))
+			Expect(Validate([]S.LogSinkDescriptor{{Type: HTTP}})).To(MatchError(MissingDestinationError{HTTP, "url"}))
+		})
+
+		It("rejects a file sink with negative rotation limits", func() {
+			sink := S.LogSinkDescriptor{Type: File, Path: "/var/log/deployadactyl.log", Rotation: S.LogRotationDescriptor{MaxBackups: -1}}
+
+			Expect(Validate([]S.LogSinkDescriptor{sink})).To(MatchError(InvalidRotationError{"/var/log/deployadactyl.log"}))
+		})
+
+		It("rejects an http sink without an http url", func() {
+			Expect(Validate([]S.LogSinkDescriptor{{Type: HTTP, URL: "fluentd:24224"}})).To(MatchError(InvalidURLError{"fluentd:24224"}))
+		})
+	})
+
+	Describe("NewLogger", func() {
+		var (
+			stdout *Buffer
+			dir    string
+		)
+
+		BeforeEach(func() {
+			stdout = NewBuffer()
+
+			var err error
+			dir, err = ioutil.TempDir("", "logsink-")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("logs to stdout without sinks", func() {
+			log, err := NewLogger(nil, logging.DEBUG, "logsink_test", stdout)
+			Expect(err).ToNot(HaveOccurred())
+
+			log.Infof("hello %s", "world")
+
+			Eventually(stdout).Should(Say(`INFO ▶ hello world`))
+		})
+
+		It("logs to every sink at its own level", func() {
+			path := filepath.Join(dir, "deployadactyl.log")
+
+			log, err := NewLogger([]S.LogSinkDescriptor{
+				{Type: Stdout},
+				{Type: File, Path: path, Level: "error"},
+			}, logging.DEBUG, "logsink_test", stdout)
+			Expect(err).ToNot(HaveOccurred())
+
+			log.Debug("debug line")
+			log.Error("error line")
+
+			Eventually(stdout).Should(Say("DEBU ▶ debug line"))
+			Eventually(stdout).Should(Say("ERRO ▶ error line"))
+
+			contents, err := ioutil.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).ToNot(ContainSubstring("debug line"))
+			Expect(string(contents)).To(ContainSubstring("ERRO ▶ error line"))
+		})
+
+		It("returns an error when the log file cannot be opened", func() {
+			path := filepath.Join(dir, "missing", "deployadactyl.log")
+
+			_, err := NewLogger([]S.LogSinkDescriptor{{Type: File, Path: path}}, logging.DEBUG, "logsink_test", stdout)
+
+			Expect(err).To(BeAssignableToTypeOf(OpenFileError{}))
+		})
+
+		It("posts every line as JSON to an http sink", func() {
+			lines := make(chan HTTPLine, 10)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				var line HTTPLine
+				Expect(json.NewDecoder(r.Body).Decode(&line)).To(Succeed())
+				lines <- line
+			}))
+			defer server.Close()
+
+			log, err := NewLogger([]S.LogSinkDescriptor{{Type: HTTP, URL: server.URL}}, logging.INFO, "logsink_test", stdout)
+			Expect(err).ToNot(HaveOccurred())
+
+			log.Debug("debug line")
+			log.Errorf("could not push %s", "app")
+
+			var line HTTPLine
+			Eventually(lines).Should(Receive(&line))
+			Expect(line.Level).To(Equal("ERROR"))
+			Expect(line.Module).To(Equal("logsink_test"))
+			Expect(line.Message).To(Equal("could not push app"))
+			Expect(line.Time).ToNot(BeZero())
+			Consistently(lines).ShouldNot(Receive())
+		})
+
+		It("sends every line to a syslog sink", func() {
+			if runtime.GOOS == "windows" {
+				Skip("syslog is not supported on windows")
+			}
+
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+
+			log, err := NewLogger([]S.LogSinkDescriptor{
+				{Type: Syslog, Network: "udp", Address: conn.LocalAddr().String(), Tag: "deployadactyl-test"},
+			}, logging.INFO, "logsink_test", stdout)
+			Expect(err).ToNot(HaveOccurred())
+
+			log.Error("error line")
+
+			buffer := make([]byte, 1024)
+			n, _, err := conn.ReadFrom(buffer)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(buffer[:n])).To(MatchRegexp(`^<27>.*deployadactyl-test\[\d+\]: error line`))
+		})
+	})
+})
@@ -0,0 +1,174 @@
+package logsink
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// BackupTimeFormat is the timestamp appended to the name of a rotated log file, such as
+// deployadactyl.log.2006-01-02T15-04-05.000. It sorts in the order the files were rotated.
+const BackupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile is a log file that is rotated once it gets too big or too old, keeping a limited number
+// of the rotated files.
+type RotatingFile struct {
+	Path     string
+	Rotation S.LogRotationDescriptor
+	Now      func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	period time.Time
+}
+
+// OpenRotatingFile opens the log file at path for appending. A file left from a previous period is
+// rotated on the first write.
+func OpenRotatingFile(path string, rotation S.LogRotationDescriptor) (*RotatingFile, error) {
+	f := &RotatingFile{Path: path, Rotation: rotation, Now: time.Now}
+
+	err := f.open()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.file.Stat()
+	if err != nil {
+		f.file.Close()
+		return nil, OpenFileError{path, err}
+	}
+	f.size = info.Size()
+	f.period = f.periodOf(info.ModTime())
+
+	return f, nil
+}
+
+// Write appends p to the log file, rotating it first when needed.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.Now()
+	if f.shouldRotate(now, len(p)) {
+		err := f.rotate(now)
+		if err != nil {
+			return 0, err
+		}
+	}
+	f.period = f.periodOf(now)
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the log file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Close()
+}
+
+// Backups returns the paths of the rotated files, oldest first.
+func (f *RotatingFile) Backups() ([]string, error) {
+	paths, err := filepath.Glob(f.Path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, path := range paths {
+		_, err := time.Parse(BackupTimeFormat, strings.TrimPrefix(path, f.Path+"."))
+		if err == nil {
+			backups = append(backups, path)
+		}
+	}
+	sort.Strings(backups)
+
+	return backups, nil
+}
+
+func (f *RotatingFile) shouldRotate(now time.Time, length int) bool {
+	if f.size == 0 {
+		return false
+	}
+
+	maxSize := int64(f.Rotation.MaxSizeMB) * 1024 * 1024
+	if maxSize > 0 && f.size+int64(length) > maxSize {
+		return true
+	}
+
+	return f.Rotation.IntervalHours > 0 && f.periodOf(now).After(f.period)
+}
+
+func (f *RotatingFile) rotate(now time.Time) error {
+	err := f.file.Close()
+	if err != nil {
+		return RotateFileError{f.Path, err}
+	}
+
+	err = os.Rename(f.Path, f.Path+"."+now.UTC().Format(BackupTimeFormat))
+	if err != nil {
+		f.open()
+		return RotateFileError{f.Path, err}
+	}
+
+	err = f.open()
+	if err != nil {
+		return err
+	}
+	f.size = 0
+
+	return f.prune(now)
+}
+
+// prune removes the rotated files beyond MaxBackups and the ones older than MaxAgeDays.
+func (f *RotatingFile) prune(now time.Time) error {
+	backups, err := f.Backups()
+	if err != nil {
+		return RotateFileError{f.Path, err}
+	}
+
+	if f.Rotation.MaxBackups > 0 && len(backups) > f.Rotation.MaxBackups {
+		for _, path := range backups[:len(backups)-f.Rotation.MaxBackups] {
+			os.Remove(path)
+		}
+		backups = backups[len(backups)-f.Rotation.MaxBackups:]
+	}
+
+	if f.Rotation.MaxAgeDays > 0 {
+		cutoff := now.Add(-time.Duration(f.Rotation.MaxAgeDays) * 24 * time.Hour)
+		for _, path := range backups {
+			info, err := os.Stat(path)
+			if err == nil && info.ModTime().Before(cutoff) {
+				os.Remove(path)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return OpenFileError{f.Path, err}
+	}
+	f.file = file
+	return nil
+}
+
+// periodOf returns the start of the rotation period of t.
+func (f *RotatingFile) periodOf(t time.Time) time.Time {
+	if f.Rotation.IntervalHours <= 0 {
+		return time.Time{}
+	}
+	return t.UTC().Truncate(time.Duration(f.Rotation.IntervalHours) * time.Hour)
+}
@@ -0,0 +1,132 @@
+package logsink_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/compozed/deployadactyl/logsink"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RotatingFile", func() {
+	var (
+		dir  string
+		path string
+		now  time.Time
+		file *RotatingFile
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "logsink-")
+		Expect(err).ToNot(HaveOccurred())
+
+		path = filepath.Join(dir, "deployadactyl.log")
+		now = time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	})
+
+	AfterEach(func() {
+		if file != nil {
+			file.Close()
+		}
+		os.RemoveAll(dir)
+	})
+
+	open := func(rotation S.LogRotationDescriptor) {
+		var err error
+		file, err = OpenRotatingFile(path, rotation)
+		Expect(err).ToNot(HaveOccurred())
+		file.Now = func() time.Time { return now }
+	}
+
+	write := func(line string) {
+		_, err := file.Write([]byte(line))
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	contents := func(path string) string {
+		b, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		return string(b)
+	}
+
+	It("appends to the file without rotation", func() {
+		Expect(ioutil.WriteFile(path, []byte("first\n"), 0644)).To(Succeed())
+		open(S.LogRotationDescriptor{})
+
+		write("second\n")
+
+		Expect(contents(path)).To(Equal("first\nsecond\n"))
+		Expect(file.Backups()).To(BeEmpty())
+	})
+
+	It("rotates the file before it grows past the maximum size", func() {
+		open(S.LogRotationDescriptor{MaxSizeMB: 1})
+
+		write(string(make([]byte, 1024*1024-1)))
+		write("a\n")
+
+		backups, err := file.Backups()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backups).To(Equal([]string{path + ".2026-10-16T10-00-00.000"}))
+		Expect(contents(path)).To(Equal("a\n"))
+	})
+
+	It("rotates the file at the start of every interval", func() {
+		open(S.LogRotationDescriptor{IntervalHours: 24})
+
+		write("today\n")
+		now = now.Add(13 * time.Hour)
+		write("still today\n")
+		now = now.Add(time.Hour)
+		write("tomorrow\n")
+
+		backups, err := file.Backups()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backups).To(HaveLen(1))
+		Expect(contents(backups[0])).To(Equal("today\nstill today\n"))
+		Expect(contents(path)).To(Equal("tomorrow\n"))
+	})
+
+	It("keeps at most the maximum number of backups", func() {
+		open(S.LogRotationDescriptor{IntervalHours: 1, MaxBackups: 2})
+
+		for i := 0; i < 4; i++ {
+			write("line\n")
+			now = now.Add(time.Hour)
+		}
+		write("line\n")
+
+		backups, err := file.Backups()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backups).To(Equal([]string{
+			path + ".2026-10-16T13-00-00.000",
+			path + ".2026-10-16T14-00-00.000",
+		}))
+	})
+
+	It("removes the backups older than the maximum age", func() {
+		old := path + ".2026-10-01T00-00-00.000"
+		Expect(ioutil.WriteFile(old, []byte("old\n"), 0644)).To(Succeed())
+		Expect(os.Chtimes(old, now.AddDate(0, 0, -15), now.AddDate(0, 0, -15))).To(Succeed())
+
+		open(S.LogRotationDescriptor{MaxSizeMB: 1, MaxAgeDays: 14})
+		write(string(make([]byte, 1024*1024)))
+		write("a\n")
+
+		backups, err := file.Backups()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backups).To(Equal([]string{path + ".2026-10-16T10-00-00.000"}))
+	})
+
+	It("ignores files that are not backups", func() {
+		Expect(ioutil.WriteFile(path+".old", []byte("old\n"), 0644)).To(Succeed())
+		open(S.LogRotationDescriptor{})
+
+		Expect(file.Backups()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+package logsink
+
+import (
+	"log/syslog"
+
+	"github.com/op/go-logging"
+)
+
+type syslogBackend struct {
+	writer *syslog.Writer
+}
+
+// newSyslogBackend connects to the local syslog when the address is empty.
+func newSyslogBackend(network, address, tag string) (logging.Backend, error) {
+	writer, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, SyslogError{address, err}
+	}
+	return syslogBackend{writer}, nil
+}
+
+// Log writes the message with the syslog severity of its level. Syslog adds its own timestamp.
+func (b syslogBackend) Log(level logging.Level, calldepth int, rec *logging.Record) error {
+	message := rec.Message()
+
+	switch level {
+	case logging.CRITICAL:
+		return b.writer.Crit(message)
+	case logging.ERROR:
+		return b.writer.Err(message)
+	case logging.WARNING:
+		return b.writer.Warning(message)
+	case logging.NOTICE:
+		return b.writer.Notice(message)
+	case logging.INFO:
+		return b.writer.Info(message)
+	}
+	return b.writer.Debug(message)
+}
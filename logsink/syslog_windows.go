@@ -0,0 +1,11 @@
+package logsink
+
+import (
+	"errors"
+
+	"github.com/op/go-logging"
+)
+
+func newSyslogBackend(network, address, tag string) (logging.Backend, error) {
+	return nil, SyslogError{address, errors.New("syslog is not supported on windows")}
+}
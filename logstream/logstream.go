@@ -0,0 +1,349 @@
+// Package logstream streams the output of the running deployments to subscribers as it is written, whichever
+// instance of the server they run on when the instances share their state.
+package logstream
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// DefaultBacklogBytes is how much of the output written before a subscription a new subscriber receives first.
+const DefaultBacklogBytes = 64 * 1024
+
+// DefaultMaxFinished is how many finished deployments keep the end of their output, so a subscription arriving
+// right after a deployment finished still receives it.
+const DefaultMaxFinished = 100
+
+// SubscriberBuffer is how many writes a subscriber can fall behind. A subscriber falling further behind is
+// unsubscribed, since dropping writes would leave holes in the output.
+const SubscriberBuffer = 256
+
+// SharedTTL is how long the shared state remembers that a deployment finished.
+const SharedTTL = 24 * time.Hour
+
+// SharedPollInterval is how often the shared state is read while the output of a deployment running on another
+// instance is streamed, in case the message ending it was missed.
+const SharedPollInterval = 5 * time.Second
+
+// NewHub returns a Hub without deployments.
+func NewHub() *Hub {
+	return &Hub{
+		BacklogBytes: DefaultBacklogBytes,
+		MaxFinished:  DefaultMaxFinished,
+		PollInterval: SharedPollInterval,
+		streams:      map[string]*stream{},
+	}
+}
+
+// Hub streams the output written by the deployments of this instance to their subscribers. Once it is shared, the
+// output is published to every instance, and the output of a deployment running on another instance is streamed
+// from the shared state.
+type Hub struct {
+	BacklogBytes int
+	MaxFinished  int
+	PollInterval time.Duration
+
+	mu       sync.Mutex
+	streams  map[string]*stream
+	finished []string
+	shared   I.SharedState
+	log      I.Logger
+}
+
+type stream struct {
+	writers     int
+	started     bool
+	finished    bool
+	backlog     []byte
+	subscribers []chan []byte
+}
+
+// message is the output of a deployment published to the shared state. The last message of a deployment is
+// finished.
+type message struct {
+	Output   []byte `json:"output,omitempty"`
+	Finished bool   `json:"finished,omitempty"`
+}
+
+// Share publishes the output of the deployments of this instance from now on to the shared state, and streams the
+// output of the deployments of the other instances.
+func (h *Hub) Share(state I.SharedState, log I.Logger) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.shared = state
+	h.log = log
+}
+
+// Open returns a writer streaming everything written to it as the output of the deployment with the uuid. The
+// stream ends once every writer opened for the deployment is closed.
+func (h *Hub) Open(uuid string) io.WriteCloser {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.streams[uuid]
+	if !ok || s.finished {
+		s = &stream{}
+		h.streams[uuid] = s
+	}
+	s.started = true
+	s.writers++
+
+	return &writer{hub: h, uuid: uuid}
+}
+
+// Subscribe returns a channel receiving the output of the deployment with the uuid as it is written, starting
+// with the end of the output written so far. A deployment that has not started yet is waited for. The channel is
+// closed once the deployment is finished, straight away if it already is, or when the subscriber falls more than
+// SubscriberBuffer writes behind. unsubscribe must be called once the output is no longer read.
+func (h *Hub) Subscribe(uuid string) (output <-chan []byte, unsubscribe func()) {
+	h.mu.Lock()
+	s, ok := h.streams[uuid]
+	if (!ok || !s.started) && h.shared != nil {
+		h.mu.Unlock()
+		return h.subscribeShared(uuid)
+	}
+	defer h.mu.Unlock()
+
+	if !ok {
+		s = &stream{}
+		h.streams[uuid] = s
+	}
+
+	subscriber := make(chan []byte, SubscriberBuffer)
+	if len(s.backlog) > 0 {
+		subscriber <- append([]byte{}, s.backlog...)
+	}
+	if s.finished {
+		close(subscriber)
+		return subscriber, func() {}
+	}
+	s.subscribers = append(s.subscribers, subscriber)
+
+	return subscriber, func() { h.unsubscribe(uuid, subscriber) }
+}
+
+func (h *Hub) unsubscribe(uuid string, subscriber chan []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.streams[uuid]
+	if !ok {
+		return
+	}
+	for i, c := range s.subscribers {
+		if c == subscriber {
+			s.subscribers = append(s.subscribers[:i:i], s.subscribers[i+1:]...)
+			break
+		}
+	}
+
+	// Waiting for a deployment that never started leaves nothing behind.
+	if !s.started && len(s.subscribers) == 0 {
+		delete(h.streams, uuid)
+	}
+}
+
+func (h *Hub) write(uuid string, p []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.streams[uuid]
+	if !ok || s.finished || len(p) == 0 {
+		return
+	}
+
+	s.backlog = append(s.backlog, p...)
+	if len(s.backlog) > h.BacklogBytes {
+		s.backlog = append([]byte{}, s.backlog[len(s.backlog)-h.BacklogBytes:]...)
+	}
+
+	output := append([]byte{}, p...)
+	subscribers := s.subscribers[:0]
+	for _, subscriber := range s.subscribers {
+		select {
+		case subscriber <- output:
+			subscribers = append(subscribers, subscriber)
+		default:
+			close(subscriber)
+		}
+	}
+	s.subscribers = subscribers
+
+	h.publish(uuid, message{Output: output})
+}
+
+func (h *Hub) close(uuid string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.streams[uuid]
+	if !ok || s.finished {
+		return
+	}
+	s.writers--
+	if s.writers > 0 {
+		return
+	}
+
+	s.finished = true
+	for _, subscriber := range s.subscribers {
+		close(subscriber)
+	}
+	s.subscribers = nil
+
+	h.finished = append(h.finished, uuid)
+	if len(h.finished) > h.MaxFinished {
+		for _, old := range h.finished[:len(h.finished)-h.MaxFinished] {
+			if h.streams[old] != nil && h.streams[old].finished {
+				delete(h.streams, old)
+			}
+		}
+		h.finished = append([]string{}, h.finished[len(h.finished)-h.MaxFinished:]...)
+	}
+
+	h.publish(uuid, message{Finished: true})
+	if h.shared != nil {
+		err := h.shared.Put(finishedKey(uuid), []byte{}, SharedTTL)
+		if err != nil {
+			h.log.Errorf("cannot share that deployment %s finished: %s", uuid, err)
+		}
+	}
+}
+
+// publish shares the message with the other instances. The messages are published while the lock is held, so they
+// are published in the order they were written.
+func (h *Hub) publish(uuid string, m message) {
+	if h.shared == nil {
+		return
+	}
+
+	data, err := json.Marshal(m)
+	if err == nil {
+		err = h.shared.Publish(channel(uuid), data)
+	}
+	if err != nil {
+		h.log.Errorf("cannot share the output of deployment %s: %s", uuid, err)
+	}
+}
+
+// subscribeShared streams the output of a deployment running on another instance. Only the output published from
+// now on is received.
+func (h *Hub) subscribeShared(uuid string) (<-chan []byte, func()) {
+	h.mu.Lock()
+	state, log, pollInterval := h.shared, h.log, h.PollInterval
+	h.mu.Unlock()
+
+	messages, unsubscribeShared := state.Subscribe(channel(uuid))
+	output := make(chan []byte, SubscriberBuffer)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(output)
+
+		// forward passes the output of the message on, and reports whether the stream is over.
+		forward := func(data []byte, ok bool) bool {
+			if !ok {
+				return true
+			}
+			m := message{}
+			if err := json.Unmarshal(data, &m); err != nil {
+				log.Errorf("cannot read the output of deployment %s: %s", uuid, err)
+				return false
+			}
+			if m.Finished {
+				return true
+			}
+			select {
+			case output <- m.Output:
+				return false
+			default:
+				return true
+			}
+		}
+
+		// finished forwards the messages received so far once the deployment finished, in case the message
+		// ending it was missed.
+		finished := func() bool {
+			_, found, err := state.Get(finishedKey(uuid))
+			if err != nil {
+				log.Errorf("cannot read whether deployment %s finished: %s", uuid, err)
+			}
+			if !found {
+				return false
+			}
+			for {
+				select {
+				case data, ok := <-messages:
+					if forward(data, ok) {
+						return true
+					}
+				default:
+					return true
+				}
+			}
+		}
+
+		if finished() {
+			return
+		}
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case data, ok := <-messages:
+				if forward(data, ok) {
+					return
+				}
+			case <-ticker.C:
+				if finished() {
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return output, func() {
+		once.Do(func() {
+			close(done)
+			unsubscribeShared()
+		})
+	}
+}
+
+type writer struct {
+	hub  *Hub
+	uuid string
+	once sync.Once
+}
+
+// Write streams p to the subscribers. It never fails, so streaming cannot fail a deployment.
+func (w *writer) Write(p []byte) (int, error) {
+	w.hub.write(w.uuid, p)
+	return len(p), nil
+}
+
+// Close ends the stream once it is the last writer of the deployment. Closing it again does nothing.
+func (w *writer) Close() error {
+	w.once.Do(func() { w.hub.close(w.uuid) })
+	return nil
+}
+
+// channel is the channel of the shared state the output of the deployment is published on.
+func channel(uuid string) string {
+	return "logs:" + uuid
+}
+
+// finishedKey is the key of the shared state set once the deployment finished.
+func finishedKey(uuid string) string {
+	return "logs-finished:" + uuid
+}
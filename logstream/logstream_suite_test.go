@@ -0,0 +1,13 @@
+package logstream_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLogstream(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Logstream Suite")
+}
@@ -0,0 +1,157 @@
+package logstream_test
+
+import (
+	"fmt"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/logstream"
+	"github.com/compozed/deployadactyl/sharedstate"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Hub", func() {
+	var hub *Hub
+
+	read := func(output <-chan []byte) string {
+		all := ""
+		for p := range output {
+			all += string(p)
+		}
+		return all
+	}
+
+	BeforeEach(func() {
+		hub = NewHub()
+	})
+
+	It("streams the output of a deployment until it is finished", func() {
+		stream := hub.Open("abc")
+		fmt.Fprint(stream, "pushing ")
+
+		output, unsubscribe := hub.Subscribe("abc")
+		defer unsubscribe()
+
+		fmt.Fprint(stream, "app\n")
+		stream.Close()
+
+		Expect(read(output)).To(Equal("pushing app\n"))
+	})
+
+	It("waits for a deployment that has not started yet", func() {
+		output, unsubscribe := hub.Subscribe("abc")
+		defer unsubscribe()
+
+		stream := hub.Open("abc")
+		fmt.Fprint(stream, "pushing app\n")
+		stream.Close()
+
+		Expect(read(output)).To(Equal("pushing app\n"))
+	})
+
+	It("sends the end of the output of a finished deployment", func() {
+		hub.BacklogBytes = 4
+		stream := hub.Open("abc")
+		fmt.Fprint(stream, "pushing app\n")
+		stream.Close()
+
+		output, unsubscribe := hub.Subscribe("abc")
+		defer unsubscribe()
+
+		Expect(read(output)).To(Equal("app\n"))
+	})
+
+	It("forgets the oldest finished deployments", func() {
+		hub.MaxFinished = 1
+		for _, uuid := range []string{"abc", "def"} {
+			stream := hub.Open(uuid)
+			fmt.Fprint(stream, uuid)
+			stream.Close()
+		}
+
+		output, unsubscribe := hub.Subscribe("def")
+		Expect(read(output)).To(Equal("def"))
+		unsubscribe()
+
+		output, unsubscribe = hub.Subscribe("abc")
+		defer unsubscribe()
+		Consistently(output).ShouldNot(Receive())
+	})
+
+	It("ends the stream once every writer of the deployment is closed", func() {
+		first := hub.Open("abc")
+		second := hub.Open("abc")
+		output, unsubscribe := hub.Subscribe("abc")
+		defer unsubscribe()
+
+		fmt.Fprint(first, "first\n")
+		first.Close()
+		first.Close()
+		fmt.Fprint(second, "second\n")
+		second.Close()
+		Expect(read(output)).To(Equal("first\nsecond\n"))
+	})
+
+	It("unsubscribes a subscriber that falls too far behind", func() {
+		stream := hub.Open("abc")
+		defer stream.Close()
+		output, unsubscribe := hub.Subscribe("abc")
+		defer unsubscribe()
+
+		for i := 0; i <= SubscriberBuffer; i++ {
+			fmt.Fprint(stream, "x")
+		}
+
+		Expect(read(output)).To(HaveLen(SubscriberBuffer))
+	})
+
+	It("stops sending the output once unsubscribed", func() {
+		stream := hub.Open("abc")
+		defer stream.Close()
+		output, unsubscribe := hub.Subscribe("abc")
+
+		unsubscribe()
+		fmt.Fprint(stream, "pushing app\n")
+
+		Consistently(output).ShouldNot(Receive())
+	})
+
+	Context("when it is shared", func() {
+		var (
+			state *sharedstate.Memory
+			other *Hub
+		)
+
+		BeforeEach(func() {
+			state = sharedstate.NewMemory()
+			log := I.DefaultLogger(NewBuffer(), logging.DEBUG, "logstream_test")
+			hub.Share(state, log)
+			other = NewHub()
+			other.Share(state, log)
+		})
+
+		It("streams the output of a deployment running on another instance", func() {
+			output, unsubscribe := other.Subscribe("abc")
+			defer unsubscribe()
+
+			stream := hub.Open("abc")
+			fmt.Fprint(stream, "pushing ")
+			fmt.Fprint(stream, "app\n")
+			stream.Close()
+
+			Eventually(func() string { return read(output) }).Should(Equal("pushing app\n"))
+		})
+
+		It("ends the stream of a deployment that finished on another instance", func() {
+			stream := hub.Open("abc")
+			stream.Close()
+
+			output, unsubscribe := other.Subscribe("abc")
+			defer unsubscribe()
+
+			Eventually(output).Should(BeClosed())
+		})
+	})
+})
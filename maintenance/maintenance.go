@@ -0,0 +1,58 @@
+// Package maintenance keeps the foundations that are in maintenance, which deployments skip.
+package maintenance
+
+import (
+	"sort"
+	"sync"
+)
+
+// NewRegistry returns a Registry with the foundations in maintenance.
+func NewRegistry(foundations []string) *Registry {
+	r := &Registry{foundations: map[string]bool{}}
+	for _, foundationURL := range foundations {
+		r.foundations[foundationURL] = true
+	}
+
+	return r
+}
+
+// Registry keeps the foundations that are in maintenance. It is only kept in memory, so the foundations put
+// in maintenance while the server runs are back to the configured ones when it restarts.
+type Registry struct {
+	mu          sync.Mutex
+	foundations map[string]bool
+}
+
+// InMaintenance reports whether the foundation is in maintenance.
+func (r *Registry) InMaintenance(foundationURL string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.foundations[foundationURL]
+}
+
+// SetMaintenance puts the foundation in maintenance, or takes it out of maintenance.
+func (r *Registry) SetMaintenance(foundationURL string, maintenance bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if maintenance {
+		r.foundations[foundationURL] = true
+	} else {
+		delete(r.foundations, foundationURL)
+	}
+}
+
+// Foundations returns the foundations in maintenance, sorted.
+func (r *Registry) Foundations() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	foundations := []string{}
+	for foundationURL := range r.foundations {
+		foundations = append(foundations, foundationURL)
+	}
+	sort.Strings(foundations)
+
+	return foundations
+}
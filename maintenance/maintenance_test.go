@@ -0,0 +1,34 @@
+package maintenance_test
+
+import (
+	. "github.com/compozed/deployadactyl/maintenance"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	var registry *Registry
+
+	BeforeEach(func() {
+		registry = NewRegistry([]string{"https://api.west.example.com"})
+	})
+
+	It("starts with the configured foundations in maintenance", func() {
+		Expect(registry.InMaintenance("https://api.west.example.com")).To(BeTrue())
+		Expect(registry.InMaintenance("https://api.east.example.com")).To(BeFalse())
+	})
+
+	It("puts foundations in maintenance", func() {
+		registry.SetMaintenance("https://api.east.example.com", true)
+
+		Expect(registry.InMaintenance("https://api.east.example.com")).To(BeTrue())
+		Expect(registry.Foundations()).To(Equal([]string{"https://api.east.example.com", "https://api.west.example.com"}))
+	})
+
+	It("takes foundations out of maintenance", func() {
+		registry.SetMaintenance("https://api.west.example.com", false)
+
+		Expect(registry.InMaintenance("https://api.west.example.com")).To(BeFalse())
+		Expect(registry.Foundations()).To(BeEmpty())
+	})
+})
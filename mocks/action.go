@@ -1,5 +1,7 @@
 package mocks
 
+import "context"
+
 // Action handmade mock for tests.
 type Action struct {
 	InitiallyCall struct {
@@ -35,31 +37,31 @@ type Action struct {
 }
 
 // Action mock method.
-func (a *Action) Initially() error {
+func (a *Action) Initially(ctx context.Context) error {
 
 	return a.InitiallyCall.Returns.Error
 }
 
-func (a *Action) Execute() error {
+func (a *Action) Execute(ctx context.Context) error {
 	return a.ExecuteCall.Returns.Error
 }
 
-func (a *Action) Verify() error {
+func (a *Action) Verify(ctx context.Context) error {
 
 	return a.VerifyCall.Returns.Error
 }
 
-func (a *Action) Success() error {
+func (a *Action) Success(ctx context.Context) error {
 
 	return a.SuccessCall.Returns.Error
 }
 
-func (a *Action) Undo() error {
+func (a *Action) Undo(ctx context.Context) error {
 
 	return a.UndoCall.Returns.Error
 }
 
-func (a *Action) Finally() error {
+func (a *Action) Finally(ctx context.Context) error {
 
 	return a.FinallyCall.Returns.Error
 }
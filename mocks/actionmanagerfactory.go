@@ -78,3 +78,66 @@ func (t *StartManagerFactory) StartManager(log interfaces.DeploymentLogger, Depl
 
 	return t.StartManagerCall.Returns.ActionCreater
 }
+
+type RestartManagerFactory struct {
+	RestartManagerCall struct {
+		Called   bool
+		Received struct {
+			Log interfaces.DeploymentLogger
+			DeployEventData structs.DeployEventData
+		}
+		Returns struct {
+			ActionCreater interfaces.ActionCreator
+		}
+	}
+}
+
+func (t *RestartManagerFactory) RestartManager(log interfaces.DeploymentLogger, DeployEventData structs.DeployEventData) interfaces.ActionCreator {
+	t.RestartManagerCall.Called = true
+	t.RestartManagerCall.Received.Log = log
+	t.RestartManagerCall.Received.DeployEventData = DeployEventData
+
+	return t.RestartManagerCall.Returns.ActionCreater
+}
+
+type RestageManagerFactory struct {
+	RestageManagerCall struct {
+		Called   bool
+		Received struct {
+			Log interfaces.DeploymentLogger
+			DeployEventData structs.DeployEventData
+		}
+		Returns struct {
+			ActionCreater interfaces.ActionCreator
+		}
+	}
+}
+
+func (t *RestageManagerFactory) RestageManager(log interfaces.DeploymentLogger, DeployEventData structs.DeployEventData) interfaces.ActionCreator {
+	t.RestageManagerCall.Called = true
+	t.RestageManagerCall.Received.Log = log
+	t.RestageManagerCall.Received.DeployEventData = DeployEventData
+
+	return t.RestageManagerCall.Returns.ActionCreater
+}
+
+type DeleteManagerFactory struct {
+	DeleteManagerCall struct {
+		Called   bool
+		Received struct {
+			Log interfaces.DeploymentLogger
+			DeployEventData structs.DeployEventData
+		}
+		Returns struct {
+			ActionCreater interfaces.ActionCreator
+		}
+	}
+}
+
+func (t *DeleteManagerFactory) DeleteManager(log interfaces.DeploymentLogger, DeployEventData structs.DeployEventData) interfaces.ActionCreator {
+	t.DeleteManagerCall.Called = true
+	t.DeleteManagerCall.Received.Log = log
+	t.DeleteManagerCall.Received.DeployEventData = DeployEventData
+
+	return t.DeleteManagerCall.Returns.ActionCreater
+}
@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// AppInspector handmade mock for tests.
+type AppInspector struct {
+	InspectCall struct {
+		Received struct {
+			Environment   S.Environment
+			FoundationURL string
+			Authorization I.Authorization
+			Org           string
+			Space         string
+			AppName       string
+		}
+		Returns struct {
+			Snapshots map[string]S.AppSnapshot
+			Errors    map[string]error
+		}
+	}
+}
+
+// Inspect mock method. Returns the snapshot and error of the foundation.
+func (a *AppInspector) Inspect(ctx context.Context, environment S.Environment, foundationURL string, auth I.Authorization, org, space, appName string) (S.AppSnapshot, error) {
+	a.InspectCall.Received.Environment = environment
+	a.InspectCall.Received.FoundationURL = foundationURL
+	a.InspectCall.Received.Authorization = auth
+	a.InspectCall.Received.Org = org
+	a.InspectCall.Received.Space = space
+	a.InspectCall.Received.AppName = appName
+
+	return a.InspectCall.Returns.Snapshots[foundationURL], a.InspectCall.Returns.Errors[foundationURL]
+}
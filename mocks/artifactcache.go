@@ -0,0 +1,59 @@
+package mocks
+
+// ArtifactCache handmade mock for tests.
+type ArtifactCache struct {
+	StoreCall struct {
+		Called   bool
+		Received struct {
+			UUID    string
+			AppPath string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	RestoreCall struct {
+		Called   bool
+		Received struct {
+			UUID string
+		}
+		Returns struct {
+			AppPath string
+			Error   error
+		}
+	}
+	RemoveCall struct {
+		Called   bool
+		Received struct {
+			UUID string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+// Store mock method.
+func (c *ArtifactCache) Store(uuid, appPath string) error {
+	c.StoreCall.Called = true
+	c.StoreCall.Received.UUID = uuid
+	c.StoreCall.Received.AppPath = appPath
+
+	return c.StoreCall.Returns.Error
+}
+
+// Restore mock method.
+func (c *ArtifactCache) Restore(uuid string) (string, error) {
+	c.RestoreCall.Called = true
+	c.RestoreCall.Received.UUID = uuid
+
+	return c.RestoreCall.Returns.AppPath, c.RestoreCall.Returns.Error
+}
+
+// Remove mock method.
+func (c *ArtifactCache) Remove(uuid string) error {
+	c.RemoveCall.Called = true
+	c.RemoveCall.Received.UUID = uuid
+
+	return c.RemoveCall.Returns.Error
+}
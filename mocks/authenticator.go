@@ -0,0 +1,50 @@
+package mocks
+
+// Authenticator handmade mock for tests.
+type Authenticator struct {
+	AuthenticateCall struct {
+		Called   int
+		Received struct {
+			Username string
+			Password string
+			Groups   []string
+		}
+		Returns struct {
+			MemberOf []string
+			Error    error
+		}
+	}
+}
+
+// Authenticate mock method.
+func (a *Authenticator) Authenticate(username, password string, groups []string) ([]string, error) {
+	a.AuthenticateCall.Called++
+	a.AuthenticateCall.Received.Username = username
+	a.AuthenticateCall.Received.Password = password
+	a.AuthenticateCall.Received.Groups = groups
+
+	return a.AuthenticateCall.Returns.MemberOf, a.AuthenticateCall.Returns.Error
+}
+
+// TokenVerifier handmade mock for tests.
+type TokenVerifier struct {
+	VerifyCall struct {
+		Called   int
+		Received struct {
+			Token string
+		}
+		Returns struct {
+			Username string
+			Groups   []string
+			Error    error
+		}
+	}
+}
+
+// Verify mock method.
+func (v *TokenVerifier) Verify(token string) (string, []string, error) {
+	v.VerifyCall.Called++
+	v.VerifyCall.Received.Token = token
+
+	return v.VerifyCall.Returns.Username, v.VerifyCall.Returns.Groups, v.VerifyCall.Returns.Error
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"io"
 
 	"bytes"
@@ -14,18 +15,21 @@ type BlueGreener struct {
 	ExecuteCall struct {
 		Write    string
 		Received struct {
+			Context       context.Context
 			ActionCreator I.ActionCreator
 			Environment   S.Environment
 			Out           io.Writer
 		}
 		Returns struct {
-			Error I.DeploymentError
+			Error   I.DeploymentError
+			Results []S.FoundationResult
 		}
 	}
 }
 
 // Push mock method.
-func (b *BlueGreener) Execute(actionCreator I.ActionCreator, environment S.Environment, out io.ReadWriter) error {
+func (b *BlueGreener) Execute(ctx context.Context, actionCreator I.ActionCreator, environment S.Environment, out io.ReadWriter) ([]S.FoundationResult, error) {
+	b.ExecuteCall.Received.Context = ctx
 	b.ExecuteCall.Received.ActionCreator = actionCreator
 	b.ExecuteCall.Received.Environment = environment
 	b.ExecuteCall.Received.Out = out
@@ -33,5 +37,5 @@ func (b *BlueGreener) Execute(actionCreator I.ActionCreator, environment S.Envir
 	if b.ExecuteCall.Write != "" {
 		bytes.NewBufferString(b.ExecuteCall.Write).WriteTo(out)
 	}
-	return b.ExecuteCall.Returns.Error
+	return b.ExecuteCall.Returns.Results, b.ExecuteCall.Returns.Error
 }
@@ -1,8 +1,8 @@
 package mocks
 
 import (
-	"bytes"
 	"fmt"
+	"io"
 
 	"github.com/gin-gonic/gin"
 
@@ -14,7 +14,7 @@ type Controller struct {
 		Called   bool
 		Received struct {
 			Deployment *I.Deployment
-			Response   *bytes.Buffer
+			Response   io.ReadWriter
 		}
 		Write struct {
 			Output string
@@ -33,9 +33,159 @@ type Controller struct {
 			Context *gin.Context
 		}
 	}
+	DeleteRequestHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	DeploymentHistoryHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	DeploymentRecordHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	TempDirectoriesHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	StaleAppsHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	SweepStaleAppsHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	MaintenanceHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	SetMaintenanceHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	DeploymentLogHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	DeploymentLogStreamHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	DeploymentProgressHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	RetryDeploymentHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	RetryFoundationsHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	CancelDeploymentHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	PromoteCanaryHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	ActiveDeploymentsHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	ProfileHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	GoroutinesHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	RuntimeStatsHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	EventStreamHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	BatchDeploymentHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	PromotionHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	DeployedVersionsHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	DriftHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	OpenAPIHandlerCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
 }
 
-func (c *Controller) RunDeployment(deployment *I.Deployment, response *bytes.Buffer) I.DeployResponse {
+func (c *Controller) RunDeployment(deployment *I.Deployment, response io.ReadWriter) I.DeployResponse {
 	c.RunDeploymentCall.Called = true
 
 	c.RunDeploymentCall.Received.Deployment = deployment
@@ -57,3 +207,153 @@ func (c *Controller) PutRequestHandler(g *gin.Context) {
 
 	c.PutRequestHandlerCall.Received.Context = g
 }
+
+func (c *Controller) DeleteRequestHandler(g *gin.Context) {
+	c.DeleteRequestHandlerCall.Called = true
+
+	c.DeleteRequestHandlerCall.Received.Context = g
+}
+
+func (c *Controller) DeploymentHistoryHandler(g *gin.Context) {
+	c.DeploymentHistoryHandlerCall.Called = true
+
+	c.DeploymentHistoryHandlerCall.Received.Context = g
+}
+
+func (c *Controller) DeploymentRecordHandler(g *gin.Context) {
+	c.DeploymentRecordHandlerCall.Called = true
+
+	c.DeploymentRecordHandlerCall.Received.Context = g
+}
+
+func (c *Controller) TempDirectoriesHandler(g *gin.Context) {
+	c.TempDirectoriesHandlerCall.Called = true
+
+	c.TempDirectoriesHandlerCall.Received.Context = g
+}
+
+func (c *Controller) StaleAppsHandler(g *gin.Context) {
+	c.StaleAppsHandlerCall.Called = true
+
+	c.StaleAppsHandlerCall.Received.Context = g
+}
+
+func (c *Controller) SweepStaleAppsHandler(g *gin.Context) {
+	c.SweepStaleAppsHandlerCall.Called = true
+
+	c.SweepStaleAppsHandlerCall.Received.Context = g
+}
+
+func (c *Controller) MaintenanceHandler(g *gin.Context) {
+	c.MaintenanceHandlerCall.Called = true
+
+	c.MaintenanceHandlerCall.Received.Context = g
+}
+
+func (c *Controller) SetMaintenanceHandler(g *gin.Context) {
+	c.SetMaintenanceHandlerCall.Called = true
+
+	c.SetMaintenanceHandlerCall.Received.Context = g
+}
+
+func (c *Controller) DeploymentLogHandler(g *gin.Context) {
+	c.DeploymentLogHandlerCall.Called = true
+
+	c.DeploymentLogHandlerCall.Received.Context = g
+}
+
+func (c *Controller) DeploymentLogStreamHandler(g *gin.Context) {
+	c.DeploymentLogStreamHandlerCall.Called = true
+
+	c.DeploymentLogStreamHandlerCall.Received.Context = g
+}
+
+func (c *Controller) DeploymentProgressHandler(g *gin.Context) {
+	c.DeploymentProgressHandlerCall.Called = true
+
+	c.DeploymentProgressHandlerCall.Received.Context = g
+}
+
+func (c *Controller) RetryDeploymentHandler(g *gin.Context) {
+	c.RetryDeploymentHandlerCall.Called = true
+
+	c.RetryDeploymentHandlerCall.Received.Context = g
+}
+
+func (c *Controller) RetryFoundationsHandler(g *gin.Context) {
+	c.RetryFoundationsHandlerCall.Called = true
+
+	c.RetryFoundationsHandlerCall.Received.Context = g
+}
+
+func (c *Controller) CancelDeploymentHandler(g *gin.Context) {
+	c.CancelDeploymentHandlerCall.Called = true
+
+	c.CancelDeploymentHandlerCall.Received.Context = g
+}
+
+func (c *Controller) PromoteCanaryHandler(g *gin.Context) {
+	c.PromoteCanaryHandlerCall.Called = true
+
+	c.PromoteCanaryHandlerCall.Received.Context = g
+}
+
+func (c *Controller) ActiveDeploymentsHandler(g *gin.Context) {
+	c.ActiveDeploymentsHandlerCall.Called = true
+
+	c.ActiveDeploymentsHandlerCall.Received.Context = g
+}
+
+func (c *Controller) ProfileHandler(g *gin.Context) {
+	c.ProfileHandlerCall.Called = true
+
+	c.ProfileHandlerCall.Received.Context = g
+}
+
+func (c *Controller) GoroutinesHandler(g *gin.Context) {
+	c.GoroutinesHandlerCall.Called = true
+
+	c.GoroutinesHandlerCall.Received.Context = g
+}
+
+func (c *Controller) RuntimeStatsHandler(g *gin.Context) {
+	c.RuntimeStatsHandlerCall.Called = true
+
+	c.RuntimeStatsHandlerCall.Received.Context = g
+}
+
+func (c *Controller) EventStreamHandler(g *gin.Context) {
+	c.EventStreamHandlerCall.Called = true
+
+	c.EventStreamHandlerCall.Received.Context = g
+}
+
+func (c *Controller) BatchDeploymentHandler(g *gin.Context) {
+	c.BatchDeploymentHandlerCall.Called = true
+
+	c.BatchDeploymentHandlerCall.Received.Context = g
+}
+
+func (c *Controller) PromotionHandler(g *gin.Context) {
+	c.PromotionHandlerCall.Called = true
+
+	c.PromotionHandlerCall.Received.Context = g
+}
+
+func (c *Controller) DeployedVersionsHandler(g *gin.Context) {
+	c.DeployedVersionsHandlerCall.Called = true
+
+	c.DeployedVersionsHandlerCall.Received.Context = g
+}
+
+func (c *Controller) DriftHandler(g *gin.Context) {
+	c.DriftHandlerCall.Called = true
+
+	c.DriftHandlerCall.Received.Context = g
+}
+
+func (c *Controller) OpenAPIHandler(g *gin.Context) {
+	c.OpenAPIHandlerCall.Called = true
+
+	c.OpenAPIHandlerCall.Received.Context = g
+}
@@ -1,9 +1,123 @@
 package mocks
 
+import (
+	"context"
+	"strings"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
 // Courier handmade mock for tests.
 type Courier struct {
 	TimesCourierCalled int
-	LoginCall          struct {
+	WithContextCall    struct {
+		Received struct {
+			Context context.Context
+		}
+	}
+	WithCABundleCall struct {
+		Received struct {
+			Path string
+		}
+	}
+	WithLogCacheCall struct {
+		Received struct {
+			Descriptor S.LogCacheDescriptor
+		}
+	}
+	ScaleCall struct {
+		TimesCalled int
+		Received    struct {
+			AppName   string
+			Instances uint16
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+	RestartInstanceCall struct {
+		Received struct {
+			AppName string
+			Indexes []int
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+	CrashCountCall struct {
+		TimesCalled int
+		Received    struct {
+			AppName string
+			Since   time.Time
+		}
+		Returns struct {
+			Count int
+			Error error
+		}
+	}
+	LogEnvelopesCall struct {
+		TimesCalled int
+		Received    struct {
+			AppName string
+		}
+		Returns struct {
+			Envelopes []S.LogEnvelope
+			Error     error
+		}
+	}
+	AppStateCall struct {
+		Received struct {
+			AppName string
+		}
+		Returns struct {
+			AppState S.AppState
+			Error    error
+		}
+	}
+	AppEnvironmentCall struct {
+		Received struct {
+			AppName string
+		}
+		Returns struct {
+			Environment map[string]string
+			Error       error
+		}
+	}
+	AppRoutesCall struct {
+		Received struct {
+			AppName string
+		}
+		Returns struct {
+			Routes []string
+			Apps   map[string][]string
+			Error  error
+		}
+	}
+	InstancesCall struct {
+		TimesCalled int
+		Received    struct {
+			AppName string
+		}
+		Returns struct {
+			Instances [][]S.Instance
+			Error     error
+		}
+	}
+	SetLabelCall struct {
+		Received struct {
+			AppName string
+			Labels  map[string]string
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+	LoginCall struct {
 		Received struct {
 			FoundationURL string
 			Username      string
@@ -28,6 +142,16 @@ type Courier struct {
 		}
 	}
 
+	RestageCall struct {
+		Received struct {
+			AppName string
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+
 	StopCall struct {
 		Received struct {
 			AppName string
@@ -48,6 +172,37 @@ type Courier struct {
 		}
 	}
 
+	DeleteByGUIDCall struct {
+		Received struct {
+			GUIDs []string
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+
+	AuthenticateCall struct {
+		Received struct {
+			FoundationURL string
+			Username      string
+			Password      string
+			SkipSSL       bool
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+
+	AppsCall struct {
+		Called  bool
+		Returns struct {
+			Apps  []S.AppSummary
+			Error error
+		}
+	}
+
 	PushCall struct {
 		Received struct {
 			AppName   string
@@ -115,6 +270,20 @@ type Courier struct {
 			AppName  string
 			Domain   string
 			Hostname string
+			Routes   []string
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+
+	UnmapRouteWithPathCall struct {
+		Received struct {
+			AppName  []string
+			Domain   []string
+			Hostname []string
+			Path     []string
 		}
 		Returns struct {
 			Output []byte
@@ -127,6 +296,7 @@ type Courier struct {
 		Received    struct {
 			Domain   string
 			Hostname string
+			Routes   []string
 		}
 		Returns struct {
 			Output []byte
@@ -141,6 +311,53 @@ type Courier struct {
 		Received struct {
 			AppName string
 		}
+		Returns struct {
+			Bool bool
+
+			// Apps are the applications that exist, when Exists depends on the application.
+			Apps map[string]bool
+		}
+	}
+
+	RunTaskCall struct {
+		Received struct {
+			AppName  string
+			TaskName string
+			Command  string
+		}
+		Returns struct {
+			Task  S.Task
+			Error error
+		}
+	}
+
+	TaskCall struct {
+		TimesCalled int
+		Received    struct {
+			GUID string
+		}
+		Returns struct {
+			Tasks  []S.Task
+			Errors []error
+		}
+	}
+
+	QuotaUsageCall struct {
+		TimesCalled int
+		Received    struct {
+			Org   string
+			Space string
+		}
+		Returns struct {
+			Usages []S.OrgSpaceQuotaUsage
+			Error  error
+		}
+	}
+
+	ServiceExistsCall struct {
+		Received struct {
+			ServiceName string
+		}
 		Returns struct {
 			Bool bool
 		}
@@ -177,6 +394,7 @@ type Courier struct {
 	}
 
 	CleanUpCall struct {
+		Called  bool
 		Returns struct {
 			Error error
 		}
@@ -214,6 +432,23 @@ func (c *Courier) Delete(appName string) ([]byte, error) {
 	return c.DeleteCall.Returns.Output, c.DeleteCall.Returns.Error
 }
 
+// DeleteByGUID mock method.
+func (c *Courier) DeleteByGUID(guid string) ([]byte, error) {
+	c.DeleteByGUIDCall.Received.GUIDs = append(c.DeleteByGUIDCall.Received.GUIDs, guid)
+
+	return c.DeleteByGUIDCall.Returns.Output, c.DeleteByGUIDCall.Returns.Error
+}
+
+// Authenticate mock method.
+func (c *Courier) Authenticate(foundationURL, username, password string, skipSSL bool) ([]byte, error) {
+	c.AuthenticateCall.Received.FoundationURL = foundationURL
+	c.AuthenticateCall.Received.Username = username
+	c.AuthenticateCall.Received.Password = password
+	c.AuthenticateCall.Received.SkipSSL = skipSSL
+
+	return c.AuthenticateCall.Returns.Output, c.AuthenticateCall.Returns.Error
+}
+
 // Push mock method.
 func (c *Courier) Push(appName, appLocation, hostname string, instances uint16) ([]byte, error) {
 	c.PushCall.Received.AppName = appName
@@ -279,12 +514,21 @@ func (c *Courier) UnmapRoute(appName, domain, hostname string) ([]byte, error) {
 	c.UnmapRouteCall.Received.AppName = appName
 	c.UnmapRouteCall.Received.Domain = domain
 	c.UnmapRouteCall.Received.Hostname = hostname
+	c.UnmapRouteCall.Received.Routes = append(c.UnmapRouteCall.Received.Routes, hostname+"."+domain)
 
 	return c.UnmapRouteCall.Returns.Output, c.UnmapRouteCall.Returns.Error
 }
 
+// UnmapRouteWithPath mock method.
 func (c *Courier) UnmapRouteWithPath(appName, domain, hostname, path string) ([]byte, error) {
-	panic("Mock not implemented.")
+	defer func() { c.TimesCourierCalled++ }()
+
+	c.UnmapRouteWithPathCall.Received.AppName = append(c.UnmapRouteWithPathCall.Received.AppName, appName)
+	c.UnmapRouteWithPathCall.Received.Domain = append(c.UnmapRouteWithPathCall.Received.Domain, domain)
+	c.UnmapRouteWithPathCall.Received.Hostname = append(c.UnmapRouteWithPathCall.Received.Hostname, hostname)
+	c.UnmapRouteWithPathCall.Received.Path = append(c.UnmapRouteWithPathCall.Received.Path, path)
+
+	return c.UnmapRouteWithPathCall.Returns.Output, c.UnmapRouteWithPathCall.Returns.Error
 }
 
 // DeleteRoute mock method.
@@ -294,6 +538,7 @@ func (c *Courier) DeleteRoute(domain, hostname string) ([]byte, error) {
 	c.DeleteRouteCall.OrderCalled = c.TimesCourierCalled
 	c.DeleteRouteCall.Received.Domain = domain
 	c.DeleteRouteCall.Received.Hostname = hostname
+	c.DeleteRouteCall.Received.Routes = append(c.DeleteRouteCall.Received.Routes, strings.TrimPrefix(hostname+"."+domain, "."))
 
 	return c.DeleteRouteCall.Returns.Output, c.DeleteRouteCall.Returns.Error
 }
@@ -309,9 +554,62 @@ func (c *Courier) Logs(appName string) ([]byte, error) {
 func (c *Courier) Exists(appName string) bool {
 	c.ExistsCall.Received.AppName = appName
 
+	if c.ExistsCall.Returns.Apps != nil {
+		return c.ExistsCall.Returns.Apps[appName]
+	}
 	return c.ExistsCall.Returns.Bool
 }
 
+// RunTask mock method.
+func (c *Courier) RunTask(appName, taskName, command string) (S.Task, error) {
+	c.RunTaskCall.Received.AppName = appName
+	c.RunTaskCall.Received.TaskName = taskName
+	c.RunTaskCall.Received.Command = command
+
+	return c.RunTaskCall.Returns.Task, c.RunTaskCall.Returns.Error
+}
+
+// QuotaUsage mock method. Each call returns the next usage.
+func (c *Courier) QuotaUsage(org, space string) (S.OrgSpaceQuotaUsage, error) {
+	defer func() { c.QuotaUsageCall.TimesCalled++ }()
+	c.QuotaUsageCall.Received.Org = org
+	c.QuotaUsageCall.Received.Space = space
+
+	var usage S.OrgSpaceQuotaUsage
+	if c.QuotaUsageCall.TimesCalled < len(c.QuotaUsageCall.Returns.Usages) {
+		usage = c.QuotaUsageCall.Returns.Usages[c.QuotaUsageCall.TimesCalled]
+	}
+
+	return usage, c.QuotaUsageCall.Returns.Error
+}
+
+// Task mock method. Returns the task and error of the call, or the last ones once they run out.
+func (c *Courier) Task(guid string) (S.Task, error) {
+	defer func() { c.TaskCall.TimesCalled++ }()
+	c.TaskCall.Received.GUID = guid
+
+	var (
+		task S.Task
+		err  error
+	)
+	if tasks := c.TaskCall.Returns.Tasks; len(tasks) > c.TaskCall.TimesCalled {
+		task = tasks[c.TaskCall.TimesCalled]
+	} else if len(tasks) > 0 {
+		task = tasks[len(tasks)-1]
+	}
+	if errs := c.TaskCall.Returns.Errors; len(errs) > c.TaskCall.TimesCalled {
+		err = errs[c.TaskCall.TimesCalled]
+	}
+	return task, err
+}
+
+// ServiceExists mock method.
+func (c *Courier) ServiceExists(serviceName string) bool {
+	c.ServiceExistsCall.Received.ServiceName = serviceName
+
+	return c.ServiceExistsCall.Returns.Bool
+}
+
 // Cups mock method
 func (c *Courier) Cups(appName string, body string) ([]byte, error) {
 	c.CupsCall.Received.AppName = appName
@@ -351,11 +649,125 @@ func (c *Courier) DeleteService(serviceName string) ([]byte, error) {
 	panic("Mock not implemented.")
 }
 
+// Restage mock method.
 func (c *Courier) Restage(appName string) ([]byte, error) {
-	panic("Mock not implemented.")
+	c.RestageCall.Received.AppName = appName
+
+	return c.RestageCall.Returns.Output, c.RestageCall.Returns.Error
 }
 
 // CleanUp mock method.
 func (c *Courier) CleanUp() error {
+	c.CleanUpCall.Called = true
+
 	return c.CleanUpCall.Returns.Error
 }
+
+// SetLabel mock method.
+func (c *Courier) SetLabel(appName string, labels map[string]string) ([]byte, error) {
+	c.SetLabelCall.Received.AppName = appName
+	c.SetLabelCall.Received.Labels = labels
+
+	return c.SetLabelCall.Returns.Output, c.SetLabelCall.Returns.Error
+}
+
+// WithContext mock method.
+func (c *Courier) WithContext(ctx context.Context) I.Courier {
+	c.WithContextCall.Received.Context = ctx
+
+	return c
+}
+
+// WithCABundle mock method.
+func (c *Courier) WithCABundle(path string) I.Courier {
+	c.WithCABundleCall.Received.Path = path
+
+	return c
+}
+
+// WithLogCache mock method.
+func (c *Courier) WithLogCache(descriptor S.LogCacheDescriptor) I.Courier {
+	c.WithLogCacheCall.Received.Descriptor = descriptor
+
+	return c
+}
+
+// Scale mock method.
+func (c *Courier) Scale(appName string, instances uint16) ([]byte, error) {
+	c.ScaleCall.TimesCalled++
+	c.ScaleCall.Received.AppName = appName
+	c.ScaleCall.Received.Instances = instances
+
+	return c.ScaleCall.Returns.Output, c.ScaleCall.Returns.Error
+}
+
+// RestartInstance mock method.
+func (c *Courier) RestartInstance(appName string, index int) ([]byte, error) {
+	c.RestartInstanceCall.Received.AppName = appName
+	c.RestartInstanceCall.Received.Indexes = append(c.RestartInstanceCall.Received.Indexes, index)
+
+	return c.RestartInstanceCall.Returns.Output, c.RestartInstanceCall.Returns.Error
+}
+
+// CrashCount mock method.
+func (c *Courier) CrashCount(appName string, since time.Time) (int, error) {
+	c.CrashCountCall.TimesCalled++
+	c.CrashCountCall.Received.AppName = appName
+	c.CrashCountCall.Received.Since = since
+
+	return c.CrashCountCall.Returns.Count, c.CrashCountCall.Returns.Error
+}
+
+// LogEnvelopes mock method.
+func (c *Courier) LogEnvelopes(appName string) ([]S.LogEnvelope, error) {
+	c.LogEnvelopesCall.TimesCalled++
+	c.LogEnvelopesCall.Received.AppName = appName
+
+	return c.LogEnvelopesCall.Returns.Envelopes, c.LogEnvelopesCall.Returns.Error
+}
+
+// AppState mock method.
+func (c *Courier) AppState(appName string) (S.AppState, error) {
+	c.AppStateCall.Received.AppName = appName
+
+	return c.AppStateCall.Returns.AppState, c.AppStateCall.Returns.Error
+}
+
+// AppEnvironment mock method.
+func (c *Courier) AppEnvironment(appName string) (map[string]string, error) {
+	c.AppEnvironmentCall.Received.AppName = appName
+
+	return c.AppEnvironmentCall.Returns.Environment, c.AppEnvironmentCall.Returns.Error
+}
+
+// AppRoutes mock method.
+func (c *Courier) AppRoutes(appName string) ([]string, error) {
+	c.AppRoutesCall.Received.AppName = appName
+
+	if c.AppRoutesCall.Returns.Apps != nil {
+		return c.AppRoutesCall.Returns.Apps[appName], c.AppRoutesCall.Returns.Error
+	}
+	return c.AppRoutesCall.Returns.Routes, c.AppRoutesCall.Returns.Error
+}
+
+// Apps mock method.
+func (c *Courier) Apps() ([]S.AppSummary, error) {
+	c.AppsCall.Called = true
+
+	return c.AppsCall.Returns.Apps, c.AppsCall.Returns.Error
+}
+
+// Instances mock method. Successive calls return successive Instances, repeating the last one.
+func (c *Courier) Instances(appName string) ([]S.Instance, error) {
+	c.InstancesCall.TimesCalled++
+	c.InstancesCall.Received.AppName = appName
+
+	instances := c.InstancesCall.Returns.Instances
+	if len(instances) == 0 {
+		return nil, c.InstancesCall.Returns.Error
+	}
+	if c.InstancesCall.TimesCalled > len(instances) {
+		return instances[len(instances)-1], c.InstancesCall.Returns.Error
+	}
+	return instances[c.InstancesCall.TimesCalled-1], c.InstancesCall.Returns.Error
+}
@@ -0,0 +1,22 @@
+package mocks
+
+// CredentialStore handmade mock for tests.
+type CredentialStore struct {
+	CredentialsCall struct {
+		Received struct {
+			Path string
+		}
+		Returns struct {
+			Username string
+			Password string
+			Error    error
+		}
+	}
+}
+
+// Credentials mock method.
+func (c *CredentialStore) Credentials(path string) (string, string, error) {
+	c.CredentialsCall.Received.Path = path
+
+	return c.CredentialsCall.Returns.Username, c.CredentialsCall.Returns.Password, c.CredentialsCall.Returns.Error
+}
@@ -0,0 +1,40 @@
+package mocks
+
+// Database handmade mock for tests.
+type Database struct {
+	ExecCall struct {
+		Received struct {
+			Queries []string
+			Args    [][]interface{}
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	QueryCall struct {
+		Received struct {
+			Queries []string
+			Args    [][]interface{}
+		}
+		Returns struct {
+			Rows  [][]string
+			Error error
+		}
+	}
+}
+
+// Exec mock method.
+func (d *Database) Exec(query string, args ...interface{}) error {
+	d.ExecCall.Received.Queries = append(d.ExecCall.Received.Queries, query)
+	d.ExecCall.Received.Args = append(d.ExecCall.Received.Args, args)
+
+	return d.ExecCall.Returns.Error
+}
+
+// Query mock method.
+func (d *Database) Query(query string, args ...interface{}) ([][]string, error) {
+	d.QueryCall.Received.Queries = append(d.QueryCall.Received.Queries, query)
+	d.QueryCall.Received.Args = append(d.QueryCall.Received.Args, args)
+
+	return d.QueryCall.Returns.Rows, d.QueryCall.Returns.Error
+}
@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"io"
+)
+
+type DeleteController struct {
+	DeleteDeploymentCall struct {
+		Received struct {
+			Context    context.Context
+			Deployment *interfaces.Deployment
+			Data       S.Params
+			Response   io.ReadWriter
+		}
+		Returns struct {
+			DeployResponse interfaces.DeployResponse
+		}
+		Writes string
+		Called bool
+	}
+}
+
+func (c *DeleteController) DeleteDeployment(ctx context.Context, deployment *interfaces.Deployment, data S.Params, response io.ReadWriter) (deployResponse interfaces.DeployResponse) {
+	c.DeleteDeploymentCall.Called = true
+	c.DeleteDeploymentCall.Received.Context = ctx
+	c.DeleteDeploymentCall.Received.Deployment = deployment
+	c.DeleteDeploymentCall.Received.Data = data
+	c.DeleteDeploymentCall.Received.Response = response
+
+	if c.DeleteDeploymentCall.Writes != "" {
+		response.Write([]byte(c.DeleteDeploymentCall.Writes))
+	}
+
+	return c.DeleteDeploymentCall.Returns.DeployResponse
+}
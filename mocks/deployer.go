@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"fmt"
 	"io"
 
@@ -13,6 +14,7 @@ type Deployer struct {
 	DeployCall struct {
 		Called   int
 		Received struct {
+			Context        context.Context
 			DeploymentInfo *structs.DeploymentInfo
 			Env            structs.Environment
 			ActionCreator  I.ActionCreator
@@ -22,16 +24,26 @@ type Deployer struct {
 			Output string
 		}
 		Returns struct {
-			Error      error
-			StatusCode int
+			Error          error
+			StatusCode     int
+			Degraded       bool
+			Foundations    []structs.FoundationResult
+			PartialSuccess bool
+
+			// Evidence is attached to the deployment info, as the push manager does when the deployment finishes.
+			Evidence []structs.DeploymentEvidence
+
+			// SkippedFoundations are set on the deployment info, as the deployer does with foundations in maintenance.
+			SkippedFoundations []string
 		}
 	}
 }
 
 // Deploy mock method.
-func (d *Deployer) Deploy(deploymentInfo *structs.DeploymentInfo, env structs.Environment, actionCreator I.ActionCreator, out io.ReadWriter) *I.DeployResponse {
+func (d *Deployer) Deploy(ctx context.Context, deploymentInfo *structs.DeploymentInfo, env structs.Environment, actionCreator I.ActionCreator, out io.ReadWriter) *I.DeployResponse {
 	d.DeployCall.Called++
 
+	d.DeployCall.Received.Context = ctx
 	d.DeployCall.Received.DeploymentInfo = deploymentInfo
 	d.DeployCall.Received.Env = env
 	d.DeployCall.Received.ActionCreator = actionCreator
@@ -40,10 +52,20 @@ func (d *Deployer) Deploy(deploymentInfo *structs.DeploymentInfo, env structs.En
 
 	fmt.Fprint(out, d.DeployCall.Write.Output)
 
+	if d.DeployCall.Returns.Evidence != nil {
+		deploymentInfo.Evidence = d.DeployCall.Returns.Evidence
+	}
+	if d.DeployCall.Returns.SkippedFoundations != nil {
+		deploymentInfo.SkippedFoundations = d.DeployCall.Returns.SkippedFoundations
+	}
+
 	response := &I.DeployResponse{
 		StatusCode:     d.DeployCall.Returns.StatusCode,
 		Error:          d.DeployCall.Returns.Error,
 		DeploymentInfo: deploymentInfo,
+		Degraded:       d.DeployCall.Returns.Degraded,
+		Foundations:    d.DeployCall.Returns.Foundations,
+		PartialSuccess: d.DeployCall.Returns.PartialSuccess,
 	}
 
 	return response
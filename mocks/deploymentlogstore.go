@@ -0,0 +1,40 @@
+package mocks
+
+// DeploymentLogStore handmade mock for tests.
+type DeploymentLogStore struct {
+	SaveCall struct {
+		Called   bool
+		Received struct {
+			UUID   string
+			Output []byte
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	GetCall struct {
+		Received struct {
+			UUID string
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+}
+
+// Save mock method.
+func (s *DeploymentLogStore) Save(uuid string, output []byte) error {
+	s.SaveCall.Called = true
+	s.SaveCall.Received.UUID = uuid
+	s.SaveCall.Received.Output = append([]byte{}, output...)
+
+	return s.SaveCall.Returns.Error
+}
+
+// Get mock method.
+func (s *DeploymentLogStore) Get(uuid string) ([]byte, error) {
+	s.GetCall.Received.UUID = uuid
+
+	return s.GetCall.Returns.Output, s.GetCall.Returns.Error
+}
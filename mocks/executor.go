@@ -1,14 +1,25 @@
 package mocks
 
+import (
+	"context"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
 // Executor handmade mock for tests.
 type Executor struct {
 	ExecuteCall struct {
-		Received struct {
-			Args []string
+		TimesCalled int
+		Received    struct {
+			Args    []string
+			AllArgs [][]string
 		}
 		Returns struct {
 			Output []byte
 			Error  error
+
+			// Outputs are returned by successive calls instead of Output, while there are any left.
+			Outputs [][]byte
 		}
 	}
 
@@ -23,6 +34,18 @@ type Executor struct {
 		}
 	}
 
+	WithContextCall struct {
+		Received struct {
+			Context context.Context
+		}
+	}
+
+	WithCABundleCall struct {
+		Received struct {
+			Path string
+		}
+	}
+
 	CleanUpCall struct {
 		Returns struct {
 			Error error
@@ -32,8 +55,13 @@ type Executor struct {
 
 // Execute mock method.
 func (e *Executor) Execute(args ...string) ([]byte, error) {
+	e.ExecuteCall.TimesCalled++
 	e.ExecuteCall.Received.Args = args
+	e.ExecuteCall.Received.AllArgs = append(e.ExecuteCall.Received.AllArgs, args)
 
+	if e.ExecuteCall.TimesCalled <= len(e.ExecuteCall.Returns.Outputs) {
+		return e.ExecuteCall.Returns.Outputs[e.ExecuteCall.TimesCalled-1], e.ExecuteCall.Returns.Error
+	}
 	return e.ExecuteCall.Returns.Output, e.ExecuteCall.Returns.Error
 }
 
@@ -49,3 +77,17 @@ func (e *Executor) ExecuteInDirectory(appLocation string, args ...string) ([]byt
 func (e *Executor) CleanUp() error {
 	return e.CleanUpCall.Returns.Error
 }
+
+// WithContext mock method.
+func (e *Executor) WithContext(ctx context.Context) I.Executor {
+	e.WithContextCall.Received.Context = ctx
+
+	return e
+}
+
+// WithCABundle mock method.
+func (e *Executor) WithCABundle(path string) I.Executor {
+	e.WithCABundleCall.Received.Path = path
+
+	return e
+}
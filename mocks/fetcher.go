@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"io"
 )
 
@@ -8,6 +9,7 @@ import (
 type Fetcher struct {
 	FetchCall struct {
 		Received struct {
+			Context     context.Context
 			ArtifactURL string
 			Manifest    string
 		}
@@ -30,7 +32,8 @@ type Fetcher struct {
 }
 
 // Fetch mock method.
-func (f *Fetcher) Fetch(url, manifest string) (string, error) {
+func (f *Fetcher) Fetch(ctx context.Context, url, manifest string) (string, error) {
+	f.FetchCall.Received.Context = ctx
 	f.FetchCall.Received.ArtifactURL = url
 	f.FetchCall.Received.Manifest = manifest
 
@@ -1,6 +1,10 @@
 package mocks
 
-import "fmt"
+import (
+	"fmt"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
 
 // HealthChecker handmade mock for tests.
 type HealthChecker struct {
@@ -21,3 +25,30 @@ func (h *HealthChecker) Check(endpoint, serverURL string) error {
 
 	return h.CheckCall.Returns.Error
 }
+
+// ApplicationHealthChecker handmade mock for tests.
+type ApplicationHealthChecker struct {
+	CheckApplicationCall struct {
+		TimesCalled int
+		Received    struct {
+			Environment   string
+			FoundationURL string
+			AppName       string
+			Endpoint      string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+// CheckApplication mock method.
+func (h *ApplicationHealthChecker) CheckApplication(environment, foundationURL, appName, endpoint string, log I.DeploymentLogger) error {
+	h.CheckApplicationCall.TimesCalled++
+	h.CheckApplicationCall.Received.Environment = environment
+	h.CheckApplicationCall.Received.FoundationURL = foundationURL
+	h.CheckApplicationCall.Received.AppName = appName
+	h.CheckApplicationCall.Received.Endpoint = endpoint
+
+	return h.CheckApplicationCall.Returns.Error
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"io"
+)
+
+// HookRunner handmade mock for tests.
+type HookRunner struct {
+	RunCall struct {
+		TimesCalled int
+		Received    struct {
+			Phases    []string
+			Variables []map[string]string
+		}
+		Returns struct {
+			// Errors are returned by phase.
+			Errors map[string]error
+		}
+	}
+}
+
+// Run mock method.
+func (h *HookRunner) Run(ctx context.Context, phase string, variables map[string]string, output io.Writer) error {
+	h.RunCall.TimesCalled++
+	h.RunCall.Received.Phases = append(h.RunCall.Received.Phases, phase)
+	h.RunCall.Received.Variables = append(h.RunCall.Received.Variables, variables)
+
+	return h.RunCall.Returns.Errors[phase]
+}
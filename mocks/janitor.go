@@ -0,0 +1,19 @@
+package mocks
+
+import (
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Janitor handmade mock for tests.
+type Janitor struct {
+	ScheduleCall struct {
+		Received struct {
+			Deletions []S.ScheduledDeletion
+		}
+	}
+}
+
+// Schedule mock method.
+func (j *Janitor) Schedule(deletion S.ScheduledDeletion) {
+	j.ScheduleCall.Received.Deletions = append(j.ScheduleCall.Received.Deletions, deletion)
+}
@@ -0,0 +1,42 @@
+package mocks
+
+import "time"
+
+// LeaderElection handmade mock for tests.
+type LeaderElection struct {
+	AcquireCall struct {
+		Received struct {
+			Identity string
+			TTL      time.Duration
+		}
+		Returns struct {
+			Leader bool
+			Error  error
+		}
+	}
+	ReleaseCall struct {
+		Called   bool
+		Received struct {
+			Identity string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+// Acquire mock method.
+func (l *LeaderElection) Acquire(identity string, ttl time.Duration) (bool, error) {
+	l.AcquireCall.Received.Identity = identity
+	l.AcquireCall.Received.TTL = ttl
+
+	return l.AcquireCall.Returns.Leader, l.AcquireCall.Returns.Error
+}
+
+// Release mock method.
+func (l *LeaderElection) Release(identity string) error {
+	l.ReleaseCall.Called = true
+	l.ReleaseCall.Received.Identity = identity
+
+	return l.ReleaseCall.Returns.Error
+}
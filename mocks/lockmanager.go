@@ -0,0 +1,41 @@
+package mocks
+
+// LockManager handmade mock for tests.
+type LockManager struct {
+	LockCall struct {
+		Received struct {
+			Key   string
+			Owner string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	UnlockCall struct {
+		Called   bool
+		Received struct {
+			Key   string
+			Owner string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+// Lock mock method.
+func (l *LockManager) Lock(key, owner string) error {
+	l.LockCall.Received.Key = key
+	l.LockCall.Received.Owner = owner
+
+	return l.LockCall.Returns.Error
+}
+
+// Unlock mock method.
+func (l *LockManager) Unlock(key, owner string) error {
+	l.UnlockCall.Called = true
+	l.UnlockCall.Received.Key = key
+	l.UnlockCall.Received.Owner = owner
+
+	return l.UnlockCall.Returns.Error
+}
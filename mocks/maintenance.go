@@ -0,0 +1,40 @@
+package mocks
+
+// FoundationMaintenance handmade mock for tests.
+type FoundationMaintenance struct {
+	InMaintenanceCall struct {
+		Returns struct {
+			// Foundations are the foundations in maintenance.
+			Foundations map[string]bool
+		}
+	}
+	SetMaintenanceCall struct {
+		Called   bool
+		Received struct {
+			FoundationURL string
+			Maintenance   bool
+		}
+	}
+	FoundationsCall struct {
+		Returns struct {
+			Foundations []string
+		}
+	}
+}
+
+// InMaintenance mock method.
+func (m *FoundationMaintenance) InMaintenance(foundationURL string) bool {
+	return m.InMaintenanceCall.Returns.Foundations[foundationURL]
+}
+
+// SetMaintenance mock method.
+func (m *FoundationMaintenance) SetMaintenance(foundationURL string, maintenance bool) {
+	m.SetMaintenanceCall.Called = true
+	m.SetMaintenanceCall.Received.FoundationURL = foundationURL
+	m.SetMaintenanceCall.Received.Maintenance = maintenance
+}
+
+// Foundations mock method.
+func (m *FoundationMaintenance) Foundations() []string {
+	return m.FoundationsCall.Returns.Foundations
+}
@@ -14,6 +14,26 @@ type Prechecker struct {
 			Error error
 		}
 	}
+	AssertBuildpacksAvailableCall struct {
+		Called   bool
+		Received struct {
+			Environment    S.Environment
+			DeploymentInfo S.DeploymentInfo
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	AssertDomainsAvailableCall struct {
+		Called   bool
+		Received struct {
+			Environment    S.Environment
+			DeploymentInfo S.DeploymentInfo
+		}
+		Returns struct {
+			Error error
+		}
+	}
 }
 
 // AssertAllFoundationsUp mock method.
@@ -22,3 +42,21 @@ func (p *Prechecker) AssertAllFoundationsUp(environment S.Environment) error {
 
 	return p.AssertAllFoundationsUpCall.Returns.Error
 }
+
+// AssertBuildpacksAvailable mock method.
+func (p *Prechecker) AssertBuildpacksAvailable(environment S.Environment, deploymentInfo S.DeploymentInfo) error {
+	p.AssertBuildpacksAvailableCall.Called = true
+	p.AssertBuildpacksAvailableCall.Received.Environment = environment
+	p.AssertBuildpacksAvailableCall.Received.DeploymentInfo = deploymentInfo
+
+	return p.AssertBuildpacksAvailableCall.Returns.Error
+}
+
+// AssertDomainsAvailable mock method.
+func (p *Prechecker) AssertDomainsAvailable(environment S.Environment, deploymentInfo S.DeploymentInfo) error {
+	p.AssertDomainsAvailableCall.Called = true
+	p.AssertDomainsAvailableCall.Received.Environment = environment
+	p.AssertDomainsAvailableCall.Received.DeploymentInfo = deploymentInfo
+
+	return p.AssertDomainsAvailableCall.Returns.Error
+}
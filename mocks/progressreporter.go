@@ -0,0 +1,34 @@
+package mocks
+
+import "fmt"
+
+// ProgressReporter handmade mock for tests.
+// Calls records every call in order, such as "StartPhase uuid executing 2".
+type ProgressReporter struct {
+	Calls []string
+}
+
+// StartPhase mock method.
+func (r *ProgressReporter) StartPhase(uuid, phase string, foundations int) {
+	r.Calls = append(r.Calls, fmt.Sprintf("StartPhase %s %s %d", uuid, phase, foundations))
+}
+
+// FinishFoundation mock method.
+func (r *ProgressReporter) FinishFoundation(uuid string) {
+	r.Calls = append(r.Calls, fmt.Sprintf("FinishFoundation %s", uuid))
+}
+
+// FailFoundation mock method.
+func (r *ProgressReporter) FailFoundation(uuid, foundationURL string) {
+	r.Calls = append(r.Calls, fmt.Sprintf("FailFoundation %s %s", uuid, foundationURL))
+}
+
+// Step mock method.
+func (r *ProgressReporter) Step(uuid, foundationURL, step string) {
+	r.Calls = append(r.Calls, fmt.Sprintf("Step %s %s %s", uuid, foundationURL, step))
+}
+
+// Finish mock method.
+func (r *ProgressReporter) Finish(uuid string, err error) {
+	r.Calls = append(r.Calls, fmt.Sprintf("Finish %s %v", uuid, err))
+}
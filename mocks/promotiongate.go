@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"context"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// PromotionGate handmade mock for tests.
+type PromotionGate struct {
+	EvaluateCall struct {
+		TimesCalled int
+		Received    struct {
+			Descriptors []S.PromotionGateDescriptor
+			AppName     string
+		}
+		Returns struct {
+			Results []S.PromotionGateResult
+			Error   error
+		}
+	}
+}
+
+// Evaluate mock method. Each call returns the next result.
+func (g *PromotionGate) Evaluate(ctx context.Context, descriptor S.PromotionGateDescriptor, appName string) (S.PromotionGateResult, error) {
+	defer func() { g.EvaluateCall.TimesCalled++ }()
+
+	g.EvaluateCall.Received.Descriptors = append(g.EvaluateCall.Received.Descriptors, descriptor)
+	g.EvaluateCall.Received.AppName = appName
+
+	result := S.PromotionGateResult{Name: descriptor.Name, Query: descriptor.Query, Max: descriptor.Max}
+	if g.EvaluateCall.TimesCalled < len(g.EvaluateCall.Returns.Results) {
+		result = g.EvaluateCall.Returns.Results[g.EvaluateCall.TimesCalled]
+	}
+
+	return result, g.EvaluateCall.Returns.Error
+}
@@ -1,15 +1,19 @@
 package mocks
 
 import (
-	"bytes"
+	"context"
 	"github.com/compozed/deployadactyl/interfaces"
+	"io"
+	"io/ioutil"
 )
 
 type PushController struct {
 	RunDeploymentCall struct {
 		Received struct {
+			Context    context.Context
 			Deployment *interfaces.Deployment
-			Response   *bytes.Buffer
+			Response   io.ReadWriter
+			Upload     []byte
 		}
 		Returns struct {
 			DeployResponse interfaces.DeployResponse
@@ -19,10 +23,14 @@ type PushController struct {
 	}
 }
 
-func (c *PushController) RunDeployment(deployment *interfaces.Deployment, response *bytes.Buffer) (deployResponse interfaces.DeployResponse) {
+func (c *PushController) RunDeployment(ctx context.Context, deployment *interfaces.Deployment, response io.ReadWriter) (deployResponse interfaces.DeployResponse) {
 	c.RunDeploymentCall.Called = true
+	c.RunDeploymentCall.Received.Context = ctx
 	c.RunDeploymentCall.Received.Deployment = deployment
 	c.RunDeploymentCall.Received.Response = response
+	if deployment.Upload != nil {
+		c.RunDeploymentCall.Received.Upload, _ = ioutil.ReadAll(deployment.Upload)
+	}
 
 	if c.RunDeploymentCall.Writes != "" {
 		response.Write([]byte(c.RunDeploymentCall.Writes))
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"fmt"
 	"io"
 )
@@ -10,6 +11,9 @@ type Pusher struct {
 	Response io.ReadWriter
 
 	InitiallyCall struct {
+		Received struct {
+			Context context.Context
+		}
 		Write struct {
 			Output string
 		}
@@ -19,6 +23,9 @@ type Pusher struct {
 	}
 
 	ExecuteCall struct {
+		Received struct {
+			Context context.Context
+		}
 		Write struct {
 			Output string
 		}
@@ -27,24 +34,39 @@ type Pusher struct {
 		}
 	}
 	VerifyCall struct {
+		Called  bool
+		Returns struct {
+			Error error
+		}
+	}
+
+	PromoteCall struct {
+		Called  bool
 		Returns struct {
 			Error error
 		}
 	}
 
 	UndoCall struct {
+		Received struct {
+			Context context.Context
+		}
 		Returns struct {
 			Error error
 		}
 	}
 
 	SuccessCall struct {
+		Called  bool
 		Returns struct {
 			Error error
 		}
 	}
 
 	FinallyCall struct {
+		Received struct {
+			Context context.Context
+		}
 		Returns struct {
 			Error error
 		}
@@ -52,7 +74,8 @@ type Pusher struct {
 }
 
 // Login mock method.
-func (p *Pusher) Initially() error {
+func (p *Pusher) Initially(ctx context.Context) error {
+	p.InitiallyCall.Received.Context = ctx
 
 	fmt.Fprint(p.Response, p.InitiallyCall.Write.Output)
 
@@ -60,28 +83,43 @@ func (p *Pusher) Initially() error {
 }
 
 // Push mock method.
-func (p *Pusher) Execute() error {
+func (p *Pusher) Execute(ctx context.Context) error {
+	p.ExecuteCall.Received.Context = ctx
 
 	fmt.Fprint(p.Response, p.ExecuteCall.Write.Output)
 
 	return p.ExecuteCall.Returns.Error
 }
 
-func (p *Pusher) Verify() error {
+func (p *Pusher) Verify(ctx context.Context) error {
+	p.VerifyCall.Called = true
+
 	return p.VerifyCall.Returns.Error
 }
 
+func (p *Pusher) Promote(ctx context.Context) error {
+	p.PromoteCall.Called = true
+
+	return p.PromoteCall.Returns.Error
+}
+
 // FinishPush mock method.
-func (p *Pusher) Success() error {
+func (p *Pusher) Success(ctx context.Context) error {
+	p.SuccessCall.Called = true
+
 	return p.SuccessCall.Returns.Error
 }
 
 // UndoPush mock method.
-func (p *Pusher) Undo() error {
+func (p *Pusher) Undo(ctx context.Context) error {
+	p.UndoCall.Received.Context = ctx
+
 	return p.UndoCall.Returns.Error
 }
 
 // CleanUp mock method.
-func (p *Pusher) Finally() error {
+func (p *Pusher) Finally(ctx context.Context) error {
+	p.FinallyCall.Received.Context = ctx
+
 	return p.FinallyCall.Returns.Error
 }
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"io"
 
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
@@ -43,6 +44,22 @@ type PushManager struct {
 	CleanUpCall struct {
 		Called bool
 	}
+	CanaryCall struct {
+		Received struct {
+			Environment S.Environment
+		}
+		Returns struct {
+			Canary bool
+		}
+	}
+	UndoFinishesCall struct {
+		Received struct {
+			Environment S.Environment
+		}
+		Returns struct {
+			Finishes bool
+		}
+	}
 }
 
 type FileSystemCleaner struct {
@@ -67,7 +84,7 @@ func (p *FileSystemCleaner) RemoveAll(path string) error {
 	return p.RemoveAllCall.Returns.Error
 }
 
-func (p *PushManager) SetUp() error {
+func (p *PushManager) SetUp(ctx context.Context) error {
 	p.SetUpCall.Called = true
 	return p.SetUpCall.Returns.Err
 }
@@ -76,6 +93,16 @@ func (p *PushManager) CleanUp() {
 	p.CleanUpCall.Called = true
 }
 
+func (p *PushManager) UndoFinishes(environment S.Environment) bool {
+	p.UndoFinishesCall.Received.Environment = environment
+	return p.UndoFinishesCall.Returns.Finishes
+}
+
+func (p *PushManager) Canary(environment S.Environment) bool {
+	p.CanaryCall.Received.Environment = environment
+	return p.CanaryCall.Returns.Canary
+}
+
 func (p *PushManager) OnStart() error {
 	p.OnStartCall.Called = true
 
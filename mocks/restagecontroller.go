@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"io"
+)
+
+type RestageController struct {
+	RestageDeploymentCall struct {
+		Received struct {
+			Context    context.Context
+			Deployment *interfaces.Deployment
+			Data       S.Params
+			Response   io.ReadWriter
+		}
+		Returns struct {
+			DeployResponse interfaces.DeployResponse
+		}
+		Writes string
+		Called bool
+	}
+}
+
+func (c *RestageController) RestageDeployment(ctx context.Context, deployment *interfaces.Deployment, data S.Params, response io.ReadWriter) (deployResponse interfaces.DeployResponse) {
+	c.RestageDeploymentCall.Called = true
+	c.RestageDeploymentCall.Received.Context = ctx
+	c.RestageDeploymentCall.Received.Deployment = deployment
+	c.RestageDeploymentCall.Received.Data = data
+	c.RestageDeploymentCall.Received.Response = response
+
+	if c.RestageDeploymentCall.Writes != "" {
+		response.Write([]byte(c.RestageDeploymentCall.Writes))
+	}
+
+	return c.RestageDeploymentCall.Returns.DeployResponse
+}
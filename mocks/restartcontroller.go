@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"io"
+)
+
+type RestartController struct {
+	RestartDeploymentCall struct {
+		Received struct {
+			Context    context.Context
+			Deployment *interfaces.Deployment
+			Data       S.Params
+			Response   io.ReadWriter
+		}
+		Returns struct {
+			DeployResponse interfaces.DeployResponse
+		}
+		Writes string
+		Called bool
+	}
+}
+
+func (c *RestartController) RestartDeployment(ctx context.Context, deployment *interfaces.Deployment, data S.Params, response io.ReadWriter) (deployResponse interfaces.DeployResponse) {
+	c.RestartDeploymentCall.Called = true
+	c.RestartDeploymentCall.Received.Context = ctx
+	c.RestartDeploymentCall.Received.Deployment = deployment
+	c.RestartDeploymentCall.Received.Data = data
+	c.RestartDeploymentCall.Received.Response = response
+
+	if c.RestartDeploymentCall.Writes != "" {
+		response.Write([]byte(c.RestartDeploymentCall.Writes))
+	}
+
+	return c.RestartDeploymentCall.Returns.DeployResponse
+}
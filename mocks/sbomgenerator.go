@@ -0,0 +1,41 @@
+package mocks
+
+import S "github.com/compozed/deployadactyl/structs"
+
+// SBOMGenerator handmade mock for tests.
+type SBOMGenerator struct {
+	DigestCall struct {
+		Received struct {
+			AppPath string
+		}
+		Returns struct {
+			Digest string
+			Error  error
+		}
+	}
+	GenerateCall struct {
+		TimesCalled int
+		Received    struct {
+			AppPath string
+		}
+		Returns struct {
+			SBOM  S.SBOM
+			Error error
+		}
+	}
+}
+
+// Digest mock method.
+func (g *SBOMGenerator) Digest(appPath string) (string, error) {
+	g.DigestCall.Received.AppPath = appPath
+
+	return g.DigestCall.Returns.Digest, g.DigestCall.Returns.Error
+}
+
+// Generate mock method.
+func (g *SBOMGenerator) Generate(appPath string) (S.SBOM, error) {
+	g.GenerateCall.TimesCalled++
+	g.GenerateCall.Received.AppPath = appPath
+
+	return g.GenerateCall.Returns.SBOM, g.GenerateCall.Returns.Error
+}
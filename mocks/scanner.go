@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"context"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Scanner handmade mock for tests.
+type Scanner struct {
+	ScanCall struct {
+		TimesCalled int
+		Received    struct {
+			Context    context.Context
+			Descriptor S.ScannerDescriptor
+			AppPath    string
+		}
+		Returns struct {
+			Result S.ScanResult
+			Error  error
+		}
+	}
+}
+
+// Scan mock method.
+func (s *Scanner) Scan(ctx context.Context, descriptor S.ScannerDescriptor, appPath string) (S.ScanResult, error) {
+	s.ScanCall.TimesCalled++
+	s.ScanCall.Received.Context = ctx
+	s.ScanCall.Received.Descriptor = descriptor
+	s.ScanCall.Received.AppPath = appPath
+
+	return s.ScanCall.Returns.Result, s.ScanCall.Returns.Error
+}
@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	"context"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// StaleAppSweeper handmade mock for tests.
+type StaleAppSweeper struct {
+	SweepCall struct {
+		Called   bool
+		Received struct {
+			Delete bool
+		}
+		Returns struct {
+			Report S.StaleAppsReport
+		}
+	}
+	LastReportCall struct {
+		Called  bool
+		Returns struct {
+			Report S.StaleAppsReport
+			Found  bool
+		}
+	}
+}
+
+// Sweep mock method.
+func (s *StaleAppSweeper) Sweep(ctx context.Context, delete bool) S.StaleAppsReport {
+	s.SweepCall.Called = true
+	s.SweepCall.Received.Delete = delete
+
+	return s.SweepCall.Returns.Report
+}
+
+// LastReport mock method.
+func (s *StaleAppSweeper) LastReport() (S.StaleAppsReport, bool) {
+	s.LastReportCall.Called = true
+
+	return s.LastReportCall.Returns.Report, s.LastReportCall.Returns.Found
+}
@@ -1,16 +1,19 @@
 package mocks
 
 import (
-	"bytes"
+	"context"
 	"github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"io"
 )
 
 type StartController struct {
 	StartDeploymentCall struct {
 		Received struct {
+			Context    context.Context
 			Deployment *interfaces.Deployment
-			Data       map[string]interface{}
-			Response   *bytes.Buffer
+			Data       S.Params
+			Response   io.ReadWriter
 		}
 		Returns struct {
 			DeployResponse interfaces.DeployResponse
@@ -20,8 +23,9 @@ type StartController struct {
 	}
 }
 
-func (c *StartController) StartDeployment(deployment *interfaces.Deployment, data map[string]interface{}, response *bytes.Buffer) (deployResponse interfaces.DeployResponse) {
+func (c *StartController) StartDeployment(ctx context.Context, deployment *interfaces.Deployment, data S.Params, response io.ReadWriter) (deployResponse interfaces.DeployResponse) {
 	c.StartDeploymentCall.Called = true
+	c.StartDeploymentCall.Received.Context = ctx
 	c.StartDeploymentCall.Received.Deployment = deployment
 	c.StartDeploymentCall.Received.Data = data
 	c.StartDeploymentCall.Received.Response = response
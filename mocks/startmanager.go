@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"github.com/compozed/deployadactyl/interfaces"
 	S "github.com/compozed/deployadactyl/structs"
 
@@ -20,7 +21,7 @@ type StartManager struct {
 	}
 }
 
-func (s *StartManager) SetUp() error {
+func (s *StartManager) SetUp(ctx context.Context) error {
 	return nil
 }
 
@@ -1,5 +1,7 @@
 package mocks
 
+import "context"
+
 type StartStopper struct {
 	InitiallyCall struct {
 		Returns struct {
@@ -37,32 +39,32 @@ type StartStopper struct {
 	}
 }
 
-func (s *StartStopper) Initially() error {
+func (s *StartStopper) Initially(ctx context.Context) error {
 
 	return s.InitiallyCall.Returns.Error
 }
 
-func (s *StartStopper) Verify() error {
+func (s *StartStopper) Verify(ctx context.Context) error {
 
 	return s.VerifyCall.Returns.Error
 }
 
-func (s *StartStopper) Finally() error {
+func (s *StartStopper) Finally(ctx context.Context) error {
 
 	return s.FinallyCall.Returns.Error
 }
 
-func (s *StartStopper) Success() error {
+func (s *StartStopper) Success(ctx context.Context) error {
 
 	return s.SuccessCall.Returns.Error
 }
 
-func (s *StartStopper) Execute() error {
+func (s *StartStopper) Execute(ctx context.Context) error {
 
 	return s.ExecuteCall.Returns.Error
 }
 
-func (s *StartStopper) Undo() error {
+func (s *StartStopper) Undo(ctx context.Context) error {
 
 	return s.UndoCall.Returns.Error
 }
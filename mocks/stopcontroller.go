@@ -1,16 +1,19 @@
 package mocks
 
 import (
-	"bytes"
+	"context"
 	"github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"io"
 )
 
 type StopController struct {
 	StopDeploymentCall struct {
 		Received struct {
+			Context    context.Context
 			Deployment *interfaces.Deployment
-			Data       map[string]interface{}
-			Response   *bytes.Buffer
+			Data       S.Params
+			Response   io.ReadWriter
 		}
 		Returns struct {
 			DeployResponse interfaces.DeployResponse
@@ -20,8 +23,9 @@ type StopController struct {
 	}
 }
 
-func (c *StopController) StopDeployment(deployment *interfaces.Deployment, data map[string]interface{}, response *bytes.Buffer) (deployResponse interfaces.DeployResponse) {
+func (c *StopController) StopDeployment(ctx context.Context, deployment *interfaces.Deployment, data S.Params, response io.ReadWriter) (deployResponse interfaces.DeployResponse) {
 	c.StopDeploymentCall.Called = true
+	c.StopDeploymentCall.Received.Context = ctx
 	c.StopDeploymentCall.Received.Deployment = deployment
 	c.StopDeploymentCall.Received.Data = data
 	c.StopDeploymentCall.Received.Response = response
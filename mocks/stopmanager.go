@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"github.com/compozed/deployadactyl/interfaces"
 	S "github.com/compozed/deployadactyl/structs"
 
@@ -24,7 +25,7 @@ type StopManager struct {
 	}
 }
 
-func (s *StopManager) SetUp() error {
+func (s *StopManager) SetUp(ctx context.Context) error {
 	return nil
 }
 
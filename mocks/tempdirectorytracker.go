@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// TempDirectoryTracker handmade mock for tests.
+type TempDirectoryTracker struct {
+	TrackCall struct {
+		Received struct {
+			DeploymentIDs []string
+			Paths         []string
+		}
+	}
+	RemoveDeploymentCall struct {
+		Called   bool
+		Received struct {
+			DeploymentID string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	ReportCall struct {
+		Called  bool
+		Returns struct {
+			Report S.TempDirectoryReport
+			Error  error
+		}
+	}
+}
+
+// Track mock method.
+func (t *TempDirectoryTracker) Track(deploymentID, path string) {
+	t.TrackCall.Received.DeploymentIDs = append(t.TrackCall.Received.DeploymentIDs, deploymentID)
+	t.TrackCall.Received.Paths = append(t.TrackCall.Received.Paths, path)
+}
+
+// RemoveDeployment mock method.
+func (t *TempDirectoryTracker) RemoveDeployment(deploymentID string) error {
+	t.RemoveDeploymentCall.Called = true
+	t.RemoveDeploymentCall.Received.DeploymentID = deploymentID
+
+	return t.RemoveDeploymentCall.Returns.Error
+}
+
+// Report mock method.
+func (t *TempDirectoryTracker) Report() (S.TempDirectoryReport, error) {
+	t.ReportCall.Called = true
+
+	return t.ReportCall.Returns.Report, t.ReportCall.Returns.Error
+}
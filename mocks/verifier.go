@@ -0,0 +1,24 @@
+package mocks
+
+import "context"
+
+// ArtifactVerifier handmade mock for tests.
+type ArtifactVerifier struct {
+	VerifyCall struct {
+		Received struct {
+			Context      context.Context
+			ArtifactPath string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+// Verify mock method.
+func (v *ArtifactVerifier) Verify(ctx context.Context, artifactPath string) error {
+	v.VerifyCall.Received.Context = ctx
+	v.VerifyCall.Received.ArtifactPath = artifactPath
+
+	return v.VerifyCall.Returns.Error
+}
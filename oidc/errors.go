@@ -0,0 +1,60 @@
+package oidc
+
+import "fmt"
+
+type InvalidIssuerError struct {
+	Issuer string
+}
+
+func (e InvalidIssuerError) Error() string {
+	return fmt.Sprintf("the oidc issuer must be an https url: %s", e.Issuer)
+}
+
+type MissingAudienceError struct{}
+
+func (e MissingAudienceError) Error() string {
+	return "oidc needs the audience of the tokens it accepts"
+}
+
+type InvalidJWKSURLError struct {
+	URL string
+}
+
+func (e InvalidJWKSURLError) Error() string {
+	return fmt.Sprintf("the oidc jwks_url must be an http or https url: %s", e.URL)
+}
+
+type InvalidTimeoutError struct {
+	Seconds int
+}
+
+func (e InvalidTimeoutError) Error() string {
+	return fmt.Sprintf("the timeout of the requests to the oidc issuer must not be negative: %d", e.Seconds)
+}
+
+type DiscoveryError struct {
+	URL string
+	Err error
+}
+
+func (e DiscoveryError) Error() string {
+	return fmt.Sprintf("cannot read the keys of the oidc issuer from %s: %s", e.URL, e.Err)
+}
+
+type IssuerMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e IssuerMismatchError) Error() string {
+	return fmt.Sprintf("the discovery document of the oidc issuer %s is that of %s", e.Expected, e.Actual)
+}
+
+// InvalidTokenError is a bearer token that is not a valid token of the issuer.
+type InvalidTokenError struct {
+	Reason string
+}
+
+func (e InvalidTokenError) Error() string {
+	return fmt.Sprintf("invalid bearer token: %s", e.Reason)
+}
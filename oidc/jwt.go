@@ -0,0 +1,139 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// token is a JSON Web Token in its compact serialization.
+type token struct {
+	header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	claims    map[string]interface{}
+	signed    []byte
+	signature []byte
+}
+
+func parseToken(raw string) (*token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, InvalidTokenError{"not a json web token"}
+	}
+
+	t := &token{signed: []byte(parts[0] + "." + parts[1])}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(header, &t.header) != nil {
+		return nil, InvalidTokenError{"malformed header"}
+	}
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(claims, &t.claims) != nil {
+		return nil, InvalidTokenError{"malformed claims"}
+	}
+	t.signature, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, InvalidTokenError{"malformed signature"}
+	}
+	return t, nil
+}
+
+// verify returns an InvalidTokenError unless the token is signed with the key. Only the RSA and ECDSA algorithms
+// are accepted, so a token cannot be signed with none or with the public key as an HMAC secret.
+func (t *token) verify(key crypto.PublicKey) error {
+	var hash crypto.Hash
+	switch t.header.Algorithm {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return InvalidTokenError{fmt.Sprintf("unsupported algorithm %q", t.header.Algorithm)}
+	}
+
+	h := hash.New()
+	h.Write(t.signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(t.header.Algorithm, "RS") && rsa.VerifyPKCS1v15(k, hash, digest, t.signature) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if strings.HasPrefix(t.header.Algorithm, "ES") && len(t.signature) == 2*size {
+			r := new(big.Int).SetBytes(t.signature[:size])
+			s := new(big.Int).SetBytes(t.signature[size:])
+			if ecdsa.Verify(k, digest, r, s) {
+				return nil
+			}
+		}
+	}
+	return InvalidTokenError{"invalid signature"}
+}
+
+// keySet is a JSON Web Key Set.
+type keySet struct {
+	Keys []struct {
+		KeyType string `json:"kty"`
+		KeyID   string `json:"kid"`
+		Use     string `json:"use"`
+		N       string `json:"n"`
+		E       string `json:"e"`
+		Curve   string `json:"crv"`
+		X       string `json:"x"`
+		Y       string `json:"y"`
+	} `json:"keys"`
+}
+
+// publicKeys returns the signing keys of the set by their ID. Keys that are not RSA or ECDSA public keys are ignored.
+func (s keySet) publicKeys() map[string]crypto.PublicKey {
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range s.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch k.KeyType {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+				continue
+			}
+			exponent := 0
+			for _, b := range e {
+				exponent = exponent<<8 | int(b)
+			}
+			keys[k.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}
+
+		case "EC":
+			curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+			curve, ok := curves[k.Curve]
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if !ok || errX != nil || errY != nil {
+				continue
+			}
+			key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+			if !curve.IsOnCurve(key.X, key.Y) {
+				continue
+			}
+			keys[k.KeyID] = key
+		}
+	}
+	return keys
+}
@@ -0,0 +1,287 @@
+// Package oidc verifies the bearer tokens of the requests. They are JSON Web Tokens signed by an OpenID Connect
+// issuer, checked with the keys the issuer publishes, so Deployadactyl never sees the password of the user.
+package oidc
+
+import (
+	"crypto"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/compozed/deployadactyl/cabundle"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// DefaultTimeout is how long a request to the issuer may take when the configuration has no timeout.
+const DefaultTimeout = 10 * time.Second
+
+// The claims of the user of a token and of its groups when the configuration does not say.
+const (
+	DefaultUsernameClaim = "sub"
+	DefaultGroupsClaim   = "groups"
+)
+
+// Leeway is the difference allowed between the clocks of the issuer and of the server when the expiry of a token is
+// checked.
+const Leeway = time.Minute
+
+// RefreshInterval is how long the keys of the issuer are kept before a token signed with an unknown key reads them
+// again, so the keys the issuer rotates in are found without every forged token reaching the issuer.
+const RefreshInterval = time.Minute
+
+// DiscoveryPath is where the discovery document is under the URL of the issuer.
+const DiscoveryPath = "/.well-known/openid-configuration"
+
+// Validate returns an error when the issuer or the URL of its keys is not a URL, there is no audience, or the
+// timeout is negative.
+func Validate(descriptor S.OIDCDescriptor) error {
+	if descriptor.Issuer == "" {
+		return nil
+	}
+
+	issuer, err := url.Parse(descriptor.Issuer)
+	if err != nil || issuer.Scheme != "https" || issuer.Host == "" {
+		return InvalidIssuerError{descriptor.Issuer}
+	}
+	if descriptor.Audience == "" {
+		return MissingAudienceError{}
+	}
+	if descriptor.JWKSURL != "" {
+		jwks, err := url.Parse(descriptor.JWKSURL)
+		if err != nil || (jwks.Scheme != "http" && jwks.Scheme != "https") || jwks.Host == "" {
+			return InvalidJWKSURLError{descriptor.JWKSURL}
+		}
+	}
+	if descriptor.TimeoutSeconds < 0 {
+		return InvalidTimeoutError{descriptor.TimeoutSeconds}
+	}
+	return nil
+}
+
+// New returns the Verifier of the descriptor. It is nil without an issuer.
+func New(descriptor S.OIDCDescriptor, fileSystem *afero.Afero) (*Verifier, error) {
+	if descriptor.Issuer == "" {
+		return nil, nil
+	}
+
+	tlsConfig, err := cabundle.TLSConfig(fileSystem, descriptor.CABundle)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.InsecureSkipVerify = descriptor.SkipSSL
+
+	timeout := DefaultTimeout
+	if descriptor.TimeoutSeconds > 0 {
+		timeout = time.Duration(descriptor.TimeoutSeconds) * time.Second
+	}
+
+	verifier := NewVerifier(descriptor)
+	verifier.HTTP = &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+	}
+	return verifier, nil
+}
+
+// NewVerifier returns a Verifier of the tokens of the issuer of the descriptor.
+func NewVerifier(descriptor S.OIDCDescriptor) *Verifier {
+	if descriptor.UsernameClaim == "" {
+		descriptor.UsernameClaim = DefaultUsernameClaim
+	}
+	if descriptor.GroupsClaim == "" {
+		descriptor.GroupsClaim = DefaultGroupsClaim
+	}
+
+	return &Verifier{
+		Descriptor: descriptor,
+		HTTP:       &http.Client{Timeout: DefaultTimeout},
+		Now:        time.Now,
+	}
+}
+
+// Verifier is the TokenVerifier of the tokens of an OpenID Connect issuer.
+//
+// The keys of the issuer are read the first time a token is verified, and again when a token is signed with a key
+// that is not known, at most once every RefreshInterval.
+type Verifier struct {
+	Descriptor S.OIDCDescriptor
+	HTTP       *http.Client
+	Now        func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+// Verify returns the username and groups claims of the token. It returns an InvalidTokenError unless the token is
+// signed by the issuer, for the audience, and has not expired. A token without groups has none.
+func (v *Verifier) Verify(token string) (string, []string, error) {
+	t, err := parseToken(token)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key, err := v.key(t.header.KeyID)
+	if err != nil {
+		return "", nil, err
+	}
+	err = t.verify(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	username, err := v.validate(t.claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return username, stringsClaim(t.claims[v.Descriptor.GroupsClaim]), nil
+}
+
+// validate returns the user of the claims of a token whose signature is valid.
+func (v *Verifier) validate(claims map[string]interface{}) (string, error) {
+	if issuer, _ := claims["iss"].(string); issuer != v.Descriptor.Issuer {
+		return "", InvalidTokenError{fmt.Sprintf("issued by %q", issuer)}
+	}
+	if !audienceHas(claims["aud"], v.Descriptor.Audience) {
+		return "", InvalidTokenError{"not issued for this audience"}
+	}
+
+	now := v.Now()
+	expiry, ok := claims["exp"].(float64)
+	if !ok {
+		return "", InvalidTokenError{"no expiry"}
+	}
+	if now.Add(-Leeway).After(time.Unix(int64(expiry), 0)) {
+		return "", InvalidTokenError{"expired"}
+	}
+	if notBefore, ok := claims["nbf"].(float64); ok && now.Add(Leeway).Before(time.Unix(int64(notBefore), 0)) {
+		return "", InvalidTokenError{"not valid yet"}
+	}
+
+	username, _ := claims[v.Descriptor.UsernameClaim].(string)
+	if username == "" {
+		return "", InvalidTokenError{fmt.Sprintf("no %s claim", v.Descriptor.UsernameClaim)}
+	}
+	return username, nil
+}
+
+// stringsClaim returns the strings of a claim that is a string or an array of strings.
+func stringsClaim(claim interface{}) []string {
+	switch values := claim.(type) {
+	case string:
+		return []string{values}
+	case []interface{}:
+		var names []string
+		for _, value := range values {
+			if name, ok := value.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// audienceHas reports whether the aud claim, a string or an array of strings, has the audience.
+func audienceHas(claim interface{}, audience string) bool {
+	for _, aud := range stringsClaim(claim) {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the key with the ID, reading the keys of the issuer when it is not known. A token without a key ID is
+// verified with the only key of the issuer.
+func (v *Verifier) key(id string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.find(id)
+	if ok || (v.keys != nil && v.Now().Sub(v.fetched) < RefreshInterval) {
+		if !ok {
+			return nil, InvalidTokenError{fmt.Sprintf("signed with unknown key %q", id)}
+		}
+		return key, nil
+	}
+
+	keys, err := v.fetchKeys()
+	if err != nil {
+		return nil, err
+	}
+	v.keys = keys
+	v.fetched = v.Now()
+
+	key, ok = v.find(id)
+	if !ok {
+		return nil, InvalidTokenError{fmt.Sprintf("signed with unknown key %q", id)}
+	}
+	return key, nil
+}
+
+func (v *Verifier) find(id string) (crypto.PublicKey, bool) {
+	if id == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[id]
+	return key, ok
+}
+
+// fetchKeys reads the keys of the issuer from the URL of its discovery document, or from the configured URL.
+func (v *Verifier) fetchKeys() (map[string]crypto.PublicKey, error) {
+	jwksURL := v.Descriptor.JWKSURL
+	if jwksURL == "" {
+		discoveryURL := strings.TrimSuffix(v.Descriptor.Issuer, "/") + DiscoveryPath
+
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		err := v.get(discoveryURL, &discovery)
+		if err != nil {
+			return nil, DiscoveryError{discoveryURL, err}
+		}
+		if discovery.Issuer != v.Descriptor.Issuer {
+			return nil, IssuerMismatchError{v.Descriptor.Issuer, discovery.Issuer}
+		}
+		jwksURL = discovery.JWKSURI
+	}
+
+	var set keySet
+	err := v.get(jwksURL, &set)
+	if err != nil {
+		return nil, DiscoveryError{jwksURL, err}
+	}
+	return set.publicKeys(), nil
+}
+
+func (v *Verifier) get(address string, body interface{}) error {
+	response, err := v.HTTP.Get(address)
+	if err != nil {
+		if urlError, ok := err.(*url.Error); ok {
+			return urlError.Err
+		}
+		return err
+	}
+	defer response.Body.Close()
+	defer io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(body)
+}
@@ -0,0 +1,289 @@
+package oidc_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/compozed/deployadactyl/oidc"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var (
+	rsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+)
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// sign returns the token of the claims signed with the key, as the algorithm of the header says.
+func sign(header, claims map[string]interface{}, key crypto.Signer) string {
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := encode(h) + "." + encode(c)
+
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, _ := ecdsa.Sign(rand.Reader, k, digest[:])
+		signature = append(padded(r, 32), padded(s, 32)...)
+	}
+	return signed + "." + encode(signature)
+}
+
+func padded(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	return append(make([]byte, size-len(b)), b...)
+}
+
+// fakeIssuer publishes its discovery document and keys, and counts the requests for them.
+type fakeIssuer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	keys     []map[string]interface{}
+	requests []string
+}
+
+func (i *fakeIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.requests = append(i.requests, r.URL.Path)
+
+	switch r.URL.Path {
+	case DiscoveryPath:
+		json.NewEncoder(w).Encode(map[string]string{"issuer": i.URL, "jwks_uri": i.URL + "/keys"})
+	case "/keys":
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": i.keys})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func rsaJWK(id string, key *rsa.PublicKey) map[string]interface{} {
+	return map[string]interface{}{"kty": "RSA", "kid": id, "use": "sig", "n": encode(key.N.Bytes()), "e": encode(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(id string, key *ecdsa.PublicKey) map[string]interface{} {
+	return map[string]interface{}{"kty": "EC", "kid": id, "crv": "P-256", "x": encode(padded(key.X, 32)), "y": encode(padded(key.Y, 32))}
+}
+
+var _ = Describe("Verifier", func() {
+	var (
+		issuer   *fakeIssuer
+		verifier *Verifier
+		now      time.Time
+		claims   map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		issuer = &fakeIssuer{keys: []map[string]interface{}{rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey)}}
+		issuer.Server = httptest.NewTLSServer(issuer)
+
+		now = time.Unix(1500000000, 0)
+		claims = map[string]interface{}{
+			"iss":   issuer.URL,
+			"aud":   "deployadactyl",
+			"sub":   "00u1a2b3c",
+			"email": "jane@example.com",
+			"exp":   now.Add(time.Hour).Unix(),
+			"nbf":   now.Add(-time.Minute).Unix(),
+		}
+
+		var err error
+		verifier, err = New(S.OIDCDescriptor{Issuer: issuer.URL, Audience: "deployadactyl", SkipSSL: true}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		verifier.Now = func() time.Time { return now }
+	})
+
+	AfterEach(func() {
+		issuer.Close()
+	})
+
+	It("returns the subject of a token signed with an RSA key of the issuer", func() {
+		username, _, err := verifier.Verify(sign(map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims, rsaKey))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(username).To(Equal("00u1a2b3c"))
+		Expect(issuer.requests).To(Equal([]string{DiscoveryPath, "/keys"}))
+	})
+
+	It("verifies tokens signed with an ECDSA key of the issuer", func() {
+		_, _, err := verifier.Verify(sign(map[string]interface{}{"alg": "ES256", "kid": "ec-1"}, claims, ecKey))
+
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("returns the username claim of the configuration", func() {
+		verifier.Descriptor.UsernameClaim = "email"
+
+		username, _, err := verifier.Verify(sign(map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims, rsaKey))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(username).To(Equal("jane@example.com"))
+	})
+
+	It("returns the groups of the token", func() {
+		claims["groups"] = []string{"deployers", "operators"}
+
+		_, groups, err := verifier.Verify(sign(map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims, rsaKey))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(groups).To(Equal([]string{"deployers", "operators"}))
+	})
+
+	It("accepts an audience among others", func() {
+		claims["aud"] = []string{"other-client", "deployadactyl"}
+
+		_, _, err := verifier.Verify(sign(map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims, rsaKey))
+
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("keeps the keys of the issuer", func() {
+		token := sign(map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims, rsaKey)
+		verifier.Verify(token)
+		verifier.Verify(token)
+
+		Expect(issuer.requests).To(HaveLen(2))
+	})
+
+	It("reads the keys again for a key rotated in", func() {
+		verifier.Verify(sign(map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims, rsaKey))
+
+		rotated, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		issuer.mu.Lock()
+		issuer.keys = append(issuer.keys, ecJWK("ec-2", &rotated.PublicKey))
+		issuer.mu.Unlock()
+		token := sign(map[string]interface{}{"alg": "ES256", "kid": "ec-2"}, claims, rotated)
+
+		_, _, err := verifier.Verify(token)
+		Expect(err).To(MatchError(InvalidTokenError{`signed with unknown key "ec-2"`}))
+
+		now = now.Add(RefreshInterval)
+		_, _, err = verifier.Verify(token)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Context("when the token is not valid", func() {
+		var header map[string]interface{}
+
+		BeforeEach(func() {
+			header = map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}
+		})
+
+		refuses := func(token, reason string) {
+			_, _, err := verifier.Verify(token)
+			Expect(err).To(MatchError(InvalidTokenError{reason}))
+		}
+
+		It("refuses what is not a json web token", func() {
+			refuses("not-a-token", "not a json web token")
+		})
+
+		It("refuses a token signed with another key", func() {
+			other, _ := rsa.GenerateKey(rand.Reader, 1024)
+			refuses(sign(header, claims, other), "invalid signature")
+		})
+
+		It("refuses a token whose claims were changed", func() {
+			parts := strings.Split(sign(header, claims, rsaKey), ".")
+			claims["sub"] = "admin"
+			changed, _ := json.Marshal(claims)
+
+			refuses(parts[0]+"."+encode(changed)+"."+parts[2], "invalid signature")
+		})
+
+		It("refuses unsigned tokens and tokens signed with the public key as an hmac secret", func() {
+			header["alg"] = "none"
+			refuses(strings.Join(strings.Split(sign(header, claims, rsaKey), ".")[:2], ".")+".", `unsupported algorithm "none"`)
+
+			header["alg"] = "HS256"
+			refuses(sign(header, claims, rsaKey), `unsupported algorithm "HS256"`)
+		})
+
+		It("refuses a token of another issuer", func() {
+			claims["iss"] = "https://evil.example.com"
+			refuses(sign(header, claims, rsaKey), `issued by "https://evil.example.com"`)
+		})
+
+		It("refuses a token for another audience", func() {
+			claims["aud"] = "other-client"
+			refuses(sign(header, claims, rsaKey), "not issued for this audience")
+		})
+
+		It("refuses an expired token", func() {
+			claims["exp"] = now.Add(-2 * Leeway).Unix()
+			refuses(sign(header, claims, rsaKey), "expired")
+
+			delete(claims, "exp")
+			refuses(sign(header, claims, rsaKey), "no expiry")
+		})
+
+		It("refuses a token that is not valid yet", func() {
+			claims["nbf"] = now.Add(2 * Leeway).Unix()
+			refuses(sign(header, claims, rsaKey), "not valid yet")
+		})
+
+		It("refuses a token without a user", func() {
+			delete(claims, "sub")
+			refuses(sign(header, claims, rsaKey), "no sub claim")
+		})
+	})
+
+	It("returns a DiscoveryError when the keys cannot be read", func() {
+		issuer.Close()
+
+		_, _, err := verifier.Verify(sign(map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims, rsaKey))
+
+		Expect(err).To(BeAssignableToTypeOf(DiscoveryError{}))
+	})
+
+	It("returns an IssuerMismatchError when the discovery document is that of another issuer", func() {
+		verifier.Descriptor.Issuer = issuer.URL + "/"
+
+		_, _, err := verifier.Verify(sign(map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims, rsaKey))
+
+		Expect(err).To(MatchError(IssuerMismatchError{issuer.URL + "/", issuer.URL}))
+	})
+
+	Describe("Validate", func() {
+		It("accepts an empty or valid configuration", func() {
+			Expect(Validate(S.OIDCDescriptor{})).To(Succeed())
+			Expect(Validate(S.OIDCDescriptor{Issuer: "https://login.example.com", Audience: "deployadactyl", JWKSURL: "https://login.example.com/keys"})).To(Succeed())
+		})
+
+		It("refuses an issuer that is not an https url", func() {
+			Expect(Validate(S.OIDCDescriptor{Issuer: "http://login.example.com", Audience: "deployadactyl"})).To(MatchError(InvalidIssuerError{"http://login.example.com"}))
+		})
+
+		It("needs an audience", func() {
+			Expect(Validate(S.OIDCDescriptor{Issuer: "https://login.example.com"})).To(MatchError(MissingAudienceError{}))
+		})
+
+		It("refuses a jwks url that is not a url", func() {
+			Expect(Validate(S.OIDCDescriptor{Issuer: "https://login.example.com", Audience: "deployadactyl", JWKSURL: "keys"})).To(MatchError(InvalidJWKSURLError{"keys"}))
+		})
+
+		It("refuses a negative timeout", func() {
+			Expect(Validate(S.OIDCDescriptor{Issuer: "https://login.example.com", Audience: "deployadactyl", TimeoutSeconds: -1})).To(MatchError(InvalidTimeoutError{-1}))
+		})
+	})
+})
@@ -0,0 +1,3365 @@
+// Package openapi describes the API of the server as an OpenAPI document.
+package openapi
+
+// Document is the OpenAPI 3 document of the API. It must be updated with every change to the endpoints.
+const Document = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "Deployadactyl",
+    "description": "Blue green deployments of applications to several Cloud Foundry foundations.",
+    "version": "3"
+  },
+  "security": [
+    {},
+    {
+      "basicAuth": []
+    },
+    {
+      "tenantToken": []
+    }
+  ],
+  "paths": {
+    "/v3/apps/{environment}/{org}/{space}/{appName}": {
+      "post": {
+        "operationId": "deploy",
+        "summary": "Push the artifact of the request to every foundation of the environment with a blue green deployment.",
+        "parameters": [
+          {
+            "name": "environment",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "org",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "space",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "appName",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "Idempotency-Key",
+            "in": "header",
+            "description": "Deploys once for the same key.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "X-Deployadactyl-Signature",
+            "in": "header",
+            "description": "The signature of an uploaded zip.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "X-Deployadactyl-Manifest-Name",
+            "in": "header",
+            "description": "The manifest of an uploaded zip.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/DeployRequest"
+              }
+            },
+            "application/zip": {
+              "schema": {
+                "type": "string",
+                "format": "binary"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "The output of the deployment, which succeeded.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "207": {
+            "description": "The deployment succeeded on some foundations only.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "The environment belongs to another tenant.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "The application is locked by another deployment, or the deployment cannot be changed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "413": {
+            "description": "The request body is too large.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "503": {
+            "description": "Every foundation of the environment is in maintenance.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      },
+      "put": {
+        "operationId": "setState",
+        "summary": "Start, stop, restart or restage the application on every foundation of the environment.",
+        "parameters": [
+          {
+            "name": "environment",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "org",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "space",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "appName",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "Idempotency-Key",
+            "in": "header",
+            "description": "Changes the state once for the same key.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/StateRequest"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "The output of the change of state.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "The environment belongs to another tenant.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "The application is locked by another deployment, or the deployment cannot be changed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      },
+      "delete": {
+        "operationId": "deleteApp",
+        "summary": "Delete the application from every foundation of the environment.",
+        "parameters": [
+          {
+            "name": "environment",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "org",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "space",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "appName",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "delete_routes",
+            "in": "query",
+            "description": "Also deletes the routes of the application no other application of the space is mapped to.",
+            "required": false,
+            "schema": {
+              "type": "boolean"
+            }
+          },
+          {
+            "name": "Idempotency-Key",
+            "in": "header",
+            "description": "Deletes the application once for the same key.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "requestBody": {
+          "required": false,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/DeleteRequest"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "The output of the delete.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "The environment belongs to another tenant.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "The application is locked by another deployment, or the deployment cannot be changed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v2/deploy/{environment}/{org}/{space}/{appName}": {
+      "post": {
+        "operationId": "deployV2",
+        "deprecated": true,
+        "summary": "Same as POST /v3/apps/{environment}/{org}/{space}/{appName}.",
+        "parameters": [
+          {
+            "name": "environment",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "org",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "space",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "appName",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/DeployRequest"
+              }
+            },
+            "application/zip": {
+              "schema": {
+                "type": "string",
+                "format": "binary"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "The output of the deployment, which succeeded.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "207": {
+            "description": "The deployment succeeded on some foundations only.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "The environment belongs to another tenant.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "The application is locked by another deployment, or the deployment cannot be changed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "413": {
+            "description": "The request body is too large.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "503": {
+            "description": "Every foundation of the environment is in maintenance.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/batches/{org}/{space}/{appName}": {
+      "post": {
+        "operationId": "deployBatch",
+        "summary": "Deploy the application to several environments in order, stopping at the first that does not succeed.",
+        "parameters": [
+          {
+            "name": "org",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "space",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "appName",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "Idempotency-Key",
+            "in": "header",
+            "description": "Deploys the batch once for the same key.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/BatchRequest"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "Every deployment of the batch succeeded.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/BatchReport"
+                }
+              }
+            }
+          },
+          "default": {
+            "description": "A deployment of the batch did not succeed.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/BatchReport"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/promotions": {
+      "post": {
+        "operationId": "promote",
+        "summary": "Deploy the artifact running in the source environment to the target environment.",
+        "parameters": [
+          {
+            "name": "Idempotency-Key",
+            "in": "header",
+            "description": "Promotes once for the same key.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/PromotionRequest"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "The output of the deployment, which succeeded.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "207": {
+            "description": "The deployment succeeded on some foundations only.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "The environment belongs to another tenant.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "The application is locked by another deployment, or the deployment cannot be changed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "413": {
+            "description": "The request body is too large.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "503": {
+            "description": "Every foundation of the environment is in maintenance.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/deployments": {
+      "get": {
+        "operationId": "listDeployments",
+        "summary": "Query the deployment history, newest first.",
+        "parameters": [
+          {
+            "name": "environment",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "org",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "space",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "app",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "status",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "component",
+            "in": "query",
+            "description": "A component of the SBOM of the deployed artifact.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "version",
+            "in": "query",
+            "description": "The version prefix of the component.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "current",
+            "in": "query",
+            "description": "Only the deployments still running on a foundation.",
+            "required": false,
+            "schema": {
+              "type": "boolean"
+            }
+          },
+          {
+            "name": "limit",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "integer"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The deployments.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/DeploymentRecord"
+                  }
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/deployments/{uuid}": {
+      "get": {
+        "operationId": "getDeployment",
+        "summary": "Return a deployment with its progress while it runs.",
+        "parameters": [
+          {
+            "name": "uuid",
+            "in": "path",
+            "description": "The uuid of the deployment.",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The deployment.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/DeploymentRecord"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/deployments/{uuid}/logs": {
+      "get": {
+        "operationId": "getDeploymentLog",
+        "summary": "Return the output of a deployment.",
+        "parameters": [
+          {
+            "name": "uuid",
+            "in": "path",
+            "description": "The uuid of the deployment.",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The output of the deployment.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/deployments/{uuid}/logs/stream": {
+      "get": {
+        "operationId": "streamDeploymentLog",
+        "summary": "Stream the output of a deployment over a WebSocket while it runs, one text message per write, until it is finished.",
+        "parameters": [
+          {
+            "name": "uuid",
+            "in": "path",
+            "description": "The uuid of the deployment.",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "101": {
+            "description": "The connection is upgraded to a WebSocket streaming the output of the deployment."
+          },
+          "400": {
+            "description": "The request is not a WebSocket handshake."
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/deployments/{uuid}/progress": {
+      "get": {
+        "operationId": "streamDeploymentProgress",
+        "summary": "Stream the progress of a deployment as server-sent events named progress, until it is finished.",
+        "parameters": [
+          {
+            "name": "uuid",
+            "in": "path",
+            "description": "The uuid of the deployment.",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The progress of the deployment every time it changes.",
+            "content": {
+              "text/event-stream": {
+                "schema": {
+                  "type": "string"
+                },
+                "x-event-data": {
+                  "$ref": "#/components/schemas/DeploymentProgress"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/deployments/{uuid}/retry": {
+      "post": {
+        "operationId": "retryDeployment",
+        "summary": "Deploy a failed deployment again with its request.",
+        "parameters": [
+          {
+            "name": "uuid",
+            "in": "path",
+            "description": "The uuid of the deployment.",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "failed_foundations",
+            "in": "query",
+            "description": "Only deploy to the foundations the deployment failed against.",
+            "required": false,
+            "schema": {
+              "type": "boolean"
+            }
+          },
+          {
+            "name": "Idempotency-Key",
+            "in": "header",
+            "description": "Retries once for the same key.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The output of the deployment, which succeeded.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "207": {
+            "description": "The deployment succeeded on some foundations only.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "The environment belongs to another tenant.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "The application is locked by another deployment, or the deployment cannot be changed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "413": {
+            "description": "The request body is too large.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "503": {
+            "description": "Every foundation of the environment is in maintenance.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/deployments/{uuid}/foundations/retry": {
+      "post": {
+        "operationId": "retryFoundations",
+        "summary": "Deploy a deployment again to the foundations it did not succeed on.",
+        "parameters": [
+          {
+            "name": "uuid",
+            "in": "path",
+            "description": "The uuid of the deployment.",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "Idempotency-Key",
+            "in": "header",
+            "description": "Retries once for the same key.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The output of the deployment, which succeeded.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "207": {
+            "description": "The deployment succeeded on some foundations only.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "The environment belongs to another tenant.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "The application is locked by another deployment, or the deployment cannot be changed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "413": {
+            "description": "The request body is too large.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "503": {
+            "description": "Every foundation of the environment is in maintenance.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            },
+            "headers": {
+              "X-Deployadactyl-Deployment-Id": {
+                "description": "The uuid of the deployment.",
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/deployments/{uuid}/cancel": {
+      "post": {
+        "operationId": "cancelDeployment",
+        "summary": "Cancel a running deployment, which then rolls back.",
+        "parameters": [
+          {
+            "name": "uuid",
+            "in": "path",
+            "description": "The uuid of the deployment.",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "202": {
+            "description": "The deployment is being cancelled.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "The application is locked by another deployment, or the deployment cannot be changed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/deployments/{uuid}/canary/promote": {
+      "post": {
+        "operationId": "promoteCanary",
+        "summary": "Promote the canary of a running deployment to every instance.",
+        "parameters": [
+          {
+            "name": "uuid",
+            "in": "path",
+            "description": "The uuid of the deployment.",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "202": {
+            "description": "The canary is being promoted.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment or feature is not found, or the canary is not waiting to be promoted.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "The application is locked by another deployment, or the deployment cannot be changed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/versions/{org}/{space}/{appName}": {
+      "get": {
+        "operationId": "listDeployedVersions",
+        "summary": "Return the artifact running on every foundation the application was deployed to.",
+        "parameters": [
+          {
+            "name": "org",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "space",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "appName",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "environment",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The deployed versions.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/DeployedVersion"
+                  }
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/drift/{org}/{space}/{appName}": {
+      "get": {
+        "operationId": "detectDrift",
+        "summary": "Compare the application on every foundation of one or two environments.",
+        "parameters": [
+          {
+            "name": "org",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "space",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "appName",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "environments",
+            "in": "query",
+            "description": "One or two comma separated environments.",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The drift report.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/DriftReport"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "The environment belongs to another tenant.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/events/stream": {
+      "get": {
+        "operationId": "streamEvents",
+        "summary": "Stream every event emitted from now on as server-sent events.",
+        "parameters": [
+          {
+            "name": "environment",
+            "in": "query",
+            "description": "Repeatable.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "app",
+            "in": "query",
+            "description": "Repeatable.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "type",
+            "in": "query",
+            "description": "Repeatable.",
+            "required": false,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The events.",
+            "content": {
+              "text/event-stream": {
+                "schema": {
+                  "type": "string"
+                },
+                "x-event-data": {
+                  "$ref": "#/components/schemas/StreamedEvent"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/admin/deployments/active": {
+      "get": {
+        "operationId": "listActiveDeployments",
+        "summary": "Return the running and queued deployments, longest running first.",
+        "security": [
+          {
+            "basicAuth": []
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The active deployments.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/ActiveDeployment"
+                  }
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "The request failed, or the deployment did not succeed.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/admin/debug/pprof/{profile}": {
+      "get": {
+        "operationId": "getProfile",
+        "summary": "Return a runtime profile of the server.",
+        "security": [
+          {
+            "basicAuth": []
+          }
+        ],
+        "parameters": [
+          {
+            "name": "profile",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The profile.",
+            "content": {
+              "application/octet-stream": {
+                "schema": {
+                  "type": "string",
+                  "format": "binary"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      },
+      "post": {
+        "operationId": "postProfile",
+        "summary": "Resolve symbols of the profile.",
+        "security": [
+          {
+            "basicAuth": []
+          }
+        ],
+        "parameters": [
+          {
+            "name": "profile",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The symbols.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/admin/debug/goroutines": {
+      "get": {
+        "operationId": "getGoroutines",
+        "summary": "Return a dump of the goroutines of the server.",
+        "security": [
+          {
+            "basicAuth": []
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The goroutines.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/admin/debug/runtime": {
+      "get": {
+        "operationId": "getRuntimeStats",
+        "summary": "Return the runtime statistics of the server.",
+        "security": [
+          {
+            "basicAuth": []
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The runtime statistics.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/RuntimeStats"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/temp-directories": {
+      "get": {
+        "operationId": "listTempDirectories",
+        "summary": "Return the temporary directories of the running deployments and the leaked ones.",
+        "responses": {
+          "200": {
+            "description": "The temporary directories.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/TempDirectoryReport"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/stale-apps": {
+      "get": {
+        "operationId": "getStaleApps",
+        "summary": "Return the report of the last sweep for stale applications.",
+        "responses": {
+          "200": {
+            "description": "The last report.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/StaleAppsReport"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      },
+      "post": {
+        "operationId": "sweepStaleApps",
+        "summary": "Sweep the foundations for stale applications right away.",
+        "security": [
+          {
+            "basicAuth": []
+          }
+        ],
+        "parameters": [
+          {
+            "name": "delete",
+            "in": "query",
+            "description": "Whether the stale applications are deleted.",
+            "required": false,
+            "schema": {
+              "type": "boolean"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "The report of the sweep.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/StaleAppsReport"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/maintenance": {
+      "get": {
+        "operationId": "listMaintenance",
+        "summary": "Return the foundations in maintenance.",
+        "responses": {
+          "200": {
+            "description": "The foundations in maintenance.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Maintenance"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      },
+      "put": {
+        "operationId": "setMaintenance",
+        "summary": "Put a foundation in maintenance or take it out of maintenance.",
+        "security": [
+          {
+            "basicAuth": []
+          }
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/MaintenanceRequest"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "The foundations in maintenance.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Maintenance"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "The request is invalid.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "The request is not authorized.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "The deployment, environment or feature is not found.",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/v3/openapi.json": {
+      "get": {
+        "operationId": "getOpenAPI",
+        "summary": "Return this document.",
+        "responses": {
+          "200": {
+            "description": "The OpenAPI document.",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object"
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "basicAuth": {
+        "type": "http",
+        "scheme": "basic",
+        "description": "The Cloud Foundry credentials of the deployment, or those of the server for the admin endpoints."
+      },
+      "tenantToken": {
+        "type": "apiKey",
+        "in": "header",
+        "name": "X-Deployadactyl-Token",
+        "description": "An API token of a tenant."
+      }
+    },
+    "schemas": {
+      "DeployRequest": {
+        "type": "object",
+        "description": "A deployment of one of an artifact_url, a git repository or maven coordinates.",
+        "properties": {
+          "artifact_url": {
+            "type": "string"
+          },
+          "git": {
+            "type": "object",
+            "properties": {
+              "url": {
+                "type": "string"
+              },
+              "ref": {
+                "type": "string"
+              }
+            },
+            "required": [
+              "url",
+              "ref"
+            ]
+          },
+          "maven": {
+            "type": "object",
+            "properties": {
+              "group_id": {
+                "type": "string"
+              },
+              "artifact_id": {
+                "type": "string"
+              },
+              "version": {
+                "type": "string",
+                "description": "A version, RELEASE or LATEST."
+              },
+              "packaging": {
+                "type": "string",
+                "default": "jar"
+              },
+              "classifier": {
+                "type": "string"
+              }
+            },
+            "required": [
+              "group_id",
+              "artifact_id",
+              "version"
+            ]
+          },
+          "manifest": {
+            "type": "string",
+            "description": "A base64 encoded or inline YAML manifest."
+          },
+          "manifests": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            },
+            "description": "Base64 encoded or inline YAML manifests by name."
+          },
+          "manifest_name": {
+            "type": "string"
+          },
+          "merge_manifest": {
+            "type": "boolean",
+            "description": "Merges the manifest onto the manifest of the artifact instead of replacing it."
+          },
+          "environment_variables": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            },
+            "description": "Environment variables set in the manifest of every application before it is pushed."
+          },
+          "health_check_endpoint": {
+            "type": "string"
+          },
+          "routes": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "properties": {
+                "hostname": {
+                  "type": "string",
+                  "description": "Defaults to the name of the application."
+                },
+                "domain": {
+                  "type": "string"
+                },
+                "path": {
+                  "type": "string"
+                }
+              },
+              "required": [
+                "domain"
+              ]
+            },
+            "description": "Routes moved to the new build with the load balanced route of the environment."
+          },
+          "data": {
+            "type": "object"
+          },
+          "metadata": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            }
+          },
+          "signature": {
+            "type": "string"
+          },
+          "signature_url": {
+            "type": "string"
+          },
+          "sbom": {
+            "$ref": "#/components/schemas/SBOM"
+          },
+          "provenance": {
+            "$ref": "#/components/schemas/Provenance"
+          }
+        }
+      },
+      "StateRequest": {
+        "type": "object",
+        "properties": {
+          "state": {
+            "type": "string",
+            "enum": [
+              "started",
+              "stopped",
+              "restarted",
+              "restaged"
+            ]
+          },
+          "mode": {
+            "type": "string"
+          },
+          "batch_size": {
+            "type": "integer"
+          },
+          "data": {
+            "type": "object"
+          },
+          "metadata": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            }
+          }
+        },
+        "required": [
+          "state"
+        ]
+      },
+      "DeleteRequest": {
+        "type": "object",
+        "properties": {
+          "data": {
+            "type": "object"
+          },
+          "metadata": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            }
+          }
+        }
+      },
+      "BatchRequest": {
+        "type": "object",
+        "properties": {
+          "environments": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "deployment": {
+            "$ref": "#/components/schemas/DeployRequest"
+          }
+        },
+        "required": [
+          "environments",
+          "deployment"
+        ]
+      },
+      "BatchReport": {
+        "type": "object",
+        "properties": {
+          "batch_id": {
+            "type": "string"
+          },
+          "status": {
+            "type": "string"
+          },
+          "deployments": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/BatchDeployment"
+            }
+          }
+        }
+      },
+      "BatchDeployment": {
+        "type": "object",
+        "properties": {
+          "environment": {
+            "type": "string"
+          },
+          "uuid": {
+            "type": "string"
+          },
+          "status": {
+            "type": "string"
+          },
+          "status_code": {
+            "type": "integer"
+          },
+          "error": {
+            "type": "string"
+          }
+        }
+      },
+      "PromotionRequest": {
+        "type": "object",
+        "properties": {
+          "source": {
+            "type": "string"
+          },
+          "target": {
+            "type": "string"
+          },
+          "org": {
+            "type": "string"
+          },
+          "space": {
+            "type": "string"
+          },
+          "app_name": {
+            "type": "string"
+          }
+        },
+        "required": [
+          "source",
+          "target",
+          "org",
+          "space",
+          "app_name"
+        ]
+      },
+      "DeploymentRecord": {
+        "type": "object",
+        "properties": {
+          "uuid": {
+            "type": "string"
+          },
+          "environment": {
+            "type": "string"
+          },
+          "org": {
+            "type": "string"
+          },
+          "space": {
+            "type": "string"
+          },
+          "app_name": {
+            "type": "string"
+          },
+          "username": {
+            "type": "string"
+          },
+          "artifact_url": {
+            "type": "string"
+          },
+          "artifact_digest": {
+            "type": "string"
+          },
+          "status": {
+            "type": "string",
+            "enum": [
+              "running",
+              "succeeded",
+              "failed",
+              "degraded",
+              "partial"
+            ]
+          },
+          "error": {
+            "type": "string"
+          },
+          "started_at": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "finished_at": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "metadata": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            }
+          },
+          "sbom": {
+            "$ref": "#/components/schemas/SBOM"
+          },
+          "provenance": {
+            "$ref": "#/components/schemas/Provenance"
+          },
+          "scan_result": {
+            "$ref": "#/components/schemas/ScanResult"
+          },
+          "evidence": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/DeploymentEvidence"
+            }
+          },
+          "request": {
+            "type": "object"
+          },
+          "foundations": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "failed_foundations": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "skipped_foundations": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "foundation_results": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/FoundationResult"
+            }
+          },
+          "progress": {
+            "$ref": "#/components/schemas/DeploymentProgress"
+          }
+        }
+      },
+      "FoundationResult": {
+        "type": "object",
+        "properties": {
+          "foundation_url": {
+            "type": "string"
+          },
+          "status": {
+            "type": "string"
+          },
+          "error": {
+            "type": "string"
+          }
+        }
+      },
+      "DeploymentProgress": {
+        "type": "object",
+        "properties": {
+          "uuid": {
+            "type": "string"
+          },
+          "phase": {
+            "type": "string",
+            "enum": [
+              "prechecking",
+              "preparing",
+              "logging_in",
+              "executing",
+              "verifying",
+              "baking",
+              "promoting",
+              "succeeding",
+              "rolling_back",
+              "finished"
+            ]
+          },
+          "percent": {
+            "type": "integer"
+          },
+          "foundations": {
+            "type": "integer"
+          },
+          "foundations_done": {
+            "type": "integer"
+          },
+          "steps": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            }
+          },
+          "failed_foundations": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "finished": {
+            "type": "boolean"
+          },
+          "error": {
+            "type": "string"
+          },
+          "updated_at": {
+            "type": "string",
+            "format": "date-time"
+          }
+        }
+      },
+      "ActiveDeployment": {
+        "type": "object",
+        "properties": {
+          "uuid": {
+            "type": "string"
+          },
+          "environment": {
+            "type": "string"
+          },
+          "org": {
+            "type": "string"
+          },
+          "space": {
+            "type": "string"
+          },
+          "app_name": {
+            "type": "string"
+          },
+          "phase": {
+            "type": "string"
+          },
+          "percent": {
+            "type": "integer"
+          },
+          "started_at": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "elapsed_seconds": {
+            "type": "integer"
+          },
+          "username": {
+            "type": "string"
+          }
+        }
+      },
+      "DeployedVersion": {
+        "type": "object",
+        "properties": {
+          "environment": {
+            "type": "string"
+          },
+          "foundation_url": {
+            "type": "string"
+          },
+          "uuid": {
+            "type": "string"
+          },
+          "status": {
+            "type": "string"
+          },
+          "artifact_url": {
+            "type": "string"
+          },
+          "artifact_digest": {
+            "type": "string"
+          },
+          "version": {
+            "type": "string"
+          },
+          "revision": {
+            "type": "string"
+          },
+          "deployed_by": {
+            "type": "string"
+          },
+          "deployed_at": {
+            "type": "string",
+            "format": "date-time"
+          }
+        }
+      },
+      "DriftReport": {
+        "type": "object",
+        "properties": {
+          "drifted": {
+            "type": "boolean"
+          },
+          "differences": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/DriftDifference"
+            }
+          },
+          "snapshots": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/AppSnapshot"
+            }
+          }
+        }
+      },
+      "DriftDifference": {
+        "type": "object",
+        "properties": {
+          "field": {
+            "type": "string"
+          },
+          "name": {
+            "type": "string"
+          },
+          "values": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/DriftValue"
+            }
+          }
+        }
+      },
+      "DriftValue": {
+        "type": "object",
+        "properties": {
+          "environment": {
+            "type": "string"
+          },
+          "foundation_url": {
+            "type": "string"
+          },
+          "value": {
+            "type": "string"
+          }
+        }
+      },
+      "AppSnapshot": {
+        "type": "object",
+        "properties": {
+          "environment": {
+            "type": "string"
+          },
+          "foundation_url": {
+            "type": "string"
+          },
+          "state": {
+            "type": "string"
+          },
+          "instances": {
+            "type": "integer"
+          },
+          "routes": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "environment_variables": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            }
+          },
+          "artifact_url": {
+            "type": "string"
+          },
+          "artifact_digest": {
+            "type": "string"
+          },
+          "version": {
+            "type": "string"
+          },
+          "error": {
+            "type": "string"
+          }
+        }
+      },
+      "StreamedEvent": {
+        "type": "object",
+        "properties": {
+          "type": {
+            "type": "string"
+          },
+          "time": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "uuid": {
+            "type": "string"
+          },
+          "environment": {
+            "type": "string"
+          },
+          "org": {
+            "type": "string"
+          },
+          "space": {
+            "type": "string"
+          },
+          "app_name": {
+            "type": "string"
+          },
+          "foundation_url": {
+            "type": "string"
+          },
+          "artifact_url": {
+            "type": "string"
+          },
+          "error": {
+            "type": "string"
+          }
+        }
+      },
+      "RuntimeStats": {
+        "type": "object",
+        "properties": {
+          "num_cpu": {
+            "type": "integer"
+          },
+          "gomaxprocs": {
+            "type": "integer"
+          },
+          "goroutines": {
+            "type": "integer"
+          },
+          "heap_alloc_bytes": {
+            "type": "integer"
+          },
+          "heap_inuse_bytes": {
+            "type": "integer"
+          },
+          "heap_idle_bytes": {
+            "type": "integer"
+          },
+          "heap_sys_bytes": {
+            "type": "integer"
+          },
+          "heap_objects": {
+            "type": "integer"
+          },
+          "stack_inuse_bytes": {
+            "type": "integer"
+          },
+          "sys_bytes": {
+            "type": "integer"
+          },
+          "total_alloc_bytes": {
+            "type": "integer"
+          },
+          "mallocs": {
+            "type": "integer"
+          },
+          "frees": {
+            "type": "integer"
+          },
+          "num_gc": {
+            "type": "integer"
+          },
+          "pause_total_ns": {
+            "type": "integer"
+          },
+          "next_gc_bytes": {
+            "type": "integer"
+          },
+          "go_version": {
+            "type": "string"
+          },
+          "last_gc": {
+            "type": "string",
+            "format": "date-time"
+          }
+        }
+      },
+      "TempDirectoryReport": {
+        "type": "object",
+        "properties": {
+          "active": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/TempDirectory"
+            }
+          },
+          "leaked": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/TempDirectory"
+            }
+          }
+        }
+      },
+      "TempDirectory": {
+        "type": "object",
+        "properties": {
+          "path": {
+            "type": "string"
+          },
+          "deployment_id": {
+            "type": "string"
+          },
+          "created_at": {
+            "type": "string",
+            "format": "date-time"
+          }
+        }
+      },
+      "StaleAppsReport": {
+        "type": "object",
+        "properties": {
+          "swept_at": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "apps": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/StaleApp"
+            }
+          },
+          "errors": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/StaleAppsFoundationError"
+            }
+          }
+        }
+      },
+      "StaleApp": {
+        "type": "object",
+        "properties": {
+          "environment": {
+            "type": "string"
+          },
+          "foundation_url": {
+            "type": "string"
+          },
+          "org": {
+            "type": "string"
+          },
+          "space": {
+            "type": "string"
+          },
+          "app_name": {
+            "type": "string"
+          },
+          "created_at": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "deleted": {
+            "type": "boolean"
+          },
+          "error": {
+            "type": "string"
+          }
+        }
+      },
+      "StaleAppsFoundationError": {
+        "type": "object",
+        "properties": {
+          "environment": {
+            "type": "string"
+          },
+          "foundation_url": {
+            "type": "string"
+          },
+          "error": {
+            "type": "string"
+          }
+        }
+      },
+      "Maintenance": {
+        "type": "object",
+        "properties": {
+          "foundations": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          }
+        }
+      },
+      "MaintenanceRequest": {
+        "type": "object",
+        "properties": {
+          "foundation_url": {
+            "type": "string"
+          },
+          "maintenance": {
+            "type": "boolean"
+          }
+        },
+        "required": [
+          "foundation_url"
+        ]
+      },
+      "SBOM": {
+        "type": "object",
+        "properties": {
+          "reference": {
+            "type": "string"
+          },
+          "generated": {
+            "type": "boolean"
+          },
+          "components": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/Component"
+            }
+          }
+        }
+      },
+      "Component": {
+        "type": "object",
+        "properties": {
+          "type": {
+            "type": "string"
+          },
+          "group": {
+            "type": "string"
+          },
+          "name": {
+            "type": "string"
+          },
+          "version": {
+            "type": "string"
+          },
+          "path": {
+            "type": "string"
+          }
+        }
+      },
+      "Provenance": {
+        "type": "object",
+        "properties": {
+          "source_repository": {
+            "type": "string"
+          },
+          "revision": {
+            "type": "string"
+          },
+          "build_id": {
+            "type": "string"
+          },
+          "build_url": {
+            "type": "string"
+          },
+          "builder": {
+            "type": "string"
+          }
+        }
+      },
+      "ScanResult": {
+        "type": "object",
+        "properties": {
+          "scanner": {
+            "type": "string"
+          },
+          "findings": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/ScanFinding"
+            }
+          },
+          "scanned_at": {
+            "type": "string",
+            "format": "date-time"
+          }
+        }
+      },
+      "ScanFinding": {
+        "type": "object",
+        "properties": {
+          "path": {
+            "type": "string"
+          },
+          "description": {
+            "type": "string"
+          }
+        }
+      },
+      "DeploymentEvidence": {
+        "type": "object",
+        "properties": {
+          "foundation_url": {
+            "type": "string"
+          },
+          "app_name": {
+            "type": "string"
+          },
+          "requests": {
+            "type": "integer"
+          },
+          "server_errors": {
+            "type": "integer"
+          },
+          "crashes": {
+            "type": "integer"
+          },
+          "excerpts": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
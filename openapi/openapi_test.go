@@ -0,0 +1,70 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	. "github.com/compozed/deployadactyl/openapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Document", func() {
+	var document map[string]interface{}
+
+	BeforeEach(func() {
+		document = map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(Document), &document)).To(Succeed())
+	})
+
+	It("is an OpenAPI 3 document", func() {
+		Expect(document["openapi"]).To(HavePrefix("3."))
+		Expect(document).To(HaveKey("info"))
+		Expect(document).To(HaveKey("paths"))
+	})
+
+	It("defines every schema it refers to", func() {
+		schemas := document["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+		for _, ref := range regexp.MustCompile(`"\$ref": "([^"]+)"`).FindAllStringSubmatch(Document, -1) {
+			Expect(ref[1]).To(HavePrefix("#/components/schemas/"))
+			Expect(schemas).To(HaveKey(strings.TrimPrefix(ref[1], "#/components/schemas/")))
+		}
+	})
+
+	It("has a unique operationId and the responses of every operation", func() {
+		ids := map[string]bool{}
+
+		for path, item := range document["paths"].(map[string]interface{}) {
+			for method, operation := range item.(map[string]interface{}) {
+				op := operation.(map[string]interface{})
+				id, _ := op["operationId"].(string)
+
+				Expect(id).ToNot(BeEmpty(), method+" "+path)
+				Expect(ids).ToNot(HaveKey(id))
+				Expect(op["responses"]).ToNot(BeEmpty(), method+" "+path)
+				ids[id] = true
+			}
+		}
+	})
+
+	It("declares the path params of every path", func() {
+		for path, item := range document["paths"].(map[string]interface{}) {
+			for _, operation := range item.(map[string]interface{}) {
+				declared := map[string]bool{}
+				params, _ := operation.(map[string]interface{})["parameters"].([]interface{})
+				for _, p := range params {
+					param := p.(map[string]interface{})
+					if param["in"] == "path" {
+						declared[param["name"].(string)] = true
+					}
+				}
+
+				for _, name := range regexp.MustCompile(`{([^}]+)}`).FindAllStringSubmatch(path, -1) {
+					Expect(declared).To(HaveKey(name[1]), path)
+				}
+			}
+		}
+	})
+})
@@ -0,0 +1,441 @@
+package postgres
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/compozed/deployadactyl/scram"
+)
+
+// The messages of the protocol.
+const (
+	authenticationMessage  = 'R'
+	backendKeyData         = 'K'
+	bindComplete           = '2'
+	commandComplete        = 'C'
+	dataRow                = 'D'
+	emptyQueryResponse     = 'I'
+	errorResponse          = 'E'
+	noticeResponse         = 'N'
+	parameterStatus        = 'S'
+	parseComplete          = '1'
+	readyForQuery          = 'Z'
+	rowDescription         = 'T'
+	noData                 = 'n'
+	notificationResponse   = 'A'
+	parameterDescription   = 't'
+	passwordMessage        = 'p'
+	parseMessage           = 'P'
+	bindMessage            = 'B'
+	executeMessage         = 'E'
+	syncMessage            = 'S'
+	terminateMessage       = 'X'
+	protocolVersion        = 196608
+	sslRequestCode         = 80877103
+	authenticationOk       = 0
+	authenticationClear    = 3
+	authenticationMD5      = 5
+	authenticationSASL     = 10
+	authenticationSASLNext = 11
+	authenticationSASLEnd  = 12
+)
+
+// maxMessage is the largest message read from the database.
+const maxMessage = 64 << 20
+
+// conn is a connection to the database, which runs one statement at a time.
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// connect opens a connection to the database and logs into it. The session is in UTC with ISO dates, so
+// timestamps can be parsed.
+func connect(config Config, tlsConfig *tls.Config, timeout time.Duration) (*conn, error) {
+	raw, err := net.DialTimeout("tcp", config.Address, timeout)
+	if err != nil {
+		return nil, ConnectError{config.Address, err}
+	}
+	raw.SetDeadline(time.Now().Add(timeout))
+
+	if tlsConfig != nil {
+		request := &encoder{}
+		request.int32(8)
+		request.int32(sslRequestCode)
+		_, err = raw.Write(request.buf)
+		if err != nil {
+			raw.Close()
+			return nil, ConnectError{config.Address, err}
+		}
+
+		answer := make([]byte, 1)
+		_, err = io.ReadFull(raw, answer)
+		if err != nil {
+			raw.Close()
+			return nil, ConnectError{config.Address, err}
+		}
+		if answer[0] != 'S' {
+			raw.Close()
+			return nil, SSLNotSupportedError{config.Address}
+		}
+
+		client := tls.Client(raw, tlsConfig)
+		err = client.Handshake()
+		if err != nil {
+			raw.Close()
+			return nil, ConnectError{config.Address, err}
+		}
+		raw = client
+	}
+
+	c := &conn{Conn: raw, reader: bufio.NewReader(raw)}
+	err = c.startup(config)
+	if err != nil {
+		c.Close()
+		if _, ok := err.(Error); ok {
+			return nil, err
+		}
+		return nil, ConnectError{config.Address, err}
+	}
+
+	raw.SetDeadline(time.Time{})
+	return c, nil
+}
+
+func (c *conn) startup(config Config) error {
+	startup := &encoder{}
+	startup.int32(0)
+	startup.int32(protocolVersion)
+	for _, param := range [][2]string{
+		{"user", config.User},
+		{"database", config.Database},
+		{"application_name", "deployadactyl"},
+		{"client_encoding", "UTF8"},
+		{"DateStyle", "ISO"},
+		{"TimeZone", "UTC"},
+	} {
+		startup.string(param[0])
+		startup.string(param[1])
+	}
+	startup.buf = append(startup.buf, 0)
+	binary.BigEndian.PutUint32(startup.buf, uint32(len(startup.buf)))
+
+	_, err := c.Write(startup.buf)
+	if err != nil {
+		return err
+	}
+
+	err = c.authenticate(config)
+	if err != nil {
+		return err
+	}
+
+	for {
+		messageType, body, err := c.receive()
+		if err != nil {
+			return err
+		}
+		if messageType == readyForQuery {
+			return nil
+		}
+		if messageType == errorResponse {
+			return parseError(body)
+		}
+		if messageType != parameterStatus && messageType != backendKeyData {
+			return UnexpectedMessageError{messageType}
+		}
+	}
+}
+
+// authenticate answers the authentication requests of the database until it accepts the connection.
+func (c *conn) authenticate(config Config) error {
+	var client *scram.Client
+
+	for {
+		messageType, body, err := c.receive()
+		if err != nil {
+			return err
+		}
+		if messageType == errorResponse {
+			return parseError(body)
+		}
+		if messageType != authenticationMessage {
+			return UnexpectedMessageError{messageType}
+		}
+
+		request := &decoder{data: body}
+		switch request.int32() {
+		case authenticationOk:
+			return nil
+		case authenticationClear:
+			err = c.send(passwordMessage, append([]byte(config.Password), 0))
+		case authenticationMD5:
+			salt := request.next(4)
+			inner := md5.Sum([]byte(config.Password + config.User))
+			outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+			err = c.send(passwordMessage, append([]byte("md5"+hex.EncodeToString(outer[:])), 0))
+		case authenticationSASL:
+			mechanisms := []string{}
+			for mechanism := request.string(); mechanism != ""; mechanism = request.string() {
+				mechanisms = append(mechanisms, mechanism)
+			}
+			if !contains(mechanisms, scram.SHA256) {
+				return UnsupportedAuthenticationError{strings.Join(mechanisms, ", ")}
+			}
+
+			client, err = scram.NewClient(scram.SHA256, "", config.Password)
+			if err != nil {
+				return err
+			}
+
+			first := client.First()
+			response := &encoder{}
+			response.string(scram.SHA256)
+			response.int32(int32(len(first)))
+			response.buf = append(response.buf, first...)
+			err = c.send(passwordMessage, response.buf)
+		case authenticationSASLNext:
+			if client == nil {
+				return UnexpectedMessageError{messageType}
+			}
+
+			var final []byte
+			final, err = client.Final(request.data)
+			if err != nil {
+				return err
+			}
+			err = c.send(passwordMessage, final)
+		case authenticationSASLEnd:
+			if client == nil {
+				return UnexpectedMessageError{messageType}
+			}
+			err = client.Verify(request.data)
+		default:
+			return UnsupportedAuthenticationError{"the method requested by the database"}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// query runs the statement with the parameters using the extended query protocol, so the parameters are never
+// interpolated into the statement, and returns the rows of its result.
+func (c *conn) query(query string, params []*string, timeout time.Duration) ([][]string, error) {
+	c.SetDeadline(time.Now().Add(timeout))
+
+	parse := &encoder{}
+	parse.string("")
+	parse.string(query)
+	parse.int16(0)
+
+	bind := &encoder{}
+	bind.string("")
+	bind.string("")
+	bind.int16(0)
+	bind.int16(int16(len(params)))
+	for _, param := range params {
+		if param == nil {
+			bind.int32(-1)
+			continue
+		}
+		bind.int32(int32(len(*param)))
+		bind.buf = append(bind.buf, *param...)
+	}
+	bind.int16(0)
+
+	execute := &encoder{}
+	execute.string("")
+	execute.int32(0)
+
+	request := message(parseMessage, parse.buf)
+	request = append(request, message(bindMessage, bind.buf)...)
+	request = append(request, message(executeMessage, execute.buf)...)
+	request = append(request, message(syncMessage, nil)...)
+
+	_, err := c.Write(request)
+	if err != nil {
+		return nil, SendError{err}
+	}
+
+	rows := [][]string{}
+	var queryErr error
+	for {
+		messageType, body, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+
+		switch messageType {
+		case dataRow:
+			response := &decoder{data: body}
+			row := make([]string, response.int16())
+			for i := range row {
+				length := response.int32()
+				if length >= 0 {
+					row[i] = string(response.next(int(length)))
+				}
+			}
+			rows = append(rows, row)
+		case errorResponse:
+			if queryErr == nil {
+				queryErr = parseError(body)
+			}
+		case readyForQuery:
+			c.SetDeadline(time.Time{})
+			if queryErr != nil {
+				return nil, queryErr
+			}
+			return rows, nil
+		case parseComplete, bindComplete, commandComplete, emptyQueryResponse, rowDescription, noData, parameterDescription:
+		default:
+			return nil, UnexpectedMessageError{messageType}
+		}
+	}
+}
+
+// Close tells the database the session is over and closes the connection.
+func (c *conn) Close() error {
+	c.Write(message(terminateMessage, nil))
+	return c.Conn.Close()
+}
+
+func (c *conn) send(messageType byte, body []byte) error {
+	_, err := c.Write(message(messageType, body))
+	return err
+}
+
+// receive returns the next message of the database, skipping its notices and notifications.
+func (c *conn) receive() (byte, []byte, error) {
+	for {
+		header := make([]byte, 5)
+		_, err := io.ReadFull(c.reader, header)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		length := binary.BigEndian.Uint32(header[1:])
+		if length < 4 || length > maxMessage {
+			return 0, nil, UnexpectedMessageError{header[0]}
+		}
+
+		body := make([]byte, length-4)
+		_, err = io.ReadFull(c.reader, body)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if header[0] == noticeResponse || header[0] == notificationResponse {
+			continue
+		}
+		return header[0], body, nil
+	}
+}
+
+func message(messageType byte, body []byte) []byte {
+	m := &encoder{buf: []byte{messageType}}
+	m.int32(int32(len(body) + 4))
+	m.buf = append(m.buf, body...)
+	return m.buf
+}
+
+// parseError returns the Error of an ErrorResponse.
+func parseError(body []byte) Error {
+	e := Error{}
+	fields := &decoder{data: body}
+	for {
+		code := fields.next(1)
+		if len(code) == 0 || code[0] == 0 {
+			return e
+		}
+
+		value := fields.string()
+		switch code[0] {
+		case 'S':
+			e.Severity = value
+		case 'C':
+			e.Code = value
+		case 'M':
+			e.Message = value
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) int16(v int16) {
+	e.buf = append(e.buf, byte(uint16(v)>>8), byte(v))
+}
+
+func (e *encoder) int32(v int32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(v))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) string(s string) {
+	e.buf = append(e.buf, s...)
+	e.buf = append(e.buf, 0)
+}
+
+// decoder reads the types of the protocol. Once the data runs out every read returns zero.
+type decoder struct {
+	data []byte
+}
+
+func (d *decoder) next(n int) []byte {
+	if n < 0 || n > len(d.data) {
+		d.data = nil
+		return nil
+	}
+
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) int16() int16 {
+	b := d.next(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (d *decoder) int32() int32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (d *decoder) string() string {
+	for i, b := range d.data {
+		if b == 0 {
+			s := string(d.data[:i])
+			d.data = d.data[i+1:]
+			return s
+		}
+	}
+	d.data = nil
+	return ""
+}
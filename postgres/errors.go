@@ -0,0 +1,82 @@
+package postgres
+
+import "fmt"
+
+type InvalidURLError struct {
+	Reason string
+}
+
+func (e InvalidURLError) Error() string {
+	return fmt.Sprintf("invalid database url: %s", e.Reason)
+}
+
+type ConnectError struct {
+	Address string
+	Err     error
+}
+
+func (e ConnectError) Error() string {
+	return fmt.Sprintf("cannot connect to the database at %s: %s", e.Address, e.Err)
+}
+
+type SSLNotSupportedError struct {
+	Address string
+}
+
+func (e SSLNotSupportedError) Error() string {
+	return fmt.Sprintf("the database at %s does not support ssl: set sslmode=disable to connect without it", e.Address)
+}
+
+type UnsupportedAuthenticationError struct {
+	Method string
+}
+
+func (e UnsupportedAuthenticationError) Error() string {
+	return fmt.Sprintf("unsupported authentication method %s", e.Method)
+}
+
+// SendError is returned when a statement could not be sent to the database, so it did not run.
+type SendError struct {
+	Err error
+}
+
+func (e SendError) Error() string {
+	return fmt.Sprintf("cannot send the statement to the database: %s", e.Err)
+}
+
+type UnexpectedMessageError struct {
+	Type byte
+}
+
+func (e UnexpectedMessageError) Error() string {
+	return fmt.Sprintf("unexpected message %q from the database", e.Type)
+}
+
+type UnsupportedArgumentError struct {
+	Position int
+	Arg      interface{}
+}
+
+func (e UnsupportedArgumentError) Error() string {
+	return fmt.Sprintf("unsupported argument $%d of type %T", e.Position, e.Arg)
+}
+
+type InvalidValueError struct {
+	Type  string
+	Value string
+}
+
+func (e InvalidValueError) Error() string {
+	return fmt.Sprintf("invalid %s %q", e.Type, e.Value)
+}
+
+// Error is an error returned by the database, such as a syntax error or a violated constraint.
+type Error struct {
+	Severity string
+	Code     string
+	Message  string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s (SQLSTATE %s)", e.Severity, e.Message, e.Code)
+}
@@ -0,0 +1,226 @@
+// Package progress keeps track of how far every deployment has got and streams it to subscribers.
+package progress
+
+import (
+	"sync"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DefaultMaxFinished is how many finished deployments are kept so their final progress can still be read.
+const DefaultMaxFinished = 1000
+
+// SubscriberBuffer is how many updates a subscriber can fall behind. Only the latest update is kept
+// when it falls further behind, since every update holds the whole progress.
+const SubscriberBuffer = 16
+
+// span is the range of percentages a phase covers.
+type span struct {
+	start, end int
+}
+
+var spans = map[string]span{
+	S.PhasePrechecking: {0, 5},
+	S.PhasePreparing:   {5, 30},
+	S.PhaseLoggingIn:   {30, 40},
+	S.PhaseExecuting:   {40, 80},
+	S.PhaseVerifying:   {80, 85},
+	S.PhaseBaking:      {85, 85},
+	S.PhasePromoting:   {85, 85},
+	S.PhaseSucceeding:  {85, 100},
+	S.PhaseRollingBack: {80, 100},
+	S.PhaseFinished:    {100, 100},
+}
+
+// NewTracker returns an empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		MaxFinished: DefaultMaxFinished,
+		Now:         time.Now,
+		deployments: map[string]*S.DeploymentProgress{},
+		subscribers: map[string][]chan S.DeploymentProgress{},
+	}
+}
+
+// Tracker keeps the progress of the running deployments and of the last MaxFinished finished ones.
+type Tracker struct {
+	MaxFinished int
+	Now         func() time.Time
+
+	mu          sync.Mutex
+	deployments map[string]*S.DeploymentProgress
+	subscribers map[string][]chan S.DeploymentProgress
+	finished    []string
+}
+
+// StartPhase moves the deployment to the phase, which is run against the number of foundations.
+func (t *Tracker) StartPhase(uuid, phase string, foundations int) {
+	t.update(uuid, func(p *S.DeploymentProgress) {
+		p.Phase = phase
+		p.Foundations = foundations
+		p.FoundationsDone = 0
+	})
+}
+
+// FinishFoundation records that one more foundation finished the current phase.
+func (t *Tracker) FinishFoundation(uuid string) {
+	t.update(uuid, func(p *S.DeploymentProgress) {
+		if p.FoundationsDone < p.Foundations {
+			p.FoundationsDone++
+		}
+	})
+}
+
+// FailFoundation records that the deployment failed against the foundation.
+func (t *Tracker) FailFoundation(uuid, foundationURL string) {
+	t.update(uuid, func(p *S.DeploymentProgress) {
+		for _, failed := range p.FailedFoundations {
+			if failed == foundationURL {
+				return
+			}
+		}
+		p.FailedFoundations = append(p.FailedFoundations, foundationURL)
+	})
+}
+
+// Step records the step a foundation is running.
+func (t *Tracker) Step(uuid, foundationURL, step string) {
+	t.update(uuid, func(p *S.DeploymentProgress) {
+		if p.Steps == nil {
+			p.Steps = map[string]string{}
+		}
+		p.Steps[foundationURL] = step
+	})
+}
+
+// Finish records that the deployment is over and ends the subscriptions to it.
+func (t *Tracker) Finish(uuid string, err error) {
+	t.update(uuid, func(p *S.DeploymentProgress) {
+		p.Phase = S.PhaseFinished
+		p.Finished = true
+		p.Steps = nil
+		if err != nil {
+			p.Error = err.Error()
+		}
+	})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, subscriber := range t.subscribers[uuid] {
+		close(subscriber)
+	}
+	delete(t.subscribers, uuid)
+
+	t.finished = append(t.finished, uuid)
+	if len(t.finished) > t.MaxFinished {
+		for _, old := range t.finished[:len(t.finished)-t.MaxFinished] {
+			delete(t.deployments, old)
+		}
+		t.finished = append([]string{}, t.finished[len(t.finished)-t.MaxFinished:]...)
+	}
+}
+
+// Get returns the progress of the deployment.
+func (t *Tracker) Get(uuid string) (S.DeploymentProgress, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.deployments[uuid]
+	if !ok {
+		return S.DeploymentProgress{}, false
+	}
+	return copyProgress(p), true
+}
+
+// Subscribe returns a channel receiving every update of the deployment, starting with its current
+// progress if it has any. The channel is closed once the deployment is finished, straight away if it
+// already is. unsubscribe must be called once the updates are no longer read.
+func (t *Tracker) Subscribe(uuid string) (updates <-chan S.DeploymentProgress, unsubscribe func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	subscriber := make(chan S.DeploymentProgress, SubscriberBuffer)
+	if p, ok := t.deployments[uuid]; ok {
+		subscriber <- copyProgress(p)
+		if p.Finished {
+			close(subscriber)
+			return subscriber, func() {}
+		}
+	}
+	t.subscribers[uuid] = append(t.subscribers[uuid], subscriber)
+
+	return subscriber, func() { t.unsubscribe(uuid, subscriber) }
+}
+
+func (t *Tracker) unsubscribe(uuid string, subscriber chan S.DeploymentProgress) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	subscribers := t.subscribers[uuid]
+	for i, s := range subscribers {
+		if s == subscriber {
+			t.subscribers[uuid] = append(subscribers[:i:i], subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (t *Tracker) update(uuid string, change func(p *S.DeploymentProgress)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.deployments[uuid]
+	if !ok {
+		p = &S.DeploymentProgress{UUID: uuid}
+		t.deployments[uuid] = p
+	}
+	if p.Finished {
+		return
+	}
+
+	change(p)
+	p.Percent = percent(p)
+	p.UpdatedAt = t.Now().UTC()
+
+	for _, subscriber := range t.subscribers[uuid] {
+		send(subscriber, copyProgress(p))
+	}
+}
+
+// send never blocks: when the subscriber has fallen behind, its oldest update is dropped.
+func send(subscriber chan S.DeploymentProgress, p S.DeploymentProgress) {
+	for {
+		select {
+		case subscriber <- p:
+			return
+		default:
+		}
+
+		select {
+		case <-subscriber:
+		default:
+		}
+	}
+}
+
+func percent(p *S.DeploymentProgress) int {
+	s := spans[p.Phase]
+	if p.Foundations == 0 {
+		return s.start
+	}
+	return s.start + (s.end-s.start)*p.FoundationsDone/p.Foundations
+}
+
+func copyProgress(p *S.DeploymentProgress) S.DeploymentProgress {
+	c := *p
+	c.FailedFoundations = append([]string(nil), p.FailedFoundations...)
+	if p.Steps != nil {
+		c.Steps = map[string]string{}
+		for foundation, step := range p.Steps {
+			c.Steps[foundation] = step
+		}
+	}
+	return c
+}
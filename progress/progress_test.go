@@ -0,0 +1,164 @@
+package progress_test
+
+import (
+	"errors"
+
+	. "github.com/compozed/deployadactyl/progress"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracker", func() {
+	var tracker *Tracker
+
+	BeforeEach(func() {
+		tracker = NewTracker()
+	})
+
+	get := func(uuid string) S.DeploymentProgress {
+		progress, ok := tracker.Get(uuid)
+		Expect(ok).To(BeTrue())
+		return progress
+	}
+
+	It("does not know deployments that never started", func() {
+		_, ok := tracker.Get("abc")
+
+		Expect(ok).To(BeFalse())
+	})
+
+	It("reports the percentage of the phase and of the foundations that finished it", func() {
+		tracker.StartPhase("abc", S.PhasePrechecking, 4)
+		Expect(get("abc").Percent).To(Equal(0))
+
+		tracker.StartPhase("abc", S.PhasePreparing, 0)
+		Expect(get("abc").Percent).To(Equal(5))
+
+		tracker.StartPhase("abc", S.PhaseExecuting, 4)
+		tracker.FinishFoundation("abc")
+		tracker.FinishFoundation("abc")
+
+		progress := get("abc")
+		Expect(progress.Phase).To(Equal(S.PhaseExecuting))
+		Expect(progress.Foundations).To(Equal(4))
+		Expect(progress.FoundationsDone).To(Equal(2))
+		Expect(progress.Percent).To(Equal(60))
+	})
+
+	It("reports the step every foundation is running", func() {
+		tracker.StartPhase("abc", S.PhaseExecuting, 2)
+		tracker.Step("abc", "https://api1.example.com", "push")
+		tracker.Step("abc", "https://api2.example.com", "health-check")
+
+		Expect(get("abc").Steps).To(Equal(map[string]string{
+			"https://api1.example.com": "push",
+			"https://api2.example.com": "health-check",
+		}))
+	})
+
+	It("reports every foundation the deployment failed against once", func() {
+		tracker.StartPhase("abc", S.PhaseExecuting, 3)
+		tracker.FailFoundation("abc", "https://api2.example.com")
+		tracker.FailFoundation("abc", "https://api3.example.com")
+		tracker.FailFoundation("abc", "https://api2.example.com")
+		tracker.Finish("abc", errors.New("push failed"))
+
+		Expect(get("abc").FailedFoundations).To(Equal([]string{"https://api2.example.com", "https://api3.example.com"}))
+	})
+
+	It("reports a finished deployment and its error", func() {
+		tracker.StartPhase("abc", S.PhaseExecuting, 1)
+		tracker.Step("abc", "https://api1.example.com", "push")
+		tracker.Finish("abc", errors.New("push failed"))
+
+		progress := get("abc")
+		Expect(progress.Phase).To(Equal(S.PhaseFinished))
+		Expect(progress.Percent).To(Equal(100))
+		Expect(progress.Finished).To(BeTrue())
+		Expect(progress.Error).To(Equal("push failed"))
+		Expect(progress.Steps).To(BeNil())
+
+		tracker.StartPhase("abc", S.PhaseExecuting, 1)
+		Expect(get("abc").Phase).To(Equal(S.PhaseFinished))
+	})
+
+	It("only keeps the most recent finished deployments", func() {
+		tracker.MaxFinished = 1
+
+		tracker.StartPhase("a", S.PhasePrechecking, 1)
+		tracker.Finish("a", nil)
+		tracker.StartPhase("b", S.PhasePrechecking, 1)
+		tracker.Finish("b", nil)
+
+		_, ok := tracker.Get("a")
+		Expect(ok).To(BeFalse())
+		Expect(get("b").Finished).To(BeTrue())
+	})
+
+	Describe("Subscribe", func() {
+		It("sends the current progress and every update until the deployment is finished", func() {
+			tracker.StartPhase("abc", S.PhasePrechecking, 1)
+
+			updates, unsubscribe := tracker.Subscribe("abc")
+			defer unsubscribe()
+
+			tracker.StartPhase("abc", S.PhaseExecuting, 1)
+			tracker.Finish("abc", nil)
+
+			var phases []string
+			for progress := range updates {
+				phases = append(phases, progress.Phase)
+			}
+			Expect(phases).To(Equal([]string{S.PhasePrechecking, S.PhaseExecuting, S.PhaseFinished}))
+		})
+
+		It("waits for a deployment that has not started", func() {
+			updates, unsubscribe := tracker.Subscribe("abc")
+			defer unsubscribe()
+
+			Consistently(updates).ShouldNot(Receive())
+
+			tracker.StartPhase("abc", S.PhasePrechecking, 1)
+
+			Eventually(updates).Should(Receive())
+		})
+
+		It("sends the final progress of a finished deployment and closes", func() {
+			tracker.StartPhase("abc", S.PhasePrechecking, 1)
+			tracker.Finish("abc", nil)
+
+			updates, unsubscribe := tracker.Subscribe("abc")
+			defer unsubscribe()
+
+			Expect(<-updates).To(Equal(get("abc")))
+			Eventually(updates).Should(BeClosed())
+		})
+
+		It("keeps the latest updates of a subscriber that falls behind", func() {
+			updates, unsubscribe := tracker.Subscribe("abc")
+			defer unsubscribe()
+
+			tracker.StartPhase("abc", S.PhaseExecuting, SubscriberBuffer+10)
+			for i := 0; i < SubscriberBuffer+10; i++ {
+				tracker.FinishFoundation("abc")
+			}
+
+			var last S.DeploymentProgress
+			for i := 0; i < SubscriberBuffer; i++ {
+				last = <-updates
+			}
+			Expect(last.FoundationsDone).To(Equal(SubscriberBuffer + 10))
+			Expect(updates).ToNot(Receive())
+		})
+
+		It("stops sending updates once unsubscribed", func() {
+			updates, unsubscribe := tracker.Subscribe("abc")
+			unsubscribe()
+
+			tracker.StartPhase("abc", S.PhasePrechecking, 1)
+
+			Expect(updates).ToNot(Receive())
+		})
+	})
+})
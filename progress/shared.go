@@ -0,0 +1,192 @@
+package progress
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// SharedTTL is how long the progress of a deployment is kept in the shared state after its last update.
+const SharedTTL = 24 * time.Hour
+
+// SharedPollInterval is how often the progress of a deployment running on another instance is read from the shared
+// state while it is streamed, in case an update was published while the subscription of this instance was lost.
+const SharedPollInterval = 5 * time.Second
+
+// NewShared returns a Shared sharing the progress of the tracker.
+func NewShared(tracker *Tracker, state I.SharedState, log I.Logger) *Shared {
+	return &Shared{
+		Tracker:      tracker,
+		State:        state,
+		TTL:          SharedTTL,
+		PollInterval: SharedPollInterval,
+		Log:          log,
+	}
+}
+
+// Shared keeps the progress of the deployments of this instance in a Tracker, and shares every update of it with
+// the other instances. The progress of a deployment running on another instance is read from the shared state.
+type Shared struct {
+	Tracker      *Tracker
+	State        I.SharedState
+	TTL          time.Duration
+	PollInterval time.Duration
+	Log          I.Logger
+
+	mu sync.Mutex
+}
+
+// StartPhase moves the deployment to the phase, which is run against the number of foundations.
+func (s *Shared) StartPhase(uuid, phase string, foundations int) {
+	s.update(uuid, func() { s.Tracker.StartPhase(uuid, phase, foundations) })
+}
+
+// FinishFoundation records that one more foundation finished the current phase.
+func (s *Shared) FinishFoundation(uuid string) {
+	s.update(uuid, func() { s.Tracker.FinishFoundation(uuid) })
+}
+
+// FailFoundation records that the deployment failed against the foundation.
+func (s *Shared) FailFoundation(uuid, foundationURL string) {
+	s.update(uuid, func() { s.Tracker.FailFoundation(uuid, foundationURL) })
+}
+
+// Step records the step a foundation is running.
+func (s *Shared) Step(uuid, foundationURL, step string) {
+	s.update(uuid, func() { s.Tracker.Step(uuid, foundationURL, step) })
+}
+
+// Finish records that the deployment is over and ends the subscriptions to it.
+func (s *Shared) Finish(uuid string, err error) {
+	s.update(uuid, func() { s.Tracker.Finish(uuid, err) })
+}
+
+// Get returns the progress of the deployment, from the shared state when it does not run on this instance.
+func (s *Shared) Get(uuid string) (S.DeploymentProgress, bool) {
+	if p, ok := s.Tracker.Get(uuid); ok {
+		return p, true
+	}
+	return s.stored(uuid)
+}
+
+// Subscribe returns a channel receiving every update of the deployment, starting with its current progress if it
+// has any, wherever it runs. The channel is closed once the deployment is finished, straight away if it already
+// is. unsubscribe must be called once the updates are no longer read.
+func (s *Shared) Subscribe(uuid string) (<-chan S.DeploymentProgress, func()) {
+	if _, ok := s.Tracker.Get(uuid); ok {
+		return s.Tracker.Subscribe(uuid)
+	}
+
+	messages, unsubscribeShared := s.State.Subscribe(key(uuid))
+	updates := make(chan S.DeploymentProgress, SubscriberBuffer)
+	done := make(chan struct{})
+	go s.forward(uuid, messages, updates, done)
+
+	var once sync.Once
+	return updates, func() {
+		once.Do(func() {
+			close(done)
+			unsubscribeShared()
+		})
+	}
+}
+
+// update runs the change and shares the progress it led to. The updates are shared one at a time, so the progress
+// in the shared state is never older than the one published last.
+func (s *Shared) update(uuid string, change func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change()
+
+	p, ok := s.Tracker.Get(uuid)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		s.Log.Errorf("cannot share the progress of deployment %s: %s", uuid, err)
+		return
+	}
+
+	err = s.State.Put(key(uuid), data, s.TTL)
+	if err == nil {
+		err = s.State.Publish(key(uuid), data)
+	}
+	if err != nil {
+		s.Log.Errorf("cannot share the progress of deployment %s: %s", uuid, err)
+	}
+}
+
+// stored returns the progress of the deployment kept in the shared state.
+func (s *Shared) stored(uuid string) (S.DeploymentProgress, bool) {
+	data, found, err := s.State.Get(key(uuid))
+	if err != nil {
+		s.Log.Errorf("cannot read the progress of deployment %s: %s", uuid, err)
+		return S.DeploymentProgress{}, false
+	}
+	if !found {
+		return S.DeploymentProgress{}, false
+	}
+
+	p := S.DeploymentProgress{}
+	if err := json.Unmarshal(data, &p); err != nil {
+		s.Log.Errorf("cannot read the progress of deployment %s: %s", uuid, err)
+		return S.DeploymentProgress{}, false
+	}
+	return p, true
+}
+
+// forward passes the progress published by another instance to the updates until the deployment is finished or
+// done is closed, along with the progress read from the shared state every PollInterval. An update older than
+// the last one forwarded is dropped.
+func (s *Shared) forward(uuid string, messages <-chan []byte, updates chan S.DeploymentProgress, done chan struct{}) {
+	defer close(updates)
+
+	var last time.Time
+	forwarded := func(p S.DeploymentProgress) bool {
+		if p.UpdatedAt.After(last) {
+			last = p.UpdatedAt
+			send(updates, p)
+		}
+		return p.Finished
+	}
+
+	if p, ok := s.stored(uuid); ok && forwarded(p) {
+		return
+	}
+
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			p := S.DeploymentProgress{}
+			if err := json.Unmarshal(message, &p); err != nil {
+				s.Log.Errorf("cannot read the progress of deployment %s: %s", uuid, err)
+				continue
+			}
+			if forwarded(p) {
+				return
+			}
+		case <-ticker.C:
+			if p, ok := s.stored(uuid); ok && forwarded(p) {
+				return
+			}
+		}
+	}
+}
+
+// key is the key and the channel of the progress of the deployment in the shared state.
+func key(uuid string) string {
+	return "progress:" + uuid
+}
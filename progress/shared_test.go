@@ -0,0 +1,105 @@
+package progress_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/progress"
+	"github.com/compozed/deployadactyl/sharedstate"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Shared", func() {
+	var (
+		state   *sharedstate.Memory
+		running *Shared
+		other   *Shared
+	)
+
+	BeforeEach(func() {
+		state = sharedstate.NewMemory()
+		log := I.DefaultLogger(NewBuffer(), logging.DEBUG, "progress_test")
+		running = NewShared(NewTracker(), state, log)
+		other = NewShared(NewTracker(), state, log)
+	})
+
+	It("returns the progress of a deployment running on another instance", func() {
+		running.StartPhase("abc", S.PhaseExecuting, 2)
+		running.FinishFoundation("abc")
+
+		progress, ok := other.Get("abc")
+
+		Expect(ok).To(BeTrue())
+		Expect(progress.Phase).To(Equal(S.PhaseExecuting))
+		Expect(progress.Percent).To(Equal(60))
+	})
+
+	It("does not know deployments that never started on any instance", func() {
+		_, ok := other.Get("abc")
+
+		Expect(ok).To(BeFalse())
+	})
+
+	It("streams the progress of a deployment running on another instance until it finishes", func() {
+		running.StartPhase("abc", S.PhasePreparing, 0)
+
+		updates, unsubscribe := other.Subscribe("abc")
+		defer unsubscribe()
+
+		Eventually(updates).Should(Receive(WithTransform(phase, Equal(S.PhasePreparing))))
+
+		running.StartPhase("abc", S.PhaseExecuting, 1)
+		Eventually(updates).Should(Receive(WithTransform(phase, Equal(S.PhaseExecuting))))
+
+		running.Finish("abc", errors.New("push failed"))
+		Eventually(updates).Should(Receive(WithTransform(phase, Equal(S.PhaseFinished))))
+		Eventually(updates).Should(BeClosed())
+	})
+
+	It("waits for a deployment that has not started yet", func() {
+		updates, unsubscribe := other.Subscribe("abc")
+		defer unsubscribe()
+
+		running.StartPhase("abc", S.PhasePrechecking, 1)
+
+		Eventually(updates).Should(Receive(WithTransform(phase, Equal(S.PhasePrechecking))))
+	})
+
+	It("reads the progress from the shared state when an update is not received", func() {
+		other.PollInterval = 10 * time.Millisecond
+		running.StartPhase("abc", S.PhaseExecuting, 1)
+
+		updates, unsubscribe := other.Subscribe("abc")
+		defer unsubscribe()
+		Eventually(updates).Should(Receive())
+
+		finished, err := json.Marshal(S.DeploymentProgress{UUID: "abc", Phase: S.PhaseFinished, Finished: true, UpdatedAt: time.Now().Add(time.Minute)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state.Put("progress:abc", finished, time.Minute)).To(Succeed())
+
+		Eventually(updates).Should(Receive(WithTransform(phase, Equal(S.PhaseFinished))))
+		Eventually(updates).Should(BeClosed())
+	})
+
+	It("streams the progress of a deployment running on this instance from its tracker", func() {
+		running.StartPhase("abc", S.PhaseExecuting, 1)
+
+		updates, unsubscribe := running.Subscribe("abc")
+		defer unsubscribe()
+		Expect(updates).To(Receive(WithTransform(phase, Equal(S.PhaseExecuting))))
+
+		running.Finish("abc", nil)
+		Expect(updates).To(Receive(WithTransform(phase, Equal(S.PhaseFinished))))
+		Expect(updates).To(BeClosed())
+	})
+})
+
+func phase(progress S.DeploymentProgress) string {
+	return progress.Phase
+}
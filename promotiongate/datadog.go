@@ -0,0 +1,82 @@
+package promotiongate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DefaultDatadogURL is the Datadog API queried when a gate does not have a URL.
+const DefaultDatadogURL = "https://api.datadoghq.com"
+
+// DefaultDatadogWindow is the period a Datadog query is run over when a gate does not have a window.
+const DefaultDatadogWindow = 5 * time.Minute
+
+type datadogResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Series []struct {
+		Pointlist [][]*float64 `json:"pointlist"`
+	} `json:"series"`
+}
+
+// queryDatadog runs a timeseries query over the window of the gate and returns the last value of every series.
+func (g *Gate) queryDatadog(ctx context.Context, descriptor S.PromotionGateDescriptor, query string) ([]float64, error) {
+	baseURL := descriptor.URL
+	if baseURL == "" {
+		baseURL = DefaultDatadogURL
+	}
+
+	window := DefaultDatadogWindow
+	if descriptor.WindowSeconds > 0 {
+		window = time.Duration(descriptor.WindowSeconds) * time.Second
+	}
+
+	apiKeyEnv := descriptor.APIKeyEnv
+	if apiKeyEnv == "" {
+		apiKeyEnv = "DD_API_KEY"
+	}
+	appKeyEnv := descriptor.AppKeyEnv
+	if appKeyEnv == "" {
+		appKeyEnv = "DD_APP_KEY"
+	}
+
+	now := g.Now()
+	values := url.Values{
+		"from":  {strconv.FormatInt(now.Add(-window).Unix(), 10)},
+		"to":    {strconv.FormatInt(now.Unix(), 10)},
+		"query": {query},
+	}
+	queryURL := fmt.Sprintf("%s/api/v1/query?%s", strings.TrimSuffix(baseURL, "/"), values.Encode())
+	headers := map[string]string{
+		"DD-API-KEY":         g.Getenv(apiKeyEnv),
+		"DD-APPLICATION-KEY": g.Getenv(appKeyEnv),
+	}
+
+	var response datadogResponse
+	err := g.get(ctx, queryURL, headers, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status == "error" {
+		return nil, ProviderError{Provider: "datadog", Message: response.Error}
+	}
+
+	last := []float64{}
+	for _, series := range response.Series {
+		for i := len(series.Pointlist) - 1; i >= 0; i-- {
+			point := series.Pointlist[i]
+			if len(point) == 2 && point[1] != nil {
+				last = append(last, *point[1])
+				break
+			}
+		}
+	}
+
+	return last, nil
+}
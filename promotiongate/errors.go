@@ -0,0 +1,38 @@
+package promotiongate
+
+import "fmt"
+
+type UnknownProviderError struct {
+	Provider string
+}
+
+func (e UnknownProviderError) Error() string {
+	return fmt.Sprintf("unknown promotion gate provider: %s: must be prometheus or datadog", e.Provider)
+}
+
+type QueryError struct {
+	Name string
+	Err  error
+}
+
+func (e QueryError) Error() string {
+	return fmt.Sprintf("cannot query promotion gate %s: %s", e.Name, e.Err)
+}
+
+type StatusError struct {
+	Status string
+	Body   []byte
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("unexpected response %s: %s", e.Status, string(e.Body))
+}
+
+type ProviderError struct {
+	Provider string
+	Message  string
+}
+
+func (e ProviderError) Error() string {
+	return fmt.Sprintf("%s returned an error: %s", e.Provider, e.Message)
+}
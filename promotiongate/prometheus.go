@@ -0,0 +1,73 @@
+package promotiongate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type prometheusSample struct {
+	Value []interface{} `json:"value"`
+}
+
+// queryPrometheus runs an instant query and returns the value of every sample.
+func (g *Gate) queryPrometheus(ctx context.Context, descriptor S.PromotionGateDescriptor, query string) ([]float64, error) {
+	queryURL := fmt.Sprintf("%s/api/v1/query?%s", strings.TrimSuffix(descriptor.URL, "/"), url.Values{"query": {query}}.Encode())
+
+	var response prometheusResponse
+	err := g.get(ctx, queryURL, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status != "success" {
+		return nil, ProviderError{Provider: "prometheus", Message: response.Error}
+	}
+
+	var samples []prometheusSample
+	switch response.Data.ResultType {
+	case "vector":
+		err = json.Unmarshal(response.Data.Result, &samples)
+	case "scalar":
+		sample := prometheusSample{}
+		err = json.Unmarshal(response.Data.Result, &sample.Value)
+		samples = append(samples, sample)
+	default:
+		return nil, ProviderError{Provider: "prometheus", Message: fmt.Sprintf("unsupported result type %s", response.Data.ResultType)}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := []float64{}
+	for _, sample := range samples {
+		if len(sample.Value) != 2 {
+			continue
+		}
+		text, ok := sample.Value[1].(string)
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsNaN(value) {
+			continue
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
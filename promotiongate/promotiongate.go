@@ -0,0 +1,120 @@
+// Package promotiongate queries metrics sources for the health of a new build before it is promoted.
+package promotiongate
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// AppPlaceholder is replaced by the name of the new build in the query of a gate.
+const AppPlaceholder = "{{app}}"
+
+// DefaultTimeout is how long a query to a metrics source may take.
+const DefaultTimeout = 30 * time.Second
+
+type GateConstructor func(log I.DeploymentLogger) I.PromotionGate
+
+func NewGate(log I.DeploymentLogger) I.PromotionGate {
+	return &Gate{
+		Log:    log,
+		Client: &http.Client{Timeout: DefaultTimeout},
+		Getenv: os.Getenv,
+		Now:    time.Now,
+	}
+}
+
+// Gate runs the query of a promotion gate against Prometheus or Datadog. When the query returns
+// several series, the highest value is compared to the threshold of the gate.
+type Gate struct {
+	Log    I.DeploymentLogger
+	Client *http.Client
+	Getenv func(string) string
+	Now    func() time.Time
+}
+
+// Evaluate waits for the delay of the gate and runs its query for appName. A breached threshold is
+// reported in the result, not as an error.
+func (g *Gate) Evaluate(ctx context.Context, descriptor S.PromotionGateDescriptor, appName string) (S.PromotionGateResult, error) {
+	query := strings.Replace(descriptor.Query, AppPlaceholder, appName, -1)
+	result := S.PromotionGateResult{Name: descriptor.Name, Query: query, Max: descriptor.Max}
+
+	if descriptor.DelaySeconds > 0 {
+		delay := time.Duration(descriptor.DelaySeconds) * time.Second
+		g.Log.Infof("waiting %s before checking promotion gate %s", delay, descriptor.Name)
+
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	g.Log.Debugf("checking promotion gate %s with %s query %s", descriptor.Name, descriptor.Provider, query)
+
+	var (
+		values []float64
+		err    error
+	)
+	switch strings.ToLower(descriptor.Provider) {
+	case "prometheus":
+		values, err = g.queryPrometheus(ctx, descriptor, query)
+	case "datadog":
+		values, err = g.queryDatadog(ctx, descriptor, query)
+	default:
+		return result, UnknownProviderError{Provider: descriptor.Provider}
+	}
+	if err != nil {
+		return result, QueryError{Name: descriptor.Name, Err: err}
+	}
+
+	if len(values) == 0 {
+		result.NoData = true
+		g.Log.Infof("promotion gate %s returned no data", descriptor.Name)
+		return result, nil
+	}
+
+	result.Value = math.Inf(-1)
+	for _, value := range values {
+		result.Value = math.Max(result.Value, value)
+	}
+
+	g.Log.Infof("promotion gate %s measured %g with a maximum of %g", descriptor.Name, result.Value, descriptor.Max)
+	return result, nil
+}
+
+// get sends a GET request with headers to the metrics source and decodes the JSON response into v.
+func (g *Gate) get(ctx context.Context, url string, headers map[string]string, v interface{}) error {
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	request = request.WithContext(ctx)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := g.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return StatusError{Status: response.Status, Body: body}
+	}
+
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,13 @@
+package promotiongate_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPromotionGate(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Promotion Gate Suite")
+}
@@ -0,0 +1,173 @@
+package promotiongate_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/promotiongate"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/op/go-logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Gate", func() {
+	var (
+		server     *httptest.Server
+		request    *http.Request
+		status     int
+		body       string
+		gate       *Gate
+		now        time.Time
+		appName    string
+		log        I.DeploymentLogger
+		env        map[string]string
+		descriptor S.PromotionGateDescriptor
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		body = ""
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request = r
+			w.WriteHeader(status)
+			fmt.Fprint(w, body)
+		}))
+
+		now = time.Unix(1500000000, 0)
+		appName = "app-new-build-uuid"
+		env = map[string]string{}
+		log = I.DeploymentLogger{Log: I.DefaultLogger(NewBuffer(), logging.DEBUG, "promotiongate_test")}
+
+		gate = &Gate{
+			Log:    log,
+			Client: &http.Client{},
+			Getenv: func(name string) string { return env[name] },
+			Now:    func() time.Time { return now },
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("with a prometheus gate", func() {
+		BeforeEach(func() {
+			descriptor = S.PromotionGateDescriptor{
+				Name:     "error-rate",
+				Provider: "prometheus",
+				URL:      server.URL,
+				Query:    `rate(errors{app="{{app}}"}[1m])`,
+				Max:      0.05,
+			}
+		})
+
+		It("returns the highest value of the vector", func() {
+			body = `{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"instance":"0"},"value":[1500000000,"0.01"]},
+				{"metric":{"instance":"1"},"value":[1500000000,"0.2"]}]}}`
+
+			result, err := gate.Evaluate(context.Background(), descriptor, appName)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.URL.Path).To(Equal("/api/v1/query"))
+			Expect(request.URL.Query().Get("query")).To(Equal(`rate(errors{app="app-new-build-uuid"}[1m])`))
+			Expect(result).To(Equal(S.PromotionGateResult{Name: "error-rate", Query: `rate(errors{app="app-new-build-uuid"}[1m])`, Value: 0.2, Max: 0.05}))
+			Expect(descriptor.Breached(result)).To(BeTrue())
+		})
+
+		It("returns the value of a scalar", func() {
+			body = `{"status":"success","data":{"resultType":"scalar","result":[1500000000,"0.01"]}}`
+
+			result, err := gate.Evaluate(context.Background(), descriptor, appName)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Value).To(Equal(0.01))
+			Expect(descriptor.Breached(result)).To(BeFalse())
+		})
+
+		It("reports when the query returns no data", func() {
+			body = `{"status":"success","data":{"resultType":"vector","result":[]}}`
+
+			result, err := gate.Evaluate(context.Background(), descriptor, appName)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.NoData).To(BeTrue())
+			Expect(descriptor.Breached(result)).To(BeTrue())
+
+			descriptor.AllowNoData = true
+			Expect(descriptor.Breached(result)).To(BeFalse())
+		})
+
+		It("returns an error when prometheus does", func() {
+			status = http.StatusBadRequest
+			body = `{"status":"error","error":"parse error"}`
+
+			_, err := gate.Evaluate(context.Background(), descriptor, appName)
+
+			Expect(err).To(MatchError(QueryError{Name: "error-rate", Err: StatusError{Status: "400 Bad Request", Body: []byte(body)}}))
+		})
+	})
+
+	Context("with a datadog gate", func() {
+		BeforeEach(func() {
+			env["DD_API_KEY"] = "api-key"
+			env["DD_APP_KEY"] = "app-key"
+			descriptor = S.PromotionGateDescriptor{
+				Name:          "latency",
+				Provider:      "datadog",
+				URL:           server.URL,
+				Query:         "avg:trace.http.request.duration{service:{{app}}}",
+				Max:           0.5,
+				WindowSeconds: 60,
+			}
+		})
+
+		It("returns the highest of the last values of the series", func() {
+			body = `{"status":"ok","series":[
+				{"pointlist":[[1499999940000,0.9],[1500000000000,0.3]]},
+				{"pointlist":[[1499999940000,0.4],[1500000000000,null]]}]}`
+
+			result, err := gate.Evaluate(context.Background(), descriptor, appName)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Value).To(Equal(0.4))
+			Expect(descriptor.Breached(result)).To(BeFalse())
+
+			Expect(request.URL.Query().Get("query")).To(Equal("avg:trace.http.request.duration{service:app-new-build-uuid}"))
+			Expect(request.URL.Query().Get("from")).To(Equal("1499999940"))
+			Expect(request.URL.Query().Get("to")).To(Equal("1500000000"))
+			Expect(request.Header.Get("DD-API-KEY")).To(Equal("api-key"))
+			Expect(request.Header.Get("DD-APPLICATION-KEY")).To(Equal("app-key"))
+		})
+
+		It("returns an error when datadog does", func() {
+			body = `{"status":"error","error":"bad query"}`
+
+			_, err := gate.Evaluate(context.Background(), descriptor, appName)
+
+			Expect(err).To(MatchError(QueryError{Name: "latency", Err: ProviderError{Provider: "datadog", Message: "bad query"}}))
+		})
+	})
+
+	It("returns an error for an unknown provider", func() {
+		_, err := gate.Evaluate(context.Background(), S.PromotionGateDescriptor{Provider: "graphite"}, appName)
+
+		Expect(err).To(MatchError(UnknownProviderError{Provider: "graphite"}))
+	})
+
+	It("stops waiting for the delay when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := gate.Evaluate(ctx, S.PromotionGateDescriptor{Provider: "prometheus", DelaySeconds: 60}, appName)
+
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
@@ -0,0 +1,476 @@
+package publisher
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+)
+
+// The retries of an AMQP message that cannot be published. The delay doubles after every retry.
+const (
+	AMQPRetries    = 3
+	AMQPRetryDelay = time.Second
+)
+
+// The frames of AMQP 0-9-1.
+const (
+	amqpMethodFrame    = 1
+	amqpHeaderFrame    = 2
+	amqpBodyFrame      = 3
+	amqpHeartbeatFrame = 8
+	amqpFrameEnd       = 0xce
+)
+
+// The methods of AMQP 0-9-1 used by the producer, as their class and method ids.
+var (
+	amqpConnectionStart   = [2]uint16{10, 10}
+	amqpConnectionStartOk = [2]uint16{10, 11}
+	amqpConnectionTune    = [2]uint16{10, 30}
+	amqpConnectionTuneOk  = [2]uint16{10, 31}
+	amqpConnectionOpen    = [2]uint16{10, 40}
+	amqpConnectionOpenOk  = [2]uint16{10, 41}
+	amqpConnectionClose   = [2]uint16{10, 50}
+	amqpConnectionCloseOk = [2]uint16{10, 51}
+	amqpChannelOpen       = [2]uint16{20, 10}
+	amqpChannelOpenOk     = [2]uint16{20, 11}
+	amqpChannelClose      = [2]uint16{20, 40}
+	amqpChannelCloseOk    = [2]uint16{20, 41}
+	amqpBasicPublish      = [2]uint16{60, 40}
+	amqpBasicAck          = [2]uint16{60, 80}
+	amqpBasicNack         = [2]uint16{60, 120}
+	amqpConfirmSelect     = [2]uint16{85, 10}
+	amqpConfirmSelectOk   = [2]uint16{85, 11}
+)
+
+// amqpBasic is the class of the content of a published message.
+const amqpBasic = 60
+
+// amqpChannel is the only channel the producer opens.
+const amqpChannel = 1
+
+// amqpMaxFrame is the largest frame read from a broker.
+const amqpMaxFrame = 16 << 20
+
+// amqpPersistent is the delivery mode of the messages that survive a restart of the broker.
+const amqpPersistent = 2
+
+// NewAMQPProducer returns an AMQPProducer of the exchange on the first of the servers that accepts the connection.
+func NewAMQPProducer(servers []string, exchange string, timeout time.Duration) *AMQPProducer {
+	return &AMQPProducer{
+		Servers:    servers,
+		Exchange:   exchange,
+		VHost:      "/",
+		Timeout:    timeout,
+		Retries:    AMQPRetries,
+		RetryDelay: AMQPRetryDelay,
+		Sleep:      time.Sleep,
+	}
+}
+
+// AMQPProducer publishes persistent messages to an exchange of an AMQP 0-9-1 broker, such as RabbitMQ, the key of a
+// message being its routing key. The channel is in confirm mode, so a message is only published once the broker
+// acknowledged it. A message that cannot be published is published again on a new connection, up to Retries times,
+// waiting RetryDelay before the first retry and twice as long before every other.
+type AMQPProducer struct {
+	Servers    []string
+	Exchange   string
+	VHost      string
+	Username   string
+	Password   string
+	TLSConfig  *tls.Config
+	Timeout    time.Duration
+	Retries    int
+	RetryDelay time.Duration
+	Sleep      func(time.Duration)
+
+	conn        net.Conn
+	reader      *bufio.Reader
+	frameMax    uint32
+	deliveryTag uint64
+}
+
+// Produce publishes the message with the routing key.
+func (a *AMQPProducer) Produce(key string, message []byte) error {
+	delay := a.RetryDelay
+
+	err := a.produce(key, message)
+	for retry := 0; err != nil && retry < a.Retries; retry++ {
+		a.close()
+		a.Sleep(delay)
+		delay *= 2
+
+		err = a.produce(key, message)
+	}
+	if err != nil {
+		a.close()
+	}
+	return err
+}
+
+func (a *AMQPProducer) produce(key string, message []byte) error {
+	if a.conn == nil {
+		err := a.connect()
+		if err != nil {
+			return err
+		}
+	}
+
+	a.conn.SetDeadline(time.Now().Add(a.Timeout))
+
+	publish := &amqpEncoder{}
+	publish.uint16(0)
+	publish.shortString(a.Exchange)
+	publish.shortString(key)
+	publish.uint8(0)
+	frames := amqpMethod(amqpChannel, amqpBasicPublish, publish.buf)
+
+	header := &amqpEncoder{}
+	header.uint16(amqpBasic)
+	header.uint16(0)
+	header.uint64(uint64(len(message)))
+	header.uint16(0x8000 | 0x1000)
+	header.shortString("application/json")
+	header.uint8(amqpPersistent)
+	frames = append(frames, amqpFrame(amqpHeaderFrame, amqpChannel, header.buf)...)
+
+	size := int(a.frameMax) - 8
+	for len(message) > 0 {
+		body := message
+		if size > 0 && len(body) > size {
+			body = body[:size]
+		}
+		frames = append(frames, amqpFrame(amqpBodyFrame, amqpChannel, body)...)
+		message = message[len(body):]
+	}
+
+	_, err := a.conn.Write(frames)
+	if err != nil {
+		return err
+	}
+	a.deliveryTag++
+
+	for {
+		method, args, err := a.readMethod()
+		if err != nil {
+			return err
+		}
+
+		switch method {
+		case amqpBasicAck, amqpBasicNack:
+			tag := (&amqpDecoder{data: args}).uint64()
+			if tag != a.deliveryTag {
+				continue
+			}
+			if method == amqpBasicNack {
+				return NackError{a.Exchange, key}
+			}
+			return nil
+		default:
+			return MalformedResponseError{"basic.publish"}
+		}
+	}
+}
+
+// connect connects to the first of the servers that accepts the connection.
+func (a *AMQPProducer) connect() error {
+	var lastErr error
+	for _, server := range a.Servers {
+		err := a.connectTo(server)
+		if err == nil {
+			return nil
+		}
+		a.close()
+		lastErr = err
+	}
+	return NoServerError{a.Servers, lastErr}
+}
+
+// connectTo opens the connection, authenticating with PLAIN, and a channel in confirm mode.
+func (a *AMQPProducer) connectTo(address string) error {
+	conn, err := net.DialTimeout("tcp", address, a.Timeout)
+	if err != nil {
+		return ConnectError{address, err}
+	}
+	a.conn = conn
+
+	if a.TLSConfig != nil {
+		config := a.TLSConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(address)
+		}
+		a.conn = tls.Client(conn, config)
+	}
+
+	a.conn.SetDeadline(time.Now().Add(a.Timeout))
+	a.reader = bufio.NewReader(a.conn)
+	a.frameMax = 0
+	a.deliveryTag = 0
+
+	err = a.handshake()
+	if err != nil {
+		return ConnectError{address, err}
+	}
+	return nil
+}
+
+func (a *AMQPProducer) handshake() error {
+	_, err := a.conn.Write([]byte("AMQP\x00\x00\x09\x01"))
+	if err != nil {
+		return err
+	}
+
+	_, err = a.expect(amqpConnectionStart)
+	if err != nil {
+		return err
+	}
+
+	startOk := &amqpEncoder{}
+	startOk.table(map[string]string{"product": "deployadactyl", "platform": "Go"})
+	startOk.shortString("PLAIN")
+	startOk.longString("\x00" + a.Username + "\x00" + a.Password)
+	startOk.shortString("en_US")
+	err = a.write(0, amqpConnectionStartOk, startOk.buf)
+	if err != nil {
+		return err
+	}
+
+	args, err := a.expect(amqpConnectionTune)
+	if err != nil {
+		return err
+	}
+	tune := &amqpDecoder{data: args}
+	channelMax := tune.uint16()
+	a.frameMax = tune.uint32()
+
+	tuneOk := &amqpEncoder{}
+	tuneOk.uint16(channelMax)
+	tuneOk.uint32(a.frameMax)
+	tuneOk.uint16(0)
+	err = a.write(0, amqpConnectionTuneOk, tuneOk.buf)
+	if err != nil {
+		return err
+	}
+
+	open := &amqpEncoder{}
+	open.shortString(a.VHost)
+	open.shortString("")
+	open.uint8(0)
+	err = a.write(0, amqpConnectionOpen, open.buf)
+	if err != nil {
+		return err
+	}
+	_, err = a.expect(amqpConnectionOpenOk)
+	if err != nil {
+		return err
+	}
+
+	channelOpen := &amqpEncoder{}
+	channelOpen.shortString("")
+	err = a.write(amqpChannel, amqpChannelOpen, channelOpen.buf)
+	if err != nil {
+		return err
+	}
+	_, err = a.expect(amqpChannelOpenOk)
+	if err != nil {
+		return err
+	}
+
+	err = a.write(amqpChannel, amqpConfirmSelect, []byte{0})
+	if err != nil {
+		return err
+	}
+	_, err = a.expect(amqpConfirmSelectOk)
+	return err
+}
+
+// expect returns the arguments of the next method, which has to be the method.
+func (a *AMQPProducer) expect(method [2]uint16) ([]byte, error) {
+	received, args, err := a.readMethod()
+	if err != nil {
+		return nil, err
+	}
+	if received != method {
+		return nil, MalformedResponseError{"connect"}
+	}
+	return args, nil
+}
+
+// readMethod returns the next method sent by the broker, skipping the heartbeats. A channel or connection closed by
+// the broker is returned as an AMQPError.
+func (a *AMQPProducer) readMethod() ([2]uint16, []byte, error) {
+	for {
+		header := make([]byte, 7)
+		_, err := io.ReadFull(a.reader, header)
+		if err != nil {
+			return [2]uint16{}, nil, err
+		}
+
+		size := binary.BigEndian.Uint32(header[3:])
+		if size > amqpMaxFrame {
+			return [2]uint16{}, nil, MalformedResponseError{"frame"}
+		}
+
+		payload := make([]byte, size+1)
+		_, err = io.ReadFull(a.reader, payload)
+		if err != nil {
+			return [2]uint16{}, nil, err
+		}
+		if payload[size] != amqpFrameEnd {
+			return [2]uint16{}, nil, MalformedResponseError{"frame"}
+		}
+
+		if header[0] == amqpHeartbeatFrame {
+			continue
+		}
+		if header[0] != amqpMethodFrame || size < 4 {
+			return [2]uint16{}, nil, MalformedResponseError{"frame"}
+		}
+
+		method := [2]uint16{binary.BigEndian.Uint16(payload), binary.BigEndian.Uint16(payload[2:])}
+		args := payload[4:size]
+
+		switch method {
+		case amqpChannelClose, amqpConnectionClose:
+			closing := &amqpDecoder{data: args}
+			code := closing.uint16()
+			text := closing.shortString()
+
+			if method == amqpChannelClose {
+				a.write(amqpChannel, amqpChannelCloseOk, nil)
+				return method, nil, AMQPError{"channel", code, text}
+			}
+			a.write(0, amqpConnectionCloseOk, nil)
+			return method, nil, AMQPError{"connection", code, text}
+		}
+		return method, args, nil
+	}
+}
+
+func (a *AMQPProducer) write(channel uint16, method [2]uint16, args []byte) error {
+	_, err := a.conn.Write(amqpMethod(channel, method, args))
+	return err
+}
+
+func (a *AMQPProducer) close() {
+	if a.conn != nil {
+		a.conn.Close()
+	}
+	a.conn = nil
+	a.reader = nil
+}
+
+func amqpMethod(channel uint16, method [2]uint16, args []byte) []byte {
+	payload := &amqpEncoder{}
+	payload.uint16(method[0])
+	payload.uint16(method[1])
+	payload.buf = append(payload.buf, args...)
+	return amqpFrame(amqpMethodFrame, channel, payload.buf)
+}
+
+func amqpFrame(frameType uint8, channel uint16, payload []byte) []byte {
+	frame := &amqpEncoder{}
+	frame.uint8(frameType)
+	frame.uint16(channel)
+	frame.uint32(uint32(len(payload)))
+	frame.buf = append(frame.buf, payload...)
+	frame.uint8(amqpFrameEnd)
+	return frame.buf
+}
+
+// amqpEncoder appends the types of AMQP 0-9-1 to a buffer.
+type amqpEncoder struct {
+	buf []byte
+}
+
+func (e *amqpEncoder) uint8(v uint8) {
+	e.buf = append(e.buf, v)
+}
+
+func (e *amqpEncoder) uint16(v uint16) {
+	e.buf = append(e.buf, byte(v>>8), byte(v))
+}
+
+func (e *amqpEncoder) uint32(v uint32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	e.buf = append(e.buf, b...)
+}
+
+func (e *amqpEncoder) uint64(v uint64) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	e.buf = append(e.buf, b...)
+}
+
+func (e *amqpEncoder) shortString(s string) {
+	e.uint8(uint8(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *amqpEncoder) longString(s string) {
+	e.uint32(uint32(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// table appends a field table of long strings.
+func (e *amqpEncoder) table(fields map[string]string) {
+	table := &amqpEncoder{}
+	for name, value := range fields {
+		table.shortString(name)
+		table.uint8('S')
+		table.longString(value)
+	}
+	e.uint32(uint32(len(table.buf)))
+	e.buf = append(e.buf, table.buf...)
+}
+
+// amqpDecoder reads the types of AMQP 0-9-1. Once the data runs out every read returns zero.
+type amqpDecoder struct {
+	data []byte
+}
+
+func (d *amqpDecoder) next(n int) []byte {
+	if n > len(d.data) {
+		d.data = nil
+		return nil
+	}
+
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *amqpDecoder) uint16() uint16 {
+	b := d.next(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (d *amqpDecoder) uint32() uint32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (d *amqpDecoder) uint64() uint64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (d *amqpDecoder) shortString() string {
+	b := d.next(1)
+	if b == nil {
+		return ""
+	}
+	return string(d.next(int(b[0])))
+}
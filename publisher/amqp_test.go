@@ -0,0 +1,267 @@
+package publisher_test
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+
+	. "github.com/compozed/deployadactyl/publisher"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// delivery is a message published to the fake AMQP broker.
+type delivery struct {
+	exchange     string
+	routingKey   string
+	contentType  string
+	deliveryMode uint8
+	body         string
+}
+
+// amqpBroker is a fake AMQP 0-9-1 broker with a single exchange. It drops the connections that publish while it has
+// drops left.
+type amqpBroker struct {
+	listener   net.Listener
+	exchange   string
+	frameMax   uint32
+	drops      int
+	logins     chan string
+	vhosts     chan string
+	deliveries chan delivery
+}
+
+func newAMQPBroker(exchange string, drops int) *amqpBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	b := &amqpBroker{
+		listener:   listener,
+		exchange:   exchange,
+		frameMax:   32,
+		drops:      drops,
+		logins:     make(chan string, 10),
+		vhosts:     make(chan string, 10),
+		deliveries: make(chan delivery, 10),
+	}
+	go b.serve()
+	return b
+}
+
+func (b *amqpBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.handle(conn)
+	}
+}
+
+func (b *amqpBroker) handle(conn net.Conn) {
+	defer conn.Close()
+
+	protocol := make([]byte, 8)
+	if _, err := io.ReadFull(conn, protocol); err != nil || string(protocol) != "AMQP\x00\x00\x09\x01" {
+		return
+	}
+
+	start := &writer{}
+	start.int8(0)
+	start.int8(9)
+	start.int32(0)
+	start.int32(5)
+	start.buf = append(start.buf, "PLAIN"...)
+	start.int32(5)
+	start.buf = append(start.buf, "en_US"...)
+	writeMethod(conn, 0, 10, 10, start.buf)
+
+	_, _, args := readFrame(conn)
+	startOk := &reader{data: args[4:]}
+	startOk.next(int(startOk.int32()))
+	startOk.next(int(startOk.int8()))
+	b.logins <- string(startOk.bytes())
+
+	tune := &writer{}
+	tune.int16(2047)
+	tune.int32(int32(b.frameMax))
+	tune.int16(60)
+	writeMethod(conn, 0, 10, 30, tune.buf)
+
+	readFrame(conn)
+	_, _, args = readFrame(conn)
+	open := &reader{data: args[4:]}
+	b.vhosts <- string(open.next(int(open.int8())))
+	writeMethod(conn, 0, 10, 41, []byte{0})
+
+	readFrame(conn)
+	writeMethod(conn, 1, 20, 11, []byte{0, 0, 0, 0})
+	readFrame(conn)
+	writeMethod(conn, 1, 85, 11, nil)
+
+	conn.Write([]byte{8, 0, 0, 0, 0, 0, 0, 0xce})
+
+	for tag := int64(1); ; tag++ {
+		frameType, _, args := readFrame(conn)
+		if frameType != 1 {
+			return
+		}
+		publish := &reader{data: args[4:]}
+		publish.int16()
+		exchange := string(publish.next(int(publish.int8())))
+		routingKey := string(publish.next(int(publish.int8())))
+
+		_, _, header := readFrame(conn)
+		properties := &reader{data: header}
+		properties.int16()
+		properties.int16()
+		size := properties.int64()
+		Expect(properties.int16()).To(Equal(int16(-28672)), "property flags")
+		contentType := string(properties.next(int(properties.int8())))
+		deliveryMode := uint8(properties.int8())
+
+		body := []byte{}
+		for int64(len(body)) < size {
+			_, _, frame := readFrame(conn)
+			Expect(len(frame)).To(BeNumerically("<=", b.frameMax-8))
+			body = append(body, frame...)
+		}
+
+		if b.drops > 0 {
+			b.drops--
+			return
+		}
+
+		if exchange != b.exchange {
+			closing := &writer{}
+			closing.int16(404)
+			text := "NOT_FOUND - no exchange '" + exchange + "'"
+			closing.int8(int8(len(text)))
+			closing.buf = append(closing.buf, text...)
+			closing.int16(60)
+			closing.int16(40)
+			writeMethod(conn, 1, 20, 40, closing.buf)
+			readFrame(conn)
+			tag--
+			continue
+		}
+
+		b.deliveries <- delivery{exchange, routingKey, contentType, deliveryMode, string(body)}
+
+		ack := &writer{}
+		ack.int64(tag)
+		ack.int8(0)
+		writeMethod(conn, 1, 60, 80, ack.buf)
+	}
+}
+
+func writeMethod(conn net.Conn, channel uint16, class, method int16, args []byte) {
+	payload := &writer{}
+	payload.int16(class)
+	payload.int16(method)
+	payload.buf = append(payload.buf, args...)
+
+	frame := &writer{}
+	frame.int8(1)
+	frame.int16(int16(channel))
+	frame.int32(int32(len(payload.buf)))
+	frame.buf = append(frame.buf, payload.buf...)
+	frame.buf = append(frame.buf, 0xce)
+	conn.Write(frame.buf)
+}
+
+func readFrame(conn net.Conn) (uint8, uint16, []byte) {
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return 0, 0, nil
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header[3:])+1)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return 0, 0, nil
+	}
+	return header[0], binary.BigEndian.Uint16(header[1:]), payload[:len(payload)-1]
+}
+
+var _ = Describe("AMQPProducer", func() {
+	var (
+		broker   *amqpBroker
+		producer *AMQPProducer
+		delays   []time.Duration
+	)
+
+	start := func(drops int) {
+		broker = newAMQPBroker("deployments", drops)
+		producer = NewAMQPProducer([]string{broker.listener.Addr().String()}, "deployments", time.Second)
+		producer.Username = "deployadactyl"
+		producer.Password = "secret"
+
+		delays = nil
+		producer.Sleep = func(delay time.Duration) { delays = append(delays, delay) }
+	}
+
+	AfterEach(func() {
+		broker.listener.Close()
+	})
+
+	It("authenticates with PLAIN on the default vhost", func() {
+		start(0)
+
+		Expect(producer.Produce("production.DeploySuccessEvent", []byte("{}"))).To(Succeed())
+
+		Eventually(broker.logins).Should(Receive(Equal("\x00deployadactyl\x00secret")))
+		Eventually(broker.vhosts).Should(Receive(Equal("/")))
+	})
+
+	It("publishes persistent JSON messages to the exchange with the routing key", func() {
+		start(0)
+		message := `{"type":"DeploySuccessEvent","uuid":"1234","environment":"production"}`
+
+		Expect(producer.Produce("production.DeploySuccessEvent", []byte(message))).To(Succeed())
+		Expect(producer.Produce("production.DeployFinishEvent", []byte("{}"))).To(Succeed())
+
+		Eventually(broker.deliveries).Should(Receive(Equal(delivery{"deployments", "production.DeploySuccessEvent", "application/json", 2, message})))
+		Eventually(broker.deliveries).Should(Receive(Equal(delivery{"deployments", "production.DeployFinishEvent", "application/json", 2, "{}"})))
+		Expect(broker.logins).To(HaveLen(1))
+	})
+
+	It("reconnects and publishes again when the connection is lost", func() {
+		start(2)
+
+		Expect(producer.Produce("production.DeploySuccessEvent", []byte("{}"))).To(Succeed())
+
+		Eventually(broker.deliveries).Should(Receive())
+		Expect(broker.logins).To(HaveLen(3))
+		Expect(delays).To(Equal([]time.Duration{AMQPRetryDelay, 2 * AMQPRetryDelay}))
+	})
+
+	It("returns the error once the retries are exhausted", func() {
+		start(4)
+
+		err := producer.Produce("production.DeploySuccessEvent", []byte("{}"))
+
+		Expect(err).To(HaveOccurred())
+		Expect(delays).To(HaveLen(AMQPRetries))
+		Expect(broker.logins).To(HaveLen(AMQPRetries + 1))
+	})
+
+	It("returns the error of the broker when the exchange does not exist", func() {
+		start(0)
+		producer.Exchange = "missing"
+		producer.Retries = 0
+
+		err := producer.Produce("production.DeploySuccessEvent", []byte("{}"))
+
+		Expect(err).To(MatchError(AMQPError{"channel", 404, "NOT_FOUND - no exchange 'missing'"}))
+	})
+
+	It("returns an error when no server can be reached", func() {
+		start(0)
+		broker.listener.Close()
+		producer.Retries = 0
+
+		err := producer.Produce("production.DeploySuccessEvent", []byte("{}"))
+
+		Expect(err).To(BeAssignableToTypeOf(NoServerError{}))
+	})
+})
@@ -0,0 +1,137 @@
+package publisher
+
+import "fmt"
+
+type UnknownTypeError struct {
+	Type string
+}
+
+func (e UnknownTypeError) Error() string {
+	return fmt.Sprintf("unknown event publisher type %s: expected %s, %s or %s", e.Type, Kafka, NATS, AMQP)
+}
+
+type MissingDestinationError struct {
+	Type  string
+	Field string
+}
+
+func (e MissingDestinationError) Error() string {
+	return fmt.Sprintf("the %s event publisher requires %s", e.Type, e.Field)
+}
+
+type UnknownSASLMechanismError struct {
+	Mechanism string
+}
+
+func (e UnknownSASLMechanismError) Error() string {
+	return fmt.Sprintf("unknown sasl mechanism %s: expected %s, %s or %s", e.Mechanism, PLAIN, SCRAMSHA256, SCRAMSHA512)
+}
+
+type InvalidTimeoutError struct {
+	Type    string
+	Seconds int
+}
+
+func (e InvalidTimeoutError) Error() string {
+	return fmt.Sprintf("the timeout of the %s event publisher must not be negative: %d", e.Type, e.Seconds)
+}
+
+type ConnectError struct {
+	Address string
+	Err     error
+}
+
+func (e ConnectError) Error() string {
+	return fmt.Sprintf("cannot connect to %s: %s", e.Address, e.Err)
+}
+
+type NoBrokerError struct {
+	Brokers []string
+	Err     error
+}
+
+func (e NoBrokerError) Error() string {
+	return fmt.Sprintf("cannot read the metadata of any broker of %v: %s", e.Brokers, e.Err)
+}
+
+type MalformedResponseError struct {
+	Request string
+}
+
+func (e MalformedResponseError) Error() string {
+	return fmt.Sprintf("malformed response to the %s request", e.Request)
+}
+
+type KafkaError struct {
+	Request string
+	Code    int16
+}
+
+func (e KafkaError) Error() string {
+	if name, ok := kafkaErrors[e.Code]; ok {
+		return fmt.Sprintf("the %s request failed: %s", e.Request, name)
+	}
+	return fmt.Sprintf("the %s request failed with kafka error code %d", e.Request, e.Code)
+}
+
+type SASLError struct {
+	Mechanism string
+	Message   string
+}
+
+func (e SASLError) Error() string {
+	return fmt.Sprintf("sasl %s authentication failed: %s", e.Mechanism, e.Message)
+}
+
+type InvalidSubjectError struct {
+	Subject string
+}
+
+func (e InvalidSubjectError) Error() string {
+	return fmt.Sprintf("invalid nats subject %q: it cannot have empty tokens, whitespace or wildcards", e.Subject)
+}
+
+type NoServerError struct {
+	Servers []string
+	Err     error
+}
+
+func (e NoServerError) Error() string {
+	return fmt.Sprintf("cannot connect to any server of %v: %s", e.Servers, e.Err)
+}
+
+type NATSError struct {
+	Message string
+}
+
+func (e NATSError) Error() string {
+	return fmt.Sprintf("the nats server returned an error: %s", e.Message)
+}
+
+type MessageTooLargeError struct {
+	Size int
+	Max  int64
+}
+
+func (e MessageTooLargeError) Error() string {
+	return fmt.Sprintf("the message of %d bytes is larger than the %d bytes the server accepts", e.Size, e.Max)
+}
+
+type AMQPError struct {
+	Scope string
+	Code  uint16
+	Text  string
+}
+
+func (e AMQPError) Error() string {
+	return fmt.Sprintf("the amqp broker closed the %s: %d %s", e.Scope, e.Code, e.Text)
+}
+
+type NackError struct {
+	Exchange   string
+	RoutingKey string
+}
+
+func (e NackError) Error() string {
+	return fmt.Sprintf("the amqp broker did not accept the message to exchange %s with routing key %s", e.Exchange, e.RoutingKey)
+}
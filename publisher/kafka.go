@@ -0,0 +1,275 @@
+package publisher
+
+import (
+	"crypto/tls"
+	"hash/fnv"
+	"net"
+	"strconv"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// The requests of the Kafka protocol used by the producer, with their versions. They are supported since Kafka 1.0.
+const (
+	kafkaProduce          int16 = 0
+	kafkaMetadata         int16 = 3
+	kafkaSASLHandshake    int16 = 17
+	kafkaSASLAuthenticate int16 = 36
+
+	kafkaProduceVersion          int16 = 3
+	kafkaMetadataVersion         int16 = 4
+	kafkaSASLHandshakeVersion    int16 = 1
+	kafkaSASLAuthenticateVersion int16 = 0
+)
+
+// kafkaClientID identifies the producer to the brokers.
+const kafkaClientID = "deployadactyl"
+
+// kafkaAllReplicas acknowledges a message once every in-sync replica has it.
+const kafkaAllReplicas int16 = -1
+
+var kafkaErrors = map[int16]string{
+	3:  "unknown topic or partition",
+	5:  "leader not available",
+	6:  "not leader for partition",
+	7:  "request timed out",
+	10: "message too large",
+	19: "not enough replicas",
+	29: "topic authorization failed",
+	31: "cluster authorization failed",
+	33: "unsupported sasl mechanism",
+	58: "sasl authentication failed",
+}
+
+// NewKafkaProducer returns a KafkaProducer of the topic on the cluster of the brokers.
+func NewKafkaProducer(brokers []string, topic string, sasl S.SASLDescriptor, timeout time.Duration) *KafkaProducer {
+	return &KafkaProducer{
+		Brokers: brokers,
+		Topic:   topic,
+		SASL:    sasl,
+		Timeout: timeout,
+		Now:     time.Now,
+		conns:   map[string]*kafkaConn{},
+	}
+}
+
+// KafkaProducer produces messages to a topic of a Kafka cluster.
+//
+// Messages are produced to the partition of their key, or to every partition in turn when they have none, and are
+// acknowledged by every in-sync replica. A message that cannot be produced is produced again once, after the
+// connections are closed and the metadata of the topic is read again.
+type KafkaProducer struct {
+	Brokers   []string
+	Topic     string
+	TLSConfig *tls.Config
+	SASL      S.SASLDescriptor
+	Timeout   time.Duration
+	Now       func() time.Time
+
+	partitions []int32
+	leaders    map[int32]string
+	conns      map[string]*kafkaConn
+	next       int
+}
+
+// Produce produces the message with the key to the topic.
+func (k *KafkaProducer) Produce(key string, message []byte) error {
+	err := k.produce(key, message)
+	if err != nil {
+		k.reset()
+		err = k.produce(key, message)
+		if err != nil {
+			k.reset()
+		}
+	}
+	return err
+}
+
+func (k *KafkaProducer) produce(key string, message []byte) error {
+	if len(k.partitions) == 0 {
+		err := k.readMetadata()
+		if err != nil {
+			return err
+		}
+	}
+
+	partition := k.partition(key)
+	conn, err := k.connect(k.leaders[partition])
+	if err != nil {
+		return err
+	}
+
+	request := &kafkaEncoder{}
+	request.nullableString(nil)
+	request.int16(kafkaAllReplicas)
+	request.int32(int32(k.Timeout / time.Millisecond))
+	request.arrayLength(1)
+	request.string(k.Topic)
+	request.arrayLength(1)
+	request.int32(partition)
+	request.bytes(encodeRecordBatch(key, message, k.Now()))
+
+	body, err := conn.roundTrip(kafkaProduce, kafkaProduceVersion, request.buf)
+	if err != nil {
+		return err
+	}
+
+	response := &kafkaDecoder{data: body}
+	for topics := response.arrayLength(); topics > 0; topics-- {
+		response.string()
+		for partitions := response.arrayLength(); partitions > 0; partitions-- {
+			response.int32()
+			code := response.int16()
+			response.int64()
+			response.int64()
+			if response.err == nil && code != 0 {
+				return KafkaError{"produce", code}
+			}
+		}
+	}
+	if response.err != nil {
+		return MalformedResponseError{"produce"}
+	}
+	return nil
+}
+
+// partition returns the partition of the key, or the next partition when there is no key.
+func (k *KafkaProducer) partition(key string) int32 {
+	if key == "" {
+		k.next++
+		return k.partitions[k.next%len(k.partitions)]
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return k.partitions[h.Sum32()%uint32(len(k.partitions))]
+}
+
+// readMetadata reads the partitions of the topic and their leaders from the first broker that answers.
+func (k *KafkaProducer) readMetadata() error {
+	var lastErr error
+	for _, broker := range k.Brokers {
+		err := k.readMetadataFrom(broker)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+	return NoBrokerError{k.Brokers, lastErr}
+}
+
+func (k *KafkaProducer) readMetadataFrom(broker string) error {
+	conn, err := k.connect(broker)
+	if err != nil {
+		return err
+	}
+
+	request := &kafkaEncoder{}
+	request.arrayLength(1)
+	request.string(k.Topic)
+	request.bool(false)
+
+	body, err := conn.roundTrip(kafkaMetadata, kafkaMetadataVersion, request.buf)
+	if err != nil {
+		return err
+	}
+
+	response := &kafkaDecoder{data: body}
+	response.int32()
+
+	brokers := map[int32]string{}
+	for count := response.arrayLength(); count > 0; count-- {
+		node := response.int32()
+		host := response.string()
+		port := response.int32()
+		response.nullableString()
+		brokers[node] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	response.nullableString()
+	response.int32()
+
+	partitions := []int32{}
+	leaders := map[int32]string{}
+	for topics := response.arrayLength(); topics > 0; topics-- {
+		code := response.int16()
+		response.string()
+		response.bool()
+		if response.err == nil && code != 0 {
+			return KafkaError{"metadata", code}
+		}
+
+		for count := response.arrayLength(); count > 0; count-- {
+			response.int16()
+			partition := response.int32()
+			leader := response.int32()
+			response.int32Array()
+			response.int32Array()
+
+			if address, ok := brokers[leader]; ok {
+				partitions = append(partitions, partition)
+				leaders[partition] = address
+			}
+		}
+	}
+	if response.err != nil {
+		return MalformedResponseError{"metadata"}
+	}
+	if len(partitions) == 0 {
+		return KafkaError{"metadata", 5}
+	}
+
+	k.partitions = partitions
+	k.leaders = leaders
+	return nil
+}
+
+// connect returns the connection to the broker, connecting and authenticating it when there is none.
+func (k *KafkaProducer) connect(address string) (*kafkaConn, error) {
+	if conn, ok := k.conns[address]; ok {
+		return conn, nil
+	}
+
+	raw, err := net.DialTimeout("tcp", address, k.Timeout)
+	if err != nil {
+		return nil, ConnectError{address, err}
+	}
+
+	if k.TLSConfig != nil {
+		config := k.TLSConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(address)
+		}
+
+		client := tls.Client(raw, config)
+		client.SetDeadline(time.Now().Add(k.Timeout))
+		err = client.Handshake()
+		if err != nil {
+			raw.Close()
+			return nil, ConnectError{address, err}
+		}
+		raw = client
+	}
+
+	conn := &kafkaConn{Conn: raw, timeout: k.Timeout}
+	if k.SASL.Mechanism != "" {
+		err = conn.authenticate(k.SASL)
+		if err != nil {
+			conn.Close()
+			return nil, ConnectError{address, err}
+		}
+	}
+
+	k.conns[address] = conn
+	return conn, nil
+}
+
+// reset closes the connections and forgets the metadata, so they are read again for the next message.
+func (k *KafkaProducer) reset() {
+	for address, conn := range k.conns {
+		conn.Close()
+		delete(k.conns, address)
+	}
+	k.partitions = nil
+	k.leaders = nil
+}
@@ -0,0 +1,328 @@
+package publisher
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net"
+	"time"
+
+	"github.com/compozed/deployadactyl/scram"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// kafkaMaxResponse is the largest response read from a broker.
+const kafkaMaxResponse = 16 << 20
+
+// kafkaConn is a connection to a Kafka broker, which answers one request at a time.
+type kafkaConn struct {
+	net.Conn
+	timeout     time.Duration
+	correlation int32
+}
+
+// roundTrip sends the request and returns the body of its response.
+func (c *kafkaConn) roundTrip(apiKey, version int16, body []byte) ([]byte, error) {
+	c.correlation++
+
+	header := &kafkaEncoder{}
+	header.int16(apiKey)
+	header.int16(version)
+	header.int32(c.correlation)
+	header.string(kafkaClientID)
+
+	request := &kafkaEncoder{}
+	request.int32(int32(len(header.buf) + len(body)))
+	request.buf = append(request.buf, header.buf...)
+	request.buf = append(request.buf, body...)
+
+	c.SetDeadline(time.Now().Add(c.timeout))
+	_, err := c.Write(request.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	size := make([]byte, 4)
+	_, err = io.ReadFull(c, size)
+	if err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(size)
+	if length < 4 || length > kafkaMaxResponse {
+		return nil, MalformedResponseError{kafkaRequestName(apiKey)}
+	}
+
+	response := make([]byte, length)
+	_, err = io.ReadFull(c, response)
+	if err != nil {
+		return nil, err
+	}
+
+	if int32(binary.BigEndian.Uint32(response)) != c.correlation {
+		return nil, MalformedResponseError{kafkaRequestName(apiKey)}
+	}
+	return response[4:], nil
+}
+
+// authenticate runs the SASL handshake and authentication of the mechanism.
+func (c *kafkaConn) authenticate(sasl S.SASLDescriptor) error {
+	handshake := &kafkaEncoder{}
+	handshake.string(sasl.Mechanism)
+
+	body, err := c.roundTrip(kafkaSASLHandshake, kafkaSASLHandshakeVersion, handshake.buf)
+	if err != nil {
+		return err
+	}
+
+	response := &kafkaDecoder{data: body}
+	code := response.int16()
+	if response.err != nil {
+		return MalformedResponseError{"sasl handshake"}
+	}
+	if code != 0 {
+		return KafkaError{"sasl handshake", code}
+	}
+
+	if sasl.Mechanism == PLAIN {
+		_, err = c.saslAuthenticate(sasl.Mechanism, []byte("\x00"+sasl.Username+"\x00"+sasl.Password))
+		return err
+	}
+
+	client, err := scram.NewClient(sasl.Mechanism, sasl.Username, sasl.Password)
+	if err != nil {
+		return err
+	}
+
+	serverFirst, err := c.saslAuthenticate(sasl.Mechanism, client.First())
+	if err != nil {
+		return err
+	}
+	final, err := client.Final(serverFirst)
+	if err != nil {
+		return err
+	}
+	serverFinal, err := c.saslAuthenticate(sasl.Mechanism, final)
+	if err != nil {
+		return err
+	}
+	return client.Verify(serverFinal)
+}
+
+// saslAuthenticate sends a message of the SASL exchange and returns the answer of the broker.
+func (c *kafkaConn) saslAuthenticate(mechanism string, message []byte) ([]byte, error) {
+	request := &kafkaEncoder{}
+	request.bytes(message)
+
+	body, err := c.roundTrip(kafkaSASLAuthenticate, kafkaSASLAuthenticateVersion, request.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &kafkaDecoder{data: body}
+	code := response.int16()
+	errorMessage := response.nullableString()
+	answer := response.bytes()
+	if response.err != nil {
+		return nil, MalformedResponseError{"sasl authenticate"}
+	}
+	if code != 0 {
+		if errorMessage == nil {
+			return nil, KafkaError{"sasl authenticate", code}
+		}
+		return nil, SASLError{mechanism, *errorMessage}
+	}
+	return answer, nil
+}
+
+func kafkaRequestName(apiKey int16) string {
+	switch apiKey {
+	case kafkaProduce:
+		return "produce"
+	case kafkaMetadata:
+		return "metadata"
+	case kafkaSASLHandshake:
+		return "sasl handshake"
+	}
+	return "sasl authenticate"
+}
+
+// encodeRecordBatch returns a record batch, the message format of Kafka 0.11 and later, holding the message.
+func encodeRecordBatch(key string, message []byte, now time.Time) []byte {
+	record := &kafkaEncoder{}
+	record.int8(0)
+	record.varint(0)
+	record.varint(0)
+	if key == "" {
+		record.varint(-1)
+	} else {
+		record.varint(int64(len(key)))
+		record.buf = append(record.buf, key...)
+	}
+	record.varint(int64(len(message)))
+	record.buf = append(record.buf, message...)
+	record.varint(0)
+
+	timestamp := now.UnixNano() / int64(time.Millisecond)
+
+	checked := &kafkaEncoder{}
+	checked.int16(0)
+	checked.int32(0)
+	checked.int64(timestamp)
+	checked.int64(timestamp)
+	checked.int64(-1)
+	checked.int16(-1)
+	checked.int32(-1)
+	checked.int32(1)
+	checked.varint(int64(len(record.buf)))
+	checked.buf = append(checked.buf, record.buf...)
+
+	batch := &kafkaEncoder{}
+	batch.int64(0)
+	batch.int32(int32(4 + 1 + 4 + len(checked.buf)))
+	batch.int32(-1)
+	batch.int8(2)
+	batch.int32(int32(crc32.Checksum(checked.buf, crc32.MakeTable(crc32.Castagnoli))))
+	batch.buf = append(batch.buf, checked.buf...)
+	return batch.buf
+}
+
+// kafkaEncoder appends the primitive types of the Kafka protocol to a buffer.
+type kafkaEncoder struct {
+	buf []byte
+}
+
+func (e *kafkaEncoder) int8(v int8) {
+	e.buf = append(e.buf, byte(v))
+}
+
+func (e *kafkaEncoder) bool(v bool) {
+	if v {
+		e.int8(1)
+		return
+	}
+	e.int8(0)
+}
+
+func (e *kafkaEncoder) int16(v int16) {
+	e.buf = append(e.buf, byte(uint16(v)>>8), byte(v))
+}
+
+func (e *kafkaEncoder) int32(v int32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(v))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *kafkaEncoder) int64(v int64) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *kafkaEncoder) varint(v int64) {
+	b := make([]byte, binary.MaxVarintLen64)
+	e.buf = append(e.buf, b[:binary.PutVarint(b, v)]...)
+}
+
+func (e *kafkaEncoder) arrayLength(n int) {
+	e.int32(int32(n))
+}
+
+func (e *kafkaEncoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *kafkaEncoder) nullableString(s *string) {
+	if s == nil {
+		e.int16(-1)
+		return
+	}
+	e.string(*s)
+}
+
+func (e *kafkaEncoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// kafkaDecoder reads the primitive types of the Kafka protocol. Once the data runs out every read returns zero and
+// err is set.
+type kafkaDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *kafkaDecoder) next(n int) []byte {
+	if d.err != nil || n < 0 || n > len(d.data) {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *kafkaDecoder) bool() bool {
+	b := d.next(1)
+	return b != nil && b[0] != 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	b := d.next(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (d *kafkaDecoder) arrayLength() int {
+	n := d.int32()
+	if n < 0 || d.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (d *kafkaDecoder) int32Array() {
+	d.next(4 * d.arrayLength())
+}
+
+func (d *kafkaDecoder) string() string {
+	return string(d.next(int(d.int16())))
+}
+
+func (d *kafkaDecoder) nullableString() *string {
+	n := d.int16()
+	if n < 0 || d.err != nil {
+		return nil
+	}
+	s := string(d.next(int(n)))
+	return &s
+}
+
+func (d *kafkaDecoder) bytes() []byte {
+	n := d.int32()
+	if n < 0 || d.err != nil {
+		return nil
+	}
+	return d.next(int(n))
+}
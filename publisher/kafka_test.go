@@ -0,0 +1,320 @@
+package publisher_test
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	. "github.com/compozed/deployadactyl/publisher"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// produced is a record produced to the fake broker.
+type produced struct {
+	topic     string
+	partition int32
+	key       []byte
+	value     []byte
+}
+
+// broker is a fake Kafka broker leading every partition of its topics. It answers the metadata, produce and SASL
+// requests of the producer, and fails the produce requests with its error code.
+type broker struct {
+	listener   net.Listener
+	partitions int32
+	code       int16
+	records    chan produced
+	auth       chan []byte
+}
+
+func newBroker(partitions int32, code int16) *broker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	b := &broker{listener: listener, partitions: partitions, code: code, records: make(chan produced, 10), auth: make(chan []byte, 10)}
+	go b.serve()
+	return b
+}
+
+func (b *broker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *broker) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		size := make([]byte, 4)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return
+		}
+		request := make([]byte, binary.BigEndian.Uint32(size))
+		if _, err := io.ReadFull(conn, request); err != nil {
+			return
+		}
+
+		r := &reader{data: request}
+		apiKey := r.int16()
+		r.int16()
+		correlation := r.int32()
+		r.string()
+
+		w := &writer{}
+		w.int32(correlation)
+		switch apiKey {
+		case 0:
+			b.produce(r, w)
+		case 3:
+			b.metadata(r, w)
+		case 17:
+			r.string()
+			w.int16(0)
+			w.int32(1)
+			w.string("PLAIN")
+		case 36:
+			b.auth <- r.bytes()
+			w.int16(0)
+			w.int16(-1)
+			w.int32(0)
+		}
+
+		response := &writer{}
+		response.int32(int32(len(w.buf)))
+		if _, err := conn.Write(append(response.buf, w.buf...)); err != nil {
+			return
+		}
+	}
+}
+
+func (b *broker) metadata(r *reader, w *writer) {
+	r.int32()
+	topic := r.string()
+
+	host, port, _ := net.SplitHostPort(b.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+
+	w.int32(0)
+	w.int32(1)
+	w.int32(1)
+	w.string(host)
+	w.int32(int32(portNumber))
+	w.int16(-1)
+	w.int16(-1)
+	w.int32(1)
+	w.int32(1)
+	w.int16(0)
+	w.string(topic)
+	w.int8(0)
+	w.int32(b.partitions)
+	for partition := int32(0); partition < b.partitions; partition++ {
+		w.int16(0)
+		w.int32(partition)
+		w.int32(1)
+		w.int32(1)
+		w.int32(1)
+		w.int32(1)
+		w.int32(1)
+	}
+}
+
+func (b *broker) produce(r *reader, w *writer) {
+	r.int16()
+	Expect(r.int16()).To(Equal(int16(-1)), "acks")
+	r.int32()
+	r.int32()
+	topic := r.string()
+	r.int32()
+	partition := r.int32()
+	batch := r.bytes()
+
+	key, value := decodeRecordBatch(batch)
+	b.records <- produced{topic, partition, key, value}
+
+	w.int32(1)
+	w.string(topic)
+	w.int32(1)
+	w.int32(partition)
+	w.int16(b.code)
+	w.int64(0)
+	w.int64(-1)
+	w.int32(0)
+}
+
+// decodeRecordBatch returns the key and value of the only record of the batch, after checking its checksum.
+func decodeRecordBatch(batch []byte) ([]byte, []byte) {
+	r := &reader{data: batch}
+	Expect(r.int64()).To(BeZero(), "base offset")
+	Expect(int(r.int32())).To(Equal(len(batch)-12), "batch length")
+	r.int32()
+	Expect(r.int8()).To(Equal(int8(2)), "magic")
+	crc := uint32(r.int32())
+	Expect(crc32.Checksum(r.data, crc32.MakeTable(crc32.Castagnoli))).To(Equal(crc), "crc")
+	r.int16()
+	r.int32()
+	r.int64()
+	r.int64()
+	r.int64()
+	r.int16()
+	r.int32()
+	Expect(r.int32()).To(Equal(int32(1)), "record count")
+
+	r.varint()
+	r.int8()
+	r.varint()
+	r.varint()
+	key := r.next(int(r.varint()))
+	value := r.next(int(r.varint()))
+	Expect(r.varint()).To(BeZero(), "headers")
+	return key, value
+}
+
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) int8(v int8) { w.buf = append(w.buf, byte(v)) }
+
+func (w *writer) int16(v int16) { w.buf = append(w.buf, byte(uint16(v)>>8), byte(v)) }
+
+func (w *writer) int32(v int32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(v))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) int64(v int64) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) string(s string) {
+	w.int16(int16(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+type reader struct {
+	data []byte
+}
+
+func (r *reader) next(n int) []byte {
+	if n < 0 {
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) int8() int8 { return int8(r.next(1)[0]) }
+
+func (r *reader) int16() int16 { return int16(binary.BigEndian.Uint16(r.next(2))) }
+
+func (r *reader) int32() int32 { return int32(binary.BigEndian.Uint32(r.next(4))) }
+
+func (r *reader) int64() int64 { return int64(binary.BigEndian.Uint64(r.next(8))) }
+
+func (r *reader) varint() int64 {
+	v, n := binary.Varint(r.data)
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *reader) string() string { return string(r.next(int(r.int16()))) }
+
+func (r *reader) bytes() []byte { return r.next(int(r.int32())) }
+
+var _ = Describe("KafkaProducer", func() {
+	var (
+		fake     *broker
+		producer *KafkaProducer
+	)
+
+	BeforeEach(func() {
+		fake = newBroker(3, 0)
+		producer = NewKafkaProducer([]string{fake.listener.Addr().String()}, "deployments", S.SASLDescriptor{}, time.Second)
+	})
+
+	AfterEach(func() {
+		fake.listener.Close()
+	})
+
+	It("produces the message with its key to the topic", func() {
+		Expect(producer.Produce("1234", []byte(`{"type":"DeploymentFinishedEvent"}`))).To(Succeed())
+
+		var record produced
+		Eventually(fake.records).Should(Receive(&record))
+		Expect(record.topic).To(Equal("deployments"))
+		Expect(string(record.key)).To(Equal("1234"))
+		Expect(string(record.value)).To(Equal(`{"type":"DeploymentFinishedEvent"}`))
+	})
+
+	It("produces the messages of a key to the same partition", func() {
+		partitions := map[int32]bool{}
+		for i := 0; i < 5; i++ {
+			Expect(producer.Produce("1234", []byte("message"))).To(Succeed())
+
+			var record produced
+			Eventually(fake.records).Should(Receive(&record))
+			partitions[record.partition] = true
+		}
+
+		Expect(partitions).To(HaveLen(1))
+	})
+
+	It("produces the messages without a key to every partition in turn", func() {
+		partitions := map[int32]bool{}
+		for i := 0; i < 3; i++ {
+			Expect(producer.Produce("", []byte("message"))).To(Succeed())
+
+			var record produced
+			Eventually(fake.records).Should(Receive(&record))
+			Expect(record.key).To(BeNil())
+			partitions[record.partition] = true
+		}
+
+		Expect(partitions).To(HaveLen(3))
+	})
+
+	It("returns the error of the broker after producing the message again", func() {
+		fake.listener.Close()
+		fake = newBroker(3, 29)
+		producer.Brokers = []string{fake.listener.Addr().String()}
+
+		err := producer.Produce("1234", []byte("message"))
+
+		Expect(err).To(MatchError(KafkaError{"produce", 29}))
+		Expect(err.Error()).To(Equal("the produce request failed: topic authorization failed"))
+		Eventually(fake.records).Should(Receive())
+		Eventually(fake.records).Should(Receive())
+	})
+
+	It("authenticates with SASL PLAIN", func() {
+		producer.SASL = S.SASLDescriptor{Mechanism: PLAIN, Username: "deployadactyl", Password: "secret"}
+
+		Expect(producer.Produce("1234", []byte("message"))).To(Succeed())
+
+		var auth []byte
+		Eventually(fake.auth).Should(Receive(&auth))
+		Expect(string(auth)).To(Equal("\x00deployadactyl\x00secret"))
+	})
+
+	It("returns an error when no broker can be reached", func() {
+		fake.listener.Close()
+
+		err := producer.Produce("1234", []byte("message"))
+
+		Expect(err).To(BeAssignableToTypeOf(NoBrokerError{}))
+	})
+})
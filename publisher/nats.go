@@ -0,0 +1,257 @@
+package publisher
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DefaultSubject is the subject the subjects of the event types are under when a NATS publisher has none.
+const DefaultSubject = "deployadactyl"
+
+// NewNATSProducer returns a NATSProducer of the subjects under the subject on the first of the servers that accepts
+// the connection.
+func NewNATSProducer(servers []string, subject string, timeout time.Duration) *NATSProducer {
+	if subject == "" {
+		subject = DefaultSubject
+	}
+
+	return &NATSProducer{
+		Servers: servers,
+		Subject: subject,
+		Timeout: timeout,
+	}
+}
+
+// NATSProducer publishes messages to NATS, the key of a message being the last token of its subject, such as
+// deployadactyl.DeploySuccessEvent. Every message is followed by a PING, so it is only published once the server has
+// answered it. A message that cannot be published is published again once, on a new connection.
+type NATSProducer struct {
+	Servers   []string
+	Subject   string
+	Username  string
+	Password  string
+	Token     string
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+
+	conn       net.Conn
+	reader     *bufio.Reader
+	maxPayload int64
+}
+
+// natsInfo is the part of the INFO of a NATS server used by the producer.
+type natsInfo struct {
+	TLSRequired bool  `json:"tls_required"`
+	MaxPayload  int64 `json:"max_payload"`
+}
+
+// natsConnect is the CONNECT of the producer.
+type natsConnect struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TLSRequired bool   `json:"tls_required"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang"`
+	Protocol    int    `json:"protocol"`
+	User        string `json:"user,omitempty"`
+	Pass        string `json:"pass,omitempty"`
+	AuthToken   string `json:"auth_token,omitempty"`
+}
+
+// Produce publishes the message to the subject of the key.
+func (n *NATSProducer) Produce(key string, message []byte) error {
+	err := n.produce(key, message)
+	if err != nil {
+		if _, ok := err.(MessageTooLargeError); ok {
+			return err
+		}
+
+		n.close()
+		err = n.produce(key, message)
+		if err != nil {
+			n.close()
+		}
+	}
+	return err
+}
+
+func (n *NATSProducer) produce(key string, message []byte) error {
+	if n.conn == nil {
+		err := n.connect()
+		if err != nil {
+			return err
+		}
+	}
+
+	if n.maxPayload > 0 && int64(len(message)) > n.maxPayload {
+		return MessageTooLargeError{len(message), n.maxPayload}
+	}
+
+	subject := n.Subject
+	if key != "" {
+		subject += "." + subjectToken(key)
+	}
+
+	command := fmt.Sprintf("PUB %s %d\r\n", subject, len(message))
+	request := append([]byte(command), message...)
+	request = append(request, "\r\nPING\r\n"...)
+
+	n.conn.SetDeadline(time.Now().Add(n.Timeout))
+	_, err := n.conn.Write(request)
+	if err != nil {
+		return err
+	}
+	return n.awaitPong()
+}
+
+// connect connects to the first of the servers that accepts the connection.
+func (n *NATSProducer) connect() error {
+	var lastErr error
+	for _, server := range n.Servers {
+		err := n.connectTo(server)
+		if err == nil {
+			return nil
+		}
+		n.close()
+		lastErr = err
+	}
+	return NoServerError{n.Servers, lastErr}
+}
+
+func (n *NATSProducer) connectTo(address string) error {
+	conn, err := net.DialTimeout("tcp", address, n.Timeout)
+	if err != nil {
+		return ConnectError{address, err}
+	}
+	n.conn = conn
+	n.conn.SetDeadline(time.Now().Add(n.Timeout))
+	n.reader = bufio.NewReader(n.conn)
+
+	line, err := n.readLine()
+	if err != nil {
+		return ConnectError{address, err}
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return ConnectError{address, MalformedResponseError{"connect"}}
+	}
+
+	info := natsInfo{}
+	err = json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info)
+	if err != nil {
+		return ConnectError{address, MalformedResponseError{"connect"}}
+	}
+	n.maxPayload = info.MaxPayload
+
+	if n.TLSConfig != nil || info.TLSRequired {
+		config := &tls.Config{}
+		if n.TLSConfig != nil {
+			config = n.TLSConfig.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(address)
+		}
+
+		client := tls.Client(conn, config)
+		client.SetDeadline(time.Now().Add(n.Timeout))
+		err = client.Handshake()
+		if err != nil {
+			return ConnectError{address, err}
+		}
+		n.conn = client
+		n.reader = bufio.NewReader(n.conn)
+	}
+
+	connect, err := json.Marshal(natsConnect{
+		TLSRequired: n.TLSConfig != nil || info.TLSRequired,
+		Name:        "deployadactyl",
+		Lang:        "go",
+		Protocol:    1,
+		User:        n.Username,
+		Pass:        n.Password,
+		AuthToken:   n.Token,
+	})
+	if err != nil {
+		return ConnectError{address, err}
+	}
+
+	request := append([]byte("CONNECT "), connect...)
+	request = append(request, "\r\nPING\r\n"...)
+	_, err = n.conn.Write(request)
+	if err != nil {
+		return ConnectError{address, err}
+	}
+
+	err = n.awaitPong()
+	if err != nil {
+		return ConnectError{address, err}
+	}
+	return nil
+}
+
+// awaitPong reads the answers of the server until the PONG of the last PING, answering the PINGs of the server.
+func (n *NATSProducer) awaitPong() error {
+	for {
+		line, err := n.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			_, err = n.conn.Write([]byte("PONG\r\n"))
+			if err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return NATSError{strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'")}
+		}
+	}
+}
+
+func (n *NATSProducer) readLine() (string, error) {
+	line, err := n.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (n *NATSProducer) close() {
+	if n.conn != nil {
+		n.conn.Close()
+	}
+	n.conn = nil
+	n.reader = nil
+}
+
+// subjectToken returns the key with the characters that cannot be in a token of a subject replaced by underscores.
+func subjectToken(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '*' || r == '>' || r <= ' ' {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// validSubject reports whether the subject can have the subjects of the event types under it. An empty subject is
+// the default subject.
+func validSubject(subject string) bool {
+	if subject == "" {
+		return true
+	}
+
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" || subjectToken(token) != token {
+			return false
+		}
+	}
+	return true
+}
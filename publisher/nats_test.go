@@ -0,0 +1,157 @@
+package publisher_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/compozed/deployadactyl/publisher"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// published is a message published to the fake NATS server.
+type published struct {
+	subject string
+	payload string
+}
+
+// natsServer is a fake NATS server that only accepts the clients with its token.
+type natsServer struct {
+	listener net.Listener
+	token    string
+	connects chan map[string]interface{}
+	messages chan published
+}
+
+func newNATSServer(token string) *natsServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	s := &natsServer{listener: listener, token: token, connects: make(chan map[string]interface{}, 10), messages: make(chan published, 10)}
+	go s.serve()
+	return s
+}
+
+func (s *natsServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *natsServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	conn.Write([]byte(`INFO {"server_id":"fake","max_payload":64,"auth_required":true}` + "\r\n"))
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			connect := map[string]interface{}{}
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "CONNECT ")), &connect)
+			s.connects <- connect
+
+			if connect["auth_token"] != s.token {
+				conn.Write([]byte("-ERR 'Authorization Violation'\r\n"))
+				return
+			}
+		case strings.HasPrefix(line, "PUB "):
+			fields := strings.Fields(line)
+			size, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			s.messages <- published{fields[1], string(payload[:size])}
+			conn.Write([]byte("PING\r\n"))
+		case line == "PING":
+			conn.Write([]byte("PONG\r\n"))
+		}
+	}
+}
+
+var _ = Describe("NATSProducer", func() {
+	var (
+		server   *natsServer
+		producer *NATSProducer
+	)
+
+	BeforeEach(func() {
+		server = newNATSServer("secret")
+		producer = NewNATSProducer([]string{server.listener.Addr().String()}, "", time.Second)
+		producer.Token = "secret"
+	})
+
+	AfterEach(func() {
+		server.listener.Close()
+	})
+
+	It("connects with its token", func() {
+		Expect(producer.Produce("DeploySuccessEvent", []byte("{}"))).To(Succeed())
+
+		var connect map[string]interface{}
+		Eventually(server.connects).Should(Receive(&connect))
+		Expect(connect).To(HaveKeyWithValue("auth_token", "secret"))
+		Expect(connect).To(HaveKeyWithValue("name", "deployadactyl"))
+		Expect(connect).To(HaveKeyWithValue("verbose", false))
+		Expect(connect).ToNot(HaveKey("user"))
+	})
+
+	It("publishes the message to the subject of its key", func() {
+		Expect(producer.Produce("DeploySuccessEvent", []byte(`{"uuid":"1234"}`))).To(Succeed())
+		Expect(producer.Produce("deploy.start", []byte(`{"uuid":"5678"}`))).To(Succeed())
+
+		Eventually(server.messages).Should(Receive(Equal(published{"deployadactyl.DeploySuccessEvent", `{"uuid":"1234"}`})))
+		Eventually(server.messages).Should(Receive(Equal(published{"deployadactyl.deploy.start", `{"uuid":"5678"}`})))
+		Expect(server.connects).To(HaveLen(1))
+	})
+
+	It("publishes under its subject", func() {
+		producer.Subject = "deployments.prod"
+
+		Expect(producer.Produce("Deploy Success*", []byte("{}"))).To(Succeed())
+
+		Eventually(server.messages).Should(Receive(Equal(published{"deployments.prod.Deploy_Success_", "{}"})))
+	})
+
+	It("returns the error of the server", func() {
+		producer.Token = "wrong"
+
+		err := producer.Produce("DeploySuccessEvent", []byte("{}"))
+
+		Expect(err).To(BeAssignableToTypeOf(NoServerError{}))
+		Expect(err.Error()).To(ContainSubstring("the nats server returned an error: Authorization Violation"))
+	})
+
+	It("returns an error when the message is larger than the server accepts", func() {
+		err := producer.Produce("DeploySuccessEvent", []byte(strings.Repeat("x", 65)))
+
+		Expect(err).To(MatchError(MessageTooLargeError{65, 64}))
+	})
+
+	It("tries the next server when one cannot be reached", func() {
+		closed, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		closed.Close()
+		producer.Servers = []string{closed.Addr().String(), server.listener.Addr().String()}
+
+		Expect(producer.Produce("DeploySuccessEvent", []byte("{}"))).To(Succeed())
+
+		Eventually(server.messages).Should(Receive())
+	})
+})
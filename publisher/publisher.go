@@ -0,0 +1,225 @@
+// Package publisher publishes the events of every deployment to the message brokers configured in the config.yml,
+// for audit and analytics pipelines that consume from them.
+package publisher
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/compozed/deployadactyl/cabundle"
+	"github.com/compozed/deployadactyl/eventmanager"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/scram"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// The types of publishers.
+const (
+	Kafka = "kafka"
+	NATS  = "nats"
+	AMQP  = "amqp"
+)
+
+// The SASL mechanisms of the publishers.
+const (
+	PLAIN       = "PLAIN"
+	SCRAMSHA256 = scram.SHA256
+	SCRAMSHA512 = scram.SHA512
+)
+
+// DefaultTimeout is how long a request to a message broker may take when the publisher has no timeout.
+const DefaultTimeout = 10 * time.Second
+
+// QueueSize is how many events can wait to be published. Further events are dropped while the queue is full, so a
+// slow or unreachable broker never holds up a deployment.
+const QueueSize = 1000
+
+// Validate returns an error when a publisher has an unknown type or SASL mechanism, or is missing its destination.
+func Validate(publishers []S.EventPublisherDescriptor) error {
+	for _, publisher := range publishers {
+		switch publisher.Type {
+		case Kafka:
+			if len(publisher.Brokers) == 0 {
+				return MissingDestinationError{publisher.Type, "brokers"}
+			}
+			if publisher.Topic == "" {
+				return MissingDestinationError{publisher.Type, "topic"}
+			}
+		case NATS:
+			if len(publisher.Servers) == 0 {
+				return MissingDestinationError{publisher.Type, "servers"}
+			}
+			if !validSubject(publisher.Subject) {
+				return InvalidSubjectError{publisher.Subject}
+			}
+		case AMQP:
+			if len(publisher.Servers) == 0 {
+				return MissingDestinationError{publisher.Type, "servers"}
+			}
+			if publisher.Exchange == "" {
+				return MissingDestinationError{publisher.Type, "exchange"}
+			}
+		default:
+			return UnknownTypeError{publisher.Type}
+		}
+
+		switch publisher.SASL.Mechanism {
+		case "", PLAIN, SCRAMSHA256, SCRAMSHA512:
+		default:
+			return UnknownSASLMechanismError{publisher.SASL.Mechanism}
+		}
+
+		if publisher.TimeoutSeconds < 0 {
+			return InvalidTimeoutError{publisher.Type, publisher.TimeoutSeconds}
+		}
+	}
+	return nil
+}
+
+// Producer sends messages to a message broker. It is only used by one goroutine at a time.
+type Producer interface {
+	Produce(key string, message []byte) error
+}
+
+// New returns the Publisher of the descriptor. It does not connect to the broker until an event is published.
+func New(descriptor S.EventPublisherDescriptor, fileSystem *afero.Afero, log I.Logger) (*Publisher, error) {
+	tlsConfig, err := cabundle.TLSConfig(fileSystem, descriptor.CABundle)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := DefaultTimeout
+	if descriptor.TimeoutSeconds > 0 {
+		timeout = time.Duration(descriptor.TimeoutSeconds) * time.Second
+	}
+
+	switch descriptor.Type {
+	case Kafka:
+		producer := NewKafkaProducer(descriptor.Brokers, descriptor.Topic, descriptor.SASL, timeout)
+		if descriptor.TLS {
+			producer.TLSConfig = tlsConfigOf(tlsConfig, descriptor.SkipSSL)
+		}
+		return NewPublisher(descriptor.Type, producer, log), nil
+	case NATS:
+		producer := NewNATSProducer(descriptor.Servers, descriptor.Subject, timeout)
+		producer.Username = descriptor.Username
+		producer.Password = descriptor.Password
+		producer.Token = descriptor.Token
+		if descriptor.TLS {
+			producer.TLSConfig = tlsConfigOf(tlsConfig, descriptor.SkipSSL)
+		}
+
+		publisher := NewPublisher(descriptor.Type, producer, log)
+		publisher.Key = typeKey
+		return publisher, nil
+	case AMQP:
+		producer := NewAMQPProducer(descriptor.Servers, descriptor.Exchange, timeout)
+		producer.Username = descriptor.Username
+		producer.Password = descriptor.Password
+		if descriptor.VHost != "" {
+			producer.VHost = descriptor.VHost
+		}
+		if descriptor.TLS {
+			producer.TLSConfig = tlsConfigOf(tlsConfig, descriptor.SkipSSL)
+		}
+
+		publisher := NewPublisher(descriptor.Type, producer, log)
+		publisher.Key = routingKey
+		return publisher, nil
+	}
+	return nil, UnknownTypeError{descriptor.Type}
+}
+
+// NewPublisher returns a Publisher of the events to the producer.
+func NewPublisher(name string, producer Producer, log I.Logger) *Publisher {
+	return &Publisher{
+		Name:     name,
+		Producer: producer,
+		Log:      log,
+		Now:      time.Now,
+		Key:      uuidKey,
+		queue:    make(chan S.StreamedEvent, QueueSize),
+	}
+}
+
+// Publisher is a binding that accepts every event and queues its summary, as the event stream has it, to be
+// published as JSON with the key of the event, which is its deployment UUID unless Key is changed.
+type Publisher struct {
+	Name     string
+	Producer Producer
+	Log      I.Logger
+	Now      func() time.Time
+	Key      func(event S.StreamedEvent) string
+
+	queue chan S.StreamedEvent
+}
+
+func (p *Publisher) Accepts(event interface{}) bool {
+	return true
+}
+
+// Emit queues the summary of the event. It never fails, so a publisher cannot fail a deployment.
+func (p *Publisher) Emit(event interface{}) error {
+	published := eventmanager.Summarize(event)
+	published.Time = p.Now()
+
+	select {
+	case p.queue <- published:
+	default:
+		p.Log.Errorf("the %s event publisher is %d events behind: dropping %s of deployment %s", p.Name, QueueSize, published.Type, published.UUID)
+	}
+	return nil
+}
+
+// Run publishes the queued events, one after the other, forever. Events that cannot be published are logged and
+// dropped.
+func (p *Publisher) Run() {
+	for event := range p.queue {
+		p.publish(event)
+	}
+}
+
+func (p *Publisher) publish(event S.StreamedEvent) {
+	message, err := json.Marshal(event)
+	if err != nil {
+		p.Log.Errorf("cannot publish %s of deployment %s: %s", event.Type, event.UUID, err)
+		return
+	}
+
+	err = p.Producer.Produce(p.Key(event), message)
+	if err != nil {
+		p.Log.Errorf("cannot publish %s of deployment %s to %s: %s", event.Type, event.UUID, p.Name, err)
+	}
+}
+
+func uuidKey(event S.StreamedEvent) string {
+	return event.UUID
+}
+
+func typeKey(event S.StreamedEvent) string {
+	return event.Type
+}
+
+// routingKey returns the environment and type of the event, such as production.DeploySuccessEvent. Events without an
+// environment have none as their environment.
+func routingKey(event S.StreamedEvent) string {
+	environment := strings.ToLower(event.Environment)
+	if environment == "" {
+		environment = "none"
+	}
+	return environment + "." + event.Type
+}
+
+// tlsConfigOf returns the TLS configuration trusting the CA bundle, if there is one, or skipping the verification of
+// the certificates.
+func tlsConfigOf(bundle *tls.Config, skipSSL bool) *tls.Config {
+	config := &tls.Config{}
+	if bundle != nil {
+		config = bundle
+	}
+	config.InsecureSkipVerify = skipSSL
+	return config
+}
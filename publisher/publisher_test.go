@@ -0,0 +1,249 @@
+package publisher_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/publisher"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+	"github.com/spf13/afero"
+)
+
+// producer sends the messages it is given to its channel, and fails with its error.
+type producer struct {
+	messages chan message
+	err      error
+}
+
+type message struct {
+	key   string
+	value []byte
+}
+
+func (p *producer) Produce(key string, value []byte) error {
+	p.messages <- message{key, value}
+	return p.err
+}
+
+type deploymentEvent struct {
+	CFContext      I.CFContext
+	DeploymentInfo *S.DeploymentInfo
+	Error          error
+}
+
+func (e deploymentEvent) Name() string {
+	return "DeploymentFinishedEvent"
+}
+
+var _ = Describe("Validate", func() {
+	var descriptor S.EventPublisherDescriptor
+
+	BeforeEach(func() {
+		descriptor = S.EventPublisherDescriptor{
+			Type:    Kafka,
+			Brokers: []string{"kafka.example.com:9092"},
+			Topic:   "deployments",
+		}
+	})
+
+	It("accepts a kafka publisher", func() {
+		Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(Succeed())
+	})
+
+	It("accepts every SASL mechanism", func() {
+		for _, mechanism := range []string{PLAIN, SCRAMSHA256, SCRAMSHA512} {
+			descriptor.SASL.Mechanism = mechanism
+			Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(Succeed())
+		}
+	})
+
+	It("accepts a nats publisher", func() {
+		descriptor = S.EventPublisherDescriptor{Type: NATS, Servers: []string{"nats.example.com:4222"}, Subject: "deployments.prod"}
+		Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(Succeed())
+	})
+
+	It("rejects a nats publisher without servers", func() {
+		descriptor = S.EventPublisherDescriptor{Type: NATS}
+		Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(MatchError(MissingDestinationError{NATS, "servers"}))
+	})
+
+	It("rejects a nats publisher with an invalid subject", func() {
+		for _, subject := range []string{"deployments.*", "deployments..prod", "deploy ments", "deployments.>"} {
+			descriptor = S.EventPublisherDescriptor{Type: NATS, Servers: []string{"nats.example.com:4222"}, Subject: subject}
+			Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(MatchError(InvalidSubjectError{subject}))
+		}
+	})
+
+	It("accepts an amqp publisher", func() {
+		descriptor = S.EventPublisherDescriptor{Type: AMQP, Servers: []string{"rabbitmq.example.com:5672"}, Exchange: "deployments"}
+		Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(Succeed())
+	})
+
+	It("rejects an amqp publisher without an exchange", func() {
+		descriptor = S.EventPublisherDescriptor{Type: AMQP, Servers: []string{"rabbitmq.example.com:5672"}}
+		Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(MatchError(MissingDestinationError{AMQP, "exchange"}))
+	})
+
+	It("rejects an unknown type", func() {
+		descriptor.Type = "carrier-pigeon"
+		Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(MatchError(UnknownTypeError{"carrier-pigeon"}))
+	})
+
+	It("rejects a kafka publisher without brokers", func() {
+		descriptor.Brokers = nil
+		Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(MatchError(MissingDestinationError{Kafka, "brokers"}))
+	})
+
+	It("rejects a kafka publisher without a topic", func() {
+		descriptor.Topic = ""
+		Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(MatchError(MissingDestinationError{Kafka, "topic"}))
+	})
+
+	It("rejects an unknown SASL mechanism", func() {
+		descriptor.SASL.Mechanism = "GSSAPI"
+		Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(MatchError(UnknownSASLMechanismError{"GSSAPI"}))
+	})
+
+	It("rejects a negative timeout", func() {
+		descriptor.TimeoutSeconds = -1
+		Expect(Validate([]S.EventPublisherDescriptor{descriptor})).To(MatchError(InvalidTimeoutError{Kafka, -1}))
+	})
+})
+
+var _ = Describe("New", func() {
+	It("returns an error when the CA bundle cannot be read", func() {
+		descriptor := S.EventPublisherDescriptor{Type: Kafka, Brokers: []string{"kafka:9092"}, Topic: "deployments", CABundle: "/missing.pem"}
+
+		_, err := New(descriptor, &afero.Afero{Fs: afero.NewMemMapFs()}, I.DefaultLogger(NewBuffer(), logging.DEBUG, "publisher_test"))
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns a kafka publisher", func() {
+		descriptor := S.EventPublisherDescriptor{Type: Kafka, Brokers: []string{"kafka:9092"}, Topic: "deployments", TLS: true}
+
+		publisher, err := New(descriptor, &afero.Afero{Fs: afero.NewMemMapFs()}, I.DefaultLogger(NewBuffer(), logging.DEBUG, "publisher_test"))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(publisher.Name).To(Equal(Kafka))
+		kafka := publisher.Producer.(*KafkaProducer)
+		Expect(kafka.Topic).To(Equal("deployments"))
+		Expect(kafka.Timeout).To(Equal(DefaultTimeout))
+		Expect(kafka.TLSConfig).ToNot(BeNil())
+	})
+
+	It("returns a nats publisher keyed by the event type", func() {
+		descriptor := S.EventPublisherDescriptor{Type: NATS, Servers: []string{"nats:4222"}, Token: "token", TimeoutSeconds: 5}
+
+		publisher, err := New(descriptor, &afero.Afero{Fs: afero.NewMemMapFs()}, I.DefaultLogger(NewBuffer(), logging.DEBUG, "publisher_test"))
+
+		Expect(err).ToNot(HaveOccurred())
+		nats := publisher.Producer.(*NATSProducer)
+		Expect(nats.Subject).To(Equal(DefaultSubject))
+		Expect(nats.Token).To(Equal("token"))
+		Expect(nats.Timeout).To(Equal(5 * time.Second))
+		Expect(nats.TLSConfig).To(BeNil())
+		Expect(publisher.Key(S.StreamedEvent{Type: "DeploySuccessEvent", UUID: "1234"})).To(Equal("DeploySuccessEvent"))
+	})
+
+	It("returns an amqp publisher keyed by the environment and type of the event", func() {
+		descriptor := S.EventPublisherDescriptor{Type: AMQP, Servers: []string{"rabbitmq:5672"}, Exchange: "deployments", VHost: "ci", Username: "user", Password: "pass"}
+
+		publisher, err := New(descriptor, &afero.Afero{Fs: afero.NewMemMapFs()}, I.DefaultLogger(NewBuffer(), logging.DEBUG, "publisher_test"))
+
+		Expect(err).ToNot(HaveOccurred())
+		amqp := publisher.Producer.(*AMQPProducer)
+		Expect(amqp.Exchange).To(Equal("deployments"))
+		Expect(amqp.VHost).To(Equal("ci"))
+		Expect(amqp.Username).To(Equal("user"))
+		Expect(amqp.Retries).To(Equal(AMQPRetries))
+		Expect(publisher.Key(S.StreamedEvent{Type: "DeploySuccessEvent", Environment: "Production"})).To(Equal("production.DeploySuccessEvent"))
+		Expect(publisher.Key(S.StreamedEvent{Type: "FoundationsUnavailableEvent"})).To(Equal("none.FoundationsUnavailableEvent"))
+	})
+})
+
+var _ = Describe("Publisher", func() {
+	var (
+		fake      *producer
+		logBuffer *Buffer
+		publisher *Publisher
+		now       time.Time
+		event     deploymentEvent
+	)
+
+	BeforeEach(func() {
+		fake = &producer{messages: make(chan message, 10)}
+		logBuffer = NewBuffer()
+		now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+		publisher = NewPublisher(Kafka, fake, I.DefaultLogger(logBuffer, logging.DEBUG, "publisher_test"))
+		publisher.Now = func() time.Time { return now }
+
+		event = deploymentEvent{
+			CFContext:      I.CFContext{Environment: "prod", Organization: "org", Space: "space", Application: "search"},
+			DeploymentInfo: &S.DeploymentInfo{UUID: "1234", ArtifactURL: "https://artifacts.example.com/search.zip"},
+		}
+	})
+
+	It("accepts every event", func() {
+		Expect(publisher.Accepts(event)).To(BeTrue())
+	})
+
+	It("publishes the summary of the event as JSON keyed by the deployment UUID", func() {
+		Expect(publisher.Emit(event)).To(Succeed())
+		go publisher.Run()
+
+		var published message
+		Eventually(fake.messages).Should(Receive(&published))
+		Expect(published.key).To(Equal("1234"))
+
+		summary := S.StreamedEvent{}
+		Expect(json.Unmarshal(published.value, &summary)).To(Succeed())
+		Expect(summary).To(Equal(S.StreamedEvent{
+			Type:        "DeploymentFinishedEvent",
+			Time:        now,
+			UUID:        "1234",
+			Environment: "prod",
+			Org:         "org",
+			Space:       "space",
+			AppName:     "search",
+			ArtifactURL: "https://artifacts.example.com/search.zip",
+		}))
+	})
+
+	It("publishes the error of a failed deployment", func() {
+		event.Error = errors.New("push failed")
+
+		Expect(publisher.Emit(event)).To(Succeed())
+		go publisher.Run()
+
+		var published message
+		Eventually(fake.messages).Should(Receive(&published))
+		Expect(string(published.value)).To(ContainSubstring(`"error":"push failed"`))
+	})
+
+	It("logs the events that cannot be published", func() {
+		fake.err = errors.New("broker is down")
+
+		Expect(publisher.Emit(event)).To(Succeed())
+		go publisher.Run()
+
+		Eventually(logBuffer).Should(Say("cannot publish DeploymentFinishedEvent of deployment 1234 to kafka: broker is down"))
+	})
+
+	It("drops the events that do not fit in the queue", func() {
+		for i := 0; i < QueueSize; i++ {
+			Expect(publisher.Emit(event)).To(Succeed())
+		}
+
+		Expect(publisher.Emit(event)).To(Succeed())
+
+		Eventually(logBuffer).Should(Say("the kafka event publisher is 1000 events behind: dropping DeploymentFinishedEvent of deployment 1234"))
+	})
+})
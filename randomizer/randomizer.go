@@ -25,6 +25,16 @@ func (r Randomizer) StringRunes(length int) string {
 	return generateRunes(length)
 }
 
+// FromAlphabet generates a random string of a specified length from the runes of an alphabet.
+func FromAlphabet(alphabet string, length int) string {
+	runes := []rune(alphabet)
+	b := make([]rune, length)
+	for i := range b {
+		b[i] = runes[rand.Intn(len(runes))]
+	}
+	return string(b)
+}
+
 func generateRunes(length int) string {
 	b := make([]rune, length)
 	for i := range b {
@@ -0,0 +1,51 @@
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type InvalidRuleError struct {
+	Environment string
+	Index       int
+	Reason      string
+}
+
+func (e InvalidRuleError) Error() string {
+	return fmt.Sprintf("access rule %d of environment %s is invalid: %s", e.Index, e.Environment, e.Reason)
+}
+
+type UnknownOperationError struct {
+	Environment string
+	Operation   string
+}
+
+func (e UnknownOperationError) Error() string {
+	return fmt.Sprintf("unknown operation %s in the access rules of environment %s: must be one of %s", e.Operation, e.Environment, strings.Join(Operations, ", "))
+}
+
+// ForbiddenError is an operation the access rules of the environment do not allow the user to run. It is written to
+// the response as JSON, so clients can tell which operation was refused to whom.
+type ForbiddenError struct {
+	User        string `json:"user"`
+	Operation   string `json:"operation"`
+	Environment string `json:"environment"`
+}
+
+func (e ForbiddenError) Error() string {
+	user := e.User
+	if user == "" {
+		user = "an anonymous user"
+	}
+	return fmt.Sprintf("%s may not %s on environment %s", user, e.Operation, e.Environment)
+}
+
+// MarshalJSON adds the message of the error to its fields.
+func (e ForbiddenError) MarshalJSON() ([]byte, error) {
+	type fields ForbiddenError
+	return json.Marshal(struct {
+		Error string `json:"error"`
+		fields
+	}{e.Error(), fields(e)})
+}
@@ -0,0 +1,79 @@
+// Package rbac restricts the operations on the environments with access rules to the users and groups the rules
+// allow.
+package rbac
+
+import (
+	"strings"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// The operations access rules allow.
+const (
+	Deploy  = "deploy"
+	Stop    = "stop"
+	Start   = "start"
+	Restart = "restart"
+	Restage = "restage"
+	Delete  = "delete"
+)
+
+// Operations are the operations access rules allow.
+var Operations = []string{Deploy, Stop, Start, Restart, Restage, Delete}
+
+// Validate returns an error when a rule of the environment allows nobody or no known operation.
+func Validate(environment S.Environment) error {
+	for i, rule := range environment.Access {
+		if len(rule.Users) == 0 && len(rule.Groups) == 0 {
+			return InvalidRuleError{environment.Name, i, "it has no users or groups"}
+		}
+		if len(rule.Operations) == 0 {
+			return InvalidRuleError{environment.Name, i, "it has no operations"}
+		}
+		for _, operation := range rule.Operations {
+			if !contains(Operations, operation) {
+				return UnknownOperationError{environment.Name, operation}
+			}
+		}
+	}
+	return nil
+}
+
+// Authorize returns a ForbiddenError unless the user of the authorization, or one of its groups, may run the
+// operation on the environment. Environments without access rules allow every operation, except to the users of
+// bearer tokens on environments that authenticate their users: they would run it with the Cloud Foundry credentials
+// of the environment, which only an access rule may let them do.
+func Authorize(environment S.Environment, operation string, auth I.Authorization) error {
+	user := auth.User()
+	if len(environment.Access) == 0 {
+		if environment.Authenticate && auth.Subject != "" && auth.Username == "" && auth.Password == "" {
+			return ForbiddenError{User: user, Operation: operation, Environment: environment.Name}
+		}
+		return nil
+	}
+
+	for _, rule := range environment.Access {
+		if !contains(rule.Operations, operation) {
+			continue
+		}
+		if user != "" && contains(rule.Users, user) {
+			return nil
+		}
+		for _, group := range auth.Groups {
+			if contains(rule.Groups, group) {
+				return nil
+			}
+		}
+	}
+	return ForbiddenError{User: user, Operation: operation, Environment: environment.Name}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package rbac_test
+
+import (
+	"encoding/json"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/rbac"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RBAC", func() {
+	var environment S.Environment
+
+	BeforeEach(func() {
+		environment = S.Environment{
+			Name: "production",
+			Access: []S.AccessRule{
+				{Users: []string{"jane"}, Operations: []string{Deploy, Stop, Start, Restart, Restage, Delete}},
+				{Groups: []string{"operators"}, Operations: []string{Stop, Start, Restart}},
+			},
+		}
+	})
+
+	Describe("Authorize", func() {
+		It("allows everything on environments without access rules", func() {
+			Expect(Authorize(S.Environment{Name: "development"}, Delete, I.Authorization{})).To(Succeed())
+		})
+
+		It("allows the operations of the rules of the user", func() {
+			Expect(Authorize(environment, Deploy, I.Authorization{Username: "jane", Password: "the-password"})).To(Succeed())
+			Expect(Authorize(environment, Delete, I.Authorization{Subject: "Jane"})).To(Succeed())
+		})
+
+		It("allows the operations of the rules of the groups of the user", func() {
+			auth := I.Authorization{Username: "john", Groups: []string{"developers", "Operators"}}
+
+			Expect(Authorize(environment, Restart, auth)).To(Succeed())
+			Expect(Authorize(environment, Deploy, auth)).To(MatchError(ForbiddenError{User: "john", Operation: Deploy, Environment: "production"}))
+		})
+
+		It("checks the user of the bearer token rather than the cloud foundry user", func() {
+			auth := I.Authorization{Username: "jane", Subject: "john"}
+
+			Expect(Authorize(environment, Deploy, auth)).To(MatchError(ForbiddenError{User: "john", Operation: Deploy, Environment: "production"}))
+		})
+
+		It("forbids the users of bearer tokens on environments that authenticate without access rules", func() {
+			development := S.Environment{Name: "development", Authenticate: true}
+
+			Expect(Authorize(development, Deploy, I.Authorization{Subject: "jane"})).To(MatchError(ForbiddenError{User: "jane", Operation: Deploy, Environment: "development"}))
+			Expect(Authorize(development, Deploy, I.Authorization{Username: "jane", Password: "the-password"})).To(Succeed())
+			Expect(Authorize(S.Environment{Name: "development"}, Deploy, I.Authorization{Subject: "jane"})).To(Succeed())
+		})
+
+		It("allows the users of bearer tokens on environments that authenticate by their access rules", func() {
+			environment.Authenticate = true
+
+			Expect(Authorize(environment, Deploy, I.Authorization{Subject: "jane"})).To(Succeed())
+			Expect(Authorize(environment, Deploy, I.Authorization{Subject: "john"})).To(MatchError(ForbiddenError{User: "john", Operation: Deploy, Environment: "production"}))
+		})
+
+		It("forbids anonymous requests", func() {
+			err := Authorize(environment, Stop, I.Authorization{})
+
+			Expect(err).To(MatchError(ForbiddenError{Operation: Stop, Environment: "production"}))
+			Expect(err.Error()).To(Equal("an anonymous user may not stop on environment production"))
+		})
+	})
+
+	Describe("ForbiddenError", func() {
+		It("is written as json", func() {
+			body, err := json.Marshal(ForbiddenError{User: "john", Operation: Deploy, Environment: "production"})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(MatchJSON(`{"error": "john may not deploy on environment production", "user": "john", "operation": "deploy", "environment": "production"}`))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts valid access rules", func() {
+			Expect(Validate(environment)).To(Succeed())
+			Expect(Validate(S.Environment{Name: "development"})).To(Succeed())
+		})
+
+		It("refuses unknown operations", func() {
+			environment.Access[1].Operations = []string{"scale"}
+
+			Expect(Validate(environment)).To(MatchError(UnknownOperationError{"production", "scale"}))
+		})
+
+		It("refuses rules that allow nobody or nothing", func() {
+			environment.Access[1].Groups = nil
+			Expect(Validate(environment)).To(MatchError(InvalidRuleError{"production", 1, "it has no users or groups"}))
+
+			environment.Access[0].Operations = nil
+			Expect(Validate(environment)).To(MatchError(InvalidRuleError{"production", 0, "it has no operations"}))
+		})
+	})
+})
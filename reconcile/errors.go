@@ -0,0 +1,96 @@
+package reconcile
+
+import "fmt"
+
+type ReadHistoryError struct {
+	Err error
+}
+
+func (e ReadHistoryError) Error() string {
+	return fmt.Sprintf("cannot read the running deployments from the history: %s", e.Err)
+}
+
+type EnvironmentNotFoundError struct {
+	Environment string
+}
+
+func (e EnvironmentNotFoundError) Error() string {
+	return fmt.Sprintf("environment not found: %s", e.Environment)
+}
+
+type CourierCreationError struct {
+	Err error
+}
+
+func (e CourierCreationError) Error() string {
+	return fmt.Sprintf("cannot create courier: %s", e.Err)
+}
+
+type LoginError struct {
+	FoundationURL string
+	Out           []byte
+}
+
+func (e LoginError) Error() string {
+	return fmt.Sprintf("cannot login to %s: %s", e.FoundationURL, string(e.Out))
+}
+
+type InspectError struct {
+	FoundationURL string
+	Err           error
+}
+
+func (e InspectError) Error() string {
+	return fmt.Sprintf("cannot inspect the applications on %s: %s", e.FoundationURL, e.Err)
+}
+
+type UnmapRouteError struct {
+	AppName string
+	Out     []byte
+}
+
+func (e UnmapRouteError) Error() string {
+	return fmt.Sprintf("cannot unmap the load balanced route of %s: %s", e.AppName, string(e.Out))
+}
+
+type DeleteApplicationError struct {
+	AppName string
+	Out     []byte
+}
+
+func (e DeleteApplicationError) Error() string {
+	return fmt.Sprintf("cannot delete %s: %s", e.AppName, string(e.Out))
+}
+
+type RenameError struct {
+	AppName string
+	Out     []byte
+}
+
+func (e RenameError) Error() string {
+	return fmt.Sprintf("cannot rename %s: %s", e.AppName, string(e.Out))
+}
+
+type ReconcileError struct {
+	FoundationURLs []string
+	Errs           []error
+}
+
+func (e ReconcileError) Error() string {
+	message := "cannot reconcile the deployment:"
+	for i, foundationURL := range e.FoundationURLs {
+		message += fmt.Sprintf("\n%s: %s", foundationURL, e.Errs[i])
+	}
+	return message
+}
+
+type InterruptedError struct {
+	Err error
+}
+
+func (e InterruptedError) Error() string {
+	if e.Err == nil {
+		return "interrupted by a restart of the server and rolled back"
+	}
+	return fmt.Sprintf("interrupted by a restart of the server: %s", e.Err)
+}
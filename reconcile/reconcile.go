@@ -0,0 +1,356 @@
+// Package reconcile finishes the deployments that were interrupted by a restart of the server.
+package reconcile
+
+import (
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/lock"
+	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// The outcomes of reconciling a deployment on a foundation.
+const (
+	Completed  = "completed"
+	RolledBack = "rolled_back"
+	Unchanged  = "unchanged"
+)
+
+// OutcomeMetadataKey is the metadata key of a reconciled deployment holding whether it was completed or rolled back.
+const OutcomeMetadataKey = "reconciled"
+
+type courierCreator interface {
+	CreateCourier() (I.Courier, error)
+}
+
+// NewReconciler returns a Reconciler of the deployments of the history, which logs into the foundations with the
+// credentials of the server, or those of the tenant of the environment.
+func NewReconciler(courierCreator courierCreator, history I.DeploymentHistory, eventManager I.EventManager, log I.Logger, environments map[string]S.Environment, auth I.Authorization) *Reconciler {
+	return &Reconciler{
+		CourierCreator: courierCreator,
+		History:        history,
+		EventManager:   eventManager,
+		Log:            log,
+		Environments:   environments,
+		Auth:           auth,
+		Now:            time.Now,
+	}
+}
+
+// Reconciler inspects the foundations of the deployments the history records as running, which can only have been
+// interrupted when the server starts, and brings every foundation of a deployment to the same blue green state.
+//
+// If the promotion had started on any foundation, which is the case once the original application lost the load
+// balanced route or the new build was renamed, it is completed on every foundation: the original application is
+// deleted and the new build renamed. Otherwise the new build is rolled back on every foundation, as it would have
+// been if the push had failed. Environments without rollback are always completed.
+//
+// When the Reconciler has Locks, a deployment whose application is locked by another deployment is still running on
+// another instance of the server, and is left running.
+type Reconciler struct {
+	CourierCreator courierCreator
+	History        I.DeploymentHistory
+	EventManager   I.EventManager
+	Log            I.Logger
+	Environments   map[string]S.Environment
+	Auth           I.Authorization
+	Credentials    I.CredentialStore
+	Now            func() time.Time
+	Locks          I.LockManager
+}
+
+// foundation is the blue green state of a deployment on a foundation.
+type foundation struct {
+	url     string
+	courier I.Courier
+
+	tempExists      bool
+	appExists       bool
+	venerableExists bool
+
+	// promoted is set when the new build was already renamed to the application name.
+	promoted bool
+
+	// servesRoute is set when the original application still has the load balanced route.
+	servesRoute bool
+}
+
+// started reports whether the promotion had started on the foundation.
+func (f foundation) started() bool {
+	return f.promoted || (f.tempExists && (f.venerableExists || (f.appExists && !f.servesRoute)))
+}
+
+// Reconcile reconciles every deployment the history records as running. It is meant to be run when the server
+// becomes the leader, either before any deployment has started or with Locks.
+//
+// A deployment whose foundations cannot all be inspected is left running, so it is reconciled by the next leader.
+func (r *Reconciler) Reconcile() error {
+	records, err := r.History.Find(S.DeploymentQuery{Status: S.DeploymentRunning})
+	if err != nil {
+		return ReadHistoryError{Err: err}
+	}
+
+	for _, record := range records {
+		r.reconcile(record)
+	}
+
+	return nil
+}
+
+func (r *Reconciler) reconcile(record S.DeploymentRecord) {
+	log := I.DeploymentLogger{Log: r.Log, UUID: record.UUID}
+
+	if r.Locks != nil {
+		key := lock.AppKey(record.Environment, record.Org, record.Space, record.AppName)
+		owner := "reconcile-" + record.UUID
+		err := r.Locks.Lock(key, owner)
+		if _, ok := err.(lock.HeldError); ok {
+			log.Infof("not reconciling the deployment of %s: %s", record.AppName, err)
+			return
+		}
+		if err != nil {
+			log.Errorf("cannot reconcile the deployment of %s: %s", record.AppName, err)
+			return
+		}
+		defer r.Locks.Unlock(key, owner)
+
+		// The deployment may have finished since it was read.
+		current, err := r.History.Get(record.UUID)
+		if err != nil || current.Status != S.DeploymentRunning {
+			return
+		}
+	}
+
+	environment, ok := r.Environments[record.Environment]
+	if !ok {
+		log.Errorf("cannot reconcile the deployment of %s: environment %s is not configured", record.AppName, record.Environment)
+		r.finish(record, log, "", EnvironmentNotFoundError{Environment: record.Environment})
+		return
+	}
+	environment.Name = record.Environment
+
+	foundationURLs := record.Foundations
+	if len(foundationURLs) == 0 {
+		foundationURLs = environment.Foundations
+	}
+
+	var foundations []foundation
+	defer func() {
+		for _, f := range foundations {
+			f.courier.CleanUp()
+		}
+	}()
+
+	for _, foundationURL := range foundationURLs {
+		f, err := r.inspect(record, environment, foundationURL)
+		if f.courier != nil {
+			foundations = append(foundations, f)
+		}
+		if err != nil {
+			log.Errorf("cannot reconcile the deployment of %s until %s can be inspected: %s", record.AppName, foundationURL, err)
+			return
+		}
+	}
+
+	complete := !environment.EnableRollback
+	for _, f := range foundations {
+		complete = complete || f.started()
+	}
+
+	outcome := RolledBack
+	if complete {
+		outcome = Completed
+	}
+	log.Infof("reconciling the interrupted deployment of %s: the push is %s on every foundation", record.AppName, outcomeDescription(outcome))
+
+	reconcileErr := ReconcileError{}
+	for _, f := range foundations {
+		var foundationOutcome string
+		var err error
+		if complete {
+			foundationOutcome, err = r.complete(record, environment, f, log)
+		} else {
+			foundationOutcome, err = r.rollBack(record, f, log)
+		}
+		if err != nil {
+			log.Errorf("cannot reconcile the deployment of %s on %s: %s", record.AppName, f.url, err)
+			reconcileErr.FoundationURLs = append(reconcileErr.FoundationURLs, f.url)
+			reconcileErr.Errs = append(reconcileErr.Errs, err)
+		}
+
+		eventErr := r.EventManager.EmitEvent(push.DeploymentReconciledEvent{
+			Record:        record,
+			Environment:   environment,
+			FoundationURL: f.url,
+			Outcome:       foundationOutcome,
+			Err:           err,
+			Log:           log,
+		})
+		if eventErr != nil {
+			log.Errorf("an error occurred when emitting a DeploymentReconciledEvent: %s", eventErr)
+		}
+	}
+
+	if len(reconcileErr.FoundationURLs) != 0 {
+		r.finish(record, log, outcome, reconcileErr)
+		return
+	}
+	r.finish(record, log, outcome, nil)
+}
+
+func (r *Reconciler) inspect(record S.DeploymentRecord, environment S.Environment, foundationURL string) (foundation, error) {
+	f := foundation{url: foundationURL}
+
+	courier, err := r.CourierCreator.CreateCourier()
+	if err != nil {
+		return f, CourierCreationError{Err: err}
+	}
+	if environment.CABundle != "" {
+		courier = courier.WithCABundle(environment.CABundle)
+	}
+	f.courier = courier
+
+	username, password := environment.Credentials(r.Auth.Username, r.Auth.Password)
+	if environment.VaultPath != "" && r.Credentials != nil {
+		username, password, err = r.Credentials.Credentials(environment.VaultPath)
+		if err != nil {
+			return f, err
+		}
+	}
+	out, err := courier.Login(foundationURL, username, password, record.Org, record.Space, environment.SkipSSL)
+	if err != nil {
+		return f, LoginError{FoundationURL: foundationURL, Out: out}
+	}
+
+	f.tempExists = courier.Exists(tempAppName(record))
+	f.appExists = courier.Exists(record.AppName)
+	f.venerableExists = courier.Exists(record.AppName + push.VenerableSuffix)
+
+	if f.appExists {
+		state, err := courier.AppState(record.AppName)
+		if err != nil {
+			return f, InspectError{FoundationURL: foundationURL, Err: err}
+		}
+		f.promoted = state.Labels[push.DeploymentLabel] == record.UUID
+
+		f.servesRoute = true
+		if environment.Domain != "" {
+			routes, err := courier.AppRoutes(record.AppName)
+			if err != nil {
+				return f, InspectError{FoundationURL: foundationURL, Err: err}
+			}
+			f.servesRoute = contains(routes, record.AppName+"."+environment.Domain)
+		}
+	}
+
+	return f, nil
+}
+
+// complete retires the original application and renames the new build, as the success of the push would have.
+func (r *Reconciler) complete(record S.DeploymentRecord, environment S.Environment, f foundation, log I.DeploymentLogger) (string, error) {
+	outcome := Unchanged
+
+	if f.tempExists {
+		if f.appExists && !f.promoted {
+			if environment.Domain != "" && f.servesRoute {
+				out, err := f.courier.UnmapRoute(record.AppName, environment.Domain, record.AppName)
+				if err != nil {
+					return outcome, UnmapRouteError{AppName: record.AppName, Out: out}
+				}
+			}
+
+			out, err := f.courier.Delete(record.AppName)
+			if err != nil {
+				return outcome, DeleteApplicationError{AppName: record.AppName, Out: out}
+			}
+			log.Infof("deleted the original application %s on %s", record.AppName, f.url)
+		}
+
+		out, err := f.courier.Rename(tempAppName(record), record.AppName)
+		if err != nil {
+			return outcome, RenameError{AppName: tempAppName(record), Out: out}
+		}
+		log.Infof("renamed %s to %s on %s", tempAppName(record), record.AppName, f.url)
+		outcome = Completed
+	}
+
+	if f.venerableExists && (f.tempExists || f.promoted) {
+		venerable := record.AppName + push.VenerableSuffix
+		out, err := f.courier.Delete(venerable)
+		if err != nil {
+			return outcome, DeleteApplicationError{AppName: venerable, Out: out}
+		}
+		log.Infof("deleted the venerable application %s on %s", venerable, f.url)
+		outcome = Completed
+	}
+
+	return outcome, nil
+}
+
+// rollBack deletes the new build, or renames it on a first deployment, as the rollback of the push would have.
+func (r *Reconciler) rollBack(record S.DeploymentRecord, f foundation, log I.DeploymentLogger) (string, error) {
+	if !f.tempExists {
+		return Unchanged, nil
+	}
+
+	if f.appExists {
+		out, err := f.courier.Delete(tempAppName(record))
+		if err != nil {
+			return Unchanged, DeleteApplicationError{AppName: tempAppName(record), Out: out}
+		}
+		log.Infof("deleted %s on %s", tempAppName(record), f.url)
+		return RolledBack, nil
+	}
+
+	out, err := f.courier.Rename(tempAppName(record), record.AppName)
+	if err != nil {
+		return Unchanged, RenameError{AppName: tempAppName(record), Out: out}
+	}
+	log.Infof("app %s did not previously exist on %s: renamed %s to %s", record.AppName, f.url, tempAppName(record), record.AppName)
+	return RolledBack, nil
+}
+
+// finish records the deployment as finished. A rolled back deployment failed, and so did a deployment that could
+// not be reconciled.
+func (r *Reconciler) finish(record S.DeploymentRecord, log I.DeploymentLogger, outcome string, err error) {
+	record.FinishedAt = r.Now().UTC()
+	record.Status = S.DeploymentSucceeded
+	record.Error = ""
+
+	if outcome != "" {
+		record.Metadata = S.MergeMetadata(record.Metadata, map[string]string{OutcomeMetadataKey: outcome})
+	}
+	if err != nil {
+		record.Status = S.DeploymentFailed
+		record.Error = InterruptedError{Err: err}.Error()
+	} else if outcome == RolledBack {
+		record.Status = S.DeploymentFailed
+		record.Error = InterruptedError{}.Error()
+	}
+
+	recordErr := r.History.Record(record)
+	if recordErr != nil {
+		log.Errorf("cannot record the reconciled deployment: %s", recordErr)
+	}
+}
+
+func tempAppName(record S.DeploymentRecord) string {
+	return record.AppName + push.TemporaryNameSuffix + record.UUID
+}
+
+func outcomeDescription(outcome string) string {
+	if outcome == Completed {
+		return "completed"
+	}
+	return "rolled back"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,318 @@
+package reconcile_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/compozed/deployadactyl/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/lock"
+	"github.com/compozed/deployadactyl/mocks"
+	. "github.com/compozed/deployadactyl/reconcile"
+	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+// courierCreator returns its couriers in turn, one per foundation.
+type courierCreator struct {
+	couriers []*mocks.Courier
+	created  *int
+}
+
+func (c courierCreator) CreateCourier() (I.Courier, error) {
+	courier := c.couriers[*c.created]
+	*c.created++
+	return courier, nil
+}
+
+var _ = Describe("Reconciler", func() {
+	var (
+		east, west   *mocks.Courier
+		created      int
+		eventManager *mocks.EventManager
+		deployments  *history.MemoryHistory
+		logBuffer    *Buffer
+		now          time.Time
+		record       S.DeploymentRecord
+		environment  S.Environment
+		locks        I.LockManager
+	)
+
+	const (
+		eastURL   = "https://api.east.example.com"
+		westURL   = "https://api.west.example.com"
+		temp      = "search-new-build-1234"
+		venerable = "search-venerable"
+	)
+
+	withApps := func(courier *mocks.Courier, apps ...string) {
+		courier.ExistsCall.Returns.Apps = map[string]bool{}
+		for _, app := range apps {
+			courier.ExistsCall.Returns.Apps[app] = true
+		}
+	}
+
+	reconciled := func() S.DeploymentRecord {
+		r, err := deployments.Get("1234")
+		Expect(err).ToNot(HaveOccurred())
+		return r
+	}
+
+	BeforeEach(func() {
+		east = &mocks.Courier{}
+		west = &mocks.Courier{}
+		for _, courier := range []*mocks.Courier{east, west} {
+			withApps(courier, "search", temp)
+			courier.AppRoutesCall.Returns.Routes = []string{"search.example.com"}
+		}
+		created = 0
+
+		eventManager = &mocks.EventManager{}
+		deployments = history.NewMemoryHistory(10)
+		logBuffer = NewBuffer()
+		now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+		locks = nil
+
+		environment = S.Environment{
+			Foundations:    []string{eastURL, westURL},
+			Domain:         "example.com",
+			SkipSSL:        true,
+			EnableRollback: true,
+		}
+
+		record = S.DeploymentRecord{
+			UUID:        "1234",
+			Environment: "prod",
+			Org:         "org",
+			Space:       "space",
+			AppName:     "search",
+			Status:      S.DeploymentRunning,
+			StartedAt:   now.Add(-time.Minute),
+			Foundations: []string{eastURL, westURL},
+		}
+	})
+
+	run := func() error {
+		Expect(deployments.Record(record)).To(Succeed())
+
+		reconciler := NewReconciler(
+			courierCreator{couriers: []*mocks.Courier{east, west}, created: &created},
+			deployments,
+			eventManager,
+			I.DefaultLogger(logBuffer, logging.DEBUG, "reconcile_test"),
+			map[string]S.Environment{"prod": environment},
+			I.Authorization{Username: "username", Password: "password"},
+		)
+		reconciler.Now = func() time.Time { return now }
+		reconciler.Locks = locks
+
+		return reconciler.Reconcile()
+	}
+
+	It("logs into every foundation with the credentials of the server", func() {
+		Expect(run()).To(Succeed())
+
+		Expect(east.LoginCall.Received.FoundationURL).To(Equal(eastURL))
+		Expect(west.LoginCall.Received.FoundationURL).To(Equal(westURL))
+		Expect(east.LoginCall.Received.Username).To(Equal("username"))
+		Expect(east.LoginCall.Received.Password).To(Equal("password"))
+		Expect(east.LoginCall.Received.Org).To(Equal("org"))
+		Expect(east.LoginCall.Received.Space).To(Equal("space"))
+		Expect(east.LoginCall.Received.SkipSSL).To(BeTrue())
+		Expect(east.CleanUpCall.Called).To(BeTrue())
+		Expect(west.CleanUpCall.Called).To(BeTrue())
+	})
+
+	Context("when the deployment was interrupted before the promotion started", func() {
+		It("deletes the new build on every foundation", func() {
+			Expect(run()).To(Succeed())
+
+			Expect(east.DeleteCall.Received.AppName).To(Equal(temp))
+			Expect(west.DeleteCall.Received.AppName).To(Equal(temp))
+			Expect(east.RenameCall.Received.AppName).To(BeEmpty())
+		})
+
+		It("records the deployment as failed", func() {
+			Expect(run()).To(Succeed())
+
+			Expect(reconciled().Status).To(Equal(S.DeploymentFailed))
+			Expect(reconciled().Error).To(Equal("interrupted by a restart of the server and rolled back"))
+			Expect(reconciled().FinishedAt).To(Equal(now))
+			Expect(reconciled().Metadata).To(HaveKeyWithValue(OutcomeMetadataKey, RolledBack))
+		})
+
+		It("emits a DeploymentReconciledEvent for every foundation", func() {
+			Expect(run()).To(Succeed())
+
+			Expect(eventManager.EmitEventCall.Received.Events).To(HaveLen(2))
+			event := eventManager.EmitEventCall.Received.Events[1].(push.DeploymentReconciledEvent)
+			Expect(event.Name()).To(Equal("DeploymentReconciledEvent"))
+			Expect(event.FoundationURL).To(Equal(westURL))
+			Expect(event.Outcome).To(Equal(RolledBack))
+			Expect(event.Record.UUID).To(Equal("1234"))
+			Expect(event.Environment.Name).To(Equal("prod"))
+			Expect(event.Err).ToNot(HaveOccurred())
+		})
+
+		It("renames the new build of a first deployment", func() {
+			withApps(west, temp)
+
+			Expect(run()).To(Succeed())
+
+			Expect(west.DeleteCall.Received.AppName).To(BeEmpty())
+			Expect(west.RenameCall.Received.AppName).To(Equal(temp))
+			Expect(west.RenameCall.Received.AppNameVenerable).To(Equal("search"))
+		})
+
+		It("completes the push when the environment does not roll back", func() {
+			environment.EnableRollback = false
+
+			Expect(run()).To(Succeed())
+
+			Expect(east.UnmapRouteCall.Received.AppName).To(Equal("search"))
+			Expect(east.UnmapRouteCall.Received.Domain).To(Equal("example.com"))
+			Expect(east.UnmapRouteCall.Received.Hostname).To(Equal("search"))
+			Expect(east.DeleteCall.Received.AppName).To(Equal("search"))
+			Expect(east.RenameCall.Received.AppName).To(Equal(temp))
+			Expect(reconciled().Status).To(Equal(S.DeploymentSucceeded))
+		})
+	})
+
+	Context("when the promotion started on a foundation", func() {
+		BeforeEach(func() {
+			withApps(west, "search", venerable)
+			west.AppStateCall.Returns.AppState = S.AppState{Labels: map[string]string{push.DeploymentLabel: "1234"}}
+		})
+
+		It("completes the push on every foundation", func() {
+			Expect(run()).To(Succeed())
+
+			Expect(east.UnmapRouteCall.Received.AppName).To(Equal("search"))
+			Expect(east.DeleteCall.Received.AppName).To(Equal("search"))
+			Expect(east.RenameCall.Received.AppName).To(Equal(temp))
+			Expect(east.RenameCall.Received.AppNameVenerable).To(Equal("search"))
+
+			Expect(west.RenameCall.Received.AppName).To(BeEmpty())
+			Expect(west.DeleteCall.Received.AppName).To(Equal(venerable))
+		})
+
+		It("records the deployment as succeeded", func() {
+			Expect(run()).To(Succeed())
+
+			Expect(reconciled().Status).To(Equal(S.DeploymentSucceeded))
+			Expect(reconciled().Error).To(BeEmpty())
+			Expect(reconciled().Metadata).To(HaveKeyWithValue(OutcomeMetadataKey, Completed))
+
+			event := eventManager.EmitEventCall.Received.Events[0].(push.DeploymentReconciledEvent)
+			Expect(event.Outcome).To(Equal(Completed))
+		})
+
+		It("does not unmap a route the original application already lost", func() {
+			east.AppRoutesCall.Returns.Routes = []string{}
+
+			Expect(run()).To(Succeed())
+
+			Expect(east.UnmapRouteCall.Received.AppName).To(BeEmpty())
+			Expect(east.DeleteCall.Received.AppName).To(Equal("search"))
+		})
+
+		It("records the deployment as failed when a foundation cannot be reconciled", func() {
+			east.RenameCall.Returns.Output = []byte("rename failed")
+			east.RenameCall.Returns.Error = errors.New("rename failed")
+
+			Expect(run()).To(Succeed())
+
+			Expect(west.DeleteCall.Received.AppName).To(Equal(venerable))
+			Expect(reconciled().Status).To(Equal(S.DeploymentFailed))
+			Expect(reconciled().Error).To(ContainSubstring(eastURL))
+			Expect(reconciled().Error).To(ContainSubstring("rename failed"))
+
+			event := eventManager.EmitEventCall.Received.Events[0].(push.DeploymentReconciledEvent)
+			Expect(event.Err).To(MatchError(RenameError{AppName: temp, Out: []byte("rename failed")}))
+		})
+	})
+
+	It("completes the push when the original application lost the load balanced route", func() {
+		east.AppRoutesCall.Returns.Routes = []string{"search-internal.example.com"}
+
+		Expect(run()).To(Succeed())
+
+		Expect(east.UnmapRouteCall.Received.AppName).To(BeEmpty())
+		Expect(east.DeleteCall.Received.AppName).To(Equal("search"))
+		Expect(west.UnmapRouteCall.Received.AppName).To(Equal("search"))
+		Expect(west.RenameCall.Received.AppName).To(Equal(temp))
+	})
+
+	It("uses the foundations of the environment when the deployment did not record any", func() {
+		record.Foundations = nil
+		environment.Foundations = []string{westURL}
+
+		Expect(run()).To(Succeed())
+
+		Expect(east.LoginCall.Received.FoundationURL).To(Equal(westURL))
+		Expect(eventManager.EmitEventCall.Received.Events).To(HaveLen(1))
+	})
+
+	It("leaves the deployment running when a foundation cannot be inspected", func() {
+		west.LoginCall.Returns.Output = []byte("login failed")
+		west.LoginCall.Returns.Error = errors.New("login failed")
+
+		Expect(run()).To(Succeed())
+
+		Expect(east.DeleteCall.Received.AppName).To(BeEmpty())
+		Expect(eventManager.EmitEventCall.Received.Events).To(BeEmpty())
+		Expect(reconciled().Status).To(Equal(S.DeploymentRunning))
+		Expect(east.CleanUpCall.Called).To(BeTrue())
+		Expect(west.CleanUpCall.Called).To(BeTrue())
+		Eventually(logBuffer).Should(Say("cannot login to " + westURL))
+	})
+
+	It("records the deployment as failed when its environment is not configured", func() {
+		record.Environment = "stage"
+
+		Expect(run()).To(Succeed())
+
+		Expect(east.LoginCall.Received.FoundationURL).To(BeEmpty())
+		Expect(reconciled().Status).To(Equal(S.DeploymentFailed))
+		Expect(reconciled().Error).To(ContainSubstring("environment not found: stage"))
+	})
+
+	Context("with locks", func() {
+		var memoryLocks *lock.MemoryLocks
+
+		BeforeEach(func() {
+			memoryLocks = lock.NewMemoryLocks()
+			locks = memoryLocks
+		})
+
+		It("leaves a deployment running when its application is locked by another instance", func() {
+			Expect(memoryLocks.Lock("prod/org/space/search", "1234")).To(Succeed())
+
+			Expect(run()).To(Succeed())
+
+			Expect(created).To(BeZero())
+			Expect(reconciled().Status).To(Equal(S.DeploymentRunning))
+			Eventually(logBuffer).Should(Say("not reconciling the deployment of search: prod/org/space/search is locked by deployment 1234"))
+		})
+
+		It("reconciles a deployment whose application is not locked, and releases the lock", func() {
+			Expect(run()).To(Succeed())
+
+			Expect(reconciled().Status).To(Equal(S.DeploymentFailed))
+			Expect(memoryLocks.Lock("prod/org/space/search", "5678")).To(Succeed())
+		})
+	})
+
+	It("does nothing to finished deployments", func() {
+		record.Status = S.DeploymentSucceeded
+
+		Expect(run()).To(Succeed())
+
+		Expect(created).To(BeZero())
+		Expect(eventManager.EmitEventCall.Received.Events).To(BeEmpty())
+	})
+})
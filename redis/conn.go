@@ -0,0 +1,163 @@
+package redis
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The types of the replies of the protocol.
+const (
+	simpleString = '+'
+	errorReply   = '-'
+	integer      = ':'
+	bulkString   = '$'
+	array        = '*'
+)
+
+// maxBulk is the largest bulk string or array read from Redis.
+const maxBulk = 64 << 20
+
+// conn is a connection to Redis, which runs one command at a time.
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// connect opens a connection to Redis, logs into it when the config has a password, and selects the database.
+func connect(config Config, tlsConfig *tls.Config, timeout time.Duration) (*conn, error) {
+	raw, err := net.DialTimeout("tcp", config.Address, timeout)
+	if err != nil {
+		return nil, ConnectError{config.Address, err}
+	}
+
+	if tlsConfig != nil {
+		secure := tls.Client(raw, tlsConfig)
+		secure.SetDeadline(time.Now().Add(timeout))
+		err = secure.Handshake()
+		if err != nil {
+			raw.Close()
+			return nil, ConnectError{config.Address, err}
+		}
+		raw = secure
+	}
+
+	c := &conn{Conn: raw, reader: bufio.NewReader(raw)}
+
+	if config.Password != "" {
+		auth := []string{"AUTH", config.Password}
+		if config.Username != "" {
+			auth = []string{"AUTH", config.Username, config.Password}
+		}
+		_, err = c.do(auth, timeout)
+		if err != nil {
+			c.Close()
+			return nil, ConnectError{config.Address, err}
+		}
+	}
+
+	if config.Database != 0 {
+		_, err = c.do([]string{"SELECT", strconv.Itoa(config.Database)}, timeout)
+		if err != nil {
+			c.Close()
+			return nil, ConnectError{config.Address, err}
+		}
+	}
+
+	return c, nil
+}
+
+// do sends the command and reads its reply.
+func (c *conn) do(args []string, timeout time.Duration) (interface{}, error) {
+	c.SetDeadline(time.Now().Add(timeout))
+
+	err := c.send(args, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// send sends the command as an array of bulk strings.
+func (c *conn) send(args []string, timeout time.Duration) error {
+	c.SetWriteDeadline(time.Now().Add(timeout))
+
+	command := []byte{array}
+	command = strconv.AppendInt(command, int64(len(args)), 10)
+	command = append(command, '\r', '\n')
+	for _, arg := range args {
+		command = append(command, bulkString)
+		command = strconv.AppendInt(command, int64(len(arg)), 10)
+		command = append(command, '\r', '\n')
+		command = append(command, arg...)
+		command = append(command, '\r', '\n')
+	}
+
+	_, err := c.Write(command)
+	return err
+}
+
+// read reads a reply. The error reply of a command is returned as an Error once the whole reply is read, so the
+// connection can still be used.
+func (c *conn) read() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, UnexpectedReplyError{line}
+	}
+	value := line[1 : len(line)-2]
+
+	switch line[0] {
+	case simpleString:
+		return value, nil
+	case errorReply:
+		return nil, Error{value}
+	case integer:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, UnexpectedReplyError{line}
+		}
+		return n, nil
+	case bulkString:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < -1 || n > maxBulk {
+			return nil, UnexpectedReplyError{line}
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		_, err = io.ReadFull(c.reader, b)
+		if err != nil {
+			return nil, err
+		}
+		return string(b[:n]), nil
+	case array:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < -1 || n > maxBulk {
+			return nil, UnexpectedReplyError{line}
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		var replyErr error
+		elements := make([]interface{}, n)
+		for i := range elements {
+			elements[i], err = c.read()
+			if _, ok := err.(Error); ok {
+				replyErr = err
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		return elements, replyErr
+	}
+
+	return nil, UnexpectedReplyError{line}
+}
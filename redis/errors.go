@@ -0,0 +1,37 @@
+package redis
+
+import "fmt"
+
+type InvalidURLError struct {
+	Reason string
+}
+
+func (e InvalidURLError) Error() string {
+	return fmt.Sprintf("invalid redis url: %s", e.Reason)
+}
+
+type ConnectError struct {
+	Address string
+	Err     error
+}
+
+func (e ConnectError) Error() string {
+	return fmt.Sprintf("cannot connect to redis at %s: %s", e.Address, e.Err)
+}
+
+type UnexpectedReplyError struct {
+	Line string
+}
+
+func (e UnexpectedReplyError) Error() string {
+	return fmt.Sprintf("unexpected reply %q from redis", e.Line)
+}
+
+// Error is an error reply of Redis, such as a wrong number of arguments or a failed script.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("redis: %s", e.Message)
+}
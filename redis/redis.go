@@ -0,0 +1,154 @@
+// Package redis is a minimal Redis client, enough for the instances of the server to coordinate through a shared
+// Redis. It speaks RESP2 over TCP, optionally with TLS, and authenticates with AUTH.
+package redis
+
+import (
+	"crypto/tls"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/compozed/deployadactyl/cabundle"
+	"github.com/spf13/afero"
+)
+
+// DefaultTimeout is how long connecting to Redis and running a command may take.
+const DefaultTimeout = 10 * time.Second
+
+// Config is where Redis is and how to log into it.
+type Config struct {
+	Address  string
+	Username string
+	Password string
+	Database int
+	TLS      bool
+	CABundle string
+}
+
+// ParseURL returns the Config of a URL such as rediss://:password@host:6379/0. The scheme is redis, or rediss to
+// connect with TLS. The path is the number of the database, 0 by default, and ca_bundle is the path of the CA bundle
+// trusted instead of the system roots.
+func ParseURL(redisURL string) (Config, error) {
+	u, err := url.Parse(redisURL)
+	if err != nil {
+		return Config{}, InvalidURLError{"it cannot be parsed"}
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return Config{}, InvalidURLError{"its scheme is not redis or rediss"}
+	}
+	if u.Host == "" {
+		return Config{}, InvalidURLError{"it has no host"}
+	}
+
+	address := u.Host
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(strings.Trim(address, "[]"), "6379")
+	}
+
+	database := 0
+	if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+		database, err = strconv.Atoi(path)
+		if err != nil || database < 0 {
+			return Config{}, InvalidURLError{"its path is not the number of a database"}
+		}
+	}
+
+	config := Config{
+		Address:  address,
+		Database: database,
+		TLS:      u.Scheme == "rediss",
+		CABundle: u.Query().Get("ca_bundle"),
+	}
+	if u.User != nil {
+		config.Username = u.User.Username()
+		config.Password, _ = u.User.Password()
+	}
+	return config, nil
+}
+
+// Open returns the Client of the URL. It does not connect until the first command is run.
+func Open(redisURL string, fs *afero.Afero) (*Client, error) {
+	config, err := ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{Config: config, Timeout: DefaultTimeout}
+	if !config.TLS {
+		return client, nil
+	}
+
+	tlsConfig, err := cabundle.TLSConfig(fs, config.CABundle)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.ServerName, _, _ = net.SplitHostPort(config.Address)
+	client.TLSConfig = tlsConfig
+
+	return client, nil
+}
+
+// Client runs commands on Redis over a single connection, one command at a time. The connection is opened when the
+// first command is run, and opened again when it is lost.
+type Client struct {
+	Config    Config
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+
+	mu   sync.Mutex
+	conn *conn
+}
+
+// Do runs the command and returns its reply: a string for simple and bulk strings, an int64 for integers, nil for
+// null replies and a []interface{} for arrays. Error replies are returned as an Error.
+//
+// A command is run once more on a new connection when the connection was lost, so it may be run twice.
+func (c *Client) Do(args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reused := c.conn != nil
+	reply, err := c.do(args)
+	if err != nil && reused && c.conn == nil {
+		reply, err = c.do(args)
+	}
+	return reply, err
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) do(args []string) (interface{}, error) {
+	if c.conn == nil {
+		cn, err := connect(c.Config, c.TLSConfig, c.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = cn
+	}
+
+	reply, err := c.conn.do(args, c.Timeout)
+	if err != nil {
+		if _, ok := err.(Error); !ok {
+			c.conn.Close()
+			c.conn = nil
+		}
+	}
+	return reply, err
+}
@@ -0,0 +1,69 @@
+package redis
+
+import "time"
+
+// Message is a message published on a channel.
+type Message struct {
+	Channel string
+	Payload string
+}
+
+// Subscribe opens a connection of its own, subscribed to the channels matching the pattern.
+func (c *Client) Subscribe(pattern string) (*Subscription, error) {
+	cn, err := connect(c.Config, c.TLSConfig, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = cn.do([]string{"PSUBSCRIBE", pattern}, c.Timeout)
+	if err != nil {
+		cn.Close()
+		return nil, err
+	}
+	return &Subscription{conn: cn, timeout: c.Timeout}, nil
+}
+
+// Subscription receives the messages published on the channels it is subscribed to. Redis sends nothing on an
+// idle subscription, so Ping must be called more often than the timeout of Receive.
+type Subscription struct {
+	conn    *conn
+	timeout time.Duration
+}
+
+// Receive waits for the next message for as long as the timeout. The replies to Ping are skipped but make it wait
+// for as long again.
+func (s *Subscription) Receive(timeout time.Duration) (Message, error) {
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(timeout))
+		reply, err := s.conn.read()
+		if err != nil {
+			return Message{}, err
+		}
+
+		elements, ok := reply.([]interface{})
+		if !ok || len(elements) == 0 {
+			return Message{}, UnexpectedReplyError{"a reply that is not a message"}
+		}
+		if elements[0] != "pmessage" {
+			continue
+		}
+		if len(elements) != 4 {
+			return Message{}, UnexpectedReplyError{"a message without a channel or payload"}
+		}
+
+		channel, _ := elements[2].(string)
+		payload, _ := elements[3].(string)
+		return Message{Channel: channel, Payload: payload}, nil
+	}
+}
+
+// Ping asks Redis for a reply, so Receive keeps waiting on a connection that was not lost. It may be called while
+// Receive waits.
+func (s *Subscription) Ping() error {
+	return s.conn.send([]string{"PING"}, s.timeout)
+}
+
+// Close closes the connection, which ends a Receive waiting on it.
+func (s *Subscription) Close() error {
+	return s.conn.Close()
+}
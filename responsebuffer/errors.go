@@ -0,0 +1,38 @@
+package responsebuffer
+
+import "fmt"
+
+type CreateFileError struct {
+	Err error
+}
+
+func (e CreateFileError) Error() string {
+	return fmt.Sprintf("cannot create a temporary file for the deployment output: %s", e.Err)
+}
+
+type WriteError struct {
+	Path string
+	Err  error
+}
+
+func (e WriteError) Error() string {
+	return fmt.Sprintf("cannot write the deployment output to %s: %s", e.Path, e.Err)
+}
+
+type ReadError struct {
+	Path string
+	Err  error
+}
+
+func (e ReadError) Error() string {
+	return fmt.Sprintf("cannot read the deployment output from %s: %s", e.Path, e.Err)
+}
+
+type RemoveError struct {
+	Path string
+	Err  error
+}
+
+func (e RemoveError) Error() string {
+	return fmt.Sprintf("cannot remove the deployment output %s: %s", e.Path, e.Err)
+}
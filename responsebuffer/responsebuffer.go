@@ -0,0 +1,165 @@
+// Package responsebuffer holds the output of a deployment in memory up to a limit, and in a temporary file beyond it,
+// so a deployment with a huge output cannot exhaust the memory of the server.
+package responsebuffer
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/compozed/deployadactyl/tempdir"
+	"github.com/spf13/afero"
+)
+
+// FilePrefix is the prefix of the temporary files holding the outputs that did not fit in memory. Those left behind
+// by a crash are removed with the other temporary directories when the server starts.
+const FilePrefix = tempdir.Prefix + "response-"
+
+// New returns an empty Buffer that keeps up to maxMemoryBytes in memory and the rest in a temporary file of dir, or
+// of the temporary directory of the operating system when dir is empty.
+func New(fileSystem *afero.Afero, dir string, maxMemoryBytes int64) *Buffer {
+	return &Buffer{
+		FileSystem:     fileSystem,
+		Dir:            dir,
+		MaxMemoryBytes: maxMemoryBytes,
+	}
+}
+
+// Buffer is read and written like a bytes.Buffer. Once its unread bytes would grow past MaxMemoryBytes they are
+// moved to a temporary file, which receives every later write. Zero keeps everything in memory.
+//
+// Close removes the temporary file, so it must be called once the output is no longer needed.
+//
+// When Stream is set, every write is copied to it as well, so the output can be followed while it is written. An
+// error of Stream never fails a write.
+type Buffer struct {
+	FileSystem     *afero.Afero
+	Dir            string
+	MaxMemoryBytes int64
+	Stream         io.Writer
+
+	memory      bytes.Buffer
+	file        afero.File
+	readOffset  int64
+	writeOffset int64
+}
+
+// Write appends p to the buffer, moving it to a temporary file when it grows past MaxMemoryBytes.
+func (b *Buffer) Write(p []byte) (int, error) {
+	if b.Stream != nil {
+		b.Stream.Write(p)
+	}
+
+	if b.file == nil {
+		if b.MaxMemoryBytes <= 0 || int64(b.memory.Len()+len(p)) <= b.MaxMemoryBytes {
+			return b.memory.Write(p)
+		}
+
+		err := b.spill()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := b.file.WriteAt(p, b.writeOffset)
+	b.writeOffset += int64(n)
+	if err != nil {
+		return n, WriteError{b.file.Name(), err}
+	}
+	return n, nil
+}
+
+// Read reads the next bytes of the buffer. It returns io.EOF once every written byte has been read.
+func (b *Buffer) Read(p []byte) (int, error) {
+	if b.file == nil {
+		return b.memory.Read(p)
+	}
+
+	if b.readOffset >= b.writeOffset {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+
+	if unread := b.writeOffset - b.readOffset; int64(len(p)) > unread {
+		p = p[:unread]
+	}
+
+	n, err := b.file.ReadAt(p, b.readOffset)
+	b.readOffset += int64(n)
+	if err != nil && !(err == io.EOF && n > 0) {
+		return n, ReadError{b.file.Name(), err}
+	}
+	return n, nil
+}
+
+// Len returns how many bytes have not been read yet.
+func (b *Buffer) Len() int64 {
+	if b.file == nil {
+		return int64(b.memory.Len())
+	}
+	return b.writeOffset - b.readOffset
+}
+
+// Spilled reports whether the buffer grew past MaxMemoryBytes and is held in a temporary file.
+func (b *Buffer) Spilled() bool {
+	return b.file != nil
+}
+
+// Tail returns at most the last n unread bytes, without reading them.
+func (b *Buffer) Tail(n int64) ([]byte, error) {
+	length := b.Len()
+	if n > length {
+		n = length
+	}
+
+	if b.file == nil {
+		return append([]byte{}, b.memory.Bytes()[length-n:]...), nil
+	}
+
+	tail := make([]byte, n)
+	read, err := b.file.ReadAt(tail, b.writeOffset-n)
+	if err != nil && !(err == io.EOF && int64(read) == n) {
+		return nil, ReadError{b.file.Name(), err}
+	}
+	return tail, nil
+}
+
+// Close removes the temporary file of the buffer, if it has one.
+func (b *Buffer) Close() error {
+	if b.file == nil {
+		return nil
+	}
+
+	name := b.file.Name()
+	b.file.Close()
+	b.file = nil
+	b.readOffset, b.writeOffset = 0, 0
+
+	err := b.FileSystem.Remove(name)
+	if err != nil {
+		return RemoveError{name, err}
+	}
+	return nil
+}
+
+// spill moves the unread bytes held in memory to a new temporary file.
+func (b *Buffer) spill() error {
+	file, err := b.FileSystem.TempFile(b.Dir, FilePrefix)
+	if err != nil {
+		return CreateFileError{err}
+	}
+
+	n, err := file.Write(b.memory.Bytes())
+	if err != nil {
+		file.Close()
+		b.FileSystem.Remove(file.Name())
+		return WriteError{file.Name(), err}
+	}
+
+	b.file = file
+	b.readOffset = 0
+	b.writeOffset = int64(n)
+	b.memory = bytes.Buffer{}
+	return nil
+}
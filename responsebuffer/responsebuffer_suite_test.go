@@ -0,0 +1,13 @@
+package responsebuffer_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestResponsebuffer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Responsebuffer Suite")
+}
@@ -0,0 +1,129 @@
+package responsebuffer_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	. "github.com/compozed/deployadactyl/responsebuffer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Buffer", func() {
+	var (
+		fileSystem *afero.Afero
+		buffer     *Buffer
+	)
+
+	tempFiles := func() []string {
+		infos, err := fileSystem.ReadDir("/work")
+		Expect(err).ToNot(HaveOccurred())
+
+		names := []string{}
+		for _, info := range infos {
+			names = append(names, info.Name())
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+		Expect(fileSystem.MkdirAll("/work", 0755)).To(Succeed())
+
+		buffer = New(fileSystem, "/work", 10)
+	})
+
+	AfterEach(func() {
+		Expect(buffer.Close()).To(Succeed())
+	})
+
+	It("keeps the output in memory up to the limit", func() {
+		fmt.Fprint(buffer, "0123456789")
+
+		Expect(buffer.Spilled()).To(BeFalse())
+		Expect(buffer.Len()).To(Equal(int64(10)))
+		Expect(tempFiles()).To(BeEmpty())
+		Expect(ioutil.ReadAll(buffer)).To(Equal([]byte("0123456789")))
+	})
+
+	It("copies every write to the stream", func() {
+		stream := &bytes.Buffer{}
+		buffer.Stream = stream
+
+		fmt.Fprint(buffer, "012345")
+		fmt.Fprint(buffer, "6789abcdef")
+
+		Expect(stream.String()).To(Equal("0123456789abcdef"))
+		Expect(ioutil.ReadAll(buffer)).To(Equal([]byte("0123456789abcdef")))
+	})
+
+	Context("when the output grows past the limit", func() {
+		BeforeEach(func() {
+			fmt.Fprint(buffer, "012345")
+			fmt.Fprint(buffer, "6789abcdef")
+			fmt.Fprint(buffer, "ghij")
+		})
+
+		It("moves it to a temporary file of the directory", func() {
+			Expect(buffer.Spilled()).To(BeTrue())
+			Expect(tempFiles()).To(HaveLen(1))
+			Expect(tempFiles()[0]).To(HavePrefix(FilePrefix))
+		})
+
+		It("reads every written byte in order", func() {
+			Expect(buffer.Len()).To(Equal(int64(20)))
+			Expect(ioutil.ReadAll(buffer)).To(Equal([]byte("0123456789abcdefghij")))
+			Expect(buffer.Len()).To(BeZero())
+		})
+
+		It("can be written to after it is read, like the output rewritten with the errors found in it", func() {
+			output, err := ioutil.ReadAll(buffer)
+			Expect(err).ToNot(HaveOccurred())
+
+			fmt.Fprint(buffer, string(output))
+			fmt.Fprint(buffer, "\nerror found")
+
+			Expect(ioutil.ReadAll(buffer)).To(Equal([]byte("0123456789abcdefghij\nerror found")))
+		})
+
+		It("returns the tail of the output without reading it", func() {
+			Expect(buffer.Tail(4)).To(Equal([]byte("ghij")))
+			Expect(buffer.Tail(100)).To(Equal([]byte("0123456789abcdefghij")))
+			Expect(buffer.Len()).To(Equal(int64(20)))
+		})
+
+		It("removes the temporary file when it is closed", func() {
+			Expect(buffer.Close()).To(Succeed())
+
+			Expect(tempFiles()).To(BeEmpty())
+			Expect(buffer.Len()).To(BeZero())
+		})
+	})
+
+	It("returns the tail of an output held in memory", func() {
+		fmt.Fprint(buffer, "012345")
+
+		Expect(buffer.Tail(2)).To(Equal([]byte("45")))
+		Expect(buffer.Tail(10)).To(Equal([]byte("012345")))
+	})
+
+	It("keeps everything in memory without a limit", func() {
+		buffer = New(fileSystem, "/work", 0)
+
+		fmt.Fprint(buffer, strings.Repeat("x", 1000))
+
+		Expect(buffer.Spilled()).To(BeFalse())
+		Expect(buffer.Len()).To(Equal(int64(1000)))
+	})
+
+	It("returns an error when the temporary file cannot be created", func() {
+		buffer = New(&afero.Afero{Fs: afero.NewReadOnlyFs(afero.NewMemMapFs())}, "/work", 1)
+
+		_, err := fmt.Fprint(buffer, "01")
+
+		Expect(err).To(BeAssignableToTypeOf(CreateFileError{}))
+	})
+})
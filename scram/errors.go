@@ -0,0 +1,27 @@
+package scram
+
+import "fmt"
+
+type UnknownMechanismError struct {
+	Mechanism string
+}
+
+func (e UnknownMechanismError) Error() string {
+	return fmt.Sprintf("unknown scram mechanism %s: expected %s or %s", e.Mechanism, SHA256, SHA512)
+}
+
+type InvalidServerMessageError struct {
+	Reason string
+}
+
+func (e InvalidServerMessageError) Error() string {
+	return fmt.Sprintf("invalid scram message from the server: %s", e.Reason)
+}
+
+type AuthenticationError struct {
+	Message string
+}
+
+func (e AuthenticationError) Error() string {
+	return fmt.Sprintf("scram authentication failed: %s", e.Message)
+}
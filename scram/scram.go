@@ -0,0 +1,151 @@
+// Package scram implements the client side of the SCRAM-SHA-256 and SCRAM-SHA-512 authentication mechanisms of
+// RFC 5802 and RFC 7677.
+package scram
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// The mechanisms of the client.
+const (
+	SHA256 = "SCRAM-SHA-256"
+	SHA512 = "SCRAM-SHA-512"
+)
+
+// NewClient returns a Client authenticating the username with the password using the mechanism.
+func NewClient(mechanism, username, password string) (*Client, error) {
+	var h func() hash.Hash
+	switch mechanism {
+	case SHA256:
+		h = sha256.New
+	case SHA512:
+		h = sha512.New
+	default:
+		return nil, UnknownMechanismError{mechanism}
+	}
+
+	nonce := make([]byte, 24)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Hash:     h,
+		Username: username,
+		Password: password,
+		Nonce:    base64.RawStdEncoding.EncodeToString(nonce),
+	}, nil
+}
+
+// Client runs one authentication exchange: its first message, the final message answering the first message of the
+// server, and the verification of the final message of the server.
+type Client struct {
+	Hash     func() hash.Hash
+	Username string
+	Password string
+	Nonce    string
+
+	clientFirstBare string
+	serverSignature []byte
+}
+
+// First returns the first message of the client.
+func (c *Client) First() []byte {
+	username := strings.Replace(strings.Replace(c.Username, "=", "=3D", -1), ",", "=2C", -1)
+	c.clientFirstBare = "n=" + username + ",r=" + c.Nonce
+	return []byte("n,," + c.clientFirstBare)
+}
+
+// Final returns the final message of the client, which proves it knows the password, given the first message of the
+// server.
+func (c *Client) Final(serverFirst []byte) ([]byte, error) {
+	attributes := parse(serverFirst)
+	nonce, salt64, iterations := attributes["r"], attributes["s"], attributes["i"]
+	if !strings.HasPrefix(nonce, c.Nonce) || len(nonce) == len(c.Nonce) {
+		return nil, InvalidServerMessageError{"the server nonce does not extend the client nonce"}
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(salt64)
+	if err != nil {
+		return nil, InvalidServerMessageError{"the salt is not base64"}
+	}
+	count, err := strconv.Atoi(iterations)
+	if err != nil || count < 1 {
+		return nil, InvalidServerMessageError{"the iteration count is not a positive number"}
+	}
+
+	saltedPassword := pbkdf2(c.Hash, []byte(c.Password), salt, count)
+	clientKey := c.hmac(saltedPassword, "Client Key")
+	storedKey := c.Hash()
+	storedKey.Write(clientKey)
+
+	clientFinalWithoutProof := "c=biws,r=" + nonce
+	authMessage := c.clientFirstBare + "," + string(serverFirst) + "," + clientFinalWithoutProof
+
+	proof := c.hmac(storedKey.Sum(nil), authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	c.serverSignature = c.hmac(c.hmac(saltedPassword, "Server Key"), authMessage)
+
+	return []byte(clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)), nil
+}
+
+// Verify returns an error unless the final message of the server proves that it knows the password too.
+func (c *Client) Verify(serverFinal []byte) error {
+	attributes := parse(serverFinal)
+	if message, ok := attributes["e"]; ok {
+		return AuthenticationError{message}
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(attributes["v"])
+	if err != nil || c.serverSignature == nil || !hmac.Equal(signature, c.serverSignature) {
+		return InvalidServerMessageError{"the server signature does not match"}
+	}
+	return nil
+}
+
+func (c *Client) hmac(key []byte, message string) []byte {
+	mac := hmac.New(c.Hash, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// parse returns the attributes of a message of the server, such as r=nonce,s=salt,i=4096.
+func parse(message []byte) map[string]string {
+	attributes := map[string]string{}
+	for _, attribute := range bytes.Split(message, []byte(",")) {
+		if len(attribute) >= 2 && attribute[1] == '=' {
+			attributes[string(attribute[:1])] = string(attribute[2:])
+		}
+	}
+	return attributes
+}
+
+// pbkdf2 derives a key as long as the hash from the password, as Hi() of RFC 5802.
+func pbkdf2(h func() hash.Hash, password, salt []byte, iterations int) []byte {
+	prf := hmac.New(h, password)
+	prf.Write(salt)
+	prf.Write([]byte{0, 0, 0, 1})
+	u := prf.Sum(nil)
+
+	key := append([]byte{}, u...)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
@@ -0,0 +1,13 @@
+package scram_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestScram(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Scram Suite")
+}
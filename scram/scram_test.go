@@ -0,0 +1,76 @@
+package scram_test
+
+import (
+	. "github.com/compozed/deployadactyl/scram"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	// The example exchange of RFC 7677.
+	const (
+		clientNonce = "rOprNGfwEbeRWgbNEkqO"
+		serverFirst = "r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"
+		clientFinal = "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+		serverFinal = "v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="
+	)
+
+	var client *Client
+
+	BeforeEach(func() {
+		var err error
+		client, err = NewClient(SHA256, "user", "pencil")
+		Expect(err).ToNot(HaveOccurred())
+		client.Nonce = clientNonce
+	})
+
+	It("authenticates as in RFC 7677", func() {
+		Expect(string(client.First())).To(Equal("n,,n=user,r=" + clientNonce))
+
+		final, err := client.Final([]byte(serverFirst))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(final)).To(Equal(clientFinal))
+
+		Expect(client.Verify([]byte(serverFinal))).To(Succeed())
+	})
+
+	It("generates a random nonce", func() {
+		other, err := NewClient(SHA512, "user", "pencil")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(other.Nonce).ToNot(BeEmpty())
+		Expect(other.Nonce).ToNot(Equal(clientNonce))
+	})
+
+	It("escapes the username", func() {
+		client.Username = "a=b,c"
+
+		Expect(string(client.First())).To(Equal("n,,n=a=3Db=2Cc,r=" + clientNonce))
+	})
+
+	It("returns an error when the server nonce does not extend the client nonce", func() {
+		client.First()
+
+		_, err := client.Final([]byte("r=other,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"))
+
+		Expect(err).To(BeAssignableToTypeOf(InvalidServerMessageError{}))
+	})
+
+	It("returns an error when the server does not know the password", func() {
+		client.First()
+		_, err := client.Final([]byte(serverFirst))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(client.Verify([]byte("v=AAAA"))).To(BeAssignableToTypeOf(InvalidServerMessageError{}))
+	})
+
+	It("returns the error of the server", func() {
+		Expect(client.Verify([]byte("e=invalid-proof"))).To(MatchError(AuthenticationError{"invalid-proof"}))
+	})
+
+	It("returns an error for an unknown mechanism", func() {
+		_, err := NewClient("SCRAM-SHA-1", "user", "pencil")
+
+		Expect(err).To(MatchError(UnknownMechanismError{"SCRAM-SHA-1"}))
+	})
+})
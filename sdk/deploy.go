@@ -0,0 +1,141 @@
+package sdk
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+
+	"github.com/compozed/deployadactyl/client"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Deployment is a deployment that is over.
+type Deployment struct {
+	UUID   string
+	Output string
+}
+
+// DeployOption configures a deployment.
+type DeployOption func(*deployOptions)
+
+type deployOptions struct {
+	request client.DeployRequest
+	zip     client.ZipOptions
+}
+
+// WithManifest deploys the application with the manifest instead of the one of the artifact.
+func WithManifest(manifest []byte) DeployOption {
+	return func(o *deployOptions) {
+		o.request.Manifest = base64.StdEncoding.EncodeToString(manifest)
+	}
+}
+
+// WithManifests provides manifests by name, of which the one selected by WithManifestName, or else the one named
+// after the environment, is deployed.
+func WithManifests(manifests map[string][]byte) DeployOption {
+	return func(o *deployOptions) {
+		o.request.Manifests = map[string]string{}
+		for name, manifest := range manifests {
+			o.request.Manifests[name] = base64.StdEncoding.EncodeToString(manifest)
+		}
+	}
+}
+
+// WithManifestName selects the manifest that is deployed.
+func WithManifestName(name string) DeployOption {
+	return func(o *deployOptions) {
+		o.request.ManifestName = name
+		o.zip.ManifestName = name
+	}
+}
+
+// WithEnvironmentVariable sets an environment variable of the application.
+func WithEnvironmentVariable(name, value string) DeployOption {
+	return func(o *deployOptions) {
+		if o.request.EnvironmentVariables == nil {
+			o.request.EnvironmentVariables = map[string]string{}
+		}
+		o.request.EnvironmentVariables[name] = value
+	}
+}
+
+// WithHealthCheck checks the endpoint of the application on every foundation before it is promoted.
+func WithHealthCheck(endpoint string) DeployOption {
+	return func(o *deployOptions) {
+		o.request.HealthCheckEndpoint = endpoint
+	}
+}
+
+// WithMetadata adds the key to the metadata of the deployment, which every event of the deployment carries.
+func WithMetadata(key, value string) DeployOption {
+	return func(o *deployOptions) {
+		if o.request.Metadata == nil {
+			o.request.Metadata = map[string]string{}
+		}
+		o.request.Metadata[key] = value
+	}
+}
+
+// WithData adds the key to the data of the deployment, which is passed to the event handlers.
+func WithData(key string, value interface{}) DeployOption {
+	return func(o *deployOptions) {
+		if o.request.Data == nil {
+			o.request.Data = S.Params{}
+		}
+		o.request.Data[key] = value
+	}
+}
+
+// WithIdempotencyKey deploys once for the same key, such as the id of a build, when the server derives the uuids of
+// deployments from the keys.
+func WithIdempotencyKey(key string) DeployOption {
+	return func(o *deployOptions) {
+		o.zip.IdempotencyKey = key
+	}
+}
+
+// WithSignature provides the signature of the artifact.
+func WithSignature(signature string) DeployOption {
+	return func(o *deployOptions) {
+		o.request.Signature = signature
+		o.zip.Signature = signature
+	}
+}
+
+// WithSBOM provides the SBOM of the artifact.
+func WithSBOM(sbom *S.SBOM) DeployOption {
+	return func(o *deployOptions) {
+		o.request.SBOM = sbom
+	}
+}
+
+// WithProvenance provides the provenance of the artifact.
+func WithProvenance(provenance *S.Provenance) DeployOption {
+	return func(o *deployOptions) {
+		o.request.Provenance = provenance
+	}
+}
+
+// Deploy deploys the artifact at the URL to the environment of the app, and returns the deployment once it is over.
+// A deployment that does not succeed everywhere is returned with a DeploymentFailedError or a PartialSuccessError.
+func (s *SDK) Deploy(ctx context.Context, app client.App, artifactURL string, opts ...DeployOption) (Deployment, error) {
+	o := deployOptions{request: client.DeployRequest{ArtifactURL: artifactURL}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	output, err := s.Client.Deploy(ctx, app, o.request, client.Options{IdempotencyKey: o.zip.IdempotencyKey})
+	return deployment(output, err)
+}
+
+// DeployZip deploys the zip to the environment of the app, and returns the deployment once it is over. Only
+// WithManifestName, WithSignature and WithIdempotencyKey apply to a zip, whose manifest is the one it contains.
+func (s *SDK) DeployZip(ctx context.Context, app client.App, zip io.Reader, opts ...DeployOption) (Deployment, error) {
+	o := deployOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	output, err := s.Client.DeployZip(ctx, app, zip, o.zip)
+	return deployment(output, err)
+}
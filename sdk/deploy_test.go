@@ -0,0 +1,129 @@
+package sdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/compozed/deployadactyl/client"
+	. "github.com/compozed/deployadactyl/sdk"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deploy", func() {
+	var (
+		server   *httptest.Server
+		request  *http.Request
+		body     map[string]interface{}
+		status   int
+		response string
+		sdk      *SDK
+		app      client.App
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		response = "deployment succeeded\n"
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request = r
+			b, _ := ioutil.ReadAll(r.Body)
+			body = map[string]interface{}{}
+			json.Unmarshal(b, &body)
+
+			w.Header().Set("X-Deployadactyl-Deployment-Id", "uuid-1")
+			w.WriteHeader(status)
+			w.Write([]byte(response))
+		}))
+		sdk = New(server.URL, WithCredentials("cf-username", "cf-password"), WithToken("search-token"))
+		app = client.App{Environment: "prod", Org: "search", Space: "production", Name: "indexer"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("deploys the artifact with the options", func() {
+		deployment, err := sdk.Deploy(context.Background(), app, "https://example.com/indexer.jar",
+			WithManifest([]byte("applications:\n- name: indexer\n")),
+			WithEnvironmentVariable("LOG_LEVEL", "debug"),
+			WithHealthCheck("/health"),
+			WithMetadata("pipeline_id", "1234"),
+			WithData("team", "search"),
+			WithIdempotencyKey("build-42"),
+		)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployment).To(Equal(Deployment{UUID: "uuid-1", Output: "deployment succeeded\n"}))
+
+		Expect(request.URL.Path).To(Equal("/v3/apps/prod/search/production/indexer"))
+		Expect(request.Header.Get("Idempotency-Key")).To(Equal("build-42"))
+		Expect(request.Header.Get("X-Deployadactyl-Token")).To(Equal("search-token"))
+		Expect(body).To(Equal(map[string]interface{}{
+			"artifact_url":          "https://example.com/indexer.jar",
+			"manifest":              "YXBwbGljYXRpb25zOgotIG5hbWU6IGluZGV4ZXIK",
+			"environment_variables": map[string]interface{}{"LOG_LEVEL": "debug"},
+			"health_check_endpoint": "/health",
+			"metadata":              map[string]interface{}{"pipeline_id": "1234"},
+			"data":                  map[string]interface{}{"team": "search"},
+		}))
+	})
+
+	It("deploys a zip with its signature and manifest name", func() {
+		_, err := sdk.DeployZip(context.Background(), app, strings.NewReader("zip"), WithSignature("signature"), WithManifestName("blue"))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(request.Header.Get("Content-Type")).To(Equal("application/zip"))
+		Expect(request.Header.Get("X-Deployadactyl-Signature")).To(Equal("signature"))
+		Expect(request.Header.Get("X-Deployadactyl-Manifest-Name")).To(Equal("blue"))
+	})
+
+	It("returns a DeploymentFailedError with the error of the deployment", func() {
+		status = http.StatusInternalServerError
+		response = "pushing the app\nDeployment Summary: 0 succeeded, 1 failed\ncannot deploy application: push failed\n"
+
+		deployment, err := sdk.Deploy(context.Background(), app, "https://example.com/indexer.jar")
+
+		Expect(err).To(MatchError(DeploymentFailedError{UUID: "uuid-1", StatusCode: http.StatusInternalServerError, Reason: "cannot deploy application: push failed"}))
+		Expect(deployment.Output).To(Equal(response))
+	})
+
+	It("returns a PartialSuccessError when the deployment succeeded on some foundations only", func() {
+		status = http.StatusMultiStatus
+		response = "deployment partially succeeded: the push failed on https://api.west.example.com\n"
+
+		_, err := sdk.Deploy(context.Background(), app, "https://example.com/indexer.jar")
+
+		Expect(err).To(MatchError(PartialSuccessError{UUID: "uuid-1", Reason: "deployment partially succeeded: the push failed on https://api.west.example.com"}))
+	})
+
+	It("returns a ConflictError when the application is locked", func() {
+		status = http.StatusConflict
+		response = "cannot deploy application: the application is locked"
+
+		_, err := sdk.Deploy(context.Background(), app, "https://example.com/indexer.jar")
+
+		Expect(err).To(MatchError(ConflictError{"cannot deploy application: the application is locked"}))
+	})
+
+	It("returns an UnauthorizedError when the credentials are refused", func() {
+		status = http.StatusUnauthorized
+		response = "invalid credentials"
+
+		_, err := sdk.Deploy(context.Background(), app, "https://example.com/indexer.jar")
+
+		Expect(err).To(MatchError(UnauthorizedError{http.StatusUnauthorized, "invalid credentials"}))
+	})
+
+	It("returns the error of a request that cannot be sent", func() {
+		server.Close()
+
+		_, err := sdk.Deploy(context.Background(), app, "https://example.com/indexer.jar")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err).ToNot(BeAssignableToTypeOf(DeploymentFailedError{}))
+	})
+})
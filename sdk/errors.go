@@ -0,0 +1,127 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/compozed/deployadactyl/client"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// UnauthorizedError is returned when the server refuses the credentials or the token, or they do not allow the
+// request.
+type UnauthorizedError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e UnauthorizedError) Error() string {
+	return fmt.Sprintf("unauthorized: %s", e.Message)
+}
+
+// NotFoundError is returned when the deployment, the environment or the feature of the server is not found.
+type NotFoundError struct {
+	Message string
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("not found: %s", e.Message)
+}
+
+// ConflictError is returned when the application is locked by another deployment, or the deployment cannot be
+// changed.
+type ConflictError struct {
+	Message string
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("conflict: %s", e.Message)
+}
+
+// DeploymentFailedError is returned when a deployment failed. Its Reason is the error of the deployment.
+type DeploymentFailedError struct {
+	UUID       string
+	StatusCode int
+	Reason     string
+}
+
+func (e DeploymentFailedError) Error() string {
+	return fmt.Sprintf("deployment %s failed: %s", e.UUID, e.Reason)
+}
+
+// PartialSuccessError is returned when a deployment only succeeded on some of its foundations.
+type PartialSuccessError struct {
+	UUID              string
+	FailedFoundations []string
+	Reason            string
+}
+
+func (e PartialSuccessError) Error() string {
+	return fmt.Sprintf("deployment %s partially succeeded: %s", e.UUID, e.Reason)
+}
+
+// DegradedError is returned when the application of a deployment that succeeded started crashing after it was
+// promoted.
+type DegradedError struct {
+	UUID string
+}
+
+func (e DegradedError) Error() string {
+	return fmt.Sprintf("deployment %s succeeded but the application is crashing", e.UUID)
+}
+
+// requestError returns the typed error of a request refused by the server, or the error itself.
+func requestError(err error) error {
+	statusError, ok := err.(client.StatusError)
+	if !ok {
+		return err
+	}
+
+	switch statusError.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return UnauthorizedError{statusError.StatusCode, statusError.Body}
+	case http.StatusNotFound:
+		return NotFoundError{statusError.Body}
+	case http.StatusConflict:
+		return ConflictError{statusError.Body}
+	}
+	return err
+}
+
+// deployment returns the deployment of the output, with the typed error of a deployment that did not succeed.
+func deployment(output client.Output, err error) (Deployment, error) {
+	d := Deployment{UUID: output.UUID, Output: output.Text}
+
+	if err != nil {
+		typed := requestError(err)
+		if statusError, ok := typed.(client.StatusError); ok {
+			return d, DeploymentFailedError{UUID: output.UUID, StatusCode: statusError.StatusCode, Reason: lastLine(output.Text)}
+		}
+		return d, typed
+	}
+
+	if output.StatusCode == http.StatusMultiStatus {
+		return d, PartialSuccessError{UUID: output.UUID, Reason: lastLine(output.Text)}
+	}
+	return d, nil
+}
+
+// recordError returns the typed error of a deployment that is over and did not succeed.
+func recordError(record S.DeploymentRecord) error {
+	switch record.Status {
+	case S.DeploymentFailed:
+		return DeploymentFailedError{UUID: record.UUID, Reason: record.Error}
+	case S.DeploymentPartial:
+		return PartialSuccessError{UUID: record.UUID, FailedFoundations: record.FailedFoundations, Reason: record.Error}
+	case S.DeploymentDegraded:
+		return DegradedError{UUID: record.UUID}
+	}
+	return nil
+}
+
+// lastLine returns the last line of the output, which holds the error of a deployment that did not succeed.
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,66 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// LogAttempts is how many times the output of a deployment that just finished is read, as it is stored right after
+// the deployment finishes.
+const LogAttempts = 3
+
+// StreamLogs writes the progress of the deployment to w while it runs, one line per phase, and then its output, which
+// is only stored once the deployment is over. Only the output is written for a deployment the history knows is over,
+// or when the server does not track the progress of deployments.
+func (s *SDK) StreamLogs(ctx context.Context, uuid string, w io.Writer) error {
+	record, err := s.Client.Deployment(ctx, uuid)
+	if err != nil && !notFound(err) {
+		return requestError(err)
+	}
+
+	if err != nil || record.Status == S.DeploymentRunning {
+		phase := ""
+		err = s.Client.StreamProgress(ctx, uuid, func(progress S.DeploymentProgress) {
+			if progress.Phase != phase {
+				phase = progress.Phase
+				fmt.Fprintf(w, "%s %s %d%%\n", uuid, phase, progress.Percent)
+			}
+		})
+		if err != nil && !notFound(err) {
+			return requestError(err)
+		}
+	}
+
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	for attempt := 1; ; attempt++ {
+		output, err := s.Client.DeploymentLog(ctx, uuid)
+		if err == nil {
+			_, err = io.WriteString(w, output)
+			return err
+		}
+
+		if !notFound(err) || attempt == LogAttempts {
+			return requestError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// notFound reports whether the server responded with a 404.
+func notFound(err error) bool {
+	_, ok := requestError(err).(NotFoundError)
+	return ok
+}
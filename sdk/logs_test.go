@@ -0,0 +1,104 @@
+package sdk_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/compozed/deployadactyl/sdk"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StreamLogs", func() {
+	var (
+		server   *httptest.Server
+		status   string
+		progress bool
+		logReads int
+		logAfter int
+		sdk      *SDK
+		buffer   *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		status = "running"
+		progress = true
+		logReads = 0
+		logAfter = 0
+		buffer = &bytes.Buffer{}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v3/deployments/uuid-1":
+				fmt.Fprintf(w, `{"uuid": "uuid-1", "status": %q}`, status)
+			case "/v3/deployments/uuid-1/progress":
+				if !progress {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, "deployment progress is not enabled")
+					return
+				}
+				fmt.Fprint(w, "event: progress\ndata: {\"phase\": \"executing\", \"percent\": 40}\n\n")
+				fmt.Fprint(w, "event: progress\ndata: {\"phase\": \"executing\", \"percent\": 60}\n\n")
+				fmt.Fprint(w, "event: progress\ndata: {\"phase\": \"finished\", \"percent\": 100}\n\n")
+			case "/v3/deployments/uuid-1/logs":
+				logReads++
+				if logReads <= logAfter {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, "the output of deployment uuid-1 was not found")
+					return
+				}
+				fmt.Fprint(w, "deployment succeeded\n")
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		sdk = New(server.URL, WithPollInterval(time.Millisecond))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("writes the phases of a running deployment and then its output", func() {
+		Expect(sdk.StreamLogs(context.Background(), "uuid-1", buffer)).To(Succeed())
+
+		Expect(buffer.String()).To(Equal("uuid-1 executing 40%\nuuid-1 finished 100%\ndeployment succeeded\n"))
+	})
+
+	It("only writes the output of a deployment that is over", func() {
+		status = "succeeded"
+
+		Expect(sdk.StreamLogs(context.Background(), "uuid-1", buffer)).To(Succeed())
+
+		Expect(buffer.String()).To(Equal("deployment succeeded\n"))
+	})
+
+	It("only writes the output without the deployment progress", func() {
+		progress = false
+
+		Expect(sdk.StreamLogs(context.Background(), "uuid-1", buffer)).To(Succeed())
+
+		Expect(buffer.String()).To(Equal("deployment succeeded\n"))
+	})
+
+	It("waits for the output to be stored", func() {
+		logAfter = 2
+
+		Expect(sdk.StreamLogs(context.Background(), "uuid-1", buffer)).To(Succeed())
+
+		Expect(logReads).To(Equal(3))
+	})
+
+	It("returns a NotFoundError when the output is not stored", func() {
+		logAfter = LogAttempts
+
+		err := sdk.StreamLogs(context.Background(), "uuid-1", buffer)
+
+		Expect(err).To(MatchError(NotFoundError{"the output of deployment uuid-1 was not found"}))
+		Expect(logReads).To(Equal(LogAttempts))
+	})
+})
@@ -0,0 +1,63 @@
+// Package sdk embeds deployments in Go pipeline tools. It builds on the client package with functional options for
+// deployments, waits for deployments to complete, streams their logs and returns typed errors.
+package sdk
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/compozed/deployadactyl/client"
+)
+
+// DefaultPollInterval is how often the record of a deployment is read while waiting for it.
+const DefaultPollInterval = 5 * time.Second
+
+// Option configures an SDK.
+type Option func(*SDK)
+
+// WithCredentials authenticates the requests with the username and password.
+func WithCredentials(username, password string) Option {
+	return func(s *SDK) {
+		s.Client.Username = username
+		s.Client.Password = password
+	}
+}
+
+// WithToken authenticates the requests with the API token of a tenant.
+func WithToken(token string) Option {
+	return func(s *SDK) {
+		s.Client.Token = token
+	}
+}
+
+// WithHTTPClient sends the requests with the HTTP client, such as one with a timeout or the CA of the server.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(s *SDK) {
+		s.Client.HTTPClient = httpClient
+	}
+}
+
+// WithPollInterval reads the record of a deployment at the interval while waiting for it.
+func WithPollInterval(interval time.Duration) Option {
+	return func(s *SDK) {
+		s.PollInterval = interval
+	}
+}
+
+// New returns an SDK of the server at the URL.
+func New(serverURL string, opts ...Option) *SDK {
+	s := &SDK{
+		Client:       client.NewClient(serverURL, "", ""),
+		PollInterval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SDK deploys applications with the Client and follows their deployments.
+type SDK struct {
+	Client       *client.Client
+	PollInterval time.Duration
+}
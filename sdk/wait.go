@@ -0,0 +1,39 @@
+package sdk
+
+import (
+	"context"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// WaitForCompletion reads the record of the deployment every PollInterval until the deployment is over, and returns
+// the record. It requires the deployment history of the server. A deployment that did not succeed is returned with
+// a DeploymentFailedError, a PartialSuccessError or a DegradedError.
+func (s *SDK) WaitForCompletion(ctx context.Context, uuid string) (S.DeploymentRecord, error) {
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		record, err := s.Client.Deployment(ctx, uuid)
+		if err != nil {
+			if ctx.Err() != nil {
+				return record, ctx.Err()
+			}
+			return record, requestError(err)
+		}
+		if record.Status != S.DeploymentRunning {
+			return record, recordError(record)
+		}
+
+		select {
+		case <-ctx.Done():
+			return record, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
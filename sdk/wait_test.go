@@ -0,0 +1,100 @@
+package sdk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/compozed/deployadactyl/sdk"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WaitForCompletion", func() {
+	var (
+		server  *httptest.Server
+		records []string
+		reads   int
+		sdk     *SDK
+	)
+
+	BeforeEach(func() {
+		reads = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v3/deployments/uuid-1" {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "deployment %s not found", r.URL.Path)
+				return
+			}
+
+			record := records[len(records)-1]
+			if reads < len(records) {
+				record = records[reads]
+			}
+			reads++
+			fmt.Fprint(w, record)
+		}))
+		sdk = New(server.URL, WithPollInterval(time.Millisecond))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns the record of the deployment once it is over", func() {
+		records = []string{
+			`{"uuid": "uuid-1", "status": "running"}`,
+			`{"uuid": "uuid-1", "status": "running"}`,
+			`{"uuid": "uuid-1", "status": "succeeded"}`,
+		}
+
+		record, err := sdk.WaitForCompletion(context.Background(), "uuid-1")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record.Status).To(Equal("succeeded"))
+		Expect(reads).To(Equal(3))
+	})
+
+	It("returns a DeploymentFailedError when the deployment failed", func() {
+		records = []string{`{"uuid": "uuid-1", "status": "failed", "error": "push failed"}`}
+
+		_, err := sdk.WaitForCompletion(context.Background(), "uuid-1")
+
+		Expect(err).To(MatchError(DeploymentFailedError{UUID: "uuid-1", Reason: "push failed"}))
+	})
+
+	It("returns a PartialSuccessError with the foundations the deployment failed on", func() {
+		records = []string{`{"uuid": "uuid-1", "status": "partial", "error": "push failed", "failed_foundations": ["https://api.west.example.com"]}`}
+
+		_, err := sdk.WaitForCompletion(context.Background(), "uuid-1")
+
+		Expect(err).To(MatchError(PartialSuccessError{UUID: "uuid-1", FailedFoundations: []string{"https://api.west.example.com"}, Reason: "push failed"}))
+	})
+
+	It("returns a DegradedError when the application is crashing", func() {
+		records = []string{`{"uuid": "uuid-1", "status": "degraded"}`}
+
+		_, err := sdk.WaitForCompletion(context.Background(), "uuid-1")
+
+		Expect(err).To(MatchError(DegradedError{UUID: "uuid-1"}))
+	})
+
+	It("returns a NotFoundError when the deployment is not found", func() {
+		_, err := sdk.WaitForCompletion(context.Background(), "uuid-2")
+
+		Expect(err).To(MatchError(NotFoundError{"deployment /v3/deployments/uuid-2 not found"}))
+	})
+
+	It("returns the error of the context once it is done", func() {
+		records = []string{`{"uuid": "uuid-1", "status": "running"}`}
+		sdk.PollInterval = time.Hour
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := sdk.WaitForCompletion(ctx, "uuid-1")
+
+		Expect(err).To(Equal(context.DeadlineExceeded))
+	})
+})
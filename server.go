@@ -44,6 +44,49 @@ func main() {
 		log.Fatal(err)
 	}
 
+	removed, err := c.SweepTempDirectories()
+	if err != nil {
+		log.Error(err)
+	}
+	for _, path := range removed {
+		log.Infof("removed leaked temporary directory %s", path)
+	}
+
+	if interval := c.CreateConfig().StaleApps.IntervalMinutes; interval > 0 {
+		log.Infof("sweeping for stale apps every %d minutes", interval)
+	}
+
+	for _, publisher := range c.CreateConfig().EventPublishers {
+		log.Infof("publishing events to %s", publisher.Type)
+	}
+	c.StartEventPublishers()
+
+	if endpoint := c.CreateConfig().Tracing.Endpoint; endpoint != "" {
+		log.Infof("exporting the traces of the deployments to %s", endpoint)
+	}
+	c.StartTracing()
+
+	if address := c.CreateConfig().Vault.Address; address != "" {
+		log.Infof("reading the credentials of environments with a vault_path from %s", address)
+	}
+	c.StartCredentialRenewal()
+
+	if url := c.CreateConfig().LDAP.URL; url != "" {
+		log.Infof("checking the basic auth of the requests to environments with ldap against %s", url)
+	}
+
+	if issuer := c.CreateConfig().OIDC.Issuer; issuer != "" {
+		log.Infof("accepting the bearer tokens issued by %s", issuer)
+	}
+
+	if c.CreateConfig().DeploymentLocks.Redis != "" {
+		log.Infof("holding deployment locks in redis")
+	}
+
+	if c.CreateConfig().SharedState.Redis != "" {
+		log.Infof("sharing the state of the deployments in redis")
+	}
+
 	em := c.CreateEventManager()
 
 	if *envVarHandlerEnabled {
@@ -56,6 +99,14 @@ func main() {
 	log.Infof("registering health check handler")
 	em.AddBinding(push.NewPushFinishedEventBinding(healthHandler.PushFinishedEventHandler))
 
+	if slackNotifier := c.CreateSlackNotifier(); slackNotifier != nil {
+		log.Infof("registering slack notification handler")
+		em.AddBinding(push.NewDeployStartEventBinding(slackNotifier.DeployStartedEventHandler))
+		em.AddBinding(push.NewDeploySuccessEventBinding(slackNotifier.DeploySuccessEventHandler))
+		em.AddBinding(push.NewDeployFailureEventBinding(slackNotifier.DeployFailureEventHandler))
+	}
+	c.StartNotifications()
+
 	if *routeMapperEnabled {
 		routeMapper := c.CreateRouteMapper()
 
@@ -63,6 +114,11 @@ func main() {
 		em.AddBinding(push.NewPushFinishedEventBinding(routeMapper.PushFinishedEventHandler))
 	}
 
+	if election := c.CreateConfig().LeaderElection.Type; election != "" {
+		log.Infof("electing the leader with %s", election)
+	}
+	c.StartLeaderElection()
+
 	l := c.CreateListener()
 	controller := c.CreateController()
 
@@ -69,6 +69,7 @@ environments:
 			NewCourier: func(executor interfaces.Executor) interfaces.Courier {
 				courier := &mocks.Courier{}
 				couriers = append(couriers, courier)
+				courier.AppRoutesCall.Returns.Routes = []string{appName + ".example.com"}
 				courier.ExistsCall.Returns.Bool = true
 				if len(couriers) == 2 {
 					courier.PushCall.Returns.Error = errors.New("failed to push")
@@ -20,6 +20,7 @@ import (
 	"encoding/base64"
 	"reflect"
 	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
 	"errors"
 	"path"
 	"io"
@@ -84,6 +85,7 @@ applications:
 			NewCourier: func(executor interfaces.Executor) interfaces.Courier {
 				courier := &mocks.Courier{}
 				couriers = append(couriers, courier)
+				courier.AppRoutesCall.Returns.Routes = []string{appName + ".example.com"}
 				courier.ExistsCall.Returns.Bool = true
 				if len(couriers) == 2 {
 					courier.PushCall.Returns.Error = errors.New("failed to push")
@@ -91,7 +93,7 @@ applications:
 
 				return courier
 			},
-			NewFetcher: func(fs *afero.Afero, ex interfaces.Extractor, log interfaces.DeploymentLogger) interfaces.Fetcher {
+			NewFetcher: func(fs *afero.Afero, ex interfaces.Extractor, log interfaces.DeploymentLogger, verifier interfaces.ArtifactVerifier, caBundle string, buckets S.BucketsDescriptor, artifactory S.ArtifactoryDescriptor, maven S.MavenDescriptor, workDir string) interfaces.Fetcher {
 				wd, _ := os.Getwd()
 
 				dstf, _ := fs.TempDir("", "service-failure-test-")
@@ -146,7 +148,12 @@ applications:
 	})
 
 	It("returns correct status code", func() {
-		Expect(response.StatusCode).To(Equal(http.StatusOK), string(responseBody))
+		Expect(response.StatusCode).To(Equal(http.StatusMultiStatus), string(responseBody))
+	})
+	It("reports the outcome on every foundation", func() {
+		Expect(string(responseBody)).To(ContainSubstring("partial success:"))
+		Expect(string(responseBody)).To(ContainSubstring("api2.example.com: failed"))
+		Expect(string(responseBody)).To(ContainSubstring("api1.example.com: succeeded"))
 	})
 	It("calls prechecker with all foundation urls", func() {
 		fs := prechecker.AssertAllFoundationsUpCall.Received.Environment.Foundations
@@ -238,7 +245,7 @@ applications:
 		Expect(eventManager.EmitCall.Received.Events[6].Type).To(Equal("deploy.finish"))
 	})
 	It("calls EmitEvent the correct number of times", func() {
-		Expect(len(eventManager.EmitEventCall.Received.Events)).To(Equal(9))
+		Expect(len(eventManager.EmitEventCall.Received.Events)).To(Equal(13))
 	})
 	It("emits a DeployStartedEvent", func() {
 		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).To(Equal(reflect.TypeOf(push.DeployStartedEvent{})))
@@ -257,10 +264,15 @@ applications:
 		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[5])).To(Equal(reflect.TypeOf(push.PushFinishedEvent{})))
 		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[6])).To(Equal(reflect.TypeOf(push.PushFinishedEvent{})))
 	})
+	It("emits a PromotionStartedEvent for each foundation, since the push is not rolled back", func() {
+		for i := 7; i < 11; i++ {
+			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[i])).To(Equal(reflect.TypeOf(push.PromotionStartedEvent{})))
+		}
+	})
 	It("emits a DeployFailureEvent", func() {
-		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[7])).To(Equal(reflect.TypeOf(push.DeployFailureEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[11])).To(Equal(reflect.TypeOf(push.DeployFailureEvent{})))
 	})
 	It("emits a DeployFinishedEvent", func() {
-		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[8])).To(Equal(reflect.TypeOf(push.DeployFinishedEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[12])).To(Equal(reflect.TypeOf(push.DeployFinishedEvent{})))
 	})
 })
@@ -20,6 +20,7 @@ import (
 	"encoding/base64"
 	"reflect"
 	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
 	"errors"
 	"path"
 	"io"
@@ -85,6 +86,7 @@ applications:
 			NewCourier: func(executor interfaces.Executor) interfaces.Courier {
 				courier := &mocks.Courier{}
 				couriers = append(couriers, courier)
+				courier.AppRoutesCall.Returns.Routes = []string{appName + ".example.com"}
 				courier.ExistsCall.Returns.Bool = true
 				if len(couriers) == 2 {
 					courier.PushCall.Returns.Error = errors.New("failed to push")
@@ -92,7 +94,7 @@ applications:
 
 				return courier
 			},
-			NewFetcher: func(fs *afero.Afero, ex interfaces.Extractor, log interfaces.DeploymentLogger) interfaces.Fetcher {
+			NewFetcher: func(fs *afero.Afero, ex interfaces.Extractor, log interfaces.DeploymentLogger, verifier interfaces.ArtifactVerifier, caBundle string, buckets S.BucketsDescriptor, artifactory S.ArtifactoryDescriptor, maven S.MavenDescriptor, workDir string) interfaces.Fetcher {
 				wd, _ := os.Getwd()
 
 				dstf, _ := fs.TempDir("", "service-failure-test-")
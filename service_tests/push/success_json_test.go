@@ -15,6 +15,7 @@ import (
 	"github.com/compozed/deployadactyl/mocks"
 	"github.com/compozed/deployadactyl/randomizer"
 	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
 	"github.com/gin-gonic/gin"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -80,11 +81,12 @@ applications:
 			NewCourier: func(executor interfaces.Executor) interfaces.Courier {
 				courier := &mocks.Courier{}
 				couriers = append(couriers, courier)
+				courier.AppRoutesCall.Returns.Routes = []string{appName + ".example.com"}
 				courier.ExistsCall.Returns.Bool = true
 
 				return courier
 			},
-			NewFetcher: func(fs *afero.Afero, ex interfaces.Extractor, log interfaces.DeploymentLogger) interfaces.Fetcher {
+			NewFetcher: func(fs *afero.Afero, ex interfaces.Extractor, log interfaces.DeploymentLogger, verifier interfaces.ArtifactVerifier, caBundle string, buckets S.BucketsDescriptor, artifactory S.ArtifactoryDescriptor, maven S.MavenDescriptor, workDir string) interfaces.Fetcher {
 				wd, _ := os.Getwd()
 
 				dstf, _ := fs.TempDir("", "service-success-test-")
@@ -230,7 +232,7 @@ applications:
 		Expect(eventManager.EmitCall.Received.Events[7].Type).To(Equal("deploy.finish"))
 	})
 	It("calls EmitEvent the correct number of times", func() {
-		Expect(len(eventManager.EmitEventCall.Received.Events)).To(Equal(10))
+		Expect(len(eventManager.EmitEventCall.Received.Events)).To(Equal(18))
 	})
 	It("emits a DeployStartedEvent", func() {
 		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).To(Equal(reflect.TypeOf(push.DeployStartedEvent{})))
@@ -250,10 +252,20 @@ applications:
 		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[6])).To(Equal(reflect.TypeOf(push.PushFinishedEvent{})))
 		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[7])).To(Equal(reflect.TypeOf(push.PushFinishedEvent{})))
 	})
+	It("emits a RouteVerifiedEvent for each foundation", func() {
+		for i := 8; i < 12; i++ {
+			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[i])).To(Equal(reflect.TypeOf(push.RouteVerifiedEvent{})))
+		}
+	})
+	It("emits a PromotionStartedEvent for each foundation once every foundation is verified", func() {
+		for i := 12; i < 16; i++ {
+			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[i])).To(Equal(reflect.TypeOf(push.PromotionStartedEvent{})))
+		}
+	})
 	It("emits a DeploySuccessEvent", func() {
-		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[8])).To(Equal(reflect.TypeOf(push.DeploySuccessEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[16])).To(Equal(reflect.TypeOf(push.DeploySuccessEvent{})))
 	})
 	It("emits a DeployFinishedEvent", func() {
-		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[9])).To(Equal(reflect.TypeOf(push.DeployFinishedEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[17])).To(Equal(reflect.TypeOf(push.DeployFinishedEvent{})))
 	})
 })
@@ -0,0 +1,98 @@
+package push
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/compozed/deployadactyl/creator"
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Service with an uppercase environment", func() {
+
+	var (
+		deployadactylServer *httptest.Server
+		eventManager        *mocks.EventManager
+		couriers            []*mocks.Courier
+		responseBody        []byte
+		response            *http.Response
+		org                 = randomizer.StringRunes(10)
+		space               = os.Getenv("SILENT_DEPLOY_ENVIRONMENT")
+		appName             = randomizer.StringRunes(10)
+	)
+
+	BeforeEach(func() {
+		os.Setenv("CF_USERNAME", randomizer.StringRunes(10))
+		os.Setenv("CF_PASSWORD", randomizer.StringRunes(10))
+
+		Expect(ioutil.WriteFile(CONFIGPATH, []byte(TESTCONFIG), 0644)).To(Succeed())
+
+		eventManager = &mocks.EventManager{}
+		couriers = make([]*mocks.Courier, 0)
+
+		provider := creator.CreatorModuleProvider{
+			NewPrechecker: func(eventManager interfaces.EventManager) interfaces.Prechecker {
+				return &mocks.Prechecker{}
+			},
+			NewCourier: func(executor interfaces.Executor) interfaces.Courier {
+				courier := &mocks.Courier{}
+				couriers = append(couriers, courier)
+				courier.AppRoutesCall.Returns.Routes = []string{appName + ".example.com"}
+				courier.ExistsCall.Returns.Bool = true
+
+				return courier
+			},
+			NewEventManager: func(log interfaces.Logger) interfaces.EventManager {
+				return eventManager
+			},
+		}
+
+		creator, err := creator.Custom("DEBUG", CONFIGPATH, provider)
+		Expect(err).ToNot(HaveOccurred())
+
+		controller := creator.CreateController()
+		deployadactylServer = httptest.NewServer(creator.CreateControllerHandler(controller))
+
+		body, err := os.Open("../fixtures/artifact-with-manifest.jar")
+		Expect(err).ToNot(HaveOccurred())
+		defer body.Close()
+
+		requestURL := fmt.Sprintf("%s/v3/apps/%s/%s/%s/%s", deployadactylServer.URL, strings.ToUpper(ENVIRONMENTNAME), org, space, appName)
+		req, err := http.NewRequest("POST", requestURL, body)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Add("Content-Type", "application/zip")
+
+		response, err = http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+
+		responseBody, err = ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		deployadactylServer.Close()
+	})
+
+	It("deploys to every foundation of the environment", func() {
+		Expect(response.StatusCode).To(Equal(http.StatusOK), string(responseBody))
+		Expect(couriers).To(HaveLen(4))
+		for _, c := range couriers {
+			Expect(c.PushCall.Received.AppName).To(ContainSubstring(appName + "-new-build-"))
+			Expect(c.RenameCall.Received.AppNameVenerable).To(Equal(appName))
+		}
+	})
+
+	It("finishes the deployment successfully", func() {
+		Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal("deploy.start"))
+		Expect(eventManager.EmitCall.Received.Events[6].Type).To(Equal("deploy.success"))
+	})
+})
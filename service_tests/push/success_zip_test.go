@@ -75,6 +75,7 @@ var _ = Describe("Service", func() {
 			NewCourier: func(executor interfaces.Executor) interfaces.Courier {
 				courier := &mocks.Courier{}
 				couriers = append(couriers, courier)
+				courier.AppRoutesCall.Returns.Routes = []string{appName + ".example.com"}
 				courier.ExistsCall.Returns.Bool = true
 
 				return courier
@@ -202,7 +203,7 @@ var _ = Describe("Service", func() {
 		Expect(eventManager.EmitCall.Received.Events[7].Type).To(Equal("deploy.finish"))
 	})
 	It("calls EmitEvent the correct number of times", func() {
-		Expect(len(eventManager.EmitEventCall.Received.Events)).To(Equal(10))
+		Expect(len(eventManager.EmitEventCall.Received.Events)).To(Equal(18))
 	})
 	It("emits a DeployStartedEvent", func() {
 		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).To(Equal(reflect.TypeOf(push.DeployStartedEvent{})))
@@ -222,10 +223,20 @@ var _ = Describe("Service", func() {
 		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[6])).To(Equal(reflect.TypeOf(push.PushFinishedEvent{})))
 		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[7])).To(Equal(reflect.TypeOf(push.PushFinishedEvent{})))
 	})
+	It("emits a RouteVerifiedEvent for each foundation", func() {
+		for i := 8; i < 12; i++ {
+			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[i])).To(Equal(reflect.TypeOf(push.RouteVerifiedEvent{})))
+		}
+	})
+	It("emits a PromotionStartedEvent for each foundation once every foundation is verified", func() {
+		for i := 12; i < 16; i++ {
+			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[i])).To(Equal(reflect.TypeOf(push.PromotionStartedEvent{})))
+		}
+	})
 	It("emits a DeploySuccessEvent", func() {
-		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[8])).To(Equal(reflect.TypeOf(push.DeploySuccessEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[16])).To(Equal(reflect.TypeOf(push.DeploySuccessEvent{})))
 	})
 	It("emits a DeployFinishedEvent", func() {
-		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[9])).To(Equal(reflect.TypeOf(push.DeployFinishedEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[17])).To(Equal(reflect.TypeOf(push.DeployFinishedEvent{})))
 	})
 })
\ No newline at end of file
@@ -0,0 +1,64 @@
+package sharedstate
+
+import (
+	"sync"
+	"time"
+)
+
+// NewMemory returns an empty Memory.
+func NewMemory() *Memory {
+	return &Memory{Now: time.Now, values: map[string]value{}}
+}
+
+// Memory holds the shared state in memory, so it is only shared within one instance of the server, such as in
+// tests.
+type Memory struct {
+	Now func() time.Time
+
+	subscribers subscribers
+	mu          sync.Mutex
+	values      map[string]value
+}
+
+type value struct {
+	data    []byte
+	expires time.Time
+}
+
+// Publish passes the message to the subscribers of the channel.
+func (m *Memory) Publish(channel string, message []byte) error {
+	m.subscribers.deliver(channel, message)
+	return nil
+}
+
+// Subscribe returns the messages published on the channel from now on, until unsubscribe is called.
+func (m *Memory) Subscribe(channel string) (<-chan []byte, func()) {
+	return m.subscribers.add(channel)
+}
+
+// Put keeps the value of the key for the TTL, dropping the values that expired.
+func (m *Memory) Put(key string, data []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.Now()
+	for k, v := range m.values {
+		if !now.Before(v.expires) {
+			delete(m.values, k)
+		}
+	}
+	m.values[key] = value{append([]byte(nil), data...), now.Add(ttl)}
+	return nil
+}
+
+// Get returns the value of the key, and reports whether it was found.
+func (m *Memory) Get(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.values[key]
+	if !ok || !m.Now().Before(v.expires) {
+		return nil, false, nil
+	}
+	return append([]byte(nil), v.data...), true, nil
+}
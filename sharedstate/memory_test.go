@@ -0,0 +1,78 @@
+package sharedstate_test
+
+import (
+	"github.com/compozed/deployadactyl/conformance"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"time"
+
+	. "github.com/compozed/deployadactyl/sharedstate"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Memory", func() {
+	var (
+		memory *Memory
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		memory = NewMemory()
+		now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+		memory.Now = func() time.Time { return now }
+	})
+
+	It("passes the messages of a channel to its subscribers", func() {
+		first, unsubscribeFirst := memory.Subscribe("events")
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := memory.Subscribe("events")
+		defer unsubscribeSecond()
+		other, unsubscribeOther := memory.Subscribe("cancel")
+		defer unsubscribeOther()
+
+		Expect(memory.Publish("events", []byte("an event"))).To(Succeed())
+
+		Expect(<-first).To(Equal([]byte("an event")))
+		Expect(<-second).To(Equal([]byte("an event")))
+		Expect(other).ToNot(Receive())
+	})
+
+	It("closes the messages when the subscriber unsubscribes", func() {
+		messages, unsubscribe := memory.Subscribe("events")
+		unsubscribe()
+		unsubscribe()
+
+		Expect(memory.Publish("events", []byte("an event"))).To(Succeed())
+		Expect(messages).To(BeClosed())
+	})
+
+	It("drops the messages of a subscriber that fell behind", func() {
+		messages, unsubscribe := memory.Subscribe("events")
+		defer unsubscribe()
+
+		for i := 0; i < SubscriberBuffer+1; i++ {
+			Expect(memory.Publish("events", []byte("an event"))).To(Succeed())
+		}
+
+		Expect(messages).To(HaveLen(SubscriberBuffer))
+	})
+
+	It("returns the values until they expire", func() {
+		Expect(memory.Put("progress:1234", []byte("finished"), time.Minute)).To(Succeed())
+
+		value, found, err := memory.Get("progress:1234")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(value).To(Equal([]byte("finished")))
+
+		now = now.Add(time.Minute)
+
+		_, found, err = memory.Get("progress:1234")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("satisfies the SharedState contract", func() {
+		conformance.SharedState(GinkgoT(), func() I.SharedState { return NewMemory() })
+	})
+})
@@ -0,0 +1,179 @@
+package sharedstate
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/redis"
+)
+
+// DefaultPrefix is prepended to the channels and keys of the shared state in Redis.
+const DefaultPrefix = "deployadactyl:state:"
+
+// The intervals of the subscription to Redis. The subscription is pinged every PingInterval and is lost once
+// nothing was received for three times as long. It is opened again every RetryInterval until it succeeds.
+const (
+	DefaultPingInterval  = 10 * time.Second
+	DefaultRetryInterval = time.Second
+)
+
+// NewRedis returns a Redis sharing the state through the client.
+func NewRedis(client *redis.Client, log I.Logger) *Redis {
+	return &Redis{
+		Client:        client,
+		Prefix:        DefaultPrefix,
+		PingInterval:  DefaultPingInterval,
+		RetryInterval: DefaultRetryInterval,
+		Log:           log,
+		subscribed:    make(chan struct{}),
+		stop:          make(chan struct{}),
+	}
+}
+
+// Redis shares the state through a Redis. Every instance subscribes once to every channel of the shared state and
+// passes the messages to its own subscribers. Messages published while the subscription is lost are not received.
+type Redis struct {
+	Client        *redis.Client
+	Prefix        string
+	PingInterval  time.Duration
+	RetryInterval time.Duration
+	Log           I.Logger
+
+	subscribers  subscribers
+	start        sync.Once
+	subscribed   chan struct{}
+	stop         chan struct{}
+	mu           sync.Mutex
+	subscription *redis.Subscription
+	closed       bool
+}
+
+// Publish publishes the message on the channel.
+func (r *Redis) Publish(channel string, message []byte) error {
+	_, err := r.Client.Do("PUBLISH", r.Prefix+channel, string(message))
+	return err
+}
+
+// Subscribe returns the messages published on the channel from now on, until unsubscribe is called. The first
+// subscription waits for the instance to subscribe to Redis, for as long as a command may take.
+func (r *Redis) Subscribe(channel string) (<-chan []byte, func()) {
+	r.start.Do(func() { go r.run() })
+
+	select {
+	case <-r.subscribed:
+	case <-time.After(r.Client.Timeout):
+	}
+	return r.subscribers.add(channel)
+}
+
+// Put sets the value of the key, which expires after the TTL.
+func (r *Redis) Put(key string, value []byte, ttl time.Duration) error {
+	_, err := r.Client.Do("SET", r.Prefix+key, string(value), "PX", strconv.FormatInt(int64(ttl/time.Millisecond), 10))
+	return err
+}
+
+// Get returns the value of the key, and reports whether it was found.
+func (r *Redis) Get(key string) ([]byte, bool, error) {
+	reply, err := r.Client.Do("GET", r.Prefix+key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return nil, false, redis.UnexpectedReplyError{"a value that is not a string"}
+	}
+	return []byte(value), true, nil
+}
+
+// Close stops receiving the messages.
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	close(r.stop)
+
+	if r.subscription != nil {
+		return r.subscription.Close()
+	}
+	return nil
+}
+
+// run subscribes to every channel of the shared state and receives their messages until Close is called, subscribing
+// again whenever the subscription is lost.
+func (r *Redis) run() {
+	var once sync.Once
+	for {
+		subscription, err := r.Client.Subscribe(r.Prefix + "*")
+		if err == nil && r.setSubscription(subscription) {
+			once.Do(func() { close(r.subscribed) })
+			err = r.receive(subscription)
+		}
+
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+		if err != nil {
+			r.Log.Errorf("lost the subscription to the shared state: %s", err)
+		}
+
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(r.RetryInterval):
+		}
+	}
+}
+
+// setSubscription keeps the subscription so Close can close it, and reports whether the Redis is still open.
+func (r *Redis) setSubscription(subscription *redis.Subscription) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		subscription.Close()
+		return false
+	}
+	r.subscription = subscription
+	return true
+}
+
+// receive passes the messages of the subscription to the subscribers of their channel, until the subscription is
+// lost.
+func (r *Redis) receive(subscription *redis.Subscription) error {
+	defer subscription.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(r.PingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				subscription.Ping()
+			}
+		}
+	}()
+
+	for {
+		message, err := subscription.Receive(3 * r.PingInterval)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(message.Channel, r.Prefix) {
+			r.subscribers.deliver(strings.TrimPrefix(message.Channel, r.Prefix), []byte(message.Payload))
+		}
+	}
+}
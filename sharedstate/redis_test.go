@@ -0,0 +1,208 @@
+package sharedstate_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/redis"
+	. "github.com/compozed/deployadactyl/sharedstate"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+	"github.com/spf13/afero"
+)
+
+// server is a fake Redis keeping the strings set on it, which never expire, and publishing the messages to every
+// subscription whatever its pattern.
+type server struct {
+	listener net.Listener
+
+	mu            sync.Mutex
+	values        map[string]string
+	ttls          map[string]string
+	subscriptions map[net.Conn]string
+}
+
+func newServer() *server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	s := &server{listener: listener, values: map[string]string{}, ttls: map[string]string{}, subscriptions: map[net.Conn]string{}}
+	go s.serve()
+	return s
+}
+
+func (s *server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// subscribed returns how many connections are subscribed.
+func (s *server) subscribed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscriptions)
+}
+
+// drop closes the subscribed connections.
+func (s *server) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.subscriptions {
+		conn.Close()
+		delete(s.subscriptions, conn)
+	}
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		command := readCommand(reader)
+		if command == nil {
+			return
+		}
+
+		s.mu.Lock()
+		switch strings.ToUpper(command[0]) {
+		case "PSUBSCRIBE":
+			s.subscriptions[conn] = command[1]
+			fmt.Fprintf(conn, "*3\r\n$10\r\npsubscribe\r\n%s:1\r\n", bulk(command[1]))
+		case "PUBLISH":
+			for subscriber, pattern := range s.subscriptions {
+				fmt.Fprintf(subscriber, "*4\r\n$8\r\npmessage\r\n%s%s%s", bulk(pattern), bulk(command[1]), bulk(command[2]))
+			}
+			fmt.Fprintf(conn, ":%d\r\n", len(s.subscriptions))
+		case "PING":
+			io.WriteString(conn, "*2\r\n$4\r\npong\r\n$0\r\n\r\n")
+		case "SET":
+			s.values[command[1]] = command[2]
+			s.ttls[command[1]] = command[4]
+			io.WriteString(conn, "+OK\r\n")
+		case "GET":
+			if value, ok := s.values[command[1]]; ok {
+				io.WriteString(conn, bulk(value))
+			} else {
+				io.WriteString(conn, "$-1\r\n")
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func bulk(value string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
+
+func readCommand(reader *bufio.Reader) []string {
+	line, err := reader.ReadString('\n')
+	if err != nil || line[0] != '*' {
+		return nil
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+
+	command := []string{}
+	for i := 0; i < n; i++ {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil
+		}
+		command = append(command, string(arg[:size]))
+	}
+	return command
+}
+
+var _ = Describe("Redis", func() {
+	var (
+		fake      *server
+		logBuffer *Buffer
+		state     *Redis
+	)
+
+	BeforeEach(func() {
+		fake = newServer()
+		logBuffer = NewBuffer()
+
+		client, err := redis.Open("redis://"+fake.listener.Addr().String(), &afero.Afero{Fs: afero.NewMemMapFs()})
+		Expect(err).ToNot(HaveOccurred())
+		client.Timeout = time.Second
+
+		state = NewRedis(client, I.DefaultLogger(logBuffer, logging.DEBUG, "sharedstate_test"))
+		state.RetryInterval = 10 * time.Millisecond
+	})
+
+	AfterEach(func() {
+		state.Close()
+		fake.listener.Close()
+	})
+
+	It("passes the messages published on a channel to its subscribers", func() {
+		messages, unsubscribe := state.Subscribe("events")
+		defer unsubscribe()
+		other, unsubscribeOther := state.Subscribe("cancel")
+		defer unsubscribeOther()
+
+		Expect(state.Publish("events", []byte("an event"))).To(Succeed())
+
+		Eventually(messages).Should(Receive(Equal([]byte("an event"))))
+		Consistently(other, 20*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("subscribes once for every subscriber", func() {
+		_, unsubscribe := state.Subscribe("events")
+		defer unsubscribe()
+		_, unsubscribeOther := state.Subscribe("cancel")
+		defer unsubscribeOther()
+
+		Consistently(fake.subscribed, 20*time.Millisecond).Should(Equal(1))
+	})
+
+	It("subscribes again when the subscription is lost", func() {
+		messages, unsubscribe := state.Subscribe("events")
+		defer unsubscribe()
+
+		fake.drop()
+		Eventually(logBuffer).Should(Say("lost the subscription to the shared state"))
+		Eventually(fake.subscribed).Should(Equal(1))
+
+		Expect(state.Publish("events", []byte("an event"))).To(Succeed())
+		Eventually(messages).Should(Receive(Equal([]byte("an event"))))
+	})
+
+	It("puts the values with their TTL and gets them", func() {
+		Expect(state.Put("progress:1234", []byte("finished"), time.Minute)).To(Succeed())
+
+		Expect(fake.values).To(HaveKeyWithValue("deployadactyl:state:progress:1234", "finished"))
+		Expect(fake.ttls).To(HaveKeyWithValue("deployadactyl:state:progress:1234", "60000"))
+
+		value, found, err := state.Get("progress:1234")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(value).To(Equal([]byte("finished")))
+
+		_, found, err = state.Get("progress:5678")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+})
@@ -0,0 +1,78 @@
+// Package sharedstate shares the state of the deployments between the instances of the server through a Redis, so
+// the progress, the event stream and the cancellation of a deployment do not depend on the instance a request
+// lands on.
+package sharedstate
+
+import (
+	"sync"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/redis"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// SubscriberBuffer is how many messages a subscriber can fall behind. Further messages are dropped for it until it
+// catches up, so a slow subscriber never holds up the others.
+const SubscriberBuffer = 64
+
+// New returns the SharedState configured by the descriptor, or nil when the state is not shared.
+func New(descriptor S.SharedStateDescriptor, fs *afero.Afero, log I.Logger) (I.SharedState, error) {
+	if descriptor.Redis == "" {
+		return nil, nil
+	}
+
+	client, err := redis.Open(descriptor.Redis, fs)
+	if err != nil {
+		return nil, err
+	}
+	return NewRedis(client, log), nil
+}
+
+// subscribers are the subscribers of every channel.
+type subscribers struct {
+	mu       sync.Mutex
+	channels map[string]map[chan []byte]bool
+}
+
+func (s *subscribers) add(channel string) (<-chan []byte, func()) {
+	messages := make(chan []byte, SubscriberBuffer)
+
+	s.mu.Lock()
+	if s.channels == nil {
+		s.channels = map[string]map[chan []byte]bool{}
+	}
+	if s.channels[channel] == nil {
+		s.channels[channel] = map[chan []byte]bool{}
+	}
+	s.channels[channel][messages] = true
+	s.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			delete(s.channels[channel], messages)
+			if len(s.channels[channel]) == 0 {
+				delete(s.channels, channel)
+			}
+			close(messages)
+		})
+	}
+	return messages, unsubscribe
+}
+
+// deliver passes the message to the subscribers of the channel that have not fallen behind.
+func (s *subscribers) deliver(channel string, message []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for subscriber := range s.channels[channel] {
+		select {
+		case subscriber <- message:
+		default:
+		}
+	}
+}
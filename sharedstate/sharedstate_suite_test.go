@@ -0,0 +1,13 @@
+package sharedstate_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestSharedstate(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Sharedstate Suite")
+}
@@ -0,0 +1,50 @@
+package deletion
+
+import (
+	"context"
+	"io"
+
+	"github.com/compozed/deployadactyl/config"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/rbac"
+	"github.com/compozed/deployadactyl/state/operation"
+	"github.com/compozed/deployadactyl/structs"
+)
+
+type DeleteControllerConstructor func(log I.DeploymentLogger, deployer I.Deployer, conf config.Config, eventManager I.EventManager, errorFinder I.ErrorFinder, deleteManagerFactory I.DeleteManagerFactory) I.DeleteController
+
+func NewDeleteController(l I.DeploymentLogger, d I.Deployer, c config.Config, em I.EventManager, ef I.ErrorFinder, rmf I.DeleteManagerFactory) I.DeleteController {
+	return &DeleteController{
+		Deployer:             d,
+		Config:               c,
+		EventManager:         em,
+		ErrorFinder:          ef,
+		DeleteManagerFactory: rmf,
+		Log:                  l,
+	}
+}
+
+// DeleteController deletes an application on every foundation.
+type DeleteController struct {
+	Log                  I.DeploymentLogger
+	DeleteManagerFactory I.DeleteManagerFactory
+	Deployer             I.Deployer
+	Config               config.Config
+	EventManager         I.EventManager
+	ErrorFinder          I.ErrorFinder
+}
+
+func (c *DeleteController) DeleteDeployment(ctx context.Context, deployment *I.Deployment, data structs.Params, response io.ReadWriter) I.DeployResponse {
+	controller := operation.Controller{
+		Log:          c.Log,
+		Deployer:     c.Deployer,
+		Config:       c.Config,
+		EventManager: c.EventManager,
+		ErrorFinder:  c.ErrorFinder,
+		Operation:    rbac.Delete,
+		Events:       events,
+	}
+	return controller.Run(ctx, deployment, data, response, func(deployEventData structs.DeployEventData) I.ActionCreator {
+		return c.DeleteManagerFactory.DeleteManager(c.Log, deployEventData)
+	})
+}
@@ -0,0 +1,111 @@
+package deletion_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/compozed/deployadactyl/config"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	. "github.com/compozed/deployadactyl/state/deletion"
+	"github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("DeleteDeployment", func() {
+	var (
+		deleteManagerFactory *mocks.DeleteManagerFactory
+		eventManager         *mocks.EventManager
+		controller           *DeleteController
+		deployment           *I.Deployment
+		logBuffer            *Buffer
+		deployer             *mocks.Deployer
+		environment          string
+		response             *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		logBuffer = NewBuffer()
+		environment = "environment-" + randomizer.StringRunes(10)
+
+		eventManager = &mocks.EventManager{}
+		deployer = &mocks.Deployer{}
+		deleteManagerFactory = &mocks.DeleteManagerFactory{}
+
+		controller = &DeleteController{
+			Log:                  I.DeploymentLogger{Log: I.DefaultLogger(logBuffer, logging.DEBUG, "api_test"), UUID: "uuid-" + randomizer.StringRunes(10)},
+			Deployer:             deployer,
+			DeleteManagerFactory: deleteManagerFactory,
+			EventManager:         eventManager,
+			Config:               config.Config{Environments: map[string]structs.Environment{environment: {Name: environment}}},
+			ErrorFinder:          &mocks.ErrorFinder{},
+		}
+		response = &bytes.Buffer{}
+
+		deployment = &I.Deployment{
+			Authorization: I.Authorization{Username: "myUser", Password: "myPassword"},
+			CFContext: I.CFContext{
+				Organization: "myOrg",
+				Space:        "mySpace",
+				Application:  "myApp",
+				Environment:  environment,
+			},
+			DeleteRoutes: true,
+		}
+	})
+
+	It("should log the delete", func() {
+		deploymentResponse := controller.DeleteDeployment(context.Background(), deployment, nil, response)
+
+		Expect(logBuffer).Should(Say(fmt.Sprintf("Preparing to delete %s with UUID %s", "myApp", deploymentResponse.DeploymentInfo.UUID)))
+	})
+
+	It("should deploy with the delete manager and whether to delete the routes", func() {
+		manager := &mocks.StartManager{}
+		deleteManagerFactory.DeleteManagerCall.Returns.ActionCreater = manager
+
+		controller.DeleteDeployment(context.Background(), deployment, nil, response)
+
+		Expect(deleteManagerFactory.DeleteManagerCall.Received.DeployEventData.DeploymentInfo.Username).Should(Equal("myUser"))
+		Expect(deleteManagerFactory.DeleteManagerCall.Received.DeployEventData.DeploymentInfo.DeleteRoutes).Should(BeTrue())
+		Expect(deployer.DeployCall.Received.ActionCreator).Should(Equal(manager))
+	})
+
+	It("should emit the delete events", func() {
+		controller.DeleteDeployment(context.Background(), deployment, nil, response)
+
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).Should(Equal(reflect.TypeOf(DeleteStartedEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).Should(Equal(reflect.TypeOf(DeleteSuccessEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[2])).Should(Equal(reflect.TypeOf(DeleteFinishedEvent{})))
+		Expect(eventManager.EmitEventCall.Received.Events[1].(DeleteSuccessEvent).CFContext.Application).Should(Equal("myApp"))
+	})
+
+	It("should emit a DeleteFailureEvent when the delete fails", func() {
+		deployer.DeployCall.Returns.Error = errors.New("deploy error")
+
+		controller.DeleteDeployment(context.Background(), deployment, nil, response)
+
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).Should(Equal(reflect.TypeOf(DeleteFailureEvent{})))
+		Expect(eventManager.EmitEventCall.Received.Events[1].(DeleteFailureEvent).Error).Should(MatchError("deploy error"))
+	})
+
+	It("should refuse the delete when the environment does not allow it", func() {
+		controller.Config.Environments[environment] = structs.Environment{
+			Name:   environment,
+			Access: []structs.AccessRule{{Users: []string{"myUser"}, Operations: []string{"start"}}},
+		}
+
+		deploymentResponse := controller.DeleteDeployment(context.Background(), deployment, nil, response)
+
+		Expect(deploymentResponse.StatusCode).Should(Equal(http.StatusForbidden))
+		Expect(deployer.DeployCall.Called).Should(Equal(0))
+	})
+})
@@ -0,0 +1,13 @@
+package deletion_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestDelete(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Delete Suite")
+}
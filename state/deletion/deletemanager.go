@@ -0,0 +1,72 @@
+package deletion
+
+import (
+	"context"
+	"io"
+
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/operation"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+var messages = operation.Messages{Operation: "delete", Done: "deleted", Foundations: "from all foundations"}
+
+type DeleteManager struct {
+	CourierCreator  operation.CourierCreator
+	EventManager    I.EventManager
+	Logger          I.DeploymentLogger
+	DeployEventData S.DeployEventData
+}
+
+func (a DeleteManager) SetUp(ctx context.Context) error {
+	return nil
+}
+
+func (a DeleteManager) OnStart() error {
+	return nil
+}
+
+func (a DeleteManager) OnFinish(env S.Environment, response io.ReadWriter, err error) I.DeployResponse {
+	return messages.OnFinish(a.Logger, response, a.DeployEventData.DeploymentInfo.AppName, err)
+}
+
+func (a DeleteManager) CleanUp() {}
+
+func (a DeleteManager) Create(environment S.Environment, response io.ReadWriter, foundationURL string) (I.Action, error) {
+	courier, err := operation.NewCourier(a.CourierCreator, environment)
+	if err != nil {
+		a.Logger.Error(err)
+		return &Deleter{}, err
+	}
+	r := &Deleter{
+		Courier:       courier,
+		CFContext:     operation.CFContext(environment, a.DeployEventData.DeploymentInfo),
+		Authorization: operation.Authorization(a.DeployEventData.DeploymentInfo),
+		EventManager:  a.EventManager,
+		Response:      response,
+		Log:           a.Logger.WithFields(I.LogFields{I.FoundationLogField: foundationURL, I.AppLogField: a.DeployEventData.DeploymentInfo.AppName}),
+		FoundationURL: foundationURL,
+		AppName:       a.DeployEventData.DeploymentInfo.AppName,
+		DeleteRoutes:  a.DeployEventData.DeploymentInfo.DeleteRoutes,
+	}
+
+	return r, nil
+}
+
+func (a DeleteManager) InitiallyError(initiallyErrors []error) error {
+	return bluegreen.LoginError{LoginErrors: initiallyErrors}
+}
+
+func (a DeleteManager) ExecuteError(executeErrors []error) error {
+	return bluegreen.DeleteError{Errors: executeErrors}
+}
+
+// UndoError returns the errors of the delete, since a deleted application can not be restored.
+func (a DeleteManager) UndoError(executeErrors, undoErrors []error) error {
+	return bluegreen.DeleteError{Errors: executeErrors}
+}
+
+func (a DeleteManager) SuccessError(successErrors []error) error {
+	return bluegreen.FinishDeleteError{FinishDeleteErrors: successErrors}
+}
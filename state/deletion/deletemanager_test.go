@@ -0,0 +1,103 @@
+package deletion_test
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/state/deletion"
+	"github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+type courierCreator struct {
+	CourierCreatorFn func() (interfaces.Courier, error)
+}
+
+func (c courierCreator) CreateCourier() (interfaces.Courier, error) {
+	if c.CourierCreatorFn != nil {
+		return c.CourierCreatorFn()
+	}
+	return &mocks.Courier{}, nil
+}
+
+var _ = Describe("DeleteManager", func() {
+	var (
+		response      *gbytes.Buffer
+		deleteManager deletion.DeleteManager
+		creator       *courierCreator
+	)
+
+	BeforeEach(func() {
+		response = gbytes.NewBuffer()
+		creator = &courierCreator{}
+		deleteManager = deletion.DeleteManager{
+			CourierCreator: creator,
+			Logger:         interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(gbytes.NewBuffer(), logging.DEBUG, "deletemanager_test"), UUID: randomizer.StringRunes(10)},
+			DeployEventData: structs.DeployEventData{
+				DeploymentInfo: &structs.DeploymentInfo{AppName: "myApp", Username: "bob", DeleteRoutes: true},
+				Response:       response,
+			},
+		}
+	})
+
+	Describe("Create", func() {
+		It("returns a Deleter for the foundation", func() {
+			action, err := deleteManager.Create(structs.Environment{Name: "myEnv"}, response, "foundation url")
+			Expect(err).ToNot(HaveOccurred())
+
+			deleter := action.(*deletion.Deleter)
+			Expect(deleter.AppName).To(Equal("myApp"))
+			Expect(deleter.CFContext.Environment).To(Equal("myEnv"))
+			Expect(deleter.Authorization.Username).To(Equal("bob"))
+			Expect(deleter.FoundationURL).To(Equal("foundation url"))
+			Expect(deleter.DeleteRoutes).To(BeTrue())
+		})
+
+		It("returns an error when the courier can not be created", func() {
+			creator.CourierCreatorFn = func() (interfaces.Courier, error) {
+				return nil, errors.New("a test error")
+			}
+
+			_, err := deleteManager.Create(structs.Environment{}, response, "foundation url")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("a test error"))
+		})
+	})
+
+	Describe("UndoError", func() {
+		It("returns the delete errors, since a delete is not rolled back", func() {
+			err := deleteManager.UndoError([]error{errors.New("execute error")}, []error{errors.New("undo error")})
+
+			Expect(err).To(Equal(bluegreen.DeleteError{Errors: []error{errors.New("execute error")}}))
+		})
+	})
+
+	Describe("OnFinish", func() {
+		It("returns http status OK when no error occurs", func() {
+			deployResponse := deleteManager.OnFinish(structs.Environment{}, response, nil)
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
+			Eventually(response).Should(gbytes.Say("Your delete was successful!"))
+		})
+
+		It("returns a bad request when login fails", func() {
+			deployResponse := deleteManager.OnFinish(structs.Environment{}, response, errors.New("login failed"))
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns an internal server error for other errors", func() {
+			deployResponse := deleteManager.OnFinish(structs.Environment{}, response, errors.New("a test error"))
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
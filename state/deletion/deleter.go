@@ -0,0 +1,199 @@
+package deletion
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state"
+)
+
+// Deleter deletes an application from a single foundation. With DeleteRoutes the routes of the application no other
+// application of the space is mapped to are deleted with it, like the -r flag of the Cloud Foundry delete command.
+type Deleter struct {
+	Courier       I.Courier
+	CFContext     I.CFContext
+	Authorization I.Authorization
+	EventManager  I.EventManager
+	Response      io.ReadWriter
+	Log           I.DeploymentLogger
+	FoundationURL string
+	AppName       string
+	DeleteRoutes  bool
+}
+
+func (d Deleter) Verify(ctx context.Context) error {
+	return nil
+}
+
+func (d Deleter) Success(ctx context.Context) error {
+	return nil
+}
+
+// Finally removes the temporary directory created by the Executor.
+func (d Deleter) Finally(ctx context.Context) error {
+	return d.Courier.CleanUp()
+}
+
+// Undo does nothing, since a deleted application can not be restored.
+func (d Deleter) Undo(ctx context.Context) error {
+	return nil
+}
+
+// Login will login to a Cloud Foundry instance.
+func (d Deleter) Initially(ctx context.Context) error {
+	d.Courier = d.Courier.WithContext(ctx)
+
+	d.Log.Debugf(
+		`logging into cloud foundry with parameters:
+		foundation URL: %+v
+		username: %+v
+		org: %+v
+		space: %+v`,
+		d.FoundationURL, d.Authorization.Username, d.CFContext.Organization, d.CFContext.Space,
+	)
+
+	output, err := d.Courier.Login(
+		d.FoundationURL,
+		d.Authorization.Username,
+		d.Authorization.Password,
+		d.CFContext.Organization,
+		d.CFContext.Space,
+		d.CFContext.SkipSSL,
+	)
+	d.Response.Write(output)
+	if err != nil {
+		d.Log.Errorf("could not login to %s", d.FoundationURL)
+		return state.LoginError{FoundationURL: d.FoundationURL, Out: output}
+	}
+
+	d.Log.Infof("logged into cloud foundry %s", d.FoundationURL)
+
+	return nil
+}
+
+// Execute deletes the application, and then the routes it leaves without an application when DeleteRoutes is set.
+// An application that does not exist is already deleted, so deleting it again succeeds.
+func (d Deleter) Execute(ctx context.Context) error {
+	d.Courier = d.Courier.WithContext(ctx)
+
+	if !d.Courier.Exists(d.AppName) {
+		d.Log.Infof("app %s does not exist on foundation %s", d.AppName, d.FoundationURL)
+		fmt.Fprintf(d.Response, "\n%s does not exist on %s\n", d.AppName, d.FoundationURL)
+		return nil
+	}
+
+	var routes []string
+	if d.DeleteRoutes {
+		var err error
+		routes, err = d.Courier.AppRoutes(d.AppName)
+		if err != nil {
+			d.Log.Errorf("could not get the routes of app %s: %s", d.AppName, err)
+			return state.RouteCleanupError{ApplicationName: d.AppName, Err: err}
+		}
+	}
+
+	d.Log.Infof("deleting app %s", d.AppName)
+
+	output, err := d.Courier.Delete(d.AppName)
+	d.Response.Write(output)
+	if err != nil {
+		d.Log.Errorf("failed to delete app on foundation %s: %s", d.FoundationURL, err.Error())
+		return state.DeleteApplicationError{ApplicationName: d.AppName, Out: output}
+	}
+
+	fmt.Fprintf(d.Response, "\ndeleted %s on %s\n", d.AppName, d.FoundationURL)
+	d.Log.Infof("successfully deleted app %s", d.AppName)
+
+	if len(routes) == 0 {
+		return nil
+	}
+	return d.deleteOrphanedRoutes(routes)
+}
+
+// deleteOrphanedRoutes deletes the routes no other application of the space is mapped to. Routes with a path are
+// kept, since they can not be deleted by their hostname and domain.
+func (d Deleter) deleteOrphanedRoutes(routes []string) error {
+	mapped, err := d.spaceRoutes()
+	if err != nil {
+		d.Log.Errorf("could not get the routes of the space: %s", err)
+		return state.RouteCleanupError{ApplicationName: d.AppName, Err: err}
+	}
+
+	domains, err := d.Courier.Domains()
+	if err != nil {
+		d.Log.Errorf("could not get the domains: %s", err)
+		return state.RouteCleanupError{ApplicationName: d.AppName, Err: err}
+	}
+
+	for _, route := range routes {
+		if mapped[route] {
+			fmt.Fprintf(d.Response, "keeping route %s, which is mapped to another application\n", route)
+			continue
+		}
+
+		hostname, domain, ok := splitRoute(route, domains)
+		if !ok {
+			fmt.Fprintf(d.Response, "keeping route %s, which can not be deleted by its hostname and domain\n", route)
+			continue
+		}
+
+		d.Log.Infof("deleting route %s", route)
+		output, err := d.Courier.DeleteRoute(domain, hostname)
+		d.Response.Write(output)
+		if err != nil {
+			d.Log.Errorf("failed to delete route %s on foundation %s: %s", route, d.FoundationURL, err.Error())
+			return state.DeleteRouteError{Route: route, Out: output}
+		}
+	}
+
+	return nil
+}
+
+// spaceRoutes returns the routes mapped to the other applications of the space.
+func (d Deleter) spaceRoutes() (map[string]bool, error) {
+	apps, err := d.Courier.Apps()
+	if err != nil {
+		return nil, err
+	}
+
+	mapped := map[string]bool{}
+	for _, app := range apps {
+		if app.Org != d.CFContext.Organization || app.Space != d.CFContext.Space || app.Name == d.AppName {
+			continue
+		}
+
+		routes, err := d.Courier.AppRoutes(app.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, route := range routes {
+			mapped[route] = true
+		}
+	}
+
+	return mapped, nil
+}
+
+// splitRoute returns the hostname and the longest of the domains the route is on. A route with a path, or on none of
+// the domains, is not split.
+func splitRoute(route string, domains []string) (hostname, domain string, ok bool) {
+	if strings.Contains(route, "/") {
+		return "", "", false
+	}
+
+	for _, d := range domains {
+		if len(d) <= len(domain) {
+			continue
+		}
+		if route == d {
+			hostname, domain, ok = "", d, true
+		} else if strings.HasSuffix(route, "."+d) {
+			hostname, domain, ok = strings.TrimSuffix(route, "."+d), d, true
+		}
+	}
+
+	return hostname, domain, ok
+}
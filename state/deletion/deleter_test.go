@@ -0,0 +1,180 @@
+package deletion_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/state"
+	. "github.com/compozed/deployadactyl/state/deletion"
+	"github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Deleter", func() {
+	var (
+		deleter Deleter
+		courier *mocks.Courier
+
+		randomUsername      string
+		randomPassword      string
+		randomOrg           string
+		randomSpace         string
+		randomAppName       string
+		randomFoundationURL string
+		response            *Buffer
+		logBuffer           *Buffer
+	)
+
+	BeforeEach(func() {
+		courier = &mocks.Courier{}
+
+		randomFoundationURL = "randomFoundationURL-" + randomizer.StringRunes(10)
+		randomUsername = "randomUsername-" + randomizer.StringRunes(10)
+		randomPassword = "randomPassword-" + randomizer.StringRunes(10)
+		randomOrg = "randomOrg-" + randomizer.StringRunes(10)
+		randomSpace = "randomSpace-" + randomizer.StringRunes(10)
+		randomAppName = "randomAppName-" + randomizer.StringRunes(10)
+
+		response = NewBuffer()
+		logBuffer = NewBuffer()
+
+		deleter = Deleter{
+			Courier: courier,
+			CFContext: interfaces.CFContext{
+				Organization: randomOrg,
+				Space:        randomSpace,
+				Application:  randomAppName,
+			},
+			Authorization: interfaces.Authorization{
+				Username: randomUsername,
+				Password: randomPassword,
+			},
+			EventManager:  &mocks.EventManager{},
+			Response:      response,
+			Log:           interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(logBuffer, logging.DEBUG, "deleter_test")},
+			FoundationURL: randomFoundationURL,
+			AppName:       randomAppName,
+		}
+	})
+
+	Describe("Initially", func() {
+		It("logs into the foundation", func() {
+			courier.LoginCall.Returns.Output = []byte("login succeeded")
+
+			Expect(deleter.Initially(context.Background())).To(Succeed())
+
+			Expect(courier.LoginCall.Received.FoundationURL).To(Equal(randomFoundationURL))
+			Expect(courier.LoginCall.Received.Username).To(Equal(randomUsername))
+			Expect(courier.LoginCall.Received.Password).To(Equal(randomPassword))
+			Expect(courier.LoginCall.Received.Org).To(Equal(randomOrg))
+			Expect(courier.LoginCall.Received.Space).To(Equal(randomSpace))
+			Eventually(response).Should(Say("login succeeded"))
+		})
+
+		It("returns an error when login fails", func() {
+			courier.LoginCall.Returns.Output = []byte("login output")
+			courier.LoginCall.Returns.Error = errors.New("login error")
+
+			err := deleter.Initially(context.Background())
+
+			Expect(err).To(MatchError(state.LoginError{FoundationURL: randomFoundationURL, Out: []byte("login output")}))
+		})
+	})
+
+	Describe("Execute", func() {
+		BeforeEach(func() {
+			courier.ExistsCall.Returns.Bool = true
+		})
+
+		It("deletes the application", func() {
+			courier.DeleteCall.Returns.Output = []byte("deleting app")
+
+			Expect(deleter.Execute(context.Background())).To(Succeed())
+
+			Expect(courier.DeleteCall.Received.AppName).To(Equal(randomAppName))
+			Expect(courier.DeleteRouteCall.Received.Routes).To(BeEmpty())
+			Eventually(response).Should(Say("deleting app"))
+			Eventually(response).Should(Say("deleted %s on %s", randomAppName, randomFoundationURL))
+		})
+
+		It("succeeds when the application does not exist", func() {
+			courier.ExistsCall.Returns.Bool = false
+
+			Expect(deleter.Execute(context.Background())).To(Succeed())
+
+			Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+			Eventually(response).Should(Say("%s does not exist on %s", randomAppName, randomFoundationURL))
+		})
+
+		It("returns an error when the application can not be deleted", func() {
+			courier.DeleteCall.Returns.Output = []byte("delete failed")
+			courier.DeleteCall.Returns.Error = errors.New("exit status 1")
+
+			err := deleter.Execute(context.Background())
+
+			Expect(err).To(MatchError(state.DeleteApplicationError{ApplicationName: randomAppName, Out: []byte("delete failed")}))
+			Eventually(response).Should(Say("delete failed"))
+		})
+
+		Context("when the routes are deleted", func() {
+			BeforeEach(func() {
+				deleter.DeleteRoutes = true
+				courier.DomainsCall.Returns.Domains = []string{"example.com", "apps.example.com"}
+				courier.AppsCall.Returns.Apps = []structs.AppSummary{
+					{Name: randomAppName, Org: randomOrg, Space: randomSpace},
+					{Name: "neighbour", Org: randomOrg, Space: randomSpace},
+					{Name: "elsewhere", Org: randomOrg, Space: "other-space"},
+				}
+				courier.AppRoutesCall.Returns.Apps = map[string][]string{
+					randomAppName: {"app.apps.example.com", "shared.apps.example.com", "apps.example.com", "app.example.com/path", "app.other.com"},
+					"neighbour":   {"shared.apps.example.com"},
+					"elsewhere":   {"app.apps.example.com"},
+				}
+			})
+
+			It("deletes the routes no other application of the space is mapped to", func() {
+				Expect(deleter.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.DeleteCall.Received.AppName).To(Equal(randomAppName))
+				Expect(courier.DeleteRouteCall.Received.Routes).To(Equal([]string{"app.apps.example.com", "apps.example.com"}))
+				Eventually(response).Should(Say("keeping route shared.apps.example.com, which is mapped to another application"))
+				Eventually(response).Should(Say("keeping route app.example.com/path"))
+				Eventually(response).Should(Say("keeping route app.other.com"))
+			})
+
+			It("does not delete the application when its routes can not be read", func() {
+				courier.AppRoutesCall.Returns.Error = errors.New("routes error")
+
+				err := deleter.Execute(context.Background())
+
+				Expect(err).To(MatchError(state.RouteCleanupError{ApplicationName: randomAppName, Err: errors.New("routes error")}))
+				Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+			})
+
+			It("returns an error when a route can not be deleted", func() {
+				courier.DeleteRouteCall.Returns.Output = []byte("route in use")
+				courier.DeleteRouteCall.Returns.Error = errors.New("exit status 1")
+
+				err := deleter.Execute(context.Background())
+
+				Expect(err).To(MatchError(state.DeleteRouteError{Route: "app.apps.example.com", Out: []byte("route in use")}))
+			})
+		})
+	})
+
+	It("can not be rolled back", func() {
+		Expect(deleter.Undo(context.Background())).To(Succeed())
+		Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+	})
+
+	It("cleans up the courier in Finally", func() {
+		Expect(deleter.Finally(context.Background())).To(Succeed())
+		Expect(courier.CleanUpCall.Called).To(BeTrue())
+	})
+})
@@ -0,0 +1,62 @@
+package deletion
+
+import (
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/operation"
+)
+
+// events make the events of a delete.
+var events = operation.Events{
+	Started:  func(e operation.Event) I.IEvent { return DeleteStartedEvent(e) },
+	Success:  func(e operation.Event) I.IEvent { return DeleteSuccessEvent(e) },
+	Failure:  func(e operation.Event) I.IEvent { return DeleteFailureEvent(e) },
+	Finished: func(e operation.Event) I.IEvent { return DeleteFinishedEvent(e) },
+}
+
+type DeleteFailureEvent operation.Event
+
+func (e DeleteFailureEvent) Name() string {
+	return "DeleteFailureEvent"
+}
+
+func NewDeleteFailureEventBinding(handler func(event DeleteFailureEvent) error) I.Binding {
+	return operation.NewBinding(DeleteFailureEvent{}, func(event interface{}) error {
+		return handler(event.(DeleteFailureEvent))
+	})
+}
+
+type DeleteSuccessEvent operation.Event
+
+func (e DeleteSuccessEvent) Name() string {
+	return "DeleteSuccessEvent"
+}
+
+func NewDeleteSuccessEventBinding(handler func(event DeleteSuccessEvent) error) I.Binding {
+	return operation.NewBinding(DeleteSuccessEvent{}, func(event interface{}) error {
+		return handler(event.(DeleteSuccessEvent))
+	})
+}
+
+type DeleteStartedEvent operation.Event
+
+func (e DeleteStartedEvent) Name() string {
+	return "DeleteStartedEvent"
+}
+
+func NewDeleteStartedEventBinding(handler func(event DeleteStartedEvent) error) I.Binding {
+	return operation.NewBinding(DeleteStartedEvent{}, func(event interface{}) error {
+		return handler(event.(DeleteStartedEvent))
+	})
+}
+
+type DeleteFinishedEvent operation.Event
+
+func (e DeleteFinishedEvent) Name() string {
+	return "DeleteFinishedEvent"
+}
+
+func NewDeleteFinishedEventBinding(handler func(event DeleteFinishedEvent) error) I.Binding {
+	return operation.NewBinding(DeleteFinishedEvent{}, func(event interface{}) error {
+		return handler(event.(DeleteFinishedEvent))
+	})
+}
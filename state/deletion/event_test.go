@@ -0,0 +1,98 @@
+package deletion_test
+
+import (
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/deletion"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("event binding", func() {
+	Describe("DeleteStartedEventBinding", func() {
+		It("should accept a DeleteStartedEvent only", func() {
+			binding := deletion.NewDeleteStartedEventBinding(nil)
+
+			Expect(binding.Accepts(deletion.DeleteStartedEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received deletion.DeleteStartedEvent
+			binding := deletion.NewDeleteStartedEventBinding(func(event deletion.DeleteStartedEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(deletion.DeleteStartedEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("DeleteSuccessEventBinding", func() {
+		It("should accept a DeleteSuccessEvent only", func() {
+			binding := deletion.NewDeleteSuccessEventBinding(nil)
+
+			Expect(binding.Accepts(deletion.DeleteSuccessEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received deletion.DeleteSuccessEvent
+			binding := deletion.NewDeleteSuccessEventBinding(func(event deletion.DeleteSuccessEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(deletion.DeleteSuccessEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("DeleteFailureEventBinding", func() {
+		It("should accept a DeleteFailureEvent only", func() {
+			binding := deletion.NewDeleteFailureEventBinding(nil)
+
+			Expect(binding.Accepts(deletion.DeleteFailureEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received deletion.DeleteFailureEvent
+			binding := deletion.NewDeleteFailureEventBinding(func(event deletion.DeleteFailureEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(deletion.DeleteFailureEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("DeleteFinishedEventBinding", func() {
+		It("should accept a DeleteFinishedEvent only", func() {
+			binding := deletion.NewDeleteFinishedEventBinding(nil)
+
+			Expect(binding.Accepts(deletion.DeleteFinishedEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received deletion.DeleteFinishedEvent
+			binding := deletion.NewDeleteFinishedEventBinding(func(event deletion.DeleteFinishedEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(deletion.DeleteFinishedEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+})
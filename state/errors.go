@@ -1,6 +1,12 @@
 package state
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
 
 type CloudFoundryGetLogsError struct {
 	CfTaskErr error
@@ -44,6 +50,33 @@ func (e PushError) Error() string {
 	return "check the Cloud Foundry output above for more information"
 }
 
+type TaskError struct {
+	Task string
+	Err  error
+}
+
+func (e TaskError) Error() string {
+	return fmt.Sprintf("cannot run task %s: %s", e.Task, e.Err)
+}
+
+type TaskFailedError struct {
+	Task   string
+	Reason string
+}
+
+func (e TaskFailedError) Error() string {
+	return fmt.Sprintf("task %s failed: %s", e.Task, e.Reason)
+}
+
+type TaskTimeoutError struct {
+	Task    string
+	Timeout time.Duration
+}
+
+func (e TaskTimeoutError) Error() string {
+	return fmt.Sprintf("task %s did not finish within %s", e.Task, e.Timeout)
+}
+
 type MapRouteError struct {
 	Out []byte
 }
@@ -61,6 +94,26 @@ func (e UnmapRouteError) Error() string {
 	return fmt.Sprintf("failed to unmap route for %s: %s", e.ApplicationName, string(e.Out))
 }
 
+type RouteVerificationError struct {
+	ApplicationName string
+	FoundationURL   string
+	Err             error
+}
+
+func (e RouteVerificationError) Error() string {
+	return fmt.Sprintf("cannot verify the routes of %s on %s: %s", e.ApplicationName, e.FoundationURL, e.Err)
+}
+
+type RouteNotMappedError struct {
+	ApplicationName string
+	FoundationURL   string
+	Route           string
+}
+
+func (e RouteNotMappedError) Error() string {
+	return fmt.Sprintf("route %s is not mapped to %s on %s", e.Route, e.ApplicationName, e.FoundationURL)
+}
+
 type InvalidContentTypeError struct{}
 
 func (e InvalidContentTypeError) Error() string {
@@ -81,6 +134,72 @@ func (e ManifestError) Error() string {
 	return "manifest decoding error"
 }
 
+type InvalidManifestNameError struct {
+	Name string
+}
+
+func (e InvalidManifestNameError) Error() string {
+	return fmt.Sprintf("invalid manifest name %s: only letters, digits, dashes and underscores are allowed", e.Name)
+}
+
+type ManifestNotFoundError struct {
+	Name string
+}
+
+func (e ManifestNotFoundError) Error() string {
+	return fmt.Sprintf("manifest %s was not found in the request or in the artifact as manifest-%s.yml", e.Name, e.Name)
+}
+
+type ManifestSelectionError struct {
+	Name string
+	Err  error
+}
+
+func (e ManifestSelectionError) Error() string {
+	return fmt.Sprintf("cannot select manifest-%s.yml of the artifact: %s", e.Name, e.Err)
+}
+
+type ManifestTemplateError struct {
+	Err error
+}
+
+func (e ManifestTemplateError) Error() string {
+	return fmt.Sprintf("cannot generate the manifest from the manifest template: %s", e.Err)
+}
+
+type ManifestOverlayError struct {
+	Err error
+}
+
+func (e ManifestOverlayError) Error() string {
+	return fmt.Sprintf("cannot merge the manifest overlay of the environment: %s", e.Err)
+}
+
+type ManifestMergeError struct {
+	Err error
+}
+
+func (e ManifestMergeError) Error() string {
+	return fmt.Sprintf("cannot merge the manifest of the request onto the manifest of the artifact: %s", e.Err)
+}
+
+type EnvironmentVariablesError struct {
+	Err error
+}
+
+func (e EnvironmentVariablesError) Error() string {
+	return fmt.Sprintf("cannot set the environment variables of the deployment in the manifest: %s", e.Err)
+}
+
+type MetadataServiceError struct {
+	Service string
+	Out     []byte
+}
+
+func (e MetadataServiceError) Error() string {
+	return fmt.Sprintf("cannot write the deployment metadata to the user provided service %s: %s", e.Service, e.Out)
+}
+
 type UnzippingError struct {
 	Err error
 }
@@ -106,6 +225,84 @@ func (e StartError) Error() string {
 	return fmt.Sprintf("cannot start %s: %s", e.ApplicationName, string(e.Out))
 }
 
+type ScaleError struct {
+	ApplicationName string
+	Out             []byte
+}
+
+func (e ScaleError) Error() string {
+	return fmt.Sprintf("cannot scale %s: %s", e.ApplicationName, string(e.Out))
+}
+
+type InstanceCrashedError struct {
+	ApplicationName string
+	FoundationURL   string
+}
+
+func (e InstanceCrashedError) Error() string {
+	return fmt.Sprintf("an instance of %s crashed while starting on %s", e.ApplicationName, e.FoundationURL)
+}
+
+type RestartInstanceError struct {
+	ApplicationName string
+	Index           int
+	Out             []byte
+}
+
+func (e RestartInstanceError) Error() string {
+	return fmt.Sprintf("cannot restart instance %d of %s: %s", e.Index, e.ApplicationName, string(e.Out))
+}
+
+type RestageError struct {
+	ApplicationName string
+	Out             []byte
+}
+
+func (e RestageError) Error() string {
+	return fmt.Sprintf("cannot restage %s: %s", e.ApplicationName, string(e.Out))
+}
+
+type DeleteRouteError struct {
+	Route string
+	Out   []byte
+}
+
+func (e DeleteRouteError) Error() string {
+	return fmt.Sprintf("cannot delete route %s: %s", e.Route, string(e.Out))
+}
+
+type RouteCleanupError struct {
+	ApplicationName string
+	Err             error
+}
+
+func (e RouteCleanupError) Error() string {
+	return fmt.Sprintf("cannot find the routes left by %s: %s", e.ApplicationName, e.Err)
+}
+
+type RestartTimeoutError struct {
+	ApplicationName string
+	FoundationURL   string
+	Index           int
+	Timeout         time.Duration
+}
+
+func (e RestartTimeoutError) Error() string {
+	return fmt.Sprintf("instance %d of %s was not running on %s %s after it was restarted", e.Index, e.ApplicationName, e.FoundationURL, e.Timeout)
+}
+
+type StartTimeoutError struct {
+	ApplicationName string
+	FoundationURL   string
+	Running         int
+	Instances       int
+	Timeout         time.Duration
+}
+
+func (e StartTimeoutError) Error() string {
+	return fmt.Sprintf("only %d of %d instances of %s were running on %s after %s", e.Running, e.Instances, e.ApplicationName, e.FoundationURL, e.Timeout)
+}
+
 type StopError struct {
 	ApplicationName string
 	Out             []byte
@@ -122,3 +319,121 @@ type ExistsError struct {
 func (e ExistsError) Error() string {
 	return fmt.Sprintf("app %s doesn't exist", e.ApplicationName)
 }
+
+type StepError struct {
+	Name string
+	Err  error
+}
+
+func (e StepError) Error() string {
+	return fmt.Sprintf("push step %s failed: %s", e.Name, e.Err)
+}
+
+type StepNotFoundError struct {
+	Phase string
+	Name  string
+}
+
+func (e StepNotFoundError) Error() string {
+	return fmt.Sprintf("push step %s not found in the %s phase", e.Name, e.Phase)
+}
+
+type InvalidPhaseError struct {
+	Phase string
+}
+
+func (e InvalidPhaseError) Error() string {
+	return fmt.Sprintf("invalid push step phase: %s", e.Phase)
+}
+
+type InvalidStepError struct {
+	Name string
+	Err  error
+}
+
+func (e InvalidStepError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("invalid push step %s: %s", e.Name, e.Err)
+	}
+	return fmt.Sprintf("invalid push step %s: name and url are required", e.Name)
+}
+
+type ArtifactScanError struct {
+	Err error
+}
+
+func (e ArtifactScanError) Error() string {
+	return fmt.Sprintf("artifact scan failed: %s", e.Err)
+}
+
+type ScanFindingsError struct {
+	Findings []S.ScanFinding
+}
+
+func (e ScanFindingsError) Error() string {
+	findings := []string{}
+	for _, finding := range e.Findings {
+		findings = append(findings, fmt.Sprintf("%s: %s", finding.Path, finding.Description))
+	}
+	return fmt.Sprintf("artifact scan reported %d findings: %s", len(e.Findings), strings.Join(findings, ", "))
+}
+
+type ArtifactInventoryError struct {
+	Err error
+}
+
+func (e ArtifactInventoryError) Error() string {
+	return fmt.Sprintf("cannot record the contents of the artifact: %s", e.Err)
+}
+
+type ArtifactDigestMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e ArtifactDigestMismatchError) Error() string {
+	return fmt.Sprintf("the digest of the artifact is %s, expected %s", e.Actual, e.Expected)
+}
+
+type PromotionRolledBackError struct {
+	ApplicationName string
+	FoundationURL   string
+	Reason          string
+}
+
+func (e PromotionRolledBackError) Error() string {
+	return fmt.Sprintf("%s was rolled back to the previous application on %s: %s", e.ApplicationName, e.FoundationURL, e.Reason)
+}
+
+type PromotionGateBreachedError struct {
+	Name          string
+	FoundationURL string
+	Value         float64
+	Max           float64
+	NoData        bool
+}
+
+func (e PromotionGateBreachedError) Error() string {
+	if e.NoData {
+		return fmt.Sprintf("promotion gate %s returned no data on %s", e.Name, e.FoundationURL)
+	}
+	return fmt.Sprintf("promotion gate %s measured %g on %s, above its maximum of %g", e.Name, e.Value, e.FoundationURL, e.Max)
+}
+
+type ArtifactURLWithGitError struct{}
+
+func (e ArtifactURLWithGitError) Error() string {
+	return "a deployment has either an artifact_url or a git repository, not both"
+}
+
+type MavenWithOtherSourceError struct{}
+
+func (e MavenWithOtherSourceError) Error() string {
+	return "a deployment with maven coordinates can not also have an artifact_url or a git repository"
+}
+
+type InvalidMavenCoordinatesError struct{}
+
+func (e InvalidMavenCoordinatesError) Error() string {
+	return "maven coordinates can not contain colons"
+}
@@ -0,0 +1,76 @@
+package state
+
+import (
+	"strconv"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+const (
+	// InstancesLabel records the number of instances an application had when it was stopped.
+	InstancesLabel = "deployadactyl.io/instances"
+
+	// StateLabel records the state an application was in when it was stopped.
+	StateLabel = "deployadactyl.io/state"
+
+	// MetadataLabelPrefix is the prefix of the labels the metadata of a deployment is set as.
+	MetadataLabelPrefix = "metadata.deployadactyl.io/"
+)
+
+// maxLabelLength is the longest label name or value Cloud Foundry accepts.
+const maxLabelLength = 63
+
+// RecordedInstances returns the instance count recorded in the labels of an application when it was stopped.
+func RecordedInstances(appState S.AppState) (uint16, bool) {
+	recorded, ok := appState.Labels[InstancesLabel]
+	if !ok {
+		return 0, false
+	}
+
+	instances, err := strconv.ParseUint(recorded, 10, 16)
+	if err != nil || instances == 0 {
+		return 0, false
+	}
+
+	return uint16(instances), true
+}
+
+// MetadataLabels returns the metadata of a deployment as labels of the application. Cloud Foundry only accepts
+// alphanumerics, '-', '_' and '.' in label names and values, which must start and end with an alphanumeric and be
+// at most 63 characters long. Other characters are replaced with '-' and the rest is trimmed to fit, and keys
+// with nothing left of them are dropped.
+func MetadataLabels(metadata map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for key, value := range metadata {
+		name := sanitizeLabel(key)
+		if name == "" {
+			continue
+		}
+		labels[MetadataLabelPrefix+name] = sanitizeLabel(value)
+	}
+	return labels
+}
+
+func sanitizeLabel(s string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if isAlphanumeric(r) || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, s)
+
+	sanitized = strings.TrimFunc(sanitized, isNotAlphanumeric)
+	if len(sanitized) > maxLabelLength {
+		sanitized = strings.TrimRightFunc(sanitized[:maxLabelLength], isNotAlphanumeric)
+	}
+	return sanitized
+}
+
+func isAlphanumeric(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+func isNotAlphanumeric(r rune) bool {
+	return !isAlphanumeric(r)
+}
@@ -0,0 +1,192 @@
+package operation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/compozed/deployadactyl/config"
+	"github.com/compozed/deployadactyl/controller/deployer"
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/rbac"
+	"github.com/compozed/deployadactyl/structs"
+)
+
+// Controller runs an operation on an application on every foundation of its environment, such as a start or a
+// restart. Operation is the rbac operation the request must be allowed, and Events make the events it emits.
+type Controller struct {
+	Log          I.DeploymentLogger
+	Deployer     I.Deployer
+	Config       config.Config
+	EventManager I.EventManager
+	ErrorFinder  I.ErrorFinder
+	Operation    string
+	Events       Events
+}
+
+// Run resolves the environment and credentials of the deployment and runs the operation with the action creator
+// the manager returns for it.
+func (c *Controller) Run(ctx context.Context, deployment *I.Deployment, data structs.Params, response io.ReadWriter, manager func(deployEventData structs.DeployEventData) I.ActionCreator) (deployResponse I.DeployResponse) {
+	cf := deployment.CFContext
+	c.Log.Debugf("Preparing to %s %s with UUID %s", c.Operation, cf.Application, c.Log.UUID)
+
+	if data == nil {
+		data = make(structs.Params)
+	}
+
+	environment, err := c.resolveEnvironment(cf.Environment)
+	if err != nil {
+		fmt.Fprintln(response, err.Error())
+		return I.DeployResponse{
+			StatusCode: http.StatusInternalServerError,
+			Error:      err,
+		}
+	}
+	err = rbac.Authorize(environment, c.Operation, deployment.Authorization)
+	if err != nil {
+		json.NewEncoder(response).Encode(err)
+		return I.DeployResponse{
+			StatusCode: http.StatusForbidden,
+			Error:      err,
+		}
+	}
+	auth, err := c.resolveAuthorization(deployment.Authorization, environment, c.Log)
+	if err != nil {
+		// Only missing basic auth is the fault of the request: the credentials of the environment could not be read.
+		statusCode := http.StatusInternalServerError
+		if _, ok := err.(deployer.BasicAuthError); ok {
+			statusCode = http.StatusUnauthorized
+		}
+		return I.DeployResponse{
+			StatusCode: statusCode,
+			Error:      err,
+		}
+	}
+
+	deploymentInfo := &structs.DeploymentInfo{
+		Org:          cf.Organization,
+		Space:        cf.Space,
+		AppName:      cf.Application,
+		Environment:  cf.Environment,
+		UUID:         c.Log.UUID,
+		Domain:       environment.Domain,
+		SkipSSL:      environment.SkipSSL,
+		CustomParams: environment.CustomParams,
+		Username:     auth.Username,
+		Password:     auth.Password,
+		Subject:      auth.Subject,
+		Data:         data,
+		Metadata:     deployment.Metadata,
+		BatchSize:    deployment.BatchSize,
+		DeleteRoutes: deployment.DeleteRoutes,
+	}
+
+	event := Event{
+		CFContext:     cf,
+		Authorization: auth,
+		Environment:   environment,
+		Data:          data,
+		Metadata:      deploymentInfo.Metadata,
+		Response:      response,
+		Log:           c.Log,
+	}
+
+	defer c.emitFinish(event)
+	defer c.emitSuccessOrFailure(event, &deployResponse)
+
+	started := c.Events.Started(event)
+	err = c.EventManager.EmitEvent(started)
+	if err != nil {
+		c.Log.Error(err)
+		err = &bluegreen.InitializationError{err}
+		return I.DeployResponse{
+			StatusCode:     http.StatusInternalServerError,
+			Error:          deployer.EventError{Type: started.Name(), Err: err},
+			DeploymentInfo: deploymentInfo,
+		}
+	}
+
+	deployEventData := structs.DeployEventData{Response: response, DeploymentInfo: deploymentInfo}
+
+	deployResponse = *c.Deployer.Deploy(ctx, deploymentInfo, environment, manager(deployEventData), response)
+	return deployResponse
+}
+
+func (c *Controller) resolveAuthorization(auth I.Authorization, envs structs.Environment, deploymentLogger I.DeploymentLogger) (I.Authorization, error) {
+	config := c.Config
+	deploymentLogger.Debug("checking for basic auth")
+	if auth.Username == "" && auth.Password == "" {
+		if envs.Authenticate && auth.Subject == "" {
+			return I.Authorization{}, deployer.BasicAuthError{}
+
+		}
+		username, password, err := config.Credentials(envs)
+		if err != nil {
+			return I.Authorization{}, err
+		}
+		auth.Username, auth.Password = username, password
+	}
+
+	return auth, nil
+}
+
+func (c *Controller) resolveEnvironment(env string) (structs.Environment, error) {
+	config := c.Config
+	environment, ok := config.Environments[env]
+	if !ok {
+		return structs.Environment{}, deployer.EnvironmentNotFoundError{env}
+	}
+	return environment, nil
+}
+
+func (c *Controller) emitFinish(event Event) {
+	event.Response = nil
+	finished := c.Events.Finished(event)
+	event.Log.Debugf("emitting a %s event", finished.Name())
+	c.EventManager.EmitEvent(finished)
+}
+
+func (c *Controller) emitSuccessOrFailure(event Event, deployResponse *I.DeployResponse) {
+	var emitted I.IEvent
+
+	if deployResponse.Error != nil {
+		c.printErrors(event.Response, &deployResponse.Error)
+		event.Error = deployResponse.Error
+		emitted = c.Events.Failure(event)
+	} else {
+		emitted = c.Events.Success(event)
+	}
+	event.Log.Debugf("emitting a %s event", emitted.Name())
+	eventErr := c.EventManager.EmitEvent(emitted)
+	if eventErr != nil {
+		event.Log.Errorf("an error occurred when emitting a %s event: %s", emitted.Name(), eventErr)
+		fmt.Fprintln(event.Response, eventErr)
+	}
+}
+
+func (c *Controller) printErrors(response io.ReadWriter, err *error) {
+	tempBuffer := bytes.Buffer{}
+	tempBuffer.ReadFrom(response)
+	fmt.Fprint(response, tempBuffer.String())
+
+	errors := c.ErrorFinder.FindErrors(tempBuffer.String())
+	if len(errors) > 0 {
+		*err = errors[0]
+		for _, error := range errors {
+			fmt.Fprintln(response)
+			fmt.Fprintln(response, "*******************")
+			fmt.Fprintln(response)
+			fmt.Fprintln(response, "The following error was found in the above logs: "+error.Error())
+			fmt.Fprintln(response)
+			fmt.Fprintln(response, "Error: "+error.Details()[0])
+			fmt.Fprintln(response)
+			fmt.Fprintln(response, "Potential solution: "+error.Solution())
+			fmt.Fprintln(response)
+			fmt.Fprintln(response, "*******************")
+		}
+	}
+}
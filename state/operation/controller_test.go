@@ -0,0 +1,626 @@
+package operation_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/compozed/deployadactyl/config"
+	D "github.com/compozed/deployadactyl/controller/deployer"
+	"github.com/compozed/deployadactyl/controller/deployer/error_finder"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	. "github.com/compozed/deployadactyl/state/operation"
+	"github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+type startedEvent Event
+
+func (e startedEvent) Name() string { return "startedEvent" }
+
+type successEvent Event
+
+func (e successEvent) Name() string { return "successEvent" }
+
+type failureEvent Event
+
+func (e failureEvent) Name() string { return "failureEvent" }
+
+type finishedEvent Event
+
+func (e finishedEvent) Name() string { return "finishedEvent" }
+
+var _ = Describe("Controller", func() {
+	var (
+		eventManager  *mocks.EventManager
+		errorFinder   *mocks.ErrorFinder
+		controller    *Controller
+		actionCreator *mocks.StartManager
+		manager       func(structs.DeployEventData) I.ActionCreator
+		received      structs.DeployEventData
+		logBuffer     *Buffer
+		deployer      *mocks.Deployer
+		uuid          string
+
+		environment string
+		response    *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		logBuffer = NewBuffer()
+		environment = "environment-" + randomizer.StringRunes(10)
+		uuid = "uuid-" + randomizer.StringRunes(10)
+
+		eventManager = &mocks.EventManager{}
+		deployer = &mocks.Deployer{}
+		errorFinder = &mocks.ErrorFinder{}
+
+		actionCreator = &mocks.StartManager{}
+		manager = func(deployEventData structs.DeployEventData) I.ActionCreator {
+			received = deployEventData
+			return actionCreator
+		}
+
+		controller = &Controller{
+			Log:          I.DeploymentLogger{Log: I.DefaultLogger(logBuffer, logging.DEBUG, "api_test"), UUID: uuid},
+			Deployer:     deployer,
+			EventManager: eventManager,
+			Config:       config.Config{},
+			ErrorFinder:  errorFinder,
+			Operation:    "restart",
+			Events: Events{
+				Started:  func(e Event) I.IEvent { return startedEvent(e) },
+				Success:  func(e Event) I.IEvent { return successEvent(e) },
+				Failure:  func(e Event) I.IEvent { return failureEvent(e) },
+				Finished: func(e Event) I.IEvent { return finishedEvent(e) },
+			},
+		}
+		environments := map[string]structs.Environment{}
+		environments[environment] = structs.Environment{}
+		controller.Config.Environments = environments
+		response = &bytes.Buffer{}
+	})
+
+	Context("When UUID is not provided", func() {
+		It("Should populate UUID", func() {
+
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: environment,
+				}}
+			response := bytes.NewBuffer([]byte{})
+			deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+			Expect(deploymentResponse.DeploymentInfo.UUID).ShouldNot(BeEmpty())
+		})
+	})
+
+	It("Should return org, space, appname, and environment when provided", func() {
+
+		deployment := &I.Deployment{
+			CFContext: I.CFContext{
+				Organization: "myOrg",
+				Space:        "mySpace",
+				Application:  "myApp",
+				Environment:  environment,
+			},
+		}
+		response := bytes.NewBuffer([]byte{})
+		deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+		Expect(deploymentResponse.DeploymentInfo.Org).Should(Equal("myOrg"))
+		Expect(deploymentResponse.DeploymentInfo.Environment).Should(Equal(environment))
+		Expect(deploymentResponse.DeploymentInfo.Space).Should(Equal("mySpace"))
+		Expect(deploymentResponse.DeploymentInfo.AppName).Should(Equal("myApp"))
+
+	})
+
+	It("Should log the operation", func() {
+
+		deployment := &I.Deployment{
+			CFContext: I.CFContext{
+				Application: "myApp",
+				Environment: environment,
+			},
+		}
+
+		response := bytes.NewBuffer([]byte{})
+		deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+		Expect(logBuffer).Should(Say(fmt.Sprintf("Preparing to restart %s with UUID %s", "myApp", deploymentResponse.DeploymentInfo.UUID)))
+
+	})
+
+	Context("When the started event succeeds", func() {
+		It("should emit a startedEvent", func() {
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Organization: "myOrg",
+					Space:        "mySpace",
+					Application:  "myApp",
+					Environment:  environment,
+				},
+			}
+			data := make(structs.Params)
+			data["mykey"] = "first value"
+			controller.Run(context.Background(), deployment, data, response, manager)
+
+			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).Should(Equal(reflect.TypeOf(startedEvent{})))
+			event := eventManager.EmitEventCall.Received.Events[0].(startedEvent)
+			Expect(event.CFContext.Space).Should(Equal("mySpace"))
+			Expect(event.CFContext.Application).Should(Equal("myApp"))
+			Expect(event.CFContext.Environment).Should(Equal(environment))
+			Expect(event.CFContext.Organization).Should(Equal("myOrg"))
+			Expect(event.Data).Should(Equal(data))
+
+		})
+	})
+
+	Context("When the started event fails", func() {
+		It("should return error", func() {
+			eventManager.EmitEventCall.Returns.Error = append(eventManager.EmitEventCall.Returns.Error, errors.New("anything"))
+
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: environment,
+				},
+			}
+			deployResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+			Expect(deployResponse.StatusCode).Should(Equal(http.StatusInternalServerError))
+			Expect(reflect.TypeOf(deployResponse.Error)).Should(Equal(reflect.TypeOf(D.EventError{})))
+
+		})
+	})
+
+	Context("When environment does not exist", func() {
+		It("Should return error", func() {
+
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: "bad environment",
+				}}
+			response := bytes.NewBuffer([]byte{})
+			deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+			Expect(reflect.TypeOf(deploymentResponse.Error)).Should(Equal(reflect.TypeOf(D.EnvironmentNotFoundError{})))
+		})
+	})
+
+	Context("When environment exists", func() {
+		It("Should return SkipSSL, CustomParams, and Domain", func() {
+
+			controller.Config.Environments[environment] = structs.Environment{
+				SkipSSL:      true,
+				Domain:       "myDomain",
+				CustomParams: make(structs.Params),
+			}
+			controller.Config.Environments[environment].CustomParams["customName"] = "customParams"
+
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: environment,
+				}}
+
+			response := bytes.NewBuffer([]byte{})
+			deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+			Expect(deploymentResponse.DeploymentInfo.Domain).Should(Equal("myDomain"))
+			Expect(deploymentResponse.DeploymentInfo.SkipSSL).Should(Equal(true))
+			Expect(deploymentResponse.DeploymentInfo.CustomParams["customName"]).Should(Equal("customParams"))
+		})
+	})
+
+	Context("When the environment does not allow the operation", func() {
+		It("Should return forbidden without running it", func() {
+			controller.Config.Environments[environment] = structs.Environment{
+				Name:   environment,
+				Access: []structs.AccessRule{{Users: []string{"myUser"}, Operations: []string{"start"}}},
+			}
+			deployment := &I.Deployment{
+				Authorization: I.Authorization{Username: "myUser", Password: "myPassword"},
+				CFContext: I.CFContext{
+					Environment: environment,
+				}}
+			response := bytes.NewBuffer([]byte{})
+			deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+			Expect(deploymentResponse.StatusCode).Should(Equal(http.StatusForbidden))
+			Expect(deployer.DeployCall.Called).Should(Equal(0))
+		})
+	})
+
+	Context("When auth does not exist", func() {
+		Context("When environment authenticate is true", func() {
+			It("Should return error", func() {
+				controller.Config.Environments[environment] = structs.Environment{
+					Authenticate: true,
+				}
+				deployment := &I.Deployment{
+					CFContext: I.CFContext{
+						Environment: environment,
+					}}
+				response := bytes.NewBuffer([]byte{})
+				deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+				Expect(reflect.TypeOf(deploymentResponse.Error)).Should(Equal(reflect.TypeOf(D.BasicAuthError{})))
+			})
+		})
+		Context("When a bearer token is used on an environment that authenticates", func() {
+			It("Should return forbidden without an access rule for its user", func() {
+				controller.Config.Environments[environment] = structs.Environment{
+					Name:         environment,
+					Authenticate: true,
+				}
+				deployment := &I.Deployment{
+					Authorization: I.Authorization{Subject: "jane"},
+					CFContext: I.CFContext{
+						Environment: environment,
+					}}
+				response := bytes.NewBuffer([]byte{})
+				deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+				Expect(deploymentResponse.StatusCode).Should(Equal(http.StatusForbidden))
+				Expect(deployer.DeployCall.Called).Should(Equal(0))
+			})
+
+			It("Should use the credentials of the config for the user of an access rule", func() {
+				controller.Config.Username = "username"
+				controller.Config.Password = "password"
+				controller.Config.Environments[environment] = structs.Environment{
+					Name:         environment,
+					Authenticate: true,
+					Access:       []structs.AccessRule{{Users: []string{"jane"}, Operations: []string{"restart"}}},
+				}
+				deployment := &I.Deployment{
+					Authorization: I.Authorization{Subject: "jane"},
+					CFContext: I.CFContext{
+						Environment: environment,
+					}}
+				response := bytes.NewBuffer([]byte{})
+				deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+				Expect(deploymentResponse.DeploymentInfo.Username).Should(Equal("username"))
+				Expect(deploymentResponse.DeploymentInfo.Subject).Should(Equal("jane"))
+			})
+		})
+		Context("When environment authenticate is false", func() {
+			It("Should username and password using the config", func() {
+				controller.Config.Username = "username"
+				controller.Config.Password = "password"
+				controller.Config.Environments[environment] = structs.Environment{
+					Authenticate: false,
+				}
+				deployment := &I.Deployment{
+					CFContext: I.CFContext{
+						Environment: environment,
+					}}
+				response := bytes.NewBuffer([]byte{})
+				deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+				Expect(deploymentResponse.DeploymentInfo.Username).Should(Equal("username"))
+				Expect(deploymentResponse.DeploymentInfo.Password).Should(Equal("password"))
+
+			})
+		})
+	})
+
+	Context("When auth is provided", func() {
+		It("Should populate the deploymentInfo with the username and password", func() {
+			deployment := &I.Deployment{
+				Authorization: I.Authorization{
+					Username: "myUser",
+					Password: "myPassword",
+				},
+				CFContext: I.CFContext{
+					Environment: environment,
+				},
+			}
+			response := bytes.NewBuffer([]byte{})
+			deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+			Expect(deploymentResponse.DeploymentInfo.Username).Should(Equal("myUser"))
+			Expect(deploymentResponse.DeploymentInfo.Password).Should(Equal("myPassword"))
+		})
+	})
+
+	Context("When data is provided", func() {
+		It("should return deployment info with proper data", func() {
+			data := structs.Params{
+				"user_id": "myuserid",
+				"group":   "mygroup",
+			}
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: environment,
+				},
+			}
+			response := bytes.NewBuffer([]byte{})
+			deploymentResponse := controller.Run(context.Background(), deployment, data, response, manager)
+			Expect(deploymentResponse.DeploymentInfo.Data["user_id"]).Should(Equal("myuserid"))
+			Expect(deploymentResponse.DeploymentInfo.Data["group"]).Should(Equal("mygroup"))
+
+		})
+
+	})
+	Context("When metadata is provided", func() {
+		It("should pass the metadata to the deployment info and events", func() {
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: environment,
+				},
+				Metadata: map[string]string{"pipeline_id": "1234"},
+			}
+			response := bytes.NewBuffer([]byte{})
+			deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+			Expect(deploymentResponse.DeploymentInfo.Metadata).Should(Equal(map[string]string{"pipeline_id": "1234"}))
+			Expect(eventManager.EmitEventCall.Received.Events[0].(startedEvent).Metadata).Should(Equal(map[string]string{"pipeline_id": "1234"}))
+		})
+	})
+
+	Context("When a batch size is requested", func() {
+		It("should pass the batch size to the deployment info", func() {
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: environment,
+				},
+				BatchSize: 3,
+			}
+			response := bytes.NewBuffer([]byte{})
+			deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+			Expect(deploymentResponse.DeploymentInfo.BatchSize).Should(Equal(3))
+		})
+	})
+	It("should create the manager of the operation", func() {
+
+		deployment := &I.Deployment{
+			Authorization: I.Authorization{
+				Username: "myUser",
+			},
+			CFContext: I.CFContext{
+				Environment: environment,
+			},
+		}
+		response := bytes.NewBuffer([]byte{})
+		controller.Run(context.Background(), deployment, nil, response, manager)
+		Expect(received.DeploymentInfo.Username).Should(Equal("myUser"))
+	})
+
+	It("should call deploy with the manager of the operation", func() {
+		deployment := &I.Deployment{
+			CFContext: I.CFContext{
+				Environment: environment,
+			},
+		}
+		response := bytes.NewBuffer([]byte{})
+		controller.Run(context.Background(), deployment, nil, response, manager)
+		Expect(deployer.DeployCall.Received.ActionCreator).Should(Equal(actionCreator))
+	})
+
+	It("should return the response of the deploy", func() {
+		deployer.DeployCall.Returns.Error = errors.New("test error")
+		deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+		deployment := &I.Deployment{
+			CFContext: I.CFContext{
+				Environment: environment,
+			},
+		}
+		response := bytes.NewBuffer([]byte{})
+		deploymentResponse := controller.Run(context.Background(), deployment, nil, response, manager)
+
+		Expect(deploymentResponse.Error.Error()).Should(Equal("test error"))
+		Expect(deploymentResponse.StatusCode).Should(Equal(http.StatusOK))
+
+	})
+
+	Context("when the operation succeeds", func() {
+		Context("if successEvent succeeds", func() {
+			It("should emit successEvent", func() {
+				deployment := &I.Deployment{
+					CFContext: I.CFContext{
+						Organization: "myOrg",
+						Space:        "mySpace",
+						Application:  "myApp",
+						Environment:  environment,
+					},
+					Authorization: I.Authorization{
+						Username: "myUser",
+						Password: "myPassword",
+					},
+				}
+				response := bytes.NewBuffer([]byte{})
+				data := make(structs.Params)
+				data["mykey"] = "first value"
+				controller.Config.Environments[environment] = structs.Environment{
+					Name:         environment,
+					Authenticate: true,
+				}
+				controller.Run(context.Background(), deployment, data, response, manager)
+
+				Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).To(Equal(reflect.TypeOf(successEvent{})))
+				event := eventManager.EmitEventCall.Received.Events[1].(successEvent)
+
+				Expect(event.CFContext.Space).Should(Equal("mySpace"))
+				Expect(event.CFContext.Application).Should(Equal("myApp"))
+				Expect(event.CFContext.Environment).Should(Equal(environment))
+				Expect(event.CFContext.Organization).Should(Equal("myOrg"))
+				Expect(event.Authorization.Username).Should(Equal("myUser"))
+				Expect(event.Authorization.Password).Should(Equal("myPassword"))
+				Expect(event.Environment.Name).Should(Equal(environment))
+				Expect(event.Data).Should(Equal(data))
+
+			})
+
+			It("should emit a startedEvent", func() {
+				deployment := &I.Deployment{
+					CFContext: I.CFContext{
+						Organization: "myOrg",
+						Space:        "mySpace",
+						Application:  "myApp",
+						Environment:  environment,
+					},
+				}
+				data := make(structs.Params)
+				data["mykey"] = "first value"
+				controller.Run(context.Background(), deployment, data, response, manager)
+
+				Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).Should(Equal(reflect.TypeOf(startedEvent{})))
+				event := eventManager.EmitEventCall.Received.Events[0].(startedEvent)
+				Expect(event.CFContext.Space).Should(Equal("mySpace"))
+				Expect(event.CFContext.Application).Should(Equal("myApp"))
+				Expect(event.CFContext.Environment).Should(Equal(environment))
+				Expect(event.CFContext.Organization).Should(Equal("myOrg"))
+				Expect(event.Data).Should(Equal(data))
+
+			})
+		})
+
+		Context("if successEvent fails", func() {
+			It("should log the error", func() {
+				eventManager.EmitEventCall.Returns.Error = []error{nil, errors.New("errors")}
+				deployment := &I.Deployment{
+					CFContext: I.CFContext{
+						Environment: environment,
+					},
+				}
+				response := bytes.NewBuffer([]byte{})
+				controller.Run(context.Background(), deployment, nil, response, manager)
+
+				Eventually(logBuffer).Should(Say("an error occurred when emitting a successEvent event: errors"))
+			})
+		})
+	})
+
+	Context("when the operation fails", func() {
+		It("print errors", func() {
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: environment,
+				},
+			}
+			deployer.DeployCall.Returns.Error = errors.New("deploy error")
+			errorFinder.FindErrorsCall.Returns.Errors = []I.LogMatchedError{error_finder.CreateLogMatchedError("a test error", []string{"error 1", "error 2", "error 3"}, "error solution", "test code")}
+			response := bytes.NewBuffer([]byte{})
+			controller.Run(context.Background(), deployment, nil, response, manager)
+			Eventually(response).Should(ContainSubstring("Potential solution"))
+		})
+
+		It("should emit failureEvent", func() {
+
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Organization: "myOrg",
+					Space:        "mySpace",
+					Application:  "myApp",
+					Environment:  environment,
+				},
+				Authorization: I.Authorization{
+					Username: "myUser",
+					Password: "myPassword",
+				},
+			}
+			response := bytes.NewBuffer([]byte{})
+			data := make(structs.Params)
+			data["mykey"] = "first value"
+			controller.Config.Environments[environment] = structs.Environment{
+				Name:         environment,
+				Authenticate: true,
+			}
+			deployer.DeployCall.Returns.Error = errors.New("deploy error")
+			controller.Run(context.Background(), deployment, data, response, manager)
+
+			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).To(Equal(reflect.TypeOf(failureEvent{})))
+			event := eventManager.EmitEventCall.Received.Events[1].(failureEvent)
+
+			Expect(event.CFContext.Space).Should(Equal("mySpace"))
+			Expect(event.CFContext.Application).Should(Equal("myApp"))
+			Expect(event.CFContext.Environment).Should(Equal(environment))
+			Expect(event.CFContext.Organization).Should(Equal("myOrg"))
+			Expect(event.Authorization.Username).Should(Equal("myUser"))
+			Expect(event.Authorization.Password).Should(Equal("myPassword"))
+			Expect(event.Environment.Name).Should(Equal(environment))
+			Expect(event.Data).Should(Equal(data))
+			Expect(event.Error.Error()).Should(Equal("deploy error"))
+
+		})
+
+		Context("if failureEvent fails", func() {
+			It("should log the error", func() {
+				eventManager.EmitEventCall.Returns.Error = []error{nil, errors.New("errors")}
+				deployment := &I.Deployment{
+					CFContext: I.CFContext{
+						Environment: environment,
+					},
+				}
+				deployer.DeployCall.Returns.Error = errors.New("deploy error")
+
+				response := bytes.NewBuffer([]byte{})
+				controller.Run(context.Background(), deployment, nil, response, manager)
+
+				Eventually(logBuffer).Should(Say("an error occurred when emitting a failureEvent event: errors"))
+			})
+		})
+
+	})
+
+	Context("when the operation finishes", func() {
+		It("should log an emit finished event", func() {
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: environment,
+				},
+			}
+
+			response := bytes.NewBuffer([]byte{})
+			controller.Run(context.Background(), deployment, nil, response, manager)
+
+			Eventually(logBuffer).Should(Say("emitting a finishedEvent"))
+		})
+
+		It("should emit finishedEvent", func() {
+
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Organization: "myOrg",
+					Space:        "mySpace",
+					Application:  "myApp",
+					Environment:  environment,
+				},
+				Authorization: I.Authorization{
+					Username: "myUser",
+					Password: "myPassword",
+				},
+			}
+			response := bytes.NewBuffer([]byte{})
+			data := make(structs.Params)
+			data["mykey"] = "first value"
+			controller.Config.Environments[environment] = structs.Environment{
+				Name:         environment,
+				Authenticate: true,
+			}
+			controller.Run(context.Background(), deployment, data, response, manager)
+
+			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[2])).To(Equal(reflect.TypeOf(finishedEvent{})))
+			event := eventManager.EmitEventCall.Received.Events[2].(finishedEvent)
+
+			Expect(event.CFContext.Space).Should(Equal("mySpace"))
+			Expect(event.CFContext.Application).Should(Equal("myApp"))
+			Expect(event.CFContext.Environment).Should(Equal(environment))
+			Expect(event.CFContext.Organization).Should(Equal("myOrg"))
+			Expect(event.Authorization.Username).Should(Equal("myUser"))
+			Expect(event.Authorization.Password).Should(Equal("myPassword"))
+			Expect(event.Environment.Name).Should(Equal(environment))
+			Expect(event.Data).Should(Equal(data))
+
+		})
+	})
+})
@@ -0,0 +1,57 @@
+package operation
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/compozed/deployadactyl/eventmanager"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/structs"
+	"github.com/go-errors/errors"
+)
+
+// Event is what the events of an operation carry. Every operation defines its own event types with it, such as
+// type RestartSuccessEvent operation.Event, so that handlers are bound to the events of that operation only.
+type Event struct {
+	CFContext     I.CFContext
+	Data          structs.Params
+	Metadata      map[string]string
+	Environment   structs.Environment
+	Authorization I.Authorization
+	Response      io.ReadWriter
+	Error         error
+	Log           I.DeploymentLogger
+}
+
+// Events make the events an operation emits when it starts, succeeds, fails and finishes.
+type Events struct {
+	Started  func(Event) I.IEvent
+	Success  func(Event) I.IEvent
+	Failure  func(Event) I.IEvent
+	Finished func(Event) I.IEvent
+}
+
+type eventBinding struct {
+	etype   reflect.Type
+	handler func(event interface{}) error
+}
+
+func (b eventBinding) Accepts(event interface{}) bool {
+	return reflect.TypeOf(event) == b.etype
+}
+
+func (b eventBinding) Emit(event interface{}) error {
+	if !b.Accepts(event) {
+		return eventmanager.InvalidEventType{Err: errors.New("invalid event type")}
+	}
+	return b.handler(event)
+}
+
+// NewBinding binds the handler to the events of the type of the event. The handler is only called with events of
+// that type.
+func NewBinding(event interface{}, handler func(event interface{}) error) I.Binding {
+	return eventBinding{
+		etype:   reflect.TypeOf(event),
+		handler: handler,
+	}
+}
@@ -0,0 +1,48 @@
+package operation_test
+
+import (
+	"github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/state/operation"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewBinding", func() {
+	Describe("Accepts", func() {
+		It("accepts the events of the type it is bound to", func() {
+			binding := NewBinding(startedEvent{}, nil)
+
+			Expect(binding.Accepts(startedEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(successEvent{})).Should(Equal(false))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+	})
+
+	Describe("Emit", func() {
+		It("invokes the handler with the event", func() {
+			var received interface{}
+			binding := NewBinding(startedEvent{}, func(event interface{}) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(startedEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received).Should(Equal(startedEvent{Metadata: map[string]string{"key": "value"}}))
+		})
+
+		It("returns an error for events of other types", func() {
+			invoked := false
+			binding := NewBinding(startedEvent{}, func(event interface{}) error {
+				invoked = true
+				return nil
+			})
+
+			err := binding.Emit(interfaces.Event{})
+
+			Expect(invoked).Should(Equal(false))
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(Equal("invalid event type"))
+		})
+	})
+})
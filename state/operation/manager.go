@@ -0,0 +1,82 @@
+package operation
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// CourierCreator creates the couriers the actions of an operation talk to Cloud Foundry with.
+type CourierCreator interface {
+	CreateCourier() (I.Courier, error)
+}
+
+// Messages name an operation in what its manager reports: the Operation, such as restart, what was Done to the
+// application, such as restarted, and the Foundations it was done on, such as "on all foundations".
+type Messages struct {
+	Operation   string
+	Done        string
+	Foundations string
+}
+
+// OnFinish reports the result of the operation on the application and returns the response of the request.
+func (m Messages) OnFinish(log I.DeploymentLogger, response io.ReadWriter, appName string, err error) I.DeployResponse {
+	if err != nil {
+		fmt.Fprintf(response, "\nYour application was not successfully %s %s: %s\n\n", m.Done, m.Foundations, err.Error())
+		if matched, _ := regexp.MatchString("login failed", err.Error()); matched {
+			return I.DeployResponse{
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			}
+		}
+		return I.DeployResponse{
+			Error:      err,
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+
+	log.Infof("successfully %s application %s", m.Done, appName)
+	fmt.Fprintf(response, "\nYour %s was successful! (^_^)b\n\n", m.Operation)
+
+	return I.DeployResponse{StatusCode: http.StatusOK}
+}
+
+// NewCourier creates the courier of an action on a foundation of the environment, with the CA bundle and log cache
+// of the environment.
+func NewCourier(creator CourierCreator, environment S.Environment) (I.Courier, error) {
+	courier, err := creator.CreateCourier()
+	if err != nil {
+		return nil, state.CourierCreationError{Err: err}
+	}
+	if environment.CABundle != "" {
+		courier = courier.WithCABundle(environment.CABundle)
+	}
+	if environment.LogCache.Enabled {
+		courier = courier.WithLogCache(environment.LogCache)
+	}
+	return courier, nil
+}
+
+// CFContext returns the context of the application of the deployment on the foundations of the environment.
+func CFContext(environment S.Environment, info *S.DeploymentInfo) I.CFContext {
+	return I.CFContext{
+		Environment:  environment.Name,
+		Organization: info.Org,
+		Space:        info.Space,
+		Application:  info.AppName,
+		SkipSSL:      info.SkipSSL,
+	}
+}
+
+// Authorization returns the Cloud Foundry credentials of the deployment.
+func Authorization(info *S.DeploymentInfo) I.Authorization {
+	return I.Authorization{
+		Username: info.Username,
+		Password: info.Password,
+	}
+}
@@ -0,0 +1,83 @@
+package operation_test
+
+import (
+	"errors"
+	"net/http"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/state"
+	. "github.com/compozed/deployadactyl/state/operation"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+type courierCreator struct {
+	courier I.Courier
+	err     error
+}
+
+func (c courierCreator) CreateCourier() (I.Courier, error) {
+	return c.courier, c.err
+}
+
+var _ = Describe("Messages", func() {
+	var (
+		messages  Messages
+		log       I.DeploymentLogger
+		logBuffer *Buffer
+		response  *Buffer
+	)
+
+	BeforeEach(func() {
+		messages = Messages{Operation: "delete", Done: "deleted", Foundations: "from all foundations"}
+		logBuffer = NewBuffer()
+		response = NewBuffer()
+		log = I.DeploymentLogger{Log: I.DefaultLogger(logBuffer, logging.DEBUG, "manager_test")}
+	})
+
+	It("reports the success of the operation", func() {
+		deployResponse := messages.OnFinish(log, response, "myApp", nil)
+
+		Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
+		Expect(response).To(Say("Your delete was successful!"))
+		Expect(logBuffer).To(Say("successfully deleted application myApp"))
+	})
+
+	It("reports the failure of the operation", func() {
+		deployResponse := messages.OnFinish(log, response, "myApp", errors.New("a test error"))
+
+		Expect(deployResponse.StatusCode).To(Equal(http.StatusInternalServerError))
+		Expect(deployResponse.Error).To(MatchError("a test error"))
+		Expect(response).To(Say("Your application was not successfully deleted from all foundations: a test error"))
+	})
+
+	It("returns a bad request when the login failed", func() {
+		deployResponse := messages.OnFinish(log, response, "myApp", errors.New("login failed"))
+
+		Expect(deployResponse.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+})
+
+var _ = Describe("NewCourier", func() {
+	It("creates a courier with the CA bundle and log cache of the environment", func() {
+		courier := &mocks.Courier{}
+		environment := S.Environment{CABundle: "ca.pem", LogCache: S.LogCacheDescriptor{Enabled: true, URL: "https://log-cache.example.com"}}
+
+		created, err := NewCourier(courierCreator{courier: courier}, environment)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(Equal(courier))
+		Expect(courier.WithCABundleCall.Received.Path).To(Equal("ca.pem"))
+		Expect(courier.WithLogCacheCall.Received.Descriptor).To(Equal(environment.LogCache))
+	})
+
+	It("returns an error when the courier can not be created", func() {
+		_, err := NewCourier(courierCreator{err: errors.New("a test error")}, S.Environment{})
+
+		Expect(err).To(MatchError(state.CourierCreationError{Err: errors.New("a test error")}))
+	})
+})
@@ -0,0 +1,107 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/compozed/deployadactyl/state"
+)
+
+// DefaultCanaryStartTimeout is how long a promoted canary is given for all of its instances to be running when the
+// environment sets no start timeout.
+const DefaultCanaryStartTimeout = 5 * time.Minute
+
+// DefaultCanaryPollInterval is how often the instances of a promoted canary are checked.
+const DefaultCanaryPollInterval = 2 * time.Second
+
+// canaryPipeline changes the steps of a pipeline to deploy a canary. The new build is pushed with the instances of
+// the canary in place of every instance, and is scaled to every instance when it is promoted.
+func canaryPipeline(pipeline *Pipeline) error {
+	err := pipeline.InsertBefore(ExecutePhase, "push-application", courierStep("push-canary", Pusher.pushCanary))
+	if err != nil {
+		return err
+	}
+	err = pipeline.Remove(ExecutePhase, "push-application")
+	if err != nil {
+		return err
+	}
+
+	pipeline.Add(PromotePhase, Step{Name: "scale-canary", Run: func(ctx context.Context, p Pusher) error { return p.scaleCanary(ctx) }})
+	return nil
+}
+
+// pushCanary pushes the new build with the share of the instances of the application the canary runs. The first
+// deployment of an application has no original application to share the traffic with, so it is pushed with every
+// instance.
+func (p Pusher) pushCanary() error {
+	if !p.Courier.Exists(p.DeploymentInfo.AppName) {
+		p.Log.Infof("%s does not exist yet: pushing every instance instead of a canary", p.DeploymentInfo.AppName)
+		return p.pushTempApplication()
+	}
+
+	canary := p
+	canary.DeploymentInfo.Instances = p.Environment.Canary.Instances(p.DeploymentInfo.Instances)
+
+	p.Log.Infof("pushing a canary of %d of %d instances", canary.DeploymentInfo.Instances, p.DeploymentInfo.Instances)
+	fmt.Fprintf(p.Response, "\npushing a canary of %d of %d instances to %s\n", canary.DeploymentInfo.Instances, p.DeploymentInfo.Instances, p.FoundationURL)
+
+	return canary.pushApplication(p.tempAppWithUUID(), p.AppPath)
+}
+
+// scaleCanary scales the canary to every instance of the application, and waits until all of them are running.
+// A crashed instance fails the promotion straight away instead of waiting for the timeout.
+func (p Pusher) scaleCanary(ctx context.Context) error {
+	appName := p.tempAppWithUUID()
+
+	p.Log.Infof("scaling the canary %s to %d instances", appName, p.DeploymentInfo.Instances)
+
+	output, err := p.Courier.Scale(appName, p.DeploymentInfo.Instances)
+	if err != nil {
+		p.Log.Errorf("could not scale the canary %s: %s", appName, err)
+		return state.ScaleError{ApplicationName: appName, Out: output}
+	}
+	p.Response.Write(output)
+
+	timeout := time.Duration(p.Environment.StartTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultCanaryStartTimeout
+	}
+	interval := p.PollInterval
+	if interval <= 0 {
+		interval = DefaultCanaryPollInterval
+	}
+
+	deadline := time.Now().Add(timeout)
+	running, instances := 0, 0
+	for {
+		appInstances, err := p.Courier.Instances(appName)
+		if err != nil {
+			p.Log.Errorf("could not read the instances of the canary %s: %s", appName, err)
+		} else {
+			running, instances = 0, len(appInstances)
+			for _, instance := range appInstances {
+				switch instance.State {
+				case "RUNNING":
+					running++
+				case "CRASHED":
+					return state.InstanceCrashedError{ApplicationName: appName, FoundationURL: p.FoundationURL}
+				}
+			}
+			if running == instances && running >= int(p.DeploymentInfo.Instances) {
+				fmt.Fprintf(p.Response, "\n%d of %d instances of the canary %s running on %s\n", running, instances, appName, p.FoundationURL)
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return state.StartTimeoutError{ApplicationName: appName, FoundationURL: p.FoundationURL, Running: running, Instances: int(p.DeploymentInfo.Instances), Timeout: timeout}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
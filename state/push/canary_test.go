@@ -0,0 +1,168 @@
+package push_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/state"
+	. "github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+type courierCreator struct {
+	courier interfaces.Courier
+}
+
+func (c courierCreator) CreateCourier() (interfaces.Courier, error) {
+	return c.courier, nil
+}
+
+var _ = Describe("Canary", func() {
+	var (
+		courier     *mocks.Courier
+		pushManager PushManager
+		environment S.Environment
+		response    *Buffer
+	)
+
+	create := func() *Pusher {
+		action, err := pushManager.Create(environment, response, "https://api.example.com")
+		Expect(err).ToNot(HaveOccurred())
+		return action.(*Pusher)
+	}
+
+	stepNames := func(pusher *Pusher, phase Phase) []string {
+		names := []string{}
+		for _, step := range pusher.Pipeline.Steps(phase) {
+			names = append(names, step.Name)
+		}
+		return names
+	}
+
+	runStep := func(pusher *Pusher, phase Phase, name string) error {
+		for _, step := range pusher.Pipeline.Steps(phase) {
+			if step.Name == name {
+				return step.Run(context.Background(), *pusher)
+			}
+		}
+		Fail("no step " + name)
+		return nil
+	}
+
+	BeforeEach(func() {
+		courier = &mocks.Courier{}
+		response = NewBuffer()
+		environment = S.Environment{
+			Name:           "production",
+			EnableRollback: true,
+			Strategy:       S.StrategyCanary,
+			Canary:         S.CanaryDescriptor{Percent: 25, BakeSeconds: 300},
+		}
+
+		pushManager = PushManager{
+			CourierCreator: courierCreator{courier},
+			Logger:         interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(NewBuffer(), logging.DEBUG, "canary_test"), UUID: "uuid-1"},
+			DeployEventData: S.DeployEventData{
+				DeploymentInfo: &S.DeploymentInfo{AppName: "app", UUID: "uuid-1", Instances: 6},
+			},
+		}
+	})
+
+	It("deploys a canary to an environment with the canary strategy", func() {
+		Expect(pushManager.Canary(environment)).To(BeTrue())
+		Expect(pushManager.Canary(S.Environment{})).To(BeFalse())
+		Expect(pushManager.Canary(S.Environment{Strategy: S.StrategyBlueGreen})).To(BeFalse())
+	})
+
+	It("pushes a canary in place of the application and scales it when it is promoted", func() {
+		pusher := create()
+
+		Expect(stepNames(pusher, ExecutePhase)).To(ContainElement("push-canary"))
+		Expect(stepNames(pusher, ExecutePhase)).ToNot(ContainElement("push-application"))
+		Expect(stepNames(pusher, PromotePhase)).To(Equal([]string{"scale-canary"}))
+	})
+
+	It("does not change the pipeline of an environment with the blue green strategy", func() {
+		environment.Strategy = ""
+
+		pusher := create()
+
+		Expect(stepNames(pusher, ExecutePhase)).To(ContainElement("push-application"))
+		Expect(stepNames(pusher, PromotePhase)).To(BeEmpty())
+	})
+
+	Describe("pushing the canary", func() {
+		It("pushes the share of the instances of the canary", func() {
+			courier.ExistsCall.Returns.Bool = true
+
+			Expect(runStep(create(), ExecutePhase, "push-canary")).To(Succeed())
+
+			Expect(courier.PushCall.Received.AppName).To(Equal("app" + TemporaryNameSuffix + "uuid-1"))
+			Expect(courier.PushCall.Received.Instances).To(Equal(uint16(2)))
+			Eventually(response).Should(Say("pushing a canary of 2 of 6 instances"))
+		})
+
+		It("pushes every instance for the first deployment of the application", func() {
+			Expect(runStep(create(), ExecutePhase, "push-canary")).To(Succeed())
+
+			Expect(courier.PushCall.Received.Instances).To(Equal(uint16(6)))
+		})
+	})
+
+	Describe("promoting the canary", func() {
+		var pusher *Pusher
+
+		BeforeEach(func() {
+			pusher = create()
+			pusher.PollInterval = time.Millisecond
+		})
+
+		It("scales the canary to every instance and waits until they are running", func() {
+			courier.InstancesCall.Returns.Instances = [][]S.Instance{
+				{{State: "RUNNING"}, {State: "STARTING"}},
+				{{State: "RUNNING"}, {State: "RUNNING"}, {State: "RUNNING"}, {State: "RUNNING"}, {State: "RUNNING"}, {State: "RUNNING"}},
+			}
+
+			Expect(pusher.Promote(context.Background())).To(Succeed())
+
+			Expect(courier.ScaleCall.Received.AppName).To(Equal("app" + TemporaryNameSuffix + "uuid-1"))
+			Expect(courier.ScaleCall.Received.Instances).To(Equal(uint16(6)))
+			Expect(courier.InstancesCall.TimesCalled).To(Equal(2))
+			Eventually(response).Should(Say("6 of 6 instances of the canary"))
+		})
+
+		It("returns an error when the canary cannot be scaled", func() {
+			courier.ScaleCall.Returns.Output = []byte("quota exceeded")
+			courier.ScaleCall.Returns.Error = errors.New("scale failed")
+
+			err := pusher.Promote(context.Background())
+
+			Expect(err).To(MatchError(state.ScaleError{ApplicationName: "app" + TemporaryNameSuffix + "uuid-1", Out: []byte("quota exceeded")}))
+		})
+
+		It("returns an error when an instance crashes", func() {
+			courier.InstancesCall.Returns.Instances = [][]S.Instance{{{State: "RUNNING"}, {State: "CRASHED"}}}
+
+			err := pusher.Promote(context.Background())
+
+			Expect(err).To(MatchError(state.InstanceCrashedError{ApplicationName: "app" + TemporaryNameSuffix + "uuid-1", FoundationURL: "https://api.example.com"}))
+		})
+
+		It("stops waiting for the instances when the deployment is cancelled", func() {
+			courier.InstancesCall.Returns.Instances = [][]S.Instance{{{State: "RUNNING"}, {State: "STARTING"}}}
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			err := pusher.Promote(ctx)
+
+			Expect(err).To(Equal(context.DeadlineExceeded))
+		})
+	})
+})
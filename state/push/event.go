@@ -30,7 +30,8 @@ type DeployStartedEvent struct {
 	Environment structs.Environment
 	Auth        interfaces.Authorization
 	Response    io.ReadWriter
-	Data        map[string]interface{}
+	Data        structs.Params
+	Metadata    map[string]string
 	Log         interfaces.DeploymentLogger
 }
 
@@ -59,7 +60,8 @@ type DeployFinishedEvent struct {
 	Environment structs.Environment
 	Auth        interfaces.Authorization
 	Response    io.ReadWriter
-	Data        map[string]interface{}
+	Data        structs.Params
+	Metadata    map[string]string
 	Log         interfaces.DeploymentLogger
 }
 
@@ -88,7 +90,8 @@ type DeploySuccessEvent struct {
 	Environment         structs.Environment
 	Auth                interfaces.Authorization
 	Response            io.ReadWriter
-	Data                map[string]interface{}
+	Data                structs.Params
+	Metadata            map[string]string
 	HealthCheckEndpoint string
 	ArtifactURL         string
 	Log                 interfaces.DeploymentLogger
@@ -119,7 +122,8 @@ type DeployFailureEvent struct {
 	Environment structs.Environment
 	Auth        interfaces.Authorization
 	Response    io.ReadWriter
-	Data        map[string]interface{}
+	Data        structs.Params
+	Metadata    map[string]string
 	Error       error
 	Log         interfaces.DeploymentLogger
 }
@@ -149,7 +153,8 @@ type PushStartedEvent struct {
 	Environment          structs.Environment
 	Auth                 interfaces.Authorization
 	Response             io.ReadWriter
-	Data                 map[string]interface{}
+	Data                 structs.Params
+	Metadata             map[string]string
 	Instances            uint16
 	EnvironmentVariables map[string]string
 	Manifest             string
@@ -182,10 +187,17 @@ type PushFinishedEvent struct {
 	FoundationURL       string
 	TempAppWithUUID     string
 	Manifest            string
-	Data                map[string]interface{}
+	Data                structs.Params
+	Metadata            map[string]string
 	Courier             interfaces.Courier
 	HealthCheckEndpoint string
 	Log                 interfaces.DeploymentLogger
+
+	// EnvironmentVariables are the environment variables the deployment set on the application.
+	EnvironmentVariables map[string]string
+
+	// HealthChecks collects the health check results of every foundation. It may be nil.
+	HealthChecks *structs.HealthCheckReport
 }
 
 func (d PushFinishedEvent) Name() string {
@@ -206,12 +218,141 @@ func NewPushFinishedEventBinding(handler func(event PushFinishedEvent) error) in
 	}
 }
 
+// PostDeployCrashDetectedEvent is emitted when a promoted application starts crashing on a foundation
+// within the crash watch window of the environment.
+type PostDeployCrashDetectedEvent struct {
+	CFContext     interfaces.CFContext
+	Auth          interfaces.Authorization
+	Environment   structs.Environment
+	Response      io.ReadWriter
+	FoundationURL string
+	AppName       string
+	Crashes       int
+	Data          structs.Params
+	Metadata      map[string]string
+	Log           interfaces.DeploymentLogger
+}
+
+func (d PostDeployCrashDetectedEvent) Name() string {
+	return "PostDeployCrashDetectedEvent"
+}
+
+func NewPostDeployCrashDetectedEventBinding(handler func(event PostDeployCrashDetectedEvent) error) interfaces.Binding {
+	return eventBinding{
+		etype: reflect.TypeOf(PostDeployCrashDetectedEvent{}),
+		handler: func(gevent interface{}) error {
+			event, ok := gevent.(PostDeployCrashDetectedEvent)
+			if ok {
+				return handler(event)
+			} else {
+				return eventmanager.InvalidEventType{errors.New("invalid event type")}
+			}
+		},
+	}
+}
+
+// RouteVerifiedEvent is emitted when the load balanced route is verified to be mapped to the new build on a foundation.
+// A handler returning an error fails the verification, which rolls back the push on every foundation.
+type RouteVerifiedEvent struct {
+	CFContext     interfaces.CFContext
+	Auth          interfaces.Authorization
+	Environment   structs.Environment
+	Response      io.ReadWriter
+	FoundationURL string
+	AppName       string
+	Route         string
+	Data          structs.Params
+	Metadata      map[string]string
+	Log           interfaces.DeploymentLogger
+}
+
+func (d RouteVerifiedEvent) Name() string {
+	return "RouteVerifiedEvent"
+}
+
+func NewRouteVerifiedEventBinding(handler func(event RouteVerifiedEvent) error) interfaces.Binding {
+	return eventBinding{
+		etype: reflect.TypeOf(RouteVerifiedEvent{}),
+		handler: func(gevent interface{}) error {
+			event, ok := gevent.(RouteVerifiedEvent)
+			if ok {
+				return handler(event)
+			} else {
+				return eventmanager.InvalidEventType{errors.New("invalid event type")}
+			}
+		},
+	}
+}
+
+// PromotionStartedEvent is emitted on a foundation before its original application is retired, once the load
+// balanced route is verified to be mapped to the new build on every foundation.
+type PromotionStartedEvent struct {
+	CFContext     interfaces.CFContext
+	Auth          interfaces.Authorization
+	Environment   structs.Environment
+	Response      io.ReadWriter
+	FoundationURL string
+	AppName       string
+	Data          structs.Params
+	Metadata      map[string]string
+	Log           interfaces.DeploymentLogger
+}
+
+func (d PromotionStartedEvent) Name() string {
+	return "PromotionStartedEvent"
+}
+
+func NewPromotionStartedEventBinding(handler func(event PromotionStartedEvent) error) interfaces.Binding {
+	return eventBinding{
+		etype: reflect.TypeOf(PromotionStartedEvent{}),
+		handler: func(gevent interface{}) error {
+			event, ok := gevent.(PromotionStartedEvent)
+			if ok {
+				return handler(event)
+			} else {
+				return eventmanager.InvalidEventType{errors.New("invalid event type")}
+			}
+		},
+	}
+}
+
+// DeploymentReconciledEvent is emitted on every foundation of a deployment that was interrupted by a restart of the
+// server, once it is reconciled at startup. The Outcome is whether its promotion was completed or it was rolled back
+// on the foundation, or whether nothing had to change. Err is the error that prevented the reconciliation, if any.
+type DeploymentReconciledEvent struct {
+	Record        structs.DeploymentRecord
+	Environment   structs.Environment
+	FoundationURL string
+	Outcome       string
+	Err           error
+	Log           interfaces.DeploymentLogger
+}
+
+func (d DeploymentReconciledEvent) Name() string {
+	return "DeploymentReconciledEvent"
+}
+
+func NewDeploymentReconciledEventBinding(handler func(event DeploymentReconciledEvent) error) interfaces.Binding {
+	return eventBinding{
+		etype: reflect.TypeOf(DeploymentReconciledEvent{}),
+		handler: func(gevent interface{}) error {
+			event, ok := gevent.(DeploymentReconciledEvent)
+			if ok {
+				return handler(event)
+			} else {
+				return eventmanager.InvalidEventType{errors.New("invalid event type")}
+			}
+		},
+	}
+}
+
 type ArtifactRetrievalStartEvent struct {
 	CFContext   interfaces.CFContext
 	Auth        interfaces.Authorization
 	Environment structs.Environment
 	Response    io.ReadWriter
-	Data        map[string]interface{}
+	Data        structs.Params
+	Metadata    map[string]string
 	Manifest    string
 	ArtifactURL string
 	Log         interfaces.DeploymentLogger
@@ -240,7 +381,8 @@ type ArtifactRetrievalFailureEvent struct {
 	Auth        interfaces.Authorization
 	Environment structs.Environment
 	Response    io.ReadWriter
-	Data        map[string]interface{}
+	Data        structs.Params
+	Metadata    map[string]string
 	Manifest    string
 	ArtifactURL string
 	Log         interfaces.DeploymentLogger
@@ -269,7 +411,8 @@ type ArtifactRetrievalSuccessEvent struct {
 	Auth                 interfaces.Authorization
 	Environment          structs.Environment
 	Response             io.ReadWriter
-	Data                 map[string]interface{}
+	Data                 structs.Params
+	Metadata             map[string]string
 	Manifest             string
 	ArtifactURL          string
 	AppPath              string
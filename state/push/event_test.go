@@ -1,6 +1,7 @@
 package push_test
 
 import (
+	"github.com/compozed/deployadactyl/conformance"
 	"github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/state/push"
 	. "github.com/onsi/ginkgo"
@@ -484,4 +485,10 @@ var _ = Describe("event binding", func() {
 			})
 		})
 	})
+
+	It("satisfies the Binding contract", func() {
+		binding := push.NewPushFinishedEventBinding(func(event push.PushFinishedEvent) error { return nil })
+
+		conformance.Binding(GinkgoT(), binding, push.PushFinishedEvent{}, push.PushStartedEvent{})
+	})
 })
@@ -0,0 +1,92 @@
+package push
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DefaultMaxExcerpts is how many log lines are kept for each foundation when the environment does not set it.
+const DefaultMaxExcerpts = 20
+
+// routerStatus matches the status code of a router access log line, which follows the quoted request.
+var routerStatus = regexp.MustCompile(`"[A-Z]+ [^"]*" (\d{3}) `)
+
+// captureDeploymentEvents reads the router and application logs of the new build from Log Cache and
+// keeps the server errors and crashes they show as evidence of the impact of the deployment.
+// The evidence is only informational, so failing to read the logs does not fail the deployment.
+func (p Pusher) captureDeploymentEvents(appName string) error {
+	if !p.Environment.EventCapture.Enabled || p.Evidence == nil {
+		return nil
+	}
+
+	envelopes, err := p.Courier.LogEnvelopes(appName)
+	if err != nil {
+		p.Log.Errorf("could not capture the logs of app %s: %s", appName, err)
+		return nil
+	}
+
+	maxExcerpts := p.Environment.EventCapture.MaxExcerpts
+	if maxExcerpts <= 0 {
+		maxExcerpts = DefaultMaxExcerpts
+	}
+
+	evidence := collectEvidence(envelopes, maxExcerpts)
+	evidence.FoundationURL = p.FoundationURL
+	evidence.AppName = appName
+	p.Evidence.Add(evidence)
+
+	p.Log.Infof("captured %d requests, %d server errors and %d crashes of app %s", evidence.Requests, evidence.ServerErrors, evidence.Crashes, appName)
+	fmt.Fprintf(p.Response, "\napp %s served %d requests with %d server errors and crashed %d times on %s\n",
+		appName, evidence.Requests, evidence.ServerErrors, evidence.Crashes, p.FoundationURL)
+
+	return nil
+}
+
+// captureFailedDeploymentEvents captures the logs of the new build before it is rolled back. Without
+// rollback the new build is promoted by the success phase, which captures them instead.
+func (p Pusher) captureFailedDeploymentEvents() error {
+	if !p.Environment.EnableRollback {
+		return nil
+	}
+	return p.captureDeploymentEvents(p.tempAppWithUUID())
+}
+
+// collectEvidence counts the requests, server errors and crashes in the logs, and keeps the log lines
+// of the first server errors and crashes.
+func collectEvidence(envelopes []S.LogEnvelope, maxExcerpts int) S.DeploymentEvidence {
+	evidence := S.DeploymentEvidence{Excerpts: []string{}}
+
+	for _, envelope := range envelopes {
+		relevant := false
+
+		switch {
+		case strings.HasPrefix(envelope.SourceType, "RTR"):
+			match := routerStatus.FindStringSubmatch(envelope.Message)
+			if match == nil {
+				continue
+			}
+			evidence.Requests++
+
+			status, _ := strconv.Atoi(match[1])
+			if status >= 500 {
+				evidence.ServerErrors++
+				relevant = true
+			}
+		case strings.HasPrefix(envelope.SourceType, "API") || strings.HasPrefix(envelope.SourceType, "CELL"):
+			if strings.Contains(envelope.Message, "CRASHED") {
+				evidence.Crashes++
+				relevant = true
+			}
+		}
+
+		if relevant && len(evidence.Excerpts) < maxExcerpts {
+			evidence.Excerpts = append(evidence.Excerpts, strings.TrimSpace(envelope.String()))
+		}
+	}
+
+	return evidence
+}
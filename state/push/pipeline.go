@@ -0,0 +1,338 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tracing"
+)
+
+// Phase identifies the point in a Pusher's lifecycle at which a Step is run.
+type Phase string
+
+const (
+	InitiallyPhase Phase = "initially"
+	VerifyPhase    Phase = "verify"
+	ExecutePhase   Phase = "execute"
+	PromotePhase   Phase = "promote"
+	SuccessPhase   Phase = "success"
+	UndoPhase      Phase = "undo"
+	FinallyPhase   Phase = "finally"
+)
+
+var phases = []Phase{InitiallyPhase, VerifyPhase, ExecutePhase, PromotePhase, SuccessPhase, UndoPhase, FinallyPhase}
+
+// Step is a named unit of work that the Pusher runs against a single foundation.
+// The Courier of the Pusher given to Run is already bound to ctx.
+type Step struct {
+	Name string
+	Run  func(ctx context.Context, p Pusher) error
+}
+
+// PipelineConstructor returns the Pipeline used by every Pusher a PushManager creates.
+type PipelineConstructor func() *Pipeline
+
+// Pipeline is the ordered list of Steps the Pusher runs in each Phase.
+// Additional steps can be registered around the default ones without changing the Pusher.
+type Pipeline struct {
+	steps map[Phase][]Step
+}
+
+// NewPipeline returns a Pipeline containing the default blue green push steps.
+func NewPipeline() *Pipeline {
+	p := &Pipeline{steps: map[Phase][]Step{}}
+
+	p.Add(InitiallyPhase, courierStep("login", Pusher.login))
+	p.Add(InitiallyPhase, courierStep("record-quota-usage", Pusher.recordQuotaUsageBefore))
+
+	p.Add(ExecutePhase, courierStep("create-metadata-service", Pusher.createMetadataService))
+	p.Add(ExecutePhase, courierStep("push-application", Pusher.pushTempApplication))
+	p.Add(ExecutePhase, courierStep("label-application", Pusher.labelApplication))
+	p.Add(ExecutePhase, Step{Name: "run-pre-promotion-task", Run: func(ctx context.Context, p Pusher) error { return p.runPrePromotionTask(ctx) }})
+	p.Add(ExecutePhase, courierStep("map-load-balanced-domain", Pusher.mapLoadBalancedDomain))
+	p.Add(ExecutePhase, courierStep("emit-push-finished", Pusher.emitPushFinished))
+	p.Add(ExecutePhase, Step{Name: "check-promotion-gates", Run: func(ctx context.Context, p Pusher) error { return p.checkPromotionGates(ctx) }})
+
+	p.Add(VerifyPhase, courierStep("verify-load-balanced-route", Pusher.verifyLoadBalancedRoute))
+
+	p.Add(SuccessPhase, courierStep("start-promotion", Pusher.startPromotion))
+	p.Add(SuccessPhase, Step{Name: "retire-original-application", Run: func(ctx context.Context, p Pusher) error { return p.retireOriginalApplication(ctx) }})
+	p.Add(SuccessPhase, courierStep("rename-new-build", Pusher.renameNewBuildToOriginalAppName))
+	p.Add(SuccessPhase, Step{Name: "watch-for-crashes", Run: func(ctx context.Context, p Pusher) error { return p.watchForCrashes(ctx) }})
+	p.Add(SuccessPhase, courierStep("capture-deployment-events", func(p Pusher) error { return p.captureDeploymentEvents(p.DeploymentInfo.AppName) }))
+
+	p.Add(UndoPhase, courierStep("capture-deployment-events", Pusher.captureFailedDeploymentEvents))
+
+	p.Add(UndoPhase, Step{Name: "rollback", Run: func(ctx context.Context, p Pusher) error { return p.rollback(ctx) }})
+
+	p.Add(FinallyPhase, courierStep("record-quota-usage", Pusher.recordQuotaUsageAfter))
+	p.Add(FinallyPhase, courierStep("clean-up", Pusher.cleanUp))
+
+	return p
+}
+
+// courierStep returns a Step for work that only needs the Courier, which is already bound to the context.
+func courierStep(name string, run func(p Pusher) error) Step {
+	return Step{Name: name, Run: func(ctx context.Context, p Pusher) error {
+		return run(p)
+	}}
+}
+
+// Add appends a step to the end of a phase.
+func (p *Pipeline) Add(phase Phase, step Step) {
+	p.steps[phase] = append(p.steps[phase], step)
+}
+
+// InsertBefore inserts a step into a phase directly before the step with the given name.
+func (p *Pipeline) InsertBefore(phase Phase, name string, step Step) error {
+	return p.insert(phase, name, step, 0)
+}
+
+// InsertAfter inserts a step into a phase directly after the step with the given name.
+func (p *Pipeline) InsertAfter(phase Phase, name string, step Step) error {
+	return p.insert(phase, name, step, 1)
+}
+
+// Remove removes the step with the given name from a phase.
+func (p *Pipeline) Remove(phase Phase, name string) error {
+	i := p.index(phase, name)
+	if i < 0 {
+		return state.StepNotFoundError{Phase: string(phase), Name: name}
+	}
+
+	p.steps[phase] = append(p.steps[phase][:i:i], p.steps[phase][i+1:]...)
+	return nil
+}
+
+// Steps returns the ordered steps of a phase.
+func (p *Pipeline) Steps(phase Phase) []Step {
+	return p.steps[phase]
+}
+
+// Copy returns a Pipeline that can be changed without affecting the original.
+func (p *Pipeline) Copy() *Pipeline {
+	c := &Pipeline{steps: map[Phase][]Step{}}
+	for phase, steps := range p.steps {
+		c.steps[phase] = append([]Step{}, steps...)
+	}
+	return c
+}
+
+// Apply registers the steps described in an environment configuration.
+func (p *Pipeline) Apply(descriptors []S.PushStepDescriptor) error {
+	for _, descriptor := range descriptors {
+		step, err := NewHTTPStep(descriptor)
+		if err != nil {
+			return err
+		}
+
+		phase := Phase(strings.ToLower(descriptor.Phase))
+		if !validPhase(phase) {
+			return state.InvalidPhaseError{Phase: descriptor.Phase}
+		}
+
+		switch {
+		case descriptor.Before != "":
+			err = p.InsertBefore(phase, descriptor.Before, step)
+		case descriptor.After != "":
+			err = p.InsertAfter(phase, descriptor.After, step)
+		default:
+			p.Add(phase, step)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// run runs the steps of the phase one after the other, until one of them fails. The phase and every step are traced
+// as spans of ctx, and the Courier is bound to the context of the step it is used by.
+func (p *Pipeline) run(ctx context.Context, phase Phase, pusher Pusher) (err error) {
+	courier := pusher.Courier
+	pusher.Log = pusher.Log.WithFields(I.LogFields{I.PhaseLogField: string(phase)})
+
+	ctx, span := tracing.Start(ctx, "push "+string(phase), tracing.String("foundation", pusher.FoundationURL))
+	defer func() {
+		span.SetError(err)
+		span.Finish()
+	}()
+
+	for _, step := range p.steps[phase] {
+		pusher.Log.Debugf("running step %s", step.Name)
+		if pusher.Progress != nil {
+			pusher.Progress.Step(pusher.DeploymentInfo.UUID, pusher.FoundationURL, step.Name)
+		}
+
+		stepCtx, stepSpan := tracing.Start(ctx, "push step "+step.Name)
+		if courier != nil {
+			pusher.Courier = courier.WithContext(stepCtx)
+		}
+
+		err = step.Run(stepCtx, pusher)
+		stepSpan.SetError(err)
+		stepSpan.Finish()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Pipeline) insert(phase Phase, name string, step Step, offset int) error {
+	i := p.index(phase, name)
+	if i < 0 {
+		return state.StepNotFoundError{Phase: string(phase), Name: name}
+	}
+	i += offset
+
+	steps := append([]Step{}, p.steps[phase][:i]...)
+	steps = append(steps, step)
+	p.steps[phase] = append(steps, p.steps[phase][i:]...)
+	return nil
+}
+
+func (p *Pipeline) index(phase Phase, name string) int {
+	for i, step := range p.steps[phase] {
+		if step.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func validPhase(phase Phase) bool {
+	for _, p := range phases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// NewHTTPStep returns a Step that sends an HTTP request, for things like warm-up calls,
+// cache priming or CDN invalidation. The URL is a template that is given the DeploymentInfo. Its values are
+// escaped for the path, or for the query once the URL has a ?, so that they cannot change the request.
+func NewHTTPStep(descriptor S.PushStepDescriptor) (Step, error) {
+	if descriptor.Name == "" || descriptor.URL == "" {
+		return Step{}, state.InvalidStepError{Name: descriptor.Name}
+	}
+	if descriptor.TimeoutSeconds < 0 {
+		return Step{}, state.InvalidStepError{Name: descriptor.Name, Err: fmt.Errorf("timeout_seconds must not be negative: %d", descriptor.TimeoutSeconds)}
+	}
+
+	urlTemplate, err := template.New(descriptor.Name).Funcs(escapeFuncs).Parse(descriptor.URL)
+	if err != nil {
+		return Step{}, state.InvalidStepError{Name: descriptor.Name, Err: err}
+	}
+	escapeValues(urlTemplate.Tree)
+
+	method := strings.ToUpper(descriptor.Method)
+	if method == "" {
+		method = "GET"
+	}
+
+	timeout := S.DefaultPushStepTimeoutSeconds * time.Second
+	if descriptor.TimeoutSeconds > 0 {
+		timeout = time.Duration(descriptor.TimeoutSeconds) * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	return Step{
+		Name: descriptor.Name,
+		Run: func(ctx context.Context, p Pusher) error {
+			url := &bytes.Buffer{}
+			err := urlTemplate.Execute(url, p.DeploymentInfo)
+			if err != nil {
+				return state.StepError{Name: descriptor.Name, Err: err}
+			}
+
+			request, err := http.NewRequest(method, url.String(), nil)
+			if err != nil {
+				return state.StepError{Name: descriptor.Name, Err: err}
+			}
+			request = request.WithContext(ctx)
+			for key, value := range descriptor.Headers {
+				request.Header.Set(key, value)
+			}
+
+			p.Log.Debugf("%s step %s: %s", descriptor.Phase, method, url)
+			resp, err := client.Do(request)
+			if err != nil {
+				return state.StepError{Name: descriptor.Name, Err: err}
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				return state.StepError{Name: descriptor.Name, Err: fmt.Errorf("%s returned %s", url, resp.Status)}
+			}
+
+			fmt.Fprintf(p.Response, "\n%s step %s completed against %s\n", descriptor.Phase, descriptor.Name, p.FoundationURL)
+			return nil
+		},
+	}, nil
+}
+
+var escapeFuncs = template.FuncMap{
+	"pathescape": func(value interface{}) string {
+		return url.PathEscape(fmt.Sprint(value))
+	},
+	"queryescape": func(value interface{}) string {
+		return url.QueryEscape(fmt.Sprint(value))
+	},
+}
+
+// escapeValues pipes the value of every action of the URL template into pathescape, or into queryescape once a ?
+// has started the query.
+func escapeValues(tree *parse.Tree) {
+	query := false
+
+	var escape func(list *parse.ListNode)
+	escape = func(list *parse.ListNode) {
+		if list == nil {
+			return
+		}
+
+		for _, node := range list.Nodes {
+			switch n := node.(type) {
+			case *parse.TextNode:
+				if bytes.IndexByte(n.Text, '?') >= 0 {
+					query = true
+				}
+			case *parse.ActionNode:
+				if len(n.Pipe.Decl) > 0 {
+					continue
+				}
+				name := "pathescape"
+				if query {
+					name = "queryescape"
+				}
+				command := &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos}
+				command.Args = []parse.Node{parse.NewIdentifier(name).SetTree(tree).SetPos(n.Pos)}
+				n.Pipe.Cmds = append(n.Pipe.Cmds, command)
+			case *parse.IfNode:
+				escape(n.List)
+				escape(n.ElseList)
+			case *parse.RangeNode:
+				escape(n.List)
+				escape(n.ElseList)
+			case *parse.WithNode:
+				escape(n.List)
+				escape(n.ElseList)
+			}
+		}
+	}
+
+	escape(tree.Root)
+}
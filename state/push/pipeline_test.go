@@ -0,0 +1,301 @@
+package push_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/state"
+	. "github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tracing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Pipeline", func() {
+	var (
+		pipeline *Pipeline
+		courier  *mocks.Courier
+		pusher   Pusher
+		response *Buffer
+		appName  string
+		ran      []string
+	)
+
+	stepNames := func(phase Phase) []string {
+		names := []string{}
+		for _, step := range pipeline.Steps(phase) {
+			names = append(names, step.Name)
+		}
+		return names
+	}
+
+	recordingStep := func(name string) Step {
+		return Step{Name: name, Run: func(ctx context.Context, p Pusher) error {
+			ran = append(ran, name)
+			return nil
+		}}
+	}
+
+	BeforeEach(func() {
+		pipeline = NewPipeline()
+		courier = &mocks.Courier{}
+		response = NewBuffer()
+		appName = "appName-" + randomizer.StringRunes(10)
+		ran = nil
+
+		pusher = Pusher{
+			Courier:        courier,
+			DeploymentInfo: S.DeploymentInfo{AppName: appName, UUID: randomizer.StringRunes(10)},
+			EventManager:   &mocks.EventManager{},
+			Response:       response,
+			Log:            interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(NewBuffer(), logging.DEBUG, "pipeline_test")},
+			Environment:    S.Environment{EnableRollback: true},
+			Pipeline:       pipeline,
+		}
+	})
+
+	Describe("NewPipeline", func() {
+		It("contains the default blue green steps in order", func() {
+			Expect(stepNames(InitiallyPhase)).To(Equal([]string{"login", "record-quota-usage"}))
+			Expect(stepNames(ExecutePhase)).To(Equal([]string{"create-metadata-service", "push-application", "label-application", "run-pre-promotion-task", "map-load-balanced-domain", "emit-push-finished", "check-promotion-gates"}))
+			Expect(stepNames(VerifyPhase)).To(Equal([]string{"verify-load-balanced-route"}))
+			Expect(stepNames(SuccessPhase)).To(Equal([]string{"start-promotion", "retire-original-application", "rename-new-build", "watch-for-crashes", "capture-deployment-events"}))
+			Expect(stepNames(UndoPhase)).To(Equal([]string{"capture-deployment-events", "rollback"}))
+			Expect(stepNames(FinallyPhase)).To(Equal([]string{"record-quota-usage", "clean-up"}))
+		})
+	})
+
+	Describe("registering steps", func() {
+		It("inserts a step before another step", func() {
+			Expect(pipeline.InsertBefore(SuccessPhase, "rename-new-build", recordingStep("warm-up"))).To(Succeed())
+
+			Expect(stepNames(SuccessPhase)).To(Equal([]string{"start-promotion", "retire-original-application", "warm-up", "rename-new-build", "watch-for-crashes", "capture-deployment-events"}))
+		})
+
+		It("inserts a step after another step", func() {
+			Expect(pipeline.InsertAfter(SuccessPhase, "rename-new-build", recordingStep("invalidate-cdn"))).To(Succeed())
+
+			Expect(stepNames(SuccessPhase)).To(Equal([]string{"start-promotion", "retire-original-application", "rename-new-build", "invalidate-cdn", "watch-for-crashes", "capture-deployment-events"}))
+		})
+
+		It("removes a step", func() {
+			Expect(pipeline.Remove(ExecutePhase, "map-load-balanced-domain")).To(Succeed())
+
+			Expect(stepNames(ExecutePhase)).To(Equal([]string{"create-metadata-service", "push-application", "label-application", "run-pre-promotion-task", "emit-push-finished", "check-promotion-gates"}))
+		})
+
+		It("returns an error when the anchor step does not exist", func() {
+			err := pipeline.InsertAfter(SuccessPhase, "not-a-step", recordingStep("warm-up"))
+
+			Expect(err).To(MatchError(state.StepNotFoundError{Phase: "success", Name: "not-a-step"}))
+		})
+
+		It("does not change the original when a copy is changed", func() {
+			pipelineCopy := pipeline.Copy()
+			pipelineCopy.Add(VerifyPhase, recordingStep("smoke-test"))
+
+			Expect(pipeline.Steps(VerifyPhase)).To(HaveLen(1))
+			Expect(pipelineCopy.Steps(VerifyPhase)).To(HaveLen(2))
+		})
+	})
+
+	Describe("running steps", func() {
+		It("runs the registered steps of a phase in order", func() {
+			pipeline.Add(VerifyPhase, recordingStep("first"))
+			pipeline.Add(VerifyPhase, recordingStep("second"))
+
+			Expect(pusher.Verify(context.Background())).To(Succeed())
+
+			Expect(ran).To(Equal([]string{"first", "second"}))
+		})
+
+		It("reports every step it runs to the progress", func() {
+			progress := &mocks.ProgressReporter{}
+			pusher.Progress = progress
+			pusher.FoundationURL = "https://api1.example.com"
+			pipeline.Add(VerifyPhase, recordingStep("first"))
+			pipeline.Add(VerifyPhase, recordingStep("second"))
+
+			Expect(pusher.Verify(context.Background())).To(Succeed())
+
+			Expect(progress.Calls).To(Equal([]string{
+				"Step " + pusher.DeploymentInfo.UUID + " https://api1.example.com verify-load-balanced-route",
+				"Step " + pusher.DeploymentInfo.UUID + " https://api1.example.com first",
+				"Step " + pusher.DeploymentInfo.UUID + " https://api1.example.com second",
+			}))
+		})
+
+		It("binds the courier to the context of the phase", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			Expect(pusher.Initially(ctx)).To(Succeed())
+
+			Expect(courier.WithContextCall.Received.Context).To(Equal(ctx))
+			Expect(courier.LoginCall.Received.FoundationURL).To(Equal(pusher.FoundationURL))
+		})
+
+		It("traces the phase and every step", func() {
+			spans := make(chan []*tracing.Span, 1)
+			tracer := tracing.NewTracer(spanExporter(spans), interfaces.DefaultLogger(NewBuffer(), logging.DEBUG, "pipeline_test"))
+			tracer.Interval = 10 * time.Millisecond
+			go tracer.Run()
+			pusher.FoundationURL = "https://api1.example.com"
+			pipeline.Add(VerifyPhase, Step{Name: "failing", Run: func(ctx context.Context, p Pusher) error {
+				return errors.New("step failed")
+			}})
+
+			Expect(pusher.Verify(tracing.WithTracer(context.Background(), tracer))).ToNot(Succeed())
+
+			var exported []*tracing.Span
+			Eventually(spans).Should(Receive(&exported))
+			Expect(exported).To(HaveLen(3))
+			Expect(exported[0].Name).To(Equal("push step verify-load-balanced-route"))
+			Expect(exported[1].Name).To(Equal("push step failing"))
+			Expect(exported[1].Error).To(Equal("step failed"))
+			Expect(exported[2].Name).To(Equal("push verify"))
+			Expect(exported[2].Attributes).To(ContainElement(tracing.String("foundation", "https://api1.example.com")))
+			Expect(exported[0].ParentSpanID).To(Equal(exported[2].SpanID))
+			Expect(exported[1].ParentSpanID).To(Equal(exported[2].SpanID))
+		})
+
+		It("stops at the first step that fails", func() {
+			pipeline.Add(VerifyPhase, Step{Name: "failing", Run: func(ctx context.Context, p Pusher) error {
+				return errors.New("step failed")
+			}})
+			pipeline.Add(VerifyPhase, recordingStep("never-run"))
+
+			Expect(pusher.Verify(context.Background())).To(MatchError("step failed"))
+			Expect(ran).To(BeEmpty())
+		})
+
+		It("runs inserted steps around the default steps", func() {
+			Expect(pipeline.InsertBefore(SuccessPhase, "rename-new-build", recordingStep("warm-up"))).To(Succeed())
+
+			Expect(pusher.Success(context.Background())).To(Succeed())
+
+			Expect(ran).To(Equal([]string{"warm-up"}))
+			Expect(courier.RenameCall.Received.AppNameVenerable).To(Equal(appName))
+		})
+	})
+
+	Describe("Apply", func() {
+		var (
+			server   *httptest.Server
+			requests []*http.Request
+			status   int
+		)
+
+		BeforeEach(func() {
+			requests = nil
+			status = http.StatusOK
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r)
+				w.WriteHeader(status)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("registers an http step from the environment configuration", func() {
+			err := pipeline.Apply([]S.PushStepDescriptor{{
+				Name:    "warm-up",
+				Phase:   "success",
+				After:   "rename-new-build",
+				URL:     server.URL + "/{{.AppName}}/warm",
+				Method:  "post",
+				Headers: map[string]string{"X-Token": "token"},
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stepNames(SuccessPhase)).To(Equal([]string{"start-promotion", "retire-original-application", "rename-new-build", "warm-up", "watch-for-crashes", "capture-deployment-events"}))
+
+			Expect(pusher.Success(context.Background())).To(Succeed())
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Method).To(Equal("POST"))
+			Expect(requests[0].URL.Path).To(Equal("/" + appName + "/warm"))
+			Expect(requests[0].Header.Get("X-Token")).To(Equal("token"))
+			Eventually(response).Should(Say("success step warm-up completed"))
+		})
+
+		It("escapes the values of the url for the path and the query", func() {
+			pusher.DeploymentInfo.Org = "team/a"
+			pusher.DeploymentInfo.Space = "dev?debug=1&x"
+			Expect(pipeline.Apply([]S.PushStepDescriptor{{
+				Name:  "warm-up",
+				Phase: "verify",
+				URL:   server.URL + "/{{.Org}}/{{.AppName}}?space={{.Space}}{{if .AppName}}&org={{.Org}}{{end}}",
+			}})).To(Succeed())
+
+			Expect(pusher.Verify(context.Background())).To(Succeed())
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].URL.EscapedPath()).To(Equal("/team%2Fa/" + appName))
+			Expect(requests[0].URL.Query()).To(Equal(url.Values{"space": {"dev?debug=1&x"}, "org": {"team/a"}}))
+		})
+
+		It("fails the step when the request takes longer than its timeout", func() {
+			done := make(chan struct{})
+			defer close(done)
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-done
+			})
+			Expect(pipeline.Apply([]S.PushStepDescriptor{{Name: "warm-up", Phase: "verify", URL: server.URL, TimeoutSeconds: 1}})).To(Succeed())
+
+			start := time.Now()
+			err := pusher.Verify(context.Background())
+
+			Expect(err).To(BeAssignableToTypeOf(state.StepError{}))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("returns an error for a negative timeout", func() {
+			err := pipeline.Apply([]S.PushStepDescriptor{{Name: "warm-up", Phase: "verify", URL: server.URL, TimeoutSeconds: -1}})
+
+			Expect(err).To(BeAssignableToTypeOf(state.InvalidStepError{}))
+			Expect(err.Error()).To(ContainSubstring("timeout_seconds must not be negative"))
+		})
+
+		It("fails the step when the request is not successful", func() {
+			status = http.StatusBadGateway
+			Expect(pipeline.Apply([]S.PushStepDescriptor{{Name: "warm-up", Phase: "verify", URL: server.URL}})).To(Succeed())
+
+			err := pusher.Verify(context.Background())
+
+			Expect(err).To(BeAssignableToTypeOf(state.StepError{}))
+			Expect(err.Error()).To(ContainSubstring("502 Bad Gateway"))
+		})
+
+		It("returns an error for an unknown phase", func() {
+			err := pipeline.Apply([]S.PushStepDescriptor{{Name: "warm-up", Phase: "sometime", URL: server.URL}})
+
+			Expect(err).To(MatchError(state.InvalidPhaseError{Phase: "sometime"}))
+		})
+
+		It("returns an error when the url is missing", func() {
+			err := pipeline.Apply([]S.PushStepDescriptor{{Name: "warm-up", Phase: "verify"}})
+
+			Expect(err).To(MatchError(state.InvalidStepError{Name: "warm-up"}))
+		})
+	})
+})
+
+// spanExporter sends the spans it exports to its channel.
+type spanExporter chan []*tracing.Span
+
+func (e spanExporter) Export(spans []*tracing.Span) error {
+	e <- spans
+	return nil
+}
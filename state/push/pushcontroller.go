@@ -2,6 +2,7 @@ package push
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/compozed/deployadactyl/config"
@@ -10,16 +11,20 @@ import (
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
 	"github.com/compozed/deployadactyl/geterrors"
 	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/rbac"
+	"github.com/compozed/deployadactyl/state"
 	"github.com/compozed/deployadactyl/structs"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 )
 
-type PushControllerConstructor func(log I.DeploymentLogger, deployer, silentDeployer I.Deployer, conf config.Config, eventManager I.EventManager, errorFinder I.ErrorFinder, pushManagerFactory I.PushManagerFactory) I.PushController
+type PushControllerConstructor func(log I.DeploymentLogger, deployer, silentDeployer I.Deployer, conf config.Config, eventManager I.EventManager, errorFinder I.ErrorFinder, pushManagerFactory I.PushManagerFactory, history I.DeploymentHistory) I.PushController
 
-func NewPushController(l I.DeploymentLogger, d, sd I.Deployer, c config.Config, em I.EventManager, ef I.ErrorFinder, pmf I.PushManagerFactory) I.PushController {
+func NewPushController(l I.DeploymentLogger, d, sd I.Deployer, c config.Config, em I.EventManager, ef I.ErrorFinder, pmf I.PushManagerFactory, h I.DeploymentHistory) I.PushController {
 	return &PushController{
 		Deployer:           d,
 		SilentDeployer:     sd,
@@ -27,6 +32,7 @@ func NewPushController(l I.DeploymentLogger, d, sd I.Deployer, c config.Config,
 		EventManager:       em,
 		ErrorFinder:        ef,
 		PushManagerFactory: pmf,
+		History:            h,
 		Log:                l,
 	}
 }
@@ -39,10 +45,11 @@ type PushController struct {
 	EventManager       I.EventManager
 	ErrorFinder        I.ErrorFinder
 	PushManagerFactory I.PushManagerFactory
+	History            I.DeploymentHistory
 }
 
 // PUSH specific
-func (c *PushController) RunDeployment(deployment *I.Deployment, response *bytes.Buffer) (deployResponse I.DeployResponse) {
+func (c *PushController) RunDeployment(ctx context.Context, deployment *I.Deployment, response io.ReadWriter) (deployResponse I.DeployResponse) {
 	cf := deployment.CFContext
 	deploymentInfo := &structs.DeploymentInfo{
 		Org:         cf.Organization,
@@ -50,12 +57,21 @@ func (c *PushController) RunDeployment(deployment *I.Deployment, response *bytes
 		AppName:     cf.Application,
 		Environment: cf.Environment,
 		UUID:        c.Log.UUID,
+		Metadata:    structs.MergeMetadata(deployment.Metadata),
+		Signature:   deployment.Signature,
+
+		ManifestName:           deployment.ManifestName,
+		ExpectedArtifactDigest: deployment.ArtifactDigest,
+		CachedArtifact:         deployment.CachedArtifact,
 	}
 
 	c.Log.Debugf("Starting deploy of %s with UUID %s", cf.Application, deploymentInfo.UUID)
 	c.Log.Debug("building deploymentInfo")
 
-	body := ioutil.NopCloser(bytes.NewBuffer(*deployment.Body))
+	var body io.Reader = deployment.Upload
+	if body == nil {
+		body = ioutil.NopCloser(bytes.NewBuffer(*deployment.Body))
+	}
 	if deployment.Type.JSON {
 		c.Log.Debug("deploying from json request")
 
@@ -78,16 +94,30 @@ func (c *PushController) RunDeployment(deployment *I.Deployment, response *bytes
 			Error:      err,
 		}
 	}
+	err = rbac.Authorize(environment, rbac.Deploy, deployment.Authorization)
+	if err != nil {
+		json.NewEncoder(response).Encode(err)
+		return I.DeployResponse{
+			StatusCode: http.StatusForbidden,
+			Error:      err,
+		}
+	}
 	auth, err := c.resolveAuthorization(deployment.Authorization, environment, c.Log)
 	if err != nil {
+		// Only missing basic auth is the fault of the request: the credentials of the environment could not be read.
+		statusCode := http.StatusInternalServerError
+		if _, ok := err.(deployer.BasicAuthError); ok {
+			statusCode = http.StatusUnauthorized
+		}
 		return I.DeployResponse{
-			StatusCode: http.StatusUnauthorized,
+			StatusCode: statusCode,
 			Error:      err,
 		}
 	}
 
 	deploymentInfo.Username = auth.Username
 	deploymentInfo.Password = auth.Password
+	deploymentInfo.Subject = auth.Subject
 	deploymentInfo.Domain = environment.Domain
 	deploymentInfo.SkipSSL = environment.SkipSSL
 	deploymentInfo.CustomParams = environment.CustomParams
@@ -104,6 +134,31 @@ func (c *PushController) RunDeployment(deployment *I.Deployment, response *bytes
 		}
 	}
 
+	if deploymentInfo.Provenance == nil {
+		deploymentInfo.Provenance = structs.ProvenanceFromMetadata(deploymentInfo.Metadata)
+	}
+
+	var request json.RawMessage
+	if deployment.Type.JSON {
+		request = *deployment.Body
+	}
+
+	environment, err = restrictFoundations(environment, deployment.Foundations)
+	if err != nil {
+		fmt.Fprintln(response, err.Error())
+		return I.DeployResponse{
+			StatusCode:     http.StatusBadRequest,
+			Error:          err,
+			DeploymentInfo: deploymentInfo,
+		}
+	}
+
+	startedAt := time.Now().UTC()
+	c.recordDeployment(deploymentInfo, request, environment.Foundations, startedAt, nil)
+	defer func() {
+		c.recordDeployment(deploymentInfo, request, environment.Foundations, startedAt, &deployResponse)
+	}()
+
 	deployEventData := structs.DeployEventData{Response: response, DeploymentInfo: deploymentInfo, RequestBody: body}
 	defer c.emitDeployFinish(&deployEventData, response, cf, auth, environment, &deployResponse, c.Log)
 	defer c.emitDeploySuccessOrFailure(&deployEventData, response, cf, auth, environment, &deployResponse, c.Log)
@@ -130,6 +185,7 @@ func (c *PushController) RunDeployment(deployment *I.Deployment, response *bytes
 		Response:    response,
 		ArtifactURL: deploymentInfo.ArtifactURL,
 		Data:        deploymentInfo.Data,
+		Metadata:    deploymentInfo.Metadata,
 		Log:         c.Log,
 	})
 	if err != nil {
@@ -150,13 +206,13 @@ func (c *PushController) RunDeployment(deployment *I.Deployment, response *bytes
 	defer close(reqChannel2)
 
 	go func() {
-		reqChannel1 <- c.Deployer.Deploy(deploymentInfo, environment, pusherCreator, response)
+		reqChannel1 <- c.Deployer.Deploy(ctx, deploymentInfo, environment, pusherCreator, response)
 	}()
 
 	silentResponse := &bytes.Buffer{}
 	if cf.Environment == os.Getenv("SILENT_DEPLOY_ENVIRONMENT") {
 		go func() {
-			reqChannel2 <- c.SilentDeployer.Deploy(deploymentInfo, environment, pusherCreator, silentResponse)
+			reqChannel2 <- c.SilentDeployer.Deploy(ctx, deploymentInfo, environment, pusherCreator, silentResponse)
 		}()
 		<-reqChannel2
 	}
@@ -173,19 +229,67 @@ func (c *PushController) getDeploymentInfo(body *[]byte, deploymentInfo *structs
 		return deploymentInfo, err
 	}
 
+	source := deploymentInfo.Git
+	if source != nil && deploymentInfo.ArtifactURL != "" {
+		return &structs.DeploymentInfo{}, state.ArtifactURLWithGitError{}
+	}
+	coordinates := deploymentInfo.Maven
+	if coordinates != nil && (source != nil || deploymentInfo.ArtifactURL != "") {
+		return &structs.DeploymentInfo{}, state.MavenWithOtherSourceError{}
+	}
+
 	getter := geterrors.WrapFunc(func(key string) string {
-		if key == "artifact_url" {
+		switch key {
+		case "artifact_url":
 			return deploymentInfo.ArtifactURL
+		case "git.url":
+			return source.URL
+		case "git.ref":
+			return source.Ref
+		case "maven.group_id":
+			return coordinates.GroupID
+		case "maven.artifact_id":
+			return coordinates.ArtifactID
+		case "maven.version":
+			return coordinates.Version
 		}
 		return ""
 	})
 
-	getter.Get("artifact_url")
+	switch {
+	case source != nil:
+		getter.Get("git.url")
+		getter.Get("git.ref")
+	case coordinates != nil:
+		getter.Get("maven.group_id")
+		getter.Get("maven.artifact_id")
+		getter.Get("maven.version")
+	default:
+		getter.Get("artifact_url")
+	}
 
 	err = getter.Err("The following properties are missing")
 	if err != nil {
 		return &structs.DeploymentInfo{}, err
 	}
+
+	if source != nil {
+		deploymentInfo.ArtifactURL = source.ArtifactURL()
+	}
+	if coordinates != nil {
+		for _, field := range []string{coordinates.GroupID, coordinates.ArtifactID, coordinates.Version, coordinates.Packaging, coordinates.Classifier} {
+			if strings.Contains(field, ":") {
+				return &structs.DeploymentInfo{}, state.InvalidMavenCoordinatesError{}
+			}
+		}
+		deploymentInfo.ArtifactURL = coordinates.ArtifactURL()
+	}
+	for _, route := range deploymentInfo.Routes {
+		err = route.Validate()
+		if err != nil {
+			return &structs.DeploymentInfo{}, err
+		}
+	}
 	return deploymentInfo, nil
 }
 
@@ -193,17 +297,96 @@ func (c *PushController) resolveAuthorization(auth I.Authorization, envs structs
 	config := c.Config
 	deploymentLogger.Debug("checking for basic auth")
 	if auth.Username == "" && auth.Password == "" {
-		if envs.Authenticate {
+		// The user of a bearer token has authenticated, and deploys with the credentials of the environment.
+		if envs.Authenticate && auth.Subject == "" {
 			return I.Authorization{}, deployer.BasicAuthError{}
 
 		}
-		auth.Username = config.Username
-		auth.Password = config.Password
+		username, password, err := config.Credentials(envs)
+		if err != nil {
+			return I.Authorization{}, err
+		}
+		auth.Username, auth.Password = username, password
 	}
 
 	return auth, nil
 }
 
+// recordDeployment adds the deployment to the history. Without a response the deployment is still running.
+// The history is only informational, so failing to record a deployment does not fail it.
+func (c *PushController) recordDeployment(info *structs.DeploymentInfo, request json.RawMessage, foundations []string, startedAt time.Time, deployResponse *I.DeployResponse) {
+	if c.History == nil {
+		return
+	}
+
+	record := structs.DeploymentRecord{
+		UUID:           info.UUID,
+		Environment:    info.Environment,
+		Org:            info.Org,
+		Space:          info.Space,
+		AppName:        info.AppName,
+		Username:       info.User(),
+		ArtifactURL:    info.ArtifactURL,
+		ArtifactDigest: info.ArtifactDigest,
+		Status:         structs.DeploymentRunning,
+		StartedAt:      startedAt,
+		Metadata:       info.Metadata,
+		SBOM:           info.SBOM,
+		Provenance:     info.Provenance,
+		ScanResult:     info.ScanResult,
+		Evidence:       info.Evidence,
+		Request:        request,
+		Foundations:    foundations,
+
+		SkippedFoundations: info.SkippedFoundations,
+	}
+
+	if deployResponse != nil {
+		record.FinishedAt = time.Now().UTC()
+		record.FoundationResults = deployResponse.Foundations
+		record.FailedFoundations = structs.FoundationsWithStatus(deployResponse.Foundations, structs.FoundationFailed)
+		record.Status = structs.DeploymentSucceeded
+		if deployResponse.Error != nil {
+			record.Status = structs.DeploymentFailed
+			record.Error = deployResponse.Error.Error()
+		} else if deployResponse.Degraded {
+			record.Status = structs.DeploymentDegraded
+		}
+		if deployResponse.PartialSuccess {
+			record.Status = structs.DeploymentPartial
+		}
+	}
+
+	err := c.History.Record(record)
+	if err != nil {
+		c.Log.Errorf("cannot record deployment %s: %s", info.UUID, err)
+	}
+}
+
+// restrictFoundations returns the environment with only the given foundations, which must be foundations of the
+// environment. The environment is returned as is without any.
+func restrictFoundations(environment structs.Environment, foundations []string) (structs.Environment, error) {
+	if len(foundations) == 0 {
+		return environment, nil
+	}
+
+	for _, foundation := range foundations {
+		found := false
+		for _, foundationURL := range environment.Foundations {
+			if foundation == foundationURL {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return environment, deployer.FoundationNotFoundError{Foundation: foundation, Environment: environment.Name}
+		}
+	}
+
+	environment.Foundations = foundations
+	return environment, nil
+}
+
 func (c *PushController) resolveEnvironment(env string) (structs.Environment, error) {
 	config := c.Config
 	environment, ok := config.Environments[env]
@@ -231,6 +414,7 @@ func (c *PushController) emitDeployFinish(deployEventData *structs.DeployEventDa
 		Environment: environment,
 		Response:    deployEventData.Response,
 		Data:        deployEventData.DeploymentInfo.Data,
+		Metadata:    deployEventData.DeploymentInfo.Metadata,
 		Log:         c.Log,
 	})
 	if finishErr != nil {
@@ -270,6 +454,7 @@ func (c PushController) emitDeploySuccessOrFailure(deployEventData *structs.Depl
 			Environment: environment,
 			Response:    deployEventData.Response,
 			Data:        deployEventData.DeploymentInfo.Data,
+			Metadata:    deployEventData.DeploymentInfo.Metadata,
 			Error:       deployResponse.Error,
 			Log:         c.Log,
 		}
@@ -282,6 +467,7 @@ func (c PushController) emitDeploySuccessOrFailure(deployEventData *structs.Depl
 			Environment:         environment,
 			Response:            deployEventData.Response,
 			Data:                deployEventData.DeploymentInfo.Data,
+			Metadata:            deployEventData.DeploymentInfo.Metadata,
 			HealthCheckEndpoint: deployEventData.DeploymentInfo.HealthCheckEndpoint,
 			ArtifactURL:         deployEventData.DeploymentInfo.ArtifactURL,
 			Log:                 c.Log,
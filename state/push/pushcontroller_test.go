@@ -2,15 +2,19 @@ package push_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/compozed/deployadactyl/config"
 	"github.com/compozed/deployadactyl/constants"
 	D "github.com/compozed/deployadactyl/controller/deployer"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
 	"github.com/compozed/deployadactyl/controller/deployer/error_finder"
+	"github.com/compozed/deployadactyl/history"
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/mocks"
 	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/rbac"
+	"github.com/compozed/deployadactyl/state"
 	"github.com/compozed/deployadactyl/state/push"
 	"github.com/compozed/deployadactyl/structs"
 	"github.com/go-errors/errors"
@@ -23,6 +27,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 )
 
 var _ = Describe("RunDeployment", func() {
@@ -106,7 +111,7 @@ var _ = Describe("RunDeployment", func() {
 				},
 			}
 			deployment.Type.ZIP = true
-			deployResponse := controller.RunDeployment(deployment, response)
+			deployResponse := controller.RunDeployment(context.Background(), deployment, response)
 
 			Eventually(deployer.DeployCall.Called).Should(Equal(1))
 			Eventually(silentDeployer.DeployCall.Called).Should(Equal(0))
@@ -142,7 +147,7 @@ var _ = Describe("RunDeployment", func() {
 			}
 			deployment.Type.ZIP = true
 
-			deployResponse := controller.RunDeployment(deployment, response)
+			deployResponse := controller.RunDeployment(context.Background(), deployment, response)
 			receivedBody, _ := ioutil.ReadAll(deployer.DeployCall.Received.DeploymentInfo.Body)
 			Eventually(deployer.DeployCall.Called).Should(Equal(1))
 			Eventually(silentDeployer.DeployCall.Called).Should(Equal(0))
@@ -163,7 +168,7 @@ var _ = Describe("RunDeployment", func() {
 			deployer.DeployCall.Returns.StatusCode = http.StatusOK
 			deployer.DeployCall.Write.Output = "little-timmy-env.zip"
 
-			deployResponse := controller.RunDeployment(&deployment, response)
+			deployResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 			Eventually(deployer.DeployCall.Called).Should(Equal(1))
 			Eventually(silentDeployer.DeployCall.Called).Should(Equal(0))
@@ -191,7 +196,7 @@ var _ = Describe("RunDeployment", func() {
 			deployer.DeployCall.Returns.StatusCode = http.StatusInternalServerError
 			deployer.DeployCall.Write.Output = "little-timmy-env.zip"
 
-			deployResponse := controller.RunDeployment(&deployment, response)
+			deployResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 			Eventually(deployer.DeployCall.Called).Should(Equal(1))
 			Eventually(silentDeployer.DeployCall.Called).Should(Equal(0))
@@ -209,6 +214,18 @@ var _ = Describe("RunDeployment", func() {
 			Eventually(string(ret)).Should(Equal("little-timmy-env.zip"))
 		})
 
+		It("deploys the zip uploaded with the request", func() {
+			upload := strings.NewReader("uploaded zip")
+			deployment.CFContext.Environment = environment
+			deployment.Type.ZIP = true
+			deployment.Body = nil
+			deployment.Upload = upload
+
+			controller.RunDeployment(context.Background(), &deployment, response)
+
+			Expect(deployer.DeployCall.Received.DeploymentInfo.Body).To(BeIdenticalTo(upload))
+		})
+
 		It("does not set the basic auth header if no credentials are passed", func() {
 			deployer.DeployCall.Write.Output = "little-timmy-env.zip"
 
@@ -228,7 +245,7 @@ var _ = Describe("RunDeployment", func() {
 					Password: "",
 				},
 			}
-			controller.RunDeployment(deployment, response)
+			controller.RunDeployment(context.Background(), deployment, response)
 
 			Eventually(deployer.DeployCall.Received.DeploymentInfo.Username).Should(Equal(""))
 			Eventually(deployer.DeployCall.Received.DeploymentInfo.Password).Should(Equal(""))
@@ -245,7 +262,7 @@ var _ = Describe("RunDeployment", func() {
 				Password: "TestPassword",
 			}
 
-			controller.RunDeployment(&deployment, response)
+			controller.RunDeployment(context.Background(), &deployment, response)
 
 			Eventually(deployer.DeployCall.Received.DeploymentInfo.Username).Should(Equal("TestUsername"))
 			Eventually(deployer.DeployCall.Received.DeploymentInfo.Password).Should(Equal("TestPassword"))
@@ -265,7 +282,7 @@ var _ = Describe("RunDeployment", func() {
 			deployer.DeployCall.Returns.StatusCode = http.StatusOK
 			deployer.DeployCall.Write.Output = "little-timmy-env.zip"
 
-			deployResponse := controller.RunDeployment(&deployment, response)
+			deployResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 			Eventually(deployer.DeployCall.Called).Should(Equal(1))
 			Eventually(silentDeployer.DeployCall.Called).Should(Equal(1))
@@ -302,7 +319,7 @@ var _ = Describe("RunDeployment", func() {
 			silentDeployUrl := server.URL + "/v1/apps/" + os.Getenv("SILENT_DEPLOY_ENVIRONMENT")
 			os.Setenv("SILENT_DEPLOY_URL", silentDeployUrl)
 
-			deployResponse := controller.RunDeployment(&deployment, response)
+			deployResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 			Eventually(deployer.DeployCall.Called).Should(Equal(1))
 			Eventually(silentDeployer.DeployCall.Called).Should(Equal(1))
@@ -320,18 +337,200 @@ var _ = Describe("RunDeployment", func() {
 		})
 	})
 
+	Context("when there is a deployment history", func() {
+		var deploymentHistory *history.MemoryHistory
+
+		BeforeEach(func() {
+			deploymentHistory = history.NewMemoryHistory(0)
+			controller.History = deploymentHistory
+
+			deployment.CFContext = I.CFContext{Environment: environment, Organization: org, Space: space, Application: appName}
+			deployment.Type.ZIP = true
+			deployment.Metadata = map[string]string{"revision": "abc123"}
+		})
+
+		It("records a successful deployment", func() {
+			deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+			controller.RunDeployment(context.Background(), &deployment, response)
+
+			record, err := deploymentHistory.Get(uuid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record.Status).To(Equal(structs.DeploymentSucceeded))
+			Expect(record.AppName).To(Equal(appName))
+			Expect(record.Environment).To(Equal(environment))
+			Expect(record.StartedAt.IsZero()).To(BeFalse())
+			Expect(record.FinishedAt.IsZero()).To(BeFalse())
+			Expect(record.Provenance).To(Equal(&structs.Provenance{Revision: "abc123"}))
+		})
+
+		It("records a failed deployment with its error", func() {
+			deployer.DeployCall.Returns.StatusCode = http.StatusInternalServerError
+			deployer.DeployCall.Returns.Error = errors.New("push failed")
+
+			controller.RunDeployment(context.Background(), &deployment, response)
+
+			record, err := deploymentHistory.Get(uuid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record.Status).To(Equal(structs.DeploymentFailed))
+			Expect(record.Error).To(Equal("push failed"))
+		})
+
+		It("records a degraded deployment", func() {
+			deployer.DeployCall.Returns.StatusCode = http.StatusOK
+			deployer.DeployCall.Returns.Degraded = true
+
+			controller.RunDeployment(context.Background(), &deployment, response)
+
+			record, err := deploymentHistory.Get(uuid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record.Status).To(Equal(structs.DeploymentDegraded))
+		})
+
+		It("records a partially successful deployment with the result on every foundation", func() {
+			results := []structs.FoundationResult{
+				{FoundationURL: "https://api1.example.com", Status: structs.FoundationSucceeded},
+				{FoundationURL: "https://api2.example.com", Status: structs.FoundationFailed, Error: "push failed"},
+			}
+			deployer.DeployCall.Returns.StatusCode = http.StatusMultiStatus
+			deployer.DeployCall.Returns.Error = errors.New("push failed")
+			deployer.DeployCall.Returns.Foundations = results
+			deployer.DeployCall.Returns.PartialSuccess = true
+
+			controller.RunDeployment(context.Background(), &deployment, response)
+
+			record, err := deploymentHistory.Get(uuid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record.Status).To(Equal(structs.DeploymentPartial))
+			Expect(record.Error).To(Equal("push failed"))
+			Expect(record.FoundationResults).To(Equal(results))
+			Expect(record.FailedFoundations).To(Equal([]string{"https://api2.example.com"}))
+		})
+
+		It("records the evidence captured during the deployment", func() {
+			evidence := []structs.DeploymentEvidence{{FoundationURL: "https://api.one.example.com", ServerErrors: 3}}
+			deployer.DeployCall.Returns.StatusCode = http.StatusOK
+			deployer.DeployCall.Returns.Evidence = evidence
+
+			controller.RunDeployment(context.Background(), &deployment, response)
+
+			record, err := deploymentHistory.Get(uuid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record.Evidence).To(Equal(evidence))
+		})
+
+		It("records the foundations of the deployment", func() {
+			controller.Config.Environments[environment] = structs.Environment{Foundations: []string{"https://api1.example.com", "https://api2.example.com"}}
+			deployment.Foundations = []string{"https://api2.example.com"}
+			deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+			controller.RunDeployment(context.Background(), &deployment, response)
+
+			record, err := deploymentHistory.Get(uuid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record.Foundations).To(Equal([]string{"https://api2.example.com"}))
+		})
+
+		It("records the foundations skipped because they were in maintenance", func() {
+			deployer.DeployCall.Returns.StatusCode = http.StatusOK
+			deployer.DeployCall.Returns.SkippedFoundations = []string{"https://api2.example.com"}
+
+			controller.RunDeployment(context.Background(), &deployment, response)
+
+			record, err := deploymentHistory.Get(uuid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record.SkippedFoundations).To(Equal([]string{"https://api2.example.com"}))
+		})
+
+		It("records the request of a JSON deployment", func() {
+			body := []byte(`{"artifact_url": "https://example.com/artifact.zip"}`)
+			deployment.Type = I.DeploymentType{JSON: true}
+			deployment.Body = &body
+			deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+			controller.RunDeployment(context.Background(), &deployment, response)
+
+			record, err := deploymentHistory.Get(uuid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(record.Request)).To(Equal(string(body)))
+		})
+
+		It("does not record the request of a zip deployment", func() {
+			deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+			controller.RunDeployment(context.Background(), &deployment, response)
+
+			record, err := deploymentHistory.Get(uuid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record.Request).To(BeNil())
+		})
+	})
+
+	Context("when the deployment is restricted to some foundations", func() {
+		BeforeEach(func() {
+			controller.Config.Environments[environment] = structs.Environment{
+				Name:        environment,
+				Foundations: []string{"https://api1.example.com", "https://api2.example.com"},
+			}
+			deployment.CFContext = I.CFContext{Environment: environment, Organization: org, Space: space, Application: appName}
+			deployment.Type.ZIP = true
+		})
+
+		It("deploys to those foundations only", func() {
+			deployment.Foundations = []string{"https://api2.example.com"}
+			deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+			controller.RunDeployment(context.Background(), &deployment, response)
+
+			Expect(deployer.DeployCall.Received.Env.Foundations).To(Equal([]string{"https://api2.example.com"}))
+			Expect(controller.Config.Environments[environment].Foundations).To(HaveLen(2))
+		})
+
+		It("returns http.StatusBadRequest for a foundation the environment does not have", func() {
+			deployment.Foundations = []string{"https://api3.example.com"}
+
+			deployResponse := controller.RunDeployment(context.Background(), &deployment, response)
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(deployResponse.Error).To(MatchError(D.FoundationNotFoundError{Foundation: "https://api3.example.com", Environment: environment}))
+			Expect(deployer.DeployCall.Called).To(Equal(0))
+		})
+	})
+
+	It("passes the deployment whose cached artifact is deployed to the deployer", func() {
+		deployment.CFContext = I.CFContext{Environment: environment, Organization: org, Space: space, Application: appName}
+		deployment.Type.ZIP = true
+		deployment.CachedArtifact = "abc"
+		deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+		controller.RunDeployment(context.Background(), &deployment, response)
+
+		Expect(deployer.DeployCall.Received.DeploymentInfo.CachedArtifact).To(Equal("abc"))
+	})
+
+	It("passes the digest the artifact must have to the deployer", func() {
+		deployment.CFContext = I.CFContext{Environment: environment, Organization: org, Space: space, Application: appName}
+		deployment.Type.ZIP = true
+		deployment.ArtifactDigest = "sha256:abc"
+		deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+		controller.RunDeployment(context.Background(), &deployment, response)
+
+		Expect(deployer.DeployCall.Received.DeploymentInfo.ExpectedArtifactDigest).To(Equal("sha256:abc"))
+	})
+
 	Context("when called", func() {
 		It("logs building deploymentInfo", func() {
 			deployment.CFContext.Environment = environment
 
-			controller.RunDeployment(&deployment, response)
+			controller.RunDeployment(context.Background(), &deployment, response)
 			Eventually(logBuffer).Should(Say("building deploymentInfo"))
 		})
 		It("creates a pusher creator", func() {
 			deployment.CFContext.Environment = environment
 			deployment.Type.ZIP = true
 
-			controller.RunDeployment(&deployment, response)
+			controller.RunDeployment(context.Background(), &deployment, response)
 			Eventually(pushManagerFactory.PushManagerCall.Called).Should(Equal(true))
 
 		})
@@ -341,7 +540,7 @@ var _ = Describe("RunDeployment", func() {
 			deployment.Body = &bodyByte
 			deployment.Type.ZIP = true
 
-			controller.RunDeployment(&deployment, response)
+			controller.RunDeployment(context.Background(), &deployment, response)
 			returnedBody, _ := ioutil.ReadAll(pushManagerFactory.PushManagerCall.Received.DeployEventData.RequestBody)
 			Eventually(returnedBody).Should(Equal(bodyByte))
 		})
@@ -351,7 +550,7 @@ var _ = Describe("RunDeployment", func() {
 
 			response = bytes.NewBuffer([]byte("hello"))
 
-			controller.RunDeployment(&deployment, response)
+			controller.RunDeployment(context.Background(), &deployment, response)
 			returnedResponse, _ := ioutil.ReadAll(pushManagerFactory.PushManagerCall.Received.DeployEventData.Response)
 			Eventually(returnedResponse).Should(Equal([]byte("hello")))
 		})
@@ -362,7 +561,7 @@ var _ = Describe("RunDeployment", func() {
 				deployment.CFContext.Environment = environment
 				deployment.Type.JSON = true
 
-				controller.RunDeployment(&deployment, response)
+				controller.RunDeployment(context.Background(), &deployment, response)
 				Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.ArtifactURL).Should(Equal("the artifact url"))
 			})
 			It("gets the manifest from the request", func() {
@@ -371,7 +570,7 @@ var _ = Describe("RunDeployment", func() {
 				deployment.CFContext.Environment = environment
 				deployment.Type.JSON = true
 
-				controller.RunDeployment(&deployment, response)
+				controller.RunDeployment(context.Background(), &deployment, response)
 				Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Manifest).Should(Equal("the manifest"))
 			})
 			It("gets the data from the request", func() {
@@ -380,9 +579,24 @@ var _ = Describe("RunDeployment", func() {
 				deployment.CFContext.Environment = environment
 				deployment.Type.JSON = true
 
-				controller.RunDeployment(&deployment, response)
+				controller.RunDeployment(context.Background(), &deployment, response)
 				Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Data["avalue"]).Should(Equal("the data"))
 			})
+			It("merges the metadata from the request over the deployment metadata", func() {
+				bodyByte := []byte("{\"artifact_url\": \"the artifact url\", \"metadata\": {\"commit\": \"def456\"}}")
+				deployment.Body = &bodyByte
+				deployment.CFContext.Environment = environment
+				deployment.Type.JSON = true
+				deployment.Metadata = map[string]string{"commit": "abc123", "pipeline_id": "1234"}
+
+				controller.RunDeployment(context.Background(), &deployment, response)
+
+				Expect(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Metadata).To(Equal(map[string]string{"commit": "def456", "pipeline_id": "1234"}))
+				Expect(deployment.Metadata["commit"]).To(Equal("abc123"))
+
+				event := eventManager.EmitEventCall.Received.Events[0].(push.DeployStartedEvent)
+				Expect(event.Metadata).To(Equal(map[string]string{"commit": "def456", "pipeline_id": "1234"}))
+			})
 		})
 		Context("the deployment info", func() {
 			Context("when environment does not exist", func() {
@@ -390,7 +604,7 @@ var _ = Describe("RunDeployment", func() {
 					deployment.CFContext.Environment = "bad env"
 					deployment.Type.ZIP = true
 
-					deploymentResponse := controller.RunDeployment(&deployment, response)
+					deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
 					Eventually(deploymentResponse.Error).Should(HaveOccurred())
 					Eventually(reflect.TypeOf(deploymentResponse.Error)).Should(Equal(reflect.TypeOf(D.EnvironmentNotFoundError{})))
 				})
@@ -407,12 +621,34 @@ var _ = Describe("RunDeployment", func() {
 							controller.Config.Username = "username-" + randomizer.StringRunes(10)
 							controller.Config.Password = "password-" + randomizer.StringRunes(10)
 
-							controller.RunDeployment(&deployment, response)
+							controller.RunDeployment(context.Background(), &deployment, response)
 
 							Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Username).Should(Equal(controller.Config.Username))
 							Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Password).Should(Equal(controller.Config.Password))
 						})
 					})
+					Context("and the environment belongs to a tenant with credentials", func() {
+						It("returns the username and password of the tenant", func() {
+							deployment.CFContext.Environment = environment
+							deployment.Type.ZIP = true
+
+							deployment.Authorization.Username = ""
+							deployment.Authorization.Password = ""
+							controller.Config.Username = "username-" + randomizer.StringRunes(10)
+							controller.Config.Password = "password-" + randomizer.StringRunes(10)
+
+							tenantEnvironment := controller.Config.Environments[environment]
+							tenantEnvironment.Tenant = "search"
+							tenantEnvironment.Username = "search-username"
+							tenantEnvironment.Password = "search-password"
+							controller.Config.Environments[environment] = tenantEnvironment
+
+							controller.RunDeployment(context.Background(), &deployment, response)
+
+							Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Username).Should(Equal("search-username"))
+							Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Password).Should(Equal("search-password"))
+						})
+					})
 					Context("and authentication is required", func() {
 						It("returns an error", func() {
 							deployment.CFContext.Environment = environment
@@ -425,11 +661,68 @@ var _ = Describe("RunDeployment", func() {
 								Authenticate: true,
 							}
 
-							deploymentResponse := controller.RunDeployment(&deployment, response)
+							deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 							Eventually(deploymentResponse.Error).Should(HaveOccurred())
 							Eventually(deploymentResponse.Error.Error()).Should(Equal("basic auth header not found"))
 						})
+
+						It("does not deploy for a user the access rules do not allow to deploy", func() {
+							deployment.CFContext.Environment = environment
+							deployment.Type.ZIP = true
+
+							deployment.Authorization = I.Authorization{Subject: "john", Groups: []string{"operators"}}
+							controller.Config.Environments[environment] = structs.Environment{
+								Name:   environment,
+								Access: []structs.AccessRule{{Groups: []string{"operators"}, Operations: []string{rbac.Stop, rbac.Start}}},
+							}
+
+							deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
+
+							Expect(deploymentResponse.StatusCode).To(Equal(http.StatusForbidden))
+							Expect(deploymentResponse.Error).To(MatchError(rbac.ForbiddenError{User: "john", Operation: rbac.Deploy, Environment: environment}))
+							Expect(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo).To(BeNil())
+						})
+
+						It("does not deploy for the user of a bearer token without an access rule", func() {
+							deployment.CFContext.Environment = environment
+							deployment.Type.ZIP = true
+
+							deployment.Authorization = I.Authorization{Subject: "jane@example.com"}
+							controller.Config.Environments[environment] = structs.Environment{
+								Name:         environment,
+								Authenticate: true,
+							}
+
+							deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
+
+							Expect(deploymentResponse.StatusCode).To(Equal(http.StatusForbidden))
+							Expect(deploymentResponse.Error).To(MatchError(rbac.ForbiddenError{User: "jane@example.com", Operation: rbac.Deploy, Environment: environment}))
+							Expect(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo).To(BeNil())
+						})
+
+						It("deploys as the user of a bearer token an access rule allows with the credentials of the config", func() {
+							deployment.CFContext.Environment = environment
+							deployment.Type.ZIP = true
+
+							deployment.Authorization = I.Authorization{Subject: "jane@example.com"}
+							controller.Config.Username = "username-" + randomizer.StringRunes(10)
+							controller.Config.Password = "password-" + randomizer.StringRunes(10)
+
+							controller.Config.Environments[environment] = structs.Environment{
+								Name:         environment,
+								Authenticate: true,
+								Access:       []structs.AccessRule{{Users: []string{"jane@example.com"}, Operations: []string{rbac.Deploy}}},
+							}
+
+							controller.RunDeployment(context.Background(), &deployment, response)
+
+							info := pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo
+							Expect(info.Username).To(Equal(controller.Config.Username))
+							Expect(info.Password).To(Equal(controller.Config.Password))
+							Expect(info.Subject).To(Equal("jane@example.com"))
+							Expect(info.User()).To(Equal("jane@example.com"))
+						})
 					})
 				})
 				Context("when Authorization has values", func() {
@@ -437,7 +730,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Environment = environment
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						Eventually(logBuffer).Should(Say("checking for basic auth"))
 					})
@@ -448,7 +741,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.Authorization.Username = "username-" + randomizer.StringRunes(10)
 						deployment.Authorization.Password = "password-" + randomizer.StringRunes(10)
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Username).Should(Equal(deployment.Authorization.Username))
 						Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Password).Should(Equal(deployment.Authorization.Password))
@@ -463,7 +756,7 @@ var _ = Describe("RunDeployment", func() {
 					deployment.CFContext.Application = appName
 					deployment.CFContext.Environment = environment
 
-					controller.RunDeployment(&deployment, response)
+					controller.RunDeployment(context.Background(), &deployment, response)
 
 					Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Org).Should(Equal(org))
 					Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Space).Should(Equal(space))
@@ -476,7 +769,7 @@ var _ = Describe("RunDeployment", func() {
 					bodyByte := []byte(`{"artifact_url": "xyz"}`)
 					deployment.Body = &bodyByte
 
-					controller.RunDeployment(&deployment, response)
+					controller.RunDeployment(context.Background(), &deployment, response)
 
 					Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.ContentType).Should(Equal("JSON"))
 				})
@@ -484,7 +777,7 @@ var _ = Describe("RunDeployment", func() {
 					deployment.CFContext.Environment = environment
 					deployment.Type.ZIP = true
 
-					controller.RunDeployment(&deployment, response)
+					controller.RunDeployment(context.Background(), &deployment, response)
 
 					Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.ContentType).Should(Equal("ZIP"))
 				})
@@ -494,7 +787,7 @@ var _ = Describe("RunDeployment", func() {
 					bodyByte := []byte(`{"artifact_url": "xyz"}`)
 					deployment.Body = &bodyByte
 
-					controller.RunDeployment(&deployment, response)
+					controller.RunDeployment(context.Background(), &deployment, response)
 
 					returnedBody, _ := ioutil.ReadAll(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Body)
 					Eventually(string(returnedBody)).Should(Equal(string(bodyByte)))
@@ -504,7 +797,7 @@ var _ = Describe("RunDeployment", func() {
 					It("returns an error", func() {
 						deployment.CFContext.Environment = environment
 
-						deployResponse := controller.RunDeployment(&deployment, response)
+						deployResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 						Eventually(reflect.TypeOf(deployResponse.Error)).Should(Equal(reflect.TypeOf(D.InvalidContentTypeError{})))
 					})
@@ -515,7 +808,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Environment = environment
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.UUID).ShouldNot(BeEmpty())
 					})
@@ -533,7 +826,7 @@ var _ = Describe("RunDeployment", func() {
 						SkipSSL: true,
 					}
 
-					controller.RunDeployment(&deployment, response)
+					controller.RunDeployment(context.Background(), &deployment, response)
 
 					Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Domain).Should(Equal(domain))
 					Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.SkipSSL).Should(BeTrue())
@@ -543,7 +836,7 @@ var _ = Describe("RunDeployment", func() {
 					deployment.CFContext.Environment = environment
 					deployment.Type.ZIP = true
 
-					customParams := make(map[string]interface{})
+					customParams := make(structs.Params)
 					customParams["param1"] = "value1"
 					customParams["param2"] = "value2"
 
@@ -551,7 +844,7 @@ var _ = Describe("RunDeployment", func() {
 						CustomParams: customParams,
 					}
 
-					controller.RunDeployment(&deployment, response)
+					controller.RunDeployment(context.Background(), &deployment, response)
 
 					Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.CustomParams["param1"]).Should(Equal("value1"))
 					Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.CustomParams["param2"]).Should(Equal("value2"))
@@ -562,7 +855,7 @@ var _ = Describe("RunDeployment", func() {
 					deployment.Authorization.Username = randomizer.StringRunes(10)
 					deployment.Type.ZIP = true
 
-					controller.RunDeployment(&deployment, response)
+					controller.RunDeployment(context.Background(), &deployment, response)
 
 					Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo).ShouldNot(BeNil())
 					Eventually(pushManagerFactory.PushManagerCall.Received.CFContext.Environment).Should(Equal(environment))
@@ -577,7 +870,7 @@ var _ = Describe("RunDeployment", func() {
 					deployment.Body = &bodyByte
 					deployment.Type.JSON = true
 
-					controller.RunDeployment(&deployment, response)
+					controller.RunDeployment(context.Background(), &deployment, response)
 
 					Eventually(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.ArtifactURL).Should(Equal(artifactURL))
 				})
@@ -589,12 +882,126 @@ var _ = Describe("RunDeployment", func() {
 						deployment.Body = &bodyByte
 						deployment.Type.JSON = true
 
-						deploymentResponse := controller.RunDeployment(&deployment, response)
+						deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 						Eventually(deploymentResponse.Error).ShouldNot(BeNil())
 						Eventually(deploymentResponse.Error.Error()).Should(ContainSubstring("The following properties are missing: artifact_url"))
 					})
 				})
+				Context("when the body has a git repository", func() {
+					It("deploys the artifact url of the repository", func() {
+						bodyByte := []byte(`{"git": {"url": "https://github.com/example/app.git", "ref": "v1.2.0"}}`)
+
+						deployment.CFContext.Environment = environment
+						deployment.Body = &bodyByte
+						deployment.Type.JSON = true
+
+						controller.RunDeployment(context.Background(), &deployment, response)
+
+						Expect(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.ArtifactURL).To(Equal("git+https://github.com/example/app.git#v1.2.0"))
+					})
+
+					It("returns an error without the ref", func() {
+						bodyByte := []byte(`{"git": {"url": "https://github.com/example/app.git"}}`)
+
+						deployment.CFContext.Environment = environment
+						deployment.Body = &bodyByte
+						deployment.Type.JSON = true
+
+						deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
+
+						Expect(deploymentResponse.Error).To(MatchError(ContainSubstring("The following properties are missing: git.ref")))
+					})
+
+					It("returns an error with an artifact url too", func() {
+						bodyByte := []byte(`{"artifact_url": "https://example.com/app.zip", "git": {"url": "https://github.com/example/app.git", "ref": "v1.2.0"}}`)
+
+						deployment.CFContext.Environment = environment
+						deployment.Body = &bodyByte
+						deployment.Type.JSON = true
+
+						deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
+
+						Expect(deploymentResponse.Error).To(MatchError(state.ArtifactURLWithGitError{}))
+					})
+				})
+				Context("when the body has maven coordinates", func() {
+					It("deploys the artifact url of the coordinates", func() {
+						bodyByte := []byte(`{"maven": {"group_id": "com.example", "artifact_id": "app", "version": "RELEASE", "packaging": "war"}}`)
+
+						deployment.CFContext.Environment = environment
+						deployment.Body = &bodyByte
+						deployment.Type.JSON = true
+
+						controller.RunDeployment(context.Background(), &deployment, response)
+
+						Expect(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.ArtifactURL).To(Equal("maven:com.example:app:RELEASE:war"))
+					})
+
+					It("returns an error without the version", func() {
+						bodyByte := []byte(`{"maven": {"group_id": "com.example", "artifact_id": "app"}}`)
+
+						deployment.CFContext.Environment = environment
+						deployment.Body = &bodyByte
+						deployment.Type.JSON = true
+
+						deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
+
+						Expect(deploymentResponse.Error).To(MatchError(ContainSubstring("The following properties are missing: maven.version")))
+					})
+
+					It("returns an error with an artifact url too", func() {
+						bodyByte := []byte(`{"artifact_url": "https://example.com/app.zip", "maven": {"group_id": "com.example", "artifact_id": "app", "version": "1.2.0"}}`)
+
+						deployment.CFContext.Environment = environment
+						deployment.Body = &bodyByte
+						deployment.Type.JSON = true
+
+						deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
+
+						Expect(deploymentResponse.Error).To(MatchError(state.MavenWithOtherSourceError{}))
+					})
+
+					It("returns an error when a coordinate has a colon", func() {
+						bodyByte := []byte(`{"maven": {"group_id": "com.example", "artifact_id": "app:web", "version": "1.2.0"}}`)
+
+						deployment.CFContext.Environment = environment
+						deployment.Body = &bodyByte
+						deployment.Type.JSON = true
+
+						deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
+
+						Expect(deploymentResponse.Error).To(MatchError(state.InvalidMavenCoordinatesError{}))
+					})
+				})
+				Context("when the body has routes", func() {
+					It("deploys with the routes", func() {
+						bodyByte := []byte(`{"artifact_url": "https://example.com/app.zip", "routes": [{"hostname": "www", "domain": "example.com"}, {"domain": "apps.example.com", "path": "/search"}]}`)
+
+						deployment.CFContext.Environment = environment
+						deployment.Body = &bodyByte
+						deployment.Type.JSON = true
+
+						controller.RunDeployment(context.Background(), &deployment, response)
+
+						Expect(pushManagerFactory.PushManagerCall.Received.DeployEventData.DeploymentInfo.Routes).To(Equal([]structs.Route{
+							{Hostname: "www", Domain: "example.com"},
+							{Domain: "apps.example.com", Path: "/search"},
+						}))
+					})
+
+					It("returns an error for an invalid route", func() {
+						bodyByte := []byte(`{"artifact_url": "https://example.com/app.zip", "routes": [{"hostname": "www.search", "domain": "example.com"}]}`)
+
+						deployment.CFContext.Environment = environment
+						deployment.Body = &bodyByte
+						deployment.Type.JSON = true
+
+						deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
+
+						Expect(deploymentResponse.Error).To(BeAssignableToTypeOf(structs.InvalidRouteError{}))
+					})
+				})
 				Context("if body is invalid", func() {
 					It("returns an error", func() {
 						bodyByte := []byte("")
@@ -603,7 +1010,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.Body = &bodyByte
 						deployment.Type.JSON = true
 
-						deploymentResponse := controller.RunDeployment(&deployment, response)
+						deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 						Eventually(deploymentResponse.Error).ShouldNot(BeNil())
 						Eventually(deploymentResponse.Error.Error()).Should(ContainSubstring("EOF"))
@@ -614,7 +1021,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Environment = environment
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						Eventually(logBuffer).Should(Say("emitting a deploy.start event"))
 					})
@@ -622,7 +1029,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Environment = environment
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						Expect(eventManager.EmitCall.Received.Events[0].Type).Should(Equal(constants.DeployStartEvent))
 					})
@@ -630,7 +1037,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Environment = environment
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						Expect(eventManager.EmitEventCall.Received.Events[0].Name()).Should(Equal("DeployStartedEvent"))
 					})
@@ -641,7 +1048,7 @@ var _ = Describe("RunDeployment", func() {
 
 							eventManager.EmitCall.Returns.Error = []error{errors.New("a test error")}
 
-							deploymentResponse := controller.RunDeployment(&deployment, response)
+							deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 							Expect(reflect.TypeOf(deploymentResponse.Error)).Should(Equal(reflect.TypeOf(D.EventError{})))
 						})
@@ -653,7 +1060,7 @@ var _ = Describe("RunDeployment", func() {
 
 							eventManager.EmitEventCall.Returns.Error = []error{errors.New("a test error")}
 
-							deploymentResponse := controller.RunDeployment(&deployment, response)
+							deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 							Expect(reflect.TypeOf(deploymentResponse.Error)).Should(Equal(reflect.TypeOf(D.EventError{})))
 						})
@@ -665,7 +1072,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Organization = org
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						deploymentInfo := eventManager.EmitCall.Received.Events[0].Data.(*structs.DeployEventData).DeploymentInfo
 						Expect(deploymentInfo.AppName).To(Equal(appName))
@@ -681,7 +1088,7 @@ var _ = Describe("RunDeployment", func() {
 
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[0].(push.DeployStartedEvent)
 						Expect(event.CFContext.Environment).To(Equal(environment))
@@ -698,7 +1105,7 @@ var _ = Describe("RunDeployment", func() {
 
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[0].(push.DeployStartedEvent)
 						Expect(event.Auth.Username).To(Equal("myuser"))
@@ -713,7 +1120,7 @@ var _ = Describe("RunDeployment", func() {
 
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[0].(push.DeployStartedEvent)
 						Expect(event.Body).ToNot(BeNil())
@@ -728,14 +1135,14 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Environment = environment
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 						Expect(eventManager.EmitCall.Received.Events[2].Type).Should(Equal(constants.DeployFinishEvent))
 					})
 					It("calls EmitEvent", func() {
 						deployment.CFContext.Environment = environment
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						Expect(eventManager.EmitEventCall.Received.Events[2].Name()).To(Equal(push.DeployFinishedEvent{}.Name()))
 					})
@@ -746,7 +1153,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Organization = org
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						deploymentInfo := eventManager.EmitCall.Received.Events[2].Data.(*structs.DeployEventData).DeploymentInfo
 						Expect(deploymentInfo.AppName).To(Equal(appName))
@@ -762,7 +1169,7 @@ var _ = Describe("RunDeployment", func() {
 
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[2].(push.DeployFinishedEvent)
 						Expect(event.CFContext.Environment).To(Equal(environment))
@@ -779,7 +1186,7 @@ var _ = Describe("RunDeployment", func() {
 
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[2].(push.DeployFinishedEvent)
 						Expect(event.Auth.Username).To(Equal("myuser"))
@@ -794,7 +1201,7 @@ var _ = Describe("RunDeployment", func() {
 
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[2].(push.DeployFinishedEvent)
 						Expect(event.Body).ToNot(BeNil())
@@ -809,7 +1216,7 @@ var _ = Describe("RunDeployment", func() {
 
 							eventManager.EmitCall.Returns.Error = []error{nil, nil, errors.New("a test error")}
 
-							deploymentResponse := controller.RunDeployment(&deployment, response)
+							deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 							Expect(reflect.TypeOf(deploymentResponse.Error)).Should(Equal(reflect.TypeOf(bluegreen.FinishDeployError{})))
 						})
@@ -821,7 +1228,7 @@ var _ = Describe("RunDeployment", func() {
 
 							eventManager.EmitEventCall.Returns.Error = []error{nil, nil, errors.New("a test error")}
 
-							deploymentResponse := controller.RunDeployment(&deployment, response)
+							deploymentResponse := controller.RunDeployment(context.Background(), &deployment, response)
 
 							Expect(reflect.TypeOf(deploymentResponse.Error)).Should(Equal(reflect.TypeOf(bluegreen.FinishDeployError{})))
 						})
@@ -832,14 +1239,14 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Environment = environment
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 						Expect(eventManager.EmitCall.Received.Events[1].Type).Should(Equal(constants.DeploySuccessEvent))
 					})
 					It("calls EmitEvent", func() {
 						deployment.CFContext.Environment = environment
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						Expect(eventManager.EmitEventCall.Received.Events[1].Name()).To(Equal(push.DeploySuccessEvent{}.Name()))
 					})
@@ -850,7 +1257,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Organization = org
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						deploymentInfo := eventManager.EmitCall.Received.Events[1].Data.(*structs.DeployEventData).DeploymentInfo
 						Expect(deploymentInfo.AppName).To(Equal(appName))
@@ -866,7 +1273,7 @@ var _ = Describe("RunDeployment", func() {
 
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[1].(push.DeploySuccessEvent)
 						Expect(event.CFContext.Environment).To(Equal(environment))
@@ -883,7 +1290,7 @@ var _ = Describe("RunDeployment", func() {
 
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[1].(push.DeploySuccessEvent)
 						Expect(event.Auth.Username).To(Equal("myuser"))
@@ -898,7 +1305,7 @@ var _ = Describe("RunDeployment", func() {
 
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[1].(push.DeploySuccessEvent)
 						Expect(event.Body).ToNot(BeNil())
@@ -910,7 +1317,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Environment = environment
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 						Eventually(logBuffer).Should(Say("emitting a deploy.success event"))
 					})
 					Context("when Emit fails", func() {
@@ -920,7 +1327,7 @@ var _ = Describe("RunDeployment", func() {
 
 							eventManager.EmitCall.Returns.Error = []error{nil, errors.New("a test error"), nil}
 
-							controller.RunDeployment(&deployment, response)
+							controller.RunDeployment(context.Background(), &deployment, response)
 							Eventually(logBuffer).Should(Say("an error occurred when emitting a deploy.success event"))
 						})
 					})
@@ -931,7 +1338,7 @@ var _ = Describe("RunDeployment", func() {
 
 							eventManager.EmitEventCall.Returns.Error = []error{nil, errors.New("a test error")}
 
-							controller.RunDeployment(&deployment, response)
+							controller.RunDeployment(context.Background(), &deployment, response)
 
 							Eventually(logBuffer).Should(Say("an error occurred when emitting a DeploySuccessEvent"))
 						})
@@ -944,7 +1351,7 @@ var _ = Describe("RunDeployment", func() {
 
 						eventManager.EmitCall.Returns.Error = []error{errors.New("a test error"), nil, nil}
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 						Expect(eventManager.EmitCall.Received.Events[1].Type).Should(Equal(constants.DeployFailureEvent))
 					})
 					It("calls EmitEvent", func() {
@@ -953,7 +1360,7 @@ var _ = Describe("RunDeployment", func() {
 
 						eventManager.EmitEventCall.Returns.Error = []error{errors.New("a test error"), nil, nil}
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						Expect(eventManager.EmitEventCall.Received.Events[1].Name()).To(Equal(push.DeployFailureEvent{}.Name()))
 					})
@@ -964,7 +1371,7 @@ var _ = Describe("RunDeployment", func() {
 						deployment.CFContext.Organization = org
 						deployment.Type.ZIP = true
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						deploymentInfo := eventManager.EmitCall.Received.Events[1].Data.(*structs.DeployEventData).DeploymentInfo
 						Expect(deploymentInfo.AppName).To(Equal(appName))
@@ -981,7 +1388,7 @@ var _ = Describe("RunDeployment", func() {
 
 						eventManager.EmitEventCall.Returns.Error = []error{errors.New("a test error"), nil, nil}
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[1].(push.DeployFailureEvent)
 						Expect(event.CFContext.Environment).To(Equal(environment))
@@ -999,7 +1406,7 @@ var _ = Describe("RunDeployment", func() {
 
 						eventManager.EmitEventCall.Returns.Error = []error{errors.New("a test error"), nil, nil}
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[1].(push.DeployFailureEvent)
 						Expect(event.Auth.Username).To(Equal("myuser"))
@@ -1014,7 +1421,7 @@ var _ = Describe("RunDeployment", func() {
 
 						eventManager.EmitEventCall.Returns.Error = []error{errors.New("a test error"), nil, nil}
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 
 						event := eventManager.EmitEventCall.Received.Events[1].(push.DeployFailureEvent)
 						Expect(event.Body).ToNot(BeNil())
@@ -1028,7 +1435,7 @@ var _ = Describe("RunDeployment", func() {
 
 						eventManager.EmitEventCall.Returns.Error = []error{errors.New("a test error"), nil, nil}
 
-						controller.RunDeployment(&deployment, response)
+						controller.RunDeployment(context.Background(), &deployment, response)
 						Eventually(logBuffer).Should(Say("emitting a deploy.failure event"))
 					})
 					Context("when Emit fails", func() {
@@ -1038,7 +1445,7 @@ var _ = Describe("RunDeployment", func() {
 
 							eventManager.EmitCall.Returns.Error = []error{errors.New("a test error"), errors.New("a test error"), nil}
 
-							controller.RunDeployment(&deployment, response)
+							controller.RunDeployment(context.Background(), &deployment, response)
 							Eventually(logBuffer).Should(Say("an error occurred when emitting a deploy.failure event"))
 						})
 					})
@@ -1049,7 +1456,7 @@ var _ = Describe("RunDeployment", func() {
 
 							eventManager.EmitEventCall.Returns.Error = []error{errors.New("a test error"), errors.New("a test error"), nil}
 
-							controller.RunDeployment(&deployment, response)
+							controller.RunDeployment(context.Background(), &deployment, response)
 
 							Eventually(logBuffer).Should(Say("an error occurred when emitting a DeployFailureEvent"))
 						})
@@ -1065,7 +1472,7 @@ var _ = Describe("RunDeployment", func() {
 					retError := error_finder.CreateLogMatchedError("a description", []string{"some details"}, "a solution", "a code")
 					errorFinder.FindErrorsCall.Returns.Errors = []I.LogMatchedError{retError}
 
-					controller.RunDeployment(&deployment, response)
+					controller.RunDeployment(context.Background(), &deployment, response)
 					responseBytes, _ := ioutil.ReadAll(response)
 					Eventually(string(responseBytes)).Should(ContainSubstring("The following error was found in the above logs: a description"))
 					Eventually(string(responseBytes)).Should(ContainSubstring("Error: some details"))
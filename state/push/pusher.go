@@ -2,8 +2,13 @@
 package push
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
+	"time"
 
 	C "github.com/compozed/deployadactyl/constants"
 	I "github.com/compozed/deployadactyl/interfaces"
@@ -15,6 +20,40 @@ import (
 // not overide the existing application name.
 const TemporaryNameSuffix = "-new-build-"
 
+// DigestLabel and DeploymentLabel are the labels set on every pushed application, so the
+// deployment history of a running application can be found from Cloud Foundry.
+const (
+	DigestLabel     = "deployadactyl.io/artifact-digest"
+	DeploymentLabel = "deployadactyl.io/deployment"
+)
+
+// DeleteAfterLabel is set on a replaced application to the unix time it is deleted at.
+const DeleteAfterLabel = "deployadactyl.io/delete-after"
+
+// MetadataServiceSuffix is appended to the name of the application to name the user provided service that holds
+// the deployment metadata in environments with a metadata service.
+const MetadataServiceSuffix = "-deployadactyl-metadata"
+
+// VenerableSuffix is appended to the name of the original application when it is kept
+// until the crash watch of an auto rollback environment is over.
+const VenerableSuffix = "-venerable"
+
+// ReplacedSuffix is appended, with the UUID of the deployment, to the name of the original application when it is
+// kept stopped until the retention period of the environment is over.
+const ReplacedSuffix = "-replaced-"
+
+// DefaultCrashWatchInterval is how often a promoted application is checked for crashes.
+const DefaultCrashWatchInterval = 10 * time.Second
+
+// DefaultTaskTimeout is how long the pre-promotion task of an environment is given when it sets no timeout.
+const DefaultTaskTimeout = 600 * time.Second
+
+// DefaultTaskPollInterval is how often a running task is checked.
+const DefaultTaskPollInterval = 5 * time.Second
+
+// maxLabelValueLength is the longest label value Cloud Foundry accepts.
+const maxLabelValueLength = 63
+
 // Pusher has a courier used to push applications to Cloud Foundry.
 // It represents logging into a single foundation to perform operations.
 type Pusher struct {
@@ -29,10 +68,89 @@ type Pusher struct {
 	Fetcher        I.Fetcher
 	CFContext      I.CFContext
 	Auth           I.Authorization
+	Pipeline       *Pipeline
+	HealthChecks   *S.HealthCheckReport
+	CrashWatch     *S.CrashWatchReport
+	HealthChecker  I.ApplicationHealthChecker
+	PromotionGate  I.PromotionGate
+	Evidence       *S.DeploymentEvidenceReport
+	PollInterval   time.Duration
+	Progress       I.ProgressReporter
+	QuotaUsage     *S.QuotaUsageReport
+	Janitor        I.Janitor
+}
+
+// Initially runs the steps of the initially phase, which logs into a Cloud Foundry instance.
+func (p Pusher) Initially(ctx context.Context) error {
+	return p.pipeline().run(ctx, InitiallyPhase, p)
+}
+
+// Verify runs the steps of the verify phase, which is run on every foundation once every foundation has executed.
+// By default it checks the load balanced route is mapped to the new build.
+func (p Pusher) Verify(ctx context.Context) error {
+	return p.pipeline().run(ctx, VerifyPhase, p)
+}
+
+// Execute runs the steps of the execute phase.
+// By default it pushes a single application to a Cloud Foundry instance using blue green deployment.
+// Blue green is done by pushing a new application with the appName+TemporaryNameSuffix+UUID.
+// It pushes the new application with the existing appName route.
+// It will map a load balanced domain if provided in the config.yml.
+//
+// Returns Cloud Foundry logs if there is an error.
+func (p Pusher) Execute(ctx context.Context) error {
+	return p.pipeline().run(ctx, ExecutePhase, p)
+}
+
+// Promote runs the steps of the promote phase, which is run on every foundation once the canaries of an
+// environment deploying with the canary strategy baked. By default it has no steps.
+func (p Pusher) Promote(ctx context.Context) error {
+	return p.pipeline().run(ctx, PromotePhase, p)
+}
+
+// Success runs the steps of the success phase.
+// By default it will delete the original application if it existed. It will always
+// rename the the newly pushed application to the appName.
+func (p Pusher) Success(ctx context.Context) error {
+	return p.pipeline().run(ctx, SuccessPhase, p)
 }
 
-// Login will login to a Cloud Foundry instance.
-func (p Pusher) Initially() error {
+// Undo runs the steps of the undo phase and is only called when an Execute fails.
+func (p Pusher) Undo(ctx context.Context) error {
+	return p.pipeline().run(ctx, UndoPhase, p)
+}
+
+// Finally runs the steps of the finally phase, which removes the temporary directory created by the Executor.
+func (p Pusher) Finally(ctx context.Context) error {
+	return p.pipeline().run(ctx, FinallyPhase, p)
+}
+
+func (p Pusher) pipeline() *Pipeline {
+	if p.Pipeline == nil {
+		return NewPipeline()
+	}
+	return p.Pipeline
+}
+
+func (p Pusher) tempAppWithUUID() string {
+	return p.DeploymentInfo.AppName + TemporaryNameSuffix + p.DeploymentInfo.UUID
+}
+
+func (p Pusher) venerableAppName() string {
+	return p.DeploymentInfo.AppName + VenerableSuffix
+}
+
+func (p Pusher) replacedAppName() string {
+	return p.DeploymentInfo.AppName + ReplacedSuffix + p.DeploymentInfo.UUID
+}
+
+// autoRollback reports whether the original application is kept until the crash watch is over.
+func (p Pusher) autoRollback() bool {
+	return p.Environment.AutoRollback && p.Environment.CrashWatchSeconds > 0
+}
+
+// login will login to a Cloud Foundry instance.
+func (p Pusher) login() error {
 	p.Log.Debugf(
 		`logging into cloud foundry with parameters:
 		foundation URL: %+v
@@ -61,36 +179,271 @@ func (p Pusher) Initially() error {
 	return nil
 }
 
-// Push pushes a single application to a Clound Foundry instance using blue green deployment.
-// Blue green is done by pushing a new application with the appName+TemporaryNameSuffix+UUID.
-// It pushes the new application with the existing appName route.
-// It will map a load balanced domain if provided in the config.yml.
-//
-// Returns Cloud Foundry logs if there is an error.
+func (p Pusher) pushTempApplication() error {
+	return p.pushApplication(p.tempAppWithUUID(), p.AppPath)
+}
+
+// MetadataServiceName returns the name of the metadata service of an application.
+func MetadataServiceName(appName string) string {
+	return appName + MetadataServiceSuffix
+}
+
+// createMetadataService creates or updates the user provided service holding the deployment metadata, which the
+// manifest binds to the new application when it is pushed.
+func (p Pusher) createMetadataService() error {
+	if !p.Environment.MetadataService {
+		return nil
+	}
 
-func (p Pusher) Verify() error {
+	credentials, err := json.Marshal(map[string]string{
+		"uuid":            p.DeploymentInfo.UUID,
+		"environment":     p.DeploymentInfo.Environment,
+		"artifact_url":    p.DeploymentInfo.ArtifactURL,
+		"artifact_digest": p.DeploymentInfo.ArtifactDigest,
+		"version":         p.DeploymentInfo.Metadata[S.VersionMetadataKey],
+		"deployed_at":     time.Now().UTC().Format(time.RFC3339),
+		"deployed_by":     p.DeploymentInfo.User(),
+	})
+	if err != nil {
+		return state.MetadataServiceError{Service: MetadataServiceName(p.DeploymentInfo.AppName), Out: []byte(err.Error())}
+	}
+
+	serviceName := MetadataServiceName(p.DeploymentInfo.AppName)
+
+	var out []byte
+	if p.Courier.ServiceExists(serviceName) {
+		out, err = p.Courier.Uups(serviceName, string(credentials))
+	} else {
+		out, err = p.Courier.Cups(serviceName, string(credentials))
+	}
+	if err != nil {
+		p.Log.Errorf("could not write the metadata service %s: %s: %s", serviceName, err, string(out))
+		return state.MetadataServiceError{Service: serviceName, Out: out}
+	}
+
+	p.Log.Infof("wrote the deployment metadata to %s", serviceName)
 	return nil
 }
 
-func (p Pusher) Execute() error {
+// labelApplication stamps the artifact digest and the metadata of the deployment on the new application.
+// Older Cloud Foundry CLIs do not have labels, so a failure is only reported.
+func (p Pusher) labelApplication() error {
+	labels := state.MetadataLabels(p.DeploymentInfo.Metadata)
 
-	var (
-		tempAppWithUUID = p.DeploymentInfo.AppName + TemporaryNameSuffix + p.DeploymentInfo.UUID
-		err             error
-	)
+	if p.DeploymentInfo.ArtifactDigest != "" {
+		// Label values can not contain a colon and are too short for a full sha256 digest.
+		digest := strings.Replace(p.DeploymentInfo.ArtifactDigest, ":", "-", 1)
+		if len(digest) > maxLabelValueLength {
+			digest = digest[:maxLabelValueLength]
+		}
+		labels[DigestLabel] = digest
+		labels[DeploymentLabel] = p.DeploymentInfo.UUID
+	}
+
+	if len(labels) == 0 {
+		return nil
+	}
 
-	err = p.pushApplication(tempAppWithUUID, p.AppPath)
+	appName := p.tempAppWithUUID()
+	out, err := p.Courier.SetLabel(appName, labels)
 	if err != nil {
-		return err
+		p.Log.Errorf("could not label %s: %s: %s", appName, err, string(out))
+		fmt.Fprintf(p.Response, "\ncould not set the labels of %s: %s\n", appName, string(out))
+		return nil
+	}
+
+	p.Log.Infof("labelled %s with %d labels", appName, len(labels))
+	return nil
+}
+
+// runPrePromotionTask runs the pre-promotion task of the environment on the new build and waits for it to finish,
+// before the new build serves any traffic.
+func (p Pusher) runPrePromotionTask(ctx context.Context) error {
+	descriptor := p.Environment.PrePromotionTask
+	if !descriptor.Enabled() || !runsOnFoundation(descriptor.Foundations, p.FoundationURL) {
+		return nil
+	}
+
+	name := descriptor.Name
+	if name == "" {
+		name = "pre-promotion"
+	}
+	timeout := time.Duration(descriptor.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultTaskTimeout
+	}
+	interval := p.PollInterval
+	if interval <= 0 {
+		interval = DefaultTaskPollInterval
+	}
+
+	appName := p.tempAppWithUUID()
+	p.Log.Infof("running task %s on %s: %s", name, appName, descriptor.Command)
+	fmt.Fprintf(p.Response, "\nrunning task %s on %s\n", name, p.FoundationURL)
+
+	task, err := p.Courier.RunTask(appName, name, descriptor.Command)
+	if err != nil {
+		return state.TaskError{Task: name, Err: err}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for !task.Done() {
+		if !time.Now().Before(deadline) {
+			return state.TaskTimeoutError{Task: name, Timeout: timeout}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		current, err := p.Courier.Task(task.GUID)
+		if err != nil {
+			p.Log.Errorf("could not read the state of task %s: %s", name, err)
+			continue
+		}
+		task = current
+	}
+
+	if task.State == S.TaskFailed {
+		logs, err := p.Courier.Logs(appName)
+		if err == nil {
+			p.Response.Write(logs)
+		}
+		return state.TaskFailedError{Task: name, Reason: task.FailureReason}
+	}
+
+	p.Log.Infof("task %s succeeded on %s", name, appName)
+	fmt.Fprintf(p.Response, "task %s succeeded on %s\n", name, p.FoundationURL)
+	return nil
+}
+
+// runsOnFoundation reports whether a task restricted to the foundations runs on the foundation.
+func runsOnFoundation(foundations []string, foundationURL string) bool {
+	if len(foundations) == 0 {
+		return true
+	}
+	for _, foundation := range foundations {
+		if foundation == foundationURL {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Pusher) mapLoadBalancedDomain() error {
+	if len(p.loadBalancedRoutes()) == 0 {
+		return nil
+	}
+	return p.mapTempAppToLoadBalancedDomain(p.tempAppWithUUID())
+}
+
+// loadBalancedRoutes returns the route of the load balanced domain, and the routes of the environment and of the
+// request, whose hostname defaults to the name of the application. A route is only returned once.
+func (p Pusher) loadBalancedRoutes() []S.Route {
+	var routes []S.Route
+	seen := map[S.Route]bool{}
+	add := func(route S.Route) {
+		if route.Hostname == "" {
+			route.Hostname = p.DeploymentInfo.AppName
+		}
+		if !seen[route] {
+			seen[route] = true
+			routes = append(routes, route)
+		}
 	}
 
 	if p.DeploymentInfo.Domain != "" {
-		err = p.mapTempAppToLoadBalancedDomain(tempAppWithUUID)
+		add(S.Route{Domain: p.DeploymentInfo.Domain})
+	}
+	for _, route := range p.Environment.Routes {
+		add(route)
+	}
+	for _, route := range p.DeploymentInfo.Routes {
+		add(route)
+	}
+
+	return routes
+}
+
+// verifyLoadBalancedRoute checks that the load balanced route is mapped to the new build. The verify phase only
+// starts once every foundation has executed, and no foundation retires its original application before every
+// foundation has verified, so the route is always served by an application on every foundation.
+func (p Pusher) verifyLoadBalancedRoute() error {
+	expected := p.loadBalancedRoutes()
+	if len(expected) == 0 {
+		return nil
+	}
+
+	appName := p.tempAppWithUUID()
+
+	routes, err := p.Courier.AppRoutes(appName)
+	if err != nil {
+		return state.RouteVerificationError{ApplicationName: appName, FoundationURL: p.FoundationURL, Err: err}
+	}
+
+	mapped := map[string]bool{}
+	for _, r := range routes {
+		mapped[r] = true
+	}
+
+	for _, r := range expected {
+		route := r.URL()
+		if !mapped[route] {
+			p.Log.Errorf("route %s is not mapped to %s", route, appName)
+			return state.RouteNotMappedError{ApplicationName: appName, FoundationURL: p.FoundationURL, Route: route}
+		}
+
+		p.Log.Infof("verified route %s is mapped to %s", route, appName)
+		fmt.Fprintf(p.Response, "\nverified route %s is mapped to %s on %s\n", route, appName, p.FoundationURL)
+
+		event := RouteVerifiedEvent{
+			CFContext:     p.CFContext,
+			Auth:          p.Auth,
+			Environment:   p.Environment,
+			Response:      p.Response,
+			FoundationURL: p.FoundationURL,
+			AppName:       appName,
+			Route:         route,
+			Data:          p.DeploymentInfo.Data,
+			Metadata:      p.DeploymentInfo.Metadata,
+			Log:           p.Log,
+		}
+		err = p.EventManager.EmitEvent(event)
 		if err != nil {
 			return err
 		}
 	}
 
+	return nil
+}
+
+// startPromotion emits a PromotionStartedEvent before the original application is retired. The new build is
+// already verified on every foundation, so an error of a handler is only logged.
+func (p Pusher) startPromotion() error {
+	event := PromotionStartedEvent{
+		CFContext:     p.CFContext,
+		Auth:          p.Auth,
+		Environment:   p.Environment,
+		Response:      p.Response,
+		FoundationURL: p.FoundationURL,
+		AppName:       p.DeploymentInfo.AppName,
+		Data:          p.DeploymentInfo.Data,
+		Metadata:      p.DeploymentInfo.Metadata,
+		Log:           p.Log,
+	}
+	err := p.EventManager.EmitEvent(event)
+	if err != nil {
+		p.Log.Errorf("an error occurred when emitting a %s event: %s", event.Name(), err)
+	}
+
+	return nil
+}
+
+func (p Pusher) emitPushFinished() error {
+	tempAppWithUUID := p.tempAppWithUUID()
+
 	p.Log.Debugf("emitting a %s event", C.PushFinishedEvent)
 	pushData := S.PushEventData{
 		AppPath:         p.AppPath,
@@ -101,23 +454,27 @@ func (p Pusher) Execute() error {
 		Response:        p.Response,
 	}
 
-	err = p.EventManager.Emit(I.Event{Type: C.PushFinishedEvent, Data: pushData})
+	err := p.EventManager.Emit(I.Event{Type: C.PushFinishedEvent, Data: pushData})
 	if err != nil {
 		return err
 	}
 	p.Log.Infof("emitted a %s event", C.PushFinishedEvent)
 
 	event := PushFinishedEvent{
-		CFContext:           p.CFContext,
-		Auth:                p.Auth,
-		Response:            p.Response,
-		AppPath:             p.AppPath,
-		FoundationURL:       p.FoundationURL,
-		TempAppWithUUID:     tempAppWithUUID,
-		Data:                p.DeploymentInfo.Data,
-		Courier:             p.Courier,
-		Manifest:            p.DeploymentInfo.Manifest,
-		HealthCheckEndpoint: p.DeploymentInfo.HealthCheckEndpoint,
+		CFContext:            p.CFContext,
+		Auth:                 p.Auth,
+		Response:             p.Response,
+		AppPath:              p.AppPath,
+		FoundationURL:        p.FoundationURL,
+		TempAppWithUUID:      tempAppWithUUID,
+		Log:                  p.Log,
+		Data:                 p.DeploymentInfo.Data,
+		Metadata:             p.DeploymentInfo.Metadata,
+		Courier:              p.Courier,
+		Manifest:             p.DeploymentInfo.Manifest,
+		HealthCheckEndpoint:  p.DeploymentInfo.HealthCheckEndpoint,
+		EnvironmentVariables: p.DeploymentInfo.EnvironmentVariables,
+		HealthChecks:         p.HealthChecks,
 	}
 	err = p.EventManager.EmitEvent(event)
 	if err != nil {
@@ -128,39 +485,121 @@ func (p Pusher) Execute() error {
 	return nil
 }
 
-// FinishPush will delete the original application if it existed. It will always
-// rename the the newly pushed application to the appName.
-func (p Pusher) Success() error {
-	if p.Courier.Exists(p.DeploymentInfo.AppName) {
-		err := p.unMapLoadBalancedRoute()
+// checkPromotionGates evaluates the promotion gates of the environment against the new build, which
+// already serves part of the traffic of the application, and fails the push if one is breached.
+func (p Pusher) checkPromotionGates(ctx context.Context) error {
+	if len(p.Environment.PromotionGates) == 0 || p.PromotionGate == nil {
+		return nil
+	}
+
+	appName := p.tempAppWithUUID()
+	for _, descriptor := range p.Environment.PromotionGates {
+		result, err := p.PromotionGate.Evaluate(ctx, descriptor, appName)
 		if err != nil {
+			p.Log.Errorf("could not check promotion gate %s: %s", descriptor.Name, err)
 			return err
 		}
 
-		err = p.deleteApplication(p.DeploymentInfo.AppName)
+		if descriptor.Breached(result) {
+			breached := state.PromotionGateBreachedError{descriptor.Name, p.FoundationURL, result.Value, result.Max, result.NoData}
+			p.Log.Error(breached)
+			fmt.Fprintf(p.Response, "\n%s\n", breached)
+			return breached
+		}
+
+		p.Log.Infof("promotion gate %s passed with %g", descriptor.Name, result.Value)
+		fmt.Fprintf(p.Response, "\npromotion gate %s passed on %s with %g\n", descriptor.Name, p.FoundationURL, result.Value)
+	}
+
+	return nil
+}
+
+// retireOriginalApplication will unmap the load balanced route from and delete the original application if it existed.
+// With auto rollback the original application is stopped and kept as the venerable application instead, and with a
+// retention period it is stopped and deleted later by the janitor.
+func (p Pusher) retireOriginalApplication(ctx context.Context) error {
+	if !p.Courier.Exists(p.DeploymentInfo.AppName) {
+		return nil
+	}
+
+	err := p.unMapLoadBalancedRoute()
+	if err != nil {
+		return err
+	}
+
+	p.drainOriginalApplication(ctx)
+
+	if p.autoRollback() {
+		return p.retainOriginalApplication()
+	}
+
+	if p.retainReplaced() {
+		return p.replaceApplication(p.DeploymentInfo.AppName)
+	}
+
+	return p.deleteApplication(p.DeploymentInfo.AppName)
+}
+
+// drainOriginalApplication waits for the drain delay of the environment, so every router stops sending requests
+// to the original application and the requests it is serving complete before it is deleted or stopped.
+// The success phase is not cancelled with the deployment, so the drain is only cut short when ctx is done otherwise.
+func (p Pusher) drainOriginalApplication(ctx context.Context) {
+	delay := time.Duration(p.Environment.DrainDelaySeconds) * time.Second
+	if delay <= 0 {
+		return
+	}
+
+	appName := p.DeploymentInfo.AppName
+	p.Log.Infof("draining connections to %s for %s", appName, delay)
+	fmt.Fprintf(p.Response, "\ndraining connections to %s on %s for %s\n", appName, p.FoundationURL, delay)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(delay):
+	}
+}
+
+// retainOriginalApplication renames the original application to the venerable name and stops it,
+// so it can be restored if the promoted application regresses during the crash watch.
+func (p Pusher) retainOriginalApplication() error {
+	appName := p.DeploymentInfo.AppName
+	venerable := p.venerableAppName()
+
+	// A venerable application is only left behind when a previous rollback failed half way.
+	if p.Courier.Exists(venerable) {
+		err := p.deleteApplication(venerable)
 		if err != nil {
 			return err
 		}
 	}
 
-	err := p.renameNewBuildToOriginalAppName()
+	p.Log.Debugf("renaming %s to %s", appName, venerable)
+	out, err := p.Courier.Rename(appName, venerable)
 	if err != nil {
-		return err
+		p.Log.Errorf("could not rename %s to %s", appName, venerable)
+		return state.RenameError{appName, out}
+	}
+
+	p.Log.Debugf("stopping %s", venerable)
+	out, err = p.Courier.Stop(venerable)
+	if err != nil {
+		p.Log.Errorf("could not stop %s", venerable)
+		return state.StopError{venerable, out}
 	}
 
+	p.Log.Infof("kept %s as %s until the crash watch is over", appName, venerable)
 	return nil
 }
 
-// UndoPush is only called when a Push fails. If it is not the first deployment, UndoPush will
-// delete the temporary application that was pushed.
-// If is the first deployment, UndoPush will rename the failed push to have the appName.
-func (p Pusher) Undo() error {
+// rollback will delete the temporary application that was pushed if it is not the first deployment.
+// If is the first deployment, rollback will rename the failed push to have the appName.
+func (p Pusher) rollback(ctx context.Context) error {
 
-	tempAppWithUUID := p.DeploymentInfo.AppName + TemporaryNameSuffix + p.DeploymentInfo.UUID
+	tempAppWithUUID := p.tempAppWithUUID()
 	if !p.Environment.EnableRollback {
 		p.Log.Errorf("Failed to deploy, deployment not rolled back due to EnableRollback=false")
 
-		return p.Success()
+		return p.Success(ctx)
 	} else {
 
 		if p.Courier.Exists(p.DeploymentInfo.AppName) {
@@ -183,8 +622,40 @@ func (p Pusher) Undo() error {
 	return nil
 }
 
-// CleanUp removes the temporary directory created by the Executor.
-func (p Pusher) Finally() error {
+// recordQuotaUsageBefore records the quota usage of the org and space on the foundation before the push.
+func (p Pusher) recordQuotaUsageBefore() error {
+	if usage, ok := p.quotaUsage(); ok {
+		p.QuotaUsage.RecordBefore(p.FoundationURL, usage)
+	}
+	return nil
+}
+
+// recordQuotaUsageAfter records the quota usage of the org and space on the foundation after the push, or after
+// it was rolled back.
+func (p Pusher) recordQuotaUsageAfter() error {
+	if usage, ok := p.quotaUsage(); ok {
+		p.QuotaUsage.RecordAfter(p.FoundationURL, usage)
+	}
+	return nil
+}
+
+// quotaUsage reads the quota usage of the org and space. Usage that cannot be read is left out of the summary
+// of the deployment, which does not fail it.
+func (p Pusher) quotaUsage() (S.OrgSpaceQuotaUsage, bool) {
+	if p.QuotaUsage == nil {
+		return S.OrgSpaceQuotaUsage{}, false
+	}
+
+	usage, err := p.Courier.QuotaUsage(p.DeploymentInfo.Org, p.DeploymentInfo.Space)
+	if err != nil {
+		p.Log.Errorf("cannot read the quota usage: %s", err)
+		return S.OrgSpaceQuotaUsage{}, false
+	}
+	return usage, true
+}
+
+// cleanUp removes the temporary directory created by the Executor.
+func (p Pusher) cleanUp() error {
 	return p.Courier.CleanUp()
 }
 
@@ -221,32 +692,50 @@ func (p Pusher) pushApplication(appName, appPath string) error {
 }
 
 func (p Pusher) mapTempAppToLoadBalancedDomain(appName string) error {
-	p.Log.Debugf("mapping route for %s to %s", p.DeploymentInfo.AppName, p.DeploymentInfo.Domain)
-
-	out, err := p.Courier.MapRoute(appName, p.DeploymentInfo.Domain, p.DeploymentInfo.AppName)
-	if err != nil {
-		p.Log.Errorf("could not map %s to %s", p.DeploymentInfo.AppName, p.DeploymentInfo.Domain)
-		return state.MapRouteError{out}
-	}
+	for _, route := range p.loadBalancedRoutes() {
+		p.Log.Debugf("mapping route for %s to %s", route.Hostname, route.Domain+route.Path)
+
+		var (
+			out []byte
+			err error
+		)
+		if route.Path == "" {
+			out, err = p.Courier.MapRoute(appName, route.Domain, route.Hostname)
+		} else {
+			out, err = p.Courier.MapRouteWithPath(appName, route.Domain, route.Hostname, route.Path)
+		}
+		if err != nil {
+			p.Log.Errorf("could not map %s to %s", route.URL(), appName)
+			return state.MapRouteError{out}
+		}
 
-	p.Log.Infof("application route created: %s.%s", p.DeploymentInfo.AppName, p.DeploymentInfo.Domain)
+		p.Log.Infof("application route created: %s", route.URL())
 
-	fmt.Fprintf(p.Response, "application route created: %s.%s", p.DeploymentInfo.AppName, p.DeploymentInfo.Domain)
+		fmt.Fprintf(p.Response, "application route created: %s", route.URL())
+	}
 
 	return nil
 }
 
 func (p Pusher) unMapLoadBalancedRoute() error {
-	if p.DeploymentInfo.Domain != "" {
-		p.Log.Debugf("unmapping route %s", p.DeploymentInfo.AppName)
-
-		out, err := p.Courier.UnmapRoute(p.DeploymentInfo.AppName, p.DeploymentInfo.Domain, p.DeploymentInfo.AppName)
+	for _, route := range p.loadBalancedRoutes() {
+		p.Log.Debugf("unmapping route %s from %s", route.URL(), p.DeploymentInfo.AppName)
+
+		var (
+			out []byte
+			err error
+		)
+		if route.Path == "" {
+			out, err = p.Courier.UnmapRoute(p.DeploymentInfo.AppName, route.Domain, route.Hostname)
+		} else {
+			out, err = p.Courier.UnmapRouteWithPath(p.DeploymentInfo.AppName, route.Domain, route.Hostname, route.Path)
+		}
 		if err != nil {
-			p.Log.Errorf("could not unmap %s", p.DeploymentInfo.AppName)
+			p.Log.Errorf("could not unmap %s from %s", route.URL(), p.DeploymentInfo.AppName)
 			return state.UnmapRouteError{p.DeploymentInfo.AppName, out}
 		}
 
-		p.Log.Infof("unmapped route %s", p.DeploymentInfo.AppName)
+		p.Log.Infof("unmapped route %s from %s", route.URL(), p.DeploymentInfo.AppName)
 	}
 
 	return nil
@@ -281,3 +770,195 @@ func (p Pusher) renameNewBuildToOriginalAppName() error {
 
 	return nil
 }
+
+// watchForCrashes watches the promoted application for the crash watch window of the environment, and
+// marks the deployment degraded if it starts crashing once traffic lands on it. The application has
+// already replaced the original one, so the deployment is not rolled back unless the environment
+// enables auto rollback, in which case the health check of the application is watched as well.
+func (p Pusher) watchForCrashes(ctx context.Context) error {
+	window := time.Duration(p.Environment.CrashWatchSeconds) * time.Second
+	if window <= 0 {
+		return nil
+	}
+	interval := p.PollInterval
+	if interval <= 0 {
+		interval = DefaultCrashWatchInterval
+	}
+
+	appName := p.DeploymentInfo.AppName
+	endpoint := p.DeploymentInfo.HealthCheckEndpoint
+	checkHealth := p.autoRollback() && p.HealthChecker != nil && endpoint != ""
+	p.Log.Infof("watching app %s for crashes for %s", appName, window)
+
+	promotedAt := time.Now()
+	deadline := promotedAt.Add(window)
+	for {
+		select {
+		case <-ctx.Done():
+			return p.retireVenerableApplication()
+		case <-time.After(interval):
+		}
+
+		crashes, err := p.Courier.CrashCount(appName, promotedAt)
+		if err != nil {
+			p.Log.Errorf("could not read the crashes of app %s: %s", appName, err)
+		} else if crashes > 0 {
+			err = p.reportCrashes(crashes)
+			if p.autoRollback() {
+				return p.rollbackPromotion(fmt.Sprintf("it crashed %d times after it was promoted", crashes))
+			}
+			return err
+		}
+
+		if checkHealth {
+			err = p.HealthChecker.CheckApplication(p.CFContext.Environment, p.FoundationURL, appName, endpoint, p.Log)
+			if err != nil {
+				return p.rollbackPromotion(fmt.Sprintf("its health check failed after it was promoted: %s", err))
+			}
+		}
+
+		if !time.Now().Before(deadline) {
+			break
+		}
+	}
+
+	p.Log.Infof("app %s did not crash after it was promoted", appName)
+	return p.retireVenerableApplication()
+}
+
+// rollbackPromotion restores the venerable application in place of the promoted application,
+// and fails the deployment. Without a venerable application there is nothing to restore.
+func (p Pusher) rollbackPromotion(reason string) error {
+	appName := p.DeploymentInfo.AppName
+	venerable := p.venerableAppName()
+
+	if !p.Courier.Exists(venerable) {
+		p.Log.Errorf("not rolling back app %s because there is no previous application: %s", appName, reason)
+		fmt.Fprintf(p.Response, "\nnot rolling back app %s on %s because there is no previous application: %s\n", appName, p.FoundationURL, reason)
+		return nil
+	}
+
+	p.Log.Errorf("rolling back app %s to the previous application: %s", appName, reason)
+
+	out, err := p.Courier.Start(venerable)
+	if err != nil {
+		p.Log.Errorf("could not start %s", venerable)
+		return state.StartError{venerable, out}
+	}
+
+	if len(p.loadBalancedRoutes()) > 0 {
+		err = p.mapTempAppToLoadBalancedDomain(venerable)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = p.deleteApplication(appName)
+	if err != nil {
+		return err
+	}
+
+	out, err = p.Courier.Rename(venerable, appName)
+	if err != nil {
+		p.Log.Errorf("could not rename %s to %s", venerable, appName)
+		return state.RenameError{venerable, out}
+	}
+
+	p.Log.Infof("rolled back app %s to the previous application", appName)
+	fmt.Fprintf(p.Response, "\nrolled back app %s on %s to the previous application\n", appName, p.FoundationURL)
+
+	return state.PromotionRolledBackError{appName, p.FoundationURL, reason}
+}
+
+// retireVenerableApplication deletes the venerable application once the crash watch is over.
+func (p Pusher) retireVenerableApplication() error {
+	venerable := p.venerableAppName()
+	if !p.autoRollback() || !p.Courier.Exists(venerable) {
+		return nil
+	}
+
+	if p.retainReplaced() {
+		return p.replaceApplication(venerable)
+	}
+
+	return p.deleteApplication(venerable)
+}
+
+// retainReplaced reports whether the replaced application is kept stopped until the retention period is over.
+func (p Pusher) retainReplaced() bool {
+	return p.Environment.DeleteReplacedAfterMinutes > 0 && p.Janitor != nil
+}
+
+// replaceApplication renames the application to the replaced name and stops it, and schedules its deletion once
+// the retention period of the environment is over. Until then a bad promotion can be recovered by starting it.
+func (p Pusher) replaceApplication(appName string) error {
+	replaced := p.replacedAppName()
+	deleteAt := time.Now().Add(time.Duration(p.Environment.DeleteReplacedAfterMinutes) * time.Minute)
+
+	p.Log.Debugf("renaming %s to %s", appName, replaced)
+	out, err := p.Courier.Rename(appName, replaced)
+	if err != nil {
+		p.Log.Errorf("could not rename %s to %s", appName, replaced)
+		return state.RenameError{appName, out}
+	}
+
+	p.Log.Debugf("stopping %s", replaced)
+	out, err = p.Courier.Stop(replaced)
+	if err != nil {
+		p.Log.Errorf("could not stop %s", replaced)
+		return state.StopError{replaced, out}
+	}
+
+	// The label only tells operators when the application goes away, the janitor does not need it.
+	out, err = p.Courier.SetLabel(replaced, map[string]string{DeleteAfterLabel: strconv.FormatInt(deleteAt.Unix(), 10)})
+	if err != nil {
+		p.Log.Errorf("could not label %s: %s: %s", replaced, err, string(out))
+	}
+
+	p.Janitor.Schedule(S.ScheduledDeletion{
+		FoundationURL: p.FoundationURL,
+		Org:           p.DeploymentInfo.Org,
+		Space:         p.DeploymentInfo.Space,
+		AppName:       replaced,
+		DeploymentID:  p.DeploymentInfo.UUID,
+		DeleteAt:      deleteAt,
+		Username:      p.DeploymentInfo.Username,
+		Password:      p.DeploymentInfo.Password,
+		SkipSSL:       p.DeploymentInfo.SkipSSL,
+		CABundle:      p.Environment.CABundle,
+	})
+
+	p.Log.Infof("stopped %s as %s until %s", appName, replaced, deleteAt)
+	fmt.Fprintf(p.Response, "\nstopped %s as %s on %s, it will be deleted at %s\n", appName, replaced, p.FoundationURL, deleteAt.UTC().Format(time.RFC3339))
+
+	return nil
+}
+
+func (p Pusher) reportCrashes(crashes int) error {
+	appName := p.DeploymentInfo.AppName
+	p.Log.Errorf("app %s crashed %d times on %s after it was promoted", appName, crashes, p.FoundationURL)
+	fmt.Fprintf(p.Response, "\napp %s crashed %d times on %s after it was promoted\n", appName, crashes, p.FoundationURL)
+
+	if p.CrashWatch != nil {
+		p.CrashWatch.Add(S.CrashWatchResult{FoundationURL: p.FoundationURL, Crashes: crashes})
+	}
+
+	event := PostDeployCrashDetectedEvent{
+		CFContext:     p.CFContext,
+		Auth:          p.Auth,
+		Environment:   p.Environment,
+		Response:      p.Response,
+		FoundationURL: p.FoundationURL,
+		AppName:       appName,
+		Crashes:       crashes,
+		Data:          p.DeploymentInfo.Data,
+		Metadata:      p.DeploymentInfo.Metadata,
+		Log:           p.Log,
+	}
+	err := p.EventManager.EmitEvent(event)
+	if err != nil {
+		p.Log.Errorf("an error occurred when emitting a %s event: %s", event.Name(), err)
+	}
+
+	return nil
+}
@@ -1,9 +1,13 @@
 package push_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
+	"strings"
+	"time"
 
 	C "github.com/compozed/deployadactyl/constants"
 	"github.com/compozed/deployadactyl/mocks"
@@ -110,7 +114,7 @@ var _ = Describe("Pusher", func() {
 		Context("when login succeeds", func() {
 			It("gives the correct info to the courier", func() {
 
-				Expect(pusher.Initially()).To(Succeed())
+				Expect(pusher.Initially(context.Background())).To(Succeed())
 
 				Expect(courier.LoginCall.Received.FoundationURL).To(Equal(randomFoundationURL))
 				Expect(courier.LoginCall.Received.Username).To(Equal(randomUsername))
@@ -123,7 +127,7 @@ var _ = Describe("Pusher", func() {
 			It("writes the output of the courier to the response", func() {
 				courier.LoginCall.Returns.Output = []byte("login succeeded")
 
-				Expect(pusher.Initially()).To(Succeed())
+				Expect(pusher.Initially(context.Background())).To(Succeed())
 
 				Eventually(response).Should(Say("login succeeded"))
 			})
@@ -134,7 +138,7 @@ var _ = Describe("Pusher", func() {
 				courier.LoginCall.Returns.Output = []byte("login output")
 				courier.LoginCall.Returns.Error = errors.New("login error")
 
-				err := pusher.Initially()
+				err := pusher.Initially(context.Background())
 				Expect(err).To(MatchError(state.LoginError{randomFoundationURL, []byte("login output")}))
 			})
 
@@ -142,7 +146,7 @@ var _ = Describe("Pusher", func() {
 				courier.LoginCall.Returns.Output = []byte("login output")
 				courier.LoginCall.Returns.Error = errors.New("login error")
 
-				err := pusher.Initially()
+				err := pusher.Initially(context.Background())
 				Expect(err).To(HaveOccurred())
 
 				Eventually(response).Should(Say("login output"))
@@ -151,11 +155,20 @@ var _ = Describe("Pusher", func() {
 			It("logs an error", func() {
 				courier.LoginCall.Returns.Error = errors.New("login error")
 
-				err := pusher.Initially()
+				err := pusher.Initially(context.Background())
 				Expect(err).To(HaveOccurred())
 
 				Eventually(logBuffer).Should(Say(fmt.Sprintf("could not login to %s", randomFoundationURL)))
 			})
+
+			It("includes the fields of the logger and the phase in every line", func() {
+				pusher.Log = pusher.Log.WithFields(interfaces.LogFields{interfaces.AppLogField: randomAppName})
+				courier.LoginCall.Returns.Error = errors.New("login error")
+
+				pusher.Initially(context.Background())
+
+				Eventually(logBuffer).Should(Say(fmt.Sprintf("app=%s phase=initially could not login to %s", randomAppName, randomFoundationURL)))
+			})
 		})
 	})
 
@@ -165,7 +178,7 @@ var _ = Describe("Pusher", func() {
 				It("pushes the new app", func() {
 					courier.PushCall.Returns.Output = []byte("push succeeded")
 
-					Expect(pusher.Execute()).To(Succeed())
+					Expect(pusher.Execute(context.Background())).To(Succeed())
 
 					Expect(courier.PushCall.Received.AppName).To(Equal(tempAppWithUUID))
 					Expect(courier.PushCall.Received.AppPath).To(Equal(randomAppPath))
@@ -186,7 +199,7 @@ var _ = Describe("Pusher", func() {
 					fetcher.FetchCall.Returns.AppPath = randomAppPath
 					courier.PushCall.Returns.Error = errors.New("push error")
 
-					err := pusher.Execute()
+					err := pusher.Execute(context.Background())
 
 					Expect(err).To(MatchError(state.PushError{}))
 				})
@@ -197,7 +210,7 @@ var _ = Describe("Pusher", func() {
 					courier.PushCall.Returns.Error = errors.New("push error")
 					courier.LogsCall.Returns.Output = []byte("cf logs")
 
-					Expect(pusher.Execute()).ToNot(Succeed())
+					Expect(pusher.Execute(context.Background())).ToNot(Succeed())
 
 					Eventually(response).Should(Say("push output"))
 					Eventually(response).Should(Say("cf logs"))
@@ -214,7 +227,7 @@ var _ = Describe("Pusher", func() {
 						courier.PushCall.Returns.Error = pushErr
 						courier.LogsCall.Returns.Error = logsErr
 
-						err := pusher.Execute()
+						err := pusher.Execute(context.Background())
 
 						Expect(err).To(MatchError(state.CloudFoundryGetLogsError{pushErr, logsErr}))
 					})
@@ -229,7 +242,7 @@ var _ = Describe("Pusher", func() {
 					courier.PushCall.Returns.Output = []byte("push succeeded")
 					fetcher.FetchFromZipCall.Returns.AppPath = randomAppPath
 
-					Expect(pusher.Execute()).To(Succeed())
+					Expect(pusher.Execute(context.Background())).To(Succeed())
 
 					Expect(courier.PushCall.Received.AppName).To(Equal(tempAppWithUUID))
 					Expect(courier.PushCall.Received.AppPath).To(Equal(randomAppPath))
@@ -253,7 +266,7 @@ var _ = Describe("Pusher", func() {
 					courier.PushCall.Returns.Output = []byte("push succeeded")
 					fetcher.FetchFromZipCall.Returns.AppPath = randomAppPath
 
-					Expect(pusher.Execute()).To(Succeed())
+					Expect(pusher.Execute(context.Background())).To(Succeed())
 
 					Expect(courier.PushCall.Received.AppName).To(Equal(tempAppWithUUID))
 					Expect(courier.PushCall.Received.AppPath).To(Equal(randomAppPath))
@@ -270,11 +283,204 @@ var _ = Describe("Pusher", func() {
 			})
 		})
 
+		Describe("running the pre-promotion task", func() {
+			BeforeEach(func() {
+				pusher.PollInterval = time.Millisecond
+				pusher.Environment.PrePromotionTask = S.TaskDescriptor{Name: "migrate", Command: "bin/migrate"}
+				courier.RunTaskCall.Returns.Task = S.Task{GUID: "task-guid", Name: "migrate", State: "RUNNING"}
+			})
+
+			It("runs the task on the new build and waits for it to succeed", func() {
+				courier.TaskCall.Returns.Tasks = []S.Task{
+					{GUID: "task-guid", State: "RUNNING"},
+					{GUID: "task-guid", State: S.TaskSucceeded},
+				}
+
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.RunTaskCall.Received.AppName).To(Equal(tempAppWithUUID))
+				Expect(courier.RunTaskCall.Received.TaskName).To(Equal("migrate"))
+				Expect(courier.RunTaskCall.Received.Command).To(Equal("bin/migrate"))
+				Expect(courier.TaskCall.Received.GUID).To(Equal("task-guid"))
+				Expect(courier.TaskCall.TimesCalled).To(Equal(2))
+				Eventually(response).Should(Say("task migrate succeeded on " + randomFoundationURL))
+			})
+
+			It("fails the push before the route is mapped when the task fails", func() {
+				courier.TaskCall.Returns.Tasks = []S.Task{{GUID: "task-guid", State: S.TaskFailed, FailureReason: "Exited with status 1"}}
+				courier.LogsCall.Returns.Output = []byte("migration 42 failed")
+
+				err := pusher.Execute(context.Background())
+
+				Expect(err).To(MatchError(state.TaskFailedError{Task: "migrate", Reason: "Exited with status 1"}))
+				Expect(courier.MapRouteCall.TimesCalled).To(Equal(0))
+				Eventually(response).Should(Say("migration 42 failed"))
+			})
+
+			It("fails the push when the task does not finish in time", func() {
+				pusher.Environment.PrePromotionTask.TimeoutSeconds = 1
+				pusher.PollInterval = 100 * time.Millisecond
+
+				err := pusher.Execute(context.Background())
+
+				Expect(err).To(MatchError(state.TaskTimeoutError{Task: "migrate", Timeout: time.Second}))
+			})
+
+			It("keeps waiting when the state of the task cannot be read", func() {
+				courier.TaskCall.Returns.Tasks = []S.Task{{}, {GUID: "task-guid", State: S.TaskSucceeded}}
+				courier.TaskCall.Returns.Errors = []error{errors.New("timeout")}
+
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+				Expect(courier.TaskCall.TimesCalled).To(Equal(2))
+			})
+
+			It("fails the push when the task cannot be started", func() {
+				courier.RunTaskCall.Returns.Error = errors.New("no droplet")
+
+				err := pusher.Execute(context.Background())
+
+				Expect(err).To(MatchError(state.TaskError{Task: "migrate", Err: errors.New("no droplet")}))
+			})
+
+			It("only runs the task on its foundations", func() {
+				pusher.Environment.PrePromotionTask.Foundations = []string{"https://api.other.example.com"}
+
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.RunTaskCall.Received.AppName).To(BeEmpty())
+			})
+		})
+
+		Describe("writing the metadata service", func() {
+			BeforeEach(func() {
+				pusher.Environment.MetadataService = true
+				pusher.DeploymentInfo.Environment = "production"
+				pusher.DeploymentInfo.Metadata = map[string]string{S.VersionMetadataKey: "1.4.2"}
+			})
+
+			It("creates the user provided service before the application is pushed", func() {
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.ServiceExistsCall.Received.ServiceName).To(Equal(randomAppName + MetadataServiceSuffix))
+				Expect(courier.CupsCall.Received.AppName).To(Equal(randomAppName + MetadataServiceSuffix))
+				Expect(courier.UupsCall.Received.AppName).To(BeEmpty())
+
+				credentials := map[string]string{}
+				Expect(json.Unmarshal([]byte(courier.CupsCall.Received.Body), &credentials)).To(Succeed())
+				Expect(credentials["uuid"]).To(Equal(randomUUID))
+				Expect(credentials["environment"]).To(Equal("production"))
+				Expect(credentials["artifact_url"]).To(Equal(randomArtifactUrl))
+				Expect(credentials["version"]).To(Equal("1.4.2"))
+				Expect(credentials["deployed_by"]).To(Equal(randomUsername))
+				Expect(credentials).To(HaveKey("deployed_at"))
+			})
+
+			It("updates the user provided service when it exists", func() {
+				courier.ServiceExistsCall.Returns.Bool = true
+
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.UupsCall.Received.AppName).To(Equal(randomAppName + MetadataServiceSuffix))
+				Expect(courier.CupsCall.Received.AppName).To(BeEmpty())
+			})
+
+			It("fails the push when the service cannot be written", func() {
+				courier.CupsCall.Returns.Error = errors.New("exit status 1")
+				courier.CupsCall.Returns.Output = []byte("not authorized")
+
+				err := pusher.Execute(context.Background())
+
+				Expect(err).To(MatchError(state.MetadataServiceError{Service: randomAppName + MetadataServiceSuffix, Out: []byte("not authorized")}))
+				Expect(courier.PushCall.Received.AppName).To(BeEmpty())
+			})
+
+			It("does nothing without a metadata service", func() {
+				pusher.Environment.MetadataService = false
+
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.CupsCall.Received.AppName).To(BeEmpty())
+			})
+		})
+
+		Describe("labelling the temporary application", func() {
+			It("sets the artifact digest label", func() {
+				pusher.DeploymentInfo.ArtifactDigest = "sha256:" + strings.Repeat("a", 64)
+
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.SetLabelCall.Received.AppName).To(Equal(tempAppWithUUID))
+				Expect(courier.SetLabelCall.Received.Labels).To(Equal(map[string]string{
+					DigestLabel:     "sha256-" + strings.Repeat("a", 56),
+					DeploymentLabel: randomUUID,
+				}))
+			})
+
+			It("does not fail the push when the label can not be set", func() {
+				pusher.DeploymentInfo.ArtifactDigest = "sha256:abc"
+				courier.SetLabelCall.Returns.Error = errors.New("unknown command")
+				courier.SetLabelCall.Returns.Output = []byte("'set-label' is not a registered command")
+
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Eventually(response).Should(Say("could not set the labels of " + tempAppWithUUID))
+			})
+
+			It("does not label the application without a digest or metadata", func() {
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.SetLabelCall.Received.AppName).To(BeEmpty())
+			})
+
+			It("sets the metadata of the deployment as labels", func() {
+				pusher.DeploymentInfo.ArtifactDigest = "sha256:abc"
+				pusher.DeploymentInfo.Metadata = map[string]string{
+					"pipeline_id": "1234",
+					"commit":      "4f2a9c1",
+				}
+
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.SetLabelCall.Received.AppName).To(Equal(tempAppWithUUID))
+				Expect(courier.SetLabelCall.Received.Labels).To(Equal(map[string]string{
+					DigestLabel:     "sha256-abc",
+					DeploymentLabel: randomUUID,
+					state.MetadataLabelPrefix + "pipeline_id": "1234",
+					state.MetadataLabelPrefix + "commit":      "4f2a9c1",
+				}))
+			})
+
+			It("labels the application with its metadata without a digest", func() {
+				pusher.DeploymentInfo.Metadata = map[string]string{"ticket": "CHG-42"}
+
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.SetLabelCall.Received.Labels).To(Equal(map[string]string{
+					state.MetadataLabelPrefix + "ticket": "CHG-42",
+				}))
+			})
+
+			It("sanitises the metadata for the label rules of Cloud Foundry", func() {
+				pusher.DeploymentInfo.Metadata = map[string]string{
+					"_build url_": "https://ci.example.com/job/42/",
+					"release":     strings.Repeat("v", 70),
+					"!!!":         "dropped",
+				}
+
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.SetLabelCall.Received.Labels).To(Equal(map[string]string{
+					state.MetadataLabelPrefix + "build-url": "https---ci.example.com-job-42",
+					state.MetadataLabelPrefix + "release":   strings.Repeat("v", 63),
+				}))
+			})
+		})
+
 		Describe("mapping the load balanced route to the temporary application", func() {
 			Context("when a domain is provided", func() {
 				It("maps the route to the app", func() {
 					fetcher.FetchCall.Returns.AppPath = randomAppPath
-					Expect(pusher.Execute()).To(Succeed())
+					Expect(pusher.Execute(context.Background())).To(Succeed())
 
 					Expect(courier.MapRouteCall.Received.AppName[0]).To(Equal(randomAppName + TemporaryNameSuffix + randomUUID))
 					Expect(courier.MapRouteCall.Received.Domain[0]).To(Equal(randomDomain))
@@ -285,6 +491,37 @@ var _ = Describe("Pusher", func() {
 				})
 			})
 
+			Context("when there are routes", func() {
+				It("maps every route to the app", func() {
+					fetcher.FetchCall.Returns.AppPath = randomAppPath
+					pusher.Environment.Routes = []S.Route{{Hostname: "www", Domain: "example.com"}}
+					pusher.DeploymentInfo.Routes = []S.Route{{Domain: "example.com", Path: "/search"}, {Hostname: "www", Domain: "example.com"}}
+
+					Expect(pusher.Execute(context.Background())).To(Succeed())
+
+					tempApp := randomAppName + TemporaryNameSuffix + randomUUID
+					Expect(courier.MapRouteCall.Received.AppName).To(Equal([]string{tempApp, tempApp}))
+					Expect(courier.MapRouteCall.Received.Domain).To(Equal([]string{randomDomain, "example.com"}))
+					Expect(courier.MapRouteCall.Received.Hostname).To(Equal([]string{randomAppName, "www"}))
+					Expect(courier.MapRouteWithPathCall.Received.AppName).To(Equal([]string{tempApp}))
+					Expect(courier.MapRouteWithPathCall.Received.Hostname).To(Equal([]string{randomAppName}))
+					Expect(courier.MapRouteWithPathCall.Received.Path).To(Equal([]string{"/search"}))
+
+					Eventually(response).Should(Say("application route created: www.example.com"))
+					Eventually(response).Should(Say(fmt.Sprintf("application route created: %s.example.com/search", randomAppName)))
+				})
+
+				It("maps them without a domain", func() {
+					fetcher.FetchCall.Returns.AppPath = randomAppPath
+					pusher.DeploymentInfo.Domain = ""
+					pusher.DeploymentInfo.Routes = []S.Route{{Hostname: "www", Domain: "example.com"}}
+
+					Expect(pusher.Execute(context.Background())).To(Succeed())
+
+					Expect(courier.MapRouteCall.Received.Domain).To(Equal([]string{"example.com"}))
+				})
+			})
+
 			Context("when a randomDomain is not provided", func() {
 				It("does not map the randomDomain", func() {
 					courier.MapRouteCall.Returns.Output = append(courier.MapRouteCall.Returns.Output, []byte("mapped route"))
@@ -300,7 +537,7 @@ var _ = Describe("Pusher", func() {
 						Fetcher:        fetcher,
 					}
 
-					Expect(pusher.Execute()).To(Succeed())
+					Expect(pusher.Execute(context.Background())).To(Succeed())
 
 					Expect(courier.MapRouteCall.Received.AppName).To(BeEmpty())
 					Expect(courier.MapRouteCall.Received.Domain).To(BeEmpty())
@@ -317,7 +554,7 @@ var _ = Describe("Pusher", func() {
 					courier.MapRouteCall.Returns.Output = append(courier.MapRouteCall.Returns.Output, []byte("unable to map route"))
 					courier.MapRouteCall.Returns.Error = append(courier.MapRouteCall.Returns.Error, errors.New("map route error"))
 
-					err := pusher.Execute()
+					err := pusher.Execute(context.Background())
 					Expect(err).To(MatchError(state.MapRouteError{[]byte("unable to map route")}))
 
 					Expect(courier.MapRouteCall.Received.AppName[0]).To(Equal(randomAppName + TemporaryNameSuffix + randomUUID))
@@ -330,20 +567,20 @@ var _ = Describe("Pusher", func() {
 
 		Context("push.finished event", func() {
 			It("calls Emit", func() {
-				pusher.Execute()
+				pusher.Execute(context.Background())
 
 				Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal("push.finished"))
 			})
 			It("does not return an error", func() {
 				fetcher.FetchCall.Returns.AppPath = randomAppPath
-				Expect(pusher.Execute()).To(Succeed())
+				Expect(pusher.Execute(context.Background())).To(Succeed())
 
 				Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal(C.PushFinishedEvent))
 			})
 
 			It("has the temporary app name on the event", func() {
 				fetcher.FetchCall.Returns.AppPath = randomAppPath
-				Expect(pusher.Execute()).To(Succeed())
+				Expect(pusher.Execute(context.Background())).To(Succeed())
 
 				Expect(eventManager.EmitCall.Received.Events[0].Data.(S.PushEventData).TempAppWithUUID).To(Equal(randomAppName + TemporaryNameSuffix + randomUUID))
 			})
@@ -352,7 +589,7 @@ var _ = Describe("Pusher", func() {
 					fetcher.FetchCall.Returns.AppPath = randomAppPath
 					eventManager.EmitCall.Returns.Error[0] = errors.New("event manager error")
 
-					err := pusher.Execute()
+					err := pusher.Execute(context.Background())
 					Expect(err).To(MatchError("event manager error"))
 				})
 			})
@@ -360,7 +597,7 @@ var _ = Describe("Pusher", func() {
 
 		Context("PushFinishedEvent", func() {
 			It("calls EmitEvent", func() {
-				pusher.Execute()
+				pusher.Execute(context.Background())
 
 				Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).To(Equal(reflect.TypeOf(PushFinishedEvent{})))
 			})
@@ -372,7 +609,7 @@ var _ = Describe("Pusher", func() {
 					Environment:  randomizer.StringRunes(10),
 				}
 
-				pusher.Execute()
+				pusher.Execute(context.Background())
 
 				event := eventManager.EmitEventCall.Received.Events[0].(PushFinishedEvent)
 				Expect(event.CFContext).To(Equal(pusher.CFContext))
@@ -383,7 +620,7 @@ var _ = Describe("Pusher", func() {
 					Password: randomizer.StringRunes(10),
 				}
 
-				pusher.Execute()
+				pusher.Execute(context.Background())
 
 				event := eventManager.EmitEventCall.Received.Events[0].(PushFinishedEvent)
 				Expect(event.Auth).To(Equal(pusher.Auth))
@@ -393,7 +630,7 @@ var _ = Describe("Pusher", func() {
 				pusher.AppPath = randomAppName
 				pusher.FoundationURL = randomFoundationURL
 
-				pusher.Execute()
+				pusher.Execute(context.Background())
 
 				event := eventManager.EmitEventCall.Received.Events[0].(PushFinishedEvent)
 
@@ -402,21 +639,82 @@ var _ = Describe("Pusher", func() {
 				Expect(event.FoundationURL).To(Equal(pusher.FoundationURL))
 				Expect(event.TempAppWithUUID).ToNot(BeNil())
 			})
+			It("provides the environment variables set on the application", func() {
+				pusher.DeploymentInfo.EnvironmentVariables = map[string]string{"FEATURE": "on"}
+
+				pusher.Execute(context.Background())
+
+				event := eventManager.EmitEventCall.Received.Events[0].(PushFinishedEvent)
+				Expect(event.EnvironmentVariables).To(Equal(map[string]string{"FEATURE": "on"}))
+				data := eventManager.EmitCall.Received.Events[0].Data.(S.PushEventData)
+				Expect(data.DeploymentInfo.EnvironmentVariables).To(Equal(map[string]string{"FEATURE": "on"}))
+			})
+			It("provides the logger of the deployment", func() {
+				pusher.Execute(context.Background())
+
+				event := eventManager.EmitEventCall.Received.Events[0].(PushFinishedEvent)
+				Expect(event.Log.Log).To(Equal(pusher.Log.Log))
+				Expect(event.Log.UUID).To(Equal(pusher.Log.UUID))
+			})
 			Context("when Emit fails", func() {
 				It("returns an error", func() {
 					fetcher.FetchCall.Returns.AppPath = randomAppPath
 					eventManager.EmitEventCall.Returns.Error = []error{errors.New("event manager error")}
 
-					err := pusher.Execute()
+					err := pusher.Execute(context.Background())
 					Expect(err).To(MatchError("event manager error"))
 				})
 			})
 		})
+
+		Context("when the environment has promotion gates", func() {
+			var promotionGate *mocks.PromotionGate
+
+			BeforeEach(func() {
+				promotionGate = &mocks.PromotionGate{}
+				pusher.PromotionGate = promotionGate
+				pusher.Environment.PromotionGates = []S.PromotionGateDescriptor{
+					{Name: "error-rate", Max: 0.05},
+					{Name: "latency", Max: 0.5},
+				}
+			})
+
+			It("checks every gate against the new build", func() {
+				promotionGate.EvaluateCall.Returns.Results = []S.PromotionGateResult{
+					{Name: "error-rate", Value: 0.01, Max: 0.05},
+					{Name: "latency", Value: 0.2, Max: 0.5},
+				}
+
+				Expect(pusher.Execute(context.Background())).To(Succeed())
+
+				Expect(promotionGate.EvaluateCall.Received.Descriptors).To(Equal(pusher.Environment.PromotionGates))
+				Expect(promotionGate.EvaluateCall.Received.AppName).To(Equal(tempAppWithUUID))
+				Eventually(response).Should(Say("promotion gate error-rate passed on %s with 0.01", randomFoundationURL))
+				Eventually(response).Should(Say("promotion gate latency passed on %s with 0.2", randomFoundationURL))
+			})
+
+			It("returns an error when a gate is breached", func() {
+				promotionGate.EvaluateCall.Returns.Results = []S.PromotionGateResult{
+					{Name: "error-rate", Value: 0.2, Max: 0.05},
+				}
+
+				err := pusher.Execute(context.Background())
+
+				Expect(err).To(MatchError(state.PromotionGateBreachedError{"error-rate", randomFoundationURL, 0.2, 0.05, false}))
+				Expect(promotionGate.EvaluateCall.TimesCalled).To(Equal(1))
+			})
+
+			It("returns an error when a gate can not be checked", func() {
+				promotionGate.EvaluateCall.Returns.Error = errors.New("query error")
+
+				Expect(pusher.Execute(context.Background())).To(MatchError("query error"))
+			})
+		})
 	})
 
 	Describe("Success", func() {
 		It("renames the newly pushed app to the original name", func() {
-			Expect(pusher.Success()).To(Succeed())
+			Expect(pusher.Success(context.Background())).To(Succeed())
 
 			Expect(courier.RenameCall.Received.AppName).To(Equal(randomAppName + TemporaryNameSuffix + randomUUID))
 			Expect(courier.RenameCall.Received.AppNameVenerable).To(Equal(randomAppName))
@@ -429,7 +727,7 @@ var _ = Describe("Pusher", func() {
 				courier.RenameCall.Returns.Output = []byte("rename output")
 				courier.RenameCall.Returns.Error = errors.New("rename error")
 
-				err := pusher.Success()
+				err := pusher.Success(context.Background())
 				Expect(err).To(MatchError(state.RenameError{randomAppName + TemporaryNameSuffix + randomUUID, []byte("rename output")}))
 
 				Expect(courier.RenameCall.Received.AppName).To(Equal(randomAppName + TemporaryNameSuffix + randomUUID))
@@ -439,19 +737,150 @@ var _ = Describe("Pusher", func() {
 			})
 		})
 
+		Context("when the environment has a crash watch window", func() {
+			BeforeEach(func() {
+				pusher.Environment.CrashWatchSeconds = 1
+				pusher.PollInterval = time.Millisecond
+				pusher.CrashWatch = &S.CrashWatchReport{}
+			})
+
+			It("marks the deployment degraded when the app crashes", func() {
+				courier.CrashCountCall.Returns.Count = 3
+
+				Expect(pusher.Success(context.Background())).To(Succeed())
+
+				Expect(courier.CrashCountCall.Received.AppName).To(Equal(randomAppName))
+				Expect(pusher.CrashWatch.Results()).To(Equal([]S.CrashWatchResult{{FoundationURL: randomFoundationURL, Crashes: 3}}))
+
+				event := eventManager.EmitEventCall.Received.Events[len(eventManager.EmitEventCall.Received.Events)-1].(PostDeployCrashDetectedEvent)
+				Expect(event.AppName).To(Equal(randomAppName))
+				Expect(event.FoundationURL).To(Equal(randomFoundationURL))
+				Expect(event.Crashes).To(Equal(3))
+				Eventually(response).Should(Say("app %s crashed 3 times on %s after it was promoted", randomAppName, randomFoundationURL))
+			})
+
+			It("watches the app for the whole window when it does not crash", func() {
+				pusher.Environment.CrashWatchSeconds = 0
+				Expect(pusher.Success(context.Background())).To(Succeed())
+				Expect(courier.CrashCountCall.TimesCalled).To(Equal(0))
+
+				pusher.Environment.CrashWatchSeconds = 1
+				pusher.PollInterval = 100 * time.Millisecond
+
+				Expect(pusher.Success(context.Background())).To(Succeed())
+
+				Expect(courier.CrashCountCall.TimesCalled).To(BeNumerically(">=", 9))
+				Expect(pusher.CrashWatch.Degraded()).To(BeFalse())
+			})
+
+			It("stops watching when the context is cancelled", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				Expect(pusher.Success(ctx)).To(Succeed())
+
+				Expect(pusher.CrashWatch.Degraded()).To(BeFalse())
+			})
+
+			Context("when the environment enables auto rollback", func() {
+				var healthChecker *mocks.ApplicationHealthChecker
+
+				BeforeEach(func() {
+					healthChecker = &mocks.ApplicationHealthChecker{}
+					pusher.HealthChecker = healthChecker
+					pusher.Environment.AutoRollback = true
+					courier.ExistsCall.Returns.Bool = true
+				})
+
+				It("keeps the original app stopped until the watch is over and then deletes it", func() {
+					pusher.PollInterval = 100 * time.Millisecond
+
+					Expect(pusher.Success(context.Background())).To(Succeed())
+
+					Expect(courier.StopCall.Received.AppName).To(Equal(randomAppName + VenerableSuffix))
+					Expect(courier.StartCall.Received.AppName).To(BeEmpty())
+					Expect(courier.DeleteCall.Received.AppName).To(Equal(randomAppName + VenerableSuffix))
+					Expect(healthChecker.CheckApplicationCall.Received.AppName).To(Equal(randomAppName))
+					Expect(healthChecker.CheckApplicationCall.Received.FoundationURL).To(Equal(randomFoundationURL))
+					Expect(healthChecker.CheckApplicationCall.Received.Endpoint).To(Equal(randomEndpoint))
+				})
+
+				It("keeps the venerable app stopped for the retention period of the environment instead of deleting it", func() {
+					janitor := &mocks.Janitor{}
+					pusher.Janitor = janitor
+					pusher.Environment.DeleteReplacedAfterMinutes = 60
+					pusher.PollInterval = 100 * time.Millisecond
+
+					Expect(pusher.Success(context.Background())).To(Succeed())
+
+					Expect(courier.RenameCall.Received.AppName).To(Equal(randomAppName + VenerableSuffix))
+					Expect(courier.RenameCall.Received.AppNameVenerable).To(Equal(randomAppName + ReplacedSuffix + randomUUID))
+					Expect(janitor.ScheduleCall.Received.Deletions).To(HaveLen(1))
+					Expect(janitor.ScheduleCall.Received.Deletions[0].AppName).To(Equal(randomAppName + ReplacedSuffix + randomUUID))
+				})
+
+				It("restores the original app when the promoted app crashes", func() {
+					courier.CrashCountCall.Returns.Count = 2
+
+					err := pusher.Success(context.Background())
+
+					Expect(err).To(MatchError(state.PromotionRolledBackError{randomAppName, randomFoundationURL, "it crashed 2 times after it was promoted"}))
+					Expect(courier.StartCall.Received.AppName).To(Equal(randomAppName + VenerableSuffix))
+					Expect(courier.MapRouteCall.Received.AppName).To(Equal([]string{randomAppName + VenerableSuffix}))
+					Expect(courier.MapRouteCall.Received.Hostname).To(Equal([]string{randomAppName}))
+					Expect(courier.DeleteCall.Received.AppName).To(Equal(randomAppName))
+					Expect(courier.RenameCall.Received.AppName).To(Equal(randomAppName + VenerableSuffix))
+					Expect(courier.RenameCall.Received.AppNameVenerable).To(Equal(randomAppName))
+					Expect(pusher.CrashWatch.Degraded()).To(BeTrue())
+					Eventually(response).Should(Say("rolled back app %s on %s to the previous application", randomAppName, randomFoundationURL))
+				})
+
+				It("restores the original app when the promoted app fails its health check", func() {
+					healthChecker.CheckApplicationCall.Returns.Error = errors.New("health check error")
+
+					err := pusher.Success(context.Background())
+
+					Expect(err).To(MatchError(state.PromotionRolledBackError{randomAppName, randomFoundationURL, "its health check failed after it was promoted: health check error"}))
+					Expect(courier.StartCall.Received.AppName).To(Equal(randomAppName + VenerableSuffix))
+					Expect(courier.DeleteCall.Received.AppName).To(Equal(randomAppName))
+					Expect(pusher.CrashWatch.Degraded()).To(BeFalse())
+				})
+
+				It("returns an error when the original app can not be stopped", func() {
+					courier.StopCall.Returns.Output = []byte("stop output")
+					courier.StopCall.Returns.Error = errors.New("stop error")
+
+					err := pusher.Success(context.Background())
+
+					Expect(err).To(MatchError(state.StopError{randomAppName + VenerableSuffix, []byte("stop output")}))
+				})
+
+				It("returns an error when the original app can not be restarted", func() {
+					courier.CrashCountCall.Returns.Count = 1
+					courier.StartCall.Returns.Output = []byte("start output")
+					courier.StartCall.Returns.Error = errors.New("start error")
+
+					err := pusher.Success(context.Background())
+
+					Expect(err).To(MatchError(state.StartError{randomAppName + VenerableSuffix, []byte("start output")}))
+					Expect(courier.DeleteCall.Received.AppName).To(Equal(randomAppName + VenerableSuffix))
+				})
+			})
+		})
+
 		Context("when the app exists", func() {
 			BeforeEach(func() {
 				courier.ExistsCall.Returns.Bool = true
 			})
 
 			It("checks the application exists", func() {
-				Expect(pusher.Success()).To(Succeed())
+				Expect(pusher.Success(context.Background())).To(Succeed())
 
 				Expect(courier.ExistsCall.Received.AppName).To(Equal(randomAppName))
 			})
 
 			It("unmaps the load balanced route", func() {
-				Expect(pusher.Success()).To(Succeed())
+				Expect(pusher.Success(context.Background())).To(Succeed())
 
 				Expect(courier.UnmapRouteCall.Received.AppName).To(Equal(randomAppName))
 				Expect(courier.UnmapRouteCall.Received.Domain).To(Equal(randomDomain))
@@ -460,14 +889,121 @@ var _ = Describe("Pusher", func() {
 				Eventually(logBuffer).Should(Say(fmt.Sprintf("unmapped route %s", randomAppName)))
 			})
 
+			It("unmaps every route", func() {
+				pusher.Environment.Routes = []S.Route{{Hostname: "www", Domain: "example.com"}}
+				pusher.DeploymentInfo.Routes = []S.Route{{Hostname: "gateway", Domain: "example.com", Path: "/search"}}
+
+				Expect(pusher.Success(context.Background())).To(Succeed())
+
+				Expect(courier.UnmapRouteCall.Received.Routes).To(Equal([]string{randomAppName + "." + randomDomain, "www.example.com"}))
+				Expect(courier.UnmapRouteWithPathCall.Received.AppName).To(Equal([]string{randomAppName}))
+				Expect(courier.UnmapRouteWithPathCall.Received.Hostname).To(Equal([]string{"gateway"}))
+				Expect(courier.UnmapRouteWithPathCall.Received.Path).To(Equal([]string{"/search"}))
+			})
+
+			It("returns an error when a route cannot be unmapped", func() {
+				pusher.DeploymentInfo.Routes = []S.Route{{Domain: "example.com", Path: "/search"}}
+				courier.UnmapRouteWithPathCall.Returns.Output = []byte("unmap failed")
+				courier.UnmapRouteWithPathCall.Returns.Error = errors.New("unmap failed")
+
+				err := pusher.Success(context.Background())
+
+				Expect(err).To(MatchError(state.UnmapRouteError{randomAppName, []byte("unmap failed")}))
+				Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+			})
+
+			It("emits a PromotionStartedEvent before it retires the original application", func() {
+				Expect(pusher.Success(context.Background())).To(Succeed())
+
+				Expect(eventManager.EmitEventCall.Received.Events).ToNot(BeEmpty())
+				event := eventManager.EmitEventCall.Received.Events[0].(PromotionStartedEvent)
+				Expect(event.FoundationURL).To(Equal(randomFoundationURL))
+				Expect(event.AppName).To(Equal(randomAppName))
+			})
+
 			It("deletes the original application ", func() {
-				Expect(pusher.Success()).To(Succeed())
+				Expect(pusher.Success(context.Background())).To(Succeed())
 
 				Expect(courier.DeleteCall.Received.AppName).To(Equal(randomAppName))
 
 				Eventually(logBuffer).Should(Say(fmt.Sprintf("deleted %s", randomAppName)))
 			})
 
+			Context("when the environment has a drain delay", func() {
+				BeforeEach(func() {
+					pusher.Environment.DrainDelaySeconds = 1
+				})
+
+				It("waits for the drain delay between unmapping the route and deleting the original application", func() {
+					started := time.Now()
+
+					Expect(pusher.Success(context.Background())).To(Succeed())
+
+					Expect(time.Since(started)).To(BeNumerically(">=", time.Second))
+					Expect(courier.DeleteCall.Received.AppName).To(Equal(randomAppName))
+					Expect(response).To(Say(fmt.Sprintf("draining connections to %s", randomAppName)))
+				})
+
+				It("stops waiting when the deployment is cancelled", func() {
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+					started := time.Now()
+
+					pusher.Success(ctx)
+
+					Expect(time.Since(started)).To(BeNumerically("<", time.Second))
+					Expect(courier.DeleteCall.Received.AppName).To(Equal(randomAppName))
+				})
+			})
+
+			Context("when the environment keeps replaced applications", func() {
+				var janitor *mocks.Janitor
+
+				BeforeEach(func() {
+					janitor = &mocks.Janitor{}
+					pusher.Janitor = janitor
+					pusher.Environment.DeleteReplacedAfterMinutes = 60
+					pusher.Environment.CABundle = "/etc/ssl/ca.pem"
+				})
+
+				It("stops the original application and schedules its deletion instead of deleting it", func() {
+					replaced := randomAppName + ReplacedSuffix + randomUUID
+					started := time.Now()
+
+					Expect(pusher.Success(context.Background())).To(Succeed())
+
+					Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+					Expect(courier.RenameCall.Received.AppName).To(Equal(tempAppWithUUID))
+					Expect(courier.StopCall.Received.AppName).To(Equal(replaced))
+					Expect(courier.SetLabelCall.Received.AppName).To(Equal(replaced))
+					Expect(courier.SetLabelCall.Received.Labels).To(HaveKey(DeleteAfterLabel))
+
+					Expect(janitor.ScheduleCall.Received.Deletions).To(HaveLen(1))
+					deletion := janitor.ScheduleCall.Received.Deletions[0]
+					Expect(deletion.FoundationURL).To(Equal(randomFoundationURL))
+					Expect(deletion.Org).To(Equal(randomOrg))
+					Expect(deletion.Space).To(Equal(randomSpace))
+					Expect(deletion.AppName).To(Equal(replaced))
+					Expect(deletion.DeploymentID).To(Equal(randomUUID))
+					Expect(deletion.Username).To(Equal(randomUsername))
+					Expect(deletion.Password).To(Equal(randomPassword))
+					Expect(deletion.CABundle).To(Equal("/etc/ssl/ca.pem"))
+					Expect(deletion.DeleteAt).To(BeTemporally("~", started.Add(time.Hour), time.Minute))
+
+					Expect(response).To(Say(fmt.Sprintf("stopped %s as %s on %s", randomAppName, replaced, randomFoundationURL)))
+				})
+
+				It("returns an error when the original application cannot be stopped", func() {
+					courier.StopCall.Returns.Error = errors.New("stop error")
+					courier.StopCall.Returns.Output = []byte("stop output")
+
+					err := pusher.Success(context.Background())
+
+					Expect(err).To(MatchError(state.StopError{randomAppName + ReplacedSuffix + randomUUID, []byte("stop output")}))
+					Expect(janitor.ScheduleCall.Received.Deletions).To(BeEmpty())
+				})
+			})
+
 			Context("when domain is not provided", func() {
 				It("does not call unmap route", func() {
 					deploymentInfo.Domain = ""
@@ -480,7 +1016,7 @@ var _ = Describe("Pusher", func() {
 						Log:            interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(logBuffer, logging.DEBUG, "pusher_test")},
 					}
 
-					pusher.Success()
+					pusher.Success(context.Background())
 
 					Expect(courier.UnmapRouteCall.Received.AppName).To(BeEmpty())
 					Expect(courier.UnmapRouteCall.Received.Domain).To(BeEmpty())
@@ -493,7 +1029,7 @@ var _ = Describe("Pusher", func() {
 					courier.UnmapRouteCall.Returns.Output = []byte("unmap output")
 					courier.UnmapRouteCall.Returns.Error = errors.New("Unmap Error")
 
-					err := pusher.Success()
+					err := pusher.Success(context.Background())
 					Expect(err).To(MatchError(state.UnmapRouteError{randomAppName, []byte("unmap output")}))
 
 					Eventually(logBuffer).Should(Say(fmt.Sprintf("could not unmap %s", randomAppName)))
@@ -506,7 +1042,7 @@ var _ = Describe("Pusher", func() {
 					courier.DeleteCall.Returns.Output = []byte("delete output")
 					courier.DeleteCall.Returns.Error = errors.New("delete error")
 
-					err := pusher.Success()
+					err := pusher.Success(context.Background())
 					Expect(err).To(MatchError(state.DeleteApplicationError{randomAppName, []byte("delete output")}))
 
 					Eventually(logBuffer).Should(Say(fmt.Sprintf("could not delete %s", randomAppName)))
@@ -518,7 +1054,7 @@ var _ = Describe("Pusher", func() {
 			It("does not delete the non-existant original application", func() {
 				courier.ExistsCall.Returns.Bool = false
 
-				err := pusher.Success()
+				err := pusher.Success(context.Background())
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
@@ -528,6 +1064,55 @@ var _ = Describe("Pusher", func() {
 		})
 	})
 
+	Describe("capturing deployment events", func() {
+		var envelopes []S.LogEnvelope
+
+		BeforeEach(func() {
+			at := time.Unix(1500000000, 0)
+			envelopes = []S.LogEnvelope{
+				{Timestamp: at, SourceType: "RTR", InstanceID: "0", Type: "OUT", Message: `app.example.com - [2017-07-14T02:40:00.000+0000] "GET /health HTTP/1.1" 200 0 2 "-" "curl"`},
+				{Timestamp: at, SourceType: "RTR", InstanceID: "1", Type: "OUT", Message: `app.example.com - [2017-07-14T02:40:00.000+0000] "POST /orders HTTP/1.1" 502 0 67 "-" "curl"`},
+				{Timestamp: at, SourceType: "APP/PROC/WEB", InstanceID: "0", Type: "ERR", Message: "panic: boom"},
+				{Timestamp: at, SourceType: "API", InstanceID: "0", Type: "OUT", Message: `App instance exited with guid abc payload: {"reason"=>"CRASHED"}`},
+			}
+			courier.LogEnvelopesCall.Returns.Envelopes = envelopes
+			pusher.Environment.EventCapture = S.EventCaptureDescriptor{Enabled: true}
+			pusher.Evidence = &S.DeploymentEvidenceReport{}
+		})
+
+		It("keeps the server errors and crashes of the promoted app", func() {
+			Expect(pusher.Success(context.Background())).To(Succeed())
+
+			Expect(courier.LogEnvelopesCall.Received.AppName).To(Equal(randomAppName))
+			Expect(pusher.Evidence.Results()).To(Equal([]S.DeploymentEvidence{{
+				FoundationURL: randomFoundationURL,
+				AppName:       randomAppName,
+				Requests:      2,
+				ServerErrors:  1,
+				Crashes:       1,
+				Excerpts:      []string{strings.TrimSpace(envelopes[1].String()), strings.TrimSpace(envelopes[3].String())},
+			}}))
+			Eventually(response).Should(Say("app %s served 2 requests with 1 server errors and crashed 1 times on %s", randomAppName, randomFoundationURL))
+		})
+
+		It("captures the new build before it is rolled back", func() {
+			pusher.Environment.EventCapture.MaxExcerpts = 1
+
+			Expect(pusher.Undo(context.Background())).To(Succeed())
+
+			Expect(courier.LogEnvelopesCall.Received.AppName).To(Equal(tempAppWithUUID))
+			Expect(pusher.Evidence.Results()[0].Excerpts).To(HaveLen(1))
+		})
+
+		It("does not fail the deployment when the logs can not be read", func() {
+			courier.LogEnvelopesCall.Returns.Error = errors.New("log cache error")
+
+			Expect(pusher.Success(context.Background())).To(Succeed())
+
+			Expect(pusher.Evidence.Results()).To(BeEmpty())
+		})
+	})
+
 	Describe("Undo", func() {
 		Context("when the app exists", func() {
 			BeforeEach(func() {
@@ -535,13 +1120,13 @@ var _ = Describe("Pusher", func() {
 			})
 
 			It("check that the app exists", func() {
-				Expect(pusher.Undo()).To(Succeed())
+				Expect(pusher.Undo(context.Background())).To(Succeed())
 				Expect(courier.ExistsCall.Received.AppName).To(Equal(randomAppName))
 			})
 
 			It("deletes the app that was pushed", func() {
 
-				Expect(pusher.Undo()).To(Succeed())
+				Expect(pusher.Undo(context.Background())).To(Succeed())
 
 				Expect(courier.DeleteCall.Received.AppName).To(Equal(randomAppName + TemporaryNameSuffix + randomUUID))
 
@@ -554,7 +1139,7 @@ var _ = Describe("Pusher", func() {
 					courier.DeleteCall.Returns.Output = []byte("delete call output")
 					courier.DeleteCall.Returns.Error = errors.New("delete error")
 
-					err := pusher.Undo()
+					err := pusher.Undo(context.Background())
 					Expect(err).To(MatchError(state.DeleteApplicationError{tempAppWithUUID, []byte("delete call output")}))
 
 					Eventually(logBuffer).Should(Say(fmt.Sprintf("could not delete %s", tempAppWithUUID)))
@@ -564,7 +1149,7 @@ var _ = Describe("Pusher", func() {
 
 		Context("when the app does not exist", func() {
 			It("renames the newly built app to the intended application name", func() {
-				Expect(pusher.Undo()).To(Succeed())
+				Expect(pusher.Undo(context.Background())).To(Succeed())
 
 				Expect(courier.RenameCall.Received.AppName).To(Equal(randomAppName + TemporaryNameSuffix + randomUUID))
 				Expect(courier.RenameCall.Received.AppNameVenerable).To(Equal(randomAppName))
@@ -577,7 +1162,7 @@ var _ = Describe("Pusher", func() {
 					courier.RenameCall.Returns.Error = errors.New("rename error")
 					courier.RenameCall.Returns.Output = []byte("rename error")
 
-					err := pusher.Undo()
+					err := pusher.Undo(context.Background())
 					Expect(err).To(MatchError(state.RenameError{tempAppWithUUID, []byte("rename error")}))
 
 					Eventually(logBuffer).Should(Say(fmt.Sprintf("could not rename %s to %s", tempAppWithUUID, randomAppName)))
@@ -590,13 +1175,132 @@ var _ = Describe("Pusher", func() {
 		It("is successful", func() {
 			courier.CleanUpCall.Returns.Error = nil
 
-			Expect(pusher.Finally()).To(Succeed())
+			Expect(pusher.Finally(context.Background())).To(Succeed())
+		})
+	})
+
+	Describe("recording the quota usage", func() {
+		var before, after S.OrgSpaceQuotaUsage
+
+		BeforeEach(func() {
+			before = S.OrgSpaceQuotaUsage{Org: S.QuotaUsage{MemoryMB: 1024, Instances: 2}}
+			after = S.OrgSpaceQuotaUsage{Org: S.QuotaUsage{MemoryMB: 2048, Instances: 4}}
+			courier.QuotaUsageCall.Returns.Usages = []S.OrgSpaceQuotaUsage{before, after}
+			pusher.QuotaUsage = &S.QuotaUsageReport{}
+		})
+
+		It("records the usage of the org and space after login and before clean up", func() {
+			Expect(pusher.Initially(context.Background())).To(Succeed())
+			Expect(pusher.Finally(context.Background())).To(Succeed())
+
+			Expect(courier.QuotaUsageCall.Received.Org).To(Equal(randomOrg))
+			Expect(courier.QuotaUsageCall.Received.Space).To(Equal(randomSpace))
+			Expect(pusher.QuotaUsage.Results()).To(Equal([]S.QuotaUsageResult{
+				{FoundationURL: randomFoundationURL, Before: &before, After: &after},
+			}))
+		})
+
+		It("does not fail the deployment when the usage cannot be read", func() {
+			courier.QuotaUsageCall.Returns.Error = errors.New("not authorized")
+
+			Expect(pusher.Initially(context.Background())).To(Succeed())
+
+			Expect(pusher.QuotaUsage.Results()).To(BeEmpty())
+			Eventually(logBuffer).Should(Say("cannot read the quota usage: not authorized"))
+		})
+
+		It("records nothing without a report", func() {
+			pusher.QuotaUsage = nil
+
+			Expect(pusher.Initially(context.Background())).To(Succeed())
+
+			Expect(courier.QuotaUsageCall.TimesCalled).To(Equal(0))
 		})
 	})
 
 	Describe("Verify", func() {
-		It("returns nil", func() {
-			Expect(pusher.Verify()).To(BeNil())
+		var route string
+
+		BeforeEach(func() {
+			route = randomAppName + "." + randomDomain
+		})
+
+		It("verifies the load balanced route is mapped to the new build", func() {
+			courier.AppRoutesCall.Returns.Routes = []string{"other." + randomDomain, route}
+
+			Expect(pusher.Verify(context.Background())).To(Succeed())
+
+			Expect(courier.AppRoutesCall.Received.AppName).To(Equal(tempAppWithUUID))
+			Expect(response).To(Say(fmt.Sprintf("verified route %s is mapped to %s", route, tempAppWithUUID)))
+		})
+
+		It("emits a RouteVerifiedEvent", func() {
+			courier.AppRoutesCall.Returns.Routes = []string{route}
+
+			Expect(pusher.Verify(context.Background())).To(Succeed())
+
+			Expect(eventManager.EmitEventCall.Received.Events).To(HaveLen(1))
+			event := eventManager.EmitEventCall.Received.Events[0].(RouteVerifiedEvent)
+			Expect(event.Route).To(Equal(route))
+			Expect(event.AppName).To(Equal(tempAppWithUUID))
+		})
+
+		It("fails when a handler of the RouteVerifiedEvent fails", func() {
+			courier.AppRoutesCall.Returns.Routes = []string{route}
+			eventManager.EmitEventCall.Returns.Error = []error{errors.New("handler failed")}
+
+			Expect(pusher.Verify(context.Background())).To(MatchError("handler failed"))
+		})
+
+		It("returns an error when the load balanced route is not mapped to the new build", func() {
+			courier.AppRoutesCall.Returns.Routes = []string{"other." + randomDomain}
+
+			err := pusher.Verify(context.Background())
+
+			Expect(err).To(MatchError(state.RouteNotMappedError{
+				ApplicationName: tempAppWithUUID,
+				FoundationURL:   randomFoundationURL,
+				Route:           route,
+			}))
+		})
+
+		It("returns an error when the routes cannot be read", func() {
+			courier.AppRoutesCall.Returns.Error = errors.New("routes error")
+
+			err := pusher.Verify(context.Background())
+
+			Expect(err).To(BeAssignableToTypeOf(state.RouteVerificationError{}))
+		})
+
+		It("verifies every route is mapped to the new build", func() {
+			pusher.DeploymentInfo.Routes = []S.Route{{Hostname: "www", Domain: "example.com", Path: "/search"}}
+			courier.AppRoutesCall.Returns.Routes = []string{route, "www.example.com/search"}
+
+			Expect(pusher.Verify(context.Background())).To(Succeed())
+
+			Expect(eventManager.EmitEventCall.Received.Events).To(HaveLen(2))
+			Expect(eventManager.EmitEventCall.Received.Events[1].(RouteVerifiedEvent).Route).To(Equal("www.example.com/search"))
+		})
+
+		It("returns an error when a route is not mapped to the new build", func() {
+			pusher.Environment.Routes = []S.Route{{Hostname: "www", Domain: "example.com"}}
+			courier.AppRoutesCall.Returns.Routes = []string{route}
+
+			err := pusher.Verify(context.Background())
+
+			Expect(err).To(MatchError(state.RouteNotMappedError{
+				ApplicationName: tempAppWithUUID,
+				FoundationURL:   randomFoundationURL,
+				Route:           "www.example.com",
+			}))
+		})
+
+		It("does nothing without a domain", func() {
+			pusher.DeploymentInfo.Domain = ""
+
+			Expect(pusher.Verify(context.Background())).To(Succeed())
+
+			Expect(courier.AppRoutesCall.Received.AppName).To(BeEmpty())
 		})
 	})
 })
@@ -1,8 +1,11 @@
 package push
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"github.com/cloudfoundry-incubator/candiedyaml"
 	"github.com/compozed/deployadactyl/constants"
 	"github.com/compozed/deployadactyl/controller/deployer"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
@@ -10,9 +13,16 @@ import (
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/state"
 	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 )
 
 const deploymentOutput = `Deployment Parameters:
@@ -49,9 +59,50 @@ type PushManager struct {
 	Auth                 I.Authorization
 	Environment          S.Environment
 	EnvironmentVariables map[string]string
+	Pipeline             *Pipeline
+	Scanner              I.Scanner
+	SBOMGenerator        I.SBOMGenerator
+
+	// HealthChecks collects the health check results of every foundation for the summary of the deployment.
+	HealthChecks *S.HealthCheckReport
+
+	// CrashWatch collects the foundations on which the promoted application started crashing.
+	CrashWatch *S.CrashWatchReport
+
+	// HealthChecker checks the promoted application during the crash watch of auto rollback environments.
+	HealthChecker I.ApplicationHealthChecker
+
+	// PromotionGate evaluates the promotion gates of the environment against the new build.
+	PromotionGate I.PromotionGate
+
+	// Evidence collects what the logs of the new build showed on every foundation.
+	Evidence *S.DeploymentEvidenceReport
+
+	// TempDirectories records the directory of the fetched artifact so it is removed even if CleanUp is never reached.
+	TempDirectories I.TempDirectoryTracker
+
+	// Progress receives the step every foundation is running.
+	Progress I.ProgressReporter
+
+	// FileSystem is used to write the manifest generated from the manifest template of the environment,
+	// and to measure the size of the extracted artifact.
+	FileSystem *afero.Afero
+
+	// Hooks runs the lifecycle hooks of the server at the phases of the push.
+	Hooks I.HookRunner
+
+	// QuotaUsage collects the quota usage of every foundation before and after the push for the summary of the deployment.
+	QuotaUsage *S.QuotaUsageReport
+
+	// Janitor deletes the replaced applications of environments with a retention period once it is over.
+	Janitor I.Janitor
+
+	// ArtifactCache keeps the fetched artifact until the deployment succeeds, so its failed foundations can be
+	// retried with it.
+	ArtifactCache I.ArtifactCache
 }
 
-func (a *PushManager) SetUp() error {
+func (a *PushManager) SetUp(ctx context.Context) error {
 	var (
 		manifestString string
 		instances      *uint16
@@ -59,24 +110,45 @@ func (a *PushManager) SetUp() error {
 		err            error
 	)
 
-	var fetchFn func() (string, error)
+	var (
+		fetchFn  func() (string, error)
+		override string
+	)
+
+	manifestName := a.manifestName()
+	if !validManifestName.MatchString(manifestName) {
+		return state.InvalidManifestNameError{Name: manifestName}
+	}
 
 	if a.DeployEventData.DeploymentInfo.ContentType == "JSON" {
 
-		if a.DeployEventData.DeploymentInfo.Manifest != "" {
-			manifest, err := base64.StdEncoding.DecodeString(a.DeployEventData.DeploymentInfo.Manifest)
+		encodedManifest := a.DeployEventData.DeploymentInfo.Manifest
+		if encodedManifest == "" {
+			encodedManifest = a.DeployEventData.DeploymentInfo.Manifests[manifestName]
+		}
+
+		if encodedManifest != "" {
+			manifestString, err = decodeManifest(encodedManifest)
 			if err != nil {
-				return state.ManifestError{}
+				return err
 			}
-			manifestString = string(manifest)
+		}
+
+		// The artifact keeps its manifest, which the manifest of the request is merged onto once it is fetched.
+		if a.DeployEventData.DeploymentInfo.MergeManifest {
+			override, manifestString = manifestString, ""
 		}
 
 		fetchFn = func() (string, error) {
 			a.Logger.Debug("deploying from json request")
-			appPath, err = a.Fetcher.Fetch(a.DeployEventData.DeploymentInfo.ArtifactURL, manifestString)
-			if err != nil {
-				return "", state.AppPathError{Err: err}
+			appPath, err = a.restoreArtifact()
+			if appPath == "" {
+				appPath, err = a.Fetcher.Fetch(ctx, a.DeployEventData.DeploymentInfo.ArtifactURL, manifestString)
+				if err != nil {
+					return "", state.AppPathError{Err: err}
+				}
 			}
+			a.cacheArtifact(appPath)
 			return appPath, nil
 		}
 	} else {
@@ -98,6 +170,7 @@ func (a *PushManager) SetUp() error {
 		Environment: a.Environment,
 		Response:    a.DeployEventData.Response,
 		Data:        a.DeployEventData.DeploymentInfo.Data,
+		Metadata:    a.DeployEventData.DeploymentInfo.Metadata,
 		Manifest:    manifestString,
 		ArtifactURL: a.DeployEventData.DeploymentInfo.ArtifactURL,
 		Log:         a.Logger,
@@ -111,6 +184,11 @@ func (a *PushManager) SetUp() error {
 		return deployer.EventError{Type: event.Name(), Err: err}
 	}
 
+	err = a.runHook(ctx, S.HookPreFetch, a.DeployEventData.Response, nil)
+	if err != nil {
+		return err
+	}
+
 	appPath, err = fetchFn()
 
 	instances = manifestro.GetInstances(manifestString)
@@ -126,6 +204,7 @@ func (a *PushManager) SetUp() error {
 			Environment: a.Environment,
 			Response:    a.DeployEventData.Response,
 			Data:        a.DeployEventData.DeploymentInfo.Data,
+			Metadata:    a.DeployEventData.DeploymentInfo.Metadata,
 			Manifest:    manifestString,
 			ArtifactURL: a.DeployEventData.DeploymentInfo.ArtifactURL,
 			Log:         a.Logger,
@@ -134,16 +213,112 @@ func (a *PushManager) SetUp() error {
 		return err
 	}
 
+	// Set before scanning so CleanUp removes the artifact when the scan fails.
+	a.DeployEventData.DeploymentInfo.AppPath = appPath
+	if a.TempDirectories != nil {
+		a.TempDirectories.Track(a.DeployEventData.DeploymentInfo.UUID, appPath)
+	}
+	a.DeployEventData.DeploymentInfo.ArtifactSize = a.artifactSize(appPath)
+
+	if a.DeployEventData.DeploymentInfo.ContentType != "JSON" || manifestString == "" {
+		selected, err := a.selectManifest(appPath, manifestName)
+		if _, ok := err.(state.ManifestNotFoundError); ok && override != "" {
+			// The manifest of the request is merged onto the manifest of the artifact instead.
+			err = nil
+		}
+		if err != nil {
+			a.Logger.Error(err)
+			return err
+		}
+		if selected != "" {
+			manifestString = selected
+			if i := manifestro.GetInstances(manifestString); i != nil {
+				instances = i
+			}
+		}
+	}
+
+	if manifestString == "" && a.Environment.ManifestTemplate != "" {
+		manifestString, err = a.generateManifest(appPath)
+		if err != nil {
+			a.Logger.Error(err)
+			return err
+		}
+		if generated := manifestro.GetInstances(manifestString); generated != nil {
+			instances = generated
+		}
+	}
+
+	if override != "" {
+		manifestString, err = a.mergeManifest(appPath, manifestString, override)
+		if err != nil {
+			a.Logger.Error(err)
+			return err
+		}
+		if merged := manifestro.GetInstances(manifestString); merged != nil {
+			instances = merged
+		}
+	}
+
+	overlay := a.Environment.ManifestOverlay
+	if a.Environment.MetadataService {
+		overlay.Services = append([]string{MetadataServiceName(a.DeployEventData.DeploymentInfo.AppName)}, overlay.Services...)
+	}
+	if overlay.Enabled() {
+		manifestString, err = a.overlayManifest(appPath, manifestString, overlay)
+		if err != nil {
+			a.Logger.Error(err)
+			return err
+		}
+		if overlaid := manifestro.GetInstances(manifestString); overlaid != nil {
+			instances = overlaid
+		}
+	}
+
+	if a.Environment.Scanner.Enabled() {
+		err = a.scan(ctx, appPath)
+		if err != nil {
+			a.Logger.Error(err)
+			return err
+		}
+	}
+
+	if a.SBOMGenerator != nil {
+		err = a.inventory(appPath)
+		if err != nil {
+			a.Logger.Error(err)
+			return err
+		}
+	} else if a.DeployEventData.DeploymentInfo.ExpectedArtifactDigest != "" {
+		err = state.ArtifactInventoryError{Err: errors.New("the digest of the artifact cannot be checked without an SBOM generator")}
+		a.Logger.Error(err)
+		return err
+	}
+
+	// The environment variables are set once the artifact is inventoried, so they can be stamped with its digest.
+	variables := a.environmentVariables()
+	if injected := unlessOverlaid(variables, overlay.Env); len(injected) > 0 {
+		manifestString, err = a.injectEnvironmentVariables(appPath, manifestString, injected)
+		if err != nil {
+			a.Logger.Error(err)
+			return err
+		}
+	}
+
+	// The environment variables of the overlay are merged last, so they replace those of the request.
+	variables = finalEnvironmentVariables(variables, overlay.Env)
+
 	event = ArtifactRetrievalSuccessEvent{
 		CFContext:            a.CFContext,
 		Auth:                 a.Auth,
 		Environment:          a.Environment,
 		Response:             a.DeployEventData.Response,
 		Data:                 a.DeployEventData.DeploymentInfo.Data,
+		Metadata:             a.DeployEventData.DeploymentInfo.Metadata,
 		Manifest:             manifestString,
 		ArtifactURL:          a.DeployEventData.DeploymentInfo.ArtifactURL,
 		AppPath:              appPath,
-		EnvironmentVariables: a.EnvironmentVariables,
+		EnvironmentVariables: variables,
 		Log:                  a.Logger,
 	}
 	a.Logger.Debugf("emitting a %s event", event.Name())
@@ -154,16 +329,397 @@ func (a *PushManager) SetUp() error {
 		return deployer.EventError{Type: event.Name(), Err: err}
 	}
 
+	err = a.runHook(ctx, S.HookPostFetch, a.DeployEventData.Response, nil)
+	if err != nil {
+		return err
+	}
+
 	a.DeployEventData.DeploymentInfo.Manifest = manifestString
 	a.DeployEventData.DeploymentInfo.AppPath = appPath
 	a.DeployEventData.DeploymentInfo.Instances = *instances
+	a.DeployEventData.DeploymentInfo.EnvironmentVariables = variables
+
+	return nil
+}
+
+// environmentVariables returns the environment variables of the request with the deployment environment variables
+// the environment stamps. Those of the deployment take precedence, since the request can not know them.
+func (a *PushManager) environmentVariables() map[string]string {
+	if len(a.Environment.StampEnvVars) == 0 {
+		return a.EnvironmentVariables
+	}
+
+	info := a.DeployEventData.DeploymentInfo
+	stamps := map[string]string{
+		S.StampDeploymentUUID: info.UUID,
+		S.StampArtifactURL:    info.ArtifactURL,
+		S.StampArtifactDigest: info.ArtifactDigest,
+		S.StampDeployedAt:     time.Now().UTC().Format(time.RFC3339),
+		S.StampDeployedBy:     info.User(),
+	}
+
+	variables := map[string]string{}
+	for name, value := range a.EnvironmentVariables {
+		variables[name] = value
+	}
+	for _, name := range a.Environment.StampEnvVars {
+		if stamps[name] != "" {
+			variables[name] = stamps[name]
+		}
+	}
+
+	return variables
+}
+
+// finalEnvironmentVariables returns the environment variables set on the application, with those of the overlay
+// replacing the variables of the deployment.
+func finalEnvironmentVariables(variables, overlay map[string]string) map[string]string {
+	if len(overlay) == 0 {
+		return variables
+	}
+
+	final := map[string]string{}
+	for name, value := range variables {
+		final[name] = value
+	}
+	for name, value := range overlay {
+		final[name] = value
+	}
+	return final
+}
+
+// unlessOverlaid returns the environment variables that the overlay, already merged onto the manifest, does not set.
+func unlessOverlaid(variables, overlay map[string]string) map[string]string {
+	if len(overlay) == 0 {
+		return variables
+	}
+
+	remaining := map[string]string{}
+	for name, value := range variables {
+		if _, ok := overlay[name]; !ok {
+			remaining[name] = value
+		}
+	}
+	return remaining
+}
+
+// runHook runs the lifecycle hooks of the phase with the context of the deployment and any additional variables.
+func (a PushManager) runHook(ctx context.Context, phase string, response io.Writer, variables map[string]string) error {
+	if a.Hooks == nil {
+		return nil
+	}
+
+	info := a.DeployEventData.DeploymentInfo
+	deployment := map[string]string{
+		"UUID":            info.UUID,
+		"ENVIRONMENT":     a.Environment.Name,
+		"ORG":             info.Org,
+		"SPACE":           info.Space,
+		"APP_NAME":        info.AppName,
+		"ARTIFACT_URL":    info.ArtifactURL,
+		"ARTIFACT_DIGEST": info.ArtifactDigest,
+		"USERNAME":        info.User(),
+		"APP_PATH":        info.AppPath,
+	}
+	for name, value := range variables {
+		deployment[name] = value
+	}
+
+	return a.Hooks.Run(ctx, phase, deployment, response)
+}
+
+// rolledBack reports whether the push failed after it started and the foundations were rolled back.
+func rolledBack(env S.Environment, err error) bool {
+	if !env.EnableRollback {
+		return false
+	}
+
+	switch e := err.(type) {
+	case bluegreen.PushError, bluegreen.RollbackError:
+		return true
+	case bluegreen.RolloutHaltedError:
+		return rolledBack(env, e.Err)
+	}
+	return false
+}
+
+// validManifestName keeps manifest names from reaching outside of the artifact.
+var validManifestName = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// manifestName returns the name of the manifest selected for the deployment, which defaults to the environment name.
+func (a *PushManager) manifestName() string {
+	if a.DeployEventData.DeploymentInfo.ManifestName != "" {
+		return a.DeployEventData.DeploymentInfo.ManifestName
+	}
+	return a.Environment.Name
+}
+
+// artifactSize returns the size in bytes of the files of the extracted artifact, or 0 when it cannot be measured.
+func (a *PushManager) artifactSize(appPath string) int64 {
+	if a.FileSystem == nil {
+		return 0
+	}
+
+	var size int64
+	err := a.FileSystem.Walk(appPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		a.Logger.Debugf("cannot measure the size of the artifact: %s", err)
+		return 0
+	}
+
+	return size
+}
+
+// selectManifest replaces the manifest of the artifact with its manifest-NAME.yml, if it has one.
+//
+// Returns the selected manifest, which is empty if the artifact has none. A manifest name given in the request
+// must be found.
+func (a *PushManager) selectManifest(appPath, name string) (string, error) {
+	explicit := a.DeployEventData.DeploymentInfo.ManifestName != ""
+	if a.FileSystem == nil || name == "" {
+		if explicit {
+			return "", state.ManifestNotFoundError{Name: name}
+		}
+		return "", nil
+	}
+
+	manifest, err := a.FileSystem.ReadFile(filepath.Join(appPath, "manifest-"+name+".yml"))
+	if os.IsNotExist(err) {
+		if explicit {
+			return "", state.ManifestNotFoundError{Name: name}
+		}
+		return "", nil
+	}
+	if err != nil {
+		return "", state.ManifestSelectionError{Name: name, Err: err}
+	}
+
+	err = a.FileSystem.WriteFile(filepath.Join(appPath, "manifest.yml"), manifest, 0600)
+	if err != nil {
+		return "", state.ManifestSelectionError{Name: name, Err: err}
+	}
+
+	a.Logger.Debugf("selected manifest-%s.yml of the artifact", name)
+	fmt.Fprintf(a.DeployEventData.Response, "\ndeploying with manifest-%s.yml of the artifact\n", name)
+	return string(manifest), nil
+}
+
+// generateManifest writes the manifest of the environment template to the artifact, unless the artifact has one.
+//
+// Returns the manifest written, which is empty if the artifact has one.
+func (a *PushManager) generateManifest(appPath string) (string, error) {
+	manifestPath := filepath.Join(appPath, "manifest.yml")
+
+	exists, err := a.FileSystem.Exists(manifestPath)
+	if err != nil {
+		return "", state.ManifestTemplateError{Err: err}
+	}
+	if exists {
+		return "", nil
+	}
+
+	info := *a.DeployEventData.DeploymentInfo
+	if info.Instances == 0 {
+		info.Instances = a.Environment.Instances
+	}
+
+	manifest, err := manifestro.Generate(a.Environment.ManifestTemplate, info)
+	if err != nil {
+		return "", state.ManifestTemplateError{Err: err}
+	}
+
+	err = a.FileSystem.WriteFile(manifestPath, []byte(manifest), 0600)
+	if err != nil {
+		return "", state.ManifestTemplateError{Err: err}
+	}
+
+	a.Logger.Debugf("generated manifest from the manifest template of %s", a.Environment.Name)
+	fmt.Fprintf(a.DeployEventData.Response, "\nno manifest was provided, generated one from the manifest template of %s\n", a.Environment.Name)
+	return manifest, nil
+}
+
+// decodeManifest returns a manifest of the request, which is base64 encoded or inline YAML.
+func decodeManifest(encoded string) (string, error) {
+	manifest, err := base64.StdEncoding.DecodeString(encoded)
+	if err == nil {
+		return string(manifest), nil
+	}
+
+	// A YAML manifest has colons and spaces, which base64 does not.
+	m := map[interface{}]interface{}{}
+	err = candiedyaml.Unmarshal([]byte(encoded), &m)
+	if err != nil || len(m) == 0 {
+		return "", state.ManifestError{}
+	}
+	return encoded, nil
+}
+
+// mergeManifest merges the manifest of the request onto the manifest, or the manifest of the artifact when there is
+// none, and writes it to the artifact.
+func (a *PushManager) mergeManifest(appPath, manifest, override string) (string, error) {
+	if a.FileSystem == nil {
+		return "", state.ManifestMergeError{Err: errors.New("there is no file system to write the manifest to")}
+	}
+
+	manifestPath := filepath.Join(appPath, "manifest.yml")
+	if manifest == "" {
+		shipped, err := a.FileSystem.ReadFile(manifestPath)
+		if err != nil && !os.IsNotExist(err) {
+			return "", state.ManifestMergeError{Err: err}
+		}
+		manifest = string(shipped)
+	}
+
+	merged, err := manifestro.Merge(manifest, override)
+	if err != nil {
+		return "", state.ManifestMergeError{Err: err}
+	}
+
+	err = a.FileSystem.WriteFile(manifestPath, []byte(merged), 0600)
+	if err != nil {
+		return "", state.ManifestMergeError{Err: err}
+	}
+
+	a.Logger.Debug("merged the manifest of the request onto the manifest of the artifact")
+	fmt.Fprint(a.DeployEventData.Response, "\nmerged the manifest of the request onto the manifest of the artifact\n")
+	return merged, nil
+}
+
+// injectEnvironmentVariables adds the environment variables of the deployment to the env of every application of the
+// manifest, or the manifest of the artifact when the request has none, and writes it to the artifact, so they are set
+// before the application first starts.
+func (a *PushManager) injectEnvironmentVariables(appPath, manifest string, variables map[string]string) (string, error) {
+	if a.FileSystem == nil {
+		return "", state.EnvironmentVariablesError{Err: errors.New("there is no file system to write the manifest to")}
+	}
+
+	manifestPath := filepath.Join(appPath, "manifest.yml")
+	if manifest == "" {
+		shipped, err := a.FileSystem.ReadFile(manifestPath)
+		if err != nil && !os.IsNotExist(err) {
+			return "", state.EnvironmentVariablesError{Err: err}
+		}
+		manifest = string(shipped)
+	}
+
+	injected, err := manifestro.Overlay(manifest, a.DeployEventData.DeploymentInfo.AppName, S.ManifestOverlay{Env: variables})
+	if err != nil {
+		return "", state.EnvironmentVariablesError{Err: err}
+	}
+
+	err = a.FileSystem.WriteFile(manifestPath, []byte(injected), 0600)
+	if err != nil {
+		return "", state.EnvironmentVariablesError{Err: err}
+	}
+
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	a.Logger.Debugf("set the environment variables %s in the manifest", strings.Join(names, ", "))
+	fmt.Fprintf(a.DeployEventData.Response, "\nset the environment variables %s in the manifest\n", strings.Join(names, ", "))
+	return injected, nil
+}
+
+// overlayManifest merges the overlay onto the manifest, or the manifest of the artifact when the request has none,
+// and writes it to the artifact.
+func (a *PushManager) overlayManifest(appPath, manifest string, overlay S.ManifestOverlay) (string, error) {
+	if a.FileSystem == nil {
+		return "", state.ManifestOverlayError{Err: errors.New("there is no file system to write the manifest to")}
+	}
+
+	manifestPath := filepath.Join(appPath, "manifest.yml")
+	if manifest == "" {
+		shipped, err := a.FileSystem.ReadFile(manifestPath)
+		if err != nil && !os.IsNotExist(err) {
+			return "", state.ManifestOverlayError{Err: err}
+		}
+		manifest = string(shipped)
+	}
+
+	merged, err := manifestro.Overlay(manifest, a.DeployEventData.DeploymentInfo.AppName, overlay)
+	if err != nil {
+		return "", state.ManifestOverlayError{Err: err}
+	}
+
+	err = a.FileSystem.WriteFile(manifestPath, []byte(merged), 0600)
+	if err != nil {
+		return "", state.ManifestOverlayError{Err: err}
+	}
+
+	a.Logger.Debugf("merged the manifest overlay of %s", a.Environment.Name)
+	return merged, nil
+}
+
+// scan submits the artifact to the scanner of the environment and records the result on the DeploymentInfo.
+func (a *PushManager) scan(ctx context.Context, appPath string) error {
+	if a.Scanner == nil {
+		return state.ArtifactScanError{Err: errors.New("no scanner is available")}
+	}
+
+	result, err := a.Scanner.Scan(ctx, a.Environment.Scanner, appPath)
+	if err != nil {
+		return state.ArtifactScanError{Err: err}
+	}
+	a.DeployEventData.DeploymentInfo.ScanResult = &result
+
+	if !result.Clean() {
+		fmt.Fprintf(a.DeployEventData.Response, "\nartifact scan reported %d findings:\n", len(result.Findings))
+		for _, finding := range result.Findings {
+			fmt.Fprintf(a.DeployEventData.Response, "  %s: %s\n", finding.Path, finding.Description)
+		}
+		return state.ScanFindingsError{Findings: result.Findings}
+	}
+
+	fmt.Fprintf(a.DeployEventData.Response, "\nartifact scan with %s scanner found nothing\n", result.Scanner)
+	return nil
+}
+
+// inventory records the digest of the artifact and, unless the request provided them, its components.
+// The digest must be the expected one, if there is one.
+func (a *PushManager) inventory(appPath string) error {
+	info := a.DeployEventData.DeploymentInfo
+
+	digest, err := a.SBOMGenerator.Digest(appPath)
+	if err != nil {
+		return state.ArtifactInventoryError{Err: err}
+	}
+	info.ArtifactDigest = digest
+	fmt.Fprintf(a.DeployEventData.Response, "\nartifact digest: %s\n", digest)
+
+	if info.ExpectedArtifactDigest != "" && digest != info.ExpectedArtifactDigest {
+		return state.ArtifactDigestMismatchError{Expected: info.ExpectedArtifactDigest, Actual: digest}
+	}
+
+	if info.SBOM != nil && len(info.SBOM.Components) > 0 {
+		return nil
+	}
+
+	sbom, err := a.SBOMGenerator.Generate(appPath)
+	if err != nil {
+		return state.ArtifactInventoryError{Err: err}
+	}
+	if info.SBOM != nil {
+		sbom.Reference = info.SBOM.Reference
+	}
+	info.SBOM = &sbom
 
 	return nil
 }
 
 func (a PushManager) OnStart() error {
 	info := a.DeployEventData.DeploymentInfo
-	deploymentMessage := fmt.Sprintf(deploymentOutput, info.ArtifactURL, info.Username, info.Environment, info.Org, info.Space, info.AppName)
+	deploymentMessage := fmt.Sprintf(deploymentOutput, info.ArtifactURL, info.User(), info.Environment, info.Org, info.Space, info.AppName)
 
 	a.Logger.Info(deploymentMessage)
 	fmt.Fprintln(a.DeployEventData.Response, deploymentMessage)
@@ -183,6 +739,7 @@ func (a PushManager) OnStart() error {
 		Response:    a.DeployEventData.Response,
 		ContentType: info.ContentType,
 		Data:        info.Data,
+		Metadata:    info.Metadata,
 		Instances:   info.Instances,
 		Log:         a.Logger,
 	}
@@ -192,11 +749,30 @@ func (a PushManager) OnStart() error {
 		err = &bluegreen.InitializationError{err}
 		return deployer.EventError{Type: event.Name(), Err: err}
 	}
-	return nil
+
+	return a.runHook(context.Background(), S.HookPrePush, a.DeployEventData.Response, nil)
 }
 
 func (a PushManager) OnFinish(env S.Environment, response io.ReadWriter, err error) I.DeployResponse {
+	a.writeHealthCheckSummary(response)
+	a.writeQuotaUsageSummary(response)
+
+	if a.Evidence != nil {
+		a.DeployEventData.DeploymentInfo.Evidence = a.Evidence.Results()
+	}
+
+	if err == nil && a.ArtifactCache != nil {
+		removeErr := a.ArtifactCache.Remove(a.DeployEventData.DeploymentInfo.UUID)
+		if removeErr != nil {
+			a.Logger.Errorf("cannot remove the cached artifact: %s", removeErr)
+		}
+	}
+
 	if err != nil {
+		if rolledBack(env, err) {
+			a.runHook(context.Background(), S.HookPostRollback, response, map[string]string{"ERROR": err.Error()})
+		}
+
 		if !env.EnableRollback {
 			a.Logger.Errorf("EnableRollback %t, returning status %d and err %s", env.EnableRollback, http.StatusOK, err)
 			return I.DeployResponse{
@@ -220,9 +796,46 @@ func (a PushManager) OnFinish(env S.Environment, response io.ReadWriter, err err
 	a.Logger.Infof("successfully deployed application %s", a.DeployEventData.DeploymentInfo.AppName)
 	fmt.Fprintf(response, "\n%s", successfulDeploy)
 
+	a.runHook(context.Background(), S.HookPostPromotion, response, nil)
+
+	if a.CrashWatch != nil && a.CrashWatch.Degraded() {
+		a.writeCrashWatchSummary(response)
+		return I.DeployResponse{StatusCode: http.StatusOK, Degraded: true}
+	}
+
 	return I.DeployResponse{StatusCode: http.StatusOK}
 }
 
+// restoreArtifact returns a copy of the cached artifact the deployment deploys, if it has one and it is still cached.
+func (a *PushManager) restoreArtifact() (string, error) {
+	uuid := a.DeployEventData.DeploymentInfo.CachedArtifact
+	if uuid == "" || a.ArtifactCache == nil {
+		return "", nil
+	}
+
+	appPath, err := a.ArtifactCache.Restore(uuid)
+	if err != nil {
+		a.Logger.Infof("fetching the artifact again: %s", err)
+		return "", err
+	}
+
+	a.Logger.Infof("deploying the cached artifact of deployment %s", uuid)
+	fmt.Fprintf(a.DeployEventData.Response, "deploying the cached artifact of deployment %s\n", uuid)
+	return appPath, nil
+}
+
+// cacheArtifact keeps a copy of the artifact of the deployment until it succeeds.
+func (a *PushManager) cacheArtifact(appPath string) {
+	if a.ArtifactCache == nil {
+		return
+	}
+
+	err := a.ArtifactCache.Store(a.DeployEventData.DeploymentInfo.UUID, appPath)
+	if err != nil {
+		a.Logger.Errorf("cannot cache the artifact: %s", err)
+	}
+}
+
 func (a PushManager) CleanUp() {
 	a.FileSystemCleaner.RemoveAll(a.DeployEventData.DeploymentInfo.AppPath)
 }
@@ -234,24 +847,69 @@ func (a PushManager) Create(environment S.Environment, response io.ReadWriter, f
 		a.Logger.Error(err)
 		return &Pusher{}, state.CourierCreationError{Err: err}
 	}
+	if environment.CABundle != "" {
+		courier = courier.WithCABundle(environment.CABundle)
+	}
+	if environment.LogCache.Enabled {
+		courier = courier.WithLogCache(environment.LogCache)
+	}
+
+	pipeline := a.Pipeline
+	if pipeline == nil {
+		pipeline = NewPipeline()
+	}
+	pipeline = pipeline.Copy()
+
+	if environment.Strategy == S.StrategyCanary {
+		err = canaryPipeline(pipeline)
+	}
+	if err == nil {
+		err = pipeline.Apply(environment.PushSteps)
+	}
+	if err != nil {
+		a.Logger.Error(err)
+		courier.CleanUp()
+		return &Pusher{}, err
+	}
 
 	p := &Pusher{
 		Courier:        courier,
 		DeploymentInfo: *a.DeployEventData.DeploymentInfo,
 		EventManager:   a.EventManager,
 		Response:       response,
-		Log:            a.Logger,
+		Log:            a.Logger.WithFields(I.LogFields{I.FoundationLogField: foundationURL, I.AppLogField: a.DeployEventData.DeploymentInfo.AppName}),
 		FoundationURL:  foundationURL,
 		AppPath:        a.DeployEventData.DeploymentInfo.AppPath,
 		Environment:    environment,
 		Fetcher:        a.Fetcher,
 		CFContext:      a.CFContext,
 		Auth:           a.Auth,
+		Pipeline:       pipeline,
+		HealthChecks:   a.HealthChecks,
+		CrashWatch:     a.CrashWatch,
+		HealthChecker:  a.HealthChecker,
+		PromotionGate:  a.PromotionGate,
+		Evidence:       a.Evidence,
+		Progress:       a.Progress,
+		QuotaUsage:     a.QuotaUsage,
+		Janitor:        a.Janitor,
 	}
 
 	return p, nil
 }
 
+// UndoFinishes reports whether the pushes are promoted instead of rolled back, which is the case when the
+// environment does not roll back.
+func (a PushManager) UndoFinishes(environment S.Environment) bool {
+	return !environment.EnableRollback
+}
+
+// Canary reports whether the pushes deploy a canary, which is the case when the environment deploys with the
+// canary strategy.
+func (a PushManager) Canary(environment S.Environment) bool {
+	return environment.Strategy == S.StrategyCanary
+}
+
 func (a PushManager) InitiallyError(initiallyErrors []error) error {
 	return bluegreen.LoginError{LoginErrors: initiallyErrors}
 }
@@ -267,3 +925,108 @@ func (a PushManager) UndoError(executeErrors, undoErrors []error) error {
 func (a PushManager) SuccessError(successErrors []error) error {
 	return bluegreen.FinishPushError{FinishPushError: successErrors}
 }
+
+// writeHealthCheckSummary writes the result and duration of the health check of every foundation.
+func (a PushManager) writeHealthCheckSummary(response io.Writer) {
+	if a.HealthChecks == nil {
+		return
+	}
+	results := a.HealthChecks.Results()
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Fprint(response, "\nHealth Checks:\n")
+	w := tabwriter.NewWriter(response, 0, 4, 2, ' ', 0)
+	for _, result := range results {
+		status := "passed"
+		if !result.Passed() {
+			status = "failed"
+		}
+
+		statusCode := "-"
+		if result.StatusCode != 0 {
+			statusCode = fmt.Sprint(result.StatusCode)
+		}
+
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%.3fs\t%s\n", result.FoundationURL, status, statusCode, result.Duration.Seconds(), result.URL)
+	}
+	w.Flush()
+}
+
+// writeQuotaUsageSummary writes the memory and instances used by the org and space on every foundation before and
+// after the push, with the limits of their quotas.
+func (a PushManager) writeQuotaUsageSummary(response io.Writer) {
+	if a.QuotaUsage == nil {
+		return
+	}
+	results := a.QuotaUsage.Results()
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Fprint(response, "\nQuota Usage:\n")
+	w := tabwriter.NewWriter(response, 0, 4, 2, ' ', 0)
+	for _, result := range results {
+		writeQuotaUsage(w, result.FoundationURL, "org", orgUsage(result.Before), orgUsage(result.After))
+		writeQuotaUsage(w, result.FoundationURL, "space", spaceUsage(result.Before), spaceUsage(result.After))
+	}
+	w.Flush()
+}
+
+func orgUsage(usage *S.OrgSpaceQuotaUsage) *S.QuotaUsage {
+	if usage == nil {
+		return nil
+	}
+	return &usage.Org
+}
+
+func spaceUsage(usage *S.OrgSpaceQuotaUsage) *S.QuotaUsage {
+	if usage == nil {
+		return nil
+	}
+	return &usage.Space
+}
+
+// writeQuotaUsage writes the usage of an org or a space before and after the push. The limits are those after the
+// push, or before it when the usage after it could not be read.
+func writeQuotaUsage(w io.Writer, foundationURL, scope string, before, after *S.QuotaUsage) {
+	limits := after
+	if limits == nil {
+		limits = before
+	}
+
+	memory := func(usage *S.QuotaUsage) string {
+		if usage == nil {
+			return "?"
+		}
+		return fmt.Sprintf("%dM", usage.MemoryMB)
+	}
+	instances := func(usage *S.QuotaUsage) string {
+		if usage == nil {
+			return "?"
+		}
+		return fmt.Sprint(usage.Instances)
+	}
+	limit := func(l *int, unit string) string {
+		if l == nil || *l < 0 {
+			return "unlimited"
+		}
+		return fmt.Sprintf("%d%s", *l, unit)
+	}
+
+	fmt.Fprintf(w, "  %s\t%s\tmemory %s -> %s of %s\tinstances %s -> %s of %s\n",
+		foundationURL, scope,
+		memory(before), memory(after), limit(limits.MemoryLimitMB, "M"),
+		instances(before), instances(after), limit(limits.InstanceLimit, ""))
+}
+
+// writeCrashWatchSummary writes the foundations on which the promoted application started crashing.
+func (a PushManager) writeCrashWatchSummary(response io.Writer) {
+	a.Logger.Errorf("application %s is degraded", a.DeployEventData.DeploymentInfo.AppName)
+
+	fmt.Fprintf(response, "\nYour deployment is degraded, the application crashed after it was promoted:\n")
+	for _, result := range a.CrashWatch.Results() {
+		fmt.Fprintf(response, "  %s  %d crashes\n", result.FoundationURL, result.Crashes)
+	}
+}
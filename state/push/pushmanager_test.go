@@ -1,13 +1,16 @@
 package push_test
 
 import (
+	"context"
 	"bytes"
 	"encoding/base64"
 	"github.com/compozed/deployadactyl/constants"
 	"github.com/compozed/deployadactyl/controller/deployer"
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
 	"github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/mocks"
 	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/state"
 	. "github.com/compozed/deployadactyl/state/push"
 	"github.com/compozed/deployadactyl/structs"
 	"github.com/go-errors/errors"
@@ -20,6 +23,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"time"
 )
 
 var _ = Describe("Actioncreator", func() {
@@ -43,7 +47,7 @@ var _ = Describe("Actioncreator", func() {
 		response = NewBuffer()
 		pusherCreator = &PushManager{
 			Fetcher:      fetcher,
-			Logger:       interfaces.DeploymentLogger{log, randomizer.StringRunes(10)},
+			Logger:       interfaces.DeploymentLogger{Log: log, UUID: randomizer.StringRunes(10)},
 			EventManager: eventManager,
 			DeployEventData: structs.DeployEventData{
 				DeploymentInfo: &structs.DeploymentInfo{},
@@ -72,7 +76,7 @@ applications:
 				}
 				pusherCreator.DeployEventData.DeploymentInfo = &deploymentInfo
 
-				pusherCreator.SetUp()
+				pusherCreator.SetUp(context.Background())
 
 				Expect(pusherCreator.DeployEventData.DeploymentInfo.Manifest).To(Equal(manifest))
 				logBytes, _ := ioutil.ReadAll(logBuffer)
@@ -88,13 +92,73 @@ applications:
 				}
 				pusherCreator.DeployEventData.DeploymentInfo = &deploymentInfo
 
-				pusherCreator.SetUp()
+				pusherCreator.SetUp(context.Background())
 
 				Expect(pusherCreator.DeployEventData.DeploymentInfo.AppPath).To(Equal("newAppPath"))
 				Expect(fetcher.FetchCall.Received.ArtifactURL).To(Equal(deploymentInfo.ArtifactURL))
 				Expect(fetcher.FetchCall.Received.Manifest).To(Equal(manifest))
 
 			})
+			It("tracks the directory of the fetched artifact", func() {
+				fetcher.FetchCall.Returns.AppPath = "newAppPath"
+				tempDirectories := &mocks.TempDirectoryTracker{}
+				pusherCreator.TempDirectories = tempDirectories
+
+				deploymentInfo := structs.DeploymentInfo{
+					UUID:        "uuid-1",
+					Manifest:    encodedManifest,
+					ArtifactURL: "https://artifacturl.com",
+					ContentType: "JSON",
+				}
+				pusherCreator.DeployEventData.DeploymentInfo = &deploymentInfo
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(tempDirectories.TrackCall.Received.DeploymentIDs).To(Equal([]string{"uuid-1"}))
+				Expect(tempDirectories.TrackCall.Received.Paths).To(Equal([]string{"newAppPath"}))
+			})
+			Context("with an artifact cache", func() {
+				var artifactCache *mocks.ArtifactCache
+
+				BeforeEach(func() {
+					artifactCache = &mocks.ArtifactCache{}
+					pusherCreator.ArtifactCache = artifactCache
+					fetcher.FetchCall.Returns.AppPath = "fetchedAppPath"
+				})
+
+				It("caches the fetched artifact", func() {
+					pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{UUID: "uuid-2", Manifest: encodedManifest, ContentType: "JSON"}
+
+					Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+					Expect(artifactCache.StoreCall.Received.UUID).To(Equal("uuid-2"))
+					Expect(artifactCache.StoreCall.Received.AppPath).To(Equal("fetchedAppPath"))
+					Expect(artifactCache.RestoreCall.Called).To(BeFalse())
+				})
+
+				It("deploys the cached artifact of the deployment it retries instead of fetching it", func() {
+					artifactCache.RestoreCall.Returns.AppPath = "restoredAppPath"
+					pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{UUID: "uuid-2", Manifest: encodedManifest, ContentType: "JSON", CachedArtifact: "uuid-1"}
+
+					Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+					Expect(artifactCache.RestoreCall.Received.UUID).To(Equal("uuid-1"))
+					Expect(fetcher.FetchCall.Received.ArtifactURL).To(BeEmpty())
+					Expect(pusherCreator.DeployEventData.DeploymentInfo.AppPath).To(Equal("restoredAppPath"))
+					Expect(artifactCache.StoreCall.Received.UUID).To(Equal("uuid-2"))
+					Eventually(response).Should(Say("deploying the cached artifact of deployment uuid-1"))
+				})
+
+				It("fetches the artifact when it is no longer cached", func() {
+					artifactCache.RestoreCall.Returns.Error = errors.New("not cached")
+					pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{Manifest: encodedManifest, ArtifactURL: "https://artifacturl.com", ContentType: "JSON", CachedArtifact: "uuid-1"}
+
+					Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+					Expect(fetcher.FetchCall.Received.ArtifactURL).To(Equal("https://artifacturl.com"))
+					Expect(pusherCreator.DeployEventData.DeploymentInfo.AppPath).To(Equal("fetchedAppPath"))
+				})
+			})
 			It("should error when artifact cannot be fetched", func() {
 				fetcher.FetchCall.Returns.Error = errors.New("fetch error")
 
@@ -105,7 +169,7 @@ applications:
 				}
 				pusherCreator.DeployEventData.DeploymentInfo = &deploymentInfo
 
-				err := pusherCreator.SetUp()
+				err := pusherCreator.SetUp(context.Background())
 
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(Equal("unzipped app path failed: fetch error"))
@@ -119,7 +183,7 @@ applications:
 				}
 				pusherCreator.DeployEventData.DeploymentInfo = &deploymentInfo
 
-				pusherCreator.SetUp()
+				pusherCreator.SetUp(context.Background())
 
 				Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(2)))
 			})
@@ -129,7 +193,7 @@ applications:
 applications:
 - name: "blah"
   instances: 2`
-  					pusherCreator.SetUp()
+  					pusherCreator.SetUp(context.Background())
 
 					Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).To(Equal(reflect.TypeOf(ArtifactRetrievalStartEvent{})))
 				})
@@ -145,7 +209,7 @@ applications:
 						Application:  randomizer.StringRunes(10),
 					}
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					Expect(eventManager.EmitEventCall.Received.Events[0].(ArtifactRetrievalStartEvent).CFContext).To(Equal(pusherCreator.CFContext))
 				})
@@ -159,7 +223,7 @@ applications:
 						Password: randomizer.StringRunes(10),
 					}
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					Expect(eventManager.EmitEventCall.Received.Events[0].(ArtifactRetrievalStartEvent).Auth).To(Equal(pusherCreator.Auth))
 				})
@@ -172,9 +236,9 @@ applications:
 
 					pusherCreator.Environment = environment
 					pusherCreator.DeployEventData.Response = response
-					pusherCreator.DeployEventData.DeploymentInfo.Data = make(map[string]interface{})
+					pusherCreator.DeployEventData.DeploymentInfo.Data = make(structs.Params)
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					event := eventManager.EmitEventCall.Received.Events[0].(ArtifactRetrievalStartEvent)
 					Expect(event.Environment).To(Equal(pusherCreator.Environment))
@@ -187,7 +251,7 @@ applications:
 					pusherCreator.DeployEventData.DeploymentInfo.ArtifactURL = "theArtifactURL"
 					pusherCreator.DeployEventData.DeploymentInfo.ContentType = "JSON"
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					event := eventManager.EmitEventCall.Received.Events[0].(ArtifactRetrievalStartEvent)
 					Expect(event.Manifest).To(Equal(manifest))
@@ -198,7 +262,7 @@ applications:
 
 						eventManager.EmitEventCall.Returns.Error = []error{errors.New("a test error")}
 
-						err := pusherCreator.SetUp()
+						err := pusherCreator.SetUp(context.Background())
 
 						Expect(err).To(HaveOccurred())
 						Expect(reflect.TypeOf(err)).To(Equal(reflect.TypeOf(deployer.EventError{})))
@@ -213,7 +277,7 @@ applications:
 applications:
 - name: "blah"
   instances: 2`
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).To(Equal(reflect.TypeOf(ArtifactRetrievalSuccessEvent{})))
 				})
@@ -229,7 +293,7 @@ applications:
 						Application:  randomizer.StringRunes(10),
 					}
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					Expect(eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent).CFContext).To(Equal(pusherCreator.CFContext))
 				})
@@ -243,7 +307,7 @@ applications:
 						Password: randomizer.StringRunes(10),
 					}
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					Expect(eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent).Auth).To(Equal(pusherCreator.Auth))
 				})
@@ -256,9 +320,9 @@ applications:
 
 					pusherCreator.Environment = environment
 					pusherCreator.DeployEventData.Response = response
-					pusherCreator.DeployEventData.DeploymentInfo.Data = make(map[string]interface{})
+					pusherCreator.DeployEventData.DeploymentInfo.Data = make(structs.Params)
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					event := eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent)
 					Expect(event.Environment).To(Equal(pusherCreator.Environment))
@@ -273,7 +337,7 @@ applications:
 
 					fetcher.FetchCall.Returns.AppPath = "new app path"
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					event := eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent)
 					Expect(event.Manifest).To(Equal(manifest))
@@ -285,7 +349,7 @@ applications:
 
 						eventManager.EmitEventCall.Returns.Error = []error{nil, errors.New("a test error")}
 
-						err := pusherCreator.SetUp()
+						err := pusherCreator.SetUp(context.Background())
 
 						Expect(err).To(HaveOccurred())
 						Expect(reflect.TypeOf(err)).To(Equal(reflect.TypeOf(deployer.EventError{})))
@@ -301,7 +365,7 @@ applications:
 					fetcher.FetchFromZipCall.Returns.Error = errors.New("a test error")
 					pusherCreator.DeployEventData.DeploymentInfo.ContentType = "ZIP"
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).To(Equal(reflect.TypeOf(ArtifactRetrievalFailureEvent{})))
 				})
@@ -316,7 +380,7 @@ applications:
 					fetcher.FetchFromZipCall.Returns.Error = errors.New("a test error")
 					pusherCreator.DeployEventData.DeploymentInfo.ContentType = "ZIP"
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					Expect(eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalFailureEvent).CFContext).To(Equal(pusherCreator.CFContext))
 				})
@@ -329,7 +393,7 @@ applications:
 					fetcher.FetchFromZipCall.Returns.Error = errors.New("a test error")
 					pusherCreator.DeployEventData.DeploymentInfo.ContentType = "ZIP"
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					Expect(eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalFailureEvent).Auth).To(Equal(pusherCreator.Auth))
 				})
@@ -338,12 +402,12 @@ applications:
 
 					pusherCreator.Environment = environment
 					pusherCreator.DeployEventData.Response = response
-					pusherCreator.DeployEventData.DeploymentInfo.Data = make(map[string]interface{})
+					pusherCreator.DeployEventData.DeploymentInfo.Data = make(structs.Params)
 
 					fetcher.FetchFromZipCall.Returns.Error = errors.New("a test error")
 					pusherCreator.DeployEventData.DeploymentInfo.ContentType = "ZIP"
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					event := eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalFailureEvent)
 					Expect(event.Environment).To(Equal(pusherCreator.Environment))
@@ -358,7 +422,7 @@ applications:
 
 					fetcher.FetchCall.Returns.Error = errors.New("a test error")
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					event := eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalFailureEvent)
 					Expect(event.Manifest).To(Equal(manifest))
@@ -384,7 +448,7 @@ applications:
 				}
 				pusherCreator.DeployEventData.DeploymentInfo = &deploymentInfo
 
-				pusherCreator.SetUp()
+				pusherCreator.SetUp(context.Background())
 
 				Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(22)))
 			})
@@ -402,7 +466,7 @@ applications:
 				deploymentInfo := structs.DeploymentInfo{ContentType: "ZIP"}
 				pusherCreator.DeployEventData.DeploymentInfo = &deploymentInfo
 
-				pusherCreator.SetUp()
+				pusherCreator.SetUp(context.Background())
 
 				Expect(pusherCreator.DeployEventData.DeploymentInfo.AppPath).To(Equal("newAppPath"))
 				logBytes, _ := ioutil.ReadAll(logBuffer)
@@ -421,7 +485,7 @@ applications:
 					deploymentInfo := structs.DeploymentInfo{ContentType: "ZIP"}
 					pusherCreator.DeployEventData.DeploymentInfo = &deploymentInfo
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(2)))
 					logBytes, _ := ioutil.ReadAll(logBuffer)
@@ -440,7 +504,7 @@ applications:
 					deploymentInfo := structs.DeploymentInfo{ContentType: "ZIP"}
 					pusherCreator.DeployEventData.DeploymentInfo = &deploymentInfo
 
-					pusherCreator.SetUp()
+					pusherCreator.SetUp(context.Background())
 
 					Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(7)))
 					logBytes, _ := ioutil.ReadAll(logBuffer)
@@ -456,12 +520,590 @@ applications:
 				}
 				pusherCreator.DeployEventData.DeploymentInfo = &deploymentInfo
 
-				err := pusherCreator.SetUp()
+				err := pusherCreator.SetUp(context.Background())
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(Equal("unzipping request body error: a test error"))
 			})
 		})
 
+		Context("when there is an SBOM generator", func() {
+			var generator *mocks.SBOMGenerator
+
+			BeforeEach(func() {
+				generator = &mocks.SBOMGenerator{}
+				generator.DigestCall.Returns.Digest = "sha256:abc"
+				generator.GenerateCall.Returns.SBOM = structs.SBOM{Generated: true, Components: []structs.Component{{Name: "log4j-core", Version: "2.14.1"}}}
+				pusherCreator.SBOMGenerator = generator
+				fetcher.FetchCall.Returns.AppPath = "newAppPath"
+			})
+
+			It("records the digest and components of the artifact", func() {
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{ContentType: "JSON"}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				info := pusherCreator.DeployEventData.DeploymentInfo
+				Expect(generator.DigestCall.Received.AppPath).To(Equal("newAppPath"))
+				Expect(info.ArtifactDigest).To(Equal("sha256:abc"))
+				Expect(info.SBOM).To(Equal(&generator.GenerateCall.Returns.SBOM))
+				Expect(response).To(Say("artifact digest: sha256:abc"))
+			})
+
+			It("keeps the reference of a provided SBOM", func() {
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{ContentType: "JSON", SBOM: &structs.SBOM{Reference: "https://example.com/sbom.json"}}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.SBOM.Reference).To(Equal("https://example.com/sbom.json"))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.SBOM.Components).To(HaveLen(1))
+			})
+
+			It("does not generate components when the request provides them", func() {
+				provided := &structs.SBOM{Components: []structs.Component{{Name: "lodash", Version: "4.17.20"}}}
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{ContentType: "JSON", SBOM: provided}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(generator.GenerateCall.TimesCalled).To(Equal(0))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.SBOM).To(Equal(provided))
+			})
+
+			It("returns an error when the digest can not be computed", func() {
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{ContentType: "JSON"}
+				generator.DigestCall.Returns.Error = errors.New("read error")
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(MatchError("cannot record the contents of the artifact: read error"))
+			})
+
+			It("accepts an artifact with the expected digest", func() {
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{ContentType: "JSON", ExpectedArtifactDigest: "sha256:abc"}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+			})
+
+			It("returns an error when the artifact does not have the expected digest", func() {
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{ContentType: "JSON", ExpectedArtifactDigest: "sha256:def"}
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(MatchError(state.ArtifactDigestMismatchError{Expected: "sha256:def", Actual: "sha256:abc"}))
+			})
+		})
+
+		Context("when there is no SBOM generator", func() {
+			It("returns an error when the artifact must have a digest", func() {
+				pusherCreator.SBOMGenerator = nil
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{ContentType: "JSON", ExpectedArtifactDigest: "sha256:abc"}
+				fetcher.FetchCall.Returns.AppPath = "newAppPath"
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(BeAssignableToTypeOf(state.ArtifactInventoryError{}))
+			})
+		})
+
+		Context("when there are several manifests", func() {
+			var fileSystem *afero.Afero
+
+			encode := func(manifest string) string {
+				return base64.StdEncoding.EncodeToString([]byte(manifest))
+			}
+
+			BeforeEach(func() {
+				fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+				Expect(fileSystem.MkdirAll("/app", 0755)).To(Succeed())
+				fetcher.FetchCall.Returns.AppPath = "/app"
+
+				pusherCreator.FileSystem = fileSystem
+				pusherCreator.Environment = structs.Environment{Name: "prod"}
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{ContentType: "JSON"}
+			})
+
+			It("measures the size of the extracted artifact", func() {
+				Expect(fileSystem.WriteFile("/app/app.jar", make([]byte, 1000), 0644)).To(Succeed())
+				Expect(fileSystem.MkdirAll("/app/lib", 0755)).To(Succeed())
+				Expect(fileSystem.WriteFile("/app/lib/lib.jar", make([]byte, 24), 0644)).To(Succeed())
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.ArtifactSize).To(Equal(int64(1024)))
+			})
+
+			It("selects the manifest of the request named after the environment", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.Manifests = map[string]string{
+					"dev":  encode("---\napplications:\n- instances: 1\n"),
+					"prod": encode("---\napplications:\n- instances: 3\n"),
+				}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(fetcher.FetchCall.Received.Manifest).To(Equal("---\napplications:\n- instances: 3\n"))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(3)))
+			})
+
+			It("selects the manifest of the request with the manifest name", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.ManifestName = "dev"
+				pusherCreator.DeployEventData.DeploymentInfo.Manifests = map[string]string{
+					"dev":  encode("---\napplications:\n- instances: 1\n"),
+					"prod": encode("---\napplications:\n- instances: 3\n"),
+				}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(fetcher.FetchCall.Received.Manifest).To(Equal("---\napplications:\n- instances: 1\n"))
+			})
+
+			It("prefers the manifest of the request", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.Manifest = encode("---\napplications:\n- instances: 2\n")
+				pusherCreator.DeployEventData.DeploymentInfo.Manifests = map[string]string{"prod": encode("---\napplications:\n- instances: 3\n")}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(fetcher.FetchCall.Received.Manifest).To(Equal("---\napplications:\n- instances: 2\n"))
+			})
+
+			It("selects the manifest of the artifact named after the environment", func() {
+				Expect(fileSystem.WriteFile("/app/manifest.yml", []byte("---\napplications:\n- instances: 1\n"), 0600)).To(Succeed())
+				Expect(fileSystem.WriteFile("/app/manifest-prod.yml", []byte("---\napplications:\n- instances: 3\n"), 0600)).To(Succeed())
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				manifest, err := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(manifest)).To(Equal("---\napplications:\n- instances: 3\n"))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Manifest).To(Equal(string(manifest)))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(3)))
+			})
+
+			It("selects the manifest of an uploaded zip file", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.ContentType = "ZIP"
+				pusherCreator.DeployEventData.DeploymentInfo.ManifestName = "canary"
+				fetcher.FetchFromZipCall.Returns.AppPath = "/app"
+				fetcher.FetchFromZipCall.Returns.Manifest = "---\napplications:\n- instances: 1\n"
+				Expect(fileSystem.WriteFile("/app/manifest-canary.yml", []byte("---\napplications:\n- instances: 5\n"), 0600)).To(Succeed())
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(5)))
+			})
+
+			It("keeps the manifest of the artifact when it has none for the environment", func() {
+				Expect(fileSystem.WriteFile("/app/manifest.yml", []byte("---\napplications:\n- instances: 1\n"), 0600)).To(Succeed())
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				manifest, _ := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(string(manifest)).To(Equal("---\napplications:\n- instances: 1\n"))
+			})
+
+			It("returns an error when the manifest name is not found", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.ManifestName = "canary"
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(MatchError(state.ManifestNotFoundError{Name: "canary"}))
+			})
+
+			It("returns an error for a manifest name outside of the artifact", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.ManifestName = "../../etc/passwd"
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(MatchError(state.InvalidManifestNameError{Name: "../../etc/passwd"}))
+				Expect(fetcher.FetchCall.Received.ArtifactURL).To(BeEmpty())
+			})
+		})
+
+		Context("when the environment has a manifest template", func() {
+			var fileSystem *afero.Afero
+
+			BeforeEach(func() {
+				fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+				Expect(fileSystem.MkdirAll("/app", 0755)).To(Succeed())
+				fetcher.FetchCall.Returns.AppPath = "/app"
+
+				pusherCreator.FileSystem = fileSystem
+				pusherCreator.Environment = structs.Environment{
+					Name:             "production",
+					Instances:        2,
+					ManifestTemplate: "---\napplications:\n- name: {{.AppName}}\n  instances: {{.Instances}}\n",
+				}
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{AppName: "search", ContentType: "JSON"}
+			})
+
+			It("generates the manifest when none is provided", func() {
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				manifest, err := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(manifest)).To(Equal("---\napplications:\n- name: search\n  instances: 2\n"))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Manifest).To(Equal(string(manifest)))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(2)))
+				Expect(eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent).Manifest).To(Equal(string(manifest)))
+				Eventually(response).Should(Say("generated one from the manifest template of production"))
+			})
+
+			It("keeps the manifest of the request", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.Manifest = base64.StdEncoding.EncodeToString([]byte("---\napplications:\n- instances: 4\n"))
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(fileSystem.Exists("/app/manifest.yml")).To(BeFalse())
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(4)))
+			})
+
+			It("keeps the manifest of the artifact", func() {
+				Expect(fileSystem.WriteFile("/app/manifest.yml", []byte("---\napplications:\n- name: shipped\n"), 0600)).To(Succeed())
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				manifest, _ := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(string(manifest)).To(ContainSubstring("shipped"))
+			})
+
+			It("returns an error when the template cannot be executed", func() {
+				pusherCreator.Environment.ManifestTemplate = "{{.Unknown}}"
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(BeAssignableToTypeOf(state.ManifestTemplateError{}))
+			})
+		})
+
+		Context("when the request merges its manifest", func() {
+			var fileSystem *afero.Afero
+
+			BeforeEach(func() {
+				fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+				Expect(fileSystem.MkdirAll("/app", 0755)).To(Succeed())
+				Expect(fileSystem.WriteFile("/app/manifest.yml", []byte("---\napplications:\n- name: search\n  memory: 1G\n  instances: 1\n  env:\n    REGION: west\n"), 0600)).To(Succeed())
+				fetcher.FetchCall.Returns.AppPath = "/app"
+
+				pusherCreator.FileSystem = fileSystem
+				pusherCreator.Environment = structs.Environment{Name: "production"}
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{
+					AppName:       "search",
+					ContentType:   "JSON",
+					MergeManifest: true,
+					Manifest:      base64.StdEncoding.EncodeToString([]byte("applications:\n- name: search\n  instances: 3\n  env:\n    FEATURE: \"on\"\n")),
+				}
+			})
+
+			It("keeps the manifest of the artifact and merges the manifest of the request onto it", func() {
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(fetcher.FetchCall.Received.Manifest).To(BeEmpty())
+				manifest, err := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(manifest)).To(ContainSubstring("memory: 1G"))
+				Expect(string(manifest)).To(ContainSubstring("REGION: west"))
+				Expect(string(manifest)).To(ContainSubstring("FEATURE: \"on\""))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Manifest).To(Equal(string(manifest)))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(3)))
+				Expect(eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent).Manifest).To(Equal(string(manifest)))
+			})
+
+			It("merges a manifest given as inline YAML", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.Manifest = "applications:\n- name: search\n  memory: 2G\n"
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				manifest, _ := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(string(manifest)).To(ContainSubstring("memory: 2G"))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(1)))
+			})
+
+			It("merges the manifest of the request named after the environment onto the manifest of the artifact", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.Manifest = ""
+				pusherCreator.DeployEventData.DeploymentInfo.ManifestName = "production"
+				pusherCreator.DeployEventData.DeploymentInfo.Manifests = map[string]string{"production": "applications:\n- name: search\n  instances: 4\n"}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				manifest, _ := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(string(manifest)).To(ContainSubstring("memory: 1G"))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(4)))
+			})
+
+			It("replaces the manifest of the artifact with an inline manifest when it does not merge", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.MergeManifest = false
+				pusherCreator.DeployEventData.DeploymentInfo.Manifest = "applications:\n- name: search\n  memory: 2G\n"
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(fetcher.FetchCall.Received.Manifest).To(Equal("applications:\n- name: search\n  memory: 2G\n"))
+			})
+
+			It("returns an error for a manifest that is neither base64 nor YAML", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.Manifest = "not a manifest"
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(MatchError(state.ManifestError{}))
+			})
+
+			It("returns an error when the manifest of the artifact cannot be merged", func() {
+				Expect(fileSystem.WriteFile("/app/manifest.yml", []byte("applications: ["), 0600)).To(Succeed())
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(BeAssignableToTypeOf(state.ManifestMergeError{}))
+			})
+		})
+
+		Context("when the environment has a manifest overlay", func() {
+			var fileSystem *afero.Afero
+
+			BeforeEach(func() {
+				fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+				Expect(fileSystem.MkdirAll("/app", 0755)).To(Succeed())
+				fetcher.FetchCall.Returns.AppPath = "/app"
+
+				pusherCreator.FileSystem = fileSystem
+				pusherCreator.Environment = structs.Environment{
+					Name:            "production",
+					ManifestOverlay: structs.ManifestOverlay{Instances: 4, Env: map[string]string{"REGION": "east"}},
+				}
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{AppName: "search", ContentType: "JSON"}
+			})
+
+			It("merges the overlay onto the manifest of the request", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.Manifest = base64.StdEncoding.EncodeToString([]byte("---\napplications:\n- name: search\n  instances: 1\n  memory: 1G\n"))
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				manifest, err := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(manifest)).To(ContainSubstring("memory: 1G"))
+				Expect(string(manifest)).To(ContainSubstring("REGION: east"))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Manifest).To(Equal(string(manifest)))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Instances).To(Equal(uint16(4)))
+				Expect(eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent).Manifest).To(Equal(string(manifest)))
+			})
+
+			It("merges the overlay onto the manifest of the artifact", func() {
+				Expect(fileSystem.WriteFile("/app/manifest.yml", []byte("---\napplications:\n- name: search\n  buildpack: java_buildpack\n"), 0600)).To(Succeed())
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				manifest, _ := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(string(manifest)).To(ContainSubstring("buildpack: java_buildpack"))
+				Expect(string(manifest)).To(ContainSubstring("REGION: east"))
+			})
+
+			It("binds the metadata service of an environment that has one", func() {
+				pusherCreator.Environment.MetadataService = true
+				pusherCreator.Environment.ManifestOverlay = structs.ManifestOverlay{}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				manifest, _ := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(string(manifest)).To(ContainSubstring("- search" + MetadataServiceSuffix))
+			})
+
+			It("returns an error when the manifest cannot be merged", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.Manifest = base64.StdEncoding.EncodeToString([]byte("applications: ["))
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(BeAssignableToTypeOf(state.ManifestOverlayError{}))
+			})
+		})
+
+		Context("when the environment stamps deployment environment variables", func() {
+			BeforeEach(func() {
+				fetcher.FetchCall.Returns.AppPath = "newAppPath"
+				pusherCreator.FileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{
+					UUID:        "uuid-1",
+					ArtifactURL: "https://example.com/artifact.zip",
+					Username:    "ci",
+					ContentType: "JSON",
+				}
+				pusherCreator.EnvironmentVariables = map[string]string{"FEATURE": "on", structs.StampDeployedBy: "someone"}
+			})
+
+			It("adds them to the environment variables of the application", func() {
+				pusherCreator.Environment.StampEnvVars = []string{structs.StampDeploymentUUID, structs.StampArtifactURL, structs.StampDeployedAt, structs.StampDeployedBy}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				variables := eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent).EnvironmentVariables
+				Expect(variables).To(HaveLen(5))
+				Expect(variables["FEATURE"]).To(Equal("on"))
+				Expect(variables[structs.StampDeploymentUUID]).To(Equal("uuid-1"))
+				Expect(variables[structs.StampArtifactURL]).To(Equal("https://example.com/artifact.zip"))
+				Expect(variables[structs.StampDeployedBy]).To(Equal("ci"))
+
+				deployedAt, err := time.Parse(time.RFC3339, variables[structs.StampDeployedAt])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deployedAt).To(BeTemporally("~", time.Now(), time.Minute))
+
+				Expect(pusherCreator.EnvironmentVariables).To(HaveLen(2))
+			})
+
+			It("leaves out the variables without a value", func() {
+				pusherCreator.Environment.StampEnvVars = []string{structs.StampDeploymentUUID, structs.StampArtifactDigest}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				variables := eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent).EnvironmentVariables
+				Expect(variables).To(HaveKey(structs.StampDeploymentUUID))
+				Expect(variables).ToNot(HaveKey(structs.StampArtifactDigest))
+			})
+
+			It("stamps the digest of the artifact into the manifest once it is inventoried", func() {
+				generator := &mocks.SBOMGenerator{}
+				generator.DigestCall.Returns.Digest = "sha256:abc"
+				pusherCreator.SBOMGenerator = generator
+				pusherCreator.Environment.StampEnvVars = []string{structs.StampArtifactDigest}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				variables := eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent).EnvironmentVariables
+				Expect(variables[structs.StampArtifactDigest]).To(Equal("sha256:abc"))
+
+				manifest, err := pusherCreator.FileSystem.ReadFile("newAppPath/manifest.yml")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(manifest)).To(ContainSubstring(structs.StampArtifactDigest + ": sha256:abc"))
+			})
+
+			It("stamps nothing by default", func() {
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				variables := eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent).EnvironmentVariables
+				Expect(variables).To(Equal(map[string]string{"FEATURE": "on", structs.StampDeployedBy: "someone"}))
+			})
+		})
+
+		Context("when the request sets environment variables", func() {
+			var fileSystem *afero.Afero
+
+			BeforeEach(func() {
+				fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+				Expect(fileSystem.MkdirAll("/app", 0755)).To(Succeed())
+				fetcher.FetchCall.Returns.AppPath = "/app"
+
+				pusherCreator.FileSystem = fileSystem
+				pusherCreator.Environment = structs.Environment{Name: "production"}
+				pusherCreator.EnvironmentVariables = map[string]string{"FEATURE": "on", "REGION": "west"}
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{
+					AppName:     "search",
+					ContentType: "JSON",
+					Manifest:    base64.StdEncoding.EncodeToString([]byte("applications:\n- name: search\n  memory: 1G\n  env:\n    LOG_LEVEL: debug\n")),
+				}
+			})
+
+			It("writes them to the env of the manifest before the application is pushed", func() {
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				manifest, err := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(manifest)).To(ContainSubstring("memory: 1G"))
+				Expect(string(manifest)).To(ContainSubstring("LOG_LEVEL: debug"))
+				Expect(string(manifest)).To(ContainSubstring("FEATURE: \"on\""))
+				Expect(string(manifest)).To(ContainSubstring("REGION: west"))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.Manifest).To(Equal(string(manifest)))
+				Expect(eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent).Manifest).To(Equal(string(manifest)))
+				Expect(response).To(Say("set the environment variables FEATURE, REGION in the manifest"))
+			})
+
+			It("writes them to the manifest of the artifact when the request has none", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.Manifest = ""
+				Expect(fileSystem.WriteFile("/app/manifest.yml", []byte("---\napplications:\n- name: search\n  buildpack: java_buildpack\n"), 0600)).To(Succeed())
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				manifest, _ := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(string(manifest)).To(ContainSubstring("buildpack: java_buildpack"))
+				Expect(string(manifest)).To(ContainSubstring("REGION: west"))
+			})
+
+			It("records the final set, in which the overlay of the environment replaces the variables of the request", func() {
+				pusherCreator.Environment.ManifestOverlay = structs.ManifestOverlay{Env: map[string]string{"REGION": "east"}}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				final := map[string]string{"FEATURE": "on", "REGION": "east"}
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.EnvironmentVariables).To(Equal(final))
+				Expect(eventManager.EmitEventCall.Received.Events[1].(ArtifactRetrievalSuccessEvent).EnvironmentVariables).To(Equal(final))
+
+				manifest, _ := fileSystem.ReadFile("/app/manifest.yml")
+				Expect(string(manifest)).To(ContainSubstring("REGION: east"))
+				Expect(pusherCreator.EnvironmentVariables).To(Equal(map[string]string{"FEATURE": "on", "REGION": "west"}))
+			})
+
+			It("leaves the manifest alone when there are none", func() {
+				pusherCreator.EnvironmentVariables = nil
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(fileSystem.Exists("/app/manifest.yml")).To(BeFalse())
+			})
+
+			It("returns an error when the manifest cannot be written", func() {
+				pusherCreator.DeployEventData.DeploymentInfo.Manifest = base64.StdEncoding.EncodeToString([]byte("applications: ["))
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(BeAssignableToTypeOf(state.EnvironmentVariablesError{}))
+			})
+		})
+
+		Context("when the environment has a scanner", func() {
+			var scanner *mocks.Scanner
+
+			BeforeEach(func() {
+				scanner = &mocks.Scanner{}
+				pusherCreator.Scanner = scanner
+				pusherCreator.Environment.Scanner = structs.ScannerDescriptor{Type: "clamav", Address: "localhost:3310"}
+				pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{ContentType: "JSON"}
+				fetcher.FetchCall.Returns.AppPath = "newAppPath"
+			})
+
+			It("scans the fetched artifact and records the result", func() {
+				scanner.ScanCall.Returns.Result = structs.ScanResult{Scanner: "clamav"}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(scanner.ScanCall.Received.AppPath).To(Equal("newAppPath"))
+				Expect(scanner.ScanCall.Received.Descriptor).To(Equal(pusherCreator.Environment.Scanner))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.ScanResult).To(Equal(&structs.ScanResult{Scanner: "clamav"}))
+			})
+
+			It("fails and records the result when the scan has findings", func() {
+				findings := []structs.ScanFinding{{Path: "lib/bad.jar", Description: "Eicar-Signature"}}
+				scanner.ScanCall.Returns.Result = structs.ScanResult{Scanner: "clamav", Findings: findings}
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(MatchError(state.ScanFindingsError{Findings: findings}))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.ScanResult.Findings).To(Equal(findings))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.AppPath).To(Equal("newAppPath"))
+				Expect(response).To(Say("lib/bad.jar: Eicar-Signature"))
+				Expect(eventManager.EmitEventCall.Received.Events).To(HaveLen(1))
+			})
+
+			It("fails when the scanner returns an error", func() {
+				scanner.ScanCall.Returns.Error = errors.New("connection refused")
+
+				err := pusherCreator.SetUp(context.Background())
+
+				Expect(err).To(MatchError("artifact scan failed: connection refused"))
+				Expect(pusherCreator.DeployEventData.DeploymentInfo.ScanResult).To(BeNil())
+			})
+
+			It("does not scan when the environment has no scanner", func() {
+				pusherCreator.Environment.Scanner = structs.ScannerDescriptor{}
+
+				Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+				Expect(scanner.ScanCall.TimesCalled).To(Equal(0))
+			})
+		})
+
 	})
 
 	Describe("OnStart", func() {
@@ -623,7 +1265,100 @@ applications:
 		})
 	})
 
+	Describe("lifecycle hooks", func() {
+		var hooks *mocks.HookRunner
+
+		BeforeEach(func() {
+			hooks = &mocks.HookRunner{}
+			pusherCreator.Hooks = hooks
+			fetcher.FetchCall.Returns.AppPath = "newAppPath"
+			pusherCreator.Environment.Name = "prod"
+			pusherCreator.DeployEventData.DeploymentInfo = &structs.DeploymentInfo{
+				UUID:        "uuid-1",
+				AppName:     "search",
+				ArtifactURL: "https://example.com/artifact.zip",
+				ContentType: "JSON",
+			}
+		})
+
+		It("runs the pre-fetch and post-fetch hooks around the fetch", func() {
+			Expect(pusherCreator.SetUp(context.Background())).To(Succeed())
+
+			Expect(hooks.RunCall.Received.Phases).To(Equal([]string{structs.HookPreFetch, structs.HookPostFetch}))
+			Expect(hooks.RunCall.Received.Variables[0]["APP_NAME"]).To(Equal("search"))
+			Expect(hooks.RunCall.Received.Variables[0]["ENVIRONMENT"]).To(Equal("prod"))
+			Expect(hooks.RunCall.Received.Variables[0]["APP_PATH"]).To(BeEmpty())
+			Expect(hooks.RunCall.Received.Variables[1]["APP_PATH"]).To(Equal("newAppPath"))
+		})
+
+		It("does not fetch the artifact when a pre-fetch hook fails", func() {
+			hooks.RunCall.Returns.Errors = map[string]error{structs.HookPreFetch: errors.New("change freeze")}
+
+			err := pusherCreator.SetUp(context.Background())
+
+			Expect(err).To(MatchError("change freeze"))
+			Expect(fetcher.FetchCall.Received.ArtifactURL).To(BeEmpty())
+		})
+
+		It("fails the deployment when a pre-push hook fails", func() {
+			hooks.RunCall.Returns.Errors = map[string]error{structs.HookPrePush: errors.New("change freeze")}
+
+			Expect(pusherCreator.OnStart()).To(MatchError("change freeze"))
+			Expect(hooks.RunCall.Received.Phases).To(Equal([]string{structs.HookPrePush}))
+		})
+
+		It("runs the post-promotion hooks after a successful deployment, which their failure does not change", func() {
+			hooks.RunCall.Returns.Errors = map[string]error{structs.HookPostPromotion: errors.New("notification failed")}
+
+			resp := pusherCreator.OnFinish(structs.Environment{}, response, nil)
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(hooks.RunCall.Received.Phases).To(Equal([]string{structs.HookPostPromotion}))
+		})
+
+		It("runs the post-rollback hooks with the error when the push was rolled back", func() {
+			pushErr := bluegreen.PushError{PushErrors: []error{errors.New("app crashed")}}
+
+			resp := pusherCreator.OnFinish(structs.Environment{EnableRollback: true}, response, pushErr)
+
+			Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(hooks.RunCall.Received.Phases).To(Equal([]string{structs.HookPostRollback}))
+			Expect(hooks.RunCall.Received.Variables[0]["ERROR"]).To(Equal(pushErr.Error()))
+		})
+
+		It("does not run the post-rollback hooks when rollback is disabled", func() {
+			pusherCreator.OnFinish(structs.Environment{}, response, bluegreen.PushError{})
+
+			Expect(hooks.RunCall.TimesCalled).To(Equal(0))
+		})
+
+		It("does not run the post-rollback hooks when the login failed", func() {
+			pusherCreator.OnFinish(structs.Environment{EnableRollback: true}, response, bluegreen.LoginError{})
+
+			Expect(hooks.RunCall.TimesCalled).To(Equal(0))
+		})
+	})
+
 	Describe("OnFinish", func() {
+		It("removes the cached artifact of a deployment that succeeded", func() {
+			artifactCache := &mocks.ArtifactCache{}
+			pusherCreator.ArtifactCache = artifactCache
+			pusherCreator.DeployEventData.DeploymentInfo.UUID = "uuid-1"
+
+			pusherCreator.OnFinish(structs.Environment{}, response, nil)
+
+			Expect(artifactCache.RemoveCall.Received.UUID).To(Equal("uuid-1"))
+		})
+
+		It("keeps the cached artifact of a deployment that failed", func() {
+			artifactCache := &mocks.ArtifactCache{}
+			pusherCreator.ArtifactCache = artifactCache
+
+			pusherCreator.OnFinish(structs.Environment{EnableRollback: true}, response, errors.New("push failed"))
+
+			Expect(artifactCache.RemoveCall.Called).To(BeFalse())
+		})
+
 		Context("when error occurs", func() {
 			Context("and EnableRollback is false", func() {
 				It("returns StatusOK", func() {
@@ -688,5 +1423,74 @@ applications:
 				Eventually(string(logBytes)).Should(ContainSubstring("Your deploy was successful!"))
 			})
 		})
+
+		It("writes the health check result of every foundation", func() {
+			pusherCreator.HealthChecks = &structs.HealthCheckReport{}
+			pusherCreator.HealthChecks.Add(structs.HealthCheckResult{FoundationURL: "https://api.two.example.com", URL: "https://app.two.example.com/health", Duration: 2 * time.Second, Error: "timeout"})
+			pusherCreator.HealthChecks.Add(structs.HealthCheckResult{FoundationURL: "https://api.one.example.com", URL: "https://app.one.example.com/health", StatusCode: 200, Duration: 1500 * time.Millisecond})
+
+			pusherCreator.OnFinish(structs.Environment{}, response, errors.New("health check failed"))
+
+			output, _ := ioutil.ReadAll(response)
+			Expect(string(output)).To(MatchRegexp(`Health Checks:
+  https://api.one.example.com +passed +200 +1.500s +https://app.one.example.com/health
+  https://api.two.example.com +failed +- +2.000s +https://app.two.example.com/health`))
+		})
+
+		It("writes the quota usage of every foundation before and after the push", func() {
+			memoryLimit, instanceLimit := 10240, 100
+			pusherCreator.QuotaUsage = &structs.QuotaUsageReport{}
+			pusherCreator.QuotaUsage.RecordBefore("https://api.one.example.com", structs.OrgSpaceQuotaUsage{
+				Org:   structs.QuotaUsage{MemoryMB: 2048, MemoryLimitMB: &memoryLimit, Instances: 4, InstanceLimit: &instanceLimit},
+				Space: structs.QuotaUsage{MemoryMB: 1024, Instances: 2},
+			})
+			pusherCreator.QuotaUsage.RecordAfter("https://api.one.example.com", structs.OrgSpaceQuotaUsage{
+				Org:   structs.QuotaUsage{MemoryMB: 3072, MemoryLimitMB: &memoryLimit, Instances: 6, InstanceLimit: &instanceLimit},
+				Space: structs.QuotaUsage{MemoryMB: 2048, Instances: 4},
+			})
+			pusherCreator.QuotaUsage.RecordBefore("https://api.two.example.com", structs.OrgSpaceQuotaUsage{
+				Org: structs.QuotaUsage{MemoryMB: 512, Instances: 1},
+			})
+
+			pusherCreator.OnFinish(structs.Environment{}, response, nil)
+
+			output, _ := ioutil.ReadAll(response)
+			Expect(string(output)).To(MatchRegexp(`Quota Usage:
+  https://api.one.example.com +org +memory 2048M -> 3072M of 10240M +instances 4 -> 6 of 100
+  https://api.one.example.com +space +memory 1024M -> 2048M of unlimited +instances 2 -> 4 of unlimited
+  https://api.two.example.com +org +memory 512M -> \? of unlimited +instances 1 -> \? of unlimited
+`))
+		})
+
+		It("marks the deployment degraded when the application crashed after it was promoted", func() {
+			pusherCreator.CrashWatch = &structs.CrashWatchReport{}
+			pusherCreator.CrashWatch.Add(structs.CrashWatchResult{FoundationURL: "https://api.one.example.com", Crashes: 4})
+
+			deployResponse := pusherCreator.OnFinish(structs.Environment{}, response, nil)
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
+			Expect(deployResponse.Degraded).To(BeTrue())
+			output, _ := ioutil.ReadAll(response)
+			Expect(string(output)).To(ContainSubstring("Your deployment is degraded"))
+			Expect(string(output)).To(ContainSubstring("https://api.one.example.com  4 crashes"))
+		})
+
+		It("is not degraded when the application did not crash", func() {
+			pusherCreator.CrashWatch = &structs.CrashWatchReport{}
+
+			deployResponse := pusherCreator.OnFinish(structs.Environment{}, response, nil)
+
+			Expect(deployResponse.Degraded).To(BeFalse())
+		})
+
+		It("attaches the captured evidence to the deployment info", func() {
+			evidence := structs.DeploymentEvidence{FoundationURL: "https://api.one.example.com", Requests: 10, ServerErrors: 2, Excerpts: []string{"503"}}
+			pusherCreator.Evidence = &structs.DeploymentEvidenceReport{}
+			pusherCreator.Evidence.Add(evidence)
+
+			pusherCreator.OnFinish(structs.Environment{}, response, errors.New("push failed"))
+
+			Expect(pusherCreator.DeployEventData.DeploymentInfo.Evidence).To(Equal([]structs.DeploymentEvidence{evidence}))
+		})
 	})
 })
@@ -0,0 +1,62 @@
+package restage
+
+import (
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/operation"
+)
+
+// events make the events of a restage.
+var events = operation.Events{
+	Started:  func(e operation.Event) I.IEvent { return RestageStartedEvent(e) },
+	Success:  func(e operation.Event) I.IEvent { return RestageSuccessEvent(e) },
+	Failure:  func(e operation.Event) I.IEvent { return RestageFailureEvent(e) },
+	Finished: func(e operation.Event) I.IEvent { return RestageFinishedEvent(e) },
+}
+
+type RestageFailureEvent operation.Event
+
+func (e RestageFailureEvent) Name() string {
+	return "RestageFailureEvent"
+}
+
+func NewRestageFailureEventBinding(handler func(event RestageFailureEvent) error) I.Binding {
+	return operation.NewBinding(RestageFailureEvent{}, func(event interface{}) error {
+		return handler(event.(RestageFailureEvent))
+	})
+}
+
+type RestageSuccessEvent operation.Event
+
+func (e RestageSuccessEvent) Name() string {
+	return "RestageSuccessEvent"
+}
+
+func NewRestageSuccessEventBinding(handler func(event RestageSuccessEvent) error) I.Binding {
+	return operation.NewBinding(RestageSuccessEvent{}, func(event interface{}) error {
+		return handler(event.(RestageSuccessEvent))
+	})
+}
+
+type RestageStartedEvent operation.Event
+
+func (e RestageStartedEvent) Name() string {
+	return "RestageStartedEvent"
+}
+
+func NewRestageStartedEventBinding(handler func(event RestageStartedEvent) error) I.Binding {
+	return operation.NewBinding(RestageStartedEvent{}, func(event interface{}) error {
+		return handler(event.(RestageStartedEvent))
+	})
+}
+
+type RestageFinishedEvent operation.Event
+
+func (e RestageFinishedEvent) Name() string {
+	return "RestageFinishedEvent"
+}
+
+func NewRestageFinishedEventBinding(handler func(event RestageFinishedEvent) error) I.Binding {
+	return operation.NewBinding(RestageFinishedEvent{}, func(event interface{}) error {
+		return handler(event.(RestageFinishedEvent))
+	})
+}
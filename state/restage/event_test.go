@@ -0,0 +1,98 @@
+package restage_test
+
+import (
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/restage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("event binding", func() {
+	Describe("RestageStartedEventBinding", func() {
+		It("should accept a RestageStartedEvent only", func() {
+			binding := restage.NewRestageStartedEventBinding(nil)
+
+			Expect(binding.Accepts(restage.RestageStartedEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received restage.RestageStartedEvent
+			binding := restage.NewRestageStartedEventBinding(func(event restage.RestageStartedEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(restage.RestageStartedEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("RestageSuccessEventBinding", func() {
+		It("should accept a RestageSuccessEvent only", func() {
+			binding := restage.NewRestageSuccessEventBinding(nil)
+
+			Expect(binding.Accepts(restage.RestageSuccessEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received restage.RestageSuccessEvent
+			binding := restage.NewRestageSuccessEventBinding(func(event restage.RestageSuccessEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(restage.RestageSuccessEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("RestageFailureEventBinding", func() {
+		It("should accept a RestageFailureEvent only", func() {
+			binding := restage.NewRestageFailureEventBinding(nil)
+
+			Expect(binding.Accepts(restage.RestageFailureEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received restage.RestageFailureEvent
+			binding := restage.NewRestageFailureEventBinding(func(event restage.RestageFailureEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(restage.RestageFailureEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("RestageFinishedEventBinding", func() {
+		It("should accept a RestageFinishedEvent only", func() {
+			binding := restage.NewRestageFinishedEventBinding(nil)
+
+			Expect(binding.Accepts(restage.RestageFinishedEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received restage.RestageFinishedEvent
+			binding := restage.NewRestageFinishedEventBinding(func(event restage.RestageFinishedEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(restage.RestageFinishedEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+})
@@ -0,0 +1,50 @@
+package restage
+
+import (
+	"context"
+	"io"
+
+	"github.com/compozed/deployadactyl/config"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/rbac"
+	"github.com/compozed/deployadactyl/state/operation"
+	"github.com/compozed/deployadactyl/structs"
+)
+
+type RestageControllerConstructor func(log I.DeploymentLogger, deployer I.Deployer, conf config.Config, eventManager I.EventManager, errorFinder I.ErrorFinder, restageManagerFactory I.RestageManagerFactory) I.RestageController
+
+func NewRestageController(l I.DeploymentLogger, d I.Deployer, c config.Config, em I.EventManager, ef I.ErrorFinder, rmf I.RestageManagerFactory) I.RestageController {
+	return &RestageController{
+		Deployer:              d,
+		Config:                c,
+		EventManager:          em,
+		ErrorFinder:           ef,
+		RestageManagerFactory: rmf,
+		Log:                   l,
+	}
+}
+
+// RestageController restages an application on every foundation.
+type RestageController struct {
+	Log                   I.DeploymentLogger
+	RestageManagerFactory I.RestageManagerFactory
+	Deployer              I.Deployer
+	Config                config.Config
+	EventManager          I.EventManager
+	ErrorFinder           I.ErrorFinder
+}
+
+func (c *RestageController) RestageDeployment(ctx context.Context, deployment *I.Deployment, data structs.Params, response io.ReadWriter) I.DeployResponse {
+	controller := operation.Controller{
+		Log:          c.Log,
+		Deployer:     c.Deployer,
+		Config:       c.Config,
+		EventManager: c.EventManager,
+		ErrorFinder:  c.ErrorFinder,
+		Operation:    rbac.Restage,
+		Events:       events,
+	}
+	return controller.Run(ctx, deployment, data, response, func(deployEventData structs.DeployEventData) I.ActionCreator {
+		return c.RestageManagerFactory.RestageManager(c.Log, deployEventData)
+	})
+}
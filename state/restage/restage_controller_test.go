@@ -0,0 +1,109 @@
+package restage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/compozed/deployadactyl/config"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	. "github.com/compozed/deployadactyl/state/restage"
+	"github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("RestageDeployment", func() {
+	var (
+		restageManagerFactory *mocks.RestageManagerFactory
+		eventManager          *mocks.EventManager
+		controller            *RestageController
+		deployment            *I.Deployment
+		logBuffer             *Buffer
+		deployer              *mocks.Deployer
+		environment           string
+		response              *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		logBuffer = NewBuffer()
+		environment = "environment-" + randomizer.StringRunes(10)
+
+		eventManager = &mocks.EventManager{}
+		deployer = &mocks.Deployer{}
+		restageManagerFactory = &mocks.RestageManagerFactory{}
+
+		controller = &RestageController{
+			Log:                   I.DeploymentLogger{Log: I.DefaultLogger(logBuffer, logging.DEBUG, "api_test"), UUID: "uuid-" + randomizer.StringRunes(10)},
+			Deployer:              deployer,
+			RestageManagerFactory: restageManagerFactory,
+			EventManager:          eventManager,
+			Config:                config.Config{Environments: map[string]structs.Environment{environment: {Name: environment}}},
+			ErrorFinder:           &mocks.ErrorFinder{},
+		}
+		response = &bytes.Buffer{}
+
+		deployment = &I.Deployment{
+			Authorization: I.Authorization{Username: "myUser", Password: "myPassword"},
+			CFContext: I.CFContext{
+				Organization: "myOrg",
+				Space:        "mySpace",
+				Application:  "myApp",
+				Environment:  environment,
+			},
+		}
+	})
+
+	It("should log the restage", func() {
+		deploymentResponse := controller.RestageDeployment(context.Background(), deployment, nil, response)
+
+		Expect(logBuffer).Should(Say(fmt.Sprintf("Preparing to restage %s with UUID %s", "myApp", deploymentResponse.DeploymentInfo.UUID)))
+	})
+
+	It("should deploy with the restage manager", func() {
+		manager := &mocks.StartManager{}
+		restageManagerFactory.RestageManagerCall.Returns.ActionCreater = manager
+
+		controller.RestageDeployment(context.Background(), deployment, nil, response)
+
+		Expect(restageManagerFactory.RestageManagerCall.Received.DeployEventData.DeploymentInfo.Username).Should(Equal("myUser"))
+		Expect(deployer.DeployCall.Received.ActionCreator).Should(Equal(manager))
+	})
+
+	It("should emit the restage events", func() {
+		controller.RestageDeployment(context.Background(), deployment, nil, response)
+
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).Should(Equal(reflect.TypeOf(RestageStartedEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).Should(Equal(reflect.TypeOf(RestageSuccessEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[2])).Should(Equal(reflect.TypeOf(RestageFinishedEvent{})))
+		Expect(eventManager.EmitEventCall.Received.Events[1].(RestageSuccessEvent).CFContext.Application).Should(Equal("myApp"))
+	})
+
+	It("should emit a RestageFailureEvent when the restage fails", func() {
+		deployer.DeployCall.Returns.Error = errors.New("deploy error")
+
+		controller.RestageDeployment(context.Background(), deployment, nil, response)
+
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).Should(Equal(reflect.TypeOf(RestageFailureEvent{})))
+		Expect(eventManager.EmitEventCall.Received.Events[1].(RestageFailureEvent).Error).Should(MatchError("deploy error"))
+	})
+
+	It("should refuse the restage when the environment does not allow it", func() {
+		controller.Config.Environments[environment] = structs.Environment{
+			Name:   environment,
+			Access: []structs.AccessRule{{Users: []string{"myUser"}, Operations: []string{"start"}}},
+		}
+
+		deploymentResponse := controller.RestageDeployment(context.Background(), deployment, nil, response)
+
+		Expect(deploymentResponse.StatusCode).Should(Equal(http.StatusForbidden))
+		Expect(deployer.DeployCall.Called).Should(Equal(0))
+	})
+})
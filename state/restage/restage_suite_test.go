@@ -0,0 +1,13 @@
+package restage_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRestage(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Restage Suite")
+}
@@ -0,0 +1,71 @@
+package restage
+
+import (
+	"context"
+	"io"
+
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/operation"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+var messages = operation.Messages{Operation: "restage", Done: "restaged", Foundations: "on all foundations"}
+
+type RestageManager struct {
+	CourierCreator  operation.CourierCreator
+	EventManager    I.EventManager
+	Logger          I.DeploymentLogger
+	DeployEventData S.DeployEventData
+}
+
+func (a RestageManager) SetUp(ctx context.Context) error {
+	return nil
+}
+
+func (a RestageManager) OnStart() error {
+	return nil
+}
+
+func (a RestageManager) OnFinish(env S.Environment, response io.ReadWriter, err error) I.DeployResponse {
+	return messages.OnFinish(a.Logger, response, a.DeployEventData.DeploymentInfo.AppName, err)
+}
+
+func (a RestageManager) CleanUp() {}
+
+func (a RestageManager) Create(environment S.Environment, response io.ReadWriter, foundationURL string) (I.Action, error) {
+	courier, err := operation.NewCourier(a.CourierCreator, environment)
+	if err != nil {
+		a.Logger.Error(err)
+		return &Restager{}, err
+	}
+	r := &Restager{
+		Courier:       courier,
+		CFContext:     operation.CFContext(environment, a.DeployEventData.DeploymentInfo),
+		Authorization: operation.Authorization(a.DeployEventData.DeploymentInfo),
+		EventManager:  a.EventManager,
+		Response:      response,
+		Log:           a.Logger.WithFields(I.LogFields{I.FoundationLogField: foundationURL, I.AppLogField: a.DeployEventData.DeploymentInfo.AppName}),
+		FoundationURL: foundationURL,
+		AppName:       a.DeployEventData.DeploymentInfo.AppName,
+	}
+
+	return r, nil
+}
+
+func (a RestageManager) InitiallyError(initiallyErrors []error) error {
+	return bluegreen.LoginError{LoginErrors: initiallyErrors}
+}
+
+func (a RestageManager) ExecuteError(executeErrors []error) error {
+	return bluegreen.RestageError{Errors: executeErrors}
+}
+
+// UndoError returns the errors of the restage, since a restaged application can not be rolled back.
+func (a RestageManager) UndoError(executeErrors, undoErrors []error) error {
+	return bluegreen.RestageError{Errors: executeErrors}
+}
+
+func (a RestageManager) SuccessError(successErrors []error) error {
+	return bluegreen.FinishRestageError{FinishRestageErrors: successErrors}
+}
@@ -0,0 +1,102 @@
+package restage_test
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/state/restage"
+	"github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+type courierCreator struct {
+	CourierCreatorFn func() (interfaces.Courier, error)
+}
+
+func (c courierCreator) CreateCourier() (interfaces.Courier, error) {
+	if c.CourierCreatorFn != nil {
+		return c.CourierCreatorFn()
+	}
+	return &mocks.Courier{}, nil
+}
+
+var _ = Describe("RestageManager", func() {
+	var (
+		response       *gbytes.Buffer
+		restageManager restage.RestageManager
+		creator        *courierCreator
+	)
+
+	BeforeEach(func() {
+		response = gbytes.NewBuffer()
+		creator = &courierCreator{}
+		restageManager = restage.RestageManager{
+			CourierCreator: creator,
+			Logger:         interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(gbytes.NewBuffer(), logging.DEBUG, "restagemanager_test"), UUID: randomizer.StringRunes(10)},
+			DeployEventData: structs.DeployEventData{
+				DeploymentInfo: &structs.DeploymentInfo{AppName: "myApp", Username: "bob"},
+				Response:       response,
+			},
+		}
+	})
+
+	Describe("Create", func() {
+		It("returns a Restager for the foundation", func() {
+			action, err := restageManager.Create(structs.Environment{Name: "myEnv"}, response, "foundation url")
+			Expect(err).ToNot(HaveOccurred())
+
+			restager := action.(*restage.Restager)
+			Expect(restager.AppName).To(Equal("myApp"))
+			Expect(restager.CFContext.Environment).To(Equal("myEnv"))
+			Expect(restager.Authorization.Username).To(Equal("bob"))
+			Expect(restager.FoundationURL).To(Equal("foundation url"))
+		})
+
+		It("returns an error when the courier can not be created", func() {
+			creator.CourierCreatorFn = func() (interfaces.Courier, error) {
+				return nil, errors.New("a test error")
+			}
+
+			_, err := restageManager.Create(structs.Environment{}, response, "foundation url")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("a test error"))
+		})
+	})
+
+	Describe("UndoError", func() {
+		It("returns the restage errors, since a restage is not rolled back", func() {
+			err := restageManager.UndoError([]error{errors.New("execute error")}, []error{errors.New("undo error")})
+
+			Expect(err).To(Equal(bluegreen.RestageError{Errors: []error{errors.New("execute error")}}))
+		})
+	})
+
+	Describe("OnFinish", func() {
+		It("returns http status OK when no error occurs", func() {
+			deployResponse := restageManager.OnFinish(structs.Environment{}, response, nil)
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
+			Eventually(response).Should(gbytes.Say("Your restage was successful!"))
+		})
+
+		It("returns a bad request when login fails", func() {
+			deployResponse := restageManager.OnFinish(structs.Environment{}, response, errors.New("login failed"))
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns an internal server error for other errors", func() {
+			deployResponse := restageManager.OnFinish(structs.Environment{}, response, errors.New("a test error"))
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package restage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state"
+)
+
+// Restager restages an application on a single foundation, so it is staged again with the latest buildpacks and
+// environment variables and started with the new droplet.
+type Restager struct {
+	Courier       I.Courier
+	CFContext     I.CFContext
+	Authorization I.Authorization
+	EventManager  I.EventManager
+	Response      io.ReadWriter
+	Log           I.DeploymentLogger
+	FoundationURL string
+	AppName       string
+}
+
+func (r Restager) Verify(ctx context.Context) error {
+	return nil
+}
+
+func (r Restager) Success(ctx context.Context) error {
+	return nil
+}
+
+// Finally removes the temporary directory created by the Executor.
+func (r Restager) Finally(ctx context.Context) error {
+	return r.Courier.CleanUp()
+}
+
+// Undo does nothing, since the droplet an application was restaged with can not be rolled back.
+func (r Restager) Undo(ctx context.Context) error {
+	return nil
+}
+
+// Login will login to a Cloud Foundry instance.
+func (r Restager) Initially(ctx context.Context) error {
+	r.Courier = r.Courier.WithContext(ctx)
+
+	r.Log.Debugf(
+		`logging into cloud foundry with parameters:
+		foundation URL: %+v
+		username: %+v
+		org: %+v
+		space: %+v`,
+		r.FoundationURL, r.Authorization.Username, r.CFContext.Organization, r.CFContext.Space,
+	)
+
+	output, err := r.Courier.Login(
+		r.FoundationURL,
+		r.Authorization.Username,
+		r.Authorization.Password,
+		r.CFContext.Organization,
+		r.CFContext.Space,
+		r.CFContext.SkipSSL,
+	)
+	r.Response.Write(output)
+	if err != nil {
+		r.Log.Errorf("could not login to %s", r.FoundationURL)
+		return state.LoginError{FoundationURL: r.FoundationURL, Out: output}
+	}
+
+	r.Log.Infof("logged into cloud foundry %s", r.FoundationURL)
+
+	return nil
+}
+
+// Execute restages the application and waits until it is started again. The recent logs of the application are
+// written to the response when it can not be restaged.
+func (r Restager) Execute(ctx context.Context) error {
+	r.Courier = r.Courier.WithContext(ctx)
+
+	if r.Courier.Exists(r.AppName) != true {
+		r.Log.Errorf("failed to restage app on foundation %s: application doesn't exist", r.FoundationURL)
+		return state.ExistsError{ApplicationName: r.AppName}
+	}
+
+	r.Log.Infof("restaging app %s", r.AppName)
+
+	output, err := r.Courier.Restage(r.AppName)
+	r.Response.Write(output)
+	if err != nil {
+		r.Log.Errorf("failed to restage app on foundation %s: %s", r.FoundationURL, err.Error())
+		r.writeRecentLogs()
+		return state.RestageError{ApplicationName: r.AppName, Out: output}
+	}
+
+	fmt.Fprintf(r.Response, "\nrestaged %s on %s\n", r.AppName, r.FoundationURL)
+	r.Log.Infof("successfully restaged app %s", r.AppName)
+
+	return nil
+}
+
+// writeRecentLogs writes the recent logs of the application to the response so a failed restage can be diagnosed.
+func (r Restager) writeRecentLogs() {
+	logs, err := r.Courier.Logs(r.AppName)
+	if err != nil {
+		r.Log.Errorf("could not get the logs of app %s: %s", r.AppName, err)
+		return
+	}
+
+	fmt.Fprintf(r.Response, "\nrecent logs of %s on %s:\n", r.AppName, r.FoundationURL)
+	r.Response.Write(logs)
+}
@@ -0,0 +1,136 @@
+package restage_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/state"
+	. "github.com/compozed/deployadactyl/state/restage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Restager", func() {
+	var (
+		restager Restager
+		courier  *mocks.Courier
+
+		randomUsername      string
+		randomPassword      string
+		randomOrg           string
+		randomSpace         string
+		randomAppName       string
+		randomFoundationURL string
+		response            *Buffer
+		logBuffer           *Buffer
+	)
+
+	BeforeEach(func() {
+		courier = &mocks.Courier{}
+
+		randomFoundationURL = "randomFoundationURL-" + randomizer.StringRunes(10)
+		randomUsername = "randomUsername-" + randomizer.StringRunes(10)
+		randomPassword = "randomPassword-" + randomizer.StringRunes(10)
+		randomOrg = "randomOrg-" + randomizer.StringRunes(10)
+		randomSpace = "randomSpace-" + randomizer.StringRunes(10)
+		randomAppName = "randomAppName-" + randomizer.StringRunes(10)
+
+		response = NewBuffer()
+		logBuffer = NewBuffer()
+
+		restager = Restager{
+			Courier: courier,
+			CFContext: interfaces.CFContext{
+				Organization: randomOrg,
+				Space:        randomSpace,
+				Application:  randomAppName,
+			},
+			Authorization: interfaces.Authorization{
+				Username: randomUsername,
+				Password: randomPassword,
+			},
+			EventManager:  &mocks.EventManager{},
+			Response:      response,
+			Log:           interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(logBuffer, logging.DEBUG, "restager_test")},
+			FoundationURL: randomFoundationURL,
+			AppName:       randomAppName,
+		}
+	})
+
+	Describe("Initially", func() {
+		It("logs into the foundation", func() {
+			courier.LoginCall.Returns.Output = []byte("login succeeded")
+
+			Expect(restager.Initially(context.Background())).To(Succeed())
+
+			Expect(courier.LoginCall.Received.FoundationURL).To(Equal(randomFoundationURL))
+			Expect(courier.LoginCall.Received.Username).To(Equal(randomUsername))
+			Expect(courier.LoginCall.Received.Password).To(Equal(randomPassword))
+			Expect(courier.LoginCall.Received.Org).To(Equal(randomOrg))
+			Expect(courier.LoginCall.Received.Space).To(Equal(randomSpace))
+			Eventually(response).Should(Say("login succeeded"))
+		})
+
+		It("returns an error when login fails", func() {
+			courier.LoginCall.Returns.Output = []byte("login output")
+			courier.LoginCall.Returns.Error = errors.New("login error")
+
+			err := restager.Initially(context.Background())
+
+			Expect(err).To(MatchError(state.LoginError{FoundationURL: randomFoundationURL, Out: []byte("login output")}))
+		})
+	})
+
+	Describe("Execute", func() {
+		BeforeEach(func() {
+			courier.ExistsCall.Returns.Bool = true
+		})
+
+		It("restages the application", func() {
+			courier.RestageCall.Returns.Output = []byte("staging app")
+
+			Expect(restager.Execute(context.Background())).To(Succeed())
+
+			Expect(courier.RestageCall.Received.AppName).To(Equal(randomAppName))
+			Eventually(response).Should(Say("staging app"))
+			Eventually(response).Should(Say("restaged %s on %s", randomAppName, randomFoundationURL))
+		})
+
+		It("returns an error when the application does not exist", func() {
+			courier.ExistsCall.Returns.Bool = false
+
+			err := restager.Execute(context.Background())
+
+			Expect(err).To(MatchError(state.ExistsError{ApplicationName: randomAppName}))
+			Expect(courier.RestageCall.Received.AppName).To(BeEmpty())
+		})
+
+		It("returns an error with the recent logs when the application can not be restaged", func() {
+			courier.RestageCall.Returns.Output = []byte("staging failed")
+			courier.RestageCall.Returns.Error = errors.New("exit status 1")
+			courier.LogsCall.Returns.Output = []byte("buildpack compile failed")
+
+			err := restager.Execute(context.Background())
+
+			Expect(err).To(MatchError(state.RestageError{ApplicationName: randomAppName, Out: []byte("staging failed")}))
+			Eventually(response).Should(Say("staging failed"))
+			Eventually(response).Should(Say("recent logs of %s on %s", randomAppName, randomFoundationURL))
+			Eventually(response).Should(Say("buildpack compile failed"))
+		})
+	})
+
+	It("can not be rolled back", func() {
+		Expect(restager.Undo(context.Background())).To(Succeed())
+		Expect(courier.RestageCall.Received.AppName).To(BeEmpty())
+	})
+
+	It("cleans up the courier in Finally", func() {
+		Expect(restager.Finally(context.Background())).To(Succeed())
+		Expect(courier.CleanUpCall.Called).To(BeTrue())
+	})
+})
@@ -0,0 +1,62 @@
+package restart
+
+import (
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/operation"
+)
+
+// events make the events of a restart.
+var events = operation.Events{
+	Started:  func(e operation.Event) I.IEvent { return RestartStartedEvent(e) },
+	Success:  func(e operation.Event) I.IEvent { return RestartSuccessEvent(e) },
+	Failure:  func(e operation.Event) I.IEvent { return RestartFailureEvent(e) },
+	Finished: func(e operation.Event) I.IEvent { return RestartFinishedEvent(e) },
+}
+
+type RestartFailureEvent operation.Event
+
+func (e RestartFailureEvent) Name() string {
+	return "RestartFailureEvent"
+}
+
+func NewRestartFailureEventBinding(handler func(event RestartFailureEvent) error) I.Binding {
+	return operation.NewBinding(RestartFailureEvent{}, func(event interface{}) error {
+		return handler(event.(RestartFailureEvent))
+	})
+}
+
+type RestartSuccessEvent operation.Event
+
+func (e RestartSuccessEvent) Name() string {
+	return "RestartSuccessEvent"
+}
+
+func NewRestartSuccessEventBinding(handler func(event RestartSuccessEvent) error) I.Binding {
+	return operation.NewBinding(RestartSuccessEvent{}, func(event interface{}) error {
+		return handler(event.(RestartSuccessEvent))
+	})
+}
+
+type RestartStartedEvent operation.Event
+
+func (e RestartStartedEvent) Name() string {
+	return "RestartStartedEvent"
+}
+
+func NewRestartStartedEventBinding(handler func(event RestartStartedEvent) error) I.Binding {
+	return operation.NewBinding(RestartStartedEvent{}, func(event interface{}) error {
+		return handler(event.(RestartStartedEvent))
+	})
+}
+
+type RestartFinishedEvent operation.Event
+
+func (e RestartFinishedEvent) Name() string {
+	return "RestartFinishedEvent"
+}
+
+func NewRestartFinishedEventBinding(handler func(event RestartFinishedEvent) error) I.Binding {
+	return operation.NewBinding(RestartFinishedEvent{}, func(event interface{}) error {
+		return handler(event.(RestartFinishedEvent))
+	})
+}
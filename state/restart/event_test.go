@@ -0,0 +1,98 @@
+package restart_test
+
+import (
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/restart"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("event binding", func() {
+	Describe("RestartStartedEventBinding", func() {
+		It("should accept a RestartStartedEvent only", func() {
+			binding := restart.NewRestartStartedEventBinding(nil)
+
+			Expect(binding.Accepts(restart.RestartStartedEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received restart.RestartStartedEvent
+			binding := restart.NewRestartStartedEventBinding(func(event restart.RestartStartedEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(restart.RestartStartedEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("RestartSuccessEventBinding", func() {
+		It("should accept a RestartSuccessEvent only", func() {
+			binding := restart.NewRestartSuccessEventBinding(nil)
+
+			Expect(binding.Accepts(restart.RestartSuccessEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received restart.RestartSuccessEvent
+			binding := restart.NewRestartSuccessEventBinding(func(event restart.RestartSuccessEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(restart.RestartSuccessEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("RestartFailureEventBinding", func() {
+		It("should accept a RestartFailureEvent only", func() {
+			binding := restart.NewRestartFailureEventBinding(nil)
+
+			Expect(binding.Accepts(restart.RestartFailureEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received restart.RestartFailureEvent
+			binding := restart.NewRestartFailureEventBinding(func(event restart.RestartFailureEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(restart.RestartFailureEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("RestartFinishedEventBinding", func() {
+		It("should accept a RestartFinishedEvent only", func() {
+			binding := restart.NewRestartFinishedEventBinding(nil)
+
+			Expect(binding.Accepts(restart.RestartFinishedEvent{})).Should(Equal(true))
+			Expect(binding.Accepts(interfaces.Event{})).Should(Equal(false))
+		})
+
+		It("should invoke the handler with the event", func() {
+			var received restart.RestartFinishedEvent
+			binding := restart.NewRestartFinishedEventBinding(func(event restart.RestartFinishedEvent) error {
+				received = event
+				return nil
+			})
+
+			Expect(binding.Emit(restart.RestartFinishedEvent{Metadata: map[string]string{"key": "value"}})).To(Succeed())
+
+			Expect(received.Metadata).Should(Equal(map[string]string{"key": "value"}))
+			Expect(binding.Emit(interfaces.Event{})).ShouldNot(Succeed())
+		})
+	})
+})
@@ -0,0 +1,50 @@
+package restart
+
+import (
+	"context"
+	"io"
+
+	"github.com/compozed/deployadactyl/config"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/rbac"
+	"github.com/compozed/deployadactyl/state/operation"
+	"github.com/compozed/deployadactyl/structs"
+)
+
+type RestartControllerConstructor func(log I.DeploymentLogger, deployer I.Deployer, conf config.Config, eventManager I.EventManager, errorFinder I.ErrorFinder, restartManagerFactory I.RestartManagerFactory) I.RestartController
+
+func NewRestartController(l I.DeploymentLogger, d I.Deployer, c config.Config, em I.EventManager, ef I.ErrorFinder, rmf I.RestartManagerFactory) I.RestartController {
+	return &RestartController{
+		Deployer:              d,
+		Config:                c,
+		EventManager:          em,
+		ErrorFinder:           ef,
+		RestartManagerFactory: rmf,
+		Log:                   l,
+	}
+}
+
+// RestartController restarts the instances of an application a batch at a time on every foundation.
+type RestartController struct {
+	Log                   I.DeploymentLogger
+	RestartManagerFactory I.RestartManagerFactory
+	Deployer              I.Deployer
+	Config                config.Config
+	EventManager          I.EventManager
+	ErrorFinder           I.ErrorFinder
+}
+
+func (c *RestartController) RestartDeployment(ctx context.Context, deployment *I.Deployment, data structs.Params, response io.ReadWriter) I.DeployResponse {
+	controller := operation.Controller{
+		Log:          c.Log,
+		Deployer:     c.Deployer,
+		Config:       c.Config,
+		EventManager: c.EventManager,
+		ErrorFinder:  c.ErrorFinder,
+		Operation:    rbac.Restart,
+		Events:       events,
+	}
+	return controller.Run(ctx, deployment, data, response, func(deployEventData structs.DeployEventData) I.ActionCreator {
+		return c.RestartManagerFactory.RestartManager(c.Log, deployEventData)
+	})
+}
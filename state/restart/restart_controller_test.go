@@ -0,0 +1,111 @@
+package restart_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/compozed/deployadactyl/config"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	. "github.com/compozed/deployadactyl/state/restart"
+	"github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("RestartDeployment", func() {
+	var (
+		restartManagerFactory *mocks.RestartManagerFactory
+		eventManager          *mocks.EventManager
+		controller            *RestartController
+		deployment            *I.Deployment
+		logBuffer             *Buffer
+		deployer              *mocks.Deployer
+		environment           string
+		response              *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		logBuffer = NewBuffer()
+		environment = "environment-" + randomizer.StringRunes(10)
+
+		eventManager = &mocks.EventManager{}
+		deployer = &mocks.Deployer{}
+		restartManagerFactory = &mocks.RestartManagerFactory{}
+
+		controller = &RestartController{
+			Log:                   I.DeploymentLogger{Log: I.DefaultLogger(logBuffer, logging.DEBUG, "api_test"), UUID: "uuid-" + randomizer.StringRunes(10)},
+			Deployer:              deployer,
+			RestartManagerFactory: restartManagerFactory,
+			EventManager:          eventManager,
+			Config:                config.Config{Environments: map[string]structs.Environment{environment: {Name: environment}}},
+			ErrorFinder:           &mocks.ErrorFinder{},
+		}
+		response = &bytes.Buffer{}
+
+		deployment = &I.Deployment{
+			Authorization: I.Authorization{Username: "myUser", Password: "myPassword"},
+			CFContext: I.CFContext{
+				Organization: "myOrg",
+				Space:        "mySpace",
+				Application:  "myApp",
+				Environment:  environment,
+			},
+			BatchSize: 3,
+		}
+	})
+
+	It("should log the restart", func() {
+		deploymentResponse := controller.RestartDeployment(context.Background(), deployment, nil, response)
+
+		Expect(logBuffer).Should(Say(fmt.Sprintf("Preparing to restart %s with UUID %s", "myApp", deploymentResponse.DeploymentInfo.UUID)))
+	})
+
+	It("should deploy with the restart manager and the batch size", func() {
+		manager := &mocks.StartManager{}
+		restartManagerFactory.RestartManagerCall.Returns.ActionCreater = manager
+
+		controller.RestartDeployment(context.Background(), deployment, nil, response)
+
+		Expect(restartManagerFactory.RestartManagerCall.Received.DeployEventData.DeploymentInfo.Username).Should(Equal("myUser"))
+		Expect(restartManagerFactory.RestartManagerCall.Received.DeployEventData.DeploymentInfo.BatchSize).Should(Equal(3))
+		Expect(deployer.DeployCall.Received.ActionCreator).Should(Equal(manager))
+	})
+
+	It("should emit the restart events", func() {
+		controller.RestartDeployment(context.Background(), deployment, nil, response)
+
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).Should(Equal(reflect.TypeOf(RestartStartedEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).Should(Equal(reflect.TypeOf(RestartSuccessEvent{})))
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[2])).Should(Equal(reflect.TypeOf(RestartFinishedEvent{})))
+		Expect(eventManager.EmitEventCall.Received.Events[1].(RestartSuccessEvent).CFContext.Application).Should(Equal("myApp"))
+	})
+
+	It("should emit a RestartFailureEvent when the restart fails", func() {
+		deployer.DeployCall.Returns.Error = errors.New("deploy error")
+
+		controller.RestartDeployment(context.Background(), deployment, nil, response)
+
+		Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).Should(Equal(reflect.TypeOf(RestartFailureEvent{})))
+		Expect(eventManager.EmitEventCall.Received.Events[1].(RestartFailureEvent).Error).Should(MatchError("deploy error"))
+	})
+
+	It("should refuse the restart when the environment does not allow it", func() {
+		controller.Config.Environments[environment] = structs.Environment{
+			Name:   environment,
+			Access: []structs.AccessRule{{Users: []string{"myUser"}, Operations: []string{"start"}}},
+		}
+
+		deploymentResponse := controller.RestartDeployment(context.Background(), deployment, nil, response)
+
+		Expect(deploymentResponse.StatusCode).Should(Equal(http.StatusForbidden))
+		Expect(deployer.DeployCall.Called).Should(Equal(0))
+	})
+})
@@ -0,0 +1,208 @@
+package restart
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+const (
+	// DefaultRestartTimeout is how long each batch of restarted instances is given to be running again.
+	DefaultRestartTimeout = 5 * time.Minute
+
+	// DefaultPollInterval is how often the restarted instances are checked.
+	DefaultPollInterval = 2 * time.Second
+)
+
+// Restarter restarts the instances of an application on a single foundation, BatchSize instances at a time.
+// Each batch has to be running again before the next one is restarted, so the application keeps serving.
+type Restarter struct {
+	Courier        I.Courier
+	CFContext      I.CFContext
+	Authorization  I.Authorization
+	EventManager   I.EventManager
+	Response       io.ReadWriter
+	Log            I.DeploymentLogger
+	FoundationURL  string
+	AppName        string
+	BatchSize      int
+	RestartTimeout time.Duration
+	PollInterval   time.Duration
+}
+
+func (r Restarter) Verify(ctx context.Context) error {
+	return nil
+}
+
+func (r Restarter) Success(ctx context.Context) error {
+	return nil
+}
+
+// Finally removes the temporary directory created by the Executor.
+func (r Restarter) Finally(ctx context.Context) error {
+	return r.Courier.CleanUp()
+}
+
+// Undo does nothing, since restarted instances can not be rolled back.
+func (r Restarter) Undo(ctx context.Context) error {
+	return nil
+}
+
+// Login will login to a Cloud Foundry instance.
+func (r Restarter) Initially(ctx context.Context) error {
+	r.Courier = r.Courier.WithContext(ctx)
+
+	r.Log.Debugf(
+		`logging into cloud foundry with parameters:
+		foundation URL: %+v
+		username: %+v
+		org: %+v
+		space: %+v`,
+		r.FoundationURL, r.Authorization.Username, r.CFContext.Organization, r.CFContext.Space,
+	)
+
+	output, err := r.Courier.Login(
+		r.FoundationURL,
+		r.Authorization.Username,
+		r.Authorization.Password,
+		r.CFContext.Organization,
+		r.CFContext.Space,
+		r.CFContext.SkipSSL,
+	)
+	r.Response.Write(output)
+	if err != nil {
+		r.Log.Errorf("could not login to %s", r.FoundationURL)
+		return state.LoginError{FoundationURL: r.FoundationURL, Out: output}
+	}
+
+	r.Log.Infof("logged into cloud foundry %s", r.FoundationURL)
+
+	return nil
+}
+
+func (r Restarter) Execute(ctx context.Context) error {
+	r.Courier = r.Courier.WithContext(ctx)
+
+	if r.Courier.Exists(r.AppName) != true {
+		r.Log.Errorf("failed to restart app on foundation %s: application doesn't exist", r.FoundationURL)
+		return state.ExistsError{ApplicationName: r.AppName}
+	}
+
+	instances, err := r.Courier.Instances(r.AppName)
+	if err != nil {
+		r.Log.Errorf("failed to restart app on foundation %s: %s", r.FoundationURL, err.Error())
+		return err
+	}
+
+	batchSize := r.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	for start := 0; start < len(instances); start += batchSize {
+		end := start + batchSize
+		if end > len(instances) {
+			end = len(instances)
+		}
+		batch := instances[start:end]
+
+		restartedAt := time.Now()
+		for _, instance := range batch {
+			r.Log.Infof("restarting instance %d of app %s", instance.Index, r.AppName)
+
+			output, err := r.Courier.RestartInstance(r.AppName, instance.Index)
+			if err != nil {
+				r.Log.Errorf("failed to restart app on foundation %s: %s", r.FoundationURL, err.Error())
+				return state.RestartInstanceError{ApplicationName: r.AppName, Index: instance.Index, Out: output}
+			}
+			r.Response.Write(output)
+		}
+
+		err := r.waitUntilRunning(ctx, batch, restartedAt)
+		if err != nil {
+			r.Log.Errorf("failed to restart app on foundation %s: %s", r.FoundationURL, err.Error())
+			r.writeRecentLogs()
+			return err
+		}
+
+		fmt.Fprintf(r.Response, "\nrestarted %d of %d instances of %s on %s\n", end, len(instances), r.AppName, r.FoundationURL)
+	}
+
+	r.Log.Infof("successfully restarted app %s", r.AppName)
+
+	return nil
+}
+
+// waitUntilRunning polls the instances of the application until every instance of the batch is running again.
+// An instance counts as restarted once it has been up for less time than has passed since it was restarted.
+func (r Restarter) waitUntilRunning(ctx context.Context, batch []S.Instance, restartedAt time.Time) error {
+	timeout := r.RestartTimeout
+	if timeout <= 0 {
+		timeout = DefaultRestartTimeout
+	}
+	interval := r.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		pending := batch[0].Index
+
+		instances, err := r.Courier.Instances(r.AppName)
+		if err != nil {
+			r.Log.Errorf("could not read the instances of app %s: %s", r.AppName, err)
+		} else {
+			pending = -1
+			for _, restarted := range batch {
+				instance, found := findInstance(instances, restarted.Index)
+				if found && instance.State == "CRASHED" {
+					return state.InstanceCrashedError{ApplicationName: r.AppName, FoundationURL: r.FoundationURL}
+				}
+				if !found || instance.State != "RUNNING" || instance.Uptime > time.Since(restartedAt) {
+					pending = restarted.Index
+					break
+				}
+			}
+			if pending < 0 {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return state.RestartTimeoutError{ApplicationName: r.AppName, FoundationURL: r.FoundationURL, Index: pending, Timeout: timeout}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// writeRecentLogs writes the recent logs of the application to the response so a failed restart can be diagnosed.
+func (r Restarter) writeRecentLogs() {
+	logs, err := r.Courier.Logs(r.AppName)
+	if err != nil {
+		r.Log.Errorf("could not get the logs of app %s: %s", r.AppName, err)
+		return
+	}
+
+	fmt.Fprintf(r.Response, "\nrecent logs of %s on %s:\n", r.AppName, r.FoundationURL)
+	r.Response.Write(logs)
+}
+
+func findInstance(instances []S.Instance, index int) (S.Instance, bool) {
+	for _, instance := range instances {
+		if instance.Index == index {
+			return instance, true
+		}
+	}
+	return S.Instance{}, false
+}
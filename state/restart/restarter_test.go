@@ -0,0 +1,204 @@
+package restart_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/state"
+	. "github.com/compozed/deployadactyl/state/restart"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Restarter", func() {
+	var (
+		restarter Restarter
+		courier   *mocks.Courier
+
+		randomUsername      string
+		randomPassword      string
+		randomOrg           string
+		randomSpace         string
+		randomAppName       string
+		randomFoundationURL string
+		response            *Buffer
+		logBuffer           *Buffer
+	)
+
+	running := func(indexes ...int) []S.Instance {
+		instances := []S.Instance{}
+		for _, index := range indexes {
+			instances = append(instances, S.Instance{Index: index, State: "RUNNING"})
+		}
+		return instances
+	}
+
+	BeforeEach(func() {
+		courier = &mocks.Courier{}
+
+		randomFoundationURL = "randomFoundationURL-" + randomizer.StringRunes(10)
+		randomUsername = "randomUsername-" + randomizer.StringRunes(10)
+		randomPassword = "randomPassword-" + randomizer.StringRunes(10)
+		randomOrg = "randomOrg-" + randomizer.StringRunes(10)
+		randomSpace = "randomSpace-" + randomizer.StringRunes(10)
+		randomAppName = "randomAppName-" + randomizer.StringRunes(10)
+
+		response = NewBuffer()
+		logBuffer = NewBuffer()
+
+		restarter = Restarter{
+			Courier: courier,
+			CFContext: interfaces.CFContext{
+				Organization: randomOrg,
+				Space:        randomSpace,
+				Application:  randomAppName,
+			},
+			Authorization: interfaces.Authorization{
+				Username: randomUsername,
+				Password: randomPassword,
+			},
+			EventManager:   &mocks.EventManager{},
+			Response:       response,
+			Log:            interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(logBuffer, logging.DEBUG, "restarter_test")},
+			FoundationURL:  randomFoundationURL,
+			AppName:        randomAppName,
+			RestartTimeout: time.Second,
+			PollInterval:   time.Millisecond,
+		}
+	})
+
+	Describe("Initially", func() {
+		It("logs into the foundation", func() {
+			courier.LoginCall.Returns.Output = []byte("login succeeded")
+
+			Expect(restarter.Initially(context.Background())).To(Succeed())
+
+			Expect(courier.LoginCall.Received.FoundationURL).To(Equal(randomFoundationURL))
+			Expect(courier.LoginCall.Received.Username).To(Equal(randomUsername))
+			Expect(courier.LoginCall.Received.Password).To(Equal(randomPassword))
+			Expect(courier.LoginCall.Received.Org).To(Equal(randomOrg))
+			Expect(courier.LoginCall.Received.Space).To(Equal(randomSpace))
+			Eventually(response).Should(Say("login succeeded"))
+		})
+
+		It("returns an error when login fails", func() {
+			courier.LoginCall.Returns.Output = []byte("login output")
+			courier.LoginCall.Returns.Error = errors.New("login error")
+
+			err := restarter.Initially(context.Background())
+
+			Expect(err).To(MatchError(state.LoginError{FoundationURL: randomFoundationURL, Out: []byte("login output")}))
+		})
+	})
+
+	Describe("Execute", func() {
+		BeforeEach(func() {
+			courier.ExistsCall.Returns.Bool = true
+		})
+
+		It("restarts one instance at a time, waiting for each to be running", func() {
+			courier.InstancesCall.Returns.Instances = [][]S.Instance{running(0, 1, 2)}
+
+			Expect(restarter.Execute(context.Background())).To(Succeed())
+
+			Expect(courier.RestartInstanceCall.Received.AppName).To(Equal(randomAppName))
+			Expect(courier.RestartInstanceCall.Received.Indexes).To(Equal([]int{0, 1, 2}))
+			Expect(courier.InstancesCall.TimesCalled).To(Equal(4))
+			Eventually(response).Should(Say("restarted 1 of 3 instances of %s", randomAppName))
+			Eventually(response).Should(Say("restarted 3 of 3 instances of %s", randomAppName))
+		})
+
+		It("restarts instances in batches", func() {
+			courier.InstancesCall.Returns.Instances = [][]S.Instance{running(0, 1, 2)}
+			restarter.BatchSize = 2
+
+			Expect(restarter.Execute(context.Background())).To(Succeed())
+
+			Expect(courier.RestartInstanceCall.Received.Indexes).To(Equal([]int{0, 1, 2}))
+			Expect(courier.InstancesCall.TimesCalled).To(Equal(3))
+			Eventually(response).Should(Say("restarted 2 of 3 instances"))
+			Eventually(response).Should(Say("restarted 3 of 3 instances"))
+		})
+
+		It("does not count an instance that has not restarted yet", func() {
+			courier.InstancesCall.Returns.Instances = [][]S.Instance{
+				{{Index: 0, State: "RUNNING", Uptime: time.Hour}},
+				{{Index: 0, State: "RUNNING", Uptime: time.Hour}},
+				{{Index: 0, State: "STARTING"}},
+				{{Index: 0, State: "RUNNING"}},
+			}
+
+			Expect(restarter.Execute(context.Background())).To(Succeed())
+
+			Expect(courier.InstancesCall.TimesCalled).To(Equal(4))
+		})
+
+		It("stops restarting and returns an error with the recent logs when an instance crashes", func() {
+			courier.InstancesCall.Returns.Instances = [][]S.Instance{
+				running(0, 1),
+				{{Index: 0, State: "CRASHED"}, {Index: 1, State: "RUNNING"}},
+			}
+			courier.LogsCall.Returns.Output = []byte("out of memory")
+
+			err := restarter.Execute(context.Background())
+
+			Expect(err).To(MatchError(state.InstanceCrashedError{ApplicationName: randomAppName, FoundationURL: randomFoundationURL}))
+			Expect(courier.RestartInstanceCall.Received.Indexes).To(Equal([]int{0}))
+			Eventually(response).Should(Say("recent logs of %s", randomAppName))
+			Eventually(response).Should(Say("out of memory"))
+		})
+
+		It("returns an error when an instance is not running before the timeout", func() {
+			courier.InstancesCall.Returns.Instances = [][]S.Instance{
+				running(0, 1),
+				{{Index: 0, State: "STARTING"}, {Index: 1, State: "RUNNING"}},
+			}
+			restarter.RestartTimeout = 20 * time.Millisecond
+
+			err := restarter.Execute(context.Background())
+
+			Expect(err).To(MatchError(state.RestartTimeoutError{
+				ApplicationName: randomAppName,
+				FoundationURL:   randomFoundationURL,
+				Index:           0,
+				Timeout:         20 * time.Millisecond,
+			}))
+			Expect(courier.RestartInstanceCall.Received.Indexes).To(Equal([]int{0}))
+		})
+
+		It("returns an error when an instance can not be restarted", func() {
+			courier.InstancesCall.Returns.Instances = [][]S.Instance{running(0)}
+			courier.RestartInstanceCall.Returns.Output = []byte("restart output")
+			courier.RestartInstanceCall.Returns.Error = errors.New("restart failed")
+
+			err := restarter.Execute(context.Background())
+
+			Expect(err).To(MatchError(state.RestartInstanceError{ApplicationName: randomAppName, Index: 0, Out: []byte("restart output")}))
+		})
+
+		It("returns an error when the app does not exist", func() {
+			courier.ExistsCall.Returns.Bool = false
+
+			err := restarter.Execute(context.Background())
+
+			Expect(err).To(MatchError(state.ExistsError{ApplicationName: randomAppName}))
+			Eventually(logBuffer).Should(Say(fmt.Sprintf("failed to restart app on foundation %s", randomFoundationURL)))
+		})
+	})
+
+	Describe("Undo", func() {
+		It("does not restart anything", func() {
+			Expect(restarter.Undo(context.Background())).To(Succeed())
+
+			Expect(courier.RestartInstanceCall.Received.Indexes).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,74 @@
+package restart
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/operation"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+var messages = operation.Messages{Operation: "restart", Done: "restarted", Foundations: "on all foundations"}
+
+type RestartManager struct {
+	CourierCreator  operation.CourierCreator
+	EventManager    I.EventManager
+	Logger          I.DeploymentLogger
+	DeployEventData S.DeployEventData
+}
+
+func (a RestartManager) SetUp(ctx context.Context) error {
+	return nil
+}
+
+func (a RestartManager) OnStart() error {
+	return nil
+}
+
+func (a RestartManager) OnFinish(env S.Environment, response io.ReadWriter, err error) I.DeployResponse {
+	return messages.OnFinish(a.Logger, response, a.DeployEventData.DeploymentInfo.AppName, err)
+}
+
+func (a RestartManager) CleanUp() {}
+
+func (a RestartManager) Create(environment S.Environment, response io.ReadWriter, foundationURL string) (I.Action, error) {
+	courier, err := operation.NewCourier(a.CourierCreator, environment)
+	if err != nil {
+		a.Logger.Error(err)
+		return &Restarter{}, err
+	}
+	r := &Restarter{
+		Courier:        courier,
+		CFContext:      operation.CFContext(environment, a.DeployEventData.DeploymentInfo),
+		Authorization:  operation.Authorization(a.DeployEventData.DeploymentInfo),
+		EventManager:   a.EventManager,
+		Response:       response,
+		Log:            a.Logger.WithFields(I.LogFields{I.FoundationLogField: foundationURL, I.AppLogField: a.DeployEventData.DeploymentInfo.AppName}),
+		FoundationURL:  foundationURL,
+		AppName:        a.DeployEventData.DeploymentInfo.AppName,
+		BatchSize:      a.DeployEventData.DeploymentInfo.BatchSize,
+		RestartTimeout: time.Duration(environment.StartTimeoutSeconds) * time.Second,
+	}
+
+	return r, nil
+}
+
+func (a RestartManager) InitiallyError(initiallyErrors []error) error {
+	return bluegreen.LoginError{LoginErrors: initiallyErrors}
+}
+
+func (a RestartManager) ExecuteError(executeErrors []error) error {
+	return bluegreen.RestartError{Errors: executeErrors}
+}
+
+// UndoError returns the errors of the restart, since restarted instances can not be rolled back.
+func (a RestartManager) UndoError(executeErrors, undoErrors []error) error {
+	return bluegreen.RestartError{Errors: executeErrors}
+}
+
+func (a RestartManager) SuccessError(successErrors []error) error {
+	return bluegreen.FinishRestartError{FinishRestartErrors: successErrors}
+}
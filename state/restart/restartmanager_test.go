@@ -0,0 +1,105 @@
+package restart_test
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	"github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/state/restart"
+	"github.com/compozed/deployadactyl/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+type courierCreator struct {
+	CourierCreatorFn func() (interfaces.Courier, error)
+}
+
+func (c courierCreator) CreateCourier() (interfaces.Courier, error) {
+	if c.CourierCreatorFn != nil {
+		return c.CourierCreatorFn()
+	}
+	return &mocks.Courier{}, nil
+}
+
+var _ = Describe("RestartManager", func() {
+	var (
+		response       *gbytes.Buffer
+		restartManager restart.RestartManager
+		creator        *courierCreator
+	)
+
+	BeforeEach(func() {
+		response = gbytes.NewBuffer()
+		creator = &courierCreator{}
+		restartManager = restart.RestartManager{
+			CourierCreator: creator,
+			Logger:         interfaces.DeploymentLogger{Log: interfaces.DefaultLogger(gbytes.NewBuffer(), logging.DEBUG, "restartmanager_test"), UUID: randomizer.StringRunes(10)},
+			DeployEventData: structs.DeployEventData{
+				DeploymentInfo: &structs.DeploymentInfo{AppName: "myApp", Username: "bob", BatchSize: 2},
+				Response:       response,
+			},
+		}
+	})
+
+	Describe("Create", func() {
+		It("returns a Restarter for the foundation", func() {
+			action, err := restartManager.Create(structs.Environment{Name: "myEnv", StartTimeoutSeconds: 60}, response, "foundation url")
+			Expect(err).ToNot(HaveOccurred())
+
+			restarter := action.(*restart.Restarter)
+			Expect(restarter.AppName).To(Equal("myApp"))
+			Expect(restarter.CFContext.Environment).To(Equal("myEnv"))
+			Expect(restarter.Authorization.Username).To(Equal("bob"))
+			Expect(restarter.FoundationURL).To(Equal("foundation url"))
+			Expect(restarter.BatchSize).To(Equal(2))
+			Expect(restarter.RestartTimeout).To(Equal(time.Minute))
+		})
+
+		It("returns an error when the courier can not be created", func() {
+			creator.CourierCreatorFn = func() (interfaces.Courier, error) {
+				return nil, errors.New("a test error")
+			}
+
+			_, err := restartManager.Create(structs.Environment{}, response, "foundation url")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("a test error"))
+		})
+	})
+
+	Describe("UndoError", func() {
+		It("returns the restart errors, since a restart is not rolled back", func() {
+			err := restartManager.UndoError([]error{errors.New("execute error")}, []error{errors.New("undo error")})
+
+			Expect(err).To(Equal(bluegreen.RestartError{Errors: []error{errors.New("execute error")}}))
+		})
+	})
+
+	Describe("OnFinish", func() {
+		It("returns http status OK when no error occurs", func() {
+			deployResponse := restartManager.OnFinish(structs.Environment{}, response, nil)
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
+			Eventually(response).Should(gbytes.Say("Your restart was successful!"))
+		})
+
+		It("returns a bad request when login fails", func() {
+			deployResponse := restartManager.OnFinish(structs.Environment{}, response, errors.New("login failed"))
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns an internal server error for other errors", func() {
+			deployResponse := restartManager.OnFinish(structs.Environment{}, response, errors.New("a test error"))
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
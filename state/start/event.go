@@ -1,133 +1,62 @@
 package start
 
 import (
-	"reflect"
-
-	"github.com/compozed/deployadactyl/eventmanager"
-	"github.com/compozed/deployadactyl/interfaces"
-	"github.com/compozed/deployadactyl/structs"
-	"github.com/go-errors/errors"
-	"io"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/operation"
 )
 
-type eventBinding struct {
-	etype   reflect.Type
-	handler func(event interface{}) error
+// events make the events of a start.
+var events = operation.Events{
+	Started:  func(e operation.Event) I.IEvent { return StartStartedEvent(e) },
+	Success:  func(e operation.Event) I.IEvent { return StartSuccessEvent(e) },
+	Failure:  func(e operation.Event) I.IEvent { return StartFailureEvent(e) },
+	Finished: func(e operation.Event) I.IEvent { return StartFinishedEvent(e) },
 }
 
-func (s eventBinding) Accepts(event interface{}) bool {
-	return reflect.TypeOf(event) == s.etype
-}
-
-func (b eventBinding) Emit(event interface{}) error {
-	return b.handler(event)
-}
-
-type StartFailureEvent struct {
-	CFContext     interfaces.CFContext
-	Data          map[string]interface{}
-	Environment   structs.Environment
-	Authorization interfaces.Authorization
-	Response      io.ReadWriter
-	Error         error
-	Log           interfaces.DeploymentLogger
-}
+type StartFailureEvent operation.Event
 
 func (e StartFailureEvent) Name() string {
 	return "StartFailureEvent"
 }
 
-func NewStartFailureEventBinding(handler func(event StartFailureEvent) error) interfaces.Binding {
-	return eventBinding{
-		etype: reflect.TypeOf(StartFailureEvent{}),
-		handler: func(gevent interface{}) error {
-			event, ok := gevent.(StartFailureEvent)
-			if ok {
-				return handler(event)
-			} else {
-				return eventmanager.InvalidEventType{Err: errors.New("invalid event type")}
-			}
-		},
-	}
+func NewStartFailureEventBinding(handler func(event StartFailureEvent) error) I.Binding {
+	return operation.NewBinding(StartFailureEvent{}, func(event interface{}) error {
+		return handler(event.(StartFailureEvent))
+	})
 }
 
-type StartSuccessEvent struct {
-	CFContext     interfaces.CFContext
-	Data          map[string]interface{}
-	Environment   structs.Environment
-	Authorization interfaces.Authorization
-	Response      io.ReadWriter
-	Log           interfaces.DeploymentLogger
-}
+type StartSuccessEvent operation.Event
 
 func (e StartSuccessEvent) Name() string {
 	return "StartSuccessEvent"
 }
 
-func NewStartSuccessEventBinding(handler func(event StartSuccessEvent) error) interfaces.Binding {
-	return eventBinding{
-		etype: reflect.TypeOf(StartSuccessEvent{}),
-		handler: func(gevent interface{}) error {
-			event, ok := gevent.(StartSuccessEvent)
-			if ok {
-				return handler(event)
-			} else {
-				return eventmanager.InvalidEventType{Err: errors.New("invalid event type")}
-			}
-		},
-	}
+func NewStartSuccessEventBinding(handler func(event StartSuccessEvent) error) I.Binding {
+	return operation.NewBinding(StartSuccessEvent{}, func(event interface{}) error {
+		return handler(event.(StartSuccessEvent))
+	})
 }
 
-type StartStartedEvent struct {
-	CFContext     interfaces.CFContext
-	Data          map[string]interface{}
-	Environment   structs.Environment
-	Authorization interfaces.Authorization
-	Response      io.ReadWriter
-	Log           interfaces.DeploymentLogger
-}
+type StartStartedEvent operation.Event
 
 func (e StartStartedEvent) Name() string {
 	return "StartStartedEvent"
 }
 
-func NewStartStartedEventBinding(handler func(event StartStartedEvent) error) interfaces.Binding {
-	return eventBinding{
-		etype: reflect.TypeOf(StartStartedEvent{}),
-		handler: func(gevent interface{}) error {
-			event, ok := gevent.(StartStartedEvent)
-			if ok {
-				return handler(event)
-			} else {
-				return eventmanager.InvalidEventType{Err: errors.New("invalid event type")}
-			}
-		},
-	}
+func NewStartStartedEventBinding(handler func(event StartStartedEvent) error) I.Binding {
+	return operation.NewBinding(StartStartedEvent{}, func(event interface{}) error {
+		return handler(event.(StartStartedEvent))
+	})
 }
 
-type StartFinishedEvent struct {
-	CFContext     interfaces.CFContext
-	Data          map[string]interface{}
-	Authorization interfaces.Authorization
-	Response      io.ReadWriter
-	Environment   structs.Environment
-	Log           interfaces.DeploymentLogger
-}
+type StartFinishedEvent operation.Event
 
 func (e StartFinishedEvent) Name() string {
 	return "StartFinishedEvent"
 }
 
-func NewStartFinishedEventBinding(handler func(event StartFinishedEvent) error) interfaces.Binding {
-	return eventBinding{
-		etype: reflect.TypeOf(StartFinishedEvent{}),
-		handler: func(gevent interface{}) error {
-			event, ok := gevent.(StartFinishedEvent)
-			if ok {
-				return handler(event)
-			} else {
-				return eventmanager.InvalidEventType{Err: errors.New("invalid event type")}
-			}
-		},
-	}
+func NewStartFinishedEventBinding(handler func(event StartFinishedEvent) error) I.Binding {
+	return operation.NewBinding(StartFinishedEvent{}, func(event interface{}) error {
+		return handler(event.(StartFinishedEvent))
+	})
 }
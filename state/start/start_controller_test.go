@@ -2,6 +2,7 @@ package start_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -28,24 +29,17 @@ var _ = Describe("StartDeployment", func() {
 		eventManager        *mocks.EventManager
 		errorFinder         *mocks.ErrorFinder
 		controller          *StartController
-		deployment          I.Deployment
 		logBuffer           *Buffer
 		deployer            *mocks.Deployer
 		uuid                string
 
-		appName     string
 		environment string
-		org         string
-		space       string
 		response    *bytes.Buffer
 	)
 
 	BeforeEach(func() {
 		logBuffer = NewBuffer()
-		appName = "appName-" + randomizer.StringRunes(10)
 		environment = "environment-" + randomizer.StringRunes(10)
-		org = "org-" + randomizer.StringRunes(10)
-		space = "non-prod"
 		uuid = "uuid-" + randomizer.StringRunes(10)
 
 		eventManager = &mocks.EventManager{}
@@ -65,16 +59,7 @@ var _ = Describe("StartDeployment", func() {
 		environments := map[string]structs.Environment{}
 		environments[environment] = structs.Environment{}
 		controller.Config.Environments = environments
-		bodyByte := []byte("{}")
 		response = &bytes.Buffer{}
-
-		deployment = I.Deployment{
-			Body:          &bodyByte,
-			Type:          I.DeploymentType{},
-			CFContext:     I.CFContext{},
-			Authorization: I.Authorization{},
-		}
-
 	})
 
 	Context("When UUID is not provided", func() {
@@ -85,7 +70,7 @@ var _ = Describe("StartDeployment", func() {
 					Environment: environment,
 				}}
 			response := bytes.NewBuffer([]byte{})
-			deploymentResponse := controller.StartDeployment(deployment, nil, response)
+			deploymentResponse := controller.StartDeployment(context.Background(), deployment, nil, response)
 
 			Expect(deploymentResponse.DeploymentInfo.UUID).ShouldNot(BeEmpty())
 		})
@@ -102,7 +87,7 @@ var _ = Describe("StartDeployment", func() {
 			},
 		}
 		response := bytes.NewBuffer([]byte{})
-		deploymentResponse := controller.StartDeployment(deployment, nil, response)
+		deploymentResponse := controller.StartDeployment(context.Background(), deployment, nil, response)
 
 		Expect(deploymentResponse.DeploymentInfo.Org).Should(Equal("myOrg"))
 		Expect(deploymentResponse.DeploymentInfo.Environment).Should(Equal(environment))
@@ -121,7 +106,7 @@ var _ = Describe("StartDeployment", func() {
 		}
 
 		response := bytes.NewBuffer([]byte{})
-		deploymentResponse := controller.StartDeployment(deployment, nil, response)
+		deploymentResponse := controller.StartDeployment(context.Background(), deployment, nil, response)
 
 		Expect(logBuffer).Should(Say(fmt.Sprintf("Preparing to start %s with UUID %s", "myApp", deploymentResponse.DeploymentInfo.UUID)))
 
@@ -137,9 +122,9 @@ var _ = Describe("StartDeployment", func() {
 					Environment:  environment,
 				},
 			}
-			data := make(map[string]interface{})
+			data := make(structs.Params)
 			data["mykey"] = "first value"
-			controller.StartDeployment(deployment, data, response)
+			controller.StartDeployment(context.Background(), deployment, data, response)
 
 			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).Should(Equal(reflect.TypeOf(StartStartedEvent{})))
 			event := eventManager.EmitEventCall.Received.Events[0].(StartStartedEvent)
@@ -161,7 +146,7 @@ var _ = Describe("StartDeployment", func() {
 					Environment: environment,
 				},
 			}
-			deployResponse := controller.StartDeployment(deployment, nil, response)
+			deployResponse := controller.StartDeployment(context.Background(), deployment, nil, response)
 
 			Expect(deployResponse.StatusCode).Should(Equal(http.StatusInternalServerError))
 			Expect(reflect.TypeOf(deployResponse.Error)).Should(Equal(reflect.TypeOf(D.EventError{})))
@@ -177,7 +162,7 @@ var _ = Describe("StartDeployment", func() {
 					Environment: "bad environment",
 				}}
 			response := bytes.NewBuffer([]byte{})
-			deploymentResponse := controller.StartDeployment(deployment, nil, response)
+			deploymentResponse := controller.StartDeployment(context.Background(), deployment, nil, response)
 
 			Expect(reflect.TypeOf(deploymentResponse.Error)).Should(Equal(reflect.TypeOf(D.EnvironmentNotFoundError{})))
 		})
@@ -189,7 +174,7 @@ var _ = Describe("StartDeployment", func() {
 			controller.Config.Environments[environment] = structs.Environment{
 				SkipSSL:      true,
 				Domain:       "myDomain",
-				CustomParams: make(map[string]interface{}),
+				CustomParams: make(structs.Params),
 			}
 			controller.Config.Environments[environment].CustomParams["customName"] = "customParams"
 
@@ -199,7 +184,7 @@ var _ = Describe("StartDeployment", func() {
 				}}
 
 			response := bytes.NewBuffer([]byte{})
-			deploymentResponse := controller.StartDeployment(deployment, nil, response)
+			deploymentResponse := controller.StartDeployment(context.Background(), deployment, nil, response)
 			Expect(deploymentResponse.DeploymentInfo.Domain).Should(Equal("myDomain"))
 			Expect(deploymentResponse.DeploymentInfo.SkipSSL).Should(Equal(true))
 			Expect(deploymentResponse.DeploymentInfo.CustomParams["customName"]).Should(Equal("customParams"))
@@ -217,7 +202,7 @@ var _ = Describe("StartDeployment", func() {
 						Environment: environment,
 					}}
 				response := bytes.NewBuffer([]byte{})
-				deploymentResponse := controller.StartDeployment(deployment, nil, response)
+				deploymentResponse := controller.StartDeployment(context.Background(), deployment, nil, response)
 
 				Expect(reflect.TypeOf(deploymentResponse.Error)).Should(Equal(reflect.TypeOf(D.BasicAuthError{})))
 			})
@@ -234,7 +219,7 @@ var _ = Describe("StartDeployment", func() {
 						Environment: environment,
 					}}
 				response := bytes.NewBuffer([]byte{})
-				deploymentResponse := controller.StartDeployment(deployment, nil, response)
+				deploymentResponse := controller.StartDeployment(context.Background(), deployment, nil, response)
 
 				Expect(deploymentResponse.DeploymentInfo.Username).Should(Equal("username"))
 				Expect(deploymentResponse.DeploymentInfo.Password).Should(Equal("password"))
@@ -255,7 +240,7 @@ var _ = Describe("StartDeployment", func() {
 				},
 			}
 			response := bytes.NewBuffer([]byte{})
-			deploymentResponse := controller.StartDeployment(deployment, nil, response)
+			deploymentResponse := controller.StartDeployment(context.Background(), deployment, nil, response)
 			Expect(deploymentResponse.DeploymentInfo.Username).Should(Equal("myUser"))
 			Expect(deploymentResponse.DeploymentInfo.Password).Should(Equal("myPassword"))
 		})
@@ -263,7 +248,7 @@ var _ = Describe("StartDeployment", func() {
 
 	Context("When data is provided", func() {
 		It("should return deployment info with proper data", func() {
-			data := map[string]interface{}{
+			data := structs.Params{
 				"user_id": "myuserid",
 				"group":   "mygroup",
 			}
@@ -273,11 +258,27 @@ var _ = Describe("StartDeployment", func() {
 				},
 			}
 			response := bytes.NewBuffer([]byte{})
-			deploymentResponse := controller.StartDeployment(deployment, data, response)
+			deploymentResponse := controller.StartDeployment(context.Background(), deployment, data, response)
 			Expect(deploymentResponse.DeploymentInfo.Data["user_id"]).Should(Equal("myuserid"))
 			Expect(deploymentResponse.DeploymentInfo.Data["group"]).Should(Equal("mygroup"))
 
 		})
+
+	})
+	Context("When metadata is provided", func() {
+		It("should pass the metadata to the deployment info and events", func() {
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: environment,
+				},
+				Metadata: map[string]string{"pipeline_id": "1234"},
+			}
+			response := bytes.NewBuffer([]byte{})
+			deploymentResponse := controller.StartDeployment(context.Background(), deployment, nil, response)
+
+			Expect(deploymentResponse.DeploymentInfo.Metadata).Should(Equal(map[string]string{"pipeline_id": "1234"}))
+			Expect(eventManager.EmitEventCall.Received.Events[0].(StartStartedEvent).Metadata).Should(Equal(map[string]string{"pipeline_id": "1234"}))
+		})
 	})
 
 	It("should create start manager", func() {
@@ -291,7 +292,7 @@ var _ = Describe("StartDeployment", func() {
 			},
 		}
 		response := bytes.NewBuffer([]byte{})
-		controller.StartDeployment(deployment, nil, response)
+		controller.StartDeployment(context.Background(), deployment, nil, response)
 		Expect(startManagerFactory.StartManagerCall.Called).Should(Equal(true))
 		Expect(startManagerFactory.StartManagerCall.Received.DeployEventData.DeploymentInfo.Username).Should(Equal("myUser"))
 	})
@@ -305,7 +306,7 @@ var _ = Describe("StartDeployment", func() {
 			},
 		}
 		response := bytes.NewBuffer([]byte{})
-		controller.StartDeployment(deployment, nil, response)
+		controller.StartDeployment(context.Background(), deployment, nil, response)
 		Expect(deployer.DeployCall.Received.ActionCreator).Should(Equal(manager))
 	})
 
@@ -319,7 +320,7 @@ var _ = Describe("StartDeployment", func() {
 			},
 		}
 		response := bytes.NewBuffer([]byte{})
-		deploymentResponse := controller.StartDeployment(deployment, nil, response)
+		deploymentResponse := controller.StartDeployment(context.Background(), deployment, nil, response)
 
 		Expect(deploymentResponse.Error.Error()).Should(Equal("test error"))
 		Expect(deploymentResponse.StatusCode).Should(Equal(http.StatusOK))
@@ -342,13 +343,13 @@ var _ = Describe("StartDeployment", func() {
 					},
 				}
 				response := bytes.NewBuffer([]byte{})
-				data := make(map[string]interface{})
+				data := make(structs.Params)
 				data["mykey"] = "first value"
 				controller.Config.Environments[environment] = structs.Environment{
 					Name:         environment,
 					Authenticate: true,
 				}
-				controller.StartDeployment(deployment, data, response)
+				controller.StartDeployment(context.Background(), deployment, data, response)
 
 				Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).To(Equal(reflect.TypeOf(StartSuccessEvent{})))
 				event := eventManager.EmitEventCall.Received.Events[1].(StartSuccessEvent)
@@ -373,9 +374,9 @@ var _ = Describe("StartDeployment", func() {
 						Environment:  environment,
 					},
 				}
-				data := make(map[string]interface{})
+				data := make(structs.Params)
 				data["mykey"] = "first value"
-				controller.StartDeployment(deployment, data, response)
+				controller.StartDeployment(context.Background(), deployment, data, response)
 
 				Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).Should(Equal(reflect.TypeOf(StartStartedEvent{})))
 				event := eventManager.EmitEventCall.Received.Events[0].(StartStartedEvent)
@@ -397,7 +398,7 @@ var _ = Describe("StartDeployment", func() {
 					},
 				}
 				response := bytes.NewBuffer([]byte{})
-				controller.StartDeployment(deployment, nil, response)
+				controller.StartDeployment(context.Background(), deployment, nil, response)
 
 				Eventually(logBuffer).Should(Say("an error occurred when emitting a StartSuccessEvent event: errors"))
 			})
@@ -414,7 +415,7 @@ var _ = Describe("StartDeployment", func() {
 			deployer.DeployCall.Returns.Error = errors.New("deploy error")
 			errorFinder.FindErrorsCall.Returns.Errors = []I.LogMatchedError{error_finder.CreateLogMatchedError("a test error", []string{"error 1", "error 2", "error 3"}, "error solution", "test code")}
 			response := bytes.NewBuffer([]byte{})
-			controller.StartDeployment(deployment, nil, response)
+			controller.StartDeployment(context.Background(), deployment, nil, response)
 			Eventually(response).Should(ContainSubstring("Potential solution"))
 		})
 
@@ -433,14 +434,14 @@ var _ = Describe("StartDeployment", func() {
 				},
 			}
 			response := bytes.NewBuffer([]byte{})
-			data := make(map[string]interface{})
+			data := make(structs.Params)
 			data["mykey"] = "first value"
 			controller.Config.Environments[environment] = structs.Environment{
 				Name:         environment,
 				Authenticate: true,
 			}
 			deployer.DeployCall.Returns.Error = errors.New("deploy error")
-			controller.StartDeployment(deployment, data, response)
+			controller.StartDeployment(context.Background(), deployment, data, response)
 
 			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).To(Equal(reflect.TypeOf(StartFailureEvent{})))
 			event := eventManager.EmitEventCall.Received.Events[1].(StartFailureEvent)
@@ -468,7 +469,7 @@ var _ = Describe("StartDeployment", func() {
 				deployer.DeployCall.Returns.Error = errors.New("deploy error")
 
 				response := bytes.NewBuffer([]byte{})
-				controller.StartDeployment(deployment, nil, response)
+				controller.StartDeployment(context.Background(), deployment, nil, response)
 
 				Eventually(logBuffer).Should(Say("an error occurred when emitting a StartFailureEvent event: errors"))
 			})
@@ -485,7 +486,7 @@ var _ = Describe("StartDeployment", func() {
 			}
 
 			response := bytes.NewBuffer([]byte{})
-			controller.StartDeployment(deployment, nil, response)
+			controller.StartDeployment(context.Background(), deployment, nil, response)
 
 			Eventually(logBuffer).Should(Say("emitting a StartFinishedEvent"))
 		})
@@ -505,13 +506,13 @@ var _ = Describe("StartDeployment", func() {
 				},
 			}
 			response := bytes.NewBuffer([]byte{})
-			data := make(map[string]interface{})
+			data := make(structs.Params)
 			data["mykey"] = "first value"
 			controller.Config.Environments[environment] = structs.Environment{
 				Name:         environment,
 				Authenticate: true,
 			}
-			controller.StartDeployment(deployment, data, response)
+			controller.StartDeployment(context.Background(), deployment, data, response)
 
 			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[2])).To(Equal(reflect.TypeOf(StartFinishedEvent{})))
 			event := eventManager.EmitEventCall.Received.Events[2].(StartFinishedEvent)
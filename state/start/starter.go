@@ -1,10 +1,22 @@
 package start
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"time"
 
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/state"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+const (
+	// DefaultStartTimeout is how long a started application is given for all of its instances to be running.
+	DefaultStartTimeout = 5 * time.Minute
+
+	// DefaultPollInterval is how often the instances of a started application are checked.
+	DefaultPollInterval = 2 * time.Second
 )
 
 type Starter struct {
@@ -16,23 +28,28 @@ type Starter struct {
 	Log           I.DeploymentLogger
 	FoundationURL string
 	AppName       string
-	Data          map[string]interface{}
+	Data          S.Params
+	StartTimeout  time.Duration
+	PollInterval  time.Duration
 }
 
-func (s Starter) Verify() error {
+func (s Starter) Verify(ctx context.Context) error {
 	return nil
 }
 
-func (s Starter) Success() error {
+func (s Starter) Success(ctx context.Context) error {
 	return nil
 }
 
-func (s Starter) Finally() error {
-	return nil
+// Finally removes the temporary directory created by the Executor.
+func (s Starter) Finally(ctx context.Context) error {
+	return s.Courier.CleanUp()
 }
 
 // Login will login to a Cloud Foundry instance.
-func (s Starter) Initially() error {
+func (s Starter) Initially(ctx context.Context) error {
+	s.Courier = s.Courier.WithContext(ctx)
+
 	s.Log.Debugf(
 		`logging into cloud foundry with parameters:
 		foundation URL: %+v
@@ -61,13 +78,19 @@ func (s Starter) Initially() error {
 	return nil
 }
 
-func (s Starter) Execute() error {
+func (s Starter) Execute(ctx context.Context) error {
+	s.Courier = s.Courier.WithContext(ctx)
 
 	if s.Courier.Exists(s.AppName) != true {
 		s.Log.Errorf("failed to start app on foundation %s: application doesn't exist", s.FoundationURL)
 		return state.ExistsError{ApplicationName: s.AppName}
 	}
 
+	err := s.restoreInstances()
+	if err != nil {
+		return err
+	}
+
 	s.Log.Infof("starting app %s", s.AppName)
 
 	output, err := s.Courier.Start(s.AppName)
@@ -77,12 +100,20 @@ func (s Starter) Execute() error {
 	}
 	s.Response.Write(output)
 
+	err = s.waitUntilRunning(ctx)
+	if err != nil {
+		s.Log.Errorf("failed to start app on foundation %s: %s", s.FoundationURL, err.Error())
+		s.writeRecentLogs()
+		return err
+	}
+
 	s.Log.Infof("successfully started app %s", s.AppName)
 
 	return nil
 }
 
-func (s Starter) Undo() error {
+func (s Starter) Undo(ctx context.Context) error {
+	s.Courier = s.Courier.WithContext(ctx)
 
 	if s.Courier.Exists(s.AppName) != true {
 		return state.ExistsError{ApplicationName: s.AppName}
@@ -100,3 +131,89 @@ func (s Starter) Undo() error {
 
 	return nil
 }
+
+// restoreInstances scales the application back to the instance count recorded when it was stopped.
+// Applications without a recorded count are started with the instances they have.
+func (s Starter) restoreInstances() error {
+	appState, err := s.Courier.AppState(s.AppName)
+	if err != nil {
+		s.Log.Errorf("could not read the recorded instances of app %s: %s", s.AppName, err)
+		return nil
+	}
+
+	instances, ok := state.RecordedInstances(appState)
+	if !ok || instances == appState.Instances {
+		return nil
+	}
+
+	s.Log.Infof("restoring %d instances of app %s", instances, s.AppName)
+
+	output, err := s.Courier.Scale(s.AppName, instances)
+	if err != nil {
+		s.Log.Errorf("failed to scale app on foundation %s: %s", s.FoundationURL, err.Error())
+		return state.ScaleError{ApplicationName: s.AppName, Out: output}
+	}
+	s.Response.Write(output)
+
+	return nil
+}
+
+// waitUntilRunning polls the instances of the application until every one of them is running.
+// A crashed instance fails the start straight away instead of waiting for the timeout.
+func (s Starter) waitUntilRunning(ctx context.Context) error {
+	timeout := s.StartTimeout
+	if timeout <= 0 {
+		timeout = DefaultStartTimeout
+	}
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	s.Log.Infof("waiting for the instances of app %s to be running", s.AppName)
+
+	deadline := time.Now().Add(timeout)
+	running, instances := 0, 0
+	for {
+		appInstances, err := s.Courier.Instances(s.AppName)
+		if err != nil {
+			s.Log.Errorf("could not read the instances of app %s: %s", s.AppName, err)
+		} else {
+			running, instances = 0, len(appInstances)
+			for _, instance := range appInstances {
+				switch instance.State {
+				case "RUNNING":
+					running++
+				case "CRASHED":
+					return state.InstanceCrashedError{ApplicationName: s.AppName, FoundationURL: s.FoundationURL}
+				}
+			}
+			if running == instances {
+				fmt.Fprintf(s.Response, "\n%d of %d instances of %s running on %s\n", running, instances, s.AppName, s.FoundationURL)
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return state.StartTimeoutError{ApplicationName: s.AppName, FoundationURL: s.FoundationURL, Running: running, Instances: instances, Timeout: timeout}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// writeRecentLogs writes the recent logs of the application to the response so a failed start can be diagnosed.
+func (s Starter) writeRecentLogs() {
+	logs, err := s.Courier.Logs(s.AppName)
+	if err != nil {
+		s.Log.Errorf("could not get the logs of app %s: %s", s.AppName, err)
+		return
+	}
+
+	fmt.Fprintf(s.Response, "\nrecent logs of %s on %s:\n", s.AppName, s.FoundationURL)
+	s.Response.Write(logs)
+}
@@ -1,13 +1,13 @@
 package start_test
 
 import (
+	"context"
 	"errors"
 	//"fmt"
-	"math/rand"
+	"time"
 
 	"github.com/compozed/deployadactyl/mocks"
 	"github.com/compozed/deployadactyl/randomizer"
-	. "github.com/compozed/deployadactyl/state/push"
 	. "github.com/compozed/deployadactyl/state/start"
 	S "github.com/compozed/deployadactyl/structs"
 	"github.com/op/go-logging"
@@ -32,16 +32,9 @@ var _ = Describe("Starter", func() {
 		randomPassword      string
 		randomOrg           string
 		randomSpace         string
-		randomDomain        string
-		randomAppPath       string
 		randomAppName       string
-		randomInstances     uint16
-		randomUUID          string
-		randomEndpoint      string
 		randomFoundationURL string
-		tempAppWithUUID     string
 		skipSSL             bool
-		deploymentInfo      S.DeploymentInfo
 		cfContext           interfaces.CFContext
 		auth                interfaces.Authorization
 		response            *Buffer
@@ -57,33 +50,13 @@ var _ = Describe("Starter", func() {
 		randomPassword = "randomPassword-" + randomizer.StringRunes(10)
 		randomOrg = "randomOrg-" + randomizer.StringRunes(10)
 		randomSpace = "randomSpace-" + randomizer.StringRunes(10)
-		randomDomain = "randomDomain-" + randomizer.StringRunes(10)
-		randomAppPath = "randomAppPath-" + randomizer.StringRunes(10)
 		randomAppName = "randomAppName-" + randomizer.StringRunes(10)
-		randomEndpoint = "randomEndpoint-" + randomizer.StringRunes(10)
-		randomUUID = randomizer.StringRunes(10)
-		randomInstances = uint16(rand.Uint32())
-
-		tempAppWithUUID = randomAppName + TemporaryNameSuffix + randomUUID
 
 		response = NewBuffer()
 		logBuffer = NewBuffer()
 
 		eventManager.EmitCall.Returns.Error = append(eventManager.EmitCall.Returns.Error, nil)
 
-		deploymentInfo = S.DeploymentInfo{
-			Username:            randomUsername,
-			Password:            randomPassword,
-			Org:                 randomOrg,
-			Space:               randomSpace,
-			AppName:             randomAppName,
-			SkipSSL:             skipSSL,
-			Instances:           randomInstances,
-			Domain:              randomDomain,
-			UUID:                randomUUID,
-			HealthCheckEndpoint: randomEndpoint,
-		}
-
 		cfContext = interfaces.CFContext{
 			Organization: randomOrg,
 			Space:        randomSpace,
@@ -111,7 +84,7 @@ var _ = Describe("Starter", func() {
 		Context("when login succeeds", func() {
 			It("gives the correct info to the courier", func() {
 
-				Expect(starter.Initially()).To(Succeed())
+				Expect(starter.Initially(context.Background())).To(Succeed())
 
 				Expect(courier.LoginCall.Received.FoundationURL).To(Equal(randomFoundationURL))
 				Expect(courier.LoginCall.Received.Username).To(Equal(randomUsername))
@@ -124,7 +97,7 @@ var _ = Describe("Starter", func() {
 			It("writes the output of the courier to the response", func() {
 				courier.LoginCall.Returns.Output = []byte("login succeeded")
 
-				Expect(starter.Initially()).To(Succeed())
+				Expect(starter.Initially(context.Background())).To(Succeed())
 
 				Eventually(response).Should(Say("login succeeded"))
 			})
@@ -135,7 +108,7 @@ var _ = Describe("Starter", func() {
 				courier.LoginCall.Returns.Output = []byte("login output")
 				courier.LoginCall.Returns.Error = errors.New("login error")
 
-				err := starter.Initially()
+				err := starter.Initially(context.Background())
 				Expect(err).To(MatchError(state.LoginError{randomFoundationURL, []byte("login output")}))
 			})
 
@@ -143,7 +116,7 @@ var _ = Describe("Starter", func() {
 				courier.LoginCall.Returns.Output = []byte("login output")
 				courier.LoginCall.Returns.Error = errors.New("login error")
 
-				err := starter.Initially()
+				err := starter.Initially(context.Background())
 				Expect(err).To(HaveOccurred())
 
 				Eventually(response).Should(Say("login output"))
@@ -152,7 +125,7 @@ var _ = Describe("Starter", func() {
 			It("logs an error", func() {
 				courier.LoginCall.Returns.Error = errors.New("login error")
 
-				err := starter.Initially()
+				err := starter.Initially(context.Background())
 				Expect(err).To(HaveOccurred())
 
 				Eventually(logBuffer).Should(Say(fmt.Sprintf("could not login to %s", randomFoundationURL)))
@@ -166,7 +139,7 @@ var _ = Describe("Starter", func() {
 				courier.ExistsCall.Returns.Bool = true
 				courier.StartCall.Returns.Output = []byte("start succeeded")
 
-				Expect(starter.Execute()).To(Succeed())
+				Expect(starter.Execute(context.Background())).To(Succeed())
 
 				Expect(courier.StartCall.Received.AppName).To(Equal(randomAppName))
 
@@ -177,13 +150,119 @@ var _ = Describe("Starter", func() {
 			})
 		})
 
+		Context("when instances were recorded when the app was stopped", func() {
+			BeforeEach(func() {
+				courier.ExistsCall.Returns.Bool = true
+				courier.AppStateCall.Returns.AppState = S.AppState{
+					Instances: 1,
+					Labels:    map[string]string{state.InstancesLabel: "4"},
+				}
+			})
+
+			It("scales the app to the recorded instances before starting it", func() {
+				Expect(starter.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.ScaleCall.Received.AppName).To(Equal(randomAppName))
+				Expect(courier.ScaleCall.Received.Instances).To(Equal(uint16(4)))
+				Expect(courier.StartCall.Received.AppName).To(Equal(randomAppName))
+			})
+
+			It("does not scale the app when it already has the recorded instances", func() {
+				courier.AppStateCall.Returns.AppState.Instances = 4
+
+				Expect(starter.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.ScaleCall.TimesCalled).To(Equal(0))
+			})
+
+			It("returns an error when the app can not be scaled", func() {
+				courier.ScaleCall.Returns.Output = []byte("scale output")
+				courier.ScaleCall.Returns.Error = errors.New("scale failed")
+
+				err := starter.Execute(context.Background())
+
+				Expect(err).To(MatchError(state.ScaleError{ApplicationName: randomAppName, Out: []byte("scale output")}))
+				Expect(courier.StartCall.Received.AppName).To(BeEmpty())
+			})
+		})
+
+		Context("when the app has been started", func() {
+			BeforeEach(func() {
+				courier.ExistsCall.Returns.Bool = true
+				starter.PollInterval = time.Millisecond
+				starter.StartTimeout = time.Second
+			})
+
+			It("waits until every instance is running", func() {
+				courier.InstancesCall.Returns.Instances = [][]S.Instance{
+					{{Index: 0, State: "STARTING"}, {Index: 1, State: "STARTING"}},
+					{{Index: 0, State: "RUNNING"}, {Index: 1, State: "STARTING"}},
+					{{Index: 0, State: "RUNNING"}, {Index: 1, State: "RUNNING"}},
+				}
+
+				Expect(starter.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.InstancesCall.TimesCalled).To(Equal(3))
+				Expect(courier.InstancesCall.Received.AppName).To(Equal(randomAppName))
+				Eventually(response).Should(Say("2 of 2 instances of %s running", randomAppName))
+			})
+
+			It("returns an error with the recent logs when an instance crashes", func() {
+				courier.InstancesCall.Returns.Instances = [][]S.Instance{{{Index: 0, State: "STARTING"}, {Index: 1, State: "CRASHED"}}}
+				courier.LogsCall.Returns.Output = []byte("exit status 1")
+
+				err := starter.Execute(context.Background())
+
+				Expect(err).To(MatchError(state.InstanceCrashedError{ApplicationName: randomAppName, FoundationURL: randomFoundationURL}))
+				Expect(courier.InstancesCall.TimesCalled).To(Equal(1))
+				Eventually(response).Should(Say("recent logs of %s", randomAppName))
+				Eventually(response).Should(Say("exit status 1"))
+			})
+
+			It("returns an error when the instances are not running before the timeout", func() {
+				courier.InstancesCall.Returns.Instances = [][]S.Instance{{{Index: 0, State: "RUNNING"}, {Index: 1, State: "STARTING"}}}
+				starter.StartTimeout = 20 * time.Millisecond
+
+				err := starter.Execute(context.Background())
+
+				Expect(err).To(MatchError(state.StartTimeoutError{
+					ApplicationName: randomAppName,
+					FoundationURL:   randomFoundationURL,
+					Running:         1,
+					Instances:       2,
+					Timeout:         20 * time.Millisecond,
+				}))
+				Expect(courier.LogsCall.Received.AppName).To(Equal(randomAppName))
+			})
+
+			It("stops waiting when the context is cancelled", func() {
+				courier.InstancesCall.Returns.Instances = [][]S.Instance{{{Index: 0, State: "STARTING"}}}
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				err := starter.Execute(ctx)
+
+				Expect(err).To(Equal(context.Canceled))
+			})
+		})
+
+		Context("when no instances were recorded", func() {
+			It("starts the app without scaling it", func() {
+				courier.ExistsCall.Returns.Bool = true
+
+				Expect(starter.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.ScaleCall.TimesCalled).To(Equal(0))
+			})
+		})
+
 		Context("when the start fails", func() {
 			It("returns an error", func() {
 				courier.ExistsCall.Returns.Bool = true
 				courier.StartCall.Returns.Output = []byte("this is some output")
 				courier.StartCall.Returns.Error = errors.New("")
 
-				err := starter.Execute()
+				err := starter.Execute(context.Background())
 
 				Expect(err).To(MatchError(state.StartError{ApplicationName: randomAppName, Out: []byte("this is some output")}))
 			})
@@ -193,7 +272,7 @@ var _ = Describe("Starter", func() {
 			It("returns an error", func() {
 				courier.ExistsCall.Returns.Bool = false
 
-				err := starter.Execute()
+				err := starter.Execute(context.Background())
 
 				Expect(err).To(MatchError(state.ExistsError{ApplicationName: randomAppName}))
 			})
@@ -204,7 +283,7 @@ var _ = Describe("Starter", func() {
 		Context("when the app does not exist", func() {
 			It("returns an error", func() {
 				courier.ExistsCall.Returns.Bool = false
-				err := starter.Undo()
+				err := starter.Undo(context.Background())
 
 				Expect(err).To(MatchError(state.ExistsError{ApplicationName: randomAppName}))
 			})
@@ -216,7 +295,7 @@ var _ = Describe("Starter", func() {
 				courier.StopCall.Returns.Output = []byte("this is some output")
 				courier.StopCall.Returns.Error = errors.New("app could not be started")
 
-				err := starter.Undo()
+				err := starter.Undo(context.Background())
 
 				Expect(err).To(MatchError(state.StopError{ApplicationName: randomAppName, Out: []byte("this is some output")}))
 			})
@@ -227,7 +306,7 @@ var _ = Describe("Starter", func() {
 				courier.ExistsCall.Returns.Bool = true
 				courier.StopCall.Returns.Output = []byte("stop succeeded")
 
-				Expect(starter.Undo()).To(Succeed())
+				Expect(starter.Undo(context.Background())).To(Succeed())
 				Expect(courier.StopCall.Received.AppName).To(Equal(randomAppName))
 
 				Eventually(response).Should(Say("stop succeeded"))
@@ -239,19 +318,27 @@ var _ = Describe("Starter", func() {
 
 	Describe("Verify", func() {
 		It("returns nil", func() {
-			Expect(starter.Verify()).To(BeNil())
+			Expect(starter.Verify(context.Background())).To(BeNil())
 		})
 	})
 
 	Describe("Success", func() {
 		It("returns nil", func() {
-			Expect(starter.Success()).To(BeNil())
+			Expect(starter.Success(context.Background())).To(BeNil())
 		})
 	})
 
 	Describe("Finally", func() {
-		It("returns nil", func() {
-			Expect(starter.Finally()).To(BeNil())
+		It("removes the temporary directory created by the Executor", func() {
+			Expect(starter.Finally(context.Background())).To(Succeed())
+
+			Expect(courier.CleanUpCall.Called).To(BeTrue())
+		})
+
+		It("returns an error when the temporary directory cannot be removed", func() {
+			courier.CleanUpCall.Returns.Error = errors.New("cannot remove")
+
+			Expect(starter.Finally(context.Background())).To(MatchError("cannot remove"))
 		})
 	})
 })
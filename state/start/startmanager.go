@@ -1,33 +1,26 @@
 package start
 
 import (
+	"context"
 	"io"
+	"time"
 
-	"fmt"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
 	I "github.com/compozed/deployadactyl/interfaces"
-	"github.com/compozed/deployadactyl/state"
+	"github.com/compozed/deployadactyl/state/operation"
 	S "github.com/compozed/deployadactyl/structs"
-	"net/http"
-	"regexp"
 )
 
-const successfulStart = `Your start was successful! (^_^)b
-
-`
-
-type courierCreator interface {
-	CreateCourier() (I.Courier, error)
-}
+var messages = operation.Messages{Operation: "start", Done: "started", Foundations: "on all foundations"}
 
 type StartManager struct {
-	CourierCreator  courierCreator
+	CourierCreator  operation.CourierCreator
 	EventManager    I.EventManager
 	Logger          I.DeploymentLogger
 	DeployEventData S.DeployEventData
 }
 
-func (a StartManager) SetUp() error {
+func (a StartManager) SetUp(ctx context.Context) error {
 	return nil
 }
 
@@ -36,53 +29,28 @@ func (a StartManager) OnStart() error {
 }
 
 func (a StartManager) OnFinish(env S.Environment, response io.ReadWriter, err error) I.DeployResponse {
-	if err != nil {
-		fmt.Fprintf(response, "\nYour application was not successfully started on all foundations: %s\n\n", err.Error())
-		if matched, _ := regexp.MatchString("login failed", err.Error()); matched {
-			return I.DeployResponse{
-				StatusCode: http.StatusBadRequest,
-				Error:      err,
-			}
-		}
-		return I.DeployResponse{
-			Error:      err,
-			StatusCode: http.StatusInternalServerError,
-		}
-	}
-
-	a.Logger.Infof("successfully started application %s", a.DeployEventData.DeploymentInfo.AppName)
-	fmt.Fprintf(response, "\n%s", successfulStart)
-
-	return I.DeployResponse{StatusCode: http.StatusOK}
+	return messages.OnFinish(a.Logger, response, a.DeployEventData.DeploymentInfo.AppName, err)
 }
 
 func (a StartManager) CleanUp() {}
 
 func (a StartManager) Create(environment S.Environment, response io.ReadWriter, foundationURL string) (I.Action, error) {
-	courier, err := a.CourierCreator.CreateCourier()
+	courier, err := operation.NewCourier(a.CourierCreator, environment)
 	if err != nil {
 		a.Logger.Error(err)
-		return &Starter{}, state.CourierCreationError{Err: err}
+		return &Starter{}, err
 	}
 	p := &Starter{
-		Courier: courier,
-		CFContext: I.CFContext{
-			Environment:  environment.Name,
-			Organization: a.DeployEventData.DeploymentInfo.Org,
-			Space:        a.DeployEventData.DeploymentInfo.Space,
-			Application:  a.DeployEventData.DeploymentInfo.AppName,
-			SkipSSL:      a.DeployEventData.DeploymentInfo.SkipSSL,
-		},
-		Authorization: I.Authorization{
-			Username: a.DeployEventData.DeploymentInfo.Username,
-			Password: a.DeployEventData.DeploymentInfo.Password,
-		},
+		Courier:       courier,
+		CFContext:     operation.CFContext(environment, a.DeployEventData.DeploymentInfo),
+		Authorization: operation.Authorization(a.DeployEventData.DeploymentInfo),
 		EventManager:  a.EventManager,
 		Response:      response,
-		Log:           a.Logger,
+		Log:           a.Logger.WithFields(I.LogFields{I.FoundationLogField: foundationURL, I.AppLogField: a.DeployEventData.DeploymentInfo.AppName}),
 		FoundationURL: foundationURL,
 		AppName:       a.DeployEventData.DeploymentInfo.AppName,
 		Data:          a.DeployEventData.DeploymentInfo.Data,
+		StartTimeout:  time.Duration(environment.StartTimeoutSeconds) * time.Second,
 	}
 
 	return p, nil
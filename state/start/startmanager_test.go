@@ -55,7 +55,7 @@ var _ = Describe("Startmanager", func() {
 		creator = &courierCreator{}
 		startManager = start.StartManager{
 			CourierCreator: creator,
-			Logger:         interfaces.DeploymentLogger{log, randomizer.StringRunes(10)},
+			Logger:         interfaces.DeploymentLogger{Log: log, UUID: randomizer.StringRunes(10)},
 			DeployEventData: structs.DeployEventData{
 				DeploymentInfo: &structs.DeploymentInfo{},
 				Response:       response,
@@ -93,7 +93,28 @@ var _ = Describe("Startmanager", func() {
 				Expect(starterData.Authorization.Username).Should(Equal("bob"))
 				Expect(starterData.Authorization.Password).Should(Equal("password"))
 				Expect(starterData.FoundationURL).Should(Equal(foundationURL))
+				Expect(starterData.Log.Fields).Should(Equal(interfaces.LogFields{"foundation": foundationURL, "app": "myApp"}))
+			})
+
+			It("should trust the CA bundle of the environment", func() {
+				courier := &mocks.Courier{}
+				creator.CourierCreatorFn = func() (interfaces.Courier, error) { return courier, nil }
+
+				_, err := startManager.Create(structs.Environment{CABundle: "/etc/ssl/internal-ca.pem"}, response, "foundation url")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(courier.WithCABundleCall.Received.Path).To(Equal("/etc/ssl/internal-ca.pem"))
+			})
+
+			It("should read logs from the Log Cache of the environment", func() {
+				courier := &mocks.Courier{}
+				creator.CourierCreatorFn = func() (interfaces.Courier, error) { return courier, nil }
+				logCache := structs.LogCacheDescriptor{Enabled: true, SourceTypes: []string{"APP"}}
+
+				_, err := startManager.Create(structs.Environment{LogCache: logCache}, response, "foundation url")
+				Expect(err).ToNot(HaveOccurred())
 
+				Expect(courier.WithLogCacheCall.Received.Descriptor).To(Equal(logCache))
 			})
 		})
 
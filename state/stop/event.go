@@ -24,7 +24,8 @@ func (b eventBinding) Emit(event interface{}) error {
 
 type StopFailureEvent struct {
 	CFContext     interfaces.CFContext
-	Data          map[string]interface{}
+	Data          structs.Params
+	Metadata      map[string]string
 	Authorization interfaces.Authorization
 	Environment   structs.Environment
 	Error         error
@@ -52,7 +53,8 @@ func NewStopFailureEventBinding(handler func(event StopFailureEvent) error) inte
 
 type StopSuccessEvent struct {
 	CFContext     interfaces.CFContext
-	Data          map[string]interface{}
+	Data          structs.Params
+	Metadata      map[string]string
 	Authorization interfaces.Authorization
 	Environment   structs.Environment
 	Response      io.ReadWriter
@@ -79,7 +81,8 @@ func NewStopSuccessEventBinding(handler func(event StopSuccessEvent) error) inte
 
 type StopStartedEvent struct {
 	CFContext     interfaces.CFContext
-	Data          map[string]interface{}
+	Data          structs.Params
+	Metadata      map[string]string
 	Environment   structs.Environment
 	Authorization interfaces.Authorization
 	Response      io.ReadWriter
@@ -106,7 +109,8 @@ func NewStopStartedEventBinding(handler func(event StopStartedEvent) error) inte
 
 type StopFinishedEvent struct {
 	CFContext     interfaces.CFContext
-	Data          map[string]interface{}
+	Data          structs.Params
+	Metadata      map[string]string
 	Authorization interfaces.Authorization
 	Environment   structs.Environment
 	Response      io.ReadWriter
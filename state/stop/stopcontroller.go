@@ -2,11 +2,14 @@ package stop
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/compozed/deployadactyl/config"
 	"github.com/compozed/deployadactyl/controller/deployer"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
 	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/rbac"
 	"github.com/compozed/deployadactyl/structs"
 	"io"
 	"net/http"
@@ -34,12 +37,12 @@ type StopController struct {
 	ErrorFinder        I.ErrorFinder
 }
 
-func (c *StopController) StopDeployment(deployment *I.Deployment, data map[string]interface{}, response *bytes.Buffer) (deployResponse I.DeployResponse) {
+func (c *StopController) StopDeployment(ctx context.Context, deployment *I.Deployment, data structs.Params, response io.ReadWriter) (deployResponse I.DeployResponse) {
 	cf := deployment.CFContext
 	c.Log.Debugf("Preparing to stop %s with UUID %s", cf.Application, c.Log.UUID)
 
 	if data == nil {
-		data = make(map[string]interface{})
+		data = make(structs.Params)
 	}
 
 	environment, err := c.resolveEnvironment(cf.Environment)
@@ -50,10 +53,23 @@ func (c *StopController) StopDeployment(deployment *I.Deployment, data map[strin
 			Error:      err,
 		}
 	}
+	err = rbac.Authorize(environment, rbac.Stop, deployment.Authorization)
+	if err != nil {
+		json.NewEncoder(response).Encode(err)
+		return I.DeployResponse{
+			StatusCode: http.StatusForbidden,
+			Error:      err,
+		}
+	}
 	auth, err := c.resolveAuthorization(deployment.Authorization, environment, c.Log)
 	if err != nil {
+		// Only missing basic auth is the fault of the request: the credentials of the environment could not be read.
+		statusCode := http.StatusInternalServerError
+		if _, ok := err.(deployer.BasicAuthError); ok {
+			statusCode = http.StatusUnauthorized
+		}
 		return I.DeployResponse{
-			StatusCode: http.StatusUnauthorized,
+			StatusCode: statusCode,
 			Error:      err,
 		}
 	}
@@ -69,15 +85,19 @@ func (c *StopController) StopDeployment(deployment *I.Deployment, data map[strin
 		CustomParams: environment.CustomParams,
 		Username:     auth.Username,
 		Password:     auth.Password,
+		Subject:      auth.Subject,
 		Data:         data,
+		Metadata:     deployment.Metadata,
+		StopMode:     deployment.StopMode,
 	}
 
-	defer c.emitStopFinish(response, c.Log, cf, &auth, &environment, data, &deployResponse)
-	defer c.emitStopSuccessOrFailure(response, c.Log, cf, &auth, &environment, data, &deployResponse)
+	defer c.emitStopFinish(response, c.Log, cf, &auth, &environment, data, deployment.Metadata, &deployResponse)
+	defer c.emitStopSuccessOrFailure(response, c.Log, cf, &auth, &environment, data, deployment.Metadata, &deployResponse)
 
 	err = c.EventManager.EmitEvent(StopStartedEvent{
 		CFContext:     cf,
 		Data:          data,
+		Metadata:      deploymentInfo.Metadata,
 		Environment:   environment,
 		Authorization: auth,
 		Response:      response,
@@ -96,16 +116,17 @@ func (c *StopController) StopDeployment(deployment *I.Deployment, data map[strin
 	deployEventData := structs.DeployEventData{Response: response, DeploymentInfo: deploymentInfo}
 
 	manager := c.StopManagerFactory.StopManager(c.Log, deployEventData)
-	return *c.Deployer.Deploy(deploymentInfo, environment, manager, response)
+	return *c.Deployer.Deploy(ctx, deploymentInfo, environment, manager, response)
 }
 
-func (c StopController) emitStopFinish(response io.ReadWriter, deploymentLogger I.DeploymentLogger, cfContext I.CFContext, auth *I.Authorization, environment *structs.Environment, data map[string]interface{}, deployResponse *I.DeployResponse) {
+func (c StopController) emitStopFinish(response io.ReadWriter, deploymentLogger I.DeploymentLogger, cfContext I.CFContext, auth *I.Authorization, environment *structs.Environment, data structs.Params, metadata map[string]string, deployResponse *I.DeployResponse) {
 	var event I.IEvent
 	event = StopFinishedEvent{
 		CFContext:     cfContext,
 		Authorization: *auth,
 		Environment:   *environment,
 		Data:          data,
+		Metadata:      metadata,
 		Response:      response,
 		Log:           deploymentLogger,
 	}
@@ -113,7 +134,7 @@ func (c StopController) emitStopFinish(response io.ReadWriter, deploymentLogger
 	c.EventManager.EmitEvent(event)
 }
 
-func (c StopController) emitStopSuccessOrFailure(response io.ReadWriter, deploymentLogger I.DeploymentLogger, cfContext I.CFContext, auth *I.Authorization, environment *structs.Environment, data map[string]interface{}, deployResponse *I.DeployResponse) {
+func (c StopController) emitStopSuccessOrFailure(response io.ReadWriter, deploymentLogger I.DeploymentLogger, cfContext I.CFContext, auth *I.Authorization, environment *structs.Environment, data structs.Params, metadata map[string]string, deployResponse *I.DeployResponse) {
 	var event I.IEvent
 
 	if deployResponse.Error != nil {
@@ -123,6 +144,7 @@ func (c StopController) emitStopSuccessOrFailure(response io.ReadWriter, deploym
 			Authorization: *auth,
 			Environment:   *environment,
 			Data:          data,
+			Metadata:      metadata,
 			Error:         deployResponse.Error,
 			Response:      response,
 			Log:           deploymentLogger,
@@ -134,6 +156,7 @@ func (c StopController) emitStopSuccessOrFailure(response io.ReadWriter, deploym
 			Authorization: *auth,
 			Environment:   *environment,
 			Data:          data,
+			Metadata:      metadata,
 			Response:      response,
 			Log:           deploymentLogger,
 		}
@@ -173,11 +196,14 @@ func (c *StopController) resolveAuthorization(auth I.Authorization, envs structs
 	config := c.Config
 	deploymentLogger.Debug("checking for basic auth")
 	if auth.Username == "" && auth.Password == "" {
-		if envs.Authenticate {
+		if envs.Authenticate && auth.Subject == "" {
 			return I.Authorization{}, deployer.BasicAuthError{}
 		}
-		auth.Username = config.Username
-		auth.Password = config.Password
+		username, password, err := config.Credentials(envs)
+		if err != nil {
+			return I.Authorization{}, err
+		}
+		auth.Username, auth.Password = username, password
 	}
 
 	return auth, nil
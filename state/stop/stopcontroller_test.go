@@ -2,6 +2,7 @@ package stop_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/compozed/deployadactyl/config"
@@ -10,6 +11,7 @@ import (
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/mocks"
 	"github.com/compozed/deployadactyl/randomizer"
+	"github.com/compozed/deployadactyl/rbac"
 	. "github.com/compozed/deployadactyl/state/stop"
 	"github.com/compozed/deployadactyl/structs"
 	. "github.com/onsi/ginkgo"
@@ -82,7 +84,7 @@ var _ = Describe("StopDeployment", func() {
 					Environment: environment,
 				}}
 			response := bytes.NewBuffer([]byte{})
-			deploymentResponse := controller.StopDeployment(deployment, nil, response)
+			deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
 
 			Expect(deploymentResponse.DeploymentInfo.UUID).ShouldNot(BeEmpty())
 		})
@@ -98,7 +100,7 @@ var _ = Describe("StopDeployment", func() {
 			},
 		}
 		response := bytes.NewBuffer([]byte{})
-		deploymentResponse := controller.StopDeployment(deployment, nil, response)
+		deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
 
 		Expect(deploymentResponse.DeploymentInfo.Org).Should(Equal("myOrg"))
 		Expect(deploymentResponse.DeploymentInfo.Environment).Should(Equal(environment))
@@ -117,7 +119,7 @@ var _ = Describe("StopDeployment", func() {
 		}
 
 		response := bytes.NewBuffer([]byte{})
-		deploymentResponse := controller.StopDeployment(deployment, nil, response)
+		deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
 
 		Expect(logBuffer).Should(Say(fmt.Sprintf("Preparing to stop %s with UUID %s", "myApp", deploymentResponse.DeploymentInfo.UUID)))
 
@@ -132,9 +134,9 @@ var _ = Describe("StopDeployment", func() {
 					Environment:  environment,
 				},
 			}
-			data := make(map[string]interface{})
+			data := make(structs.Params)
 			data["mykey"] = "first value"
-			controller.StopDeployment(deployment, data, response)
+			controller.StopDeployment(context.Background(), deployment, data, response)
 
 			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).Should(Equal(reflect.TypeOf(StopStartedEvent{})))
 			stopEvent := eventManager.EmitEventCall.Received.Events[0].(StopStartedEvent)
@@ -155,7 +157,7 @@ var _ = Describe("StopDeployment", func() {
 					Environment: environment,
 				},
 			}
-			deployResponse := controller.StopDeployment(deployment, nil, response)
+			deployResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
 
 			Expect(deployResponse.StatusCode).Should(Equal(http.StatusInternalServerError))
 			Expect(reflect.TypeOf(deployResponse.Error)).Should(Equal(reflect.TypeOf(D.EventError{})))
@@ -171,7 +173,7 @@ var _ = Describe("StopDeployment", func() {
 					Environment: "bad environment",
 				}}
 			response := bytes.NewBuffer([]byte{})
-			deploymentResponse := controller.StopDeployment(deployment, nil, response)
+			deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
 
 			Expect(reflect.TypeOf(deploymentResponse.Error)).Should(Equal(reflect.TypeOf(D.EnvironmentNotFoundError{})))
 		})
@@ -182,7 +184,7 @@ var _ = Describe("StopDeployment", func() {
 			controller.Config.Environments[environment] = structs.Environment{
 				SkipSSL:      true,
 				Domain:       "myDomain",
-				CustomParams: make(map[string]interface{}),
+				CustomParams: make(structs.Params),
 			}
 			controller.Config.Environments[environment].CustomParams["customName"] = "customParams"
 
@@ -192,7 +194,7 @@ var _ = Describe("StopDeployment", func() {
 				}}
 
 			response := bytes.NewBuffer([]byte{})
-			deploymentResponse := controller.StopDeployment(deployment, nil, response)
+			deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
 			Expect(deploymentResponse.DeploymentInfo.Domain).Should(Equal("myDomain"))
 			Expect(deploymentResponse.DeploymentInfo.SkipSSL).Should(Equal(true))
 			Expect(deploymentResponse.DeploymentInfo.CustomParams["customName"]).Should(Equal("customParams"))
@@ -209,7 +211,7 @@ var _ = Describe("StopDeployment", func() {
 						Environment: environment,
 					}}
 				response := bytes.NewBuffer([]byte{})
-				deploymentResponse := controller.StopDeployment(deployment, nil, response)
+				deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
 
 				Expect(reflect.TypeOf(deploymentResponse.Error)).Should(Equal(reflect.TypeOf(D.BasicAuthError{})))
 			})
@@ -226,13 +228,97 @@ var _ = Describe("StopDeployment", func() {
 						Environment: environment,
 					}}
 				response := bytes.NewBuffer([]byte{})
-				deploymentResponse := controller.StopDeployment(deployment, nil, response)
+				deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
 
 				Expect(deploymentResponse.DeploymentInfo.Username).Should(Equal("username"))
 				Expect(deploymentResponse.DeploymentInfo.Password).Should(Equal("password"))
 
 			})
 		})
+		Context("When the environment has a vault path", func() {
+			var credentialStore *mocks.CredentialStore
+
+			BeforeEach(func() {
+				credentialStore = &mocks.CredentialStore{}
+				controller.Config.CredentialStore = credentialStore
+				controller.Config.Username = "username"
+				controller.Config.Password = "password"
+				controller.Config.Environments[environment] = structs.Environment{
+					Name:      environment,
+					VaultPath: "secret/data/cf/" + environment,
+				}
+			})
+
+			It("Should use the username and password of the vault secret", func() {
+				credentialStore.CredentialsCall.Returns.Username = "vault-user"
+				credentialStore.CredentialsCall.Returns.Password = "vault-password"
+				deployment := &I.Deployment{
+					CFContext: I.CFContext{
+						Environment: environment,
+					}}
+				response := bytes.NewBuffer([]byte{})
+				deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
+
+				Expect(credentialStore.CredentialsCall.Received.Path).Should(Equal("secret/data/cf/" + environment))
+				Expect(deploymentResponse.DeploymentInfo.Username).Should(Equal("vault-user"))
+				Expect(deploymentResponse.DeploymentInfo.Password).Should(Equal("vault-password"))
+			})
+
+			It("Should return an internal server error when the secret cannot be read", func() {
+				credentialStore.CredentialsCall.Returns.Error = errors.New("permission denied")
+				deployment := &I.Deployment{
+					CFContext: I.CFContext{
+						Environment: environment,
+					}}
+				response := bytes.NewBuffer([]byte{})
+				deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
+
+				Expect(deploymentResponse.StatusCode).Should(Equal(http.StatusInternalServerError))
+				Expect(deploymentResponse.Error).Should(MatchError(config.CredentialsError{Environment: environment, Err: errors.New("permission denied")}))
+				Expect(deployer.DeployCall.Called).Should(BeZero())
+			})
+		})
+	})
+	Context("When the environment has access rules", func() {
+		var credentialStore *mocks.CredentialStore
+
+		BeforeEach(func() {
+			credentialStore = &mocks.CredentialStore{}
+			controller.Config.CredentialStore = credentialStore
+			controller.Config.Environments[environment] = structs.Environment{
+				Name:      environment,
+				VaultPath: "secret/data/cf/" + environment,
+				Access: []structs.AccessRule{
+					{Users: []string{"jane"}, Operations: []string{rbac.Deploy, rbac.Stop}},
+					{Groups: []string{"operators"}, Operations: []string{rbac.Start}},
+				},
+			}
+		})
+
+		It("Should stop for a user allowed to stop", func() {
+			deployment := &I.Deployment{
+				Authorization: I.Authorization{Subject: "jane"},
+				CFContext:     I.CFContext{Environment: environment},
+			}
+			deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
+
+			Expect(deploymentResponse.Error).ShouldNot(HaveOccurred())
+			Expect(deploymentResponse.DeploymentInfo.Subject).Should(Equal("jane"))
+		})
+
+		It("Should return a forbidden error before the credentials are read for other users", func() {
+			deployment := &I.Deployment{
+				Authorization: I.Authorization{Subject: "john", Groups: []string{"operators"}},
+				CFContext:     I.CFContext{Environment: environment},
+			}
+			deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
+
+			Expect(deploymentResponse.StatusCode).Should(Equal(http.StatusForbidden))
+			Expect(deploymentResponse.Error).Should(MatchError(rbac.ForbiddenError{User: "john", Operation: rbac.Stop, Environment: environment}))
+			Expect(response.String()).Should(MatchJSON(`{"error": "john may not stop on environment ` + environment + `", "user": "john", "operation": "stop", "environment": "` + environment + `"}`))
+			Expect(credentialStore.CredentialsCall.Received.Path).Should(BeEmpty())
+			Expect(deployer.DeployCall.Called).Should(BeZero())
+		})
 	})
 	Context("When auth is provided", func() {
 		It("Should populate the deploymentInfo with the username and password", func() {
@@ -246,7 +332,7 @@ var _ = Describe("StopDeployment", func() {
 				},
 			}
 			response := bytes.NewBuffer([]byte{})
-			deploymentResponse := controller.StopDeployment(deployment, nil, response)
+			deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
 			Expect(deploymentResponse.DeploymentInfo.Username).Should(Equal("myUser"))
 			Expect(deploymentResponse.DeploymentInfo.Password).Should(Equal("myPassword"))
 		})
@@ -263,7 +349,7 @@ var _ = Describe("StopDeployment", func() {
 				},
 			}
 			response := bytes.NewBuffer([]byte{})
-			deploymentResponse := controller.StopDeployment(deployment, nil, response)
+			deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
 			Expect(deploymentResponse.DeploymentInfo.Username).Should(Equal("myUser"))
 			Expect(deploymentResponse.DeploymentInfo.Password).Should(Equal("myPassword"))
 		})
@@ -271,7 +357,7 @@ var _ = Describe("StopDeployment", func() {
 
 	Context("When data is provided", func() {
 		It("should return deployment info with proper data", func() {
-			data := map[string]interface{}{
+			data := structs.Params{
 				"user_id": "myuserid",
 				"group":   "mygroup",
 			}
@@ -281,11 +367,41 @@ var _ = Describe("StopDeployment", func() {
 				},
 			}
 			response := bytes.NewBuffer([]byte{})
-			deploymentResponse := controller.StopDeployment(deployment, data, response)
+			deploymentResponse := controller.StopDeployment(context.Background(), deployment, data, response)
 			Expect(deploymentResponse.DeploymentInfo.Data["user_id"]).Should(Equal("myuserid"))
 			Expect(deploymentResponse.DeploymentInfo.Data["group"]).Should(Equal("mygroup"))
 
 		})
+
+	})
+	Context("When metadata is provided", func() {
+		It("should pass the metadata to the deployment info and events", func() {
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: environment,
+				},
+				Metadata: map[string]string{"pipeline_id": "1234"},
+			}
+			response := bytes.NewBuffer([]byte{})
+			deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
+
+			Expect(deploymentResponse.DeploymentInfo.Metadata).Should(Equal(map[string]string{"pipeline_id": "1234"}))
+			Expect(eventManager.EmitEventCall.Received.Events[0].(StopStartedEvent).Metadata).Should(Equal(map[string]string{"pipeline_id": "1234"}))
+		})
+	})
+	Context("When a stop mode is requested", func() {
+		It("should pass the mode to the deployment info", func() {
+			deployment := &I.Deployment{
+				CFContext: I.CFContext{
+					Environment: environment,
+				},
+				StopMode: structs.StopModeScaleToZero,
+			}
+			response := bytes.NewBuffer([]byte{})
+			deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
+
+			Expect(deploymentResponse.DeploymentInfo.StopMode).Should(Equal(structs.StopModeScaleToZero))
+		})
 	})
 	It("should create stop manager", func() {
 
@@ -298,7 +414,7 @@ var _ = Describe("StopDeployment", func() {
 			},
 		}
 		response := bytes.NewBuffer([]byte{})
-		controller.StopDeployment(deployment, nil, response)
+		controller.StopDeployment(context.Background(), deployment, nil, response)
 		Expect(stopManagerFactory.StopManagerCall.Called).Should(Equal(true))
 		Expect(stopManagerFactory.StopManagerCall.Received.DeployEventData.DeploymentInfo.Username).Should(Equal("myUser"))
 	})
@@ -311,7 +427,7 @@ var _ = Describe("StopDeployment", func() {
 			},
 		}
 		response := bytes.NewBuffer([]byte{})
-		controller.StopDeployment(deployment, nil, response)
+		controller.StopDeployment(context.Background(), deployment, nil, response)
 		Expect(deployer.DeployCall.Received.ActionCreator).Should(Equal(manager))
 	})
 
@@ -325,7 +441,7 @@ var _ = Describe("StopDeployment", func() {
 			},
 		}
 		response := bytes.NewBuffer([]byte{})
-		deploymentResponse := controller.StopDeployment(deployment, nil, response)
+		deploymentResponse := controller.StopDeployment(context.Background(), deployment, nil, response)
 
 		Expect(deploymentResponse.Error.Error()).Should(Equal("test error"))
 		Expect(deploymentResponse.StatusCode).Should(Equal(http.StatusOK))
@@ -348,13 +464,13 @@ var _ = Describe("StopDeployment", func() {
 					},
 				}
 				response := bytes.NewBuffer([]byte{})
-				data := make(map[string]interface{})
+				data := make(structs.Params)
 				data["mykey"] = "first value"
 				controller.Config.Environments[environment] = structs.Environment{
 					Name:         environment,
 					Authenticate: true,
 				}
-				controller.StopDeployment(deployment, data, response)
+				controller.StopDeployment(context.Background(), deployment, data, response)
 
 				Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).To(Equal(reflect.TypeOf(StopSuccessEvent{})))
 				stopSuccessEvent := eventManager.EmitEventCall.Received.Events[1].(StopSuccessEvent)
@@ -378,9 +494,9 @@ var _ = Describe("StopDeployment", func() {
 						Environment:  environment,
 					},
 				}
-				data := make(map[string]interface{})
+				data := make(structs.Params)
 				data["mykey"] = "first value"
-				controller.StopDeployment(deployment, data, response)
+				controller.StopDeployment(context.Background(), deployment, data, response)
 
 				Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[0])).Should(Equal(reflect.TypeOf(StopStartedEvent{})))
 				stopEvent := eventManager.EmitEventCall.Received.Events[0].(StopStartedEvent)
@@ -401,7 +517,7 @@ var _ = Describe("StopDeployment", func() {
 					},
 				}
 				response := bytes.NewBuffer([]byte{})
-				controller.StopDeployment(deployment, nil, response)
+				controller.StopDeployment(context.Background(), deployment, nil, response)
 
 				Eventually(logBuffer).Should(Say("an error occurred when emitting a StopSuccessEvent event: errors"))
 			})
@@ -418,7 +534,7 @@ var _ = Describe("StopDeployment", func() {
 			deployer.DeployCall.Returns.Error = errors.New("deploy error")
 			errorFinder.FindErrorsCall.Returns.Errors = []I.LogMatchedError{error_finder.CreateLogMatchedError("a test error", []string{"error 1", "error 2", "error 3"}, "error solution", "test code")}
 			response := bytes.NewBuffer([]byte{})
-			controller.StopDeployment(deployment, nil, response)
+			controller.StopDeployment(context.Background(), deployment, nil, response)
 			Eventually(response).Should(ContainSubstring("Potential solution"))
 		})
 		It("should emit StopFailureEvent", func() {
@@ -436,14 +552,14 @@ var _ = Describe("StopDeployment", func() {
 				},
 			}
 			response := bytes.NewBuffer([]byte{})
-			data := make(map[string]interface{})
+			data := make(structs.Params)
 			data["mykey"] = "first value"
 			controller.Config.Environments[environment] = structs.Environment{
 				Name:         environment,
 				Authenticate: true,
 			}
 			deployer.DeployCall.Returns.Error = errors.New("deploy error")
-			controller.StopDeployment(deployment, data, response)
+			controller.StopDeployment(context.Background(), deployment, data, response)
 
 			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[1])).To(Equal(reflect.TypeOf(StopFailureEvent{})))
 			event := eventManager.EmitEventCall.Received.Events[1].(StopFailureEvent)
@@ -470,7 +586,7 @@ var _ = Describe("StopDeployment", func() {
 				deployer.DeployCall.Returns.Error = errors.New("deploy error")
 
 				response := bytes.NewBuffer([]byte{})
-				controller.StopDeployment(deployment, nil, response)
+				controller.StopDeployment(context.Background(), deployment, nil, response)
 
 				Eventually(logBuffer).Should(Say("an error occurred when emitting a StopFailureEvent event: errors"))
 			})
@@ -486,7 +602,7 @@ var _ = Describe("StopDeployment", func() {
 			}
 
 			response := bytes.NewBuffer([]byte{})
-			controller.StopDeployment(deployment, nil, response)
+			controller.StopDeployment(context.Background(), deployment, nil, response)
 
 			Eventually(logBuffer).Should(Say("emitting a StopFinishedEvent"))
 		})
@@ -505,13 +621,13 @@ var _ = Describe("StopDeployment", func() {
 				},
 			}
 			response := bytes.NewBuffer([]byte{})
-			data := make(map[string]interface{})
+			data := make(structs.Params)
 			data["mykey"] = "first value"
 			controller.Config.Environments[environment] = structs.Environment{
 				Name:         environment,
 				Authenticate: true,
 			}
-			controller.StopDeployment(deployment, data, response)
+			controller.StopDeployment(context.Background(), deployment, data, response)
 
 			Expect(reflect.TypeOf(eventManager.EmitEventCall.Received.Events[2])).To(Equal(reflect.TypeOf(StopFinishedEvent{})))
 			event := eventManager.EmitEventCall.Received.Events[2].(StopFinishedEvent)
@@ -1,6 +1,7 @@
 package stop
 
 import (
+	"context"
 	"fmt"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
 	I "github.com/compozed/deployadactyl/interfaces"
@@ -30,7 +31,7 @@ func (a StopManager) Logger() I.DeploymentLogger {
 	return a.Log
 }
 
-func (a StopManager) SetUp() error {
+func (a StopManager) SetUp(ctx context.Context) error {
 	return nil
 }
 
@@ -68,6 +69,9 @@ func (a StopManager) Create(environment S.Environment, response io.ReadWriter, f
 		a.Log.Error(err)
 		return &Stopper{}, state.CourierCreationError{Err: err}
 	}
+	if environment.CABundle != "" {
+		courier = courier.WithCABundle(environment.CABundle)
+	}
 	p := &Stopper{
 		Courier: courier,
 		CFContext: I.CFContext{
@@ -83,9 +87,10 @@ func (a StopManager) Create(environment S.Environment, response io.ReadWriter, f
 		},
 		EventManager:  a.EventManager,
 		Response:      response,
-		Log:           a.Log,
+		Log:           a.Log.WithFields(I.LogFields{I.FoundationLogField: foundationURL, I.AppLogField: a.DeployEventData.DeploymentInfo.AppName}),
 		FoundationURL: foundationURL,
 		AppName:       a.DeployEventData.DeploymentInfo.AppName,
+		Mode:          a.DeployEventData.DeploymentInfo.StopMode,
 	}
 
 	return p, nil
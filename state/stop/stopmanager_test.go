@@ -53,7 +53,7 @@ var _ = Describe("Stopmanager", func() {
 		creator = &courierCreator{}
 		stopManager = stop.StopManager{
 			CourierCreator: creator,
-			Log:            interfaces.DeploymentLogger{log, randomizer.StringRunes(10)},
+			Log:            interfaces.DeploymentLogger{Log: log, UUID: randomizer.StringRunes(10)},
 			DeployEventData: structs.DeployEventData{
 				DeploymentInfo: &structs.DeploymentInfo{},
 				Response:       response,
@@ -1,9 +1,13 @@
 package stop
 
 import (
+	"context"
+	"fmt"
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/state"
+	S "github.com/compozed/deployadactyl/structs"
 	"io"
+	"strings"
 )
 
 type Stopper struct {
@@ -15,22 +19,26 @@ type Stopper struct {
 	Log           I.DeploymentLogger
 	FoundationURL string
 	AppName       string
+	Mode          S.StopMode
 }
 
-func (s Stopper) Verify() error {
+func (s Stopper) Verify(ctx context.Context) error {
 	return nil
 }
 
-func (s Stopper) Success() error {
+func (s Stopper) Success(ctx context.Context) error {
 	return nil
 }
 
-func (s Stopper) Finally() error {
-	return nil
+// Finally removes the temporary directory created by the Executor.
+func (s Stopper) Finally(ctx context.Context) error {
+	return s.Courier.CleanUp()
 }
 
 // Login will login to a Cloud Foundry instance.
-func (s Stopper) Initially() error {
+func (s Stopper) Initially(ctx context.Context) error {
+	s.Courier = s.Courier.WithContext(ctx)
+
 	s.Log.Debugf(
 		`logging into cloud foundry with parameters:
 		foundation URL: %+v
@@ -59,13 +67,20 @@ func (s Stopper) Initially() error {
 	return nil
 }
 
-func (s Stopper) Execute() error {
+func (s Stopper) Execute(ctx context.Context) error {
+	s.Courier = s.Courier.WithContext(ctx)
 
 	if s.Courier.Exists(s.AppName) != true {
 		s.Log.Errorf("failed to stop app on foundation %s: application doesn't exist", s.FoundationURL)
 		return state.ExistsError{ApplicationName: s.AppName}
 	}
 
+	s.recordState()
+
+	if s.Mode == S.StopModeScaleToZero {
+		return s.scaleToZero()
+	}
+
 	s.Log.Infof("stopping app %s", s.AppName)
 
 	output, err := s.Courier.Stop(s.AppName)
@@ -80,12 +95,17 @@ func (s Stopper) Execute() error {
 	return nil
 }
 
-func (s Stopper) Undo() error {
+func (s Stopper) Undo(ctx context.Context) error {
+	s.Courier = s.Courier.WithContext(ctx)
 
 	if s.Courier.Exists(s.AppName) != true {
 		return nil
 	}
 
+	if s.Mode == S.StopModeScaleToZero {
+		return s.scaleToRecordedInstances()
+	}
+
 	s.Log.Infof("starting app %s", s.AppName)
 
 	output, err := s.Courier.Start(s.AppName)
@@ -98,3 +118,70 @@ func (s Stopper) Undo() error {
 
 	return nil
 }
+
+// recordState labels the application with its instance count and state, so starting it restores them.
+// The application is stopped even if they can not be recorded.
+func (s Stopper) recordState() {
+	appState, err := s.Courier.AppState(s.AppName)
+	if err != nil {
+		s.Log.Errorf("could not record the instances of app %s: %s", s.AppName, err)
+		return
+	}
+
+	// An application without instances keeps the count recorded when it was scaled down.
+	if appState.Instances == 0 {
+		return
+	}
+
+	output, err := s.Courier.SetLabel(s.AppName, map[string]string{
+		state.InstancesLabel: fmt.Sprint(appState.Instances),
+		state.StateLabel:     strings.ToLower(appState.State),
+	})
+	if err != nil {
+		s.Log.Errorf("could not record the instances of app %s: %s", s.AppName, output)
+		return
+	}
+
+	s.Log.Infof("recorded %d instances of app %s", appState.Instances, s.AppName)
+}
+
+// scaleToZero removes every instance of the application but leaves it started, keeping its routes and
+// service bindings so it can be scaled back up without being restaged.
+func (s Stopper) scaleToZero() error {
+	s.Log.Infof("scaling app %s to zero instances", s.AppName)
+
+	output, err := s.Courier.Scale(s.AppName, 0)
+	if err != nil {
+		s.Log.Errorf("failed to scale app on foundation %s: %s", s.FoundationURL, err.Error())
+		return state.ScaleError{ApplicationName: s.AppName, Out: output}
+	}
+	s.Response.Write(output)
+
+	s.Log.Infof("successfully scaled app %s to zero instances", s.AppName)
+
+	return nil
+}
+
+// scaleToRecordedInstances undoes scaleToZero.
+func (s Stopper) scaleToRecordedInstances() error {
+	appState, err := s.Courier.AppState(s.AppName)
+	if err != nil {
+		return err
+	}
+
+	instances, ok := state.RecordedInstances(appState)
+	if !ok {
+		s.Log.Errorf("cannot restore app %s: no instances were recorded", s.AppName)
+		return nil
+	}
+
+	s.Log.Infof("scaling app %s back to %d instances", s.AppName, instances)
+
+	output, err := s.Courier.Scale(s.AppName, instances)
+	if err != nil {
+		return state.ScaleError{ApplicationName: s.AppName, Out: output}
+	}
+	s.Response.Write(output)
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package stop_test
 
 import (
+	"context"
 	"errors"
 	//"fmt"
 	"math/rand"
@@ -110,7 +111,7 @@ var _ = Describe("Stopper", func() {
 		Context("when login succeeds", func() {
 			It("gives the correct info to the courier", func() {
 
-				Expect(stopper.Initially()).To(Succeed())
+				Expect(stopper.Initially(context.Background())).To(Succeed())
 
 				Expect(courier.LoginCall.Received.FoundationURL).To(Equal(randomFoundationURL))
 				Expect(courier.LoginCall.Received.Username).To(Equal(randomUsername))
@@ -123,7 +124,7 @@ var _ = Describe("Stopper", func() {
 			It("writes the output of the courier to the response", func() {
 				courier.LoginCall.Returns.Output = []byte("login succeeded")
 
-				Expect(stopper.Initially()).To(Succeed())
+				Expect(stopper.Initially(context.Background())).To(Succeed())
 
 				Eventually(response).Should(Say("login succeeded"))
 			})
@@ -134,7 +135,7 @@ var _ = Describe("Stopper", func() {
 				courier.LoginCall.Returns.Output = []byte("login output")
 				courier.LoginCall.Returns.Error = errors.New("login error")
 
-				err := stopper.Initially()
+				err := stopper.Initially(context.Background())
 				Expect(err).To(MatchError(state.LoginError{randomFoundationURL, []byte("login output")}))
 			})
 
@@ -142,7 +143,7 @@ var _ = Describe("Stopper", func() {
 				courier.LoginCall.Returns.Output = []byte("login output")
 				courier.LoginCall.Returns.Error = errors.New("login error")
 
-				err := stopper.Initially()
+				err := stopper.Initially(context.Background())
 				Expect(err).To(HaveOccurred())
 
 				Eventually(response).Should(Say("login output"))
@@ -151,7 +152,7 @@ var _ = Describe("Stopper", func() {
 			It("logs an error", func() {
 				courier.LoginCall.Returns.Error = errors.New("login error")
 
-				err := stopper.Initially()
+				err := stopper.Initially(context.Background())
 				Expect(err).To(HaveOccurred())
 
 				Eventually(logBuffer).Should(Say(fmt.Sprintf("could not login to %s", randomFoundationURL)))
@@ -165,7 +166,7 @@ var _ = Describe("Stopper", func() {
 				courier.ExistsCall.Returns.Bool = true
 				courier.StopCall.Returns.Output = []byte("stop succeeded")
 
-				Expect(stopper.Execute()).To(Succeed())
+				Expect(stopper.Execute(context.Background())).To(Succeed())
 
 				Expect(courier.StopCall.Received.AppName).To(Equal(randomAppName))
 
@@ -176,13 +177,48 @@ var _ = Describe("Stopper", func() {
 			})
 		})
 
+		Context("when the app has running instances", func() {
+			It("records the instances and state in labels", func() {
+				courier.ExistsCall.Returns.Bool = true
+				courier.AppStateCall.Returns.AppState = S.AppState{State: "STARTED", Instances: 3}
+
+				Expect(stopper.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.AppStateCall.Received.AppName).To(Equal(randomAppName))
+				Expect(courier.SetLabelCall.Received.AppName).To(Equal(randomAppName))
+				Expect(courier.SetLabelCall.Received.Labels).To(Equal(map[string]string{
+					state.InstancesLabel: "3",
+					state.StateLabel:     "started",
+				}))
+			})
+
+			It("does not overwrite the labels when the app has no instances", func() {
+				courier.ExistsCall.Returns.Bool = true
+				courier.AppStateCall.Returns.AppState = S.AppState{State: "STOPPED"}
+
+				Expect(stopper.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.SetLabelCall.Received.Labels).To(BeNil())
+			})
+
+			It("still stops the app when the state can not be read", func() {
+				courier.ExistsCall.Returns.Bool = true
+				courier.AppStateCall.Returns.Error = errors.New("curl failed")
+
+				Expect(stopper.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.StopCall.Received.AppName).To(Equal(randomAppName))
+				Eventually(logBuffer).Should(Say("could not record the instances"))
+			})
+		})
+
 		Context("when the stop fails", func() {
 			It("returns an error", func() {
 				courier.ExistsCall.Returns.Bool = true
 				courier.StopCall.Returns.Output = []byte("this is some output")
 				courier.StopCall.Returns.Error = errors.New("")
 
-				err := stopper.Execute()
+				err := stopper.Execute(context.Background())
 
 				Expect(err).To(MatchError(state.StopError{ApplicationName: randomAppName, Out: []byte("this is some output")}))
 			})
@@ -192,18 +228,60 @@ var _ = Describe("Stopper", func() {
 			It("returns an error", func() {
 				courier.ExistsCall.Returns.Bool = false
 
-				err := stopper.Execute()
+				err := stopper.Execute(context.Background())
 
 				Expect(err).To(MatchError(state.ExistsError{ApplicationName: randomAppName}))
 			})
 		})
+
+		Context("when scaling to zero", func() {
+			BeforeEach(func() {
+				courier.ExistsCall.Returns.Bool = true
+				courier.AppStateCall.Returns.AppState = S.AppState{State: "STARTED", Instances: 3}
+				stopper.Mode = S.StopModeScaleToZero
+			})
+
+			It("scales the app to zero instances instead of stopping it", func() {
+				courier.ScaleCall.Returns.Output = []byte("scale succeeded")
+
+				Expect(stopper.Execute(context.Background())).To(Succeed())
+
+				Expect(courier.ScaleCall.Received.AppName).To(Equal(randomAppName))
+				Expect(courier.ScaleCall.Received.Instances).To(Equal(uint16(0)))
+				Expect(courier.StopCall.Received.AppName).To(BeEmpty())
+				Expect(courier.SetLabelCall.Received.Labels[state.InstancesLabel]).To(Equal("3"))
+				Eventually(response).Should(Say("scale succeeded"))
+			})
+
+			It("returns an error when the scale fails", func() {
+				courier.ScaleCall.Returns.Output = []byte("scale output")
+				courier.ScaleCall.Returns.Error = errors.New("scale failed")
+
+				err := stopper.Execute(context.Background())
+
+				Expect(err).To(MatchError(state.ScaleError{ApplicationName: randomAppName, Out: []byte("scale output")}))
+			})
+		})
 	})
 
 	Describe("Undo", func() {
+		Context("when the app was scaled to zero", func() {
+			It("scales the app back to the recorded instances", func() {
+				courier.ExistsCall.Returns.Bool = true
+				courier.AppStateCall.Returns.AppState = S.AppState{Labels: map[string]string{state.InstancesLabel: "3"}}
+				stopper.Mode = S.StopModeScaleToZero
+
+				Expect(stopper.Undo(context.Background())).To(Succeed())
+
+				Expect(courier.ScaleCall.Received.Instances).To(Equal(uint16(3)))
+				Expect(courier.StartCall.Received.AppName).To(BeEmpty())
+			})
+		})
+
 		Context("when the app does not exist", func() {
 			It("return without error", func() {
 				courier.ExistsCall.Returns.Bool = false
-				err := stopper.Undo()
+				err := stopper.Undo(context.Background())
 
 				Expect(err).To(BeNil())
 			})
@@ -215,7 +293,7 @@ var _ = Describe("Stopper", func() {
 				courier.StartCall.Returns.Output = []byte("this is some output")
 				courier.StartCall.Returns.Error = errors.New("app could not be started")
 
-				err := stopper.Undo()
+				err := stopper.Undo(context.Background())
 
 				Expect(err).To(MatchError(state.StartError{ApplicationName: randomAppName, Out: []byte("this is some output")}))
 			})
@@ -226,7 +304,7 @@ var _ = Describe("Stopper", func() {
 				courier.ExistsCall.Returns.Bool = true
 				courier.StartCall.Returns.Output = []byte("start succeeded")
 
-				Expect(stopper.Undo()).To(Succeed())
+				Expect(stopper.Undo(context.Background())).To(Succeed())
 				Expect(courier.StartCall.Received.AppName).To(Equal(randomAppName))
 
 				Eventually(response).Should(Say("start succeeded"))
@@ -238,19 +316,27 @@ var _ = Describe("Stopper", func() {
 
 	Describe("Verify", func() {
 		It("returns nil", func() {
-			Expect(stopper.Verify()).To(BeNil())
+			Expect(stopper.Verify(context.Background())).To(BeNil())
 		})
 	})
 
 	Describe("Success", func() {
 		It("returns nil", func() {
-			Expect(stopper.Success()).To(BeNil())
+			Expect(stopper.Success(context.Background())).To(BeNil())
 		})
 	})
 
 	Describe("Finally", func() {
-		It("returns nil", func() {
-			Expect(stopper.Finally()).To(BeNil())
+		It("removes the temporary directory created by the Executor", func() {
+			Expect(stopper.Finally(context.Background())).To(Succeed())
+
+			Expect(courier.CleanUpCall.Called).To(BeTrue())
+		})
+
+		It("returns an error when the temporary directory cannot be removed", func() {
+			courier.CleanUpCall.Returns.Error = errors.New("cannot remove")
+
+			Expect(stopper.Finally(context.Background())).To(MatchError("cannot remove"))
 		})
 	})
 })
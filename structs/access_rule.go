@@ -0,0 +1,10 @@
+package structs
+
+// AccessRule allows its Users and the members of its Groups to run its Operations on an environment: deploy, stop,
+// start, restart, restage or delete. A user is the username of the basic auth or the user of the bearer token of a
+// request, and a group is one of the LDAP groups of the user, by DN or common name, or of the groups of its token.
+type AccessRule struct {
+	Users      []string `yaml:"users"`
+	Groups     []string `yaml:"groups"`
+	Operations []string `yaml:"operations"`
+}
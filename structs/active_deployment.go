@@ -0,0 +1,20 @@
+package structs
+
+import "time"
+
+// PhaseQueued is the phase of a deployment that was accepted but has not started its first phase yet.
+const PhaseQueued = "queued"
+
+// ActiveDeployment is a deployment that is running or queued.
+type ActiveDeployment struct {
+	UUID           string    `json:"uuid"`
+	Environment    string    `json:"environment"`
+	Org            string    `json:"org"`
+	Space          string    `json:"space"`
+	AppName        string    `json:"app_name"`
+	Phase          string    `json:"phase"`
+	Percent        int       `json:"percent"`
+	StartedAt      time.Time `json:"started_at"`
+	ElapsedSeconds int       `json:"elapsed_seconds"`
+	Username       string    `json:"username"`
+}
@@ -0,0 +1,29 @@
+package structs
+
+import "time"
+
+// AppState is the state of an application on a foundation.
+type AppState struct {
+	GUID      string
+	State     string
+	Instances uint16
+	Labels    map[string]string
+}
+
+// Instance is a single instance of the web process of an application, such as a RUNNING, STARTING,
+// CRASHED or DOWN instance.
+type Instance struct {
+	Index  int
+	State  string
+	Uptime time.Duration
+}
+
+// AppSummary is an application on a foundation, with the org and space it is in.
+type AppSummary struct {
+	GUID      string
+	Name      string
+	Org       string
+	Space     string
+	State     string
+	CreatedAt time.Time
+}
@@ -0,0 +1,11 @@
+package structs
+
+// ArtifactCacheDescriptor configures the cache of the artifacts of failed deployments, from which their failed
+// foundations are retried without fetching the artifact again.
+//
+// Artifacts are kept for MaxAgeMinutes, and are not cached when it is zero. They are kept in the Directory, or in
+// an artifact-cache directory of the work directory when it is empty.
+type ArtifactCacheDescriptor struct {
+	MaxAgeMinutes int    `yaml:"max_age_minutes"`
+	Directory     string `yaml:"directory"`
+}
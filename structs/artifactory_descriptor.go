@@ -0,0 +1,12 @@
+package structs
+
+// ArtifactoryDescriptor configures the fetching of artifacts from the JFrog Artifactory server at URL, such as
+// https://artifactory.example.com/artifactory.
+//
+// Artifacts under URL are downloaded with the APIKey, which is expanded with environment variables, and their SHA-256
+// is checked against the one Artifactory reports. An artifact URL ending in /latest is the most recently modified
+// file of its folder.
+type ArtifactoryDescriptor struct {
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+}
@@ -0,0 +1,23 @@
+package structs
+
+// DeploymentSkipped is a deployment of a batch that was not run because an earlier one did not succeed.
+const DeploymentSkipped = "skipped"
+
+// BatchReport is the combined result of deploying an application to several environments, one after the other.
+//
+// Status is succeeded when every deployment succeeded, and otherwise the status of the deployment the batch
+// stopped at.
+type BatchReport struct {
+	BatchID     string            `json:"batch_id"`
+	Status      string            `json:"status"`
+	Deployments []BatchDeployment `json:"deployments"`
+}
+
+// BatchDeployment is the result of the deployment of a batch to a single environment.
+type BatchDeployment struct {
+	Environment string `json:"environment"`
+	UUID        string `json:"uuid,omitempty"`
+	Status      string `json:"status"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
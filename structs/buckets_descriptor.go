@@ -0,0 +1,35 @@
+package structs
+
+// BucketsDescriptor holds how the artifacts and signatures of s3:// and gs:// URLs are fetched for an environment.
+type BucketsDescriptor struct {
+	S3  S3Descriptor  `yaml:"s3"`
+	GCS GCSDescriptor `yaml:"gcs"`
+}
+
+// S3Descriptor configures the fetching of s3://bucket/key URLs from Amazon S3, or from a compatible store at
+// Endpoint.
+//
+// Region is the region of the buckets, us-east-1 by default. Buckets are addressed by their virtual host, unless
+// PathStyle is set, there is an Endpoint or the name of the bucket has dots. Requests are signed with the
+// AccessKeyID, SecretAccessKey and SessionToken, and are anonymous without them. SSECustomerKey is the base64 AES-256
+// key of objects encrypted with a customer provided key. The secrets are expanded with environment variables.
+type S3Descriptor struct {
+	Region          string `yaml:"region"`
+	Endpoint        string `yaml:"endpoint"`
+	PathStyle       bool   `yaml:"path_style"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	SessionToken    string `yaml:"session_token"`
+	SSECustomerKey  string `yaml:"sse_customer_key"`
+}
+
+// GCSDescriptor configures the fetching of gs://bucket/object URLs from Google Cloud Storage, or from Endpoint.
+//
+// Requests are authorized with an access token of the service account of the JSON key at CredentialsFile, and are
+// anonymous without it. EncryptionKey is the base64 AES-256 key of objects encrypted with a customer supplied key,
+// and is expanded with environment variables.
+type GCSDescriptor struct {
+	Endpoint        string `yaml:"endpoint"`
+	CredentialsFile string `yaml:"credentials_file"`
+	EncryptionKey   string `yaml:"encryption_key"`
+}
@@ -0,0 +1,46 @@
+package structs
+
+// The strategies an environment deploys applications with.
+const (
+	StrategyBlueGreen = "blue-green"
+	StrategyCanary    = "canary"
+)
+
+// Strategies are the strategies an environment can deploy applications with.
+var Strategies = []string{StrategyBlueGreen, StrategyCanary}
+
+// DefaultCanaryPercent is the percentage of the instances a canary runs when the environment does not set one.
+const DefaultCanaryPercent = 10
+
+// DefaultCanaryConfirmTimeoutSeconds is how long a canary waits to be promoted when the environment does not say.
+const DefaultCanaryConfirmTimeoutSeconds = 3600
+
+// CanaryDescriptor describes the canary of an environment deploying with the canary strategy.
+//
+// The new build is pushed with Percent of the instances of the application, rounded up, next to the original
+// application, and serves its share of the traffic of the route. It is scaled to every instance and replaces the
+// original application once it baked for BakeSeconds on every foundation, or once it is promoted. With Confirm, it
+// waits to be promoted for at most ConfirmTimeoutSeconds instead, and is rolled back when it is not.
+type CanaryDescriptor struct {
+	Percent               int  `yaml:"percent"`
+	BakeSeconds           int  `yaml:"bake_seconds"`
+	Confirm               bool `yaml:"confirm"`
+	ConfirmTimeoutSeconds int  `yaml:"confirm_timeout_seconds"`
+}
+
+// Instances returns how many of the instances of the application the canary runs, which is at least one.
+func (d CanaryDescriptor) Instances(instances uint16) uint16 {
+	percent := d.Percent
+	if percent <= 0 {
+		percent = DefaultCanaryPercent
+	}
+
+	canary := (int(instances)*percent + 99) / 100
+	if canary < 1 {
+		canary = 1
+	}
+	if canary > int(instances) && instances > 0 {
+		canary = int(instances)
+	}
+	return uint16(canary)
+}
@@ -0,0 +1,13 @@
+package structs
+
+// The couriers an environment can run Cloud Foundry commands with.
+const (
+	// CourierCLI runs the commands with the Cloud Foundry CLI.
+	CourierCLI = "cli"
+
+	// CourierAPI runs the commands against the v3 API of the Cloud Controller, without the Cloud Foundry CLI.
+	CourierAPI = "api"
+)
+
+// Couriers are the couriers an environment can run Cloud Foundry commands with.
+var Couriers = []string{CourierCLI, CourierAPI}
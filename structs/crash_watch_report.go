@@ -0,0 +1,46 @@
+package structs
+
+import (
+	"sort"
+	"sync"
+)
+
+// CrashWatchResult is the number of times a promoted application crashed on one foundation
+// while it was being watched.
+type CrashWatchResult struct {
+	FoundationURL string
+	Crashes       int
+}
+
+// CrashWatchReport collects the foundations on which a promoted application started crashing,
+// which are watched concurrently.
+type CrashWatchReport struct {
+	mu      sync.Mutex
+	results []CrashWatchResult
+}
+
+// Add records the crashes of an application on a foundation.
+func (r *CrashWatchReport) Add(result CrashWatchResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.results = append(r.results, result)
+}
+
+// Results returns the recorded results ordered by foundation.
+func (r *CrashWatchReport) Results() []CrashWatchResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := append([]CrashWatchResult{}, r.results...)
+	sort.Slice(results, func(i, j int) bool { return results[i].FoundationURL < results[j].FoundationURL })
+	return results
+}
+
+// Degraded returns true if the application crashed on any foundation.
+func (r *CrashWatchReport) Degraded() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.results) > 0
+}
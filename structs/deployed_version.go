@@ -0,0 +1,21 @@
+package structs
+
+import "time"
+
+// VersionMetadataKey is the metadata key holding the version of the deployed artifact.
+const VersionMetadataKey = "version"
+
+// DeployedVersion is what is running on a foundation: the artifact of the latest deployment of the application
+// to the foundation that succeeded.
+type DeployedVersion struct {
+	Environment    string    `json:"environment"`
+	FoundationURL  string    `json:"foundation_url"`
+	UUID           string    `json:"uuid"`
+	Status         string    `json:"status"`
+	ArtifactURL    string    `json:"artifact_url"`
+	ArtifactDigest string    `json:"artifact_digest,omitempty"`
+	Version        string    `json:"version,omitempty"`
+	Revision       string    `json:"revision,omitempty"`
+	DeployedBy     string    `json:"deployed_by"`
+	DeployedAt     time.Time `json:"deployed_at"`
+}
@@ -0,0 +1,41 @@
+package structs
+
+import (
+	"sort"
+	"sync"
+)
+
+// DeploymentEvidence is what the router and application logs of the new build showed on one
+// foundation during a deployment. Excerpts are the log lines of server errors and crashes.
+type DeploymentEvidence struct {
+	FoundationURL string   `json:"foundation_url"`
+	AppName       string   `json:"app_name"`
+	Requests      int      `json:"requests"`
+	ServerErrors  int      `json:"server_errors"`
+	Crashes       int      `json:"crashes"`
+	Excerpts      []string `json:"excerpts"`
+}
+
+// DeploymentEvidenceReport collects the evidence of every foundation, which are deployed to concurrently.
+type DeploymentEvidenceReport struct {
+	mu      sync.Mutex
+	results []DeploymentEvidence
+}
+
+// Add records the evidence of a foundation.
+func (r *DeploymentEvidenceReport) Add(evidence DeploymentEvidence) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.results = append(r.results, evidence)
+}
+
+// Results returns the recorded evidence ordered by foundation.
+func (r *DeploymentEvidenceReport) Results() []DeploymentEvidence {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := append([]DeploymentEvidence{}, r.results...)
+	sort.Slice(results, func(i, j int) bool { return results[i].FoundationURL < results[j].FoundationURL })
+	return results
+}
@@ -11,6 +11,7 @@ type DeploymentInfo struct {
 	Manifest             string `json:"manifest"`
 	Username             string
 	Password             string
+	Subject              string `json:"-"`
 	Environment          string
 	Org                  string
 	Space                string
@@ -24,8 +25,91 @@ type DeploymentInfo struct {
 	Body                 io.Reader
 	EnvironmentVariables map[string]string `json:"environment_variables"`
 	HealthCheckEndpoint  string            `json:"health_check_endpoint"`
-	CustomParams         map[string]interface{}
+	CustomParams         Params
+
+	// Routes of the request are mapped to the application with the routes of the environment.
+	Routes []Route `json:"routes"`
 
 	// Generic map used for users to provide their own deployment properties in JSON format.
-	Data map[string]interface{} `json:"data"`
+	Data Params `json:"data"`
+
+	// Metadata carries caller provided identifiers, such as pipeline IDs, commit SHAs or
+	// ticket numbers, through every event emitted for the deployment.
+	Metadata map[string]string `json:"metadata"`
+
+	// ScanResult is the outcome of scanning the artifact, if the environment has a scanner.
+	ScanResult *ScanResult `json:"-"`
+
+	// Evidence is what the router and application logs showed during the deployment, if the environment captures them.
+	Evidence []DeploymentEvidence `json:"-"`
+
+	// SBOM and Provenance may be provided with the request. Components missing from the SBOM
+	// are found in the extracted artifact, whose digest is stored in ArtifactDigest.
+	SBOM           *SBOM       `json:"sbom,omitempty"`
+	Provenance     *Provenance `json:"provenance,omitempty"`
+	ArtifactDigest string      `json:"-"`
+
+	// ArtifactSize is the size in bytes of the extracted artifact.
+	ArtifactSize int64 `json:"-"`
+
+	// ExpectedArtifactDigest is the digest the artifact must have, such as when it is promoted from another environment.
+	ExpectedArtifactDigest string `json:"-"`
+
+	// CachedArtifact is the uuid of the deployment whose cached artifact is deployed instead of fetching the ArtifactURL.
+	CachedArtifact string `json:"-"`
+
+	// Git is the repository the application is checked out from instead of an artifact. It is deployed as the
+	// ArtifactURL of the source.
+	Git *GitSource `json:"git,omitempty"`
+
+	// Maven are the coordinates of the artifact resolved against the Maven repositories of the environment instead
+	// of an artifact URL. They are deployed as the ArtifactURL of the coordinates.
+	Maven *MavenCoordinates `json:"maven,omitempty"`
+
+	// Signature is the signature of the artifact. Without it the signature is fetched from
+	// SignatureURL, or from the artifact URL with a .sig or .asc suffix.
+	Signature    string `json:"signature"`
+	SignatureURL string `json:"signature_url"`
+
+	// Manifests are base64 encoded manifests by name, of which the one named ManifestName, or else the environment,
+	// is deployed when there is no Manifest.
+	Manifests    map[string]string `json:"manifests"`
+	ManifestName string            `json:"manifest_name"`
+
+	// MergeManifest merges the Manifest onto the manifest of the artifact instead of replacing it. The Manifest and
+	// Manifests are base64 encoded or inline YAML.
+	MergeManifest bool `json:"merge_manifest"`
+
+	// StopMode selects how the application is stopped by a stop request.
+	StopMode StopMode `json:"-"`
+
+	// BatchSize is the number of instances a rolling restart restarts at a time.
+	BatchSize int `json:"-"`
+
+	// DeleteRoutes deletes the routes a delete request leaves without an application.
+	DeleteRoutes bool `json:"-"`
+
+	// SkippedFoundations are the foundations of the environment the deployment skipped because they were in maintenance.
+	SkippedFoundations []string `json:"-"`
+}
+
+// User returns who requested the deployment: the user of the bearer token of the request, or else the Cloud Foundry
+// user the deployment is made with.
+func (d DeploymentInfo) User() string {
+	if d.Subject != "" {
+		return d.Subject
+	}
+	return d.Username
+}
+
+// MergeMetadata returns a new metadata map containing the keys of every given map.
+// Keys in later maps take precedence over keys in earlier ones.
+func MergeMetadata(metadata ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, m := range metadata {
+		for key, value := range m {
+			merged[key] = value
+		}
+	}
+	return merged
 }
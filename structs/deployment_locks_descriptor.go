@@ -0,0 +1,15 @@
+package structs
+
+// DefaultLockTTLSeconds is how long the lock of an application outlives an instance of the server that stopped
+// refreshing it when the configuration does not set it.
+const DefaultLockTTLSeconds = 30
+
+// DeploymentLocksDescriptor configures where the locks of the applications being deployed are held.
+//
+// Without Redis the locks are only held in memory, so they do not hold across several instances of the server.
+// Redis is the URL of a Redis the locks are held in instead. A lock held in Redis is refreshed while its deployment
+// runs, and expires TTLSeconds after its instance stopped refreshing it. Zero uses the default.
+type DeploymentLocksDescriptor struct {
+	Redis      string `yaml:"redis"`
+	TTLSeconds int    `yaml:"ttl_seconds"`
+}
@@ -0,0 +1,13 @@
+package structs
+
+// DeploymentLogsDescriptor configures where the output of every deployment is kept.
+//
+// Without a Directory the output is only kept in memory and is lost on restart. Postgres is the URL of a PostgreSQL
+// database the output is kept in instead, so it can be shared by several instances of the server. At most MaxLogs
+// deployments are kept, and none older than MaxAgeDays. Zero keeps logs of any age.
+type DeploymentLogsDescriptor struct {
+	Directory  string `yaml:"directory"`
+	MaxLogs    int    `yaml:"max_logs"`
+	MaxAgeDays int    `yaml:"max_age_days"`
+	Postgres   string `yaml:"postgres"`
+}
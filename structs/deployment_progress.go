@@ -0,0 +1,36 @@
+package structs
+
+import "time"
+
+// The phases of a deployment, in the order they are run. A deployment that fails to execute is rolled
+// back instead of succeeding. Only the canaries of a canary deployment bake and are promoted.
+const (
+	PhasePrechecking = "prechecking"
+	PhasePreparing   = "preparing"
+	PhaseLoggingIn   = "logging_in"
+	PhaseExecuting   = "executing"
+	PhaseVerifying   = "verifying"
+	PhaseBaking      = "baking"
+	PhasePromoting   = "promoting"
+	PhaseSucceeding  = "succeeding"
+	PhaseRollingBack = "rolling_back"
+	PhaseFinished    = "finished"
+)
+
+// DeploymentProgress is how far a deployment has got.
+//
+// FoundationsDone counts the foundations that finished the current phase. Steps holds the step each
+// foundation is running, such as "push" or "health-check", keyed by foundation URL. FailedFoundations are
+// the foundations the deployment failed against, in the order they failed.
+type DeploymentProgress struct {
+	UUID              string            `json:"uuid"`
+	Phase             string            `json:"phase"`
+	Percent           int               `json:"percent"`
+	Foundations       int               `json:"foundations"`
+	FoundationsDone   int               `json:"foundations_done"`
+	Steps             map[string]string `json:"steps,omitempty"`
+	FailedFoundations []string          `json:"failed_foundations,omitempty"`
+	Finished          bool              `json:"finished"`
+	Error             string            `json:"error,omitempty"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,76 @@
+package structs
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeploymentRunning   = "running"
+	DeploymentSucceeded = "succeeded"
+	DeploymentFailed    = "failed"
+
+	// DeploymentDegraded is a successful deployment whose application started crashing after it was promoted.
+	DeploymentDegraded = "degraded"
+
+	// DeploymentPartial is a deployment that succeeded on some of its foundations but failed on or skipped others.
+	DeploymentPartial = "partial"
+)
+
+// DeploymentRecord is the entry kept in the deployment history for a single deployment.
+type DeploymentRecord struct {
+	UUID           string               `json:"uuid"`
+	Environment    string               `json:"environment"`
+	Org            string               `json:"org"`
+	Space          string               `json:"space"`
+	AppName        string               `json:"app_name"`
+	Username       string               `json:"username"`
+	ArtifactURL    string               `json:"artifact_url"`
+	ArtifactDigest string               `json:"artifact_digest"`
+	Status         string               `json:"status"`
+	Error          string               `json:"error,omitempty"`
+	StartedAt      time.Time            `json:"started_at"`
+	FinishedAt     time.Time            `json:"finished_at,omitempty"`
+	Metadata       map[string]string    `json:"metadata"`
+	SBOM           *SBOM                `json:"sbom,omitempty"`
+	Provenance     *Provenance          `json:"provenance,omitempty"`
+	ScanResult     *ScanResult          `json:"scan_result,omitempty"`
+	Evidence       []DeploymentEvidence `json:"evidence,omitempty"`
+
+	// Request is the JSON body the deployment was requested with, so a failed deployment can be retried.
+	// Deployments of uploaded zip files have no request.
+	Request json.RawMessage `json:"request,omitempty"`
+
+	// Foundations are the foundations the deployment deployed to.
+	Foundations []string `json:"foundations,omitempty"`
+
+	// FailedFoundations are the foundations a failed deployment failed against.
+	FailedFoundations []string `json:"failed_foundations,omitempty"`
+
+	// SkippedFoundations are the foundations the deployment skipped because they were in maintenance.
+	SkippedFoundations []string `json:"skipped_foundations,omitempty"`
+
+	// FoundationResults are the outcomes of a finished deployment on every foundation.
+	FoundationResults []FoundationResult `json:"foundation_results,omitempty"`
+
+	// Progress is how far a running deployment has got. It is only set when a single deployment is read.
+	Progress *DeploymentProgress `json:"progress,omitempty"`
+}
+
+// DeploymentQuery filters the deployment history. Empty fields match every record.
+//
+// Component matches records whose SBOM contains a component with that name, and ComponentVersion
+// further restricts it to versions starting with the given prefix, so "2.14" matches "2.14.1".
+// Current only returns the latest successful deployment of each application, which is the
+// version that is running.
+type DeploymentQuery struct {
+	Environment      string
+	Org              string
+	Space            string
+	AppName          string
+	Status           string
+	Component        string
+	ComponentVersion string
+	Current          bool
+	Limit            int
+}
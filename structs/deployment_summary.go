@@ -0,0 +1,71 @@
+package structs
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// DeploymentSummary is what a deployment took and what it left behind. It is written as the footer of every
+// response.
+type DeploymentSummary struct {
+	Duration time.Duration `json:"duration"`
+
+	// ArtifactSizeBytes is the size of the extracted artifact that was pushed, 0 when nothing was fetched.
+	ArtifactSizeBytes int64 `json:"artifact_size_bytes"`
+
+	// FoundationsSucceeded and FoundationsFailed are both 0 when the deployment failed before any foundation
+	// was reached.
+	Foundations          int `json:"foundations"`
+	FoundationsSucceeded int `json:"foundations_succeeded"`
+	FoundationsFailed    int `json:"foundations_failed"`
+
+	// FoundationsSkipped are the foundations that were in maintenance. They are not part of Foundations.
+	FoundationsSkipped int `json:"foundations_skipped"`
+
+	// Instances is the number of instances of the new build on every foundation.
+	Instances uint16 `json:"instances"`
+
+	// PartialResults are the outcomes on every foundation of a deployment that only partially succeeded.
+	PartialResults []FoundationResult `json:"partial_results,omitempty"`
+}
+
+// Write writes the summary as the footer of a response.
+func (s DeploymentSummary) Write(w io.Writer) {
+	fmt.Fprintf(w, "\nDeployment Summary:\n")
+	fmt.Fprintf(w, "  duration: %s\n", s.Duration-s.Duration%time.Millisecond)
+	fmt.Fprintf(w, "  artifact size: %s\n", formatBytes(s.ArtifactSizeBytes))
+	fmt.Fprintf(w, "  foundations: %d succeeded, %d failed of %d\n", s.FoundationsSucceeded, s.FoundationsFailed, s.Foundations)
+	if s.FoundationsSkipped > 0 {
+		fmt.Fprintf(w, "  skipped foundations in maintenance: %d\n", s.FoundationsSkipped)
+	}
+	fmt.Fprintf(w, "  new instances: %d\n", s.Instances)
+
+	if len(s.PartialResults) > 0 {
+		fmt.Fprintf(w, "  partial success:\n")
+		for _, result := range s.PartialResults {
+			if result.Error != "" {
+				fmt.Fprintf(w, "    %s: %s: %s\n", result.FoundationURL, result.Status, result.Error)
+			} else {
+				fmt.Fprintf(w, "    %s: %s\n", result.FoundationURL, result.Status)
+			}
+		}
+	}
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	value, suffix := float64(bytes)/unit, "KB"
+	for _, next := range []string{"MB", "GB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
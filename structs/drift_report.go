@@ -0,0 +1,51 @@
+package structs
+
+// AppSnapshot is the state of an application on a single foundation, as far as drift detection is concerned.
+//
+// EnvironmentVariables holds a digest of the value of every variable rather than the value, which may be a secret.
+// The artifact is the one the deployment history says is deployed to the foundation. Error is set when the
+// foundation could not be inspected.
+type AppSnapshot struct {
+	Environment          string            `json:"environment"`
+	FoundationURL        string            `json:"foundation_url"`
+	State                string            `json:"state,omitempty"`
+	Instances            uint16            `json:"instances"`
+	Routes               []string          `json:"routes"`
+	EnvironmentVariables map[string]string `json:"environment_variables"`
+	ArtifactURL          string            `json:"artifact_url,omitempty"`
+	ArtifactDigest       string            `json:"artifact_digest,omitempty"`
+	Version              string            `json:"version,omitempty"`
+	Error                string            `json:"error,omitempty"`
+}
+
+// The fields of an application compared by drift detection.
+const (
+	DriftArtifactURL         = "artifact_url"
+	DriftArtifactDigest      = "artifact_digest"
+	DriftVersion             = "version"
+	DriftInstances           = "instances"
+	DriftRoutes              = "routes"
+	DriftEnvironmentVariable = "environment_variable"
+)
+
+// DriftReport compares the snapshots of an application on several foundations.
+type DriftReport struct {
+	Drifted     bool              `json:"drifted"`
+	Differences []DriftDifference `json:"differences"`
+	Snapshots   []AppSnapshot     `json:"snapshots"`
+}
+
+// DriftDifference is a field that does not have the same value on every foundation. Name is the name of the
+// environment variable when the field is an environment variable.
+type DriftDifference struct {
+	Field  string       `json:"field"`
+	Name   string       `json:"name,omitempty"`
+	Values []DriftValue `json:"values"`
+}
+
+// DriftValue is the value of a field on a foundation. An empty value means the field is not set.
+type DriftValue struct {
+	Environment   string `json:"environment"`
+	FoundationURL string `json:"foundation_url"`
+	Value         string `json:"value"`
+}
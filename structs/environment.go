@@ -8,6 +8,165 @@ type Environment struct {
 	Authenticate   bool
 	SkipSSL        bool `yaml:"skip_ssl"`
 	Instances      uint16
-	EnableRollback bool                   `yaml:"rollback_enabled"`
-	CustomParams   map[string]interface{} `yaml:"custom_params"`
+	EnableRollback bool                 `yaml:"rollback_enabled"`
+	CustomParams   Params               `yaml:"custom_params"`
+	PushSteps      []PushStepDescriptor `yaml:"push_steps"`
+
+	// MinSuccessfulFoundations is how many foundations a deployment has to succeed on to be accepted. A deployment
+	// that meets it only rolls back the foundations it failed on, which can then be retried. Zero requires every
+	// foundation to succeed.
+	MinSuccessfulFoundations int `yaml:"min_successful_foundations"`
+
+	// MaxConcurrentFoundations is how many foundations a deployment works on at once, so an environment with many
+	// foundations does not overwhelm the server or the artifact source. Zero works on every foundation at once.
+	MaxConcurrentFoundations int `yaml:"max_concurrent_foundations"`
+
+	// AbortOnDisconnect cancels a deployment and rolls it back when the client disconnects.
+	AbortOnDisconnect bool `yaml:"abort_on_disconnect"`
+
+	// Scanner is submitted every fetched artifact before it is pushed.
+	Scanner ScannerDescriptor `yaml:"scanner"`
+
+	// CABundle is the path of the PEM encoded certificate authorities trusted instead of the system roots
+	// when logging into the foundations, fetching artifacts and checking the health of applications.
+	CABundle string `yaml:"ca_bundle"`
+
+	// HealthCheck configures the HTTP client of the health checks.
+	HealthCheck HealthCheckDescriptor `yaml:"health_check"`
+
+	// StartTimeoutSeconds is how long a started application is given for all of its instances to be running.
+	StartTimeoutSeconds int `yaml:"start_timeout_seconds"`
+
+	// CrashWatchSeconds is how long a promoted application is watched for crashes. Zero disables the watch.
+	CrashWatchSeconds int `yaml:"crash_watch_seconds"`
+
+	// DrainDelaySeconds is how long the original application keeps running after the load balanced route is
+	// unmapped from it, before it is deleted or stopped.
+	DrainDelaySeconds int `yaml:"drain_delay_seconds"`
+
+	// AutoRollback keeps the original application until the crash watch is over, and restores it
+	// if the promoted application crashes or fails its health check during the watch.
+	AutoRollback bool `yaml:"auto_rollback"`
+
+	// DeleteReplacedAfterMinutes keeps the application replaced by a push stopped for that long before it is
+	// deleted, so it can be started again if the promotion was a mistake. Zero deletes it right away.
+	DeleteReplacedAfterMinutes int `yaml:"delete_replaced_after_minutes"`
+
+	// Strategy is how applications are deployed to the environment: blue-green, the default, or canary.
+	Strategy string `yaml:"strategy"`
+
+	// Canary describes the canary of the canary strategy.
+	Canary CanaryDescriptor `yaml:"canary"`
+
+	// Rolling deploys the foundations in batches instead of all at once.
+	Rolling RollingDescriptor `yaml:"rolling"`
+
+	// Courier is how Cloud Foundry commands are run on the foundations of the environment: cli or api.
+	// The courier of the server is used when it is empty.
+	Courier string `yaml:"courier"`
+
+	// PromotionGates are checked against the new build before it replaces the original application.
+	PromotionGates []PromotionGateDescriptor `yaml:"promotion_gates"`
+
+	// LogCache reads the logs added to failure responses from the Log Cache API of the foundations.
+	LogCache LogCacheDescriptor `yaml:"log_cache"`
+
+	// EventCapture keeps the server errors and crashes in the logs of the new build with the deployment record.
+	EventCapture EventCaptureDescriptor `yaml:"event_capture"`
+
+	// Signature verifies the signature of every fetched artifact before it is extracted.
+	Signature SignatureDescriptor `yaml:"signature"`
+
+	// Git lets deployments check out and build their application from a git repository.
+	Git GitDescriptor `yaml:"git"`
+
+	// Buckets holds the credentials the artifacts of s3:// and gs:// URLs are fetched with.
+	Buckets BucketsDescriptor `yaml:"buckets"`
+
+	// Artifactory resolves, authorizes and checks the artifacts fetched from an Artifactory server.
+	Artifactory ArtifactoryDescriptor `yaml:"artifactory"`
+
+	// Maven holds the repositories the Maven coordinates of deployments are resolved against.
+	Maven MavenDescriptor `yaml:"maven"`
+
+	// StampEnvVars are the names of the deployment environment variables set on every pushed application,
+	// so it can report which deployment it came from.
+	StampEnvVars []string `yaml:"stamp_env_vars"`
+
+	// ManifestTemplate is a Go template given the DeploymentInfo that generates the manifest of applications
+	// deployed without one.
+	ManifestTemplate string `yaml:"manifest_template"`
+
+	// ManifestOverlay is merged onto the manifest of every application deployed to the environment.
+	ManifestOverlay ManifestOverlay `yaml:"manifest_overlay"`
+
+	// MetadataService binds every pushed application to a user provided service holding the deployment metadata,
+	// for platforms where deployment environment variables are not allowed.
+	MetadataService bool `yaml:"metadata_service"`
+
+	// PrePromotionTask is run on the new build after it is pushed and before it is mapped to the load balanced route.
+	PrePromotionTask TaskDescriptor `yaml:"pre_promotion_task"`
+
+	// Webhooks are posted the events of the deployments to the environment.
+	Webhooks []WebhookDescriptor `yaml:"webhooks"`
+
+	// VaultPath is the path of the Vault secret holding the username and password of the Cloud Foundry user of the
+	// environment, read at deploy time instead of the credentials of the configuration or of the tenant.
+	VaultPath string `yaml:"vault_path"`
+
+	// LDAP requires the requests to the environment to have the basic auth of an LDAP user, who must be a member of
+	// one of the LDAPGroups when there are any.
+	LDAP       bool     `yaml:"ldap"`
+	LDAPGroups []string `yaml:"ldap_groups"`
+
+	// Routes are mapped to every pushed application with the route of the load balanced domain, and moved from the
+	// original application to the new build with it.
+	Routes []Route `yaml:"routes"`
+
+	// Access restricts the operations on the environment to the users and groups of its rules. Every operation is
+	// allowed to everyone when there are none.
+	Access []AccessRule `yaml:"access"`
+
+	// Tenant is the name of the tenant the environment is configured under. Shared environments have none.
+	Tenant string `yaml:"-"`
+
+	// Username and Password are the Cloud Foundry credentials of the tenant, if it has its own.
+	Username string `yaml:"-"`
+	Password string `yaml:"-"`
+}
+
+// Credentials returns the Cloud Foundry credentials of the tenant of the environment, or the username and password
+// given when the environment is shared or its tenant has none.
+func (e Environment) Credentials(username, password string) (string, string) {
+	if e.Username == "" {
+		return username, password
+	}
+	return e.Username, e.Password
+}
+
+// ManifestOverlay holds the manifest attributes an environment sets on every application, whatever its manifest.
+// Environment variables are added to those of the manifest and services are bound in addition to its own.
+type ManifestOverlay struct {
+	Instances uint16            `yaml:"instances"`
+	Memory    string            `yaml:"memory"`
+	DiskQuota string            `yaml:"disk_quota"`
+	Env       map[string]string `yaml:"env"`
+	Services  []string          `yaml:"services"`
+}
+
+// Enabled reports whether the overlay changes anything.
+func (o ManifestOverlay) Enabled() bool {
+	return o.Instances > 0 || o.Memory != "" || o.DiskQuota != "" || len(o.Env) > 0 || len(o.Services) > 0
 }
+
+// The deployment environment variables that can be stamped on a pushed application.
+const (
+	StampDeploymentUUID = "DEPLOYADACTYL_UUID"
+	StampArtifactURL    = "ARTIFACT_URL"
+	StampArtifactDigest = "ARTIFACT_DIGEST"
+	StampDeployedAt     = "DEPLOYED_AT"
+	StampDeployedBy     = "DEPLOYED_BY"
+)
+
+// StampEnvVarNames are the names of every deployment environment variable.
+var StampEnvVarNames = []string{StampDeploymentUUID, StampArtifactURL, StampArtifactDigest, StampDeployedAt, StampDeployedBy}
@@ -1,8 +1,17 @@
 package structs
 
+const (
+	// StdoutStream limits an error matcher to the standard output of the cf CLI.
+	StdoutStream = "stdout"
+
+	// StderrStream limits an error matcher to the standard error of the cf CLI.
+	StderrStream = "stderr"
+)
+
 type ErrorMatcherDescriptor struct {
 	Description string `yaml:"description"`
 	Pattern     string `yaml:"pattern"`
 	Solution    string `yaml:"solution"`
 	Code        string `yaml:"code"`
+	Stream      string `yaml:"stream"`
 }
@@ -0,0 +1,33 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+)
+
+type MissingParamsError struct {
+	Keys []string
+}
+
+func (e MissingParamsError) Error() string {
+	return fmt.Sprintf("missing required parameters: %s", strings.Join(e.Keys, ", "))
+}
+
+type ParamTypeError struct {
+	Key   string
+	Type  string
+	Value interface{}
+}
+
+func (e ParamTypeError) Error() string {
+	return fmt.Sprintf("parameter %s must be a %s: got %T %v", e.Key, e.Type, e.Value, e.Value)
+}
+
+type InvalidRouteError struct {
+	Route  Route
+	Reason string
+}
+
+func (e InvalidRouteError) Error() string {
+	return fmt.Sprintf("invalid route %s: %s", strings.TrimPrefix(e.Route.URL(), "."), e.Reason)
+}
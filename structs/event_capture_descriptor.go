@@ -0,0 +1,8 @@
+package structs
+
+// EventCaptureDescriptor describes how the router and application logs of a deployment are kept as
+// evidence of its impact. MaxExcerpts is the most log lines kept for each foundation.
+type EventCaptureDescriptor struct {
+	Enabled     bool `yaml:"enabled"`
+	MaxExcerpts int  `yaml:"max_excerpts"`
+}
@@ -0,0 +1,40 @@
+package structs
+
+// EventPublisherDescriptor describes a message broker the events of every deployment are published to as JSON.
+//
+// Type is kafka, nats or amqp. A kafka publisher produces to Topic on the cluster of Brokers, keyed by the deployment
+// UUID so the events of a deployment stay in order, and SASL authenticates its connections. A nats publisher publishes
+// to the subject of the event type under Subject on the first of Servers that accepts it, authenticating with Token or
+// Username and Password. An amqp publisher publishes to Exchange in VHost on the first of Servers that accepts it,
+// with the environment and type of the event as the routing key, authenticating with Username and Password. TLS connects to the brokers over TLS, trusting CABundle instead of the system roots when it
+// is set. TimeoutSeconds limits every request to the brokers and defaults to 10 seconds.
+//
+// The username, password and token are expanded with environment variables.
+type EventPublisherDescriptor struct {
+	Type           string         `yaml:"type"`
+	Brokers        []string       `yaml:"brokers,flow"`
+	Topic          string         `yaml:"topic"`
+	TLS            bool           `yaml:"tls"`
+	CABundle       string         `yaml:"ca_bundle"`
+	SkipSSL        bool           `yaml:"skip_ssl"`
+	SASL           SASLDescriptor `yaml:"sasl"`
+	TimeoutSeconds int            `yaml:"timeout_seconds"`
+	Servers        []string       `yaml:"servers,flow"`
+	Subject        string         `yaml:"subject"`
+	Username       string         `yaml:"username"`
+	Password       string         `yaml:"password"`
+	Token          string         `yaml:"token"`
+	Exchange       string         `yaml:"exchange"`
+	VHost          string         `yaml:"vhost"`
+}
+
+// SASLDescriptor authenticates the connections to a message broker. Mechanism is PLAIN, SCRAM-SHA-256 or
+// SCRAM-SHA-512, and no mechanism does not authenticate.
+//
+// The username and password are expanded with environment variables, so they can be given as "${KAFKA_PASSWORD}"
+// instead of being written to the configuration.
+type SASLDescriptor struct {
+	Mechanism string `yaml:"mechanism"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+}
@@ -0,0 +1,46 @@
+package structs
+
+// The outcomes of a deployment on a foundation.
+const (
+	FoundationSucceeded  = "succeeded"
+	FoundationFailed     = "failed"
+	FoundationRolledBack = "rolled_back"
+
+	// FoundationAborted is a foundation the deployment did not change because it could not log into another one, or
+	// because its rolling rollout halted before it.
+	FoundationAborted = "aborted"
+
+	// FoundationSkipped is a foundation the deployment skipped because it was in maintenance.
+	FoundationSkipped = "skipped"
+)
+
+// FoundationResult is the outcome of a deployment on one of the foundations of its environment.
+type FoundationResult struct {
+	FoundationURL string `json:"foundation_url"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+}
+
+// PartialSuccess reports whether the deployment succeeded on some of the foundations but not on all of them.
+func PartialSuccess(results []FoundationResult) bool {
+	succeeded := 0
+	for _, result := range results {
+		if result.Status == FoundationSucceeded {
+			succeeded++
+		}
+	}
+
+	return succeeded > 0 && succeeded < len(results)
+}
+
+// FoundationsWithStatus returns the foundations of the results with the status.
+func FoundationsWithStatus(results []FoundationResult, status string) []string {
+	var foundations []string
+	for _, result := range results {
+		if result.Status == status {
+			foundations = append(foundations, result.FoundationURL)
+		}
+	}
+
+	return foundations
+}
@@ -0,0 +1,20 @@
+package structs
+
+// GitDescriptor lets the deployments to an environment check out their application from a git repository instead
+// of fetching an artifact.
+//
+// The ref of the request is checked out and BuildCommand, if any, is run in the checkout for at most
+// BuildTimeoutSeconds, 600 by default. Path is the directory of the checkout that is pushed, or a zip, jar or war it
+// builds, which is extracted; the whole checkout is pushed without it. Username and Password are the basic auth of
+// the https repositories under the URLs of Repositories, and are never sent to any other repository. The password is
+// expanded with environment variables. Other repositories are cloned with the git configuration and ssh keys of the
+// server.
+type GitDescriptor struct {
+	Enabled             bool     `yaml:"enabled"`
+	BuildCommand        []string `yaml:"build_command,flow"`
+	BuildTimeoutSeconds int      `yaml:"build_timeout_seconds"`
+	Path                string   `yaml:"path"`
+	Username            string   `yaml:"username"`
+	Password            string   `yaml:"password"`
+	Repositories        []string `yaml:"repositories"`
+}
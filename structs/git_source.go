@@ -0,0 +1,32 @@
+package structs
+
+import "strings"
+
+// GitScheme prefixes the artifact URL of a deployment checked out from a git repository.
+const GitScheme = "git+"
+
+// GitSource is the git repository a deployment is checked out from, and the SHA, tag or branch checked out.
+type GitSource struct {
+	URL string `json:"url"`
+	Ref string `json:"ref"`
+}
+
+// ArtifactURL returns the artifact URL standing for the source, such as
+// git+https://github.com/example/app.git#v1.2.0, so it is recorded and replayed like any other artifact.
+func (s GitSource) ArtifactURL() string {
+	return GitScheme + s.URL + "#" + s.Ref
+}
+
+// ParseGitArtifactURL returns the source of an artifact URL made by ArtifactURL, and false for any other URL.
+func ParseGitArtifactURL(artifactURL string) (GitSource, bool) {
+	if !strings.HasPrefix(artifactURL, GitScheme) {
+		return GitSource{}, false
+	}
+
+	location := strings.TrimPrefix(artifactURL, GitScheme)
+	i := strings.LastIndex(location, "#")
+	if i < 0 {
+		return GitSource{}, false
+	}
+	return GitSource{URL: location[:i], Ref: location[i+1:]}, true
+}
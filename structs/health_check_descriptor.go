@@ -0,0 +1,24 @@
+package structs
+
+// HealthCheckDescriptor describes the HTTP client the health checks of an environment are sent with.
+//
+// Header values are expanded with environment variables, so tokens for an auth proxy
+// can be given as "Bearer ${HEALTH_CHECK_TOKEN}" instead of being written to the configuration.
+// Proxy is the URL of an HTTP proxy. Certificates are only verified when VerifySSL is set or the
+// environment has a CA bundle, and ClientCertificate and ClientKey are paths to a PEM certificate
+// and key presented to servers that require one.
+type HealthCheckDescriptor struct {
+	TimeoutSeconds    int               `yaml:"timeout_seconds"`
+	Proxy             string            `yaml:"proxy"`
+	VerifySSL         bool              `yaml:"verify_ssl"`
+	ClientCertificate string            `yaml:"client_certificate"`
+	ClientKey         string            `yaml:"client_key"`
+	DisableRedirects  bool              `yaml:"disable_redirects"`
+	Headers           map[string]string `yaml:"headers"`
+}
+
+// Configured returns true if any setting differs from the default client.
+func (d HealthCheckDescriptor) Configured() bool {
+	return d.TimeoutSeconds > 0 || d.Proxy != "" || d.VerifySSL || d.ClientCertificate != "" ||
+		d.DisableRedirects || len(d.Headers) > 0
+}
@@ -0,0 +1,52 @@
+package structs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthCheckResult is the outcome of the health check of a pushed application on one foundation.
+type HealthCheckResult struct {
+	FoundationURL string
+	URL           string
+	StatusCode    int
+	Duration      time.Duration
+	Error         string
+}
+
+// Passed returns true if the application was healthy.
+func (r HealthCheckResult) Passed() bool {
+	return r.Error == ""
+}
+
+// HealthCheckReport collects the results of the health checks of a deployment,
+// which are run concurrently on every foundation.
+type HealthCheckReport struct {
+	mu      sync.Mutex
+	results []HealthCheckResult
+}
+
+// Add records the result of a health check.
+func (r *HealthCheckReport) Add(result HealthCheckResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.results = append(r.results, result)
+}
+
+// Results returns the recorded results ordered by foundation.
+func (r *HealthCheckReport) Results() []HealthCheckResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := append([]HealthCheckResult{}, r.results...)
+	sort.Stable(byFoundation(results))
+	return results
+}
+
+type byFoundation []HealthCheckResult
+
+func (r byFoundation) Len() int           { return len(r) }
+func (r byFoundation) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r byFoundation) Less(i, j int) bool { return r[i].FoundationURL < r[j].FoundationURL }
@@ -0,0 +1,11 @@
+package structs
+
+// HistoryDescriptor configures where the deployment history is kept.
+//
+// Without a File the history is only kept in memory and is lost on restart. Postgres is the URL of a PostgreSQL
+// database the history is kept in instead, so it can be shared by several instances of the server.
+type HistoryDescriptor struct {
+	File       string `yaml:"file"`
+	MaxRecords int    `yaml:"max_records"`
+	Postgres   string `yaml:"postgres"`
+}
@@ -0,0 +1,29 @@
+package structs
+
+// LDAPDescriptor configures the LDAP or Active Directory server the basic auth of the requests to the environments
+// with ldap is checked against.
+//
+// URL is ldap://host:389 or ldaps://host:636, and StartTLS secures an ldap connection before anything is sent.
+// The users are found under UserBaseDN with UserFilter, (uid=%s) by default or (sAMAccountName=%s) for Active
+// Directory, where %s is the escaped username, after binding as BindDN with BindPassword, or anonymously without
+// them. The bind password is expanded with environment variables.
+//
+// The groups of a user are the values of its GroupAttribute, memberOf by default, or, when there is a GroupBaseDN,
+// the groups under it matching GroupFilter, (member=%s) by default, where %s is the escaped DN of the user.
+//
+// CABundle is trusted instead of the system roots, or any certificate with SkipSSL. Connecting to the server and
+// every operation must not take longer than TimeoutSeconds, 10 by default.
+type LDAPDescriptor struct {
+	URL            string `yaml:"url"`
+	StartTLS       bool   `yaml:"start_tls"`
+	BindDN         string `yaml:"bind_dn"`
+	BindPassword   string `yaml:"bind_password"`
+	UserBaseDN     string `yaml:"user_base_dn"`
+	UserFilter     string `yaml:"user_filter"`
+	GroupAttribute string `yaml:"group_attribute"`
+	GroupBaseDN    string `yaml:"group_base_dn"`
+	GroupFilter    string `yaml:"group_filter"`
+	CABundle       string `yaml:"ca_bundle"`
+	SkipSSL        bool   `yaml:"skip_ssl"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+}
@@ -0,0 +1,22 @@
+package structs
+
+// LeaderElectionDescriptor configures how the instance of the server that runs the background jobs, such as the
+// sweep for stale applications and the reconciliation of interrupted deployments, is elected among the instances.
+//
+// Type is redis, consul or kubernetes. Without a type every instance is the leader, which is only right when the
+// server runs alone. A redis election holds Key in the Redis of URL. A consul election acquires Key in the key value
+// store of the Consul agent at URL with a session, authenticating with Token. A kubernetes election holds the Lease
+// named Key in Namespace through the API server at URL, authenticating with Token; they default to the API server,
+// namespace and service account token of the pod. CABundle is trusted instead of the system roots.
+//
+// The leader holds the leadership for TTLSeconds, 15 by default, and renews it every third of it. The URL and token
+// are expanded with environment variables.
+type LeaderElectionDescriptor struct {
+	Type       string `yaml:"type"`
+	URL        string `yaml:"url"`
+	Token      string `yaml:"token"`
+	Key        string `yaml:"key"`
+	Namespace  string `yaml:"namespace"`
+	CABundle   string `yaml:"ca_bundle"`
+	TTLSeconds int    `yaml:"ttl_seconds"`
+}
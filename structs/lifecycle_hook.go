@@ -0,0 +1,23 @@
+package structs
+
+// The phases of a push at which lifecycle hooks are run.
+const (
+	HookPreFetch      = "pre-fetch"
+	HookPostFetch     = "post-fetch"
+	HookPrePush       = "pre-push"
+	HookPostPromotion = "post-promotion"
+	HookPostRollback  = "post-rollback"
+)
+
+// LifecycleHookPhases are the phases a lifecycle hook can be run at, in the order they happen.
+var LifecycleHookPhases = []string{HookPreFetch, HookPostFetch, HookPrePush, HookPostPromotion, HookPostRollback}
+
+// LifecycleHook describes a local command run by the server at a phase of every push.
+//
+// Command is run without a shell, with the context of the deployment in DEPLOYADACTYL_ environment variables.
+// It is killed after TimeoutSeconds.
+type LifecycleHook struct {
+	Phase          string   `yaml:"phase"`
+	Command        []string `yaml:"command,flow"`
+	TimeoutSeconds int      `yaml:"timeout_seconds"`
+}
@@ -0,0 +1,43 @@
+package structs
+
+// The limits used when the configuration does not set them.
+const (
+	DefaultMaxRequestMB        = 10
+	DefaultMaxUploadMB         = 1024
+	DefaultMaxResponseBufferMB = 16
+)
+
+// LimitsDescriptor configures how large requests and deployment outputs may grow, so a mistaken multi-GB payload
+// cannot exhaust the memory of the server.
+//
+// MaxRequestMB limits the body of every request but uploaded artifacts, such as base64 encoded manifests and custom
+// params, and MaxUploadMB limits the artifacts uploaded as a zip. Larger requests are refused with 413. The output
+// of a deployment is kept in memory up to MaxResponseBufferMB, and in a temporary file of the work directory
+// beyond it. Zero uses the default limit.
+type LimitsDescriptor struct {
+	MaxRequestMB        int `yaml:"max_request_mb"`
+	MaxUploadMB         int `yaml:"max_upload_mb"`
+	MaxResponseBufferMB int `yaml:"max_response_buffer_mb"`
+}
+
+// MaxRequestBytes returns the largest body of a request that is not an uploaded artifact.
+func (l LimitsDescriptor) MaxRequestBytes() int64 {
+	return megabytes(l.MaxRequestMB, DefaultMaxRequestMB)
+}
+
+// MaxUploadBytes returns the largest artifact that can be uploaded as a zip.
+func (l LimitsDescriptor) MaxUploadBytes() int64 {
+	return megabytes(l.MaxUploadMB, DefaultMaxUploadMB)
+}
+
+// MaxResponseBufferBytes returns how much of the output of a deployment is kept in memory.
+func (l LimitsDescriptor) MaxResponseBufferBytes() int64 {
+	return megabytes(l.MaxResponseBufferMB, DefaultMaxResponseBufferMB)
+}
+
+func megabytes(configured, defaultMB int) int64 {
+	if configured == 0 {
+		configured = defaultMB
+	}
+	return int64(configured) << 20
+}
@@ -0,0 +1,15 @@
+package structs
+
+// LogCacheDescriptor describes how the logs of an application are read from the Log Cache API of a
+// foundation instead of from the recent logs of the Cloud Foundry CLI.
+//
+// URL is the Log Cache API, and defaults to the log_cache link of the Cloud Controller. Limit is the
+// most envelopes read, up to 1000, and LookbackSeconds is how far back they are read from. Only logs
+// whose source type starts with one of SourceTypes, such as "APP" or "STG", are kept when it is set.
+type LogCacheDescriptor struct {
+	Enabled         bool     `yaml:"enabled"`
+	URL             string   `yaml:"url"`
+	Limit           int      `yaml:"limit"`
+	LookbackSeconds int      `yaml:"lookback_seconds"`
+	SourceTypes     []string `yaml:"source_types,flow"`
+}
@@ -0,0 +1,20 @@
+package structs
+
+import (
+	"fmt"
+	"time"
+)
+
+// LogEnvelope is a single log line of an application read from Log Cache.
+type LogEnvelope struct {
+	Timestamp  time.Time
+	SourceType string
+	InstanceID string
+	Type       string
+	Message    string
+}
+
+// String formats the envelope like a line of the recent logs of the Cloud Foundry CLI.
+func (e LogEnvelope) String() string {
+	return fmt.Sprintf("   %s [%s/%s] %s %s", e.Timestamp.UTC().Format("2006-01-02T15:04:05.00-0700"), e.SourceType, e.InstanceID, e.Type, e.Message)
+}
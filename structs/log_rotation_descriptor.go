@@ -0,0 +1,18 @@
+package structs
+
+// LogRotationDescriptor describes when a log file is rotated and how long the rotated files are kept.
+//
+// The file is rotated once it would grow past MaxSizeMB, and at the start of every period of
+// IntervalHours, counted from midnight UTC. At most MaxBackups rotated files are kept, and none older
+// than MaxAgeDays. Zero disables the limit.
+type LogRotationDescriptor struct {
+	MaxSizeMB     int `yaml:"max_size_mb"`
+	IntervalHours int `yaml:"interval_hours"`
+	MaxBackups    int `yaml:"max_backups"`
+	MaxAgeDays    int `yaml:"max_age_days"`
+}
+
+// Enabled reports whether the file is ever rotated.
+func (d LogRotationDescriptor) Enabled() bool {
+	return d.MaxSizeMB > 0 || d.IntervalHours > 0
+}
@@ -0,0 +1,19 @@
+package structs
+
+// LogSinkDescriptor describes where the logs of the server are written.
+//
+// Type is stdout, file, syslog or http. A file sink appends to Path, which is rotated according to
+// Rotation. A syslog sink writes to the local syslog, or to Address over Network when it is set,
+// tagged with Tag. An http sink posts every line as JSON to URL, such as the HTTP input of fluentd.
+// Level is the lowest level written to the sink and defaults to the level of the server.
+type LogSinkDescriptor struct {
+	Type    string `yaml:"type"`
+	Level   string `yaml:"level"`
+	Path    string `yaml:"path"`
+	Network string `yaml:"network"`
+	Address string `yaml:"address"`
+	Tag     string `yaml:"tag"`
+	URL     string `yaml:"url"`
+
+	Rotation LogRotationDescriptor `yaml:"rotation"`
+}
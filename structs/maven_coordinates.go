@@ -0,0 +1,49 @@
+package structs
+
+import "strings"
+
+// MavenScheme prefixes the artifact URL of a deployment resolved from Maven coordinates.
+const MavenScheme = "maven:"
+
+// MavenCoordinates are the Maven artifact a deployment is resolved from. The Version can be RELEASE or LATEST, and
+// the Packaging is jar when it is empty.
+type MavenCoordinates struct {
+	GroupID    string `json:"group_id"`
+	ArtifactID string `json:"artifact_id"`
+	Version    string `json:"version"`
+	Packaging  string `json:"packaging,omitempty"`
+	Classifier string `json:"classifier,omitempty"`
+}
+
+// ArtifactURL returns the artifact URL standing for the coordinates, such as maven:com.example:app:1.2.0:war, so
+// they are recorded and replayed like any other artifact.
+func (c MavenCoordinates) ArtifactURL() string {
+	parts := []string{c.GroupID, c.ArtifactID, c.Version}
+	if c.Packaging != "" || c.Classifier != "" {
+		parts = append(parts, c.Packaging)
+	}
+	if c.Classifier != "" {
+		parts = append(parts, c.Classifier)
+	}
+	return MavenScheme + strings.Join(parts, ":")
+}
+
+// ParseMavenArtifactURL returns the coordinates of an artifact URL made by ArtifactURL, and false for any other URL.
+func ParseMavenArtifactURL(artifactURL string) (MavenCoordinates, bool) {
+	if !strings.HasPrefix(artifactURL, MavenScheme) {
+		return MavenCoordinates{}, false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(artifactURL, MavenScheme), ":")
+	if len(parts) < 3 || len(parts) > 5 {
+		return MavenCoordinates{}, false
+	}
+	parts = append(parts, "", "")
+	return MavenCoordinates{
+		GroupID:    parts[0],
+		ArtifactID: parts[1],
+		Version:    parts[2],
+		Packaging:  parts[3],
+		Classifier: parts[4],
+	}, true
+}
@@ -0,0 +1,12 @@
+package structs
+
+// MavenDescriptor holds the Maven repositories the coordinates of deployments are resolved against, such as
+// https://repo.maven.apache.org/maven2. They are searched in order, and the first one with the artifact is used.
+//
+// Username and Password are the basic auth of the repositories, and the password is expanded with environment
+// variables.
+type MavenDescriptor struct {
+	Repositories []string `yaml:"repositories"`
+	Username     string   `yaml:"username"`
+	Password     string   `yaml:"password"`
+}
@@ -0,0 +1,20 @@
+package structs
+
+// OIDCDescriptor configures the OpenID Connect issuer whose tokens are accepted as bearer tokens instead of basic
+// auth.
+//
+// Issuer is the URL of the issuer, such as https://login.example.com, which must be the iss of the tokens. Tokens are
+// only accepted when their aud has Audience, the client ID of Deployadactyl at the issuer. The keys of the issuer are
+// read from the jwks_uri of its discovery document, or from JWKSURL. The user of a token is its UsernameClaim, sub by
+// default, such as email or preferred_username, and its groups are its GroupsClaim, groups by default. CABundle is trusted instead of the system roots, or any certificate
+// with SkipSSL. A request to the issuer that takes longer than TimeoutSeconds, 10 by default, fails.
+type OIDCDescriptor struct {
+	Issuer         string `yaml:"issuer"`
+	Audience       string `yaml:"audience"`
+	JWKSURL        string `yaml:"jwks_url"`
+	UsernameClaim  string `yaml:"username_claim"`
+	GroupsClaim    string `yaml:"groups_claim"`
+	CABundle       string `yaml:"ca_bundle"`
+	SkipSSL        bool   `yaml:"skip_ssl"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+}
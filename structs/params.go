@@ -0,0 +1,143 @@
+package structs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Params is a generic map of user provided properties, such as the custom_params of an
+// environment or the data of a deployment request. The typed getters return the default
+// value when a key is missing and a ParamTypeError when a key holds a value of the wrong type,
+// so handlers never need to type assert the raw values themselves.
+type Params map[string]interface{}
+
+// Has returns true if the key is present.
+func (p Params) Has(key string) bool {
+	_, ok := p[key]
+	return ok
+}
+
+// Require returns a MissingParamsError listing every key that is not present.
+func (p Params) Require(keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if !p.Has(key) {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return MissingParamsError{Keys: missing}
+	}
+	return nil
+}
+
+// GetString returns the string stored at key, or defaultValue if the key is missing.
+// Numbers and booleans are formatted as strings.
+func (p Params) GetString(key, defaultValue string) (string, error) {
+	value, ok := p[key]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), nil
+	}
+
+	return defaultValue, ParamTypeError{Key: key, Type: "string", Value: value}
+}
+
+// GetInt returns the integer stored at key, or defaultValue if the key is missing.
+// Whole JSON numbers and numeric strings are converted.
+func (p Params) GetInt(key string, defaultValue int) (int, error) {
+	value, ok := p[key]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int8:
+		return int(v), nil
+	case int16:
+		return int(v), nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case uint:
+		return int(v), nil
+	case uint8:
+		return int(v), nil
+	case uint16:
+		return int(v), nil
+	case uint32:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	case float32:
+		if float64(v) == math.Trunc(float64(v)) {
+			return int(v), nil
+		}
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), nil
+		}
+	case string:
+		i, err := strconv.Atoi(v)
+		if err == nil {
+			return i, nil
+		}
+	}
+
+	return defaultValue, ParamTypeError{Key: key, Type: "int", Value: value}
+}
+
+// GetBool returns the boolean stored at key, or defaultValue if the key is missing.
+// Strings such as "true" and "false" are converted.
+func (p Params) GetBool(key string, defaultValue bool) (bool, error) {
+	value, ok := p[key]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err == nil {
+			return b, nil
+		}
+	}
+
+	return defaultValue, ParamTypeError{Key: key, Type: "bool", Value: value}
+}
+
+// GetParams returns the nested map stored at key, or an empty Params if the key is missing.
+func (p Params) GetParams(key string) (Params, error) {
+	value, ok := p[key]
+	if !ok || value == nil {
+		return Params{}, nil
+	}
+
+	switch v := value.(type) {
+	case Params:
+		return v, nil
+	case map[string]interface{}:
+		return Params(v), nil
+	case map[interface{}]interface{}:
+		params := Params{}
+		for k, nested := range v {
+			params[fmt.Sprint(k)] = nested
+		}
+		return params, nil
+	}
+
+	return Params{}, ParamTypeError{Key: key, Type: "map", Value: value}
+}
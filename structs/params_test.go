@@ -0,0 +1,129 @@
+package structs_test
+
+import (
+	"encoding/json"
+
+	. "github.com/compozed/deployadactyl/structs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Params", func() {
+	var params Params
+
+	BeforeEach(func() {
+		Expect(json.Unmarshal([]byte(`{
+			"name": "my-app",
+			"count": 3,
+			"ratio": 1.5,
+			"count_string": "7",
+			"enabled": true,
+			"enabled_string": "false",
+			"nested": {"key": "value"}
+		}`), &params)).To(Succeed())
+	})
+
+	Describe("GetString", func() {
+		It("returns the value", func() {
+			Expect(params.GetString("name", "default")).To(Equal("my-app"))
+		})
+
+		It("returns the default when the key is missing", func() {
+			Expect(params.GetString("missing", "default")).To(Equal("default"))
+		})
+
+		It("formats numbers", func() {
+			Expect(params.GetString("count", "")).To(Equal("3"))
+		})
+
+		It("returns an error when the value is not a string", func() {
+			value, err := params.GetString("nested", "default")
+
+			Expect(value).To(Equal("default"))
+			Expect(err).To(BeAssignableToTypeOf(ParamTypeError{}))
+		})
+	})
+
+	Describe("GetInt", func() {
+		It("returns whole JSON numbers", func() {
+			Expect(params.GetInt("count", 0)).To(Equal(3))
+		})
+
+		It("converts numeric strings", func() {
+			Expect(params.GetInt("count_string", 0)).To(Equal(7))
+		})
+
+		It("returns the default when the key is missing", func() {
+			Expect(params.GetInt("missing", 42)).To(Equal(42))
+		})
+
+		It("returns an error for fractional numbers", func() {
+			value, err := params.GetInt("ratio", 42)
+
+			Expect(value).To(Equal(42))
+			Expect(err).To(MatchError("parameter ratio must be a int: got float64 1.5"))
+		})
+
+		It("returns an error for non numeric strings", func() {
+			_, err := params.GetInt("name", 0)
+
+			Expect(err).To(BeAssignableToTypeOf(ParamTypeError{}))
+		})
+	})
+
+	Describe("GetBool", func() {
+		It("returns the value", func() {
+			Expect(params.GetBool("enabled", false)).To(BeTrue())
+		})
+
+		It("converts boolean strings", func() {
+			Expect(params.GetBool("enabled_string", true)).To(BeFalse())
+		})
+
+		It("returns the default when the key is missing", func() {
+			Expect(params.GetBool("missing", true)).To(BeTrue())
+		})
+
+		It("returns an error when the value is not a bool", func() {
+			_, err := params.GetBool("count", false)
+
+			Expect(err).To(BeAssignableToTypeOf(ParamTypeError{}))
+		})
+	})
+
+	Describe("GetParams", func() {
+		It("returns nested maps", func() {
+			nested, err := params.GetParams("nested")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nested.GetString("key", "")).To(Equal("value"))
+		})
+
+		It("converts yaml maps", func() {
+			params = Params{"nested": map[interface{}]interface{}{"key": "value"}}
+
+			nested, err := params.GetParams("nested")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nested).To(Equal(Params{"key": "value"}))
+		})
+	})
+
+	Describe("Require", func() {
+		It("succeeds when all keys are present", func() {
+			Expect(params.Require("name", "count")).To(Succeed())
+		})
+
+		It("lists the missing keys", func() {
+			Expect(params.Require("name", "first", "second")).To(MatchError(MissingParamsError{Keys: []string{"first", "second"}}))
+		})
+
+		It("handles a nil map", func() {
+			var empty Params
+
+			Expect(empty.GetString("name", "default")).To(Equal("default"))
+			Expect(empty.Require("name")).To(MatchError("missing required parameters: name"))
+		})
+	})
+})
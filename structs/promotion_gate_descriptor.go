@@ -0,0 +1,39 @@
+package structs
+
+// PromotionGateDescriptor describes a metric that must stay within its threshold while the new
+// build serves traffic, or the new build is not promoted.
+//
+// Provider is "prometheus" or "datadog". Every "{{app}}" in Query is replaced by the name of the
+// new build. The gate is breached when the value of the query is greater than Max, or when the
+// query returns no data unless AllowNoData is set. DelaySeconds is how long the new build serves
+// traffic before the query is run. Datadog queries are run over the last WindowSeconds, with the
+// API and application keys read from the environment variables named by APIKeyEnv and AppKeyEnv.
+type PromotionGateDescriptor struct {
+	Name          string  `yaml:"name"`
+	Provider      string  `yaml:"provider"`
+	URL           string  `yaml:"url"`
+	Query         string  `yaml:"query"`
+	Max           float64 `yaml:"max"`
+	AllowNoData   bool    `yaml:"allow_no_data"`
+	DelaySeconds  int     `yaml:"delay_seconds"`
+	WindowSeconds int     `yaml:"window_seconds"`
+	APIKeyEnv     string  `yaml:"api_key_env"`
+	AppKeyEnv     string  `yaml:"app_key_env"`
+}
+
+// PromotionGateResult is the value a promotion gate measured for the new build.
+type PromotionGateResult struct {
+	Name   string  `json:"name"`
+	Query  string  `json:"query"`
+	Value  float64 `json:"value"`
+	Max    float64 `json:"max"`
+	NoData bool    `json:"no_data"`
+}
+
+// Breached returns true if the result of the gate does not allow the new build to be promoted.
+func (d PromotionGateDescriptor) Breached(result PromotionGateResult) bool {
+	if result.NoData {
+		return !d.AllowNoData
+	}
+	return result.Value > d.Max
+}
@@ -0,0 +1,16 @@
+package structs
+
+// DefaultPushStepTimeoutSeconds is how long the request of a push step is given when it has no timeout.
+const DefaultPushStepTimeoutSeconds = 30
+
+// PushStepDescriptor describes an additional HTTP step that is run by the Pusher.
+type PushStepDescriptor struct {
+	Name           string            `yaml:"name"`
+	Phase          string            `yaml:"phase"`
+	Before         string            `yaml:"before"`
+	After          string            `yaml:"after"`
+	URL            string            `yaml:"url"`
+	Method         string            `yaml:"method"`
+	Headers        map[string]string `yaml:"headers"`
+	TimeoutSeconds int               `yaml:"timeout_seconds"`
+}
@@ -0,0 +1,75 @@
+package structs
+
+import (
+	"sort"
+	"sync"
+)
+
+// QuotaUsage is the memory and the instances an org or a space uses, with the limits of its quota.
+// A nil limit is unlimited.
+type QuotaUsage struct {
+	MemoryMB      int  `json:"memory_mb"`
+	MemoryLimitMB *int `json:"memory_limit_mb"`
+	Instances     int  `json:"instances"`
+	InstanceLimit *int `json:"instance_limit"`
+}
+
+// OrgSpaceQuotaUsage is the quota usage of the org and the space of a deployment on a foundation.
+type OrgSpaceQuotaUsage struct {
+	Org   QuotaUsage `json:"org"`
+	Space QuotaUsage `json:"space"`
+}
+
+// QuotaUsageResult is the quota usage of a foundation before and after a deployment. Usage that could not be
+// read is nil.
+type QuotaUsageResult struct {
+	FoundationURL string              `json:"foundation_url"`
+	Before        *OrgSpaceQuotaUsage `json:"before"`
+	After         *OrgSpaceQuotaUsage `json:"after"`
+}
+
+// QuotaUsageReport collects the quota usage of every foundation of a deployment. It is safe for concurrent use
+// by the pushers of the foundations.
+type QuotaUsageReport struct {
+	mu      sync.Mutex
+	results map[string]*QuotaUsageResult
+}
+
+// RecordBefore records the quota usage of a foundation before the deployment.
+func (r *QuotaUsageReport) RecordBefore(foundationURL string, usage OrgSpaceQuotaUsage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.result(foundationURL).Before = &usage
+}
+
+// RecordAfter records the quota usage of a foundation after the deployment.
+func (r *QuotaUsageReport) RecordAfter(foundationURL string, usage OrgSpaceQuotaUsage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.result(foundationURL).After = &usage
+}
+
+// Results returns the recorded results ordered by foundation.
+func (r *QuotaUsageReport) Results() []QuotaUsageResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := []QuotaUsageResult{}
+	for _, result := range r.results {
+		results = append(results, *result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].FoundationURL < results[j].FoundationURL })
+	return results
+}
+
+func (r *QuotaUsageReport) result(foundationURL string) *QuotaUsageResult {
+	if r.results == nil {
+		r.results = map[string]*QuotaUsageResult{}
+	}
+	if r.results[foundationURL] == nil {
+		r.results[foundationURL] = &QuotaUsageResult{FoundationURL: foundationURL}
+	}
+	return r.results[foundationURL]
+}
@@ -0,0 +1,33 @@
+package structs
+
+// RollingDescriptor describes the rollout of an environment whose foundations are deployed in batches.
+//
+// Once Enabled, the foundations are deployed BatchSize at a time, one by default, in the order of the environment.
+// A batch is only started once the previous one succeeded, and the rollout halts on the first batch that fails, so
+// the foundations after it keep the original application.
+type RollingDescriptor struct {
+	Enabled   bool `yaml:"enabled"`
+	BatchSize int  `yaml:"batch_size"`
+}
+
+// Batches returns the foundations in the batches they are deployed in. Without a rolling rollout, every foundation
+// is in a single batch.
+func (d RollingDescriptor) Batches(foundations []string) [][]string {
+	size := len(foundations)
+	if d.Enabled {
+		size = d.BatchSize
+		if size < 1 {
+			size = 1
+		}
+	}
+
+	var batches [][]string
+	for len(foundations) > 0 {
+		if size > len(foundations) {
+			size = len(foundations)
+		}
+		batches = append(batches, foundations[:size:size])
+		foundations = foundations[size:]
+	}
+	return batches
+}
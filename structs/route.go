@@ -0,0 +1,31 @@
+package structs
+
+import "strings"
+
+// Route is a route mapped to the application of a deployment besides the route of the load balanced domain: a
+// hostname on a domain, and an optional path. The hostname defaults to the name of the application, like the route
+// of the load balanced domain.
+type Route struct {
+	Hostname string `yaml:"hostname" json:"hostname,omitempty"`
+	Domain   string `yaml:"domain" json:"domain"`
+	Path     string `yaml:"path" json:"path,omitempty"`
+}
+
+// URL returns the route as Cloud Foundry reports it, such as search.apps.example.com/api.
+func (r Route) URL() string {
+	return r.Hostname + "." + r.Domain + r.Path
+}
+
+// Validate returns an InvalidRouteError when the route has no domain, its hostname is not a single DNS label, or its
+// path is not a path such as /api.
+func (r Route) Validate() error {
+	switch {
+	case r.Domain == "" || strings.ContainsAny(r.Domain, "/ "):
+		return InvalidRouteError{r, "the domain is missing or is not a domain"}
+	case strings.ContainsAny(r.Hostname, "./ "):
+		return InvalidRouteError{r, "the hostname is not a single DNS label"}
+	case r.Path != "" && (!strings.HasPrefix(r.Path, "/") || r.Path == "/" || strings.Contains(r.Path, " ")):
+		return InvalidRouteError{r, "the path is not a path such as /api"}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package structs
+
+import "time"
+
+// RuntimeStats are the memory, garbage collection and goroutine statistics of the server.
+type RuntimeStats struct {
+	GoVersion       string    `json:"go_version"`
+	NumCPU          int       `json:"num_cpu"`
+	GOMAXPROCS      int       `json:"gomaxprocs"`
+	Goroutines      int       `json:"goroutines"`
+	HeapAllocBytes  uint64    `json:"heap_alloc_bytes"`
+	HeapInuseBytes  uint64    `json:"heap_inuse_bytes"`
+	HeapIdleBytes   uint64    `json:"heap_idle_bytes"`
+	HeapSysBytes    uint64    `json:"heap_sys_bytes"`
+	HeapObjects     uint64    `json:"heap_objects"`
+	StackInuseBytes uint64    `json:"stack_inuse_bytes"`
+	SysBytes        uint64    `json:"sys_bytes"`
+	TotalAllocBytes uint64    `json:"total_alloc_bytes"`
+	Mallocs         uint64    `json:"mallocs"`
+	Frees           uint64    `json:"frees"`
+	NumGC           uint32    `json:"num_gc"`
+	PauseTotalNs    uint64    `json:"pause_total_ns"`
+	LastGC          time.Time `json:"last_gc"`
+	NextGCBytes     uint64    `json:"next_gc_bytes"`
+}
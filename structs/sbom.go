@@ -0,0 +1,46 @@
+package structs
+
+// SBOM is the software bill of materials of a deployed artifact.
+//
+// Reference is the location of an SBOM document produced by the build, if the deployment
+// request provided one. Components are either provided with the request or found in the
+// extracted artifact, in which case Generated is true.
+type SBOM struct {
+	Reference  string      `json:"reference"`
+	Generated  bool        `json:"generated"`
+	Components []Component `json:"components"`
+}
+
+// Component is a library or package contained in an artifact.
+type Component struct {
+	Type    string `json:"type"`
+	Group   string `json:"group,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Path    string `json:"path,omitempty"`
+}
+
+// Provenance describes where and how an artifact was built.
+type Provenance struct {
+	SourceRepository string `json:"source_repository"`
+	Revision         string `json:"revision"`
+	BuildID          string `json:"build_id"`
+	BuildURL         string `json:"build_url"`
+	Builder          string `json:"builder"`
+}
+
+// ProvenanceFromMetadata returns the provenance described by the source_repository, revision,
+// build_id, build_url and builder metadata keys, or nil if none of them are present.
+func ProvenanceFromMetadata(metadata map[string]string) *Provenance {
+	provenance := Provenance{
+		SourceRepository: metadata["source_repository"],
+		Revision:         metadata["revision"],
+		BuildID:          metadata["build_id"],
+		BuildURL:         metadata["build_url"],
+		Builder:          metadata["builder"],
+	}
+	if provenance == (Provenance{}) {
+		return nil
+	}
+	return &provenance
+}
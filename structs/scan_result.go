@@ -0,0 +1,21 @@
+package structs
+
+import "time"
+
+// ScanResult is the outcome of scanning an artifact.
+type ScanResult struct {
+	Scanner   string        `json:"scanner"`
+	Findings  []ScanFinding `json:"findings"`
+	ScannedAt time.Time     `json:"scanned_at"`
+}
+
+// ScanFinding is a single file the scanner reported.
+type ScanFinding struct {
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// Clean returns true if the scanner did not report anything.
+func (r ScanResult) Clean() bool {
+	return len(r.Findings) == 0
+}
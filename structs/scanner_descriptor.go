@@ -0,0 +1,18 @@
+package structs
+
+// ScannerDescriptor describes the scanner an artifact is submitted to after it is fetched.
+//
+// Type is one of "command", "clamav" or "icap". Command is run with the artifact directory
+// appended as the last argument. Address is the host:port of a clamd daemon or the icap:// URL
+// of an ICAP service.
+type ScannerDescriptor struct {
+	Type           string   `yaml:"type"`
+	Command        []string `yaml:"command,flow"`
+	Address        string   `yaml:"address"`
+	TimeoutSeconds int      `yaml:"timeout_seconds"`
+}
+
+// Enabled returns true if a scanner is configured.
+func (d ScannerDescriptor) Enabled() bool {
+	return d.Type != ""
+}
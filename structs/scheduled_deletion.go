@@ -0,0 +1,18 @@
+package structs
+
+import "time"
+
+// ScheduledDeletion is a replaced application that is kept stopped until it is deleted at DeleteAt.
+// The credentials of the deployment that replaced it are used to delete it.
+type ScheduledDeletion struct {
+	FoundationURL string    `json:"foundation_url"`
+	Org           string    `json:"org"`
+	Space         string    `json:"space"`
+	AppName       string    `json:"app_name"`
+	DeploymentID  string    `json:"deployment_id,omitempty"`
+	DeleteAt      time.Time `json:"delete_at"`
+	Username      string    `json:"-"`
+	Password      string    `json:"-"`
+	SkipSSL       bool      `json:"-"`
+	CABundle      string    `json:"-"`
+}
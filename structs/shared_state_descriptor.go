@@ -0,0 +1,10 @@
+package structs
+
+// SharedStateDescriptor configures the state the instances of the server share, so the progress, the event stream
+// and the cancellation of a deployment work whichever instance a request lands on.
+//
+// Redis is the URL of the Redis the state is shared through. Without it, the state is only held by the instance
+// running the deployment.
+type SharedStateDescriptor struct {
+	Redis string `yaml:"redis"`
+}
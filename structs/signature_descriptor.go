@@ -0,0 +1,18 @@
+package structs
+
+// SignatureDescriptor describes how the signatures of artifacts are verified before they are pushed.
+//
+// Type is "cosign" for signatures made with cosign sign-blob or "gpg" for detached GPG signatures.
+// PublicKeys are paths to, or the contents of, PEM encoded or armored public keys; an artifact
+// signed by any of them is accepted. When Required is false unsigned artifacts are still pushed,
+// but an artifact with an invalid signature is always rejected.
+type SignatureDescriptor struct {
+	Type       string   `yaml:"type"`
+	PublicKeys []string `yaml:"public_keys"`
+	Required   bool     `yaml:"required"`
+}
+
+// Enabled returns true if signature verification is configured.
+func (d SignatureDescriptor) Enabled() bool {
+	return d.Type != ""
+}
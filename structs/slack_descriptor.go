@@ -0,0 +1,17 @@
+package structs
+
+// SlackDescriptor configures the messages posted to Slack when a deployment starts, succeeds or fails.
+//
+// WebhookURL is the incoming webhook of the Slack app the messages are posted with, and is expanded with environment
+// variables, so it can be given as "${SLACK_WEBHOOK_URL}" instead of being written to the configuration. Without a
+// webhook URL nothing is posted. Channel, Username and IconEmoji override those of the webhook when they are set.
+// Only the deployments to Environments are posted, or those to every environment when there are none. A post that
+// takes longer than TimeoutSeconds, 10 by default, fails.
+type SlackDescriptor struct {
+	WebhookURL     string   `yaml:"webhook_url"`
+	Channel        string   `yaml:"channel"`
+	Username       string   `yaml:"username"`
+	IconEmoji      string   `yaml:"icon_emoji"`
+	Environments   []string `yaml:"environments,flow"`
+	TimeoutSeconds int      `yaml:"timeout_seconds"`
+}
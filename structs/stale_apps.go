@@ -0,0 +1,29 @@
+package structs
+
+import "time"
+
+// StaleApp is a temporary application left behind by a deployment that crashed or was cancelled.
+type StaleApp struct {
+	Environment   string    `json:"environment"`
+	FoundationURL string    `json:"foundation_url"`
+	Org           string    `json:"org"`
+	Space         string    `json:"space"`
+	AppName       string    `json:"app_name"`
+	CreatedAt     time.Time `json:"created_at"`
+	Deleted       bool      `json:"deleted"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// StaleAppsFoundationError is a foundation that could not be swept for stale applications.
+type StaleAppsFoundationError struct {
+	Environment   string `json:"environment"`
+	FoundationURL string `json:"foundation_url"`
+	Error         string `json:"error"`
+}
+
+// StaleAppsReport lists the stale applications found on every foundation by a sweep.
+type StaleAppsReport struct {
+	SweptAt time.Time                  `json:"swept_at"`
+	Apps    []StaleApp                 `json:"apps"`
+	Errors  []StaleAppsFoundationError `json:"errors"`
+}
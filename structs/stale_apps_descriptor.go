@@ -0,0 +1,12 @@
+package structs
+
+// StaleAppsDescriptor configures the periodic sweep for temporary applications left behind by deployments that
+// crashed or were cancelled.
+//
+// The sweep runs every IntervalMinutes, and does not run when it is zero. Temporary applications older than
+// MaxAgeMinutes are stale, and are deleted when Delete is set. Otherwise they are only reported.
+type StaleAppsDescriptor struct {
+	IntervalMinutes int  `yaml:"interval_minutes"`
+	MaxAgeMinutes   int  `yaml:"max_age_minutes"`
+	Delete          bool `yaml:"delete"`
+}
@@ -0,0 +1,18 @@
+package structs
+
+// StopMode selects how an application is stopped.
+type StopMode string
+
+const (
+	// StopModeStop stops the application with cf stop.
+	StopModeStop StopMode = ""
+
+	// StopModeScaleToZero scales the application to zero instances instead, which keeps it started
+	// with its routes and service bindings so starting it again does not need to restage it.
+	StopModeScaleToZero StopMode = "scale_to_zero"
+)
+
+// Valid returns whether the mode is a known StopMode.
+func (m StopMode) Valid() bool {
+	return m == StopModeStop || m == StopModeScaleToZero
+}
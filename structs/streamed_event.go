@@ -0,0 +1,18 @@
+package structs
+
+import "time"
+
+// StreamedEvent is what the event stream and the event publishers tell about an event. It only has what identifies
+// the deployment the event is about, never its credentials, request body or output.
+type StreamedEvent struct {
+	Type          string    `json:"type"`
+	Time          time.Time `json:"time"`
+	UUID          string    `json:"uuid,omitempty"`
+	Environment   string    `json:"environment,omitempty"`
+	Org           string    `json:"org,omitempty"`
+	Space         string    `json:"space,omitempty"`
+	AppName       string    `json:"app_name,omitempty"`
+	FoundationURL string    `json:"foundation_url,omitempty"`
+	ArtifactURL   string    `json:"artifact_url,omitempty"`
+	Error         string    `json:"error,omitempty"`
+}
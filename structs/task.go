@@ -0,0 +1,36 @@
+package structs
+
+// The states of a Cloud Foundry task that is over.
+const (
+	TaskSucceeded = "SUCCEEDED"
+	TaskFailed    = "FAILED"
+)
+
+// Task is a one-off command run by Cloud Foundry with the droplet of an application.
+type Task struct {
+	GUID          string
+	Name          string
+	State         string
+	FailureReason string
+}
+
+// Done reports whether the task is over.
+func (t Task) Done() bool {
+	return t.State == TaskSucceeded || t.State == TaskFailed
+}
+
+// TaskDescriptor describes a task run on the new build of every push, such as database migrations, before it
+// serves any traffic. The push fails and is rolled back if the task fails or is not done within TimeoutSeconds,
+// which defaults to 600. Foundations restricts the task to some of the foundations of the environment, such as
+// a single foundation when they share a database.
+type TaskDescriptor struct {
+	Name           string   `yaml:"name"`
+	Command        string   `yaml:"command"`
+	TimeoutSeconds int      `yaml:"timeout_seconds"`
+	Foundations    []string `yaml:"foundations"`
+}
+
+// Enabled reports whether the task is run.
+func (d TaskDescriptor) Enabled() bool {
+	return d.Command != ""
+}
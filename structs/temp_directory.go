@@ -0,0 +1,16 @@
+package structs
+
+import "time"
+
+// TempDirectory is a temporary file or directory created on the local file system by Deployadactyl.
+type TempDirectory struct {
+	Path         string    `json:"path"`
+	DeploymentID string    `json:"deployment_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TempDirectoryReport lists the temporary directories of the running deployments and those that were left behind.
+type TempDirectoryReport struct {
+	Active []TempDirectory `json:"active"`
+	Leaked []TempDirectory `json:"leaked"`
+}
@@ -0,0 +1,15 @@
+package structs
+
+// Tenant is a team sharing the server. Its environments can only be deployed to, and their deployments only seen,
+// with one of its API tokens, and they are logged into with its Cloud Foundry credentials instead of those of the
+// server.
+//
+// The username, password and API tokens are expanded with environment variables, so they can be given as
+// "${SEARCH_API_TOKEN}" instead of being written to the configuration.
+type Tenant struct {
+	Name         string
+	Username     string
+	Password     string
+	APITokens    []string      `yaml:"api_tokens,flow"`
+	Environments []Environment `yaml:",flow"`
+}
@@ -0,0 +1,17 @@
+package structs
+
+// TracingDescriptor configures the export of the spans of every deployment to an OpenTelemetry collector.
+//
+// Endpoint is the OTLP/HTTP URL the spans are posted to as JSON, such as http://collector:4318/v1/traces. Without an
+// endpoint deployments are not traced. Headers are sent with every export, and their values are expanded with
+// environment variables, such as an API key given as "${OTLP_API_KEY}". ServiceName is the service.name of the
+// spans, deployadactyl by default. CABundle is trusted instead of the system roots, or any certificate with SkipSSL.
+// An export that takes longer than TimeoutSeconds, 10 by default, fails.
+type TracingDescriptor struct {
+	Endpoint       string            `yaml:"endpoint"`
+	Headers        map[string]string `yaml:"headers"`
+	ServiceName    string            `yaml:"service_name"`
+	CABundle       string            `yaml:"ca_bundle"`
+	SkipSSL        bool              `yaml:"skip_ssl"`
+	TimeoutSeconds int               `yaml:"timeout_seconds"`
+}
@@ -0,0 +1,21 @@
+package structs
+
+// VaultDescriptor configures the HashiCorp Vault the Cloud Foundry credentials of the environments with a vault_path
+// are read from.
+//
+// Address is the URL of Vault, such as https://vault.example.com:8200. Deployadactyl logs in with the AppRole RoleID
+// and SecretID at AuthMount, approle by default, or uses Token when there is no role. The token, role and secret are
+// expanded with environment variables. Namespace is sent with every request to Vault Enterprise. CABundle is trusted
+// instead of the system roots, or any certificate with SkipSSL. A request that takes longer than TimeoutSeconds, 10 by
+// default, fails.
+type VaultDescriptor struct {
+	Address        string `yaml:"address"`
+	Token          string `yaml:"token"`
+	RoleID         string `yaml:"role_id"`
+	SecretID       string `yaml:"secret_id"`
+	AuthMount      string `yaml:"auth_mount"`
+	Namespace      string `yaml:"namespace"`
+	CABundle       string `yaml:"ca_bundle"`
+	SkipSSL        bool   `yaml:"skip_ssl"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+}
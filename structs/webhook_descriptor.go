@@ -0,0 +1,18 @@
+package structs
+
+// WebhookDescriptor describes a URL the events of the deployments to an environment are posted to as JSON.
+//
+// Events are the types of the events posted, such as DeploySuccessEvent, or the started, finished, success and
+// failure events of every deployment, start, stop, restart, restage and delete when there are none. The payloads are
+// signed with Secret when it is set. Headers are sent with every payload. A post that takes longer than
+// TimeoutSeconds, 10 by default, fails, and a failed post is retried Retries times, 3 by default.
+//
+// The secret and the values of the headers are expanded with environment variables.
+type WebhookDescriptor struct {
+	URL            string            `yaml:"url"`
+	Secret         string            `yaml:"secret"`
+	Events         []string          `yaml:"events,flow"`
+	Headers        map[string]string `yaml:"headers"`
+	TimeoutSeconds int               `yaml:"timeout_seconds"`
+	Retries        int               `yaml:"retries"`
+}
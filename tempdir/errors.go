@@ -0,0 +1,25 @@
+package tempdir
+
+import "fmt"
+
+type ReadTempDirectoryError struct {
+	Dir string
+	Err error
+}
+
+func (e ReadTempDirectoryError) Error() string {
+	return fmt.Sprintf("cannot read temporary directory %s: %s", e.Dir, e.Err)
+}
+
+type RemoveTempDirectoryError struct {
+	Paths []string
+	Errs  []error
+}
+
+func (e RemoveTempDirectoryError) Error() string {
+	message := "cannot remove temporary directories:"
+	for i, path := range e.Paths {
+		message += fmt.Sprintf("\n%s: %s", path, e.Errs[i])
+	}
+	return message
+}
@@ -0,0 +1,188 @@
+// Package tempdir keeps track of the temporary directories created for each deployment so that none are left behind.
+package tempdir
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// Prefix is the prefix of the name of every temporary file and directory created by Deployadactyl.
+const Prefix = "deployadactyl-"
+
+// DefaultGracePeriod is how old an untracked temporary directory has to be before it is reported as leaked.
+// Artifacts that are being downloaded or verified are not tracked, so younger directories may still be in use.
+const DefaultGracePeriod = 10 * time.Minute
+
+// NewTracker returns a Tracker of the temporary directories in the temporary directory of the operating system
+// and in the work directory, if there is one.
+func NewTracker(fileSystem *afero.Afero, workDir string) *Tracker {
+	dirs := []string{os.TempDir()}
+	if workDir != "" && filepath.Clean(workDir) != filepath.Clean(os.TempDir()) {
+		dirs = append(dirs, workDir)
+	}
+
+	return &Tracker{
+		FileSystem:  fileSystem,
+		Dirs:        dirs,
+		GracePeriod: DefaultGracePeriod,
+		Now:         time.Now,
+	}
+}
+
+// Tracker records the temporary directories created for each deployment, removes them once the deployment
+// is finished and finds the ones left behind by deployments that never finished.
+type Tracker struct {
+	FileSystem  *afero.Afero
+	Dirs        []string
+	GracePeriod time.Duration
+	Now         func() time.Time
+
+	mu          sync.Mutex
+	directories map[string]S.TempDirectory
+}
+
+// Track records that path was created for the deployment.
+func (t *Tracker) Track(deploymentID, path string) {
+	if path == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.directories == nil {
+		t.directories = map[string]S.TempDirectory{}
+	}
+	t.directories[path] = S.TempDirectory{Path: path, DeploymentID: deploymentID, CreatedAt: t.Now()}
+}
+
+// RemoveDeployment removes every temporary directory of the deployment that still exists.
+func (t *Tracker) RemoveDeployment(deploymentID string) error {
+	t.mu.Lock()
+	var paths []string
+	for path, directory := range t.directories {
+		if directory.DeploymentID == deploymentID {
+			paths = append(paths, path)
+			delete(t.directories, path)
+		}
+	}
+	t.mu.Unlock()
+
+	return t.remove(paths)
+}
+
+// Report returns the temporary directories of the running deployments and the ones that were left behind.
+func (t *Tracker) Report() (S.TempDirectoryReport, error) {
+	report := S.TempDirectoryReport{Active: t.active(), Leaked: []S.TempDirectory{}}
+
+	leaked, err := t.untracked()
+	if err != nil {
+		return report, err
+	}
+
+	cutoff := t.Now().Add(-t.GracePeriod)
+	for _, directory := range leaked {
+		if directory.CreatedAt.Before(cutoff) {
+			report.Leaked = append(report.Leaked, directory)
+		}
+	}
+
+	return report, nil
+}
+
+// Sweep removes every temporary directory that is not tracked, regardless of its age.
+// It is meant to be run at startup, before any deployment has started.
+//
+// Returns the paths that were removed.
+func (t *Tracker) Sweep() ([]string, error) {
+	leaked, err := t.untracked()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, directory := range leaked {
+		paths = append(paths, directory.Path)
+	}
+
+	return paths, t.remove(paths)
+}
+
+// active returns the tracked directories that still exist, oldest first.
+func (t *Tracker) active() []S.TempDirectory {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	active := []S.TempDirectory{}
+	for path, directory := range t.directories {
+		exists, _ := t.FileSystem.Exists(path)
+		if !exists {
+			delete(t.directories, path)
+			continue
+		}
+		active = append(active, directory)
+	}
+	sortDirectories(active)
+
+	return active
+}
+
+// untracked returns the temporary files and directories created by Deployadactyl that are not tracked, oldest first.
+func (t *Tracker) untracked() ([]S.TempDirectory, error) {
+	var untracked []S.TempDirectory
+	for _, dir := range t.Dirs {
+		infos, err := t.FileSystem.ReadDir(dir)
+		if err != nil {
+			return nil, ReadTempDirectoryError{Dir: dir, Err: err}
+		}
+
+		t.mu.Lock()
+		for _, info := range infos {
+			if !strings.HasPrefix(info.Name(), Prefix) {
+				continue
+			}
+
+			path := filepath.Join(dir, info.Name())
+			if _, ok := t.directories[path]; ok {
+				continue
+			}
+			untracked = append(untracked, S.TempDirectory{Path: path, CreatedAt: info.ModTime()})
+		}
+		t.mu.Unlock()
+	}
+	sortDirectories(untracked)
+
+	return untracked, nil
+}
+
+func (t *Tracker) remove(paths []string) error {
+	removeErr := RemoveTempDirectoryError{}
+	for _, path := range paths {
+		err := t.FileSystem.RemoveAll(path)
+		if err != nil {
+			removeErr.Paths = append(removeErr.Paths, path)
+			removeErr.Errs = append(removeErr.Errs, err)
+		}
+	}
+
+	if len(removeErr.Paths) != 0 {
+		return removeErr
+	}
+	return nil
+}
+
+func sortDirectories(directories []S.TempDirectory) {
+	sort.Slice(directories, func(i, j int) bool {
+		if directories[i].CreatedAt.Equal(directories[j].CreatedAt) {
+			return directories[i].Path < directories[j].Path
+		}
+		return directories[i].CreatedAt.Before(directories[j].CreatedAt)
+	})
+}
@@ -0,0 +1,13 @@
+package tempdir_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTempdir(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tempdir Suite")
+}
@@ -0,0 +1,138 @@
+package tempdir_test
+
+import (
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/compozed/deployadactyl/tempdir"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Tracker", func() {
+	var (
+		fileSystem *afero.Afero
+		tracker    *Tracker
+		now        time.Time
+	)
+
+	mkdir := func(path string, age time.Duration) {
+		Expect(fileSystem.MkdirAll(path, 0755)).To(Succeed())
+		Expect(fileSystem.WriteFile(path+"/manifest.yml", []byte("---"), 0644)).To(Succeed())
+		Expect(fileSystem.Chtimes(path, now.Add(-age), now.Add(-age))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+		now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+		tracker = NewTracker(fileSystem, "")
+		tracker.Dirs = []string{"/tmp"}
+		tracker.Now = func() time.Time { return now }
+
+		mkdir("/tmp/deployadactyl-executor-1", time.Hour)
+		mkdir("/tmp/deployadactyl-unzipped-2", time.Hour)
+		mkdir("/tmp/deployadactyl-executor-3", 2*time.Hour)
+		mkdir("/tmp/deployadactyl-executor-4", time.Minute)
+		mkdir("/tmp/other-5", time.Hour)
+	})
+
+	Describe("RemoveDeployment", func() {
+		It("removes the directories of the deployment", func() {
+			tracker.Track("uuid-1", "/tmp/deployadactyl-executor-1")
+			tracker.Track("uuid-1", "/tmp/deployadactyl-unzipped-2")
+			tracker.Track("uuid-2", "/tmp/deployadactyl-executor-3")
+
+			Expect(tracker.RemoveDeployment("uuid-1")).To(Succeed())
+
+			Expect(fileSystem.DirExists("/tmp/deployadactyl-executor-1")).To(BeFalse())
+			Expect(fileSystem.DirExists("/tmp/deployadactyl-unzipped-2")).To(BeFalse())
+			Expect(fileSystem.DirExists("/tmp/deployadactyl-executor-3")).To(BeTrue())
+		})
+
+		It("ignores directories that were already removed", func() {
+			tracker.Track("uuid-1", "/tmp/deployadactyl-executor-1")
+			Expect(fileSystem.RemoveAll("/tmp/deployadactyl-executor-1")).To(Succeed())
+
+			Expect(tracker.RemoveDeployment("uuid-1")).To(Succeed())
+		})
+
+		It("returns an error when a directory cannot be removed", func() {
+			fileSystem.Fs = afero.NewReadOnlyFs(fileSystem.Fs)
+			tracker.Track("uuid-1", "/tmp/deployadactyl-executor-1")
+
+			err := tracker.RemoveDeployment("uuid-1")
+
+			Expect(err).To(BeAssignableToTypeOf(RemoveTempDirectoryError{}))
+			Expect(err.Error()).To(ContainSubstring("/tmp/deployadactyl-executor-1"))
+		})
+	})
+
+	Describe("Report", func() {
+		It("lists the tracked directories as active and the old untracked ones as leaked", func() {
+			tracker.Track("uuid-1", "/tmp/deployadactyl-executor-1")
+
+			report, err := tracker.Report()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(report.Active).To(Equal([]S.TempDirectory{
+				{Path: "/tmp/deployadactyl-executor-1", DeploymentID: "uuid-1", CreatedAt: now},
+			}))
+			Expect(report.Leaked).To(HaveLen(2))
+			Expect(report.Leaked[0].Path).To(Equal("/tmp/deployadactyl-executor-3"))
+			Expect(report.Leaked[1].Path).To(Equal("/tmp/deployadactyl-unzipped-2"))
+		})
+
+		It("forgets tracked directories that no longer exist", func() {
+			tracker.Track("uuid-1", "/tmp/deployadactyl-executor-1")
+			Expect(fileSystem.RemoveAll("/tmp/deployadactyl-executor-1")).To(Succeed())
+
+			report, err := tracker.Report()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(report.Active).To(BeEmpty())
+		})
+
+		It("returns an error when the temporary directory cannot be read", func() {
+			tracker.Dirs = []string{"/tmp", "/missing"}
+
+			_, err := tracker.Report()
+
+			Expect(err).To(BeAssignableToTypeOf(ReadTempDirectoryError{}))
+		})
+	})
+
+	Describe("NewTracker", func() {
+		It("looks for leaked directories in the work directory as well", func() {
+			tracker = NewTracker(fileSystem, "/var/deployadactyl")
+
+			Expect(tracker.Dirs).To(HaveLen(2))
+			Expect(tracker.Dirs[1]).To(Equal("/var/deployadactyl"))
+		})
+	})
+
+	Describe("Sweep", func() {
+		It("removes every untracked directory regardless of its age", func() {
+			tracker.Track("uuid-1", "/tmp/deployadactyl-executor-1")
+
+			removed, err := tracker.Sweep()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(removed).To(ConsistOf("/tmp/deployadactyl-unzipped-2", "/tmp/deployadactyl-executor-3", "/tmp/deployadactyl-executor-4"))
+			Expect(fileSystem.DirExists("/tmp/deployadactyl-executor-1")).To(BeTrue())
+			Expect(fileSystem.DirExists("/tmp/other-5")).To(BeTrue())
+		})
+
+		It("removes untracked directories from every directory", func() {
+			tracker.Dirs = []string{"/tmp", "/var/deployadactyl"}
+			mkdir("/var/deployadactyl/deployadactyl-zip-6", time.Hour)
+
+			removed, err := tracker.Sweep()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(removed).To(ContainElement("/var/deployadactyl/deployadactyl-zip-6"))
+			Expect(fileSystem.DirExists("/var/deployadactyl/deployadactyl-zip-6")).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package testharness
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/compozed/deployadactyl/state/push"
+)
+
+// AssertDeployed returns an error unless the app was deployed to the space: it is started, and neither a new build
+// nor a venerable app of it is left.
+func (f *Foundation) AssertDeployed(org, space, name string) error {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	_, err := f.assertStarted(org, space, name)
+	return err
+}
+
+// AssertReplaced returns an error unless the app that was running before a deployment was replaced by the
+// deployed app, which kept its routes. The app it replaced may only be left stopped, until it is retired.
+func (f *Foundation) AssertReplaced(org, space string, before App) error {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	a, err := f.assertStarted(org, space, before.Name)
+	if err != nil {
+		return err
+	}
+	if a.GUID == before.GUID {
+		return AssertionError{f.url, before.Name, "was not replaced"}
+	}
+	if err := f.assertRoutes(a, before.Routes); err != nil {
+		return err
+	}
+
+	for _, replaced := range f.apps {
+		if replaced.Org == org && replaced.Space == space && strings.HasPrefix(replaced.Name, before.Name+push.ReplacedSuffix) && replaced.State != AppStopped {
+			return AssertionError{f.url, replaced.Name, "the replaced app is " + replaced.State}
+		}
+	}
+	return nil
+}
+
+// AssertRolledBack returns an error unless the app that was running before a deployment is running again with
+// its routes, and nothing the deployment pushed is left.
+func (f *Foundation) AssertRolledBack(org, space string, before App) error {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	a, err := f.assertStarted(org, space, before.Name)
+	if err != nil {
+		return err
+	}
+	if a.GUID != before.GUID {
+		return AssertionError{f.url, before.Name, "was replaced"}
+	}
+	return f.assertRoutes(a, before.Routes)
+}
+
+// AssertNotDeployed returns an error if the app, a new build or a venerable app of it is in the space, such as
+// after the deployment of a new app was rolled back.
+func (f *Foundation) AssertNotDeployed(org, space, name string) error {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	if f.app(org, space, name) != nil {
+		return AssertionError{f.url, name, "was deployed"}
+	}
+	return f.assertNoLeftovers(org, space, name)
+}
+
+// assertStarted returns the app of the space, unless it is not started or a new build or venerable app is left.
+func (f *Foundation) assertStarted(org, space, name string) (*App, error) {
+	a := f.app(org, space, name)
+	if a == nil {
+		return nil, AssertionError{f.url, name, "not found"}
+	}
+	if a.State != AppStarted {
+		return nil, AssertionError{f.url, name, "is " + a.State}
+	}
+	return a, f.assertNoLeftovers(org, space, name)
+}
+
+func (f *Foundation) assertNoLeftovers(org, space, name string) error {
+	for _, a := range f.apps {
+		if a.Org != org || a.Space != space {
+			continue
+		}
+		if strings.HasPrefix(a.Name, name+push.TemporaryNameSuffix) {
+			return AssertionError{f.url, name, "the new build " + a.Name + " is left"}
+		}
+		if a.Name == name+push.VenerableSuffix {
+			return AssertionError{f.url, name, "the venerable app " + a.Name + " is left"}
+		}
+	}
+	return nil
+}
+
+func (f *Foundation) assertRoutes(a *App, routes []string) error {
+	for _, route := range routes {
+		found := false
+		for _, r := range a.Routes {
+			found = found || r == route
+		}
+		if !found {
+			return AssertionError{f.url, a.Name, fmt.Sprintf("route %s is not mapped: %v", route, a.Routes)}
+		}
+	}
+	return nil
+}
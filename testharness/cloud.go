@@ -0,0 +1,356 @@
+// Package testharness is an in-memory Cloud Foundry for the integration tests of event handlers, action creators
+// and other extensions. Its Courier changes the apps, routes and services of the foundations of a Cloud instead of
+// running the Cloud Foundry CLI, so a test can deploy through the real push, start and stop controllers and then
+// assert what every foundation looks like.
+package testharness
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// The states of an app.
+const (
+	AppStarted = "STARTED"
+	AppStopped = "STOPPED"
+)
+
+// The states of an instance of an app.
+const (
+	InstanceRunning = "RUNNING"
+	InstanceCrashed = "CRASHED"
+	InstanceDown    = "DOWN"
+)
+
+// DefaultMemoryMB is the memory of an instance of an app whose memory is not set, as in Cloud Foundry.
+const DefaultMemoryMB = 1024
+
+// NewCloud returns a Cloud with a foundation at every URL.
+func NewCloud(foundationURLs ...string) *Cloud {
+	c := &Cloud{foundations: map[string]*Foundation{}, Now: time.Now}
+	for _, foundationURL := range foundationURLs {
+		c.AddFoundation(foundationURL)
+	}
+	return c
+}
+
+// Cloud is a set of in-memory foundations, which is safe for concurrent use by the couriers of a deployment.
+type Cloud struct {
+	// Now is the time apps are created, crash and log at.
+	Now func() time.Time
+
+	mu          sync.Mutex
+	foundations map[string]*Foundation
+	guids       int
+}
+
+// AddFoundation adds a foundation at the URL with the domains, the first of which is the domain pushed apps are
+// routed to. A foundation without domains accepts routes of any domain.
+func (c *Cloud) AddFoundation(foundationURL string, domains ...string) *Foundation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := &Foundation{
+		cloud:           c,
+		url:             foundationURL,
+		domains:         domains,
+		latency:         map[Command]time.Duration{},
+		stagingFailures: map[string]string{},
+		taskFailures:    map[string]string{},
+		services:        map[string]*Service{},
+		tasks:           map[string]S.Task{},
+	}
+	c.foundations[foundationURL] = f
+	return f
+}
+
+// Foundation returns the foundation at the URL, or nil.
+func (c *Cloud) Foundation(foundationURL string) *Foundation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.foundations[foundationURL]
+}
+
+// NewCourier returns a Courier of the Cloud. It is a courier.CourierConstructor, so that the CreatorModuleProvider
+// of a test creates the couriers of the Cloud. The executor is only cleaned up.
+func (c *Cloud) NewCourier(executor I.Executor) I.Courier {
+	return &Courier{cloud: c, executor: executor, target: &target{}}
+}
+
+func (c *Cloud) guid(kind string) string {
+	c.guids++
+	return fmt.Sprintf("%s-guid-%d", kind, c.guids)
+}
+
+// App is an application of a foundation.
+type App struct {
+	GUID        string
+	Name        string
+	Org         string
+	Space       string
+	State       string
+	Instances   uint16
+	MemoryMB    int
+	Path        string
+	Routes      []string
+	Environment map[string]string
+	Labels      map[string]string
+	Services    []string
+	CreatedAt   time.Time
+
+	// Crashed are the indexes of the instances that are crashing, and Crashes the times any instance crashed.
+	Crashed []int
+	Crashes []time.Time
+
+	Logs []S.LogEnvelope
+}
+
+func (a *App) copy() App {
+	c := *a
+	c.Routes = append([]string{}, a.Routes...)
+	c.Services = append([]string{}, a.Services...)
+	c.Crashed = append([]int{}, a.Crashed...)
+	c.Crashes = append([]time.Time{}, a.Crashes...)
+	c.Logs = append([]S.LogEnvelope{}, a.Logs...)
+	c.Environment = copyMap(a.Environment)
+	c.Labels = copyMap(a.Labels)
+	return c
+}
+
+func (a *App) crashed(index int) bool {
+	for _, i := range a.Crashed {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// Service is a service instance of a space, or a user provided service with its credentials.
+type Service struct {
+	Name         string
+	Org          string
+	Space        string
+	Offering     string
+	Plan         string
+	UserProvided bool
+	Credentials  string
+}
+
+// Foundation is an in-memory Cloud Foundry foundation.
+type Foundation struct {
+	cloud *Cloud
+	url   string
+
+	username        string
+	password        string
+	domains         []string
+	down            bool
+	latency         map[Command]time.Duration
+	stagingFailures map[string]string
+	taskFailures    map[string]string
+
+	faults []*fault
+
+	apps     []*App
+	services map[string]*Service
+	tasks    map[string]S.Task
+	commands []Run
+}
+
+// URL returns the URL of the foundation.
+func (f *Foundation) URL() string {
+	return f.url
+}
+
+// SetCredentials makes logins with other credentials fail. Any credentials are accepted by default.
+func (f *Foundation) SetCredentials(username, password string) {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	f.username, f.password = username, password
+}
+
+// SetDown makes logins to the foundation fail, as if it could not be reached.
+func (f *Foundation) SetDown(down bool) {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	f.down = down
+}
+
+// SetLatency delays every run of the command on the foundation, such as a slow push.
+func (f *Foundation) SetLatency(command Command, latency time.Duration) {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	f.latency[command] = latency
+}
+
+// FailStaging makes the pushes of the app fail to stage with the output, including the pushes of its new builds,
+// whose names start with the name of the app and a dash. The app is created but stays stopped.
+func (f *Foundation) FailStaging(appName, output string) {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	f.stagingFailures[appName] = output
+}
+
+// FailTask makes the tasks with the name fail with the reason.
+func (f *Foundation) FailTask(taskName, reason string) {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	f.taskFailures[taskName] = reason
+}
+
+// AddApp adds an app that is already running on the foundation, and returns it with its GUID.
+func (f *Foundation) AddApp(app App) App {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	a := app.copy()
+	if a.GUID == "" {
+		a.GUID = f.cloud.guid("app")
+	}
+	if a.State == "" {
+		a.State = AppStarted
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = f.cloud.Now()
+	}
+	f.apps = append(f.apps, &a)
+	return a.copy()
+}
+
+// App returns the app of the space.
+func (f *Foundation) App(org, space, name string) (App, bool) {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	a := f.app(org, space, name)
+	if a == nil {
+		return App{}, false
+	}
+	return a.copy(), true
+}
+
+// Apps returns the apps of the space, sorted by name.
+func (f *Foundation) Apps(org, space string) []App {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	apps := []App{}
+	for _, a := range f.apps {
+		if a.Org == org && a.Space == space {
+			apps = append(apps, a.copy())
+		}
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Name < apps[j].Name })
+	return apps
+}
+
+// Service returns the service instance of the space.
+func (f *Foundation) Service(org, space, name string) (Service, bool) {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	s, ok := f.services[serviceKey(org, space, name)]
+	if !ok {
+		return Service{}, false
+	}
+	return *s, true
+}
+
+// Crash makes the instance of the app crash, until it is restarted.
+func (f *Foundation) Crash(org, space, name string, index int) error {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	a := f.app(org, space, name)
+	if a == nil {
+		return AppNotFoundError{name}
+	}
+	if !a.crashed(index) {
+		a.Crashed = append(a.Crashed, index)
+	}
+	a.Crashes = append(a.Crashes, f.cloud.Now())
+	return nil
+}
+
+// Log adds log lines of the app, such as the requests to it, with the source type, such as RTR or APP/PROC/WEB.
+func (f *Foundation) Log(org, space, name, sourceType string, messages ...string) error {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	a := f.app(org, space, name)
+	if a == nil {
+		return AppNotFoundError{name}
+	}
+	for _, message := range messages {
+		a.Logs = append(a.Logs, S.LogEnvelope{Timestamp: f.cloud.Now(), SourceType: sourceType, InstanceID: "0", Type: "OUT", Message: message})
+	}
+	return nil
+}
+
+func (f *Foundation) app(org, space, name string) *App {
+	for _, a := range f.apps {
+		if a.Org == org && a.Space == space && a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+func (f *Foundation) remove(app *App) {
+	for i, a := range f.apps {
+		if a == app {
+			f.apps = append(f.apps[:i:i], f.apps[i+1:]...)
+			return
+		}
+	}
+}
+
+// stagingFailure returns the output of the staging failure of the app, or of the app it is a build of.
+func (f *Foundation) stagingFailure(appName string) (string, bool) {
+	for name, output := range f.stagingFailures {
+		if buildOf(appName, name) {
+			return output, true
+		}
+	}
+	return "", false
+}
+
+// knownDomain reports whether routes of the domain can be mapped.
+func (f *Foundation) knownDomain(domain string) bool {
+	if len(f.domains) == 0 {
+		return true
+	}
+	for _, d := range f.domains {
+		if d == domain {
+			return true
+		}
+	}
+	return false
+}
+
+func serviceKey(org, space, name string) string {
+	return org + "/" + space + "/" + name
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for key, value := range m {
+		c[key] = value
+	}
+	return c
+}
@@ -0,0 +1,726 @@
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Command is a command of the Cloud Foundry CLI run by a Courier, which latency can be injected into.
+type Command string
+
+// The commands run by a Courier.
+const (
+	Login           Command = "login"
+	Auth            Command = "auth"
+	Push            Command = "push"
+	Rename          Command = "rename"
+	Delete          Command = "delete"
+	Start           Command = "start"
+	Stop            Command = "stop"
+	Restage         Command = "restage"
+	Scale           Command = "scale"
+	RestartInstance Command = "restart-app-instance"
+	MapRoute        Command = "map-route"
+	UnmapRoute      Command = "unmap-route"
+	DeleteRoute     Command = "delete-route"
+	CreateService   Command = "create-service"
+	BindService     Command = "bind-service"
+	UnbindService   Command = "unbind-service"
+	DeleteService   Command = "delete-service"
+	Cups            Command = "cups"
+	Uups            Command = "uups"
+	SetLabel        Command = "set-label"
+	Logs            Command = "logs"
+	RunTask         Command = "run-task"
+	AppInfo         Command = "app"
+	ServiceInfo     Command = "service"
+	Apps            Command = "apps"
+	Domains         Command = "domains"
+	Quota           Command = "quota"
+	Task            Command = "task"
+
+	// HealthCheck is a request to a route of an app by the client of a Cloud.
+	HealthCheck Command = "health-check"
+)
+
+// target is the foundation, org and space a Courier logged in to. It is shared by the copies of a Courier, as the
+// login of the Cloud Foundry CLI is shared by the commands run in its home directory.
+type target struct {
+	foundation *Foundation
+	org        string
+	space      string
+}
+
+// Courier runs the commands of the Cloud Foundry CLI against the foundation of a Cloud it logged in to.
+type Courier struct {
+	cloud    *Cloud
+	executor I.Executor
+	target   *target
+	ctx      context.Context
+}
+
+// Login logs in to the foundation at the URL and targets the org and space.
+func (c Courier) Login(foundationURL, username, password, org, space string, skipSSL bool) ([]byte, error) {
+	f, err := c.authenticate(Login, foundationURL, username, password)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	c.cloud.mu.Lock()
+	defer c.cloud.mu.Unlock()
+
+	*c.target = target{foundation: f, org: org, space: space}
+	return []byte(fmt.Sprintf("API endpoint: %s\nAuthenticating...\nOK\nTargeted org %s\nTargeted space %s\n", foundationURL, org, space)), nil
+}
+
+// Authenticate logs in to the foundation at the URL without targeting a space.
+func (c Courier) Authenticate(foundationURL, username, password string, skipSSL bool) ([]byte, error) {
+	f, err := c.authenticate(Auth, foundationURL, username, password)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	c.cloud.mu.Lock()
+	defer c.cloud.mu.Unlock()
+
+	*c.target = target{foundation: f}
+	return []byte(fmt.Sprintf("API endpoint: %s\nAuthenticating...\nOK\n", foundationURL)), nil
+}
+
+func (c Courier) authenticate(command Command, foundationURL, username, password string) (*Foundation, error) {
+	f := c.cloud.Foundation(foundationURL)
+	if f == nil {
+		return nil, UnknownFoundationError{foundationURL}
+	}
+	if err := c.wait(f, command); err != nil {
+		return nil, err
+	}
+
+	c.cloud.mu.Lock()
+	defer c.cloud.mu.Unlock()
+
+	_, err := f.inject(command, "")
+	switch {
+	case err != nil:
+	case f.down:
+		err = FoundationDownError{foundationURL}
+	case f.username != "" && (username != f.username || password != f.password):
+		err = InvalidCredentialsError{foundationURL, username}
+	}
+	f.commands = append(f.commands, Run{Command: command, Error: err})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Push creates the app, or updates it, with the environment variables and memory of the manifest.yml in the
+// appLocation. The app is routed to the hostname on the first domain of the foundation and started, unless its
+// staging fails.
+func (c Courier) Push(appName, appLocation, hostname string, instances uint16) ([]byte, error) {
+	m, err := readManifest(appLocation)
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+
+	return c.run(Push, appName, func(f *Foundation, t target) (string, error) {
+		a := f.app(t.org, t.space, appName)
+		if a == nil {
+			a = &App{GUID: f.cloud.guid("app"), Name: appName, Org: t.org, Space: t.space, CreatedAt: f.cloud.Now()}
+			f.apps = append(f.apps, a)
+		}
+		a.Path = appLocation
+		a.Instances = instances
+		if a.Instances == 0 {
+			a.Instances = m.instances
+		}
+		if a.Instances == 0 {
+			a.Instances = 1
+		}
+		a.MemoryMB = m.memoryMB
+		a.Environment = copyMap(m.env)
+		a.Crashed = nil
+		if len(f.domains) > 0 && hostname != "" {
+			addRoute(a, hostname+"."+f.domains[0])
+		}
+
+		if output, failed := f.stagingFailure(appName); failed {
+			a.State = AppStopped
+			a.Logs = append(a.Logs, S.LogEnvelope{Timestamp: f.cloud.Now(), SourceType: "STG", InstanceID: "0", Type: "ERR", Message: output})
+			return fmt.Sprintf("Pushing app %s...\nStaging app and tracing logs...\n%s\nFAILED\n", appName, output), StagingError{appName, output}
+		}
+
+		a.State = AppStarted
+		a.Logs = append(a.Logs, S.LogEnvelope{Timestamp: f.cloud.Now(), SourceType: "STG", InstanceID: "0", Type: "OUT", Message: "Staging complete"})
+		return fmt.Sprintf("Pushing app %s...\nStaging app and tracing logs...\nWaiting for app %s to start...\nOK\n", appName, appName), nil
+	})
+}
+
+// Rename renames the app.
+func (c Courier) Rename(oldName, newName string) ([]byte, error) {
+	return c.run(Rename, oldName, func(f *Foundation, t target) (string, error) {
+		a := f.app(t.org, t.space, oldName)
+		if a == nil {
+			return "", AppNotFoundError{oldName}
+		}
+		if f.app(t.org, t.space, newName) != nil {
+			return "", AppExistsError{newName}
+		}
+		a.Name = newName
+		return fmt.Sprintf("Renaming app %s to %s...\nOK\n", oldName, newName), nil
+	})
+}
+
+// Delete deletes the app of the targeted space. Deleting an app that does not exist succeeds, as in Cloud Foundry.
+func (c Courier) Delete(appName string) ([]byte, error) {
+	return c.run(Delete, appName, func(f *Foundation, t target) (string, error) {
+		a := f.app(t.org, t.space, appName)
+		if a == nil {
+			return fmt.Sprintf("App %s does not exist.\nOK\n", appName), nil
+		}
+		f.remove(a)
+		return fmt.Sprintf("Deleting app %s...\nOK\n", appName), nil
+	})
+}
+
+// DeleteByGUID deletes the app with the GUID from any space of the foundation.
+func (c Courier) DeleteByGUID(guid string) ([]byte, error) {
+	return c.run(Delete, guid, func(f *Foundation, t target) (string, error) {
+		for _, a := range f.apps {
+			if a.GUID == guid {
+				f.remove(a)
+				return "", nil
+			}
+		}
+		return "", AppNotFoundError{guid}
+	})
+}
+
+// Start starts the app, unless its staging fails.
+func (c Courier) Start(appName string) ([]byte, error) {
+	return c.update(Start, appName, func(f *Foundation, a *App) (string, error) {
+		if output, failed := f.stagingFailure(appName); failed {
+			return output, StagingError{appName, output}
+		}
+		a.State = AppStarted
+		return fmt.Sprintf("Starting app %s...\nOK\n", appName), nil
+	})
+}
+
+// Stop stops the app.
+func (c Courier) Stop(appName string) ([]byte, error) {
+	return c.update(Stop, appName, func(f *Foundation, a *App) (string, error) {
+		a.State = AppStopped
+		return fmt.Sprintf("Stopping app %s...\nOK\n", appName), nil
+	})
+}
+
+// Restage restages and starts the app, unless its staging fails.
+func (c Courier) Restage(appName string) ([]byte, error) {
+	return c.update(Restage, appName, func(f *Foundation, a *App) (string, error) {
+		if output, failed := f.stagingFailure(appName); failed {
+			a.State = AppStopped
+			return output, StagingError{appName, output}
+		}
+		a.State = AppStarted
+		a.Crashed = nil
+		return fmt.Sprintf("Restaging app %s...\nOK\n", appName), nil
+	})
+}
+
+// Scale sets the number of instances of the app.
+func (c Courier) Scale(appName string, instances uint16) ([]byte, error) {
+	return c.update(Scale, appName, func(f *Foundation, a *App) (string, error) {
+		a.Instances = instances
+		return fmt.Sprintf("Scaling app %s...\nOK\n", appName), nil
+	})
+}
+
+// RestartInstance restarts the instance of the app, which stops it crashing.
+func (c Courier) RestartInstance(appName string, index int) ([]byte, error) {
+	return c.update(RestartInstance, appName, func(f *Foundation, a *App) (string, error) {
+		if index < 0 || index >= int(a.Instances) {
+			return "", InstanceNotFoundError{appName, index}
+		}
+		for i, crashed := range a.Crashed {
+			if crashed == index {
+				a.Crashed = append(a.Crashed[:i:i], a.Crashed[i+1:]...)
+				break
+			}
+		}
+		return fmt.Sprintf("Restarting instance %d of app %s...\nOK\n", index, appName), nil
+	})
+}
+
+// MapRoute routes the hostname on the domain to the app.
+func (c Courier) MapRoute(appName, domain, hostname string) ([]byte, error) {
+	return c.MapRouteWithPath(appName, domain, hostname, "")
+}
+
+// MapRouteWithPath routes the path of the hostname on the domain to the app.
+func (c Courier) MapRouteWithPath(appName, domain, hostname, path string) ([]byte, error) {
+	return c.update(MapRoute, appName, func(f *Foundation, a *App) (string, error) {
+		if !f.knownDomain(domain) {
+			return "", UnknownDomainError{domain}
+		}
+		r := route(domain, hostname, path)
+		addRoute(a, r)
+		return fmt.Sprintf("Mapping route %s to app %s...\nOK\n", r, appName), nil
+	})
+}
+
+// UnmapRoute removes the route of the hostname on the domain from the app.
+func (c Courier) UnmapRoute(appName, domain, hostname string) ([]byte, error) {
+	return c.UnmapRouteWithPath(appName, domain, hostname, "")
+}
+
+// UnmapRouteWithPath removes the route of the path of the hostname on the domain from the app.
+func (c Courier) UnmapRouteWithPath(appName, domain, hostname, path string) ([]byte, error) {
+	return c.update(UnmapRoute, appName, func(f *Foundation, a *App) (string, error) {
+		r := route(domain, hostname, path)
+		a.Routes = removeString(a.Routes, r)
+		return fmt.Sprintf("Removing route %s from app %s...\nOK\n", r, appName), nil
+	})
+}
+
+// DeleteRoute removes the route of the hostname on the domain from every app of the foundation.
+func (c Courier) DeleteRoute(domain, hostname string) ([]byte, error) {
+	return c.run(DeleteRoute, hostname, func(f *Foundation, t target) (string, error) {
+		r := route(domain, hostname, "")
+		for _, a := range f.apps {
+			a.Routes = removeString(a.Routes, r)
+		}
+		return fmt.Sprintf("Deleting route %s...\nOK\n", r), nil
+	})
+}
+
+// CreateService creates a service instance of the offering and plan in the targeted space.
+func (c Courier) CreateService(service, plan, name string) ([]byte, error) {
+	return c.run(CreateService, name, func(f *Foundation, t target) (string, error) {
+		f.services[serviceKey(t.org, t.space, name)] = &Service{Name: name, Org: t.org, Space: t.space, Offering: service, Plan: plan}
+		return fmt.Sprintf("Creating service instance %s...\nOK\n", name), nil
+	})
+}
+
+// BindService binds the service instance to the app.
+func (c Courier) BindService(appName, serviceName string) ([]byte, error) {
+	return c.update(BindService, appName, func(f *Foundation, a *App) (string, error) {
+		if _, ok := f.services[serviceKey(a.Org, a.Space, serviceName)]; !ok {
+			return "", ServiceNotFoundError{serviceName}
+		}
+		a.Services = removeString(a.Services, serviceName)
+		a.Services = append(a.Services, serviceName)
+		return fmt.Sprintf("Binding service %s to app %s...\nOK\n", serviceName, appName), nil
+	})
+}
+
+// UnbindService unbinds the service instance from the app.
+func (c Courier) UnbindService(appName, serviceName string) ([]byte, error) {
+	return c.update(UnbindService, appName, func(f *Foundation, a *App) (string, error) {
+		a.Services = removeString(a.Services, serviceName)
+		return fmt.Sprintf("Unbinding app %s from service %s...\nOK\n", appName, serviceName), nil
+	})
+}
+
+// DeleteService deletes the service instance of the targeted space.
+func (c Courier) DeleteService(serviceName string) ([]byte, error) {
+	return c.run(DeleteService, serviceName, func(f *Foundation, t target) (string, error) {
+		key := serviceKey(t.org, t.space, serviceName)
+		if _, ok := f.services[key]; !ok {
+			return "", ServiceNotFoundError{serviceName}
+		}
+		delete(f.services, key)
+		return fmt.Sprintf("Deleting service %s...\nOK\n", serviceName), nil
+	})
+}
+
+// Cups creates a user provided service with the credentials.
+func (c Courier) Cups(serviceName string, body string) ([]byte, error) {
+	return c.run(Cups, serviceName, func(f *Foundation, t target) (string, error) {
+		f.services[serviceKey(t.org, t.space, serviceName)] = &Service{Name: serviceName, Org: t.org, Space: t.space, UserProvided: true, Credentials: body}
+		return fmt.Sprintf("Creating user provided service %s...\nOK\n", serviceName), nil
+	})
+}
+
+// Uups updates the credentials of a user provided service.
+func (c Courier) Uups(serviceName string, body string) ([]byte, error) {
+	return c.run(Uups, serviceName, func(f *Foundation, t target) (string, error) {
+		s, ok := f.services[serviceKey(t.org, t.space, serviceName)]
+		if !ok {
+			return "", ServiceNotFoundError{serviceName}
+		}
+		s.Credentials = body
+		return fmt.Sprintf("Updating user provided service %s...\nOK\n", serviceName), nil
+	})
+}
+
+// SetLabel adds the labels to the app.
+func (c Courier) SetLabel(appName string, labels map[string]string) ([]byte, error) {
+	return c.update(SetLabel, appName, func(f *Foundation, a *App) (string, error) {
+		if a.Labels == nil {
+			a.Labels = map[string]string{}
+		}
+		for key, value := range labels {
+			a.Labels[key] = value
+		}
+		return fmt.Sprintf("Setting label(s) for app %s...\nOK\n", appName), nil
+	})
+}
+
+// Logs returns the recent logs of the app.
+func (c Courier) Logs(appName string) ([]byte, error) {
+	return c.update(Logs, appName, func(f *Foundation, a *App) (string, error) {
+		lines := []string{fmt.Sprintf("Retrieving logs for app %s...", appName)}
+		for _, envelope := range a.Logs {
+			lines = append(lines, envelope.String())
+		}
+		return strings.Join(lines, "\n") + "\n", nil
+	})
+}
+
+// LogEnvelopes returns the recent logs of the app.
+func (c Courier) LogEnvelopes(appName string) ([]S.LogEnvelope, error) {
+	var envelopes []S.LogEnvelope
+	_, err := c.update(Logs, appName, func(f *Foundation, a *App) (string, error) {
+		envelopes = append([]S.LogEnvelope{}, a.Logs...)
+		return "", nil
+	})
+	return envelopes, err
+}
+
+// Exists reports whether the app is in the targeted space.
+func (c Courier) Exists(appName string) bool {
+	_, err := c.update(AppInfo, appName, func(f *Foundation, a *App) (string, error) { return "", nil })
+	return err == nil
+}
+
+// ServiceExists reports whether the service instance is in the targeted space.
+func (c Courier) ServiceExists(serviceName string) bool {
+	_, err := c.run(ServiceInfo, serviceName, func(f *Foundation, t target) (string, error) {
+		if _, ok := f.services[serviceKey(t.org, t.space, serviceName)]; !ok {
+			return "", ServiceNotFoundError{serviceName}
+		}
+		return "", nil
+	})
+	return err == nil
+}
+
+// Domains returns the domains of the foundation.
+func (c Courier) Domains() ([]string, error) {
+	var domains []string
+	_, err := c.run(Domains, "", func(f *Foundation, t target) (string, error) {
+		domains = append([]string{}, f.domains...)
+		return "", nil
+	})
+	return domains, err
+}
+
+// AppState returns the state, instances and labels of the app.
+func (c Courier) AppState(appName string) (S.AppState, error) {
+	var state S.AppState
+	_, err := c.update(AppInfo, appName, func(f *Foundation, a *App) (string, error) {
+		state = S.AppState{GUID: a.GUID, State: a.State, Instances: a.Instances, Labels: copyMap(a.Labels)}
+		return "", nil
+	})
+	return state, err
+}
+
+// AppEnvironment returns the environment variables of the app.
+func (c Courier) AppEnvironment(appName string) (map[string]string, error) {
+	var environment map[string]string
+	_, err := c.update(AppInfo, appName, func(f *Foundation, a *App) (string, error) {
+		environment = copyMap(a.Environment)
+		if environment == nil {
+			environment = map[string]string{}
+		}
+		return "", nil
+	})
+	return environment, err
+}
+
+// AppRoutes returns the sorted routes of the app.
+func (c Courier) AppRoutes(appName string) ([]string, error) {
+	var routes []string
+	_, err := c.update(AppInfo, appName, func(f *Foundation, a *App) (string, error) {
+		routes = append([]string{}, a.Routes...)
+		sort.Strings(routes)
+		return "", nil
+	})
+	return routes, err
+}
+
+// Apps returns the apps of every space of the foundation.
+func (c Courier) Apps() ([]S.AppSummary, error) {
+	apps := []S.AppSummary{}
+	_, err := c.run(Apps, "", func(f *Foundation, t target) (string, error) {
+		for _, a := range f.apps {
+			apps = append(apps, S.AppSummary{GUID: a.GUID, Name: a.Name, Org: a.Org, Space: a.Space, State: a.State, CreatedAt: a.CreatedAt})
+		}
+		return "", nil
+	})
+	return apps, err
+}
+
+// Instances returns the instances of the app, which are DOWN while it is stopped.
+func (c Courier) Instances(appName string) ([]S.Instance, error) {
+	var instances []S.Instance
+	_, err := c.update(AppInfo, appName, func(f *Foundation, a *App) (string, error) {
+		for i := 0; i < int(a.Instances); i++ {
+			state := InstanceRunning
+			switch {
+			case a.State != AppStarted:
+				state = InstanceDown
+			case a.crashed(i):
+				state = InstanceCrashed
+			}
+			instances = append(instances, S.Instance{Index: i, State: state})
+		}
+		return "", nil
+	})
+	return instances, err
+}
+
+// CrashCount returns the number of times the instances of the app crashed since the time.
+func (c Courier) CrashCount(appName string, since time.Time) (int, error) {
+	count := 0
+	_, err := c.update(AppInfo, appName, func(f *Foundation, a *App) (string, error) {
+		for _, crash := range a.Crashes {
+			if !crash.Before(since) {
+				count++
+			}
+		}
+		return "", nil
+	})
+	return count, err
+}
+
+// RunTask runs the task on the app. It is done at once, and fails when the foundation is told it fails.
+func (c Courier) RunTask(appName, taskName, command string) (S.Task, error) {
+	var task S.Task
+	_, err := c.update(RunTask, appName, func(f *Foundation, a *App) (string, error) {
+		task = S.Task{GUID: f.cloud.guid("task"), Name: taskName, State: S.TaskSucceeded}
+		if reason, failed := f.taskFailures[taskName]; failed {
+			task.State, task.FailureReason = S.TaskFailed, reason
+		}
+		f.tasks[task.GUID] = task
+		return "", nil
+	})
+	return task, err
+}
+
+// Task returns the task with the GUID.
+func (c Courier) Task(guid string) (S.Task, error) {
+	var task S.Task
+	_, err := c.run(Task, guid, func(f *Foundation, t target) (string, error) {
+		var ok bool
+		if task, ok = f.tasks[guid]; !ok {
+			return "", TaskNotFoundError{guid}
+		}
+		return "", nil
+	})
+	return task, err
+}
+
+// QuotaUsage returns the memory and instances of the started apps of the org and of the space. Their quotas have
+// no limits.
+func (c Courier) QuotaUsage(org, space string) (S.OrgSpaceQuotaUsage, error) {
+	var usage S.OrgSpaceQuotaUsage
+	_, err := c.run(Quota, "", func(f *Foundation, t target) (string, error) {
+		for _, a := range f.apps {
+			if a.Org != org || a.State != AppStarted {
+				continue
+			}
+			memory := a.MemoryMB
+			if memory == 0 {
+				memory = DefaultMemoryMB
+			}
+			usage.Org.Instances += int(a.Instances)
+			usage.Org.MemoryMB += memory * int(a.Instances)
+			if a.Space == space {
+				usage.Space.Instances += int(a.Instances)
+				usage.Space.MemoryMB += memory * int(a.Instances)
+			}
+		}
+		return "", nil
+	})
+	return usage, err
+}
+
+// CleanUp cleans up the executor of the Courier.
+func (c Courier) CleanUp() error {
+	if c.executor == nil {
+		return nil
+	}
+	return c.executor.CleanUp()
+}
+
+// WithContext returns a Courier whose commands fail once ctx is done, including while they are delayed.
+func (c Courier) WithContext(ctx context.Context) I.Courier {
+	c.ctx = ctx
+	return c
+}
+
+// WithCABundle returns the Courier, as the foundations of a Cloud have no certificates.
+func (c Courier) WithCABundle(path string) I.Courier {
+	return c
+}
+
+// WithLogCache returns the Courier, as the logs of the foundations of a Cloud are read directly.
+func (c Courier) WithLogCache(descriptor S.LogCacheDescriptor) I.Courier {
+	return c
+}
+
+// run delays the command on the app by the latency of the foundation logged in to, and then runs it unless a
+// fault of the foundation fails it. The run is recorded in the commands of the foundation.
+func (c Courier) run(command Command, app string, fn func(f *Foundation, t target) (string, error)) ([]byte, error) {
+	c.cloud.mu.Lock()
+	t := *c.target
+	c.cloud.mu.Unlock()
+
+	if t.foundation == nil {
+		return []byte(NotLoggedInError{}.Error()), NotLoggedInError{}
+	}
+	if err := c.wait(t.foundation, command); err != nil {
+		return []byte(err.Error()), err
+	}
+
+	c.cloud.mu.Lock()
+	defer c.cloud.mu.Unlock()
+
+	var output string
+	injected, err := t.foundation.inject(command, app)
+	if injected != nil {
+		output = injected.Output
+	} else {
+		output, err = fn(t.foundation, t)
+	}
+	if err != nil && output == "" {
+		output = err.Error()
+	}
+	t.foundation.commands = append(t.foundation.commands, Run{Command: command, App: app, Error: err})
+	return []byte(output), err
+}
+
+// update runs the command on the app of the targeted space.
+func (c Courier) update(command Command, appName string, fn func(f *Foundation, a *App) (string, error)) ([]byte, error) {
+	return c.run(command, appName, func(f *Foundation, t target) (string, error) {
+		a := f.app(t.org, t.space, appName)
+		if a == nil {
+			return "", AppNotFoundError{appName}
+		}
+		return fn(f, a)
+	})
+}
+
+// wait sleeps for the latency of the command on the foundation, unless the context of the Courier is done first.
+func (c Courier) wait(f *Foundation, command Command) error {
+	c.cloud.mu.Lock()
+	latency := f.latency[command]
+	c.cloud.mu.Unlock()
+
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if latency <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// manifest is the part of the manifest.yml of a pushed app the foundation reads.
+type manifest struct {
+	instances uint16
+	memoryMB  int
+	env       map[string]string
+}
+
+// readManifest reads the first application of the manifest.yml in the directory, if there is one.
+func readManifest(directory string) (manifest, error) {
+	m := manifest{}
+	b, err := ioutil.ReadFile(filepath.Join(directory, "manifest.yml"))
+	if err != nil {
+		return m, nil
+	}
+
+	var content struct {
+		Applications []struct {
+			Instances uint16            `yaml:"instances"`
+			Memory    string            `yaml:"memory"`
+			Env       map[string]string `yaml:"env"`
+		} `yaml:"applications"`
+	}
+	if err := candiedyaml.Unmarshal(b, &content); err != nil {
+		return m, InvalidManifestError{err}
+	}
+	if len(content.Applications) == 0 {
+		return m, nil
+	}
+
+	application := content.Applications[0]
+	m.instances = application.Instances
+	m.env = application.Env
+	m.memoryMB = megabytes(application.Memory)
+	return m, nil
+}
+
+// megabytes parses a memory size like 512M or 1G.
+func megabytes(size string) int {
+	size = strings.ToUpper(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "B"))
+	multiplier := 1
+	if strings.HasSuffix(size, "G") {
+		multiplier = 1024
+	}
+	n, err := strconv.Atoi(strings.TrimRight(size, "MG"))
+	if err != nil {
+		return 0
+	}
+	return n * multiplier
+}
+
+func route(domain, hostname, path string) string {
+	r := domain
+	if hostname != "" {
+		r = hostname + "." + domain
+	}
+	if path != "" {
+		r += "/" + strings.TrimPrefix(path, "/")
+	}
+	return r
+}
+
+func addRoute(a *App, r string) {
+	a.Routes = append(removeString(a.Routes, r), r)
+}
+
+func removeString(values []string, value string) []string {
+	kept := []string{}
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
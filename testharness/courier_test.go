@@ -0,0 +1,255 @@
+package testharness_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/compozed/deployadactyl/conformance"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/compozed/deployadactyl/testharness"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Courier", func() {
+	var (
+		cloud      *Cloud
+		foundation *Foundation
+		courier    I.Courier
+		appPath    string
+	)
+
+	BeforeEach(func() {
+		cloud = NewCloud()
+		foundation = cloud.AddFoundation("api1.example.com", "apps.example.com", "example.com")
+		courier = cloud.NewCourier(nil)
+
+		var err error
+		appPath, err = ioutil.TempDir("", "testharness-")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(courier.Login("api1.example.com", "username", "password", "org", "space", false)).ToNot(BeEmpty())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(appPath)
+	})
+
+	Describe("Login", func() {
+		It("fails for an unknown foundation", func() {
+			_, err := courier.Login("api9.example.com", "username", "password", "org", "space", false)
+
+			Expect(err).To(MatchError(UnknownFoundationError{"api9.example.com"}))
+		})
+
+		It("fails with other credentials than the ones of the foundation", func() {
+			foundation.SetCredentials("username", "secret")
+
+			_, err := courier.Login("api1.example.com", "username", "password", "org", "space", false)
+
+			Expect(err).To(MatchError(InvalidCredentialsError{"api1.example.com", "username"}))
+		})
+
+		It("fails while the foundation is down", func() {
+			foundation.SetDown(true)
+
+			_, err := courier.Login("api1.example.com", "username", "password", "org", "space", false)
+
+			Expect(err).To(MatchError(FoundationDownError{"api1.example.com"}))
+		})
+
+		It("is required to run a command", func() {
+			_, err := cloud.NewCourier(nil).Push("app", appPath, "app", 1)
+
+			Expect(err).To(MatchError(NotLoggedInError{}))
+		})
+
+		It("is shared by the copies of the courier", func() {
+			c := cloud.NewCourier(nil)
+			other := c.WithContext(context.Background())
+
+			Expect(c.Login("api1.example.com", "username", "password", "org", "space", false)).ToNot(BeEmpty())
+
+			_, err := other.Push("app", appPath, "app", 1)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("Push", func() {
+		It("starts the app with the environment of its manifest, routed to the first domain", func() {
+			manifest := "---\napplications:\n- name: app\n  memory: 512M\n  env:\n    GREETING: hello\n"
+			Expect(ioutil.WriteFile(filepath.Join(appPath, "manifest.yml"), []byte(manifest), 0600)).To(Succeed())
+
+			_, err := courier.Push("app", appPath, "hostname", 2)
+			Expect(err).ToNot(HaveOccurred())
+
+			app, found := foundation.App("org", "space", "app")
+			Expect(found).To(BeTrue())
+			Expect(app.State).To(Equal(AppStarted))
+			Expect(app.Instances).To(Equal(uint16(2)))
+			Expect(app.MemoryMB).To(Equal(512))
+			Expect(app.Routes).To(Equal([]string{"hostname.apps.example.com"}))
+			Expect(courier.AppEnvironment("app")).To(Equal(map[string]string{"GREETING": "hello"}))
+		})
+
+		It("leaves the app stopped when its staging fails, including the new builds of the app", func() {
+			foundation.FailStaging("app", "no buildpack detected")
+
+			out, err := courier.Push("app-new-build-1234", appPath, "app", 1)
+
+			Expect(err).To(MatchError(StagingError{"app-new-build-1234", "no buildpack detected"}))
+			Expect(string(out)).To(ContainSubstring("no buildpack detected"))
+			state, _ := courier.AppState("app-new-build-1234")
+			Expect(state.State).To(Equal(AppStopped))
+			Expect(courier.Logs("app-new-build-1234")).To(ContainSubstring("[STG/0] ERR no buildpack detected"))
+		})
+
+		It("is delayed by the latency of the foundation until the context is done", func() {
+			foundation.SetLatency(Push, time.Minute)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			_, err := courier.WithContext(ctx).Push("app", appPath, "app", 1)
+
+			Expect(err).To(Equal(context.DeadlineExceeded))
+			Expect(courier.Exists("app")).To(BeFalse())
+		})
+	})
+
+	Describe("blue green deployments", func() {
+		BeforeEach(func() {
+			foundation.AddApp(App{Name: "app", Org: "org", Space: "space", Instances: 1, Routes: []string{"app.example.com"}})
+		})
+
+		It("renames, routes and deletes the apps", func() {
+			Expect(courier.Push("app-new-build-1234", appPath, "app", 1)).ToNot(BeEmpty())
+			Expect(courier.MapRoute("app-new-build-1234", "example.com", "app")).ToNot(BeEmpty())
+			Expect(courier.Rename("app", "app-venerable")).ToNot(BeEmpty())
+			Expect(courier.Delete("app-venerable")).ToNot(BeEmpty())
+			Expect(courier.Rename("app-new-build-1234", "app")).ToNot(BeEmpty())
+
+			apps := foundation.Apps("org", "space")
+			Expect(apps).To(HaveLen(1))
+			Expect(apps[0].Name).To(Equal("app"))
+			Expect(courier.AppRoutes("app")).To(Equal([]string{"app.apps.example.com", "app.example.com"}))
+		})
+
+		It("does not rename an app to the name of another app", func() {
+			Expect(courier.Push("app-new-build-1234", appPath, "app", 1)).ToNot(BeEmpty())
+
+			_, err := courier.Rename("app-new-build-1234", "app")
+
+			Expect(err).To(MatchError(AppExistsError{"app"}))
+		})
+
+		It("does not map a route of an unknown domain", func() {
+			_, err := courier.MapRoute("app", "example.org", "app")
+
+			Expect(err).To(MatchError(UnknownDomainError{"example.org"}))
+		})
+
+		It("deletes a route from every app", func() {
+			Expect(courier.DeleteRoute("example.com", "app")).ToNot(BeEmpty())
+
+			Expect(courier.AppRoutes("app")).To(BeEmpty())
+		})
+	})
+
+	Describe("instances", func() {
+		BeforeEach(func() {
+			foundation.AddApp(App{Name: "app", Org: "org", Space: "space", Instances: 2})
+		})
+
+		It("crash until they are restarted", func() {
+			since := time.Now().Add(-time.Second)
+			Expect(foundation.Crash("org", "space", "app", 1)).To(Succeed())
+
+			Expect(courier.Instances("app")).To(Equal([]S.Instance{{Index: 0, State: InstanceRunning}, {Index: 1, State: InstanceCrashed}}))
+			Expect(courier.CrashCount("app", since)).To(Equal(1))
+
+			Expect(courier.RestartInstance("app", 1)).ToNot(BeEmpty())
+			Expect(courier.Instances("app")).To(Equal([]S.Instance{{Index: 0, State: InstanceRunning}, {Index: 1, State: InstanceRunning}}))
+		})
+
+		It("are down while the app is stopped", func() {
+			Expect(courier.Stop("app")).ToNot(BeEmpty())
+
+			Expect(courier.Instances("app")).To(Equal([]S.Instance{{Index: 0, State: InstanceDown}, {Index: 1, State: InstanceDown}}))
+		})
+
+		It("count towards the quota usage while the app is started", func() {
+			Expect(courier.QuotaUsage("org", "space")).To(Equal(S.OrgSpaceQuotaUsage{
+				Org:   S.QuotaUsage{MemoryMB: 2 * DefaultMemoryMB, Instances: 2},
+				Space: S.QuotaUsage{MemoryMB: 2 * DefaultMemoryMB, Instances: 2},
+			}))
+		})
+	})
+
+	Describe("tasks", func() {
+		BeforeEach(func() {
+			foundation.AddApp(App{Name: "app", Org: "org", Space: "space", Instances: 1})
+		})
+
+		It("succeed", func() {
+			task, err := courier.RunTask("app", "migrate", "bin/migrate")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(courier.Task(task.GUID)).To(Equal(S.Task{GUID: task.GUID, Name: "migrate", State: S.TaskSucceeded}))
+		})
+
+		It("fail with the reason of the foundation", func() {
+			foundation.FailTask("migrate", "exit status 1")
+
+			task, err := courier.RunTask("app", "migrate", "bin/migrate")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(task.State).To(Equal(S.TaskFailed))
+			Expect(task.FailureReason).To(Equal("exit status 1"))
+		})
+	})
+
+	Describe("services", func() {
+		BeforeEach(func() {
+			foundation.AddApp(App{Name: "app", Org: "org", Space: "space", Instances: 1})
+		})
+
+		It("are created, bound and deleted", func() {
+			Expect(courier.CreateService("database", "small", "db")).ToNot(BeEmpty())
+			Expect(courier.ServiceExists("db")).To(BeTrue())
+			Expect(courier.BindService("app", "db")).ToNot(BeEmpty())
+
+			app, _ := foundation.App("org", "space", "app")
+			Expect(app.Services).To(Equal([]string{"db"}))
+
+			Expect(courier.DeleteService("db")).ToNot(BeEmpty())
+			Expect(courier.ServiceExists("db")).To(BeFalse())
+		})
+
+		It("are provided by the user", func() {
+			Expect(courier.Cups("credentials", `{"password":"one"}`)).ToNot(BeEmpty())
+			Expect(courier.Uups("credentials", `{"password":"two"}`)).ToNot(BeEmpty())
+
+			service, found := foundation.Service("org", "space", "credentials")
+			Expect(found).To(BeTrue())
+			Expect(service.UserProvided).To(BeTrue())
+			Expect(service.Credentials).To(Equal(`{"password":"two"}`))
+		})
+	})
+
+	It("satisfies the Courier contract", func() {
+		conformance.Courier(GinkgoT(), conformance.CourierSubject{
+			NewCourier:    func() I.Courier { return cloud.NewCourier(nil) },
+			FoundationURL: "api1.example.com",
+			Username:      "username",
+			Password:      "password",
+			Org:           "org",
+			Space:         "space",
+			Domain:        "apps.example.com",
+			AppPath:       appPath,
+		})
+	})
+})
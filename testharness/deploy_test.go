@@ -0,0 +1,123 @@
+package testharness_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/compozed/deployadactyl/creator"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/compozed/deployadactyl/testharness"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Deploying to a Cloud", func() {
+	const config = `---
+environments:
+- name: test
+  domain: example.com
+  instances: 1
+  rollback_enabled: true
+  foundations:
+  - api1.example.com
+  - api2.example.com
+`
+
+	var (
+		cloud  *Cloud
+		server *httptest.Server
+		dir    string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "testharness-")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "config.yml"), []byte(config), 0600)).To(Succeed())
+
+		cloud = NewCloud()
+		cloud.AddFoundation("api1.example.com", "apps1.example.com", "example.com")
+		cloud.AddFoundation("api2.example.com", "apps2.example.com", "example.com")
+
+		c, err := creator.Custom("DEBUG", filepath.Join(dir, "config.yml"), creator.CreatorModuleProvider{
+			NewCourier: cloud.NewCourier,
+			NewPrechecker: func(I.EventManager) I.Prechecker {
+				return &mocks.Prechecker{}
+			},
+			NewFetcher: func(fs *afero.Afero, ex I.Extractor, log I.DeploymentLogger, verifier I.ArtifactVerifier, caBundle string, buckets S.BucketsDescriptor, artifactory S.ArtifactoryDescriptor, maven S.MavenDescriptor, workDir string) I.Fetcher {
+				appPath, _ := ioutil.TempDir(dir, "app-")
+				ioutil.WriteFile(filepath.Join(appPath, "manifest.yml"), []byte("---\napplications:\n- name: app\n  env:\n    VERSION: two\n"), 0600)
+
+				fetcher := &mocks.Fetcher{}
+				fetcher.FetchCall.Returns.AppPath = appPath
+				return fetcher
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		server = httptest.NewServer(c.CreateControllerHandler(c.CreateController()))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	deploy := func() (int, string) {
+		resp, err := http.Post(fmt.Sprintf("%s/v3/apps/test/org/space/app", server.URL), "application/json", bytes.NewBufferString(`{"artifact_url": "https://example.com/app.jar"}`))
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return resp.StatusCode, string(body)
+	}
+
+	It("pushes a new app to every foundation", func() {
+		status, body := deploy()
+		Expect(status).To(Equal(http.StatusOK), body)
+
+		for _, foundationURL := range []string{"api1.example.com", "api2.example.com"} {
+			apps := cloud.Foundation(foundationURL).Apps("org", "space")
+			Expect(apps).To(HaveLen(1))
+			Expect(apps[0].Name).To(Equal("app"))
+			Expect(apps[0].State).To(Equal(AppStarted))
+			Expect(apps[0].Routes).To(ContainElement("app.example.com"))
+			Expect(apps[0].Environment).To(HaveKeyWithValue("VERSION", "two"))
+		}
+	})
+
+	It("replaces the app of every foundation", func() {
+		old := cloud.Foundation("api1.example.com").AddApp(App{Name: "app", Org: "org", Space: "space", Instances: 1, Routes: []string{"app.example.com"}})
+
+		status, body := deploy()
+		Expect(status).To(Equal(http.StatusOK), body)
+
+		apps := cloud.Foundation("api1.example.com").Apps("org", "space")
+		Expect(apps).To(HaveLen(1))
+		Expect(apps[0].Name).To(Equal("app"))
+		Expect(apps[0].GUID).ToNot(Equal(old.GUID))
+	})
+
+	It("rolls the foundations back when the app fails to stage on one of them", func() {
+		old := cloud.Foundation("api1.example.com").AddApp(App{Name: "app", Org: "org", Space: "space", Instances: 1, Routes: []string{"app.example.com"}})
+		cloud.Foundation("api2.example.com").AddApp(App{Name: "app", Org: "org", Space: "space", Instances: 1, Routes: []string{"app.example.com"}})
+		cloud.Foundation("api2.example.com").FailStaging("app", "no buildpack detected")
+
+		status, body := deploy()
+		Expect(status).To(Equal(http.StatusInternalServerError), body)
+
+		apps := cloud.Foundation("api1.example.com").Apps("org", "space")
+		Expect(apps).To(HaveLen(1))
+		Expect(apps[0].GUID).To(Equal(old.GUID))
+		Expect(apps[0].State).To(Equal(AppStarted))
+	})
+})
@@ -0,0 +1,124 @@
+package testharness
+
+import "fmt"
+
+type UnknownFoundationError struct {
+	URL string
+}
+
+func (e UnknownFoundationError) Error() string {
+	return fmt.Sprintf("API endpoint not found at '%s'", e.URL)
+}
+
+type FoundationDownError struct {
+	URL string
+}
+
+func (e FoundationDownError) Error() string {
+	return fmt.Sprintf("Request error: Get %s/v2/info: dial tcp: connection refused", e.URL)
+}
+
+type InvalidCredentialsError struct {
+	URL      string
+	Username string
+}
+
+func (e InvalidCredentialsError) Error() string {
+	return fmt.Sprintf("Credentials of %s were rejected by %s", e.Username, e.URL)
+}
+
+type NotLoggedInError struct{}
+
+func (e NotLoggedInError) Error() string {
+	return "Not logged in. Use 'cf login' to log in."
+}
+
+type AppNotFoundError struct {
+	AppName string
+}
+
+func (e AppNotFoundError) Error() string {
+	return fmt.Sprintf("App '%s' not found", e.AppName)
+}
+
+type AppExistsError struct {
+	AppName string
+}
+
+func (e AppExistsError) Error() string {
+	return fmt.Sprintf("App with the name '%s' already exists", e.AppName)
+}
+
+type UnknownDomainError struct {
+	Domain string
+}
+
+func (e UnknownDomainError) Error() string {
+	return fmt.Sprintf("Domain '%s' not found", e.Domain)
+}
+
+type ServiceNotFoundError struct {
+	ServiceName string
+}
+
+func (e ServiceNotFoundError) Error() string {
+	return fmt.Sprintf("Service instance '%s' not found", e.ServiceName)
+}
+
+type StagingError struct {
+	AppName string
+	Output  string
+}
+
+func (e StagingError) Error() string {
+	return fmt.Sprintf("staging of %s failed: %s", e.AppName, e.Output)
+}
+
+type InstanceNotFoundError struct {
+	AppName string
+	Index   int
+}
+
+func (e InstanceNotFoundError) Error() string {
+	return fmt.Sprintf("Instance %d of app '%s' not found", e.Index, e.AppName)
+}
+
+type TaskNotFoundError struct {
+	GUID string
+}
+
+func (e TaskNotFoundError) Error() string {
+	return fmt.Sprintf("Task '%s' not found", e.GUID)
+}
+
+type InvalidManifestError struct {
+	Err error
+}
+
+func (e InvalidManifestError) Error() string {
+	return fmt.Sprintf("cannot parse the manifest.yml: %s", e.Err)
+}
+
+type FaultError struct {
+	FoundationURL string
+	Command       Command
+	App           string
+	Output        string
+}
+
+func (e FaultError) Error() string {
+	if e.App == "" {
+		return fmt.Sprintf("%s failed on %s: %s", e.Command, e.FoundationURL, e.Output)
+	}
+	return fmt.Sprintf("%s of %s failed on %s: %s", e.Command, e.App, e.FoundationURL, e.Output)
+}
+
+type AssertionError struct {
+	FoundationURL string
+	App           string
+	Reason        string
+}
+
+func (e AssertionError) Error() string {
+	return fmt.Sprintf("%s on %s: %s", e.App, e.FoundationURL, e.Reason)
+}
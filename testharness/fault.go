@@ -0,0 +1,93 @@
+package testharness
+
+import (
+	"strings"
+)
+
+// Fault fails the runs of a command on a foundation, such as its logins, the pushes or renames of an app, or the
+// health checks of an app.
+type Fault struct {
+	// Command is the command that fails.
+	Command Command
+
+	// App is the app whose commands fail, including its new builds, whose names start with the name of the app and
+	// a dash. The commands of every app fail when it is empty.
+	App string
+
+	// Times is how many runs of the command fail before it succeeds again, which makes it flaky. Every run fails
+	// when it is zero.
+	Times int
+
+	// Output is the output of the failed command, or the body of the response to a failed health check.
+	Output string
+
+	// StatusCode is the status of the response to a failed health check, which is 503 when it is zero.
+	StatusCode int
+}
+
+// Run is a command run on a foundation.
+type Run struct {
+	Command Command
+	App     string
+	Error   error
+}
+
+// fault is an injected Fault with the runs it has left to fail.
+type fault struct {
+	Fault
+	left int
+}
+
+// Inject adds the faults to the foundation.
+func (f *Foundation) Inject(faults ...Fault) {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	for _, injected := range faults {
+		f.faults = append(f.faults, &fault{Fault: injected, left: injected.Times})
+	}
+}
+
+// Commands returns the commands run on the foundation, in the order they were run.
+func (f *Foundation) Commands() []Run {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	return append([]Run{}, f.commands...)
+}
+
+// Ran returns the apps the command was run on, in the order it was run.
+func (f *Foundation) Ran(command Command) []string {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	apps := []string{}
+	for _, run := range f.commands {
+		if run.Command == command {
+			apps = append(apps, run.App)
+		}
+	}
+	return apps
+}
+
+// inject returns the first fault of the command on the app that has runs left to fail, and its error.
+func (f *Foundation) inject(command Command, app string) (*fault, error) {
+	for _, injected := range f.faults {
+		if injected.Command != command || (injected.App != "" && !buildOf(app, injected.App)) {
+			continue
+		}
+		if injected.Times > 0 {
+			if injected.left == 0 {
+				continue
+			}
+			injected.left--
+		}
+		return injected, FaultError{f.url, command, app, injected.Output}
+	}
+	return nil, nil
+}
+
+// buildOf reports whether the app is the named app or one of its builds.
+func buildOf(app, name string) bool {
+	return app == name || strings.HasPrefix(app, name+"-")
+}
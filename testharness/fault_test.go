@@ -0,0 +1,76 @@
+package testharness_test
+
+import (
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/testharness"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Faults", func() {
+	var (
+		foundation *Foundation
+		courier    I.Courier
+	)
+
+	BeforeEach(func() {
+		cloud := NewCloud()
+		foundation = cloud.AddFoundation("api1.example.com", "example.com")
+		foundation.AddApp(App{Name: "app", Org: "org", Space: "space", Instances: 1})
+		foundation.AddApp(App{Name: "app-new-build-1234", Org: "org", Space: "space", Instances: 1})
+		foundation.AddApp(App{Name: "other", Org: "org", Space: "space", Instances: 1})
+		courier = cloud.NewCourier(nil)
+	})
+
+	It("fail the logins to the foundation", func() {
+		foundation.Inject(Fault{Command: Login, Output: "503 Service Unavailable"})
+
+		_, err := courier.Login("api1.example.com", "username", "password", "org", "space", false)
+
+		Expect(err).To(MatchError(FaultError{"api1.example.com", Login, "", "503 Service Unavailable"}))
+	})
+
+	Context("once logged in", func() {
+		BeforeEach(func() {
+			Expect(courier.Login("api1.example.com", "username", "password", "org", "space", false)).ToNot(BeEmpty())
+		})
+
+		It("fail the command on the app and its new builds with the output", func() {
+			foundation.Inject(Fault{Command: MapRoute, App: "app", Output: "route is taken"})
+
+			out, err := courier.MapRoute("app-new-build-1234", "example.com", "app")
+			Expect(err).To(MatchError(FaultError{"api1.example.com", MapRoute, "app-new-build-1234", "route is taken"}))
+			Expect(string(out)).To(Equal("route is taken"))
+
+			Expect(courier.MapRoute("other", "example.com", "other")).To(ContainSubstring("OK"))
+
+			app, _ := foundation.App("org", "space", "app-new-build-1234")
+			Expect(app.Routes).To(BeEmpty())
+		})
+
+		It("fail the first times the command runs when they are flaky", func() {
+			foundation.Inject(Fault{Command: Rename, Times: 2, Output: "timeout"})
+
+			_, err := courier.Rename("other", "another")
+			Expect(err).To(HaveOccurred())
+			_, err = courier.Rename("other", "another")
+			Expect(err).To(HaveOccurred())
+			_, err = courier.Rename("other", "another")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("are recorded with the commands of the foundation", func() {
+			foundation.Inject(Fault{Command: Stop, Output: "timeout"})
+
+			courier.Stop("app")
+			courier.Start("app")
+
+			Expect(foundation.Commands()).To(Equal([]Run{
+				{Command: Login},
+				{Command: Stop, App: "app", Error: FaultError{"api1.example.com", Stop, "app", "timeout"}},
+				{Command: Start, App: "app"},
+			}))
+			Expect(foundation.Ran(Start)).To(Equal([]string{"app"}))
+		})
+	})
+})
@@ -0,0 +1,94 @@
+package testharness
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// HealthCheckClient returns a client whose requests are answered by the apps of the Cloud the URL is routed to,
+// so it can replace the client of a health checker. A request fails with 404 when no started app is routed to
+// the URL, with 502 when every instance of the app crashed, and with a HealthCheck fault of its foundation.
+// The foundations need different domains for the routes of their apps to be told apart.
+func (c *Cloud) HealthCheckClient() I.Client {
+	return healthCheckClient{c}
+}
+
+type healthCheckClient struct {
+	cloud *Cloud
+}
+
+// Get answers the request with the app the URL is routed to.
+func (h healthCheckClient) Get(rawURL string) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	r := u.Host + strings.TrimSuffix(u.Path, "/")
+
+	f, a := h.cloud.routed(r)
+	if f == nil {
+		return response(http.StatusNotFound, "404 Not Found: Requested route ('"+u.Host+"') does not exist."), nil
+	}
+
+	f.cloud.mu.Lock()
+	latency := f.latency[HealthCheck]
+	f.cloud.mu.Unlock()
+	time.Sleep(latency)
+
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	injected, err := f.inject(HealthCheck, a.Name)
+	f.commands = append(f.commands, Run{Command: HealthCheck, App: a.Name, Error: err})
+	if injected != nil {
+		status := injected.StatusCode
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		return response(status, injected.Output), nil
+	}
+
+	switch {
+	case a.State != AppStarted:
+		return response(http.StatusNotFound, "404 Not Found: Requested route ('"+u.Host+"') does not exist."), nil
+	case len(a.Crashed) >= int(a.Instances):
+		return response(http.StatusBadGateway, "502 Bad Gateway: Registered endpoint failed to handle the request."), nil
+	}
+	return response(http.StatusOK, "ok"), nil
+}
+
+// routed returns the foundation and app with the longest route the URL without its scheme starts with.
+func (c *Cloud) routed(r string) (*Foundation, *App) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		foundation *Foundation
+		app        *App
+		longest    int
+	)
+	for _, f := range c.foundations {
+		for _, a := range f.apps {
+			for _, route := range a.Routes {
+				if (r == route || strings.HasPrefix(r, route+"/")) && len(route) > longest {
+					foundation, app, longest = f, a, len(route)
+				}
+			}
+		}
+	}
+	return foundation, app
+}
+
+func response(status int, body string) *http.Response {
+	return &http.Response{
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
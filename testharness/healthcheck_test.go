@@ -0,0 +1,73 @@
+package testharness_test
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/testharness"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HealthCheckClient", func() {
+	var (
+		foundation *Foundation
+		client     I.Client
+	)
+
+	BeforeEach(func() {
+		cloud := NewCloud()
+		foundation = cloud.AddFoundation("api1.example.com", "apps1.example.com")
+		foundation.AddApp(App{Name: "app", Org: "org", Space: "space", Instances: 2, Routes: []string{"app.apps1.example.com"}})
+		client = cloud.HealthCheckClient()
+	})
+
+	get := func(url string) (int, string) {
+		resp, err := client.Get(url)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return resp.StatusCode, string(body)
+	}
+
+	status := func(url string) int {
+		code, _ := get(url)
+		return code
+	}
+
+	It("answers with the app the url is routed to", func() {
+		Expect(status("https://app.apps1.example.com/health")).To(Equal(http.StatusOK))
+		Expect(foundation.Ran(HealthCheck)).To(Equal([]string{"app"}))
+	})
+
+	It("does not find a route no app is routed to", func() {
+		Expect(status("https://other.apps1.example.com/health")).To(Equal(http.StatusNotFound))
+	})
+
+	It("does not find a stopped app", func() {
+		foundation.AddApp(App{Name: "stopped", Org: "org", Space: "space", Instances: 1, State: AppStopped, Routes: []string{"stopped.apps1.example.com"}})
+
+		Expect(status("https://stopped.apps1.example.com/health")).To(Equal(http.StatusNotFound))
+	})
+
+	It("fails once every instance crashed", func() {
+		Expect(foundation.Crash("org", "space", "app", 0)).To(Succeed())
+		Expect(status("https://app.apps1.example.com/health")).To(Equal(http.StatusOK))
+
+		Expect(foundation.Crash("org", "space", "app", 1)).To(Succeed())
+		Expect(status("https://app.apps1.example.com/health")).To(Equal(http.StatusBadGateway))
+	})
+
+	It("fails with the health check faults of the foundation", func() {
+		foundation.Inject(Fault{Command: HealthCheck, App: "app", Times: 1, StatusCode: http.StatusInternalServerError, Output: "database is down"})
+
+		code, body := get("https://app.apps1.example.com/health")
+		Expect(code).To(Equal(http.StatusInternalServerError))
+		Expect(body).To(Equal("database is down"))
+
+		Expect(status("https://app.apps1.example.com/health")).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,48 @@
+package testharness
+
+import "time"
+
+// Scenario describes a Cloud: its foundations, the apps already running on them and the faults injected into them.
+type Scenario struct {
+	Foundations []FoundationScenario
+}
+
+// FoundationScenario describes a foundation of a Scenario.
+type FoundationScenario struct {
+	URL     string
+	Domains []string
+
+	// Apps are running on the foundation before the deployment.
+	Apps []App
+
+	// Down makes the logins to the foundation fail, as if it could not be reached.
+	Down bool
+
+	// Latency delays the runs of the commands, such as a slow staging with a latency of Push.
+	Latency map[Command]time.Duration
+
+	// StagingFailures are the outputs of the failed stagings of apps, keyed by the names of the apps.
+	StagingFailures map[string]string
+
+	Faults []Fault
+}
+
+// Cloud returns a Cloud with the foundations of the scenario.
+func (s Scenario) Cloud() *Cloud {
+	c := NewCloud()
+	for _, described := range s.Foundations {
+		f := c.AddFoundation(described.URL, described.Domains...)
+		for _, app := range described.Apps {
+			f.AddApp(app)
+		}
+		f.SetDown(described.Down)
+		for command, latency := range described.Latency {
+			f.SetLatency(command, latency)
+		}
+		for appName, output := range described.StagingFailures {
+			f.FailStaging(appName, output)
+		}
+		f.Inject(described.Faults...)
+	}
+	return c
+}
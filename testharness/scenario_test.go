@@ -0,0 +1,216 @@
+package testharness_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/compozed/deployadactyl/creator"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/compozed/deployadactyl/testharness"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Scenario", func() {
+	const (
+		one    = "https://api.cf.one.example.com"
+		two    = "https://api.cf.two.example.com"
+		config = `---
+environments:
+- name: test
+  domain: example.com
+  instances: 1
+  rollback_enabled: true
+  foundations:
+  - https://api.cf.one.example.com
+  - https://api.cf.two.example.com
+`
+	)
+
+	var (
+		scenario Scenario
+		cloud    *Cloud
+		dir      string
+		existing = App{Name: "app", Org: "org", Space: "space", Instances: 1, Routes: []string{"app.example.com"}}
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "testharness-")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "config.yml"), []byte(config), 0600)).To(Succeed())
+
+		scenario = Scenario{Foundations: []FoundationScenario{
+			{URL: one, Domains: []string{"apps.one.example.com", "example.com"}},
+			{URL: two, Domains: []string{"apps.two.example.com", "example.com"}},
+		}}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	// deploy deploys the app to the Cloud of the scenario with a health check, and returns the status code.
+	deploy := func() int {
+		c, err := creator.Custom("DEBUG", filepath.Join(dir, "config.yml"), creator.CreatorModuleProvider{
+			NewCourier: cloud.NewCourier,
+			NewPrechecker: func(I.EventManager) I.Prechecker {
+				return &mocks.Prechecker{}
+			},
+			NewFetcher: func(fs *afero.Afero, ex I.Extractor, log I.DeploymentLogger, verifier I.ArtifactVerifier, caBundle string, buckets S.BucketsDescriptor, artifactory S.ArtifactoryDescriptor, maven S.MavenDescriptor, workDir string) I.Fetcher {
+				appPath, _ := ioutil.TempDir(dir, "app-")
+				fetcher := &mocks.Fetcher{}
+				fetcher.FetchCall.Returns.AppPath = appPath
+				return fetcher
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		healthChecker := c.CreateHealthChecker()
+		healthChecker.Client = cloud.HealthCheckClient()
+		c.CreateEventManager().AddBinding(push.NewPushFinishedEventBinding(healthChecker.PushFinishedEventHandler))
+
+		server := httptest.NewServer(c.CreateControllerHandler(c.CreateController()))
+		defer server.Close()
+
+		resp, err := http.Post(fmt.Sprintf("%s/v3/apps/test/org/space/app", server.URL), "application/json", bytes.NewBufferString(`{"artifact_url": "https://example.com/app.jar", "health_check_endpoint": "/health"}`))
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	It("replaces the apps of every foundation", func() {
+		scenario.Foundations[0].Apps = []App{existing}
+		scenario.Foundations[1].Apps = []App{existing}
+		cloud = scenario.Cloud()
+		before, _ := cloud.Foundation(one).App("org", "space", "app")
+
+		Expect(deploy()).To(Equal(http.StatusOK))
+
+		Expect(cloud.Foundation(one).AssertReplaced("org", "space", before)).To(Succeed())
+		Expect(cloud.Foundation(one).AssertRolledBack("org", "space", before)).To(MatchError(AssertionError{one, "app", "was replaced"}))
+		Expect(cloud.Foundation(two).Ran(HealthCheck)).To(HaveLen(1))
+	})
+
+	Context("when a foundation fails", func() {
+		var before map[string]App
+
+		BeforeEach(func() {
+			scenario.Foundations[0].Apps = []App{existing}
+			scenario.Foundations[1].Apps = []App{existing}
+		})
+
+		JustBeforeEach(func() {
+			cloud = scenario.Cloud()
+			before = map[string]App{}
+			for _, foundationURL := range []string{one, two} {
+				before[foundationURL], _ = cloud.Foundation(foundationURL).App("org", "space", "app")
+			}
+		})
+
+		rolledBack := func() {
+			for _, foundationURL := range []string{one, two} {
+				Expect(cloud.Foundation(foundationURL).AssertRolledBack("org", "space", before[foundationURL])).To(Succeed())
+			}
+		}
+
+		Context("to log in", func() {
+			BeforeEach(func() {
+				scenario.Foundations[1].Faults = []Fault{{Command: Login, Output: "503 Service Unavailable"}}
+			})
+
+			It("does not push to any foundation", func() {
+				Expect(deploy()).To(Equal(http.StatusBadRequest))
+				rolledBack()
+				Expect(cloud.Foundation(one).Ran(Push)).To(BeEmpty())
+			})
+		})
+
+		Context("to push", func() {
+			BeforeEach(func() {
+				scenario.Foundations[1].Faults = []Fault{{Command: Push, App: "app", Output: "insufficient resources"}}
+			})
+
+			It("rolls back every foundation", func() {
+				Expect(deploy()).To(Equal(http.StatusInternalServerError))
+				rolledBack()
+			})
+		})
+
+		Context("to stage", func() {
+			BeforeEach(func() {
+				scenario.Foundations[1].StagingFailures = map[string]string{"app": "no buildpack detected"}
+			})
+
+			It("rolls back every foundation", func() {
+				Expect(deploy()).To(Equal(http.StatusInternalServerError))
+				rolledBack()
+			})
+		})
+
+		Context("to map the route", func() {
+			BeforeEach(func() {
+				scenario.Foundations[1].Faults = []Fault{{Command: MapRoute, App: "app", Output: "route is taken"}}
+			})
+
+			It("rolls back every foundation", func() {
+				Expect(deploy()).To(Equal(http.StatusInternalServerError))
+				rolledBack()
+			})
+		})
+
+		Context("to pass the health check once", func() {
+			BeforeEach(func() {
+				scenario.Foundations[0].Faults = []Fault{{Command: HealthCheck, App: "app", Times: 1, StatusCode: http.StatusInternalServerError}}
+			})
+
+			It("rolls back every foundation, and succeeds the next time", func() {
+				Expect(deploy()).To(Equal(http.StatusInternalServerError))
+				rolledBack()
+
+				Expect(deploy()).To(Equal(http.StatusOK))
+				for _, foundationURL := range []string{one, two} {
+					Expect(cloud.Foundation(foundationURL).AssertReplaced("org", "space", before[foundationURL])).To(Succeed())
+				}
+			})
+		})
+
+		Context("to rename the new build after a slow staging", func() {
+			BeforeEach(func() {
+				scenario.Foundations[0].Latency = map[Command]time.Duration{Push: 50 * time.Millisecond}
+				scenario.Foundations[0].Faults = []Fault{{Command: Rename, App: "app-new-build", Output: "timeout"}}
+			})
+
+			It("succeeds partially, as the foundations are no longer rolled back", func() {
+				Expect(deploy()).To(Equal(http.StatusMultiStatus))
+
+				Expect(cloud.Foundation(one).AssertDeployed("org", "space", "app")).ToNot(Succeed())
+				Expect(cloud.Foundation(two).AssertReplaced("org", "space", before[two])).To(Succeed())
+			})
+		})
+	})
+
+	Context("when a new app fails to deploy to a foundation", func() {
+		BeforeEach(func() {
+			scenario.Foundations[1].Faults = []Fault{{Command: Push, App: "app", Output: "insufficient resources"}}
+			cloud = scenario.Cloud()
+		})
+
+		It("keeps it on the other foundations", func() {
+			Expect(deploy()).To(Equal(http.StatusInternalServerError))
+
+			Expect(cloud.Foundation(one).AssertDeployed("org", "space", "app")).To(Succeed())
+			Expect(cloud.Foundation(two).AssertNotDeployed("org", "space", "app")).To(Succeed())
+		})
+	})
+})
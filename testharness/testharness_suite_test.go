@@ -0,0 +1,21 @@
+package testharness_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestTestharness(t *testing.T) {
+	RegisterFailHandler(Fail)
+	gin.SetMode(gin.TestMode)
+	RunSpecs(t, "Testharness Suite")
+}
+
+var _ = BeforeSuite(func() {
+	os.Setenv("CF_USERNAME", "username")
+	os.Setenv("CF_PASSWORD", "password")
+})
@@ -0,0 +1,27 @@
+package tlspin
+
+import "fmt"
+
+type PinMismatchError struct {
+	Host string
+}
+
+func (e PinMismatchError) Error() string {
+	return fmt.Sprintf("the certificate of %s does not match any of its pins", e.Host)
+}
+
+type InvalidPinError struct {
+	Pin string
+}
+
+func (e InvalidPinError) Error() string {
+	return fmt.Sprintf("invalid certificate pin %s: expected sha256/<base64> or sha256:<hex>", e.Pin)
+}
+
+type PlaintextError struct {
+	URL string
+}
+
+func (e PlaintextError) Error() string {
+	return fmt.Sprintf("%s is pinned but does not use https", e.URL)
+}
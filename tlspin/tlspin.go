@@ -0,0 +1,203 @@
+// Package tlspin verifies that TLS servers present a pinned certificate or public key.
+package tlspin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	spkiPrefix        = "sha256/"
+	fingerprintPrefix = "sha256:"
+
+	dialTimeout = 30 * time.Second
+)
+
+// Pins maps a foundation URL or host to the pins one of its certificates must match.
+// A host starting with "*." matches every direct subdomain, such as the routes of pushed applications.
+//
+// A pin is either "sha256/<base64>", the hash of the subject public key info of a certificate
+// as used by HPKP, or "sha256:<hex>", the fingerprint of the whole certificate. Pins are checked
+// even when certificate verification is skipped.
+type Pins map[string][]string
+
+// Validate returns an InvalidPinError for the first pin that can not be parsed.
+func (p Pins) Validate() error {
+	for _, pins := range p {
+		for _, pin := range pins {
+			_, _, err := parsePin(pin)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// For returns the pins of the host of a URL, or nil when the host is not pinned.
+func (p Pins) For(rawURL string) []string {
+	host := hostOf(rawURL)
+	if host == "" {
+		return nil
+	}
+
+	for key, pins := range p {
+		if hostOf(key) == host {
+			return pins
+		}
+	}
+
+	for key, pins := range p {
+		pattern := hostOf(key)
+		if !strings.HasPrefix(pattern, "*.") {
+			continue
+		}
+		label := strings.TrimSuffix(host, pattern[1:])
+		if label != host && label != "" && !strings.Contains(label, ".") {
+			return pins
+		}
+	}
+
+	return nil
+}
+
+// Verify returns a PinMismatchError unless one of the certificates matches one of the pins of the host.
+func (p Pins) Verify(host string, certificates []*x509.Certificate) error {
+	pins := p.For(host)
+	if pins == nil {
+		return nil
+	}
+
+	for _, pin := range pins {
+		spki, digest, err := parsePin(pin)
+		if err != nil {
+			return err
+		}
+
+		for _, certificate := range certificates {
+			var sum [sha256.Size]byte
+			if spki {
+				sum = sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+			} else {
+				sum = sha256.Sum256(certificate.Raw)
+			}
+			if bytes.Equal(sum[:], digest) {
+				return nil
+			}
+		}
+	}
+
+	return PinMismatchError{Host: hostOf(host)}
+}
+
+// DialTLS returns a function for http.Transport.DialTLS that verifies the pins of the host
+// after the handshake. config may be nil.
+func (p Pins) DialTLS(config *tls.Config) func(network, addr string) (net.Conn, error) {
+	return func(network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return p.dial(network, addr, host, config)
+	}
+}
+
+// Check connects to the server of a URL and verifies its pins. Hosts without pins are not contacted.
+// config may be nil.
+func (p Pins) Check(rawURL string, config *tls.Config) error {
+	if p.For(rawURL) == nil {
+		return nil
+	}
+
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" {
+		return PlaintextError{URL: rawURL}
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	conn, err := p.dial("tcp", net.JoinHostPort(u.Hostname(), port), u.Hostname(), config)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (p Pins) dial(network, addr, host string, config *tls.Config) (net.Conn, error) {
+	if config == nil {
+		config = &tls.Config{}
+	}
+	config = config.Clone()
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, network, addr, config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.Verify(host, conn.ConnectionState().PeerCertificates)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// parsePin returns whether the pin is a public key hash and the decoded digest.
+func parsePin(pin string) (spki bool, digest []byte, err error) {
+	switch {
+	case strings.HasPrefix(pin, spkiPrefix):
+		spki = true
+		digest, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, spkiPrefix))
+	case strings.HasPrefix(pin, fingerprintPrefix):
+		digest, err = hex.DecodeString(strings.Replace(strings.TrimPrefix(pin, fingerprintPrefix), ":", "", -1))
+	default:
+		return false, nil, InvalidPinError{Pin: pin}
+	}
+
+	if err != nil || len(digest) != sha256.Size {
+		return false, nil, InvalidPinError{Pin: pin}
+	}
+	return spki, digest, nil
+}
+
+// hostOf returns the lower case host name of a URL, a host:port pair or a host.
+func hostOf(s string) string {
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return ""
+		}
+		return strings.ToLower(u.Hostname())
+	}
+
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	return strings.ToLower(s)
+}
+
+func parseURL(rawURL string) (*url.URL, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	return url.Parse(rawURL)
+}
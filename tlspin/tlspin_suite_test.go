@@ -0,0 +1,13 @@
+package tlspin_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTlspin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tlspin Suite")
+}
@@ -0,0 +1,152 @@
+package tlspin_test
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/compozed/deployadactyl/tlspin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pins", func() {
+	var (
+		server      *httptest.Server
+		certificate *x509.Certificate
+		spkiPin     string
+		certPin     string
+		otherPin    string
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		var err error
+		certificate, err = x509.ParseCertificate(server.TLS.Certificates[0].Certificate[0])
+		Expect(err).ToNot(HaveOccurred())
+
+		spki := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+		spkiPin = "sha256/" + base64.StdEncoding.EncodeToString(spki[:])
+
+		fingerprint := sha256.Sum256(certificate.Raw)
+		certPin = "sha256:" + strings.ToUpper(hex.EncodeToString(fingerprint[:]))
+
+		other := sha256.Sum256([]byte("another key"))
+		otherPin = "sha256/" + base64.StdEncoding.EncodeToString(other[:])
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Validate", func() {
+		It("accepts public key hashes and certificate fingerprints", func() {
+			Expect(Pins{"api.example.com": {spkiPin, certPin}}.Validate()).To(Succeed())
+		})
+
+		It("returns an error for a pin with an unknown algorithm", func() {
+			Expect(Pins{"api.example.com": {"sha1/abc"}}.Validate()).To(MatchError(InvalidPinError{Pin: "sha1/abc"}))
+		})
+
+		It("returns an error for a pin of the wrong length", func() {
+			Expect(Pins{"api.example.com": {"sha256:abcd"}}.Validate()).To(MatchError(InvalidPinError{Pin: "sha256:abcd"}))
+		})
+	})
+
+	Describe("For", func() {
+		pins := Pins{
+			"https://api.example.com":   {"api"},
+			"*.apps.example.com":        {"apps"},
+			"login.example.com:8443":    {"login"},
+			"https://API.Other.example": {"other"},
+		}
+
+		It("looks up pins by the host of a url", func() {
+			Expect(pins.For("https://api.example.com/v2/info")).To(Equal([]string{"api"}))
+			Expect(pins.For("api.example.com")).To(Equal([]string{"api"}))
+			Expect(pins.For("https://login.example.com")).To(Equal([]string{"login"}))
+			Expect(pins.For("https://api.other.example")).To(Equal([]string{"other"}))
+		})
+
+		It("matches direct subdomains of wildcard hosts", func() {
+			Expect(pins.For("https://my-app.apps.example.com/health")).To(Equal([]string{"apps"}))
+			Expect(pins.For("https://a.b.apps.example.com")).To(BeNil())
+			Expect(pins.For("https://apps.example.com")).To(BeNil())
+		})
+
+		It("returns nil for hosts that are not pinned", func() {
+			Expect(pins.For("https://example.com")).To(BeNil())
+		})
+	})
+
+	Describe("Check", func() {
+		insecure := &tls.Config{InsecureSkipVerify: true}
+
+		It("succeeds when the public key is pinned", func() {
+			Expect(Pins{server.URL: {otherPin, spkiPin}}.Check(server.URL, insecure)).To(Succeed())
+		})
+
+		It("succeeds when the certificate is pinned", func() {
+			Expect(Pins{server.URL: {certPin}}.Check(server.URL, insecure)).To(Succeed())
+		})
+
+		It("returns an error when no pin matches, even though verification is skipped", func() {
+			err := Pins{server.URL: {otherPin}}.Check(server.URL, insecure)
+
+			Expect(err).To(MatchError(PinMismatchError{Host: "127.0.0.1"}))
+		})
+
+		It("verifies the certificate chain unless verification is skipped", func() {
+			err := Pins{server.URL: {spkiPin}}.Check(server.URL, nil)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err).ToNot(BeAssignableToTypeOf(PinMismatchError{}))
+		})
+
+		It("does not contact hosts without pins", func() {
+			Expect(Pins{}.Check("https://unreachable.invalid", nil)).To(Succeed())
+		})
+
+		It("returns an error when a pinned host is not using https", func() {
+			url := strings.Replace(server.URL, "https", "http", 1)
+
+			Expect(Pins{server.URL: {spkiPin}}.Check(url, insecure)).To(MatchError(PlaintextError{URL: url}))
+		})
+	})
+
+	Describe("DialTLS", func() {
+		client := func(pins Pins) *http.Client {
+			config := &tls.Config{InsecureSkipVerify: true}
+			return &http.Client{Transport: &http.Transport{TLSClientConfig: config, DialTLS: pins.DialTLS(config)}}
+		}
+
+		It("allows requests to a server with a pinned certificate", func() {
+			resp, err := client(Pins{server.URL: {spkiPin}}).Get(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("rejects requests to a server whose certificate does not match", func() {
+			_, err := client(Pins{server.URL: {otherPin}}).Get(server.URL)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(PinMismatchError{Host: "127.0.0.1"}.Error()))
+		})
+
+		It("allows requests to hosts without pins", func() {
+			resp, err := client(Pins{"https://api.example.com": {otherPin}}).Get(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+		})
+	})
+})
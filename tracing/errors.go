@@ -0,0 +1,29 @@
+package tracing
+
+import "fmt"
+
+type InvalidEndpointError struct {
+	Endpoint string
+}
+
+func (e InvalidEndpointError) Error() string {
+	return fmt.Sprintf("the tracing endpoint must be an http or https url: %s", e.Endpoint)
+}
+
+type InvalidTimeoutError struct {
+	Seconds int
+}
+
+func (e InvalidTimeoutError) Error() string {
+	return fmt.Sprintf("the timeout of the tracing export must not be negative: %d", e.Seconds)
+}
+
+type ExportError struct {
+	Endpoint   string
+	StatusCode int
+	Message    string
+}
+
+func (e ExportError) Error() string {
+	return fmt.Sprintf("%s rejected the spans with status %d: %s", e.Endpoint, e.StatusCode, e.Message)
+}
@@ -0,0 +1,227 @@
+package tracing
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/compozed/deployadactyl/cabundle"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// DefaultServiceName is the service.name of the spans when the configuration has none.
+const DefaultServiceName = "deployadactyl"
+
+// DefaultTimeout is how long an export may take when the configuration has no timeout.
+const DefaultTimeout = 10 * time.Second
+
+// scopeName is the instrumentation scope of the spans.
+const scopeName = "github.com/compozed/deployadactyl/tracing"
+
+// The span kind and status code of OTLP.
+const (
+	spanKindInternal = 1
+	statusCodeError  = 2
+)
+
+// Validate returns an error when the endpoint is not an http or https URL, or the timeout is negative.
+func Validate(descriptor S.TracingDescriptor) error {
+	if descriptor.Endpoint == "" {
+		return nil
+	}
+
+	endpoint, err := url.Parse(descriptor.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return InvalidEndpointError{descriptor.Endpoint}
+	}
+	if descriptor.TimeoutSeconds < 0 {
+		return InvalidTimeoutError{descriptor.TimeoutSeconds}
+	}
+	return nil
+}
+
+// New returns the Tracer of the descriptor, exporting to its endpoint with OTLP. It is nil without an endpoint, so
+// deployments are not traced.
+func New(descriptor S.TracingDescriptor, fileSystem *afero.Afero, log I.Logger) (*Tracer, error) {
+	if descriptor.Endpoint == "" {
+		return nil, nil
+	}
+
+	tlsConfig, err := cabundle.TLSConfig(fileSystem, descriptor.CABundle)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.InsecureSkipVerify = descriptor.SkipSSL
+
+	timeout := DefaultTimeout
+	if descriptor.TimeoutSeconds > 0 {
+		timeout = time.Duration(descriptor.TimeoutSeconds) * time.Second
+	}
+
+	exporter := NewOTLPExporter(descriptor.Endpoint, descriptor.ServiceName)
+	exporter.Headers = descriptor.Headers
+	exporter.Client = &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+	}
+	return NewTracer(exporter, log), nil
+}
+
+// NewOTLPExporter returns an OTLPExporter posting to the endpoint. An empty service name is DefaultServiceName.
+func NewOTLPExporter(endpoint, serviceName string) *OTLPExporter {
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+
+	return &OTLPExporter{
+		Endpoint:    endpoint,
+		ServiceName: serviceName,
+		Client:      &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// OTLPExporter posts the spans as the JSON encoding of an OTLP ExportTraceServiceRequest to the Endpoint of an
+// OpenTelemetry collector, with the Headers.
+type OTLPExporter struct {
+	Endpoint    string
+	ServiceName string
+	Headers     map[string]string
+	Client      *http.Client
+}
+
+// Export posts the spans, and fails unless the collector accepts them.
+func (e *OTLPExporter) Export(spans []*Span) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("POST", e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range e.Headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := e.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		return ExportError{e.Endpoint, response.StatusCode, string(bytes.TrimSpace(message))}
+	}
+	io.Copy(ioutil.Discard, response.Body)
+	return nil
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue is an AnyValue of OTLP, whose 64 bit integers are encoded as strings.
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func (e *OTLPExporter) request(spans []*Span) otlpRequest {
+	scopeSpans := otlpScopeSpans{Scope: otlpScope{Name: scopeName}}
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentSpanID,
+			Name:              span.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        attributesOf(span.Attributes),
+		}
+		if span.Failed {
+			s.Status = &otlpStatus{Code: statusCodeError, Message: span.Error}
+		}
+		scopeSpans.Spans = append(scopeSpans.Spans, s)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: attributesOf([]Attribute{String("service.name", e.ServiceName)})},
+		ScopeSpans: []otlpScopeSpans{scopeSpans},
+	}}}
+}
+
+func attributesOf(attributes []Attribute) []otlpAttribute {
+	var converted []otlpAttribute
+	for _, attribute := range attributes {
+		var value otlpValue
+		switch v := attribute.Value.(type) {
+		case string:
+			value.StringValue = &v
+		case int:
+			i := strconv.Itoa(v)
+			value.IntValue = &i
+		case bool:
+			value.BoolValue = &v
+		default:
+			continue
+		}
+		converted = append(converted, otlpAttribute{attribute.Key, value})
+	}
+	return converted
+}
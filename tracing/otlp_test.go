@@ -0,0 +1,152 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/compozed/deployadactyl/tracing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Validate", func() {
+	It("accepts no endpoint", func() {
+		Expect(Validate(S.TracingDescriptor{})).To(Succeed())
+	})
+
+	It("accepts an http endpoint", func() {
+		Expect(Validate(S.TracingDescriptor{Endpoint: "http://collector:4318/v1/traces"})).To(Succeed())
+	})
+
+	It("rejects an endpoint that is not an http url", func() {
+		err := Validate(S.TracingDescriptor{Endpoint: "collector:4317"})
+
+		Expect(err).To(MatchError(InvalidEndpointError{"collector:4317"}))
+	})
+
+	It("rejects a negative timeout", func() {
+		err := Validate(S.TracingDescriptor{Endpoint: "https://collector/v1/traces", TimeoutSeconds: -1})
+
+		Expect(err).To(MatchError(InvalidTimeoutError{-1}))
+	})
+})
+
+var _ = Describe("OTLPExporter", func() {
+	var (
+		server   *httptest.Server
+		requests chan *http.Request
+		bodies   chan []byte
+		status   int
+		tracer   *Tracer
+	)
+
+	BeforeEach(func() {
+		requests = make(chan *http.Request, 1)
+		bodies = make(chan []byte, 1)
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requests <- r
+			bodies <- body
+			w.WriteHeader(status)
+			w.Write([]byte("bad spans\n"))
+		}))
+
+		var err error
+		tracer, err = New(S.TracingDescriptor{
+			Endpoint:    server.URL + "/v1/traces",
+			Headers:     map[string]string{"X-Api-Key": "secret"},
+			ServiceName: "deployadactyl-test",
+		}, &afero.Afero{Fs: afero.NewMemMapFs()}, I.DefaultLogger(NewBuffer(), logging.DEBUG, "tracing_test"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("does not trace without an endpoint", func() {
+		tracer, err := New(S.TracingDescriptor{}, &afero.Afero{Fs: afero.NewMemMapFs()}, I.DefaultLogger(NewBuffer(), logging.DEBUG, "tracing_test"))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tracer).To(BeNil())
+	})
+
+	It("posts the spans as OTLP JSON", func() {
+		ctx := WithTracer(context.Background(), tracer, String("deployment.uuid", "the-uuid"))
+		_, span := Start(ctx, "deployer", Int("foundations", 2), Bool("canary", false))
+		span.Start = time.Unix(1, 500)
+		span.SetError(errors.New("push failed"))
+		span.End = time.Unix(2, 0)
+
+		Expect(tracer.Exporter.Export([]*Span{span})).To(Succeed())
+
+		var request *http.Request
+		Eventually(requests).Should(Receive(&request))
+		Expect(request.Method).To(Equal("POST"))
+		Expect(request.URL.Path).To(Equal("/v1/traces"))
+		Expect(request.Header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(request.Header.Get("X-Api-Key")).To(Equal("secret"))
+
+		var body []byte
+		Eventually(bodies).Should(Receive(&body))
+		Expect(body).To(MatchJSON(`{"resourceSpans": [{
+			"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "deployadactyl-test"}}]},
+			"scopeSpans": [{
+				"scope": {"name": "github.com/compozed/deployadactyl/tracing"},
+				"spans": [{
+					"traceId": "` + span.TraceID + `",
+					"spanId": "` + span.SpanID + `",
+					"name": "deployer",
+					"kind": 1,
+					"startTimeUnixNano": "1000000500",
+					"endTimeUnixNano": "2000000000",
+					"attributes": [
+						{"key": "deployment.uuid", "value": {"stringValue": "the-uuid"}},
+						{"key": "foundations", "value": {"intValue": "2"}},
+						{"key": "canary", "value": {"boolValue": false}}
+					],
+					"status": {"code": 2, "message": "push failed"}
+				}]
+			}]
+		}]}`))
+	})
+
+	It("sends the parent of a span", func() {
+		ctx := WithTracer(context.Background(), tracer)
+		parentCtx, parent := Start(ctx, "controller")
+		_, child := Start(parentCtx, "deployer")
+
+		Expect(tracer.Exporter.Export([]*Span{child})).To(Succeed())
+
+		var body []byte
+		Eventually(bodies).Should(Receive(&body))
+		var request struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []map[string]interface{}
+				}
+			}
+		}
+		Expect(json.Unmarshal(body, &request)).To(Succeed())
+		Expect(request.ResourceSpans[0].ScopeSpans[0].Spans[0]["parentSpanId"]).To(Equal(parent.SpanID))
+	})
+
+	It("fails when the collector rejects the spans", func() {
+		status = http.StatusBadRequest
+
+		err := tracer.Exporter.Export([]*Span{})
+
+		Expect(err).To(MatchError(ExportError{server.URL + "/v1/traces", http.StatusBadRequest, "bad spans"}))
+	})
+})
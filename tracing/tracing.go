@@ -0,0 +1,256 @@
+// Package tracing records the spans of a deployment, from the request to the controller down to the commands and
+// requests sent to Cloud Foundry, and exports them to an OpenTelemetry collector, so a slow deployment shows where it
+// spends its time.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// QueueSize is how many ended spans can wait to be exported. Further spans are dropped while the queue is full, so a
+// slow or unreachable collector never holds up a deployment.
+const QueueSize = 1000
+
+// BatchSize is the most spans exported at once.
+const BatchSize = 100
+
+// DefaultInterval is how often the ended spans are exported.
+const DefaultInterval = 5 * time.Second
+
+// Attribute is a key and a string, int or bool value describing a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{key, value}
+}
+
+// Int returns an int attribute.
+func Int(key string, value int) Attribute {
+	return Attribute{key, value}
+}
+
+// Bool returns a bool attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{key, value}
+}
+
+// Exporter sends ended spans to a tracing backend. It is only used by one goroutine at a time.
+type Exporter interface {
+	Export(spans []*Span) error
+}
+
+// Span is a timed operation of a trace. Its ids are hex encoded, and it has no ParentSpanID when it is the root of
+// the trace.
+//
+// The methods of a nil Span do nothing, so the code of a deployment does not have to know whether it is traced.
+type Span struct {
+	Name         string
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Start        time.Time
+	End          time.Time
+	Attributes   []Attribute
+	Error        string
+	Failed       bool
+
+	tracer *Tracer
+	lock   sync.Mutex
+	ended  bool
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.ended {
+		s.Attributes = append(s.Attributes, attributes...)
+	}
+}
+
+// SetError marks the span as failed with the error. A nil error does nothing.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.ended {
+		s.Failed = true
+		s.Error = err.Error()
+	}
+}
+
+// Finish ends the span and queues it to be exported. Only the first call has an effect.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = s.tracer.Now()
+	s.lock.Unlock()
+
+	s.tracer.enqueue(s)
+}
+
+// NewTracer returns a Tracer exporting the spans with the exporter.
+func NewTracer(exporter Exporter, log I.Logger) *Tracer {
+	return &Tracer{
+		Exporter: exporter,
+		Log:      log,
+		Interval: DefaultInterval,
+		Now:      time.Now,
+		queue:    make(chan *Span, QueueSize),
+	}
+}
+
+// Tracer queues the spans as they end, and exports them in batches every Interval, or as soon as BatchSize spans
+// are waiting.
+type Tracer struct {
+	Exporter Exporter
+	Log      I.Logger
+	Interval time.Duration
+	Now      func() time.Time
+
+	queue chan *Span
+}
+
+// Run exports the queued spans forever. Spans that cannot be exported are logged and dropped.
+func (t *Tracer) Run() {
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	for {
+		select {
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) < BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		t.export(batch)
+		batch = nil
+	}
+}
+
+func (t *Tracer) export(spans []*Span) {
+	err := t.Exporter.Export(spans)
+	if err != nil {
+		t.Log.Errorf("cannot export %d spans: %s", len(spans), err)
+	}
+}
+
+func (t *Tracer) enqueue(span *Span) {
+	select {
+	case t.queue <- span:
+	default:
+		t.Log.Errorf("the tracer is %d spans behind: dropping %s of trace %s", QueueSize, span.Name, span.TraceID)
+	}
+}
+
+type contextKey struct{}
+
+// trace is what a context knows of the trace it is part of.
+type trace struct {
+	tracer     *Tracer
+	attributes []Attribute
+	traceID    string
+	spanID     string
+}
+
+func traceOf(ctx context.Context) trace {
+	if ctx == nil {
+		return trace{}
+	}
+	t, _ := ctx.Value(contextKey{}).(trace)
+	return t
+}
+
+// WithTracer returns a context whose spans are recorded by the tracer. The attributes are set on every span started
+// with the context, such as the UUID of the deployment. A nil tracer returns ctx.
+func WithTracer(ctx context.Context, tracer *Tracer, attributes ...Attribute) context.Context {
+	if tracer == nil {
+		return ctx
+	}
+
+	t := traceOf(ctx)
+	t.tracer = tracer
+	t.attributes = append(append([]Attribute{}, t.attributes...), attributes...)
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+var traceparentPattern = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+// WithRemoteParent returns a context whose next span is a child of the span of the W3C traceparent header, such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01, so the spans of a deployment join the trace of the
+// pipeline that requested it. An invalid or empty traceparent returns ctx.
+func WithRemoteParent(ctx context.Context, traceparent string) context.Context {
+	match := traceparentPattern.FindStringSubmatch(traceparent)
+	if match == nil || match[1] == strings.Repeat("0", 32) || match[2] == strings.Repeat("0", 16) {
+		return ctx
+	}
+
+	t := traceOf(ctx)
+	t.traceID, t.spanID = match[1], match[2]
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// Start starts a span that is a child of the span of ctx, and returns it with a context to start its children with.
+// When ctx has no tracer, the span is nil and ctx is returned.
+func Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, *Span) {
+	t := traceOf(ctx)
+	if t.tracer == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		Name:         name,
+		TraceID:      t.traceID,
+		SpanID:       newID(8),
+		ParentSpanID: t.spanID,
+		Start:        t.tracer.Now(),
+		Attributes:   append(append([]Attribute{}, t.attributes...), attributes...),
+		tracer:       t.tracer,
+	}
+	if span.TraceID == "" {
+		span.TraceID = newID(16)
+	}
+
+	t.traceID, t.spanID = span.TraceID, span.SpanID
+	return context.WithValue(ctx, contextKey{}, t), span
+}
+
+func newID(size int) string {
+	id := make([]byte, size)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
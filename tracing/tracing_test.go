@@ -0,0 +1,145 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	. "github.com/compozed/deployadactyl/tracing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+// exporter sends the spans it is given to its channel, and fails with its error.
+type exporter struct {
+	batches chan []*Span
+	err     error
+}
+
+func (e *exporter) Export(spans []*Span) error {
+	e.batches <- spans
+	return e.err
+}
+
+var _ = Describe("Tracing", func() {
+	var (
+		fake      *exporter
+		logBuffer *Buffer
+		tracer    *Tracer
+		ctx       context.Context
+	)
+
+	BeforeEach(func() {
+		fake = &exporter{batches: make(chan []*Span, 10)}
+		logBuffer = NewBuffer()
+		tracer = NewTracer(fake, I.DefaultLogger(logBuffer, logging.DEBUG, "tracing_test"))
+		tracer.Interval = 10 * time.Millisecond
+		ctx = WithTracer(context.Background(), tracer, String("deployment.uuid", "the-uuid"))
+	})
+
+	It("does not trace a context without a tracer", func() {
+		background := context.Background()
+
+		spanCtx, span := Start(background, "deployer")
+
+		Expect(span).To(BeNil())
+		Expect(spanCtx).To(Equal(background))
+		span.SetAttributes(String("app", "my-app"))
+		span.SetError(errors.New("failed"))
+		span.Finish()
+	})
+
+	It("returns the context without a tracer", func() {
+		background := context.Background()
+
+		Expect(WithTracer(background, nil)).To(Equal(background))
+	})
+
+	It("starts the children of a span in its trace", func() {
+		parentCtx, parent := Start(ctx, "controller")
+		_, child := Start(parentCtx, "deployer")
+
+		Expect(parent.TraceID).To(MatchRegexp("^[0-9a-f]{32}$"))
+		Expect(parent.SpanID).To(MatchRegexp("^[0-9a-f]{16}$"))
+		Expect(parent.ParentSpanID).To(BeEmpty())
+		Expect(child.TraceID).To(Equal(parent.TraceID))
+		Expect(child.ParentSpanID).To(Equal(parent.SpanID))
+		Expect(child.SpanID).ToNot(Equal(parent.SpanID))
+	})
+
+	It("sets the attributes of the context on every span", func() {
+		_, span := Start(ctx, "deployer", String("app", "my-app"))
+		span.SetAttributes(Int("foundations", 2), Bool("canary", true))
+
+		Expect(span.Attributes).To(Equal([]Attribute{
+			String("deployment.uuid", "the-uuid"),
+			String("app", "my-app"),
+			Int("foundations", 2),
+			Bool("canary", true),
+		}))
+	})
+
+	It("joins the trace of the traceparent", func() {
+		remoteCtx := WithRemoteParent(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		_, span := Start(remoteCtx, "controller")
+
+		Expect(span.TraceID).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+		Expect(span.ParentSpanID).To(Equal("00f067aa0ba902b7"))
+	})
+
+	It("ignores an invalid traceparent", func() {
+		for _, traceparent := range []string{"", "garbage", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+			Expect(WithRemoteParent(ctx, traceparent)).To(Equal(ctx))
+		}
+	})
+
+	It("exports the ended spans", func() {
+		_, span := Start(ctx, "deployer")
+		span.SetError(errors.New("push failed"))
+		span.Finish()
+		span.Finish()
+		go tracer.Run()
+
+		var batch []*Span
+		Eventually(fake.batches).Should(Receive(&batch))
+		Expect(batch).To(ConsistOf(span))
+		Expect(span.Failed).To(BeTrue())
+		Expect(span.Error).To(Equal("push failed"))
+		Expect(span.End).ToNot(BeTemporally("<", span.Start))
+		Consistently(fake.batches, 50*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("does not change a span once it ended", func() {
+		_, span := Start(ctx, "deployer")
+		span.Finish()
+
+		span.SetAttributes(String("app", "my-app"))
+		span.SetError(errors.New("too late"))
+
+		Expect(span.Attributes).To(HaveLen(1))
+		Expect(span.Failed).To(BeFalse())
+	})
+
+	It("logs the spans that cannot be exported", func() {
+		fake.err = errors.New("collector down")
+		_, span := Start(ctx, "deployer")
+		span.Finish()
+		go tracer.Run()
+
+		Eventually(fake.batches).Should(Receive())
+		Eventually(logBuffer).Should(Say("cannot export 1 spans: collector down"))
+	})
+
+	It("drops the spans beyond the queue", func() {
+		for i := 0; i <= QueueSize; i++ {
+			_, span := Start(ctx, "deployer")
+			span.Finish()
+		}
+
+		Eventually(logBuffer).Should(Say("the tracer is 1000 spans behind: dropping deployer of trace"))
+	})
+})
@@ -0,0 +1,59 @@
+package vault
+
+import "fmt"
+
+type InvalidAddressError struct {
+	Address string
+}
+
+func (e InvalidAddressError) Error() string {
+	return fmt.Sprintf("the vault address must be an http or https url: %s", e.Address)
+}
+
+type MissingAuthError struct{}
+
+func (e MissingAuthError) Error() string {
+	return "vault needs a token, or a role_id and secret_id"
+}
+
+type InvalidTimeoutError struct {
+	Seconds int
+}
+
+func (e InvalidTimeoutError) Error() string {
+	return fmt.Sprintf("the timeout of the vault requests must not be negative: %d", e.Seconds)
+}
+
+type LoginError struct {
+	Mount string
+}
+
+func (e LoginError) Error() string {
+	return fmt.Sprintf("the vault login at auth/%s returned no token", e.Mount)
+}
+
+type RequestError struct {
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e RequestError) Error() string {
+	return fmt.Sprintf("vault answered %s with status %d: %s", e.Path, e.StatusCode, e.Message)
+}
+
+type ExpiredLeaseError struct {
+	LeaseID string
+}
+
+func (e ExpiredLeaseError) Error() string {
+	return fmt.Sprintf("the vault lease %s cannot be renewed", e.LeaseID)
+}
+
+type MissingCredentialsError struct {
+	Path string
+}
+
+func (e MissingCredentialsError) Error() string {
+	return fmt.Sprintf("the vault secret %s has no %s and %s", e.Path, UsernameKey, PasswordKey)
+}
@@ -0,0 +1,378 @@
+// Package vault reads the Cloud Foundry credentials of the environments from HashiCorp Vault at deploy time, so they
+// do not have to be written to the configuration or to environment variables.
+package vault
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/compozed/deployadactyl/cabundle"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// DefaultTimeout is how long a request to Vault may take when the configuration has no timeout.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultAuthMount is where the AppRole auth method is mounted when the configuration does not say.
+const DefaultAuthMount = "approle"
+
+// RenewInterval is how often Run checks whether the token has to be renewed.
+const RenewInterval = time.Minute
+
+// The keys of the username and password in the data of a secret.
+const (
+	UsernameKey = "username"
+	PasswordKey = "password"
+)
+
+// Validate returns an error when the address is not an http or https URL, there is neither a token nor a complete
+// AppRole, or the timeout is negative.
+func Validate(descriptor S.VaultDescriptor) error {
+	if descriptor.Address == "" {
+		return nil
+	}
+
+	address, err := url.Parse(descriptor.Address)
+	if err != nil || (address.Scheme != "http" && address.Scheme != "https") || address.Host == "" {
+		return InvalidAddressError{descriptor.Address}
+	}
+	if descriptor.RoleID == "" && descriptor.Token == "" || (descriptor.RoleID == "") != (descriptor.SecretID == "") {
+		return MissingAuthError{}
+	}
+	if descriptor.TimeoutSeconds < 0 {
+		return InvalidTimeoutError{descriptor.TimeoutSeconds}
+	}
+	return nil
+}
+
+// New returns the Client of the descriptor. It is nil without an address.
+func New(descriptor S.VaultDescriptor, fileSystem *afero.Afero, log I.Logger) (*Client, error) {
+	if descriptor.Address == "" {
+		return nil, nil
+	}
+
+	tlsConfig, err := cabundle.TLSConfig(fileSystem, descriptor.CABundle)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.InsecureSkipVerify = descriptor.SkipSSL
+
+	timeout := DefaultTimeout
+	if descriptor.TimeoutSeconds > 0 {
+		timeout = time.Duration(descriptor.TimeoutSeconds) * time.Second
+	}
+
+	client := NewClient(descriptor, log)
+	client.HTTP = &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+	}
+	return client, nil
+}
+
+// NewClient returns a Client of the Vault of the descriptor.
+func NewClient(descriptor S.VaultDescriptor, log I.Logger) *Client {
+	if descriptor.AuthMount == "" {
+		descriptor.AuthMount = DefaultAuthMount
+	}
+
+	return &Client{
+		Descriptor: descriptor,
+		Log:        log,
+		HTTP:       &http.Client{Timeout: DefaultTimeout},
+		Now:        time.Now,
+		leases:     map[string]*lease{},
+	}
+}
+
+// Client is the CredentialStore of the environments that keep their credentials in Vault.
+//
+// It logs in with the AppRole of the descriptor, or uses its token, and renews the token before it expires. When the
+// token cannot be renewed any more, it logs in again with the AppRole. Secrets with a lease, such as those of a
+// secrets engine creating Cloud Foundry users, are kept and renewed until their lease cannot be renewed, and then
+// read again. Other secrets, such as those of the KV engine, are read at every deployment, so a rotated password is
+// used as soon as it is written.
+type Client struct {
+	Descriptor S.VaultDescriptor
+	Log        I.Logger
+	HTTP       *http.Client
+	Now        func() time.Time
+
+	mu     sync.Mutex
+	token  *lease
+	leases map[string]*lease
+}
+
+// lease is a token or secret that expires, with the time it was obtained so it is renewed once two thirds of its
+// duration are gone.
+type lease struct {
+	id        string
+	renewable bool
+	obtained  time.Time
+	duration  time.Duration
+	data      map[string]interface{}
+}
+
+// expiring reports whether the lease has to be renewed. A lease without a duration never expires.
+func (l *lease) expiring(now time.Time) bool {
+	return l.duration > 0 && now.Sub(l.obtained) >= l.duration*2/3
+}
+
+// Credentials reads the username and password of the secret at the path. The path is that of the Vault API, such as
+// secret/data/cf/production for the version 2 of the KV engine mounted at secret.
+func (c *Client) Credentials(path string) (string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := c.read(strings.Trim(path, "/"))
+	if err != nil {
+		return "", "", err
+	}
+
+	// The version 2 of the KV engine nests the secret in the data of the response.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	username, _ := data[UsernameKey].(string)
+	password, _ := data[PasswordKey].(string)
+	if username == "" || password == "" {
+		return "", "", MissingCredentialsError{path}
+	}
+	return username, password, nil
+}
+
+// Run renews the token in the background, forever, so it does not expire between deployments.
+func (c *Client) Run() {
+	for range time.Tick(RenewInterval) {
+		c.mu.Lock()
+		_, err := c.authenticate()
+		c.mu.Unlock()
+		if err != nil {
+			c.Log.Errorf("cannot renew the vault token: %s", err)
+		}
+	}
+}
+
+// read returns the data of the secret at the path, renewing its lease when it has one.
+func (c *Client) read(path string) (map[string]interface{}, error) {
+	now := c.Now()
+	if cached, ok := c.leases[path]; ok {
+		if !cached.expiring(now) {
+			return cached.data, nil
+		}
+		if cached.renewable {
+			renewed, err := c.renewLease(cached)
+			if err == nil {
+				return renewed.data, nil
+			}
+			c.Log.Errorf("cannot renew the lease of vault secret %s, reading it again: %s", path, err)
+		}
+		delete(c.leases, path)
+	}
+
+	var response secretResponse
+	err := c.request("GET", path, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.LeaseID != "" {
+		c.leases[path] = &lease{
+			id:        response.LeaseID,
+			renewable: response.Renewable,
+			obtained:  now,
+			duration:  time.Duration(response.LeaseDuration) * time.Second,
+			data:      response.Data,
+		}
+	}
+	return response.Data, nil
+}
+
+func (c *Client) renewLease(cached *lease) (*lease, error) {
+	now := c.Now()
+	var response secretResponse
+	err := c.request("PUT", "sys/leases/renew", map[string]interface{}{"lease_id": cached.id}, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.LeaseDuration == 0 {
+		return nil, ExpiredLeaseError{cached.id}
+	}
+
+	cached.renewable = response.Renewable
+	cached.obtained = now
+	cached.duration = time.Duration(response.LeaseDuration) * time.Second
+	return cached, nil
+}
+
+// authenticate returns the token, after logging in when there is none yet or renewing it when it expires soon.
+// A token that cannot be renewed is replaced by logging in again with the AppRole.
+func (c *Client) authenticate() (string, error) {
+	now := c.Now()
+	if c.token == nil {
+		if c.Descriptor.RoleID != "" {
+			return c.login()
+		}
+		// The time to live of a configured token is unknown until it is looked up.
+		c.token = &lease{id: c.Descriptor.Token}
+		var response lookupResponse
+		err := c.call("GET", "auth/token/lookup-self", nil, &response, c.token.id)
+		if err != nil {
+			c.token = nil
+			return "", err
+		}
+		c.token.renewable = response.Data.Renewable
+		c.token.obtained = now
+		c.token.duration = time.Duration(response.Data.TTL) * time.Second
+	}
+
+	if !c.token.expiring(now) {
+		return c.token.id, nil
+	}
+
+	if c.token.renewable {
+		var response authResponse
+		err := c.call("POST", "auth/token/renew-self", map[string]interface{}{}, &response, c.token.id)
+		if err == nil && response.Auth.ClientToken != "" {
+			c.token = response.Auth.lease(now)
+			return c.token.id, nil
+		}
+		if err != nil {
+			c.Log.Errorf("cannot renew the vault token: %s", err)
+		}
+	}
+
+	if c.Descriptor.RoleID != "" {
+		return c.login()
+	}
+	// A configured token that cannot be renewed is used until Vault refuses it.
+	return c.token.id, nil
+}
+
+func (c *Client) login() (string, error) {
+	var response authResponse
+	body := map[string]interface{}{"role_id": c.Descriptor.RoleID, "secret_id": c.Descriptor.SecretID}
+	err := c.call("POST", "auth/"+strings.Trim(c.Descriptor.AuthMount, "/")+"/login", body, &response, "")
+	if err != nil {
+		c.token = nil
+		return "", err
+	}
+	if response.Auth.ClientToken == "" {
+		c.token = nil
+		return "", LoginError{c.Descriptor.AuthMount}
+	}
+
+	c.token = response.Auth.lease(c.Now())
+	return c.token.id, nil
+}
+
+// request sends an authenticated request to the path of the Vault API. A request refused with the token of an
+// AppRole, which may have been revoked, is sent again after logging in again.
+func (c *Client) request(method, path string, body interface{}, response interface{}) error {
+	token, err := c.authenticate()
+	if err != nil {
+		return err
+	}
+
+	err = c.call(method, path, body, response, token)
+	if refused, ok := err.(RequestError); ok && refused.StatusCode == http.StatusForbidden && c.Descriptor.RoleID != "" {
+		token, err = c.login()
+		if err != nil {
+			return err
+		}
+		return c.call(method, path, body, response, token)
+	}
+	return err
+}
+
+func (c *Client) call(method, path string, body interface{}, response interface{}, token string) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	request, err := http.NewRequest(method, strings.TrimRight(c.Descriptor.Address, "/")+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		request.Header.Set("X-Vault-Token", token)
+	}
+	if c.Descriptor.Namespace != "" {
+		request.Header.Set("X-Vault-Namespace", c.Descriptor.Namespace)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var failure errorResponse
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&failure)
+		return RequestError{path, resp.StatusCode, strings.Join(failure.Errors, "; ")}
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(response)
+	io.Copy(ioutil.Discard, resp.Body)
+	if err != nil {
+		return RequestError{path, resp.StatusCode, err.Error()}
+	}
+	return nil
+}
+
+type secretResponse struct {
+	LeaseID       string                 `json:"lease_id"`
+	Renewable     bool                   `json:"renewable"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+type authResponse struct {
+	Auth auth `json:"auth"`
+}
+
+type auth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+func (a auth) lease(now time.Time) *lease {
+	return &lease{id: a.ClientToken, renewable: a.Renewable, obtained: now, duration: time.Duration(a.LeaseDuration) * time.Second}
+}
+
+type lookupResponse struct {
+	Data struct {
+		TTL       int  `json:"ttl"`
+		Renewable bool `json:"renewable"`
+	} `json:"data"`
+}
+
+type errorResponse struct {
+	Errors []string `json:"errors"`
+}
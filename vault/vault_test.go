@@ -0,0 +1,289 @@
+package vault_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/compozed/deployadactyl/vault"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+type vaultRequest struct {
+	Method    string
+	Path      string
+	Token     string
+	Namespace string
+	Body      map[string]interface{}
+}
+
+// fakeVault answers the requests to the paths with the status and body of the path, and records them.
+type fakeVault struct {
+	mu        sync.Mutex
+	requests  []vaultRequest
+	responses map[string][]fakeResponse
+}
+
+type fakeResponse struct {
+	status int
+	body   interface{}
+}
+
+func (v *fakeVault) on(path string, status int, body interface{}) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.responses[path] = append(v.responses[path], fakeResponse{status, body})
+}
+
+func (v *fakeVault) paths() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var paths []string
+	for _, r := range v.requests {
+		paths = append(paths, r.Method+" "+r.Path)
+	}
+	return paths
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request := vaultRequest{Method: r.Method, Path: r.URL.Path, Token: r.Header.Get("X-Vault-Token"), Namespace: r.Header.Get("X-Vault-Namespace")}
+	json.NewDecoder(r.Body).Decode(&request.Body)
+
+	v.mu.Lock()
+	v.requests = append(v.requests, request)
+	responses := v.responses[r.URL.Path]
+	response := fakeResponse{http.StatusNotFound, map[string]interface{}{"errors": []string{}}}
+	if len(responses) > 0 {
+		response = responses[0]
+		// The last response of a path is repeated.
+		if len(responses) > 1 {
+			v.responses[r.URL.Path] = responses[1:]
+		}
+	}
+	v.mu.Unlock()
+
+	w.WriteHeader(response.status)
+	json.NewEncoder(w).Encode(response.body)
+}
+
+func login(token string, ttl int) map[string]interface{} {
+	return map[string]interface{}{"auth": map[string]interface{}{"client_token": token, "lease_duration": ttl, "renewable": true}}
+}
+
+func kv2(username, password string) map[string]interface{} {
+	return map[string]interface{}{"data": map[string]interface{}{
+		"data":     map[string]interface{}{"username": username, "password": password},
+		"metadata": map[string]interface{}{"version": 3},
+	}}
+}
+
+var _ = Describe("Client", func() {
+	var (
+		server     *httptest.Server
+		vault      *fakeVault
+		descriptor S.VaultDescriptor
+		logBuffer  *Buffer
+		now        time.Time
+		client     *Client
+	)
+
+	BeforeEach(func() {
+		vault = &fakeVault{responses: map[string][]fakeResponse{}}
+		server = httptest.NewServer(vault)
+		descriptor = S.VaultDescriptor{Address: server.URL, RoleID: "the-role", SecretID: "the-secret", Namespace: "team"}
+		logBuffer = NewBuffer()
+		now = time.Now()
+	})
+
+	JustBeforeEach(func() {
+		client = NewClient(descriptor, I.DefaultLogger(logBuffer, logging.DEBUG, "vault_test"))
+		client.Now = func() time.Time { return now }
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("logs in with the approle and reads the credentials of a kv version 2 secret", func() {
+		vault.on("/v1/auth/approle/login", http.StatusOK, login("the-token", 3600))
+		vault.on("/v1/secret/data/cf/production", http.StatusOK, kv2("cf-user", "cf-password"))
+
+		username, password, err := client.Credentials("/secret/data/cf/production")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(username).To(Equal("cf-user"))
+		Expect(password).To(Equal("cf-password"))
+		Expect(vault.requests[0].Body).To(Equal(map[string]interface{}{"role_id": "the-role", "secret_id": "the-secret"}))
+		Expect(vault.requests[1].Token).To(Equal("the-token"))
+		Expect(vault.requests[1].Namespace).To(Equal("team"))
+	})
+
+	It("reads the credentials of a kv version 1 secret", func() {
+		vault.on("/v1/auth/approle/login", http.StatusOK, login("the-token", 3600))
+		vault.on("/v1/kv/cf/production", http.StatusOK, map[string]interface{}{"lease_duration": 2764800, "data": map[string]interface{}{"username": "cf-user", "password": "cf-password"}})
+
+		username, password, err := client.Credentials("kv/cf/production")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(username).To(Equal("cf-user"))
+		Expect(password).To(Equal("cf-password"))
+	})
+
+	It("reads a secret without a lease at every deployment, so a rotated password is used", func() {
+		vault.on("/v1/auth/approle/login", http.StatusOK, login("the-token", 3600))
+		vault.on("/v1/secret/data/cf/production", http.StatusOK, kv2("cf-user", "old-password"))
+		vault.on("/v1/secret/data/cf/production", http.StatusOK, kv2("cf-user", "new-password"))
+
+		client.Credentials("secret/data/cf/production")
+		_, password, err := client.Credentials("secret/data/cf/production")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(password).To(Equal("new-password"))
+		Expect(vault.paths()).To(Equal([]string{"POST /v1/auth/approle/login", "GET /v1/secret/data/cf/production", "GET /v1/secret/data/cf/production"}))
+	})
+
+	It("returns an error when the secret has no username or password", func() {
+		vault.on("/v1/auth/approle/login", http.StatusOK, login("the-token", 3600))
+		vault.on("/v1/secret/data/cf/production", http.StatusOK, kv2("cf-user", ""))
+
+		_, _, err := client.Credentials("secret/data/cf/production")
+
+		Expect(err).To(MatchError(MissingCredentialsError{"secret/data/cf/production"}))
+	})
+
+	It("returns the errors of vault", func() {
+		vault.on("/v1/auth/approle/login", http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid role or secret ID"}})
+
+		_, _, err := client.Credentials("secret/data/cf/production")
+
+		Expect(err).To(MatchError(RequestError{"auth/approle/login", http.StatusBadRequest, "invalid role or secret ID"}))
+	})
+
+	It("renews the token once two thirds of its time to live are gone", func() {
+		vault.on("/v1/auth/approle/login", http.StatusOK, login("the-token", 3600))
+		vault.on("/v1/auth/token/renew-self", http.StatusOK, login("the-token", 3600))
+		vault.on("/v1/secret/data/cf/production", http.StatusOK, kv2("cf-user", "cf-password"))
+
+		client.Credentials("secret/data/cf/production")
+		now = now.Add(30 * time.Minute)
+		client.Credentials("secret/data/cf/production")
+		now = now.Add(15 * time.Minute)
+		_, _, err := client.Credentials("secret/data/cf/production")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vault.paths()).To(Equal([]string{
+			"POST /v1/auth/approle/login",
+			"GET /v1/secret/data/cf/production",
+			"GET /v1/secret/data/cf/production",
+			"POST /v1/auth/token/renew-self",
+			"GET /v1/secret/data/cf/production",
+		}))
+	})
+
+	It("logs in again when the token cannot be renewed", func() {
+		vault.on("/v1/auth/approle/login", http.StatusOK, login("the-token", 3600))
+		vault.on("/v1/auth/approle/login", http.StatusOK, login("another-token", 3600))
+		vault.on("/v1/auth/token/renew-self", http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		vault.on("/v1/secret/data/cf/production", http.StatusOK, kv2("cf-user", "cf-password"))
+
+		client.Credentials("secret/data/cf/production")
+		now = now.Add(time.Hour)
+		_, _, err := client.Credentials("secret/data/cf/production")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vault.requests[4].Token).To(Equal("another-token"))
+		Expect(logBuffer).To(Say("cannot renew the vault token"))
+	})
+
+	It("logs in again when the token was revoked", func() {
+		vault.on("/v1/auth/approle/login", http.StatusOK, login("the-token", 3600))
+		vault.on("/v1/auth/approle/login", http.StatusOK, login("another-token", 3600))
+		vault.on("/v1/secret/data/cf/production", http.StatusOK, kv2("cf-user", "cf-password"))
+		vault.on("/v1/secret/data/cf/production", http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		vault.on("/v1/secret/data/cf/production", http.StatusOK, kv2("cf-user", "cf-password"))
+
+		client.Credentials("secret/data/cf/production")
+		_, _, err := client.Credentials("secret/data/cf/production")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vault.requests[4].Token).To(Equal("another-token"))
+	})
+
+	Context("when the secret has a lease", func() {
+		BeforeEach(func() {
+			vault.on("/v1/auth/approle/login", http.StatusOK, login("the-token", 0))
+			vault.on("/v1/cf/creds/deployer", http.StatusOK, map[string]interface{}{
+				"lease_id": "cf/creds/deployer/abc", "renewable": true, "lease_duration": 600,
+				"data": map[string]interface{}{"username": "generated-user", "password": "generated-password"},
+			})
+		})
+
+		It("keeps the secret until its lease expires soon, then renews the lease", func() {
+			vault.on("/v1/sys/leases/renew", http.StatusOK, map[string]interface{}{"lease_id": "cf/creds/deployer/abc", "renewable": true, "lease_duration": 600})
+
+			client.Credentials("cf/creds/deployer")
+			now = now.Add(5 * time.Minute)
+			client.Credentials("cf/creds/deployer")
+			now = now.Add(2 * time.Minute)
+			username, _, err := client.Credentials("cf/creds/deployer")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(username).To(Equal("generated-user"))
+			Expect(vault.paths()).To(Equal([]string{"POST /v1/auth/approle/login", "GET /v1/cf/creds/deployer", "PUT /v1/sys/leases/renew"}))
+			Expect(vault.requests[2].Body).To(Equal(map[string]interface{}{"lease_id": "cf/creds/deployer/abc"}))
+		})
+
+		It("reads the secret again when its lease cannot be renewed", func() {
+			vault.on("/v1/sys/leases/renew", http.StatusBadRequest, map[string]interface{}{"errors": []string{"lease not found"}})
+
+			client.Credentials("cf/creds/deployer")
+			now = now.Add(8 * time.Minute)
+			_, _, err := client.Credentials("cf/creds/deployer")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vault.paths()).To(Equal([]string{"POST /v1/auth/approle/login", "GET /v1/cf/creds/deployer", "PUT /v1/sys/leases/renew", "GET /v1/cf/creds/deployer"}))
+			Expect(logBuffer).To(Say("cannot renew the lease of vault secret cf/creds/deployer"))
+		})
+	})
+
+	Context("when vault is given a token", func() {
+		BeforeEach(func() {
+			descriptor = S.VaultDescriptor{Address: server.URL + "/", Token: "the-token"}
+		})
+
+		It("looks the token up and uses it", func() {
+			vault.on("/v1/auth/token/lookup-self", http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"ttl": 0, "renewable": false}})
+			vault.on("/v1/secret/data/cf/production", http.StatusOK, kv2("cf-user", "cf-password"))
+
+			_, _, err := client.Credentials("secret/data/cf/production")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vault.paths()).To(Equal([]string{"GET /v1/auth/token/lookup-self", "GET /v1/secret/data/cf/production"}))
+			Expect(vault.requests[1].Token).To(Equal("the-token"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts a token or a complete approle", func() {
+			Expect(Validate(S.VaultDescriptor{})).To(Succeed())
+			Expect(Validate(S.VaultDescriptor{Address: "https://vault.example.com", Token: "the-token"})).To(Succeed())
+			Expect(Validate(S.VaultDescriptor{Address: "https://vault.example.com", RoleID: "the-role", SecretID: "the-secret"})).To(Succeed())
+		})
+
+		It("refuses an address that is not an http url", func() {
+			Expect(Validate(S.VaultDescriptor{Address: "vault.example.com", Token: "the-token"})).To(MatchError(InvalidAddressError{"vault.example.com"}))
+		})
+
+		It("refuses a missing token or an incomplete approle", func() {
+			Expect(Validate(S.VaultDescriptor{Address: "https://vault.example.com"})).To(MatchError(MissingAuthError{}))
+			Expect(Validate(S.VaultDescriptor{Address: "https://vault.example.com", RoleID: "the-role"})).To(MatchError(MissingAuthError{}))
+		})
+	})
+})
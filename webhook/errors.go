@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"fmt"
+	"time"
+)
+
+type DeliveryError struct {
+	Err error
+}
+
+func (e DeliveryError) Error() string {
+	return fmt.Sprintf("cannot create the delivery of a webhook: %s", e.Err)
+}
+
+type MissingHeaderError struct {
+	Header string
+}
+
+func (e MissingHeaderError) Error() string {
+	return fmt.Sprintf("the webhook has no %s header", e.Header)
+}
+
+type InvalidTimestampError struct {
+	Timestamp string
+}
+
+func (e InvalidTimestampError) Error() string {
+	return fmt.Sprintf("the timestamp %s of the webhook is not a number of seconds", e.Timestamp)
+}
+
+type InvalidSignatureError struct {
+	Delivery string
+}
+
+func (e InvalidSignatureError) Error() string {
+	return fmt.Sprintf("the signature of webhook delivery %s does not match its payload", e.Delivery)
+}
+
+type ExpiredSignatureError struct {
+	Delivery  string
+	Signed    time.Time
+	Tolerance time.Duration
+}
+
+func (e ExpiredSignatureError) Error() string {
+	return fmt.Sprintf("webhook delivery %s was signed at %s, more than %s from now", e.Delivery, e.Signed.UTC().Format(time.RFC3339), e.Tolerance)
+}
+
+type ReplayedDeliveryError struct {
+	Delivery string
+}
+
+func (e ReplayedDeliveryError) Error() string {
+	return fmt.Sprintf("webhook delivery %s was received before", e.Delivery)
+}
+
+type InvalidURLError struct {
+	Environment string
+	URL         string
+}
+
+func (e InvalidURLError) Error() string {
+	return fmt.Sprintf("the webhooks of environment %s must be http or https urls: %s", e.Environment, e.URL)
+}
+
+type UnknownEventError struct {
+	Environment string
+	Event       string
+}
+
+func (e UnknownEventError) Error() string {
+	return fmt.Sprintf("a webhook of environment %s listens to the unknown event %s", e.Environment, e.Event)
+}
+
+type InvalidWebhookError struct {
+	Environment    string
+	URL            string
+	TimeoutSeconds int
+	Retries        int
+}
+
+func (e InvalidWebhookError) Error() string {
+	return fmt.Sprintf("the timeout and retries of webhook %s of environment %s must not be negative: %d and %d", e.URL, e.Environment, e.TimeoutSeconds, e.Retries)
+}
+
+type RejectedError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e RejectedError) Error() string {
+	return fmt.Sprintf("the payload was rejected with status %d: %s", e.StatusCode, e.Message)
+}
+
+// Retryable reports whether the webhook may accept the payload later.
+func (e RejectedError) Retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == 429
+}
@@ -0,0 +1,256 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/compozed/deployadactyl/eventmanager"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// DefaultTimeout is how long a post may take when the webhook has no timeout.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultRetries is how many times a failed post is retried when the webhook does not say.
+const DefaultRetries = 3
+
+// QueueSize is how many payloads can wait to be posted to a webhook. Further payloads are dropped while the queue is
+// full, so a slow or unreachable endpoint never holds up a deployment.
+const QueueSize = 1000
+
+// EventHeader carries the type of the event of a payload.
+const EventHeader = "X-Deployadactyl-Event"
+
+// DefaultEvents are the types of the events posted to the webhooks that do not list any.
+var DefaultEvents = []string{
+	"DeployStartedEvent", "DeployFinishEvent", "DeploySuccessEvent", "DeployFailureEvent",
+	"StartStartedEvent", "StartFinishedEvent", "StartSuccessEvent", "StartFailureEvent",
+	"StopStartedEvent", "StopFinishedEvent", "StopSuccessEvent", "StopFailureEvent",
+	"RestartStartedEvent", "RestartFinishedEvent", "RestartSuccessEvent", "RestartFailureEvent",
+	"RestageStartedEvent", "RestageFinishedEvent", "RestageSuccessEvent", "RestageFailureEvent",
+	"DeleteStartedEvent", "DeleteFinishedEvent", "DeleteSuccessEvent", "DeleteFailureEvent",
+}
+
+// Events are the types of the events that can be posted to a webhook.
+var Events = append([]string{
+	"PushStartedEvent", "PushFinishedEvent", "RouteVerifiedEvent", "PromotionStartedEvent",
+	"PostDeployCrashDetectedEvent", "DeploymentReconciledEvent",
+	"ArtifactRetrievalStartEvent", "ArtifactRetrievalSuccessEvent", "ArtifactRetrievalFailureEvent",
+}, DefaultEvents...)
+
+// Validate returns an error when a webhook of the environment is not an http or https URL, listens to an unknown
+// event, or has a negative timeout or number of retries.
+func Validate(environment string, webhooks []S.WebhookDescriptor) error {
+	for _, descriptor := range webhooks {
+		endpoint, err := url.Parse(descriptor.URL)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return InvalidURLError{environment, descriptor.URL}
+		}
+
+		for _, event := range descriptor.Events {
+			if !contains(Events, event) {
+				return UnknownEventError{environment, event}
+			}
+		}
+
+		if descriptor.TimeoutSeconds < 0 || descriptor.Retries < 0 {
+			return InvalidWebhookError{environment, descriptor.URL, descriptor.TimeoutSeconds, descriptor.Retries}
+		}
+	}
+	return nil
+}
+
+// NewNotifier returns a Notifier posting the events of the deployments to every environment to its webhooks.
+func NewNotifier(environments map[string]S.Environment, log I.Logger) *Notifier {
+	notifier := &Notifier{
+		Log:       log,
+		Now:       time.Now,
+		Sleep:     time.Sleep,
+		endpoints: map[string][]*endpoint{},
+	}
+
+	for name, environment := range environments {
+		for _, descriptor := range environment.Webhooks {
+			timeout := DefaultTimeout
+			if descriptor.TimeoutSeconds > 0 {
+				timeout = time.Duration(descriptor.TimeoutSeconds) * time.Second
+			}
+			events := descriptor.Events
+			if len(events) == 0 {
+				events = DefaultEvents
+			}
+			retries := descriptor.Retries
+			if retries == 0 {
+				retries = DefaultRetries
+			}
+
+			notifier.endpoints[strings.ToLower(name)] = append(notifier.endpoints[strings.ToLower(name)], &endpoint{
+				name:       redact(descriptor.URL),
+				descriptor: descriptor,
+				events:     events,
+				retries:    retries,
+				client:     &http.Client{Timeout: timeout},
+				queue:      make(chan S.StreamedEvent, QueueSize),
+			})
+		}
+	}
+	return notifier
+}
+
+// Notifier is a binding that accepts every event and queues its summary, as the event stream has it, to be posted
+// to the webhooks of the environment of the event that listen to its type. Every webhook is posted its payloads one
+// after the other, in the background, so the events of a deployment stay in order.
+type Notifier struct {
+	Log   I.Logger
+	Now   func() time.Time
+	Sleep func(time.Duration)
+
+	endpoints map[string][]*endpoint
+}
+
+// endpoint is a webhook with the queue of the payloads waiting to be posted to it.
+type endpoint struct {
+	name       string
+	descriptor S.WebhookDescriptor
+	events     []string
+	retries    int
+	client     *http.Client
+	queue      chan S.StreamedEvent
+}
+
+// Empty reports whether no environment has webhooks.
+func (n *Notifier) Empty() bool {
+	return len(n.endpoints) == 0
+}
+
+func (n *Notifier) Accepts(event interface{}) bool {
+	return true
+}
+
+// Emit queues the summary of the event to the webhooks listening to it. It never fails, so a webhook cannot fail a
+// deployment.
+func (n *Notifier) Emit(event interface{}) error {
+	summary := eventmanager.Summarize(event)
+	summary.Time = n.Now()
+
+	for _, e := range n.endpoints[strings.ToLower(summary.Environment)] {
+		if !contains(e.events, summary.Type) {
+			continue
+		}
+
+		select {
+		case e.queue <- summary:
+		default:
+			n.Log.Errorf("the webhook %s is %d events behind: dropping %s of deployment %s", e.name, QueueSize, summary.Type, summary.UUID)
+		}
+	}
+	return nil
+}
+
+// Start posts the queued payloads to every webhook in the background.
+func (n *Notifier) Start() {
+	for _, endpoints := range n.endpoints {
+		for _, e := range endpoints {
+			go n.run(e)
+		}
+	}
+}
+
+func (n *Notifier) run(e *endpoint) {
+	for event := range e.queue {
+		err := n.deliver(e, event)
+		if err != nil {
+			n.Log.Errorf("cannot post %s of deployment %s to the webhook %s: %s", event.Type, event.UUID, e.name, err)
+		}
+	}
+}
+
+// deliver posts the event to the webhook, and posts it again after 1, 2, 4 seconds and so on until it is accepted
+// or has been retried as many times as the webhook allows. A payload the webhook refuses with a client error other
+// than 429 Too Many Requests is not retried. Every post carries the same signature and delivery.
+func (n *Notifier) deliver(e *endpoint, event S.StreamedEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(EventHeader, event.Type)
+	for name, value := range e.descriptor.Headers {
+		header.Set(name, value)
+	}
+	if e.descriptor.Secret != "" {
+		err = Sign(header, e.descriptor.Secret, body, n.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = post(e.client, e.descriptor.URL, header, body)
+		if err == nil {
+			return nil
+		}
+		if rejected, ok := err.(RejectedError); ok && !rejected.Retryable() {
+			return err
+		}
+		if attempt == e.retries {
+			return err
+		}
+		n.Sleep(time.Second << uint(attempt))
+	}
+}
+
+func post(client *http.Client, endpoint string, header http.Header, body []byte) error {
+	request, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header = header
+
+	response, err := client.Do(request)
+	if err != nil {
+		// The query of the URL may hold a token, so the URL is left out of the error.
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		return RejectedError{response.StatusCode, string(bytes.TrimSpace(message))}
+	}
+	io.Copy(ioutil.Discard, response.Body)
+	return nil
+}
+
+// redact returns the URL without its user and query, which may hold credentials, to be logged.
+func redact(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "(invalid url)"
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return parsed.String()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/state/push"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/compozed/deployadactyl/webhook"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/op/go-logging"
+)
+
+type delivery struct {
+	header http.Header
+	body   []byte
+}
+
+var _ = Describe("Notifier", func() {
+	var (
+		server     *httptest.Server
+		deliveries chan delivery
+		statuses   chan int
+		logBuffer  *Buffer
+		logger     I.Logger
+		sleeps     chan time.Duration
+		now        time.Time
+		descriptor S.WebhookDescriptor
+		notifier   *Notifier
+		cf         I.CFContext
+		log        I.DeploymentLogger
+	)
+
+	BeforeEach(func() {
+		deliveries = make(chan delivery, 10)
+		statuses = make(chan int, 10)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			deliveries <- delivery{r.Header, body}
+
+			status := http.StatusOK
+			select {
+			case status = <-statuses:
+			default:
+			}
+			w.WriteHeader(status)
+			w.Write([]byte("nope"))
+		}))
+
+		logBuffer = NewBuffer()
+		logger = I.DefaultLogger(logBuffer, logging.DEBUG, "webhook_test")
+		sleeps = make(chan time.Duration, 10)
+		now = time.Now()
+		descriptor = S.WebhookDescriptor{URL: server.URL + "/deployments?token=the-token"}
+
+		cf = I.CFContext{Environment: "production", Organization: "my-org", Space: "my-space", Application: "my-app"}
+		log = I.DeploymentLogger{Log: logger, UUID: "the-uuid"}
+	})
+
+	JustBeforeEach(func() {
+		notifier = NewNotifier(map[string]S.Environment{"production": {Name: "Production", Webhooks: []S.WebhookDescriptor{descriptor}}}, logger)
+		notifier.Now = func() time.Time { return now }
+		notifier.Sleep = func(d time.Duration) { sleeps <- d }
+		notifier.Start()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("is empty when no environment has webhooks", func() {
+		Expect(NewNotifier(map[string]S.Environment{"production": {Name: "Production"}}, logger).Empty()).To(BeTrue())
+	})
+
+	It("accepts every event", func() {
+		Expect(notifier.Accepts(push.PushStartedEvent{})).To(BeTrue())
+		Expect(notifier.Accepts(I.Event{Type: "deploy.start"})).To(BeTrue())
+	})
+
+	It("posts the summary of the event to the webhooks of its environment", func() {
+		Expect(notifier.Emit(push.DeploySuccessEvent{CFContext: cf, Log: log})).To(Succeed())
+
+		var posted delivery
+		Eventually(deliveries).Should(Receive(&posted))
+		Expect(posted.header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(posted.header.Get(EventHeader)).To(Equal("DeploySuccessEvent"))
+
+		var event S.StreamedEvent
+		Expect(json.Unmarshal(posted.body, &event)).To(Succeed())
+		Expect(event.Time.Equal(now)).To(BeTrue())
+		event.Time = time.Time{}
+		Expect(event).To(Equal(S.StreamedEvent{
+			Type:        "DeploySuccessEvent",
+			UUID:        "the-uuid",
+			Environment: "production",
+			Org:         "my-org",
+			Space:       "my-space",
+			AppName:     "my-app",
+		}))
+	})
+
+	It("does not post the events of other environments", func() {
+		cf.Environment = "staging"
+		Expect(notifier.Emit(push.DeploySuccessEvent{CFContext: cf, Log: log})).To(Succeed())
+
+		Consistently(deliveries, "100ms").ShouldNot(Receive())
+	})
+
+	It("only posts the default events when the webhook lists none", func() {
+		Expect(notifier.Emit(push.PushStartedEvent{CFContext: cf, Log: log})).To(Succeed())
+
+		Consistently(deliveries, "100ms").ShouldNot(Receive())
+	})
+
+	Context("when the webhook lists its events", func() {
+		BeforeEach(func() {
+			descriptor.Events = []string{"DeployFailureEvent"}
+		})
+
+		It("only posts those events", func() {
+			Expect(notifier.Emit(push.DeploySuccessEvent{CFContext: cf, Log: log})).To(Succeed())
+			Expect(notifier.Emit(push.DeployFailureEvent{CFContext: cf, Log: log, Error: errors.New("push failed")})).To(Succeed())
+
+			var posted delivery
+			Eventually(deliveries).Should(Receive(&posted))
+			Expect(posted.header.Get(EventHeader)).To(Equal("DeployFailureEvent"))
+			Expect(string(posted.body)).To(ContainSubstring(`"error":"push failed"`))
+			Consistently(deliveries, "100ms").ShouldNot(Receive())
+		})
+	})
+
+	Context("when the webhook has a secret and headers", func() {
+		BeforeEach(func() {
+			descriptor.Secret = "the-secret"
+			descriptor.Headers = map[string]string{"Authorization": "Bearer the-token"}
+		})
+
+		It("signs the payloads so they can be verified", func() {
+			Expect(notifier.Emit(push.DeploySuccessEvent{CFContext: cf, Log: log})).To(Succeed())
+
+			var posted delivery
+			Eventually(deliveries).Should(Receive(&posted))
+			Expect(posted.header.Get("Authorization")).To(Equal("Bearer the-token"))
+
+			verifier := NewVerifier("the-secret")
+			verifier.Now = func() time.Time { return now }
+			Expect(verifier.Verify(posted.header, posted.body)).To(Succeed())
+		})
+	})
+
+	Context("when the webhook fails", func() {
+		BeforeEach(func() {
+			descriptor.Secret = "the-secret"
+			descriptor.Retries = 2
+		})
+
+		It("retries with the same delivery after a growing delay", func() {
+			statuses <- http.StatusBadGateway
+			statuses <- http.StatusTooManyRequests
+
+			Expect(notifier.Emit(push.DeploySuccessEvent{CFContext: cf, Log: log})).To(Succeed())
+
+			var first, second, third delivery
+			Eventually(deliveries).Should(Receive(&first))
+			Eventually(deliveries).Should(Receive(&second))
+			Eventually(deliveries).Should(Receive(&third))
+			Expect(second.header.Get(DeliveryHeader)).To(Equal(first.header.Get(DeliveryHeader)))
+			Expect(third.body).To(Equal(first.body))
+			Expect(sleeps).To(Receive(Equal(time.Second)))
+			Expect(sleeps).To(Receive(Equal(2 * time.Second)))
+			Consistently(deliveries, "100ms").ShouldNot(Receive())
+		})
+
+		It("logs the error without the query of the url once it has retried as many times as it may", func() {
+			statuses <- http.StatusServiceUnavailable
+			statuses <- http.StatusServiceUnavailable
+			statuses <- http.StatusServiceUnavailable
+
+			Expect(notifier.Emit(push.DeploySuccessEvent{CFContext: cf, Log: log})).To(Succeed())
+
+			Eventually(logBuffer).Should(Say("cannot post DeploySuccessEvent of deployment the-uuid to the webhook %s/deployments: the payload was rejected with status 503: nope", server.URL))
+			Expect(deliveries).To(HaveLen(3))
+			Expect(string(logBuffer.Contents())).ToNot(ContainSubstring("the-token"))
+		})
+
+		It("does not retry a payload the webhook refuses", func() {
+			statuses <- http.StatusBadRequest
+
+			Expect(notifier.Emit(push.DeploySuccessEvent{CFContext: cf, Log: log})).To(Succeed())
+
+			Eventually(logBuffer).Should(Say("rejected with status 400"))
+			Expect(deliveries).To(HaveLen(1))
+			Expect(sleeps).To(BeEmpty())
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts http and https webhooks listening to known events", func() {
+			Expect(Validate("production", []S.WebhookDescriptor{
+				{URL: "https://hooks.example.com", Events: []string{"StopSuccessEvent"}},
+				{URL: "http://hooks.example.com"},
+			})).To(Succeed())
+		})
+
+		It("refuses negative timeouts and retries", func() {
+			err := Validate("production", []S.WebhookDescriptor{{URL: "https://hooks.example.com", Retries: -1}})
+
+			Expect(err).To(MatchError(InvalidWebhookError{"production", "https://hooks.example.com", 0, -1}))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+// Package webhook posts the events of the deployments to the webhook endpoints of their environment, and signs the
+// payloads so their receivers can verify they came from deployadactyl and were not replayed.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The headers of a signed payload.
+//
+// The signature is the hex HMAC-SHA256 of "<timestamp>.<delivery>.<body>" keyed by the secret of the endpoint, with
+// the prefix sha256=. The timestamp is the time the payload was signed in seconds since the Unix epoch, and the
+// delivery is unique to every payload, so a receiver can refuse a payload that is old or that it received before.
+const (
+	SignatureHeader = "X-Deployadactyl-Signature"
+	TimestampHeader = "X-Deployadactyl-Timestamp"
+	DeliveryHeader  = "X-Deployadactyl-Delivery"
+)
+
+// SignaturePrefix names the algorithm of a signature.
+const SignaturePrefix = "sha256="
+
+// DefaultTolerance is how far the timestamp of a payload may be from the time it is verified.
+const DefaultTolerance = 5 * time.Minute
+
+// Signature returns the signature of the body of the delivery signed at the timestamp with the secret.
+func Signature(secret string, timestamp int64, delivery string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "." + delivery + "."))
+	mac.Write(body)
+	return SignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign sets the headers of a request carrying the body signed with the secret at now, with a new delivery.
+func Sign(header http.Header, secret string, body []byte, now time.Time) error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return DeliveryError{err}
+	}
+	delivery := hex.EncodeToString(b)
+	timestamp := now.Unix()
+
+	header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	header.Set(DeliveryHeader, delivery)
+	header.Set(SignatureHeader, Signature(secret, timestamp, delivery, body))
+	return nil
+}
+
+// NewVerifier returns a Verifier of the payloads signed with the secret.
+func NewVerifier(secret string) *Verifier {
+	return &Verifier{Secret: secret, Tolerance: DefaultTolerance, Now: time.Now, seen: map[string]time.Time{}}
+}
+
+// Verifier verifies the payloads received by a webhook endpoint. It remembers the deliveries it verified until they
+// expire, and refuses them when they are received again.
+type Verifier struct {
+	Secret    string
+	Tolerance time.Duration
+	Now       func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// Verify returns an error when the payload is not signed with the secret, was signed further than the Tolerance
+// from now, or was verified before.
+func (v *Verifier) Verify(header http.Header, body []byte) error {
+	for _, name := range []string{SignatureHeader, TimestampHeader, DeliveryHeader} {
+		if header.Get(name) == "" {
+			return MissingHeaderError{name}
+		}
+	}
+
+	timestamp, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return InvalidTimestampError{header.Get(TimestampHeader)}
+	}
+	delivery := header.Get(DeliveryHeader)
+
+	expected := Signature(v.Secret, timestamp, delivery, body)
+	if !hmac.Equal([]byte(header.Get(SignatureHeader)), []byte(expected)) {
+		return InvalidSignatureError{delivery}
+	}
+
+	now := v.Now()
+	signed := time.Unix(timestamp, 0)
+	if signed.Before(now.Add(-v.Tolerance)) || signed.After(now.Add(v.Tolerance)) {
+		return ExpiredSignatureError{delivery, signed, v.Tolerance}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for d, at := range v.seen {
+		if at.Before(now.Add(-v.Tolerance)) {
+			delete(v.seen, d)
+		}
+	}
+	if _, ok := v.seen[delivery]; ok {
+		return ReplayedDeliveryError{delivery}
+	}
+	v.seen[delivery] = signed
+	return nil
+}
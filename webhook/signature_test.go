@@ -0,0 +1,102 @@
+package webhook_test
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/compozed/deployadactyl/webhook"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Signature", func() {
+	var (
+		now      time.Time
+		body     []byte
+		header   http.Header
+		verifier *Verifier
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+		body = []byte(`{"type":"deploy.success","appName":"app"}`)
+		header = http.Header{}
+		verifier = NewVerifier("secret")
+		verifier.Now = func() time.Time { return now }
+	})
+
+	It("signs the timestamp, delivery and body with HMAC-SHA256", func() {
+		Expect(Signature("secret", 1792152000, "abc", body)).To(Equal("sha256=0e45039862ea21a2fecd25fc8564054bb50f69c756d6c4240bca0e9ce35964f4"))
+	})
+
+	It("sets the headers of a signed payload", func() {
+		Expect(Sign(header, "secret", body, now)).To(Succeed())
+
+		Expect(header.Get(TimestampHeader)).To(Equal("1792152000"))
+		Expect(header.Get(DeliveryHeader)).To(MatchRegexp("^[0-9a-f]{32}$"))
+		Expect(header.Get(SignatureHeader)).To(Equal(Signature("secret", 1792152000, header.Get(DeliveryHeader), body)))
+	})
+
+	It("creates a new delivery for every payload", func() {
+		other := http.Header{}
+		Expect(Sign(header, "secret", body, now)).To(Succeed())
+		Expect(Sign(other, "secret", body, now)).To(Succeed())
+
+		Expect(other.Get(DeliveryHeader)).ToNot(Equal(header.Get(DeliveryHeader)))
+	})
+
+	Describe("Verifier", func() {
+		BeforeEach(func() {
+			Expect(Sign(header, "secret", body, now)).To(Succeed())
+		})
+
+		It("verifies a signed payload", func() {
+			Expect(verifier.Verify(header, body)).To(Succeed())
+		})
+
+		It("refuses a payload that was changed", func() {
+			err := verifier.Verify(header, []byte(`{"type":"deploy.success","appName":"other"}`))
+
+			Expect(err).To(MatchError(InvalidSignatureError{header.Get(DeliveryHeader)}))
+		})
+
+		It("refuses a payload signed with another secret", func() {
+			verifier.Secret = "other"
+
+			Expect(verifier.Verify(header, body)).To(BeAssignableToTypeOf(InvalidSignatureError{}))
+		})
+
+		It("refuses a payload whose timestamp was changed", func() {
+			header.Set(TimestampHeader, "1792152060")
+
+			Expect(verifier.Verify(header, body)).To(BeAssignableToTypeOf(InvalidSignatureError{}))
+		})
+
+		It("refuses a payload signed longer ago than the tolerance", func() {
+			now = now.Add(DefaultTolerance + time.Second)
+
+			err := verifier.Verify(header, body)
+
+			Expect(err).To(MatchError(ExpiredSignatureError{header.Get(DeliveryHeader), time.Unix(1792152000, 0), DefaultTolerance}))
+		})
+
+		It("refuses a delivery it verified before", func() {
+			Expect(verifier.Verify(header, body)).To(Succeed())
+			now = now.Add(time.Minute)
+
+			Expect(verifier.Verify(header, body)).To(MatchError(ReplayedDeliveryError{header.Get(DeliveryHeader)}))
+		})
+
+		It("refuses a payload without a signature", func() {
+			header.Del(SignatureHeader)
+
+			Expect(verifier.Verify(header, body)).To(MatchError(MissingHeaderError{SignatureHeader}))
+		})
+
+		It("refuses a timestamp that is not a number", func() {
+			header.Set(TimestampHeader, "yesterday")
+
+			Expect(verifier.Verify(header, body)).To(MatchError(InvalidTimestampError{"yesterday"}))
+		})
+	})
+})